@@ -3,19 +3,31 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/peaberberian/paul-envs/internal/commands"
 	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
 	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/logging"
+	"github.com/peaberberian/paul-envs/internal/profiling"
 )
 
 func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(),
 		os.Interrupt, syscall.SIGTERM)
 	defer cancel()
+	go func() {
+		// Engine commands are given some time to clean up once interrupted,
+		// a second interrupt terminates paul-envs right away
+		<-ctx.Done()
+		cancel()
+	}()
 
 	// Console: Handle input/output messages
 	console := console.New(ctx, os.Stdin, os.Stdout, os.Stderr)
@@ -27,28 +39,132 @@ func main() {
 		os.Exit(1)
 	}
 
-	if len(os.Args) < 2 {
+	cliArgs, profile, tracePath := extractProfileFlag(os.Args[1:])
+	cliArgs, offline := extractGlobalFlag(cliArgs, "--offline")
+	engine.SetOffline(offline)
+	cliArgs, dryRun := extractGlobalFlag(cliArgs, "--dry-run")
+	engine.SetDryRun(dryRun)
+	files.SetDryRun(dryRun)
+	cliArgs, ci := extractGlobalFlag(cliArgs, "--ci")
+	nonInteractive := ci || os.Getenv("PAULENV_NONINTERACTIVE") == "1"
+	console.SetNonInteractive(nonInteractive)
+	engine.SetNonInteractive(nonInteractive)
+	cliArgs, refreshEngine := extractGlobalFlag(cliArgs, "--refresh-engine")
+	cliArgs, wait := extractGlobalFlag(cliArgs, "--wait")
+	commands.SetWaitForProjects(wait)
+	cliArgs, verbosity := extractVerbosityFlags(cliArgs)
+	console.SetQuiet(verbosity == logging.VerbosityQuiet)
+	cliArgs, output, err := extractOutputFlag(cliArgs)
+	console.SetOutput(output)
+	if err != nil {
+		console.Error("Error: %v", err)
+		os.Exit(commands.ExitUsage)
+	}
+	if len(cliArgs) < 1 {
 		commands.Help(filestore, console)
 		os.Exit(0)
 	}
+	if cliArgs[0] == "prompt" {
+		// Run at each shell prompt: neither logged nor recorded in the
+		// history, which would also slow it down
+		if err := commands.Prompt(ctx, cliArgs[1:], filestore, console); err != nil {
+			console.Error("Error: %v", err)
+			os.Exit(commands.ExitCode(err))
+		}
+		return
+	}
+	logFile, logErr := filestore.OpenDebugLog()
+	if logErr != nil {
+		logging.Setup(verbosity, os.Stderr, nil)
+		logging.Log().Debug("cannot write the log file", "error", logErr)
+	} else {
+		defer logFile.Close()
+		logging.Setup(verbosity, os.Stderr, logFile)
+	}
+
+	globalConfig, err := filestore.LoadGlobalConfig()
+	if err != nil {
+		console.Warn("Ignoring the global configuration: %v", err)
+	}
+	engine.SetPreferredEngine(engine.Selection(globalConfig.Engine))
+	engine.SetContainerNameTemplate(globalConfig.ContainerNameTemplate())
+	engine.SetRegistrySettings(engine.RegistrySettings{
+		Mirrors:   globalConfig.RegistryMirrors,
+		Insecure:  globalConfig.InsecureRegistries,
+		AuthFiles: globalConfig.RegistryAuthFiles,
+	}, filestore)
+	engine.SetDetectionCache(filestore, refreshEngine)
+	filestore.SetGlobalDotfilesPath(globalConfig.Dotfiles)
 
-	cmd := os.Args[1]
-	args := os.Args[2:]
+	cmd := cliArgs[0]
+	args := cliArgs[1:]
 
+	if profile {
+		profiling.Enable()
+	}
+	start := time.Now()
+	logging.Log().Debug("command started", "args", strings.Join(cliArgs, " "), "pid", os.Getpid())
+	endCommand := profiling.Track(profiling.CategoryCommand, cmd)
+	stopRecordingBuilds := commands.RecordBuildTimings(ctx, filestore, console)
 	cmdErr := runCommand(ctx, cmd, args, filestore, console)
+	stopRecordingBuilds()
+	endCommand()
+	if cmdErr != nil {
+		logging.Log().Debug("command failed", "duration", time.Since(start).Round(time.Millisecond), "error", cmdErr)
+	} else {
+		logging.Log().Debug("command done", "duration", time.Since(start).Round(time.Millisecond))
+	}
+	if profile {
+		reportProfile(console, cmd, time.Since(start), tracePath)
+	}
 	if errors.Is(cmdErr, errUnknownCommand) {
 		console.Error("Error: unknown command: %s", cmd)
 		console.Error("Run with --help to have a list of authorized commands")
-		os.Exit(1)
+		recordHistory(filestore, cmd, start, commands.ExitUsage, cmdErr)
+		os.Exit(commands.ExitUsage)
 	}
 
+	if cmdErr != nil && ctx.Err() != nil && !errors.Is(cmdErr, context.Canceled) {
+		// e.g. an engine command exiting on its own once interrupted
+		cmdErr = fmt.Errorf("%w: %w", ctx.Err(), cmdErr)
+	}
 	if cmdErr != nil {
 		if errors.Is(cmdErr, context.Canceled) {
 			console.Error("\nOperation cancelled")
-			os.Exit(130)
+		} else {
+			console.Error("Error: %v", cmdErr)
 		}
-		console.Error("Error: %v", cmdErr)
-		os.Exit(1)
+		recordHistory(filestore, cmd, start, commands.ExitCode(cmdErr), cmdErr)
+		os.Exit(commands.ExitCode(cmdErr))
+	}
+	recordHistory(filestore, cmd, start, 0, nil)
+}
+
+// Append that invocation, and the engine calls it made, to the history shown
+// by `paul-envs history`.
+func recordHistory(filestore *files.FileStore, cmd string, start time.Time, exitCode int, cmdErr error) {
+	if cmd == "history" {
+		return
+	}
+	entry := files.HistoryEntry{
+		Time:     start,
+		Args:     os.Args[1:],
+		Duration: time.Since(start),
+		ExitCode: exitCode,
+	}
+	entry.Dir, _ = os.Getwd()
+	if cmdErr != nil {
+		entry.Error = cmdErr.Error()
+	}
+	for _, call := range engine.ExecutedCalls() {
+		entry.EngineCalls = append(entry.EngineCalls, files.HistoryEngineCall{
+			Command:  call.Command,
+			Duration: call.Duration,
+			ExitCode: call.ExitCode,
+		})
+	}
+	if err := filestore.AppendHistory(entry); err != nil {
+		logging.Log().Debug("cannot write the history", "error", err)
 	}
 }
 
@@ -72,6 +188,32 @@ func runCommand(
 		return commands.Run(ctx, args, filestore, console)
 	case "remove", "rm", "r", "--remove", "-r":
 		return commands.Remove(ctx, args, filestore, console)
+	case "doctor":
+		return commands.Doctor(ctx, args, filestore, console)
+	case "support-bundle":
+		return commands.SupportBundle(ctx, args, filestore, console)
+	case "stats":
+		return commands.Stats(ctx, args, filestore, console)
+	case "config":
+		return commands.Config(ctx, args, filestore, console)
+	case "enable-emulation":
+		return commands.EnableEmulation(ctx, args, filestore, console)
+	case "exec":
+		return commands.Exec(ctx, args, filestore, console)
+	case "cp":
+		return commands.Cp(ctx, args, filestore, console)
+	case "try":
+		return commands.Try(ctx, args, filestore, console)
+	case "stop":
+		return commands.Stop(ctx, args, filestore, console)
+	case "tmp":
+		return commands.Tmp(ctx, args, filestore, console)
+	case "shortcut":
+		return commands.Shortcut(ctx, args, filestore, console)
+	case "services":
+		return commands.Services(ctx, args, filestore, console)
+	case "fix-perms":
+		return commands.FixPerms(ctx, args, filestore, console)
 	case "version", "v", "--version", "-v":
 		return commands.Version(ctx, args, console)
 	case "completion":
@@ -80,6 +222,92 @@ func runCommand(
 		return commands.Clean(ctx, args, filestore, console)
 	case "interactive", "i", "--interactive", "-i":
 		return commands.Interactive(ctx, args, filestore, console)
+	case "rebuild":
+		return commands.Rebuild(ctx, args, filestore, console)
+	case "sshd":
+		return commands.SSHD(ctx, args, filestore, console)
+	case "push":
+		return commands.Push(ctx, args, filestore, console)
+	case "pull":
+		return commands.Pull(ctx, args, filestore, console)
+	case "import":
+		return commands.Import(ctx, args, filestore, console)
+	case "update":
+		return commands.Update(ctx, args, filestore, console)
+	case "backup":
+		return commands.Backup(ctx, args, filestore, console)
+	case "restore":
+		return commands.Restore(ctx, args, filestore, console)
+	case "outdated":
+		return commands.Outdated(ctx, args, filestore, console)
+	case "reconcile":
+		return commands.Reconcile(ctx, args, filestore, console)
+	case "clone":
+		return commands.Clone(ctx, args, filestore, console)
+	case "rename":
+		return commands.Rename(ctx, args, filestore, console)
+	case "info":
+		return commands.Info(ctx, args, filestore, console)
+	case "inspect":
+		return commands.Inspect(ctx, args, filestore, console)
+	case "status", "st":
+		return commands.Status(ctx, args, filestore, console)
+	case "trust":
+		return commands.Trust(ctx, args, filestore, console)
+	case "tui":
+		return commands.Tui(ctx, args, filestore, console)
+	case "gc":
+		return commands.GC(ctx, args, filestore, console)
+	case "export":
+		return commands.Export(ctx, args, filestore, console)
+	case "ssh-config":
+		return commands.SSHConfig(ctx, args, filestore, console)
+	case "code":
+		return commands.Code(ctx, args, filestore, console)
+	case "watch":
+		return commands.Watch(ctx, args, filestore, console)
+	case "snapshot":
+		return commands.Snapshot(ctx, args, filestore, console)
+	case "rollback":
+		return commands.Rollback(ctx, args, filestore, console)
+	case "history":
+		return commands.History(args, filestore, console)
+	case "times":
+		return commands.Times(args, filestore, console)
+	case "cache-stats":
+		return commands.CacheStats(ctx, args, filestore, console)
+	case "reap":
+		return commands.Reap(ctx, args, filestore, console)
+	case "daemon":
+		return commands.Daemon(ctx, args, filestore, console)
+	case "du":
+		return commands.Du(ctx, args, filestore, console)
+	case "image":
+		return commands.Image(ctx, args, filestore, console)
+	case "volume":
+		return commands.Volume(ctx, args, filestore, console)
+	case "diff":
+		return commands.Diff(ctx, args, filestore, console)
+	case "events":
+		return commands.Events(ctx, args, filestore, console)
+	case "migrate":
+		return commands.Migrate(ctx, args, filestore, console)
+	case "freeze":
+		return commands.Freeze(ctx, args, filestore, console)
+	case "thaw":
+		return commands.Thaw(ctx, args, filestore, console)
+	case "group":
+		return commands.Group(ctx, args, filestore, console)
+	case "resources":
+		return commands.Resources(ctx, args, filestore, console)
+	case "api":
+		return commands.API(ctx, args, filestore, console)
+	case "shellenv":
+		return commands.Shellenv(ctx, args, filestore, console)
+	case "prompt":
+		return commands.Prompt(ctx, args, filestore, console)
+	case "task":
+		return commands.Task(ctx, args, filestore, console)
 	case "help", "h", "--help", "-h":
 		if len(args) == 0 || isHelpCommand(args[0]) {
 			commands.Help(filestore, console)
@@ -91,6 +319,194 @@ func runCommand(
 	}
 }
 
+// Remove the global `--profile-cli[=<trace-file>]` flag from the given
+// arguments, wherever it is, and returns whether it was present and the
+// trace file path, if any.
+func extractProfileFlag(args []string) ([]string, bool, string) {
+	rest := make([]string, 0, len(args))
+	profile := false
+	tracePath := ""
+	for i, arg := range args {
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		if arg == "--profile-cli" {
+			profile = true
+			continue
+		}
+		if value, ok := strings.CutPrefix(arg, "--profile-cli="); ok {
+			profile = true
+			tracePath = value
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, profile, tracePath
+}
+
+// Remove the `--output=<mode>` global flag from the given arguments and
+// returns the output mode it chooses, else the one of the PAULENV_OUTPUT
+// environment variable, else `console.OutputAuto`.
+//
+// If the chosen mode is invalid, `console.OutputAuto` is returned with the
+// error, to display it.
+func extractOutputFlag(args []string) ([]string, console.Output, error) {
+	args, value := extractGlobalValueFlag(args, "--output")
+	if value == "" {
+		value = os.Getenv("PAULENV_OUTPUT")
+	}
+	if value == "" {
+		return args, console.OutputAuto, nil
+	}
+	output, err := console.ParseOutput(value)
+	if err != nil {
+		return args, console.OutputAuto, err
+	}
+	return args, output, nil
+}
+
+// Remove the given global flag taking a value (e.g. `--output=plain`) from
+// the given arguments, and returns its last value, empty if it was not
+// present.
+//
+// Like `extractGlobalFlag`, it is only looked for before the command's first
+// argument not starting with a dash, and left to commands defining their
+// own.
+func extractGlobalValueFlag(args []string, flag string) ([]string, string) {
+	end := globalFlagsEnd(args, flag)
+	rest := make([]string, 0, len(args))
+	value := ""
+	for _, arg := range args[:end] {
+		if v, ok := strings.CutPrefix(arg, flag+"="); ok {
+			value = v
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return append(rest, args[end:]...), value
+}
+
+// Global flags which some commands also define with another meaning (e.g.
+// `gc --dry-run` only listing what it would remove). For those commands, the
+// global flag has to be put before the command, e.g. `paul-envs --dry-run gc`.
+var commandLocalFlags = map[string][]string{
+	"gc":             {"--dry-run"},
+	"reap":           {"--dry-run"},
+	"support-bundle": {"--output"},
+}
+
+// Returns `true` if `arg`, met in place of the command, is a command defining
+// its own `flag`: the remaining arguments are then left to it.
+func isCommandOwningFlag(arg string, flag string) bool {
+	for _, f := range commandLocalFlags[arg] {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove the given global boolean flag (e.g. `--offline`) from the given
+// arguments, before the command's first argument not starting with a dash,
+// and returns whether it was present.
+//
+// It is not looked for after that argument, which may be followed by a
+// command to run in a container (e.g. in `run app npm publish --dry-run`),
+// nor after a command defining its own flag of that name (see
+// `commandLocalFlags`).
+func extractGlobalFlag(args []string, flag string) ([]string, bool) {
+	end := globalFlagsEnd(args, flag)
+	rest := make([]string, 0, len(args))
+	found := false
+	for _, arg := range args[:end] {
+		if arg == flag {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return append(rest, args[end:]...), found
+}
+
+// Returns the index in the given arguments from which the given global flag
+// is not looked for anymore: `--`, the first argument following the command
+// not starting with a dash, or the argument following a command defining its
+// own flag of that name.
+func globalFlagsEnd(args []string, flag string) int {
+	seenCommand := false
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return i
+		case strings.HasPrefix(arg, "-"):
+		case seenCommand:
+			return i
+		case isCommandOwningFlag(arg, flag):
+			return i + 1
+		default:
+			seenCommand = true
+		}
+	}
+	return len(args)
+}
+
+// Remove the global verbosity flags (`-v`, `-vv`, `--verbose`, `-q` and
+// `--quiet`) following the command, and returns the verbosity they set.
+//
+// They are not looked for in place of the command, where `-v` means
+// `version`, nor after its first argument not starting with a dash, which
+// may be followed by a command to run in a container (e.g. in
+// `run app grep -v foo`).
+func extractVerbosityFlags(args []string) ([]string, logging.Verbosity) {
+	verbosity := logging.VerbosityNormal
+	if len(args) == 0 {
+		return args, verbosity
+	}
+	rest := []string{args[0]}
+	for i, arg := range args[1:] {
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			return append(rest, args[i+1:]...), verbosity
+		}
+		switch arg {
+		case "-v", "--verbose":
+			verbosity = max(verbosity, logging.VerbosityVerbose)
+		case "-vv":
+			verbosity = logging.VerbosityTrace
+		case "-q", "--quiet":
+			verbosity = logging.VerbosityQuiet
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return rest, verbosity
+}
+
+// Write the profile of the last command: a summary on the error output and,
+// if a trace file path is given, the full trace in it.
+func reportProfile(console *console.Console, cmd string, total time.Duration, tracePath string) {
+	spans := profiling.Spans()
+	w := console.ErrorWriter()
+	fmt.Fprintf(w, "\nProfile of '%s':\n", cmd)
+	if err := profiling.WriteSummary(w, spans, total, 10); err != nil {
+		console.Error("Could not write profile summary: %v", err)
+	}
+	if tracePath == "" {
+		return
+	}
+	file, err := os.Create(tracePath)
+	if err != nil {
+		console.Error("Could not write trace file: %v", err)
+		return
+	}
+	defer file.Close()
+	if err := profiling.WriteTrace(file, spans); err != nil {
+		console.Error("Could not write trace file: %v", err)
+		return
+	}
+	fmt.Fprintf(w, "Trace written to %s\n", tracePath)
+}
+
 func isHelpCommand(arg string) bool {
 	switch arg {
 	case "help", "h", "--help", "-h":
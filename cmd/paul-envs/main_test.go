@@ -0,0 +1,60 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExtractGlobalFlag(t *testing.T) {
+	tests := []struct {
+		args  string
+		flag  string
+		rest  string
+		found bool
+	}{
+		{args: "--dry-run build app", flag: "--dry-run", rest: "build app", found: true},
+		{args: "build --dry-run app", flag: "--dry-run", rest: "build app", found: true},
+		{args: "build app --dry-run", flag: "--dry-run", rest: "build app --dry-run"},
+		{args: "run app npm publish --dry-run", flag: "--dry-run", rest: "run app npm publish --dry-run"},
+		{args: "run app -- ls --dry-run", flag: "--dry-run", rest: "run app -- ls --dry-run"},
+		{args: "run --ci app make test", flag: "--ci", rest: "run app make test", found: true},
+		{args: "status --wait", flag: "--wait", rest: "status", found: true},
+		{args: "build --offline app", flag: "--offline", rest: "build app", found: true},
+		{args: "run app go build --offline", flag: "--offline", rest: "run app go build --offline"},
+		{args: "--offline run app yarn install --offline", flag: "--offline", rest: "run app yarn install --offline", found: true},
+		// Commands defining their own flag of that name keep it
+		{args: "--dry-run gc", flag: "--dry-run", rest: "gc", found: true},
+		{args: "gc --dry-run", flag: "--dry-run", rest: "gc --dry-run"},
+		{args: "run gc --dry-run", flag: "--dry-run", rest: "run gc --dry-run"},
+	}
+
+	for _, tt := range tests {
+		rest, found := extractGlobalFlag(strings.Fields(tt.args), tt.flag)
+		if found != tt.found || !slices.Equal(rest, strings.Fields(tt.rest)) {
+			t.Errorf("extractGlobalFlag(%q, %q) = %q, %v, want %q, %v", tt.args, tt.flag, rest, found, tt.rest, tt.found)
+		}
+	}
+}
+
+func TestExtractGlobalValueFlag(t *testing.T) {
+	tests := []struct {
+		args  string
+		flag  string
+		rest  string
+		value string
+	}{
+		{args: "--output=plain build app", flag: "--output", rest: "build app", value: "plain"},
+		{args: "build --output=quiet app", flag: "--output", rest: "build app", value: "quiet"},
+		{args: "build --output=plain --output=fancy", flag: "--output", rest: "build", value: "fancy"},
+		{args: "exec app go build --output=json", flag: "--output", rest: "exec app go build --output=json"},
+		{args: "--output=plain support-bundle --output=bundle.zip", flag: "--output", rest: "support-bundle --output=bundle.zip", value: "plain"},
+	}
+
+	for _, tt := range tests {
+		rest, value := extractGlobalValueFlag(strings.Fields(tt.args), tt.flag)
+		if value != tt.value || !slices.Equal(rest, strings.Fields(tt.rest)) {
+			t.Errorf("extractGlobalValueFlag(%q, %q) = %q, %q, want %q, %q", tt.args, tt.flag, rest, value, tt.rest, tt.value)
+		}
+	}
+}
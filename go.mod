@@ -2,6 +2,7 @@ module github.com/peaberberian/paul-envs
 
 go 1.25.4
 
-require golang.org/x/term v0.42.0
-
-require golang.org/x/sys v0.43.0 // indirect
+require (
+	golang.org/x/sys v0.43.0
+	golang.org/x/term v0.42.0
+)
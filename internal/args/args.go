@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -59,6 +60,21 @@ func ParseAndPrompt(args []string, cons *console.Console, filestor *files.FileSt
 		return config.Config{}, err
 	}
 
+	// Only ask for the shell if neither given nor set in the global
+	// configuration, whose errors have already been reported
+	askShell := parsed.shell == ""
+	if globalConfig, err := filestor.LoadGlobalConfig(); askShell && err == nil && globalConfig.Shell != "" {
+		cfg.Shell = globalConfig.Shell
+		askShell = false
+	}
+
+	if parsed.dotfilesProfile != "" {
+		if err := validateDotfilesProfile(parsed.dotfilesProfile, filestor); err != nil {
+			return config.Config{}, err
+		}
+		cfg.DotfilesProfile = parsed.dotfilesProfile
+	}
+
 	if parsed.seedDotfiles {
 		hasTemplate, err := filestor.HasGlobalDotfilesTemplate()
 		if err != nil {
@@ -72,10 +88,15 @@ func ParseAndPrompt(args []string, cons *console.Console, filestor *files.FileSt
 
 	// Prompt for missing values if interactive
 	if !noPrompt {
-		if err := promptMissing(cons, &cfg); err != nil {
+		if err := promptMissing(cons, &cfg, askShell); err != nil {
 			return config.Config{}, err
 		}
-		if !cfg.SeedDotfiles {
+		if cfg.DotfilesProfile == "" && !cfg.SeedDotfiles {
+			if err := promptDotfilesProfile(cons, filestor, &cfg); err != nil {
+				return config.Config{}, err
+			}
+		}
+		if cfg.DotfilesProfile == "" && !cfg.SeedDotfiles {
 			if err := promptDotfilesSeed(cons, filestor, &cfg); err != nil {
 				return config.Config{}, err
 			}
@@ -84,6 +105,13 @@ func ParseAndPrompt(args []string, cons *console.Console, filestor *files.FileSt
 		return config.Config{}, err
 	}
 
+	if len(cfg.CargoPackages) > 0 && (cfg.InstallRust == "" || cfg.InstallRust == config.VersionNone) {
+		return config.Config{}, errors.New("--cargo-package needs Rust to be installed (--rust)")
+	}
+	if len(cfg.NpmPackages) > 0 && (cfg.InstallNode == "" || cfg.InstallNode == config.VersionNone) {
+		return config.Config{}, errors.New("--npm-package needs Node.js to be installed (--nodejs)")
+	}
+
 	// Final validation for mise requirement
 	if !cfg.InstallMise && !noPrompt {
 		checkMiseRequirement(cons, &cfg)
@@ -96,6 +124,7 @@ func ParseAndPrompt(args []string, cons *console.Console, filestor *files.FileSt
 type parsedFlags struct {
 	noPrompt          bool
 	seedDotfiles      bool
+	dotfilesProfile   string
 	name              string
 	uid               string
 	gid               string
@@ -106,11 +135,15 @@ type parsedFlags struct {
 	pythonVersion     string
 	goVersion         string
 	enableWasm        bool
+	compilerCache     bool
+	nestedContainers  bool
+	systemd           bool
 	enableSsh         bool
 	enableSudo        bool
 	gitName           string
 	gitEmail          string
 	noMise            bool
+	noCompletions     bool
 	installNeovim     bool
 	installStarship   bool
 	installOhMyPosh   bool
@@ -123,6 +156,9 @@ type parsedFlags struct {
 	installCodex      bool
 	installFirefox    bool
 	packages          []string
+	pipxPackages      []string
+	cargoPackages     []string
+	npmPackages       []string
 	ports             []string
 	volumes           []string
 }
@@ -152,16 +188,20 @@ func newCreateFlagSet(p *parsedFlags, noPrompt *bool, cons *console.Console) *fl
 
 	flagset.BoolVar(noPrompt, "no-prompt", false, "Non-interactive mode")
 	flagset.BoolVar(&p.seedDotfiles, "seed-dotfiles", false, "Seed the project dotfiles directory from the global template")
+	flagset.StringVar(&p.dotfilesProfile, "dotfiles-profile", "", "Use the given shared dotfiles profile instead of the project's own dotfiles directory")
 	flagset.StringVar(&p.name, "name", "", "How to name that project. If not specified, the project's directory name will be used instead.")
 	flagset.StringVar(&p.uid, "uid", "", "UID to rely on in the container. Your current one will be used as a safe default if unspecified, or \"1000\" on Windows.")
 	flagset.StringVar(&p.gid, "gid", "", "GID to rely on in the container. Your current one will be used as a safe default if unspecified, or \"1000\" on Windows.")
 	flagset.StringVar(&p.username, "username", "", "Username that will be used in the container. \"dev\" when not specified.")
-	flagset.StringVar(&p.shell, "shell", "", "Default shell when running the container. Can be \"bash\", \"zsh\" or \"fish\". \"bash\" by default.")
-	flagset.StringVar(&p.nodeVersion, "nodejs", "", "Add Node.js and npm to the container.\n\nAccepted values: \"none\" (not installed), \"latest\", or an explicit X.Y.Z version.\n\nDefault: \"none\".")
-	flagset.StringVar(&p.rustVersion, "rust", "", "Add Rust and cargo to the container.\n\nAccepted values: \"none\" (not installed), \"latest\", or an explicit X.Y.Z version.\n\nDefault: \"none\".")
-	flagset.StringVar(&p.pythonVersion, "python", "", "Add Python to the container.\n\nAccepted values: \"none\" (not installed), \"latest\", or an explicit X.Y.Z version.\n\nDefault: \"none\".")
-	flagset.StringVar(&p.goVersion, "go", "", "Add Go to the container.\n\nAccepted values: \"none\" (not installed), \"latest\", or an explicit X.Y.Z version.\n\nDefault: \"none\".")
+	flagset.StringVar(&p.shell, "shell", "", "Default shell when running the container. Can be \"bash\", \"zsh\", \"fish\" or \"nushell\". Defaults to the SHELL global setting (see 'paul-envs config'), else \"bash\".")
+	flagset.StringVar(&p.nodeVersion, "nodejs", "", "Add Node.js and npm to the container.\n\nAccepted values: \"none\" (not installed), \"latest\", \"lts\", or a version, complete (X.Y.Z) or not (e.g. X or X.Y) to get the latest matching one.\n\nDefault: \"none\".")
+	flagset.StringVar(&p.rustVersion, "rust", "", "Add Rust and cargo to the container.\n\nAccepted values: \"none\" (not installed), \"latest\", a release channel (\"stable\", \"beta\", \"nightly\"), or a version, complete (X.Y.Z) or not (e.g. X or X.Y) to get the latest matching one.\n\nDefault: \"none\".")
+	flagset.StringVar(&p.pythonVersion, "python", "", "Add Python to the container.\n\nAccepted values: \"none\" (not installed), \"latest\", or a version, complete (X.Y.Z) or not (e.g. X or X.Y) to get the latest matching one.\n\nDefault: \"none\".")
+	flagset.StringVar(&p.goVersion, "go", "", "Add Go to the container.\n\nAccepted values: \"none\" (not installed), \"latest\", or a version, complete (X.Y.Z) or not (e.g. X or X.Y) to get the latest matching one.\n\nDefault: \"none\".")
 	flagset.BoolVar(&p.enableWasm, "enable-wasm", false, "Add WASM tools in the container: binaryen, Rust WASM target if Rust is enabled.")
+	flagset.BoolVar(&p.compilerCache, "compiler-cache", false, "Cache C/C++ compilations through ccache and Rust ones through sccache, in a volume shared by all projects.")
+	flagset.BoolVar(&p.nestedContainers, "nested-containers", false, "Install rootless Podman in the container, so containers can be built and run from it.")
+	flagset.BoolVar(&p.systemd, "systemd", false, "Install systemd in the container and run it as its first process, to develop services relying on systemd units.")
 	flagset.BoolVar(&p.enableSsh, "enable-ssh", false, "Enable SSH access to the container, to e.g. allow your host to easily access its files.")
 	flagset.BoolVar(&p.enableSudo, "enable-sudo", false, "Install sudo in the container with root password:\"dev\"")
 	flagset.StringVar(&p.gitName, "git-name", "", "The git user name to use in the container. Not overwritten by default")
@@ -170,6 +210,7 @@ func newCreateFlagSet(p *parsedFlags, noPrompt *bool, cons *console.Console) *fl
 	flagset.BoolVar(&p.installStarship, "starship", false, "Add Starship (prompt) to the container")
 	flagset.BoolVar(&p.installOhMyPosh, "oh-my-posh", false, "Add Oh My Posh (prompt) to the container")
 	flagset.BoolVar(&p.noMise, "no-mise", false, "Prevent installation of \"mise\", which is used to install languages in specific versions")
+	flagset.BoolVar(&p.noCompletions, "no-completions", false, "Do not set up shell completions for the tools installed in the container")
 	flagset.BoolVar(&p.installAtuin, "atuin", false, "Add Atuin (shell history tool) to the container")
 	flagset.BoolVar(&p.installZellij, "zellij", false, "Add Zellij (terminal multiplexer) to the container")
 	flagset.BoolVar(&p.installJujutsu, "jujutsu", false, "Add Jujutsu (VCS) to the container")
@@ -179,6 +220,9 @@ func newCreateFlagSet(p *parsedFlags, noPrompt *bool, cons *console.Console) *fl
 	flagset.BoolVar(&p.installCodex, "codex", false, "Add Codex (LLM agentic tool) to the container")
 	flagset.BoolVar(&p.installFirefox, "firefox", false, "Add Mozilla Firefox (Web browser) to the container")
 	flagset.Var((*stringSliceFlag)(&p.packages), "package", "Additional Ubuntu package to install in the container. This option can be repeated for multiple packages")
+	flagset.Var((*stringSliceFlag)(&p.pipxPackages), "pipx-package", "Python application to install in the container through pipx. This option can be repeated for multiple packages")
+	flagset.Var((*stringSliceFlag)(&p.cargoPackages), "cargo-package", "Crate to install in the container through \"cargo install\", which needs --rust. This option can be repeated for multiple packages")
+	flagset.Var((*stringSliceFlag)(&p.npmPackages), "npm-package", "npm package to install globally in the container, which needs --nodejs. This option can be repeated for multiple packages")
 	flagset.Var((*stringSliceFlag)(&p.ports), "port", "Expose container port. This option can be repeated for exposing multiple ports")
 	flagset.Var((*stringSliceFlag)(&p.volumes), "volume", "Mount volume in the container, as HOST:CONT[:ro]. This option can be repeated for mounting multiple volumes")
 
@@ -237,31 +281,34 @@ func buildConfig(projectPath string, p *parsedFlags) (config.Config, error) {
 
 	// Language versions
 	if p.nodeVersion != "" {
-		if err := utils.ValidateVersionArg(p.nodeVersion); err != nil {
+		if err := utils.ValidateToolchainVersion("node", p.nodeVersion); err != nil {
 			return config.Config{}, fmt.Errorf("invalid node version '%s': %w", p.nodeVersion, err)
 		}
 		cfg.InstallNode = p.nodeVersion
 	}
 	if p.rustVersion != "" {
-		if err := utils.ValidateVersionArg(p.rustVersion); err != nil {
+		if err := utils.ValidateToolchainVersion("rust", p.rustVersion); err != nil {
 			return config.Config{}, fmt.Errorf("invalid rust version '%s': %w", p.rustVersion, err)
 		}
 		cfg.InstallRust = p.rustVersion
 	}
 	if p.pythonVersion != "" {
-		if err := utils.ValidateVersionArg(p.pythonVersion); err != nil {
+		if err := utils.ValidateToolchainVersion("python", p.pythonVersion); err != nil {
 			return config.Config{}, fmt.Errorf("invalid python version '%s': %w", p.pythonVersion, err)
 		}
 		cfg.InstallPython = p.pythonVersion
 	}
 	if p.goVersion != "" {
-		if err := utils.ValidateVersionArg(p.goVersion); err != nil {
+		if err := utils.ValidateToolchainVersion("go", p.goVersion); err != nil {
 			return config.Config{}, fmt.Errorf("invalid go version '%s': %w", p.goVersion, err)
 		}
 		cfg.InstallGo = p.goVersion
 	}
 
 	cfg.EnableWasm = p.enableWasm
+	cfg.EnableCompilerCache = p.compilerCache
+	cfg.EnableNestedContainers = p.nestedContainers
+	cfg.EnableSystemd = p.systemd
 	cfg.EnableSsh = p.enableSsh
 	cfg.EnableSudo = p.enableSudo
 
@@ -285,6 +332,22 @@ func buildConfig(projectPath string, p *parsedFlags) (config.Config, error) {
 		return config.Config{}, fmt.Errorf("invalid package list: %s", strings.Join(invalidPackages, " "))
 	}
 	cfg.Packages = validPackages
+	for _, list := range []struct {
+		flag     string
+		packages []string
+		dest     *[]string
+	}{
+		{"--pipx-package", p.pipxPackages, &cfg.PipxPackages},
+		{"--cargo-package", p.cargoPackages, &cfg.CargoPackages},
+		{"--npm-package", p.npmPackages, &cfg.NpmPackages},
+	} {
+		for _, name := range list.packages {
+			if !utils.IsValidLanguagePackageName(name) {
+				return config.Config{}, fmt.Errorf("invalid %s: %s", list.flag, name)
+			}
+		}
+		*list.dest = list.packages
+	}
 
 	// Tools
 	cfg.InstallNeovim = p.installNeovim
@@ -299,6 +362,7 @@ func buildConfig(projectPath string, p *parsedFlags) (config.Config, error) {
 	cfg.InstallClaudeCode = p.installClaudeCode
 	cfg.InstallCodex = p.installCodex
 	cfg.InstallFirefox = p.installFirefox
+	cfg.InstallCompletions = !p.noCompletions
 
 	// Project name
 	if p.name == "" {
@@ -340,9 +404,9 @@ func validateProjectName(name string, filestor *files.FileStore, cons *console.C
 	return nil
 }
 
-func promptMissing(cons *console.Console, cfg *config.Config) error {
+func promptMissing(cons *console.Console, cfg *config.Config, askShell bool) error {
 	// Shell
-	if cfg.Shell == config.ShellBash {
+	if askShell {
 		cons.WriteLn("")
 		if err := promptShell(cons, cfg); err != nil {
 			return err
@@ -492,8 +556,10 @@ func parseShell(s string) (config.Shell, error) {
 		return config.ShellZsh, nil
 	case "fish":
 		return config.ShellFish, nil
+	case "nushell", "nu":
+		return config.ShellNushell, nil
 	default:
-		return config.ShellBash, fmt.Errorf("invalid shell '%s'. Must be one of: bash, zsh, fish", s)
+		return config.ShellBash, fmt.Errorf("invalid shell '%s'. Must be one of: bash, zsh, fish, nushell", s)
 	}
 }
 
@@ -526,7 +592,8 @@ func filterValidPackages(packages []string) ([]string, []string) {
 
 func hasAnyLanguage(cfg *config.Config) bool {
 	return cfg.InstallNode != "" || cfg.InstallRust != "" ||
-		cfg.InstallPython != "" || cfg.InstallGo != "" || cfg.EnableWasm
+		cfg.InstallPython != "" || cfg.InstallGo != "" || cfg.EnableWasm ||
+		cfg.EnableCompilerCache
 }
 
 func hasAnyDevTool(cfg *config.Config) bool {
@@ -569,6 +636,58 @@ func validateNoPromptConfig(cfg *config.Config) error {
 	return nil
 }
 
+// Check that a dotfiles profile of that name exists.
+func validateDotfilesProfile(name string, filestor *files.FileStore) error {
+	if err := config.ValidateDotfilesProfileName(name); err != nil {
+		return fmt.Errorf("invalid dotfiles profile: %w", err)
+	}
+	profiles, err := filestor.ListDotfilesProfiles()
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(profiles, name) {
+		return fmt.Errorf("dotfiles profile '%s' not found, create the %s directory first", name, filestor.GetDotfilesProfilePath(name))
+	}
+	return nil
+}
+
+func promptDotfilesProfile(cons *console.Console, filestor *files.FileStore, cfg *config.Config) error {
+	profiles, err := filestor.ListDotfilesProfiles()
+	if err != nil {
+		return err
+	}
+	if len(profiles) == 0 {
+		return nil
+	}
+
+	for {
+		cons.WriteLn("")
+		cons.Info("=== Dotfiles ===")
+		cons.WriteLn("Select the dotfiles to apply in the container:")
+		cons.WriteLn("  0) the project's own dotfiles/ directory (default)")
+		for i, profile := range profiles {
+			cons.WriteLn("  %d) profile '%s'", i+1, profile)
+		}
+		choice, err := cons.AskString("Choice", "0")
+		if err != nil {
+			return fmt.Errorf("unable to prompt for dotfiles profile: %w", err)
+		}
+		if choice == "0" {
+			return nil
+		}
+		if index, err := strconv.Atoi(choice); err == nil && index >= 1 && index <= len(profiles) {
+			cfg.DotfilesProfile = profiles[index-1]
+			return nil
+		}
+		if slices.Contains(profiles, choice) {
+			cfg.DotfilesProfile = choice
+			return nil
+		}
+		cons.Warn("Unrecognized choice: \"%s\"", choice)
+		cons.Warn("Please select an element from the list, or leave empty for the default.")
+	}
+}
+
 func promptDotfilesSeed(cons *console.Console, filestor *files.FileStore, cfg *config.Config) error {
 	hasTemplate, err := filestor.HasGlobalDotfilesTemplate()
 	if err != nil {
@@ -633,7 +752,7 @@ func confirmAgenticToolSelection(cons *console.Console, cfg *config.Config) erro
 }
 
 func selectedLanguages(cfg *config.Config) []string {
-	selected := make([]string, 0, 5)
+	selected := make([]string, 0, 6)
 	if cfg.InstallNode != "" && cfg.InstallNode != config.VersionNone {
 		selected = append(selected, fmt.Sprintf("Node.js (%s)", cfg.InstallNode))
 	}
@@ -649,6 +768,9 @@ func selectedLanguages(cfg *config.Config) []string {
 	if cfg.EnableWasm {
 		selected = append(selected, "WebAssembly tools")
 	}
+	if cfg.EnableCompilerCache {
+		selected = append(selected, "Compiler caches")
+	}
 	return selected
 }
 
@@ -701,6 +823,7 @@ func resetLanguages(cfg *config.Config) {
 	cfg.InstallPython = ""
 	cfg.InstallGo = ""
 	cfg.EnableWasm = false
+	cfg.EnableCompilerCache = false
 }
 
 func resetDevTools(cfg *config.Config) {
@@ -730,6 +853,7 @@ func promptShell(cons *console.Console, cfg *config.Config) error {
 		cons.WriteLn("  1) bash (default)")
 		cons.WriteLn("  2) zsh")
 		cons.WriteLn("  3) fish")
+		cons.WriteLn("  4) nushell")
 
 		choice, err := cons.AskString("Choice", "1")
 		if err != nil {
@@ -746,6 +870,9 @@ func promptShell(cons *console.Console, cfg *config.Config) error {
 		case "3", "fish":
 			cfg.Shell = config.ShellFish
 			return nil
+		case "4", "nushell", "nu":
+			cfg.Shell = config.ShellNushell
+			return nil
 		default:
 			cons.Warn("Unrecognized choice: \"%s\"", choice)
 			cons.Warn("Please select an element from the list, or leave empty for the default.")
@@ -764,6 +891,7 @@ func promptLanguages(cons *console.Console, cfg *config.Config, askVersion bool)
 		cons.WriteLn("  3) Python")
 		cons.WriteLn("  4) Go")
 		cons.WriteLn("  5) WebAssembly tools (Binaryen, Rust WASM target if Rust is enabled)")
+		cons.WriteLn("  6) Compiler caches (ccache for C/C++, sccache for Rust)")
 
 		choices, err := cons.AskString("Choice", "none")
 		if err != nil {
@@ -781,11 +909,11 @@ func promptLanguages(cons *console.Console, cfg *config.Config, askVersion bool)
 					cfg.InstallNode = config.VersionLatest
 					break
 				}
-				ver, err := cons.AskString("Node.js version (latest/none/X.Y.Z)", config.VersionLatest)
+				ver, err := cons.AskString("Node.js version (latest/lts/none/X[.Y[.Z]])", config.VersionLatest)
 				if err != nil {
 					return fmt.Errorf("unable to prompt for Node.js version: %w", err)
 				}
-				if err := utils.ValidateVersionArg(ver); err != nil {
+				if err := utils.ValidateToolchainVersion("node", ver); err != nil {
 					// TODO: just reask version, not the whole thing
 					cons.Warn("Invalid version format: %v", err)
 					allValid = false
@@ -797,11 +925,11 @@ func promptLanguages(cons *console.Console, cfg *config.Config, askVersion bool)
 					cfg.InstallRust = config.VersionLatest
 					break
 				}
-				ver, err := cons.AskString("Rust version (latest/none/X.Y.Z)", config.VersionLatest)
+				ver, err := cons.AskString("Rust version (latest/stable/beta/nightly/none/X[.Y[.Z]])", config.VersionLatest)
 				if err != nil {
 					return fmt.Errorf("unable to prompt for Rust version: %w", err)
 				}
-				if err := utils.ValidateVersionArg(ver); err != nil {
+				if err := utils.ValidateToolchainVersion("rust", ver); err != nil {
 					// TODO: just reask version, not the whole thing
 					cons.Warn("Invalid version format: %v", err)
 					allValid = false
@@ -813,11 +941,11 @@ func promptLanguages(cons *console.Console, cfg *config.Config, askVersion bool)
 					cfg.InstallPython = config.VersionLatest
 					break
 				}
-				ver, err := cons.AskString("Python version (latest/none/X.Y.Z)", config.VersionLatest)
+				ver, err := cons.AskString("Python version (latest/none/X[.Y[.Z]])", config.VersionLatest)
 				if err != nil {
 					return fmt.Errorf("unable to prompt for Python version: %w", err)
 				}
-				if err := utils.ValidateVersionArg(ver); err != nil {
+				if err := utils.ValidateToolchainVersion("python", ver); err != nil {
 					// TODO: just reask version, not the whole thing
 					cons.Warn("Invalid version format: %v", err)
 					allValid = false
@@ -829,11 +957,11 @@ func promptLanguages(cons *console.Console, cfg *config.Config, askVersion bool)
 					cfg.InstallGo = config.VersionLatest
 					break
 				}
-				ver, err := cons.AskString("Go version (latest/none/X.Y.Z)", config.VersionLatest)
+				ver, err := cons.AskString("Go version (latest/none/X[.Y[.Z]])", config.VersionLatest)
 				if err != nil {
 					return fmt.Errorf("unable to prompt for Go version: %w", err)
 				}
-				if err := utils.ValidateVersionArg(ver); err != nil {
+				if err := utils.ValidateToolchainVersion("go", ver); err != nil {
 					// TODO: just reask version, not the whole thing
 					cons.Warn("Invalid version format: %v", err)
 					allValid = false
@@ -842,6 +970,8 @@ func promptLanguages(cons *console.Console, cfg *config.Config, askVersion bool)
 				cfg.InstallGo = ver
 			case "5":
 				cfg.EnableWasm = true
+			case "6":
+				cfg.EnableCompilerCache = true
 			case "none":
 				return nil
 			default:
@@ -859,6 +989,7 @@ func promptLanguages(cons *console.Console, cfg *config.Config, askVersion bool)
 			cfg.InstallPython = ""
 			cfg.InstallGo = ""
 			cfg.EnableWasm = false
+			cfg.EnableCompilerCache = false
 			continue
 		}
 
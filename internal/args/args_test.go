@@ -190,7 +190,7 @@ func TestPromptMissing_ConfirmsPrefilledLanguages(t *testing.T) {
 
 	cons := console.New(context.Background(), strings.NewReader("\n\n\n"), &out, &bytes.Buffer{})
 
-	if err := promptMissing(cons, &cfg); err != nil {
+	if err := promptMissing(cons, &cfg, false); err != nil {
 		t.Fatalf("promptMissing() error = %v", err)
 	}
 	if cfg.InstallNode != config.VersionLatest {
@@ -217,7 +217,7 @@ func TestPromptMissing_RepromptsPrefilledDevTools(t *testing.T) {
 
 	cons := console.New(context.Background(), strings.NewReader("\nn\n2 4\n\n"), &out, &bytes.Buffer{})
 
-	if err := promptMissing(cons, &cfg); err != nil {
+	if err := promptMissing(cons, &cfg, false); err != nil {
 		t.Fatalf("promptMissing() error = %v", err)
 	}
 	if cfg.InstallNeovim {
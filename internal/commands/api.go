@@ -0,0 +1,338 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/logging"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func API(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var socketPath string
+	flagset := newCommandFlagSet("api", console)
+	flagset.StringVar(&socketPath, "socket", "", "Path of the Unix socket to listen on. Default: 'api.sock' in the application data directory.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs api [flags]",
+			"Serve a local HTTP API with JSON responses on a Unix socket until interrupted, so other tools (editor plugins, status bars, scripts...) can list projects, see their status, build them, start them in the background and stop them without parsing the output of the CLI.\n\n"+
+				"Endpoints:\n"+
+				"  GET  /v1/projects              status of all projects\n"+
+				"  GET  /v1/projects/{name}       status of a project\n"+
+				"  POST /v1/projects/{name}/build build its image\n"+
+				"  POST /v1/projects/{name}/run   start its container in the background\n"+
+				"  POST /v1/projects/{name}/stop  stop its running containers\n\n"+
+				"Actions respond with whether they succeeded, the CLI's exit code for their failure and the messages they displayed. The socket is only accessible to the current user.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flagset.NArg() > 0 {
+		return utils.WithCategory(errors.New("api does not take arguments"), errUsage)
+	}
+	if socketPath == "" {
+		if err := filestore.PrepareAPISocketDir(); err != nil {
+			return err
+		}
+		socketPath = filestore.GetAPISocketPath()
+	}
+
+	if conn, err := net.Dial("unix", socketPath); err == nil {
+		conn.Close()
+		return fmt.Errorf("another 'paul-envs api' already listens on %s", socketPath)
+	}
+	// Left by an `api` which did not end normally
+	_ = os.Remove(socketPath)
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s: %w", socketPath, err)
+	}
+	defer os.Remove(socketPath)
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("cannot restrict access to %s: %w", socketPath, err)
+	}
+
+	server := &http.Server{
+		Handler:           newAPIHandler(filestore),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	console.Info("Serving the paul-envs API on %s", socketPath)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("API server failed: %w", err)
+	}
+	return nil
+}
+
+// Status of a project, as given by the API.
+type apiProject struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// Engine its image was found on, empty if it is not built
+	Engine     string         `json:"engine,omitempty"`
+	Built      bool           `json:"built"`
+	BuiltAt    *time.Time     `json:"builtAt,omitempty"`
+	ImageSize  string         `json:"imageSize,omitempty"`
+	Containers []apiContainer `json:"containers"`
+	Volumes    []string       `json:"volumes"`
+	Networks   []string       `json:"networks"`
+}
+
+type apiContainer struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+	// Empty for the project's default instance (see `run --instance`)
+	Instance string `json:"instance,omitempty"`
+	Running  bool   `json:"running"`
+}
+
+// Outcome of an action asked through the API.
+type apiActionResult struct {
+	OK bool `json:"ok"`
+	// What failed, empty if it succeeded
+	Error string `json:"error,omitempty"`
+	// Exit code of the CLI for the same outcome
+	ExitCode int `json:"exitCode"`
+	// Messages displayed by the action, each line prefixed by its level
+	// (e.g. "[warn] ")
+	Output string `json:"output"`
+}
+
+type apiHandler struct {
+	filestore *files.FileStore
+	mux       *http.ServeMux
+}
+
+func newAPIHandler(filestore *files.FileStore) *apiHandler {
+	h := &apiHandler{filestore: filestore, mux: http.NewServeMux()}
+	h.mux.HandleFunc("GET /v1/projects", h.listProjects)
+	h.mux.HandleFunc("GET /v1/projects/{name}", h.getProject)
+	h.mux.HandleFunc("POST /v1/projects/{name}/build", h.action(func(ctx context.Context, name string, console *console.Console) error {
+		return Build(ctx, []string{name}, h.filestore, console)
+	}))
+	h.mux.HandleFunc("POST /v1/projects/{name}/run", h.action(h.runProject))
+	h.mux.HandleFunc("POST /v1/projects/{name}/stop", h.action(h.stopProject))
+	return h
+}
+
+func (h *apiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logging.Log().Info("API request", "method", r.Method, "path", r.URL.Path)
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *apiHandler) listProjects(w http.ResponseWriter, r *http.Request) {
+	overviews, err := collectProjectOverviews(r.Context(), h.filestore, newAPIConsole(r.Context(), &bytes.Buffer{}))
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	projects := make([]apiProject, 0, len(overviews))
+	for _, overview := range overviews {
+		projects = append(projects, newAPIProject(overview))
+	}
+	writeAPIResponse(w, http.StatusOK, projects)
+}
+
+func (h *apiHandler) getProject(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if err := validateProjectName(name); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	overview, err := h.projectOverview(r.Context(), name, newAPIConsole(r.Context(), &bytes.Buffer{}))
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeAPIResponse(w, http.StatusOK, newAPIProject(overview))
+}
+
+// Handler running an action on the project named in the request, its
+// messages being sent back with its outcome.
+func (h *apiHandler) action(run func(ctx context.Context, name string, console *console.Console) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		var output bytes.Buffer
+		err := validateProjectName(name)
+		if err == nil {
+			err = run(r.Context(), name, newAPIConsole(r.Context(), &output))
+		}
+		result := apiActionResult{OK: err == nil, ExitCode: ExitCode(err), Output: output.String()}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		writeAPIResponse(w, apiStatus(err), result)
+	}
+}
+
+// Start the container of the project in the background, as `code` does.
+func (h *apiHandler) runProject(ctx context.Context, name string, console *console.Console) error {
+	if !h.filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+	if err := ensureProjectCompatible(name, h.filestore, console); err != nil {
+		return fmt.Errorf("cannot run project '%s': %w", name, err)
+	}
+	unlock, err := lockProject(ctx, name, h.filestore, console)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	project, err := h.filestore.GetProject(name)
+	if err != nil {
+		return fmt.Errorf("failed to obtain information on project '%s': %w", name, err)
+	}
+	containerEngine, _, err := newProjectEngine(ctx, name, engine.SelectionAuto, h.filestore, console)
+	if err != nil {
+		return err
+	}
+	container, err := ensureProjectContainerRunning(ctx, project, containerEngine, h.filestore, console)
+	if err != nil {
+		return err
+	}
+	console.Success("Container %s of project '%s' is running", apiContainerName(container), name)
+	return nil
+}
+
+// Stop the running containers of the project, as the `tui` does.
+func (h *apiHandler) stopProject(ctx context.Context, name string, console *console.Console) error {
+	unlock, err := lockProject(ctx, name, h.filestore, console)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	overview, err := h.projectOverview(ctx, name, console)
+	if err != nil {
+		return err
+	}
+	running := overview.RunningContainers()
+	if len(running) == 0 {
+		console.Info("No running container for project '%s'", name)
+		return nil
+	}
+	if overview.containerEngine == nil {
+		return fmt.Errorf("%w: cannot stop the containers of project '%s'", engine.ErrEngineUnavailable, name)
+	}
+	for _, container := range running {
+		if err := overview.containerEngine.StopContainer(ctx, container); err != nil {
+			return fmt.Errorf("could not stop container %s: %w", apiContainerName(container), err)
+		}
+		console.Success("Stopped container %s", apiContainerName(container))
+	}
+	return nil
+}
+
+func (h *apiHandler) projectOverview(ctx context.Context, name string, console *console.Console) (projectOverview, error) {
+	if !h.filestore.DoesProjectExist(name) {
+		return projectOverview{}, projectNotFoundError(name)
+	}
+	overviews, err := collectProjectOverviews(ctx, h.filestore, console)
+	if err != nil {
+		return projectOverview{}, err
+	}
+	for _, overview := range overviews {
+		if overview.Entry.ProjectName == name {
+			return overview, nil
+		}
+	}
+	return projectOverview{}, projectNotFoundError(name)
+}
+
+// Console of an API request: nothing can be asked and messages are written
+// to `output`, prefixed by their level.
+func newAPIConsole(ctx context.Context, output *bytes.Buffer) *console.Console {
+	c := console.New(ctx, strings.NewReader(""), output, output)
+	c.SetNonInteractive(true)
+	return c
+}
+
+func newAPIProject(overview projectOverview) apiProject {
+	project := apiProject{
+		Name:       overview.Entry.ProjectName,
+		Path:       overview.Entry.ProjectPath,
+		Engine:     overview.EngineName,
+		Built:      overview.IsBuilt(),
+		Containers: make([]apiContainer, 0, len(overview.Containers)),
+		Volumes:    make([]string, 0, len(overview.Volumes)),
+		Networks:   make([]string, 0, len(overview.Networks)),
+	}
+	if overview.Image != nil {
+		project.BuiltAt = overview.Image.BuiltAt
+		project.ImageSize = overview.Image.Size
+	}
+	for _, container := range overview.Containers {
+		project.Containers = append(project.Containers, apiContainer{
+			Name:     apiContainerName(container),
+			ID:       container.ContainerId,
+			Instance: container.Instance,
+			Running:  container.Running,
+		})
+	}
+	for _, volume := range overview.Volumes {
+		project.Volumes = append(project.Volumes, volume.VolumeName)
+	}
+	for _, network := range overview.Networks {
+		project.Networks = append(project.Networks, network.NetworkName)
+	}
+	return project
+}
+
+func apiContainerName(container engine.ContainerInfo) string {
+	if container.ContainerName != nil {
+		return *container.ContainerName
+	}
+	return container.ContainerId
+}
+
+// HTTP status of a request which failed with that error, `200` if it did not.
+func apiStatus(err error) int {
+	switch ExitCode(err) {
+	case ExitSuccess:
+		return http.StatusOK
+	case ExitUsage, ExitValidationFailed, ExitInteractionRequired:
+		return http.StatusBadRequest
+	case ExitProjectNotFound:
+		return http.StatusNotFound
+	case ExitProjectLocked:
+		return http.StatusConflict
+	case ExitEngineUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeAPIError(w http.ResponseWriter, err error) {
+	writeAPIResponse(w, apiStatus(err), apiActionResult{Error: err.Error(), ExitCode: ExitCode(err)})
+}
+
+func writeAPIResponse(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logging.Log().Debug("API response not sent", "error", err)
+	}
+}
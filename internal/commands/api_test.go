@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestAPIHandlerErrors(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	store, err := files.NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	handler := newAPIHandler(store)
+
+	for _, tc := range []struct {
+		method, path string
+		status       int
+		exitCode     int
+	}{
+		{http.MethodGet, "/v1/projects/missing", http.StatusNotFound, ExitProjectNotFound},
+		{http.MethodPost, "/v1/projects/missing/build", http.StatusNotFound, ExitProjectNotFound},
+		{http.MethodPost, "/v1/projects/missing/run", http.StatusNotFound, ExitProjectNotFound},
+		{http.MethodPost, "/v1/projects/missing/stop", http.StatusNotFound, ExitProjectNotFound},
+		{http.MethodGet, "/v1/projects/in%20valid", http.StatusBadRequest, ExitValidationFailed},
+	} {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(tc.method, tc.path, nil))
+		if recorder.Code != tc.status {
+			t.Errorf("%s %s status = %d, want %d", tc.method, tc.path, recorder.Code, tc.status)
+		}
+		var result apiActionResult
+		if err := json.Unmarshal(recorder.Body.Bytes(), &result); err != nil {
+			t.Fatalf("%s %s body %q is not an action result: %v", tc.method, tc.path, recorder.Body, err)
+		}
+		if result.OK || result.ExitCode != tc.exitCode || result.Error == "" {
+			t.Errorf("%s %s result = %+v, want a failure with exit code %d", tc.method, tc.path, result, tc.exitCode)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/projects/missing/build", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET of an action status = %d, want %d", recorder.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestNewAPIProject(t *testing.T) {
+	builtAt := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	containerName := "paulenv-app"
+	project := newAPIProject(projectOverview{
+		Entry:      files.ProjectEntry{ProjectName: "app", ProjectPath: "/src/app"},
+		EngineName: "docker",
+		Image:      &engine.ImageInfo{ImageName: "paulenv:app", BuiltAt: &builtAt, Size: "1.2GB"},
+		Containers: []engine.ContainerInfo{
+			{ContainerName: &containerName, ContainerId: "abc", Running: true},
+			{ContainerId: "def", Instance: "test"},
+		},
+		Volumes: []engine.VolumeInfo{{VolumeName: "paulenv-app-local"}},
+	})
+
+	encoded, err := json.Marshal(project)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `{"name":"app","path":"/src/app","engine":"docker","built":true,"builtAt":"2026-01-02T00:00:00Z","imageSize":"1.2GB",` +
+		`"containers":[{"name":"paulenv-app","id":"abc","running":true},{"name":"def","id":"def","instance":"test","running":false}],` +
+		`"volumes":["paulenv-app-local"],"networks":[]}`
+	if string(encoded) != want {
+		t.Fatalf("project = %s\nwant %s", encoded, want)
+	}
+}
@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+)
+
+// Returns the architecture-specific images of the given project.
+func listProjectArchImages(ctx context.Context, containerEngine engine.ContainerEngine, projectName string) ([]engine.ArchImageInfo, error) {
+	all, err := containerEngine.ListArchImages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list architecture-specific images: %w", err)
+	}
+	var images []engine.ArchImageInfo
+	for _, image := range all {
+		if image.ProjectName == projectName {
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
+
+// Make the current image of that project the one built for this host's
+// architecture if it isn't already and there's one, warning when it would
+// otherwise run through emulation.
+func selectHostArchImage(ctx context.Context, projectName string, containerEngine engine.ContainerEngine, console *console.Console) {
+	current, err := containerEngine.GetImageInfo(ctx, projectName)
+	if err != nil || current == nil || current.Architecture == "" || current.Architecture == runtime.GOARCH {
+		return
+	}
+	images, err := listProjectArchImages(ctx, containerEngine, projectName)
+	if err != nil {
+		console.Warn("Could not look for an image of project '%s' built for this host: %s", projectName, err)
+		return
+	}
+	for _, image := range images {
+		if image.Architecture != runtime.GOARCH {
+			continue
+		}
+		if err := containerEngine.UseArchImage(ctx, image); err != nil {
+			console.Warn("Could not use the %s image of project '%s': %s", image.Architecture, projectName, err)
+			break
+		}
+		console.Info("Using the %s image of project '%s', matching this host.", image.Architecture, projectName)
+		if image.BuiltAt != nil && current.BuiltAt != nil && image.BuiltAt.Before(*current.BuiltAt) {
+			console.Warn("It was built before its %s image, rebuild it if its configuration changed since: 'paul-envs build --platform %s %s'",
+				current.Architecture, image.Architecture, projectName)
+		}
+		return
+	}
+	console.Warn("Project '%s' only has an image built for %s, it will run through emulation on this %s host, which is much slower.",
+		projectName, current.Architecture, runtime.GOARCH)
+	console.WriteLn("Hint: Build it for this host with 'paul-envs build --platform %s %s'", runtime.GOARCH, projectName)
+	warnIfNoEmulation(current.Architecture, console)
+}
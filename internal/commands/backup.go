@@ -0,0 +1,207 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Backup(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var withVolumes bool
+	var force bool
+	var engineSelection string
+	flagset := newCommandFlagSet("backup", console)
+	flagset.BoolVar(&withVolumes, "volumes", false, "Also save the content of the projects' volumes (their ~/.container-local\ndirectory and the data of their services). The shared cache is never saved.")
+	flagset.BoolVar(&force, "force", false, "Replace the backup file if it already exists")
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine whose volumes are saved with --volumes: docker or podman.\nDefault: auto-select, preferring Podman.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs backup <file> [flags]",
+			"Save the whole state of paul-envs in a single archive: its global configuration and dotfiles, every project's configuration, dotfiles and previous configurations, and what it knows of their builds. Restore it with 'paul-envs restore', e.g. on a new machine.\n\nImages are not saved, as they can be rebuilt from it.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) != 1 {
+		return utils.WithCategory(errors.New("expected the path of the backup file"), errUsage)
+	}
+	path := args[0]
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("'%s' already exists\nHint: Replace it with '--force'", path)
+	}
+
+	var volumes []string
+	var containerEngine engine.ContainerEngine
+	if withVolumes {
+		selectedEngine, err := parseCommandEngineSelection(engineSelection)
+		if err != nil {
+			return err
+		}
+		if containerEngine, err = engine.NewSelected(ctx, console, selectedEngine); err != nil {
+			return err
+		}
+		if err := engine.EnsurePodmanMachine(ctx, containerEngine, engine.MachineNeeds{}, console); err != nil {
+			return err
+		}
+		if volumes, err = backedUpVolumes(ctx, containerEngine); err != nil {
+			return err
+		}
+	}
+
+	console.Info("Writing backup to %s...", path)
+	manifest, err := filestore.WriteBackup(ctx, path, volumes, func(name string, w io.Writer) error {
+		console.Info("Saving volume %s...", name)
+		return containerEngine.ExportVolume(ctx, name, w)
+	})
+	if err != nil {
+		return err
+	}
+	console.Success("Backed up %d project(s) and %d volume(s) to %s", len(manifest.Projects), len(manifest.Volumes), path)
+	console.WriteLn("It may contain credentials (e.g. in dotfiles or run.conf files): keep it somewhere safe.")
+	return nil
+}
+
+func Restore(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var force bool
+	var noVolumes bool
+	var engineSelection string
+	flagset := newCommandFlagSet("restore", console)
+	flagset.BoolVar(&force, "force", false, "Replace files and volume content which already exist with another content")
+	flagset.BoolVar(&noVolumes, "no-volumes", false, "Do not restore the volumes saved in the backup")
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine in which saved volumes are restored: docker or podman.\nDefault: auto-select, preferring Podman.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs restore <file> [flags]",
+			"Restore the state of paul-envs saved by 'paul-envs backup', and the volumes saved with it. Projects and files which are not in it are kept.\n\nNothing is restored if files it contains already exist with another content, unless '--force' is given.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) != 1 {
+		return utils.WithCategory(errors.New("expected the path of the backup file"), errUsage)
+	}
+	path := args[0]
+	selectedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockRegistry(ctx, filestore, console)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	manifest, err := filestore.RestoreBackup(ctx, path, force)
+	if err != nil {
+		if errors.Is(err, files.ErrBackupConflict) {
+			return fmt.Errorf("cannot restore '%s': %w\nHint: Replace them with '--force'", path, err)
+		}
+		return err
+	}
+	console.Success("Restored %d project(s) from the backup of %s", len(manifest.Projects), manifest.CreatedAt.Local().Format("2006-01-02 15:04"))
+
+	if len(manifest.Volumes) > 0 && !noVolumes {
+		if err := restoreBackupVolumes(ctx, path, manifest, selectedEngine, force, filestore, console); err != nil {
+			return err
+		}
+	}
+	console.WriteLn("Images were not part of the backup: build the projects with 'paul-envs rebuild'.")
+	return nil
+}
+
+// Restore the volumes of the backup at `path` with the selected engine. Those
+// which already exist are only replaced if `force` is set.
+func restoreBackupVolumes(
+	ctx context.Context,
+	path string,
+	manifest files.BackupManifest,
+	selectedEngine engine.Selection,
+	force bool,
+	filestore *files.FileStore,
+	console *console.Console,
+) error {
+	containerEngine, err := engine.NewSelected(ctx, console, selectedEngine)
+	if err != nil {
+		return err
+	}
+	if err := engine.EnsurePodmanMachine(ctx, containerEngine, engine.MachineNeeds{}, console); err != nil {
+		return err
+	}
+	existing, err := backedUpVolumes(ctx, containerEngine)
+	if err != nil {
+		return err
+	}
+	restored := 0
+	restoreVolume := func(name string) bool {
+		if slices.Contains(existing, name) && !force {
+			console.Warn("Volume %s already exists, it was kept (use '--force' to restore its files over it)", name)
+			return false
+		}
+		return true
+	}
+	// Docker reads and writes volumes through the shared base image
+	if err = filestore.RefreshBaseFiles(); err != nil {
+		return fmt.Errorf("cannot restore volumes: Failed to refresh base build files: %w", err)
+	}
+	buildOptions := engine.BuildOptions{Heartbeat: engine.DefaultBuildHeartbeat, StallThreshold: engine.DefaultBuildStallThreshold}
+	if globalConfig, err := filestore.LoadGlobalConfig(); err == nil {
+		applyGlobalBuildConfig(&buildOptions, globalConfig)
+	}
+	engineInfo, err := containerEngine.Info(ctx)
+	if err != nil {
+		console.Warn("Could not obtain container engine information: %s", err)
+	}
+	if _, err := ensureBaseImageIsBuilt(ctx, containerEngine, engineInfo.Name, false, buildOptions, filestore, console); err != nil {
+		return err
+	}
+	err = files.RestoreBackupVolumes(path, restoreVolume, func(name string, r io.Reader) error {
+		console.Info("Restoring volume %s...", name)
+		restored++
+		return containerEngine.ImportVolume(ctx, name, r)
+	})
+	if err != nil {
+		return err
+	}
+	console.Success("Restored %d of the %d volume(s) of the backup", restored, len(manifest.Volumes))
+	return nil
+}
+
+// Names of the volumes of paul-envs projects saved by `backup --volumes`: all
+// but the shared caches, which can be rebuilt.
+func backedUpVolumes(ctx context.Context, containerEngine engine.ContainerEngine) ([]string, error) {
+	volumes, err := containerEngine.ListVolumes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list volumes: %w", err)
+	}
+	var names []string
+	for _, volume := range volumes {
+		if !engine.IsSharedCacheVolume(volume.VolumeName) {
+			names = append(names, volume.VolumeName)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+)
+
+// Summary of a project displayed before entering its container.
+type startupBanner struct {
+	ProjectName string
+	EngineName  string
+	// `nil` if unknown
+	ImageBuiltAt *time.Time
+	Ports        []string
+	// Host port of its ssh server, empty if none
+	SSHPort string
+	// Why the image should be rebuilt, empty if it is up-to-date
+	PendingRebuild string
+	// `true` if an already running container is joined
+	Joining bool
+}
+
+func (b startupBanner) Lines(now time.Time) []string {
+	header := b.ProjectName
+	if b.EngineName != "" {
+		header += " (" + b.EngineName + ")"
+	}
+	if b.Joining {
+		header += ", joining running container"
+	}
+	lines := []string{header}
+	if b.ImageBuiltAt != nil {
+		lines = append(lines, "  Image built : "+formatImageAge(b.ImageBuiltAt, now))
+	}
+	if len(b.Ports) > 0 {
+		lines = append(lines, "  Ports       : "+strings.Join(b.Ports, ", "))
+	}
+	if b.SSHPort != "" {
+		lines = append(lines, "  SSH         : localhost:"+b.SSHPort+" (see 'paul-envs ssh-config "+b.ProjectName+"')")
+	}
+	if b.PendingRebuild != "" {
+		lines = append(lines, "  Rebuild     : pending, "+b.PendingRebuild)
+	}
+	return lines
+}
+
+func writeStartupBanner(console *console.Console, banner startupBanner) {
+	lines := banner.Lines(time.Now())
+	console.Info("%s", lines[0])
+	for _, line := range lines[1:] {
+		console.WriteLn("%s", line)
+	}
+	if banner.PendingRebuild != "" {
+		console.WriteLn("Hint: Use 'paul-envs build %s' to apply pending changes", banner.ProjectName)
+	}
+}
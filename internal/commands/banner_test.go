@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestStartupBannerLines(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	builtAt := now.Add(-3 * 24 * time.Hour)
+
+	got := startupBanner{
+		ProjectName:    "app",
+		EngineName:     "podman",
+		ImageBuiltAt:   &builtAt,
+		Ports:          []string{"3000:3000", "8080:80"},
+		SSHPort:        "2222",
+		PendingRebuild: "build.conf file has changed since last build",
+		Joining:        true,
+	}.Lines(now)
+	want := []string{
+		"app (podman), joining running container",
+		"  Image built : 3d ago",
+		"  Ports       : 3000:3000, 8080:80",
+		"  SSH         : localhost:2222 (see 'paul-envs ssh-config app')",
+		"  Rebuild     : pending, build.conf file has changed since last build",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("Lines() = %q, want %q", got, want)
+	}
+
+	got = startupBanner{ProjectName: "app"}.Lines(now)
+	if !slices.Equal(got, []string{"app"}) {
+		t.Fatalf("Lines() = %q for a minimal banner, want only the project name", got)
+	}
+}
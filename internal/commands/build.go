@@ -5,25 +5,47 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
 
+	"github.com/peaberberian/paul-envs/internal/config"
 	"github.com/peaberberian/paul-envs/internal/console"
 	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/events"
 	"github.com/peaberberian/paul-envs/internal/files"
 	"github.com/peaberberian/paul-envs/internal/utils"
 )
 
-func Build(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+func Build(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) (err error) {
 	var noCache bool
+	var rebuildBase bool
+	var rollback bool
+	var heartbeat time.Duration
+	var stallAfter time.Duration
 	var engineSelection string
+	var rootful bool
+	var platform string
+	var verify bool
+	var buildArgs stringListFlag
 	flagset := newCommandFlagSet("build", console)
 	flagset.BoolVar(&noCache, "no-cache", false, "Build the image without using cached layers")
+	flagset.BoolVar(&rebuildBase, "base", false, "Also rebuild the shared base image all project images are built on.\nWithout a project name, only rebuild that base image.")
+	flagset.BoolVar(&rollback, "rollback", false, "Restore the project configuration files from before they were last\nregenerated, then build with them")
+	flagset.DurationVar(&heartbeat, "heartbeat", engine.DefaultBuildHeartbeat, "Print a heartbeat each time the build stays silent for that long, 0 to disable")
+	flagset.DurationVar(&stallAfter, "stall-after", engine.DefaultBuildStallThreshold, "Report the build as possibly stalled, with likely causes, once silent for\nthat long, 0 to disable")
 	flagset.StringVar(&engineSelection, "engine", "", "Container engine to use for this build: docker or podman.\nDefault: auto-select, preferring Podman.")
+	flagset.BoolVar(&rootful, "rootful", false, "Build with rootful Podman, for projects which need it e.g. to publish ports\nbelow 1024 or use devices. Later commands on the project keep using it.")
+	flagset.StringVar(&platform, "platform", "", "Architecture to build the image for (e.g. arm64 or linux/arm64), through emulation\nif it is not this host's. Images built for each architecture are kept and\n'run' picks the one matching the host. Default: the engine's own.")
+	flagset.BoolVar(&verify, "verify", false, "Once built, start the project's shell in a throwaway container with its\ndotfiles applied, failing if it errors or its rc files write errors.")
+	flagset.Var(&buildArgs, "build-arg", "Build argument to give to this build, as `NAME=VALUE`, replacing the one of\nthe project's build.conf if set. This option can be repeated.")
 	flagset.Usage = func() {
 		writeCommandUsage(
 			console,
 			flagset,
-			"paul-envs build [project-name] [flags]",
-			"Build a project image. If no project name is provided, paul-envs asks you to choose one.",
+			"paul-envs build [project-name|@group...] [flags]",
+			"Build a project image. If no project name is provided, paul-envs uses the one selected through PAULENV_PROJECT or the one of the current directory, otherwise asks you to choose one.\n\nWith several project names or groups of projects ('@group', see 'paul-envs group'), their images are built in parallel, their output only going to their build logs, and a summary is displayed once they are all over.",
 		)
 	}
 	if err := parseCommandFlags(flagset, args); err != nil {
@@ -33,31 +55,101 @@ func Build(ctx context.Context, args []string, filestore *files.FileStore, conso
 		return err
 	}
 	args = flagset.Args()
+	if heartbeat < 0 || stallAfter < 0 {
+		return utils.WithCategory(errors.New("--heartbeat and --stall-after cannot be negative"), errUsage)
+	}
+	buildOptions := engine.BuildOptions{NoCache: noCache, Heartbeat: heartbeat, StallThreshold: stallAfter}
+	// Errors have already been reported when starting
+	if globalConfig, err := filestore.LoadGlobalConfig(); err == nil {
+		applyGlobalBuildConfig(&buildOptions, globalConfig)
+	}
+	if platform != "" {
+		parsed, err := engine.ParsePlatform(platform)
+		if err != nil {
+			return utils.WithCategory(err, errUsage)
+		}
+		buildOptions.Platform = parsed
+	}
+	for _, arg := range buildArgs {
+		name, value, err := config.ParseBuildArg(arg)
+		if err != nil {
+			return utils.WithCategory(fmt.Errorf("invalid --build-arg: %w", err), errUsage)
+		}
+		if buildOptions.BuildArgs == nil {
+			buildOptions.BuildArgs = map[string]string{}
+		}
+		buildOptions.BuildArgs[name] = value
+	}
 
 	selectedEngine, err := parseCommandEngineSelection(engineSelection)
 	if err != nil {
 		return err
 	}
+	if selectedEngine, err = applyRootfulFlag(selectedEngine, rootful); err != nil {
+		return err
+	}
+	if rebuildBase && len(args) == 0 {
+		if len(buildArgs) > 0 {
+			return utils.WithCategory(errors.New("--build-arg only applies to project builds"), errUsage)
+		}
+		start := time.Now()
+		err = buildBaseImageOnly(ctx, selectedEngine, buildOptions, filestore, console)
+		notifyLongOperation(ctx, filestore, console, start, "Build of the shared base image", err)
+		return err
+	}
+	if len(args) > 1 || hasGroupSelector(args) {
+		if rebuildBase || rollback || verify || platform != "" {
+			return utils.WithCategory(errors.New("--base, --rollback, --verify and --platform only apply to the build of a single project"), errUsage)
+		}
+		names, err := resolveProjectSelectors(args, filestore)
+		if err != nil {
+			return err
+		}
+		// Their outputs would be mixed, they are only written to build logs
+		buildOptions.Quiet = true
+		return rebuildProjects(ctx, names, false, selectedEngine, buildOptions, engineQueryParallelism(filestore), filestore, console)
+	}
 	name, err := getProjectName(args, filestore, console, "build")
 	if err != nil {
 		return err
 	}
 
-	if err := utils.ValidateProjectName(name); err != nil {
+	if err := validateProjectName(name); err != nil {
 		return err
 	}
 	if !filestore.DoesProjectExist(name) {
-		return fmt.Errorf("project '%s' not found\nHint: Use 'paul-envs list' to see available projects", name)
+		return projectNotFoundError(name)
 	}
+	unlock, err := lockProject(ctx, name, filestore, console)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
+	if rollback {
+		if err := filestore.RollbackProjectFiles(name); err != nil {
+			return fmt.Errorf("cannot build project '%s': %w", name, err)
+		}
+		console.Info("Restored the previous configuration files of project '%s'.", name)
+	}
 	if err := ensureProjectCompatible(name, filestore, console); err != nil {
 		return fmt.Errorf("cannot build project '%s': %w", name, err)
 	}
 
+	start := time.Now()
+	defer func() {
+		notifyLongOperation(ctx, filestore, console, start, fmt.Sprintf("Build of project '%s'", name), err)
+	}()
 	containerEngine, err := engine.NewSelected(ctx, console, selectedEngine)
 	if err != nil {
 		return err
 	}
+	if err := ensureProjectMachine(ctx, name, containerEngine, filestore, console); err != nil {
+		return err
+	}
+	if err := ensureNoNameCollisions(ctx, name, containerEngine, console); err != nil {
+		return err
+	}
 
 	if err = filestore.RefreshBaseFiles(); err != nil {
 		return fmt.Errorf("cannot build: Failed to refresh base build files: %w", err)
@@ -68,7 +160,12 @@ func Build(ctx context.Context, args []string, filestore *files.FileStore, conso
 		return err
 	}
 
-	console.Info("Building project '%s'...", name)
+	engineInfo, engineInfoErr := containerEngine.Info(ctx)
+	baseRebuilt, err := ensureBaseImageIsBuilt(ctx, containerEngine, engineInfo.Name, rebuildBase, buildOptions, filestore, console)
+	if err != nil {
+		return err
+	}
+
 	project, err := filestore.GetProject(name)
 	if err != nil {
 		return fmt.Errorf("failed to obtain information on project '%s': %w", name, err)
@@ -76,26 +173,353 @@ func Build(ctx context.Context, args []string, filestore *files.FileStore, conso
 	if noCache {
 		console.Info("Ignoring cached image layers for this build.")
 	}
-	if err := containerEngine.BuildImage(ctx, project, engine.BuildOptions{NoCache: noCache}); err != nil {
+	if arch := engine.PlatformArchitecture(buildOptions.Platform); buildOptions.Platform != "" && arch != runtime.GOARCH {
+		console.Info("Building for %s through emulation, this can be much slower than a native build.", buildOptions.Platform)
+		warnIfNoEmulation(arch, console)
+	}
+	previousImage, err := buildProjectImage(ctx, project, containerEngine, engineInfo, engineInfoErr, buildOptions, filestore, console)
+	if err != nil {
+		return err
+	}
+	if verify {
+		if err := verifyProjectShell(ctx, project, containerEngine, previousImage, console); err != nil {
+			return err
+		}
+	}
+	console.Success("Built project '%s'", name)
+	if previousImage != nil {
+		console.WriteLn("Its previous image was kept: use 'paul-envs rollback %s' to go back to it.", name)
+	}
+	if baseRebuilt {
+		reportProjectsOnOutdatedBase(engineInfo.Name, filestore, console)
+	}
+	return nil
+}
+
+// Check that the shell of the freshly built image of that project starts
+// cleanly with its dotfiles, rather than discovering it when first running it.
+func verifyProjectShell(
+	ctx context.Context,
+	project files.ProjectEntry,
+	containerEngine engine.ContainerEngine,
+	previousImage *engine.GenerationInfo,
+	console *console.Console,
+) error {
+	name := project.ProjectName
+	console.Info("Checking that the shell of project '%s' starts cleanly with its dotfiles...", name)
+	check, err := containerEngine.CheckShell(ctx, project)
+	if err != nil {
+		return utils.WithCategory(fmt.Errorf("could not check the shell of project '%s': %w", name, err), errBuildFailed)
+	}
+	if check.Passed() {
+		return nil
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "the shell of project '%s' did not start cleanly", name)
+	if check.ExitCode != 0 {
+		fmt.Fprintf(&msg, " (exit code %d)", check.ExitCode)
+	}
+	if check.Errors != "" {
+		fmt.Fprintf(&msg, ":\n%s", check.Errors)
+	}
+	msg.WriteString("\nHint: Fix its shell configuration or dotfiles (DOTFILES_PATH or DOTFILES_PROFILE in its run.conf), dotfiles do not need a rebuild")
+	if previousImage != nil {
+		fmt.Fprintf(&msg, "\nIts previous image was kept: use 'paul-envs rollback %s' to go back to it.", name)
+	}
+	return utils.WithCategory(errors.New(msg.String()), errBuildFailed)
+}
+
+// Set the build settings of the global configuration in `options`.
+func applyGlobalBuildConfig(options *engine.BuildOptions, globalConfig config.GlobalConfig) {
+	options.DistributionImage = globalConfig.BaseImage
+	options.Timeout = globalConfig.BuildTimeout
+	options.Retries = globalConfig.BuildRetryCount()
+	options.Builder = globalConfig.PodmanBuilder
+}
+
+// Build the image of that project, keeping its current one as a previous
+// generation, then record how it was built.
+//
+// Returns the previous generation kept, `nil` if none was.
+func buildProjectImage(
+	ctx context.Context,
+	project files.ProjectEntry,
+	containerEngine engine.ContainerEngine,
+	engineInfo engine.EngineInfo,
+	engineInfoErr error,
+	buildOptions engine.BuildOptions,
+	filestore *files.FileStore,
+	console *console.Console,
+) (*engine.GenerationInfo, error) {
+	name := project.ProjectName
+	if err := ensureProjectDockerfileTrusted(project, filestore, console); err != nil {
+		return nil, err
+	}
+	if err := filestore.RefreshProjectDockerfile(name); err != nil {
+		return nil, fmt.Errorf("cannot build: Failed to write the Dockerfile of project '%s': %w", name, err)
+	}
+	// Recorded in the image's provenance, only known for the engine's own
+	// base image
+	if engineInfoErr == nil && buildOptions.Platform == "" {
+		if digest, err := filestore.GetBaseImageDistributionDigest(engineInfo.Name); err == nil {
+			buildOptions.DistributionDigest = digest
+		}
+	}
+	buildLog, err := filestore.CreateProjectBuildLog(name)
+	if err != nil {
+		console.Warn("Could not create the build log of project '%s': %s", name, err)
+	} else {
+		buildOptions.Log = buildLog
+	}
+	previousImage, err := saveImageGeneration(ctx, project, containerEngine)
+	if err != nil {
+		console.Warn("Could not keep the current image of project '%s' for rollbacks: %s", name, err)
+	}
+	endSection := func(error) {}
+	if !buildOptions.Quiet {
+		// Collapsed on terminals, only its last lines being displayed if it
+		// fails
+		section := console.StartSection(fmt.Sprintf("Building project '%s'", name))
+		buildOptions.Stdout, buildOptions.Stderr = section, section
+		endSection = section.End
+	}
+	events.Emit(events.BuildStart, name, nil)
+	buildErr := containerEngine.BuildImage(ctx, project, buildOptions)
+	endSection(buildErr)
+	if buildLog != nil {
+		if err := buildLog.Close(); err != nil {
+			console.Warn("Could not write the build log of project '%s': %s", name, err)
+		}
+	}
+	// Once its build log is complete, for its timing to be recorded
+	events.Emit(events.BuildEnd, name, buildErr)
+	if buildErr != nil {
+		// The current image was left untouched, no need to keep a copy of it
+		if previousImage != nil {
+			if err := containerEngine.RemoveGeneration(ctx, *previousImage); err != nil {
+				console.Warn("Could not remove the copy of the current image of project '%s': %s", name, err)
+			}
+		}
+		if buildLog != nil {
+			console.WriteLn("Build log written to %s", filestore.GetProjectBuildLogPath(name))
+		}
+		if ctx.Err() == nil {
+			reportDir, err := writeBuildFailureReport(ctx, project, buildErr, containerEngine, filestore, time.Now())
+			if err != nil {
+				console.Warn("Could not write a report of the failed build: %s", err)
+			} else {
+				console.WriteLn("Build failure report written to %s\n"+
+					"Hint: Review it before attaching it to a bug report, redaction is a best effort", reportDir)
+			}
+		}
+		if filestore.HasPreviousGeneration(name) {
+			console.WriteLn("Hint: Its configuration files changed since the last successful build.\n"+
+				"You can restore them with 'paul-envs build --rollback %s'", name)
+		}
+		return nil, utils.WithCategory(buildErr, errBuildFailed)
+	}
+	if err := filestore.DiscardPreviousGeneration(name); err != nil {
+		console.Warn("Could not remove the previous configuration files of project '%s': %s", name, err)
+	}
+	recordProjectImage(ctx, project, containerEngine, engineInfo, engineInfoErr, filestore, console)
+	return previousImage, nil
+}
+
+// Record that the project got a new image from its current configuration
+// (built or pulled), pruning its previous images beyond those kept.
+func recordProjectImage(
+	ctx context.Context,
+	project files.ProjectEntry,
+	containerEngine engine.ContainerEngine,
+	engineInfo engine.EngineInfo,
+	engineInfoErr error,
+	filestore *files.FileStore,
+	console *console.Console,
+) {
+	name := project.ProjectName
+	pruneImageGenerations(ctx, project, filestore, containerEngine, console)
+	if _, err := containerEngine.SaveArchImage(ctx, name); err != nil {
+		console.Warn("Could not tag the image of project '%s' with its architecture: %s", name, err)
+	}
+	if engineInfoErr != nil {
+		console.Warn("Could not refresh 'project.buildinfo' file for project '%s': impossible to get container engine version: %s", name, engineInfoErr)
+	} else if err := filestore.RefreshBuildInfoFile(name, engineInfo.Name, engineInfo.Version); err != nil {
+		console.Warn("Could not refresh 'project.buildinfo' file for project '%s': %s", name, err)
+	}
+}
+
+func buildBaseImageOnly(
+	ctx context.Context,
+	selectedEngine engine.Selection,
+	options engine.BuildOptions,
+	filestore *files.FileStore,
+	console *console.Console,
+) error {
+	containerEngine, err := engine.NewSelected(ctx, console, selectedEngine)
+	if err != nil {
+		return err
+	}
+	if err := engine.EnsurePodmanMachine(ctx, containerEngine, engine.MachineNeeds{}, console); err != nil {
 		return err
 	}
+	if err = filestore.RefreshBaseFiles(); err != nil {
+		return fmt.Errorf("cannot build: Failed to refresh base build files: %w", err)
+	}
 	engineInfo, err := containerEngine.Info(ctx)
 	if err != nil {
-		console.Warn("Could not refresh 'project.buildinfo' file for this project: impossible to get container engine version: %s", err)
+		console.Warn("Could not obtain container engine information: %s", err)
+	}
+	if _, err := ensureBaseImageIsBuilt(ctx, containerEngine, engineInfo.Name, true, options, filestore, console); err != nil {
+		return err
+	}
+	reportProjectsOnOutdatedBase(engineInfo.Name, filestore, console)
+	return nil
+}
+
+// Build the shared base image if `force` is set, if it is missing, or if it was
+// built from another `Dockerfile.base` or distribution image.
+//
+// It is built from the digest its distribution image is pinned to, pinning it
+// to the digest its tag currently resolves to if it is not pinned yet.
+//
+// Returns `true` if it has been (re-)built.
+func ensureBaseImageIsBuilt(
+	ctx context.Context,
+	containerEngine engine.ContainerEngine,
+	engineName string,
+	force bool,
+	options engine.BuildOptions,
+	filestore *files.FileStore,
+	console *console.Console,
+) (bool, error) {
+	distributionImage := distributionImageOf(options)
+	digest, err := filestore.GetDistributionPin(distributionImage)
+	if err != nil {
+		console.Warn("Could not read the digest %s is pinned to, building from its tag: %s", distributionImage, err)
+	}
+	// Only the engine's own base image is tracked, others are rebuilt with
+	// `--base`
+	tracked := engineName != "" && options.Platform == ""
+	if !force {
+		hasBase, err := containerEngine.HasBaseImage(ctx, options.Platform)
+		if err != nil {
+			return false, fmt.Errorf("cannot check if the shared base image is built: %w", err)
+		}
+		outdated := false
+		if hasBase && tracked {
+			outdated, err = filestore.IsBaseImageOutdated(engineName, options.DistributionImage, digest)
+			if err != nil {
+				console.Warn("Could not check if the shared base image is up-to-date: %s", err)
+			}
+		}
+		if hasBase && !outdated {
+			return false, nil
+		}
+		if hasBase && engine.IsOffline() {
+			console.Warn("The shared base image is outdated, but cannot be rebuilt offline: building on it as is")
+			return false, nil
+		}
+	}
+	if engine.IsOffline() {
+		// Its distribution image and packages come from the network
+		return false, fmt.Errorf("%w: cannot build the shared base image, which needs network access\nHint: Build it once online with 'paul-envs build --base'", engine.ErrOffline)
+	}
+
+	baseOptions := options
+	if digest != "" {
+		baseOptions.DistributionImage = engine.PinnedImageReference(distributionImage, digest)
+	}
+	if options.DistributionImage != "" || digest != "" {
+		console.Info("Building the shared base image from %s...", baseOptions.DistributionImage)
 	} else {
-		err = filestore.RefreshBuildInfoFile(name, engineInfo.Name, engineInfo.Version)
+		console.Info("Building the shared base image...")
+	}
+	if err := containerEngine.BuildBaseImage(ctx, filestore.GetBaseFilesDir(), baseOptions); err != nil {
+		return false, utils.WithCategory(err, errBuildFailed)
+	}
+	if digest == "" && tracked {
+		digest = pinDistributionImage(ctx, containerEngine, distributionImage, filestore, console)
+	}
+	if tracked {
+		if err := filestore.RefreshBaseImageBuildInfo(engineName, options.DistributionImage, digest); err != nil {
+			console.Warn("Could not refresh the shared base image build information: %s", err)
+		}
+	}
+	console.Success("Built the shared base image")
+	return true, nil
+}
+
+// Returns the distribution image the shared base image is built from with the
+// given options.
+func distributionImageOf(options engine.BuildOptions) string {
+	if options.DistributionImage == "" {
+		return files.DefaultDistributionImage
+	}
+	return options.DistributionImage
+}
+
+// Pin the given distribution image to the digest it resolved to, as the shared
+// base image was just built from it, so later builds use that same image.
+//
+// Returns that digest, empty if it could not be pinned.
+func pinDistributionImage(
+	ctx context.Context,
+	containerEngine engine.ContainerEngine,
+	distributionImage string,
+	filestore *files.FileStore,
+	console *console.Console,
+) string {
+	digest, err := containerEngine.ImageDigest(ctx, distributionImage, false)
+	if err != nil {
+		console.Warn("Could not pin %s to its current digest: %s", distributionImage, err)
+		return ""
+	}
+	// e.g. built locally, it cannot be pulled by digest
+	if digest == "" {
+		return ""
+	}
+	if err := filestore.SetDistributionPin(distributionImage, digest); err != nil {
+		console.Warn("Could not pin %s to its current digest: %s", distributionImage, err)
+		return ""
+	}
+	console.Info("Pinned %s to %s, move it to a newer image with 'paul-envs update'.", distributionImage, digest)
+	return digest
+}
+
+// Tell which projects were built on a previous version of the shared base image
+// and thus should be rebuilt.
+func reportProjectsOnOutdatedBase(engineName string, filestore *files.FileStore, console *console.Console) {
+	entries, err := filestore.GetAllProjects()
+	if err != nil || engineName == "" {
+		return
+	}
+	outdated := []string{}
+	for _, entry := range entries {
+		bState, err := filestore.ReadBuildInfo(entry.ProjectName)
 		if err != nil {
-			console.Warn("Could not refresh 'project.buildinfo' file for this project: %s", err)
+			continue
+		}
+		needsRebuild, reason, err := filestore.NeedsRebuild(entry.ProjectName, engineName, bState)
+		if err == nil && needsRebuild && reason == files.RebuildBaseImageChanged {
+			outdated = append(outdated, entry.ProjectName)
 		}
 	}
-	console.Success("Built project '%s'", name)
-	return nil
+	if len(outdated) == 0 {
+		return
+	}
+	console.Warn("Those projects were built on the previous shared base image and should be rebuilt: %s", strings.Join(outdated, ", "))
+	console.WriteLn("Hint: Rebuild them all with 'paul-envs rebuild --stale', or use 'paul-envs run --auto-rebuild <project-name>'")
 }
 
+// Returns the project given in `args`, otherwise the one detected by
+// `detectProjectName`, otherwise asks for one.
 func getProjectName(args []string, filestore *files.FileStore, console *console.Console, action string) (string, error) {
 	if len(args) > 0 {
 		return args[0], nil
 	}
+	if name, err := detectProjectName(filestore, console); err != nil || name != "" {
+		return name, err
+	}
 	entries, err := filestore.GetAllProjects()
 	if err != nil {
 		return "", fmt.Errorf("could not list all projects: %w", err)
@@ -121,6 +545,30 @@ func getProjectName(args []string, filestore *files.FileStore, console *console.
 	return name, nil
 }
 
+// Returns the project selected through `PAULENV_PROJECT` (see `paul-envs
+// shellenv`), otherwise the one the current directory belongs to: named by a
+// `.paulenv` marker file or whose mounted directory contains it. Empty if
+// there is none.
+func detectProjectName(filestore *files.FileStore, console *console.Console) (string, error) {
+	if name := os.Getenv(projectEnvVar); name != "" {
+		return name, nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", nil
+	}
+	name, err := filestore.FindProjectOfDir(cwd)
+	if err != nil {
+		return "", fmt.Errorf("cannot find the project of the current directory: %w", err)
+	}
+	if name != "" {
+		// On the error output, not to mix with the shell code or ssh
+		// configuration of `shellenv` and `ssh-config`
+		console.Note("Using project '%s' of the current directory", name)
+	}
+	return name, nil
+}
+
 func ensureSharedCacheVolumeIsCreated(ctx context.Context, containerEngine engine.ContainerEngine) error {
 	volumes, err := containerEngine.ListVolumes(ctx)
 	if err != nil {
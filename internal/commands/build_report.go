@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/table"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Number of the project's last invocations put in a build failure report.
+const buildReportHistoryLength = 20
+
+// Write a report of the failed build of that project in its own directory,
+// with what is redacted from secrets and personal information like in a
+// support bundle. Returns the path of that directory.
+func writeBuildFailureReport(
+	ctx context.Context,
+	project files.ProjectEntry,
+	buildErr error,
+	containerEngine engine.ContainerEngine,
+	filestore *files.FileStore,
+	now time.Time,
+) (string, error) {
+	reportFiles := collectBuildReportFiles(ctx, project, buildErr, containerEngine, filestore, now)
+	homeDir, _ := os.UserHomeDir()
+	for i := range reportFiles {
+		reportFiles[i].Data = []byte(utils.RedactSecrets(string(reportFiles[i].Data), homeDir))
+	}
+	return filestore.WriteBuildReport(project.ProjectName, now, reportFiles)
+}
+
+// Gather the unredacted content of a build failure report, by path relative
+// to its directory.
+//
+// What cannot be obtained is described in the report instead.
+func collectBuildReportFiles(
+	ctx context.Context,
+	project files.ProjectEntry,
+	buildErr error,
+	containerEngine engine.ContainerEngine,
+	filestore *files.FileStore,
+	now time.Time,
+) []files.ArchiveFile {
+	readFile := func(path string) []byte {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Appendf(nil, "cannot read %s: %s\n", path, err)
+		}
+		return data
+	}
+
+	var dockerfile []byte
+	if path, err := engine.ProjectDockerfile(project); err != nil {
+		dockerfile = fmt.Appendf(nil, "cannot find the Dockerfile: %s\n", err)
+	} else {
+		dockerfile = readFile(path)
+	}
+	var compose []byte
+	if bundle, err := engine.ComposeBundle(project); err != nil {
+		compose = fmt.Appendf(nil, "cannot generate the compose file: %s\n", err)
+	} else {
+		compose = bundle.Files["compose.yaml"]
+	}
+
+	var system bytes.Buffer
+	system.Write(supportSystemInfo(ctx, []engine.ContainerEngine{containerEngine}, nil, now))
+	fmt.Fprintf(&system, "Build error: %s\n\n", buildErr)
+	system.Write(supportProjectEngineState(ctx, project.ProjectName, []engine.ContainerEngine{containerEngine}))
+
+	return []files.ArchiveFile{
+		{Name: "build.log", Data: readFile(filestore.GetProjectBuildLogPath(project.ProjectName))},
+		{Name: "Dockerfile", Data: dockerfile},
+		{Name: "compose.yaml", Data: compose},
+		{Name: "build.conf", Data: readFile(project.BuildConfigPath)},
+		{Name: "run.conf", Data: readFile(project.RuntimeConfigPath)},
+		{Name: "engine.txt", Data: system.Bytes()},
+		{Name: "history.txt", Data: buildReportHistory(project.ProjectName, filestore)},
+	}
+}
+
+// The last recorded invocations naming that project, with the container
+// engine calls they made.
+func buildReportHistory(projectName string, filestore *files.FileStore) []byte {
+	entries, err := filestore.ReadHistory()
+	if err != nil {
+		return fmt.Appendf(nil, "%s\n", err)
+	}
+	entries = slices.DeleteFunc(entries, func(entry files.HistoryEntry) bool {
+		return !slices.Contains(entry.Args, projectName)
+	})
+	if len(entries) > buildReportHistoryLength {
+		entries = entries[len(entries)-buildReportHistoryLength:]
+	}
+	if len(entries) == 0 {
+		return []byte("no recorded invocation\n")
+	}
+	var b bytes.Buffer
+	if err := table.Render(&b, []string{"WHEN", "COMMAND", "DURATION", "EXIT"}, historyRows(entries, true), table.Options{Wide: true}); err != nil {
+		return fmt.Appendf(nil, "%s\n", err)
+	}
+	return b.Bytes()
+}
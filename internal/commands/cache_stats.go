@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Script displaying the statistics of both compiler caches in a container.
+const compilerCacheStatsScript = `echo "ccache (C/C++):"
+ccache --show-stats
+echo
+echo "sccache (Rust, since the container started):"
+sccache --show-stats`
+
+// Script resetting the statistics of both compiler caches in a container.
+const compilerCacheZeroScript = `ccache --zero-stats && sccache --zero-stats`
+
+func CacheStats(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	var zero bool
+	flagset := newCommandFlagSet("cache-stats", console)
+	flagset.BoolVar(&zero, "zero", false, "Reset the statistics instead of displaying them")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs cache-stats [flags] [project-name]",
+			"Show the hit statistics of the compiler caches of a project enabling ENABLE_COMPILER_CACHE in its build.conf: ccache's for C/C++, shared by all such projects, and sccache's for Rust, since its container started. If the container is not running, it is started then stopped on exit.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	name, err := getProjectName(flagset.Args(), filestore, console, "show the compiler cache statistics of")
+	if err != nil {
+		return err
+	}
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+	if err := ensureProjectCompatible(name, filestore, console); err != nil {
+		return fmt.Errorf("cannot show the compiler cache statistics of project '%s': %w", name, err)
+	}
+	project, err := filestore.GetProject(name)
+	if err != nil {
+		return fmt.Errorf("failed to obtain information on project '%s': %w", name, err)
+	}
+	buildCfg, err := config.LoadBuildConfig(project.BuildConfigPath)
+	if err != nil {
+		return err
+	}
+	if buildCfg.Args["ENABLE_COMPILER_CACHE"] != "true" {
+		return fmt.Errorf("project '%s' has no compiler cache\nHint: Set ENABLE_COMPILER_CACHE to 'true' in its build.conf then rebuild it", name)
+	}
+
+	containerEngine, _, err := newProjectEngine(ctx, name, engine.SelectionAuto, filestore, console)
+	if err != nil {
+		return err
+	}
+	container, err := findRunningProjectContainer(ctx, containerEngine, name)
+	if err != nil {
+		return err
+	}
+	if container == nil {
+		started, err := startTemporaryContainer(ctx, project, containerEngine, filestore, console, "to read its compiler caches")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := containerEngine.StopContainer(context.WithoutCancel(ctx), started); err != nil {
+				console.Warn("Could not stop the container of project '%s': %s", name, err)
+			}
+		}()
+		container = &started
+	}
+
+	script := compilerCacheStatsScript
+	if zero {
+		script = compilerCacheZeroScript
+	}
+	err = containerEngine.ExecContainer(ctx, *container, []string{"sh", "-c", script}, engine.ExecOptions{
+		User:  buildCfg.Args["USERNAME"],
+		NoTTY: true,
+	})
+	if err != nil {
+		return err
+	}
+	if zero {
+		console.Success("Reset the compiler cache statistics of project '%s'", name)
+	}
+	return nil
+}
@@ -105,8 +105,8 @@ func Clean(ctx context.Context, args []string, filestore *files.FileStore, conso
 	}
 
 	if cleanOpts.buildCache {
-		buildCacheEngines := enginesSupportingBuildCachePrune(containerEngines)
-		unsupportedBuildCacheEngines := enginesWithoutBuildCachePrune(containerEngines)
+		buildCacheEngines := enginesSupportingBuildCachePrune(ctx, containerEngines)
+		unsupportedBuildCacheEngines := enginesWithoutBuildCachePrune(ctx, containerEngines)
 		if len(buildCacheEngines) == 0 {
 			console.Info("\n4. Builder cache")
 			console.WriteLn("Scoped builder-cache pruning is not supported for: %s.", strings.Join(engineNames(unsupportedBuildCacheEngines), ", "))
@@ -167,7 +167,10 @@ func parseCleanEngineSelection(value string) (engine.Selection, error) {
 	case string(engine.SelectionAll):
 		return engine.SelectionAll, nil
 	default:
-		return "", fmt.Errorf("invalid --engine value %q. Must be one of: docker, podman, all", value)
+		if engine.IsPlugin(value) {
+			return engine.Selection(value), nil
+		}
+		return "", fmt.Errorf("invalid --engine value %q. Must be one of: docker, podman, all or the name of an engine plugin", value)
 	}
 }
 
@@ -222,6 +225,16 @@ func removeContainers(ctx context.Context, containerEngine engine.ContainerEngin
 	if err != nil {
 		return fmt.Errorf("cannot list current containers: %w", err)
 	}
+	sidecars, err := containerEngine.ListSidecars(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot list services: %w", err)
+	}
+	for _, sidecar := range sidecars {
+		console.WriteLn("  • Removing container: %s", sidecar.ContainerName)
+		if err := containerEngine.RemoveSidecar(ctx, sidecar); err != nil {
+			console.Warn("    WARNING: failed to remove container: %v", err)
+		}
+	}
 	for _, container := range containers {
 		if container.ContainerName == nil {
 			console.WriteLn("  • Removing unknown container")
@@ -281,20 +294,20 @@ func removeNetworks(ctx context.Context, containerEngine engine.ContainerEngine,
 	return nil
 }
 
-func enginesSupportingBuildCachePrune(containerEngines []engine.ContainerEngine) []engine.ContainerEngine {
+func enginesSupportingBuildCachePrune(ctx context.Context, containerEngines []engine.ContainerEngine) []engine.ContainerEngine {
 	result := make([]engine.ContainerEngine, 0, len(containerEngines))
 	for _, containerEngine := range containerEngines {
-		if containerEngine.SupportsBuildCachePrune() {
+		if containerEngine.Capabilities(ctx).BuildCachePrune {
 			result = append(result, containerEngine)
 		}
 	}
 	return result
 }
 
-func enginesWithoutBuildCachePrune(containerEngines []engine.ContainerEngine) []engine.ContainerEngine {
+func enginesWithoutBuildCachePrune(ctx context.Context, containerEngines []engine.ContainerEngine) []engine.ContainerEngine {
 	result := make([]engine.ContainerEngine, 0, len(containerEngines))
 	for _, containerEngine := range containerEngines {
-		if !containerEngine.SupportsBuildCachePrune() {
+		if !containerEngine.Capabilities(ctx).BuildCachePrune {
 			result = append(result, containerEngine)
 		}
 	}
@@ -317,10 +330,13 @@ func writeEngineSection(console *console.Console, containerEngine engine.Contain
 }
 
 func cleanEngineName(containerEngine engine.ContainerEngine) string {
-	switch containerEngine.(type) {
+	switch e := containerEngine.(type) {
 	case *engine.DockerEngine:
 		return "docker"
 	case *engine.PodmanEngine:
+		if e.IsRootful() {
+			return "podman-rootful"
+		}
 		return "podman"
 	default:
 		return "unknown"
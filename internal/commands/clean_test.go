@@ -57,17 +57,18 @@ func TestParseCleanEngineSelection(t *testing.T) {
 }
 
 func TestEnginesSupportingBuildCachePrune(t *testing.T) {
-	engines := []engine.ContainerEngine{
-		&engine.PodmanEngine{},
-		&engine.DockerEngine{},
-	}
+	supporting := &engine.FakeEngine{Supported: engine.Capabilities{BuildCachePrune: true}}
+	engines := []engine.ContainerEngine{&engine.FakeEngine{}, supporting}
 
-	got := enginesSupportingBuildCachePrune(engines)
+	got := enginesSupportingBuildCachePrune(context.Background(), engines)
 	if len(got) != 1 {
 		t.Fatalf("enginesSupportingBuildCachePrune() returned %d engines, want 1", len(got))
 	}
-	if _, ok := got[0].(*engine.DockerEngine); !ok {
-		t.Fatalf("enginesSupportingBuildCachePrune() kept %T, want DockerEngine", got[0])
+	if got[0] != supporting {
+		t.Fatalf("enginesSupportingBuildCachePrune() kept the engine without scoped pruning")
+	}
+	if got := enginesWithoutBuildCachePrune(context.Background(), engines); len(got) != 1 || got[0] == supporting {
+		t.Fatalf("enginesWithoutBuildCachePrune() = %v, want the engine without scoped pruning", got)
 	}
 }
 
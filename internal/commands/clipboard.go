@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/logging"
+)
+
+// Size above which copies from the container are refused.
+const maxClipboardInput = 64 << 20
+
+// Serves the host's clipboard to the container: `GET` returns its content and
+// `POST` replaces it by the request's body. `?selection=primary` targets the
+// primary selection instead, where the host has one.
+func (h *hostCommandHandler) serveClipboard(w http.ResponseWriter, r *http.Request) {
+	if !h.clipboard {
+		http.Error(w, fmt.Sprintf("paulenv-clipboard: the host's clipboard is not shared with project '%s'\nHint: Share it with 'CLIPBOARD true' in its run.conf\n",
+			h.projectName), http.StatusForbidden)
+		return
+	}
+	primary := r.URL.Query().Get("selection") == "primary"
+	switch r.Method {
+	case http.MethodGet:
+		content, err := readHostClipboard(primary)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("paulenv-clipboard: %s\n", err), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(content)
+	case http.MethodPost:
+		content, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxClipboardInput))
+		if err != nil {
+			http.Error(w, "paulenv-clipboard: cannot read the copied content\n", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err := writeHostClipboard(primary, content); err != nil {
+			http.Error(w, fmt.Sprintf("paulenv-clipboard: %s\n", err), http.StatusInternalServerError)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "paulenv-clipboard: invalid request", http.StatusMethodNotAllowed)
+	}
+}
+
+// Commands reading and writing one of the host's clipboards.
+type hostClipboardTool struct {
+	copy  []string
+	paste []string
+}
+
+// Returns the commands giving access to the host's clipboard, or its primary
+// selection, depending on its display server.
+func detectHostClipboardTool(primary bool) (hostClipboardTool, error) {
+	if runtime.GOOS == "darwin" {
+		// There's no primary selection on macOS
+		return hostClipboardTool{copy: []string{"pbcopy"}, paste: []string{"pbpaste"}}, nil
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" && hasHostCommand("wl-copy") && hasHostCommand("wl-paste") {
+		tool := hostClipboardTool{copy: []string{"wl-copy"}, paste: []string{"wl-paste", "--no-newline"}}
+		if primary {
+			tool.copy = append(tool.copy, "--primary")
+			tool.paste = append(tool.paste, "--primary")
+		}
+		return tool, nil
+	}
+	if os.Getenv("DISPLAY") != "" {
+		selection := "clipboard"
+		if primary {
+			selection = "primary"
+		}
+		if hasHostCommand("xclip") {
+			return hostClipboardTool{
+				copy:  []string{"xclip", "-selection", selection, "-in"},
+				paste: []string{"xclip", "-selection", selection, "-out"},
+			}, nil
+		}
+		if hasHostCommand("xsel") {
+			return hostClipboardTool{
+				copy:  []string{"xsel", "--" + selection, "--input"},
+				paste: []string{"xsel", "--" + selection, "--output"},
+			}, nil
+		}
+	}
+	return hostClipboardTool{}, errors.New("no clipboard found on the host, install wl-clipboard (Wayland), xclip or xsel (X11)")
+}
+
+func hasHostCommand(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+func readHostClipboard(primary bool) ([]byte, error) {
+	tool, err := detectHostClipboardTool(primary)
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(tool.paste[0], tool.paste[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		logging.Log().Debug("reading the host's clipboard failed", "command", tool.paste, "error", err)
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("cannot read the host's clipboard with %s: %s", tool.paste[0], message)
+		}
+		return nil, fmt.Errorf("cannot read the host's clipboard with %s: %w", tool.paste[0], err)
+	}
+	return stdout.Bytes(), nil
+}
+
+func writeHostClipboard(primary bool, content []byte) error {
+	tool, err := detectHostClipboardTool(primary)
+	if err != nil {
+		return err
+	}
+	// No output is read: `wl-copy` and `xclip` stay in the background to serve
+	// what they copied, keeping it open.
+	cmd := exec.Command(tool.copy[0], tool.copy[1:]...)
+	cmd.Stdin = bytes.NewReader(content)
+	if err := cmd.Run(); err != nil {
+		logging.Log().Debug("writing the host's clipboard failed", "command", tool.copy, "error", err)
+		return fmt.Errorf("cannot write the host's clipboard with %s: %w", tool.copy[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/events"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Clone(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var withVolumes bool
+	var engineSelection string
+	flagset := newCommandFlagSet("clone", console)
+	flagset.BoolVar(&withVolumes, "with-volumes", false, "Also copy the content of the project's ~/.container-local volume")
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine whose volume is copied with --with-volumes: docker or podman.\nDefault: the last build engine for the project, otherwise auto-select.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs clone [flags] <project-name> <new-name>",
+			"Create a new project with the same definition as an existing one: its build.conf, run.conf, README and dotfiles are copied as they are, e.g. to try a change of configuration without touching the original.\n\nThe new project is not built, its images are not shared with the original.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) != 2 {
+		return utils.WithCategory(errors.New("expected the names of the project to clone and of the new project"), errUsage)
+	}
+	from, to := args[0], args[1]
+	if err := validateProjectName(from); err != nil {
+		return err
+	}
+	if err := validateProjectName(to); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(from) {
+		return projectNotFoundError(from)
+	}
+	if from == to {
+		return utils.WithCategory(errors.New("the new name is the name of the cloned project"), errUsage)
+	}
+	requestedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+
+	unlockRegistry, err := lockRegistry(ctx, filestore, console)
+	if err != nil {
+		return err
+	}
+	defer unlockRegistry()
+	unlockProject, err := lockProject(ctx, from, filestore, console)
+	if err != nil {
+		return err
+	}
+	defer unlockProject()
+	if filestore.DoesProjectExist(to) {
+		return fmt.Errorf("project '%s' already exists", to)
+	}
+	if err := ensureProjectCompatible(from, filestore, console); err != nil {
+		return err
+	}
+
+	var containerEngine engine.ContainerEngine
+	if withVolumes {
+		if containerEngine, _, err = newProjectEngine(ctx, from, requestedEngine, filestore, console); err != nil {
+			return err
+		}
+		if err := ensureNoNameCollisions(ctx, to, containerEngine, console); err != nil {
+			return err
+		}
+	}
+
+	if err := filestore.CloneProject(ctx, from, to); err != nil {
+		return fmt.Errorf("cannot clone project '%s': %w", from, err)
+	}
+	if withVolumes {
+		console.Info("Copying the volume of project '%s'...", from)
+		copied, err := engine.CopyProjectLocalVolume(ctx, containerEngine, from, to)
+		if err != nil {
+			// Not left half-created
+			if rmErr := filestore.DeleteProjectDirectory(to); rmErr != nil {
+				console.Warn("Could not remove the files of project '%s': %s", to, rmErr)
+			}
+			return fmt.Errorf("cannot clone project '%s': %w", from, err)
+		} else if !copied {
+			console.Warn("Project '%s' has no volume yet, there was nothing to copy", from)
+		}
+	}
+	events.Emit(events.ProjectCreated, to, nil)
+	console.Success("Cloned project '%s' to '%s'", from, to)
+	console.WriteLn("Hint: Build it with 'paul-envs build %s'", to)
+	return nil
+}
@@ -0,0 +1,170 @@
+package commands
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func Code(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var printOnly bool
+	var engineSelection string
+	flagset := newCommandFlagSet("code", console)
+	flagset.BoolVar(&printOnly, "print", false, "Only print the URI opening the container in VS Code instead of launching it")
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to use: docker or podman.\nDefault: the one used to build the project.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs code [project-name] [flags]",
+			"Open a project's container in VS Code, through its Dev Containers extension. If the container is not running yet, it is started in the background and keeps running until stopped.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+
+	name, err := getProjectName(args, filestore, console, "open in VS Code")
+	if err != nil {
+		return err
+	}
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+	if err := ensureProjectCompatible(name, filestore, console); err != nil {
+		return fmt.Errorf("cannot open project '%s': %w", name, err)
+	}
+	project, err := filestore.GetProject(name)
+	if err != nil {
+		return fmt.Errorf("failed to obtain information on project '%s': %w", name, err)
+	}
+
+	requestedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+	containerEngine, _, err := newProjectEngine(ctx, name, requestedEngine, filestore, console)
+	if err != nil {
+		return err
+	}
+	container, err := ensureProjectContainerRunning(ctx, project, containerEngine, filestore, console)
+	if err != nil {
+		return err
+	}
+
+	workDir, err := engine.ProjectWorkDir(project)
+	if err != nil {
+		return err
+	}
+	uri := vscodeAttachURI(container.ContainerId, workDir)
+	if printOnly {
+		console.WriteLn("%s", uri)
+		return nil
+	}
+
+	codePath, err := exec.LookPath("code")
+	if err != nil {
+		return fmt.Errorf("VS Code's 'code' command not found\n"+
+			"Hint: Use 'paul-envs code --print %s' to obtain the URI to open instead", name)
+	}
+	cmd := exec.CommandContext(ctx, codePath, "--folder-uri", uri)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to launch VS Code: %w", err)
+	}
+	console.Success("Opened project '%s' in VS Code", name)
+	if _, ok := containerEngine.(*engine.PodmanEngine); ok {
+		console.WriteLn("Hint: VS Code's 'dev.containers.dockerPath' setting has to be set to 'podman' for it to find the container")
+	}
+	return nil
+}
+
+// Returns the running container of the given project, starting it in the
+// background with its services if it is not running yet.
+func ensureProjectContainerRunning(
+	ctx context.Context,
+	project files.ProjectEntry,
+	containerEngine engine.ContainerEngine,
+	filestore *files.FileStore,
+	console *console.Console,
+) (engine.ContainerInfo, error) {
+	name := project.ProjectName
+	hasBeenBuilt, err := containerEngine.HasBeenBuilt(ctx, name)
+	if err != nil {
+		return engine.ContainerInfo{}, fmt.Errorf("failed to get the status of the '%s' project: %w", name, err)
+	}
+	if !hasBeenBuilt {
+		return engine.ContainerInfo{}, fmt.Errorf("project '%s' has not been built yet\nHint: Use 'paul-envs build %s' first", name, name)
+	}
+
+	container, err := findRunningProjectContainer(ctx, containerEngine, name)
+	if err != nil {
+		return engine.ContainerInfo{}, err
+	}
+	if container != nil {
+		return *container, nil
+	}
+	if err := ensureNoNameCollisions(ctx, name, containerEngine, console); err != nil {
+		return engine.ContainerInfo{}, err
+	}
+	runtimeCfg, err := config.LoadRuntimeConfig(project.RuntimeConfigPath)
+	if err != nil {
+		return engine.ContainerInfo{}, fmt.Errorf("cannot start project '%s': %w", name, err)
+	}
+	if err := prepareProjectRuntimeFiles(project, runtimeCfg, filestore); err != nil {
+		return engine.ContainerInfo{}, err
+	}
+	if err := startProjectSidecars(ctx, project, runtimeCfg, nil, containerEngine, console); err != nil {
+		return engine.ContainerInfo{}, err
+	}
+	console.Info("Starting the container of project '%s' in the background...", name)
+	return startDetachedContainer(ctx, project, containerEngine, console)
+}
+
+// Returns the running container of the given project, `nil` if there's none.
+// Its default instance is preferred over the others (see `run --instance`).
+func findRunningProjectContainer(
+	ctx context.Context,
+	containerEngine engine.ContainerEngine,
+	projectName string,
+) (*engine.ContainerInfo, error) {
+	containers, err := containerEngine.ListContainers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list containers: %w", err)
+	}
+	var found *engine.ContainerInfo
+	for _, container := range containers {
+		if container.Running && container.ProjectName != nil && *container.ProjectName == projectName {
+			if container.Instance == "" {
+				return &container, nil
+			}
+			if found == nil {
+				found = &container
+			}
+		}
+	}
+	return found, nil
+}
+
+// URI opening the given directory of a running container in VS Code through
+// its Dev Containers extension.
+func vscodeAttachURI(containerID string, dir string) string {
+	return "vscode-remote://attached-container+" + hex.EncodeToString([]byte(containerID)) + dir
+}
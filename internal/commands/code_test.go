@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/engine"
+)
+
+func TestVSCodeAttachURI(t *testing.T) {
+	got := vscodeAttachURI("4f2a", "/home/dev/projects/app")
+	want := "vscode-remote://attached-container+34663261/home/dev/projects/app"
+	if got != want {
+		t.Fatalf("vscodeAttachURI() = %q, want %q", got, want)
+	}
+}
+
+func TestFindRunningProjectContainer(t *testing.T) {
+	app, other := "app", "other"
+	stub := &engine.FakeEngine{Containers: []engine.ContainerInfo{
+		{ProjectName: &other, ContainerId: "1", Running: true},
+		{ProjectName: &app, ContainerId: "2", Running: false},
+		{ProjectName: &app, ContainerId: "3", Running: true},
+	}}
+	got, err := findRunningProjectContainer(context.Background(), stub, "app")
+	if err != nil || got == nil || got.ContainerId != "3" {
+		t.Fatalf("findRunningProjectContainer() = %+v, %v, want container 3", got, err)
+	}
+	stub.Containers = []engine.ContainerInfo{
+		{ProjectName: &app, ContainerId: "1", Instance: "tests", Running: true},
+		{ProjectName: &app, ContainerId: "2", Running: true},
+	}
+	got, err = findRunningProjectContainer(context.Background(), stub, "app")
+	if err != nil || got == nil || got.ContainerId != "2" {
+		t.Fatalf("findRunningProjectContainer() = %+v, %v, want default instance 2", got, err)
+	}
+	got, err = findRunningProjectContainer(context.Background(), stub, "missing")
+	if err != nil || got != nil {
+		t.Fatalf("findRunningProjectContainer() = %+v, %v, want nil, nil", got, err)
+	}
+}
@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Config(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	flagset := newCommandFlagSet("config", console)
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs config <list|get|set|unset> [setting] [value] [flags]",
+			"Manage the global configuration, setting defaults for all projects: the preferred container engine, the distribution image of the shared base image, the shell of new projects, the dotfiles template location, the parallelism and the secret backends. 'list' shows every setting, 'get' prints the value of one, 'set' changes it and 'unset' restores its default.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) == 0 {
+		return utils.WithCategory(errors.New("expected a subcommand: list, get, set or unset"), errUsage)
+	}
+
+	switch args[0] {
+	case "list", "ls":
+		if len(args) > 1 {
+			return utils.WithCategory(errors.New("'config list' does not take arguments"), errUsage)
+		}
+		globalConfig, err := filestore.LoadGlobalConfig()
+		if err != nil {
+			return err
+		}
+		console.WriteLn("Global configuration: %s", filestore.GetGlobalConfigPath())
+		for _, setting := range config.GlobalSettings {
+			value := globalConfig.Get(setting.Key)
+			if value == "" {
+				value = "(not set)"
+			}
+			console.WriteLn("")
+			console.Info("%s: %s", setting.Key, value)
+			console.WriteLn("  %s", setting.Description)
+		}
+		return nil
+	case "get":
+		if len(args) != 2 {
+			return utils.WithCategory(errors.New("'config get' takes exactly one setting name"), errUsage)
+		}
+		setting, err := config.FindGlobalSetting(args[1])
+		if err != nil {
+			return utils.WithCategory(err, errUsage)
+		}
+		globalConfig, err := filestore.LoadGlobalConfig()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(console.Writer(), globalConfig.Get(setting.Key))
+		return err
+	case "set":
+		if len(args) < 3 {
+			return utils.WithCategory(errors.New("'config set' takes a setting name and its value"), errUsage)
+		}
+		setting, err := config.FindGlobalSetting(args[1])
+		if err != nil {
+			return utils.WithCategory(err, errUsage)
+		}
+		value := strings.Join(args[2:], " ")
+		if (setting.Key == "DOTFILES" || setting.Key == "AGE_IDENTITY") && value != "~" && !strings.HasPrefix(value, "~/") {
+			if value, err = filepath.Abs(value); err != nil {
+				return fmt.Errorf("invalid %s path: %w", setting.Key, err)
+			}
+		}
+		if err := setting.Validate(value); err != nil {
+			return utils.WithCategory(err, errUsage)
+		}
+		if err := updateGlobalSetting(filestore, setting.Key, value); err != nil {
+			return err
+		}
+		console.Success("Set %s to %s", setting.Key, value)
+		if setting.Key == "BASE_IMAGE" {
+			console.WriteLn("Hint: The shared base image will be rebuilt from it on the next build, after which projects should be rebuilt too")
+		}
+		return nil
+	case "unset", "rm":
+		if len(args) != 2 {
+			return utils.WithCategory(errors.New("'config unset' takes exactly one setting name"), errUsage)
+		}
+		setting, err := config.FindGlobalSetting(args[1])
+		if err != nil {
+			return utils.WithCategory(err, errUsage)
+		}
+		if err := updateGlobalSetting(filestore, setting.Key, ""); err != nil {
+			return err
+		}
+		console.Success("Restored the default %s", setting.Key)
+		return nil
+	default:
+		return utils.WithCategory(fmt.Errorf("invalid config subcommand %q: expected list, get, set or unset", args[0]), errUsage)
+	}
+}
+
+// Set a directive of the global configuration file, removing it if `value`
+// is empty.
+func updateGlobalSetting(filestore *files.FileStore, key string, value string) error {
+	content, err := filestore.ReadGlobalConfig()
+	if err != nil {
+		return err
+	}
+	return filestore.WriteGlobalConfig(config.SetGlobalSetting(content, key, value))
+}
@@ -0,0 +1,155 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"path"
+	"runtime"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Cp(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	flagset := newCommandFlagSet("cp", console)
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs cp [flags] <source> <destination>",
+			"Copy a file or directory between the host and a project's container, one of both paths being written <project-name>:<path>. Paths in the container are relative to the project's working directory. If the container is not running, it is started for the copy then stopped, so only its persisted volumes and mounts keep what is copied into it.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) != 2 {
+		return utils.WithCategory(errors.New("expected a source and a destination, one of them written <project-name>:<path>"), errUsage)
+	}
+	srcProject, srcPath, fromContainer := parseCopyOperand(args[0])
+	dstProject, dstPath, toContainer := parseCopyOperand(args[1])
+	if fromContainer == toContainer {
+		return utils.WithCategory(errors.New("exactly one of the source and the destination must be written <project-name>:<path>"), errUsage)
+	}
+	name, containerPath := dstProject, dstPath
+	if fromContainer {
+		name, containerPath = srcProject, srcPath
+	}
+
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+	if err := ensureProjectCompatible(name, filestore, console); err != nil {
+		return fmt.Errorf("cannot copy with project '%s': %w", name, err)
+	}
+	project, err := filestore.GetProject(name)
+	if err != nil {
+		return fmt.Errorf("failed to obtain information on project '%s': %w", name, err)
+	}
+	if !path.IsAbs(containerPath) {
+		workDir, err := engine.ProjectWorkDir(project)
+		if err != nil {
+			return err
+		}
+		containerPath = path.Join(workDir, containerPath)
+	}
+
+	containerEngine, _, err := newProjectEngine(ctx, name, engine.SelectionAuto, filestore, console)
+	if err != nil {
+		return err
+	}
+	container, err := findRunningProjectContainer(ctx, containerEngine, name)
+	if err != nil {
+		return err
+	}
+	if container == nil {
+		started, err := startTemporaryContainer(ctx, project, containerEngine, filestore, console, "for the copy")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := containerEngine.StopContainer(context.WithoutCancel(ctx), started); err != nil {
+				console.Warn("Could not stop the container of project '%s': %s", name, err)
+			}
+		}()
+		container = &started
+	}
+
+	if fromContainer {
+		if err := containerEngine.CopyFrom(ctx, *container, containerPath, dstPath); err != nil {
+			return err
+		}
+		console.Success("Copied %s from project '%s' to %s", containerPath, name, dstPath)
+		return nil
+	}
+	if err := containerEngine.CopyTo(ctx, *container, srcPath, containerPath); err != nil {
+		return err
+	}
+	console.Success("Copied %s to %s in project '%s'", srcPath, containerPath, name)
+	return nil
+}
+
+// Parse an operand of `cp`, returning the project and path of
+// "<project-name>:<path>" ones and `false` for host paths.
+func parseCopyOperand(operand string) (string, string, bool) {
+	name, containerPath, found := strings.Cut(operand, ":")
+	if !found || name == "" || strings.ContainsAny(name, `/\`) {
+		return "", operand, false
+	}
+	// Drive letter of a Windows path
+	if runtime.GOOS == "windows" && len(name) == 1 {
+		return "", operand, false
+	}
+	return name, containerPath, true
+}
+
+// Start the container of a project in the background for the time of an
+// operation, described by `purpose` (e.g. "for the copy").
+func startTemporaryContainer(
+	ctx context.Context,
+	project files.ProjectEntry,
+	containerEngine engine.ContainerEngine,
+	filestore *files.FileStore,
+	console *console.Console,
+	purpose string,
+) (engine.ContainerInfo, error) {
+	name := project.ProjectName
+	hasBeenBuilt, err := containerEngine.HasBeenBuilt(ctx, name)
+	if err != nil {
+		return engine.ContainerInfo{}, fmt.Errorf("failed to get the status of the '%s' project: %w", name, err)
+	}
+	if !hasBeenBuilt {
+		return engine.ContainerInfo{}, fmt.Errorf("project '%s' has not been built yet\nHint: Use 'paul-envs build %s' first", name, name)
+	}
+	if err := ensureNoNameCollisions(ctx, name, containerEngine, console); err != nil {
+		return engine.ContainerInfo{}, err
+	}
+	runtimeCfg, err := config.LoadRuntimeConfig(project.RuntimeConfigPath)
+	if err != nil {
+		return engine.ContainerInfo{}, fmt.Errorf("cannot start project '%s': %w", name, err)
+	}
+	if err := prepareProjectRuntimeFiles(project, runtimeCfg, filestore); err != nil {
+		return engine.ContainerInfo{}, err
+	}
+	console.Info("Starting the container of project '%s' %s...", name, purpose)
+	return startDetachedContainer(ctx, project, containerEngine, console)
+}
@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+)
+
+func TestParseCopyOperand(t *testing.T) {
+	tests := []struct {
+		operand     string
+		wantProject string
+		wantPath    string
+		wantOk      bool
+	}{
+		{"myapp:/tmp/out", "myapp", "/tmp/out", true},
+		{"myapp:dist", "myapp", "dist", true},
+		{"myapp:", "myapp", "", true},
+		{"./dist", "", "./dist", false},
+		{"./dir:with-colon", "", "./dir:with-colon", false},
+		{":/tmp", "", ":/tmp", false},
+	}
+	for _, tt := range tests {
+		project, path, ok := parseCopyOperand(tt.operand)
+		if project != tt.wantProject || path != tt.wantPath || ok != tt.wantOk {
+			t.Errorf("parseCopyOperand(%q) = %q, %q, %v, want %q, %q, %v",
+				tt.operand, project, path, ok, tt.wantProject, tt.wantPath, tt.wantOk)
+		}
+	}
+}
+
+func TestCpRequiresOneContainerOperand(t *testing.T) {
+	var out bytes.Buffer
+	cons := console.New(context.Background(), strings.NewReader(""), &out, &out)
+
+	for _, args := range [][]string{
+		{},
+		{"alpha:/tmp/file"},
+		{"./a", "./b"},
+		{"alpha:/a", "beta:/b"},
+		{"alpha:/a", "./b", "./c"},
+	} {
+		if err := Cp(context.Background(), args, nil, cons); ExitCode(err) != ExitUsage {
+			t.Errorf("Cp(%q) exit code = %d, want %d (error: %v)", args, ExitCode(err), ExitUsage, err)
+		}
+	}
+}
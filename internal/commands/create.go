@@ -11,6 +11,7 @@ import (
 	"github.com/peaberberian/paul-envs/internal/args"
 	"github.com/peaberberian/paul-envs/internal/config"
 	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/events"
 	"github.com/peaberberian/paul-envs/internal/files"
 	"github.com/peaberberian/paul-envs/internal/utils"
 )
@@ -23,9 +24,14 @@ func Create(argsList []string, filestore *files.FileStore, console *console.Cons
 
 	cfg, err := args.ParseAndPrompt(argsList, console, filestore)
 	if err != nil {
-		return err
+		return utils.WithCategory(err, errValidationFailed)
 	}
 
+	unlock, err := lockRegistry(context.Background(), filestore, console)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	if err := generateProjectFiles(&cfg, filestore); err != nil {
 		return err
 	}
@@ -34,6 +40,7 @@ func Create(argsList []string, filestore *files.FileStore, console *console.Cons
 			return fmt.Errorf("failed to seed project dotfiles: %w", err)
 		}
 	}
+	events.Emit(events.ProjectCreated, cfg.ProjectName, nil)
 	printNextSteps(&cfg, filestore, console)
 	return nil
 }
@@ -46,37 +53,45 @@ func generateProjectFiles(cfg *config.Config, filestore *files.FileStore) error
 	// TODO: Should those template definitions be moved to the `FileStore` code?
 	// It could only take the Config as argument
 	buildData := files.BuildTemplateData{
-		Version:           versions.BuildConfigVersion.ToString(),
-		HostUID:           utils.EscapeEnvValue(cfg.UID),
-		HostGID:           utils.EscapeEnvValue(cfg.GID),
-		Username:          utils.EscapeEnvValue(cfg.Username),
-		Shell:             string(cfg.Shell),
-		InstallNode:       utils.EscapeEnvValue(cfg.InstallNode),
-		InstallRust:       utils.EscapeEnvValue(cfg.InstallRust),
-		InstallPython:     utils.EscapeEnvValue(cfg.InstallPython),
-		InstallGo:         utils.EscapeEnvValue(cfg.InstallGo),
-		EnableWasm:        strconv.FormatBool(cfg.EnableWasm),
-		EnableSSH:         strconv.FormatBool(cfg.EnableSsh),
-		EnableSudo:        strconv.FormatBool(cfg.EnableSudo),
-		Packages:          utils.EscapeEnvValue(strings.Join(cfg.Packages, " ")),
-		InstallNeovim:     strconv.FormatBool(cfg.InstallNeovim),
-		InstallStarship:   strconv.FormatBool(cfg.InstallStarship),
-		InstallOhMyPosh:   strconv.FormatBool(cfg.InstallOhMyPosh),
-		InstallAtuin:      strconv.FormatBool(cfg.InstallAtuin),
-		InstallMise:       strconv.FormatBool(cfg.InstallMise),
-		InstallZellij:     strconv.FormatBool(cfg.InstallZellij),
-		InstallJujutsu:    strconv.FormatBool(cfg.InstallJujutsu),
-		InstallDelta:      strconv.FormatBool(cfg.InstallDelta),
-		InstallOpenCode:   strconv.FormatBool(cfg.InstallOpenCode),
-		InstallClaudeCode: strconv.FormatBool(cfg.InstallClaudeCode),
-		InstallCodex:      strconv.FormatBool(cfg.InstallCodex),
-		InstallFirefox:    strconv.FormatBool(cfg.InstallFirefox),
+		Version:                versions.BuildConfigVersion.ToString(),
+		HostUID:                utils.EscapeEnvValue(cfg.UID),
+		HostGID:                utils.EscapeEnvValue(cfg.GID),
+		Username:               utils.EscapeEnvValue(cfg.Username),
+		Shell:                  string(cfg.Shell),
+		InstallNode:            utils.EscapeEnvValue(cfg.InstallNode),
+		InstallRust:            utils.EscapeEnvValue(cfg.InstallRust),
+		InstallPython:          utils.EscapeEnvValue(cfg.InstallPython),
+		InstallGo:              utils.EscapeEnvValue(cfg.InstallGo),
+		EnableWasm:             strconv.FormatBool(cfg.EnableWasm),
+		EnableCompilerCache:    strconv.FormatBool(cfg.EnableCompilerCache),
+		EnableNestedContainers: strconv.FormatBool(cfg.EnableNestedContainers),
+		EnableSystemd:          strconv.FormatBool(cfg.EnableSystemd),
+		EnableSSH:              strconv.FormatBool(cfg.EnableSsh),
+		EnableSudo:             strconv.FormatBool(cfg.EnableSudo),
+		Packages:               utils.EscapeEnvValue(strings.Join(cfg.Packages, " ")),
+		PipxPackages:           utils.EscapeEnvValue(strings.Join(cfg.PipxPackages, " ")),
+		CargoPackages:          utils.EscapeEnvValue(strings.Join(cfg.CargoPackages, " ")),
+		NpmPackages:            utils.EscapeEnvValue(strings.Join(cfg.NpmPackages, " ")),
+		InstallNeovim:          strconv.FormatBool(cfg.InstallNeovim),
+		InstallStarship:        strconv.FormatBool(cfg.InstallStarship),
+		InstallOhMyPosh:        strconv.FormatBool(cfg.InstallOhMyPosh),
+		InstallAtuin:           strconv.FormatBool(cfg.InstallAtuin),
+		InstallMise:            strconv.FormatBool(cfg.InstallMise),
+		InstallZellij:          strconv.FormatBool(cfg.InstallZellij),
+		InstallJujutsu:         strconv.FormatBool(cfg.InstallJujutsu),
+		InstallDelta:           strconv.FormatBool(cfg.InstallDelta),
+		InstallOpenCode:        strconv.FormatBool(cfg.InstallOpenCode),
+		InstallClaudeCode:      strconv.FormatBool(cfg.InstallClaudeCode),
+		InstallCodex:           strconv.FormatBool(cfg.InstallCodex),
+		InstallFirefox:         strconv.FormatBool(cfg.InstallFirefox),
+		InstallCompletions:     strconv.FormatBool(cfg.InstallCompletions),
 	}
 
 	runtimeData := files.RuntimeTemplateData{
 		Version:         versions.RuntimeConfigVersion.ToString(),
 		ProjectHostPath: utils.EscapeEnvValue(cfg.ProjectHostPath),
 		DotfilesPath:    "dotfiles",
+		DotfilesProfile: cfg.DotfilesProfile,
 		GitName:         utils.EscapeEnvValue(cfg.GitName),
 		GitEmail:        utils.EscapeEnvValue(cfg.GitEmail),
 		Volumes:         cfg.Volumes,
@@ -110,8 +125,13 @@ func printNextSteps(cfg *config.Config, filestore *files.FileStore, console *con
 	// TODO: rely on just `GetProject` instead
 	console.WriteLn("     - %s", filestore.GetProjectBuildConfigPath(cfg.ProjectName))
 	console.WriteLn("     - %s", filestore.GetProjectRuntimeConfigPath(cfg.ProjectName))
-	console.WriteLn("  2. Optionally add project-specific dotfiles:")
-	console.WriteLn("     - %s", filestore.GetProjectDotfilesPath(cfg.ProjectName))
+	if cfg.DotfilesProfile != "" {
+		console.WriteLn("  2. Optionally edit the dotfiles of its '%s' profile, shared with other projects:", cfg.DotfilesProfile)
+		console.WriteLn("     - %s", filestore.GetDotfilesProfilePath(cfg.DotfilesProfile))
+	} else {
+		console.WriteLn("  2. Optionally add project-specific dotfiles:")
+		console.WriteLn("     - %s", filestore.GetProjectDotfilesPath(cfg.ProjectName))
+	}
 	console.WriteLn("     These are synced when the environment starts, so you can do this now or later.")
 	console.WriteLn("  3. Build the environment:")
 	console.WriteLn("     paul-envs build %s", cfg.ProjectName)
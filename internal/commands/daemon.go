@@ -0,0 +1,354 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/logging"
+	"github.com/peaberberian/paul-envs/internal/notify"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Daemon(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var once bool
+	var noNotify bool
+	var systemdUnit bool
+	var metricsAddress string
+	flagset := newCommandFlagSet("daemon", console)
+	flagset.BoolVar(&once, "once", false, "Run the tasks a single time then exit, e.g. from a cron job")
+	flagset.BoolVar(&noNotify, "no-notify", false, "Do not display desktop notifications of what the tasks did")
+	flagset.BoolVar(&systemdUnit, "systemd-unit", false, "Only output a systemd user service running the daemon")
+	flagset.StringVar(&metricsAddress, "metrics-address", "", "Serve Prometheus metrics of the projects on that address (e.g. 127.0.0.1:9464), under /metrics")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs daemon [flags]",
+			"Run maintenance tasks in the background, every DAEMON_INTERVAL (default: 1h) until interrupted: collecting the resources of deleted projects and old images ('gc'), checking whether the shared base image is behind its distribution image ('outdated') and stopping idle containers, services past their SERVICE_GRACE_PERIOD, as well as containers past their project's lease on shared hosts ('reap'). In between, containers dying unexpectedly are reported as they do ('crashes', not done with --once).\n\nThe DAEMON_TASKS global setting restricts which of them are run. What they did is written to the log file and displayed as desktop notifications.\n\nWith --metrics-address, metrics of the projects (running containers, image age, volume usage, build durations) are also served for Prometheus to scrape.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flagset.NArg() > 0 {
+		return utils.WithCategory(errors.New("daemon does not take arguments"), errUsage)
+	}
+	if once && metricsAddress != "" {
+		return utils.WithCategory(errors.New("--metrics-address cannot be used with --once"), errUsage)
+	}
+	if systemdUnit {
+		executable, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("cannot locate the paul-envs executable: %w", err)
+		}
+		_, err = fmt.Fprint(console.Writer(), daemonSystemdUnit(executable))
+		return err
+	}
+
+	d := daemon{
+		filestore:        filestore,
+		console:          console,
+		notify:           !noNotify,
+		once:             once,
+		failing:          map[string]bool{},
+		notifiedOutdated: map[string]string{},
+	}
+	if metricsAddress != "" {
+		if err := serveMetrics(ctx, metricsAddress, filestore, console); err != nil {
+			return err
+		}
+	}
+	for {
+		// Re-read at each round so changes apply without restarting it
+		globalConfig, err := filestore.LoadGlobalConfig()
+		if err != nil {
+			return err
+		}
+		// Tasks following the engines' events do so until the next round
+		roundCtx, endRound := context.WithCancel(ctx)
+		d.runTasks(roundCtx, globalConfig)
+		if once {
+			endRound()
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(globalConfig.DaemonRunInterval()):
+		}
+		endRound()
+		d.watchers.Wait()
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// State of `paul-envs daemon` kept between its rounds of tasks.
+type daemon struct {
+	filestore *files.FileStore
+	console   *console.Console
+	// If `true`, desktop notifications are displayed
+	notify bool
+	// If `true`, tasks are run a single time, without following events
+	once bool
+	// Goroutines following the engines' events for the current round
+	watchers sync.WaitGroup
+	// Tasks whose last run failed, whose failure was thus already notified
+	failing map[string]bool
+	// By engine, outdated projects last reported with the latest distribution
+	// image digest, not to report them again at each round
+	notifiedOutdated map[string]string
+}
+
+// Run once all tasks enabled by the global configuration.
+func (d *daemon) runTasks(ctx context.Context, globalConfig config.GlobalConfig) {
+	containerEngines, err := engine.NewSet(ctx, d.console, engine.SelectionAll)
+	if err != nil {
+		d.reportFailure(ctx, "engines", err)
+		return
+	}
+	d.failing["engines"] = false
+	for _, task := range globalConfig.DaemonTaskList() {
+		if ctx.Err() != nil {
+			return
+		}
+		var summary string
+		var err error
+		switch task {
+		case "gc":
+			summary, err = d.collectGarbage(ctx, containerEngines)
+		case "outdated":
+			summary, err = d.checkOutdated(ctx, containerEngines, globalConfig)
+		case "reap":
+			summary, err = d.reapIdle(ctx, containerEngines, globalConfig)
+		case "crashes":
+			if !d.once {
+				d.watchCrashes(ctx, containerEngines)
+			}
+		}
+		if err != nil {
+			d.reportFailure(ctx, task, err)
+			continue
+		}
+		d.failing[task] = false
+		logging.Log().Info("daemon task done", "task", task, "result", summary)
+		if summary != "" {
+			d.console.WriteLn("%s %s: %s", time.Now().Format(time.DateTime), task, summary)
+			d.sendNotification(ctx, summary)
+		}
+	}
+}
+
+// Returns a summary of what was removed, empty if nothing was.
+func (d *daemon) collectGarbage(ctx context.Context, containerEngines []engine.ContainerEngine) (string, error) {
+	unlock, err := lockRegistry(ctx, d.filestore, d.console)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	projects, retentions, sharedVolumes, err := loadGarbageOwners(d.filestore, d.console)
+	if err != nil {
+		return "", err
+	}
+	removed := 0
+	for _, containerEngine := range containerEngines {
+		resources, err := listEngineResources(ctx, containerEngine, engineQueryParallelism(d.filestore))
+		if err != nil {
+			return "", err
+		}
+		for _, candidate := range findGarbage(projects, retentions, sharedVolumes, resources, 0, time.Now()) {
+			if engine.IsDryRun() {
+				removed++
+			} else if err := candidate.remove(ctx, containerEngine); err != nil {
+				d.console.Warn("Failed to remove %s '%s': %v", candidate.kind, candidate.name, err)
+			} else {
+				removed++
+			}
+		}
+	}
+	if removed == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("removed %d unused resources", removed), nil
+}
+
+// Returns a summary of the projects behind the latest distribution image,
+// empty if none is or if they were already reported.
+func (d *daemon) checkOutdated(ctx context.Context, containerEngines []engine.ContainerEngine, globalConfig config.GlobalConfig) (string, error) {
+	distributionImage := distributionImageOf(engine.BuildOptions{DistributionImage: globalConfig.BaseImage})
+	var summaries []string
+	for _, containerEngine := range containerEngines {
+		engineInfo, err := containerEngine.Info(ctx)
+		if err != nil {
+			return "", fmt.Errorf("could not obtain container engine information: %w", err)
+		}
+		freshness, err := checkBaseImageFreshness(ctx, containerEngine, engineInfo.Name, distributionImage, d.filestore)
+		if err != nil {
+			return "", err
+		}
+		outdated, err := findOutdatedProjects(engineInfo.Name, freshness.behind(), d.filestore)
+		if err != nil {
+			return "", err
+		}
+		names := make([]string, 0, len(outdated))
+		for _, project := range outdated {
+			names = append(names, project.name)
+		}
+		reported := freshness.latestDigest + " " + strings.Join(names, ",")
+		if len(outdated) == 0 || d.notifiedOutdated[engineInfo.Name] == reported {
+			continue
+		}
+		d.notifiedOutdated[engineInfo.Name] = reported
+		summaries = append(summaries, fmt.Sprintf(
+			"%d %s project(s) behind the latest %s, rebuild them with 'paul-envs outdated --rebuild'",
+			len(outdated), engineInfo.Name, distributionImage,
+		))
+	}
+	return strings.Join(summaries, "; "), nil
+}
+
+// Returns a summary of the idle containers stopped, of the services stopped
+// past their grace period, and of the containers warned or stopped for going
+// past their project's lease, empty if none was.
+func (d *daemon) reapIdle(ctx context.Context, containerEngines []engine.ContainerEngine, globalConfig config.GlobalConfig) (string, error) {
+	policies, err := loadIdlePolicies(d.filestore, globalConfig.IdleTimeout, d.console)
+	if err != nil {
+		return "", err
+	}
+	sidecarDeadlines, err := loadSidecarDeadlines(d.filestore, d.console)
+	if err != nil {
+		return "", err
+	}
+	leases, err := loadLeases()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	stopped, stoppedSidecars, leaseWarned, leaseStopped := 0, 0, 0, 0
+	for _, containerEngine := range containerEngines {
+		if mayHaveIdleContainers(policies, now) {
+			containers, err := containerEngine.ListContainers(ctx)
+			if err != nil {
+				return "", fmt.Errorf("could not list containers: %w", err)
+			}
+			idleContainers := findIdleContainers(containers, policies, now)
+			if engine.IsDryRun() {
+				stopped += len(idleContainers)
+			} else {
+				stopped += stopIdleContainers(ctx, idleContainers, containerEngine, d.console)
+			}
+		}
+		projects, err := stopLingeringSidecars(ctx, sidecarDeadlines, now, engine.IsDryRun(), containerEngine, d.console)
+		if err != nil {
+			return "", err
+		}
+		stoppedSidecars += projects
+		warned, stoppedPastLease, err := enforceLeases(ctx, leases, containerEngine, engine.IsDryRun(), d.filestore, d.console)
+		if err != nil {
+			return "", err
+		}
+		leaseWarned += warned
+		leaseStopped += stoppedPastLease
+	}
+	var summaries []string
+	if stopped > 0 {
+		summaries = append(summaries, fmt.Sprintf("stopped %d idle container(s)", stopped))
+	}
+	if stoppedSidecars > 0 {
+		summaries = append(summaries, fmt.Sprintf("stopped the services of %d project(s) past their grace period", stoppedSidecars))
+	}
+	if leaseWarned > 0 {
+		summaries = append(summaries, fmt.Sprintf("warned %d container(s) nearing the end of their lease", leaseWarned))
+	}
+	if leaseStopped > 0 {
+		summaries = append(summaries, fmt.Sprintf("stopped %d container(s) past their lease", leaseStopped))
+	}
+	return strings.Join(summaries, ", "), nil
+}
+
+// Report, until `ctx` is done, the project containers dying unexpectedly.
+func (d *daemon) watchCrashes(ctx context.Context, containerEngines []engine.ContainerEngine) {
+	for _, containerEngine := range containerEngines {
+		d.watchers.Add(1)
+		go func() {
+			defer d.watchers.Done()
+			crashes := engine.NewCrashTracker()
+			err := containerEngine.StreamEvents(ctx, func(event engine.Event) {
+				if !crashes.IsCrash(event) {
+					return
+				}
+				summary := crashSummary(event)
+				logging.Log().Warn("container crashed", "container", event.Name, "project", event.ProjectName)
+				d.console.WriteLn("%s crashes: %s", event.Time.Local().Format(time.DateTime), summary)
+				d.sendNotification(ctx, summary)
+			})
+			if err != nil {
+				// Not notified, the engine being probably unreachable for
+				// other tasks too
+				logging.Log().Error("daemon task failed", "task", "crashes", "error", err)
+				d.console.Warn("%s crashes failed: %s", time.Now().Format(time.DateTime), err)
+			}
+		}()
+	}
+}
+
+// e.g. "container paulenv-demo of project 'demo' crashed (exit code 139)"
+func crashSummary(event engine.Event) string {
+	summary := "container " + event.Name
+	if event.ProjectName != "" {
+		summary += " of project '" + event.ProjectName + "'"
+	}
+	summary += " crashed"
+	if event.ExitCode != nil {
+		summary += fmt.Sprintf(" (exit code %d)", *event.ExitCode)
+	}
+	return summary
+}
+
+// Report a task which failed, notifying it only when it starts failing.
+func (d *daemon) reportFailure(ctx context.Context, task string, err error) {
+	logging.Log().Error("daemon task failed", "task", task, "error", err)
+	d.console.Warn("%s %s failed: %s", time.Now().Format(time.DateTime), task, err)
+	if !d.failing[task] {
+		d.failing[task] = true
+		d.sendNotification(ctx, fmt.Sprintf("%s failed: %s", task, err))
+	}
+}
+
+func (d *daemon) sendNotification(ctx context.Context, message string) {
+	if !d.notify {
+		return
+	}
+	if err := notify.Send(ctx, "paul-envs", message); err != nil {
+		logging.Log().Debug("notification not displayed", "error", err)
+	}
+}
+
+// Returns a systemd user service running `paul-envs daemon` with that
+// executable.
+func daemonSystemdUnit(executable string) string {
+	return fmt.Sprintf(`[Unit]
+Description=paul-envs maintenance daemon
+
+[Service]
+ExecStart="%s" daemon
+Restart=on-failure
+RestartSec=1min
+
+[Install]
+WantedBy=default.target
+`, executable)
+}
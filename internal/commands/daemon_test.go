@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/engine"
+)
+
+func TestDaemonSystemdUnit(t *testing.T) {
+	unit := daemonSystemdUnit("/home/me/bin/paul-envs")
+	for _, want := range []string{
+		`ExecStart="/home/me/bin/paul-envs" daemon`,
+		"WantedBy=default.target",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit does not contain %q:\n%s", want, unit)
+		}
+	}
+}
+
+func TestCrashSummary(t *testing.T) {
+	exitCode := 139
+	event := engine.Event{Type: "container", Action: "die", Name: "paulenv-demo", ProjectName: "demo", ExitCode: &exitCode}
+	if got, want := crashSummary(event), "container paulenv-demo of project 'demo' crashed (exit code 139)"; got != want {
+		t.Fatalf("crashSummary() = %q, want %q", got, want)
+	}
+	if got, want := crashSummary(engine.Event{Type: "container", Action: "die", Name: "paulenv-x"}), "container paulenv-x crashed"; got != want {
+		t.Fatalf("crashSummary() = %q, want %q", got, want)
+	}
+}
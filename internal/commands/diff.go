@@ -0,0 +1,181 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Diff(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var engineSelection string
+	flagset := newCommandFlagSet("diff", console)
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to query: docker or podman.\nDefault: the one the project was built with.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs diff [flags] <project-name>",
+			"Show what changed in a project's build.conf and run.conf since its image was last built (e.g. packages added, mounts changed), and whether a rebuild is actually needed: run.conf changes only apply on the next run, while build.conf changes or a new shared base image need one.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) != 1 {
+		return utils.WithCategory(errors.New("expected the name of the project to compare"), errUsage)
+	}
+	name := args[0]
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+	requestedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+	containerEngine, _, err := newProjectEngine(ctx, name, requestedEngine, filestore, console)
+	if err != nil {
+		return err
+	}
+	engineInfo, err := containerEngine.Info(ctx)
+	if err != nil {
+		return fmt.Errorf("could not obtain container engine information: %w", err)
+	}
+	status, reason, err := projectRebuildStatus(ctx, name, containerEngine, engineInfo.Name, filestore)
+	if err != nil {
+		console.Warn("Could not check if project '%s' is up-to-date: %s", name, err)
+	}
+	if status == rebuildNotBuilt {
+		console.Info("Project '%s' has never been built, there is nothing to compare to", name)
+		return nil
+	}
+
+	buildChanges, err := configFileChanges(filestore.GetProjectBuiltBuildConfigPath(name), filestore.GetProjectBuildConfigPath(name))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		console.Warn("The configuration project '%s' was built with is unknown, rebuild it to track its changes", name)
+		if imageInfo, err := containerEngine.GetImageInfo(ctx, name); err == nil && imageInfo.ConfigHash != "" {
+			if hash, err := utils.FileHash(filestore.GetProjectBuildConfigPath(name)); err == nil && hash != imageInfo.ConfigHash {
+				console.WriteLn("Its build.conf changed since its image was built")
+			}
+		}
+	case err != nil:
+		return fmt.Errorf("could not compare build.conf: %w", err)
+	default:
+		writeConfigChanges(console, "build.conf, needs a rebuild:", buildChanges)
+		runtimeChanges, err := configFileChanges(filestore.GetProjectBuiltRuntimeConfigPath(name), filestore.GetProjectRuntimeConfigPath(name))
+		if err != nil {
+			return fmt.Errorf("could not compare run.conf: %w", err)
+		}
+		writeConfigChanges(console, "run.conf, applied on the next run:", runtimeChanges)
+		if len(buildChanges) == 0 && len(runtimeChanges) == 0 {
+			console.WriteLn("No configuration change since project '%s' was built", name)
+		}
+	}
+
+	if status == rebuildUpToDate {
+		console.Success("The image of project '%s' is up-to-date, no rebuild is needed", name)
+		return nil
+	}
+	console.Warn("Project '%s' needs a rebuild: %s", name, reason)
+	console.WriteLn("Hint: Rebuild it with 'paul-envs build %s'", name)
+	return nil
+}
+
+func writeConfigChanges(console *console.Console, title string, changes []string) {
+	if len(changes) == 0 {
+		return
+	}
+	console.Info("%s", title)
+	for _, change := range changes {
+		console.WriteLn("  %s", change)
+	}
+}
+
+// Changes between the directives of the `before` and `after` conf files.
+func configFileChanges(before string, after string) ([]string, error) {
+	beforeDirectives, err := config.ParseFile(before)
+	if err != nil {
+		return nil, err
+	}
+	afterDirectives, err := config.ParseFile(after)
+	if err != nil {
+		return nil, err
+	}
+	return directiveChanges(beforeDirectives, afterDirectives), nil
+}
+
+// Describe what changed from the `before` directives to the `after` ones, by
+// directive name: `+` for added values, `-` for removed ones and `~` for a
+// value replaced by another. Package lists are compared package by package.
+func directiveChanges(before []config.Directive, after []config.Directive) []string {
+	beforeValues, afterValues := directiveValues(before), directiveValues(after)
+	keys := slices.Sorted(maps.Keys(beforeValues))
+	for key := range afterValues {
+		if _, ok := beforeValues[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	var changes []string
+	for _, key := range keys {
+		removed := valuesMissingFrom(beforeValues[key], afterValues[key])
+		added := valuesMissingFrom(afterValues[key], beforeValues[key])
+		if len(removed) == 1 && len(added) == 1 && !config.IsPackageListDirective(key) {
+			changes = append(changes, fmt.Sprintf("~ %s %s -> %s", key, removed[0], added[0]))
+			continue
+		}
+		for _, value := range removed {
+			changes = append(changes, "- "+key+" "+value)
+		}
+		for _, value := range added {
+			changes = append(changes, "+ "+key+" "+value)
+		}
+	}
+	return changes
+}
+
+// Values of the given directives by name, package lists being split into
+// their packages.
+func directiveValues(directives []config.Directive) map[string][]string {
+	values := map[string][]string{}
+	for _, d := range directives {
+		if config.IsPackageListDirective(d.Key) {
+			values[d.Key] = append(values[d.Key], strings.Fields(d.Value)...)
+		} else {
+			values[d.Key] = append(values[d.Key], d.Value)
+		}
+	}
+	return values
+}
+
+// Values of `values` which are not in `others`, each occurrence counting.
+func valuesMissingFrom(values []string, others []string) []string {
+	remaining := slices.Clone(others)
+	var missing []string
+	for _, value := range values {
+		if i := slices.Index(remaining, value); i >= 0 {
+			remaining = slices.Delete(remaining, i, i+1)
+		} else {
+			missing = append(missing, value)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+func TestDirectiveChanges(t *testing.T) {
+	before := []config.Directive{
+		{Key: "INSTALL_NODE", Value: "20"},
+		{Key: "SUPPLEMENTARY_PACKAGES", Value: "fd ripgrep"},
+		{Key: "MOUNT", Value: "~/notes /home/user/notes"},
+		{Key: "ENABLE_SSH", Value: "false"},
+	}
+	after := []config.Directive{
+		{Key: "INSTALL_NODE", Value: "22"},
+		{Key: "SUPPLEMENTARY_PACKAGES", Value: "ripgrep jq"},
+		{Key: "MOUNT", Value: "~/notes /home/user/notes"},
+		{Key: "MOUNT", Value: "~/data /data"},
+		{Key: "ENABLE_SSH", Value: "false"},
+	}
+	want := []string{
+		"~ INSTALL_NODE 20 -> 22",
+		"+ MOUNT ~/data /data",
+		"- SUPPLEMENTARY_PACKAGES fd",
+		"+ SUPPLEMENTARY_PACKAGES jq",
+	}
+	if got := directiveChanges(before, after); !slices.Equal(got, want) {
+		t.Fatalf("directiveChanges() = %q, want %q", got, want)
+	}
+	if got := directiveChanges(before, before); len(got) != 0 {
+		t.Fatalf("directiveChanges() without change = %q", got)
+	}
+}
+
+func TestDirectiveChanges_SinglePackageReplaced(t *testing.T) {
+	before := []config.Directive{{Key: "NPM_PACKAGES", Value: "typescript"}}
+	after := []config.Directive{{Key: "NPM_PACKAGES", Value: "prettier"}}
+	want := []string{"- NPM_PACKAGES typescript", "+ NPM_PACKAGES prettier"}
+	if got := directiveChanges(before, after); !slices.Equal(got, want) {
+		t.Fatalf("directiveChanges() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,236 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Free space under which building images is likely to fail.
+const (
+	lowDiskSpaceWarning = 5 * 1000 * 1000 * 1000
+	lowDiskSpaceFailure = 1 * 1000 * 1000 * 1000
+)
+
+func Doctor(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	flagset := newCommandFlagSet("doctor", console)
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs doctor [flags]",
+			"Check that this host is set up to run paul-envs: installed and reachable container engines, rootless setup, compose availability, free disk space, emulation of other architectures and validity of projects' configuration files, printing how to fix each problem found.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flagset.NArg() > 0 {
+		return utils.WithCategory(errors.New("doctor takes no argument"), errUsage)
+	}
+
+	sections := runDoctorChecks(ctx, filestore)
+	failures, warnings := 0, 0
+	for i, section := range sections {
+		if i > 0 {
+			console.WriteLn("")
+		}
+		console.Info("%s", section.title)
+		for _, diagnostic := range section.diagnostics {
+			writeDiagnostic(console, diagnostic)
+			switch diagnostic.Status {
+			case engine.DiagnosticFailure:
+				failures++
+			case engine.DiagnosticWarning:
+				warnings++
+			}
+		}
+	}
+
+	console.WriteLn("")
+	if failures > 0 {
+		return fmt.Errorf("%d problem(s) found, %d warning(s)", failures, warnings)
+	}
+	if warnings > 0 {
+		console.Warn("No blocking problem found, %d warning(s)", warnings)
+		return nil
+	}
+	console.Success("No problem found")
+	return nil
+}
+
+type doctorSection struct {
+	title       string
+	diagnostics []engine.Diagnostic
+}
+
+func runDoctorChecks(ctx context.Context, filestore *files.FileStore) []doctorSection {
+	return []doctorSection{
+		{"Container engines", diagnoseEngines(ctx)},
+		{"Disk space", []engine.Diagnostic{diagnoseDiskSpace(filestore.GetBaseFilesDir())}},
+		{"Emulation", engine.DiagnoseEmulation()},
+		{"Configuration", diagnoseProjectConfigs(filestore)},
+	}
+}
+
+func writeDiagnostic(console *console.Console, diagnostic engine.Diagnostic) {
+	line, fix := formatDiagnostic(diagnostic)
+	switch diagnostic.Status {
+	case engine.DiagnosticOk:
+		console.Success("%s", line)
+	case engine.DiagnosticWarning:
+		console.Warn("%s", line)
+	case engine.DiagnosticFailure:
+		console.Error("%s", line)
+	default:
+		console.WriteLn("%s", line)
+	}
+	if fix != "" {
+		console.WriteLn("%s", fix)
+	}
+}
+
+// Returns the line describing a diagnostic and the one describing its fix,
+// empty if there's none.
+func formatDiagnostic(diagnostic engine.Diagnostic) (string, string) {
+	mark := "-"
+	switch diagnostic.Status {
+	case engine.DiagnosticOk:
+		mark = "✓"
+	case engine.DiagnosticWarning:
+		mark = "!"
+	case engine.DiagnosticFailure:
+		mark = "✗"
+	}
+	line := fmt.Sprintf("  %s %s", mark, diagnostic.Name)
+	if diagnostic.Detail != "" {
+		line += ": " + diagnostic.Detail
+	}
+	fix := ""
+	if diagnostic.Fix != "" {
+		fix = "    Fix: " + strings.ReplaceAll(diagnostic.Fix, "\n", "\n         ")
+	}
+	return line, fix
+}
+
+func diagnoseEngines(ctx context.Context) []engine.Diagnostic {
+	var diagnostics []engine.Diagnostic
+	installed := false
+	for _, engineName := range []string{"docker", "podman"} {
+		engineDiagnostics, isInstalled := engine.Diagnose(ctx, engineName)
+		diagnostics = append(diagnostics, engineDiagnostics...)
+		installed = installed || isInstalled
+	}
+	if !installed {
+		diagnostics = append(diagnostics, engine.Diagnostic{
+			Name:   "container engine",
+			Status: engine.DiagnosticFailure,
+			Detail: "neither docker nor podman is installed",
+			Fix:    "Install Docker (https://docs.docker.com/engine/install/) or Podman (https://podman.io/docs/installation).",
+		})
+	}
+	return diagnostics
+}
+
+// Check the free space where paul-envs stores its data.
+//
+// Images are stored by the container engine, often on the same filesystem,
+// which cannot always be inspected without privileges.
+func diagnoseDiskSpace(dataDir string) engine.Diagnostic {
+	diagnostic := engine.Diagnostic{Name: "free space"}
+	// The data directory may not have been created yet
+	dir := dataDir
+	for {
+		if _, err := os.Stat(dir); err == nil || filepath.Dir(dir) == dir {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+	free, err := utils.FreeDiskSpace(dir)
+	if errors.Is(err, errors.ErrUnsupported) {
+		diagnostic.Status = engine.DiagnosticSkipped
+		diagnostic.Detail = "not supported on this platform"
+		return diagnostic
+	} else if err != nil {
+		diagnostic.Status = engine.DiagnosticWarning
+		diagnostic.Detail = fmt.Sprintf("cannot check the free space of %s: %s", dir, err)
+		return diagnostic
+	}
+	return diskSpaceDiagnostic(dir, free)
+}
+
+func diskSpaceDiagnostic(dir string, free int64) engine.Diagnostic {
+	diagnostic := engine.Diagnostic{
+		Name:   "free space",
+		Detail: fmt.Sprintf("%s available on %s", utils.FormatSize(free), dir),
+	}
+	switch {
+	case free < lowDiskSpaceFailure:
+		diagnostic.Status = engine.DiagnosticFailure
+		diagnostic.Fix = "Free some space, e.g. with 'paul-envs gc' and your engine's 'system prune' command."
+	case free < lowDiskSpaceWarning:
+		diagnostic.Status = engine.DiagnosticWarning
+		diagnostic.Fix = "Builds may run out of space, consider 'paul-envs gc' and your engine's 'system prune' command."
+	default:
+		diagnostic.Status = engine.DiagnosticOk
+	}
+	return diagnostic
+}
+
+// Check that the configuration files of all projects can be loaded.
+func diagnoseProjectConfigs(filestore *files.FileStore) []engine.Diagnostic {
+	projects, err := filestore.GetAllProjects()
+	if err != nil {
+		return []engine.Diagnostic{{
+			Name:   "projects",
+			Status: engine.DiagnosticFailure,
+			Detail: err.Error(),
+		}}
+	}
+	if len(projects) == 0 {
+		return []engine.Diagnostic{{Name: "projects", Status: engine.DiagnosticSkipped, Detail: "no project created yet"}}
+	}
+	diagnostics := make([]engine.Diagnostic, 0, len(projects))
+	for _, project := range projects {
+		diagnostics = append(diagnostics, diagnoseProjectConfig(project))
+	}
+	return diagnostics
+}
+
+func diagnoseProjectConfig(project files.ProjectEntry) engine.Diagnostic {
+	diagnostic := engine.Diagnostic{Name: project.ProjectName}
+	if _, err := config.LoadBuildConfig(project.BuildConfigPath); err != nil {
+		diagnostic.Status = engine.DiagnosticFailure
+		diagnostic.Detail = fmt.Sprintf("invalid build.conf: %s", err)
+		diagnostic.Fix = fmt.Sprintf("Edit %s", project.BuildConfigPath)
+		return diagnostic
+	}
+	if _, err := config.LoadRuntimeConfig(project.RuntimeConfigPath); err != nil {
+		diagnostic.Status = engine.DiagnosticFailure
+		diagnostic.Detail = fmt.Sprintf("invalid run.conf: %s", err)
+		diagnostic.Fix = fmt.Sprintf("Edit %s", project.RuntimeConfigPath)
+		return diagnostic
+	}
+	if _, err := os.Stat(project.ProjectPath); err != nil {
+		diagnostic.Status = engine.DiagnosticWarning
+		diagnostic.Detail = fmt.Sprintf("project directory %s is not accessible", project.ProjectPath)
+		diagnostic.Fix = fmt.Sprintf("Restore it, update its PATH in %s or remove the project with 'paul-envs remove %s'",
+			project.RuntimeConfigPath, project.ProjectName)
+		return diagnostic
+	}
+	diagnostic.Status = engine.DiagnosticOk
+	return diagnostic
+}
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestDiskSpaceDiagnostic(t *testing.T) {
+	tests := []struct {
+		free int64
+		want engine.DiagnosticStatus
+	}{
+		{free: 500 * 1000 * 1000, want: engine.DiagnosticFailure},
+		{free: 3 * 1000 * 1000 * 1000, want: engine.DiagnosticWarning},
+		{free: 50 * 1000 * 1000 * 1000, want: engine.DiagnosticOk},
+	}
+	for _, tt := range tests {
+		got := diskSpaceDiagnostic("/data", tt.free)
+		if got.Status != tt.want {
+			t.Fatalf("diskSpaceDiagnostic(%d) status = %v, want %v", tt.free, got.Status, tt.want)
+		}
+		if (got.Fix == "") != (tt.want == engine.DiagnosticOk) {
+			t.Fatalf("diskSpaceDiagnostic(%d) fix = %q", tt.free, got.Fix)
+		}
+	}
+}
+
+func TestDiagnoseProjectConfig(t *testing.T) {
+	dir := t.TempDir()
+	project := files.ProjectEntry{
+		ProjectName:       "app",
+		ProjectPath:       dir,
+		BuildConfigPath:   filepath.Join(dir, "build.conf"),
+		RuntimeConfigPath: filepath.Join(dir, "run.conf"),
+	}
+	writeFile := func(path string, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(project.BuildConfigPath, "VERSION 1.0.0\n")
+	writeFile(project.RuntimeConfigPath, "PATH "+dir+"\n")
+	got := diagnoseProjectConfig(project)
+	if got.Status != engine.DiagnosticFailure || !strings.Contains(got.Detail, "build.conf") ||
+		!strings.Contains(got.Fix, project.BuildConfigPath) {
+		t.Fatalf("diagnoseProjectConfig() = %+v, want an invalid build.conf", got)
+	}
+}
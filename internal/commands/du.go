@@ -0,0 +1,188 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/table"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Du(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var engineSelection string
+	flagset := newCommandFlagSet("du", console)
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to query: docker, podman, or all. Default: all available engines.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs du [flags]",
+			"Show the disk space used by each project, sorted by usage: by its images (only counting the layers no other image shares, which removing them would free) and by its volumes.\n\nWhat all projects share (the shared base image, cache volumes) is reported apart, as is the engine's build cache, which cannot be attributed to a project.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flagset.NArg() > 0 {
+		return utils.WithCategory(errors.New("du does not take arguments"), errUsage)
+	}
+	selection := engine.SelectionAll
+	if engineSelection != "" {
+		var err error
+		if selection, err = parseCleanEngineSelection(engineSelection); err != nil {
+			return utils.WithCategory(err, errUsage)
+		}
+	}
+
+	entries, err := filestore.GetAllProjects()
+	if err != nil {
+		return fmt.Errorf("could not list all projects: %w", err)
+	}
+	projects := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		projects[entry.ProjectName] = true
+	}
+	engines, err := engine.NewSet(ctx, console, selection)
+	if err != nil {
+		return err
+	}
+	for _, containerEngine := range engines {
+		writeEngineSection(console, containerEngine)
+		resources, err := listEngineResources(ctx, containerEngine, engineQueryParallelism(filestore))
+		if err != nil {
+			return err
+		}
+		usage, err := containerEngine.GetDiskUsage(ctx)
+		if err != nil {
+			return err
+		}
+		usages := projectDiskUsages(resources, usage)
+		if len(usages) == 0 {
+			console.WriteLn("  Nothing stored")
+		} else if err := table.Render(console.Writer(), []string{
+			"PROJECT", "IMAGES", "VOLUMES", "TOTAL",
+		}, diskUsageRows(usages, projects), table.Options{Width: table.TerminalWidth(console.Writer())}); err != nil {
+			return err
+		}
+		if usage.BuildCache > 0 {
+			console.WriteLn("Build cache (whole engine): %s", utils.FormatSize(usage.BuildCache))
+		}
+	}
+	return nil
+}
+
+// Disk space used by a project, or by what all projects share.
+type projectDiskUsage struct {
+	// Name of the project, empty for what all projects share
+	name    string
+	images  int64
+	volumes int64
+}
+
+func (u projectDiskUsage) total() int64 {
+	return u.images + u.volumes
+}
+
+// Attribute the disk space reported by an engine to the projects its
+// resources belong to, sorted by decreasing usage.
+//
+// Project images only count their unique size, an image tagged by several of
+// their references (e.g. a generation of the current one) being counted once.
+// The shared base image is counted in full, as the layers it shares with
+// project images would not be freed by removing any of them.
+func projectDiskUsages(resources engineResources, usage engine.DiskUsage) []projectDiskUsage {
+	images := map[string]engine.ImageUsage{}
+	for ref, image := range usage.Images {
+		images[strings.TrimPrefix(ref, "localhost/")] = image
+	}
+	byProject := map[string]*projectDiskUsage{}
+	get := func(projectName string) *projectDiskUsage {
+		if byProject[projectName] == nil {
+			byProject[projectName] = &projectDiskUsage{name: projectName}
+		}
+		return byProject[projectName]
+	}
+	counted := map[string]bool{}
+	addImage := func(projectName string, imageName string) {
+		image, ok := images[strings.TrimPrefix(imageName, "localhost/")]
+		if !ok || counted[image.ImageId] {
+			return
+		}
+		counted[image.ImageId] = true
+		if projectName == "" {
+			get("").images += image.Size
+		} else {
+			get(projectName).images += image.UniqueSize
+		}
+	}
+
+	for _, image := range resources.images {
+		projectName := ""
+		if image.ProjectName != nil {
+			projectName = *image.ProjectName
+		}
+		addImage(projectName, image.ImageName)
+	}
+	for _, generation := range resources.generations {
+		addImage(generation.ProjectName, generation.ImageName)
+	}
+	for _, image := range resources.archImages {
+		addImage(image.ProjectName, image.ImageName)
+	}
+	for _, snapshot := range resources.snapshots {
+		addImage(snapshot.ProjectName, snapshot.ImageName)
+	}
+	for _, volume := range resources.volumes {
+		size := usage.Volumes[volume.VolumeName]
+		if projectName, ok := projectNameFromLocalVolume(volume.VolumeName); ok {
+			get(projectName).volumes += size
+		} else {
+			get("").volumes += size
+		}
+	}
+
+	result := make([]projectDiskUsage, 0, len(byProject))
+	for _, u := range byProject {
+		if u.total() > 0 || u.name != "" {
+			result = append(result, *u)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].total() != result[j].total() {
+			return result[i].total() > result[j].total()
+		}
+		return result[i].name < result[j].name
+	})
+	return result
+}
+
+func diskUsageRows(usages []projectDiskUsage, projects map[string]bool) []table.Row {
+	rows := make([]table.Row, 0, len(usages))
+	for _, u := range usages {
+		name := u.name
+		switch {
+		case name == "":
+			name = "(shared)"
+		case !projects[name]:
+			// Left over by a removed project, see `paul-envs gc`
+			name += " (deleted)"
+		}
+		rows = append(rows, table.Row{
+			{name},
+			{utils.FormatSize(u.images)},
+			{utils.FormatSize(u.volumes)},
+			{utils.FormatSize(u.total())},
+		})
+	}
+	return rows
+}
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/engine"
+)
+
+func TestProjectDiskUsages(t *testing.T) {
+	str := func(s string) *string { return &s }
+	resources := engineResources{
+		images: []engine.ImageInfo{
+			{ImageName: "localhost/paulenv-base:latest"},
+			{ProjectName: str("app"), ImageName: "localhost/paulenv:app"},
+			{ProjectName: str("small"), ImageName: "localhost/paulenv:small"},
+		},
+		generations: []engine.GenerationInfo{
+			// Same image as the current one
+			{ProjectName: "app", Number: 2, ImageName: "localhost/paulenv-generation:app.2"},
+			{ProjectName: "app", Number: 1, ImageName: "localhost/paulenv-generation:app.1"},
+		},
+		snapshots: []engine.SnapshotInfo{
+			{ProjectName: "gone", Tag: "v1", ImageName: "localhost/paulenv-snapshot:gone.v1"},
+		},
+		volumes: []engine.VolumeInfo{
+			{VolumeName: "paulenv-app-local"},
+			{VolumeName: "paulenv-app.db-local"},
+			{VolumeName: "paulenv-shared-cache"},
+		},
+	}
+	usage := engine.DiskUsage{
+		Images: map[string]engine.ImageUsage{
+			"localhost/paulenv-base:latest":      {ImageId: "base", Size: 1000, UniqueSize: 0},
+			"localhost/paulenv:app":              {ImageId: "app2", Size: 1500, UniqueSize: 300},
+			"localhost/paulenv-generation:app.2": {ImageId: "app2", Size: 1500, UniqueSize: 300},
+			"localhost/paulenv-generation:app.1": {ImageId: "app1", Size: 1400, UniqueSize: 200},
+			"localhost/paulenv:small":            {ImageId: "small", Size: 1010, UniqueSize: 10},
+			"localhost/paulenv-snapshot:gone.v1": {ImageId: "gone", Size: 1100, UniqueSize: 100},
+			"localhost/unrelated:latest":         {ImageId: "unrelated", Size: 5000, UniqueSize: 5000},
+		},
+		Volumes: map[string]int64{
+			"paulenv-app-local":    2000,
+			"paulenv-app.db-local": 500,
+			"paulenv-shared-cache": 700,
+		},
+	}
+
+	want := []projectDiskUsage{
+		{name: "app", images: 500, volumes: 2500},
+		{name: "", images: 1000, volumes: 700},
+		{name: "gone", images: 100},
+		{name: "small", images: 10},
+	}
+	if got := projectDiskUsages(resources, usage); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
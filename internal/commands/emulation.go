@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func EnableEmulation(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var engineSelection string
+	flagset := newCommandFlagSet("enable-emulation", console)
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine through which emulators are registered: docker or podman.\nDefault: auto-select, preferring Podman.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs enable-emulation [architecture...] [flags]",
+			"Register QEMU emulators on this host through the container engine, so images built for other architectures (e.g. arm64 or linux/arm64, by default amd64 and arm64 other than this host's) can be built and run, much slower than natively. Requires a privileged container, thus rootful Docker or Podman.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	architectures := []string{}
+	for _, arg := range flagset.Args() {
+		platform, err := engine.ParsePlatform(arg)
+		if err != nil {
+			return utils.WithCategory(err, errUsage)
+		}
+		architecture := engine.PlatformArchitecture(platform)
+		if err := engine.ValidateEmulatedArchitecture(architecture); err != nil {
+			return utils.WithCategory(err, errUsage)
+		}
+		if !slices.Contains(architectures, architecture) {
+			architectures = append(architectures, architecture)
+		}
+	}
+	if len(architectures) == 0 {
+		architectures = engine.DefaultEmulatedArchitectures()
+	}
+	selection, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+
+	missing := []string{}
+	for _, architecture := range architectures {
+		if enabled, err := engine.IsEmulationEnabled(architecture); err != nil || !enabled {
+			missing = append(missing, architecture)
+		}
+	}
+	if len(missing) == 0 {
+		console.Success("Emulation of %s is already set up", strings.Join(architectures, ", "))
+		return nil
+	}
+
+	containerEngine, err := engine.NewSelected(ctx, console, selection)
+	if err != nil {
+		return err
+	}
+	console.Info("Registering emulators of %s...", strings.Join(missing, ", "))
+	if err := containerEngine.EnableEmulation(ctx, missing); err != nil {
+		return err
+	}
+
+	failed := []string{}
+	for _, architecture := range missing {
+		// Registrations can only be checked from a Linux host, elsewhere
+		// they're done in the engine's virtual machine
+		if enabled, err := engine.IsEmulationEnabled(architecture); err == nil && !enabled {
+			failed = append(failed, architecture)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("emulation of %s is still not set up, see the output above", strings.Join(failed, ", "))
+	}
+	console.Success("Emulation of %s is set up", strings.Join(missing, ", "))
+	console.WriteLn("Hint: It doesn't persist across reboots on all systems, 'paul-envs doctor' tells if it has to be set up again")
+	return nil
+}
+
+// Warn if images built for the given architecture cannot run on this host for
+// lack of emulator.
+func warnIfNoEmulation(architecture string, console *console.Console) {
+	if enabled, err := engine.IsEmulationEnabled(architecture); err == nil && !enabled {
+		console.Warn("No emulator of %s is registered on this host, images built for it cannot run.", architecture)
+		console.WriteLn("Hint: Set one up with 'paul-envs enable-emulation %s'", architecture)
+	}
+}
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"context"
+	"sync"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Maximum number of container engine queries made at once, from the
+// PARALLELISM global setting.
+func engineQueryParallelism(filestore *files.FileStore) int {
+	// Errors have already been reported when starting
+	if globalConfig, err := filestore.LoadGlobalConfig(); err == nil {
+		return globalConfig.MaxParallelism()
+	}
+	return config.DefaultParallelism()
+}
+
+// Run the given container engine queries concurrently, at most `limit` at
+// once, and return the error of the first one failing, if any.
+//
+// As soon as one fails, the context given to the others is cancelled, their
+// results being of no use anymore.
+func runEngineQueries(ctx context.Context, limit int, queries ...func(ctx context.Context) error) error {
+	queryCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var mu sync.Mutex
+	var firstErr error
+	utils.Parallel(len(queries), limit, func(i int) {
+		if queryCtx.Err() != nil {
+			return
+		}
+		if err := queries[i](queryCtx); err != nil {
+			mu.Lock()
+			defer mu.Unlock()
+			if firstErr == nil {
+				firstErr = err
+				cancel()
+			}
+		}
+	})
+	if firstErr == nil {
+		return ctx.Err()
+	}
+	return firstErr
+}
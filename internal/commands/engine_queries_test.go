@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRunEngineQueries(t *testing.T) {
+	var ran atomic.Int32
+	query := func(ctx context.Context) error {
+		ran.Add(1)
+		return nil
+	}
+	if err := runEngineQueries(context.Background(), 2, query, query, query); err != nil || ran.Load() != 3 {
+		t.Fatalf("runEngineQueries() = %v after %d queries, want nil after 3", err, ran.Load())
+	}
+
+	failure := errors.New("cannot list images")
+	failing := func(ctx context.Context) error { return failure }
+	waiting := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if err := runEngineQueries(context.Background(), 2, waiting, failing); err != failure {
+		t.Fatalf("runEngineQueries() = %v, want the failure cancelling the others", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := runEngineQueries(ctx, 1, query); !errors.Is(err, context.Canceled) {
+		t.Fatalf("runEngineQueries() = %v with a cancelled context, want context.Canceled", err)
+	}
+}
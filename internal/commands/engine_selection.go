@@ -2,12 +2,16 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/peaberberian/paul-envs/internal/config"
 	"github.com/peaberberian/paul-envs/internal/console"
 	"github.com/peaberberian/paul-envs/internal/engine"
 	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
 )
 
 func parseCommandEngineSelection(value string) (engine.Selection, error) {
@@ -18,8 +22,26 @@ func parseCommandEngineSelection(value string) (engine.Selection, error) {
 		return engine.SelectionDocker, nil
 	case string(engine.SelectionPodman):
 		return engine.SelectionPodman, nil
+	case string(engine.SelectionPodmanRootful):
+		return engine.SelectionPodmanRootful, nil
 	default:
-		return "", fmt.Errorf("invalid --engine value %q. Must be one of: docker, podman", value)
+		if engine.IsPlugin(value) {
+			return engine.Selection(value), nil
+		}
+		return "", fmt.Errorf("invalid --engine value %q. Must be one of: docker, podman, podman-rootful or the name of an engine plugin", value)
+	}
+}
+
+// Apply the `--rootful` flag of a command to its requested engine selection.
+func applyRootfulFlag(selection engine.Selection, rootful bool) (engine.Selection, error) {
+	if !rootful {
+		return selection, nil
+	}
+	switch selection {
+	case engine.SelectionAuto, engine.SelectionPodman, engine.SelectionPodmanRootful:
+		return engine.SelectionPodmanRootful, nil
+	default:
+		return "", utils.WithCategory(fmt.Errorf("--rootful only applies to Podman, not to --engine %s", selection), errUsage)
 	}
 }
 
@@ -55,7 +77,11 @@ func resolveProjectEngineSelection(
 
 func buildArgsForEngine(projectName string, selection engine.Selection) []string {
 	args := []string{}
-	if selection != engine.SelectionAuto {
+	switch selection {
+	case engine.SelectionAuto:
+	case engine.SelectionPodmanRootful:
+		args = append(args, "--rootful")
+	default:
 		args = append(args, "--engine", string(selection))
 	}
 	args = append(args, projectName)
@@ -76,3 +102,47 @@ func newProjectEngine(
 	}
 	return containerEngine, selected, nil
 }
+
+// Fail early if resource names derived from that project are already taken by
+// resources foreign to it.
+func ensureNoNameCollisions(
+	ctx context.Context,
+	projectName string,
+	containerEngine engine.ContainerEngine,
+	console *console.Console,
+) error {
+	collisions, err := engine.FindNameCollisions(ctx, containerEngine, projectName)
+	if err != nil {
+		console.Warn("Could not check for resource name collisions: %s", err)
+		return nil
+	}
+	if len(collisions) == 0 {
+		return nil
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "resource names needed by project '%s' are already used by resources not managed by paul-envs:", projectName)
+	for _, collision := range collisions {
+		fmt.Fprintf(&msg, "\n  - %s '%s' %s", collision.Kind, collision.Name, collision.Detail)
+	}
+	msg.WriteString("\nHint: Rename or remove those resources, or re-create this project under another name with 'paul-envs create --name <other-name> <path>'")
+	return errors.New(msg.String())
+}
+
+// Make sure the Podman machine, when the engine needs one, runs with enough
+// resources for that project.
+func ensureProjectMachine(
+	ctx context.Context,
+	projectName string,
+	containerEngine engine.ContainerEngine,
+	filestore *files.FileStore,
+	console *console.Console,
+) error {
+	needs := engine.MachineNeeds{}
+	if project, err := filestore.GetProject(projectName); err == nil {
+		if runtimeCfg, err := config.LoadRuntimeConfig(project.RuntimeConfigPath); err == nil {
+			needs.Memory = runtimeCfg.Memory
+			needs.Cpus = runtimeCfg.Cpus
+		}
+	}
+	return engine.EnsurePodmanMachine(ctx, containerEngine, needs, console)
+}
@@ -23,6 +23,7 @@ func TestParseCommandEngineSelection(t *testing.T) {
 		{input: "", want: engine.SelectionAuto, ok: true},
 		{input: "docker", want: engine.SelectionDocker, ok: true},
 		{input: "podman", want: engine.SelectionPodman, ok: true},
+		{input: "podman-rootful", want: engine.SelectionPodmanRootful, ok: true},
 		{input: "all", ok: false},
 	}
 
@@ -40,6 +41,34 @@ func TestParseCommandEngineSelection(t *testing.T) {
 	}
 }
 
+func TestApplyRootfulFlag(t *testing.T) {
+	tests := []struct {
+		selection engine.Selection
+		rootful   bool
+		want      engine.Selection
+		ok        bool
+	}{
+		{selection: engine.SelectionAuto, rootful: false, want: engine.SelectionAuto, ok: true},
+		{selection: engine.SelectionDocker, rootful: false, want: engine.SelectionDocker, ok: true},
+		{selection: engine.SelectionAuto, rootful: true, want: engine.SelectionPodmanRootful, ok: true},
+		{selection: engine.SelectionPodman, rootful: true, want: engine.SelectionPodmanRootful, ok: true},
+		{selection: engine.SelectionDocker, rootful: true, ok: false},
+	}
+
+	for _, tt := range tests {
+		got, err := applyRootfulFlag(tt.selection, tt.rootful)
+		if tt.ok && err != nil {
+			t.Fatalf("applyRootfulFlag(%q, %t) unexpected error: %v", tt.selection, tt.rootful, err)
+		}
+		if !tt.ok && err == nil {
+			t.Fatalf("applyRootfulFlag(%q, %t) expected error, got none", tt.selection, tt.rootful)
+		}
+		if tt.ok && got != tt.want {
+			t.Fatalf("applyRootfulFlag(%q, %t) = %q, want %q", tt.selection, tt.rootful, got, tt.want)
+		}
+	}
+}
+
 func TestResolveProjectEngineSelectionUsesLastBuildEngine(t *testing.T) {
 	t.Setenv("XDG_DATA_HOME", t.TempDir())
 	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
@@ -92,6 +121,12 @@ func TestBuildArgsForEngine(t *testing.T) {
 	if strings.Join(got, "\x00") != strings.Join(want, "\x00") {
 		t.Fatalf("buildArgsForEngine() = %v, want %v", got, want)
 	}
+
+	got = buildArgsForEngine("alpha", engine.SelectionPodmanRootful)
+	want = []string{"--rootful", "alpha"}
+	if strings.Join(got, "\x00") != strings.Join(want, "\x00") {
+		t.Fatalf("buildArgsForEngine() = %v, want %v", got, want)
+	}
 }
 
 func TestBuildHelpIncludesEngineFlag(t *testing.T) {
@@ -104,7 +139,7 @@ func TestBuildHelpIncludesEngineFlag(t *testing.T) {
 
 	got := out.String()
 	for _, fragment := range []string{
-		"Usage: paul-envs build [project-name] [flags]",
+		"Usage: paul-envs build [project-name|@group...] [flags]",
 		"--engine string",
 		"Container engine to use for this build: docker or podman.",
 	} {
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Events(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var engineSelection string
+	flagset := newCommandFlagSet("events", console)
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to follow: docker or podman.\nDefault: the one the project was built with, or auto-select, preferring Podman.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs events [flags] [project-name]",
+			"Display what happens to paul-envs' containers, images, volumes and networks as it happens (e.g. a container starting or dying), optionally only those of a project, until interrupted. Containers dying unexpectedly, killed by a signal or for exceeding their memory limit, are highlighted.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) > 1 {
+		return utils.WithCategory(errors.New("expected at most the name of a project"), errUsage)
+	}
+	requestedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+
+	projectName := ""
+	var containerEngine engine.ContainerEngine
+	if len(args) == 1 {
+		projectName = args[0]
+		if err := validateProjectName(projectName); err != nil {
+			return err
+		}
+		if !filestore.DoesProjectExist(projectName) {
+			return projectNotFoundError(projectName)
+		}
+		containerEngine, _, err = newProjectEngine(ctx, projectName, requestedEngine, filestore, console)
+	} else {
+		containerEngine, err = engine.NewSelected(ctx, console, requestedEngine)
+	}
+	if err != nil {
+		return err
+	}
+
+	crashes := engine.NewCrashTracker()
+	return containerEngine.StreamEvents(ctx, func(event engine.Event) {
+		crashed := crashes.IsCrash(event)
+		if projectName != "" && event.ProjectName != projectName {
+			return
+		}
+		line := event.Time.Local().Format(time.DateTime) + " " + event.String()
+		if crashed {
+			console.Warn("%s: crashed", line)
+		} else {
+			console.WriteLn("%s", line)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Exec(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	var options engine.ExecOptions
+	flagset := newCommandFlagSet("exec", console)
+	flagset.StringVar(&options.User, "user", "", "User running the command, as a name or `UID[:GID]`. Default: the project's user.")
+	flagset.StringVar(&options.WorkDir, "workdir", "", "Directory in the container in which the command is run. Default: the project's working directory.")
+	flagset.BoolVar(&options.NoTTY, "T", false, "Never allocate a pseudo-terminal, e.g. to pipe the command's output.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs exec [flags] <project-name> [--] <command...>",
+			"Run a command in the running container of a project, directly instead of through its entrypoint like 'run' does: the shell configuration is not loaded, which suits one-off commands and scripts. Its standard input is forwarded to it.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) == 0 {
+		return utils.WithCategory(errors.New("expected the name of the project in which to run the command"), errUsage)
+	}
+	name := args[0]
+	cmdArgs := args[1:]
+	if len(cmdArgs) > 0 && cmdArgs[0] == "--" {
+		cmdArgs = cmdArgs[1:]
+	}
+	if len(cmdArgs) == 0 {
+		return utils.WithCategory(errors.New("expected the command to run after the project's name"), errUsage)
+	}
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+	if err := ensureProjectCompatible(name, filestore, console); err != nil {
+		return fmt.Errorf("cannot exec in project '%s': %w", name, err)
+	}
+	project, err := filestore.GetProject(name)
+	if err != nil {
+		return fmt.Errorf("failed to obtain information on project '%s': %w", name, err)
+	}
+	if options.User == "" {
+		if options.User, err = engine.ProjectUsername(project); err != nil {
+			return err
+		}
+	}
+	if options.WorkDir == "" {
+		if options.WorkDir, err = engine.ProjectWorkDir(project); err != nil {
+			return err
+		}
+	}
+
+	containerEngine, _, err := newProjectEngine(ctx, name, engine.SelectionAuto, filestore, console)
+	if err != nil {
+		return err
+	}
+	container, err := findRunningProjectContainer(ctx, containerEngine, name)
+	if err != nil {
+		return err
+	}
+	if container == nil {
+		return fmt.Errorf("project '%s' has no running container\nHint: Start it with 'paul-envs run %s'", name, name)
+	}
+	return containerEngine.ExecContainer(ctx, *container, cmdArgs, options)
+}
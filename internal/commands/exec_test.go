@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+)
+
+func TestExecRequiresProjectAndCommand(t *testing.T) {
+	var out bytes.Buffer
+	cons := console.New(context.Background(), strings.NewReader(""), &out, &out)
+
+	for _, args := range [][]string{
+		{},
+		{"alpha"},
+		{"alpha", "--"},
+		{"-T", "alpha", "--"},
+	} {
+		if err := Exec(context.Background(), args, nil, cons); ExitCode(err) != ExitUsage {
+			t.Errorf("Exec(%q) exit code = %d, want %d (error: %v)", args, ExitCode(err), ExitUsage, err)
+		}
+	}
+}
+
+func TestExecHelp(t *testing.T) {
+	var out bytes.Buffer
+	cons := console.New(context.Background(), strings.NewReader(""), &out, &out)
+
+	if err := Exec(context.Background(), []string{"--help"}, nil, cons); err != nil {
+		t.Fatalf("Exec(--help) error = %v", err)
+	}
+	for _, fragment := range []string{
+		"Usage: paul-envs exec [flags] <project-name> [--] <command...>",
+		"--user",
+		"--workdir",
+		"-T",
+	} {
+		if !strings.Contains(out.String(), fragment) {
+			t.Fatalf("expected help output to contain %q, got:\n%s", fragment, out.String())
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Exit codes of the CLI, by failure category, so wrapper scripts can branch
+// on them. They are documented in the README: existing ones must never
+// change.
+const (
+	ExitSuccess = 0
+	// Any failure not covered by a more specific code
+	ExitFailure = 1
+	// Unknown command, invalid flag or argument
+	ExitUsage = 2
+	// No usable container engine (not installed, daemon not running...)
+	ExitEngineUnavailable = 3
+	// The given project does not exist
+	ExitProjectNotFound = 4
+	// The container engine failed to build an image
+	ExitBuildFailed = 5
+	// Invalid project name or configuration
+	ExitValidationFailed = 6
+	// Missing permissions on files or on the container engine
+	ExitPermissionDenied = 7
+	// An answer had to be asked in non-interactive mode (`--ci`)
+	ExitInteractionRequired = 8
+	// The project is in use by another paul-envs process (without `--wait`)
+	ExitProjectLocked = 9
+	// Interrupted by the user (e.g. Ctrl+C)
+	ExitInterrupted = 130
+)
+
+var errUsage = errors.New("invalid usage")
+var errProjectNotFound = errors.New("project not found")
+var errBuildFailed = engine.ErrBuildFailed
+var errValidationFailed = errors.New("validation failed")
+
+// Wrapped by the questions of the console in non-interactive mode.
+var errNonInteractive = console.ErrNonInteractive
+
+// Failure of a command run in a container, whose exit code the CLI exits
+// with (see `paul-envs task`).
+type commandExitError struct {
+	exitCode int
+}
+
+func (e *commandExitError) Error() string {
+	return fmt.Sprintf("command exited with code %d", e.exitCode)
+}
+
+// Returns the exit code of the CLI for the error returned by a command.
+func ExitCode(err error) int {
+	var exitErr *commandExitError
+	switch {
+	case err == nil:
+		return ExitSuccess
+	case errors.As(err, &exitErr):
+		return exitErr.exitCode
+	case errors.Is(err, context.Canceled):
+		return ExitInterrupted
+	case errors.Is(err, errUsage):
+		return ExitUsage
+	case errors.Is(err, fs.ErrPermission):
+		return ExitPermissionDenied
+	case errors.Is(err, engine.ErrEngineUnavailable):
+		return ExitEngineUnavailable
+	case errors.Is(err, errProjectNotFound):
+		return ExitProjectNotFound
+	case errors.Is(err, errBuildFailed):
+		return ExitBuildFailed
+	case errors.Is(err, errValidationFailed):
+		return ExitValidationFailed
+	case errors.Is(err, errNonInteractive):
+		return ExitInteractionRequired
+	case errors.Is(err, files.ErrLocked):
+		return ExitProjectLocked
+	default:
+		return ExitFailure
+	}
+}
+
+// Error returned when the given project does not exist.
+func projectNotFoundError(name string) error {
+	return utils.WithCategory(
+		fmt.Errorf("project '%s' not found\nHint: Use 'paul-envs list' to see available projects", name),
+		errProjectNotFound,
+	)
+}
+
+// Validate the name of a project given by the user.
+func validateProjectName(name string) error {
+	if strings.HasPrefix(name, "@") {
+		return utils.WithCategory(
+			fmt.Errorf("'%s' is a group of projects, which only build, rebuild and gc accept\nHint: Give a project name instead", name),
+			errUsage,
+		)
+	}
+	return utils.WithCategory(utils.ValidateProjectName(name), errValidationFailed)
+}
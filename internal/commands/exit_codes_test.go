@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, ExitSuccess},
+		{"generic", errors.New("boom"), ExitFailure},
+		{"interrupted", fmt.Errorf("build: %w", context.Canceled), ExitInterrupted},
+		{"engine", fmt.Errorf("cannot run: %w", utils.WithCategory(errors.New("no engine"), engine.ErrEngineUnavailable)), ExitEngineUnavailable},
+		{"project not found", projectNotFoundError("demo"), ExitProjectNotFound},
+		{"build failed", utils.WithCategory(errors.New("exit status 1"), errBuildFailed), ExitBuildFailed},
+		{"invalid name", validateProjectName("bad name!"), ExitValidationFailed},
+		{"permission", fmt.Errorf("cannot write: %w", &fs.PathError{Op: "open", Path: "/x", Err: fs.ErrPermission}), ExitPermissionDenied},
+		{"interrupted build", utils.WithCategory(context.Canceled, errBuildFailed), ExitInterrupted},
+		{"non-interactive", fmt.Errorf("cannot choose a project: %w", errNonInteractive), ExitInteractionRequired},
+		{"locked project", fmt.Errorf("cannot lock project 'app': %w", files.ErrLocked), ExitProjectLocked},
+		{"task command", fmt.Errorf("task of project 'app' failed: %w", &commandExitError{exitCode: 42}), 42},
+	}
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("%s: ExitCode(%v) = %d, want %d", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestParseCommandFlags_UsageExitCode(t *testing.T) {
+	var out bytes.Buffer
+	flagset := newCommandFlagSet("test", console.New(context.Background(), strings.NewReader(""), &out, &out))
+	if err := parseCommandFlags(flagset, []string{"--unknown"}); ExitCode(err) != ExitUsage {
+		t.Fatalf("ExitCode() = %d for an unknown flag, want %d", ExitCode(err), ExitUsage)
+	}
+	if err := parseCommandFlags(flagset, []string{"--help"}); !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("parseCommandFlags(--help) = %v, want flag.ErrHelp", err)
+	}
+}
@@ -0,0 +1,189 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Export(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	var force, deployment, play bool
+	flagset := newCommandFlagSet("export", console)
+	flagset.BoolVar(&force, "force", false, "Overwrite files already present in the target directory, or the target bundle")
+	flagset.BoolVar(&deployment, "deployment", false, "With 'kube', write a Deployment running the project's Pod instead of the Pod itself")
+	flagset.BoolVar(&play, "play", false, "With 'kube', then run it with 'podman kube play'")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs export <compose|bundle|kube> <project-name> <directory|file> [flags]",
+			"Export a project as a standalone bundle usable without paul-envs. 'compose' writes a compose.yaml file with the Dockerfile, entrypoint, `.env` file and dotfiles it relies on. The compose.override.yaml files of paul-envs' config directory and of the project's directory, if any, are written alongside it, to be layered on top of it.\n\n'bundle' writes a .tar.gz file recreating the project on another machine with 'paul-envs import': its build.conf, run.conf, README and dotfiles, along with the 'compose' export. The git author identity and service credentials (e.g. passwords given with SERVICE_ENV) are stripped from it.\n\n'kube' writes the Kubernetes YAML of a Pod running the project, with its sidecar services, its directory and dotfiles mounted from the host and its volumes as persistent volume claims, which 'podman kube play' maps to Podman volumes.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) == 0 {
+		return errors.New("expected an export format: compose, bundle or kube")
+	}
+	format := args[0]
+	if format != "compose" && format != "bundle" && format != "kube" {
+		return fmt.Errorf("invalid export format %q: expected compose, bundle or kube", format)
+	}
+	if (deployment || play) && format != "kube" {
+		return utils.WithCategory(errors.New("--deployment and --play only apply to 'export kube'"), errUsage)
+	}
+	if len(args) != 3 {
+		if format == "compose" {
+			return errors.New("'export compose' takes a project name and a target directory")
+		}
+		return fmt.Errorf("'export %s' takes a project name and a target file", format)
+	}
+
+	name := args[1]
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+	if err := ensureProjectCompatible(name, filestore, console); err != nil {
+		return fmt.Errorf("cannot export project '%s': %w", name, err)
+	}
+	project, err := filestore.GetProject(name)
+	if err != nil {
+		return fmt.Errorf("failed to obtain information on project '%s': %w", name, err)
+	}
+
+	switch format {
+	case "bundle":
+		return exportProjectBundle(project, args[2], force, filestore, console)
+	case "kube":
+		return exportProjectKube(ctx, project, args[2], force, deployment, play, filestore, console)
+	}
+	bundle, err := engine.ComposeBundle(project)
+	if err != nil {
+		return fmt.Errorf("cannot export project '%s': %w", name, err)
+	}
+	dir, err := filepath.Abs(args[2])
+	if err != nil {
+		return fmt.Errorf("invalid directory '%s': %w", args[2], err)
+	}
+	if err := filestore.WriteBundle(ctx, dir, bundle, force); err != nil {
+		if !force {
+			return fmt.Errorf("cannot export project '%s': %w\nHint: Use '--force' to overwrite existing files", name, err)
+		}
+		return fmt.Errorf("cannot export project '%s': %w", name, err)
+	}
+	console.Success("Exported project '%s' to %s", name, dir)
+	mainService := name
+	if runtimeCfg, err := config.LoadRuntimeConfig(project.RuntimeConfigPath); err == nil {
+		mainService = runtimeCfg.MainServiceName(name)
+	}
+	composeCommand := engine.ComposeCommand(bundle)
+	if provider, err := engine.DetectComposeProvider(ctx, ""); err != nil {
+		console.Warn("No compose implementation found to run it: install the Docker Compose plugin, 'podman-compose' or 'docker-compose'")
+	} else if provider.IsFallback() {
+		console.Warn("'%s' is not available, falling back to '%s'", provider.Preferred, provider.Command)
+		composeCommand = engine.ComposeCommandWith(bundle, provider.Command)
+	}
+	console.WriteLn("Hint: Adapt its '.env' file, then run '%s run --rm %s' from that directory", composeCommand, mainService)
+	return nil
+}
+
+// Write the project definition as a bundle at `path`, with its compose export
+// so it can also be used without paul-envs.
+func exportProjectBundle(
+	project files.ProjectEntry,
+	path string,
+	force bool,
+	filestore *files.FileStore,
+	console *console.Console,
+) error {
+	name := project.ProjectName
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("cannot export project '%s': '%s' already exists\nHint: Use '--force' to overwrite it", name, path)
+	}
+	compose, err := engine.ShareableComposeBundle(project)
+	if err != nil {
+		return fmt.Errorf("cannot export project '%s': %w", name, err)
+	}
+	extraFiles := make([]files.ArchiveFile, 0, len(compose.Files))
+	for _, fileName := range slices.Sorted(maps.Keys(compose.Files)) {
+		extraFiles = append(extraFiles, files.ArchiveFile{Name: fileName, Data: compose.Files[fileName], Mode: 0644})
+	}
+	stripped, err := filestore.ExportProjectBundle(name, compose.Dirs["dotfiles"], extraFiles, path)
+	if err != nil {
+		return fmt.Errorf("cannot export project '%s': %w", name, err)
+	}
+	console.Success("Exported project '%s' to %s", name, path)
+	if len(stripped) > 0 {
+		console.WriteLn("Stripped from it: %s", strings.Join(stripped, ", "))
+	}
+	console.WriteLn("Hint: Recreate it on another machine with 'paul-envs import %s --path <project-directory>'", filepath.Base(path))
+	return nil
+}
+
+// Write the Kubernetes YAML of the project at `path`, then run it with
+// `podman kube play` if `play` is set.
+func exportProjectKube(
+	ctx context.Context,
+	project files.ProjectEntry,
+	path string,
+	force bool,
+	deployment bool,
+	play bool,
+	filestore *files.FileStore,
+	console *console.Console,
+) error {
+	name := project.ProjectName
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("cannot export project '%s': '%s' already exists\nHint: Use '--force' to overwrite it", name, path)
+	}
+	// Checked first, not to write the file if it cannot be played
+	var containerEngine engine.ContainerEngine
+	if play {
+		var err error
+		if containerEngine, _, err = newProjectEngine(ctx, name, engine.SelectionAuto, filestore, console); err != nil {
+			return err
+		}
+		if !containerEngine.Capabilities(ctx).KubePlay {
+			return fmt.Errorf("--play needs an engine able to run Kubernetes YAML, such as Podman 4.2 or later, which did not build project '%s'\nHint: Build it with 'paul-envs build --engine podman %s'", name, name)
+		}
+	}
+	content, err := engine.KubeYAML(project, deployment)
+	if err != nil {
+		return fmt.Errorf("cannot export project '%s': %w", name, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("cannot export project '%s': %w", name, err)
+	}
+	console.Success("Exported project '%s' to %s", name, path)
+	if !play {
+		console.WriteLn("Hint: Run it with 'podman kube play %s', once built with Podman", path)
+		return nil
+	}
+	return containerEngine.PlayKube(ctx, path)
+}
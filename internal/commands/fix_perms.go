@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Maximum number of entries of a project's directory checked for files owned
+// by another user once its container exits, not to delay that exit on huge
+// directories.
+const postRunOwnershipScanLimit = 20000
+
+func FixPerms(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var noPrompt bool
+	var engineSelection string
+	flagset := newCommandFlagSet("fix-perms", console)
+	flagset.BoolVar(&noPrompt, "no-prompt", false, "Non-interactive mode: fix without asking for confirmation")
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to use: docker or podman.\nDefault: the one the project was built with.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs fix-perms [flags] [project-name]",
+			"Give the files of a project's directory owned by another user back to you, e.g. those owned by root or by unexpected IDs after switching between rootful and rootless engines or changing USERNS, which you cannot modify nor remove anymore.\n\nThey are found from the host, then changed from a throwaway container running as root, through the shared base image.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) > 1 {
+		return utils.WithCategory(errors.New("fix-perms takes at most one project name"), errUsage)
+	}
+	name, err := getProjectName(args, filestore, console, "fix the permissions of")
+	if err != nil {
+		return err
+	}
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+	requestedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+	project, err := filestore.GetProject(name)
+	if err != nil {
+		return fmt.Errorf("failed to obtain information on project '%s': %w", name, err)
+	}
+
+	foreign, err := engine.FindForeignOwnedFiles(project.ProjectPath, 0)
+	if err != nil {
+		return err
+	}
+	if foreign.Count == 0 {
+		console.Success("All files of project '%s' in %s belong to you", name, project.ProjectPath)
+		return nil
+	}
+	console.WriteLn("%d files of project '%s' in %s belong to another user:", foreign.Count, name, project.ProjectPath)
+	writeForeignOwnedExamples(console, foreign)
+	choice, err := yesNoWithOptionalPrompt(console, noPrompt, "Give them back to you?", true)
+	if err != nil {
+		return err
+	} else if !choice {
+		console.WriteLn("Skipping fixes")
+		return nil
+	}
+
+	containerEngine, _, err := newProjectEngine(ctx, name, requestedEngine, filestore, console)
+	if err != nil {
+		return err
+	}
+	if err := containerEngine.FixOwnership(ctx, project.ProjectPath); err != nil {
+		return err
+	}
+	remaining, err := engine.FindForeignOwnedFiles(project.ProjectPath, 0)
+	if err != nil {
+		return err
+	}
+	if remaining.Count > 0 {
+		console.WriteLn("%d files still belong to another user:", remaining.Count)
+		writeForeignOwnedExamples(console, remaining)
+		return fmt.Errorf("could not give all files of project '%s' back to you\n"+
+			"Hint: Their owners may be unknown to the engine's user namespace, 'sudo chown -R \"$(id -u):$(id -g)\" %s' also fixes them", name, project.ProjectPath)
+	}
+	console.Success("Gave %d files of project '%s' back to you", foreign.Count, name)
+	return nil
+}
+
+func writeForeignOwnedExamples(console *console.Console, foreign engine.ForeignOwnership) {
+	for _, example := range foreign.Examples {
+		console.WriteLn("  • %s", example)
+	}
+	if foreign.Count > len(foreign.Examples) {
+		console.WriteLn("  ...")
+	}
+}
+
+// Once the container of a project exits, warn if it left files owned by
+// another user in its directory.
+func warnForeignOwnedFiles(project files.ProjectEntry, console *console.Console) {
+	foreign, err := engine.FindForeignOwnedFiles(project.ProjectPath, postRunOwnershipScanLimit)
+	if err != nil || foreign.Count == 0 {
+		return
+	}
+	console.Warn("%d files of project '%s' belong to another user than you, e.g. %s", foreign.Count, project.ProjectName, foreign.Examples[0])
+	console.WriteLn("Hint: Give them back to you with 'paul-envs fix-perms %s'", project.ProjectName)
+}
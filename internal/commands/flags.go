@@ -1,10 +1,13 @@
 package commands
 
 import (
+	"errors"
 	"flag"
+	"strings"
 
 	"github.com/peaberberian/paul-envs/internal/clihelp"
 	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/utils"
 )
 
 func newCommandFlagSet(name string, console *console.Console) *flag.FlagSet {
@@ -14,7 +17,11 @@ func newCommandFlagSet(name string, console *console.Console) *flag.FlagSet {
 }
 
 func parseCommandFlags(flagset *flag.FlagSet, args []string) error {
-	return flagset.Parse(args)
+	err := flagset.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return utils.WithCategory(err, errUsage)
+	}
+	return err
 }
 
 func writeCommandUsage(
@@ -45,3 +52,15 @@ func hasFlags(flagset *flag.FlagSet) bool {
 	})
 	return hasAny
 }
+
+// Flag which can be repeated, each value being appended to the list.
+type stringListFlag []string
+
+func (s *stringListFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringListFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
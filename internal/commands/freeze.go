@@ -0,0 +1,215 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Freeze(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var engineSelection string
+	flagset := newCommandFlagSet("freeze", console)
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to use, which has to be rootful Podman.\nDefault: the one used to build the project.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs freeze [project-name] [flags]",
+			"Save the running container of a project, with its processes and their memory, then stop it. 'paul-envs thaw' resumes it where it was, even after a reboot, e.g. to keep a long session with warm caches.\n\nThis relies on CRIU, through rootful Podman. Volumes are not part of it, and the project's services are stopped then started again from scratch. Containers started with 'paul-envs run' lose their terminal: join them again with 'run' once thawed.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	name, containerEngine, unlock, err := prepareCheckpointCommand(ctx, "freeze", flagset.Args(), engineSelection, filestore, console)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := checkCheckpointCapability(ctx, "freeze", name, containerEngine); err != nil {
+		return err
+	}
+	return freezeProject(ctx, name, containerEngine, filestore, console)
+}
+
+// Checkpoint the running default container of that project, then remove it
+// and its services if no other instance uses them.
+func freezeProject(
+	ctx context.Context,
+	name string,
+	containerEngine engine.ContainerEngine,
+	filestore *files.FileStore,
+	console *console.Console,
+) error {
+	if filestore.HasProjectCheckpoint(name) {
+		return fmt.Errorf("project '%s' is already frozen\nHint: Resume it with 'paul-envs thaw %s', or drop its checkpoint with 'paul-envs thaw %s --discard'", name, name, name)
+	}
+
+	containers, err := containerEngine.ListContainers(ctx)
+	if err != nil {
+		return fmt.Errorf("could not list containers: %w", err)
+	}
+	var container *engine.ContainerInfo
+	for _, c := range engine.ProjectInstances(containers, name) {
+		if c.Running && c.Instance == "" {
+			container = &c
+		}
+	}
+	if container == nil {
+		return fmt.Errorf("project '%s' has no running container to freeze\nHint: Start it with 'paul-envs run %s'", name, name)
+	}
+
+	if err := filestore.CreateCheckpointsDir(); err != nil {
+		return err
+	}
+	checkpointPath := filestore.GetProjectCheckpointPath(name)
+	console.Info("Freezing the container of project '%s'...", name)
+	if err := containerEngine.CheckpointContainer(ctx, *container, checkpointPath); err != nil {
+		// A partial archive would not be restorable
+		if rmErr := filestore.RemoveProjectCheckpoint(name); rmErr != nil {
+			console.Warn("%s", rmErr)
+		}
+		return fmt.Errorf("cannot freeze project '%s': %w", name, err)
+	}
+	// Restoring recreates it, under the same name
+	if err := containerEngine.RemoveContainer(ctx, *container); err != nil && !errors.Is(err, engine.ErrNotFound) {
+		console.Warn("Could not remove the stopped container of project '%s': %s", name, err)
+	}
+	if !hasRunningInstances(ctx, name, containerEngine) {
+		if err := stopProjectSidecars(ctx, name, containerEngine, console); err != nil {
+			console.Warn("Could not stop the services of project '%s': %s", name, err)
+		}
+	}
+	console.Success("Froze project '%s' to %s", name, checkpointPath)
+	console.WriteLn("Resume it with 'paul-envs thaw %s'.", name)
+	return nil
+}
+
+func Thaw(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var engineSelection string
+	var discard bool
+	flagset := newCommandFlagSet("thaw", console)
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to use, which has to be rootful Podman.\nDefault: the one used to build the project.")
+	flagset.BoolVar(&discard, "discard", false, "Remove the checkpoint written by 'freeze' instead of resuming it")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs thaw [project-name] [flags]",
+			"Resume the container of a project saved by 'paul-envs freeze', with the processes it was running, in the background. Join it afterwards with 'paul-envs run'.\n\nIts image has to be the one it was frozen with: rebuilding or cleaning the project in between makes its checkpoint unusable.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	name, containerEngine, unlock, err := prepareCheckpointCommand(ctx, "thaw", flagset.Args(), engineSelection, filestore, console)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if !filestore.HasProjectCheckpoint(name) {
+		return fmt.Errorf("project '%s' is not frozen\nHint: Freeze its running container with 'paul-envs freeze %s'", name, name)
+	}
+	if discard {
+		if err := filestore.RemoveProjectCheckpoint(name); err != nil {
+			return err
+		}
+		console.Success("Discarded the checkpoint of project '%s'", name)
+		return nil
+	}
+	if err := checkCheckpointCapability(ctx, "thaw", name, containerEngine); err != nil {
+		return err
+	}
+	if container, err := findRunningProjectContainer(ctx, containerEngine, name); err != nil {
+		return err
+	} else if container != nil && container.Instance == "" {
+		return fmt.Errorf("project '%s' is already running\nHint: Stop it first, or drop its checkpoint with 'paul-envs thaw %s --discard'", name, name)
+	}
+
+	project, err := filestore.GetProject(name)
+	if err != nil {
+		return fmt.Errorf("failed to obtain information on project '%s': %w", name, err)
+	}
+	runtimeCfg, err := config.LoadRuntimeConfig(project.RuntimeConfigPath)
+	if err != nil {
+		return fmt.Errorf("cannot thaw project '%s': %w", name, err)
+	}
+	if err := prepareProjectRuntimeFiles(project, runtimeCfg, filestore); err != nil {
+		return err
+	}
+	if err := startProjectSidecars(ctx, project, runtimeCfg, nil, containerEngine, console); err != nil {
+		return err
+	}
+	console.Info("Thawing the container of project '%s'...", name)
+	if err := containerEngine.RestoreContainer(ctx, project, filestore.GetProjectCheckpointPath(name)); err != nil {
+		// Kept, so it can be restored once the issue is fixed
+		return fmt.Errorf("cannot thaw project '%s': %w\nHint: Its checkpoint is kept, 'paul-envs thaw %s --discard' removes it", name, err, name)
+	}
+	if err := filestore.RemoveProjectCheckpoint(name); err != nil {
+		console.Warn("%s", err)
+	}
+	console.Success("Thawed project '%s', it runs in the background", name)
+	console.WriteLn("Join it with 'paul-envs run %s'.", name)
+	return nil
+}
+
+// Common set up of `freeze` and `thaw`: returns the name of the project they
+// apply to, locked, and its container engine.
+func prepareCheckpointCommand(
+	ctx context.Context,
+	command string,
+	args []string,
+	engineSelection string,
+	filestore *files.FileStore,
+	console *console.Console,
+) (string, engine.ContainerEngine, func(), error) {
+	if len(args) > 1 {
+		return "", nil, nil, utils.WithCategory(fmt.Errorf("%s takes at most one project name", command), errUsage)
+	}
+	name, err := getProjectName(args, filestore, console, command)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if err := validateProjectName(name); err != nil {
+		return "", nil, nil, err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return "", nil, nil, projectNotFoundError(name)
+	}
+	requestedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	unlock, err := lockProject(ctx, name, filestore, console)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	containerEngine, _, err := newProjectEngine(ctx, name, requestedEngine, filestore, console)
+	if err != nil {
+		unlock()
+		return "", nil, nil, err
+	}
+	return name, containerEngine, unlock, nil
+}
+
+// Fails, before anything is done, if that engine cannot checkpoint containers.
+func checkCheckpointCapability(ctx context.Context, command string, name string, containerEngine engine.ContainerEngine) error {
+	if containerEngine.Capabilities(ctx).Checkpoint {
+		return nil
+	}
+	return fmt.Errorf("cannot %s project '%s': %w: its container engine cannot run CRIU, only rootful Podman can\n"+
+		"Hint: Use rootful Podman with '--engine %s'", command, name, engine.ErrCheckpointUnsupported, engine.SelectionPodmanRootful)
+}
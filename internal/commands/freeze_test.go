@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestFreezeProject(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	store, err := files.NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	demo := "demo"
+	testsName, defaultName := "paulenv-demo..tests", "paulenv-demo"
+	containers := []engine.ContainerInfo{
+		{ProjectName: &demo, ContainerName: &testsName, ContainerId: "tests", Instance: "tests", Running: true},
+		{ProjectName: &demo, ContainerName: &defaultName, ContainerId: "default", Running: true},
+	}
+	sidecars := []engine.SidecarInfo{{ProjectName: "demo", ServiceName: "db", ContainerId: "db", Running: true}}
+	var out bytes.Buffer
+	cons := console.New(context.Background(), strings.NewReader(""), &out, &out)
+
+	fake := &engine.FakeEngine{Containers: containers, Sidecars: sidecars}
+	if err := freezeProject(context.Background(), "demo", fake, store, cons); err != nil {
+		t.Fatalf("freezeProject() error = %v", err)
+	}
+	checkpoints := fake.CallsTo("CheckpointContainer")
+	if len(checkpoints) != 1 || checkpoints[0].Args[0].(engine.ContainerInfo).ContainerId != "default" ||
+		checkpoints[0].Args[1] != store.GetProjectCheckpointPath("demo") {
+		t.Fatalf("freezeProject() should checkpoint the default instance's container, got %v", checkpoints)
+	}
+	if removed := fake.CallsTo("RemoveContainer"); len(removed) != 1 || removed[0].Args[0].(engine.ContainerInfo).ContainerId != "default" {
+		t.Fatalf("freezeProject() should remove the checkpointed container, removed %v", removed)
+	}
+	if calls := fake.CallsTo("RemoveSidecar"); len(calls) != 0 {
+		t.Fatalf("freezeProject() should keep services used by another instance, removed %v", calls)
+	}
+
+	// As written by the engine
+	if err := os.WriteFile(store.GetProjectCheckpointPath("demo"), []byte("checkpoint"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = freezeProject(context.Background(), "demo", &engine.FakeEngine{Containers: containers}, store, cons)
+	if err == nil || !strings.Contains(err.Error(), "already frozen") {
+		t.Fatalf("freezeProject() of a frozen project error = %v", err)
+	}
+	if err := store.RemoveProjectCheckpoint("demo"); err != nil || store.HasProjectCheckpoint("demo") {
+		t.Fatalf("RemoveProjectCheckpoint() error = %v", err)
+	}
+
+	err = freezeProject(context.Background(), "demo", &engine.FakeEngine{Containers: containers[:1]}, store, cons)
+	if err == nil || !strings.Contains(err.Error(), "no running container") {
+		t.Fatalf("freezeProject() without a running default container error = %v", err)
+	}
+}
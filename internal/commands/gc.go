@@ -0,0 +1,530 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func GC(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var dryRun bool
+	var noPrompt bool
+	var olderThan string
+	var engineSelection string
+	flagset := newCommandFlagSet("gc", console)
+	flagset.BoolVar(&dryRun, "dry-run", false, "Only display what would be removed")
+	flagset.BoolVar(&noPrompt, "no-prompt", false, "Non-interactive mode: remove without asking for confirmation")
+	flagset.StringVar(&olderThan, "older-than", "", "Also remove project images, current or unpinned ones kept for rollbacks,\nbuilt before that age (e.g. 30d, 2w, 12h), alongside their stopped containers")
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to collect from: docker, podman, or all. Default: all available engines.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs gc [project-name|@group...] [flags]",
+			"Remove containers, images, volumes and networks belonging to projects which do not exist anymore, previous images not kept by their project's retention policy, and optionally project images older than a given age.\n\nIf project names or groups of projects ('@group', see 'paul-envs group') are given, only the resources of those projects are considered.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	// The global flag (`paul-envs --dry-run gc`) has the same meaning
+	dryRun = dryRun || engine.IsDryRun()
+	// Resources of projects to collect, of all of them if nil
+	var scope map[string]bool
+	if len(flagset.Args()) > 0 {
+		names, err := resolveProjectSelectors(flagset.Args(), filestore)
+		if err != nil {
+			return err
+		}
+		scope = make(map[string]bool, len(names))
+		for _, name := range names {
+			// Those of deleted projects can be given too
+			if err := validateProjectName(name); err != nil {
+				return err
+			}
+			scope[name] = true
+		}
+	}
+
+	var maxAge time.Duration
+	if olderThan != "" {
+		var err error
+		maxAge, err = parseAgeThreshold(olderThan)
+		if err != nil {
+			return err
+		}
+	}
+	selectedEngine, err := parseCleanEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+	if selectedEngine == engine.SelectionAuto {
+		selectedEngine = engine.SelectionAll
+	}
+
+	// Projects must not be created while resources are attributed to them
+	unlock, err := lockRegistry(ctx, filestore, console)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	projects, retentions, sharedVolumes, err := loadGarbageOwners(filestore, console)
+	if err != nil {
+		return err
+	}
+
+	containerEngines, err := engine.NewSet(ctx, console, selectedEngine)
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	now := time.Now()
+	for _, containerEngine := range containerEngines {
+		writeEngineSection(console, containerEngine)
+		resources, err := listEngineResources(ctx, containerEngine, engineQueryParallelism(filestore))
+		if err != nil {
+			return err
+		}
+		candidates := findGarbage(projects, retentions, sharedVolumes, resources, maxAge, now)
+		if scope != nil {
+			candidates = slices.DeleteFunc(candidates, func(c gcCandidate) bool {
+				return !scope[c.project]
+			})
+		}
+		if len(candidates) == 0 {
+			console.WriteLn("  Nothing to remove")
+			continue
+		}
+		for _, candidate := range candidates {
+			console.WriteLn("  • %s", candidate.String())
+		}
+		total += len(candidates)
+		if dryRun {
+			continue
+		}
+
+		choice, err := yesNoWithOptionalPrompt(console, noPrompt, fmt.Sprintf("Remove those %d resources?", len(candidates)), true)
+		if err != nil {
+			return err
+		} else if !choice {
+			console.WriteLn("Skipping removal")
+			continue
+		}
+		for _, candidate := range candidates {
+			if err := candidate.remove(ctx, containerEngine); err != nil {
+				console.Warn("    WARNING: failed to remove %s '%s': %v", candidate.kind, candidate.name, err)
+			}
+		}
+	}
+
+	if dryRun {
+		console.Success("\nDry run: %d resources would be removed", total)
+		return nil
+	}
+	console.Success("\nGarbage collection complete!")
+	return nil
+}
+
+// Returns the existing projects, to which resources can belong, the image
+// retention policy of each and the shared volumes they declare, `nil` if the
+// run.conf of one of them could not be read.
+func loadGarbageOwners(filestore *files.FileStore, console *console.Console) (map[string]bool, map[string]imageRetention, map[string]bool, error) {
+	entries, err := filestore.GetAllProjects()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("could not list all projects: %w", err)
+	}
+	projects := make(map[string]bool, len(entries))
+	retentions := make(map[string]imageRetention, len(entries))
+	sharedVolumes := map[string]bool{}
+	for _, entry := range entries {
+		projects[entry.ProjectName] = true
+		if sharedVolumes != nil {
+			runtimeCfg, err := config.LoadRuntimeConfig(entry.RuntimeConfigPath)
+			if err != nil {
+				console.Warn("Could not obtain the shared volumes of project '%s', keeping all of them: %s", entry.ProjectName, err)
+				sharedVolumes = nil
+			} else {
+				for _, volume := range runtimeCfg.SharedVolumes {
+					sharedVolumes[volume.Name] = true
+				}
+			}
+		}
+		retention, err := projectImageRetention(entry, filestore)
+		if err != nil {
+			console.Warn("Could not obtain the image retention policy of project '%s': %s", entry.ProjectName, err)
+			continue
+		}
+		retentions[entry.ProjectName] = retention
+	}
+	return projects, retentions, sharedVolumes, nil
+}
+
+// All paul-envs resources known by a container engine.
+type engineResources struct {
+	containers  []engine.ContainerInfo
+	images      []engine.ImageInfo
+	generations []engine.GenerationInfo
+	archImages  []engine.ArchImageInfo
+	snapshots   []engine.SnapshotInfo
+	sidecars    []engine.SidecarInfo
+	volumes     []engine.VolumeInfo
+	networks    []engine.NetworkInfo
+}
+
+func listEngineResources(ctx context.Context, containerEngine engine.ContainerEngine, parallelism int) (engineResources, error) {
+	var resources engineResources
+	err := runEngineQueries(ctx, parallelism,
+		func(ctx context.Context) (err error) {
+			if resources.containers, err = containerEngine.ListContainers(ctx); err != nil {
+				return fmt.Errorf("cannot list current containers: %w", err)
+			}
+			return nil
+		},
+		func(ctx context.Context) (err error) {
+			if resources.images, err = containerEngine.ListImages(ctx); err != nil {
+				return fmt.Errorf("cannot list current images: %w", err)
+			}
+			return nil
+		},
+		func(ctx context.Context) (err error) {
+			if resources.generations, err = containerEngine.ListGenerations(ctx); err != nil {
+				return fmt.Errorf("cannot list previous images: %w", err)
+			}
+			return nil
+		},
+		func(ctx context.Context) (err error) {
+			if resources.archImages, err = containerEngine.ListArchImages(ctx); err != nil {
+				return fmt.Errorf("cannot list architecture-specific images: %w", err)
+			}
+			return nil
+		},
+		func(ctx context.Context) (err error) {
+			if resources.snapshots, err = containerEngine.ListSnapshots(ctx); err != nil {
+				return fmt.Errorf("cannot list snapshots: %w", err)
+			}
+			return nil
+		},
+		func(ctx context.Context) (err error) {
+			if resources.sidecars, err = containerEngine.ListSidecars(ctx); err != nil {
+				return fmt.Errorf("cannot list services: %w", err)
+			}
+			return nil
+		},
+		func(ctx context.Context) (err error) {
+			if resources.volumes, err = containerEngine.ListVolumes(ctx); err != nil {
+				return fmt.Errorf("cannot list current volumes: %w", err)
+			}
+			return nil
+		},
+		func(ctx context.Context) (err error) {
+			if resources.networks, err = containerEngine.ListNetworks(ctx); err != nil {
+				return fmt.Errorf("cannot list current networks: %w", err)
+			}
+			return nil
+		},
+	)
+	return resources, err
+}
+
+// A resource which can be garbage collected.
+type gcCandidate struct {
+	kind   string
+	name   string
+	reason string
+	// Project it belongs to, empty if it is shared between projects
+	project string
+	// Disk usage reported by the container engine, empty if unknown
+	size   string
+	remove func(ctx context.Context, containerEngine engine.ContainerEngine) error
+}
+
+func (c gcCandidate) String() string {
+	if c.size != "" {
+		return fmt.Sprintf("%s '%s' (%s, %s)", c.kind, c.name, c.reason, c.size)
+	}
+	return fmt.Sprintf("%s '%s' (%s)", c.kind, c.name, c.reason)
+}
+
+// Select resources belonging to projects not in `projects`, previous images
+// of remaining projects their retention policy (in `retentions`) does not keep
+// and, if `maxAge` is not `0`, project images (current and unpinned previous
+// ones) built more than `maxAge` ago with their stopped containers.
+//
+// Resources shared by all projects (base image, cache volume) are only selected
+// when no project is left, shared volumes when no project declares them
+// anymore, as listed in `sharedVolumes` (all of them being kept if it is
+// `nil`). Running containers, and the images they rely on, are never selected.
+func findGarbage(
+	projects map[string]bool,
+	retentions map[string]imageRetention,
+	sharedVolumes map[string]bool,
+	resources engineResources,
+	maxAge time.Duration,
+	now time.Time,
+) []gcCandidate {
+	noProjectLeft := len(projects) == 0
+	running := map[string]bool{}
+	for _, container := range resources.containers {
+		if container.Running && container.ProjectName != nil {
+			running[*container.ProjectName] = true
+		}
+	}
+
+	// Reason for which a project's image should be removed, if it should
+	imageReasons := map[string]string{}
+	candidates := []gcCandidate{}
+	images := []gcCandidate{}
+	for _, image := range resources.images {
+		reason := ""
+		if image.ProjectName == nil {
+			if noProjectLeft && len(running) == 0 {
+				reason = "no project left"
+			}
+		} else if running[*image.ProjectName] {
+			continue
+		} else if !projects[*image.ProjectName] {
+			reason = "project deleted"
+		} else if maxAge > 0 && image.BuiltAt != nil && now.Sub(*image.BuiltAt) > maxAge {
+			reason = "built " + formatImageAge(image.BuiltAt, now)
+		}
+		if reason == "" {
+			continue
+		}
+		project := ""
+		if image.ProjectName != nil {
+			project = *image.ProjectName
+			imageReasons[project] = reason
+		}
+		images = append(images, gcCandidate{
+			kind:    "image",
+			name:    image.ImageName,
+			reason:  reason,
+			project: project,
+			size:    image.Size,
+			remove: func(ctx context.Context, c engine.ContainerEngine) error {
+				return c.RemoveImage(ctx, image)
+			},
+		})
+	}
+
+	for _, container := range resources.containers {
+		if container.Running || container.ProjectName == nil {
+			continue
+		}
+		reason := ""
+		if !projects[*container.ProjectName] {
+			reason = "project deleted"
+		} else if imageReason, ok := imageReasons[*container.ProjectName]; ok {
+			reason = "image " + imageReason
+		} else {
+			continue
+		}
+		name := container.ContainerId
+		if container.ContainerName != nil {
+			name = *container.ContainerName
+		}
+		candidates = append(candidates, gcCandidate{
+			kind:    "container",
+			name:    name,
+			reason:  reason,
+			project: *container.ProjectName,
+			remove: func(ctx context.Context, c engine.ContainerEngine) error {
+				return c.RemoveContainer(ctx, container)
+			},
+		})
+	}
+	for _, sidecar := range resources.sidecars {
+		if projects[sidecar.ProjectName] || running[sidecar.ProjectName] {
+			continue
+		}
+		candidates = append(candidates, gcCandidate{
+			kind:    "service container",
+			name:    sidecar.ContainerName,
+			reason:  "project deleted",
+			project: sidecar.ProjectName,
+			remove: func(ctx context.Context, c engine.ContainerEngine) error {
+				return c.RemoveSidecar(ctx, sidecar)
+			},
+		})
+	}
+	candidates = append(candidates, images...)
+
+	generationsByProject := map[string][]engine.GenerationInfo{}
+	for _, generation := range resources.generations {
+		generationsByProject[generation.ProjectName] = append(generationsByProject[generation.ProjectName], generation)
+	}
+	beyondRetention := map[string]bool{}
+	for projectName, generations := range generationsByProject {
+		retention, ok := retentions[projectName]
+		if !ok {
+			continue
+		}
+		slices.SortFunc(generations, func(a, b engine.GenerationInfo) int {
+			return b.Number - a.Number
+		})
+		for _, generation := range retention.expired(generations) {
+			beyondRetention[generation.ImageName] = true
+		}
+	}
+
+	for _, generation := range resources.generations {
+		reason := ""
+		if !projects[generation.ProjectName] && !running[generation.ProjectName] {
+			reason = "project deleted"
+		} else if beyondRetention[generation.ImageName] {
+			reason = "beyond retention policy"
+		} else if retentions[generation.ProjectName].pinned[generation.Number] {
+			continue
+		} else if maxAge > 0 && generation.BuiltAt != nil && now.Sub(*generation.BuiltAt) > maxAge {
+			reason = "built " + formatImageAge(generation.BuiltAt, now)
+		}
+		if reason == "" {
+			continue
+		}
+		candidates = append(candidates, gcCandidate{
+			kind:    "previous image",
+			name:    generation.ImageName,
+			reason:  reason,
+			project: generation.ProjectName,
+			size:    generation.Size,
+			remove: func(ctx context.Context, c engine.ContainerEngine) error {
+				return c.RemoveGeneration(ctx, generation)
+			},
+		})
+	}
+
+	for _, image := range resources.archImages {
+		if projects[image.ProjectName] || running[image.ProjectName] {
+			continue
+		}
+		candidates = append(candidates, gcCandidate{
+			kind:    "architecture image",
+			name:    image.ImageName,
+			reason:  "project deleted",
+			project: image.ProjectName,
+			size:    image.Size,
+			remove: func(ctx context.Context, c engine.ContainerEngine) error {
+				return c.RemoveArchImage(ctx, image)
+			},
+		})
+	}
+
+	// Snapshots are taken on purpose, they are only removed with their project
+	for _, snapshot := range resources.snapshots {
+		if projects[snapshot.ProjectName] || running[snapshot.ProjectName] {
+			continue
+		}
+		candidates = append(candidates, gcCandidate{
+			kind:    "snapshot",
+			name:    snapshot.ImageName,
+			reason:  "project deleted",
+			project: snapshot.ProjectName,
+			size:    snapshot.Size,
+			remove: func(ctx context.Context, c engine.ContainerEngine) error {
+				return c.RemoveSnapshot(ctx, snapshot)
+			},
+		})
+	}
+
+	for _, volume := range resources.volumes {
+		reason := ""
+		project := ""
+		// Shared caches are not tied to any project
+		if engine.IsSharedCacheVolume(volume.VolumeName) {
+			if noProjectLeft && len(running) == 0 {
+				reason = "no project left"
+			}
+		} else if name, ok := engine.SharedVolumeFromName(volume.VolumeName); ok {
+			if sharedVolumes != nil && !sharedVolumes[name] {
+				reason = "no project declares it"
+			}
+		} else if projectName, ok := projectNameFromLocalVolume(volume.VolumeName); ok && !projects[projectName] && !running[projectName] {
+			reason = "project deleted"
+			project = projectName
+		}
+		if reason == "" {
+			continue
+		}
+		candidates = append(candidates, gcCandidate{
+			kind:    "volume",
+			name:    volume.VolumeName,
+			reason:  reason,
+			project: project,
+			remove: func(ctx context.Context, c engine.ContainerEngine) error {
+				return c.RemoveVolume(ctx, volume)
+			},
+		})
+	}
+
+	for _, network := range resources.networks {
+		reason := "project deleted"
+		project := ""
+		if network.ProjectName == nil {
+			// The network shared between projects
+			if !noProjectLeft || len(running) > 0 {
+				continue
+			}
+			reason = "no project left"
+		} else if projects[*network.ProjectName] || running[*network.ProjectName] {
+			continue
+		} else {
+			project = *network.ProjectName
+		}
+		candidates = append(candidates, gcCandidate{
+			kind:    "network",
+			name:    network.NetworkName,
+			reason:  reason,
+			project: project,
+			remove: func(ctx context.Context, c engine.ContainerEngine) error {
+				return c.RemoveNetwork(ctx, network)
+			},
+		})
+	}
+	return candidates
+}
+
+// Returns the project owning the given local volume, which is either its own
+// (`paulenv-<project>-local`) or one of its sidecars' data volumes
+// (`paulenv-<project>.<service>-local`).
+func projectNameFromLocalVolume(volumeName string) (string, bool) {
+	name, ok := strings.CutPrefix(volumeName, "paulenv-")
+	if !ok {
+		return "", false
+	}
+	name, ok = strings.CutSuffix(name, "-local")
+	name, _, _ = strings.Cut(name, ".")
+	return name, ok && name != ""
+}
+
+// Parse an age such as "30d", "2w" or any duration understood by
+// `time.ParseDuration` (e.g. "12h").
+func parseAgeThreshold(value string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if num, ok := strings.CutSuffix(value, suffix); ok {
+			n, err := strconv.Atoi(num)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid --older-than value %q. Expected e.g. 30d, 2w or 12h", value)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid --older-than value %q. Expected e.g. 30d, 2w or 12h", value)
+	}
+	return d, nil
+}
@@ -0,0 +1,180 @@
+package commands
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/engine"
+)
+
+func TestFindGarbage(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-40 * 24 * time.Hour)
+	recent := now.Add(-2 * time.Hour)
+	str := func(s string) *string { return &s }
+
+	resources := engineResources{
+		containers: []engine.ContainerInfo{
+			{ProjectName: str("gone"), ContainerName: str("paulenv-gone"), ContainerId: "1"},
+			{ProjectName: str("old"), ContainerName: str("paulenv-old"), ContainerId: "2"},
+			{ProjectName: str("busy"), ContainerName: str("paulenv-busy"), ContainerId: "3", Running: true},
+			{ProjectName: str("fresh"), ContainerName: str("paulenv-fresh"), ContainerId: "4"},
+		},
+		images: []engine.ImageInfo{
+			{ImageName: "paulenv-base:latest", BuiltAt: &old},
+			{ProjectName: str("gone"), ImageName: "paulenv:gone", BuiltAt: &recent},
+			{ProjectName: str("old"), ImageName: "paulenv:old", BuiltAt: &old},
+			{ProjectName: str("busy"), ImageName: "paulenv:busy", BuiltAt: &old},
+			{ProjectName: str("fresh"), ImageName: "paulenv:fresh", BuiltAt: &recent},
+		},
+		generations: []engine.GenerationInfo{
+			{ProjectName: "gone", Number: 1, ImageName: "paulenv-generation:gone.1", BuiltAt: &recent},
+			{ProjectName: "old", Number: 3, ImageName: "paulenv-generation:old.3", BuiltAt: &old},
+			{ProjectName: "fresh", Number: 1, ImageName: "paulenv-generation:fresh.1", BuiltAt: &recent},
+		},
+		snapshots: []engine.SnapshotInfo{
+			{ProjectName: "gone", Tag: "v1", ImageName: "paulenv-snapshot:gone.v1", CreatedAt: &recent},
+			{ProjectName: "old", Tag: "v1", ImageName: "paulenv-snapshot:old.v1", CreatedAt: &old},
+		},
+		volumes: []engine.VolumeInfo{
+			{VolumeName: "paulenv-shared-cache"},
+			{VolumeName: "paulenv-cache-npm"},
+			{VolumeName: "paulenv-gone-local"},
+			{VolumeName: "paulenv-old-local"},
+			{VolumeName: "paulenv-gone.db-local"},
+			{VolumeName: "paulenv-old.db-local"},
+			{VolumeName: "paulenv-shared.datasets"},
+			{VolumeName: "paulenv-shared.scratch"},
+		},
+		sidecars: []engine.SidecarInfo{
+			{ProjectName: "gone", ServiceName: "db", ContainerName: "paulenv-gone.db", Running: true},
+			{ProjectName: "old", ServiceName: "db", ContainerName: "paulenv-old.db", Running: true},
+		},
+		networks: []engine.NetworkInfo{
+			{ProjectName: str("gone"), NetworkName: "paulenv-gone"},
+			{ProjectName: str("fresh"), NetworkName: "paulenv-fresh"},
+			{NetworkName: "paulenv-shared"},
+		},
+	}
+	projects := map[string]bool{"old": true, "busy": true, "fresh": true}
+
+	describe := func(candidates []gcCandidate) []string {
+		result := []string{}
+		for _, candidate := range candidates {
+			result = append(result, candidate.String())
+		}
+		return result
+	}
+
+	got := describe(findGarbage(projects, nil, nil, resources, 0, now))
+	want := []string{
+		"container 'paulenv-gone' (project deleted)",
+		"service container 'paulenv-gone.db' (project deleted)",
+		"image 'paulenv:gone' (project deleted)",
+		"previous image 'paulenv-generation:gone.1' (project deleted)",
+		"snapshot 'paulenv-snapshot:gone.v1' (project deleted)",
+		"volume 'paulenv-gone-local' (project deleted)",
+		"volume 'paulenv-gone.db-local' (project deleted)",
+		"network 'paulenv-gone' (project deleted)",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("findGarbage() = %v, want %v", got, want)
+	}
+
+	got = describe(findGarbage(projects, nil, nil, resources, 30*24*time.Hour, now))
+	want = []string{
+		"container 'paulenv-gone' (project deleted)",
+		"container 'paulenv-old' (image built 40d ago)",
+		"service container 'paulenv-gone.db' (project deleted)",
+		"image 'paulenv:gone' (project deleted)",
+		"image 'paulenv:old' (built 40d ago)",
+		"previous image 'paulenv-generation:gone.1' (project deleted)",
+		"previous image 'paulenv-generation:old.3' (built 40d ago)",
+		"snapshot 'paulenv-snapshot:gone.v1' (project deleted)",
+		"volume 'paulenv-gone-local' (project deleted)",
+		"volume 'paulenv-gone.db-local' (project deleted)",
+		"network 'paulenv-gone' (project deleted)",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("findGarbage() with max age = %v, want %v", got, want)
+	}
+
+	got = describe(findGarbage(projects, nil, map[string]bool{"datasets": true}, resources, 0, now))
+	if !slices.Contains(got, "volume 'paulenv-shared.scratch' (no project declares it)") ||
+		slices.ContainsFunc(got, func(c string) bool { return strings.Contains(c, "paulenv-shared.datasets") }) {
+		t.Fatalf("findGarbage() should only select the shared volumes no project declares, got %v", got)
+	}
+
+	resources.generations = append(resources.generations,
+		engine.GenerationInfo{ProjectName: "fresh", Number: 2, ImageName: "paulenv-generation:fresh.2", BuiltAt: &recent})
+	retentions := map[string]imageRetention{
+		"old":   {keep: 2, pinned: map[int]bool{3: true}},
+		"fresh": {keep: 1},
+	}
+	got = describe(findGarbage(projects, retentions, nil, resources, 30*24*time.Hour, now))
+	want = []string{
+		"container 'paulenv-gone' (project deleted)",
+		"container 'paulenv-old' (image built 40d ago)",
+		"service container 'paulenv-gone.db' (project deleted)",
+		"image 'paulenv:gone' (project deleted)",
+		"image 'paulenv:old' (built 40d ago)",
+		"previous image 'paulenv-generation:gone.1' (project deleted)",
+		"previous image 'paulenv-generation:fresh.1' (beyond retention policy)",
+		"snapshot 'paulenv-snapshot:gone.v1' (project deleted)",
+		"volume 'paulenv-gone-local' (project deleted)",
+		"volume 'paulenv-gone.db-local' (project deleted)",
+		"network 'paulenv-gone' (project deleted)",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("findGarbage() with retention policies = %v, want %v", got, want)
+	}
+
+	// Candidates are attributed to their project, for `gc <project>`
+	for _, candidate := range findGarbage(projects, nil, nil, resources, 30*24*time.Hour, now) {
+		want := ""
+		for _, name := range []string{"gone", "old", "fresh"} {
+			if strings.Contains(candidate.name, "-"+name) || strings.Contains(candidate.name, ":"+name) {
+				want = name
+			}
+		}
+		if candidate.project != want {
+			t.Errorf("findGarbage() attributed %s to project %q, want %q", candidate, candidate.project, want)
+		}
+	}
+
+	resources.containers = nil
+	got = describe(findGarbage(map[string]bool{}, nil, nil, resources, 0, now))
+	for _, shared := range []string{
+		"image 'paulenv-base:latest' (no project left)",
+		"volume 'paulenv-shared-cache' (no project left)",
+		"volume 'paulenv-cache-npm' (no project left)",
+		"network 'paulenv-shared' (no project left)",
+	} {
+		if !slices.Contains(got, shared) {
+			t.Fatalf("findGarbage() without project = %v, want it to contain %q", got, shared)
+		}
+	}
+}
+
+func TestParseAgeThreshold(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "30d", want: 30 * 24 * time.Hour},
+		{value: "2w", want: 14 * 24 * time.Hour},
+		{value: "12h", want: 12 * time.Hour},
+		{value: "0d", wantErr: true},
+		{value: "-1h", wantErr: true},
+		{value: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseAgeThreshold(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Fatalf("parseAgeThreshold(%q) = %v, %v, want %v (error: %v)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
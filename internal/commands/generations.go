@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Returns previous generations of the given project's image, most recent
+// first.
+func listProjectGenerations(ctx context.Context, containerEngine engine.ContainerEngine, projectName string) ([]engine.GenerationInfo, error) {
+	all, err := containerEngine.ListGenerations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list previous images: %w", err)
+	}
+	var generations []engine.GenerationInfo
+	for _, generation := range all {
+		if generation.ProjectName == projectName {
+			generations = append(generations, generation)
+		}
+	}
+	slices.SortFunc(generations, func(a, b engine.GenerationInfo) int {
+		return b.Number - a.Number
+	})
+	return generations, nil
+}
+
+// Number of previous images to keep for that project, as set in its run.conf.
+func keptImageGenerations(project files.ProjectEntry) int {
+	runtimeCfg, err := config.LoadRuntimeConfig(project.RuntimeConfigPath)
+	if err != nil {
+		return config.DefaultImageGenerations
+	}
+	return runtimeCfg.KeptImageGenerations()
+}
+
+// Keep the current image of that project as a new generation before it is
+// rebuilt, if it has one and generations are kept.
+//
+// Returns the saved generation, `nil` if none was.
+func saveImageGeneration(
+	ctx context.Context,
+	project files.ProjectEntry,
+	containerEngine engine.ContainerEngine,
+) (*engine.GenerationInfo, error) {
+	if keptImageGenerations(project) == 0 {
+		return nil, nil
+	}
+	hasBeenBuilt, err := containerEngine.HasBeenBuilt(ctx, project.ProjectName)
+	if err != nil || !hasBeenBuilt {
+		return nil, err
+	}
+	generations, err := listProjectGenerations(ctx, containerEngine, project.ProjectName)
+	if err != nil {
+		return nil, err
+	}
+	number := 1
+	if len(generations) > 0 {
+		number = generations[0].Number + 1
+	}
+	generation, err := containerEngine.SaveGeneration(ctx, project.ProjectName, number)
+	if err != nil {
+		return nil, err
+	}
+	return &generation, nil
+}
+
+// Rules deciding which previous images of a project are kept.
+type imageRetention struct {
+	// Number of unpinned generations kept
+	keep int
+	// Maximum total size in bytes of kept generations, `0` for no limit
+	maxSize int64
+	// Generations never removed, by number
+	pinned map[int]bool
+}
+
+// Retention policy of that project, as set in its run.conf and through pins.
+func projectImageRetention(project files.ProjectEntry, filestore *files.FileStore) (imageRetention, error) {
+	retention := imageRetention{keep: config.DefaultImageGenerations}
+	if runtimeCfg, err := config.LoadRuntimeConfig(project.RuntimeConfigPath); err == nil {
+		retention.keep = runtimeCfg.KeptImageGenerations()
+		retention.maxSize = runtimeCfg.ImageGenerationsMaxSize
+	}
+	pinned, err := filestore.GetPinnedGenerations(project.ProjectName)
+	if err != nil {
+		return retention, err
+	}
+	retention.pinned = pinned
+	return retention, nil
+}
+
+// Select the generations, given most recent first, this policy does not keep.
+//
+// The `keep` most recent unpinned ones are kept, minus the oldest of them as
+// long as all kept ones are larger than `maxSize`. Pinned ones are always
+// kept, and count towards that size.
+func (r imageRetention) expired(generations []engine.GenerationInfo) []engine.GenerationInfo {
+	var kept, expired []engine.GenerationInfo
+	var totalSize int64
+	for _, generation := range generations {
+		if r.pinned[generation.Number] {
+			totalSize += generationSize(generation)
+		} else if len(kept) < r.keep {
+			kept = append(kept, generation)
+			totalSize += generationSize(generation)
+		} else {
+			expired = append(expired, generation)
+		}
+	}
+	for r.maxSize > 0 && totalSize > r.maxSize && len(kept) > 0 {
+		oldest := kept[len(kept)-1]
+		kept = kept[:len(kept)-1]
+		totalSize -= generationSize(oldest)
+		expired = append(expired, oldest)
+	}
+	return expired
+}
+
+// Size of that generation as reported by its container engine, `0` if unknown.
+func generationSize(generation engine.GenerationInfo) int64 {
+	size, err := utils.ParseSize(generation.Size)
+	if err != nil {
+		return 0
+	}
+	return size
+}
+
+// Remove the generations of that project's image its retention policy does
+// not keep.
+func pruneImageGenerations(
+	ctx context.Context,
+	project files.ProjectEntry,
+	filestore *files.FileStore,
+	containerEngine engine.ContainerEngine,
+	console *console.Console,
+) {
+	retention, err := projectImageRetention(project, filestore)
+	if err != nil {
+		console.Warn("Could not remove old images of project '%s': %s", project.ProjectName, err)
+		return
+	}
+	generations, err := listProjectGenerations(ctx, containerEngine, project.ProjectName)
+	if err != nil {
+		console.Warn("Could not remove old images of project '%s': %s", project.ProjectName, err)
+		return
+	}
+	for _, generation := range retention.expired(generations) {
+		if err := containerEngine.RemoveGeneration(ctx, generation); err != nil {
+			console.Warn("Could not remove old image '%s': %s", generation.ImageName, err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/engine"
+)
+
+func TestImageRetention_Expired(t *testing.T) {
+	generation := func(number int, size string) engine.GenerationInfo {
+		return engine.GenerationInfo{ProjectName: "myproject", Number: number, Size: size}
+	}
+	// Most recent first
+	generations := []engine.GenerationInfo{
+		generation(5, "1GB"),
+		generation(4, "1GB"),
+		generation(3, "2GB"),
+		generation(2, "1GB"),
+		generation(1, ""),
+	}
+	numbers := func(generations []engine.GenerationInfo) []int {
+		result := []int{}
+		for _, generation := range generations {
+			result = append(result, generation.Number)
+		}
+		slices.Sort(result)
+		return result
+	}
+
+	tests := []struct {
+		name      string
+		retention imageRetention
+		want      []int
+	}{
+		{"keep last", imageRetention{keep: 2}, []int{1, 2, 3}},
+		{"keep all", imageRetention{keep: 10}, []int{}},
+		{"pinned only", imageRetention{keep: 0, pinned: map[int]bool{2: true}}, []int{1, 3, 4, 5}},
+		{"pinned on top", imageRetention{keep: 2, pinned: map[int]bool{4: true}}, []int{1, 2}},
+		{"max size", imageRetention{keep: 10, maxSize: 3_000_000_000}, []int{1, 2, 3}},
+		{"max size with pins", imageRetention{keep: 10, maxSize: 3_000_000_000, pinned: map[int]bool{3: true}}, []int{1, 2, 4}},
+		{"pins beyond max size", imageRetention{keep: 10, maxSize: 1_000_000_000, pinned: map[int]bool{3: true}}, []int{1, 2, 4, 5}},
+	}
+	for _, tt := range tests {
+		if got := numbers(tt.retention.expired(generations)); !slices.Equal(got, tt.want) {
+			t.Fatalf("%s: expired() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
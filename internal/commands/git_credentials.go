@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/logging"
+)
+
+// Operations of git credential helpers, as the container's one forwards
+// them, and the `git credential` subcommand doing each on the host.
+var gitCredentialOperations = map[string]string{
+	"get":   "fill",
+	"store": "approve",
+	"erase": "reject",
+}
+
+// Serve the host's git credentials to the container of the given project
+// if its run.conf asks for it (`GIT_CREDENTIALS`), until the returned
+// function is called.
+//
+// Requests are answered by running `git credential` on the host, so they go
+// through its own helpers (keychains, `gh`...). Terminal prompts are disabled
+// there: git asks in the container instead when no helper has them.
+func serveGitCredentials(project files.ProjectEntry, runtimeCfg config.RuntimeConfig) (func(), error) {
+	if !runtimeCfg.GitCredentials || runtime.GOOS == "windows" {
+		return func() {}, nil
+	}
+	socketPath := filepath.Join(project.GitCredentialsDir, files.GitCredentialsSocketName)
+	// Left by a `run` which did not end normally
+	_ = os.Remove(socketPath)
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serve git credentials to project '%s': %w", project.ProjectName, err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(handleGitCredentialRequest)}
+	go server.Serve(listener)
+	return func() {
+		server.Close()
+		_ = os.Remove(socketPath)
+	}, nil
+}
+
+func handleGitCredentialRequest(w http.ResponseWriter, r *http.Request) {
+	operation, ok := gitCredentialOperations[strings.TrimPrefix(r.URL.Path, "/")]
+	if r.Method != http.MethodPost || !ok {
+		http.NotFound(w, r)
+		return
+	}
+	cmd := exec.CommandContext(r.Context(), "git", "credential", operation)
+	cmd.Stdin = io.LimitReader(r.Body, 64<<10)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	output, err := cmd.Output()
+	if err != nil {
+		logging.Log().Debug("git credential request failed", "operation", operation, "error", err)
+		http.Error(w, "no credentials", http.StatusNotFound)
+		return
+	}
+	_, _ = w.Write(output)
+}
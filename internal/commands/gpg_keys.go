@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Export the host's gpg public keys and their owner trust for the container
+// of the given project (`GPG_AGENT` directive), as its forwarded agent only
+// knows secret keys.
+//
+// Hosts without gnupg or whose sockets cannot be forwarded are skipped: the
+// directive is reported as ignored when running the container.
+func prepareProjectGPGKeys(project files.ProjectEntry, filestore *files.FileStore) error {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return nil
+	}
+	// Without socket activation, gpg-agent only creates its sockets once started
+	if err := exec.Command("gpgconf", "--launch", "gpg-agent").Run(); errors.Is(err, exec.ErrNotFound) {
+		return nil
+	}
+	publicKeys, err := exec.Command("gpg", "--batch", "--export").Output()
+	if err != nil {
+		return fmt.Errorf("cannot export gpg public keys: %w", err)
+	}
+	ownerTrust, err := exec.Command("gpg", "--batch", "--export-ownertrust").Output()
+	if err != nil {
+		return fmt.Errorf("cannot export gpg owner trust: %w", err)
+	}
+	return filestore.PrepareProjectGPGKeys(project.ProjectName, publicKeys, ownerTrust)
+}
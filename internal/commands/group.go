@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Group(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	flagset := newCommandFlagSet("group", console)
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs group <list|add|remove> [group] [project-name...] [flags]",
+			"Manage groups of projects (e.g. \"work\", \"oss\"), which 'build', 'rebuild', 'stop' and 'gc' accept as '@group' in place of project names to act on all of their projects at once. 'list' shows every group and its projects, 'add' puts the given projects in a group and 'remove' takes them out of it, or removes the whole group if no project is given.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) == 0 {
+		return utils.WithCategory(errors.New("expected a subcommand: list, add or remove"), errUsage)
+	}
+
+	switch args[0] {
+	case "list", "ls":
+		if len(args) > 1 {
+			return utils.WithCategory(errors.New("'group list' does not take arguments"), errUsage)
+		}
+		groups, err := filestore.GetAllGroups()
+		if err != nil {
+			return err
+		}
+		if len(groups) == 0 {
+			console.WriteLn("  (no group)")
+			console.WriteLn("Add projects to one with 'paul-envs group add <group> <project-name...>'.")
+			return nil
+		}
+		names := make([]string, 0, len(groups))
+		for name := range groups {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			console.Info("@%s", name)
+			console.WriteLn("  %s", strings.Join(groups[name], ", "))
+		}
+		return nil
+	case "add", "remove", "rm":
+		if len(args) < 2 || (args[0] == "add" && len(args) < 3) {
+			return utils.WithCategory(fmt.Errorf("'group %s' expects a group name followed by project names", args[0]), errUsage)
+		}
+		group := strings.TrimPrefix(args[1], "@")
+		if err := utils.ValidateGroupName(group); err != nil {
+			return utils.WithCategory(err, errValidationFailed)
+		}
+		add := args[0] == "add"
+		names := args[2:]
+		if !add && len(names) == 0 {
+			groups, err := filestore.GetAllGroups()
+			if err != nil {
+				return err
+			}
+			if names = groups[group]; len(names) == 0 {
+				return fmt.Errorf("group '%s' has no project\nHint: Use 'paul-envs group list' to see existing groups", group)
+			}
+		}
+		for _, name := range names {
+			if err := validateProjectName(name); err != nil {
+				return err
+			}
+			if !filestore.DoesProjectExist(name) {
+				return projectNotFoundError(name)
+			}
+		}
+		for _, name := range names {
+			if err := setProjectGroup(name, group, add, filestore); err != nil {
+				return err
+			}
+		}
+		if add {
+			console.Success("Added %s to group '%s'", strings.Join(names, ", "), group)
+		} else {
+			console.Success("Removed %s from group '%s'", strings.Join(names, ", "), group)
+		}
+		return nil
+	default:
+		return utils.WithCategory(fmt.Errorf("invalid group subcommand %q: expected list, add or remove", args[0]), errUsage)
+	}
+}
+
+// Put that project in the given group, or take it out of it.
+func setProjectGroup(name string, group string, add bool, filestore *files.FileStore) error {
+	groups, err := filestore.GetProjectGroups(name)
+	if err != nil {
+		return fmt.Errorf("cannot obtain the groups of project '%s': %w", name, err)
+	}
+	if add {
+		groups = append(groups, group)
+	} else {
+		groups = slices.DeleteFunc(groups, func(g string) bool { return g == group })
+	}
+	if err := filestore.SetProjectGroups(name, groups); err != nil {
+		return fmt.Errorf("cannot update the groups of project '%s': %w", name, err)
+	}
+	return nil
+}
+
+// Tell if some of those arguments select a group of projects (`@group`).
+func hasGroupSelector(args []string) bool {
+	return slices.ContainsFunc(args, func(arg string) bool {
+		return strings.HasPrefix(arg, "@")
+	})
+}
+
+// Replace the `@group` selectors among those project names by the projects
+// of their group, keeping the order in which they are given and skipping
+// projects given more than once.
+func resolveProjectSelectors(args []string, filestore *files.FileStore) ([]string, error) {
+	var groups map[string][]string
+	names := make([]string, 0, len(args))
+	for _, arg := range args {
+		group, isGroup := strings.CutPrefix(arg, "@")
+		if !isGroup {
+			if !slices.Contains(names, arg) {
+				names = append(names, arg)
+			}
+			continue
+		}
+		if err := utils.ValidateGroupName(group); err != nil {
+			return nil, utils.WithCategory(err, errValidationFailed)
+		}
+		if groups == nil {
+			var err error
+			if groups, err = filestore.GetAllGroups(); err != nil {
+				return nil, err
+			}
+		}
+		members, ok := groups[group]
+		if !ok {
+			return nil, utils.WithCategory(
+				fmt.Errorf("group '%s' has no project\nHint: Add projects to it with 'paul-envs group add %s <project-name...>'", group, group),
+				errProjectNotFound,
+			)
+		}
+		for _, member := range members {
+			if !slices.Contains(names, member) {
+				names = append(names, member)
+			}
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestGroupProjects(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	store, err := files.NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	for _, name := range []string{"api", "web", "docs"} {
+		if err := store.CreateProjectFiles(
+			name,
+			testBuildTemplateData(),
+			files.RuntimeTemplateData{Version: "1.0.0", ProjectHostPath: t.TempDir()},
+		); err != nil {
+			t.Fatalf("CreateProjectFiles() error = %v", err)
+		}
+	}
+	ctx := context.Background()
+	var out bytes.Buffer
+	cons := console.New(ctx, strings.NewReader(""), &out, &out)
+	for _, args := range [][]string{
+		{"add", "work", "web", "api"},
+		{"add", "@oss", "docs", "web"},
+		{"add", "tmp", "docs"},
+		{"remove", "oss", "web"},
+		{"remove", "tmp"},
+	} {
+		if err := Group(ctx, args, store, cons); err != nil {
+			t.Fatalf("Group(%v) error = %v", args, err)
+		}
+	}
+
+	groups, err := store.GetAllGroups()
+	if err != nil {
+		t.Fatalf("GetAllGroups() error = %v", err)
+	}
+	if len(groups) != 2 || !slices.Equal(groups["work"], []string{"api", "web"}) || !slices.Equal(groups["oss"], []string{"docs"}) {
+		t.Fatalf("GetAllGroups() = %v, want api and web in work, docs in oss", groups)
+	}
+
+	names, err := resolveProjectSelectors([]string{"docs", "@work", "@oss", "api"}, store)
+	if err != nil {
+		t.Fatalf("resolveProjectSelectors() error = %v", err)
+	}
+	if want := []string{"docs", "api", "web"}; !slices.Equal(names, want) {
+		t.Fatalf("resolveProjectSelectors() = %v, want %v", names, want)
+	}
+	if _, err := resolveProjectSelectors([]string{"@tmp"}, store); !errors.Is(err, errProjectNotFound) {
+		t.Fatalf("resolveProjectSelectors() of an empty group error = %v, want project not found", err)
+	}
+	if err := Group(ctx, []string{"add", "work", "missing"}, store, cons); !errors.Is(err, errProjectNotFound) {
+		t.Fatalf("Group(add) of a missing project error = %v, want project not found", err)
+	}
+	if err := validateProjectName("@work"); !errors.Is(err, errUsage) {
+		t.Fatalf("validateProjectName(@work) error = %v, want a usage error", err)
+	}
+}
@@ -29,12 +29,109 @@ Commands:
   list         List projects
   build        Build a project image
   run          Run or join a project container
+  stop         Stop the containers and services of projects
   remove       Remove one project and its managed assets
   version      Show paul-envs and container engine versions
   completion   Print shell completion scripts
   help         Show global or per-command help
   interactive  Start the guided interactive flow
   clean        Remove global paul-envs data and managed assets across projects
+  tui          Start a dashboard to manage all projects
+  trust        List or revoke trusted in-repository definitions
+  status       Show the engine-side state of each project
+  gc           Remove resources of deleted projects and old images
+  export       Export a project as a compose bundle, a shareable project bundle or Kubernetes YAML
+  ssh-config   Print an ssh_config entry to connect to a project's container
+  code         Open a project's container in VS Code
+  watch        Rebuild a project when its configuration changes
+  snapshot     Save a project's running container as an image
+  rollback     Go back to a project's previously built image
+  doctor       Diagnose the host setup and print how to fix problems
+  support-bundle
+               Collect redacted diagnostics into an archive for bug reports
+  stats        Show live resource usage of running project containers
+  config       Manage the global configuration (default engine, base image, shell...)
+  enable-emulation
+               Set up QEMU emulation to run images of other architectures
+  try          Run a project on another container engine without changing its own
+  exec         Run a command in a running project container without its entrypoint
+  cp           Copy files between the host and a project container
+  info         Show a project's details and README
+  rebuild      Rebuild several project images in parallel
+  sshd         Serve ssh connections to a project's container
+  push         Push a project's image to a container registry
+  pull         Pull a project's image from a container registry
+  import       Create a project from a bundle exported on another machine
+  update       Move the pinned distribution image to its latest digest and rebuild
+  backup       Save the whole paul-envs state in an archive
+  restore      Restore the paul-envs state saved by backup
+  outdated     List projects behind the latest distribution image
+  reconcile    Report and fix mismatches between projects and engine resources
+  rename       Rename a project along with its container resources
+  clone        Create a new project with the same definition as an existing one
+  history      Show the last invocations and the engine calls they made
+  cache-stats  Show the hit statistics of a project's compiler caches
+  reap         Stop containers nothing was attached to for too long
+  daemon       Run gc, outdated checks and reap periodically
+  du           Show the disk space used by each project
+  image        Analyze the layers of a project image
+  volume       Show where a volume's data is stored and its size
+  diff         Show what changed in a project's configuration since its image was built
+  events       Follow what happens to paul-envs' containers, images and volumes
+  migrate      Upgrade paul-envs' files written by a previous release
+  freeze       Save a running project container with its processes, to resume it later
+  thaw         Resume a project container saved by freeze
+  group        Manage groups of projects, selected with @group
+  resources    List the images, containers, volumes and networks of paul-envs
+  api          Serve a local HTTP API for other tools
+  shellenv     Output shell code selecting a project
+  prompt       Output the selected project for a shell prompt
+  task         Run a command in a new container and exit with its code
+  inspect      Show where a project's image comes from
+  times        Summarize how long builds, pulls and runs took
+  tmp          Run an image in a throwaway environment removed once exited
+  shortcut     Add a GUI application of a project to the host's application menu
+  services     List, restart or stop the background processes of a project
+  fix-perms    Give files of a project owned by another user back to you
+
+Global flags:
+  --profile-cli[=<trace-file>]
+               Report where time went in that invocation (engine calls, file
+               reads and writes...), optionally writing the full trace to a
+               file loadable in chrome://tracing or ui.perfetto.dev
+  -v, --verbose, -vv
+               Also display container engine calls and, with -vv, their
+               outputs (put those flags after the command, e.g.
+               'build -v myApp', where -v alone means 'version')
+  -q, --quiet  Only display errors, warnings and results, not progress
+  --output=<auto|fancy|plain|quiet>
+               How messages and progress are rendered: colors, spinners and
+               build outputs collapsed into a line (fancy), plain lines
+               prefixed by their level (plain) or only errors and warnings
+               (quiet). By default fancy on terminals and plain elsewhere
+               (PAULENV_OUTPUT). Before the command for support-bundle,
+               whose own --output is the archive to write
+  --offline    Never reach container registries: builds and containers only
+               use images already there, and pushes or pulls fail right away
+  --dry-run    Only display the container engine calls changing anything and
+               the files which would be written, without doing it (before
+               the command for gc and reap, whose own --dry-run only lists
+               what they would do)
+  --wait       Wait for projects used by another paul-envs process (e.g. being
+               built) instead of failing right away
+  --refresh-engine
+               Detect the container engine to use again instead of relying
+               on the one found by previous invocations
+  --ci         Never ask anything (failing with exit code 8 instead), never
+               allocate a terminal to containers and prefix messages with
+               their level, e.g. in CI pipelines (PAULENV_NONINTERACTIVE=1)
+
+Global flags go before the command's arguments (e.g. 'build --offline myApp'),
+as those may be followed by a command to run in a container, keeping its own
+flags (e.g. 'run myApp npm publish --dry-run').
+
+Each invocation appends its messages and container engine calls, with their
+outputs, to a paul-envs.log file in paul-envs' data directory, for debugging.
 
 Run 'paul-envs <command> --help' for command-specific flags and examples.
 `)
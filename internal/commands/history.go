@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/table"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func History(args []string, filestore *files.FileStore, console *console.Console) error {
+	var limit int
+	var calls, failed, wide bool
+	flagset := newCommandFlagSet("history", console)
+	flagset.IntVar(&limit, "limit", 20, "Number of most recent invocations displayed, 0 for all of them")
+	flagset.BoolVar(&calls, "calls", false, "Also display the container engine calls of each invocation")
+	flagset.BoolVar(&failed, "failed", false, "Only display failed invocations")
+	flagset.BoolVar(&wide, "wide", false, "Never elide values, even if the table does not fit the terminal")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs history [project-name] [flags]",
+			"Show the last paul-envs invocations (all of them, or only those naming the given project), with their duration, exit code and, optionally, the container engine calls they made.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) > 1 {
+		return utils.WithCategory(errors.New("history takes at most one project name"), errUsage)
+	}
+	if limit < 0 {
+		return utils.WithCategory(fmt.Errorf("invalid --limit %d: must not be negative", limit), errUsage)
+	}
+
+	entries, err := filestore.ReadHistory()
+	if err != nil {
+		return err
+	}
+	entries = slices.DeleteFunc(entries, func(entry files.HistoryEntry) bool {
+		return (failed && entry.ExitCode == 0) || (len(args) == 1 && !slices.Contains(entry.Args, args[0]))
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	if len(entries) == 0 {
+		console.WriteLn("  (no recorded invocation)")
+		return nil
+	}
+	return table.Render(console.Writer(), []string{
+		"WHEN", "COMMAND", "DURATION", "EXIT",
+	}, historyRows(entries, calls), table.Options{Width: table.TerminalWidth(console.Writer()), Wide: wide})
+}
+
+func historyRows(entries []files.HistoryEntry, withCalls bool) []table.Row {
+	rows := make([]table.Row, 0, len(entries))
+	for _, entry := range entries {
+		command := []string{"paul-envs " + strings.Join(entry.Args, " ")}
+		durations := []string{formatHistoryDuration(entry.Duration)}
+		exitCodes := []string{strconv.Itoa(entry.ExitCode)}
+		if withCalls {
+			for _, call := range entry.EngineCalls {
+				command = append(command, "  "+call.Command)
+				durations = append(durations, formatHistoryDuration(call.Duration))
+				exitCodes = append(exitCodes, strconv.Itoa(call.ExitCode))
+			}
+		}
+		rows = append(rows, table.Row{
+			{entry.Time.Local().Format("2006-01-02 15:04:05")},
+			command,
+			durations,
+			exitCodes,
+		})
+	}
+	return rows
+}
+
+func formatHistoryDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
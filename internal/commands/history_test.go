@@ -0,0 +1,34 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/table"
+)
+
+func TestHistoryRows(t *testing.T) {
+	entries := []files.HistoryEntry{{
+		Time:     time.Date(2026, 1, 2, 13, 4, 5, 0, time.Local),
+		Args:     []string{"build", "app"},
+		Duration: 92340 * time.Millisecond,
+		ExitCode: 5,
+		EngineCalls: []files.HistoryEngineCall{
+			{Command: "docker image inspect paulenv:app", Duration: 12 * time.Millisecond, ExitCode: 1},
+		},
+	}}
+	want := []table.Row{{
+		{"2026-01-02 13:04:05"},
+		{"paul-envs build app", "  docker image inspect paulenv:app"},
+		{"1m32.3s", "12ms"},
+		{"5", "1"},
+	}}
+	if got := historyRows(entries, true); !reflect.DeepEqual(got, want) {
+		t.Fatalf("historyRows() = %v, want %v", got, want)
+	}
+	if got := historyRows(entries, false); len(got[0][1]) != 1 {
+		t.Fatalf("historyRows() without calls = %v, want only the invocation", got)
+	}
+}
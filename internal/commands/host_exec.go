@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/logging"
+)
+
+// Size above which the standard input given to a host command is written to
+// a temporary file rather than kept in memory.
+const maxHostCommandMemoryInput = 8 << 20
+
+// Part of the error output of a host command sent back to the container, in
+// a header of the response.
+const maxHostCommandErrorOutput = 16 << 10
+
+// Time given to the descendants of a host command to close its outputs once
+// it exited, e.g. a browser started by `xdg-open`, after which they are not
+// waited for anymore.
+const hostCommandOutputDelay = 2 * time.Second
+
+// Serve the host commands the container of the given project may run and
+// the host's clipboard, as set by its run.conf (`HOST_COMMAND` and
+// `CLIPBOARD`), until the returned function is called.
+func serveHostCommands(project files.ProjectEntry, runtimeCfg config.RuntimeConfig) (func(), error) {
+	if !runtimeCfg.ServesHostRequests() || runtime.GOOS == "windows" {
+		return func() {}, nil
+	}
+	// Commands are run where the container runs them when in the project's
+	// directory, otherwise at the root of the project's directory
+	mountDir, err := engine.ProjectMountDir(project)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serve host commands to project '%s': %w", project.ProjectName, err)
+	}
+	socketPath := filepath.Join(project.HostExecDir, files.HostExecSocketName)
+	// Left by a `run` which did not end normally
+	_ = os.Remove(socketPath)
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serve host commands to project '%s': %w", project.ProjectName, err)
+	}
+	handler := &hostCommandHandler{
+		projectName: project.ProjectName,
+		allowed:     runtimeCfg.HostCommands,
+		clipboard:   runtimeCfg.Clipboard,
+		mountDir:    mountDir,
+		hostDir:     project.ProjectPath,
+	}
+	server := &http.Server{Handler: handler}
+	go server.Serve(listener)
+	return func() {
+		server.Close()
+		_ = os.Remove(socketPath)
+	}, nil
+}
+
+// Runs the host commands asked by `paulenv-host` in the container.
+//
+// Requests are multipart forms with the command's name, its `arg`s in order,
+// the container's working directory (`cwd`) and optionally its standard input
+// (`stdin`). The response's body is the command's output, its exit code and
+// the end of its error output being given by headers.
+//
+// The host's clipboard is read and written at `/clipboard` if allowed.
+type hostCommandHandler struct {
+	projectName string
+	allowed     []string
+	clipboard   bool
+	// Where the project's directory is mounted in the container
+	mountDir string
+	// The project's directory on the host
+	hostDir string
+}
+
+func (h *hostCommandHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/clipboard" {
+		h.serveClipboard(w, r)
+		return
+	}
+	if r.Method != http.MethodPost || r.URL.Path != "/exec" {
+		http.NotFound(w, r)
+		return
+	}
+	if err := r.ParseMultipartForm(maxHostCommandMemoryInput); err != nil {
+		http.Error(w, "paulenv-host: invalid request", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	name := r.FormValue("command")
+	if !slices.Contains(h.allowed, name) {
+		http.Error(w, fmt.Sprintf("paulenv-host: '%s' is not allowed for project '%s'\nHint: Allow it with 'HOST_COMMAND %s' in its run.conf\n",
+			name, h.projectName, name), http.StatusForbidden)
+		return
+	}
+
+	cmd := exec.CommandContext(r.Context(), name, r.MultipartForm.Value["arg"]...)
+	cmd.Dir = h.hostWorkDir(r.FormValue("cwd"))
+	cmd.WaitDelay = hostCommandOutputDelay
+	if stdin, _, err := r.FormFile("stdin"); err == nil {
+		defer stdin.Close()
+		cmd.Stdin = stdin
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	exitCode := 0
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() >= 0 {
+			exitCode = exitErr.ExitCode()
+		} else if !errors.Is(err, exec.ErrWaitDelay) {
+			logging.Log().Debug("host command failed", "command", name, "error", err)
+			fmt.Fprintf(&stderr, "paulenv-host: cannot run '%s' on the host: %s\n", name, err)
+			exitCode = 127
+		}
+	}
+	errorOutput := stderr.Bytes()
+	if len(errorOutput) > maxHostCommandErrorOutput {
+		errorOutput = errorOutput[len(errorOutput)-maxHostCommandErrorOutput:]
+	}
+	w.Header().Set("X-Paulenv-Exit-Code", strconv.Itoa(exitCode))
+	if len(errorOutput) > 0 {
+		w.Header().Set("X-Paulenv-Stderr", base64.StdEncoding.EncodeToString(errorOutput))
+	}
+	_, _ = w.Write(stdout.Bytes())
+}
+
+// Returns the host directory corresponding to the given working directory of
+// the container, the project's directory if it is outside of it.
+func (h *hostCommandHandler) hostWorkDir(containerDir string) string {
+	containerDir = path.Clean(containerDir)
+	if containerDir == h.mountDir {
+		return h.hostDir
+	}
+	rel, ok := strings.CutPrefix(containerDir, h.mountDir+"/")
+	if !ok {
+		return h.hostDir
+	}
+	return filepath.Join(h.hostDir, filepath.FromSlash(rel))
+}
@@ -0,0 +1,140 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/base64"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func hostCommandRequest(t *testing.T, fields map[string][]string, stdin string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for _, key := range []string{"command", "cwd", "arg"} {
+		for _, value := range fields[key] {
+			form.WriteField(key, value)
+		}
+	}
+	if stdin != "" {
+		part, err := form.CreateFormFile("stdin", "stdin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte(stdin))
+	}
+	form.Close()
+	req := httptest.NewRequest(http.MethodPost, "/exec", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestHostCommandHandler(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("host commands are not served on Windows")
+	}
+	hostDir := t.TempDir()
+	handler := &hostCommandHandler{
+		projectName: "demo",
+		allowed:     []string{"sh", "cat"},
+		mountDir:    "/home/dev/projects/demo",
+		hostDir:     hostDir,
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, hostCommandRequest(t, map[string][]string{
+		"command": {"sh"},
+		"cwd":     {"/home/dev/projects/demo"},
+		"arg":     {"-c", `pwd; echo "$0" >&2; exit 3`, "a b"},
+	}, ""))
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Paulenv-Exit-Code") != "3" {
+		t.Fatalf("ServeHTTP() = %d, exit code %q", rec.Code, rec.Header().Get("X-Paulenv-Exit-Code"))
+	}
+	if got, _ := filepath.EvalSymlinks(strings.TrimSpace(rec.Body.String())); got != mustEvalSymlinks(t, hostDir) {
+		t.Fatalf("ServeHTTP() ran in %q, want the project's directory %q", rec.Body.String(), hostDir)
+	}
+	if stderr, _ := base64.StdEncoding.DecodeString(rec.Header().Get("X-Paulenv-Stderr")); string(stderr) != "a b\n" {
+		t.Fatalf("ServeHTTP() error output = %q, want the arguments given in order", stderr)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, hostCommandRequest(t, map[string][]string{"command": {"cat"}}, "copied text"))
+	if rec.Code != http.StatusOK || rec.Body.String() != "copied text" || rec.Header().Get("X-Paulenv-Exit-Code") != "0" {
+		t.Fatalf("ServeHTTP() with an input = %d, %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, hostCommandRequest(t, map[string][]string{"command": {"rm"}, "arg": {"-rf", "/"}}, ""))
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "HOST_COMMAND rm") {
+		t.Fatalf("ServeHTTP() of a command not allowed = %d, %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHostCommandWorkDir(t *testing.T) {
+	handler := &hostCommandHandler{mountDir: "/home/dev/projects/demo", hostDir: "/src/demo"}
+	for containerDir, want := range map[string]string{
+		"/home/dev/projects/demo":         "/src/demo",
+		"/home/dev/projects/demo/web/src": filepath.Join("/src/demo", "web", "src"),
+		"/home/dev/projects/demo2":        "/src/demo",
+		"/home/dev/projects/demo/../x":    "/src/demo",
+		"":                                "/src/demo",
+	} {
+		if got := handler.hostWorkDir(containerDir); got != want {
+			t.Errorf("hostWorkDir(%q) = %q, want %q", containerDir, got, want)
+		}
+	}
+}
+
+func mustEvalSymlinks(t *testing.T, path string) string {
+	t.Helper()
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resolved
+}
+
+func TestHostCommandHandlerClipboard(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("relies on a fake xclip")
+	}
+	// Fake `xclip` keeping each selection in a file
+	binDir := t.TempDir()
+	script := "#!/bin/sh\nfile=\"" + binDir + "/$2\"\nif [ \"$3\" = -in ]; then cat > \"$file\"; else cat \"$file\"; fi\n"
+	if err := os.WriteFile(filepath.Join(binDir, "xclip"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("WAYLAND_DISPLAY", "")
+	t.Setenv("DISPLAY", ":0")
+
+	handler := &hostCommandHandler{projectName: "demo", clipboard: true}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/clipboard", strings.NewReader("copied\ntext")))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("copy: ServeHTTP() = %d, %q", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/clipboard?selection=primary", strings.NewReader("selected")))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("copy to the primary selection: ServeHTTP() = %d, %q", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/clipboard", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "copied\ntext" {
+		t.Fatalf("paste: ServeHTTP() = %d, %q", rec.Code, rec.Body.String())
+	}
+
+	handler.clipboard = false
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/clipboard", nil))
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "CLIPBOARD true") {
+		t.Fatalf("paste without CLIPBOARD: ServeHTTP() = %d, %q", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,302 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/logging"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Interval at which a `run` attached to a container records that it is still
+// in use.
+var activityRefreshInterval = time.Minute
+
+func Reap(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var dryRun bool
+	var idle string
+	var engineSelection string
+	flagset := newCommandFlagSet("reap", console)
+	flagset.BoolVar(&dryRun, "dry-run", false, "Only display which containers would be stopped")
+	flagset.StringVar(&idle, "idle", "", "Stop containers nothing was attached to for that long (e.g. 12h, 2d),\nreplacing the global IDLE_TIMEOUT for projects without their own")
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to look at: docker, podman, or all. Default: all available engines.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs reap [flags]",
+			"Stop the running project containers nothing was attached to for longer than their idle timeout: the IDLE_TIMEOUT of their run.conf, or else the global one.\n\nAlso stop the services projects kept running once their last container exited, after the SERVICE_GRACE_PERIOD of their run.conf.\n\nOn hosts whose administrator gave projects leases (see "+config.DefaultLeasesPath+"), also warn the users of containers nearing the end of their project's lease from inside them, and stop those past it once warned.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	dryRun = dryRun || engine.IsDryRun()
+	if len(flagset.Args()) > 0 {
+		return utils.WithCategory(errors.New("reap does not take arguments"), errUsage)
+	}
+
+	globalConfig, err := filestore.LoadGlobalConfig()
+	if err != nil {
+		return err
+	}
+	defaultTimeout := globalConfig.IdleTimeout
+	if idle != "" {
+		if defaultTimeout, err = parseAgeThreshold(idle); err != nil {
+			return utils.WithCategory(fmt.Errorf("invalid --idle value %q. Expected e.g. 12h or 2d", idle), errUsage)
+		}
+	}
+	selectedEngine, err := parseCleanEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+	if selectedEngine == engine.SelectionAuto {
+		selectedEngine = engine.SelectionAll
+	}
+
+	policies, err := loadIdlePolicies(filestore, defaultTimeout, console)
+	if err != nil {
+		return err
+	}
+	sidecarDeadlines, err := loadSidecarDeadlines(filestore, console)
+	if err != nil {
+		return err
+	}
+	leases, err := loadLeases()
+	if err != nil {
+		return err
+	}
+	containerEngines, err := engine.NewSet(ctx, console, selectedEngine)
+	if err != nil {
+		return err
+	}
+	for _, containerEngine := range containerEngines {
+		writeEngineSection(console, containerEngine)
+		containers, err := containerEngine.ListContainers(ctx)
+		if err != nil {
+			return fmt.Errorf("could not list containers: %w", err)
+		}
+		idleContainers := findIdleContainers(containers, policies, time.Now())
+		if len(idleContainers) == 0 {
+			console.WriteLn("  No idle container")
+		}
+		for _, idle := range idleContainers {
+			console.WriteLn("  • %s (idle for %s)", idle.name(), formatIdleDuration(idle.idleFor))
+		}
+		if !dryRun {
+			stopIdleContainers(ctx, idleContainers, containerEngine, console)
+		}
+		if _, err := stopLingeringSidecars(ctx, sidecarDeadlines, time.Now(), dryRun, containerEngine, console); err != nil {
+			return err
+		}
+		if _, _, err := enforceLeases(ctx, leases, containerEngine, dryRun, filestore, console); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// When a project's containers were last in use and how long they may then
+// stay idle.
+type idlePolicy struct {
+	// Duration after which they are stopped, `0` to never stop them
+	timeout time.Duration
+	// By instance, the default one being ""
+	lastActivity map[string]time.Time
+}
+
+// A running container of a project nothing was attached to for too long.
+type idleContainer struct {
+	project   files.ProjectEntry
+	container engine.ContainerInfo
+	idleFor   time.Duration
+}
+
+func (c idleContainer) name() string {
+	if c.container.ContainerName != nil {
+		return *c.container.ContainerName
+	}
+	return c.container.ContainerId
+}
+
+// Load the idle policy of every project which can have idle containers.
+func loadIdlePolicies(filestore *files.FileStore, defaultTimeout time.Duration, console *console.Console) (map[string]idlePolicy, error) {
+	entries, err := filestore.GetAllProjects()
+	if err != nil {
+		return nil, fmt.Errorf("could not list all projects: %w", err)
+	}
+	policies := make(map[string]idlePolicy, len(entries))
+	for _, entry := range entries {
+		timeout := defaultTimeout
+		runtimeCfg, err := config.LoadRuntimeConfig(entry.RuntimeConfigPath)
+		if err != nil {
+			console.Warn("Could not obtain the idle timeout of project '%s': %s", entry.ProjectName, err)
+			continue
+		}
+		if runtimeCfg.IdleTimeout != nil {
+			timeout = *runtimeCfg.IdleTimeout
+		}
+		if timeout == 0 {
+			continue
+		}
+		activity, err := filestore.GetProjectActivity(entry.ProjectName)
+		if err != nil {
+			console.Warn("Could not obtain the activity of project '%s': %s", entry.ProjectName, err)
+			continue
+		}
+		if len(activity) > 0 {
+			policies[entry.ProjectName] = idlePolicy{timeout: timeout, lastActivity: activity}
+		}
+	}
+	return policies, nil
+}
+
+// Returns `true` if a container of those projects may be idle at `now`, before
+// even listing them.
+func mayHaveIdleContainers(policies map[string]idlePolicy, now time.Time) bool {
+	for _, policy := range policies {
+		for _, at := range policy.lastActivity {
+			if now.Sub(at) > policy.timeout {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Returns the running containers of projects nothing was attached to for
+// longer than their idle timeout. Those whose activity was never recorded
+// (e.g. started before paul-envs recorded it) are left alone.
+func findIdleContainers(containers []engine.ContainerInfo, policies map[string]idlePolicy, now time.Time) []idleContainer {
+	var result []idleContainer
+	for projectName, policy := range policies {
+		for _, container := range engine.ProjectInstances(containers, projectName) {
+			at, ok := policy.lastActivity[container.Instance]
+			if !container.Running || !ok || now.Sub(at) <= policy.timeout {
+				continue
+			}
+			result = append(result, idleContainer{
+				project:   files.ProjectEntry{ProjectName: projectName},
+				container: container,
+				idleFor:   now.Sub(at),
+			})
+		}
+	}
+	return result
+}
+
+// Stop the given idle containers, then the services of projects left with no
+// running container.
+//
+// Returns the number of containers stopped.
+func stopIdleContainers(ctx context.Context, idleContainers []idleContainer, containerEngine engine.ContainerEngine, console *console.Console) int {
+	stopped := 0
+	stoppedProjects := map[string]bool{}
+	for _, idle := range idleContainers {
+		if err := containerEngine.StopContainer(ctx, idle.container); err != nil {
+			console.Warn("Could not stop idle container %s: %s", idle.name(), err)
+			continue
+		}
+		console.Success("Stopped idle container %s", idle.name())
+		stopped++
+		stoppedProjects[idle.project.ProjectName] = true
+	}
+	for projectName := range stoppedProjects {
+		cleanUpProjectRun(ctx, projectName, containerEngine, console)
+	}
+	return stopped
+}
+
+// Stop the idle containers of other projects than `currentProject` on that
+// engine, when running a project's container, as well as the services they
+// kept running past their grace period.
+//
+// Containers are only listed if a project's recorded activity is old enough
+// for one of them to be idle, so this costs nothing when none can be.
+func reapIdleContainers(
+	ctx context.Context,
+	currentProject string,
+	containerEngine engine.ContainerEngine,
+	filestore *files.FileStore,
+	console *console.Console,
+) {
+	globalConfig, err := filestore.LoadGlobalConfig()
+	if err != nil {
+		// Reported at startup
+		return
+	}
+	policies, err := loadIdlePolicies(filestore, globalConfig.IdleTimeout, console)
+	if err != nil {
+		logging.Log().Debug("idle containers not looked for", "error", err)
+		return
+	}
+	delete(policies, currentProject)
+	now := time.Now()
+	if mayHaveIdleContainers(policies, now) {
+		containers, err := containerEngine.ListContainers(ctx)
+		if err != nil {
+			logging.Log().Debug("idle containers not looked for", "error", err)
+			return
+		}
+		stopIdleContainers(ctx, findIdleContainers(containers, policies, now), containerEngine, console)
+	}
+	sidecarDeadlines, err := loadSidecarDeadlines(filestore, console)
+	if err != nil {
+		logging.Log().Debug("lingering services not looked for", "error", err)
+		return
+	}
+	delete(sidecarDeadlines, currentProject)
+	if _, err := stopLingeringSidecars(ctx, sidecarDeadlines, now, false, containerEngine, console); err != nil {
+		logging.Log().Debug("lingering services not looked for", "error", err)
+	}
+}
+
+// Record that the given instance of a project's container is in use, then
+// again regularly until the returned function is called, when it stops being.
+//
+// Failures are only logged: they only make it look idle sooner.
+func trackProjectActivity(filestore *files.FileStore, projectName string, instance string) func() {
+	record := func() {
+		if err := filestore.RecordProjectActivity(projectName, instance, time.Now()); err != nil {
+			logging.Log().Debug("activity not recorded", "project", projectName, "error", err)
+		}
+	}
+	record()
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(activityRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				record()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+		record()
+	}
+}
+
+func formatIdleDuration(d time.Duration) string {
+	if d >= 48*time.Hour {
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	}
+	return d.Round(time.Minute).String()
+}
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/engine"
+)
+
+func TestFindIdleContainers(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	str := func(s string) *string { return &s }
+
+	containers := []engine.ContainerInfo{
+		{ProjectName: str("app"), ContainerName: str("paulenv-app"), ContainerId: "1", Running: true},
+		{ProjectName: str("app"), ContainerName: str("paulenv-app..tests"), Instance: "tests", ContainerId: "2", Running: true},
+		{ProjectName: str("app"), ContainerName: str("paulenv-app..docs"), Instance: "docs", ContainerId: "3", Running: true},
+		{ProjectName: str("stopped"), ContainerName: str("paulenv-stopped"), ContainerId: "4"},
+		{ProjectName: str("untracked"), ContainerName: str("paulenv-untracked"), ContainerId: "5", Running: true},
+		{ProjectName: str("other"), ContainerName: str("paulenv-other"), ContainerId: "6", Running: true},
+	}
+	policies := map[string]idlePolicy{
+		"app": {timeout: 12 * time.Hour, lastActivity: map[string]time.Time{
+			"":      now.Add(-13 * time.Hour),
+			"tests": now.Add(-time.Hour),
+			// "docs" never recorded
+		}},
+		"stopped": {timeout: time.Hour, lastActivity: map[string]time.Time{
+			"": now.Add(-48 * time.Hour),
+		}},
+		"other": {timeout: 24 * time.Hour, lastActivity: map[string]time.Time{
+			"": now.Add(-13 * time.Hour),
+		}},
+	}
+
+	var names []string
+	for _, idle := range findIdleContainers(containers, policies, now) {
+		names = append(names, idle.name())
+		if idle.name() == "paulenv-app" && idle.idleFor != 13*time.Hour {
+			t.Errorf("idleFor = %s, want 13h", idle.idleFor)
+		}
+	}
+	slices.Sort(names)
+	want := []string{"paulenv-app"}
+	if !slices.Equal(names, want) {
+		t.Errorf("findIdleContainers() = %v, want %v", names, want)
+	}
+}
+
+func TestMayHaveIdleContainers(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	recent := map[string]idlePolicy{
+		"app": {timeout: 12 * time.Hour, lastActivity: map[string]time.Time{"": now.Add(-time.Hour)}},
+	}
+	if mayHaveIdleContainers(recent, now) {
+		t.Error("mayHaveIdleContainers() = true with only recent activity")
+	}
+	recent["old"] = idlePolicy{timeout: time.Hour, lastActivity: map[string]time.Time{"x": now.Add(-2 * time.Hour)}}
+	if !mayHaveIdleContainers(recent, now) {
+		t.Error("mayHaveIdleContainers() = false with an activity older than its timeout")
+	}
+}
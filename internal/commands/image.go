@@ -0,0 +1,237 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/table"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Layers below that size are not worth slimming.
+const minFlaggedLayerSize = 10 * 1000 * 1000
+
+// Number of biggest layers listed by `image analyze`.
+const biggestLayerCount = 3
+
+func Image(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var all bool
+	var engineSelection string
+	flagset := newCommandFlagSet("image", console)
+	flagset.BoolVar(&all, "all", false, "Also list the layers of the shared base image and instructions only\nchanging the image's metadata")
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to use: docker or podman.\nDefault: the one the project was built with.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs image analyze [project-name] [flags]",
+			"Show the size of each layer of a project's image, in build order, with the Dockerfile instruction which produced it, then its biggest layers. Layers keeping a package manager's cache (apt lists, pip, npm...) are flagged, as cleaning it in the same instruction would slim the image.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) == 0 || args[0] != "analyze" {
+		return utils.WithCategory(errors.New("expected a subcommand: analyze"), errUsage)
+	}
+	if len(args) > 2 {
+		return utils.WithCategory(errors.New("'image analyze' takes at most one project name"), errUsage)
+	}
+
+	name, err := getProjectName(args[1:], filestore, console, "analyze the image of")
+	if err != nil {
+		return err
+	}
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+	requestedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+	containerEngine, _, err := newProjectEngine(ctx, name, requestedEngine, filestore, console)
+	if err != nil {
+		return err
+	}
+	hasBeenBuilt, err := containerEngine.HasBeenBuilt(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to get the status of the '%s' project: %w", name, err)
+	}
+	if !hasBeenBuilt {
+		return fmt.Errorf("project '%s' has not been built yet\nHint: Use 'paul-envs build %s' first", name, name)
+	}
+	layers, err := containerEngine.GetImageHistory(ctx, name)
+	if err != nil {
+		return err
+	}
+	return writeImageAnalysis(name, layers, all, console)
+}
+
+// A layer of a project image, as displayed by `image analyze`.
+type analyzedLayer struct {
+	engine.ImageLayer
+	// Dockerfile instruction which produced it
+	instruction string
+	// Why it could be slimmed, empty if no reason was found
+	finding string
+}
+
+func writeImageAnalysis(name string, history []engine.ImageLayer, all bool, console *console.Console) error {
+	layers := analyzeLayers(history)
+	var total, baseSize int64
+	var baseCount int
+	for _, layer := range layers {
+		total += layer.Size
+		if layer.FromBaseImage {
+			baseSize += layer.Size
+			baseCount++
+		}
+	}
+
+	var rows []table.Row
+	if baseCount > 0 && !all {
+		rows = append(rows, table.Row{
+			{utils.FormatSize(baseSize)},
+			{fmt.Sprintf("(%d layers of the shared base image)", baseCount)},
+			{},
+		})
+	}
+	for _, layer := range layers {
+		if !all && (layer.FromBaseImage || layer.Size == 0) {
+			continue
+		}
+		rows = append(rows, table.Row{{utils.FormatSize(layer.Size)}, {layer.instruction}, {layer.finding}})
+	}
+	if err := table.Render(console.Writer(), []string{"SIZE", "INSTRUCTION", "NOTE"}, rows,
+		table.Options{Width: table.TerminalWidth(console.Writer())}); err != nil {
+		return err
+	}
+
+	console.WriteLn("\nImage of project '%s': %s, of which %s shared with other projects through the base image",
+		name, utils.FormatSize(total), utils.FormatSize(baseSize))
+	biggest := biggestProjectLayers(layers, biggestLayerCount)
+	if len(biggest) > 0 {
+		console.WriteLn("Biggest layers of the project:")
+		for i, layer := range biggest {
+			console.WriteLn("  %d. %s (%d%%) %s", i+1, utils.FormatSize(layer.Size), layer.Size*100/max(total, 1),
+				elideInstruction(layer.instruction, 70))
+		}
+	}
+	flagged := 0
+	for _, layer := range layers {
+		if layer.finding != "" {
+			flagged++
+		}
+	}
+	if flagged > 0 {
+		console.Warn("%d layer(s) keep a package manager's cache, which could be removed by the instruction creating it", flagged)
+	}
+	return nil
+}
+
+// Returns the layers of a project image from the oldest to the most recent,
+// with the instruction which produced each and what could slim them.
+func analyzeLayers(history []engine.ImageLayer) []analyzedLayer {
+	layers := make([]analyzedLayer, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		instruction := layerInstruction(history[i].CreatedBy)
+		layer := analyzedLayer{ImageLayer: history[i], instruction: instruction}
+		if layer.Size >= minFlaggedLayerSize {
+			layer.finding = layerFinding(instruction)
+		}
+		layers = append(layers, layer)
+	}
+	return layers
+}
+
+// Returns the `count` biggest layers not coming from the shared base image.
+func biggestProjectLayers(layers []analyzedLayer, count int) []analyzedLayer {
+	var result []analyzedLayer
+	for _, layer := range layers {
+		if !layer.FromBaseImage && layer.Size > 0 {
+			result = append(result, layer)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Size > result[j].Size })
+	return result[:min(count, len(result))]
+}
+
+// Build arguments prefixed by Docker to `RUN` instructions using them, e.g.
+// "|2 USERNAME=dev SHELL=zsh ".
+var buildArgsPrefix = regexp.MustCompile(`^\|[0-9]+ (?:\S+=\S* )*`)
+
+// Returns the Dockerfile instruction of a layer from how the engine recorded
+// it, e.g. "RUN apt-get install -y git" for
+// "RUN |1 PACKAGES=git /bin/sh -c apt-get install -y git # buildkit".
+func layerInstruction(createdBy string) string {
+	s := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(createdBy), "# buildkit"))
+	isRun := false
+	if rest, ok := strings.CutPrefix(s, "RUN "); ok {
+		s, isRun = rest, true
+	}
+	s = buildArgsPrefix.ReplaceAllString(s, "")
+	if rest, ok := strings.CutPrefix(s, "/bin/sh -c "); ok {
+		if metadata, ok := strings.CutPrefix(rest, "#(nop)"); ok {
+			return strings.TrimSpace(metadata)
+		}
+		s, isRun = rest, true
+	}
+	if isRun {
+		return "RUN " + s
+	}
+	if s == "" {
+		return "(unknown)"
+	}
+	return s
+}
+
+// What a package manager leaves behind when the instruction installing
+// packages does not clean it.
+var packageCacheChecks = []struct {
+	installs *regexp.Regexp
+	cleans   *regexp.Regexp
+	finding  string
+}{
+	{regexp.MustCompile(`apt(-get)? install`), regexp.MustCompile(`rm -rf /var/lib/apt/lists`), "apt lists kept: rm -rf /var/lib/apt/lists/*"},
+	{regexp.MustCompile(`pip3? install`), regexp.MustCompile(`--no-cache-dir|pip3? cache purge`), "pip cache kept: --no-cache-dir"},
+	{regexp.MustCompile(`npm (install|ci)\b`), regexp.MustCompile(`npm cache clean|--cache[ =]`), "npm cache kept: npm cache clean --force"},
+	{regexp.MustCompile(`apk add`), regexp.MustCompile(`--no-cache|rm -rf /var/cache/apk`), "apk cache kept: --no-cache"},
+	{regexp.MustCompile(`(dnf|yum) install`), regexp.MustCompile(`(dnf|yum) clean all`), "dnf/yum cache kept: dnf clean all"},
+}
+
+// Returns why a layer produced by that instruction could be slimmed, empty if
+// no reason was found.
+func layerFinding(instruction string) string {
+	if !strings.HasPrefix(instruction, "RUN ") {
+		return ""
+	}
+	var findings []string
+	for _, check := range packageCacheChecks {
+		if check.installs.MatchString(instruction) && !check.cleans.MatchString(instruction) {
+			findings = append(findings, check.finding)
+		}
+	}
+	return strings.Join(findings, "; ")
+}
+
+func elideInstruction(instruction string, width int) string {
+	if len([]rune(instruction)) <= width {
+		return instruction
+	}
+	return string([]rune(instruction)[:width-1]) + "…"
+}
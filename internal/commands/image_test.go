@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/engine"
+)
+
+func TestLayerInstruction(t *testing.T) {
+	for _, tc := range []struct{ createdBy, want string }{
+		{"RUN |2 USERNAME=dev SHELL=zsh /bin/sh -c apt-get install -y git # buildkit", "RUN apt-get install -y git"},
+		{"RUN /bin/sh -c npm ci # buildkit", "RUN npm ci"},
+		{"/bin/sh -c pip install flask", "RUN pip install flask"},
+		{"|1 PACKAGES=git /bin/sh -c apt-get install -y $PACKAGES", "RUN apt-get install -y $PACKAGES"},
+		{`/bin/sh -c #(nop)  CMD ["/bin/bash"]`, `CMD ["/bin/bash"]`},
+		{"COPY entrypoint.sh /usr/local/bin/ # buildkit", "COPY entrypoint.sh /usr/local/bin/"},
+		{"", "(unknown)"},
+	} {
+		if got := layerInstruction(tc.createdBy); got != tc.want {
+			t.Errorf("layerInstruction(%q) = %q, want %q", tc.createdBy, got, tc.want)
+		}
+	}
+}
+
+func TestLayerFinding(t *testing.T) {
+	for _, tc := range []struct {
+		instruction string
+		flagged     bool
+	}{
+		{"RUN apt-get update && apt-get install -y git", true},
+		{"RUN apt-get update && apt-get install -y git && rm -rf /var/lib/apt/lists/*", false},
+		{"RUN pip install flask", true},
+		{"RUN pip install --no-cache-dir flask", false},
+		{"RUN npm ci && npm cache clean --force", false},
+		{"COPY apt-get install /", false},
+	} {
+		if got := layerFinding(tc.instruction); (got != "") != tc.flagged {
+			t.Errorf("layerFinding(%q) = %q, flagged: want %t", tc.instruction, got, tc.flagged)
+		}
+	}
+}
+
+func TestAnalyzeLayers(t *testing.T) {
+	history := []engine.ImageLayer{
+		{CreatedBy: "RUN /bin/sh -c pip install flask # buildkit", Size: 5_000_000},
+		{CreatedBy: "RUN /bin/sh -c npm install -g typescript # buildkit", Size: 80_000_000},
+		{CreatedBy: "USER dev"},
+		{CreatedBy: "RUN /bin/sh -c apt-get install -y git # buildkit", Size: 400_000_000, FromBaseImage: true},
+	}
+	layers := analyzeLayers(history)
+	if len(layers) != 4 || layers[0].instruction != "RUN apt-get install -y git" || layers[3].instruction != "RUN pip install flask" {
+		t.Fatalf("unexpected layers order: %+v", layers)
+	}
+	// Too small to be flagged
+	if layers[3].finding != "" {
+		t.Errorf("small layer flagged: %q", layers[3].finding)
+	}
+	if layers[2].finding == "" {
+		t.Error("npm layer not flagged")
+	}
+	biggest := biggestProjectLayers(layers, 3)
+	if len(biggest) != 2 || biggest[0].Size != 80_000_000 || biggest[1].Size != 5_000_000 {
+		t.Errorf("unexpected biggest layers: %+v", biggest)
+	}
+}
@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Import(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var name string
+	var projectPath string
+	var mappings stringListFlag
+	var engineSelection string
+	flagset := newCommandFlagSet("import", console)
+	flagset.StringVar(&name, "name", "", "Name of the created project.\nDefault: the one it was exported with, or the name of the distrobox or toolbox\ncontainer.")
+	flagset.StringVar(&projectPath, "path", "", "Directory of this machine to mount as the project, replacing the one it was\nexported with. Default: the same directory. For a distrobox or toolbox\ncontainer: its home directory if it has its own, otherwise the current one.")
+	flagset.Var(&mappings, "map", "Replace a host path prefix of its run.conf (e.g. of a MOUNT) by another, as\n<old>=<new> (e.g. /home/alice=/home/bob). Can be repeated.")
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine the distrobox or toolbox container was created with: docker\nor podman. Default: auto-select, preferring Podman.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs import [distrobox|toolbox] <bundle|container> [flags]",
+			"Create a project from a bundle written by 'paul-envs export bundle' on another machine, with its build.conf, run.conf, README and dotfiles. Host paths of its run.conf can be remapped to those of this machine with --path and --map.\n\nWith 'distrobox' or 'toolbox', create a project equivalent to a container created by those tools instead: with the same user, shell, additional packages and mounted directories. Packages installed in it afterwards are not found, add them to its build.conf. A bundle named like those tools can be imported as e.g. './distrobox'.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) > 0 && (args[0] == "distrobox" || args[0] == "toolbox") {
+		if len(args) != 2 {
+			return utils.WithCategory(fmt.Errorf("expected the name of the %s container to import", args[0]), errUsage)
+		}
+		if len(mappings) > 0 {
+			return utils.WithCategory(fmt.Errorf("--map does not apply to '%s' containers", args[0]), errUsage)
+		}
+		return importToolbox(ctx, args[0], args[1], name, projectPath, engineSelection, filestore, console)
+	}
+	if engineSelection != "" {
+		return utils.WithCategory(errors.New("--engine only applies to distrobox and toolbox containers"), errUsage)
+	}
+	if len(args) != 1 {
+		return utils.WithCategory(errors.New("expected the path of the bundle to import"), errUsage)
+	}
+	pathMappings, err := parsePathMappings(mappings)
+	if err != nil {
+		return utils.WithCategory(err, errUsage)
+	}
+
+	bundle, err := files.ReadProjectBundle(args[0])
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		name = bundle.ProjectName
+	}
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if projectPath != "" {
+		if projectPath, err = filepath.Abs(projectPath); err != nil {
+			return fmt.Errorf("invalid directory '%s': %w", projectPath, err)
+		}
+		if info, err := os.Stat(projectPath); err != nil || !info.IsDir() {
+			return fmt.Errorf("project directory '%s' does not exist", projectPath)
+		}
+		if exportedPath := bundle.ProjectPath(); exportedPath != "" {
+			pathMappings = append([]files.PathMapping{{From: exportedPath, To: projectPath}}, pathMappings...)
+		}
+	}
+	runtimeConfig := files.RemapRuntimeConfig(bundle.RuntimeConfig, pathMappings)
+
+	unlock, err := lockRegistry(ctx, filestore, console)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if filestore.DoesProjectExist(name) {
+		return fmt.Errorf("project '%s' already exists\nHint: Import it under another name with '--name'", name)
+	}
+	if err := filestore.ImportProjectBundle(ctx, name, bundle, runtimeConfig); err != nil {
+		return fmt.Errorf("cannot import project '%s': %w", name, err)
+	}
+
+	console.Success("Imported project '%s'", name)
+	runtimeConfigPath := filestore.GetProjectRuntimeConfigPath(name)
+	if runtimeCfg, err := config.LoadRuntimeConfig(runtimeConfigPath); err == nil {
+		for _, missing := range missingHostPaths(runtimeCfg, filestore) {
+			console.Warn("%s does not exist on this machine, remap it with '--map' or edit %s", missing, runtimeConfigPath)
+		}
+	}
+	if len(bundle.Stripped) > 0 {
+		console.Warn("Values stripped from the bundle, set them in %s: %s", runtimeConfigPath, strings.Join(bundle.Stripped, ", "))
+	}
+	console.WriteLn("Next, build it with 'paul-envs build %s' or, if its image was pushed, pull it with 'paul-envs pull %s <repository>'.", name, name)
+	return nil
+}
+
+// Parse `--map` values, as `<old>=<new>`.
+func parsePathMappings(values []string) ([]files.PathMapping, error) {
+	mappings := make([]files.PathMapping, 0, len(values))
+	for _, value := range values {
+		from, to, ok := strings.Cut(value, "=")
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("invalid --map value %q: expected <old>=<new>", value)
+		}
+		mappings = append(mappings, files.PathMapping{From: from, To: to})
+	}
+	return mappings, nil
+}
+
+// Describe the host paths the given run.conf refers to which do not exist.
+// Relative ones, inside the project's own directory, are not checked.
+func missingHostPaths(runtimeCfg config.RuntimeConfig, filestore *files.FileStore) []string {
+	var missing []string
+	check := func(description string, path string) {
+		if !filepath.IsAbs(path) {
+			return
+		}
+		if _, err := os.Stat(path); err != nil {
+			missing = append(missing, fmt.Sprintf("%s '%s'", description, path))
+		}
+	}
+	check("Project directory", runtimeCfg.ProjectPath)
+	if runtimeCfg.DotfilesProfile != "" {
+		check("Dotfiles profile", filestore.GetDotfilesProfilePath(runtimeCfg.DotfilesProfile))
+	} else {
+		check("Dotfiles directory", runtimeCfg.DotfilesPath)
+	}
+	for _, mount := range runtimeCfg.Mounts {
+		check("MOUNT source", mount.Source)
+	}
+	check("SECCOMP_PROFILE", runtimeCfg.SeccompProfile)
+	for _, script := range runtimeCfg.StartupScripts {
+		check("STARTUP script", script.Path)
+	}
+	for _, script := range runtimeCfg.ActivationScripts {
+		check("ACTIVATE script", script)
+	}
+	return missing
+}
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestParsePathMappings(t *testing.T) {
+	got, err := parsePathMappings([]string{"/home/alice=/home/bob", "/mnt/a=b=/mnt/c"})
+	if err != nil {
+		t.Fatalf("parsePathMappings() error = %v", err)
+	}
+	want := []files.PathMapping{{From: "/home/alice", To: "/home/bob"}, {From: "/mnt/a", To: "b=/mnt/c"}}
+	if !slices.Equal(got, want) {
+		t.Fatalf("parsePathMappings() = %v, want %v", got, want)
+	}
+	for _, invalid := range []string{"/home/alice", "=/home/bob", "/home/alice="} {
+		if _, err := parsePathMappings([]string{invalid}); err == nil {
+			t.Fatalf("parsePathMappings(%q) should fail", invalid)
+		}
+	}
+}
+
+func TestToolboxCreateArgs(t *testing.T) {
+	container := engine.ToolboxContainer{
+		Tool:     "distrobox",
+		Name:     "mybox",
+		Image:    "docker.io/library/ubuntu:24.04",
+		User:     "alice",
+		Shell:    "/usr/bin/nu",
+		Packages: []string{"ripgrep", "fd-find"},
+		Volumes:  []string{"/data:/data:ro"},
+	}
+	got, unsupported := toolboxCreateArgs(container, "mybox", "/home/alice/mybox")
+	want := []string{
+		"/home/alice/mybox", "--no-prompt", "--name", "mybox",
+		"--username", "alice",
+		"--shell", "nushell",
+		"--package", "ripgrep", "--package", "fd-find",
+		"--volume", "/data:/data:ro",
+	}
+	if !slices.Equal(got, want) || len(unsupported) != 0 {
+		t.Fatalf("toolboxCreateArgs() = %v, %v, want %v", got, unsupported, want)
+	}
+
+	container.Image = "registry.fedoraproject.org/fedora-toolbox:40"
+	container.Shell = "/bin/tcsh"
+	got, unsupported = toolboxCreateArgs(container, "mybox", "/home/alice/mybox")
+	if slices.Contains(got, "--package") || slices.Contains(got, "--shell") {
+		t.Fatalf("toolboxCreateArgs() = %v, want no package nor unknown shell", got)
+	}
+	if !slices.Equal(unsupported, container.Packages) {
+		t.Fatalf("toolboxCreateArgs() unsupported packages = %v, want %v", unsupported, container.Packages)
+	}
+}
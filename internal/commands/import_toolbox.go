@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/args"
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/events"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Create a project equivalent to the distrobox or toolbox container of the
+// given name.
+func importToolbox(
+	ctx context.Context,
+	tool string,
+	containerName string,
+	name string,
+	projectPath string,
+	engineSelection string,
+	filestore *files.FileStore,
+	console *console.Console,
+) error {
+	requestedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+	containerEngine, err := engine.NewSelected(ctx, console, requestedEngine)
+	if err != nil {
+		return err
+	}
+	container, err := containerEngine.InspectToolbox(ctx, containerName)
+	if err != nil {
+		return err
+	}
+	if container.Tool != tool {
+		console.Warn("Container %s was created by %s, not %s.", containerName, container.Tool, tool)
+	}
+	if name == "" {
+		if name, err = utils.SanitizeProjectName(container.Name); err != nil {
+			return fmt.Errorf("cannot derive a project name from '%s', give one with '--name': %w", container.Name, err)
+		}
+	}
+	if projectPath == "" {
+		if projectPath, err = toolboxProjectPath(container); err != nil {
+			return err
+		}
+	}
+
+	createArgs, unsupportedPackages := toolboxCreateArgs(container, name, projectPath)
+	cfg, err := args.ParseAndPrompt(createArgs, console, filestore)
+	if err != nil {
+		return utils.WithCategory(err, errValidationFailed)
+	}
+	unlock, err := lockRegistry(ctx, filestore, console)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := generateProjectFiles(&cfg, filestore); err != nil {
+		return err
+	}
+	events.Emit(events.ProjectCreated, cfg.ProjectName, nil)
+
+	console.Success("Imported %s container %s as project '%s'", container.Tool, container.Name, cfg.ProjectName)
+	if len(unsupportedPackages) > 0 {
+		console.Warn("%s was created from %s, not from a Debian-based image like paul-envs' base one: add the equivalents of its packages (%s) to PACKAGES in %s",
+			container.Name, container.Image, strings.Join(unsupportedPackages, " "), filestore.GetProjectBuildConfigPath(cfg.ProjectName))
+	}
+	printNextSteps(&cfg, filestore, console)
+	return nil
+}
+
+// Returns the directory to mount as the project: the container's home
+// directory if it has its own, otherwise the current one.
+func toolboxProjectPath(container engine.ToolboxContainer) (string, error) {
+	hostHome, _ := os.UserHomeDir()
+	if container.Home != "" && filepath.Clean(container.Home) != filepath.Clean(hostHome) {
+		if info, err := os.Stat(container.Home); err == nil && info.IsDir() {
+			return container.Home, nil
+		}
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("cannot get the current directory, give the project's one with '--path': %w", err)
+	}
+	return cwd, nil
+}
+
+// Returns the arguments of `paul-envs create` creating a project equivalent
+// to `container`, along with its packages which cannot be installed as-is
+// because it is not Debian-based.
+func toolboxCreateArgs(container engine.ToolboxContainer, name string, projectPath string) ([]string, []string) {
+	createArgs := []string{projectPath, "--no-prompt", "--name", name}
+	if container.User != "" {
+		createArgs = append(createArgs, "--username", container.User)
+	}
+	var shell config.Shell
+	shellName := path.Base(container.Shell)
+	if shellName == "nu" {
+		shellName = string(config.ShellNushell)
+	}
+	if container.Shell != "" && shell.Set(shellName) == nil {
+		createArgs = append(createArgs, "--shell", shell.String())
+	}
+	var unsupportedPackages []string
+	if isDebianBasedImage(container.Image) {
+		for _, pkg := range container.Packages {
+			createArgs = append(createArgs, "--package", pkg)
+		}
+	} else {
+		unsupportedPackages = container.Packages
+	}
+	for _, volume := range container.Volumes {
+		createArgs = append(createArgs, "--volume", volume)
+	}
+	return createArgs, unsupportedPackages
+}
+
+// Returns `true` if the given image reference looks like one of a Debian or
+// Ubuntu image, whose package names are those of paul-envs' base image.
+func isDebianBasedImage(image string) bool {
+	// e.g. "quay.io/toolbx/ubuntu-toolbox:24.04" or "docker.io/library/debian:12"
+	repository := image
+	if i := strings.LastIndex(repository, "/"); i >= 0 {
+		repository = repository[i+1:]
+	}
+	return strings.Contains(repository, "ubuntu") || strings.Contains(repository, "debian")
+}
@@ -0,0 +1,149 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/markdown"
+	"github.com/peaberberian/paul-envs/internal/table"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Info(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	full := false
+	flagset := newCommandFlagSet("info", console)
+	flagset.BoolVar(&full, "full", false, "Also display the project's README, if it has one")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs info [flags] <project-name>",
+			"Show what is known about a project: its directory, configuration files, repository, image and containers. With --full, its README (a README.md file in its configuration directory, or in the .paulenv directory of its repository) is also displayed.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) != 1 {
+		return utils.WithCategory(errors.New("expected the name of the project to show"), errUsage)
+	}
+	name := args[0]
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+
+	overviews, err := collectProjectOverviews(ctx, filestore, console)
+	if err != nil {
+		return err
+	}
+	var overview *projectOverview
+	for i := range overviews {
+		if overviews[i].Entry.ProjectName == name {
+			overview = &overviews[i]
+		}
+	}
+	if overview == nil {
+		return fmt.Errorf("failed to obtain information on project '%s'", name)
+	}
+	source, err := filestore.GetProjectSource(name)
+	if err != nil {
+		console.Warn("Could not determine where project '%s' comes from: %s", name, err)
+	}
+	readme, err := filestore.ReadProjectReadme(name)
+	if err != nil {
+		return err
+	}
+
+	lines := projectInfoLines(*overview, source, filestore.GetProjectReadmePath(name), readme != "", time.Now())
+	console.Info("%s", lines[0])
+	for _, line := range lines[1:] {
+		console.WriteLn("%s", line)
+	}
+	if !full {
+		if readme != "" {
+			console.WriteLn("Hint: Display its README with 'paul-envs info --full %s'", name)
+		}
+		return nil
+	}
+	if readme == "" {
+		console.WriteLn("")
+		console.WriteLn("  (no README)")
+		return nil
+	}
+	console.WriteLn("")
+	return writeProjectReadme(console, readme)
+}
+
+// Lines describing a project for the `info` command, `source` being the
+// repository it comes from, if any.
+func projectInfoLines(overview projectOverview, source string, readmePath string, hasReadme bool, now time.Time) []string {
+	entry := overview.Entry
+	lines := []string{
+		entry.ProjectName,
+		"  Directory   : " + entry.ProjectPath,
+		"  build.conf  : " + entry.BuildConfigPath,
+		"  run.conf    : " + entry.RuntimeConfigPath,
+	}
+	if source != "" {
+		lines = append(lines, "  Repository  : "+source)
+	}
+	if hasReadme {
+		lines = append(lines, "  README      : "+readmePath)
+	}
+	if !overview.IsBuilt() {
+		return append(lines, "  Image       : not built")
+	}
+	image := "built " + formatImageAge(overview.Image.BuiltAt, now)
+	if overview.Image.BuiltAt == nil {
+		image = "built"
+	}
+	if overview.EngineName != "" {
+		image += " with " + overview.EngineName
+	}
+	if overview.Image.Size != "" {
+		image += ", " + overview.Image.Size
+	}
+	lines = append(lines, "  Image       : "+image)
+
+	containers := make([]string, 0, len(overview.Containers))
+	for _, container := range overview.Containers {
+		name := container.ContainerId
+		if container.ContainerName != nil {
+			name = *container.ContainerName
+		}
+		if container.Running {
+			containers = append(containers, name+" (running)")
+		} else {
+			containers = append(containers, name+" (stopped)")
+		}
+	}
+	return append(lines, "  Containers  : "+strings.Join(orNone(containers), ", "))
+}
+
+// Render a project's README, in Markdown, on the console.
+func writeProjectReadme(console *console.Console, readme string) error {
+	w := console.Writer()
+	return markdown.Render(w, readme, markdown.Options{
+		Width:  table.TerminalWidth(w),
+		Styled: markdown.IsTerminal(w),
+	})
+}
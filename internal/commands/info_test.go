@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestProjectInfoLines(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	builtAt := now.Add(-2 * time.Hour)
+	containerName := "paulenv-app"
+	overview := projectOverview{
+		Entry: files.ProjectEntry{
+			ProjectName:       "app",
+			ProjectPath:       "/code/app",
+			BuildConfigPath:   "/data/app/build.conf",
+			RuntimeConfigPath: "/data/app/run.conf",
+		},
+		EngineName: "podman",
+		Image:      &engine.ImageInfo{ImageName: "paulenv:app", BuiltAt: &builtAt, Size: "1.2GB"},
+		Containers: []engine.ContainerInfo{{ContainerName: &containerName, ContainerId: "abc", Running: true}},
+	}
+
+	got := projectInfoLines(overview, "/code/app", "/data/app/README.md", true, now)
+	want := []string{
+		"app",
+		"  Directory   : /code/app",
+		"  build.conf  : /data/app/build.conf",
+		"  run.conf    : /data/app/run.conf",
+		"  Repository  : /code/app",
+		"  README      : /data/app/README.md",
+		"  Image       : built 2h ago with podman, 1.2GB",
+		"  Containers  : paulenv-app (running)",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("projectInfoLines() =\n%q\nwant:\n%q", got, want)
+	}
+
+	overview.Image = nil
+	got = projectInfoLines(overview, "", "/data/app/README.md", false, now)
+	if got[len(got)-1] != "  Image       : not built" || slices.Contains(got, "  README      : /data/app/README.md") {
+		t.Fatalf("projectInfoLines() of an unbuilt project without README = %q", got)
+	}
+}
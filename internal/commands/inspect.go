@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Inspect(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var engineSelection string
+	flagset := newCommandFlagSet("inspect", console)
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to use: docker or podman.\nDefault: the one the project was built with.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs inspect [flags] <project-name>",
+			"Show where the image of a project comes from, as recorded when it was built: when and by which version of paul-envs, the distribution image the shared base image was built from, the commit of the project's dotfiles and the packages its build.conf installs.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) != 1 {
+		return utils.WithCategory(errors.New("expected the name of the project to inspect"), errUsage)
+	}
+	name := args[0]
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+	requestedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+	containerEngine, _, err := newProjectEngine(ctx, name, requestedEngine, filestore, console)
+	if err != nil {
+		return err
+	}
+	image, err := containerEngine.GetImageInfo(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to inspect the image of project '%s': %w", name, err)
+	}
+	if image == nil || image.BuiltAt == nil {
+		return fmt.Errorf("project '%s' has not been built yet\nHint: Use 'paul-envs build %s' first", name, name)
+	}
+	project, err := filestore.GetProject(name)
+	if err != nil {
+		return err
+	}
+	currentHash, err := utils.FileHash(project.BuildConfigPath)
+	if err != nil {
+		console.Warn("Could not hash the build.conf of project '%s': %s", name, err)
+	}
+
+	lines := provenanceLines(*image, currentHash, time.Now())
+	console.Info("%s", lines[0])
+	for _, line := range lines[1:] {
+		console.WriteLn("%s", line)
+	}
+	if image.Provenance.Version == "" {
+		console.WriteLn("Hint: It was built before paul-envs recorded where images come from, rebuild it with 'paul-envs build %s'", name)
+	}
+	return nil
+}
+
+// Lines describing where a project's image comes from for the `inspect`
+// command, `currentHash` being the hash of the project's build.conf.
+func provenanceLines(image engine.ImageInfo, currentHash string, now time.Time) []string {
+	provenance := image.Provenance
+	lines := []string{image.ImageName}
+	built := "unknown"
+	if image.BuiltAt != nil {
+		built = image.BuiltAt.Local().Format(time.DateTime) + " (" + formatImageAge(image.BuiltAt, now) + ")"
+	}
+	lines = append(lines, "  Built at        : "+built)
+	lines = append(lines, "  Built by        : "+orUnknown(prefixed("paul-envs v", provenance.Version)))
+	if image.Architecture != "" {
+		lines = append(lines, "  Architecture    : "+image.Architecture)
+	}
+	lines = append(lines, "  Base image      : "+orUnknown(provenance.BaseImage))
+	dotfiles := provenance.DotfilesCommit
+	if dotfiles == "" && provenance.Version != "" {
+		dotfiles = "none recorded"
+	}
+	lines = append(lines, "  Dotfiles commit : "+orUnknown(dotfiles))
+	config := "unknown"
+	switch {
+	case image.ConfigHash == "":
+	case currentHash == "":
+		config = image.ConfigHash
+	case image.ConfigHash == currentHash:
+		config = image.ConfigHash + " (current build.conf)"
+	default:
+		config = image.ConfigHash + " (build.conf changed since)"
+	}
+	lines = append(lines, "  build.conf hash : "+config)
+
+	if len(provenance.Packages) == 0 {
+		if provenance.Version == "" {
+			return append(lines, "  Packages        : unknown")
+		}
+		return append(lines, "  Packages        : none")
+	}
+	lines = append(lines, "  Packages        :")
+	var managers []string
+	byManager := map[string][]string{}
+	for _, pkg := range provenance.Packages {
+		manager, name, ok := strings.Cut(pkg, ":")
+		if !ok {
+			manager, name = "other", pkg
+		}
+		if _, seen := byManager[manager]; !seen {
+			managers = append(managers, manager)
+		}
+		byManager[manager] = append(byManager[manager], name)
+	}
+	for _, manager := range managers {
+		lines = append(lines, fmt.Sprintf("    %-6s %s", manager+":", strings.Join(byManager[manager], " ")))
+	}
+	return lines
+}
+
+// Returns `value` prefixed by `prefix`, empty if it is.
+func prefixed(prefix string, value string) string {
+	if value == "" {
+		return ""
+	}
+	return prefix + value
+}
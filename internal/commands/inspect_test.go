@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/engine"
+)
+
+func TestProvenanceLines(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+	builtAt := now.Add(-49 * time.Hour)
+	image := engine.ImageInfo{
+		ImageName:    "paulenv:myapp",
+		BuiltAt:      &builtAt,
+		Architecture: "arm64",
+		ConfigHash:   "abc",
+		Provenance: engine.Provenance{
+			Version:        "0.8.0",
+			BaseImage:      "ubuntu:24.04@sha256:123",
+			DotfilesCommit: "deadbeef-dirty",
+			Packages:       []string{"apt:git", "apt:curl", "npm:typescript"},
+		},
+	}
+	got := provenanceLines(image, "def", now)
+	want := []string{
+		"paulenv:myapp",
+		"  Built at        : " + builtAt.Format(time.DateTime) + " (" + formatImageAge(&builtAt, now) + ")",
+		"  Built by        : paul-envs v0.8.0",
+		"  Architecture    : arm64",
+		"  Base image      : ubuntu:24.04@sha256:123",
+		"  Dotfiles commit : deadbeef-dirty",
+		"  build.conf hash : abc (build.conf changed since)",
+		"  Packages        :",
+		"    apt:   git curl",
+		"    npm:   typescript",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("provenanceLines() =\n%q\nwant\n%q", got, want)
+	}
+
+	legacy := provenanceLines(engine.ImageInfo{ImageName: "paulenv:old", BuiltAt: &builtAt}, "def", now)
+	for _, line := range []string{"  Built by        : unknown", "  Base image      : unknown", "  Packages        : unknown"} {
+		if !slices.Contains(legacy, line) {
+			t.Fatalf("provenanceLines() of an unlabelled image = %q, want it to contain %q", legacy, line)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/logging"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Load the leases the host's administrator gave to projects, see
+// `config.LeasesConfig`.
+func loadLeases() (config.LeasesConfig, error) {
+	leases, err := config.LoadLeasesConfig(config.LeasesPath())
+	if err != nil {
+		return config.LeasesConfig{}, fmt.Errorf("could not read the leases of projects: %w", err)
+	}
+	return leases, nil
+}
+
+// Layout of the times at which containers are to be stopped, as displayed to
+// their users.
+const leaseTimeLayout = "15:04"
+
+// When a container is to be stopped for going past its project's lease, and
+// why.
+type leaseDeadline struct {
+	at time.Time
+	// e.g. "the lease of project 'demo' allows it to run for 8h"
+	reason string
+}
+
+// Returns when a container of a project under that lease, which started at
+// `startedAt` (zero if unknown) and whose images and volumes use `diskUsage`
+// bytes, is to be stopped at the earliest, or `false` if it can run on.
+//
+// Projects using more disk space than their lease allows are to be stopped
+// right away.
+func findLeaseDeadline(projectName string, lease config.Lease, startedAt time.Time, diskUsage int64, now time.Time) (leaseDeadline, bool) {
+	var deadline leaseDeadline
+	found := false
+	if lease.MaxRuntime > 0 && !startedAt.IsZero() {
+		deadline = leaseDeadline{
+			at:     startedAt.Add(lease.MaxRuntime),
+			reason: fmt.Sprintf("the lease of project '%s' allows it to run for %s", projectName, lease.MaxRuntime),
+		}
+		found = true
+	}
+	if lease.MaxDisk > 0 && diskUsage > lease.MaxDisk && (!found || now.Before(deadline.at)) {
+		deadline = leaseDeadline{
+			at: now,
+			reason: fmt.Sprintf("project '%s' uses %s of disk space, above the %s its lease allows",
+				projectName, utils.FormatSize(diskUsage), utils.FormatSize(lease.MaxDisk)),
+		}
+		found = true
+	}
+	return deadline, found
+}
+
+// What to do with a container according to its lease.
+type leaseAction int
+
+const (
+	leaseKeep leaseAction = iota
+	// Warn its users that it will be stopped
+	leaseWarn
+	// Stop it, its users having been warned long enough ago
+	leaseStop
+)
+
+// Decide what to do with a container whose deadline is `deadline`, whose
+// users were warned at `warnedAt` (zero if they never were), for them to be
+// warned `warning` before it is stopped.
+//
+// Containers are only stopped once their users were warned for that long,
+// even if their deadline passed in between.
+func planLease(deadline time.Time, warnedAt time.Time, warning time.Duration, now time.Time) leaseAction {
+	switch {
+	case now.Before(deadline.Add(-warning)):
+		return leaseKeep
+	case warnedAt.IsZero():
+		return leaseWarn
+	case !now.Before(deadline) && !now.Before(warnedAt.Add(warning)):
+		return leaseStop
+	default:
+		return leaseKeep
+	}
+}
+
+// Warn the users of the running containers of the current user's projects
+// which near the end of their project's lease, and stop those past it once
+// warned long enough ago. With `dryRun`, only display what would be done.
+//
+// Returns the number of containers warned and stopped.
+func enforceLeases(
+	ctx context.Context,
+	leases config.LeasesConfig,
+	containerEngine engine.ContainerEngine,
+	dryRun bool,
+	filestore *files.FileStore,
+	console *console.Console,
+) (int, int, error) {
+	if leases.IsEmpty() {
+		return 0, 0, nil
+	}
+	entries, err := filestore.GetAllProjects()
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not list all projects: %w", err)
+	}
+	containers, err := containerEngine.ListContainers(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not list containers: %w", err)
+	}
+	running := map[string][]engine.ContainerInfo{}
+	needsDiskUsage := false
+	for _, entry := range entries {
+		lease := leases.LeaseOf(entry.ProjectName)
+		if lease.IsUnlimited() {
+			continue
+		}
+		for _, container := range engine.ProjectInstances(containers, entry.ProjectName) {
+			if container.Running {
+				running[entry.ProjectName] = append(running[entry.ProjectName], container)
+				needsDiskUsage = needsDiskUsage || lease.MaxDisk > 0
+			}
+		}
+	}
+	if len(running) == 0 {
+		return 0, 0, nil
+	}
+	diskUsages := map[string]int64{}
+	if needsDiskUsage {
+		resources, err := listEngineResources(ctx, containerEngine, engineQueryParallelism(filestore))
+		if err != nil {
+			return 0, 0, err
+		}
+		usage, err := containerEngine.GetDiskUsage(ctx)
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, u := range projectDiskUsages(resources, usage) {
+			diskUsages[u.name] = u.total()
+		}
+	}
+
+	warned, stopped := 0, 0
+	now := time.Now()
+	warning := leases.WarningPeriod()
+	for projectName, projectContainers := range running {
+		lease := leases.LeaseOf(projectName)
+		previousWarnings, err := filestore.GetProjectLeaseWarnings(projectName)
+		if err != nil {
+			console.Warn("Could not obtain the lease warnings of project '%s': %s", projectName, err)
+			continue
+		}
+		warnings := map[string]time.Time{}
+		projectStopped := false
+		for _, container := range projectContainers {
+			name := container.ContainerId
+			if container.ContainerName != nil {
+				name = *container.ContainerName
+			}
+			var startedAt time.Time
+			if lease.MaxRuntime > 0 {
+				if startedAt, err = containerEngine.GetContainerStartTime(ctx, container); err != nil {
+					console.Warn("Could not obtain when container %s started: %s", name, err)
+				}
+			}
+			deadline, ok := findLeaseDeadline(projectName, lease, startedAt, diskUsages[projectName], now)
+			if !ok {
+				continue
+			}
+			warnedAt := previousWarnings[container.ContainerId]
+			switch planLease(deadline.at, warnedAt, warning, now) {
+			case leaseKeep:
+				if !warnedAt.IsZero() {
+					warnings[container.ContainerId] = warnedAt
+				}
+			case leaseWarn:
+				stopAt := deadline.at
+				if earliest := now.Add(warning); stopAt.Before(earliest) {
+					stopAt = earliest
+				}
+				if dryRun {
+					console.WriteLn("  • %s would be warned it is stopped at %s: %s", name, stopAt.Local().Format(leaseTimeLayout), deadline.reason)
+					warned++
+					continue
+				}
+				message := fmt.Sprintf("paul-envs: this container will be stopped at %s (in %d minutes), as %s. Save your work!",
+					stopAt.Local().Format(leaseTimeLayout), int(stopAt.Sub(now).Round(time.Minute).Minutes()), deadline.reason)
+				if err := engine.BroadcastMessage(ctx, containerEngine, container, message); err != nil {
+					// It is still stopped once warned for long enough: its
+					// owner is also warned below, e.g. through the daemon's
+					// notifications
+					logging.Log().Warn("lease warning not displayed", "container", name, "error", err)
+				}
+				console.Warn("Container %s will be stopped at %s: %s", name, stopAt.Local().Format(leaseTimeLayout), deadline.reason)
+				warnings[container.ContainerId] = now
+				warned++
+			case leaseStop:
+				if dryRun {
+					console.WriteLn("  • %s would be stopped: %s", name, deadline.reason)
+					stopped++
+					continue
+				}
+				if err := containerEngine.StopContainer(ctx, container); err != nil {
+					console.Warn("Could not stop container %s past its lease: %s", name, err)
+					warnings[container.ContainerId] = warnedAt
+					continue
+				}
+				console.Success("Stopped container %s: %s", name, deadline.reason)
+				stopped++
+				projectStopped = true
+			}
+		}
+		if dryRun {
+			continue
+		}
+		if err := filestore.SetProjectLeaseWarnings(projectName, warnings); err != nil {
+			console.Warn("Could not record the lease warnings of project '%s': %s", projectName, err)
+		}
+		if projectStopped {
+			cleanUpProjectRun(ctx, projectName, containerEngine, console)
+		}
+	}
+	return warned, stopped, nil
+}
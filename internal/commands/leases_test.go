@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+func TestFindLeaseDeadline(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	started := now.Add(-7 * time.Hour)
+	lease := config.Lease{MaxRuntime: 8 * time.Hour, MaxDisk: 50_000_000_000}
+
+	deadline, ok := findLeaseDeadline("app", lease, started, 10_000_000_000, now)
+	if !ok || !deadline.at.Equal(now.Add(time.Hour)) || !strings.Contains(deadline.reason, "run for 8h") {
+		t.Errorf("findLeaseDeadline() under the disk limit = %+v, %v, want the end of its runtime", deadline, ok)
+	}
+	deadline, ok = findLeaseDeadline("app", lease, started, 60_000_000_000, now)
+	if !ok || !deadline.at.Equal(now) || !strings.Contains(deadline.reason, "uses 60GB of disk space, above the 50GB") {
+		t.Errorf("findLeaseDeadline() over the disk limit = %+v, %v, want now", deadline, ok)
+	}
+	deadline, ok = findLeaseDeadline("app", lease, now.Add(-9*time.Hour), 60_000_000_000, now)
+	if !ok || !deadline.at.Equal(now.Add(-time.Hour)) {
+		t.Errorf("findLeaseDeadline() past its runtime = %+v, %v, want the end of its runtime", deadline, ok)
+	}
+	if deadline, ok = findLeaseDeadline("app", lease, time.Time{}, 10_000_000_000, now); ok {
+		t.Errorf("findLeaseDeadline() with an unknown start = %+v, want none", deadline)
+	}
+	if deadline, ok = findLeaseDeadline("app", config.Lease{}, started, 60_000_000_000, now); ok {
+		t.Errorf("findLeaseDeadline() without limits = %+v, want none", deadline)
+	}
+}
+
+func TestPlanLease(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	warning := 15 * time.Minute
+	for _, tc := range []struct {
+		name     string
+		deadline time.Time
+		warnedAt time.Time
+		want     leaseAction
+	}{
+		{"far from its deadline", now.Add(time.Hour), time.Time{}, leaseKeep},
+		{"within the warning period", now.Add(10 * time.Minute), time.Time{}, leaseWarn},
+		{"past its deadline without warning", now.Add(-time.Hour), time.Time{}, leaseWarn},
+		{"warned, before its deadline", now.Add(5 * time.Minute), now.Add(-10 * time.Minute), leaseKeep},
+		{"warned long enough ago, past its deadline", now, now.Add(-15 * time.Minute), leaseStop},
+		{"warned too recently, past its deadline", now.Add(-time.Hour), now.Add(-5 * time.Minute), leaseKeep},
+	} {
+		if got := planLease(tc.deadline, tc.warnedAt, warning, now); got != tc.want {
+			t.Errorf("planLease() %s = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
@@ -6,17 +6,22 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/peaberberian/paul-envs/internal/config"
 	"github.com/peaberberian/paul-envs/internal/console"
 	"github.com/peaberberian/paul-envs/internal/engine"
 	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/table"
 )
 
 func List(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
 	nameOnly := false
+	wide := false
 	flagset := newCommandFlagSet("list", console)
 	flagset.BoolVar(&nameOnly, "names", false, "Only display names")
+	flagset.BoolVar(&wide, "wide", false, "Display full values even if they do not fit in the terminal width")
 	flagset.Usage = func() {
 		writeCommandUsage(
 			console,
@@ -48,13 +53,37 @@ func List(ctx context.Context, args []string, filestore *files.FileStore, consol
 		engineCache := map[engine.Selection]engine.ContainerEngine{}
 		var allEngines []engine.ContainerEngine
 
+		// Last state seen on engines, used for projects whose engine is
+		// unreachable
+		var cache *files.EngineStateCache
+		cacheLoaded, usedCache := false, false
+		rows := make([]table.Row, 0, len(entries))
 		for _, entry := range entries {
 			imageInfo, warnErr := listProjectImageInfo(ctx, entry.ProjectName, filestore, console, engineCache, &allEngines)
+			if warnErr != nil && imageInfo == nil {
+				if !cacheLoaded {
+					cache, _ = filestore.GetEngineStateCache()
+					cacheLoaded = true
+				}
+				if cached := cachedImageInfo(cache, entry.ProjectName); cached != nil {
+					imageInfo, warnErr, usedCache = cached, nil, true
+				}
+			}
 			if warnErr != nil {
 				console.Warn("Could not obtain image info for project '%s': %s", entry.ProjectName, warnErr)
 			}
-			printProjectInfo(entry, imageInfo, console)
+			rows = append(rows, projectInfoRow(entry, imageInfo))
+		}
+		if usedCache {
+			warnShowingCachedState(cache, console)
+		}
+		err := table.Render(console.Writer(), []string{
+			"PROJECT", "MOUNTED PROJECT", "IMAGE", "LAST BUILT", "PORTS", "VOLUMES", "CONFIG DIRECTORY",
+		}, rows, table.Options{Width: table.TerminalWidth(console.Writer()), Wide: wide})
+		if err != nil {
+			return err
 		}
+		console.WriteLn("")
 		if len(entries) <= 1 {
 			console.WriteLn("Total: %d project", len(entries))
 		} else {
@@ -127,19 +156,29 @@ func listProjectEngineSelection(projectName string, filestore *files.FileStore)
 	return selected, nil
 }
 
-func printProjectInfo(projectEntry files.ProjectEntry, imageInfo *engine.ImageInfo, console *console.Console) bool {
-	console.Info("%s", projectEntry.ProjectName)
-	console.WriteLn("  Mounted project   : %s", projectEntry.ProjectPath)
-	console.WriteLn("  build.conf file   : %s", projectEntry.BuildConfigPath)
-	console.WriteLn("  run.conf file     : %s", projectEntry.RuntimeConfigPath)
+func projectInfoRow(projectEntry files.ProjectEntry, imageInfo *engine.ImageInfo) table.Row {
+	image := "-"
+	lastBuilt := "-"
 	if imageInfo != nil {
-		console.WriteLn("  Container image   : %s", imageInfo.ImageName)
+		image = imageInfo.ImageName
 		if imageInfo.BuiltAt == nil {
-			console.WriteLn("  Last built at     : Never")
+			lastBuilt = "never"
 		} else {
-			console.WriteLn("  Last built at     : %s", imageInfo.BuiltAt)
+			lastBuilt = imageInfo.BuiltAt.Local().Format("2006-01-02 15:04")
 		}
 	}
-	console.WriteLn("")
-	return true
+	var ports, volumes []string
+	if runtimeCfg, err := config.LoadRuntimeConfig(projectEntry.RuntimeConfigPath); err == nil {
+		ports = runtimeCfg.Ports
+		volumes = runtimeCfg.Volumes
+	}
+	return table.Row{
+		{projectEntry.ProjectName},
+		{projectEntry.ProjectPath},
+		{image},
+		{lastBuilt},
+		ports,
+		volumes,
+		{filepath.Dir(projectEntry.BuildConfigPath)},
+	}
 }
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Whether to wait for projects used by other paul-envs processes (the global
+// `--wait` flag), instead of failing right away.
+var waitForProjects bool
+
+// Make commands wait for projects used by other paul-envs processes instead
+// of failing right away.
+func SetWaitForProjects(enabled bool) {
+	waitForProjects = enabled
+}
+
+// Take the lock of the given project, so it is not built, run or modified by
+// two paul-envs processes at once.
+//
+// If another process holds it, fails unless waiting was enabled through
+// `SetWaitForProjects`, in which case the user is told we are waiting.
+func lockProject(ctx context.Context, name string, filestore *files.FileStore, console *console.Console) (func(), error) {
+	var unlock func()
+	var err error
+	if waitForProjects {
+		unlock, err = filestore.LockProject(ctx, name, func() {
+			console.Info("Waiting for another paul-envs process using project '%s' to finish...", name)
+		})
+	} else {
+		unlock, err = filestore.TryLockProject(ctx, name)
+	}
+	if errors.Is(err, files.ErrLocked) {
+		return nil, fmt.Errorf("cannot lock project '%s': %w\nHint: Wait for it to finish, or run again with --wait to do so automatically", name, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot lock project '%s': %w", name, err)
+	}
+	return unlock, nil
+}
+
+// Take the lock of the project registry, telling the user if another
+// paul-envs process makes us wait for it.
+func lockRegistry(ctx context.Context, filestore *files.FileStore, console *console.Console) (func(), error) {
+	unlock, err := filestore.LockRegistry(ctx, func() {
+		console.Info("Waiting for another paul-envs process creating or removing a project to finish...")
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot lock the project registry: %w", err)
+	}
+	return unlock, nil
+}
@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/logging"
+	"github.com/peaberberian/paul-envs/internal/notify"
+)
+
+// Tell users who may have switched to something else that an operation
+// started at `start` (e.g. "Build of project 'foo'") is over, if it took
+// longer than the NOTIFY_AFTER global setting: through a desktop
+// notification, or the terminal's bell where there is none.
+//
+// Interrupted operations and non-interactive runs are not notified.
+func notifyLongOperation(ctx context.Context, filestore *files.FileStore, console *console.Console, start time.Time, operation string, err error) {
+	if console.IsNonInteractive() || errors.Is(err, context.Canceled) {
+		return
+	}
+	threshold := config.DefaultNotifyAfter
+	if globalConfig, err := filestore.LoadGlobalConfig(); err == nil {
+		threshold = globalConfig.NotifyThreshold()
+	}
+	elapsed := time.Since(start)
+	if threshold == 0 || elapsed < threshold {
+		return
+	}
+	message := longOperationMessage(operation, elapsed, err)
+	if err := notify.Send(ctx, "paul-envs", message); err != nil {
+		logging.Log().Debug("notification not displayed", "error", err)
+		if err := notify.RingBell(console.ErrorWriter()); err != nil {
+			logging.Log().Debug("terminal bell not rung", "error", err)
+		}
+	}
+}
+
+// e.g. "Build of project 'foo' failed after 12m3s: exit status 1"
+func longOperationMessage(operation string, elapsed time.Duration, err error) string {
+	if err == nil {
+		return fmt.Sprintf("%s finished after %s", operation, elapsed.Round(time.Second))
+	}
+	reason, _, _ := strings.Cut(err.Error(), "\n")
+	return fmt.Sprintf("%s failed after %s: %s", operation, elapsed.Round(time.Second), reason)
+}
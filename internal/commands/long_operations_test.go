@@ -0,0 +1,17 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLongOperationMessage(t *testing.T) {
+	if got, want := longOperationMessage("Build of project 'demo'", 12*time.Minute+3400*time.Millisecond, nil), "Build of project 'demo' finished after 12m3s"; got != want {
+		t.Errorf("longOperationMessage() = %q, want %q", got, want)
+	}
+	err := errors.New("exit status 1\nHint: see the build log")
+	if got, want := longOperationMessage("Pull of project 'demo'", 90*time.Second, err), "Pull of project 'demo' failed after 1m30s: exit status 1"; got != want {
+		t.Errorf("longOperationMessage() = %q, want %q", got, want)
+	}
+}
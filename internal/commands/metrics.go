@@ -0,0 +1,258 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/logging"
+)
+
+// Minimum time between two collections of the metrics, scrapes in between
+// being answered with the last ones: listing the resources and disk usage of
+// every engine is too costly to be done at each scrape.
+const metricsCacheDuration = 30 * time.Second
+
+// Upper bounds, in seconds, of the buckets of the build duration histograms.
+var buildDurationBuckets = []float64{30, 60, 120, 300, 600, 1200, 1800, 3600}
+
+// Serve the metrics of the projects on that address, in the Prometheus text
+// exposition format, until `ctx` is done.
+func serveMetrics(ctx context.Context, address string, filestore *files.FileStore, console *console.Console) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("cannot listen for metrics on %s: %w", address, err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", &metricsHandler{filestore: filestore, console: console})
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logging.Log().Error("metrics server stopped", "error", err)
+			console.Warn("Metrics are not served anymore: %s", err)
+		}
+	}()
+	console.WriteLn("Serving metrics on http://%s/metrics", listener.Addr())
+	return nil
+}
+
+type metricsHandler struct {
+	filestore *files.FileStore
+	console   *console.Console
+	// Guards the fields below and serializes collections
+	mu          sync.Mutex
+	rendered    []byte
+	collectedAt time.Time
+}
+
+func (h *metricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.mu.Lock()
+	if h.rendered == nil || time.Since(h.collectedAt) >= metricsCacheDuration {
+		var buf bytes.Buffer
+		writeMetrics(&buf, collectMetrics(r.Context(), h.filestore, h.console), time.Now())
+		h.rendered = buf.Bytes()
+		h.collectedAt = time.Now()
+	}
+	rendered := h.rendered
+	h.mu.Unlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(rendered)
+}
+
+// Metrics of all projects, as collected at a given time.
+type fleetMetrics struct {
+	// By engine name, `true` if it could be queried
+	engines  map[string]bool
+	projects []projectMetrics
+	// By project, durations of all their successful builds
+	buildDurations map[string][]time.Duration
+}
+
+// Metrics of a project on a container engine.
+type projectMetrics struct {
+	project           string
+	engine            string
+	runningContainers int
+	// `nil` if it has no image on that engine
+	imageBuiltAt *time.Time
+	volumeBytes  int64
+}
+
+func collectMetrics(ctx context.Context, filestore *files.FileStore, console *console.Console) fleetMetrics {
+	metrics := fleetMetrics{engines: map[string]bool{}, buildDurations: map[string][]time.Duration{}}
+	entries, err := filestore.GetAllProjects()
+	if err != nil {
+		logging.Log().Error("cannot list projects for metrics", "error", err)
+	}
+	projectNames := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		projectNames = append(projectNames, entry.ProjectName)
+		durations, err := filestore.GetBuildDurations(entry.ProjectName)
+		if err != nil {
+			logging.Log().Warn("cannot read build durations", "project", entry.ProjectName, "error", err)
+			continue
+		}
+		metrics.buildDurations[entry.ProjectName] = durations
+	}
+
+	containerEngines, err := engine.NewSet(ctx, console, engine.SelectionAll)
+	if err != nil {
+		logging.Log().Error("cannot query container engines for metrics", "error", err)
+		return metrics
+	}
+	for _, containerEngine := range containerEngines {
+		engineName := cleanEngineName(containerEngine)
+		resources, err := listEngineResources(ctx, containerEngine, engineQueryParallelism(filestore))
+		if err != nil {
+			logging.Log().Error("cannot list engine resources for metrics", "engine", engineName, "error", err)
+			metrics.engines[engineName] = false
+			continue
+		}
+		var volumeSizes map[string]int64
+		if usage, err := containerEngine.GetDiskUsage(ctx); err != nil {
+			logging.Log().Warn("cannot obtain disk usage for metrics", "engine", engineName, "error", err)
+		} else {
+			volumeSizes = usage.Volumes
+		}
+		metrics.engines[engineName] = true
+		metrics.projects = append(metrics.projects, engineProjectMetrics(engineName, projectNames, resources, volumeSizes)...)
+	}
+	return metrics
+}
+
+// Attribute the resources of an engine to the projects they belong to, every
+// project in `projectNames` being reported even without any resource.
+func engineProjectMetrics(engineName string, projectNames []string, resources engineResources, volumeSizes map[string]int64) []projectMetrics {
+	byProject := map[string]*projectMetrics{}
+	get := func(project string) *projectMetrics {
+		if byProject[project] == nil {
+			byProject[project] = &projectMetrics{project: project, engine: engineName}
+		}
+		return byProject[project]
+	}
+	for _, project := range projectNames {
+		get(project)
+	}
+	for _, container := range resources.containers {
+		if container.ProjectName != nil && container.Running {
+			get(*container.ProjectName).runningContainers++
+		}
+	}
+	for _, sidecar := range resources.sidecars {
+		if sidecar.Running {
+			get(sidecar.ProjectName).runningContainers++
+		}
+	}
+	for _, image := range resources.images {
+		if image.ProjectName != nil && image.BuiltAt != nil {
+			get(*image.ProjectName).imageBuiltAt = image.BuiltAt
+		}
+	}
+	for _, volume := range resources.volumes {
+		if project, ok := projectNameFromLocalVolume(volume.VolumeName); ok {
+			get(project).volumeBytes += volumeSizes[volume.VolumeName]
+		}
+	}
+
+	result := make([]projectMetrics, 0, len(byProject))
+	for _, m := range byProject {
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].project < result[j].project })
+	return result
+}
+
+// Write those metrics in the Prometheus text exposition format, image ages
+// being relative to `now`.
+func writeMetrics(w io.Writer, metrics fleetMetrics, now time.Time) {
+	engineNames := make([]string, 0, len(metrics.engines))
+	for name := range metrics.engines {
+		engineNames = append(engineNames, name)
+	}
+	sort.Strings(engineNames)
+	fmt.Fprintln(w, "# HELP paulenv_engine_up Whether the container engine could be queried.")
+	fmt.Fprintln(w, "# TYPE paulenv_engine_up gauge")
+	for _, name := range engineNames {
+		up := 0
+		if metrics.engines[name] {
+			up = 1
+		}
+		fmt.Fprintf(w, "paulenv_engine_up{engine=%s} %d\n", metricLabelValue(name), up)
+	}
+
+	fmt.Fprintln(w, "# HELP paulenv_project_running_containers Number of running containers of the project, including its services.")
+	fmt.Fprintln(w, "# TYPE paulenv_project_running_containers gauge")
+	for _, m := range metrics.projects {
+		fmt.Fprintf(w, "paulenv_project_running_containers{project=%s,engine=%s} %d\n", metricLabelValue(m.project), metricLabelValue(m.engine), m.runningContainers)
+	}
+	fmt.Fprintln(w, "# HELP paulenv_project_image_age_seconds Time since the current image of the project was built.")
+	fmt.Fprintln(w, "# TYPE paulenv_project_image_age_seconds gauge")
+	for _, m := range metrics.projects {
+		if m.imageBuiltAt != nil {
+			fmt.Fprintf(w, "paulenv_project_image_age_seconds{project=%s,engine=%s} %s\n", metricLabelValue(m.project), metricLabelValue(m.engine), formatMetricValue(now.Sub(*m.imageBuiltAt).Seconds()))
+		}
+	}
+	fmt.Fprintln(w, "# HELP paulenv_project_volume_bytes Disk space used by the volumes of the project.")
+	fmt.Fprintln(w, "# TYPE paulenv_project_volume_bytes gauge")
+	for _, m := range metrics.projects {
+		fmt.Fprintf(w, "paulenv_project_volume_bytes{project=%s,engine=%s} %d\n", metricLabelValue(m.project), metricLabelValue(m.engine), m.volumeBytes)
+	}
+
+	projects := make([]string, 0, len(metrics.buildDurations))
+	for project := range metrics.buildDurations {
+		projects = append(projects, project)
+	}
+	sort.Strings(projects)
+	fmt.Fprintln(w, "# HELP paulenv_project_build_duration_seconds Duration of the successful builds of the project.")
+	fmt.Fprintln(w, "# TYPE paulenv_project_build_duration_seconds histogram")
+	for _, project := range projects {
+		durations := metrics.buildDurations[project]
+		label := metricLabelValue(project)
+		var sum float64
+		for _, duration := range durations {
+			sum += duration.Seconds()
+		}
+		for _, bound := range buildDurationBuckets {
+			count := 0
+			for _, duration := range durations {
+				if duration.Seconds() <= bound {
+					count++
+				}
+			}
+			fmt.Fprintf(w, "paulenv_project_build_duration_seconds_bucket{project=%s,le=\"%s\"} %d\n", label, formatMetricValue(bound), count)
+		}
+		fmt.Fprintf(w, "paulenv_project_build_duration_seconds_bucket{project=%s,le=\"+Inf\"} %d\n", label, len(durations))
+		fmt.Fprintf(w, "paulenv_project_build_duration_seconds_sum{project=%s} %s\n", label, formatMetricValue(sum))
+		fmt.Fprintf(w, "paulenv_project_build_duration_seconds_count{project=%s} %d\n", label, len(durations))
+	}
+}
+
+// Quote a label value, escaping what the exposition format requires.
+func metricLabelValue(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+func formatMetricValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
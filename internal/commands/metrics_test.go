@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/engine"
+)
+
+func TestEngineProjectMetrics(t *testing.T) {
+	app, other := "app", "other"
+	builtAt := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	resources := engineResources{
+		containers: []engine.ContainerInfo{
+			{ProjectName: &app, ContainerId: "a1", Running: true},
+			{ProjectName: &app, ContainerId: "a2", Running: false},
+			{ProjectName: &other, ContainerId: "o1", Running: true},
+			{ContainerId: "unrelated", Running: true},
+		},
+		sidecars: []engine.SidecarInfo{{ProjectName: "app", ServiceName: "db", Running: true}},
+		images:   []engine.ImageInfo{{ProjectName: &app, ImageName: "paulenv:app", BuiltAt: &builtAt}},
+		volumes:  []engine.VolumeInfo{{VolumeName: "paulenv-app-local"}, {VolumeName: "paulenv-shared-cache"}},
+	}
+	metrics := engineProjectMetrics("docker", []string{"app", "idle"}, resources, map[string]int64{"paulenv-app-local": 2048, "paulenv-shared-cache": 4096})
+
+	if len(metrics) != 3 {
+		t.Fatalf("engineProjectMetrics() = %+v, want app, idle and other", metrics)
+	}
+	byProject := map[string]projectMetrics{}
+	for _, m := range metrics {
+		byProject[m.project] = m
+	}
+	if m := byProject["app"]; m.runningContainers != 2 || m.imageBuiltAt == nil || !m.imageBuiltAt.Equal(builtAt) || m.volumeBytes != 2048 {
+		t.Errorf("app metrics = %+v", m)
+	}
+	if m := byProject["idle"]; m.runningContainers != 0 || m.imageBuiltAt != nil || m.volumeBytes != 0 || m.engine != "docker" {
+		t.Errorf("idle metrics = %+v", m)
+	}
+	if m := byProject["other"]; m.runningContainers != 1 {
+		t.Errorf("other metrics = %+v", m)
+	}
+}
+
+func TestWriteMetrics(t *testing.T) {
+	now := time.Date(2026, 1, 2, 1, 0, 0, 0, time.UTC)
+	builtAt := now.Add(-90 * time.Minute)
+	var out strings.Builder
+	writeMetrics(&out, fleetMetrics{
+		engines: map[string]bool{"docker": true, "podman": false},
+		projects: []projectMetrics{
+			{project: "app", engine: "docker", runningContainers: 2, imageBuiltAt: &builtAt, volumeBytes: 2048},
+			{project: `we"ird`, engine: "docker"},
+		},
+		buildDurations: map[string][]time.Duration{
+			"app":   {45 * time.Second, 90 * time.Second, 2 * time.Hour},
+			"fresh": {},
+		},
+	}, now)
+
+	got := out.String()
+	for _, want := range []string{
+		"# TYPE paulenv_engine_up gauge\n",
+		`paulenv_engine_up{engine="docker"} 1` + "\n",
+		`paulenv_engine_up{engine="podman"} 0` + "\n",
+		`paulenv_project_running_containers{project="app",engine="docker"} 2` + "\n",
+		`paulenv_project_running_containers{project="we\"ird",engine="docker"} 0` + "\n",
+		`paulenv_project_image_age_seconds{project="app",engine="docker"} 5400` + "\n",
+		`paulenv_project_volume_bytes{project="app",engine="docker"} 2048` + "\n",
+		"# TYPE paulenv_project_build_duration_seconds histogram\n",
+		`paulenv_project_build_duration_seconds_bucket{project="app",le="30"} 0` + "\n",
+		`paulenv_project_build_duration_seconds_bucket{project="app",le="60"} 1` + "\n",
+		`paulenv_project_build_duration_seconds_bucket{project="app",le="3600"} 2` + "\n",
+		`paulenv_project_build_duration_seconds_bucket{project="app",le="+Inf"} 3` + "\n",
+		`paulenv_project_build_duration_seconds_sum{project="app"} 7335` + "\n",
+		`paulenv_project_build_duration_seconds_count{project="fresh"} 0` + "\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("metrics do not contain %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, `paulenv_project_image_age_seconds{project="we\"ird"`) {
+		t.Errorf("metrics report the age of a missing image:\n%s", got)
+	}
+}
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Migrate(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var list bool
+	var rollback bool
+	flagset := newCommandFlagSet("migrate", console)
+	flagset.BoolVar(&list, "list", false, "Only list the pending migrations")
+	flagset.BoolVar(&rollback, "rollback", false, "Restore paul-envs' files as they were before the last migration, e.g. to go\nback to the previous release")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs migrate [flags]",
+			"Upgrade paul-envs' files (its state and every project) written by a previous release to the formats of this one. Run it after upgrading paul-envs.\n\nA backup of paul-envs' files is written first: a failed migration is reverted, and '--rollback' restores them as they were before the last one.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flagset.NArg() > 0 {
+		return utils.WithCategory(errors.New("migrate does not take arguments"), errUsage)
+	}
+	if list && rollback {
+		return utils.WithCategory(errors.New("--list and --rollback cannot be combined"), errUsage)
+	}
+
+	// Projects must not be created or removed while being migrated
+	unlock, err := lockRegistry(ctx, filestore, console)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if rollback {
+		backupPath, err := filestore.RollbackMigrations(ctx)
+		if err != nil {
+			return fmt.Errorf("cannot roll back the last migration: %w", err)
+		}
+		console.Success("Restored paul-envs' files from %s", backupPath)
+		return nil
+	}
+
+	migrations, err := filestore.PendingMigrations()
+	if err != nil {
+		return fmt.Errorf("cannot migrate: %w", err)
+	}
+	if len(migrations) == 0 {
+		console.WriteLn("Everything is up to date")
+		return nil
+	}
+	for _, m := range migrations {
+		console.WriteLn("%s", m)
+		for _, step := range m.Steps {
+			console.WriteLn("  - %s", step)
+		}
+	}
+	if list {
+		return nil
+	}
+
+	backupPath, err := filestore.Migrate(ctx, migrations)
+	if err != nil {
+		return err
+	}
+	console.Success("Migrated %d item(s)", len(migrations))
+	console.WriteLn("Previous files saved to %s, restore them with 'paul-envs migrate --rollback'.", backupPath)
+	return nil
+}
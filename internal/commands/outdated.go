@@ -0,0 +1,189 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// A built project whose image is behind the latest distribution image.
+type outdatedProject struct {
+	name   string
+	reason string
+}
+
+func Outdated(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var rebuild bool
+	var engineSelection string
+	flagset := newCommandFlagSet("outdated", console)
+	flagset.BoolVar(&rebuild, "rebuild", false, "Move to the latest distribution image and rebuild the projects which are behind")
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to use: docker or podman.\nDefault: auto-select, preferring Podman.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs outdated [flags]",
+			"Check the registry for the latest image of the distribution the shared base image is built from (e.g. ubuntu:24.04), and list the built projects whose image is behind it: either because that distribution image was updated since the base image was built, or because they were built on a previous base image.\n\nThe distribution image is pulled to know its latest digest, but builds keep using the one it is pinned to until 'paul-envs update' or '--rebuild'.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flagset.NArg() > 0 {
+		return utils.WithCategory(errors.New("unexpected arguments"), errUsage)
+	}
+
+	buildOptions := engine.BuildOptions{}
+	// Errors have already been reported when starting
+	if globalConfig, err := filestore.LoadGlobalConfig(); err == nil {
+		buildOptions.DistributionImage = globalConfig.BaseImage
+	}
+	selectedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+	containerEngine, err := engine.NewSelected(ctx, console, selectedEngine)
+	if err != nil {
+		return err
+	}
+	if err := engine.EnsurePodmanMachine(ctx, containerEngine, engine.MachineNeeds{}, console); err != nil {
+		return err
+	}
+	engineInfo, err := containerEngine.Info(ctx)
+	if err != nil {
+		return fmt.Errorf("could not obtain container engine information: %w", err)
+	}
+
+	distributionImage := distributionImageOf(buildOptions)
+	console.Info("Checking the registry for the latest %s...", distributionImage)
+	freshness, err := checkBaseImageFreshness(ctx, containerEngine, engineInfo.Name, distributionImage, filestore)
+	if err != nil {
+		return err
+	}
+
+	baseBehind := freshness.behind()
+	switch {
+	case freshness.latestDigest == "":
+		console.Warn("%s has no registry digest, only projects built on a previous base image are reported", distributionImage)
+	case freshness.builtDigest == "":
+		console.Warn("The image of %s the shared base image was built from is unknown, rebuild it with 'paul-envs update' to track it", distributionImage)
+	case baseBehind:
+		console.WriteLn("%s was updated: the shared base image is built from %s, its latest image is %s", distributionImage, freshness.builtDigest, freshness.latestDigest)
+	default:
+		console.WriteLn("The shared base image is built from the latest %s (%s)", distributionImage, freshness.latestDigest)
+	}
+
+	outdated, err := findOutdatedProjects(engineInfo.Name, baseBehind, filestore)
+	if err != nil {
+		return err
+	}
+	if len(outdated) == 0 {
+		console.Success("No built project is behind the latest %s", distributionImage)
+		if baseBehind && rebuild {
+			return Update(ctx, engineArgs(engineSelection), filestore, console)
+		}
+		return nil
+	}
+	names := make([]string, 0, len(outdated))
+	for _, project := range outdated {
+		names = append(names, project.name)
+		console.WriteLn("  %s: %s", project.name, project.reason)
+	}
+	if !rebuild {
+		console.Warn("%d project(s) are behind the latest %s", len(outdated), distributionImage)
+		console.WriteLn("Hint: Rebuild them with 'paul-envs outdated --rebuild'")
+		return nil
+	}
+
+	if baseBehind {
+		if err := Update(ctx, engineArgs(engineSelection), filestore, console); err != nil {
+			return err
+		}
+	}
+	rebuildArgs := append(append([]string{"--stale"}, engineArgs(engineSelection)...), names...)
+	return Rebuild(ctx, rebuildArgs, filestore, console)
+}
+
+// Digests of the distribution image the shared base image of an engine was
+// built from, and of its latest image in the registry. Either is empty when
+// unknown.
+type baseImageFreshness struct {
+	builtDigest  string
+	latestDigest string
+}
+
+// Returns `true` if the shared base image is known to be behind the latest
+// distribution image.
+func (f baseImageFreshness) behind() bool {
+	return f.builtDigest != "" && f.latestDigest != "" && f.builtDigest != f.latestDigest
+}
+
+// Pull the latest `distributionImage` to compare it to the one the shared base
+// image of that engine was built from.
+func checkBaseImageFreshness(
+	ctx context.Context,
+	containerEngine engine.ContainerEngine,
+	engineName string,
+	distributionImage string,
+	filestore *files.FileStore,
+) (baseImageFreshness, error) {
+	builtDigest, err := filestore.GetBaseImageDistributionDigest(engineName)
+	if err != nil {
+		return baseImageFreshness{}, err
+	}
+	latestDigest, err := containerEngine.ImageDigest(ctx, distributionImage, true)
+	if err != nil {
+		return baseImageFreshness{}, err
+	}
+	return baseImageFreshness{builtDigest: builtDigest, latestDigest: latestDigest}, nil
+}
+
+// Returns the projects built with the given engine whose image is behind the
+// latest distribution image: all of them if `baseBehind` is set, as the shared
+// base image itself is, otherwise those built on a previous base image.
+func findOutdatedProjects(engineName string, baseBehind bool, filestore *files.FileStore) ([]outdatedProject, error) {
+	entries, err := filestore.GetAllProjects()
+	if err != nil {
+		return nil, fmt.Errorf("could not list all projects: %w", err)
+	}
+	var outdated []outdatedProject
+	for _, entry := range entries {
+		buildInfo, err := filestore.ReadBuildInfo(entry.ProjectName)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("cannot read build information of project '%s': %w", entry.ProjectName, err)
+		}
+		needsRebuild, reason, err := filestore.NeedsRebuild(entry.ProjectName, engineName, buildInfo)
+		switch {
+		case err != nil:
+			return nil, err
+		case reason == files.RebuildDifferentEngine:
+			// Built on the base image of another engine, checked with it
+			continue
+		case baseBehind:
+			outdated = append(outdated, outdatedProject{name: entry.ProjectName, reason: "the distribution image was updated since its base image was built"})
+		case needsRebuild && reason == files.RebuildBaseImageChanged:
+			outdated = append(outdated, outdatedProject{name: entry.ProjectName, reason: reason.String()})
+		}
+	}
+	return outdated, nil
+}
+
+// Arguments forwarding an explicit `--engine` selection to another command.
+func engineArgs(engineSelection string) []string {
+	if engineSelection == "" {
+		return nil
+	}
+	return []string{"--engine", engineSelection}
+}
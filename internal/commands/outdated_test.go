@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestFindOutdatedProjects(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	store, err := files.NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	for _, name := range []string{"app", "docs", "web"} {
+		if err := store.CreateProjectFiles(
+			name,
+			testBuildTemplateData(),
+			files.RuntimeTemplateData{Version: "1.0.0", ProjectHostPath: t.TempDir()},
+		); err != nil {
+			t.Fatalf("CreateProjectFiles() error = %v", err)
+		}
+	}
+	if err := store.RefreshBaseImageBuildInfo("docker", "", "sha256:aaa"); err != nil {
+		t.Fatalf("RefreshBaseImageBuildInfo() error = %v", err)
+	}
+	if err := store.RefreshBuildInfoFile("app", "docker", "27.0.0"); err != nil {
+		t.Fatalf("RefreshBuildInfoFile() error = %v", err)
+	}
+	if err := store.RefreshBuildInfoFile("web", "podman", "5.0.0"); err != nil {
+		t.Fatalf("RefreshBuildInfoFile() error = %v", err)
+	}
+
+	outdated, err := findOutdatedProjects("docker", false, store)
+	if err != nil || len(outdated) != 0 {
+		t.Fatalf("findOutdatedProjects() = %v, %v, want none", outdated, err)
+	}
+	outdated, err = findOutdatedProjects("docker", true, store)
+	if err != nil || len(outdated) != 1 || outdated[0].name != "app" {
+		t.Fatalf("findOutdatedProjects() with the base image behind = %v, %v, want app", outdated, err)
+	}
+
+	// The shared base image was rebuilt since "app" was built
+	baseInfo := filepath.Join(store.GetBaseFilesDir(), "base-docker.buildinfo")
+	if err := os.WriteFile(baseInfo, []byte("DOCKERFILE=other\nLAST_BUILT_AT=2000-01-01T00:00:00Z\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outdated, err = findOutdatedProjects("docker", false, store)
+	if err != nil || len(outdated) != 1 || outdated[0].reason != files.RebuildBaseImageChanged.String() {
+		t.Fatalf("findOutdatedProjects() on a previous base image = %v, %v, want app", outdated, err)
+	}
+}
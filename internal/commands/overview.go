@@ -0,0 +1,317 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// State of a single project, cross-referenced between its files and what the
+// available container engines know about it.
+type projectOverview struct {
+	Entry files.ProjectEntry
+	// Name of the engine on which its image has been found, empty if it has not
+	// been built on any available engine.
+	EngineName string
+	Image      *engine.ImageInfo
+	Containers []engine.ContainerInfo
+	Volumes    []engine.VolumeInfo
+	Networks   []engine.NetworkInfo
+	// The engine on which containers and image were found, `nil` if none
+	containerEngine engine.ContainerEngine
+}
+
+func (p projectOverview) IsBuilt() bool {
+	return p.Image != nil
+}
+
+func (p projectOverview) RunningContainers() []engine.ContainerInfo {
+	running := []engine.ContainerInfo{}
+	for _, container := range p.Containers {
+		if container.Running {
+			running = append(running, container)
+		}
+	}
+	return running
+}
+
+// Collect an overview of every project across all available engines.
+//
+// Engine-level failures are reported as warnings, in which case the
+// corresponding information is just missing.
+func collectProjectOverviews(
+	ctx context.Context,
+	filestore *files.FileStore,
+	console *console.Console,
+) ([]projectOverview, error) {
+	entries, err := filestore.GetAllProjects()
+	if err != nil {
+		return nil, fmt.Errorf("could not list all projects: %w", err)
+	}
+
+	overviews := make([]projectOverview, 0, len(entries))
+	indexes := map[string]int{}
+	for i, entry := range entries {
+		overviews = append(overviews, projectOverview{Entry: entry})
+		indexes[entry.ProjectName] = i
+	}
+	if len(entries) == 0 {
+		return overviews, nil
+	}
+
+	engines, err := engine.NewSet(ctx, console, engine.SelectionAll)
+	if err != nil {
+		if !applyCachedOverviews(overviews, filestore, console) {
+			console.Warn("Could not obtain container engine information: %s", err)
+		}
+		return overviews, nil
+	}
+
+	results := queryOverviewEngines(ctx, engines, engineQueryParallelism(filestore))
+	var unreachable []error
+	complete := true
+	for i, containerEngine := range engines {
+		result := results[i]
+		if result.imagesErr != nil {
+			// Most likely unreachable (e.g. its daemon or machine is stopped),
+			// other calls would have failed the same way
+			unreachable = append(unreachable, result.imagesErr)
+			continue
+		}
+		for _, image := range result.images {
+			idx, ok := projectIndex(indexes, image.ProjectName)
+			if !ok || overviews[idx].Image != nil {
+				continue
+			}
+			overviews[idx].Image = &image
+			overviews[idx].EngineName = result.engineName
+			overviews[idx].containerEngine = containerEngine
+		}
+
+		if result.containersErr != nil {
+			console.Warn("Could not list containers: %s", result.containersErr)
+			complete = false
+		}
+		for _, container := range result.containers {
+			idx, ok := projectIndex(indexes, container.ProjectName)
+			if !ok {
+				continue
+			}
+			overviews[idx].Containers = append(overviews[idx].Containers, container)
+			if overviews[idx].containerEngine == nil {
+				overviews[idx].containerEngine = containerEngine
+				overviews[idx].EngineName = result.engineName
+			}
+		}
+
+		if result.volumesErr != nil {
+			console.Warn("Could not list volumes: %s", result.volumesErr)
+			complete = false
+		}
+		for _, volume := range result.volumes {
+			// Also its services' and instances' volumes
+			projectName, ok := projectNameFromLocalVolume(volume.VolumeName)
+			if !ok {
+				continue
+			}
+			if idx, ok := indexes[projectName]; ok {
+				overviews[idx].Volumes = append(overviews[idx].Volumes, volume)
+			}
+		}
+
+		if result.networksErr != nil {
+			console.Warn("Could not list networks: %s", result.networksErr)
+			complete = false
+		}
+		for _, network := range result.networks {
+			if idx, ok := projectIndex(indexes, network.ProjectName); ok {
+				overviews[idx].Networks = append(overviews[idx].Networks, network)
+			}
+		}
+	}
+
+	if len(unreachable) == len(engines) && applyCachedOverviews(overviews, filestore, console) {
+		return overviews, nil
+	}
+	for _, err := range unreachable {
+		console.Warn("Could not list images: %s", err)
+	}
+	if len(unreachable) == 0 && complete {
+		if err := filestore.SetEngineStateCache(overviewsCache(overviews, time.Now())); err != nil {
+			console.Warn("Could not cache the state of projects: %s", err)
+		}
+	}
+	return overviews, nil
+}
+
+// What an engine reported for `collectProjectOverviews`.
+type overviewEngineResult struct {
+	// Empty if unknown
+	engineName    string
+	images        []engine.ImageInfo
+	imagesErr     error
+	containers    []engine.ContainerInfo
+	containersErr error
+	volumes       []engine.VolumeInfo
+	volumesErr    error
+	networks      []engine.NetworkInfo
+	networksErr   error
+}
+
+// Query all given engines at once, at most `limit` queries being made at the
+// same time, as each can take a while (e.g. through a Podman machine).
+// Failures are reported in the results, to be handled by query.
+func queryOverviewEngines(ctx context.Context, engines []engine.ContainerEngine, limit int) []overviewEngineResult {
+	results := make([]overviewEngineResult, len(engines))
+	var queries []func()
+	for i, containerEngine := range engines {
+		result := &results[i]
+		queries = append(queries,
+			func() {
+				if info, err := containerEngine.Info(ctx); err == nil {
+					result.engineName = info.Name
+				}
+			},
+			func() { result.images, result.imagesErr = containerEngine.ListImages(ctx) },
+			func() { result.containers, result.containersErr = containerEngine.ListContainers(ctx) },
+			func() { result.volumes, result.volumesErr = containerEngine.ListVolumes(ctx) },
+			func() { result.networks, result.networksErr = containerEngine.ListNetworks(ctx) },
+		)
+	}
+	utils.Parallel(len(queries), limit, func(i int) { queries[i]() })
+	return results
+}
+
+// Fill overviews from the last state seen on container engines, for when none
+// is reachable, announcing it. Returns `false` if there's none.
+func applyCachedOverviews(overviews []projectOverview, filestore *files.FileStore, console *console.Console) bool {
+	cache, err := filestore.GetEngineStateCache()
+	if err != nil {
+		console.Warn("Could not read the cached state of projects: %s", err)
+		return false
+	}
+	if cache == nil {
+		return false
+	}
+	warnShowingCachedState(cache, console)
+	for i := range overviews {
+		state, ok := cache.Projects[overviews[i].Entry.ProjectName]
+		if !ok {
+			continue
+		}
+		overviews[i].EngineName = state.EngineName
+		overviews[i].Image = cachedImageInfo(cache, overviews[i].Entry.ProjectName)
+		for _, container := range state.Containers {
+			overviews[i].Containers = append(overviews[i].Containers, engine.ContainerInfo{
+				ProjectName:   &overviews[i].Entry.ProjectName,
+				ContainerName: &container.Name,
+				Running:       container.Running,
+			})
+		}
+		for _, volume := range state.Volumes {
+			overviews[i].Volumes = append(overviews[i].Volumes, engine.VolumeInfo{VolumeName: volume})
+		}
+		for _, network := range state.Networks {
+			overviews[i].Networks = append(overviews[i].Networks, engine.NetworkInfo{
+				ProjectName: &overviews[i].Entry.ProjectName,
+				NetworkName: network,
+			})
+		}
+	}
+	return true
+}
+
+func warnShowingCachedState(cache *files.EngineStateCache, console *console.Console) {
+	console.Warn("Container engines are unreachable, showing cached data from %s.", cache.At.Local().Format("2006-01-02 15:04"))
+	console.WriteLn("Hint: Run 'paul-envs doctor' to find out why")
+}
+
+// Image of the given project in the cached state of projects, `nil` if it was
+// not built.
+func cachedImageInfo(cache *files.EngineStateCache, projectName string) *engine.ImageInfo {
+	if cache == nil {
+		return nil
+	}
+	state, ok := cache.Projects[projectName]
+	if !ok || state.ImageName == "" {
+		return nil
+	}
+	return &engine.ImageInfo{
+		ProjectName: &projectName,
+		ImageName:   state.ImageName,
+		BuiltAt:     state.ImageBuiltAt,
+		Size:        state.ImageSize,
+	}
+}
+
+// State of the given overviews, to be displayed while engines are unreachable.
+func overviewsCache(overviews []projectOverview, at time.Time) files.EngineStateCache {
+	cache := files.EngineStateCache{At: at, Projects: map[string]*files.CachedProjectState{}}
+	for _, overview := range overviews {
+		state := &files.CachedProjectState{EngineName: overview.EngineName}
+		if overview.Image != nil {
+			state.ImageName = overview.Image.ImageName
+			state.ImageBuiltAt = overview.Image.BuiltAt
+			state.ImageSize = overview.Image.Size
+		}
+		for _, container := range overview.Containers {
+			name := container.ContainerId
+			if container.ContainerName != nil {
+				name = *container.ContainerName
+			}
+			state.Containers = append(state.Containers, files.CachedContainer{Name: name, Running: container.Running})
+		}
+		for _, volume := range overview.Volumes {
+			state.Volumes = append(state.Volumes, volume.VolumeName)
+		}
+		for _, network := range overview.Networks {
+			state.Networks = append(state.Networks, network.NetworkName)
+		}
+		cache.Projects[overview.Entry.ProjectName] = state
+	}
+	return cache
+}
+
+func projectIndex(indexes map[string]int, projectName *string) (int, bool) {
+	if projectName == nil {
+		return 0, false
+	}
+	idx, ok := indexes[*projectName]
+	return idx, ok
+}
+
+// Format how long ago an image was built in a short human-readable way (e.g.
+// "3d ago").
+func formatImageAge(builtAt *time.Time, now time.Time) string {
+	if builtAt == nil {
+		return "-"
+	}
+	if now.Sub(*builtAt) < time.Minute {
+		return "just now"
+	}
+	return formatAge(builtAt, now) + " ago"
+}
+
+// Format the time elapsed since the given one in a short human-readable way
+// (e.g. "3d"), for columns of ages and uptimes.
+func formatAge(since *time.Time, now time.Time) string {
+	if since == nil {
+		return "-"
+	}
+	age := now.Sub(*since)
+	switch {
+	case age < time.Minute:
+		return "<1m"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(age.Hours()/24))
+	}
+}
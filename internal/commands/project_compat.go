@@ -10,6 +10,7 @@ import (
 	"github.com/peaberberian/paul-envs/internal/config"
 	"github.com/peaberberian/paul-envs/internal/console"
 	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
 )
 
 func ensureProjectCompatible(projectName string, filestore *files.FileStore, console *console.Console) error {
@@ -28,14 +29,14 @@ func ensureProjectCompatible(projectName string, filestore *files.FileStore, con
 			return offerProjectReinitialize(projectName, filestore, console,
 				fmt.Sprintf("This project uses an incompatible build.conf format (%s).", err))
 		}
-		return fmt.Errorf("invalid build.conf: %w", err)
+		return utils.WithCategory(fmt.Errorf("invalid build.conf: %w", err), errValidationFailed)
 	}
 	if _, err := config.LoadRuntimeConfig(filestore.GetProjectRuntimeConfigPath(projectName)); err != nil {
 		if strings.Contains(err.Error(), "incompatible VERSION") || strings.Contains(err.Error(), "missing required directive VERSION") {
 			return offerProjectReinitialize(projectName, filestore, console,
 				fmt.Sprintf("This project uses an incompatible run.conf format (%s).", err))
 		}
-		return fmt.Errorf("invalid run.conf: %w", err)
+		return utils.WithCategory(fmt.Errorf("invalid run.conf: %w", err), errValidationFailed)
 	}
 
 	_, err = filestore.ReadBuildInfo(projectName)
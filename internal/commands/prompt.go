@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Run at each shell prompt, so it only reads paul-envs' own files: neither
+// container engine nor global configuration.
+func Prompt(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	var format string
+	var staleSymbol string
+	flagset := newCommandFlagSet("prompt", console)
+	flagset.StringVar(&format, "format", "{project}{stale}", "What to output, where {project} is replaced by the project's name and {stale} by\n--stale-symbol if its image has to be (re)built.")
+	flagset.StringVar(&staleSymbol, "stale-symbol", "*", "Symbol replacing {stale} when the project's image is missing or stale")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs prompt [flags] [project-name]",
+			"Output the given project, or the one selected through "+projectEnvVar+" (see 'paul-envs shellenv'), for a shell prompt to display it, along with whether its image is missing or stale. Nothing is output without a project.\n\nIt is fast enough to be run at each prompt: container engines are not queried, the project's image being known from its last build.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) > 1 {
+		return utils.WithCategory(errors.New("prompt takes at most one project name"), errUsage)
+	}
+
+	name := os.Getenv(projectEnvVar)
+	if len(args) == 1 {
+		name = args[0]
+	}
+	if name == "" || utils.ValidateProjectName(name) != nil || !filestore.DoesProjectExist(name) {
+		return nil
+	}
+	stale := ""
+	if isProjectImageStale(name, filestore) {
+		stale = staleSymbol
+	}
+	_, err := fmt.Fprintln(console.Writer(), strings.NewReplacer("{project}", name, "{stale}", stale).Replace(format))
+	return err
+}
+
+// Tell if the image of the project has to be (re)built, from the record of
+// its last build: its configuration or the shared base image changed since,
+// or it never was built.
+func isProjectImageStale(name string, filestore *files.FileStore) bool {
+	buildInfo, err := filestore.ReadBuildInfo(name)
+	if err != nil {
+		return true
+	}
+	needsRebuild, _, err := filestore.NeedsRebuild(name, "", buildInfo)
+	return err == nil && needsRebuild
+}
@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestPrompt(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	store, err := files.NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	if err := store.CreateProjectFiles(
+		"app",
+		testBuildTemplateData(),
+		files.RuntimeTemplateData{Version: "1.0.0", ProjectHostPath: t.TempDir()},
+	); err != nil {
+		t.Fatalf("CreateProjectFiles() error = %v", err)
+	}
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		env  string
+		args []string
+		want string
+	}{
+		// Never built
+		{"app", nil, "app*\n"},
+		{"", []string{"--format", "({project}{stale})", "--stale-symbol", " !", "app"}, "(app !)\n"},
+		{"", nil, ""},
+		{"missing", nil, ""},
+		{"in valid", nil, ""},
+	} {
+		t.Setenv(projectEnvVar, tc.env)
+		var out bytes.Buffer
+		if err := Prompt(ctx, tc.args, store, console.New(ctx, strings.NewReader(""), &out, &out)); err != nil {
+			t.Fatalf("Prompt(%v) with %s=%q error = %v", tc.args, projectEnvVar, tc.env, err)
+		}
+		if out.String() != tc.want {
+			t.Errorf("Prompt(%v) with %s=%q = %q, want %q", tc.args, projectEnvVar, tc.env, out.String(), tc.want)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Rebuild(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	var stale bool
+	var noCache bool
+	var jobs int
+	var engineSelection string
+	flagset := newCommandFlagSet("rebuild", console)
+	flagset.BoolVar(&stale, "stale", false, "Only rebuild projects whose image no longer matches their definition, e.g. because their build.conf or the shared base image changed since")
+	flagset.BoolVar(&noCache, "no-cache", false, "Build the images without using cached layers")
+	flagset.IntVar(&jobs, "jobs", 0, "Maximum number of images built at once. Default: the PARALLELISM setting of the global configuration.")
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to use for those builds: docker or podman. Default: the one each project was last built with.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs rebuild [flags] [project-name|@group...]",
+			"Rebuild the images of the given projects, or of those of the given groups ('@group', see 'paul-envs group'), by default of all already built ones, in parallel. The output of each build is written to its build log, and a summary of rebuilt, failed and skipped projects is displayed once they are all over.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if jobs < 0 {
+		return utils.WithCategory(errors.New("--jobs cannot be negative"), errUsage)
+	}
+	requestedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return utils.WithCategory(err, errUsage)
+	}
+	buildOptions := engine.BuildOptions{NoCache: noCache, Quiet: true, StallThreshold: engine.DefaultBuildStallThreshold}
+	if globalConfig, err := filestore.LoadGlobalConfig(); err == nil {
+		applyGlobalBuildConfig(&buildOptions, globalConfig)
+		if jobs == 0 {
+			jobs = globalConfig.MaxParallelism()
+		}
+	}
+
+	names, err := resolveProjectSelectors(flagset.Args(), filestore)
+	if err != nil {
+		return err
+	}
+	return rebuildProjects(ctx, names, stale, requestedEngine, buildOptions, jobs, filestore, console)
+}
+
+// Rebuild the images of the given projects, of all already built ones if
+// none is given, with at most `jobs` builds at once, then display a summary
+// of their outcome.
+func rebuildProjects(
+	ctx context.Context,
+	names []string,
+	stale bool,
+	requestedEngine engine.Selection,
+	buildOptions engine.BuildOptions,
+	jobs int,
+	filestore *files.FileStore,
+	console *console.Console,
+) error {
+	explicit := len(names) > 0
+	if !explicit {
+		entries, err := filestore.GetAllProjects()
+		if err != nil {
+			return fmt.Errorf("could not list all projects: %w", err)
+		}
+		for _, entry := range entries {
+			names = append(names, entry.ProjectName)
+		}
+	}
+	for _, name := range names {
+		if err := validateProjectName(name); err != nil {
+			return err
+		}
+		if !filestore.DoesProjectExist(name) {
+			return projectNotFoundError(name)
+		}
+	}
+
+	// Engines are shared by projects built with the same one
+	engines := map[engine.Selection]*rebuildEngine{}
+	var planned []rebuildTarget
+	summary := rebuildSummary{}
+	for _, name := range names {
+		if err := ensureProjectCompatible(name, filestore, console); err != nil {
+			summary.Failed = append(summary.Failed, name)
+			console.Error("Cannot rebuild project '%s': %s", name, err)
+			continue
+		}
+		selection := resolveProjectEngineSelection(name, requestedEngine, filestore, console)
+		target, ok := engines[selection]
+		if !ok {
+			containerEngine, err := engine.NewSelected(ctx, console, selection)
+			if err != nil {
+				return err
+			}
+			target = &rebuildEngine{containerEngine: containerEngine}
+			target.info, target.infoErr = containerEngine.Info(ctx)
+			engines[selection] = target
+		}
+		status, reason, err := projectRebuildStatus(ctx, name, target.containerEngine, target.info.Name, filestore)
+		if err != nil {
+			console.Warn("Could not check if project '%s' is up-to-date: %s", name, err)
+		}
+		switch {
+		case status == rebuildNotBuilt && !explicit:
+			summary.NotBuilt = append(summary.NotBuilt, name)
+			continue
+		case status == rebuildUpToDate && stale:
+			summary.UpToDate = append(summary.UpToDate, name)
+			continue
+		case status == rebuildNotBuilt && stale:
+			reason = "never built"
+		}
+		project, err := filestore.GetProject(name)
+		if err != nil {
+			return fmt.Errorf("failed to obtain information on project '%s': %w", name, err)
+		}
+		target.used = true
+		planned = append(planned, rebuildTarget{project: project, engine: target, reason: reason})
+	}
+
+	if len(planned) > 0 {
+		if err := filestore.RefreshBaseFiles(); err != nil {
+			return fmt.Errorf("cannot build: Failed to refresh base build files: %w", err)
+		}
+	}
+	for _, target := range engines {
+		if !target.used {
+			continue
+		}
+		if err := ensureSharedCacheVolumeIsCreated(ctx, target.containerEngine); err != nil {
+			return err
+		}
+		if _, err := ensureBaseImageIsBuilt(ctx, target.containerEngine, target.info.Name, false, buildOptions, filestore, console); err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	results := make([]error, len(planned))
+	utils.Parallel(len(planned), jobs, func(i int) {
+		results[i] = rebuildProject(ctx, planned[i], buildOptions, filestore, console)
+	})
+	for i, target := range planned {
+		if results[i] != nil {
+			summary.Failed = append(summary.Failed, target.project.ProjectName)
+		} else {
+			summary.Rebuilt = append(summary.Rebuilt, target.project.ProjectName)
+		}
+	}
+
+	writeRebuildSummary(console, summary)
+	var failure error
+	if len(summary.Failed) > 0 {
+		failure = utils.WithCategory(fmt.Errorf("failed to rebuild %s", strings.Join(summary.Failed, ", ")), errBuildFailed)
+	}
+	if len(planned) > 0 {
+		notifyLongOperation(ctx, filestore, console, start, fmt.Sprintf("Rebuild of %d project(s)", len(planned)), failure)
+	}
+	return failure
+}
+
+// Container engine with which projects are rebuilt.
+type rebuildEngine struct {
+	containerEngine engine.ContainerEngine
+	info            engine.EngineInfo
+	infoErr         error
+	// `true` if at least one project is rebuilt with it
+	used bool
+}
+
+type rebuildTarget struct {
+	project files.ProjectEntry
+	engine  *rebuildEngine
+	// Why it is rebuilt, empty if it is explicitly asked for
+	reason string
+}
+
+// Whether the image of a project matches its definition.
+type rebuildStatus int
+
+const (
+	rebuildUpToDate rebuildStatus = iota
+	rebuildStale
+	rebuildNotBuilt
+)
+
+// Tell if the image of that project no longer matches its definition, with
+// the reason why if so.
+//
+// Errors are returned along with `rebuildStale`, as an image which cannot be
+// checked had better be rebuilt.
+func projectRebuildStatus(
+	ctx context.Context,
+	name string,
+	containerEngine engine.ContainerEngine,
+	engineName string,
+	filestore *files.FileStore,
+) (rebuildStatus, string, error) {
+	buildInfo, err := filestore.ReadBuildInfo(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return rebuildNotBuilt, "", nil
+	} else if err != nil {
+		return rebuildStale, "invalid build information", err
+	}
+	hasBeenBuilt, err := containerEngine.HasBeenBuilt(ctx, name)
+	if err != nil {
+		return rebuildStale, "unknown image status", err
+	} else if !hasBeenBuilt {
+		return rebuildStale, "its image is missing", nil
+	}
+	needsRebuild, reason, err := filestore.NeedsRebuild(name, engineName, buildInfo)
+	if err != nil {
+		return rebuildStale, "unknown build state", err
+	} else if needsRebuild {
+		return rebuildStale, reason.String(), nil
+	}
+	return rebuildUpToDate, "", nil
+}
+
+func rebuildProject(
+	ctx context.Context,
+	target rebuildTarget,
+	buildOptions engine.BuildOptions,
+	filestore *files.FileStore,
+	console *console.Console,
+) error {
+	name := target.project.ProjectName
+	unlock, err := lockProject(ctx, name, filestore, console)
+	if err != nil {
+		console.Error("%s", err)
+		return err
+	}
+	defer unlock()
+
+	if target.reason != "" {
+		console.Info("Rebuilding project '%s': %s...", name, target.reason)
+	} else {
+		console.Info("Rebuilding project '%s'...", name)
+	}
+	start := time.Now()
+	_, err = buildProjectImage(ctx, target.project, target.engine.containerEngine, target.engine.info, target.engine.infoErr, buildOptions, filestore, console)
+	if err != nil {
+		console.Error("Failed to rebuild project '%s': %s", name, err)
+		// Its output was only written to its build log
+		var buildErr *engine.BuildError
+		if errors.As(err, &buildErr) && buildErr.LogTail != "" {
+			console.WriteLn("%s", strings.TrimRight(lastLines(buildErr.LogTail, rebuildFailureLines), "\n"))
+		}
+		return err
+	}
+	console.Success("Rebuilt project '%s' in %s", name, time.Since(start).Round(time.Second))
+	return nil
+}
+
+// Number of lines of the output of a failed build displayed by `rebuild`.
+const rebuildFailureLines = 10
+
+// Outcome of a `rebuild`, by project name.
+type rebuildSummary struct {
+	Rebuilt  []string
+	Failed   []string
+	UpToDate []string
+	NotBuilt []string
+}
+
+func (s rebuildSummary) Lines() []string {
+	lines := []string{}
+	if len(s.Rebuilt) > 0 {
+		lines = append(lines, "  Rebuilt     : "+strings.Join(s.Rebuilt, ", "))
+	}
+	if len(s.Failed) > 0 {
+		lines = append(lines, "  Failed      : "+strings.Join(s.Failed, ", "))
+	}
+	if len(s.UpToDate) > 0 {
+		lines = append(lines, "  Up-to-date  : "+strings.Join(s.UpToDate, ", "))
+	}
+	if len(s.NotBuilt) > 0 {
+		lines = append(lines, "  Not built   : "+strings.Join(s.NotBuilt, ", "))
+	}
+	return lines
+}
+
+func writeRebuildSummary(console *console.Console, summary rebuildSummary) {
+	lines := summary.Lines()
+	if len(lines) == 0 {
+		console.WriteLn("  (no project found)")
+		console.WriteLn("Hint: Create one with 'paul-envs create <path>'")
+		return
+	}
+	console.WriteLn("")
+	console.Info("Summary:")
+	for _, line := range lines {
+		console.WriteLn("%s", line)
+	}
+	if len(summary.Failed) > 0 {
+		console.WriteLn("Hint: Rebuild failed projects with 'paul-envs build <project-name>' to follow their output")
+	}
+	if len(summary.NotBuilt) > 0 {
+		console.WriteLn("Hint: Projects never built are skipped unless named, use 'paul-envs build <project-name>' to build them")
+	}
+}
@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestProjectRebuildStatus(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	store, err := files.NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	if err := store.CreateProjectFiles(
+		"app",
+		testBuildTemplateData(),
+		files.RuntimeTemplateData{Version: "1.0.0", ProjectHostPath: t.TempDir()},
+	); err != nil {
+		t.Fatalf("CreateProjectFiles() error = %v", err)
+	}
+	ctx := context.Background()
+	docker := &engine.FakeEngine{Name: "docker", Version: "27.0.0", BuiltProjects: []string{"app"}}
+
+	status, _, err := projectRebuildStatus(ctx, "app", docker, "docker", store)
+	if err != nil || status != rebuildNotBuilt {
+		t.Fatalf("projectRebuildStatus() before any build = %v, %v, want not built", status, err)
+	}
+
+	if err := store.RefreshBuildInfoFile("app", "docker", "27.0.0"); err != nil {
+		t.Fatalf("RefreshBuildInfoFile() error = %v", err)
+	}
+	status, _, err = projectRebuildStatus(ctx, "app", docker, "docker", store)
+	if err != nil || status != rebuildUpToDate {
+		t.Fatalf("projectRebuildStatus() after a build = %v, %v, want up-to-date", status, err)
+	}
+
+	status, reason, err := projectRebuildStatus(ctx, "app", &engine.FakeEngine{Name: "docker"}, "docker", store)
+	if err != nil || status != rebuildStale || reason != "its image is missing" {
+		t.Fatalf("projectRebuildStatus() without image = %v, %q, %v, want stale", status, reason, err)
+	}
+
+	podman := &engine.FakeEngine{Name: "podman", Version: "5.0.0", BuiltProjects: []string{"app"}}
+	status, reason, err = projectRebuildStatus(ctx, "app", podman, "podman", store)
+	if err != nil || status != rebuildStale || reason != files.RebuildDifferentEngine.String() {
+		t.Fatalf("projectRebuildStatus() on another engine = %v, %q, %v, want stale", status, reason, err)
+	}
+}
+
+func TestRebuildSummaryLines(t *testing.T) {
+	summary := rebuildSummary{
+		Rebuilt:  []string{"api", "web"},
+		Failed:   []string{"legacy"},
+		UpToDate: []string{"docs"},
+	}
+	want := []string{
+		"  Rebuilt     : api, web",
+		"  Failed      : legacy",
+		"  Up-to-date  : docs",
+	}
+	if got := summary.Lines(); !slices.Equal(got, want) {
+		t.Fatalf("Lines() =\n%q\nwant:\n%q", got, want)
+	}
+}
@@ -0,0 +1,249 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Reconcile(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var fix bool
+	var noPrompt bool
+	var engineSelection string
+	flagset := newCommandFlagSet("reconcile", console)
+	flagset.BoolVar(&fix, "fix", false, "Fix the inconsistencies found")
+	flagset.BoolVar(&noPrompt, "no-prompt", false, "Non-interactive mode: fix without asking for confirmation")
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to check: docker, podman, or all. Default: all available engines.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs reconcile [flags]",
+			"Compare the projects known by paul-envs with the resources of the container engines, and report what does not match: containers, images, volumes and networks of projects which do not exist anymore, and projects whose resources were removed or left behind outside of paul-envs (e.g. a missing image, a pinned previous image which was removed, containers left stopped).\n\nWith '--fix', the former are removed and the state of the latter is corrected.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flagset.NArg() > 0 {
+		return utils.WithCategory(errors.New("reconcile does not take arguments"), errUsage)
+	}
+	selectedEngine, err := parseCleanEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+	if selectedEngine == engine.SelectionAuto {
+		selectedEngine = engine.SelectionAll
+	}
+
+	// Projects must not be created or removed while being compared
+	unlock, err := lockRegistry(ctx, filestore, console)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	records, err := readProjectRecords(filestore)
+	if err != nil {
+		return err
+	}
+	containerEngines, err := engine.NewSet(ctx, console, selectedEngine)
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	failed := 0
+	for _, containerEngine := range containerEngines {
+		writeEngineSection(console, containerEngine)
+		engineInfo, err := containerEngine.Info(ctx)
+		if err != nil {
+			return fmt.Errorf("could not obtain container engine information: %w", err)
+		}
+		resources, err := listEngineResources(ctx, containerEngine, engineQueryParallelism(filestore))
+		if err != nil {
+			return err
+		}
+		issues := findInconsistencies(engineInfo.Name, records, resources, filestore)
+		if len(issues) == 0 {
+			console.WriteLn("  Everything matches")
+			continue
+		}
+		for _, issue := range issues {
+			console.WriteLn("  • %s", issue.String())
+		}
+		total += len(issues)
+		if !fix {
+			continue
+		}
+
+		choice, err := yesNoWithOptionalPrompt(console, noPrompt, fmt.Sprintf("Fix those %d inconsistencies?", len(issues)), true)
+		if err != nil {
+			return err
+		} else if !choice {
+			console.WriteLn("Skipping fixes")
+			continue
+		}
+		for _, issue := range issues {
+			if err := issue.apply(ctx, containerEngine); err != nil {
+				failed++
+				console.Warn("    WARNING: failed to fix %s: %v", issue.subject, err)
+			}
+		}
+	}
+
+	switch {
+	case total == 0:
+		console.Success("\nProjects and container engine resources are consistent")
+	case !fix:
+		console.Warn("\n%d inconsistencies found", total)
+		console.WriteLn("Hint: Fix them with 'paul-envs reconcile --fix'")
+	case failed > 0:
+		return fmt.Errorf("failed to fix %d of the %d inconsistencies", failed, total)
+	default:
+		console.Success("\nReconciliation complete!")
+	}
+	return nil
+}
+
+// What paul-envs knows of a project, to be compared with engine resources.
+type projectRecord struct {
+	name string
+	// Name of the engine it was last built with, empty if never built
+	builtWith string
+	// Numbers of the previous images pinned for it
+	pinned map[int]bool
+}
+
+func readProjectRecords(filestore *files.FileStore) ([]projectRecord, error) {
+	entries, err := filestore.GetAllProjects()
+	if err != nil {
+		return nil, fmt.Errorf("could not list all projects: %w", err)
+	}
+	records := make([]projectRecord, 0, len(entries))
+	for _, entry := range entries {
+		record := projectRecord{name: entry.ProjectName}
+		// Unreadable build information is reported by `status`, the
+		// project is then just considered as never built.
+		record.builtWith, _ = filestore.GetBuildEngineSelection(entry.ProjectName)
+		if record.pinned, err = filestore.GetPinnedGenerations(entry.ProjectName); err != nil {
+			return nil, fmt.Errorf("cannot read pinned images of project '%s': %w", entry.ProjectName, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// A mismatch between the projects known by paul-envs and the resources of a
+// container engine.
+type inconsistency struct {
+	// What is inconsistent, e.g. "project 'app'"
+	subject string
+	problem string
+	// What `apply` does
+	fix   string
+	apply func(ctx context.Context, containerEngine engine.ContainerEngine) error
+}
+
+func (i inconsistency) String() string {
+	return fmt.Sprintf("%s: %s (fix: %s)", i.subject, i.problem, i.fix)
+}
+
+// Compare the given projects with the resources of the engine called
+// `engineName`, returning the inconsistencies found: resources of projects
+// which do not exist anymore first, then those of existing projects.
+func findInconsistencies(
+	engineName string,
+	records []projectRecord,
+	resources engineResources,
+	filestore *files.FileStore,
+) []inconsistency {
+	projects := make(map[string]bool, len(records))
+	for _, record := range records {
+		projects[record.name] = true
+	}
+	issues := []inconsistency{}
+	for _, candidate := range findGarbage(projects, nil, nil, resources, 0, time.Now()) {
+		// Other candidates (e.g. beyond a retention policy) are expected
+		if candidate.reason != "project deleted" {
+			continue
+		}
+		issues = append(issues, inconsistency{
+			subject: fmt.Sprintf("%s '%s'", candidate.kind, candidate.name),
+			problem: "its project does not exist anymore",
+			fix:     "remove it",
+			apply:   candidate.remove,
+		})
+	}
+
+	images := map[string]bool{}
+	for _, image := range resources.images {
+		if image.ProjectName != nil {
+			images[*image.ProjectName] = true
+		}
+	}
+	generations := map[string]bool{}
+	for _, generation := range resources.generations {
+		generations[fmt.Sprintf("%s#%d", generation.ProjectName, generation.Number)] = true
+	}
+	for _, record := range records {
+		subject := fmt.Sprintf("project '%s'", record.name)
+		if record.builtWith == engineName {
+			if !images[record.name] {
+				issues = append(issues, inconsistency{
+					subject: subject,
+					problem: "built with " + engineName + " but its image is missing",
+					fix:     "consider it as never built",
+					apply: func(context.Context, engine.ContainerEngine) error {
+						return filestore.ForgetBuild(record.name)
+					},
+				})
+			}
+			numbers := make([]int, 0, len(record.pinned))
+			for number := range record.pinned {
+				if !generations[fmt.Sprintf("%s#%d", record.name, number)] {
+					numbers = append(numbers, number)
+				}
+			}
+			slices.Sort(numbers)
+			for _, number := range numbers {
+				issues = append(issues, inconsistency{
+					subject: subject,
+					problem: fmt.Sprintf("its previous image #%d is pinned but missing", number),
+					fix:     "unpin it",
+					apply: func(context.Context, engine.ContainerEngine) error {
+						return filestore.SetGenerationPinned(record.name, number, false)
+					},
+				})
+			}
+		}
+
+		leftovers := engine.RunLeftoversIn(record.name, resources.containers, resources.sidecars, resources.networks)
+		if !leftovers.IsEmpty() {
+			names := slices.Clone(leftovers.Containers)
+			for _, network := range leftovers.Networks {
+				names = append(names, "network "+network.NetworkName)
+			}
+			issues = append(issues, inconsistency{
+				subject: subject,
+				problem: "left behind by a run which did not end normally: " + strings.Join(names, ", "),
+				fix:     "remove them",
+				apply: func(ctx context.Context, c engine.ContainerEngine) error {
+					return engine.RemoveRunLeftovers(ctx, c, leftovers)
+				},
+			})
+		}
+	}
+	return issues
+}
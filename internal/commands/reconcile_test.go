@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestFindInconsistencies(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	store, err := files.NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	for _, name := range []string{"app", "web", "api"} {
+		if err := store.CreateProjectFiles(
+			name,
+			testBuildTemplateData(),
+			files.RuntimeTemplateData{Version: "1.0.0", ProjectHostPath: t.TempDir()},
+		); err != nil {
+			t.Fatalf("CreateProjectFiles() error = %v", err)
+		}
+	}
+	if err := store.RefreshBuildInfoFile("app", "docker", "27.0.0"); err != nil {
+		t.Fatalf("RefreshBuildInfoFile() error = %v", err)
+	}
+	if err := store.RefreshBuildInfoFile("web", "docker", "27.0.0"); err != nil {
+		t.Fatalf("RefreshBuildInfoFile() error = %v", err)
+	}
+	if err := store.SetGenerationPinned("web", 2, true); err != nil {
+		t.Fatalf("SetGenerationPinned() error = %v", err)
+	}
+	records, err := readProjectRecords(store)
+	if err != nil {
+		t.Fatalf("readProjectRecords() error = %v", err)
+	}
+
+	str := func(s string) *string { return &s }
+	resources := engineResources{
+		containers: []engine.ContainerInfo{
+			{ProjectName: str("gone"), ContainerName: str("renamed"), ContainerId: "1"},
+			{ProjectName: str("api"), ContainerName: str("paulenv-api"), ContainerId: "2"},
+		},
+		images: []engine.ImageInfo{
+			{ImageName: "paulenv-base:latest"},
+			{ProjectName: str("web"), ImageName: "paulenv:web"},
+			{ProjectName: str("gone"), ImageName: "paulenv:gone"},
+		},
+		generations: []engine.GenerationInfo{
+			{ProjectName: "web", Number: 1, ImageName: "paulenv-generation:web.1"},
+		},
+		volumes: []engine.VolumeInfo{
+			{VolumeName: "paulenv-shared-cache"},
+			{VolumeName: "paulenv-app-local"},
+			{VolumeName: "paulenv-gone-local"},
+		},
+	}
+	issues := findInconsistencies("docker", records, resources, store)
+	got := []string{}
+	for _, issue := range issues {
+		got = append(got, issue.subject+": "+issue.problem)
+	}
+	want := []string{
+		"container 'renamed': its project does not exist anymore",
+		"image 'paulenv:gone': its project does not exist anymore",
+		"volume 'paulenv-gone-local': its project does not exist anymore",
+		"project 'api': left behind by a run which did not end normally: paulenv-api",
+		"project 'app': built with docker but its image is missing",
+		"project 'web': its previous image #2 is pinned but missing",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("findInconsistencies() =\n%q\nwant:\n%q", got, want)
+	}
+
+	// Projects built with another engine are checked with it
+	if issues := findInconsistencies("podman", records, resources, store); len(issues) != 4 {
+		t.Fatalf("findInconsistencies() on another engine = %d issues, want 4", len(issues))
+	}
+
+	ctx := context.Background()
+	for _, issue := range issues[4:] {
+		if err := issue.apply(ctx, &engine.FakeEngine{}); err != nil {
+			t.Fatalf("apply() of %q error = %v", issue.subject, err)
+		}
+	}
+	if _, err := store.ReadBuildInfo("app"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("fixing a missing image should forget the build, got %v", err)
+	}
+	if pinned, err := store.GetPinnedGenerations("web"); err != nil || len(pinned) != 0 {
+		t.Fatalf("fixing a missing pinned image should unpin it, got %v, %v", pinned, err)
+	}
+}
@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Push(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var engineSelection string
+	flagset := newCommandFlagSet("push", console)
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to push from: docker or podman.\nDefault: the one used to build the project.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs push <project-name> <repository[:tag]> [flags]",
+			"Push a project's image to a container registry, so others can pull it with 'paul-envs pull' instead of building it. Without a tag, the image is tagged with the project name (e.g. 'paul-envs push myApp ghcr.io/team/envs' pushes 'ghcr.io/team/envs:myApp').\n\nThe container engine's own credentials are used: log in with e.g. 'docker login ghcr.io' first if the registry needs them.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	name, reference, err := parseRegistryArgs(flagset.Args())
+	if err != nil {
+		return err
+	}
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+	requestedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+	containerEngine, _, err := newProjectEngine(ctx, name, requestedEngine, filestore, console)
+	if err != nil {
+		return err
+	}
+	hasImage, err := containerEngine.HasBeenBuilt(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to get the status of the '%s' project: %w", name, err)
+	} else if !hasImage {
+		return fmt.Errorf("project '%s' is not built\nHint: Build it first with 'paul-envs build %s'", name, name)
+	}
+	console.Info("Pushing the image of project '%s' as %s...", name, reference)
+	if err := containerEngine.PushImage(ctx, name, reference); err != nil {
+		return err
+	}
+	console.Success("Pushed project '%s' as %s", name, reference)
+	return nil
+}
+
+func Pull(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var engineSelection string
+	flagset := newCommandFlagSet("pull", console)
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to pull with: docker or podman.\nDefault: the one used to build the project.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs pull <project-name> <repository[:tag]> [flags]",
+			"Pull a project's image pushed with 'paul-envs push' as its current image, instead of building it. The project has to be created first, with the same configuration as the one the image was built from. Without a tag, the image tagged with the project name is pulled.\n\nThe replaced image is kept like on rebuilds, so 'paul-envs rollback' can go back to it. The container engine's own credentials are used: log in with e.g. 'docker login ghcr.io' first if the registry needs them.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	name, reference, err := parseRegistryArgs(flagset.Args())
+	if err != nil {
+		return err
+	}
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+	unlock, err := lockProject(ctx, name, filestore, console)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := ensureProjectCompatible(name, filestore, console); err != nil {
+		return fmt.Errorf("cannot pull project '%s': %w", name, err)
+	}
+	requestedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+	containerEngine, _, err := newProjectEngine(ctx, name, requestedEngine, filestore, console)
+	if err != nil {
+		return err
+	}
+	if err := ensureProjectMachine(ctx, name, containerEngine, filestore, console); err != nil {
+		return err
+	}
+	if err := ensureNoNameCollisions(ctx, name, containerEngine, console); err != nil {
+		return err
+	}
+	project, err := filestore.GetProject(name)
+	if err != nil {
+		return fmt.Errorf("failed to obtain information on project '%s': %w", name, err)
+	}
+
+	previousImage, err := saveImageGeneration(ctx, project, containerEngine)
+	if err != nil {
+		console.Warn("Could not keep the current image of project '%s' for rollbacks: %s", name, err)
+	}
+	console.Info("Pulling the image of project '%s' from %s...", name, reference)
+	start := time.Now()
+	err = containerEngine.PullImage(ctx, name, reference)
+	notifyLongOperation(ctx, filestore, console, start, fmt.Sprintf("Pull of project '%s'", name), err)
+	recordProjectTiming(ctx, name, files.TimingPull, start, err, filestore, console)
+	if err != nil {
+		// The current image was left untouched, no need to keep a copy of it
+		if previousImage != nil {
+			if err := containerEngine.RemoveGeneration(ctx, *previousImage); err != nil {
+				console.Warn("Could not remove the copy of the current image of project '%s': %s", name, err)
+			}
+		}
+		return err
+	}
+	engineInfo, engineInfoErr := containerEngine.Info(ctx)
+	recordProjectImage(ctx, project, containerEngine, engineInfo, engineInfoErr, filestore, console)
+	console.Success("Pulled project '%s' from %s", name, reference)
+	if previousImage != nil {
+		console.WriteLn("Its previous image was kept: use 'paul-envs rollback %s' to go back to it.", name)
+	}
+	return nil
+}
+
+// Parse the `<project-name> <repository[:tag]>` arguments of `push` and
+// `pull`, returning the full reference of the project's image.
+func parseRegistryArgs(args []string) (string, string, error) {
+	if len(args) != 2 {
+		return "", "", utils.WithCategory(errors.New("expected a project name and a repository"), errUsage)
+	}
+	reference, err := engine.RegistryReference(args[1], args[0])
+	if err != nil {
+		return "", "", utils.WithCategory(err, errUsage)
+	}
+	return args[0], reference, nil
+}
@@ -8,22 +8,27 @@ import (
 
 	"github.com/peaberberian/paul-envs/internal/console"
 	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/events"
 	"github.com/peaberberian/paul-envs/internal/files"
-	"github.com/peaberberian/paul-envs/internal/utils"
 )
 
 func Remove(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
 	var noPrompt bool
 	var engineSelection string
+	var all, containers, images, volumes bool
 	flagset := newCommandFlagSet("remove", console)
 	flagset.BoolVar(&noPrompt, "no-prompt", false, "Non-interactive mode: require a project name and skip the confirmation prompt")
 	flagset.StringVar(&engineSelection, "engine", "", "Container engine to use for asset removal: docker or podman. Default: the last build engine for the project, otherwise auto-select.")
+	flagset.BoolVar(&all, "all", false, "Remove the project's configuration along with all of its container assets. This is the default without any of the flags below.")
+	flagset.BoolVar(&containers, "containers", false, "Remove the project's containers, those of its services and its network, keeping its configuration.")
+	flagset.BoolVar(&images, "image", false, "Remove the project's image, with its snapshots, previous and per-architecture images, keeping its configuration.")
+	flagset.BoolVar(&volumes, "volumes", false, "Remove the project's volumes and those of its services, keeping its configuration.")
 	flagset.Usage = func() {
 		writeCommandUsage(
 			console,
 			flagset,
 			"paul-envs remove [flags] [project-name]",
-			"Remove a single project configuration and its managed container assets. If no project name is provided, paul-envs asks you to choose one.",
+			"Remove a single project configuration and its managed container assets, or only some of those assets with --containers, --image and --volumes. The exact resources that will be destroyed are listed before asking for confirmation. If no project name is provided, paul-envs asks you to choose one.",
 		)
 	}
 	if err := parseCommandFlags(flagset, args); err != nil {
@@ -33,6 +38,7 @@ func Remove(ctx context.Context, args []string, filestore *files.FileStore, cons
 		return err
 	}
 	args = flagset.Args()
+	scope := parseRemovalScope(all, containers, images, volumes)
 
 	var name string
 	if len(args) == 0 {
@@ -66,7 +72,7 @@ func Remove(ctx context.Context, args []string, filestore *files.FileStore, cons
 		name = args[0]
 	}
 
-	if err := utils.ValidateProjectName(name); err != nil {
+	if err := validateProjectName(name); err != nil {
 		return err
 	}
 
@@ -74,88 +80,289 @@ func Remove(ctx context.Context, args []string, filestore *files.FileStore, cons
 	// 	return fmt.Errorf("Project '%s' not found\nHint: Use 'paul-envs list' to see available projects", name)
 	// }
 
-	if noPrompt {
-		console.Info("Skipping confirmation prompt because --no-prompt was provided.")
-	} else {
-		choice, err := console.AskYesNo(fmt.Sprintf("Remove project '%s'?", name), false)
+	requestedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+	if scope.configuration {
+		unlockRegistry, err := lockRegistry(ctx, filestore, console)
 		if err != nil {
 			return err
 		}
-		if !choice {
-			return nil
-		}
+		defer unlockRegistry()
 	}
-
-	requestedEngine, err := parseCommandEngineSelection(engineSelection)
+	unlockProject, err := lockProject(ctx, name, filestore, console)
 	if err != nil {
 		return err
 	}
+	defer unlockProject()
 	containerEngine, _, err := newProjectEngine(ctx, name, requestedEngine, filestore, console)
 	if err != nil {
 		return err
 	}
-	err = removeContainer(ctx, name, containerEngine, console)
+	plan, err := planProjectRemoval(ctx, name, scope, containerEngine)
 	if err != nil {
 		return err
 	}
-	err = removeImage(ctx, name, containerEngine, console)
-	if err != nil {
-		return err
+	if plan.isEmpty() {
+		console.Info("Nothing to remove for project '%s'.", name)
+		return nil
 	}
-	err = removeVolume(ctx, name, containerEngine, console)
-	if err != nil {
-		return err
+	console.WriteLn("The following will be removed:")
+	for _, line := range plan.describe() {
+		console.WriteLn("  %s", line)
 	}
-	err = removeNetwork(ctx, name, containerEngine, console)
-	if err != nil {
+	if noPrompt {
+		console.Info("Skipping confirmation prompt because --no-prompt was provided.")
+	} else {
+		choice, err := console.AskYesNo(fmt.Sprintf("Remove those resources of project '%s'?", name), false)
+		if err != nil {
+			return err
+		}
+		if !choice {
+			return nil
+		}
+	}
+
+	if err := plan.execute(ctx, containerEngine, console); err != nil {
 		return err
 	}
+	// A frozen container is gone with the others
+	if scope.containers {
+		if err := filestore.RemoveProjectCheckpoint(name); err != nil {
+			return err
+		}
+	}
+	if !scope.configuration {
+		console.Success("The selected resources of project '%s' have been succesfully removed!", name)
+		return nil
+	}
 	console.WriteLn("Removing '%s' project directory...", name)
 	if err := filestore.DeleteProjectDirectory(name); err != nil {
 		return fmt.Errorf("Failed to remove project directory: %w", err)
 	}
 	console.Success("Removed project directory with success!")
+	if count, err := filestore.RemoveProjectShortcuts(name); err != nil {
+		console.Warn("Could not remove the application menu shortcuts of project '%s': %s", name, err)
+	} else if count > 0 {
+		console.WriteLn("Removed its %d application menu shortcut(s).", count)
+	}
+	events.Emit(events.ProjectRemoved, name, nil)
 	console.Success("The project '%s' has been succesfully removed from your system!", name)
 	return nil
 }
 
-func removeContainer(ctx context.Context, projectName string, containerEngine engine.ContainerEngine, console *console.Console) error {
-	console.WriteLn("Stopping and removing '%s' container...", projectName)
+// What `remove` deletes of a project.
+type removalScope struct {
+	// Its containers, those of its services and its network
+	containers bool
+	// Its image, along with its snapshots, previous and per-architecture ones
+	images bool
+	// Its volumes and those of its services
+	volumes bool
+	// Its configuration directory, only removed along with everything else
+	configuration bool
+}
 
-	containers, err := containerEngine.ListContainers(ctx)
-	if err != nil {
-		return fmt.Errorf("cannot list current containers: %w", err)
+// Parse the scope flags of `remove`. Without any, everything is removed.
+func parseRemovalScope(all, containers, images, volumes bool) removalScope {
+	if all || (!containers && !images && !volumes) {
+		return removalScope{containers: true, images: true, volumes: true, configuration: true}
 	}
-	for _, container := range containers {
-		if container.ProjectName != nil && *container.ProjectName == projectName {
-			if err := containerEngine.RemoveContainer(ctx, container); err != nil {
-				return err
+	return removalScope{containers: containers, images: images, volumes: volumes}
+}
+
+// The engine resources of a project `remove` is about to delete, listed
+// beforehand so they can be confirmed.
+type removalPlan struct {
+	projectName string
+	scope       removalScope
+	sidecars    []engine.SidecarInfo
+	containers  []engine.ContainerInfo
+	networks    []engine.NetworkInfo
+	images      []engine.ImageInfo
+	snapshots   []engine.SnapshotInfo
+	generations []engine.GenerationInfo
+	archImages  []engine.ArchImageInfo
+	volumes     []engine.VolumeInfo
+}
+
+func planProjectRemoval(
+	ctx context.Context,
+	projectName string,
+	scope removalScope,
+	containerEngine engine.ContainerEngine,
+) (removalPlan, error) {
+	plan := removalPlan{projectName: projectName, scope: scope}
+	var err error
+	if scope.containers {
+		if plan.sidecars, err = listProjectSidecars(ctx, containerEngine, projectName); err != nil {
+			return plan, err
+		}
+		containers, err := containerEngine.ListContainers(ctx)
+		if err != nil {
+			return plan, fmt.Errorf("cannot list current containers: %w", err)
+		}
+		// It may have several: one per instance (see `run --instance`), those
+		// of `try`...
+		for _, container := range containers {
+			if container.ProjectName != nil && *container.ProjectName == projectName {
+				plan.containers = append(plan.containers, container)
+			}
+		}
+		networks, err := containerEngine.ListNetworks(ctx)
+		if err != nil {
+			return plan, fmt.Errorf("cannot list current networks: %w", err)
+		}
+		for _, network := range networks {
+			if network.ProjectName != nil && *network.ProjectName == projectName {
+				plan.networks = append(plan.networks, network)
 			}
-			console.Success("Removed container with success!")
-			return nil
 		}
 	}
-	console.Info("no current running '%s' container found", projectName)
-	return nil
+	if scope.images {
+		images, err := containerEngine.ListImages(ctx)
+		if err != nil {
+			return plan, fmt.Errorf("cannot list current images: %w", err)
+		}
+		for _, image := range images {
+			if image.ProjectName != nil && *image.ProjectName == projectName {
+				plan.images = append(plan.images, image)
+			}
+		}
+		if plan.snapshots, err = listProjectSnapshots(ctx, containerEngine, projectName); err != nil {
+			return plan, err
+		}
+		if plan.generations, err = listProjectGenerations(ctx, containerEngine, projectName); err != nil {
+			return plan, err
+		}
+		if plan.archImages, err = listProjectArchImages(ctx, containerEngine, projectName); err != nil {
+			return plan, err
+		}
+	}
+	if scope.volumes {
+		volumes, err := containerEngine.ListVolumes(ctx)
+		if err != nil {
+			return plan, fmt.Errorf("cannot list current volumes: %w", err)
+		}
+		for _, volume := range volumes {
+			// Its sidecars' data volumes are attributed to it the same way
+			if owner, ok := projectNameFromLocalVolume(volume.VolumeName); ok && owner == projectName {
+				plan.volumes = append(plan.volumes, volume)
+			}
+		}
+	}
+	return plan, nil
 }
 
-func removeImage(ctx context.Context, projectName string, containerEngine engine.ContainerEngine, console *console.Console) error {
-	console.WriteLn("Stopping and removing '%s' image...", projectName)
+// Returns `true` if that plan would not remove anything.
+func (p removalPlan) isEmpty() bool {
+	return !p.scope.configuration && len(p.describe()) == 0
+}
 
-	images, err := containerEngine.ListImages(ctx)
-	if err != nil {
-		return fmt.Errorf("cannot list current images: %w", err)
+// One line per resource the plan removes, e.g. "volume   paulenv-app-local".
+func (p removalPlan) describe() []string {
+	var lines []string
+	add := func(kind string, name string, detail string) {
+		line := fmt.Sprintf("%-10s %s", kind, name)
+		if detail != "" {
+			line += " (" + detail + ")"
+		}
+		lines = append(lines, line)
 	}
-	for _, image := range images {
-		if image.ProjectName != nil && *image.ProjectName == projectName {
-			if err := containerEngine.RemoveImage(ctx, image); err != nil {
-				return err
-			}
-			console.Success("Removed '%s' image with success!", image.ImageName)
-			return nil
+	for _, container := range p.containers {
+		name := container.ContainerId
+		if container.ContainerName != nil {
+			name = *container.ContainerName
 		}
+		detail := "stopped"
+		if container.Running {
+			detail = "running"
+		}
+		add("container", name, detail)
+	}
+	for _, sidecar := range p.sidecars {
+		add("container", sidecar.ContainerName, "service "+sidecar.ServiceName)
+	}
+	for _, network := range p.networks {
+		add("network", network.NetworkName, "")
+	}
+	for _, image := range p.images {
+		add("image", image.ImageName, "")
+	}
+	for _, generation := range p.generations {
+		add("image", generation.ImageName, fmt.Sprintf("generation %d", generation.Number))
+	}
+	for _, image := range p.archImages {
+		add("image", image.ImageName, image.Architecture)
+	}
+	for _, snapshot := range p.snapshots {
+		add("snapshot", snapshot.ImageName, "")
+	}
+	for _, volume := range p.volumes {
+		add("volume", volume.VolumeName, "")
+	}
+	if p.scope.configuration {
+		add("config", p.projectName, "project configuration and files")
+	}
+	return lines
+}
+
+// Remove the engine resources of that plan. The project's configuration, if
+// part of it, is left to the caller.
+func (p removalPlan) execute(ctx context.Context, containerEngine engine.ContainerEngine, console *console.Console) error {
+	for _, sidecar := range p.sidecars {
+		console.WriteLn("Stopping service '%s'...", sidecar.ServiceName)
+		if err := containerEngine.RemoveSidecar(ctx, sidecar); err != nil {
+			return err
+		}
+	}
+	for _, container := range p.containers {
+		if err := containerEngine.RemoveContainer(ctx, container); err != nil {
+			return err
+		}
+	}
+	if len(p.containers) == 1 {
+		console.Success("Removed container with success!")
+	} else if len(p.containers) > 1 {
+		console.Success("Removed %d containers with success!", len(p.containers))
+	}
+	for _, network := range p.networks {
+		if err := containerEngine.RemoveNetwork(ctx, network); err != nil {
+			return err
+		}
+		console.Success("Removed '%s' network with success!", network.NetworkName)
+	}
+	for _, image := range p.images {
+		if err := containerEngine.RemoveImage(ctx, image); err != nil {
+			return err
+		}
+		console.Success("Removed '%s' image with success!", image.ImageName)
+	}
+	for _, snapshot := range p.snapshots {
+		if err := containerEngine.RemoveSnapshot(ctx, snapshot); err != nil {
+			return err
+		}
+		console.Success("Removed '%s' snapshot with success!", snapshot.ImageName)
+	}
+	for _, generation := range p.generations {
+		if err := containerEngine.RemoveGeneration(ctx, generation); err != nil {
+			return err
+		}
+		console.Success("Removed '%s' image with success!", generation.ImageName)
+	}
+	for _, image := range p.archImages {
+		if err := containerEngine.RemoveArchImage(ctx, image); err != nil {
+			return err
+		}
+		console.Success("Removed '%s' image with success!", image.ImageName)
+	}
+	for _, volume := range p.volumes {
+		if err := containerEngine.RemoveVolume(ctx, volume); err != nil {
+			return err
+		}
+		console.Success("Removed '%s' volume with success!", volume.VolumeName)
 	}
-	console.Info("no '%s' image found", projectName)
 	return nil
 }
 
@@ -166,35 +373,19 @@ func removeVolume(ctx context.Context, projectName string, containerEngine engin
 	if err != nil {
 		return fmt.Errorf("cannot list current volumes: %w", err)
 	}
+	found := false
 	for _, volume := range volumes {
-		if volume.VolumeName == fmt.Sprintf("paulenv-%s-local", projectName) {
+		// Its sidecars' data volumes are attributed to it the same way
+		if owner, ok := projectNameFromLocalVolume(volume.VolumeName); ok && owner == projectName {
 			if err := containerEngine.RemoveVolume(ctx, volume); err != nil {
 				return err
 			}
 			console.Success("Removed '%s' volume with success!", volume.VolumeName)
-			return nil
+			found = true
 		}
 	}
-	console.Info("no 'paulenv-%s-local' volume found", projectName)
-	return nil
-}
-
-func removeNetwork(ctx context.Context, projectName string, containerEngine engine.ContainerEngine, console *console.Console) error {
-	console.WriteLn("Stopping and removing '%s' network interfaces...", projectName)
-
-	networks, err := containerEngine.ListNetworks(ctx)
-	if err != nil {
-		return fmt.Errorf("cannot list current networks: %w", err)
-	}
-	for _, network := range networks {
-		if network.ProjectName != nil && *network.ProjectName == projectName {
-			if err := containerEngine.RemoveNetwork(ctx, network); err != nil {
-				return err
-			}
-			console.Success("Removed '%s' network with success!", network.NetworkName)
-			return nil
-		}
+	if !found {
+		console.Info("no 'paulenv-%s-local' volume found", projectName)
 	}
-	console.Info("no '%s' network found", projectName)
 	return nil
 }
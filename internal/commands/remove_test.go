@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+)
+
+func TestParseRemovalScope(t *testing.T) {
+	full := removalScope{containers: true, images: true, volumes: true, configuration: true}
+	if got := parseRemovalScope(false, false, false, false); got != full {
+		t.Errorf("parseRemovalScope() without flags = %+v, want everything", got)
+	}
+	if got := parseRemovalScope(true, false, true, false); got != full {
+		t.Errorf("parseRemovalScope() with --all = %+v, want everything", got)
+	}
+	if got := parseRemovalScope(false, false, true, true); got != (removalScope{images: true, volumes: true}) {
+		t.Errorf("parseRemovalScope(--image --volumes) = %+v, want only images and volumes", got)
+	}
+}
+
+func TestRemovalPlan_Scoped(t *testing.T) {
+	app, other := "app", "other"
+	containerName := "paulenv-app"
+	fake := &engine.FakeEngine{
+		Containers: []engine.ContainerInfo{{ProjectName: &app, ContainerName: &containerName, ContainerId: "1", Running: true}},
+		Images:     []engine.ImageInfo{{ProjectName: &app, ImageName: "localhost/paulenv:app"}, {ProjectName: &other, ImageName: "localhost/paulenv:other"}},
+		Volumes:    []engine.VolumeInfo{{VolumeName: "paulenv-app-local"}, {VolumeName: "paulenv-app.db-local"}, {VolumeName: "paulenv-other-local"}},
+	}
+
+	plan, err := planProjectRemoval(context.Background(), "app", parseRemovalScope(false, true, false, false), fake)
+	if err != nil {
+		t.Fatalf("planProjectRemoval() error = %v", err)
+	}
+	if got := plan.describe(); len(got) != 1 || got[0] != "container  paulenv-app (running)" {
+		t.Fatalf("describe() = %q, want the project's container", got)
+	}
+
+	plan, err = planProjectRemoval(context.Background(), "app", parseRemovalScope(false, false, true, true), fake)
+	if err != nil {
+		t.Fatalf("planProjectRemoval() error = %v", err)
+	}
+	want := []string{
+		"image      localhost/paulenv:app",
+		"volume     paulenv-app-local",
+		"volume     paulenv-app.db-local",
+	}
+	if got := plan.describe(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("describe() = %q, want %q", got, want)
+	}
+
+	var out bytes.Buffer
+	cons := console.New(context.Background(), strings.NewReader(""), &out, &out)
+	if err := plan.execute(context.Background(), fake, cons); err != nil {
+		t.Fatalf("execute() error = %v", err)
+	}
+	if calls := fake.CallsTo("RemoveContainer"); len(calls) != 0 {
+		t.Errorf("execute() removed containers out of its scope: %+v", calls)
+	}
+	if calls := fake.CallsTo("RemoveImage"); len(calls) != 1 {
+		t.Errorf("execute() image removals = %+v, want only the project's", calls)
+	}
+	if calls := fake.CallsTo("RemoveVolume"); len(calls) != 2 {
+		t.Errorf("execute() volume removals = %+v, want the project's two", calls)
+	}
+}
@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/events"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Rename(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var engineSelection string
+	flagset := newCommandFlagSet("rename", console)
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine whose resources are renamed: docker or podman. Default: the last build engine for the project, otherwise auto-select.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs rename [flags] <project-name> <new-name>",
+			"Rename a project along with its container resources: its images (including previous ones and snapshots) are tagged under the new name and its volumes, and those of its services, are copied to it, before those under the previous name are removed. Its network and stopped containers are removed, they are created again on the next run. Its application menu shortcuts (see 'paul-envs shortcut') then run it under its new name.\n\nThe project must not be running. Inside its container, it is then mounted under its new name.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) != 2 {
+		return utils.WithCategory(errors.New("expected the current and new names of the project"), errUsage)
+	}
+	from, to := args[0], args[1]
+	if err := validateProjectName(from); err != nil {
+		return err
+	}
+	if err := validateProjectName(to); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(from) {
+		return projectNotFoundError(from)
+	}
+	if from == to {
+		return utils.WithCategory(errors.New("the new name is the current one"), errUsage)
+	}
+	requestedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+
+	unlockRegistry, err := lockRegistry(ctx, filestore, console)
+	if err != nil {
+		return err
+	}
+	defer unlockRegistry()
+	unlockProject, err := lockProject(ctx, from, filestore, console)
+	if err != nil {
+		return err
+	}
+	defer unlockProject()
+	if filestore.DoesProjectExist(to) {
+		return fmt.Errorf("project '%s' already exists", to)
+	}
+	if err := ensureProjectCompatible(from, filestore, console); err != nil {
+		return err
+	}
+	// Its checkpoint would recreate its container under its former name
+	if filestore.HasProjectCheckpoint(from) {
+		return fmt.Errorf("project '%s' is frozen\nHint: Resume it first with 'paul-envs thaw %s'", from, from)
+	}
+	containerEngine, _, err := newProjectEngine(ctx, from, requestedEngine, filestore, console)
+	if err != nil {
+		return err
+	}
+	if err := ensureNoNameCollisions(ctx, to, containerEngine, console); err != nil {
+		return err
+	}
+	rename, err := engine.PlanProjectRename(ctx, containerEngine, from, to)
+	if err != nil {
+		return fmt.Errorf("cannot rename project '%s': %w", from, err)
+	}
+
+	// The project is only renamed on the engine once its files are, so a
+	// failure leaves it under a single name
+	if err := filestore.RenameProject(from, to); err != nil {
+		return err
+	}
+	console.Info("Renaming %d image(s) and copying %d volume(s)...", len(rename.Images), len(rename.Volumes))
+	if err := rename.Copy(ctx, containerEngine); err != nil {
+		if revertErr := filestore.RenameProject(to, from); revertErr != nil {
+			console.Warn("Could not give the project files back their previous name: %s", revertErr)
+		}
+		return fmt.Errorf("cannot rename the resources of project '%s': %w", from, err)
+	}
+	if err := rename.RemovePrevious(ctx, containerEngine); err != nil {
+		console.Warn("Could not remove all resources under the previous name: %s", err)
+		console.WriteLn("Hint: Remove them with 'paul-envs reconcile --fix'")
+	}
+	// Shortcuts live outside of the project's directory
+	renameShortcut := func(entry []byte) []byte { return renameDesktopEntry(entry, from, to) }
+	if count, err := filestore.RenameProjectShortcuts(from, to, renameShortcut); err != nil {
+		console.Warn("Could not move the application menu shortcuts of project '%s': %s", from, err)
+		console.WriteLn("Hint: Add them again with 'paul-envs shortcut %s <command...>'", to)
+	} else if count > 0 {
+		console.WriteLn("Moved its %d application menu shortcut(s).", count)
+	}
+	// Subscribers know projects by name
+	events.Emit(events.ProjectRemoved, from, nil)
+	events.Emit(events.ProjectCreated, to, nil)
+	console.Success("Renamed project '%s' to '%s'", from, to)
+	return nil
+}
@@ -0,0 +1,364 @@
+package commands
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/table"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Categories of resources `resources` can be restricted to, in display order.
+var resourceCategories = []string{"images", "containers", "volumes", "networks"}
+
+// Keys `resources --sort` accepts.
+var resourceSortKeys = []string{"kind", "name", "project", "age", "size"}
+
+func Resources(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var engineSelection string
+	var sortKey string
+	var filters stringListFlag
+	wide := false
+	flagset := newCommandFlagSet("resources", console)
+	flagset.Var(&filters, "filter", "Only list resources matching that `condition`: project=NAME (or @group), status=running\nor stopped (containers), age>DURATION or age<DURATION (e.g. 30d, 12h), size>SIZE\nor size<SIZE (e.g. 1GB). This option can be repeated: all conditions must match,\nexcept project ones of which one has to.")
+	flagset.StringVar(&sortKey, "sort", "kind", "Order of the listed resources: kind, name, project, age (oldest first) or size\n(biggest first). Resources whose age or size is unknown come last.")
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to query: docker, podman, or all. Default: all available engines.")
+	flagset.BoolVar(&wide, "wide", false, "Display full values even if they do not fit in the terminal width")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs resources [images|containers|volumes|networks...] [flags]",
+			"List the images, containers, volumes and networks paul-envs created, or only those of the given categories, with the project they belong to, their status, age and size. Filtering and sorting are done by paul-envs, the same way whatever the container engine.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	categories := flagset.Args()
+	for _, category := range categories {
+		if !slices.Contains(resourceCategories, category) {
+			return utils.WithCategory(fmt.Errorf("invalid resource category %q: expected %s", category, strings.Join(resourceCategories, ", ")), errUsage)
+		}
+	}
+	if len(categories) == 0 {
+		categories = resourceCategories
+	}
+	if !slices.Contains(resourceSortKeys, sortKey) {
+		return utils.WithCategory(fmt.Errorf("invalid --sort value %q: expected %s", sortKey, strings.Join(resourceSortKeys, ", ")), errUsage)
+	}
+	filter, err := parseResourceFilters(filters, filestore)
+	if err != nil {
+		return err
+	}
+	selection := engine.SelectionAll
+	if engineSelection != "" {
+		if selection, err = parseCleanEngineSelection(engineSelection); err != nil {
+			return utils.WithCategory(err, errUsage)
+		}
+	}
+
+	entries, err := filestore.GetAllProjects()
+	if err != nil {
+		return fmt.Errorf("could not list all projects: %w", err)
+	}
+	projects := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		projects[entry.ProjectName] = true
+	}
+	engines, err := engine.NewSet(ctx, console, selection)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, containerEngine := range engines {
+		writeEngineSection(console, containerEngine)
+		resources, err := listEngineResources(ctx, containerEngine, engineQueryParallelism(filestore))
+		if err != nil {
+			return err
+		}
+		// Only known from the engine's disk usage, which is slower to obtain
+		var volumeSizes map[string]int64
+		if slices.Contains(categories, "volumes") {
+			if usage, err := containerEngine.GetDiskUsage(ctx); err != nil {
+				console.Warn("Could not obtain the size of volumes: %s", err)
+			} else {
+				volumeSizes = usage.Volumes
+			}
+		}
+		list := slices.DeleteFunc(collectResourceEntries(resources, volumeSizes, categories), func(entry resourceEntry) bool {
+			return !filter.matches(entry, now)
+		})
+		sortResourceEntries(list, sortKey)
+		if len(list) == 0 {
+			console.WriteLn("  Nothing found")
+			continue
+		}
+		if err := table.Render(console.Writer(), []string{
+			"KIND", "NAME", "PROJECT", "STATUS", "AGE", "SIZE",
+		}, resourceRows(list, projects, now), table.Options{Width: table.TerminalWidth(console.Writer()), Wide: wide}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A resource of a container engine, as listed by `resources`.
+type resourceEntry struct {
+	// One of `resourceCategories`
+	category string
+	// Finer kind of resource, e.g. "previous image"
+	kind string
+	name string
+	// Project it belongs to, empty if it is shared between projects
+	project string
+	// "running" or "stopped" for containers, empty otherwise
+	status string
+	// When it was built or created, `nil` if unknown
+	createdAt *time.Time
+	// In bytes, `-1` if unknown
+	size int64
+}
+
+// List the resources of the given categories.
+func collectResourceEntries(resources engineResources, volumeSizes map[string]int64, categories []string) []resourceEntry {
+	var list []resourceEntry
+	imageSize := func(size string) int64 {
+		if parsed, err := utils.ParseSize(size); err == nil {
+			return parsed
+		}
+		return -1
+	}
+	if slices.Contains(categories, "images") {
+		for _, image := range resources.images {
+			project := ""
+			if image.ProjectName != nil {
+				project = *image.ProjectName
+			}
+			list = append(list, resourceEntry{category: "images", kind: "image", name: image.ImageName, project: project, createdAt: image.BuiltAt, size: imageSize(image.Size)})
+		}
+		for _, generation := range resources.generations {
+			list = append(list, resourceEntry{category: "images", kind: "previous image", name: generation.ImageName, project: generation.ProjectName, createdAt: generation.BuiltAt, size: imageSize(generation.Size)})
+		}
+		for _, image := range resources.archImages {
+			list = append(list, resourceEntry{category: "images", kind: "architecture image", name: image.ImageName, project: image.ProjectName, createdAt: image.BuiltAt, size: imageSize(image.Size)})
+		}
+		for _, snapshot := range resources.snapshots {
+			list = append(list, resourceEntry{category: "images", kind: "snapshot", name: snapshot.ImageName, project: snapshot.ProjectName, createdAt: snapshot.CreatedAt, size: imageSize(snapshot.Size)})
+		}
+	}
+	containerStatus := func(running bool) string {
+		if running {
+			return "running"
+		}
+		return "stopped"
+	}
+	if slices.Contains(categories, "containers") {
+		for _, container := range resources.containers {
+			entry := resourceEntry{category: "containers", kind: "container", name: container.ContainerId, status: containerStatus(container.Running), size: -1}
+			if container.ContainerName != nil {
+				entry.name = *container.ContainerName
+			}
+			if container.ProjectName != nil {
+				entry.project = *container.ProjectName
+			}
+			list = append(list, entry)
+		}
+		for _, sidecar := range resources.sidecars {
+			list = append(list, resourceEntry{category: "containers", kind: "service container", name: sidecar.ContainerName, project: sidecar.ProjectName, status: containerStatus(sidecar.Running), size: -1})
+		}
+	}
+	if slices.Contains(categories, "volumes") {
+		for _, volume := range resources.volumes {
+			entry := resourceEntry{category: "volumes", kind: "volume", name: volume.VolumeName, createdAt: volume.CreatedAt, size: -1}
+			if project, ok := projectNameFromLocalVolume(volume.VolumeName); ok {
+				entry.project = project
+			}
+			if size, ok := volumeSizes[volume.VolumeName]; ok {
+				entry.size = size
+			}
+			list = append(list, entry)
+		}
+	}
+	if slices.Contains(categories, "networks") {
+		for _, network := range resources.networks {
+			entry := resourceEntry{category: "networks", kind: "network", name: network.NetworkName, size: -1}
+			if network.ProjectName != nil {
+				entry.project = *network.ProjectName
+			}
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// Conditions given to `resources --filter`, all of which have to match.
+type resourceFilter struct {
+	// Any of them has to match, all projects if nil
+	projects map[string]bool
+	status   string
+	// Bounds of the age and size, `0` if unbounded
+	minAge, maxAge   time.Duration
+	minSize, maxSize int64
+}
+
+var resourceFilterRe = regexp.MustCompile(`^([a-z]+)\s*([=<>])\s*(.+)$`)
+
+func parseResourceFilters(conditions []string, filestore *files.FileStore) (resourceFilter, error) {
+	var filter resourceFilter
+	for _, condition := range conditions {
+		match := resourceFilterRe.FindStringSubmatch(strings.TrimSpace(condition))
+		if match == nil {
+			return resourceFilter{}, utils.WithCategory(fmt.Errorf("invalid --filter %q: expected e.g. project=myapp, status=running, age>30d or size>1GB", condition), errUsage)
+		}
+		key, op, value := match[1], match[2], strings.TrimSpace(match[3])
+		switch {
+		case key == "project" && op == "=":
+			names, err := resolveProjectSelectors([]string{value}, filestore)
+			if err != nil {
+				return resourceFilter{}, err
+			}
+			if filter.projects == nil {
+				filter.projects = map[string]bool{}
+			}
+			for _, name := range names {
+				filter.projects[name] = true
+			}
+		case key == "status" && op == "=":
+			if value != "running" && value != "stopped" {
+				return resourceFilter{}, utils.WithCategory(fmt.Errorf("invalid --filter %q: status is either running or stopped", condition), errUsage)
+			}
+			filter.status = value
+		case key == "age" && op != "=":
+			age, err := parseAgeThreshold(value)
+			if err != nil {
+				return resourceFilter{}, utils.WithCategory(fmt.Errorf("invalid --filter %q: expected an age such as 30d, 2w or 12h", condition), errUsage)
+			}
+			if op == ">" {
+				filter.minAge = age
+			} else {
+				filter.maxAge = age
+			}
+		case key == "size" && op != "=":
+			size, err := utils.ParseSize(value)
+			if err != nil || size <= 0 {
+				return resourceFilter{}, utils.WithCategory(fmt.Errorf("invalid --filter %q: expected a size such as 500MB or 1GB", condition), errUsage)
+			}
+			if op == ">" {
+				filter.minSize = size
+			} else {
+				filter.maxSize = size
+			}
+		default:
+			return resourceFilter{}, utils.WithCategory(fmt.Errorf("invalid --filter %q: expected project=, status=, age> or age<, size> or size<", condition), errUsage)
+		}
+	}
+	return filter, nil
+}
+
+// Tell if that resource matches all conditions. Those whose age or size is
+// unknown never match conditions on them.
+func (f resourceFilter) matches(entry resourceEntry, now time.Time) bool {
+	if f.projects != nil && !f.projects[entry.project] {
+		return false
+	}
+	if f.status != "" && entry.status != f.status {
+		return false
+	}
+	if f.minAge > 0 || f.maxAge > 0 {
+		if entry.createdAt == nil {
+			return false
+		}
+		age := now.Sub(*entry.createdAt)
+		if (f.minAge > 0 && age <= f.minAge) || (f.maxAge > 0 && age >= f.maxAge) {
+			return false
+		}
+	}
+	if f.minSize > 0 || f.maxSize > 0 {
+		if entry.size < 0 {
+			return false
+		}
+		if (f.minSize > 0 && entry.size <= f.minSize) || (f.maxSize > 0 && entry.size >= f.maxSize) {
+			return false
+		}
+	}
+	return true
+}
+
+// Sort resources by the given key of `resourceSortKeys`, then by category
+// and name.
+func sortResourceEntries(list []resourceEntry, key string) {
+	slices.SortStableFunc(list, func(a, b resourceEntry) int {
+		switch key {
+		case "name":
+			if c := strings.Compare(a.name, b.name); c != 0 {
+				return c
+			}
+		case "project":
+			if c := strings.Compare(a.project, b.project); c != 0 {
+				return c
+			}
+		case "age":
+			switch {
+			case a.createdAt == nil && b.createdAt != nil:
+				return 1
+			case a.createdAt != nil && b.createdAt == nil:
+				return -1
+			case a.createdAt != nil && !a.createdAt.Equal(*b.createdAt):
+				return a.createdAt.Compare(*b.createdAt)
+			}
+		case "size":
+			// Unknown sizes (-1) end up last
+			if c := cmp.Compare(b.size, a.size); c != 0 {
+				return c
+			}
+		}
+		if c := slices.Index(resourceCategories, a.category) - slices.Index(resourceCategories, b.category); c != 0 {
+			return c
+		}
+		return strings.Compare(a.name, b.name)
+	})
+}
+
+func resourceRows(list []resourceEntry, projects map[string]bool, now time.Time) []table.Row {
+	rows := make([]table.Row, 0, len(list))
+	for _, entry := range list {
+		project := entry.project
+		switch {
+		case project == "":
+			project = "(shared)"
+		case !projects[project]:
+			// Left over by a removed project, see `paul-envs gc`
+			project += " (deleted)"
+		}
+		status, size := entry.status, "-"
+		if status == "" {
+			status = "-"
+		}
+		if entry.size >= 0 {
+			size = utils.FormatSize(entry.size)
+		}
+		rows = append(rows, table.Row{
+			{entry.kind},
+			{entry.name},
+			{project},
+			{status},
+			{formatAge(entry.createdAt, now)},
+			{size},
+		})
+	}
+	return rows
+}
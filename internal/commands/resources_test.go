@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/engine"
+)
+
+func TestResourceEntries(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-40 * 24 * time.Hour)
+	recent := now.Add(-2 * time.Hour)
+	str := func(s string) *string { return &s }
+	resources := engineResources{
+		containers: []engine.ContainerInfo{
+			{ProjectName: str("api"), ContainerName: str("paulenv-api"), ContainerId: "1", Running: true},
+			{ProjectName: str("web"), ContainerName: str("paulenv-web"), ContainerId: "2"},
+		},
+		images: []engine.ImageInfo{
+			{ImageName: "paulenv-base:latest", BuiltAt: &old, Size: "900MB"},
+			{ProjectName: str("api"), ImageName: "paulenv:api", BuiltAt: &recent, Size: "2.5GB"},
+			{ProjectName: str("web"), ImageName: "paulenv:web", BuiltAt: &old, Size: "1.2GB"},
+		},
+		generations: []engine.GenerationInfo{
+			{ProjectName: "web", Number: 1, ImageName: "paulenv-generation:web.1"},
+		},
+		volumes: []engine.VolumeInfo{
+			{VolumeName: "paulenv-api-local"},
+			{VolumeName: "paulenv-shared-cache"},
+		},
+		networks: []engine.NetworkInfo{
+			{ProjectName: str("api"), NetworkName: "paulenv-api"},
+		},
+	}
+	volumeSizes := map[string]int64{"paulenv-api-local": 300_000_000}
+	names := func(list []resourceEntry) []string {
+		result := []string{}
+		for _, entry := range list {
+			result = append(result, entry.name)
+		}
+		return result
+	}
+	filtered := func(filter resourceFilter, categories []string, sortKey string) []string {
+		list := slices.DeleteFunc(collectResourceEntries(resources, volumeSizes, categories), func(entry resourceEntry) bool {
+			return !filter.matches(entry, now)
+		})
+		sortResourceEntries(list, sortKey)
+		return names(list)
+	}
+
+	got := filtered(resourceFilter{}, resourceCategories, "kind")
+	want := []string{
+		"paulenv-base:latest", "paulenv-generation:web.1", "paulenv:api", "paulenv:web",
+		"paulenv-api", "paulenv-web",
+		"paulenv-api-local", "paulenv-shared-cache",
+		"paulenv-api",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("resources = %v, want %v", got, want)
+	}
+	if got, want := filtered(resourceFilter{}, []string{"images", "volumes"}, "size"), []string{
+		"paulenv:api", "paulenv:web", "paulenv-base:latest", "paulenv-api-local", "paulenv-generation:web.1", "paulenv-shared-cache",
+	}; !slices.Equal(got, want) {
+		t.Fatalf("resources sorted by size = %v, want %v", got, want)
+	}
+	if got, want := filtered(resourceFilter{}, []string{"images"}, "age"), []string{
+		"paulenv-base:latest", "paulenv:web", "paulenv:api", "paulenv-generation:web.1",
+	}; !slices.Equal(got, want) {
+		t.Fatalf("resources sorted by age = %v, want %v", got, want)
+	}
+
+	for _, test := range []struct {
+		conditions []string
+		want       []string
+	}{
+		{[]string{"status=running"}, []string{"paulenv-api"}},
+		{[]string{"age>30d"}, []string{"paulenv-base:latest", "paulenv:web"}},
+		{[]string{"age<1d", "size>1GB"}, []string{"paulenv:api"}},
+		{[]string{"size<1GB"}, []string{"paulenv-base:latest", "paulenv-api-local"}},
+		{[]string{"project=web", "project=api", "size>1GB"}, []string{"paulenv:api", "paulenv:web"}},
+	} {
+		filter, err := parseResourceFilters(test.conditions, nil)
+		if err != nil {
+			t.Fatalf("parseResourceFilters(%v) error = %v", test.conditions, err)
+		}
+		if got := filtered(filter, resourceCategories, "kind"); !slices.Equal(got, test.want) {
+			t.Errorf("resources with --filter %v = %v, want %v", test.conditions, got, test.want)
+		}
+	}
+	for _, invalid := range []string{"project>api", "status=paused", "age>soon", "size=1GB", "owner=me", "age"} {
+		if _, err := parseResourceFilters([]string{invalid}, nil); err == nil {
+			t.Errorf("parseResourceFilters(%q) error = nil, want an error", invalid)
+		}
+	}
+}
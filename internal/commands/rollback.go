@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/table"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Rollback(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var list bool
+	var to int
+	var pin int
+	var unpin int
+	var engineSelection string
+	flagset := newCommandFlagSet("rollback", console)
+	flagset.BoolVar(&list, "list", false, "List the previous images of that project instead of rolling back")
+	flagset.IntVar(&to, "to", 0, "Number of the previous image to go back to, as listed by --list.\nDefault: the most recent one.")
+	flagset.IntVar(&pin, "pin", 0, "Pin that previous image instead of rolling back, so it is never removed\nautomatically")
+	flagset.IntVar(&unpin, "unpin", 0, "Unpin that previous image instead of rolling back")
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to use: docker or podman.\nDefault: the one used to build the project.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs rollback [project-name] [flags]",
+			"Replace a project's image by one of its previously built images, which are kept on rebuilds (see IMAGE_GENERATIONS in its run.conf). The current image is discarded.\n\nPinned previous images are kept regardless of the project's retention policy.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	modes := 0
+	for _, set := range []bool{list, to != 0, pin != 0, unpin != 0} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return utils.WithCategory(errors.New("only one of --list, --to, --pin and --unpin can be used"), errUsage)
+	}
+	if to < 0 || pin < 0 || unpin < 0 {
+		return utils.WithCategory(errors.New("invalid --to, --pin or --unpin value: must be positive"), errUsage)
+	}
+
+	name, err := getProjectName(args, filestore, console, "roll back")
+	if err != nil {
+		return err
+	}
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+	if !list {
+		unlock, err := lockProject(ctx, name, filestore, console)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+	requestedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+	containerEngine, _, err := newProjectEngine(ctx, name, requestedEngine, filestore, console)
+	if err != nil {
+		return err
+	}
+
+	generations, err := listProjectGenerations(ctx, containerEngine, name)
+	if err != nil {
+		return err
+	}
+	pinned, err := filestore.GetPinnedGenerations(name)
+	if err != nil {
+		return err
+	}
+	if list {
+		return writeGenerationList(generations, pinned, console)
+	}
+	if unpin != 0 {
+		if !pinned[unpin] {
+			return fmt.Errorf("previous image %d of project '%s' is not pinned", unpin, name)
+		}
+		if err := filestore.SetGenerationPinned(name, unpin, false); err != nil {
+			return err
+		}
+		console.Success("Unpinned previous image %d of project '%s'", unpin, name)
+		console.WriteLn("It will be removed once its retention policy does not keep it anymore.")
+		return nil
+	}
+	if pin != 0 {
+		if !slices.ContainsFunc(generations, func(g engine.GenerationInfo) bool { return g.Number == pin }) {
+			return fmt.Errorf("project '%s' has no previous image %d\nHint: Use 'paul-envs rollback --list %s' to see them", name, pin, name)
+		}
+		if err := filestore.SetGenerationPinned(name, pin, true); err != nil {
+			return err
+		}
+		console.Success("Pinned previous image %d of project '%s'", pin, name)
+		return nil
+	}
+	if len(generations) == 0 {
+		return fmt.Errorf("project '%s' has no previous image to roll back to", name)
+	}
+	target := generations[0]
+	if to != 0 {
+		found := false
+		for _, generation := range generations {
+			if generation.Number == to {
+				target, found = generation, true
+			}
+		}
+		if !found {
+			return fmt.Errorf("project '%s' has no previous image %d\nHint: Use 'paul-envs rollback --list %s' to see them", name, to, name)
+		}
+	}
+
+	if err := containerEngine.RestoreGeneration(ctx, target); err != nil {
+		return err
+	}
+	// It is now the current image, unless pinned it does not need to be kept
+	if !pinned[target.Number] {
+		if err := containerEngine.RemoveGeneration(ctx, target); err != nil {
+			console.Warn("Could not remove the now redundant image '%s': %s", target.ImageName, err)
+		}
+	}
+	console.Success("Rolled project '%s' back to its previous image %d", name, target.Number)
+	if container, err := findRunningProjectContainer(ctx, containerEngine, name); err == nil && container != nil {
+		console.WriteLn("Its running container still uses the image it was started with, until it exits.")
+	}
+	return nil
+}
+
+func writeGenerationList(generations []engine.GenerationInfo, pinned map[int]bool, console *console.Console) error {
+	if len(generations) == 0 {
+		console.WriteLn("No previous image found.")
+		return nil
+	}
+	rows := make([]table.Row, 0, len(generations))
+	for _, generation := range generations {
+		builtAt := "-"
+		if generation.BuiltAt != nil {
+			builtAt = generation.BuiltAt.Local().Format("2006-01-02 15:04")
+		}
+		size := generation.Size
+		if size == "" {
+			size = "-"
+		}
+		pin := "no"
+		if pinned[generation.Number] {
+			pin = "yes"
+		}
+		rows = append(rows, table.Row{{strconv.Itoa(generation.Number)}, {builtAt}, {size}, {pin}, {generation.ImageName}})
+	}
+	return table.Render(console.Writer(), []string{"NUMBER", "BUILT", "SIZE", "PINNED", "IMAGE"}, rows,
+		table.Options{Width: table.TerminalWidth(console.Writer())})
+}
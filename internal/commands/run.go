@@ -5,10 +5,18 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/peaberberian/paul-envs/internal/config"
 	"github.com/peaberberian/paul-envs/internal/console"
 	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/events"
 	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/logging"
+	"github.com/peaberberian/paul-envs/internal/secrets"
 	"github.com/peaberberian/paul-envs/internal/utils"
 )
 
@@ -21,13 +29,36 @@ func Run(ctx context.Context, args []string, filestore *files.FileStore, console
 
 	flagset := newCommandFlagSet("run", console)
 	var engineSelection string
+	var rootful bool
+	var autoRebuild bool
+	var noBanner bool
+	var service string
+	var instance string
+	var separateVolume bool
+	var fresh bool
+	var resetVolumes bool
+	var envVars stringListFlag
+	var envFiles stringListFlag
+	var profiles stringListFlag
+	flagset.BoolVar(&autoRebuild, "auto-rebuild", false, "Build the project image first, without asking, when it is missing or stale.")
+	flagset.BoolVar(&noBanner, "no-banner", false, "Do not display the project summary before entering its container.")
 	flagset.StringVar(&engineSelection, "engine", "", "Container engine to use: docker or podman. Default: the last build engine for the project, otherwise auto-select.")
+	flagset.BoolVar(&rootful, "rootful", false, "Use rootful Podman, e.g. to publish ports below 1024 or use devices.")
+	flagset.StringVar(&service, "service", "", "Service to run or join: one declared with SERVICE in the project's run.conf, or the\nproject's own one (named by MAIN_SERVICE, the project name by default).\nDefault: the project's own service.")
+	flagset.StringVar(&instance, "instance", "", "Run or join another `name`d instance of the project's container, from the same image\nand next to its default one. Default: its default instance.")
+	flagset.Var(&profiles, "profile", "Also start the services of that `profile`, set with SERVICE_PROFILE in the project's\nrun.conf. This option can be repeated.")
+	flagset.BoolVar(&separateVolume, "separate-volume", false, "Give a new --instance its own home volume instead of sharing the project's.")
+	flagset.BoolVar(&fresh, "fresh", false, "Remove the project's container (of --instance if set) and stop its services first,\ninstead of joining them, so it starts again from its image.")
+	flagset.BoolVar(&resetVolumes, "reset-volumes", false, "With --fresh, also remove the containers of all of the project's instances and its\nvolumes (home volumes and services' data), which start empty.")
+	flagset.Var(&envVars, "e", "Shorthand for --env `KEY=VALUE`.")
+	flagset.Var(&envVars, "env", "Environment variable to set in the container, as `KEY=VALUE`, or KEY to take its value from the host. Set on top of the project's own variables and those of --env-file. This option can be repeated.")
+	flagset.Var(&envFiles, "env-file", "Env `file` of variables to set in the container, one KEY=VALUE per line. Set on top of the project's own variables. This option can be repeated.")
 	flagset.Usage = func() {
 		writeCommandUsage(
 			console,
 			flagset,
 			"paul-envs run [project-name] [command...] [flags]",
-			"Run a project container or join the already running one. If no project name is provided, paul-envs asks you to choose one.",
+			"Run a project container or join the already running one. If no project name is provided, paul-envs uses the `.paulenv/` definition of the current repository if any, then the project selected through PAULENV_PROJECT or the one of the current directory (named by a `.paulenv` file or whose directory contains it), or asks you to choose one.",
 		)
 	}
 	if err := parseCommandFlags(flagset, args); err != nil {
@@ -41,6 +72,19 @@ func Run(ctx context.Context, args []string, filestore *files.FileStore, console
 	var name string
 	var cmdArgs []string
 
+	if len(args) == 0 {
+		name, err := syncRepoProject(ctx, filestore, console)
+		if err == nil && name == "" {
+			name, err = detectProjectName(filestore, console)
+		}
+		if err != nil {
+			return err
+		}
+		if name != "" {
+			args = []string{name}
+		}
+	}
+
 	if len(args) == 0 {
 		console.WriteLn("No project name given, listing projects...")
 		entries, err := filestore.GetAllProjects()
@@ -72,42 +116,104 @@ func Run(ctx context.Context, args []string, filestore *files.FileStore, console
 		}
 	}
 
-	if err := utils.ValidateProjectName(name); err != nil {
+	// Sessions are interactive, one project at a time
+	if group, isGroup := strings.CutPrefix(name, "@"); isGroup {
+		return utils.WithCategory(fmt.Errorf("run takes a single project, not a group of them\nHint: Build the projects of group '%s' with 'paul-envs build @%s', or stop them with 'paul-envs stop @%s'", group, group, group), errUsage)
+	}
+	if err := validateProjectName(name); err != nil {
 		return err
 	}
 	if !filestore.DoesProjectExist(name) {
-		return fmt.Errorf("project '%s' not found\nHint: Use 'paul-envs list' to see available projects", name)
+		return projectNotFoundError(name)
 	}
 	if err := ensureProjectCompatible(name, filestore, console); err != nil {
 		return fmt.Errorf("cannot run project '%s': %w", name, err)
 	}
 
+	runOptions, err := parseRunOptions(envVars, envFiles)
+	if err != nil {
+		return err
+	}
+	if instance != "" {
+		if err := utils.ValidateInstanceName(instance); err != nil {
+			return utils.WithCategory(err, errUsage)
+		}
+	} else if separateVolume {
+		return utils.WithCategory(errors.New("--separate-volume requires --instance"), errUsage)
+	}
+	if resetVolumes && !fresh {
+		return utils.WithCategory(errors.New("--reset-volumes requires --fresh"), errUsage)
+	}
+	runOptions.Instance = engine.Instance{Name: instance, SeparateVolume: separateVolume}
+	runOptions.Profiles = profiles
+
 	requestedEngine, err := parseCommandEngineSelection(engineSelection)
 	if err != nil {
 		return err
 	}
+	if requestedEngine, err = applyRootfulFlag(requestedEngine, rootful); err != nil {
+		return err
+	}
 	containerEngine, selectedEngine, err := newProjectEngine(ctx, name, requestedEngine, filestore, console)
 	if err != nil {
 		return err
 	}
+	if err := ensureProjectMachine(ctx, name, containerEngine, filestore, console); err != nil {
+		return err
+	}
+	if err := ensureNoNameCollisions(ctx, name, containerEngine, console); err != nil {
+		return err
+	}
 
 	project, err := filestore.GetProject(name)
 	if err != nil {
 		return fmt.Errorf("failed to obtain information on project '%s': %w", name, err)
 	}
+	// Read once for the whole run, so it is never seen in two states
+	runtimeCfg, err := config.LoadRuntimeConfig(project.RuntimeConfigPath)
+	if err != nil {
+		return fmt.Errorf("cannot run project '%s': %w", name, err)
+	}
+	if service != "" || len(profiles) > 0 {
+		if err := checkServiceProfiles(name, runtimeCfg, profiles); err != nil {
+			return err
+		}
+		if service != "" && service != runtimeCfg.MainServiceName(name) {
+			if instance != "" {
+				return utils.WithCategory(errors.New("--instance only applies to the project's own service"), errUsage)
+			}
+			if fresh {
+				return utils.WithCategory(errors.New("--fresh only applies to the project's own service"), errUsage)
+			}
+			return joinProjectSidecar(ctx, project, runtimeCfg, service, profiles, cmdArgs, containerEngine, console)
+		}
+	}
 
+	// Not held while the container runs, which may last for hours, only
+	// while it may be built or removed
+	unlock, err := lockProject(ctx, name, filestore, console)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	builtForRun := false
+	pendingRebuild := ""
 	needsRebuild, reason, err := runRebuildDecision(ctx, project.ProjectName, filestore, containerEngine)
 	if err != nil {
 		console.Warn("Cannot check previous build metadata: %s", err)
 	} else if needsRebuild {
 		console.WriteLn("The '%s' project needs to be re-built: %s", project.ProjectName, reason)
-		choice, err := console.AskYesNo("Do you want to build it first?", true)
+		choice := autoRebuild
+		if !autoRebuild {
+			choice, err = console.AskYesNo("Do you want to build it first?", true)
+		}
 		if err != nil || choice {
 			if err = Build(ctx, buildArgsForEngine(project.ProjectName, selectedEngine), filestore, console); err != nil {
 				return fmt.Errorf("did not succeed to build project: %w", err)
 			}
 			builtForRun = true
+		} else {
+			pendingRebuild = reason.String()
 		}
 	}
 
@@ -118,7 +224,10 @@ func Run(ctx context.Context, args []string, filestore *files.FileStore, console
 		}
 		if !hasBeenBuilt {
 			console.WriteLn("The '%s' project has not been built yet", project.ProjectName)
-			choice, err := console.AskYesNo("Do you want to build it first?", true)
+			choice := autoRebuild
+			if !autoRebuild {
+				choice, err = console.AskYesNo("Do you want to build it first?", true)
+			}
 			if err != nil || !choice {
 				return fmt.Errorf("please run 'paul-envs build %s' first", project.ProjectName)
 			}
@@ -128,27 +237,480 @@ func Run(ctx context.Context, args []string, filestore *files.FileStore, console
 		}
 	}
 
+	if fresh {
+		if err := freshenProject(ctx, name, instance, resetVolumes, containerEngine, console); err != nil {
+			return fmt.Errorf("cannot run project '%s' afresh: %w", name, err)
+		}
+	}
+	unlock()
+	showBanner := len(cmdArgs) == 0 && !noBanner
+	return runOrJoinProject(ctx, project, runtimeCfg, cmdArgs, runOptions, containerEngine, showBanner, pendingRebuild, filestore, console)
+}
+
+// Join the already running container of that project (or of the instance of
+// it in `options`), or run it along with its sidecars if they are not already.
+func runOrJoinProject(
+	ctx context.Context,
+	project files.ProjectEntry,
+	runtimeCfg config.RuntimeConfig,
+	cmdArgs []string,
+	options engine.RunOptions,
+	containerEngine engine.ContainerEngine,
+	showBanner bool,
+	pendingRebuild string,
+	filestore *files.FileStore,
+	console *console.Console,
+) error {
+	name := project.ProjectName
 	containerList, err := containerEngine.ListContainers(ctx)
 	if err != nil {
 		console.Warn("Could not list already launched containers: %s", err)
 	} else {
 		for _, container := range containerList {
-			if *container.ProjectName == name {
+			if *container.ProjectName == name && container.Instance == options.Instance.Name {
+				if showBanner {
+					showProjectBanner(ctx, project, runtimeCfg, containerEngine, pendingRebuild, true, console)
+					showProjectReadmeOnce(ctx, project, runtimeCfg, containerEngine, filestore, console)
+				}
 				console.Info("Container already created, joining it.")
-				return containerEngine.JoinContainer(ctx, container, cmdArgs)
+				if len(options.Env) > 0 {
+					console.Warn("Environment variables are only set when creating the container, ignoring them.")
+				}
+				// Services of newly requested profiles are started, and
+				// stopped along with the others
+				if len(options.Profiles) > 0 {
+					if err := startProjectSidecars(ctx, project, runtimeCfg, options.Profiles, containerEngine, console); err != nil {
+						return err
+					}
+				}
+				events.Emit(events.RunStart, name, nil)
+				stopTracking := trackProjectActivity(filestore, name, options.Instance.Name)
+				err := containerEngine.JoinContainer(ctx, container, cmdArgs)
+				stopTracking()
+				events.Emit(events.RunEnd, name, err)
+				if engine.AttachesToSession(project, cmdArgs) {
+					endProjectSession(context.WithoutCancel(ctx), project, runtimeCfg, container, containerEngine, console)
+				}
+				return err
 			}
 		}
 	}
+	reapIdleContainers(ctx, name, containerEngine, filestore, console)
+	selectHostArchImage(ctx, name, containerEngine, console)
+	if showBanner {
+		showProjectBanner(ctx, project, runtimeCfg, containerEngine, pendingRebuild, false, console)
+		showProjectReadmeOnce(ctx, project, runtimeCfg, containerEngine, filestore, console)
+	}
 
-	console.Info("Creating \"leader\" container for the project '%s', other 'run' calls will join it.", name)
-	err = containerEngine.RunContainer(ctx, project, cmdArgs)
+	if err := prepareProjectRuntimeFiles(project, runtimeCfg, filestore); err != nil {
+		return err
+	}
+	if options.Secrets, err = resolveProjectSecrets(ctx, project, runtimeCfg, filestore); err != nil {
+		return fmt.Errorf("cannot run project '%s': %w", name, err)
+	}
+	warnUnsupportedDirectives(runtimeCfg, console)
+	stopGitCredentials, err := serveGitCredentials(project, runtimeCfg)
+	if err != nil {
+		return err
+	}
+	defer stopGitCredentials()
+	stopHostCommands, err := serveHostCommands(project, runtimeCfg)
 	if err != nil {
 		return err
 	}
+	defer stopHostCommands()
+	// Services still running from a previous run are kept from being stopped
+	// for their grace period being over while they are reused
+	if err := filestore.RecordProjectActivity(name, options.Instance.Name, time.Now()); err != nil {
+		logging.Log().Debug("activity not recorded", "project", name, "error", err)
+	}
+	if err := startProjectSidecars(ctx, project, runtimeCfg, options.Profiles, containerEngine, console); err != nil {
+		return err
+	}
+	if options.Instance.Name != "" {
+		console.Info("Creating \"leader\" container for instance '%s' of the project '%s', other 'run --instance %s' calls will join it.", options.Instance.Name, name, options.Instance.Name)
+	} else {
+		console.Info("Creating \"leader\" container for the project '%s', other 'run' calls will join it.", name)
+	}
+	events.Emit(events.RunStart, name, nil)
+	stopTracking := trackProjectActivity(filestore, name, options.Instance.Name)
+	runStart := time.Now()
+	err = containerEngine.RunContainer(ctx, project, cmdArgs, options)
+	stopTracking()
+	recordProjectTiming(ctx, name, files.TimingRun, runStart, err, filestore, console)
+	events.Emit(events.RunEnd, name, err)
+	err = reportStartupFailures(project, err, console)
+	cleanUpInteractiveRun(context.WithoutCancel(ctx), project, runtimeCfg, containerEngine, console)
+	warnForeignOwnedFiles(project, console)
+	if err != nil {
+		return err
+	}
+	if engine.AttachesToSession(project, cmdArgs) && isInstanceRunning(context.WithoutCancel(ctx), name, options.Instance.Name, containerEngine) {
+		console.Info("Detached from the session of project '%s', what runs in it goes on.", name)
+		console.WriteLn("Hint: Attach to it again with 'paul-envs run %s'", runCommandArgs(name, options.Instance.Name))
+		return nil
+	}
 	console.Info("Exiting leader container for that project, those that have joined it will also exit.")
 	return nil
 }
 
+// Remove the container of that instance of the project, and stop its services
+// if no other instance runs, for its next run to start from its image (`run
+// --fresh`). With `resetVolumes`, the containers of all its instances are
+// removed, then its volumes.
+func freshenProject(
+	ctx context.Context,
+	name string,
+	instance string,
+	resetVolumes bool,
+	containerEngine engine.ContainerEngine,
+	console *console.Console,
+) error {
+	containers, err := containerEngine.ListContainers(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot list current containers: %w", err)
+	}
+	for _, container := range engine.ProjectInstances(containers, name) {
+		if container.Instance != instance && !resetVolumes {
+			continue
+		}
+		containerName := container.ContainerId
+		if container.ContainerName != nil {
+			containerName = *container.ContainerName
+		}
+		console.WriteLn("Removing container '%s'...", containerName)
+		if err := containerEngine.RemoveContainer(ctx, container); err != nil {
+			return err
+		}
+	}
+	if !resetVolumes && hasRunningInstances(ctx, name, containerEngine) {
+		console.Info("Other instances of project '%s' are running, keeping its services.", name)
+		return nil
+	}
+	if err := stopProjectSidecars(ctx, name, containerEngine, console); err != nil {
+		return err
+	}
+	if resetVolumes {
+		return removeVolume(ctx, name, containerEngine, console)
+	}
+	return nil
+}
+
+// Stop the services of a project whose containers exited, and remove what
+// its runs left behind.
+func cleanUpProjectRun(ctx context.Context, name string, containerEngine engine.ContainerEngine, console *console.Console) {
+	// Sidecars live as long as the last leader container of the project's
+	// instances, even if it was interrupted
+	if !hasRunningInstances(ctx, name, containerEngine) {
+		if stopErr := stopProjectSidecars(ctx, name, containerEngine, console); stopErr != nil {
+			console.Warn("Could not stop the services of project '%s': %s", name, stopErr)
+		}
+	}
+	removeRunLeftovers(ctx, name, containerEngine, console)
+}
+
+// Same as `cleanUpProjectRun` once an interactive run of that project ended,
+// except that its services keep running for the grace period set in its
+// run.conf (`SERVICE_GRACE_PERIOD`), in case it is run again soon.
+func cleanUpInteractiveRun(
+	ctx context.Context,
+	project files.ProjectEntry,
+	runtimeCfg config.RuntimeConfig,
+	containerEngine engine.ContainerEngine,
+	console *console.Console,
+) {
+	if runtimeCfg.ServiceGracePeriod == 0 || hasRunningInstances(ctx, project.ProjectName, containerEngine) {
+		cleanUpProjectRun(ctx, project.ProjectName, containerEngine, console)
+		return
+	}
+	if sidecars, err := listProjectSidecars(ctx, containerEngine, project.ProjectName); err == nil && len(sidecars) > 0 {
+		console.Info("Keeping the services of project '%s' running for %s, in case it is run again.", project.ProjectName, runtimeCfg.ServiceGracePeriod)
+	}
+	removeRunLeftovers(ctx, project.ProjectName, containerEngine, console)
+}
+
+// Once a shell attached to the persistent session of a project's container
+// exits, stop that container if it was the session's own shell, as nothing
+// else will (`PERSISTENT_SESSION` directive).
+func endProjectSession(
+	ctx context.Context,
+	project files.ProjectEntry,
+	runtimeCfg config.RuntimeConfig,
+	container engine.ContainerInfo,
+	containerEngine engine.ContainerEngine,
+	console *console.Console,
+) {
+	stopped, err := engine.StopEndedSession(ctx, containerEngine, project, container)
+	if err != nil {
+		console.Warn("Could not stop the container of project '%s' once its session ended: %s", project.ProjectName, err)
+		return
+	}
+	if !stopped {
+		console.Info("Detached from the session of project '%s', what runs in it goes on.", project.ProjectName)
+		console.WriteLn("Hint: Attach to it again with 'paul-envs run %s'", runCommandArgs(project.ProjectName, container.Instance))
+		return
+	}
+	cleanUpInteractiveRun(ctx, project, runtimeCfg, containerEngine, console)
+}
+
+// Arguments of `paul-envs run` reaching the given instance of a project.
+func runCommandArgs(name string, instance string) string {
+	if instance == "" {
+		return name
+	}
+	return name + " --instance " + instance
+}
+
+// Returns `true` if the container of that instance of the project is running.
+func isInstanceRunning(ctx context.Context, name string, instance string, containerEngine engine.ContainerEngine) bool {
+	containers, err := containerEngine.ListContainers(ctx)
+	if err != nil {
+		return false
+	}
+	for _, container := range engine.ProjectInstances(containers, name) {
+		if container.Instance == instance && container.Running {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns `true` if a container of one of that project's instances is still
+// running. Containers which cannot be listed are considered stopped.
+func hasRunningInstances(ctx context.Context, name string, containerEngine engine.ContainerEngine) bool {
+	containers, err := containerEngine.ListContainers(ctx)
+	if err != nil {
+		return false
+	}
+	for _, container := range engine.ProjectInstances(containers, name) {
+		if container.Running {
+			return true
+		}
+	}
+	return false
+}
+
+// Warn about the directives of the project's run.conf this host cannot honor.
+func warnUnsupportedDirectives(runtimeCfg config.RuntimeConfig, console *console.Console) {
+	for _, directive := range engine.UnsupportedDirectives(runtimeCfg) {
+		console.Warn("Ignoring %s.", directive)
+	}
+}
+
+// Remove what this run, or previous ones which did not end normally, left
+// behind: stopped containers with their anonymous volumes and the project's
+// network once unused.
+func removeRunLeftovers(ctx context.Context, name string, containerEngine engine.ContainerEngine, console *console.Console) {
+	leftovers, err := engine.FindRunLeftovers(ctx, containerEngine, name)
+	if err != nil {
+		console.Warn("Could not look for resources left by previous runs of project '%s': %s", name, err)
+		return
+	}
+	if leftovers.IsEmpty() {
+		return
+	}
+	if len(leftovers.Containers) > 0 {
+		console.Info("Removing stopped containers left by runs which did not end normally: %s.", strings.Join(leftovers.Containers, ", "))
+	}
+	if err := engine.RemoveRunLeftovers(ctx, containerEngine, leftovers); err != nil {
+		console.Warn("Could not remove resources left by runs of project '%s': %s", name, err)
+	}
+}
+
+// Collect the environment variables given to `run`, those of env files first
+// so they can be overridden individually.
+func parseRunOptions(envVars []string, envFiles []string) (engine.RunOptions, error) {
+	var options engine.RunOptions
+	for _, path := range envFiles {
+		env, err := config.LoadEnvFile(path)
+		if err != nil {
+			return engine.RunOptions{}, utils.WithCategory(fmt.Errorf("invalid --env-file: %w", err), errUsage)
+		}
+		options.Env = append(options.Env, env...)
+	}
+	for _, assignment := range envVars {
+		variable, found, err := config.ParseEnvAssignment(assignment)
+		if err != nil {
+			return engine.RunOptions{}, utils.WithCategory(err, errUsage)
+		}
+		if found {
+			options.Env = append(options.Env, variable)
+		}
+	}
+	return options, nil
+}
+
+// Display the startup banner of that project, unless disabled in its run.conf.
+func showProjectBanner(
+	ctx context.Context,
+	project files.ProjectEntry,
+	runtimeCfg config.RuntimeConfig,
+	containerEngine engine.ContainerEngine,
+	pendingRebuild string,
+	joining bool,
+	console *console.Console,
+) {
+	if runtimeCfg.NoBanner {
+		return
+	}
+	banner := startupBanner{
+		ProjectName:    project.ProjectName,
+		Ports:          runtimeCfg.Ports,
+		SSHPort:        runtimeCfg.SSHPort,
+		PendingRebuild: pendingRebuild,
+		Joining:        joining,
+	}
+	if info, err := containerEngine.Info(ctx); err == nil {
+		banner.EngineName = info.Name
+	}
+	if image, err := containerEngine.GetImageInfo(ctx, project.ProjectName); err == nil && image != nil {
+		banner.ImageBuiltAt = image.BuiltAt
+	}
+	writeStartupBanner(console, banner)
+}
+
+// Display the README of that project if its run.conf asks for it and it has
+// not been displayed yet since its image was built.
+func showProjectReadmeOnce(
+	ctx context.Context,
+	project files.ProjectEntry,
+	runtimeCfg config.RuntimeConfig,
+	containerEngine engine.ContainerEngine,
+	filestore *files.FileStore,
+	console *console.Console,
+) {
+	if !runtimeCfg.ShowReadme {
+		return
+	}
+	image, err := containerEngine.GetImageInfo(ctx, project.ProjectName)
+	if err != nil || image == nil || image.BuiltAt == nil {
+		return
+	}
+	if filestore.IsProjectReadmeShown(project.ProjectName, *image.BuiltAt) {
+		return
+	}
+	readme, err := filestore.ReadProjectReadme(project.ProjectName)
+	if err != nil {
+		console.Warn("%s", err)
+		return
+	} else if readme == "" {
+		return
+	}
+	console.WriteLn("")
+	if err := writeProjectReadme(console, readme); err != nil {
+		console.Warn("Could not display the README of project '%s': %s", project.ProjectName, err)
+		return
+	}
+	console.WriteLn("")
+	console.WriteLn("Hint: Display it again with 'paul-envs info --full %s'", project.ProjectName)
+	if err := filestore.MarkProjectReadmeShown(project.ProjectName, *image.BuiltAt); err != nil {
+		console.Warn("Could not record that the README of project '%s' was displayed: %s", project.ProjectName, err)
+	}
+}
+
+// Write the files needed on the host to run the given project's container:
+// its ssh key if it runs an ssh server reachable from the host and its X11
+// authority file if it forwards the host's display.
+func prepareProjectRuntimeFiles(project files.ProjectEntry, runtimeCfg config.RuntimeConfig, filestore *files.FileStore) error {
+	if runtimeCfg.SSHPort != "" {
+		if _, err := filestore.EnsureProjectSSHKey(project.ProjectName); err != nil {
+			return fmt.Errorf("cannot prepare ssh access to project '%s': %w", project.ProjectName, err)
+		}
+	}
+	// Also without startup scripts, so those of a previous run aren't reported
+	if err := filestore.ResetProjectStartupProgress(project.ProjectName); err != nil {
+		return fmt.Errorf("cannot prepare startup scripts of project '%s': %w", project.ProjectName, err)
+	}
+	if runtimeCfg.Display {
+		if err := filestore.PrepareProjectXauthority(project.ProjectName); err != nil {
+			return fmt.Errorf("cannot prepare display forwarding for project '%s': %w", project.ProjectName, err)
+		}
+	}
+	if runtimeCfg.GitCredentials {
+		if err := filestore.PrepareProjectGitCredentialsDir(project.ProjectName); err != nil {
+			return fmt.Errorf("cannot prepare git credentials forwarding for project '%s': %w", project.ProjectName, err)
+		}
+	}
+	if runtimeCfg.ServesHostRequests() {
+		if err := filestore.PrepareProjectHostExecDir(project.ProjectName); err != nil {
+			return fmt.Errorf("cannot prepare host commands and clipboard sharing for project '%s': %w", project.ProjectName, err)
+		}
+	}
+	if runtimeCfg.GPGAgent {
+		if err := prepareProjectGPGKeys(project, filestore); err != nil {
+			return fmt.Errorf("cannot prepare gpg-agent forwarding for project '%s': %w", project.ProjectName, err)
+		}
+	}
+	return nil
+}
+
+// Fetch the secrets of a project from their backends, as "NAME=VALUE".
+func resolveProjectSecrets(ctx context.Context, project files.ProjectEntry, runtimeCfg config.RuntimeConfig, filestore *files.FileStore) ([]string, error) {
+	if len(runtimeCfg.Secrets) == 0 {
+		return nil, nil
+	}
+	// Errors were already reported at startup
+	globalConfig, _ := filestore.LoadGlobalConfig()
+	return secrets.Resolve(ctx, runtimeCfg.Secrets, globalConfig.SecretsBackend, secrets.Options{
+		AgeIdentity: globalConfig.AgeIdentity,
+		BaseDir:     filepath.Dir(project.RuntimeConfigPath),
+	})
+}
+
+// Tell which startup scripts failed in the leader container that just exited
+// with `runErr`, which is returned with more context if one stopped it.
+func reportStartupFailures(project files.ProjectEntry, runErr error, console *console.Console) error {
+	reports, err := engine.ReadStartupProgress(project)
+	if err != nil {
+		console.Warn("Could not check the startup scripts of project '%s': %s", project.ProjectName, err)
+		return runErr
+	}
+	for _, report := range reports {
+		if report.Status != engine.StartupFailed {
+			continue
+		}
+		if report.OnFailure == config.StartupWarn {
+			console.Warn("Startup script '%s' of project '%s' failed with exit code %d", report.Name, project.ProjectName, report.ExitCode)
+		} else if runErr != nil {
+			return fmt.Errorf("startup script '%s' failed with exit code %d, the container was stopped: %w\nHint: Add '%s' after its path in %s to go on despite its failures",
+				report.Name, report.ExitCode, runErr, config.StartupWarn, project.RuntimeConfigPath)
+		}
+	}
+	return runErr
+}
+
+// Time after which a container started in the background is considered to
+// have started successfully. Those failing early (invalid configuration,
+// failing startup scripts...) exit within it.
+var detachedStartGracePeriod = time.Second
+
+// Start the container of a project in the background, then check that it did
+// not exit right away.
+func startDetachedContainer(
+	ctx context.Context,
+	project files.ProjectEntry,
+	containerEngine engine.ContainerEngine,
+	console *console.Console,
+) (engine.ContainerInfo, error) {
+	container, err := containerEngine.StartContainer(ctx, project)
+	if err != nil {
+		return engine.ContainerInfo{}, err
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, detachedStartGracePeriod)
+	defer cancel()
+	exit, err := containerEngine.WaitContainer(waitCtx, container)
+	if waitCtx.Err() != nil && ctx.Err() == nil {
+		return container, nil
+	}
+	if err != nil {
+		// Already removed, as containers are run with `--rm`
+		err = fmt.Errorf("the container of project '%s' stopped right after starting: %w", project.ProjectName, err)
+	} else {
+		err = fmt.Errorf("the container of project '%s' stopped right after starting, with %s", project.ProjectName, exit)
+	}
+	return engine.ContainerInfo{}, reportStartupFailures(project, err, console)
+}
+
 func runRebuildDecision(
 	ctx context.Context,
 	projectName string,
@@ -170,3 +732,107 @@ func runRebuildDecision(
 
 	return filestore.NeedsRebuild(projectName, engineInfo.Name, buildInfo)
 }
+
+// Register or update the project defined by a `.paulenv/` directory in the
+// current directory or one of its parents.
+//
+// Returns an empty project name if no such definition exists.
+func syncRepoProject(ctx context.Context, filestore *files.FileStore, console *console.Console) (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("cannot obtain current directory: %w", err)
+	}
+	def, err := files.FindRepoDefinition(cwd)
+	if err != nil || def == nil {
+		return "", err
+	}
+
+	if err := ensureRepoDefinitionTrusted(*def, filestore, console); err != nil {
+		return "", err
+	}
+
+	name, err := utils.SanitizeProjectName(filepath.Base(def.RootDir))
+	if err != nil {
+		return "", fmt.Errorf("cannot derive a project name from '%s': %w", def.RootDir, err)
+	}
+	unlockRegistry, err := lockRegistry(ctx, filestore, console)
+	if err != nil {
+		return "", err
+	}
+	defer unlockRegistry()
+	unlockProject, err := lockProject(ctx, name, filestore, console)
+	if err != nil {
+		return "", err
+	}
+	defer unlockProject()
+	existed := filestore.DoesProjectExist(name)
+	changed, err := filestore.SyncRepoDefinition(ctx, name, *def)
+	if err != nil {
+		return "", fmt.Errorf("cannot use in-repo definition in %s: %w", def.DefinitionDir, err)
+	}
+	if !existed {
+		console.Success("Registered project '%s' from %s", name, def.DefinitionDir)
+		events.Emit(events.ProjectCreated, name, nil)
+	} else if changed {
+		console.Info("Updated project '%s' from %s", name, def.DefinitionDir)
+	}
+	return name, nil
+}
+
+// Ask the user to trust the given in-repo definition if it has never been
+// trusted or if it changed since.
+func ensureRepoDefinitionTrusted(def files.RepoDefinition, filestore *files.FileStore, console *console.Console) error {
+	fingerprint, err := def.Fingerprint()
+	if err != nil {
+		return err
+	}
+	trust, err := filestore.GetDefinitionTrust(def.RootDir)
+	if err != nil {
+		return fmt.Errorf("cannot check trusted definitions: %w", err)
+	}
+	if trust != nil && trust.Fingerprint == fingerprint {
+		return nil
+	}
+
+	if trust == nil {
+		console.Warn("Found a paul-envs definition in %s which has not been trusted yet.", def.DefinitionDir)
+	} else {
+		console.Warn("The paul-envs definition in %s changed since you trusted it.", def.DefinitionDir)
+	}
+	console.WriteLn("It may declare arbitrary build steps and host mounts, please review its files first.")
+	choice, err := console.AskYesNo("Trust this definition?", false)
+	if err != nil {
+		return err
+	}
+	if !choice {
+		return fmt.Errorf("definition in %s is not trusted", def.DefinitionDir)
+	}
+	if err := filestore.TrustDefinition(def.RootDir, fingerprint); err != nil {
+		return fmt.Errorf("cannot record trusted definition: %w", err)
+	}
+	return nil
+}
+
+// Ask again to trust the in-repo definition the project was registered from,
+// if it changed since, when the project builds from a Dockerfile of that
+// definition: unlike its other files, that Dockerfile is read from the
+// repository at each build, including when built by name from elsewhere.
+func ensureProjectDockerfileTrusted(project files.ProjectEntry, filestore *files.FileStore, console *console.Console) error {
+	source, err := filestore.GetProjectSource(project.ProjectName)
+	if err != nil || source == "" {
+		return err
+	}
+	buildCfg, err := config.LoadBuildConfig(project.BuildConfigPath)
+	if err != nil || buildCfg.Dockerfile == "" {
+		// Reported by the build itself
+		return nil
+	}
+	def, err := files.FindRepoDefinition(source)
+	if err != nil {
+		return err
+	}
+	if def == nil || def.RootDir != source {
+		return fmt.Errorf("project '%s' builds from a Dockerfile of the in-repo definition in %s, which is no longer there", project.ProjectName, source)
+	}
+	return ensureRepoDefinitionTrusted(*def, filestore, console)
+}
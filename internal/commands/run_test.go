@@ -1,84 +1,53 @@
 package commands
 
 import (
+	"bytes"
 	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
 	"github.com/peaberberian/paul-envs/internal/engine"
 	"github.com/peaberberian/paul-envs/internal/files"
 )
 
-type stubEngine struct {
-	name    string
-	version string
-}
-
-func (s *stubEngine) Info(context.Context) (engine.EngineInfo, error) {
-	return engine.EngineInfo{Name: s.name, Version: s.version}, nil
-}
-
-func (s *stubEngine) BuildImage(context.Context, files.ProjectEntry, engine.BuildOptions) error {
-	return nil
-}
-
-func (s *stubEngine) RunContainer(context.Context, files.ProjectEntry, []string) error {
-	return nil
-}
-
-func (s *stubEngine) JoinContainer(context.Context, engine.ContainerInfo, []string) error {
-	return nil
-}
-
-func (s *stubEngine) CreateVolume(context.Context, string) error {
-	return nil
-}
-
-func (s *stubEngine) HasBeenBuilt(context.Context, string) (bool, error) {
-	return false, nil
-}
-
-func (s *stubEngine) GetImageInfo(context.Context, string) (*engine.ImageInfo, error) {
-	return nil, nil
-}
-
-func (s *stubEngine) ListContainers(context.Context) ([]engine.ContainerInfo, error) {
-	return []engine.ContainerInfo{}, nil
-}
-
-func (s *stubEngine) RemoveContainer(context.Context, engine.ContainerInfo) error {
-	return nil
-}
-
-func (s *stubEngine) ListImages(context.Context) ([]engine.ImageInfo, error) {
-	return []engine.ImageInfo{}, nil
-}
-
-func (s *stubEngine) RemoveImage(context.Context, engine.ImageInfo) error {
-	return nil
-}
-
-func (s *stubEngine) ListVolumes(context.Context) ([]engine.VolumeInfo, error) {
-	return []engine.VolumeInfo{}, nil
-}
-
-func (s *stubEngine) RemoveVolume(context.Context, engine.VolumeInfo) error {
-	return nil
-}
-
-func (s *stubEngine) ListNetworks(context.Context) ([]engine.NetworkInfo, error) {
-	return []engine.NetworkInfo{}, nil
-}
-
-func (s *stubEngine) RemoveNetwork(context.Context, engine.NetworkInfo) error {
-	return nil
-}
-
-func (s *stubEngine) PruneBuildCache(context.Context) error {
-	return nil
-}
-
-func (s *stubEngine) SupportsBuildCachePrune() bool {
-	return false
+// Build configuration of a project installing nothing optional.
+func testBuildTemplateData() files.BuildTemplateData {
+	return files.BuildTemplateData{
+		Version:                "1.0.0",
+		HostUID:                "1000",
+		HostGID:                "1000",
+		Username:               "dev",
+		Shell:                  "bash",
+		InstallNode:            "none",
+		InstallRust:            "none",
+		InstallPython:          "none",
+		InstallGo:              "none",
+		EnableWasm:             "false",
+		EnableCompilerCache:    "false",
+		EnableNestedContainers: "false",
+		EnableSystemd:          "false",
+		EnableSSH:              "false",
+		EnableSudo:             "false",
+		Packages:               "",
+		InstallNeovim:          "false",
+		InstallStarship:        "false",
+		InstallOhMyPosh:        "false",
+		InstallAtuin:           "false",
+		InstallMise:            "false",
+		InstallZellij:          "false",
+		InstallJujutsu:         "false",
+		InstallDelta:           "false",
+		InstallOpenCode:        "false",
+		InstallClaudeCode:      "false",
+		InstallCodex:           "false",
+		InstallFirefox:         "false",
+		InstallCompletions:     "true",
+	}
 }
 
 func TestRunRebuildDecisionDetectsEngineSwitch(t *testing.T) {
@@ -93,33 +62,7 @@ func TestRunRebuildDecisionDetectsEngineSwitch(t *testing.T) {
 	projectPath := t.TempDir()
 	if err := store.CreateProjectFiles(
 		"switch-engine",
-		files.BuildTemplateData{
-			Version:           "1.0.0",
-			HostUID:           "1000",
-			HostGID:           "1000",
-			Username:          "dev",
-			Shell:             "bash",
-			InstallNode:       "none",
-			InstallRust:       "none",
-			InstallPython:     "none",
-			InstallGo:         "none",
-			EnableWasm:        "false",
-			EnableSSH:         "false",
-			EnableSudo:        "false",
-			Packages:          "",
-			InstallNeovim:     "false",
-			InstallStarship:   "false",
-			InstallOhMyPosh:   "false",
-			InstallAtuin:      "false",
-			InstallMise:       "false",
-			InstallZellij:     "false",
-			InstallJujutsu:    "false",
-			InstallDelta:      "false",
-			InstallOpenCode:   "false",
-			InstallClaudeCode: "false",
-			InstallCodex:      "false",
-			InstallFirefox:    "false",
-		},
+		testBuildTemplateData(),
 		files.RuntimeTemplateData{
 			Version:         "1.0.0",
 			ProjectHostPath: projectPath,
@@ -136,7 +79,7 @@ func TestRunRebuildDecisionDetectsEngineSwitch(t *testing.T) {
 		context.Background(),
 		"switch-engine",
 		store,
-		&stubEngine{name: "podman", version: "5.0.0"},
+		&engine.FakeEngine{Name: "podman", Version: "5.0.0"},
 	)
 	if err != nil {
 		t.Fatalf("runRebuildDecision() error = %v", err)
@@ -148,3 +91,86 @@ func TestRunRebuildDecisionDetectsEngineSwitch(t *testing.T) {
 		t.Fatalf("runRebuildDecision() reason = %v, want %v", reason, files.RebuildDifferentEngine)
 	}
 }
+
+func TestStartDetachedContainer(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "demo", StartupProgressPath: filepath.Join(t.TempDir(), "progress")}
+	var out bytes.Buffer
+	cons := console.New(context.Background(), strings.NewReader(""), &out, &out)
+
+	prev := detachedStartGracePeriod
+	t.Cleanup(func() { detachedStartGracePeriod = prev })
+	detachedStartGracePeriod = 10 * time.Millisecond
+	if _, err := startDetachedContainer(context.Background(), project, &engine.FakeEngine{}, cons); err != nil {
+		t.Fatalf("startDetachedContainer() error = %v", err)
+	}
+
+	exiting := &engine.FakeEngine{Exit: &engine.ContainerExit{ExitCode: 137, OOMKilled: true}}
+	_, err := startDetachedContainer(context.Background(), project, exiting, cons)
+	if err == nil || !strings.Contains(err.Error(), "exit code 137, out of memory") {
+		t.Fatalf("startDetachedContainer() of an exiting container error = %v", err)
+	}
+
+	progress := "startup\tstarted\tdeps.sh\nstartup\tfailed\tdeps.sh\t2\tabort\n"
+	if err := os.WriteFile(project.StartupProgressPath, []byte(progress), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = startDetachedContainer(context.Background(), project, exiting, cons)
+	if err == nil || !strings.Contains(err.Error(), "startup script 'deps.sh' failed with exit code 2") {
+		t.Fatalf("startDetachedContainer() with a failing startup script error = %v", err)
+	}
+}
+
+func TestFreshenProject(t *testing.T) {
+	demo, other := "demo", "other"
+	defaultName, testsName, otherName := "paulenv-demo", "paulenv-demo..tests", "paulenv-other"
+	containers := []engine.ContainerInfo{
+		{ProjectName: &demo, ContainerName: &defaultName, ContainerId: "default", Running: true},
+		{ProjectName: &demo, ContainerName: &testsName, ContainerId: "tests", Instance: "tests", Running: true},
+		{ProjectName: &other, ContainerName: &otherName, ContainerId: "other", Running: true},
+	}
+	sidecars := []engine.SidecarInfo{{ProjectName: "demo", ServiceName: "db", ContainerId: "db"}}
+	volumes := []engine.VolumeInfo{{VolumeName: "paulenv-demo-local"}, {VolumeName: "paulenv-other-local"}}
+	var out bytes.Buffer
+	cons := console.New(context.Background(), strings.NewReader(""), &out, &out)
+
+	fake := &engine.FakeEngine{Containers: containers, Sidecars: sidecars, Volumes: volumes}
+	if err := freshenProject(context.Background(), "demo", "", false, fake, cons); err != nil {
+		t.Fatalf("freshenProject() error = %v", err)
+	}
+	removed := fake.CallsTo("RemoveContainer")
+	if len(removed) != 1 || removed[0].Args[0].(engine.ContainerInfo).ContainerId != "default" {
+		t.Fatalf("freshenProject() should only remove the default instance's container, removed %v", removed)
+	}
+	if calls := fake.CallsTo("RemoveSidecar"); len(calls) != 0 {
+		t.Fatalf("freshenProject() should keep services used by another instance, removed %v", calls)
+	}
+
+	fake = &engine.FakeEngine{Containers: containers, Sidecars: sidecars, Volumes: volumes}
+	if err := freshenProject(context.Background(), "demo", "", true, fake, cons); err != nil {
+		t.Fatalf("freshenProject() error = %v", err)
+	}
+	if calls := fake.CallsTo("RemoveContainer"); len(calls) != 2 {
+		t.Fatalf("freshenProject() should remove all instances when resetting volumes, removed %v", calls)
+	}
+	if calls := fake.CallsTo("RemoveSidecar"); len(calls) != 1 {
+		t.Fatalf("freshenProject() should stop the project's services, removed %v", calls)
+	}
+	removedVolumes := fake.CallsTo("RemoveVolume")
+	if len(removedVolumes) != 1 || removedVolumes[0].Args[0].(engine.VolumeInfo).VolumeName != "paulenv-demo-local" {
+		t.Fatalf("freshenProject() should only remove the project's volumes, removed %v", removedVolumes)
+	}
+}
+
+func TestCheckServiceProfiles(t *testing.T) {
+	runtimeCfg := config.RuntimeConfig{Services: []config.Service{
+		{Name: "db", Image: "postgres:16", Profiles: []string{"db"}},
+		{Name: "cache", Image: "redis:7"},
+	}}
+	if err := checkServiceProfiles("app", runtimeCfg, []string{"db"}); err != nil {
+		t.Fatalf("checkServiceProfiles() error = %v", err)
+	}
+	err := checkServiceProfiles("app", runtimeCfg, []string{"db", "search"})
+	if err == nil || !strings.Contains(err.Error(), "no service profile 'search'") || !strings.Contains(err.Error(), "Its profiles are: db") {
+		t.Fatalf("checkServiceProfiles() = %v, want an error listing the known profiles", err)
+	}
+}
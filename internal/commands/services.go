@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/table"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Services(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	var follow bool
+	flagset := newCommandFlagSet("services", console)
+	flagset.BoolVar(&follow, "follow", false, "With logs, keep printing what the process outputs until interrupted.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs services [flags] [project-name] [restart|stop|start|logs <process>]",
+			"List the background processes of a project's running container (declared with PROCESS in its run.conf), which its entrypoint restarts when they exit, or restart, stop, start one of them or print its output.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+
+	name, err := getProjectName(args[:min(len(args), 1)], filestore, console, "list the background processes of")
+	if err != nil {
+		return err
+	}
+	action, processName := "", ""
+	if len(args) > 1 {
+		action = args[1]
+		if len(args) != 3 {
+			return utils.WithCategory(fmt.Errorf("expected the name of the process to %s", action), errUsage)
+		}
+		processName = args[2]
+	}
+	switch action {
+	case "", "restart", "stop", "start", "logs":
+	default:
+		return utils.WithCategory(fmt.Errorf("unknown action %q, expected restart, stop, start or logs", action), errUsage)
+	}
+	if follow && action != "logs" {
+		return utils.WithCategory(errors.New("--follow only applies to logs"), errUsage)
+	}
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+	project, err := filestore.GetProject(name)
+	if err != nil {
+		return fmt.Errorf("failed to obtain information on project '%s': %w", name, err)
+	}
+	runtimeCfg, err := config.LoadRuntimeConfig(project.RuntimeConfigPath)
+	if err != nil {
+		return err
+	}
+	if processName != "" && !slices.ContainsFunc(runtimeCfg.Processes, func(p config.Process) bool { return p.Name == processName }) {
+		return fmt.Errorf("project '%s' has no background process named '%s'\n"+
+			"Hint: Declare it with a PROCESS line in its run.conf", name, processName)
+	}
+
+	containerEngine, _, err := newProjectEngine(ctx, name, engine.SelectionAuto, filestore, console)
+	if err != nil {
+		return err
+	}
+	container, err := findRunningProjectContainer(ctx, containerEngine, name)
+	if err != nil {
+		return err
+	}
+	if container == nil {
+		return fmt.Errorf("project '%s' has no running container\nHint: Start it with 'paul-envs run %s'", name, name)
+	}
+
+	switch action {
+	case "restart":
+		if err := engine.RestartProcess(ctx, containerEngine, *container, processName); err != nil {
+			return err
+		}
+		console.Success("Restarted process '%s' of project '%s'.", processName, name)
+		return nil
+	case "stop":
+		if err := engine.StopProcess(ctx, containerEngine, *container, processName); err != nil {
+			return err
+		}
+		console.Success("Stopped process '%s' of project '%s', until started again.", processName, name)
+		return nil
+	case "start":
+		if err := engine.StartProcess(ctx, containerEngine, *container, processName); err != nil {
+			return err
+		}
+		console.Success("Started process '%s' of project '%s'.", processName, name)
+		return nil
+	case "logs":
+		return engine.ProcessLogs(ctx, containerEngine, *container, processName, follow, console.Writer())
+	}
+
+	if len(runtimeCfg.Processes) == 0 {
+		console.WriteLn("Project '%s' has no background process.", name)
+		console.WriteLn("Hint: Declare them with PROCESS lines in its run.conf")
+		return nil
+	}
+	processes, err := engine.ListProcesses(ctx, containerEngine, *container)
+	if err != nil {
+		return err
+	}
+	if err := table.Render(console.Writer(), []string{
+		"PROCESS", "STATE", "PID", "UPTIME", "RESTARTS", "LAST EXIT",
+	}, processRows(runtimeCfg.Processes, processes, time.Now()), table.Options{Width: table.TerminalWidth(console.Writer())}); err != nil {
+		return err
+	}
+	if len(processes) < len(runtimeCfg.Processes) {
+		console.WriteLn("")
+		console.WriteLn("Hint: Processes declared after its container started run once it is started again, with 'paul-envs run --fresh %s'", name)
+	}
+	return nil
+}
+
+// Rows of the `services` table, for the processes declared in run.conf in
+// their order, with the state reported by the container for those it runs.
+func processRows(declared []config.Process, processes []engine.ProcessStatus, now time.Time) []table.Row {
+	rows := make([]table.Row, 0, len(declared))
+	for _, process := range declared {
+		i := slices.IndexFunc(processes, func(p engine.ProcessStatus) bool { return p.Name == process.Name })
+		if i < 0 {
+			rows = append(rows, table.Row{{process.Name}, {"not started"}, {"-"}, {"-"}, {"-"}, {"-"}})
+			continue
+		}
+		status := processes[i]
+		state, pid, uptime := "restarting", "-", "-"
+		switch {
+		case status.Stopped:
+			state = "stopped"
+		case status.Pid > 0:
+			state, pid = "running", strconv.Itoa(status.Pid)
+			if !status.StartedAt.IsZero() {
+				uptime = formatAge(&status.StartedAt, now)
+			}
+		}
+		lastExit := "-"
+		if status.LastExitCode != nil {
+			lastExit = strconv.Itoa(*status.LastExitCode)
+		}
+		rows = append(rows, table.Row{{process.Name}, {state}, {pid}, {uptime}, {strconv.Itoa(status.Restarts)}, {lastExit}})
+	}
+	return rows
+}
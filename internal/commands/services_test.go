@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/table"
+)
+
+func TestProcessRows(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	started := now.Add(-2 * time.Hour)
+	code := 137
+	declared := []config.Process{{Name: "lsp"}, {Name: "dockerd"}, {Name: "db"}, {Name: "watcher"}}
+	processes := []engine.ProcessStatus{
+		{Name: "dockerd", Stopped: true, Restarts: 1, LastExitCode: &code},
+		{Name: "lsp", Pid: 42, StartedAt: started},
+		{Name: "watcher", Restarts: 4, LastExitCode: &code},
+	}
+	want := []table.Row{
+		{{"lsp"}, {"running"}, {"42"}, {formatAge(&started, now)}, {"0"}, {"-"}},
+		{{"dockerd"}, {"stopped"}, {"-"}, {"-"}, {"1"}, {"137"}},
+		{{"db"}, {"not started"}, {"-"}, {"-"}, {"-"}, {"-"}},
+		{{"watcher"}, {"restarting"}, {"-"}, {"-"}, {"4"}, {"137"}},
+	}
+	if rows := processRows(declared, processes, now); !reflect.DeepEqual(rows, want) {
+		t.Fatalf("processRows() = %v, want %v", rows, want)
+	}
+}
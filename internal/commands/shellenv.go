@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Environment variable selecting the project of commands given none, as set
+// by `paul-envs shellenv`.
+const projectEnvVar = "PAULENV_PROJECT"
+
+// Commands of the project `paul-envs shellenv` defines aliases for, as
+// `penv-<command>`.
+var shellenvAliasedCommands = []string{"run", "build", "exec", "status", "info"}
+
+func Shellenv(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	var shell string
+	var envrc bool
+	flagset := newCommandFlagSet("shellenv", console)
+	flagset.StringVar(&shell, "shell", "", "Shell to output code for: bash, zsh or fish. Default: the one of $SHELL, bash if it is none of them.")
+	flagset.BoolVar(&envrc, "envrc", false, "Output a snippet for direnv's .envrc instead, selecting the project when entering its directory")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs shellenv [flags] [project-name]",
+			"Output shell code selecting a project: it sets "+projectEnvVar+", which commands given no project name then act on, and defines aliases for its common commands (penv-run, penv-build, penv-exec, penv-status and penv-info). Evaluate it in the current shell, e.g. with 'eval \"$(paul-envs shellenv myApp)\"'.\n\nWith --envrc, a snippet for direnv is output instead, to add to the .envrc of the project's directory: entering it then selects the project and tells how to join its environment.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) > 1 {
+		return utils.WithCategory(errors.New("shellenv takes at most one project name"), errUsage)
+	}
+	if envrc && shell != "" {
+		return utils.WithCategory(errors.New("--shell cannot be used with --envrc, direnv evaluating .envrc files with bash"), errUsage)
+	}
+	if shell == "" {
+		shell = defaultShellenvShell(os.Getenv("SHELL"))
+	} else if shell != "bash" && shell != "zsh" && shell != "fish" {
+		return utils.WithCategory(fmt.Errorf("invalid shell %q: expected bash, zsh or fish", shell), errUsage)
+	}
+
+	name, err := getProjectName(args, filestore, console, "select")
+	if err != nil {
+		return err
+	}
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+	if envrc {
+		_, err = fmt.Fprint(console.Writer(), envrcSnippet(name))
+	} else {
+		_, err = fmt.Fprint(console.Writer(), shellenvCode(name, shell))
+	}
+	return err
+}
+
+// Shell `shellenv` outputs code for when none is given, from `$SHELL`.
+func defaultShellenvShell(shellPath string) string {
+	switch shell := filepath.Base(shellPath); shell {
+	case "zsh", "fish":
+		return shell
+	default:
+		return "bash"
+	}
+}
+
+// Code selecting the given project in that shell.
+func shellenvCode(name string, shell string) string {
+	var b strings.Builder
+	if shell == "fish" {
+		fmt.Fprintf(&b, "set -gx %s %s\n", projectEnvVar, shellQuote(name))
+		for _, command := range shellenvAliasedCommands {
+			fmt.Fprintf(&b, "alias penv-%s %s\n", command, shellQuote("paul-envs "+command+" "+name))
+		}
+		return b.String()
+	}
+	fmt.Fprintf(&b, "export %s=%s\n", projectEnvVar, shellQuote(name))
+	for _, command := range shellenvAliasedCommands {
+		fmt.Fprintf(&b, "alias penv-%s=%s\n", command, shellQuote("paul-envs "+command+" "+name))
+	}
+	return b.String()
+}
+
+// Snippet of a `.envrc` selecting the given project when direnv loads it.
+//
+// direnv only keeps the environment variables of what it evaluates, so no
+// alias is defined: it tells instead how to join the project's environment.
+func envrcSnippet(name string) string {
+	return fmt.Sprintf(`# paul-envs: select project '%[1]s' when entering this directory
+if command -v paul-envs >/dev/null 2>&1; then
+    export %[2]s=%[3]s
+    log_status "paul-envs: project '%[1]s' selected, join its environment with 'paul-envs run'"
+fi
+`, name, projectEnvVar, shellQuote(name))
+}
+
+// Quote a value for POSIX shells and fish, which do not escape quotes the
+// same way: project names cannot contain any.
+func shellQuote(value string) string {
+	return "'" + value + "'"
+}
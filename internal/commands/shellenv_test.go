@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestShellenv(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("SHELL", "/usr/bin/fish")
+	store, err := files.NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	if err := store.CreateProjectFiles(
+		"app",
+		testBuildTemplateData(),
+		files.RuntimeTemplateData{Version: "1.0.0", ProjectHostPath: t.TempDir()},
+	); err != nil {
+		t.Fatalf("CreateProjectFiles() error = %v", err)
+	}
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"--shell", "bash", "app"}, []string{"export PAULENV_PROJECT='app'\n", "alias penv-run='paul-envs run app'\n"}},
+		// From $SHELL
+		{[]string{"app"}, []string{"set -gx PAULENV_PROJECT 'app'\n", "alias penv-exec 'paul-envs exec app'\n"}},
+		{[]string{"--envrc", "app"}, []string{"export PAULENV_PROJECT='app'\n", "log_status "}},
+	} {
+		var out bytes.Buffer
+		if err := Shellenv(ctx, tc.args, store, console.New(ctx, strings.NewReader(""), &out, &out)); err != nil {
+			t.Fatalf("Shellenv(%v) error = %v", tc.args, err)
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("Shellenv(%v) output does not contain %q:\n%s", tc.args, want, out.String())
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	cons := console.New(ctx, strings.NewReader(""), &out, &out)
+	for _, args := range [][]string{{"--shell", "tcsh", "app"}, {"--envrc", "--shell", "zsh", "app"}} {
+		if err := Shellenv(ctx, args, store, cons); ExitCode(err) != ExitUsage {
+			t.Errorf("Shellenv(%v) error = %v, want a usage error", args, err)
+		}
+	}
+	if err := Shellenv(ctx, []string{"missing"}, store, cons); ExitCode(err) != ExitProjectNotFound {
+		t.Errorf("Shellenv() of a missing project error = %v, want it not found", err)
+	}
+}
+
+func TestGetProjectNameFromEnv(t *testing.T) {
+	t.Setenv(projectEnvVar, "selected")
+	var out bytes.Buffer
+	cons := console.New(context.Background(), strings.NewReader(""), &out, &out)
+	if name, err := getProjectName(nil, nil, cons, "build"); err != nil || name != "selected" {
+		t.Fatalf("getProjectName() = %q, %v, want the selected project", name, err)
+	}
+	if name, err := getProjectName([]string{"given"}, nil, cons, "build"); err != nil || name != "given" {
+		t.Fatalf("getProjectName() with an argument = %q, %v, want the given project", name, err)
+	}
+}
+
+func TestGetProjectNameFromDirectory(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(projectEnvVar, "")
+	store, err := files.NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	projectDir := t.TempDir()
+	if err := store.CreateProjectFiles(
+		"app",
+		testBuildTemplateData(),
+		files.RuntimeTemplateData{Version: "1.0.0", ProjectHostPath: projectDir},
+	); err != nil {
+		t.Fatalf("CreateProjectFiles() error = %v", err)
+	}
+	nested := filepath.Join(projectDir, "src")
+	if err := os.Mkdir(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(nested)
+
+	var out bytes.Buffer
+	cons := console.New(context.Background(), strings.NewReader(""), &out, &out)
+	if name, err := getProjectName(nil, store, cons, "build"); err != nil || name != "app" {
+		t.Fatalf("getProjectName() = %q, %v, want the project of the directory", name, err)
+	}
+	if !strings.Contains(out.String(), "Using project 'app'") {
+		t.Errorf("getProjectName() did not tell which project is used:\n%s", out.String())
+	}
+}
+
+func TestShellenvOfCurrentDirectory(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(projectEnvVar, "")
+	store, err := files.NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	projectDir := t.TempDir()
+	if err := store.CreateProjectFiles(
+		"app",
+		testBuildTemplateData(),
+		files.RuntimeTemplateData{Version: "1.0.0", ProjectHostPath: projectDir},
+	); err != nil {
+		t.Fatalf("CreateProjectFiles() error = %v", err)
+	}
+	t.Chdir(projectDir)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--shell", "bash"}, shellenvCode("app", "bash")},
+		{[]string{"--envrc"}, envrcSnippet("app")},
+	} {
+		var out, errOut bytes.Buffer
+		if err := Shellenv(ctx, tc.args, store, console.New(ctx, strings.NewReader(""), &out, &errOut)); err != nil {
+			t.Fatalf("Shellenv(%v) error = %v", tc.args, err)
+		}
+		// Evaluated by shells or written to a .envrc
+		if out.String() != tc.want {
+			t.Errorf("Shellenv(%v) output = %q, want only %q", tc.args, out.String(), tc.want)
+		}
+		if !strings.Contains(errOut.String(), "Using project 'app'") {
+			t.Errorf("Shellenv(%v) did not tell which project is used:\n%s", tc.args, errOut.String())
+		}
+	}
+}
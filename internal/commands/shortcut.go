@@ -0,0 +1,243 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Shortcut(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	var name string
+	var icon string
+	var remove bool
+	flagset := newCommandFlagSet("shortcut", console)
+	flagset.StringVar(&name, "name", "", "Name of the application in the menu. Default: the name of the command.")
+	flagset.StringVar(&icon, "icon", "", "Icon of the application in the menu: a name from the host's icon theme or the path\nto an image. Default: the name of the command.")
+	flagset.BoolVar(&remove, "remove", false, "Remove the project's shortcut to that command, or all of its shortcuts if no\ncommand is given.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs shortcut [flags] [project-name] [command...]",
+			"Add an application to the host's application menu, running a GUI application of a project's container (through 'paul-envs run', joining its container if already running) on the host's display. The project needs 'DISPLAY true' in its run.conf.\n\nWithout a command, list the shortcuts of that project, or of all projects if none is given.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+
+	if len(args) == 0 {
+		if remove {
+			return utils.WithCategory(errors.New("expected the name of the project whose shortcuts to remove"), errUsage)
+		}
+		return listShortcuts("", filestore, console)
+	}
+	projectName, cmdArgs := args[0], args[1:]
+	if err := validateProjectName(projectName); err != nil {
+		return err
+	}
+	if remove {
+		return removeShortcuts(projectName, cmdArgs, name, filestore, console)
+	}
+	if !filestore.DoesProjectExist(projectName) {
+		return projectNotFoundError(projectName)
+	}
+	if len(cmdArgs) == 0 {
+		return listShortcuts(projectName, filestore, console)
+	}
+	return addShortcut(projectName, cmdArgs, name, icon, filestore, console)
+}
+
+func addShortcut(projectName string, cmdArgs []string, name string, icon string, filestore *files.FileStore, console *console.Console) error {
+	if runtime.GOOS != "linux" {
+		return errors.New("shortcuts are desktop entries, which only Linux desktops read")
+	}
+	project, err := filestore.GetProject(projectName)
+	if err != nil {
+		return fmt.Errorf("failed to obtain information on project '%s': %w", projectName, err)
+	}
+	runtimeCfg, err := config.LoadRuntimeConfig(project.RuntimeConfigPath)
+	if err != nil {
+		return err
+	}
+	if !runtimeCfg.Display {
+		return fmt.Errorf("project '%s' does not forward the host's display to its container\n"+
+			"Hint: Set 'DISPLAY true' in its run.conf", projectName)
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate the paul-envs executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+
+	command := filepath.Base(cmdArgs[0])
+	if name == "" {
+		name = command
+	}
+	if icon == "" {
+		icon = command
+	}
+	path := filestore.GetShortcutPath(projectName, shortcutID(name))
+	content := formatDesktopEntry(projectName, name, icon, executable, cmdArgs)
+	if err := filestore.WriteShortcut(path, []byte(content)); err != nil {
+		return err
+	}
+	console.Success("Added '%s' to the application menu, running '%s' in project '%s'.", name, strings.Join(cmdArgs, " "), projectName)
+	console.WriteLn("Shortcut written to %s", path)
+	return nil
+}
+
+func listShortcuts(projectName string, filestore *files.FileStore, console *console.Console) error {
+	shortcuts, err := filestore.ListShortcuts(projectName)
+	if err != nil {
+		return err
+	}
+	if len(shortcuts) == 0 {
+		console.WriteLn("No shortcut found.")
+		console.WriteLn("Hint: Add one with 'paul-envs shortcut <project-name> <command...>'")
+		return nil
+	}
+	for _, shortcut := range shortcuts {
+		if projectName == "" {
+			console.WriteLn("%s (%s): %s", shortcut.Name, shortcut.ProjectName, shortcut.Path)
+		} else {
+			console.WriteLn("%s: %s", shortcut.Name, shortcut.Path)
+		}
+	}
+	return nil
+}
+
+// Remove the shortcut of that project named `name` or after `cmdArgs`, or all
+// of its shortcuts if both are empty.
+func removeShortcuts(projectName string, cmdArgs []string, name string, filestore *files.FileStore, console *console.Console) error {
+	if name == "" && len(cmdArgs) > 0 {
+		name = filepath.Base(cmdArgs[0])
+	}
+	if name == "" {
+		count, err := filestore.RemoveProjectShortcuts(projectName)
+		if err != nil {
+			return err
+		}
+		console.Success("Removed %d shortcut(s) of project '%s'.", count, projectName)
+		return nil
+	}
+	path := filestore.GetShortcutPath(projectName, shortcutID(name))
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("project '%s' has no shortcut named '%s'\n"+
+			"Hint: List them with 'paul-envs shortcut %s'", projectName, name, projectName)
+	}
+	if err := filestore.RemoveShortcut(files.Shortcut{Path: path}); err != nil {
+		return err
+	}
+	console.Success("Removed shortcut '%s' of project '%s'.", name, projectName)
+	return nil
+}
+
+// Part of a shortcut's filename told apart from the project's other
+// shortcuts, derived from its name.
+func shortcutID(name string) string {
+	var id strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			id.WriteRune(r)
+		} else {
+			id.WriteRune('-')
+		}
+	}
+	return id.String()
+}
+
+// Desktop entry launching `cmdArgs` in that project's container, through
+// the paul-envs binary at `executable`.
+//
+// It runs with `--ci`, as nobody can answer a prompt from a menu.
+func formatDesktopEntry(projectName string, name string, icon string, executable string, cmdArgs []string) string {
+	execArgs := append([]string{executable, "--ci", "run", "--no-banner", projectName}, cmdArgs...)
+	for i, arg := range execArgs {
+		execArgs[i] = desktopExecArg(arg)
+	}
+	var b strings.Builder
+	b.WriteString("[Desktop Entry]\n")
+	b.WriteString("Type=Application\n")
+	fmt.Fprintf(&b, "Name=%s (%s)\n", desktopEntryValue(name), projectName)
+	fmt.Fprintf(&b, "Comment=%s\n", desktopEntryValue(fmt.Sprintf("Run %s in paul-envs project %s", strings.Join(cmdArgs, " "), projectName)))
+	fmt.Fprintf(&b, "Exec=%s\n", strings.Join(execArgs, " "))
+	fmt.Fprintf(&b, "Icon=%s\n", desktopEntryValue(icon))
+	b.WriteString("Terminal=false\n")
+	fmt.Fprintf(&b, "%s=%s\n", files.ShortcutProjectKey, projectName)
+	return b.String()
+}
+
+// Desktop entry of a shortcut of project `from`, as written by
+// `formatDesktopEntry`, once that project is renamed to `to`.
+func renameDesktopEntry(entry []byte, from string, to string) []byte {
+	lines := strings.Split(string(entry), "\n")
+	for i, line := range lines {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case files.ShortcutProjectKey:
+			if value == from {
+				value = to
+			}
+		case "Name":
+			if name, ok := strings.CutSuffix(value, " ("+from+")"); ok {
+				value = name + " (" + to + ")"
+			}
+		case "Comment":
+			if comment, ok := strings.CutSuffix(value, " in paul-envs project "+from); ok {
+				value = comment + " in paul-envs project " + to
+			}
+		case "Exec":
+			// Project names never need quoting
+			prefix, rest, found := strings.Cut(value, " run --no-banner "+from)
+			if found && (rest == "" || strings.HasPrefix(rest, " ")) {
+				value = prefix + " run --no-banner " + to + rest
+			}
+		}
+		lines[i] = key + "=" + value
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// Escape a string value of a desktop entry.
+func desktopEntryValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\t", `\t`, "\r", `\r`).Replace(value)
+}
+
+// Quote an argument of a desktop entry's `Exec` key if needed, as the
+// desktop would split it otherwise.
+func desktopExecArg(arg string) string {
+	// Field codes (e.g. "%f") are expanded even in quoted arguments
+	arg = strings.ReplaceAll(arg, "%", "%%")
+	if arg != "" && !strings.ContainsAny(arg, " \t\n\"'\\><~|&;$*?#()`") {
+		return arg
+	}
+	quoted := `"` + strings.NewReplacer(`"`, `\"`, "`", "\\`", `$`, `\$`, `\`, `\\`).Replace(arg) + `"`
+	// The `Exec` key is itself a string value, unescaped before being split
+	return desktopEntryValue(quoted)
+}
@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatDesktopEntry(t *testing.T) {
+	got := formatDesktopEntry("app", "Firefox", "firefox", "/opt/paul envs/paul-envs", []string{"firefox", "--new-window", "https://example.com/?q=100%"})
+	want := "[Desktop Entry]\n" +
+		"Type=Application\n" +
+		"Name=Firefox (app)\n" +
+		"Comment=Run firefox --new-window https://example.com/?q=100% in paul-envs project app\n" +
+		`Exec="/opt/paul envs/paul-envs" --ci run --no-banner app firefox --new-window "https://example.com/?q=100%%"` + "\n" +
+		"Icon=firefox\n" +
+		"Terminal=false\n" +
+		"X-PaulEnvs-Project=app\n"
+	if got != want {
+		t.Fatalf("formatDesktopEntry() = %q, want %q", got, want)
+	}
+}
+
+func TestDesktopExecArg(t *testing.T) {
+	for arg, want := range map[string]string{
+		"code":      "code",
+		"":          `""`,
+		`say "hi"`:  `"say \\"hi\\""`,
+		`C:\dir`:    `"C:\\\\dir"`,
+		"$HOME/a b": `"\\$HOME/a b"`,
+		"--flag=%u": "--flag=%%u",
+		"it's":      `"it's"`,
+		"a`b`":      "\"a\\\\`b\\\\`\"",
+	} {
+		if got := desktopExecArg(arg); got != want {
+			t.Errorf("desktopExecArg(%q) = %q, want %q", arg, got, want)
+		}
+	}
+}
+
+func TestShortcutID(t *testing.T) {
+	if got := shortcutID("My App 2"); got != "my-app-2" {
+		t.Fatalf("shortcutID() = %q, want %q", got, "my-app-2")
+	}
+}
+
+func TestRenameDesktopEntry(t *testing.T) {
+	cmdArgs := []string{"firefox", "--new-window", "app"}
+	entry := formatDesktopEntry("app", "Firefox", "firefox", "/usr/bin/paul-envs", cmdArgs)
+	got := string(renameDesktopEntry([]byte(entry), "app", "web"))
+	if want := formatDesktopEntry("web", "Firefox", "firefox", "/usr/bin/paul-envs", cmdArgs); got != want {
+		t.Fatalf("renameDesktopEntry() = %q, want %q", got, want)
+	}
+
+	// Only the project itself is renamed, not those it prefixes
+	entry = formatDesktopEntry("app-2", "Code", "code", "/usr/bin/paul-envs", []string{"code"})
+	if got := string(renameDesktopEntry([]byte(entry), "app", "web")); strings.Contains(got, "web") {
+		t.Fatalf("renameDesktopEntry() of another project = %q", got)
+	}
+}
@@ -0,0 +1,325 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/logging"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Returns the sidecars of the given project known by that engine.
+func listProjectSidecars(ctx context.Context, containerEngine engine.ContainerEngine, projectName string) ([]engine.SidecarInfo, error) {
+	all, err := containerEngine.ListSidecars(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list services: %w", err)
+	}
+	var sidecars []engine.SidecarInfo
+	for _, sidecar := range all {
+		if sidecar.ProjectName == projectName {
+			sidecars = append(sidecars, sidecar)
+		}
+	}
+	return sidecars, nil
+}
+
+// Start the sidecar services declared in that project's run.conf which are
+// not running yet, leaving out those belonging only to other profiles than
+// the given ones.
+func startProjectSidecars(
+	ctx context.Context,
+	project files.ProjectEntry,
+	runtimeCfg config.RuntimeConfig,
+	profiles []string,
+	containerEngine engine.ContainerEngine,
+	console *console.Console,
+) error {
+	if len(runtimeCfg.Services) == 0 {
+		return nil
+	}
+	sidecars, err := listProjectSidecars(ctx, containerEngine, project.ProjectName)
+	if err != nil {
+		return err
+	}
+	running := map[string]engine.SidecarInfo{}
+	for _, sidecar := range sidecars {
+		if sidecar.Running {
+			running[sidecar.ServiceName] = sidecar
+		}
+	}
+	var checks []sidecarHealthcheck
+	for _, service := range runtimeCfg.Services {
+		if !service.EnabledBy(profiles) {
+			continue
+		}
+		sidecar, isRunning := running[service.Name]
+		if !isRunning {
+			console.Info("Starting service '%s' (%s)...", service.Name, service.Image)
+			if sidecar, err = containerEngine.StartSidecar(ctx, project.ProjectName, service, runtimeCfg.SharedNetwork); err != nil {
+				return fmt.Errorf("cannot start service '%s' of project '%s': %w", service.Name, project.ProjectName, err)
+			}
+		}
+		if service.Healthcheck != "" {
+			checks = append(checks, sidecarHealthcheck{sidecar: sidecar, command: service.Healthcheck})
+		}
+	}
+	if timeout := runtimeCfg.ServiceReadyTimeout(); timeout > 0 && len(checks) > 0 {
+		return waitForSidecars(ctx, project.ProjectName, checks, timeout, containerEngine, console)
+	}
+	return nil
+}
+
+// A sidecar to wait for, with the healthcheck of its service.
+type sidecarHealthcheck struct {
+	sidecar engine.SidecarInfo
+	command string
+}
+
+// Interval between two healthchecks of the services not ready yet.
+var sidecarHealthcheckInterval = time.Second
+
+// Wait for the given sidecars to be ready, for at most `timeout`. Those still
+// not ready then are reported, without failing: whatever needs them will.
+func waitForSidecars(
+	ctx context.Context,
+	projectName string,
+	checks []sidecarHealthcheck,
+	timeout time.Duration,
+	containerEngine engine.ContainerEngine,
+	console *console.Console,
+) error {
+	checks = slices.Clone(checks)
+	start := time.Now()
+	status := console.Status()
+	defer status.Clear()
+	for waited := false; ; waited = true {
+		checks = slices.DeleteFunc(checks, func(check sidecarHealthcheck) bool {
+			return containerEngine.CheckSidecarHealth(ctx, check.sidecar, check.command) == nil
+		})
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		names := make([]string, len(checks))
+		for i, check := range checks {
+			names[i] = check.sidecar.ServiceName
+		}
+		elapsed := time.Since(start)
+		switch {
+		case len(checks) == 0:
+			status.Clear()
+			if waited {
+				console.Success("Services of project '%s' are ready after %s", projectName, elapsed.Round(time.Second))
+			}
+			return nil
+		case elapsed >= timeout:
+			status.Clear()
+			console.Warn("Service(s) %s of project '%s' still not ready after %s, continuing anyway\n"+
+				"Hint: Check their SERVICE_HEALTHCHECK, or wait longer with SERVICE_WAIT_TIMEOUT in the project's run.conf",
+				strings.Join(names, ", "), projectName, timeout)
+			return nil
+		}
+		status.Update(fmt.Sprintf("Waiting for service(s) %s to be ready (%s)", strings.Join(names, ", "), elapsed.Round(time.Second)))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sidecarHealthcheckInterval):
+		}
+	}
+}
+
+// Stop and remove all sidecars of that project, including those started for
+// a profile by another run.
+func stopProjectSidecars(
+	ctx context.Context,
+	projectName string,
+	containerEngine engine.ContainerEngine,
+	console *console.Console,
+) error {
+	sidecars, err := listProjectSidecars(ctx, containerEngine, projectName)
+	if err != nil {
+		return err
+	}
+	for _, sidecar := range sidecars {
+		console.WriteLn("Stopping service '%s'...", sidecar.ServiceName)
+		if err := containerEngine.RemoveSidecar(ctx, sidecar); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns when the services of each project which keeps them running once
+// its last container exited (`SERVICE_GRACE_PERIOD`) are to be stopped, by
+// project name: that long after it was last in use. Projects whose activity
+// was never recorded are left out.
+func loadSidecarDeadlines(filestore *files.FileStore, console *console.Console) (map[string]time.Time, error) {
+	entries, err := filestore.GetAllProjects()
+	if err != nil {
+		return nil, fmt.Errorf("could not list all projects: %w", err)
+	}
+	deadlines := map[string]time.Time{}
+	for _, entry := range entries {
+		runtimeCfg, err := config.LoadRuntimeConfig(entry.RuntimeConfigPath)
+		if err != nil {
+			// Its services are then stopped along with its last container
+			logging.Log().Debug("grace period of services unknown", "project", entry.ProjectName, "error", err)
+			continue
+		}
+		if runtimeCfg.ServiceGracePeriod == 0 || len(runtimeCfg.Services) == 0 {
+			continue
+		}
+		activity, err := filestore.GetProjectActivity(entry.ProjectName)
+		if err != nil {
+			console.Warn("Could not obtain the activity of project '%s': %s", entry.ProjectName, err)
+			continue
+		}
+		var lastUse time.Time
+		for _, at := range activity {
+			if at.After(lastUse) {
+				lastUse = at
+			}
+		}
+		if !lastUse.IsZero() {
+			deadlines[entry.ProjectName] = lastUse.Add(runtimeCfg.ServiceGracePeriod)
+		}
+	}
+	return deadlines, nil
+}
+
+// Stop the services of the projects past their deadline in `deadlines` whose
+// containers all exited. With `dryRun`, only display whose would be.
+//
+// Services are only listed if a deadline passed, so this costs nothing when
+// none did.
+//
+// Returns the number of projects whose services were stopped.
+func stopLingeringSidecars(
+	ctx context.Context,
+	deadlines map[string]time.Time,
+	now time.Time,
+	dryRun bool,
+	containerEngine engine.ContainerEngine,
+	console *console.Console,
+) (int, error) {
+	passed := false
+	for _, deadline := range deadlines {
+		passed = passed || now.After(deadline)
+	}
+	if !passed {
+		return 0, nil
+	}
+	all, err := containerEngine.ListSidecars(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("cannot list services: %w", err)
+	}
+	var projects []string
+	for _, sidecar := range all {
+		deadline, ok := deadlines[sidecar.ProjectName]
+		if ok && sidecar.Running && now.After(deadline) && !slices.Contains(projects, sidecar.ProjectName) {
+			projects = append(projects, sidecar.ProjectName)
+		}
+	}
+	slices.Sort(projects)
+	stopped := 0
+	for _, projectName := range projects {
+		if hasRunningInstances(ctx, projectName, containerEngine) {
+			continue
+		}
+		if dryRun {
+			console.WriteLn("  • The services of project '%s' would be stopped, its grace period being over", projectName)
+			stopped++
+			continue
+		}
+		if err := stopProjectSidecars(ctx, projectName, containerEngine, console); err != nil {
+			console.Warn("Could not stop the services of project '%s': %s", projectName, err)
+			continue
+		}
+		console.Success("Stopped the services of project '%s', its grace period being over", projectName)
+		stopped++
+	}
+	return stopped, nil
+}
+
+// Start a shell, or the given command, in one of the sidecar services of that
+// project.
+//
+// If its services were not running yet, they are started for that session
+// only, as when running the project's own container.
+func joinProjectSidecar(
+	ctx context.Context,
+	project files.ProjectEntry,
+	runtimeCfg config.RuntimeConfig,
+	serviceName string,
+	profiles []string,
+	args []string,
+	containerEngine engine.ContainerEngine,
+	console *console.Console,
+) error {
+	names := []string{runtimeCfg.MainServiceName(project.ProjectName)}
+	declared := false
+	for _, service := range runtimeCfg.Services {
+		names = append(names, service.Name)
+		if service.Name == serviceName {
+			declared = true
+			// Joining a service implies its profiles, as with compose
+			profiles = append(slices.Clone(profiles), service.Profiles...)
+		}
+	}
+	if !declared {
+		return utils.WithCategory(fmt.Errorf("project '%s' has no service '%s'\nHint: Its services are: %s",
+			project.ProjectName, serviceName, strings.Join(names, ", ")), errUsage)
+	}
+
+	sidecars, err := listProjectSidecars(ctx, containerEngine, project.ProjectName)
+	if err != nil {
+		return err
+	}
+	alreadyRunning := false
+	for _, sidecar := range sidecars {
+		alreadyRunning = alreadyRunning || sidecar.Running
+	}
+	if err := startProjectSidecars(ctx, project, runtimeCfg, profiles, containerEngine, console); err != nil {
+		return err
+	}
+	if !alreadyRunning {
+		defer func() {
+			if err := stopProjectSidecars(context.WithoutCancel(ctx), project.ProjectName, containerEngine, console); err != nil {
+				console.Warn("Could not stop the services of project '%s': %s", project.ProjectName, err)
+			}
+		}()
+	}
+
+	if sidecars, err = listProjectSidecars(ctx, containerEngine, project.ProjectName); err != nil {
+		return err
+	}
+	for _, sidecar := range sidecars {
+		if sidecar.ServiceName == serviceName && sidecar.Running {
+			console.Info("Joining service '%s' of project '%s'.", serviceName, project.ProjectName)
+			return containerEngine.JoinSidecar(ctx, sidecar, args)
+		}
+	}
+	return fmt.Errorf("service '%s' of project '%s' is not running", serviceName, project.ProjectName)
+}
+
+// Check that the given profiles are those of services of that project.
+func checkServiceProfiles(projectName string, runtimeCfg config.RuntimeConfig, profiles []string) error {
+	known := runtimeCfg.ServiceProfiles()
+	for _, profile := range profiles {
+		if slices.Contains(known, profile) {
+			continue
+		}
+		if len(known) == 0 {
+			return utils.WithCategory(fmt.Errorf("project '%s' has no service profile '%s'\nHint: Set them with SERVICE_PROFILE in its run.conf", projectName, profile), errUsage)
+		}
+		return utils.WithCategory(fmt.Errorf("project '%s' has no service profile '%s'\nHint: Its profiles are: %s",
+			projectName, profile, strings.Join(known, ", ")), errUsage)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+)
+
+func TestWaitForSidecars(t *testing.T) {
+	interval := sidecarHealthcheckInterval
+	sidecarHealthcheckInterval = time.Millisecond
+	t.Cleanup(func() { sidecarHealthcheckInterval = interval })
+	checks := []sidecarHealthcheck{{sidecar: engine.SidecarInfo{ProjectName: "app", ServiceName: "db"}, command: "pg_isready"}}
+	var out bytes.Buffer
+	cons := console.New(context.Background(), strings.NewReader(""), &out, &out)
+
+	fake := &engine.FakeEngine{}
+	if err := waitForSidecars(context.Background(), "app", checks, time.Minute, fake, cons); err != nil {
+		t.Fatalf("waitForSidecars() error = %v", err)
+	}
+	calls := fake.CallsTo("CheckSidecarHealth")
+	if len(calls) != 1 || calls[0].Args[1] != "pg_isready" {
+		t.Fatalf("waitForSidecars() healthchecks = %+v, want a single one", calls)
+	}
+
+	fake = &engine.FakeEngine{Errors: map[string]error{"CheckSidecarHealth": errors.New("exit status 2")}}
+	out.Reset()
+	if err := waitForSidecars(context.Background(), "app", checks, 20*time.Millisecond, fake, cons); err != nil {
+		t.Fatalf("waitForSidecars() error = %v, want services never ready to only be reported", err)
+	}
+	if len(fake.CallsTo("CheckSidecarHealth")) < 2 || !strings.Contains(out.String(), "db of project 'app' still not ready") {
+		t.Fatalf("waitForSidecars() output = %q, want db to be retried then reported", out.String())
+	}
+}
+
+func TestStopLingeringSidecars(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	str := func(s string) *string { return &s }
+	var out bytes.Buffer
+	cons := console.New(context.Background(), strings.NewReader(""), &out, &out)
+	deadlines := map[string]time.Time{
+		"over":    now.Add(-time.Minute),
+		"running": now.Add(-time.Minute),
+		"grace":   now.Add(time.Minute),
+	}
+	fake := &engine.FakeEngine{
+		Containers: []engine.ContainerInfo{
+			{ProjectName: str("running"), ContainerName: str("paulenv-running"), ContainerId: "1", Running: true},
+			{ProjectName: str("over"), ContainerName: str("paulenv-over"), ContainerId: "2"},
+		},
+		Sidecars: []engine.SidecarInfo{
+			{ProjectName: "over", ServiceName: "db", Running: true},
+			{ProjectName: "over", ServiceName: "cache", Running: true},
+			{ProjectName: "running", ServiceName: "db", Running: true},
+			{ProjectName: "grace", ServiceName: "db", Running: true},
+			{ProjectName: "untracked", ServiceName: "db", Running: true},
+		},
+	}
+
+	stopped, err := stopLingeringSidecars(context.Background(), deadlines, now, true, fake, cons)
+	if err != nil || stopped != 1 || len(fake.CallsTo("RemoveSidecar")) != 0 {
+		t.Fatalf("stopLingeringSidecars(dryRun) = %d, %v, want 1 project listed and none stopped", stopped, err)
+	}
+
+	stopped, err = stopLingeringSidecars(context.Background(), deadlines, now, false, fake, cons)
+	if err != nil || stopped != 1 {
+		t.Fatalf("stopLingeringSidecars() = %d, %v, want the services of 1 project stopped", stopped, err)
+	}
+	for _, call := range fake.CallsTo("RemoveSidecar") {
+		if sidecar := call.Args[0].(engine.SidecarInfo); sidecar.ProjectName != "over" {
+			t.Errorf("stopLingeringSidecars() stopped %+v, want only those of project 'over'", sidecar)
+		}
+	}
+	if calls := fake.CallsTo("RemoveSidecar"); len(calls) != 2 {
+		t.Errorf("stopLingeringSidecars() stopped %d services, want 2", len(calls))
+	}
+
+	fake = &engine.FakeEngine{}
+	delete(deadlines, "over")
+	delete(deadlines, "running")
+	if stopped, err := stopLingeringSidecars(context.Background(), deadlines, now, false, fake, cons); err != nil || stopped != 0 || len(fake.CallsTo("ListSidecars")) != 0 {
+		t.Errorf("stopLingeringSidecars() = %d, %v, want services not even listed before any deadline", stopped, err)
+	}
+}
@@ -0,0 +1,196 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/table"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Snapshot(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var tag string
+	var list bool
+	var restoreTag string
+	var deleteTag string
+	var engineSelection string
+	flagset := newCommandFlagSet("snapshot", console)
+	flagset.StringVar(&tag, "tag", "", "Tag of the new snapshot, replacing any snapshot with the same tag.\nDefault: the current date and time, e.g. 20260314-153000.")
+	flagset.BoolVar(&list, "list", false, "List the snapshots of that project instead of taking one")
+	flagset.StringVar(&restoreTag, "restore", "", "Make the snapshot with that tag the project's image, used by new\ncontainers until the project is rebuilt")
+	flagset.StringVar(&deleteTag, "delete", "", "Remove the snapshot with that tag")
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to use: docker or podman.\nDefault: the one used to build the project.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs snapshot [project-name] [flags]",
+			"Save the current state of a project's running container (installed packages, tweaks...) as a tagged image, which can later be restored as the project's image. Volumes, like the project's directory and its persisted home directories, are not part of snapshots.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+
+	modes := 0
+	for _, set := range []bool{list, restoreTag != "", deleteTag != "", tag != ""} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return utils.WithCategory(errors.New("only one of --tag, --list, --restore and --delete can be set"), errUsage)
+	}
+	for _, t := range []string{tag, restoreTag, deleteTag} {
+		if t != "" {
+			if err := engine.ValidateSnapshotTag(t); err != nil {
+				return utils.WithCategory(err, errValidationFailed)
+			}
+		}
+	}
+
+	name, err := getProjectName(args, filestore, console, "snapshot")
+	if err != nil {
+		return err
+	}
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+	if !list {
+		unlock, err := lockProject(ctx, name, filestore, console)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+	requestedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+	containerEngine, _, err := newProjectEngine(ctx, name, requestedEngine, filestore, console)
+	if err != nil {
+		return err
+	}
+
+	snapshots, err := listProjectSnapshots(ctx, containerEngine, name)
+	if err != nil {
+		return err
+	}
+	switch {
+	case list:
+		return writeSnapshotList(snapshots, console)
+	case restoreTag != "":
+		snapshot, err := findSnapshot(snapshots, name, restoreTag)
+		if err != nil {
+			return err
+		}
+		if err := containerEngine.RestoreSnapshot(ctx, *snapshot); err != nil {
+			return err
+		}
+		console.Success("Restored snapshot '%s' as the image of project '%s'", restoreTag, name)
+		console.WriteLn("New containers of that project will run it. Use 'paul-envs build %s' to go back to an image built from its configuration.", name)
+		return nil
+	case deleteTag != "":
+		snapshot, err := findSnapshot(snapshots, name, deleteTag)
+		if err != nil {
+			return err
+		}
+		if err := containerEngine.RemoveSnapshot(ctx, *snapshot); err != nil {
+			return err
+		}
+		console.Success("Removed snapshot '%s' of project '%s'", deleteTag, name)
+		return nil
+	}
+
+	container, err := findRunningProjectContainer(ctx, containerEngine, name)
+	if err != nil {
+		return err
+	}
+	if container == nil {
+		return fmt.Errorf("project '%s' has no running container to snapshot\nHint: Use 'paul-envs run %s' first", name, name)
+	}
+	if tag == "" {
+		tag = time.Now().Format("20060102-150405")
+	}
+	if slices.ContainsFunc(snapshots, func(s engine.SnapshotInfo) bool { return s.Tag == tag }) {
+		console.Warn("Replacing the existing snapshot '%s' of project '%s'.", tag, name)
+	}
+	console.Info("Saving the container of project '%s'...", name)
+	snapshot, err := containerEngine.CommitContainer(ctx, *container, name, tag)
+	if err != nil {
+		return err
+	}
+	console.Success("Saved snapshot '%s' of project '%s' as %s", snapshot.Tag, name, snapshot.ImageName)
+	console.WriteLn("Hint: Use 'paul-envs snapshot --restore %s %s' to run it in new containers", snapshot.Tag, name)
+	return nil
+}
+
+// Returns snapshots of the given project, most recent first.
+func listProjectSnapshots(ctx context.Context, containerEngine engine.ContainerEngine, projectName string) ([]engine.SnapshotInfo, error) {
+	all, err := containerEngine.ListSnapshots(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list snapshots: %w", err)
+	}
+	var snapshots []engine.SnapshotInfo
+	for _, snapshot := range all {
+		if snapshot.ProjectName == projectName {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	slices.SortStableFunc(snapshots, func(a, b engine.SnapshotInfo) int {
+		switch {
+		case a.CreatedAt == nil || b.CreatedAt == nil:
+			return 0
+		case a.CreatedAt.After(*b.CreatedAt):
+			return -1
+		case a.CreatedAt.Before(*b.CreatedAt):
+			return 1
+		}
+		return 0
+	})
+	return snapshots, nil
+}
+
+func findSnapshot(snapshots []engine.SnapshotInfo, projectName string, tag string) (*engine.SnapshotInfo, error) {
+	for _, snapshot := range snapshots {
+		if snapshot.Tag == tag {
+			return &snapshot, nil
+		}
+	}
+	return nil, fmt.Errorf("project '%s' has no snapshot '%s'\nHint: Use 'paul-envs snapshot --list %s' to see its snapshots", projectName, tag, projectName)
+}
+
+func writeSnapshotList(snapshots []engine.SnapshotInfo, console *console.Console) error {
+	if len(snapshots) == 0 {
+		console.WriteLn("No snapshot found.")
+		return nil
+	}
+	rows := make([]table.Row, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		createdAt := "-"
+		if snapshot.CreatedAt != nil {
+			createdAt = snapshot.CreatedAt.Local().Format("2006-01-02 15:04")
+		}
+		size := snapshot.Size
+		if size == "" {
+			size = "-"
+		}
+		rows = append(rows, table.Row{{snapshot.Tag}, {createdAt}, {size}, {snapshot.ImageName}})
+	}
+	return table.Render(console.Writer(), []string{"TAG", "CREATED", "SIZE", "IMAGE"}, rows,
+		table.Options{Width: table.TerminalWidth(console.Writer())})
+}
@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func SSHConfig(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	flagset := newCommandFlagSet("ssh-config", console)
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs ssh-config [project-name]",
+			"Print an ssh_config entry to connect to a project's container through its ssh server, e.g. to append to ~/.ssh/config for VS Code Remote-SSH or JetBrains Gateway. The project needs 'ENABLE_SSH true' in its build.conf and an 'SSH_PORT' in its run.conf, otherwise the entry targets 'paul-envs sshd', which has to be running when connecting.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+
+	name, err := getProjectName(args, filestore, console, "configure ssh access to")
+	if err != nil {
+		return err
+	}
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+	if err := ensureProjectCompatible(name, filestore, console); err != nil {
+		return fmt.Errorf("cannot configure ssh access to project '%s': %w", name, err)
+	}
+	project, err := filestore.GetProject(name)
+	if err != nil {
+		return fmt.Errorf("failed to obtain information on project '%s': %w", name, err)
+	}
+
+	buildCfg, err := config.LoadBuildConfig(project.BuildConfigPath)
+	if err != nil {
+		return err
+	}
+	runtimeCfg, err := config.LoadRuntimeConfig(project.RuntimeConfigPath)
+	if err != nil {
+		return err
+	}
+	port := runtimeCfg.SSHPort
+	if port != "" && buildCfg.Args["ENABLE_SSH"] != "true" {
+		return fmt.Errorf("project '%s' does not run an ssh server\n"+
+			"Hint: Set 'ENABLE_SSH true' in its build.conf then rebuild it", name)
+	}
+	if port == "" {
+		// Only reachable while `paul-envs sshd` runs
+		port, err = filestore.GetProjectSSHDPort(name)
+		if err != nil {
+			return err
+		}
+	}
+	if port == "" {
+		return fmt.Errorf("the container of project '%s' is not reachable through ssh from the host\n"+
+			"Hint: Run 'paul-envs sshd %s', or set 'ENABLE_SSH true' in its build.conf and an 'SSH_PORT' (e.g. 'SSH_PORT 2222') in its run.conf", name, name)
+	}
+
+	keyPath, err := filestore.EnsureProjectSSHKey(name)
+	if err != nil {
+		return fmt.Errorf("cannot prepare ssh access to project '%s': %w", name, err)
+	}
+	console.WriteLn("%s", formatSSHConfig(name, buildCfg.Args["USERNAME"], port, keyPath))
+	return nil
+}
+
+// Format the ssh_config entry allowing to connect to the given project's
+// container.
+//
+// The container's host key changes on each rebuild and the port is only
+// reachable from this machine, so host key checking is disabled for it.
+func formatSSHConfig(projectName string, username string, port string, keyPath string) string {
+	if strings.ContainsAny(keyPath, " \t") {
+		keyPath = `"` + keyPath + `"`
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Host paulenv-%s\n", projectName)
+	sb.WriteString("  HostName 127.0.0.1\n")
+	fmt.Fprintf(&sb, "  Port %s\n", port)
+	fmt.Fprintf(&sb, "  User %s\n", username)
+	fmt.Fprintf(&sb, "  IdentityFile %s\n", keyPath)
+	sb.WriteString("  IdentitiesOnly yes\n")
+	sb.WriteString("  StrictHostKeyChecking no\n")
+	sb.WriteString("  UserKnownHostsFile /dev/null\n")
+	sb.WriteString("  LogLevel ERROR")
+	return sb.String()
+}
@@ -0,0 +1,19 @@
+package commands
+
+import "testing"
+
+func TestFormatSSHConfig(t *testing.T) {
+	got := formatSSHConfig("app", "dev", "2222", "/home/me/my keys/id_ed25519")
+	want := "Host paulenv-app\n" +
+		"  HostName 127.0.0.1\n" +
+		"  Port 2222\n" +
+		"  User dev\n" +
+		"  IdentityFile \"/home/me/my keys/id_ed25519\"\n" +
+		"  IdentitiesOnly yes\n" +
+		"  StrictHostKeyChecking no\n" +
+		"  UserKnownHostsFile /dev/null\n" +
+		"  LogLevel ERROR"
+	if got != want {
+		t.Fatalf("formatSSHConfig() = %q, want %q", got, want)
+	}
+}
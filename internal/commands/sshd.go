@@ -0,0 +1,253 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Directory of the container where `paul-envs sshd` keeps its host key and
+// the project's authorized key.
+const sshdContainerDir = "/etc/ssh/paulenv"
+
+// Script run as root in a container to set up what `sshd` needs, installing
+// it first if the image does not have it. Takes the authorized key as
+// argument.
+const sshdProvisionScript = `set -e
+if [ ! -x /usr/sbin/sshd ]; then
+	export DEBIAN_FRONTEND=noninteractive
+	apt-get update -qq
+	apt-get install -y -qq --no-install-recommends openssh-server
+fi
+mkdir -p /run/sshd ` + sshdContainerDir + `
+[ -f ` + sshdContainerDir + `/host_key ] || ssh-keygen -q -t ed25519 -N '' -f ` + sshdContainerDir + `/host_key
+printf '%s\n' "$1" > ` + sshdContainerDir + `/authorized_keys
+`
+
+func SSHD(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	var port string
+	flagset := newCommandFlagSet("sshd", console)
+	flagset.StringVar(&port, "port", "", "Port of this machine to serve ssh connections on. Default: the one used the last time, otherwise a free one.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs sshd [flags] [project-name]",
+			"Serve ssh connections to a project's container on a port only reachable from this machine, until interrupted, e.g. for IDEs, rsync or scp. Only the project's generated key is accepted: 'paul-envs ssh-config' prints an ssh_config entry using it. An ssh server is installed in the container if its image has none (see ENABLE_SSH in build.conf). If the container is not running, it is started then stopped on exit.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return utils.WithCategory(fmt.Errorf("invalid --port %q: expected a number between 1 and 65535", port), errUsage)
+		}
+	}
+
+	name, err := getProjectName(args, filestore, console, "serve ssh connections to")
+	if err != nil {
+		return err
+	}
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+	if err := ensureProjectCompatible(name, filestore, console); err != nil {
+		return fmt.Errorf("cannot serve ssh connections to project '%s': %w", name, err)
+	}
+	project, err := filestore.GetProject(name)
+	if err != nil {
+		return fmt.Errorf("failed to obtain information on project '%s': %w", name, err)
+	}
+	buildCfg, err := config.LoadBuildConfig(project.BuildConfigPath)
+	if err != nil {
+		return err
+	}
+	username := buildCfg.Args["USERNAME"]
+
+	keyPath, err := filestore.EnsureProjectSSHKey(name)
+	if err != nil {
+		return fmt.Errorf("cannot prepare ssh access to project '%s': %w", name, err)
+	}
+	authorizedKey, err := os.ReadFile(project.SSHAuthorizedKeysPath)
+	if err != nil {
+		return fmt.Errorf("cannot prepare ssh access to project '%s': %w", name, err)
+	}
+	lastPort, err := filestore.GetProjectSSHDPort(name)
+	if err != nil {
+		return err
+	}
+	listener, err := listenSSHD(port, lastPort)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	_, listenPort, _ := net.SplitHostPort(listener.Addr().String())
+	if err := filestore.SetProjectSSHDPort(name, listenPort); err != nil {
+		return err
+	}
+
+	containerEngine, _, err := newProjectEngine(ctx, name, engine.SelectionAuto, filestore, console)
+	if err != nil {
+		return err
+	}
+	container, err := findRunningProjectContainer(ctx, containerEngine, name)
+	if err != nil {
+		return err
+	}
+	if container == nil {
+		started, err := startTemporaryContainer(ctx, project, containerEngine, filestore, console, "for ssh access")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := containerEngine.StopContainer(context.WithoutCancel(ctx), started); err != nil {
+				console.Warn("Could not stop the container of project '%s': %s", name, err)
+			}
+		}()
+		container = &started
+	}
+	if err := provisionSSHD(ctx, project, *container, containerEngine, strings.TrimSpace(string(authorizedKey)), console); err != nil {
+		return err
+	}
+
+	console.Success("Serving ssh connections to project '%s' on 127.0.0.1:%s, press Ctrl+C to stop.", name, listenPort)
+	console.WriteLn("Connect with: ssh -p %s -i %s %s@127.0.0.1", listenPort, keyPath, username)
+	console.WriteLn("Or add the entry printed by 'paul-envs ssh-config %s' to your ~/.ssh/config", name)
+	if err := serveSSHD(ctx, listener, *container, containerEngine, username, console); err != nil {
+		return err
+	}
+	console.WriteLn("")
+	console.Info("Stopped serving ssh connections to project '%s'.", name)
+	return nil
+}
+
+// Listen on the requested port of the loopback interface, otherwise on the
+// one used the last time, otherwise on a free one.
+func listenSSHD(requested string, last string) (net.Listener, error) {
+	port := requested
+	if port == "" {
+		port = last
+	}
+	if port == "" {
+		port = "0"
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", port))
+	if err == nil {
+		return listener, nil
+	}
+	if requested == "" {
+		return nil, fmt.Errorf("cannot listen on port %s, used the last time: %w\n"+
+			"Hint: Another 'paul-envs sshd' may already serve this project, otherwise choose another port with --port", port, err)
+	}
+	return nil, fmt.Errorf("cannot listen on port %s: %w", port, err)
+}
+
+// Set up what `sshd` needs in the given container.
+func provisionSSHD(
+	ctx context.Context,
+	project files.ProjectEntry,
+	container engine.ContainerInfo,
+	containerEngine engine.ContainerEngine,
+	authorizedKey string,
+	console *console.Console,
+) error {
+	root := engine.ExecOptions{User: "root", NoTTY: true, Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	if err := containerEngine.ExecContainer(ctx, container, []string{"test", "-x", "/usr/sbin/sshd"}, root); err != nil {
+		console.Info("Installing an ssh server in the container of project '%s'...", project.ProjectName)
+	}
+	var output bytes.Buffer
+	root.Stdout, root.Stderr = &output, &output
+	if err := containerEngine.ExecContainer(ctx, container, []string{"sh", "-c", sshdProvisionScript, "sh", authorizedKey}, root); err != nil {
+		return fmt.Errorf("cannot set up an ssh server in the container of project '%s': %w\n%s"+
+			"Hint: Set 'ENABLE_SSH true' in %s then rebuild it to have one in its image",
+			project.ProjectName, err, output.String(), project.BuildConfigPath)
+	}
+	return nil
+}
+
+// Arguments running `sshd` in inetd mode, serving a single connection through
+// its standard input and output. Only the container user can log in, with the
+// project's key.
+func sshdArgs(username string) []string {
+	return []string{
+		"/usr/sbin/sshd", "-i",
+		"-f", "/dev/null",
+		"-h", sshdContainerDir + "/host_key",
+		"-o", "AuthorizedKeysFile " + sshdContainerDir + "/authorized_keys",
+		"-o", "AllowUsers " + username,
+		"-o", "PasswordAuthentication no",
+		"-o", "KbdInteractiveAuthentication no",
+		"-o", "PermitRootLogin no",
+		// The account of the container user has no password
+		"-o", "UsePAM yes",
+		// The authorized key may belong to the host user
+		"-o", "StrictModes no",
+		"-o", "Subsystem sftp internal-sftp",
+	}
+}
+
+// Accept connections on `listener` until `ctx` is done, each one served by
+// its own `sshd` process in the container.
+func serveSSHD(
+	ctx context.Context,
+	listener net.Listener,
+	container engine.ContainerInfo,
+	containerEngine engine.ContainerEngine,
+	username string,
+	console *console.Console,
+) error {
+	stop := context.AfterFunc(ctx, func() { listener.Close() })
+	defer stop()
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("cannot accept ssh connections: %w", err)
+		}
+		wg.Go(func() {
+			defer conn.Close()
+			var stderr bytes.Buffer
+			err := containerEngine.ExecContainer(ctx, container, sshdArgs(username), engine.ExecOptions{
+				User:   "root",
+				NoTTY:  true,
+				Stdin:  conn,
+				Stdout: conn,
+				Stderr: &stderr,
+			})
+			if err != nil && ctx.Err() == nil {
+				console.Warn("ssh connection from %s failed: %s %s", conn.RemoteAddr(), err, strings.TrimSpace(stderr.String()))
+			}
+		})
+	}
+}
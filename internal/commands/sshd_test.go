@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+)
+
+// Engine whose exec'd commands echo their input, recording their arguments.
+type echoStubEngine struct {
+	engine.FakeEngine
+	args chan []string
+}
+
+func (s *echoStubEngine) ExecContainer(_ context.Context, _ engine.ContainerInfo, args []string, options engine.ExecOptions) error {
+	s.args <- args
+	_, err := io.Copy(options.Stdout, options.Stdin)
+	return err
+}
+
+func TestListenSSHD(t *testing.T) {
+	listener, err := listenSSHD("", "")
+	if err != nil {
+		t.Fatalf("listenSSHD() error = %v", err)
+	}
+	defer listener.Close()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	if !strings.HasPrefix(listener.Addr().String(), "127.0.0.1:") || port == "0" {
+		t.Fatalf("listenSSHD() should listen on a free loopback port, got %s", listener.Addr())
+	}
+
+	if _, err := listenSSHD("", port); err == nil || !strings.Contains(err.Error(), "used the last time") {
+		t.Fatalf("listenSSHD() on the busy last port error = %v", err)
+	}
+	if _, err := listenSSHD(port, ""); err == nil || strings.Contains(err.Error(), "used the last time") {
+		t.Fatalf("listenSSHD() on a busy requested port error = %v", err)
+	}
+}
+
+func TestServeSSHD(t *testing.T) {
+	listener, err := listenSSHD("", "")
+	if err != nil {
+		t.Fatalf("listenSSHD() error = %v", err)
+	}
+	defer listener.Close()
+	containerEngine := &echoStubEngine{args: make(chan []string, 1)}
+	cons := console.New(context.Background(), strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- serveSSHD(ctx, listener, engine.ContainerInfo{ContainerId: "abc"}, containerEngine, "dev", cons)
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	if _, err := conn.Write([]byte("SSH-2.0-test\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || line != "SSH-2.0-test\n" {
+		t.Fatalf("connection should be piped to the container, read %q, %v", line, err)
+	}
+	conn.Close()
+	if args := <-containerEngine.args; !slices.Equal(args[:2], []string{"/usr/sbin/sshd", "-i"}) || !slices.Contains(args, "AllowUsers dev") {
+		t.Fatalf("serveSSHD() should run sshd in inetd mode for the container user, got %v", args)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serveSSHD() error = %v", err)
+	}
+}
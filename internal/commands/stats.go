@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/table"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Stats(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var watch bool
+	var interval time.Duration
+	var engineSelection string
+	flagset := newCommandFlagSet("stats", console)
+	flagset.BoolVar(&watch, "watch", false, "Refresh the statistics continuously until interrupted")
+	flagset.DurationVar(&interval, "interval", 2*time.Second, "How often to refresh with '--watch'.\nDefault: 2s")
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to query: docker, podman, or all. Default: all available engines.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs stats [project-name] [flags]",
+			"Show the CPU, memory, network and block I/O usage of the running containers of all projects (or only the given one), grouped by project with their sidecar services.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) > 1 {
+		return utils.WithCategory(errors.New("stats takes at most one project name"), errUsage)
+	}
+	projectName := ""
+	if len(args) == 1 {
+		projectName = args[0]
+		if err := validateProjectName(projectName); err != nil {
+			return err
+		}
+		if !filestore.DoesProjectExist(projectName) {
+			return projectNotFoundError(projectName)
+		}
+	}
+	if interval <= 0 {
+		return utils.WithCategory(fmt.Errorf("invalid --interval %s: must be positive", interval), errUsage)
+	}
+	selection := engine.SelectionAll
+	if engineSelection != "" {
+		var err error
+		if selection, err = parseCleanEngineSelection(engineSelection); err != nil {
+			return utils.WithCategory(err, errUsage)
+		}
+	}
+	engines, err := engine.NewSet(ctx, console, selection)
+	if err != nil {
+		return err
+	}
+
+	if !watch {
+		return writeStats(ctx, engines, projectName, console)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		clearScreen(console.Writer())
+		console.Info("Every %s, press Ctrl+C to stop.", interval)
+		if err := writeStats(ctx, engines, projectName, console); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			// Containers may stop while being queried, the next refresh
+			// will not list them anymore
+			console.Warn("%s", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+type engineStats struct {
+	engineName string
+	engine.ContainerStats
+}
+
+func writeStats(ctx context.Context, engines []engine.ContainerEngine, projectName string, console *console.Console) error {
+	var stats []engineStats
+	for _, containerEngine := range engines {
+		containerStats, err := containerEngine.GetContainerStats(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get statistics from %s: %w", cleanEngineName(containerEngine), err)
+		}
+		for _, s := range containerStats {
+			if projectName == "" || s.ProjectName == projectName {
+				stats = append(stats, engineStats{cleanEngineName(containerEngine), s})
+			}
+		}
+	}
+	if len(stats) == 0 {
+		console.WriteLn("  (no running container)")
+		return nil
+	}
+	return table.Render(console.Writer(), []string{
+		"PROJECT", "CONTAINER", "ENGINE", "CPU", "MEMORY", "MEM %", "NET I/O", "BLOCK I/O",
+	}, statsRows(stats), table.Options{Width: table.TerminalWidth(console.Writer())})
+}
+
+// Rows of the stats table, grouping the containers of each project with its
+// own container first.
+func statsRows(stats []engineStats) []table.Row {
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].ProjectName != stats[j].ProjectName {
+			return stats[i].ProjectName < stats[j].ProjectName
+		}
+		if stats[i].ServiceName != stats[j].ServiceName {
+			return stats[i].ServiceName < stats[j].ServiceName
+		}
+		return stats[i].engineName < stats[j].engineName
+	})
+	rows := make([]table.Row, 0, len(stats))
+	for i, s := range stats {
+		project := s.ProjectName
+		if i > 0 && stats[i-1].ProjectName == s.ProjectName {
+			project = ""
+		}
+		container := s.ContainerName
+		if s.ServiceName != "" {
+			container = "service " + s.ServiceName
+		}
+		rows = append(rows, table.Row{
+			{project}, {container}, {s.engineName}, {s.CPU}, {s.Memory}, {s.MemoryPercent}, {s.NetIO}, {s.BlockIO},
+		})
+	}
+	return rows
+}
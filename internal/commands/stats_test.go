@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/engine"
+)
+
+func TestStatsRows_GroupsByProject(t *testing.T) {
+	stats := []engineStats{
+		{"docker", engine.ContainerStats{ProjectName: "web", ContainerName: "paulenv-web", CPU: "3%"}},
+		{"docker", engine.ContainerStats{ProjectName: "app", ServiceName: "db", ContainerName: "paulenv-app.db", CPU: "1%"}},
+		{"docker", engine.ContainerStats{ProjectName: "app", ContainerName: "paulenv-app", CPU: "2%"}},
+	}
+	rows := statsRows(stats)
+	want := [][2]string{
+		{"app", "paulenv-app"},
+		{"", "service db"},
+		{"web", "paulenv-web"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("statsRows() = %d rows, want %d", len(rows), len(want))
+	}
+	for i, row := range rows {
+		if row[0][0] != want[i][0] || row[1][0] != want[i][1] {
+			t.Fatalf("statsRows()[%d] = %v, want project %q and container %q", i, row, want[i][0], want[i][1])
+		}
+	}
+	if rows[0][3][0] != "2%" {
+		t.Fatalf("statsRows()[0] CPU = %q, want 2%%", rows[0][3][0])
+	}
+}
@@ -0,0 +1,182 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/table"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Status(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	wide := false
+	flagset := newCommandFlagSet("status", console)
+	flagset.BoolVar(&wide, "wide", false, "Display full values even if they do not fit in the terminal width")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs status [project-name] [flags]",
+			"Show, for each project (or only the given one), whether it is built, the age and size of its image, the disk space used by its image and volumes, its containers, volumes and networks, as known by all available container engines.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) > 1 {
+		return errors.New("status takes at most one project name")
+	}
+	if len(args) == 1 && !filestore.DoesProjectExist(args[0]) {
+		return projectNotFoundError(args[0])
+	}
+
+	overviews, err := collectProjectOverviews(ctx, filestore, console)
+	if err != nil {
+		return err
+	}
+	if len(overviews) == 0 {
+		console.WriteLn("  (no project found)")
+		console.WriteLn("Hint: Create one with 'paul-envs create <path>'")
+		return nil
+	}
+
+	if len(args) == 1 {
+		overviews = slices.DeleteFunc(overviews, func(overview projectOverview) bool {
+			return overview.Entry.ProjectName != args[0]
+		})
+	}
+	diskSizes := projectDiskSizes(ctx, overviews, console)
+	now := time.Now()
+	rows := []table.Row{}
+	for _, overview := range overviews {
+		rows = append(rows, projectStatusRow(overview, diskSizes, now))
+	}
+	return table.Render(console.Writer(), []string{
+		"PROJECT", "ENGINE", "BUILT", "IMAGE SIZE", "DISK USAGE", "CONTAINERS", "VOLUMES", "NETWORKS",
+	}, rows, table.Options{Width: table.TerminalWidth(console.Writer()), Wide: wide})
+}
+
+// Disk space used by the given projects on the engine they were found on: the
+// unique size of their image (the layers no other image shares) and the size
+// of their volumes, by project name.
+//
+// Projects not found on any engine, or whose engine could not report its disk
+// usage, are missing.
+func projectDiskSizes(ctx context.Context, overviews []projectOverview, console *console.Console) map[string]int64 {
+	usages := map[engine.ContainerEngine]*engine.DiskUsage{}
+	sizes := map[string]int64{}
+	for _, overview := range overviews {
+		if overview.containerEngine == nil {
+			continue
+		}
+		usage, ok := usages[overview.containerEngine]
+		if !ok {
+			if u, err := overview.containerEngine.GetDiskUsage(ctx); err != nil {
+				console.Warn("Could not obtain disk usage: %s", err)
+			} else {
+				usage = &u
+			}
+			usages[overview.containerEngine] = usage
+		}
+		if usage != nil {
+			sizes[overview.Entry.ProjectName] = projectDiskSize(overview, *usage)
+		}
+	}
+	return sizes
+}
+
+func projectDiskSize(overview projectOverview, usage engine.DiskUsage) int64 {
+	var size int64
+	if overview.Image != nil {
+		imageName := strings.TrimPrefix(overview.Image.ImageName, "localhost/")
+		for ref, image := range usage.Images {
+			if strings.TrimPrefix(ref, "localhost/") == imageName {
+				size += image.UniqueSize
+				break
+			}
+		}
+	}
+	for _, volume := range overview.Volumes {
+		size += usage.Volumes[volume.VolumeName]
+	}
+	return size
+}
+
+func projectStatusRow(overview projectOverview, diskSizes map[string]int64, now time.Time) table.Row {
+	engineName := "-"
+	if overview.EngineName != "" {
+		engineName = overview.EngineName
+	}
+	built := "no"
+	size := "-"
+	if overview.IsBuilt() {
+		built = "yes"
+		if overview.Image.BuiltAt != nil {
+			built = formatImageAge(overview.Image.BuiltAt, now)
+		}
+		if overview.Image.Size != "" {
+			size = overview.Image.Size
+		}
+	}
+
+	diskUsage := "-"
+	if diskSize, ok := diskSizes[overview.Entry.ProjectName]; ok {
+		diskUsage = utils.FormatSize(diskSize)
+	}
+
+	containers := make([]string, 0, len(overview.Containers))
+	for _, container := range overview.Containers {
+		name := container.ContainerId
+		if container.ContainerName != nil {
+			name = *container.ContainerName
+		}
+		if container.Running {
+			containers = append(containers, name+" (running)")
+		} else {
+			containers = append(containers, name+" (stopped)")
+		}
+	}
+	volumes := make([]string, 0, len(overview.Volumes))
+	for _, volume := range overview.Volumes {
+		volumes = append(volumes, volume.VolumeName)
+	}
+	networks := make([]string, 0, len(overview.Networks))
+	for _, network := range overview.Networks {
+		networks = append(networks, network.NetworkName)
+	}
+
+	return table.Row{
+		{overview.Entry.ProjectName},
+		{engineName},
+		{built},
+		{size},
+		{diskUsage},
+		orNone(containers),
+		orNone(volumes),
+		orNone(networks),
+	}
+}
+
+func orNone(values []string) []string {
+	if len(values) == 0 {
+		return []string{"-"}
+	}
+	return values
+}
@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/table"
+)
+
+func TestProjectStatusRow(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	builtAt := now.Add(-5 * time.Hour)
+	containerName := "paulenv-app"
+	overview := projectOverview{
+		Entry:      files.ProjectEntry{ProjectName: "app", ProjectPath: "/code/app"},
+		EngineName: "docker",
+		Image:      &engine.ImageInfo{ImageName: "paulenv:app", BuiltAt: &builtAt, Size: "800MB"},
+		Containers: []engine.ContainerInfo{{ContainerName: &containerName, ContainerId: "abc", Running: true}},
+		Volumes:    []engine.VolumeInfo{{VolumeName: "paulenv-app-local"}},
+	}
+
+	var out strings.Builder
+	if err := table.Render(&out, []string{"PROJECT", "ENGINE", "BUILT", "IMAGE SIZE", "DISK USAGE", "CONTAINERS", "VOLUMES", "NETWORKS"},
+		[]table.Row{projectStatusRow(overview, map[string]int64{"app": 3_200_000_000}, now)}, table.Options{}); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	got := out.String()
+	for _, fragment := range []string{"app", "docker", "5h ago", "800MB", "3.2GB", "paulenv-app (running)", "paulenv-app-local"} {
+		if !strings.Contains(got, fragment) {
+			t.Fatalf("expected output to contain %q, got:\n%s", fragment, got)
+		}
+	}
+}
+
+func TestFormatImageAge(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		age  time.Duration
+		want string
+	}{
+		{age: 10 * time.Second, want: "just now"},
+		{age: 5 * time.Minute, want: "5m ago"},
+		{age: 3 * time.Hour, want: "3h ago"},
+		{age: 50 * time.Hour, want: "2d ago"},
+	}
+
+	for _, tt := range tests {
+		builtAt := now.Add(-tt.age)
+		if got := formatImageAge(&builtAt, now); got != tt.want {
+			t.Errorf("formatImageAge(-%s) = %q, want %q", tt.age, got, tt.want)
+		}
+	}
+	if got := formatImageAge(nil, now); got != "-" {
+		t.Errorf("formatImageAge(nil) = %q, want %q", got, "-")
+	}
+}
+
+func TestProjectDiskSizes(t *testing.T) {
+	fake := &engine.FakeEngine{DiskUsage: engine.DiskUsage{
+		Images: map[string]engine.ImageUsage{
+			"localhost/paulenv:app": {ImageId: "a", Size: 900, UniqueSize: 300},
+			"paulenv-base:latest":   {ImageId: "b", Size: 600, UniqueSize: 600},
+		},
+		Volumes: map[string]int64{
+			"paulenv-app-local":       20,
+			"paulenv-app.db-local":    5,
+			"paulenv-other-local":     1000,
+			"paulenv-shared-cache-go": 7,
+		},
+	}}
+	overviews := []projectOverview{
+		{
+			Entry:           files.ProjectEntry{ProjectName: "app"},
+			Image:           &engine.ImageInfo{ImageName: "paulenv:app"},
+			Volumes:         []engine.VolumeInfo{{VolumeName: "paulenv-app-local"}, {VolumeName: "paulenv-app.db-local"}},
+			containerEngine: fake,
+		},
+		{Entry: files.ProjectEntry{ProjectName: "unbuilt"}},
+	}
+
+	cons := console.New(context.Background(), strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{})
+	got := projectDiskSizes(context.Background(), overviews, cons)
+	if len(got) != 1 || got["app"] != 325 {
+		t.Fatalf("projectDiskSizes() = %v, want map[app:325]", got)
+	}
+}
+
+func TestApplyCachedOverviews(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	store, err := files.NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	var out bytes.Buffer
+	cons := console.New(context.Background(), strings.NewReader(""), &out, &out)
+
+	overviews := []projectOverview{{Entry: files.ProjectEntry{ProjectName: "app"}}}
+	if applyCachedOverviews(overviews, store, cons) {
+		t.Fatal("applyCachedOverviews() = true without a cached state")
+	}
+
+	at := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	containerName := "paulenv-app"
+	seen := []projectOverview{{
+		Entry:      files.ProjectEntry{ProjectName: "app"},
+		EngineName: "podman",
+		Image:      &engine.ImageInfo{ImageName: "paulenv:app", Size: "1GB"},
+		Containers: []engine.ContainerInfo{{ContainerName: &containerName, ContainerId: "abc", Running: true}},
+	}}
+	if err := store.SetEngineStateCache(overviewsCache(seen, at)); err != nil {
+		t.Fatalf("SetEngineStateCache() error = %v", err)
+	}
+
+	if !applyCachedOverviews(overviews, store, cons) {
+		t.Fatal("applyCachedOverviews() = false with a cached state")
+	}
+	got := overviews[0]
+	if got.EngineName != "podman" || !got.IsBuilt() || got.Image.Size != "1GB" || len(got.RunningContainers()) != 1 {
+		t.Fatalf("overview from cache = %+v", got)
+	}
+	if got.containerEngine != nil {
+		t.Fatal("overview from cache should not have a container engine")
+	}
+	if !strings.Contains(out.String(), "showing cached data from") {
+		t.Fatalf("expected a cached data warning, got %q", out.String())
+	}
+}
@@ -0,0 +1,172 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Stop(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var engineSelection string
+	flagset := newCommandFlagSet("stop", console)
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to use: docker or podman.\nDefault: the one used to build each project.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs stop [project-name|@group...] [flags]",
+			"Stop the running containers of a project, all of its instances included, then its services. If no project name is provided, paul-envs uses the one selected through PAULENV_PROJECT or the one of the current directory, otherwise asks you to choose one.\n\nWith several project names or groups of projects ('@group', see 'paul-envs group'), they are stopped in parallel and a summary is displayed once they all are.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	requestedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+
+	args = flagset.Args()
+	var names []string
+	if len(args) > 1 || hasGroupSelector(args) {
+		if names, err = resolveProjectSelectors(args, filestore); err != nil {
+			return err
+		}
+	} else {
+		name, err := getProjectName(args, filestore, console, "stop")
+		if err != nil {
+			return err
+		}
+		names = []string{name}
+	}
+	for _, name := range names {
+		if err := validateProjectName(name); err != nil {
+			return err
+		}
+		if !filestore.DoesProjectExist(name) {
+			return projectNotFoundError(name)
+		}
+	}
+
+	// Engines are shared by projects run with the same one
+	engines := map[engine.Selection]engine.ContainerEngine{}
+	projectEngines := make([]engine.ContainerEngine, len(names))
+	for i, name := range names {
+		selection := resolveProjectEngineSelection(name, requestedEngine, filestore, console)
+		if _, ok := engines[selection]; !ok {
+			if engines[selection], err = engine.NewSelected(ctx, console, selection); err != nil {
+				return err
+			}
+		}
+		projectEngines[i] = engines[selection]
+	}
+
+	stopped := make([]int, len(names))
+	results := make([]error, len(names))
+	utils.Parallel(len(names), engineQueryParallelism(filestore), func(i int) {
+		stopped[i], results[i] = stopProject(ctx, names[i], projectEngines[i], filestore, console)
+	})
+	if len(names) == 1 {
+		if results[0] == nil && stopped[0] == 0 {
+			console.Info("No running container for project '%s'", names[0])
+		}
+		return results[0]
+	}
+
+	summary := stopSummary{}
+	for i, name := range names {
+		switch {
+		case results[i] != nil:
+			console.Error("Could not stop project '%s': %s", name, results[i])
+			summary.Failed = append(summary.Failed, name)
+		case stopped[i] > 0:
+			summary.Stopped = append(summary.Stopped, name)
+		default:
+			summary.NotRunning = append(summary.NotRunning, name)
+		}
+	}
+	console.WriteLn("")
+	console.Info("Summary:")
+	for _, line := range summary.Lines() {
+		console.WriteLn("%s", line)
+	}
+	if len(summary.Failed) > 0 {
+		return fmt.Errorf("failed to stop %s", strings.Join(summary.Failed, ", "))
+	}
+	return nil
+}
+
+// Stop the running containers of that project, then its services and what
+// its runs left behind.
+//
+// Returns the number of containers stopped.
+func stopProject(
+	ctx context.Context,
+	name string,
+	containerEngine engine.ContainerEngine,
+	filestore *files.FileStore,
+	console *console.Console,
+) (int, error) {
+	unlock, err := lockProject(ctx, name, filestore, console)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	containers, err := containerEngine.ListContainers(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not list containers: %w", err)
+	}
+	stopped := 0
+	var errs []error
+	for _, container := range engine.ProjectInstances(containers, name) {
+		if !container.Running {
+			continue
+		}
+		containerName := container.ContainerId
+		if container.ContainerName != nil {
+			containerName = *container.ContainerName
+		}
+		if err := containerEngine.StopContainer(ctx, container); err != nil {
+			errs = append(errs, fmt.Errorf("could not stop container %s: %w", containerName, err))
+			continue
+		}
+		console.Success("Stopped container %s", containerName)
+		stopped++
+	}
+	// Also stops the services kept running past the last container
+	// (`SERVICE_GRACE_PERIOD`)
+	cleanUpProjectRun(context.WithoutCancel(ctx), name, containerEngine, console)
+	return stopped, errors.Join(errs...)
+}
+
+// Outcome of stopping several projects.
+type stopSummary struct {
+	Stopped    []string
+	Failed     []string
+	NotRunning []string
+}
+
+func (s stopSummary) Lines() []string {
+	lines := []string{}
+	if len(s.Stopped) > 0 {
+		lines = append(lines, "  Stopped     : "+strings.Join(s.Stopped, ", "))
+	}
+	if len(s.Failed) > 0 {
+		lines = append(lines, "  Failed      : "+strings.Join(s.Failed, ", "))
+	}
+	if len(s.NotRunning) > 0 {
+		lines = append(lines, "  Not running : "+strings.Join(s.NotRunning, ", "))
+	}
+	return lines
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestStopProject(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	store, err := files.NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	var out bytes.Buffer
+	cons := console.New(context.Background(), strings.NewReader(""), &out, &out)
+	str := func(s string) *string { return &s }
+
+	fake := &engine.FakeEngine{
+		Containers: []engine.ContainerInfo{
+			{ProjectName: str("app"), ContainerName: str("paulenv-app"), ContainerId: "1", Running: true},
+			{ProjectName: str("app"), ContainerName: str("paulenv-app..tests"), Instance: "tests", ContainerId: "2", Running: true},
+			{ProjectName: str("app"), ContainerName: str("paulenv-app..docs"), Instance: "docs", ContainerId: "3"},
+			{ProjectName: str("other"), ContainerName: str("paulenv-other"), ContainerId: "4", Running: true},
+		},
+		Sidecars: []engine.SidecarInfo{{ProjectName: "app", ServiceName: "db", Running: true}},
+	}
+	stopped, err := stopProject(context.Background(), "app", fake, store, cons)
+	if err != nil || stopped != 2 {
+		t.Fatalf("stopProject() = %d, %v, want its 2 running containers stopped", stopped, err)
+	}
+	for _, call := range fake.CallsTo("StopContainer") {
+		if container := call.Args[0].(engine.ContainerInfo); *container.ProjectName != "app" {
+			t.Errorf("stopProject() stopped %+v, want only containers of project 'app'", container)
+		}
+	}
+	// The fake engine still lists them as running
+	if len(fake.CallsTo("RemoveSidecar")) != 0 {
+		t.Errorf("stopProject() stopped services of a project still running")
+	}
+
+	// Services kept running past its last container
+	fake = &engine.FakeEngine{Sidecars: []engine.SidecarInfo{{ProjectName: "app", ServiceName: "db", Running: true}}}
+	if stopped, err := stopProject(context.Background(), "app", fake, store, cons); err != nil || stopped != 0 {
+		t.Fatalf("stopProject() = %d, %v, want no container stopped", stopped, err)
+	}
+	if len(fake.CallsTo("RemoveSidecar")) != 1 {
+		t.Errorf("stopProject() did not stop the services of a project with no running container")
+	}
+
+	fake = &engine.FakeEngine{
+		Errors:     map[string]error{"StopContainer": errors.New("engine down")},
+		Containers: []engine.ContainerInfo{{ProjectName: str("app"), ContainerName: str("paulenv-app"), ContainerId: "1", Running: true}},
+	}
+	if _, err := stopProject(context.Background(), "app", fake, store, cons); err == nil || !strings.Contains(err.Error(), "paulenv-app") {
+		t.Errorf("stopProject() error = %v, want the containers which could not be stopped", err)
+	}
+}
+
+func TestRunRejectsGroups(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	store, err := files.NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	var out bytes.Buffer
+	cons := console.New(context.Background(), strings.NewReader(""), &out, &out)
+	if err := Run(context.Background(), []string{"@work"}, store, cons); !errors.Is(err, errUsage) {
+		t.Errorf("Run(@work) error = %v, want a usage error", err)
+	}
+}
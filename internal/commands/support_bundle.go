@@ -0,0 +1,259 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	versions "github.com/peaberberian/paul-envs/internal"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Number of lines of the last build log put in a support bundle.
+const supportBundleBuildLogLines = 200
+
+// Number of lines of paul-envs' own log put in a support bundle.
+const supportBundleDebugLogLines = 500
+
+func SupportBundle(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var output string
+	flagset := newCommandFlagSet("support-bundle", console)
+	flagset.StringVar(&output, "output", "", "Path of the archive to write. Default: paul-envs-support-<date>.tar.gz in the current directory")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs support-bundle [project-name] [flags]",
+			"Collect diagnostics (paul-envs and container engine versions, 'doctor' checks, the end of paul-envs' log and, for the given project, its configuration, compose file and last build log) into a single archive to attach to a bug report. What looks like secrets and personal information is redacted.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) > 1 {
+		return utils.WithCategory(errors.New("support-bundle takes at most one project name"), errUsage)
+	}
+	var project *files.ProjectEntry
+	if len(args) == 1 {
+		if err := validateProjectName(args[0]); err != nil {
+			return err
+		}
+		if !filestore.DoesProjectExist(args[0]) {
+			return projectNotFoundError(args[0])
+		}
+		entry, err := filestore.GetProject(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to obtain information on project '%s': %w", args[0], err)
+		}
+		project = &entry
+	}
+
+	now := time.Now()
+	if output == "" {
+		output = fmt.Sprintf("paul-envs-support-%s.tar.gz", now.Format("20060102-150405"))
+	}
+	output, err := filepath.Abs(output)
+	if err != nil {
+		return fmt.Errorf("invalid output path '%s': %w", output, err)
+	}
+
+	console.WriteLn("Collecting diagnostics...")
+	archiveFiles := collectSupportFiles(ctx, project, filestore, console, now)
+	homeDir, _ := os.UserHomeDir()
+	root := strings.TrimSuffix(filepath.Base(output), ".tar.gz")
+	for i := range archiveFiles {
+		archiveFiles[i].Name = root + "/" + archiveFiles[i].Name
+		archiveFiles[i].Data = []byte(utils.RedactSecrets(string(archiveFiles[i].Data), homeDir))
+	}
+	if err := filestore.WriteArchive(output, archiveFiles); err != nil {
+		return err
+	}
+
+	console.Success("Support bundle written to %s", output)
+	for _, file := range archiveFiles {
+		console.WriteLn("  - %s", file.Name)
+	}
+	console.WriteLn("Hint: Redaction is a best effort, review its content (e.g. 'tar -xzf %s') before sharing it", filepath.Base(output))
+	return nil
+}
+
+// Gather the unredacted content of a support bundle, by path relative to its
+// root.
+//
+// Information that cannot be obtained is described in the bundle rather than
+// failing, as it is often what the bundle is needed for.
+func collectSupportFiles(
+	ctx context.Context,
+	project *files.ProjectEntry,
+	filestore *files.FileStore,
+	console *console.Console,
+	now time.Time,
+) []files.ArchiveFile {
+	engines, enginesErr := engine.NewSet(ctx, console, engine.SelectionAll)
+
+	archiveFiles := []files.ArchiveFile{
+		{Name: "system.txt", Data: supportSystemInfo(ctx, engines, enginesErr, now)},
+		{Name: "doctor.txt", Data: supportDoctorReport(ctx, filestore)},
+	}
+	debugLog, err := os.ReadFile(filestore.GetDebugLogPath())
+	if err != nil {
+		debugLog = fmt.Appendf(nil, "no log: %s\n", err)
+	} else {
+		debugLog = []byte(lastLines(string(debugLog), supportBundleDebugLogLines))
+	}
+	archiveFiles = append(archiveFiles, files.ArchiveFile{Name: "paul-envs.log", Data: debugLog})
+	if project == nil {
+		return archiveFiles
+	}
+
+	readFile := func(name string, path string) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			data = fmt.Appendf(nil, "cannot read %s: %s\n", path, err)
+		}
+		archiveFiles = append(archiveFiles, files.ArchiveFile{Name: "project/" + name, Data: data})
+	}
+	readFile("build.conf", project.BuildConfigPath)
+	readFile("run.conf", project.RuntimeConfigPath)
+
+	var compose []byte
+	if bundle, err := engine.ComposeBundle(*project); err != nil {
+		compose = fmt.Appendf(nil, "cannot generate the compose file: %s\n", err)
+	} else {
+		compose = bundle.Files["compose.yaml"]
+	}
+	archiveFiles = append(archiveFiles,
+		files.ArchiveFile{Name: "project/compose.yaml", Data: compose},
+		files.ArchiveFile{Name: "project/engine.txt", Data: supportProjectEngineState(ctx, project.ProjectName, engines)},
+	)
+
+	buildLog, err := os.ReadFile(filestore.GetProjectBuildLogPath(project.ProjectName))
+	if err != nil {
+		buildLog = fmt.Appendf(nil, "no build log: %s\n", err)
+	} else {
+		buildLog = []byte(lastLines(string(buildLog), supportBundleBuildLogLines))
+	}
+	archiveFiles = append(archiveFiles, files.ArchiveFile{Name: "project/build.log", Data: buildLog})
+	return archiveFiles
+}
+
+func supportSystemInfo(ctx context.Context, engines []engine.ContainerEngine, enginesErr error, now time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Collected on: %s\n", now.Format(time.RFC3339))
+	fmt.Fprintf(&b, "paul-envs version: %d.%d.%d\n",
+		versions.Version.Major, versions.Version.Minor, versions.Version.Patch)
+	fmt.Fprintf(&b, "OS/Architecture: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "Go version: %s\n", runtime.Version())
+	if enginesErr != nil {
+		fmt.Fprintf(&b, "Container engines: %s\n", enginesErr)
+		return b.Bytes()
+	}
+	for _, containerEngine := range engines {
+		info, err := containerEngine.Info(ctx)
+		if err != nil {
+			fmt.Fprintf(&b, "Container engine %s: %s\n", cleanEngineName(containerEngine), err)
+			continue
+		}
+		fmt.Fprintf(&b, "Container engine: %s %s\n", info.Name, info.Version)
+	}
+	return b.Bytes()
+}
+
+func supportDoctorReport(ctx context.Context, filestore *files.FileStore) []byte {
+	var b bytes.Buffer
+	for i, section := range runDoctorChecks(ctx, filestore) {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(section.title + "\n")
+		for _, diagnostic := range section.diagnostics {
+			line, fix := formatDiagnostic(diagnostic)
+			b.WriteString(line + "\n")
+			if fix != "" {
+				b.WriteString(fix + "\n")
+			}
+		}
+	}
+	return b.Bytes()
+}
+
+// Describe the image, containers and sidecars of a project known by each
+// engine.
+func supportProjectEngineState(ctx context.Context, projectName string, engines []engine.ContainerEngine) []byte {
+	var b bytes.Buffer
+	if len(engines) == 0 {
+		b.WriteString("no container engine available\n")
+	}
+	for i, containerEngine := range engines {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s\n", cleanEngineName(containerEngine))
+		image, err := containerEngine.GetImageInfo(ctx, projectName)
+		switch {
+		case err != nil:
+			fmt.Fprintf(&b, "  image: %s\n", err)
+		case image == nil:
+			b.WriteString("  image: not built\n")
+		default:
+			builtAt := "unknown"
+			if image.BuiltAt != nil {
+				builtAt = image.BuiltAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(&b, "  image: %s, built at %s, size %s\n", image.ImageName, builtAt, image.Size)
+		}
+		containers, err := containerEngine.ListContainers(ctx)
+		if err != nil {
+			fmt.Fprintf(&b, "  containers: %s\n", err)
+		}
+		for _, container := range containers {
+			if container.ProjectName == nil || *container.ProjectName != projectName {
+				continue
+			}
+			fmt.Fprintf(&b, "  container: %s, running: %t\n", derefOr(container.ContainerName, container.ContainerId), container.Running)
+		}
+		sidecars, err := containerEngine.ListSidecars(ctx)
+		if err != nil {
+			fmt.Fprintf(&b, "  sidecars: %s\n", err)
+		}
+		for _, sidecar := range sidecars {
+			if sidecar.ProjectName == projectName {
+				fmt.Fprintf(&b, "  sidecar: %s, running: %t\n", sidecar.ContainerName, sidecar.Running)
+			}
+		}
+	}
+	return b.Bytes()
+}
+
+func derefOr(value *string, fallback string) string {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
+
+// Returns the last `count` lines of `text`.
+func lastLines(text string, count int) string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= count {
+		return text
+	}
+	return fmt.Sprintf("(%d earlier lines omitted)\n", len(lines)-count) + strings.Join(lines[len(lines)-count:], "")
+}
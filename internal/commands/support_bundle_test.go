@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/engine"
+)
+
+func TestLastLines(t *testing.T) {
+	if got := lastLines("a\nb\n", 5); got != "a\nb\n" {
+		t.Fatalf("lastLines() = %q, want the whole text", got)
+	}
+	if got := lastLines("a\nb\nc\nd\n", 2); got != "(2 earlier lines omitted)\nc\nd\n" {
+		t.Fatalf("lastLines() = %q", got)
+	}
+	if got := lastLines("a\nb\nc", 1); got != "(2 earlier lines omitted)\nc" {
+		t.Fatalf("lastLines() = %q", got)
+	}
+}
+
+func TestSupportSystemInfo(t *testing.T) {
+	engines := []engine.ContainerEngine{&engine.FakeEngine{Name: "docker", Version: "27.1.0"}}
+	got := string(supportSystemInfo(context.Background(), engines, nil, time.Now()))
+	if !strings.Contains(got, "paul-envs version: ") || !strings.Contains(got, "Container engine: docker 27.1.0") {
+		t.Fatalf("supportSystemInfo() = %q", got)
+	}
+
+	got = string(supportSystemInfo(context.Background(), nil, engine.ErrEngineUnavailable, time.Now()))
+	if !strings.Contains(got, "Container engines: ") {
+		t.Fatalf("supportSystemInfo() = %q, want the engine error", got)
+	}
+}
@@ -0,0 +1,279 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/events"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Prefix of the instances `task` runs its commands in, followed by a random
+// identifier so tasks of a same project can run at the same time.
+const taskInstancePrefix = "task-"
+
+func Task(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	var engineSelection string
+	var autoRebuild bool
+	var artifactsDir string
+	var artifacts stringListFlag
+	var envVars stringListFlag
+	var envFiles stringListFlag
+	flagset := newCommandFlagSet("task", console)
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to use: docker or podman. Default: the last build engine for the project, otherwise auto-select.")
+	flagset.BoolVar(&autoRebuild, "auto-rebuild", false, "Build the project image first when it is missing or stale, instead of failing\nor using the stale one.")
+	flagset.Var(&artifacts, "artifact", "Absolute `path` of a file or directory of the container to copy to --artifacts-dir\nonce the command exited, even if it failed. This option can be repeated.")
+	flagset.StringVar(&artifactsDir, "artifacts-dir", ".", "Host `directory` artifacts are copied to.")
+	flagset.Var(&envVars, "e", "Shorthand for --env `KEY=VALUE`.")
+	flagset.Var(&envVars, "env", "Environment variable to set in the container, as `KEY=VALUE`, or KEY to take its value from the host. Set on top of the project's own variables and those of --env-file. This option can be repeated.")
+	flagset.Var(&envFiles, "env-file", "Env `file` of variables to set in the container, one KEY=VALUE per line. Set on top of the project's own variables. This option can be repeated.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs task [flags] <project-name> -- <command...>",
+			"Run a command in a new container of a project, without a terminal, then remove it: its output is streamed as it goes and paul-envs exits with the command's exit code, e.g. for CI jobs or reproducible builds. The project's services are started for it.\n\nFiles the command produced outside of the project's directory can be copied back to the host with --artifact.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) == 0 {
+		return utils.WithCategory(errors.New("expected the name of the project to run the task of"), errUsage)
+	}
+	name := args[0]
+	cmdArgs := args[1:]
+	if len(cmdArgs) > 0 && cmdArgs[0] == "--" {
+		cmdArgs = cmdArgs[1:]
+	}
+	if len(cmdArgs) == 0 {
+		return utils.WithCategory(errors.New("expected the command to run after the project name, e.g. 'paul-envs task myApp -- make test'"), errUsage)
+	}
+	for _, artifact := range artifacts {
+		if !path.IsAbs(artifact) {
+			return utils.WithCategory(fmt.Errorf("invalid --artifact '%s': expected an absolute path in the container", artifact), errUsage)
+		}
+	}
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+	if err := ensureProjectCompatible(name, filestore, console); err != nil {
+		return fmt.Errorf("cannot run a task of project '%s': %w", name, err)
+	}
+	runOptions, err := parseRunOptions(envVars, envFiles)
+	if err != nil {
+		return err
+	}
+
+	requestedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+	containerEngine, selectedEngine, err := newProjectEngine(ctx, name, requestedEngine, filestore, console)
+	if err != nil {
+		return err
+	}
+	if err := ensureProjectMachine(ctx, name, containerEngine, filestore, console); err != nil {
+		return err
+	}
+	project, err := filestore.GetProject(name)
+	if err != nil {
+		return fmt.Errorf("failed to obtain information on project '%s': %w", name, err)
+	}
+	if err := ensureTaskImage(ctx, project, autoRebuild, selectedEngine, containerEngine, filestore, console); err != nil {
+		return err
+	}
+
+	id, err := utils.GenerateUUIDv4()
+	if err != nil {
+		return fmt.Errorf("cannot name the container of the task: %w", err)
+	}
+	runOptions.Instance = engine.Instance{Name: taskInstancePrefix + id[:8]}
+	runOptions.Keep = true
+	// No terminal: its output is the command's, as is
+	runOptions.Stdin = strings.NewReader("")
+	runOptions.Stdout = os.Stdout
+	runOptions.Stderr = os.Stderr
+	return runProjectTask(ctx, project, cmdArgs, runOptions, artifacts, artifactsDir, containerEngine, filestore, console)
+}
+
+// Make sure the image of the project can run a task, building it first with
+// `autoRebuild` if it is missing or stale.
+func ensureTaskImage(
+	ctx context.Context,
+	project files.ProjectEntry,
+	autoRebuild bool,
+	selectedEngine engine.Selection,
+	containerEngine engine.ContainerEngine,
+	filestore *files.FileStore,
+	console *console.Console,
+) error {
+	name := project.ProjectName
+	unlock, err := lockProject(ctx, name, filestore, console)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	needsRebuild, reason, err := runRebuildDecision(ctx, name, filestore, containerEngine)
+	if err != nil {
+		console.Warn("Cannot check previous build metadata: %s", err)
+	}
+	if !needsRebuild {
+		hasBeenBuilt, err := containerEngine.HasBeenBuilt(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to get the status of the '%s' project: %w", name, err)
+		}
+		if hasBeenBuilt {
+			return nil
+		}
+		if !autoRebuild {
+			return fmt.Errorf("project '%s' has not been built yet\nHint: Use 'paul-envs build %s' first, or --auto-rebuild", name, name)
+		}
+	} else if !autoRebuild {
+		console.Warn("The '%s' project needs to be re-built (%s), running the task with its current image", name, reason)
+		return nil
+	}
+	if err := Build(ctx, buildArgsForEngine(name, selectedEngine), filestore, console); err != nil {
+		return fmt.Errorf("did not succeed to build project: %w", err)
+	}
+	return nil
+}
+
+// Run the command of a task in the kept container of `options`, copy its
+// artifacts, then remove it.
+func runProjectTask(
+	ctx context.Context,
+	project files.ProjectEntry,
+	cmdArgs []string,
+	options engine.RunOptions,
+	artifacts []string,
+	artifactsDir string,
+	containerEngine engine.ContainerEngine,
+	filestore *files.FileStore,
+	console *console.Console,
+) error {
+	name := project.ProjectName
+	runtimeCfg, err := config.LoadRuntimeConfig(project.RuntimeConfigPath)
+	if err != nil {
+		return fmt.Errorf("cannot run a task of project '%s': %w", name, err)
+	}
+	if err := prepareProjectRuntimeFiles(project, runtimeCfg, filestore); err != nil {
+		return err
+	}
+	if options.Secrets, err = resolveProjectSecrets(ctx, project, runtimeCfg, filestore); err != nil {
+		return fmt.Errorf("cannot run a task of project '%s': %w", name, err)
+	}
+	warnUnsupportedDirectives(runtimeCfg, console)
+	stopGitCredentials, err := serveGitCredentials(project, runtimeCfg)
+	if err != nil {
+		return err
+	}
+	defer stopGitCredentials()
+	stopHostCommands, err := serveHostCommands(project, runtimeCfg)
+	if err != nil {
+		return err
+	}
+	defer stopHostCommands()
+	if err := startProjectSidecars(ctx, project, runtimeCfg, nil, containerEngine, console); err != nil {
+		return err
+	}
+
+	console.Info("Running task '%s' of project '%s'...", strings.Join(cmdArgs, " "), name)
+	events.Emit(events.RunStart, name, nil)
+	runErr := containerEngine.RunContainer(ctx, project, cmdArgs, options)
+	events.Emit(events.RunEnd, name, runErr)
+	runErr = reportStartupFailures(project, runErr, console)
+
+	cleanupCtx := context.WithoutCancel(ctx)
+	container, err := findTaskContainer(cleanupCtx, name, options.Instance.Name, containerEngine)
+	if err != nil {
+		console.Warn("Could not find the container of the task: %s", err)
+	} else if container != nil {
+		if ctx.Err() == nil {
+			copyTaskArtifacts(ctx, *container, artifacts, artifactsDir, containerEngine, console)
+		}
+		if err := containerEngine.RemoveContainer(cleanupCtx, *container); err != nil {
+			console.Warn("Could not remove the container of the task: %s", err)
+		}
+	} else if len(artifacts) > 0 {
+		console.Warn("The container of the task is gone, its artifacts cannot be copied")
+	}
+	cleanUpProjectRun(cleanupCtx, name, containerEngine, console)
+
+	var exitErr *exec.ExitError
+	if errors.As(runErr, &exitErr) && exitErr.ExitCode() > 0 && ctx.Err() == nil {
+		return fmt.Errorf("task of project '%s' failed: %w", name, &commandExitError{exitCode: exitErr.ExitCode()})
+	}
+	return runErr
+}
+
+// Returns the container of the given task instance, `nil` if there is none.
+func findTaskContainer(
+	ctx context.Context,
+	projectName string,
+	instance string,
+	containerEngine engine.ContainerEngine,
+) (*engine.ContainerInfo, error) {
+	containers, err := containerEngine.ListContainers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list containers: %w", err)
+	}
+	for _, container := range engine.ProjectInstances(containers, projectName) {
+		if container.Instance == instance {
+			return &container, nil
+		}
+	}
+	return nil, nil
+}
+
+// Copy the given paths of the task's container into `artifactsDir`, under
+// their base name. Missing ones are reported but do not fail the task, whose
+// outcome is its command's.
+func copyTaskArtifacts(
+	ctx context.Context,
+	container engine.ContainerInfo,
+	artifacts []string,
+	artifactsDir string,
+	containerEngine engine.ContainerEngine,
+	console *console.Console,
+) {
+	if len(artifacts) == 0 {
+		return
+	}
+	if err := os.MkdirAll(artifactsDir, 0755); err != nil {
+		console.Warn("Could not create the artifacts directory: %s", err)
+		return
+	}
+	for _, artifact := range artifacts {
+		target := filepath.Join(artifactsDir, path.Base(artifact))
+		if err := containerEngine.CopyFrom(ctx, container, artifact, target); err != nil {
+			console.Warn("Could not copy artifact %s: %s", artifact, err)
+			continue
+		}
+		console.Success("Copied artifact %s to %s", artifact, target)
+	}
+}
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestTask_Usage(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	store, err := files.NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	var out bytes.Buffer
+	cons := console.New(context.Background(), strings.NewReader(""), &out, &out)
+	for _, args := range [][]string{
+		{},
+		{"app"},
+		{"app", "--"},
+		{"--artifact", "dist", "app", "--", "make"},
+	} {
+		if err := Task(context.Background(), args, store, cons); ExitCode(err) != ExitUsage {
+			t.Errorf("Task(%v) error = %v, want a usage error", args, err)
+		}
+	}
+	if err := Task(context.Background(), []string{"missing", "--", "make"}, store, cons); ExitCode(err) != ExitProjectNotFound {
+		t.Errorf("Task() of a missing project error = %v, want it not found", err)
+	}
+}
+
+func TestRunProjectTask(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	store, err := files.NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	if err := store.CreateProjectFiles(
+		"app",
+		testBuildTemplateData(),
+		files.RuntimeTemplateData{Version: "1.0.0", ProjectHostPath: t.TempDir()},
+	); err != nil {
+		t.Fatalf("CreateProjectFiles() error = %v", err)
+	}
+	project, err := store.GetProject("app")
+	if err != nil {
+		t.Fatalf("GetProject() error = %v", err)
+	}
+	app, containerName := "app", "paulenv-app..task-1"
+	task := engine.ContainerInfo{ProjectName: &app, ContainerName: &containerName, ContainerId: "1", Instance: "task-1"}
+	fake := &engine.FakeEngine{
+		Containers: []engine.ContainerInfo{task},
+		Errors:     map[string]error{"RunContainer": fmt.Errorf("run failed: %w", exec.Command("sh", "-c", "exit 3").Run())},
+	}
+	artifactsDir := t.TempDir()
+	options := engine.RunOptions{Instance: engine.Instance{Name: "task-1"}, Keep: true}
+	var out bytes.Buffer
+	cons := console.New(context.Background(), strings.NewReader(""), &out, &out)
+
+	err = runProjectTask(context.Background(), project, []string{"make", "test"}, options, []string{"/tmp/dist"}, artifactsDir, fake, store, cons)
+	if ExitCode(err) != 3 {
+		t.Fatalf("runProjectTask() error = %v, want the command's exit code 3", err)
+	}
+	copies := fake.CallsTo("CopyFrom")
+	if len(copies) != 1 || copies[0].Args[1] != "/tmp/dist" || copies[0].Args[2] != filepath.Join(artifactsDir, "dist") {
+		t.Fatalf("runProjectTask() copies = %+v, want /tmp/dist copied to the artifacts directory", copies)
+	}
+	removed := fake.CallsTo("RemoveContainer")
+	if len(removed) == 0 || removed[0].Args[0].(engine.ContainerInfo).ContainerId != "1" {
+		t.Fatalf("runProjectTask() did not remove the task's container: %+v", removed)
+	}
+}
@@ -0,0 +1,268 @@
+package commands
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/events"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/table"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Number of most recent builds the cache hit ratio of a project is computed
+// on, compared to that of its builds before them.
+const recentBuildsCount = 5
+
+func Times(args []string, filestore *files.FileStore, console *console.Console) error {
+	var wide bool
+	flagset := newCommandFlagSet("times", console)
+	flagset.BoolVar(&wide, "wide", false, "Never elide values, even if the table does not fit the terminal")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs times [flags] [project-name]",
+			"Summarize how long the builds, pulls and runs of all projects (or only the given one) took, slowest environments first: number of builds, their average and slowest durations, the share of build steps reused from the engine's cache in their last builds compared to earlier ones, and the total time spent building.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) > 1 {
+		return utils.WithCategory(errors.New("times takes at most one project name"), errUsage)
+	}
+
+	var names []string
+	if len(args) == 1 {
+		if err := validateProjectName(args[0]); err != nil {
+			return err
+		}
+		if !filestore.DoesProjectExist(args[0]) {
+			return projectNotFoundError(args[0])
+		}
+		names = []string{args[0]}
+	} else {
+		entries, err := filestore.GetAllProjects()
+		if err != nil {
+			return fmt.Errorf("could not list all projects: %w", err)
+		}
+		for _, entry := range entries {
+			names = append(names, entry.ProjectName)
+		}
+	}
+
+	var summaries []timingSummary
+	for _, name := range names {
+		timings, err := filestore.GetTimings(name)
+		if err != nil {
+			console.Warn("Could not read the timings of project '%s': %s", name, err)
+			continue
+		}
+		if len(timings) > 0 {
+			summaries = append(summaries, summarizeTimings(name, timings))
+		}
+	}
+	if len(summaries) == 0 {
+		console.WriteLn("  (no recorded build, pull or run)")
+		return nil
+	}
+	slices.SortStableFunc(summaries, func(a, b timingSummary) int {
+		return cmp.Compare(b.AverageBuild(), a.AverageBuild())
+	})
+	err := table.Render(console.Writer(), []string{
+		"PROJECT", "BUILDS", "AVERAGE BUILD", "SLOWEST BUILD", "CACHE HITS", "PULLS", "RUNS",
+	}, timingRows(summaries), table.Options{Width: table.TerminalWidth(console.Writer()), Wide: wide})
+	if err != nil {
+		return err
+	}
+	var total time.Duration
+	builds := 0
+	for _, summary := range summaries {
+		total += summary.BuildTime
+		builds += summary.Builds
+	}
+	console.WriteLn("")
+	console.WriteLn("Total time spent building: %s over %d build(s)", formatHistoryDuration(total), builds)
+	return nil
+}
+
+// Durations recorded for a project, summarized for the `times` command.
+type timingSummary struct {
+	ProjectName string
+	// Builds, failed ones included
+	Builds       int
+	FailedBuilds int
+	// Time spent in all builds, failed ones included
+	BuildTime time.Duration
+	// Slowest successful build
+	SlowestBuild time.Duration
+	// Durations of successful builds
+	successfulBuildTime time.Duration
+	// Cache hit ratio of the last builds with known steps, and of those before
+	// them. Negative if there are none.
+	RecentCacheHits  float64
+	EarlierCacheHits float64
+	Pulls            int
+	PullTime         time.Duration
+	Runs             int
+	RunTime          time.Duration
+}
+
+// Average duration of the successful builds of the project, `0` if there are
+// none.
+func (s timingSummary) AverageBuild() time.Duration {
+	successful := s.Builds - s.FailedBuilds
+	if successful == 0 {
+		return 0
+	}
+	return s.successfulBuildTime / time.Duration(successful)
+}
+
+// Summarize the timings of a project, given oldest first.
+func summarizeTimings(name string, timings []files.Timing) timingSummary {
+	summary := timingSummary{ProjectName: name}
+	var cacheStats []files.Timing
+	for _, timing := range timings {
+		switch timing.Kind {
+		case files.TimingBuild:
+			summary.Builds++
+			summary.BuildTime += timing.Duration
+			if timing.Failed {
+				summary.FailedBuilds++
+				continue
+			}
+			summary.successfulBuildTime += timing.Duration
+			summary.SlowestBuild = max(summary.SlowestBuild, timing.Duration)
+			if timing.Steps > 0 {
+				cacheStats = append(cacheStats, timing)
+			}
+		case files.TimingPull:
+			summary.Pulls++
+			summary.PullTime += timing.Duration
+		case files.TimingRun:
+			summary.Runs++
+			summary.RunTime += timing.Duration
+		}
+	}
+	split := max(0, len(cacheStats)-recentBuildsCount)
+	summary.RecentCacheHits = cacheHitRatio(cacheStats[split:])
+	summary.EarlierCacheHits = cacheHitRatio(cacheStats[:split])
+	return summary
+}
+
+// Share of the steps of those builds reused from the engine's cache, `-1` if
+// there are none.
+func cacheHitRatio(builds []files.Timing) float64 {
+	cached, steps := 0, 0
+	for _, build := range builds {
+		cached += build.CachedSteps
+		steps += build.Steps
+	}
+	if steps == 0 {
+		return -1
+	}
+	return float64(cached) / float64(steps)
+}
+
+func timingRows(summaries []timingSummary) []table.Row {
+	rows := make([]table.Row, 0, len(summaries))
+	for _, summary := range summaries {
+		builds := strconv.Itoa(summary.Builds)
+		if summary.FailedBuilds > 0 {
+			builds += fmt.Sprintf(" (%d failed)", summary.FailedBuilds)
+		}
+		average, slowest := "-", "-"
+		if summary.Builds > summary.FailedBuilds {
+			average = formatHistoryDuration(summary.AverageBuild())
+			slowest = formatHistoryDuration(summary.SlowestBuild)
+		}
+		cacheHits := "-"
+		if summary.RecentCacheHits >= 0 {
+			cacheHits = fmt.Sprintf("%.0f%%", summary.RecentCacheHits*100)
+			if summary.EarlierCacheHits >= 0 {
+				cacheHits += fmt.Sprintf(" (was %.0f%%)", summary.EarlierCacheHits*100)
+			}
+		}
+		rows = append(rows, table.Row{
+			{summary.ProjectName},
+			{builds},
+			{average},
+			{slowest},
+			{cacheHits},
+			{formatTimingCount(summary.Pulls, summary.PullTime)},
+			{formatTimingCount(summary.Runs, summary.RunTime)},
+		})
+	}
+	return rows
+}
+
+// e.g. "3 (12m4s)", "-" if `count` is `0`
+func formatTimingCount(count int, total time.Duration) string {
+	if count == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d (%s)", count, formatHistoryDuration(total))
+}
+
+// Record how long a pull or run of that project took, unless it was
+// interrupted.
+func recordProjectTiming(ctx context.Context, name string, kind files.TimingKind, start time.Time, err error, filestore *files.FileStore, console *console.Console) {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	timing := files.Timing{Kind: kind, At: time.Now(), Duration: time.Since(start), Failed: err != nil}
+	if err := filestore.RecordTiming(name, timing); err != nil {
+		console.Warn("Could not record the %s duration of project '%s': %s", kind, name, err)
+	}
+}
+
+// Record how long each build of a project takes, from its `BuildStart` and
+// `BuildEnd` events, and how many of its steps came from the engine's cache
+// according to its build log. Builds interrupted through `ctx` say nothing of
+// how long one takes and are not recorded.
+//
+// The returned function stops recording them.
+func RecordBuildTimings(ctx context.Context, filestore *files.FileStore, console *console.Console) func() {
+	var mu sync.Mutex
+	starts := map[string]time.Time{}
+	stopStarts := events.OnBuildStart(func(event events.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		starts[event.Project] = event.Time
+	})
+	stopEnds := events.OnBuildEnd(func(event events.Event) {
+		mu.Lock()
+		start, ok := starts[event.Project]
+		delete(starts, event.Project)
+		mu.Unlock()
+		if !ok || (event.Err != nil && ctx.Err() != nil) {
+			return
+		}
+		timing := files.Timing{Kind: files.TimingBuild, At: event.Time, Duration: event.Time.Sub(start), Failed: event.Err != nil}
+		if output, err := os.ReadFile(filestore.GetProjectBuildLogPath(event.Project)); err == nil {
+			timing.CachedSteps, timing.Steps = engine.BuildCacheStats(string(output))
+		}
+		if err := filestore.RecordTiming(event.Project, timing); err != nil {
+			console.Warn("Could not record the build duration of project '%s': %s", event.Project, err)
+		}
+	})
+	return func() {
+		stopStarts()
+		stopEnds()
+	}
+}
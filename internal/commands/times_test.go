@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/events"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/table"
+)
+
+func TestSummarizeTimings(t *testing.T) {
+	build := func(duration time.Duration, failed bool, cached, steps int) files.Timing {
+		return files.Timing{Kind: files.TimingBuild, Duration: duration, Failed: failed, CachedSteps: cached, Steps: steps}
+	}
+	timings := []files.Timing{
+		build(4*time.Minute, false, 0, 10),
+		build(2*time.Minute, false, 0, 0),
+		build(30*time.Second, true, 0, 0),
+		{Kind: files.TimingPull, Duration: 20 * time.Second},
+		build(time.Minute, false, 8, 10),
+		build(time.Minute, false, 9, 10),
+		build(time.Minute, false, 7, 10),
+		build(time.Minute, false, 8, 10),
+		build(time.Minute, false, 8, 10),
+		{Kind: files.TimingRun, Duration: time.Hour},
+		{Kind: files.TimingRun, Duration: 30 * time.Minute, Failed: true},
+	}
+	summary := summarizeTimings("app", timings)
+	if summary.Builds != 8 || summary.FailedBuilds != 1 {
+		t.Fatalf("summarizeTimings() counted %d builds, %d failed, want 8 and 1", summary.Builds, summary.FailedBuilds)
+	}
+	if summary.BuildTime != 11*time.Minute+30*time.Second || summary.SlowestBuild != 4*time.Minute {
+		t.Fatalf("summarizeTimings() = %s building, slowest %s", summary.BuildTime, summary.SlowestBuild)
+	}
+	if got := summary.AverageBuild(); got != 11*time.Minute/7 {
+		t.Fatalf("AverageBuild() = %s, want %s", got, 11*time.Minute/7)
+	}
+	if summary.RecentCacheHits != 0.8 || summary.EarlierCacheHits != 0 {
+		t.Fatalf("summarizeTimings() cache hits = %v (was %v), want 0.8 (was 0)", summary.RecentCacheHits, summary.EarlierCacheHits)
+	}
+
+	want := []table.Row{{
+		{"app"}, {"8 (1 failed)"}, {"1m34.3s"}, {"4m0s"}, {"80% (was 0%)"}, {"1 (20s)"}, {"2 (1h30m0s)"},
+	}}
+	if got := timingRows([]timingSummary{summary}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("timingRows() = %v, want %v", got, want)
+	}
+
+	pulled := summarizeTimings("pulled", []files.Timing{{Kind: files.TimingPull, Duration: time.Minute}})
+	want = []table.Row{{{"pulled"}, {"0"}, {"-"}, {"-"}, {"-"}, {"1 (1m0s)"}, {"-"}}}
+	if got := timingRows([]timingSummary{pulled}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("timingRows() without builds = %v, want %v", got, want)
+	}
+}
+
+func TestRecordBuildTimings(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	store, err := files.NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	var out bytes.Buffer
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stop := RecordBuildTimings(ctx, store, console.New(context.Background(), strings.NewReader(""), &out, &out))
+
+	events.Emit(events.BuildStart, "app", nil)
+	events.Emit(events.BuildEnd, "app", nil)
+	events.Emit(events.BuildStart, "app", nil)
+	events.Emit(events.BuildEnd, "app", errors.New("failed"))
+	// Without its start
+	events.Emit(events.BuildEnd, "other", nil)
+	// Interrupted
+	events.Emit(events.BuildStart, "app", nil)
+	cancel()
+	events.Emit(events.BuildEnd, "app", context.Canceled)
+	stop()
+	// Once stopped
+	events.Emit(events.BuildStart, "app", nil)
+	events.Emit(events.BuildEnd, "app", nil)
+
+	timings, err := store.GetTimings("app")
+	if err != nil {
+		t.Fatalf("GetTimings() error = %v", err)
+	}
+	if len(timings) != 2 || timings[0].Failed || !timings[1].Failed {
+		t.Fatalf("timings = %+v, want a successful then a failed build", timings)
+	}
+	if timings, _ := store.GetTimings("other"); len(timings) != 0 {
+		t.Errorf("timings of other = %+v, want none", timings)
+	}
+}
@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Tmp(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	var image string
+	var inject bool
+	var join string
+	var engineSelection string
+	flagset := newCommandFlagSet("tmp", console)
+	flagset.StringVar(&image, "image", "", "Image to run, e.g. fedora:41. Required unless joining.")
+	flagset.BoolVar(&inject, "inject", false, "Run it through paul-envs' entrypoint, injected at runtime,\nwhich applies the global dotfiles and is also run by --join")
+	flagset.StringVar(&join, "join", "", "Open a shell, or run the command, in the running throwaway environment of that name")
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to run it with: docker or podman.\nDefault: auto-select, preferring Podman.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs tmp (--image <image> | --join <name>) [command...] [flags]",
+			"Run an image as is in a throwaway environment, e.g. for a quick experiment on another distribution, without creating a project. It gets an auto-generated name, and its container, network and volume (mounted on /workspace) are all removed once it exits.\n\nWith --inject, a minimal entrypoint of paul-envs replaces the image's own, for images not built by it: it copies the global dotfiles in the home directory before starting the image's shell. It only needs a /bin/sh in the image.\n\nOther sessions can join a running throwaway environment with --join, going through its injected entrypoint if it has one. They exit along with the first one.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	switch {
+	case join != "" && (image != "" || inject):
+		return utils.WithCategory(errors.New("--join cannot be combined with --image or --inject, which only apply to new environments"), errUsage)
+	case join == "" && image == "":
+		return utils.WithCategory(errors.New("expected the image to run with --image, or the environment to join with --join"), errUsage)
+	}
+	selection, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return utils.WithCategory(err, errUsage)
+	}
+	containerEngine, err := engine.NewSelected(ctx, console, selection)
+	if err != nil {
+		return err
+	}
+	if join != "" {
+		console.Info("Joining throwaway environment '%s'.", join)
+		if err := containerEngine.JoinThrowaway(ctx, join, flagset.Args()); err != nil {
+			return fmt.Errorf("cannot join throwaway environment '%s': %w\nHint: Only running environments can be joined, with the name displayed when starting them", join, err)
+		}
+		return nil
+	}
+
+	var options engine.ThrowawayOptions
+	if inject {
+		if options, err = throwawayInjection(filestore); err != nil {
+			return err
+		}
+	}
+	name, err := newThrowawayName()
+	if err != nil {
+		return fmt.Errorf("failed to generate a name for the environment: %w", err)
+	}
+
+	console.Info("Running %s in throwaway environment '%s', removed once exited.", image, name)
+	console.WriteLn("Hint: Other sessions can join it with 'paul-envs tmp --join %s'", name)
+	return containerEngine.RunThrowaway(ctx, name, image, flagset.Args(), options)
+}
+
+// Options running a throwaway environment through the injected entrypoint of
+// paul-envs, applying the global dotfiles.
+func throwawayInjection(filestore *files.FileStore) (engine.ThrowawayOptions, error) {
+	entrypointPath, err := filestore.WriteInjectedEntrypoint()
+	if err != nil {
+		return engine.ThrowawayOptions{}, fmt.Errorf("cannot write the entrypoint to inject: %w", err)
+	}
+	options := engine.ThrowawayOptions{EntrypointPath: entrypointPath}
+	hasDotfiles, err := filestore.HasGlobalDotfilesTemplate()
+	if err != nil {
+		return engine.ThrowawayOptions{}, fmt.Errorf("cannot read the global dotfiles: %w", err)
+	}
+	if hasDotfiles {
+		options.DotfilesPath = filestore.GetGlobalDotfilesPath()
+	}
+	return options, nil
+}
+
+// Generate the name of a new throwaway environment, e.g. "tmp-3fa9c1d2".
+func newThrowawayName() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "tmp-" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestThrowawayInjection(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	store, err := files.NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+
+	options, err := throwawayInjection(store)
+	if err != nil {
+		t.Fatalf("throwawayInjection() error = %v", err)
+	}
+	if _, err := os.Stat(options.EntrypointPath); err != nil {
+		t.Fatalf("throwawayInjection() entrypoint %q not written: %v", options.EntrypointPath, err)
+	}
+	if options.DotfilesPath != "" {
+		t.Errorf("throwawayInjection() dotfiles = %q, want none without global dotfiles", options.DotfilesPath)
+	}
+
+	dotfiles := store.GetGlobalDotfilesPath()
+	if err := os.MkdirAll(dotfiles, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dotfiles, ".bashrc"), []byte("alias ll='ls -l'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if options, err = throwawayInjection(store); err != nil || options.DotfilesPath != dotfiles {
+		t.Errorf("throwawayInjection() = %+v, %v, want the global dotfiles %q", options, err, dotfiles)
+	}
+}
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func Trust(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	flagset := newCommandFlagSet("trust", console)
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs trust <list|revoke> [repository-path] [flags]",
+			"Manage in-repository `.paulenv/` definitions you trusted. 'list' shows them, 'revoke' forgets the one of the given repository (default: the current one), so it has to be trusted again on next use.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) == 0 {
+		return errors.New("expected a subcommand: list or revoke")
+	}
+
+	switch args[0] {
+	case "list", "ls":
+		if len(args) > 1 {
+			return errors.New("'trust list' does not take arguments")
+		}
+		trusted, err := filestore.GetTrustedDefinitions()
+		if err != nil {
+			return err
+		}
+		if len(trusted) == 0 {
+			console.WriteLn("  (no trusted definition)")
+			return nil
+		}
+		for _, t := range trusted {
+			console.Info("%s", t.RootDir)
+			console.WriteLn("  Fingerprint : %s", t.Fingerprint)
+			console.WriteLn("  Trusted at  : %s", t.TrustedAt.Format("2006-01-02 15:04:05"))
+		}
+		return nil
+	case "revoke", "rm":
+		if len(args) > 2 {
+			return errors.New("'trust revoke' takes at most one repository path")
+		}
+		rootDir, err := trustTargetRoot(args[1:])
+		if err != nil {
+			return err
+		}
+		revoked, err := filestore.RevokeDefinitionTrust(rootDir)
+		if err != nil {
+			return err
+		}
+		if !revoked {
+			return fmt.Errorf("no trusted definition for %s\nHint: Use 'paul-envs trust list' to see trusted definitions", rootDir)
+		}
+		console.Success("Revoked trust for %s", rootDir)
+		return nil
+	default:
+		return fmt.Errorf("invalid trust subcommand %q: expected list or revoke", args[0])
+	}
+}
+
+// Obtain the repository root targeted by `trust revoke`: the given path if
+// any, otherwise the repository containing the current directory.
+func trustTargetRoot(args []string) (string, error) {
+	if len(args) == 1 {
+		return filepath.Abs(args[0])
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("cannot obtain current directory: %w", err)
+	}
+	def, err := files.FindRepoDefinition(cwd)
+	if err != nil {
+		return "", err
+	}
+	if def == nil {
+		return "", errors.New("no `.paulenv/` definition found from the current directory\nHint: Give the repository path explicitly")
+	}
+	return def.RootDir, nil
+}
@@ -0,0 +1,226 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Try(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	var engineSelection string
+	var build bool
+	var keep bool
+	flagset := newCommandFlagSet("try", console)
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to try the project on: docker or podman. Required.")
+	flagset.BoolVar(&build, "build", false, "Build the project image on that engine, instead of copying the one of the\nproject's own engine.")
+	flagset.BoolVar(&keep, "keep", false, "Keep the project image on that engine once exited, to try it again without\ncopying or building it.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs try <project-name> --engine <docker|podman> [command...] [flags]",
+			"Run a project on another container engine than the one it was last built with, e.g. to debug engine-specific behaviors. Its image is copied from its own engine, or built if it cannot be, then removed when exiting. The project keeps being built and run with its own engine afterwards.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) == 0 {
+		return utils.WithCategory(errors.New("expected the name of the project to try"), errUsage)
+	}
+	if engineSelection == "" {
+		return utils.WithCategory(errors.New("expected the engine to try the project on with --engine"), errUsage)
+	}
+	trySelection, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return utils.WithCategory(err, errUsage)
+	}
+	name := args[0]
+	cmdArgs := args[1:]
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+	if err := ensureProjectCompatible(name, filestore, console); err != nil {
+		return fmt.Errorf("cannot try project '%s': %w", name, err)
+	}
+
+	// Not through `resolveProjectEngineSelection`, the project's own engine is
+	// only a source here
+	ownSelection := engine.SelectionAuto
+	if lastBuildEngine, err := filestore.GetBuildEngineSelection(name); err == nil {
+		ownSelection, _ = parseCommandEngineSelection(lastBuildEngine)
+	} else if !os.IsNotExist(err) {
+		console.Warn("Could not determine the last build engine for '%s': %s", name, err)
+	}
+	if ownSelection == trySelection {
+		return fmt.Errorf("project '%s' is already built with %s\nHint: Run it with 'paul-envs run %s'", name, trySelection, name)
+	}
+
+	tryEngine, err := engine.NewSelected(ctx, console, trySelection)
+	if err != nil {
+		return err
+	}
+	if err := ensureNoNameCollisions(ctx, name, tryEngine, console); err != nil {
+		return err
+	}
+	project, err := filestore.GetProject(name)
+	if err != nil {
+		return fmt.Errorf("failed to obtain information on project '%s': %w", name, err)
+	}
+
+	materialized, err := materializeProjectImage(ctx, project, tryEngine, ownSelection, build, filestore, console)
+	if err != nil {
+		return err
+	}
+	if materialized && !keep {
+		defer func() {
+			// Also removed after an interruption
+			cleanupCtx := context.WithoutCancel(ctx)
+			image, err := tryEngine.GetImageInfo(cleanupCtx, name)
+			if err == nil && image != nil {
+				err = tryEngine.RemoveImage(cleanupCtx, *image)
+			}
+			if err != nil {
+				console.Warn("Could not remove the image of project '%s' from %s: %s", name, trySelection, err)
+			}
+		}()
+	}
+
+	runtimeCfg, err := config.LoadRuntimeConfig(project.RuntimeConfigPath)
+	if err != nil {
+		return fmt.Errorf("cannot try project '%s': %w", name, err)
+	}
+	console.Info("Trying project '%s' on %s, its own engine is left untouched.", name, trySelection)
+	return runOrJoinProject(ctx, project, runtimeCfg, cmdArgs, engine.RunOptions{}, tryEngine, len(cmdArgs) == 0, "", filestore, console)
+}
+
+// Make the image of that project available on `tryEngine`: copied from its
+// own engine if it has one, built otherwise or if `build` is set. An image
+// already there is reused unless `build` is set.
+//
+// Unlike `build`, nothing is recorded about it, so the project's own engine
+// stays the one of its last build.
+//
+// Returns `true` if the image has been copied or built.
+func materializeProjectImage(
+	ctx context.Context,
+	project files.ProjectEntry,
+	tryEngine engine.ContainerEngine,
+	ownSelection engine.Selection,
+	build bool,
+	filestore *files.FileStore,
+	console *console.Console,
+) (bool, error) {
+	name := project.ProjectName
+	unlock, err := lockProject(ctx, name, filestore, console)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	if !build {
+		hasImage, err := tryEngine.HasBeenBuilt(ctx, name)
+		if err != nil {
+			return false, fmt.Errorf("failed to get the status of the '%s' project: %w", name, err)
+		}
+		if hasImage {
+			console.Info("Using the image of project '%s' already on that engine, use --build to rebuild it.", name)
+			return false, nil
+		}
+		if ownSelection != engine.SelectionAuto {
+			err := copyProjectImageFrom(ctx, name, ownSelection, tryEngine, console)
+			if err == nil {
+				return true, nil
+			}
+			console.Warn("Could not copy the image of project '%s' from %s: %s", name, ownSelection, err)
+		}
+	}
+
+	if err = filestore.RefreshBaseFiles(); err != nil {
+		return false, fmt.Errorf("cannot build: Failed to refresh base build files: %w", err)
+	}
+	if err := ensureSharedCacheVolumeIsCreated(ctx, tryEngine); err != nil {
+		return false, err
+	}
+	buildOptions := engine.BuildOptions{Heartbeat: engine.DefaultBuildHeartbeat, StallThreshold: engine.DefaultBuildStallThreshold}
+	if globalConfig, err := filestore.LoadGlobalConfig(); err == nil {
+		applyGlobalBuildConfig(&buildOptions, globalConfig)
+	}
+	engineInfo, _ := tryEngine.Info(ctx)
+	if _, err := ensureBaseImageIsBuilt(ctx, tryEngine, engineInfo.Name, false, buildOptions, filestore, console); err != nil {
+		return false, err
+	}
+	if err := ensureProjectDockerfileTrusted(project, filestore, console); err != nil {
+		return false, err
+	}
+	if err := filestore.RefreshProjectDockerfile(name); err != nil {
+		return false, fmt.Errorf("cannot build: Failed to write the Dockerfile of project '%s': %w", name, err)
+	}
+	console.Info("Building project '%s'...", name)
+	if err := tryEngine.BuildImage(ctx, project, buildOptions); err != nil {
+		return false, utils.WithCategory(err, errBuildFailed)
+	}
+	return true, nil
+}
+
+// Copy the image of that project from the engine selected by `from`, if it
+// has one.
+func copyProjectImageFrom(
+	ctx context.Context,
+	projectName string,
+	from engine.Selection,
+	to engine.ContainerEngine,
+	console *console.Console,
+) error {
+	fromEngine, err := engine.NewSelected(ctx, console, from)
+	if err != nil {
+		return err
+	}
+	hasImage, err := fromEngine.HasBeenBuilt(ctx, projectName)
+	if err != nil {
+		return err
+	} else if !hasImage {
+		return errors.New("it is not built there")
+	}
+	console.Info("Copying the image of project '%s' from %s...", projectName, from)
+
+	exportCtx, cancelExport := context.WithCancel(ctx)
+	defer cancelExport()
+	reader, writer := io.Pipe()
+	exportErr := make(chan error, 1)
+	go func() {
+		err := fromEngine.ExportImage(exportCtx, projectName, writer)
+		writer.CloseWithError(err)
+		exportErr <- err
+	}()
+	if err := to.ImportImage(ctx, projectName, reader); err != nil {
+		// The export may be blocked writing what is not read anymore
+		cancelExport()
+		reader.Close()
+		<-exportErr
+		return err
+	}
+	return <-exportErr
+}
@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+)
+
+func TestTryRequiresProjectAndEngine(t *testing.T) {
+	var out bytes.Buffer
+	cons := console.New(context.Background(), strings.NewReader(""), &out, &out)
+
+	for _, args := range [][]string{
+		{},
+		{"alpha"},
+		{"--engine", "containerd", "alpha"},
+	} {
+		if err := Try(context.Background(), args, nil, cons); ExitCode(err) != ExitUsage {
+			t.Errorf("Try(%q) exit code = %d, want %d (error: %v)", args, ExitCode(err), ExitUsage, err)
+		}
+	}
+}
+
+func TestTryHelp(t *testing.T) {
+	var out bytes.Buffer
+	cons := console.New(context.Background(), strings.NewReader(""), &out, &out)
+
+	if err := Try(context.Background(), []string{"--help"}, nil, cons); err != nil {
+		t.Fatalf("Try(--help) error = %v", err)
+	}
+	for _, fragment := range []string{
+		"Usage: paul-envs try <project-name> --engine <docker|podman> [command...] [flags]",
+		"--build",
+		"--keep",
+	} {
+		if !strings.Contains(out.String(), fragment) {
+			t.Fatalf("expected help output to contain %q, got:\n%s", fragment, out.String())
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+	"golang.org/x/term"
+)
+
+type tuiKey int
+
+const (
+	tuiKeyUnknown tuiKey = iota
+	tuiKeyUp
+	tuiKeyDown
+	tuiKeyBuild
+	tuiKeyRun
+	tuiKeyStop
+	tuiKeyRemove
+	tuiKeyRefresh
+	tuiKeyQuit
+)
+
+func Tui(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	flagset := newCommandFlagSet("tui", console)
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs tui [flags]",
+			"Start a dashboard listing all projects with their build status, running containers, image age and disk usage, from which they can be built, run, joined, stopped and removed.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	stdinFd := int(os.Stdin.Fd())
+	if console.IsNonInteractive() {
+		return utils.WithCategory(errors.New("the tui command cannot run in non-interactive mode\nHint: Use 'paul-envs list' instead"), errNonInteractive)
+	}
+	if !term.IsTerminal(stdinFd) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return errors.New("the tui command needs an interactive terminal\nHint: Use 'paul-envs list' instead")
+	}
+
+	selected := 0
+	message := ""
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		overviews, err := collectProjectOverviews(ctx, filestore, console)
+		if err != nil {
+			return err
+		}
+		if selected >= len(overviews) {
+			selected = max(len(overviews)-1, 0)
+		}
+		renderDashboard(console.Writer(), overviews, selected, message, time.Now())
+		message = ""
+
+		key, err := readTuiKey(stdinFd)
+		if err != nil {
+			return err
+		}
+
+		switch key {
+		case tuiKeyQuit:
+			return nil
+		case tuiKeyUp:
+			if selected > 0 {
+				selected--
+			}
+			continue
+		case tuiKeyDown:
+			if selected < len(overviews)-1 {
+				selected++
+			}
+			continue
+		case tuiKeyRefresh, tuiKeyUnknown:
+			continue
+		}
+
+		if len(overviews) == 0 {
+			message = "No project found. Create one with 'paul-envs create <path>'."
+			continue
+		}
+		current := overviews[selected]
+		name := current.Entry.ProjectName
+
+		var actionErr error
+		clearScreen(console.Writer())
+		switch key {
+		case tuiKeyBuild:
+			actionErr = Build(ctx, []string{name}, filestore, console)
+		case tuiKeyRun:
+			actionErr = Run(ctx, []string{name}, filestore, console)
+		case tuiKeyRemove:
+			actionErr = Remove(ctx, []string{name}, filestore, console)
+		case tuiKeyStop:
+			running := current.RunningContainers()
+			if len(running) == 0 {
+				message = fmt.Sprintf("No running container for project '%s'.", name)
+				continue
+			}
+			if current.containerEngine == nil {
+				// Only known from the cached state of projects
+				message = "Container engines are unreachable, cannot stop containers."
+				continue
+			}
+			for _, container := range running {
+				if err := current.containerEngine.StopContainer(ctx, container); err != nil {
+					actionErr = err
+				}
+			}
+			if actionErr == nil {
+				message = fmt.Sprintf("Stopped containers of project '%s'.", name)
+				continue
+			}
+		}
+
+		if errors.Is(actionErr, context.Canceled) {
+			return actionErr
+		}
+		if actionErr != nil {
+			console.Error("Command failed: %v", actionErr)
+		}
+		console.WriteLn("")
+		console.WriteLn("Press any key to return to the dashboard...")
+		if _, err := readTuiKey(stdinFd); err != nil {
+			return err
+		}
+	}
+}
+
+// Read a single key press from the terminal whose file descriptor is given,
+// by temporarily putting it into raw mode.
+func readTuiKey(fd int) (tuiKey, error) {
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return tuiKeyUnknown, fmt.Errorf("could not read from terminal: %w", err)
+	}
+	defer term.Restore(fd, oldState)
+
+	buf := make([]byte, 8)
+	n, err := os.Stdin.Read(buf)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return tuiKeyQuit, nil
+		}
+		return tuiKeyUnknown, err
+	}
+	return decodeTuiKey(buf[:n]), nil
+}
+
+func decodeTuiKey(input []byte) tuiKey {
+	switch string(input) {
+	case "\x1b[A", "\x1bOA", "k":
+		return tuiKeyUp
+	case "\x1b[B", "\x1bOB", "j":
+		return tuiKeyDown
+	case "b":
+		return tuiKeyBuild
+	case "r", "\r", "\n":
+		return tuiKeyRun
+	case "s":
+		return tuiKeyStop
+	case "d":
+		return tuiKeyRemove
+	case "g":
+		return tuiKeyRefresh
+	case "q", "\x1b", "\x03", "\x04":
+		return tuiKeyQuit
+	default:
+		return tuiKeyUnknown
+	}
+}
+
+func clearScreen(w io.Writer) {
+	fmt.Fprint(w, "\033[H\033[2J")
+}
+
+func renderDashboard(w io.Writer, overviews []projectOverview, selected int, message string, now time.Time) {
+	clearScreen(w)
+	fmt.Fprintln(w, "paul-envs dashboard")
+	fmt.Fprintln(w, "")
+
+	if len(overviews) == 0 {
+		fmt.Fprintln(w, "  (no project found)")
+	} else {
+		rows := [][]string{{"PROJECT", "BUILT", "ENGINE", "IMAGE AGE", "SIZE", "CONTAINERS"}}
+		for _, overview := range overviews {
+			rows = append(rows, dashboardRow(overview, now))
+		}
+		widths := make([]int, len(rows[0]))
+		for _, row := range rows {
+			for i, cell := range row {
+				widths[i] = max(widths[i], len(cell))
+			}
+		}
+		for i, row := range rows {
+			prefix := "  "
+			if i-1 == selected {
+				prefix = "> "
+			}
+			cells := make([]string, len(row))
+			for j, cell := range row {
+				cells[j] = fmt.Sprintf("%-*s", widths[j], cell)
+			}
+			line := prefix + strings.TrimRight(strings.Join(cells, "  "), " ")
+			if i-1 == selected {
+				line = "\033[7m" + line + "\033[0m"
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+
+	fmt.Fprintln(w, "")
+	if message != "" {
+		fmt.Fprintln(w, message)
+		fmt.Fprintln(w, "")
+	}
+	fmt.Fprintln(w, "[↑/k ↓/j] select  [r/enter] run or join  [b] build  [s] stop  [d] remove  [g] refresh  [q] quit")
+}
+
+func dashboardRow(overview projectOverview, now time.Time) []string {
+	built := "no"
+	age := "-"
+	size := "-"
+	if overview.IsBuilt() {
+		built = "yes"
+		age = formatAge(overview.Image.BuiltAt, now)
+		if overview.Image.Size != "" {
+			size = overview.Image.Size
+		}
+	}
+	engineName := overview.EngineName
+	if engineName == "" {
+		engineName = "-"
+	}
+	containers := "-"
+	if len(overview.Containers) > 0 {
+		containers = fmt.Sprintf("%d running / %d", len(overview.RunningContainers()), len(overview.Containers))
+	}
+	return []string{overview.Entry.ProjectName, built, engineName, age, size, containers}
+}
@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestDecodeTuiKey(t *testing.T) {
+	tests := []struct {
+		input string
+		want  tuiKey
+	}{
+		{"\x1b[A", tuiKeyUp},
+		{"k", tuiKeyUp},
+		{"\x1b[B", tuiKeyDown},
+		{"j", tuiKeyDown},
+		{"b", tuiKeyBuild},
+		{"\r", tuiKeyRun},
+		{"s", tuiKeyStop},
+		{"d", tuiKeyRemove},
+		{"\x03", tuiKeyQuit},
+		{"q", tuiKeyQuit},
+		{"z", tuiKeyUnknown},
+	}
+	for _, tt := range tests {
+		if got := decodeTuiKey([]byte(tt.input)); got != tt.want {
+			t.Fatalf("decodeTuiKey(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRenderDashboard(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	builtAt := now.Add(-72 * time.Hour)
+	running := "app"
+	overviews := []projectOverview{
+		{
+			Entry:      files.ProjectEntry{ProjectName: "app"},
+			EngineName: "podman",
+			Image:      &engine.ImageInfo{ImageName: "paulenv:app", BuiltAt: &builtAt, Size: "1.2GB"},
+			Containers: []engine.ContainerInfo{{ProjectName: &running, Running: true}},
+		},
+		{Entry: files.ProjectEntry{ProjectName: "other"}},
+	}
+
+	var out strings.Builder
+	renderDashboard(&out, overviews, 1, "", now)
+	got := out.String()
+
+	for _, fragment := range []string{"app", "podman", "3d", "1.2GB", "1 running / 1", "> other"} {
+		if !strings.Contains(got, fragment) {
+			t.Fatalf("expected dashboard to contain %q, got:\n%s", fragment, got)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Update(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var engineSelection string
+	flagset := newCommandFlagSet("update", console)
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to use: docker or podman.\nDefault: auto-select, preferring Podman.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs update [project-name] [flags]",
+			"Move the distribution image the shared base image is built from (e.g. ubuntu:24.04) to the image its tag now points to, and rebuild that base image on it. Otherwise, builds keep using the image it resolved to when first built, so environments do not change without notice.\n\nWith a project name, that project is then rebuilt on the new base image.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) > 1 {
+		return utils.WithCategory(errors.New("expected at most one project name"), errUsage)
+	}
+	name := ""
+	if len(args) == 1 {
+		name = args[0]
+		if err := validateProjectName(name); err != nil {
+			return err
+		}
+		if !filestore.DoesProjectExist(name) {
+			return projectNotFoundError(name)
+		}
+	}
+
+	buildOptions := engine.BuildOptions{Heartbeat: engine.DefaultBuildHeartbeat, StallThreshold: engine.DefaultBuildStallThreshold}
+	// Errors have already been reported when starting
+	if globalConfig, err := filestore.LoadGlobalConfig(); err == nil {
+		applyGlobalBuildConfig(&buildOptions, globalConfig)
+	}
+	selectedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+	containerEngine, err := engine.NewSelected(ctx, console, selectedEngine)
+	if err != nil {
+		return err
+	}
+	if err := engine.EnsurePodmanMachine(ctx, containerEngine, engine.MachineNeeds{}, console); err != nil {
+		return err
+	}
+	if err = filestore.RefreshBaseFiles(); err != nil {
+		return fmt.Errorf("cannot update: Failed to refresh base build files: %w", err)
+	}
+	engineInfo, err := containerEngine.Info(ctx)
+	if err != nil {
+		console.Warn("Could not obtain container engine information: %s", err)
+	}
+
+	distributionImage := distributionImageOf(buildOptions)
+	previousDigest, err := filestore.GetDistributionPin(distributionImage)
+	if err != nil {
+		return err
+	}
+	console.Info("Pulling the image %s now points to...", distributionImage)
+	digest, err := containerEngine.ImageDigest(ctx, distributionImage, true)
+	if err != nil {
+		return err
+	}
+	if digest == "" {
+		return fmt.Errorf("%s has no registry digest it could be pinned to", distributionImage)
+	}
+	if digest == previousDigest {
+		console.Success("%s is already pinned to its latest image (%s)", distributionImage, digest)
+		return nil
+	}
+
+	if err := filestore.SetDistributionPin(distributionImage, digest); err != nil {
+		return err
+	}
+	if _, err := ensureBaseImageIsBuilt(ctx, containerEngine, engineInfo.Name, true, buildOptions, filestore, console); err != nil {
+		// Builds keep using the previous image until an update succeeds
+		if pinErr := filestore.SetDistributionPin(distributionImage, previousDigest); pinErr != nil {
+			console.Warn("Could not restore the previous pin of %s: %s", distributionImage, pinErr)
+		}
+		return err
+	}
+	if previousDigest != "" {
+		console.Success("Moved %s from %s to %s", distributionImage, previousDigest, digest)
+	} else {
+		console.Success("Pinned %s to %s", distributionImage, digest)
+	}
+
+	if name != "" {
+		if err := Build(ctx, append(engineArgs(engineSelection), name), filestore, console); err != nil {
+			return err
+		}
+	}
+	reportProjectsOnOutdatedBase(engineInfo.Name, filestore, console)
+	return nil
+}
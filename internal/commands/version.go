@@ -5,6 +5,7 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"strings"
 
 	versions "github.com/peaberberian/paul-envs/internal"
 	"github.com/peaberberian/paul-envs/internal/console"
@@ -46,5 +47,26 @@ func Version(ctx context.Context, args []string, console *console.Console) error
 	}
 	console.WriteLn("Container engine: %s", info.Name)
 	console.WriteLn("Container engine version: %s", info.Version)
+	if provider, err := engine.DetectComposeProvider(ctx, info.Name); err != nil {
+		console.WriteLn("Compose: none found")
+	} else if provider.IsFallback() {
+		console.WriteLn("Compose: %s ('%s' is not available)", provider.Command, provider.Preferred)
+	} else {
+		console.WriteLn("Compose: %s", provider.Command)
+	}
+	var supported, unsupported []string
+	for _, capability := range containerEngine.Capabilities(ctx).List() {
+		if capability.Supported {
+			supported = append(supported, capability.Name)
+		} else {
+			unsupported = append(unsupported, capability.Name)
+		}
+	}
+	if len(supported) > 0 {
+		console.WriteLn("Supported: %s", strings.Join(supported, ", "))
+	}
+	if len(unsupported) > 0 {
+		console.WriteLn("Unsupported: %s", strings.Join(unsupported, ", "))
+	}
 	return nil
 }
@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+func Volume(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var engineSelection string
+	flagset := newCommandFlagSet("volume", console)
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to query: docker or podman.\nDefault: the one the project was built with.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs volume inspect <volume-name|project-name> [flags]",
+			"Show where the data of a volume is stored, its driver, creation time and size. Given a project name, its own volume (keeping e.g. its shell history and tools' data) is shown.\n\nWith Docker Desktop or a Podman machine, the storage location is inside the engine's virtual machine.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if len(args) == 0 || args[0] != "inspect" {
+		return utils.WithCategory(errors.New("expected a subcommand: inspect"), errUsage)
+	}
+	if len(args) != 2 {
+		return utils.WithCategory(errors.New("'volume inspect' takes the name of a volume or project"), errUsage)
+	}
+	requestedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+
+	volumeName := args[1]
+	var containerEngine engine.ContainerEngine
+	if !strings.HasPrefix(volumeName, "paulenv-") && filestore.DoesProjectExist(volumeName) {
+		containerEngine, _, err = newProjectEngine(ctx, volumeName, requestedEngine, filestore, console)
+		volumeName = engine.ProjectLocalVolumeName(volumeName)
+	} else {
+		containerEngine, err = engine.NewSelected(ctx, console, requestedEngine)
+	}
+	if err != nil {
+		return err
+	}
+	volume, err := containerEngine.InspectVolume(ctx, volumeName)
+	if err != nil {
+		return err
+	}
+	lines := volumeInfoLines(volume, time.Now())
+	console.Info("%s", lines[0])
+	for _, line := range lines[1:] {
+		console.WriteLn("%s", line)
+	}
+	return nil
+}
+
+// Lines describing a volume for the `volume inspect` command.
+func volumeInfoLines(volume engine.VolumeInfo, now time.Time) []string {
+	lines := []string{
+		volume.VolumeName,
+		"  Driver      : " + orUnknown(volume.Driver),
+		"  Mountpoint  : " + orUnknown(volume.Mountpoint),
+	}
+	if projectName, ok := projectNameFromLocalVolume(volume.VolumeName); ok {
+		lines = append(lines, "  Project     : "+projectName)
+	}
+	created := "unknown"
+	if volume.CreatedAt != nil {
+		created = volume.CreatedAt.Local().Format(time.DateTime) + " (" + formatImageAge(volume.CreatedAt, now) + ")"
+	}
+	lines = append(lines, "  Created     : "+created)
+	size := "unknown"
+	if volume.Size != nil {
+		size = utils.FormatSize(*volume.Size)
+	}
+	return append(lines, "  Size        : "+size)
+}
+
+func orUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/engine"
+)
+
+func TestVolumeInfoLines(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+	createdAt := now.Add(-72 * time.Hour)
+	size := int64(25 * 1000 * 1000)
+	got := volumeInfoLines(engine.VolumeInfo{
+		VolumeName: "paulenv-demo-local",
+		Driver:     "local",
+		Mountpoint: "/var/lib/docker/volumes/paulenv-demo-local/_data",
+		CreatedAt:  &createdAt,
+		Size:       &size,
+	}, now)
+	want := []string{
+		"paulenv-demo-local",
+		"  Driver      : local",
+		"  Mountpoint  : /var/lib/docker/volumes/paulenv-demo-local/_data",
+		"  Project     : demo",
+		"  Created     : 2026-03-07 12:00:00 (3d ago)",
+		"  Size        : 25MB",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("volumeInfoLines() = %q, want %q", got, want)
+	}
+
+	got = volumeInfoLines(engine.VolumeInfo{VolumeName: "paulenv-shared-cache"}, now)
+	want = []string{
+		"paulenv-shared-cache",
+		"  Driver      : unknown",
+		"  Mountpoint  : unknown",
+		"  Created     : unknown",
+		"  Size        : unknown",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("volumeInfoLines() = %q, want %q", got, want)
+	}
+}
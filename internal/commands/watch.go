@@ -0,0 +1,229 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/engine"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// How long to wait for a stopped container to be removed before starting a
+// new one.
+const restartRemovalTimeout = 10 * time.Second
+
+func Watch(ctx context.Context, args []string, filestore *files.FileStore, console *console.Console) error {
+	var restart bool
+	var interval time.Duration
+	var engineSelection string
+	flagset := newCommandFlagSet("watch", console)
+	flagset.BoolVar(&restart, "restart", false, "Also restart the project's running container when its inputs change.\nThe new container is started in the background: 'paul-envs run' joins it.")
+	flagset.DurationVar(&interval, "interval", 2*time.Second, "How often to check for changes.\nDefault: 2s")
+	flagset.StringVar(&engineSelection, "engine", "", "Container engine to use: docker or podman.\nDefault: the one used to build the project.")
+	flagset.Usage = func() {
+		writeCommandUsage(
+			console,
+			flagset,
+			"paul-envs watch [project-name] [flags]",
+			"Watch a project's configuration files and dotfiles, rebuilding its image when its build.conf changes, until interrupted.",
+		)
+	}
+	if err := parseCommandFlags(flagset, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flagset.Args()
+	if interval <= 0 {
+		return fmt.Errorf("invalid --interval %s: must be positive", interval)
+	}
+
+	name, err := getProjectName(args, filestore, console, "watch")
+	if err != nil {
+		return err
+	}
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if !filestore.DoesProjectExist(name) {
+		return projectNotFoundError(name)
+	}
+	if err := ensureProjectCompatible(name, filestore, console); err != nil {
+		return fmt.Errorf("cannot watch project '%s': %w", name, err)
+	}
+	project, err := filestore.GetProject(name)
+	if err != nil {
+		return fmt.Errorf("failed to obtain information on project '%s': %w", name, err)
+	}
+	requestedEngine, err := parseCommandEngineSelection(engineSelection)
+	if err != nil {
+		return err
+	}
+	containerEngine, selectedEngine, err := newProjectEngine(ctx, name, requestedEngine, filestore, console)
+	if err != nil {
+		return err
+	}
+
+	previous := snapshotInputs(watchedInputs(project, console))
+	console.Info("Watching project '%s' for changes, press Ctrl+C to stop.", name)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			console.WriteLn("")
+			console.Info("Stopped watching project '%s'.", name)
+			return nil
+		case <-ticker.C:
+		}
+
+		current := snapshotInputs(watchedInputs(project, console))
+		changed := changedInputs(previous, current)
+		previous = current
+		if len(changed) == 0 {
+			continue
+		}
+		for _, path := range changed {
+			console.Info("Changed: %s", path)
+		}
+
+		if slices.Contains(changed, project.BuildConfigPath) {
+			if err := Build(ctx, buildArgsForEngine(name, selectedEngine), filestore, console); err != nil {
+				if ctx.Err() != nil {
+					continue
+				}
+				console.Error("Error: %v", err)
+				console.Info("Still watching project '%s', fix its configuration to trigger a new build.", name)
+				continue
+			}
+		}
+		if restart {
+			if err := restartProjectContainer(ctx, project, containerEngine, filestore, console); err != nil && ctx.Err() == nil {
+				console.Error("Error: %v", err)
+			}
+		} else if !slices.Contains(changed, project.BuildConfigPath) {
+			console.Info("Those changes apply to new containers of project '%s'.", name)
+		}
+	}
+}
+
+// Files and directories whose changes affect the given project.
+func watchedInputs(project files.ProjectEntry, console *console.Console) []string {
+	inputs := []string{project.BuildConfigPath, project.RuntimeConfigPath}
+	dotfilesDir, err := engine.ProjectDotfilesDir(project)
+	if err != nil {
+		// Reported when building or running
+		console.Warn("Could not resolve the dotfiles directory to watch: %s", err)
+	} else if dotfilesDir != "" {
+		inputs = append(inputs, dotfilesDir)
+	}
+	return inputs
+}
+
+type inputStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Modification time and size of all files found under the given paths.
+// Missing paths are ignored, so their creation is seen as a change.
+func snapshotInputs(paths []string) map[string]inputStamp {
+	snapshot := make(map[string]inputStamp)
+	for _, root := range paths {
+		_ = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			snapshot[path] = inputStamp{modTime: info.ModTime(), size: info.Size()}
+			return nil
+		})
+	}
+	return snapshot
+}
+
+// Sorted paths of files added, removed or modified between two snapshots.
+func changedInputs(before map[string]inputStamp, after map[string]inputStamp) []string {
+	var changed []string
+	for path, stamp := range after {
+		if prev, ok := before[path]; !ok || !prev.modTime.Equal(stamp.modTime) || prev.size != stamp.size {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// Stop the running container of that project, if any, and start a new one in
+// the background.
+func restartProjectContainer(
+	ctx context.Context,
+	project files.ProjectEntry,
+	containerEngine engine.ContainerEngine,
+	filestore *files.FileStore,
+	console *console.Console,
+) error {
+	container, err := findRunningProjectContainer(ctx, containerEngine, project.ProjectName)
+	if err != nil {
+		return err
+	}
+	if container == nil {
+		console.Info("No running container for project '%s', nothing to restart.", project.ProjectName)
+		return nil
+	}
+	console.Info("Stopping the container of project '%s'...", project.ProjectName)
+	if err := containerEngine.StopContainer(ctx, *container); err != nil {
+		return fmt.Errorf("could not stop the container of project '%s': %w", project.ProjectName, err)
+	}
+	// Containers are run with `--rm`, their removal may lag behind their stop
+	deadline := time.Now().Add(restartRemovalTimeout)
+	for {
+		containers, err := containerEngine.ListContainers(ctx)
+		if err != nil {
+			return fmt.Errorf("could not list containers: %w", err)
+		}
+		if !slices.ContainsFunc(containers, func(c engine.ContainerInfo) bool {
+			return c.ContainerId == container.ContainerId
+		}) {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("the stopped container of project '%s' was not removed in time", project.ProjectName)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+
+	runtimeCfg, err := config.LoadRuntimeConfig(project.RuntimeConfigPath)
+	if err != nil {
+		return fmt.Errorf("cannot restart project '%s': %w", project.ProjectName, err)
+	}
+	if err := prepareProjectRuntimeFiles(project, runtimeCfg, filestore); err != nil {
+		return err
+	}
+	if _, err := startDetachedContainer(ctx, project, containerEngine, console); err != nil {
+		return err
+	}
+	console.Success("Restarted the container of project '%s' in the background", project.ProjectName)
+	return nil
+}
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSnapshotInputs_DetectsChanges(t *testing.T) {
+	dir := t.TempDir()
+	buildConf := filepath.Join(dir, "build.conf")
+	dotfiles := filepath.Join(dir, "dotfiles")
+	if err := os.WriteFile(buildConf, []byte("VERSION 1.1.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dotfiles, "nvim"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dotfiles, "nvim", "init.lua"), []byte("-- a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	inputs := []string{buildConf, filepath.Join(dir, "run.conf"), dotfiles}
+
+	before := snapshotInputs(inputs)
+	if len(before) != 2 {
+		t.Fatalf("snapshotInputs() found %d files, want 2: %v", len(before), before)
+	}
+	if changed := changedInputs(before, snapshotInputs(inputs)); len(changed) != 0 {
+		t.Fatalf("changedInputs() = %v without changes, want none", changed)
+	}
+
+	if err := os.WriteFile(buildConf, []byte("VERSION 1.1.0\nHOST_UID 1000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(buildConf, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "run.conf"), []byte("PATH /x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dotfiles, "nvim", "init.lua")); err != nil {
+		t.Fatal(err)
+	}
+
+	got := changedInputs(before, snapshotInputs(inputs))
+	want := []string{buildConf, filepath.Join(dotfiles, "nvim", "init.lua"), filepath.Join(dir, "run.conf")}
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Fatalf("changedInputs() = %v, want %v", got, want)
+	}
+}
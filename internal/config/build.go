@@ -2,10 +2,14 @@ package config
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 
+	"github.com/peaberberian/paul-envs/internal/profiling"
 	"github.com/peaberberian/paul-envs/internal/utils"
 )
 
@@ -16,6 +20,19 @@ import (
 type BuildConfig struct {
 	Version utils.Version
 	Args    map[string]string
+	// Other build arguments, from `BUILD_ARG NAME=VALUE` directives, given to
+	// the build after `Args` so they can replace them
+	BuildArgs map[string]string
+	// Dockerfile, or directory containing one, to build the project from
+	// instead of the generated one, from the `DOCKERFILE` directive. Relative
+	// to the project's directory if not absolute. Empty if not set.
+	Dockerfile string
+	// Labels set on the project's image, from `IMAGE_LABEL NAME=VALUE`
+	// directives
+	Labels map[string]string
+	// If `true`, the layers of the project's image are squashed into a single
+	// one on top of the shared base image, from the `SQUASH` directive
+	Squash bool
 }
 
 // requiredBuildDirectives must be present in every build.conf.
@@ -28,21 +45,25 @@ var requiredBuildDirectives = map[string]struct{}{
 
 // boolBuildDirectives must have the value "true" or "false".
 var boolBuildDirectives = map[string]struct{}{
-	"INSTALL_NEOVIM":      {},
-	"INSTALL_STARSHIP":    {},
-	"INSTALL_OH_MY_POSH":  {},
-	"INSTALL_ATUIN":       {},
-	"INSTALL_MISE":        {},
-	"INSTALL_ZELLIJ":      {},
-	"INSTALL_JUJUTSU":     {},
-	"INSTALL_DELTA":       {},
-	"INSTALL_OPEN_CODE":   {},
-	"INSTALL_CLAUDE_CODE": {},
-	"INSTALL_CODEX":       {},
-	"INSTALL_FIREFOX":     {},
-	"ENABLE_WASM":         {},
-	"ENABLE_SSH":          {},
-	"ENABLE_SUDO":         {},
+	"INSTALL_NEOVIM":           {},
+	"INSTALL_STARSHIP":         {},
+	"INSTALL_OH_MY_POSH":       {},
+	"INSTALL_ATUIN":            {},
+	"INSTALL_MISE":             {},
+	"INSTALL_ZELLIJ":           {},
+	"INSTALL_JUJUTSU":          {},
+	"INSTALL_DELTA":            {},
+	"INSTALL_OPEN_CODE":        {},
+	"INSTALL_CLAUDE_CODE":      {},
+	"INSTALL_CODEX":            {},
+	"INSTALL_FIREFOX":          {},
+	"INSTALL_COMPLETIONS":      {},
+	"ENABLE_WASM":              {},
+	"ENABLE_COMPILER_CACHE":    {},
+	"ENABLE_NESTED_CONTAINERS": {},
+	"ENABLE_SYSTEMD":           {},
+	"ENABLE_SSH":               {},
+	"ENABLE_SUDO":              {},
 }
 
 // versionBuildDirectives must be "none" or a non-empty, whitespace-free string.
@@ -53,11 +74,23 @@ var versionBuildDirectives = map[string]struct{}{
 	"INSTALL_GO":     {},
 }
 
+// languagePackageDirectives are space-separated lists of packages installed
+// through a language's package manager, mapped to the directive installing
+// that language, if pipx does not come with it.
+var languagePackageDirectives = map[string]string{
+	"PIPX_PACKAGES":  "",
+	"CARGO_PACKAGES": "INSTALL_RUST",
+	"NPM_PACKAGES":   "INSTALL_NODE",
+}
+
 // allBuildDirectives is the union of all recognised directive names.
 var allBuildDirectives = func() map[string]struct{} {
 	m := map[string]struct{}{
 		"SUPPLEMENTARY_PACKAGES": {},
 	}
+	for k := range languagePackageDirectives {
+		m[k] = struct{}{}
+	}
 	for k := range requiredBuildDirectives {
 		m[k] = struct{}{}
 	}
@@ -74,6 +107,7 @@ var allBuildDirectives = func() map[string]struct{} {
 // and returns a BuildConfig whose Args are ready to forward as --build-arg
 // flags. Any validation failure returns a descriptive error.
 func LoadBuildConfig(path string) (BuildConfig, error) {
+	defer profiling.Track(profiling.CategoryFiles, "load "+path)()
 	directives, err := ParseFile(path)
 	if err != nil {
 		return BuildConfig{}, fmt.Errorf("load build config %s: %w", filepath.Base(path), err)
@@ -84,8 +118,61 @@ func LoadBuildConfig(path string) (BuildConfig, error) {
 	}
 
 	args := make(map[string]string, len(directives))
+	var buildArgs map[string]string
+	var dockerfile string
+	var labels map[string]string
+	var squash *bool
 
 	for _, d := range directives {
+		switch d.Key {
+		case "IMAGE_LABEL":
+			name, value, ok := strings.Cut(d.Value, "=")
+			if !ok || !imageLabelNameRegex.MatchString(name) {
+				return BuildConfig{}, fmt.Errorf("%s: directive \"IMAGE_LABEL\": expected NAME=VALUE, got %q", filepath.Base(path), d.Value)
+			}
+			if _, duplicate := labels[name]; duplicate {
+				return BuildConfig{}, fmt.Errorf("%s: image label %q is set more than once", filepath.Base(path), name)
+			}
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			labels[name] = value
+			continue
+		case "SQUASH":
+			if squash != nil {
+				return BuildConfig{}, fmt.Errorf("%s: directive \"SQUASH\" appears more than once", filepath.Base(path))
+			}
+			if d.Value != "true" && d.Value != "false" {
+				return BuildConfig{}, fmt.Errorf("%s: directive \"SQUASH\" must be \"true\" or \"false\", got %q", filepath.Base(path), d.Value)
+			}
+			value := d.Value == "true"
+			squash = &value
+			continue
+		}
+		if d.Key == "DOCKERFILE" {
+			if dockerfile != "" {
+				return BuildConfig{}, fmt.Errorf("%s: directive \"DOCKERFILE\" appears more than once", filepath.Base(path))
+			}
+			if strings.TrimSpace(d.Value) == "" {
+				return BuildConfig{}, fmt.Errorf("%s: directive \"DOCKERFILE\" needs a path", filepath.Base(path))
+			}
+			dockerfile = d.Value
+			continue
+		}
+		if d.Key == "BUILD_ARG" {
+			name, value, err := ParseBuildArg(d.Value)
+			if err != nil {
+				return BuildConfig{}, fmt.Errorf("%s: directive \"BUILD_ARG\": %w", filepath.Base(path), err)
+			}
+			if _, duplicate := buildArgs[name]; duplicate {
+				return BuildConfig{}, fmt.Errorf("%s: build argument %q is set more than once", filepath.Base(path), name)
+			}
+			if buildArgs == nil {
+				buildArgs = map[string]string{}
+			}
+			buildArgs[name] = value
+			continue
+		}
 		if _, known := allBuildDirectives[d.Key]; !known {
 			fmt.Fprintf(os.Stderr, "Warning: %s: ignoring unknown directive %q\n", filepath.Base(path), d.Key)
 			continue
@@ -115,7 +202,49 @@ func LoadBuildConfig(path string) (BuildConfig, error) {
 		}
 	}
 
-	return BuildConfig{Version: version, Args: args}, nil
+	for _, k := range slices.Sorted(maps.Keys(languagePackageDirectives)) {
+		language := languagePackageDirectives[k]
+		if language != "" && strings.TrimSpace(args[k]) != "" && args[language] == "none" {
+			return BuildConfig{}, fmt.Errorf(
+				"%s: directive %q needs %q to install its language",
+				filepath.Base(path), k, language,
+			)
+		}
+	}
+
+	return BuildConfig{
+		Version:    version,
+		Args:       args,
+		BuildArgs:  buildArgs,
+		Dockerfile: dockerfile,
+		Labels:     labels,
+		Squash:     squash != nil && *squash,
+	}, nil
+}
+
+// IsPackageListDirective tells if that build.conf directive is a
+// space-separated list of packages.
+func IsPackageListDirective(key string) bool {
+	_, isLanguagePackages := languagePackageDirectives[key]
+	return isLanguagePackages || key == "SUPPLEMENTARY_PACKAGES"
+}
+
+var buildArgNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Label names are usually namespaced, e.g. "org.opencontainers.image.vendor".
+var imageLabelNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]*$`)
+
+// ParseBuildArg parses a build argument written as "NAME=VALUE", its value
+// being allowed to be empty.
+func ParseBuildArg(arg string) (string, string, error) {
+	name, value, ok := strings.Cut(arg, "=")
+	if !ok {
+		return "", "", fmt.Errorf("expected NAME=VALUE, got %q", arg)
+	}
+	if !buildArgNameRegex.MatchString(name) {
+		return "", "", fmt.Errorf("invalid build argument name %q", name)
+	}
+	return name, value, nil
 }
 
 // validateBuildValue checks that value is acceptable for the given directive.
@@ -137,5 +266,21 @@ func validateBuildValue(key, value string) error {
 		return nil
 	}
 
+	if _, ok := languagePackageDirectives[key]; ok {
+		for _, name := range strings.Fields(value) {
+			if !utils.IsValidLanguagePackageName(name) {
+				return fmt.Errorf("directive %q: invalid package %q", key, name)
+			}
+		}
+		return nil
+	}
+
+	if key == "USER_SHELL" {
+		var shell Shell
+		if err := shell.Set(value); err != nil {
+			return fmt.Errorf("directive %q: expected one of bash, zsh, fish or nushell, got %q", key, value)
+		}
+	}
+
 	return nil
 }
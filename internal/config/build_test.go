@@ -1,6 +1,7 @@
 package config
 
 import (
+	"maps"
 	"os"
 	"path/filepath"
 	"strings"
@@ -287,6 +288,76 @@ func TestLoadBuildConfig_UnknownDirectiveIgnored(t *testing.T) {
 	}
 }
 
+func TestLoadBuildConfig_BuildArgs(t *testing.T) {
+	content := minimalValidConf + "BUILD_ARG PIP_INDEX_URL=https://pypi.example.com/simple?a=b\nBUILD_ARG EMPTY=\n"
+	cfg, err := LoadBuildConfig(writeTempConf(t, content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.BuildArgs["PIP_INDEX_URL"]; got != "https://pypi.example.com/simple?a=b" {
+		t.Errorf("BuildArgs[PIP_INDEX_URL] = %q", got)
+	}
+	if got, ok := cfg.BuildArgs["EMPTY"]; !ok || got != "" {
+		t.Errorf("BuildArgs[EMPTY] = %q, %v, want an empty value", got, ok)
+	}
+	if _, ok := cfg.Args["BUILD_ARG"]; ok {
+		t.Error("BUILD_ARG should not be forwarded as a build arg itself")
+	}
+
+	for _, bad := range []string{"BUILD_ARG NO_VALUE\n", "BUILD_ARG 1NAME=x\n", "BUILD_ARG A=1\nBUILD_ARG A=2\n"} {
+		if _, err := LoadBuildConfig(writeTempConf(t, minimalValidConf+bad)); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestLoadBuildConfig_Dockerfile(t *testing.T) {
+	cfg, err := LoadBuildConfig(writeTempConf(t, minimalValidConf+"DOCKERFILE docker/Dockerfile.dev\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Dockerfile != "docker/Dockerfile.dev" {
+		t.Errorf("Dockerfile = %q", cfg.Dockerfile)
+	}
+	if _, ok := cfg.Args["DOCKERFILE"]; ok {
+		t.Error("DOCKERFILE should not be forwarded as a build arg")
+	}
+	if _, err := LoadBuildConfig(writeTempConf(t, minimalValidConf+"DOCKERFILE a\nDOCKERFILE b\n")); err == nil {
+		t.Error("expected an error for a duplicate DOCKERFILE")
+	}
+}
+
+func TestLoadBuildConfig_ImageSettings(t *testing.T) {
+	cfg, err := LoadBuildConfig(writeTempConf(t, minimalValidConf+
+		"IMAGE_LABEL org.opencontainers.image.vendor=ACME\nIMAGE_LABEL team=a=b\nSQUASH true\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{"org.opencontainers.image.vendor": "ACME", "team": "a=b"}
+	if !maps.Equal(cfg.Labels, want) {
+		t.Errorf("Labels = %v, want %v", cfg.Labels, want)
+	}
+	if !cfg.Squash {
+		t.Error("Squash should be true")
+	}
+	for _, name := range []string{"IMAGE_LABEL", "SQUASH"} {
+		if _, ok := cfg.Args[name]; ok {
+			t.Errorf("%s should not be forwarded as a build arg", name)
+		}
+	}
+	for _, conf := range []string{
+		"IMAGE_LABEL vendor\n",
+		"IMAGE_LABEL -x=1\n",
+		"IMAGE_LABEL a=1\nIMAGE_LABEL a=2\n",
+		"SQUASH yes\n",
+		"SQUASH true\nSQUASH false\n",
+	} {
+		if _, err := LoadBuildConfig(writeTempConf(t, minimalValidConf+conf)); err == nil {
+			t.Errorf("expected an error for %q", conf)
+		}
+	}
+}
+
 func TestLoadBuildConfig_MissingVersion(t *testing.T) {
 	content := confWithoutDirective(t, minimalValidConf, "VERSION")
 	path := writeTempConf(t, content)
@@ -383,6 +454,50 @@ func TestLoadBuildConfig_VersionBadValue(t *testing.T) {
 	}
 }
 
+func TestLoadBuildConfig_UserShell(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "nushell"} {
+		path := writeTempConf(t, buildConfWithOverride(t, "USER_SHELL", shell))
+		cfg, err := LoadBuildConfig(path)
+		if err != nil {
+			t.Fatalf("unexpected error for USER_SHELL %s: %v", shell, err)
+		}
+		if cfg.Args["USER_SHELL"] != shell {
+			t.Fatalf("USER_SHELL = %q, want %q", cfg.Args["USER_SHELL"], shell)
+		}
+	}
+
+	path := writeTempConf(t, buildConfWithOverride(t, "USER_SHELL", "tcsh"))
+	_, err := LoadBuildConfig(path)
+	if err == nil {
+		t.Fatal("expected error for USER_SHELL tcsh, got nil")
+	}
+	assertErrorContains(t, err, "USER_SHELL")
+}
+
+func TestLoadBuildConfig_LanguagePackages(t *testing.T) {
+	content := buildConfWithOverride(t, "INSTALL_NODE", "22") +
+		"PIPX_PACKAGES ruff==0.6.9 httpie\nNPM_PACKAGES @scope/tool@1.2 typescript\nCARGO_PACKAGES \n"
+	cfg, err := LoadBuildConfig(writeTempConf(t, content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Args["PIPX_PACKAGES"] != "ruff==0.6.9 httpie" || cfg.Args["NPM_PACKAGES"] != "@scope/tool@1.2 typescript" {
+		t.Fatalf("unexpected package args: %v", cfg.Args)
+	}
+
+	_, err = LoadBuildConfig(writeTempConf(t, minimalValidConf+"CARGO_PACKAGES ripgrep\n"))
+	if err == nil {
+		t.Fatal("expected error for CARGO_PACKAGES without INSTALL_RUST, got nil")
+	}
+	assertErrorContains(t, err, "INSTALL_RUST")
+
+	_, err = LoadBuildConfig(writeTempConf(t, minimalValidConf+"PIPX_PACKAGES \"ruff;rm\"\n"))
+	if err == nil {
+		t.Fatal("expected error for an invalid package, got nil")
+	}
+	assertErrorContains(t, err, "PIPX_PACKAGES")
+}
+
 func TestLoadBuildConfig_EmptyFile(t *testing.T) {
 	path := writeTempConf(t, "")
 	_, err := LoadBuildConfig(path)
@@ -21,11 +21,13 @@ const (
 	ShellBash Shell = "bash"
 	ShellZsh  Shell = "zsh"
 	ShellFish Shell = "fish"
+	// Nushell, whose executable is `nu`
+	ShellNushell Shell = "nushell"
 )
 
 func (s *Shell) Set(value string) error {
 	switch Shell(value) {
-	case ShellBash, ShellZsh, ShellFish:
+	case ShellBash, ShellZsh, ShellFish, ShellNushell:
 		*s = Shell(value)
 		return nil
 	default:
@@ -78,6 +80,17 @@ type Config struct {
 	// WebAssembly target for Rust if it is installed.
 	EnableWasm bool
 
+	// If 'true', install ccache and sccache and cache C/C++ and Rust
+	// compilations in a volume shared by all projects.
+	EnableCompilerCache bool
+
+	// If 'true', install Podman so containers can be built and run inside the
+	// container.
+	EnableNestedContainers bool
+
+	// If 'true', install systemd and run it as the container's PID 1.
+	EnableSystemd bool
+
 	// If 'true', openssh will be installed
 	EnableSsh bool
 
@@ -100,10 +113,18 @@ type Config struct {
 	InstallCodex      bool
 	InstallFirefox    bool
 
+	// If 'true', set up shell completions for the tools found in the image.
+	InstallCompletions bool
+
 	Ports    []uint16
 	Volumes  []string
 	Packages []string
 
+	// Global packages installed through pipx, cargo and npm
+	PipxPackages  []string
+	CargoPackages []string
+	NpmPackages   []string
+
 	// TODO: optionals?
 	GitName         string
 	GitEmail        string
@@ -111,6 +132,9 @@ type Config struct {
 	ProjectDestPath string
 	SshKeyPath      string
 	SeedDotfiles    bool
+	// Name of the shared dotfiles profile used instead of the project's own
+	// dotfiles, if any
+	DotfilesProfile string
 }
 
 // New creates a config with UID/GID auto-detected.
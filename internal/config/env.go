@@ -0,0 +1,109 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/profiling"
+)
+
+// ParseEnvAssignment parses an environment variable given as "KEY=VALUE", or
+// as "KEY" to take its value from the host, like `docker run --env` does.
+//
+// Returns it as "KEY=VALUE", and `false` if it is a "KEY" not set on the host.
+func ParseEnvAssignment(assignment string) (string, bool, error) {
+	key, value, hasValue := strings.Cut(assignment, "=")
+	if key == "" || strings.ContainsAny(key, " \t") {
+		return "", false, fmt.Errorf("invalid environment variable %q, expected KEY=VALUE", assignment)
+	}
+	if !hasValue {
+		value, found := os.LookupEnv(key)
+		if !found {
+			return "", false, nil
+		}
+		return key + "=" + value, true, nil
+	}
+	return key + "=" + value, true, nil
+}
+
+// LoadEnvFile parses path as an env file, with one "KEY=VALUE" (or "KEY" to
+// take its value from the host) per line, in the format of `docker run
+// --env-file`: values are taken as is, without quotes removal nor expansion.
+// Empty lines and lines starting with `#` are ignored.
+func LoadEnvFile(path string) ([]string, error) {
+	defer profiling.Track(profiling.CategoryFiles, "load "+path)()
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var env []string
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimLeft(strings.TrimRight(scanner.Text(), "\r"), " \t")
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		variable, found, err := ParseEnvAssignment(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s: line %d: %w", filepath.Base(path), lineNum, err)
+		}
+		if found {
+			env = append(env, variable)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", filepath.Base(path), err)
+	}
+	return env, nil
+}
+
+var passthroughEnvPatternRegex = regexp.MustCompile(`^[A-Za-z_*][A-Za-z0-9_*]*$`)
+
+// Parse the value of a PASSTHROUGH_ENV directive: names of host environment
+// variables, where `*` matches any characters (e.g. `AWS_*`).
+func parsePassthroughEnv(value string) ([]string, error) {
+	patterns := strings.Fields(value)
+	if len(patterns) == 0 {
+		return nil, errors.New("must list at least one variable name")
+	}
+	for _, pattern := range patterns {
+		if !passthroughEnvPatternRegex.MatchString(pattern) {
+			return nil, fmt.Errorf("invalid variable name %q, expected a name like TERM or AWS_*", pattern)
+		}
+		if strings.Trim(pattern, "*") == "" {
+			return nil, fmt.Errorf("%q would give the container the whole host environment", pattern)
+		}
+	}
+	return patterns, nil
+}
+
+// Returns the sorted names of the variables of `environ`, as "KEY=VALUE",
+// matching one of the given PASSTHROUGH_ENV patterns.
+func PassthroughEnvNames(patterns []string, environ []string) []string {
+	var names []string
+	for _, variable := range environ {
+		name, _, _ := strings.Cut(variable, "=")
+		if name == "" || slices.Contains(names, name) {
+			continue
+		}
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, name); matched {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
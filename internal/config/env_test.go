@@ -0,0 +1,69 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseEnvAssignment(t *testing.T) {
+	t.Setenv("PAULENV_TEST_HOST", "from-host")
+	tests := []struct {
+		input string
+		want  string
+		found bool
+		ok    bool
+	}{
+		{input: "KEY=value", want: "KEY=value", found: true, ok: true},
+		{input: "KEY=a=b", want: "KEY=a=b", found: true, ok: true},
+		{input: "KEY=", want: "KEY=", found: true, ok: true},
+		{input: "PAULENV_TEST_HOST", want: "PAULENV_TEST_HOST=from-host", found: true, ok: true},
+		{input: "PAULENV_TEST_UNSET_VARIABLE", found: false, ok: true},
+		{input: "=value", ok: false},
+		{input: "MY KEY=value", ok: false},
+	}
+	for _, tt := range tests {
+		got, found, err := ParseEnvAssignment(tt.input)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseEnvAssignment(%q) error = %v, want ok=%v", tt.input, err, tt.ok)
+			continue
+		}
+		if got != tt.want || found != tt.found {
+			t.Errorf("ParseEnvAssignment(%q) = %q, %v, want %q, %v", tt.input, got, found, tt.want, tt.found)
+		}
+	}
+}
+
+func TestLoadEnvFile(t *testing.T) {
+	t.Setenv("PAULENV_TEST_HOST", "from-host")
+	env, err := LoadEnvFile(writeConf(t, "# comment\n\nDEBUG=1\n  QUOTED=\"kept\"\r\nPAULENV_TEST_HOST\nPAULENV_TEST_UNSET_VARIABLE\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"DEBUG=1", "QUOTED=\"kept\"", "PAULENV_TEST_HOST=from-host"}
+	if !slices.Equal(env, want) {
+		t.Errorf("want %q, got %q", want, env)
+	}
+
+	if _, err := LoadEnvFile(writeConf(t, "DEBUG=1\n=oops\n")); err == nil {
+		t.Errorf("expected an error for an empty key, got none")
+	}
+}
+
+func TestPassthroughEnvNames(t *testing.T) {
+	environ := []string{
+		"TERM=xterm-256color",
+		"AWS_PROFILE=dev",
+		"AWS_SECRET_ACCESS_KEY=secret",
+		"MY_AWS_THING=1",
+		"HOME=/home/alice",
+		"TERM=duplicate",
+	}
+	got := PassthroughEnvNames([]string{"TERM", "AWS_*", "COLORTERM"}, environ)
+	want := []string{"AWS_PROFILE", "AWS_SECRET_ACCESS_KEY", "TERM"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("PassthroughEnvNames() = %v, want %v", got, want)
+	}
+	if got := PassthroughEnvNames(nil, environ); len(got) != 0 {
+		t.Fatalf("PassthroughEnvNames() without patterns = %v, want none", got)
+	}
+}
@@ -0,0 +1,623 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/profiling"
+)
+
+// GlobalConfig holds the user's defaults, parsed from the paul-envs.conf file
+// of paul-envs' configuration directory.
+//
+// It has the same syntax as build.conf and run.conf files and all its
+// directives are optional.
+type GlobalConfig struct {
+	// optional; container engine used when none is explicitly requested,
+	// "docker" or "podman", auto-selected if empty
+	Engine string
+	// optional; distribution image the shared base image is built from, the
+	// one of `Dockerfile.base` if empty
+	BaseImage string
+	// optional; shell of new projects when none is given to `create`, asked
+	// for if empty
+	Shell Shell
+	// optional; directory of the dotfiles template new projects can be
+	// seeded from, the one of paul-envs' configuration directory if empty
+	Dotfiles string
+	// optional; maximum number of builds or container engine queries done at
+	// once, `DefaultParallelism()` if 0
+	Parallelism int
+	// optional; backend of secrets not naming one, `DefaultSecretBackend`
+	// if empty
+	SecretsBackend string
+	// optional; age identity file decrypting secrets of the `age` backend
+	AgeIdentity string
+	// optional; maximum duration of each build attempt, `0` for no limit
+	BuildTimeout time.Duration
+	// optional; number of times a build failing on a transient error is
+	// retried, `DefaultBuildRetries` if nil
+	BuildRetries *int
+	// optional; duration after which containers nothing attached to are
+	// stopped, `0` to never stop them
+	IdleTimeout time.Duration
+	// optional; interval at which `paul-envs daemon` runs its tasks,
+	// `DefaultDaemonInterval` if 0
+	DaemonInterval time.Duration
+	// optional; tasks run by `paul-envs daemon`, all of `DaemonTasks` if nil
+	DaemonTasks []string
+	// optional; program building images with Podman, "podman" or "buildah",
+	// Podman itself if empty
+	PodmanBuilder string
+	// optional; duration after which the end of builds and pulls is
+	// notified, `0` to never notify it, `DefaultNotifyAfter` if nil
+	NotifyAfter *time.Duration
+	// optional; name of project containers, a template with a `{project}`
+	// placeholder, `DefaultContainerName` if empty
+	ContainerName string
+	// optional; mirror builds and pulls reach each registry (e.g.
+	// "docker.io") through, by registry
+	RegistryMirrors map[string]string
+	// optional; registries (or mirrors) reached without verifying their TLS
+	// certificate, or over plain HTTP
+	InsecureRegistries []string
+	// optional; file with the credentials of each registry, in the format
+	// written by `docker login` and `podman login`, by registry
+	RegistryAuthFiles map[string]string
+}
+
+// Number of times a build failing on a transient error (e.g. a network error
+// while pulling an image) is retried when not configured.
+const DefaultBuildRetries = 2
+
+// Interval at which `paul-envs daemon` runs its tasks when not configured.
+const DefaultDaemonInterval = time.Hour
+
+// Duration after which the end of builds and pulls is notified when not
+// configured.
+const DefaultNotifyAfter = time.Minute
+
+// Name of project containers when not configured.
+const DefaultContainerName = "paulenv-{project}"
+
+// Tasks `paul-envs daemon` can run: collecting garbage, checking whether the
+// base image is behind its distribution image, stopping idle containers (and
+// those past their project's lease) and reporting those which crash.
+var DaemonTasks = []string{"gc", "outdated", "reap", "crashes"}
+
+// A directive of the global configuration file.
+type GlobalSetting struct {
+	Key         string
+	Description string
+	validate    func(value string) error
+}
+
+// Every directive of the global configuration file, in the order in which
+// they are listed.
+var GlobalSettings = []GlobalSetting{
+	{
+		Key:         "ENGINE",
+		Description: "Container engine used when none is requested: docker, podman or the name of an engine plugin. Default: Podman if available, else Docker.",
+		validate: func(value string) error {
+			if value == "docker" || value == "podman" {
+				return nil
+			}
+			// Engine plugins, see the `engine` package
+			if _, err := exec.LookPath("paulenv-engine-" + value); err == nil && !strings.ContainsAny(value, `/\`) {
+				return nil
+			}
+			return fmt.Errorf("expected \"docker\", \"podman\" or an engine plugin found in the PATH, got %q", value)
+		},
+	},
+	{
+		Key:         "BASE_IMAGE",
+		Description: "Distribution image the shared base image is built from. Only Debian-based images (e.g. debian:12) are supported. Default: ubuntu:24.04.",
+		validate: func(value string) error {
+			if strings.ContainsAny(value, " \t") {
+				return fmt.Errorf("expected an image name without whitespace, got %q", value)
+			}
+			return nil
+		},
+	},
+	{
+		Key:         "SHELL",
+		Description: "Shell of new projects when 'create' is not given one: bash, zsh, fish or nushell. Default: asked for.",
+		validate: func(value string) error {
+			var shell Shell
+			return shell.Set(value)
+		},
+	},
+	{
+		Key:         "DOTFILES",
+		Description: "Directory of the dotfiles template new projects can be seeded from. Default: the dotfiles directory of paul-envs' configuration directory.",
+		validate: func(value string) error {
+			if value != "~" && !strings.HasPrefix(value, "~/") && !filepath.IsAbs(value) {
+				return fmt.Errorf("expected an absolute path, got %q", value)
+			}
+			return nil
+		},
+	},
+	{
+		Key:         "PARALLELISM",
+		Description: "Maximum number of builds or container engine queries done at once. Default: the number of CPUs, up to 4.",
+		validate: func(value string) error {
+			if v, err := strconv.Atoi(value); err != nil || v <= 0 {
+				return fmt.Errorf("expected a positive integer, got %q", value)
+			}
+			return nil
+		},
+	},
+	{
+		Key:         "SECRETS_BACKEND",
+		Description: "Backend of the secrets of run.conf files not naming one: age, keyring, pass, gopass or env. Default: env.",
+		validate:    ValidateSecretBackend,
+	},
+	{
+		Key:         "AGE_IDENTITY",
+		Description: "age identity file decrypting the secrets of the age backend. Default: none, that backend cannot be used.",
+		validate: func(value string) error {
+			if value != "~" && !strings.HasPrefix(value, "~/") && !filepath.IsAbs(value) {
+				return fmt.Errorf("expected an absolute path, got %q", value)
+			}
+			return nil
+		},
+	},
+	{
+		Key:         "BUILD_TIMEOUT",
+		Description: "Maximum duration of each build attempt (e.g. 2h), 0 for no limit. Default: 0.",
+		validate:    validateBuildTimeout,
+	},
+	{
+		Key:         "BUILD_RETRIES",
+		Description: "Number of times a build failing on a transient network or registry error is retried. Default: 2.",
+		validate:    validateBuildRetries,
+	},
+	{
+		Key:         "PODMAN_BUILDER",
+		Description: "Program building images with Podman: podman, or buildah to call it directly. Default: podman.",
+		validate: func(value string) error {
+			if value != "podman" && value != "buildah" {
+				return fmt.Errorf("expected \"podman\" or \"buildah\", got %q", value)
+			}
+			return nil
+		},
+	},
+	{
+		Key:         "IDLE_TIMEOUT",
+		Description: "Duration (e.g. 12h) after which running containers nothing attached to are stopped, 0 to never stop them. Default: 0.",
+		validate:    validateIdleTimeout,
+	},
+	{
+		Key:         "NOTIFY_AFTER",
+		Description: "Duration (e.g. 5m) after which builds and image pulls are notified when they end, through a desktop notification or else the terminal's bell, 0 to never notify them. Default: 1m.",
+		validate:    validateNotifyAfter,
+	},
+	{
+		Key:         "CONTAINER_NAME",
+		Description: "Name of project containers and of their networks, where {project} is replaced by the project's name and the optional {user} and {host} by the user's and host's. Default: paulenv-{project}.",
+		validate:    validateContainerName,
+	},
+	{
+		Key:         "REGISTRY_MIRRORS",
+		Description: "Comma-separated registry=mirror pairs (e.g. docker.io=mirror.example.com/docker-remote), the mirror through which builds and pulls reach each registry. Default: none.",
+		validate: func(value string) error {
+			_, err := parseRegistryMap(value, validateRegistryLocation)
+			return err
+		},
+	},
+	{
+		Key:         "INSECURE_REGISTRIES",
+		Description: "Comma-separated registries or mirrors reached without verifying their TLS certificate, or over plain HTTP. Default: none.",
+		validate: func(value string) error {
+			for _, registry := range splitList(value) {
+				if err := validateRegistryHost(registry); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Key:         "REGISTRY_AUTH_FILES",
+		Description: "Comma-separated registry=path pairs, the file with the credentials of each registry as written by 'docker login' or 'podman login'. Default: those of the engine.",
+		validate: func(value string) error {
+			_, err := parseRegistryMap(value, func(path string) error {
+				if !filepath.IsAbs(path) {
+					return fmt.Errorf("expected an absolute path, got %q", path)
+				}
+				return nil
+			})
+			return err
+		},
+	},
+	{
+		Key:         "DAEMON_INTERVAL",
+		Description: "Interval (e.g. 30m) at which 'paul-envs daemon' runs its tasks. Default: 1h.",
+		validate:    validateDaemonInterval,
+	},
+	{
+		Key:         "DAEMON_TASKS",
+		Description: "Comma-separated tasks run by 'paul-envs daemon', among gc, outdated, reap and crashes. Default: all of them.",
+		validate:    validateDaemonTasks,
+	},
+}
+
+func validateBuildTimeout(value string) error {
+	if v, err := time.ParseDuration(value); err != nil || v < 0 {
+		return fmt.Errorf("expected a duration, e.g. \"2h\" or \"90m\", got %q", value)
+	}
+	return nil
+}
+
+func validateIdleTimeout(value string) error {
+	if v, err := time.ParseDuration(value); err != nil || v < 0 {
+		return fmt.Errorf("expected a duration, e.g. \"12h\" or \"30m\", got %q", value)
+	}
+	return nil
+}
+
+func validateDaemonInterval(value string) error {
+	if v, err := time.ParseDuration(value); err != nil || v <= 0 {
+		return fmt.Errorf("expected a positive duration, e.g. \"1h\" or \"30m\", got %q", value)
+	}
+	return nil
+}
+
+func validateDaemonTasks(value string) error {
+	for _, task := range strings.Split(value, ",") {
+		if !slices.Contains(DaemonTasks, strings.TrimSpace(task)) {
+			return fmt.Errorf("unknown task %q, expected a comma-separated list among %s", strings.TrimSpace(task), strings.Join(DaemonTasks, ", "))
+		}
+	}
+	return nil
+}
+
+func validateNotifyAfter(value string) error {
+	if v, err := time.ParseDuration(value); err != nil || v < 0 {
+		return fmt.Errorf("expected a duration, e.g. \"5m\" or \"30s\", got %q", value)
+	}
+	return nil
+}
+
+// Letters, digits, `_` and `-`. Dots are left out of a CONTAINER_NAME: they
+// separate the names of a project's containers from those of its instances
+// and services.
+var containerNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+func validateContainerName(value string) error {
+	if strings.Count(value, "{project}") != 1 {
+		return fmt.Errorf("expected a name with a single {project} placeholder, e.g. \"paulenv-{user}-{project}\", got %q", value)
+	}
+	// Placeholders are replaced by names made of those same characters
+	expanded := strings.NewReplacer("{project}", "p", "{user}", "u", "{host}", "h").Replace(value)
+	if !containerNameRegex.MatchString(expanded) {
+		return fmt.Errorf("expected letters, digits, '_', '-' and the {project}, {user} and {host} placeholders, got %q", value)
+	}
+	return nil
+}
+
+// Host of a registry, with an optional port (e.g. "registry.example.com:5000").
+var registryHostRegex = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9.-]*[a-zA-Z0-9])?(:[0-9]+)?$`)
+
+func validateRegistryHost(value string) error {
+	if !registryHostRegex.MatchString(value) {
+		return fmt.Errorf("expected a registry host, e.g. \"docker.io\" or \"registry.example.com:5000\", got %q", value)
+	}
+	return nil
+}
+
+// A registry host optionally followed by a path, as mirrors can be
+// repositories of a registry (e.g. "mirror.example.com/docker-remote").
+func validateRegistryLocation(value string) error {
+	host, path, _ := strings.Cut(value, "/")
+	if err := validateRegistryHost(host); err != nil {
+		return err
+	}
+	if strings.HasSuffix(path, "/") || strings.Contains(path, "//") || strings.ContainsAny(path, " \t:@") {
+		return fmt.Errorf("expected a registry host optionally followed by a path, got %q", value)
+	}
+	return nil
+}
+
+// Parse comma-separated `<registry>=<value>` pairs, checking each value with
+// `validate`.
+func parseRegistryMap(value string, validate func(string) error) (map[string]string, error) {
+	registries := map[string]string{}
+	for _, pair := range splitList(value) {
+		registry, registryValue, ok := strings.Cut(pair, "=")
+		registry, registryValue = strings.TrimSpace(registry), strings.TrimSpace(registryValue)
+		if !ok || registryValue == "" {
+			return nil, fmt.Errorf("expected comma-separated <registry>=<value> pairs, got %q", pair)
+		}
+		if err := validateRegistryHost(registry); err != nil {
+			return nil, err
+		}
+		if _, seen := registries[registry]; seen {
+			return nil, fmt.Errorf("registry %q is given more than once", registry)
+		}
+		if err := validate(registryValue); err != nil {
+			return nil, err
+		}
+		registries[registry] = registryValue
+	}
+	return registries, nil
+}
+
+// Format pairs the way `parseRegistryMap` parses them, sorted by registry.
+func formatRegistryMap(registries map[string]string) string {
+	pairs := make([]string, 0, len(registries))
+	for _, registry := range slices.Sorted(maps.Keys(registries)) {
+		pairs = append(pairs, registry+"="+registries[registry])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Split a comma-separated list, trimming its elements.
+func splitList(value string) []string {
+	var elements []string
+	for _, element := range strings.Split(value, ",") {
+		elements = append(elements, strings.TrimSpace(element))
+	}
+	return elements
+}
+
+func validateBuildRetries(value string) error {
+	if v, err := strconv.Atoi(value); err != nil || v < 0 {
+		return fmt.Errorf("expected a positive integer or 0, got %q", value)
+	}
+	return nil
+}
+
+// Returns the directive of the global configuration file with that key,
+// regardless of its case.
+func FindGlobalSetting(key string) (GlobalSetting, error) {
+	for _, setting := range GlobalSettings {
+		if strings.EqualFold(setting.Key, key) {
+			return setting, nil
+		}
+	}
+	keys := make([]string, 0, len(GlobalSettings))
+	for _, setting := range GlobalSettings {
+		keys = append(keys, setting.Key)
+	}
+	return GlobalSetting{}, fmt.Errorf("unknown setting %q, expected one of: %s", key, strings.Join(keys, ", "))
+}
+
+// Check that the given value is acceptable for that directive.
+func (s GlobalSetting) Validate(value string) error {
+	if err := s.validate(value); err != nil {
+		return fmt.Errorf("%s: %w", s.Key, err)
+	}
+	return nil
+}
+
+// Value of the given directive in that configuration, empty if not set.
+func (c GlobalConfig) Get(key string) string {
+	switch key {
+	case "ENGINE":
+		return c.Engine
+	case "BASE_IMAGE":
+		return c.BaseImage
+	case "SHELL":
+		return string(c.Shell)
+	case "DOTFILES":
+		return c.Dotfiles
+	case "PARALLELISM":
+		if c.Parallelism == 0 {
+			return ""
+		}
+		return strconv.Itoa(c.Parallelism)
+	case "SECRETS_BACKEND":
+		return c.SecretsBackend
+	case "AGE_IDENTITY":
+		return c.AgeIdentity
+	case "BUILD_TIMEOUT":
+		if c.BuildTimeout == 0 {
+			return ""
+		}
+		return c.BuildTimeout.String()
+	case "BUILD_RETRIES":
+		if c.BuildRetries == nil {
+			return ""
+		}
+		return strconv.Itoa(*c.BuildRetries)
+	case "IDLE_TIMEOUT":
+		if c.IdleTimeout == 0 {
+			return ""
+		}
+		return c.IdleTimeout.String()
+	case "DAEMON_INTERVAL":
+		if c.DaemonInterval == 0 {
+			return ""
+		}
+		return c.DaemonInterval.String()
+	case "DAEMON_TASKS":
+		return strings.Join(c.DaemonTasks, ",")
+	case "PODMAN_BUILDER":
+		return c.PodmanBuilder
+	case "NOTIFY_AFTER":
+		if c.NotifyAfter == nil {
+			return ""
+		}
+		return c.NotifyAfter.String()
+	case "CONTAINER_NAME":
+		return c.ContainerName
+	case "REGISTRY_MIRRORS":
+		return formatRegistryMap(c.RegistryMirrors)
+	case "INSECURE_REGISTRIES":
+		return strings.Join(c.InsecureRegistries, ",")
+	case "REGISTRY_AUTH_FILES":
+		return formatRegistryMap(c.RegistryAuthFiles)
+	default:
+		return ""
+	}
+}
+
+// Maximum number of builds or container engine queries that should be done
+// at once.
+func (c GlobalConfig) MaxParallelism() int {
+	if c.Parallelism > 0 {
+		return c.Parallelism
+	}
+	return DefaultParallelism()
+}
+
+// Number of times a build failing on a transient error should be retried.
+func (c GlobalConfig) BuildRetryCount() int {
+	if c.BuildRetries != nil {
+		return *c.BuildRetries
+	}
+	return DefaultBuildRetries
+}
+
+// Interval at which `paul-envs daemon` should run its tasks.
+func (c GlobalConfig) DaemonRunInterval() time.Duration {
+	if c.DaemonInterval > 0 {
+		return c.DaemonInterval
+	}
+	return DefaultDaemonInterval
+}
+
+// Duration after which the end of builds and pulls should be notified, `0`
+// if it should never be.
+func (c GlobalConfig) NotifyThreshold() time.Duration {
+	if c.NotifyAfter != nil {
+		return *c.NotifyAfter
+	}
+	return DefaultNotifyAfter
+}
+
+// Name of project containers, with its placeholders.
+func (c GlobalConfig) ContainerNameTemplate() string {
+	if c.ContainerName != "" {
+		return c.ContainerName
+	}
+	return DefaultContainerName
+}
+
+// Tasks `paul-envs daemon` should run, among `DaemonTasks`.
+func (c GlobalConfig) DaemonTaskList() []string {
+	if c.DaemonTasks != nil {
+		return c.DaemonTasks
+	}
+	return DaemonTasks
+}
+
+// Maximum number of builds or container engine queries done at once when not
+// configured.
+func DefaultParallelism() int {
+	return min(runtime.NumCPU(), 4)
+}
+
+// LoadGlobalConfig parses path as the global configuration file.
+// A missing file is not an error and results in the default configuration.
+func LoadGlobalConfig(path string) (GlobalConfig, error) {
+	defer profiling.Track(profiling.CategoryFiles, "load "+path)()
+	directives, err := ParseFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return GlobalConfig{}, nil
+	} else if err != nil {
+		return GlobalConfig{}, fmt.Errorf("load global config %s: %w", filepath.Base(path), err)
+	}
+
+	var cfg GlobalConfig
+	for _, d := range directives {
+		setting, err := FindGlobalSetting(d.Key)
+		if err != nil || setting.Key != d.Key {
+			fmt.Fprintf(os.Stderr, "Warning: %s: ignoring unknown directive %q\n", filepath.Base(path), d.Key)
+			continue
+		}
+		// `~` has already been expanded by the parser
+		if err := setting.Validate(d.Value); err != nil {
+			return GlobalConfig{}, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		switch d.Key {
+		case "ENGINE":
+			cfg.Engine = d.Value
+		case "BASE_IMAGE":
+			cfg.BaseImage = d.Value
+		case "SHELL":
+			cfg.Shell = Shell(d.Value)
+		case "DOTFILES":
+			cfg.Dotfiles = d.Value
+		case "PARALLELISM":
+			cfg.Parallelism, _ = strconv.Atoi(d.Value)
+		case "SECRETS_BACKEND":
+			cfg.SecretsBackend = d.Value
+		case "AGE_IDENTITY":
+			cfg.AgeIdentity = d.Value
+		case "BUILD_TIMEOUT":
+			cfg.BuildTimeout, _ = time.ParseDuration(d.Value)
+		case "BUILD_RETRIES":
+			retries, _ := strconv.Atoi(d.Value)
+			cfg.BuildRetries = &retries
+		case "IDLE_TIMEOUT":
+			cfg.IdleTimeout, _ = time.ParseDuration(d.Value)
+		case "DAEMON_INTERVAL":
+			cfg.DaemonInterval, _ = time.ParseDuration(d.Value)
+		case "DAEMON_TASKS":
+			cfg.DaemonTasks = []string{}
+			for _, task := range strings.Split(d.Value, ",") {
+				cfg.DaemonTasks = append(cfg.DaemonTasks, strings.TrimSpace(task))
+			}
+		case "PODMAN_BUILDER":
+			cfg.PodmanBuilder = d.Value
+		case "NOTIFY_AFTER":
+			notifyAfter, _ := time.ParseDuration(d.Value)
+			cfg.NotifyAfter = &notifyAfter
+		case "CONTAINER_NAME":
+			cfg.ContainerName = d.Value
+		case "REGISTRY_MIRRORS":
+			cfg.RegistryMirrors, _ = parseRegistryMap(d.Value, validateRegistryLocation)
+		case "INSECURE_REGISTRIES":
+			cfg.InsecureRegistries = splitList(d.Value)
+		case "REGISTRY_AUTH_FILES":
+			cfg.RegistryAuthFiles, _ = parseRegistryMap(d.Value, func(string) error { return nil })
+		}
+	}
+	return cfg, nil
+}
+
+// Returns the given global configuration file content with that directive set
+// to `value`, or removed if `value` is empty.
+//
+// Its first occurrence is replaced in place and the following ones removed,
+// so comments and the order of other directives are kept.
+func SetGlobalSetting(content string, key string, value string) string {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	newLine := key + " " + value + "\n"
+	result := make([]string, 0, len(lines)+1)
+	found := false
+	for _, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		lineKey, _, _ := strings.Cut(strings.TrimRight(trimmed, "\r\n"), " ")
+		if trimmed == "" || trimmed[0] == '#' || lineKey != key {
+			result = append(result, line)
+			continue
+		}
+		if !found && value != "" {
+			result = append(result, newLine)
+		}
+		found = true
+	}
+	if !found && value != "" {
+		if len(result) > 0 && !strings.HasSuffix(result[len(result)-1], "\n") {
+			result = append(result, "\n")
+		}
+		result = append(result, newLine)
+	}
+	return strings.Join(result, "")
+}
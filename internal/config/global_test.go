@@ -0,0 +1,168 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadGlobalConfig_Missing(t *testing.T) {
+	cfg, err := LoadGlobalConfig(filepath.Join(t.TempDir(), "paul-envs.conf"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, GlobalConfig{}) {
+		t.Errorf("want the default configuration, got %+v", cfg)
+	}
+	if cfg.MaxParallelism() != DefaultParallelism() {
+		t.Errorf("MaxParallelism: want %d, got %d", DefaultParallelism(), cfg.MaxParallelism())
+	}
+	if cfg.BuildRetryCount() != DefaultBuildRetries {
+		t.Errorf("BuildRetryCount: want %d, got %d", DefaultBuildRetries, cfg.BuildRetryCount())
+	}
+	if cfg.DaemonRunInterval() != DefaultDaemonInterval {
+		t.Errorf("DaemonRunInterval: want %s, got %s", DefaultDaemonInterval, cfg.DaemonRunInterval())
+	}
+	if !reflect.DeepEqual(cfg.DaemonTaskList(), DaemonTasks) {
+		t.Errorf("DaemonTaskList: want %v, got %v", DaemonTasks, cfg.DaemonTaskList())
+	}
+	if cfg.NotifyThreshold() != DefaultNotifyAfter {
+		t.Errorf("NotifyThreshold: want %s, got %s", DefaultNotifyAfter, cfg.NotifyThreshold())
+	}
+}
+
+func TestLoadGlobalConfig_AllSettings(t *testing.T) {
+	path := writeConf(t, "# my defaults\nENGINE docker\nBASE_IMAGE debian:12\nSHELL zsh\nDOTFILES /home/me/dotfiles\nPARALLELISM 2\n"+
+		"SECRETS_BACKEND pass\nAGE_IDENTITY /home/me/.age/key.txt\nBUILD_TIMEOUT 2h\nBUILD_RETRIES 0\nIDLE_TIMEOUT 12h\n"+
+		"DAEMON_INTERVAL 30m\nDAEMON_TASKS gc, reap\nPODMAN_BUILDER buildah\nNOTIFY_AFTER 0\n"+
+		"CONTAINER_NAME {user}-{project}\n"+
+		"REGISTRY_MIRRORS docker.io=mirror.example.com/docker-remote, quay.io=mirror.example.com:5000\n"+
+		"INSECURE_REGISTRIES mirror.example.com:5000,localhost:5000\n"+
+		"REGISTRY_AUTH_FILES mirror.example.com=/home/me/.config/mirror-auth.json\n")
+	cfg, err := LoadGlobalConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	noRetries := 0
+	neverNotify := time.Duration(0)
+	want := GlobalConfig{
+		Engine:         "docker",
+		BaseImage:      "debian:12",
+		Shell:          ShellZsh,
+		Dotfiles:       "/home/me/dotfiles",
+		Parallelism:    2,
+		SecretsBackend: "pass",
+		AgeIdentity:    "/home/me/.age/key.txt",
+		BuildTimeout:   2 * time.Hour,
+		BuildRetries:   &noRetries,
+		IdleTimeout:    12 * time.Hour,
+		DaemonInterval: 30 * time.Minute,
+		DaemonTasks:    []string{"gc", "reap"},
+		PodmanBuilder:  "buildah",
+		NotifyAfter:    &neverNotify,
+		ContainerName:  "{user}-{project}",
+		RegistryMirrors: map[string]string{
+			"docker.io": "mirror.example.com/docker-remote",
+			"quay.io":   "mirror.example.com:5000",
+		},
+		InsecureRegistries: []string{"mirror.example.com:5000", "localhost:5000"},
+		RegistryAuthFiles:  map[string]string{"mirror.example.com": "/home/me/.config/mirror-auth.json"},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("want %+v, got %+v", want, cfg)
+	}
+	if cfg.BuildRetryCount() != 0 {
+		t.Errorf("BuildRetryCount: want 0, got %d", cfg.BuildRetryCount())
+	}
+	if cfg.DaemonRunInterval() != 30*time.Minute {
+		t.Errorf("DaemonRunInterval: want 30m, got %s", cfg.DaemonRunInterval())
+	}
+	if cfg.NotifyThreshold() != 0 {
+		t.Errorf("NotifyThreshold: want 0, got %s", cfg.NotifyThreshold())
+	}
+	if cfg.MaxParallelism() != 2 {
+		t.Errorf("MaxParallelism: want 2, got %d", cfg.MaxParallelism())
+	}
+	if got := cfg.Get("REGISTRY_MIRRORS"); got != "docker.io=mirror.example.com/docker-remote,quay.io=mirror.example.com:5000" {
+		t.Errorf("Get(\"REGISTRY_MIRRORS\"): got %q", got)
+	}
+	for _, setting := range GlobalSettings {
+		if cfg.Get(setting.Key) == "" {
+			t.Errorf("Get(%q): want a value, got none", setting.Key)
+		}
+	}
+}
+
+func TestLoadGlobalConfig_InvalidValues(t *testing.T) {
+	for _, content := range []string{
+		"ENGINE containerd\n",
+		"SHELL tcsh\n",
+		"DOTFILES relative/dir\n",
+		"PARALLELISM 0\n",
+		"PARALLELISM many\n",
+		"SECRETS_BACKEND vault\n",
+		"AGE_IDENTITY key.txt\n",
+		"BUILD_TIMEOUT forever\n",
+		"BUILD_RETRIES -1\n",
+		"IDLE_TIMEOUT 2 days\n",
+		"DAEMON_INTERVAL 0\n",
+		"DAEMON_TASKS gc,prune\n",
+		"PODMAN_BUILDER docker\n",
+		"NOTIFY_AFTER soon\n",
+		"CONTAINER_NAME paulenv\n",
+		"CONTAINER_NAME {project}-{project}\n",
+		"CONTAINER_NAME paulenv.{project}\n",
+		"CONTAINER_NAME {project}-{uid}\n",
+		"CONTAINER_NAME -{project}\n",
+		"REGISTRY_MIRRORS mirror.example.com\n",
+		"REGISTRY_MIRRORS docker.io=https://mirror.example.com\n",
+		"REGISTRY_MIRRORS docker.io=a.example.com,docker.io=b.example.com\n",
+		"INSECURE_REGISTRIES http://localhost:5000\n",
+		"REGISTRY_AUTH_FILES docker.io=auth.json\n",
+	} {
+		if _, err := LoadGlobalConfig(writeConf(t, content)); err == nil {
+			t.Errorf("%q: expected an error, got none", content)
+		}
+	}
+}
+
+func TestFindGlobalSetting(t *testing.T) {
+	setting, err := FindGlobalSetting("base_image")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if setting.Key != "BASE_IMAGE" {
+		t.Errorf("want BASE_IMAGE, got %q", setting.Key)
+	}
+	_, err = FindGlobalSetting("COLOR")
+	if err == nil || !strings.Contains(err.Error(), "PARALLELISM") {
+		t.Errorf("want an error listing the settings, got %v", err)
+	}
+}
+
+func TestSetGlobalSetting(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		key     string
+		value   string
+		want    string
+	}{
+		{"empty file", "", "SHELL", "fish", "SHELL fish\n"},
+		{"appended", "# defaults\nENGINE docker", "SHELL", "fish", "# defaults\nENGINE docker\nSHELL fish\n"},
+		{"replaced in place", "ENGINE docker\n# comment\nSHELL zsh\n", "ENGINE", "podman", "ENGINE podman\n# comment\nSHELL zsh\n"},
+		{"duplicates removed", "SHELL zsh\nENGINE docker\nSHELL bash\n", "SHELL", "fish", "SHELL fish\nENGINE docker\n"},
+		{"commented out kept", "# SHELL zsh\n", "SHELL", "fish", "# SHELL zsh\nSHELL fish\n"},
+		{"unset", "ENGINE docker\nSHELL zsh\n", "SHELL", "", "ENGINE docker\n"},
+		{"unset missing", "ENGINE docker\n", "SHELL", "", "ENGINE docker\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SetGlobalSetting(tt.content, tt.key, tt.value); got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
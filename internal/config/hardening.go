@@ -0,0 +1,46 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var capabilityRegex = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// Parse the value of a HARDENED_CAPS directive: whitespace-separated Linux
+// capabilities, with or without their `CAP_` prefix (e.g. `NET_BIND_SERVICE`).
+//
+// Returns them without that prefix.
+func parseCapabilities(value string) ([]string, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return nil, errors.New("must list at least one capability")
+	}
+	capabilities := make([]string, 0, len(fields))
+	for _, field := range fields {
+		capability := strings.TrimPrefix(strings.ToUpper(field), "CAP_")
+		if capability == "ALL" || !capabilityRegex.MatchString(capability) {
+			return nil, fmt.Errorf("invalid capability %q, expected a name like NET_BIND_SERVICE", field)
+		}
+		capabilities = append(capabilities, capability)
+	}
+	return capabilities, nil
+}
+
+// Parse the value of a MASK directive: a path in the container, relative ones
+// being relative to the mounted project directory.
+func parseMaskedPath(value string) (string, error) {
+	if strings.ContainsAny(value, " \t:") || value == "" {
+		return "", fmt.Errorf("must be a single path without ':', got %q", value)
+	}
+	if path.IsAbs(value) && path.Clean(value) == "/" {
+		return "", errors.New("cannot mask the root directory")
+	}
+	if !path.IsAbs(value) && (path.Clean(value) == "." || strings.HasPrefix(path.Clean(value), "../") || path.Clean(value) == "..") {
+		return "", fmt.Errorf("relative path %q must be inside the project directory", value)
+	}
+	return value, nil
+}
@@ -0,0 +1,206 @@
+// # leases.go
+// On a host shared by several users (e.g. a beefy dev server), an
+// administrator can give projects a lease: how long their containers may run
+// and how much disk space their images and volumes may use. Containers past
+// their lease are stopped by `paul-envs reap` and `paul-envs daemon`, after
+// their users were warned from inside them.
+//
+// Leases are set in a leases.conf file outside of users' own configuration,
+// with the same syntax as the other configuration files:
+//
+//	# Lease of all projects
+//	MAX_RUNTIME 8h
+//	MAX_DISK 50g
+//	# Warn that long before stopping containers
+//	WARN_BEFORE 15m
+//	# Lease of a given project, replacing the one above for what it sets
+//	LEASE ml-training MAX_RUNTIME=48h MAX_DISK=200g
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// File where an administrator sets the leases of projects, when not
+// replaced through `LeasesPathEnvVar`.
+const DefaultLeasesPath = "/etc/paul-envs/leases.conf"
+
+// Environment variable replacing `DefaultLeasesPath`.
+const LeasesPathEnvVar = "PAULENV_LEASES"
+
+// How long before stopping a container past its lease its users are warned,
+// when not configured.
+const DefaultLeaseWarning = 15 * time.Minute
+
+// Limits a project is held to.
+type Lease struct {
+	// Maximum duration its containers may run for, `0` for no limit
+	MaxRuntime time.Duration
+	// Maximum disk space, in bytes, its images and volumes may use, `0` for
+	// no limit
+	MaxDisk int64
+}
+
+// Returns `true` if that lease sets no limit.
+func (l Lease) IsUnlimited() bool {
+	return l.MaxRuntime == 0 && l.MaxDisk == 0
+}
+
+// What a `LEASE` directive sets for a project, `nil` for what it leaves to
+// the default lease.
+type projectLease struct {
+	maxRuntime *time.Duration
+	maxDisk    *int64
+}
+
+// LeasesConfig holds the leases of projects, parsed from the leases.conf
+// file.
+type LeasesConfig struct {
+	// Lease of projects without their own
+	Default Lease
+	// Duration for which users are warned before their containers are
+	// stopped, `DefaultLeaseWarning` if 0
+	WarnBefore time.Duration
+	// By project name
+	projects map[string]projectLease
+}
+
+// Path of the leases.conf file.
+func LeasesPath() string {
+	if path := os.Getenv(LeasesPathEnvVar); path != "" {
+		return path
+	}
+	return DefaultLeasesPath
+}
+
+// Lease of the given project.
+func (c LeasesConfig) LeaseOf(projectName string) Lease {
+	lease := c.Default
+	if project, ok := c.projects[projectName]; ok {
+		if project.maxRuntime != nil {
+			lease.MaxRuntime = *project.maxRuntime
+		}
+		if project.maxDisk != nil {
+			lease.MaxDisk = *project.maxDisk
+		}
+	}
+	return lease
+}
+
+// Returns `true` if no project has a lease setting a limit.
+func (c LeasesConfig) IsEmpty() bool {
+	if !c.Default.IsUnlimited() {
+		return false
+	}
+	for name := range c.projects {
+		if !c.LeaseOf(name).IsUnlimited() {
+			return false
+		}
+	}
+	return true
+}
+
+// Duration for which users should be warned before their containers are
+// stopped.
+func (c LeasesConfig) WarningPeriod() time.Duration {
+	if c.WarnBefore > 0 {
+		return c.WarnBefore
+	}
+	return DefaultLeaseWarning
+}
+
+func parseLeaseRuntime(value string) (time.Duration, error) {
+	v, err := time.ParseDuration(value)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("expected a duration, e.g. \"8h\" or \"90m\", got %q", value)
+	}
+	return v, nil
+}
+
+func parseLeaseDisk(value string) (int64, error) {
+	v, err := utils.ParseSize(value)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("expected a size, e.g. \"50g\" or \"500m\", got %q", value)
+	}
+	return v, nil
+}
+
+// Parse the value of a `LEASE` directive: a project name followed by
+// `MAX_RUNTIME=` and `MAX_DISK=` settings.
+func parseProjectLease(value string) (string, projectLease, error) {
+	fields := strings.Fields(value)
+	if len(fields) < 2 {
+		return "", projectLease{}, fmt.Errorf("expected a project name followed by MAX_RUNTIME=<duration> or MAX_DISK=<size>, got %q", value)
+	}
+	var lease projectLease
+	for _, field := range fields[1:] {
+		key, setting, _ := strings.Cut(field, "=")
+		switch key {
+		case "MAX_RUNTIME":
+			runtime, err := parseLeaseRuntime(setting)
+			if err != nil {
+				return "", projectLease{}, fmt.Errorf("MAX_RUNTIME: %w", err)
+			}
+			lease.maxRuntime = &runtime
+		case "MAX_DISK":
+			disk, err := parseLeaseDisk(setting)
+			if err != nil {
+				return "", projectLease{}, fmt.Errorf("MAX_DISK: %w", err)
+			}
+			lease.maxDisk = &disk
+		default:
+			return "", projectLease{}, fmt.Errorf("unknown setting %q, expected MAX_RUNTIME=<duration> or MAX_DISK=<size>", field)
+		}
+	}
+	return fields[0], lease, nil
+}
+
+// LoadLeasesConfig parses path as a leases.conf file.
+// A missing file is not an error and results in no lease.
+func LoadLeasesConfig(path string) (LeasesConfig, error) {
+	directives, err := ParseFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return LeasesConfig{}, nil
+	} else if err != nil {
+		return LeasesConfig{}, fmt.Errorf("load leases %s: %w", path, err)
+	}
+
+	cfg := LeasesConfig{projects: map[string]projectLease{}}
+	name := filepath.Base(path)
+	for _, d := range directives {
+		switch d.Key {
+		case "MAX_RUNTIME":
+			if cfg.Default.MaxRuntime, err = parseLeaseRuntime(d.Value); err != nil {
+				return LeasesConfig{}, fmt.Errorf("%s: MAX_RUNTIME: %w", name, err)
+			}
+		case "MAX_DISK":
+			if cfg.Default.MaxDisk, err = parseLeaseDisk(d.Value); err != nil {
+				return LeasesConfig{}, fmt.Errorf("%s: MAX_DISK: %w", name, err)
+			}
+		case "WARN_BEFORE":
+			if cfg.WarnBefore, err = parseLeaseRuntime(d.Value); err != nil {
+				return LeasesConfig{}, fmt.Errorf("%s: WARN_BEFORE: %w", name, err)
+			}
+		case "LEASE":
+			project, lease, err := parseProjectLease(d.Value)
+			if err != nil {
+				return LeasesConfig{}, fmt.Errorf("%s: LEASE: %w", name, err)
+			}
+			if _, seen := cfg.projects[project]; seen {
+				return LeasesConfig{}, fmt.Errorf("%s: project %q has more than one LEASE", name, project)
+			}
+			cfg.projects[project] = lease
+		default:
+			fmt.Fprintf(os.Stderr, "Warning: %s: ignoring unknown directive %q\n", name, d.Key)
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadLeasesConfig_Missing(t *testing.T) {
+	cfg, err := LoadLeasesConfig(filepath.Join(t.TempDir(), "leases.conf"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.IsEmpty() {
+		t.Errorf("want no lease, got %+v", cfg)
+	}
+	if cfg.WarningPeriod() != DefaultLeaseWarning {
+		t.Errorf("WarningPeriod: want %s, got %s", DefaultLeaseWarning, cfg.WarningPeriod())
+	}
+}
+
+func TestLoadLeasesConfig(t *testing.T) {
+	path := writeConf(t, "# shared server\nMAX_RUNTIME 8h\nMAX_DISK 50g\nWARN_BEFORE 30m\n"+
+		"LEASE training MAX_RUNTIME=48h MAX_DISK=200g\nLEASE docs MAX_DISK=0\n")
+	cfg, err := LoadLeasesConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.WarningPeriod() != 30*time.Minute {
+		t.Errorf("WarningPeriod: want 30m, got %s", cfg.WarningPeriod())
+	}
+	for project, want := range map[string]Lease{
+		"other":    {MaxRuntime: 8 * time.Hour, MaxDisk: 50_000_000_000},
+		"training": {MaxRuntime: 48 * time.Hour, MaxDisk: 200_000_000_000},
+		"docs":     {MaxRuntime: 8 * time.Hour},
+	} {
+		if got := cfg.LeaseOf(project); got != want {
+			t.Errorf("LeaseOf(%q): want %+v, got %+v", project, want, got)
+		}
+	}
+	if cfg.IsEmpty() {
+		t.Error("IsEmpty: want false")
+	}
+}
+
+func TestLoadLeasesConfig_OnlyUnlimitedLeases(t *testing.T) {
+	cfg, err := LoadLeasesConfig(writeConf(t, "LEASE docs MAX_RUNTIME=0\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.IsEmpty() {
+		t.Errorf("IsEmpty: want true, got %+v", cfg)
+	}
+}
+
+func TestLoadLeasesConfig_InvalidValues(t *testing.T) {
+	for _, content := range []string{
+		"MAX_RUNTIME forever\n",
+		"MAX_DISK lots\n",
+		"WARN_BEFORE -5m\n",
+		"LEASE training\n",
+		"LEASE training MAX_CPU=4\n",
+		"LEASE training MAX_RUNTIME=1d\n",
+		"LEASE training MAX_DISK=1g\nLEASE training MAX_RUNTIME=1h\n",
+	} {
+		if _, err := LoadLeasesConfig(writeConf(t, content)); err == nil {
+			t.Errorf("LoadLeasesConfig(%q): want an error", content)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Value of TIMEZONE and LOCALE taking the host's setting.
+const HostSetting = "host"
+
+// e.g. "Europe/Paris", "UTC" or "Etc/GMT+2"
+var timezoneRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_+-]*(/[A-Za-z0-9_+-]+)*$`)
+
+// e.g. "fr_FR.UTF-8" or "sr_RS.UTF-8@latin"
+var localeRegex = regexp.MustCompile(`^[A-Za-z]+(_[A-Za-z0-9]+)?(\.[A-Za-z0-9-]+)?(@[A-Za-z0-9]+)?$`)
+
+// Parse the value of a TIMEZONE directive: `host` or a zone of the tz
+// database.
+func parseTimezone(value string) (string, error) {
+	if value != HostSetting && !timezoneRegex.MatchString(value) {
+		return "", fmt.Errorf("must be host or a timezone name, e.g. \"Europe/Paris\", got %q", value)
+	}
+	return value, nil
+}
+
+// Parse the value of a LOCALE directive: `host` or a locale name.
+func parseLocale(value string) (string, error) {
+	if value != HostSetting && !localeRegex.MatchString(value) {
+		return "", fmt.Errorf("must be host or a locale name, e.g. \"fr_FR.UTF-8\", got %q", value)
+	}
+	return value, nil
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// An additional host directory or file mounted in a project's container.
+type Mount struct {
+	// Path on the host, as written in run.conf
+	Source string
+	// Absolute path in the container
+	Target   string
+	ReadOnly bool
+	// optional; SELinux relabeling of the source: "z" to share it between
+	// containers, "Z" to make it private to this one, automatic if empty
+	Relabel string
+	// optional; if set, the source is never relabeled
+	NoRelabel bool
+}
+
+// Parse the value of a MOUNT directive: a host path, a target in the container
+// and optionally comma-separated options among `ro`, `rw`, `z`, `Z` and
+// `nolabel`.
+func parseMount(value string) (Mount, error) {
+	fields := strings.Fields(value)
+	if len(fields) < 2 || len(fields) > 3 {
+		return Mount{}, fmt.Errorf("must be a host path, a container path and optional options, got %q", value)
+	}
+	mount := Mount{Source: fields[0], Target: fields[1]}
+	// Only a Windows drive letter may come before a ':', which separates
+	// the engine's `--volume` fields
+	source := mount.Source
+	if len(source) >= 2 && source[1] == ':' {
+		source = source[2:]
+	}
+	if strings.Contains(source, ":") {
+		return Mount{}, fmt.Errorf("host path %q cannot contain ':'", mount.Source)
+	}
+	if !path.IsAbs(mount.Target) || path.Clean(mount.Target) == "/" || strings.Contains(mount.Target, ":") {
+		return Mount{}, fmt.Errorf("container path must be absolute, not the root and without ':', got %q", mount.Target)
+	}
+	if cleaned := path.Clean(mount.Target); cleaned == "/paul-env" || strings.HasPrefix(cleaned, "/paul-env/") {
+		return Mount{}, errors.New("container paths under /paul-env are reserved to paul-envs")
+	}
+	if len(fields) == 2 {
+		return mount, nil
+	}
+	var hasMode bool
+	for option := range strings.SplitSeq(fields[2], ",") {
+		switch option {
+		case "ro", "rw":
+			if hasMode {
+				return Mount{}, fmt.Errorf("options %q set both ro and rw", fields[2])
+			}
+			hasMode = true
+			mount.ReadOnly = option == "ro"
+		case "z", "Z", "nolabel":
+			if mount.Relabel != "" || mount.NoRelabel {
+				return Mount{}, fmt.Errorf("options %q set more than one of z, Z and nolabel", fields[2])
+			}
+			if option == "nolabel" {
+				mount.NoRelabel = true
+			} else {
+				mount.Relabel = option
+			}
+		default:
+			return Mount{}, fmt.Errorf("unknown option %q, expected ro, rw, z, Z or nolabel", option)
+		}
+	}
+	return mount, nil
+}
@@ -0,0 +1,43 @@
+package config
+
+import "testing"
+
+func TestParseMount(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Mount
+	}{
+		{"/srv/data /data", Mount{Source: "/srv/data", Target: "/data"}},
+		{"./shared /home/dev/shared ro", Mount{Source: "./shared", Target: "/home/dev/shared", ReadOnly: true}},
+		{"/srv/db /var/db rw,Z", Mount{Source: "/srv/db", Target: "/var/db", Relabel: "Z"}},
+		{"C:\\data /data ro,z", Mount{Source: "C:\\data", Target: "/data", ReadOnly: true, Relabel: "z"}},
+		{"/srv/data /data nolabel", Mount{Source: "/srv/data", Target: "/data", NoRelabel: true}},
+	}
+	for _, tt := range tests {
+		got, err := parseMount(tt.value)
+		if err != nil {
+			t.Errorf("parseMount(%q) error = %v", tt.value, err)
+		} else if got != tt.want {
+			t.Errorf("parseMount(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseMount_Invalid(t *testing.T) {
+	for _, value := range []string{
+		"/srv/data",
+		"/srv/data relative",
+		"/srv/data /",
+		"/srv/data /data ro extra",
+		"/srv/da:ta /data",
+		"/srv/data /data ro,rw",
+		"/srv/data /data z,Z",
+		"/srv/data /data Z,nolabel",
+		"/srv/data /data cached",
+		"/srv/data /paul-env/dotfiles",
+	} {
+		if _, err := parseMount(value); err == nil {
+			t.Errorf("parseMount(%q): expected an error, got none", value)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Package managers whose cache projects can share through a volume of its
+// own (`PACKAGE_CACHE` directive), with where it is relative to the container
+// user's home.
+var packageCachePaths = map[string]string{
+	"npm":    ".container-cache/.npm",
+	"yarn":   ".container-cache/.yarn",
+	"pip":    ".container-cache/pip",
+	"go":     ".container-cache/go/mod",
+	"cargo":  ".cargo/registry",
+	"maven":  ".m2/repository",
+	"gradle": ".gradle/caches",
+}
+
+// Names of the package managers whose cache can be shared, sorted.
+func PackageCacheNames() []string {
+	return slices.Sorted(maps.Keys(packageCachePaths))
+}
+
+// Path of the cache of the given package manager, relative to the container
+// user's home.
+func PackageCachePath(name string) string {
+	return packageCachePaths[name]
+}
+
+// Parse the space-separated package managers of a `PACKAGE_CACHE` directive.
+func parsePackageCaches(value string) ([]string, error) {
+	names := strings.Fields(value)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("must list at least one package manager")
+	}
+	for _, name := range names {
+		if _, ok := packageCachePaths[name]; !ok {
+			return nil, fmt.Errorf("unknown package manager %q, expected one of: %s",
+				name, strings.Join(PackageCacheNames(), ", "))
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"slices"
+	"strings"
+)
+
+// Proxy settings given to builds and containers, which can be set in run.conf
+// to replace those of the host.
+var proxyVariables = []string{"HTTP_PROXY", "HTTPS_PROXY", "FTP_PROXY", "ALL_PROXY", "NO_PROXY"}
+
+func isProxyVariable(name string) bool {
+	return slices.Contains(proxyVariables, name)
+}
+
+// Returns the proxy settings given to builds and containers, as sorted
+// "KEY=VALUE": those of `environ`, set in upper or lower case, replaced by
+// `overrides` (e.g. those of run.conf) where an empty value unsets one.
+//
+// Each is given under both its upper and lower case names, as tools read
+// either.
+func ProxyEnv(environ []string, overrides map[string]string) []string {
+	values := map[string]string{}
+	for _, variable := range environ {
+		name, value, _ := strings.Cut(variable, "=")
+		upper := strings.ToUpper(name)
+		if !isProxyVariable(upper) || value == "" {
+			continue
+		}
+		// The upper case one wins when both are set
+		if _, set := values[upper]; !set || name == upper {
+			values[upper] = value
+		}
+	}
+	for name, value := range overrides {
+		values[name] = value
+	}
+	var env []string
+	for _, name := range proxyVariables {
+		if value := values[name]; value != "" {
+			env = append(env, name+"="+value, strings.ToLower(name)+"="+value)
+		}
+	}
+	slices.Sort(env)
+	return env
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestProxyEnv(t *testing.T) {
+	environ := []string{
+		"http_proxy=http://lower:3128",
+		"HTTP_PROXY=http://proxy:3128",
+		"https_proxy=http://proxy:3129",
+		"NO_PROXY=localhost",
+		"FTP_PROXY=",
+		"HOME=/home/alice",
+	}
+	got := ProxyEnv(environ, nil)
+	want := []string{
+		"HTTPS_PROXY=http://proxy:3129",
+		"HTTP_PROXY=http://proxy:3128",
+		"NO_PROXY=localhost",
+		"http_proxy=http://proxy:3128",
+		"https_proxy=http://proxy:3129",
+		"no_proxy=localhost",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("ProxyEnv() = %v, want %v", got, want)
+	}
+
+	got = ProxyEnv(environ, map[string]string{"HTTPS_PROXY": "", "NO_PROXY": "localhost,.corp.example"})
+	want = []string{
+		"HTTP_PROXY=http://proxy:3128",
+		"NO_PROXY=localhost,.corp.example",
+		"http_proxy=http://proxy:3128",
+		"no_proxy=localhost,.corp.example",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("ProxyEnv() with overrides = %v, want %v", got, want)
+	}
+	if got := ProxyEnv([]string{"HOME=/home/alice"}, nil); len(got) != 0 {
+		t.Fatalf("ProxyEnv() without proxy = %v, want none", got)
+	}
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// A host name resolved to a fixed address in the container, declared with
+// HOST in run.conf.
+type HostEntry struct {
+	Name string
+	IP   string
+}
+
+var hostNameRegex = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?)*$`)
+
+// Parse the value of a HOST directive: a host name followed by its address
+// (e.g. "git.corp.example 10.0.0.12").
+func parseHostEntry(value string) (HostEntry, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 2 || !hostNameRegex.MatchString(fields[0]) || net.ParseIP(fields[1]) == nil {
+		return HostEntry{}, fmt.Errorf("must be a host name followed by an IP address, e.g. \"git.corp.example 10.0.0.12\", got %q", value)
+	}
+	return HostEntry{Name: fields[0], IP: fields[1]}, nil
+}
+
+// Parse the value of a DNS directive: addresses of DNS servers.
+func parseDNSServers(value string) ([]string, error) {
+	servers := strings.Fields(value)
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("must list at least one IP address")
+	}
+	for _, server := range servers {
+		if net.ParseIP(server) == nil {
+			return nil, fmt.Errorf("%q is not an IP address", server)
+		}
+	}
+	return servers, nil
+}
+
+// Parse the value of a DNS_SEARCH directive: domains searched for host names
+// which are not fully qualified.
+func parseDNSSearchDomains(value string) ([]string, error) {
+	domains := strings.Fields(value)
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("must list at least one domain")
+	}
+	for _, domain := range domains {
+		if !hostNameRegex.MatchString(domain) {
+			return nil, fmt.Errorf("%q is not a domain name", domain)
+		}
+	}
+	return domains, nil
+}
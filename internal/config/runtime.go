@@ -3,26 +3,307 @@ package config
 import (
 	"fmt"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/peaberberian/paul-envs/internal/profiling"
 	"github.com/peaberberian/paul-envs/internal/utils"
 )
 
 // RuntimeConfig holds the parsed contents of a run.conf file.
 type RuntimeConfig struct {
-	Version      utils.Version
-	ProjectPath  string
-	Volumes      []string
-	Ports        []string
-	WorkDir      string // optional; defaults to the project mount target if empty
-	DotfilesPath string // optional; if set, mounted read-only and synced into $HOME on start
-	GitName      string // optional; applied to git/jj at container start
-	GitEmail     string // optional; applied to git/jj at container start
+	Version           utils.Version
+	ProjectPath       string
+	Volumes           []string
+	Ports             []string
+	WorkDir           string   // optional; defaults to the project mount target if empty
+	DotfilesPath      string   // optional; if set, mounted read-only and synced into $HOME on start
+	DotfilesProfile   string   // optional; name of a shared dotfiles profile used instead of DotfilesPath
+	GitName           string   // optional; applied to git/jj at container start
+	GitEmail          string   // optional; applied to git/jj at container start
+	Cpus              string   // optional; maximum number of CPUs, e.g. "2" or "1.5"
+	Memory            string   // optional; memory limit, e.g. "4g" or "512m"
+	PidsLimit         string   // optional; maximum number of processes
+	NoBanner          bool     // optional; if set, no startup banner is displayed on run
+	ShowReadme        bool     // optional; if set, the project's README is displayed on the first run of a new image
+	PersistentSession bool     // optional; if set, shells run in a tmux session which outlives the terminals attached to it
+	SSHPort           string   // optional; host port (on the loopback) forwarded to the container's ssh server
+	Display           bool     // optional; if set, the host's Wayland and X11 displays are forwarded
+	Audio             bool     // optional; if set, the host's PulseAudio and PipeWire sockets are forwarded
+	SSHAgent          bool     // optional; if set, the host's ssh agent is forwarded
+	GitCredentials    bool     // optional; if set, git asks the host's credential helpers while `run` goes
+	GPGAgent          bool     // optional; if set, the host's gpg-agent is forwarded
+	HostCommands      []string // optional; host commands the container may run through `paulenv-host` while `run` goes
+	Clipboard         bool     // optional; if set, the container can copy to and paste from the host's clipboard while `run` goes
+	Groups            []string // optional; host groups added to the container user (e.g. "video")
+	// optional; number of previously built images kept for rollbacks,
+	// `DefaultImageGenerations` if nil
+	ImageGenerations *int
+	// optional; total size in bytes above which the oldest previously built
+	// images are removed, `0` for no limit
+	ImageGenerationsMaxSize int64
+	// optional; additional containers started alongside the project's one
+	Services []Service
+	// optional; name of the project's own service, by which its sidecars reach
+	// it, the project name if empty
+	MainService string
+	// optional; if set, the project's container and its sidecars join the
+	// network shared by all projects setting it, instead of their own
+	SharedNetwork bool
+	// optional; host names resolved to fixed addresses in the container
+	ExtraHosts []HostEntry
+	// optional; DNS servers used by the container instead of the engine's
+	// default ones
+	DNSServers []string
+	// optional; domains searched for host names which are not fully qualified
+	DNSSearch []string
+	// optional; proxy settings (e.g. "HTTP_PROXY") replacing those of the
+	// host for builds and containers, an empty value (`none` in run.conf)
+	// unsetting it
+	Proxy map[string]string
+	// optional; maximum duration of each build attempt, replacing the global
+	// one, `0` for no limit
+	BuildTimeout *time.Duration
+	// optional; number of times a build failing on a transient error is
+	// retried, replacing the global setting
+	BuildRetries *int
+	// optional; duration after which its containers are stopped once nothing
+	// is attached to them, replacing the global one, `0` to never stop them
+	IdleTimeout *time.Duration
+	// optional; maximum duration waited for its services with a healthcheck
+	// to be ready, `0` to not wait for them
+	ServiceWaitTimeout *time.Duration
+	// optional; duration for which its services keep running once its last
+	// container exited, in case it is run again, `0` to stop them right away
+	ServiceGracePeriod time.Duration
+	// optional; user namespace of the container
+	Userns Userns
+	// optional; timezone of the container (`TZ`), `HostSetting` for the
+	// host's one
+	Timezone string
+	// optional; locale of the container (`LANG`), `HostSetting` for the
+	// host's one
+	Locale string
+	// optional; scripts run in that order when the container starts
+	StartupScripts []StartupScript
+	// optional; scripts sourced in that order by the container's shells, and
+	// by those running its commands, e.g. to load a version manager
+	ActivationScripts []string
+	// optional; background processes the entrypoint starts and restarts when
+	// they exit
+	Processes []Process
+	// optional; secrets given to the container as environment variables
+	Secrets []Secret
+	// optional; additional host paths mounted in the container
+	Mounts []Mount
+	// optional; if set, bind mounts are not relabeled for SELinux on hosts
+	// enforcing it
+	NoSELinuxRelabel bool
+	// optional; if set, the container runs with a read-only root filesystem,
+	// without new privileges and with only the capabilities needed by its
+	// entrypoint
+	Hardened bool
+	// optional; capabilities kept on top of those of the hardened profile,
+	// without their `CAP_` prefix
+	HardenedCapabilities []string
+	// optional; seccomp profile applied instead of the engine's default one
+	SeccompProfile string
+	// optional; paths hidden in the container, relative ones being relative to
+	// the mounted project directory
+	MaskedPaths []string
+	// optional; names of host environment variables given to the container,
+	// where `*` matches any characters (e.g. "AWS_*")
+	PassthroughEnv []string
+	// optional; package managers (e.g. "npm", "cargo") whose cache is a
+	// volume of its own, shared with the other projects listing them
+	PackageCaches []string
+	// optional; volumes shared on purpose with the other projects declaring
+	// them
+	SharedVolumes []SharedVolume
+	// optional; tmpfs mounted in the container
+	Tmpfs []TmpfsMount
 }
 
+// What to do when a startup script of a project fails.
+type StartupFailurePolicy string
+
+const (
+	// Stop the container before starting the shell or command
+	StartupAbort StartupFailurePolicy = "abort"
+	// Tell about it and go on with the next scripts
+	StartupWarn StartupFailurePolicy = "warn"
+)
+
+// A script run in the project's container when it starts, before its shell
+// or command.
+type StartupScript struct {
+	// Path on the host, as written in run.conf
+	Path      string
+	OnFailure StartupFailurePolicy
+}
+
+// Parse the value of a STARTUP directive: a path optionally followed by a
+// failure policy, "abort" by default.
+func parseStartupScript(value string) (StartupScript, error) {
+	script := StartupScript{Path: value, OnFailure: StartupAbort}
+	if i := strings.LastIndexAny(value, " \t"); i >= 0 {
+		switch policy := StartupFailurePolicy(value[i+1:]); policy {
+		case StartupAbort, StartupWarn:
+			script.Path = strings.TrimSpace(value[:i])
+			script.OnFailure = policy
+		}
+	}
+	if script.Path == "" || strings.Contains(script.Path, "\n") {
+		return StartupScript{}, fmt.Errorf("must be a script path optionally followed by abort or warn, got %q", value)
+	}
+	return script, nil
+}
+
+// A background process of a project's container (e.g. a language server),
+// supervised by its entrypoint.
+type Process struct {
+	Name string
+	// Shell command starting it, which should not return while it runs
+	Command string
+	// optional; if set, it runs as root instead of the container user
+	Root bool
+}
+
+// A sidecar service of a project (e.g. a database), running in its own
+// container reachable from the project's one through its name.
+type Service struct {
+	Name  string
+	Image string
+	// Environment variables, as "KEY=VALUE"
+	Env []string
+	// optional; directory of its container persisted across runs
+	DataPath string
+	// optional; profiles it belongs to, in which case it is only started when
+	// one of them is requested (like compose's `profiles`)
+	Profiles []string
+	// optional; shell command run in its container, succeeding once it is
+	// ready to be used (like compose's `healthcheck`)
+	Healthcheck string
+}
+
+// Returns `true` if that service is started when running its project with
+// the given profiles, which is always the case if it belongs to none.
+func (s Service) EnabledBy(profiles []string) bool {
+	if len(s.Profiles) == 0 {
+		return true
+	}
+	for _, profile := range profiles {
+		if slices.Contains(s.Profiles, profile) {
+			return true
+		}
+	}
+	return false
+}
+
+var serviceNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Names of host commands, looked up in the host's PATH
+var hostCommandRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.+-]*$`)
+
+var dotfilesProfileNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Check that the given name can designate a dotfiles profile, which is a
+// directory name.
+func ValidateDotfilesProfileName(name string) error {
+	if !dotfilesProfileNameRegex.MatchString(name) {
+		return fmt.Errorf("must be a name made of letters, digits, '.', '_' and '-', got %q", name)
+	}
+	return nil
+}
+
+// Name of the project's own service, by which its sidecars reach it and under
+// which it is exported to compose.
+func (c RuntimeConfig) MainServiceName(projectName string) string {
+	if c.MainService == "" {
+		return projectName
+	}
+	return c.MainService
+}
+
+// Returns the profiles its services belong to, in declaration order.
+func (c RuntimeConfig) ServiceProfiles() []string {
+	var profiles []string
+	for _, service := range c.Services {
+		for _, profile := range service.Profiles {
+			if !slices.Contains(profiles, profile) {
+				profiles = append(profiles, profile)
+			}
+		}
+	}
+	return profiles
+}
+
+// Whether `run` has to serve requests of its container on the host
+// (`HOST_COMMAND` and `CLIPBOARD` directives).
+func (c RuntimeConfig) ServesHostRequests() bool {
+	return len(c.HostCommands) > 0 || c.Clipboard
+}
+
+// Number of previously built images of a project kept by default.
+const DefaultImageGenerations = 2
+
+// Number of previously built images of that project to keep.
+func (c RuntimeConfig) KeptImageGenerations() int {
+	if c.ImageGenerations == nil {
+		return DefaultImageGenerations
+	}
+	return *c.ImageGenerations
+}
+
+// Maximum duration waited by default for services to be ready.
+const DefaultServiceWaitTimeout = time.Minute
+
+// Maximum duration to wait for its services with a healthcheck to be ready,
+// `0` if they should not be waited for.
+func (c RuntimeConfig) ServiceReadyTimeout() time.Duration {
+	if c.ServiceWaitTimeout == nil {
+		return DefaultServiceWaitTimeout
+	}
+	return *c.ServiceWaitTimeout
+}
+
+func validateServiceWaitTimeout(value string) error {
+	if v, err := time.ParseDuration(value); err != nil || v < 0 {
+		return fmt.Errorf("expected a duration, e.g. \"30s\" or \"2m\", got %q", value)
+	}
+	return nil
+}
+
+// Returns the background process with that name, `nil` if there's none.
+func (c *RuntimeConfig) findProcess(name string) *Process {
+	for i := range c.Processes {
+		if c.Processes[i].Name == name {
+			return &c.Processes[i]
+		}
+	}
+	return nil
+}
+
+// Returns the service with that name, `nil` if there's none.
+func (c *RuntimeConfig) findService(name string) *Service {
+	for i := range c.Services {
+		if c.Services[i].Name == name {
+			return &c.Services[i]
+		}
+	}
+	return nil
+}
+
+var memoryLimitRegex = regexp.MustCompile(`^[0-9]+[bkmgBKMG]?$`)
+
 // LoadRuntimeConfig parses the run.conf file at path and returns a
 // RuntimeConfig. It returns an error if the file cannot be parsed or if the
 // required PATH directive is absent.
 func LoadRuntimeConfig(path string) (RuntimeConfig, error) {
+	defer profiling.Track(profiling.CategoryFiles, "load "+path)()
 	directives, err := ParseFile(path)
 	if err != nil {
 		return RuntimeConfig{}, fmt.Errorf("load runtime config %s: %w", filepath.Base(path), err)
@@ -40,16 +321,414 @@ func LoadRuntimeConfig(path string) (RuntimeConfig, error) {
 			cfg.ProjectPath = d.Value
 		case "VOLUME":
 			cfg.Volumes = append(cfg.Volumes, d.Value)
+		case "MOUNT":
+			mount, err := parseMount(d.Value)
+			if err != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: MOUNT %w", filepath.Base(path), err)
+			}
+			cfg.Mounts = append(cfg.Mounts, mount)
 		case "PORT":
 			cfg.Ports = append(cfg.Ports, d.Value)
 		case "WORKDIR":
 			cfg.WorkDir = d.Value
 		case "DOTFILES_PATH":
 			cfg.DotfilesPath = d.Value
+		case "DOTFILES_PROFILE":
+			if err := ValidateDotfilesProfileName(d.Value); err != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: DOTFILES_PROFILE %w", filepath.Base(path), err)
+			}
+			cfg.DotfilesProfile = d.Value
 		case "GIT_AUTHOR_NAME":
 			cfg.GitName = d.Value
 		case "GIT_AUTHOR_EMAIL":
 			cfg.GitEmail = d.Value
+		case "CPUS":
+			if v, err := strconv.ParseFloat(d.Value, 64); err != nil || v <= 0 {
+				return RuntimeConfig{}, fmt.Errorf("%s: CPUS must be a positive number, got %q", filepath.Base(path), d.Value)
+			}
+			cfg.Cpus = d.Value
+		case "MEMORY":
+			if !memoryLimitRegex.MatchString(d.Value) {
+				return RuntimeConfig{}, fmt.Errorf("%s: MEMORY must be a size such as 512m or 4g, got %q", filepath.Base(path), d.Value)
+			}
+			cfg.Memory = d.Value
+		case "PIDS_LIMIT":
+			if v, err := strconv.Atoi(d.Value); err != nil || v <= 0 {
+				return RuntimeConfig{}, fmt.Errorf("%s: PIDS_LIMIT must be a positive integer, got %q", filepath.Base(path), d.Value)
+			}
+			cfg.PidsLimit = d.Value
+		case "BANNER":
+			switch d.Value {
+			case "true":
+				cfg.NoBanner = false
+			case "false":
+				cfg.NoBanner = true
+			default:
+				return RuntimeConfig{}, fmt.Errorf("%s: BANNER must be true or false, got %q", filepath.Base(path), d.Value)
+			}
+		case "SHOW_README":
+			switch d.Value {
+			case "true":
+				cfg.ShowReadme = true
+			case "false":
+				cfg.ShowReadme = false
+			default:
+				return RuntimeConfig{}, fmt.Errorf("%s: SHOW_README must be true or false, got %q", filepath.Base(path), d.Value)
+			}
+		case "DISPLAY":
+			switch d.Value {
+			case "true":
+				cfg.Display = true
+			case "false":
+				cfg.Display = false
+			default:
+				return RuntimeConfig{}, fmt.Errorf("%s: DISPLAY must be true or false, got %q", filepath.Base(path), d.Value)
+			}
+		case "SELINUX_RELABEL":
+			switch d.Value {
+			case "true":
+				cfg.NoSELinuxRelabel = false
+			case "false":
+				cfg.NoSELinuxRelabel = true
+			default:
+				return RuntimeConfig{}, fmt.Errorf("%s: SELINUX_RELABEL must be true or false, got %q", filepath.Base(path), d.Value)
+			}
+		case "HARDENED":
+			switch d.Value {
+			case "true":
+				cfg.Hardened = true
+			case "false":
+				cfg.Hardened = false
+			default:
+				return RuntimeConfig{}, fmt.Errorf("%s: HARDENED must be true or false, got %q", filepath.Base(path), d.Value)
+			}
+		case "HARDENED_CAPS":
+			capabilities, err := parseCapabilities(d.Value)
+			if err != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: HARDENED_CAPS %w", filepath.Base(path), err)
+			}
+			cfg.HardenedCapabilities = append(cfg.HardenedCapabilities, capabilities...)
+		case "SECCOMP_PROFILE":
+			if strings.Contains(d.Value, ",") {
+				return RuntimeConfig{}, fmt.Errorf("%s: SECCOMP_PROFILE path cannot contain ',', got %q", filepath.Base(path), d.Value)
+			}
+			cfg.SeccompProfile = d.Value
+		case "MASK":
+			masked, err := parseMaskedPath(d.Value)
+			if err != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: MASK %w", filepath.Base(path), err)
+			}
+			cfg.MaskedPaths = append(cfg.MaskedPaths, masked)
+		case "GROUP":
+			if strings.ContainsAny(d.Value, " \t:") {
+				return RuntimeConfig{}, fmt.Errorf("%s: GROUP must be a single group name, got %q", filepath.Base(path), d.Value)
+			}
+			cfg.Groups = append(cfg.Groups, d.Value)
+		case "AUDIO":
+			switch d.Value {
+			case "true":
+				cfg.Audio = true
+			case "false":
+				cfg.Audio = false
+			default:
+				return RuntimeConfig{}, fmt.Errorf("%s: AUDIO must be true or false, got %q", filepath.Base(path), d.Value)
+			}
+		case "SSH_AGENT":
+			switch d.Value {
+			case "true":
+				cfg.SSHAgent = true
+			case "false":
+				cfg.SSHAgent = false
+			default:
+				return RuntimeConfig{}, fmt.Errorf("%s: SSH_AGENT must be true or false, got %q", filepath.Base(path), d.Value)
+			}
+		case "GIT_CREDENTIALS":
+			switch d.Value {
+			case "true":
+				cfg.GitCredentials = true
+			case "false":
+				cfg.GitCredentials = false
+			default:
+				return RuntimeConfig{}, fmt.Errorf("%s: GIT_CREDENTIALS must be true or false, got %q", filepath.Base(path), d.Value)
+			}
+		case "PERSISTENT_SESSION":
+			switch d.Value {
+			case "true":
+				cfg.PersistentSession = true
+			case "false":
+				cfg.PersistentSession = false
+			default:
+				return RuntimeConfig{}, fmt.Errorf("%s: PERSISTENT_SESSION must be true or false, got %q", filepath.Base(path), d.Value)
+			}
+		case "HOST_COMMAND":
+			for _, name := range strings.Fields(d.Value) {
+				if !hostCommandRegex.MatchString(name) {
+					return RuntimeConfig{}, fmt.Errorf("%s: HOST_COMMAND must be followed by command names found in the host's PATH, e.g. \"xdg-open notify-send\", got %q", filepath.Base(path), name)
+				}
+				if !slices.Contains(cfg.HostCommands, name) {
+					cfg.HostCommands = append(cfg.HostCommands, name)
+				}
+			}
+		case "CLIPBOARD":
+			switch d.Value {
+			case "true":
+				cfg.Clipboard = true
+			case "false":
+				cfg.Clipboard = false
+			default:
+				return RuntimeConfig{}, fmt.Errorf("%s: CLIPBOARD must be true or false, got %q", filepath.Base(path), d.Value)
+			}
+		case "GPG_AGENT":
+			switch d.Value {
+			case "true":
+				cfg.GPGAgent = true
+			case "false":
+				cfg.GPGAgent = false
+			default:
+				return RuntimeConfig{}, fmt.Errorf("%s: GPG_AGENT must be true or false, got %q", filepath.Base(path), d.Value)
+			}
+		case "IMAGE_GENERATIONS":
+			v, err := strconv.Atoi(d.Value)
+			if err != nil || v < 0 {
+				return RuntimeConfig{}, fmt.Errorf("%s: IMAGE_GENERATIONS must be a positive number or 0, got %q", filepath.Base(path), d.Value)
+			}
+			cfg.ImageGenerations = &v
+		case "IMAGE_GENERATIONS_MAX_SIZE":
+			v, err := utils.ParseSize(d.Value)
+			if err != nil || v <= 0 {
+				return RuntimeConfig{}, fmt.Errorf("%s: IMAGE_GENERATIONS_MAX_SIZE must be a size such as 500m or 10g, got %q", filepath.Base(path), d.Value)
+			}
+			cfg.ImageGenerationsMaxSize = v
+		case "SERVICE":
+			name, image, _ := strings.Cut(d.Value, " ")
+			image = strings.TrimSpace(image)
+			if !serviceNameRegex.MatchString(name) || image == "" || strings.ContainsAny(image, " \t") {
+				return RuntimeConfig{}, fmt.Errorf("%s: SERVICE must be a lowercase name followed by an image, e.g. \"db postgres:16\", got %q", filepath.Base(path), d.Value)
+			}
+			if cfg.findService(name) != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: service %q is declared more than once", filepath.Base(path), name)
+			}
+			cfg.Services = append(cfg.Services, Service{Name: name, Image: image})
+		case "SERVICE_ENV":
+			name, env, _ := strings.Cut(d.Value, " ")
+			service := cfg.findService(name)
+			if service == nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: SERVICE_ENV refers to service %q, which has to be declared first with SERVICE", filepath.Base(path), name)
+			}
+			env = strings.TrimSpace(env)
+			if key, _, ok := strings.Cut(env, "="); !ok || key == "" {
+				return RuntimeConfig{}, fmt.Errorf("%s: SERVICE_ENV must be a service name followed by KEY=VALUE, got %q", filepath.Base(path), d.Value)
+			}
+			service.Env = append(service.Env, env)
+		case "SERVICE_DATA":
+			name, dataPath, _ := strings.Cut(d.Value, " ")
+			service := cfg.findService(name)
+			if service == nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: SERVICE_DATA refers to service %q, which has to be declared first with SERVICE", filepath.Base(path), name)
+			}
+			dataPath = strings.TrimSpace(dataPath)
+			if !strings.HasPrefix(dataPath, "/") || service.DataPath != "" {
+				return RuntimeConfig{}, fmt.Errorf("%s: SERVICE_DATA must be given once per service, as a service name followed by an absolute path, got %q", filepath.Base(path), d.Value)
+			}
+			service.DataPath = dataPath
+		case "SERVICE_PROFILE":
+			name, profiles, _ := strings.Cut(d.Value, " ")
+			service := cfg.findService(name)
+			if service == nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: SERVICE_PROFILE refers to service %q, which has to be declared first with SERVICE", filepath.Base(path), name)
+			}
+			fields := strings.Fields(profiles)
+			if len(fields) == 0 {
+				return RuntimeConfig{}, fmt.Errorf("%s: SERVICE_PROFILE must be a service name followed by one or more profile names, got %q", filepath.Base(path), d.Value)
+			}
+			for _, profile := range fields {
+				if !serviceNameRegex.MatchString(profile) {
+					return RuntimeConfig{}, fmt.Errorf("%s: SERVICE_PROFILE profiles must be lowercase names, got %q", filepath.Base(path), profile)
+				}
+				if !slices.Contains(service.Profiles, profile) {
+					service.Profiles = append(service.Profiles, profile)
+				}
+			}
+		case "SERVICE_HEALTHCHECK":
+			name, command, _ := strings.Cut(d.Value, " ")
+			service := cfg.findService(name)
+			if service == nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: SERVICE_HEALTHCHECK refers to service %q, which has to be declared first with SERVICE", filepath.Base(path), name)
+			}
+			command = strings.TrimSpace(command)
+			if command == "" || service.Healthcheck != "" {
+				return RuntimeConfig{}, fmt.Errorf("%s: SERVICE_HEALTHCHECK must be given once per service, as a service name followed by a command, got %q", filepath.Base(path), d.Value)
+			}
+			service.Healthcheck = command
+		case "SERVICE_WAIT_TIMEOUT":
+			if err := validateServiceWaitTimeout(d.Value); err != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: SERVICE_WAIT_TIMEOUT: %w", filepath.Base(path), err)
+			}
+			timeout, _ := time.ParseDuration(d.Value)
+			cfg.ServiceWaitTimeout = &timeout
+		case "SERVICE_GRACE_PERIOD":
+			if err := validateServiceWaitTimeout(d.Value); err != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: SERVICE_GRACE_PERIOD: %w", filepath.Base(path), err)
+			}
+			cfg.ServiceGracePeriod, _ = time.ParseDuration(d.Value)
+		case "MAIN_SERVICE":
+			if !serviceNameRegex.MatchString(d.Value) {
+				return RuntimeConfig{}, fmt.Errorf("%s: MAIN_SERVICE must be a lowercase name, got %q", filepath.Base(path), d.Value)
+			}
+			cfg.MainService = d.Value
+		case "SHARED_NETWORK":
+			switch d.Value {
+			case "true":
+				cfg.SharedNetwork = true
+			case "false":
+				cfg.SharedNetwork = false
+			default:
+				return RuntimeConfig{}, fmt.Errorf("%s: SHARED_NETWORK must be true or false, got %q", filepath.Base(path), d.Value)
+			}
+		case "STARTUP":
+			script, err := parseStartupScript(d.Value)
+			if err != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: STARTUP %w", filepath.Base(path), err)
+			}
+			for _, other := range cfg.StartupScripts {
+				if other.Path == script.Path {
+					return RuntimeConfig{}, fmt.Errorf("%s: startup script %q is declared more than once", filepath.Base(path), script.Path)
+				}
+			}
+			cfg.StartupScripts = append(cfg.StartupScripts, script)
+		case "ACTIVATE":
+			if strings.TrimSpace(d.Value) == "" {
+				return RuntimeConfig{}, fmt.Errorf("%s: ACTIVATE must be followed by a script path", filepath.Base(path))
+			}
+			if slices.Contains(cfg.ActivationScripts, d.Value) {
+				return RuntimeConfig{}, fmt.Errorf("%s: activation script %q is declared more than once", filepath.Base(path), d.Value)
+			}
+			cfg.ActivationScripts = append(cfg.ActivationScripts, d.Value)
+		case "PROCESS":
+			name, command, _ := strings.Cut(d.Value, " ")
+			command = strings.TrimSpace(command)
+			if !serviceNameRegex.MatchString(name) || command == "" || strings.Contains(command, "\n") {
+				return RuntimeConfig{}, fmt.Errorf("%s: PROCESS must be a lowercase name followed by a command, e.g. \"lsp gopls serve\", got %q", filepath.Base(path), d.Value)
+			}
+			if cfg.findProcess(name) != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: process %q is declared more than once", filepath.Base(path), name)
+			}
+			cfg.Processes = append(cfg.Processes, Process{Name: name, Command: command})
+		case "PROCESS_ROOT":
+			process := cfg.findProcess(strings.TrimSpace(d.Value))
+			if process == nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: PROCESS_ROOT refers to process %q, which has to be declared first with PROCESS", filepath.Base(path), d.Value)
+			}
+			process.Root = true
+		case "SECRET":
+			secret, err := parseSecret(d.Value)
+			if err != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: SECRET %w", filepath.Base(path), err)
+			}
+			cfg.Secrets = append(cfg.Secrets, secret)
+		case "PASSTHROUGH_ENV":
+			patterns, err := parsePassthroughEnv(d.Value)
+			if err != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: PASSTHROUGH_ENV %w", filepath.Base(path), err)
+			}
+			cfg.PassthroughEnv = append(cfg.PassthroughEnv, patterns...)
+		case "HOST":
+			entry, err := parseHostEntry(d.Value)
+			if err != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: HOST %w", filepath.Base(path), err)
+			}
+			cfg.ExtraHosts = append(cfg.ExtraHosts, entry)
+		case "DNS":
+			servers, err := parseDNSServers(d.Value)
+			if err != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: DNS %w", filepath.Base(path), err)
+			}
+			cfg.DNSServers = append(cfg.DNSServers, servers...)
+		case "DNS_SEARCH":
+			domains, err := parseDNSSearchDomains(d.Value)
+			if err != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: DNS_SEARCH %w", filepath.Base(path), err)
+			}
+			cfg.DNSSearch = append(cfg.DNSSearch, domains...)
+		case "HTTP_PROXY", "HTTPS_PROXY", "FTP_PROXY", "ALL_PROXY", "NO_PROXY":
+			if cfg.Proxy == nil {
+				cfg.Proxy = map[string]string{}
+			}
+			// Not given the host's one
+			if d.Value == "none" {
+				cfg.Proxy[d.Key] = ""
+			} else {
+				cfg.Proxy[d.Key] = d.Value
+			}
+		case "BUILD_TIMEOUT":
+			if err := validateBuildTimeout(d.Value); err != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: BUILD_TIMEOUT: %w", filepath.Base(path), err)
+			}
+			timeout, _ := time.ParseDuration(d.Value)
+			cfg.BuildTimeout = &timeout
+		case "IDLE_TIMEOUT":
+			if err := validateIdleTimeout(d.Value); err != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: IDLE_TIMEOUT: %w", filepath.Base(path), err)
+			}
+			timeout, _ := time.ParseDuration(d.Value)
+			cfg.IdleTimeout = &timeout
+		case "BUILD_RETRIES":
+			if err := validateBuildRetries(d.Value); err != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: BUILD_RETRIES: %w", filepath.Base(path), err)
+			}
+			retries, _ := strconv.Atoi(d.Value)
+			cfg.BuildRetries = &retries
+		case "USERNS":
+			userns, err := parseUserns(d.Value)
+			if err != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: USERNS %w", filepath.Base(path), err)
+			}
+			cfg.Userns = userns
+		case "TIMEZONE":
+			timezone, err := parseTimezone(d.Value)
+			if err != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: TIMEZONE %w", filepath.Base(path), err)
+			}
+			cfg.Timezone = timezone
+		case "LOCALE":
+			locale, err := parseLocale(d.Value)
+			if err != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: LOCALE %w", filepath.Base(path), err)
+			}
+			cfg.Locale = locale
+		case "PACKAGE_CACHE":
+			caches, err := parsePackageCaches(d.Value)
+			if err != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: PACKAGE_CACHE %w", filepath.Base(path), err)
+			}
+			for _, cache := range caches {
+				if !slices.Contains(cfg.PackageCaches, cache) {
+					cfg.PackageCaches = append(cfg.PackageCaches, cache)
+				}
+			}
+		case "SHARED_VOLUME":
+			volume, err := parseSharedVolume(d.Value)
+			if err != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: SHARED_VOLUME %w", filepath.Base(path), err)
+			}
+			if slices.ContainsFunc(cfg.SharedVolumes, func(v SharedVolume) bool { return v.Name == volume.Name }) {
+				return RuntimeConfig{}, fmt.Errorf("%s: SHARED_VOLUME %q is declared more than once", filepath.Base(path), volume.Name)
+			}
+			cfg.SharedVolumes = append(cfg.SharedVolumes, volume)
+		case "TMPFS":
+			mount, err := parseTmpfs(d.Value)
+			if err != nil {
+				return RuntimeConfig{}, fmt.Errorf("%s: TMPFS %w", filepath.Base(path), err)
+			}
+			if slices.ContainsFunc(cfg.Tmpfs, func(m TmpfsMount) bool { return m.Target == mount.Target }) {
+				return RuntimeConfig{}, fmt.Errorf("%s: TMPFS %q is declared more than once", filepath.Base(path), mount.Target)
+			}
+			cfg.Tmpfs = append(cfg.Tmpfs, mount)
+		case "SSH_PORT":
+			if v, err := strconv.Atoi(d.Value); err != nil || v <= 0 || v > 65535 {
+				return RuntimeConfig{}, fmt.Errorf("%s: SSH_PORT must be a port number, got %q", filepath.Base(path), d.Value)
+			}
+			cfg.SSHPort = d.Value
 		default:
 			return RuntimeConfig{}, fmt.Errorf("%s: unknown directive %q", filepath.Base(path), d.Key)
 		}
@@ -58,6 +737,13 @@ func LoadRuntimeConfig(path string) (RuntimeConfig, error) {
 	if cfg.ProjectPath == "" {
 		return RuntimeConfig{}, fmt.Errorf("%s: required directive PATH is missing", filepath.Base(path))
 	}
+	if cfg.MainService != "" && cfg.findService(cfg.MainService) != nil {
+		return RuntimeConfig{}, fmt.Errorf("%s: MAIN_SERVICE %q is also declared as a SERVICE", filepath.Base(path), cfg.MainService)
+	}
+	if cfg.Hardened && len(cfg.Groups) > 0 {
+		// Groups are registered in /etc/group, which is read-only then
+		return RuntimeConfig{}, fmt.Errorf("%s: GROUP cannot be used with HARDENED", filepath.Base(path))
+	}
 
 	return cfg, nil
 }
@@ -3,7 +3,10 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"testing"
+	"time"
 )
 
 // writeConf writes content to a temp file and returns its path.
@@ -195,6 +198,500 @@ func TestLoadRuntimeConfig_UnknownDirective(t *testing.T) {
 	}
 }
 
+func TestLoadRuntimeConfig_ResourceLimits(t *testing.T) {
+	content := "VERSION 1.2.0\nPATH /srv/myproject\nCPUS 1.5\nMEMORY 4g\nPIDS_LIMIT 512\n"
+	cfg, err := LoadRuntimeConfig(writeConf(t, content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Cpus != "1.5" || cfg.Memory != "4g" || cfg.PidsLimit != "512" {
+		t.Errorf("resource limits: want 1.5/4g/512, got %q/%q/%q", cfg.Cpus, cfg.Memory, cfg.PidsLimit)
+	}
+}
+
+func TestLoadRuntimeConfig_InvalidResourceLimits(t *testing.T) {
+	for _, line := range []string{"CPUS 0", "CPUS many", "MEMORY 4 gigs", "PIDS_LIMIT -1"} {
+		content := "VERSION 1.2.0\nPATH /srv/myproject\n" + line + "\n"
+		if _, err := LoadRuntimeConfig(writeConf(t, content)); err == nil {
+			t.Errorf("expected error for %q, got nil", line)
+		}
+	}
+}
+
+func TestLoadRuntimeConfig_Banner(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nBANNER false\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.NoBanner {
+		t.Errorf("NoBanner: want true with BANNER false")
+	}
+	if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nBANNER off\n")); err == nil {
+		t.Errorf("expected error for BANNER off, got nil")
+	}
+}
+
+func TestLoadRuntimeConfig_Hardened(t *testing.T) {
+	content := "VERSION 1.2.0\nPATH /srv/myproject\nHARDENED true\nHARDENED_CAPS cap_net_bind_service SYS_PTRACE\n" +
+		"SECCOMP_PROFILE seccomp.json\nMASK .env\nMASK /etc/hostname\n"
+	cfg, err := LoadRuntimeConfig(writeConf(t, content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Hardened || cfg.SeccompProfile != "seccomp.json" {
+		t.Errorf("Hardened, SeccompProfile: got %v, %q", cfg.Hardened, cfg.SeccompProfile)
+	}
+	if !reflect.DeepEqual(cfg.HardenedCapabilities, []string{"NET_BIND_SERVICE", "SYS_PTRACE"}) {
+		t.Errorf("HardenedCapabilities: got %v", cfg.HardenedCapabilities)
+	}
+	if !reflect.DeepEqual(cfg.MaskedPaths, []string{".env", "/etc/hostname"}) {
+		t.Errorf("MaskedPaths: got %v", cfg.MaskedPaths)
+	}
+
+	for _, line := range []string{
+		"HARDENED yes",
+		"HARDENED_CAPS ALL",
+		"HARDENED_CAPS net-admin",
+		"MASK /",
+		"MASK ../other",
+		"MASK a:b",
+		"HARDENED true\nGROUP video",
+	} {
+		if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\n"+line+"\n")); err == nil {
+			t.Errorf("expected error for %q, got nil", line)
+		}
+	}
+}
+
+func TestLoadRuntimeConfig_ShowReadme(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nSHOW_README true\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.ShowReadme {
+		t.Errorf("ShowReadme: want true with SHOW_README true")
+	}
+	if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nSHOW_README yes\n")); err == nil {
+		t.Errorf("expected error for SHOW_README yes, got nil")
+	}
+}
+
+func TestLoadRuntimeConfig_ImageGenerations(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.KeptImageGenerations() != DefaultImageGenerations {
+		t.Errorf("KeptImageGenerations: want default %d, got %d", DefaultImageGenerations, cfg.KeptImageGenerations())
+	}
+	cfg, err = LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nIMAGE_GENERATIONS 0\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.KeptImageGenerations() != 0 {
+		t.Errorf("KeptImageGenerations: want 0, got %d", cfg.KeptImageGenerations())
+	}
+	if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nIMAGE_GENERATIONS -1\n")); err == nil {
+		t.Errorf("expected error for IMAGE_GENERATIONS -1, got nil")
+	}
+	cfg, err = LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nIMAGE_GENERATIONS_MAX_SIZE 10g\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ImageGenerationsMaxSize != 10_000_000_000 {
+		t.Errorf("ImageGenerationsMaxSize: want 10g, got %d", cfg.ImageGenerationsMaxSize)
+	}
+	if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nIMAGE_GENERATIONS_MAX_SIZE 0\n")); err == nil {
+		t.Errorf("expected error for IMAGE_GENERATIONS_MAX_SIZE 0, got nil")
+	}
+}
+
+func TestLoadRuntimeConfig_Services(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\n"+
+		"SERVICE db postgres:16\nSERVICE_ENV db POSTGRES_PASSWORD=dev\nSERVICE_DATA db /var/lib/postgresql/data\n"+
+		"SERVICE cache redis:7\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Services) != 2 {
+		t.Fatalf("Services: want 2, got %+v", cfg.Services)
+	}
+	db := cfg.Services[0]
+	if db.Name != "db" || db.Image != "postgres:16" || len(db.Env) != 1 || db.Env[0] != "POSTGRES_PASSWORD=dev" || db.DataPath != "/var/lib/postgresql/data" {
+		t.Errorf("Services[0]: got %+v", db)
+	}
+	if cfg.Services[1].Name != "cache" || cfg.Services[1].Image != "redis:7" {
+		t.Errorf("Services[1]: got %+v", cfg.Services[1])
+	}
+	if got := cfg.MainServiceName("myproject"); got != "myproject" {
+		t.Errorf("MainServiceName: want the project name by default, got %q", got)
+	}
+	cfg, err = LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nMAIN_SERVICE app\nSERVICE db postgres:16\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.MainServiceName("myproject"); got != "app" {
+		t.Errorf("MainServiceName: want app, got %q", got)
+	}
+
+	for _, invalid := range []string{
+		"SERVICE db\n",
+		"SERVICE My.Db postgres:16\n",
+		"SERVICE db postgres:16\nSERVICE db redis:7\n",
+		"SERVICE_ENV db A=b\n",
+		"SERVICE db postgres:16\nSERVICE_ENV db novalue\n",
+		"SERVICE db postgres:16\nSERVICE_DATA db relative/path\n",
+		"SERVICE db postgres:16\nSERVICE_DATA db /a\nSERVICE_DATA db /b\n",
+		"MAIN_SERVICE My.App\n",
+		"SERVICE db postgres:16\nMAIN_SERVICE db\n",
+		"SERVICE_PROFILE db heavy\n",
+		"SERVICE db postgres:16\nSERVICE_PROFILE db\n",
+		"SERVICE db postgres:16\nSERVICE_PROFILE db Heavy\n",
+		"SERVICE_HEALTHCHECK db pg_isready\n",
+		"SERVICE db postgres:16\nSERVICE_HEALTHCHECK db\n",
+		"SERVICE db postgres:16\nSERVICE_HEALTHCHECK db pg_isready\nSERVICE_HEALTHCHECK db true\n",
+		"SERVICE_WAIT_TIMEOUT soon\n",
+		"SERVICE_WAIT_TIMEOUT -1s\n",
+		"SERVICE_GRACE_PERIOD later\n",
+		"SERVICE_GRACE_PERIOD -5m\n",
+	} {
+		if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\n"+invalid)); err == nil {
+			t.Errorf("expected error for %q, got nil", invalid)
+		}
+	}
+}
+
+func TestLoadRuntimeConfig_ServiceProfiles(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\n"+
+		"SERVICE db postgres:16\nSERVICE_PROFILE db db heavy\nSERVICE cache redis:7\n"+
+		"SERVICE search opensearchproject/opensearch:2\nSERVICE_PROFILE search heavy\nSERVICE_PROFILE search heavy\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.ServiceProfiles(); !slices.Equal(got, []string{"db", "heavy"}) {
+		t.Errorf("ServiceProfiles: want [db heavy], got %v", got)
+	}
+	if got := cfg.Services[2].Profiles; !slices.Equal(got, []string{"heavy"}) {
+		t.Errorf("Services[2].Profiles: want [heavy], got %v", got)
+	}
+	for _, tc := range []struct {
+		profiles []string
+		want     []bool
+	}{
+		{nil, []bool{false, true, false}},
+		{[]string{"db"}, []bool{true, true, false}},
+		{[]string{"other", "heavy"}, []bool{true, true, true}},
+	} {
+		for i, service := range cfg.Services {
+			if got := service.EnabledBy(tc.profiles); got != tc.want[i] {
+				t.Errorf("Services[%d].EnabledBy(%v): want %v, got %v", i, tc.profiles, tc.want[i], got)
+			}
+		}
+	}
+}
+
+func TestLoadRuntimeConfig_Groups(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nGROUP video\nGROUP kvm\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Groups) != 2 || cfg.Groups[0] != "video" || cfg.Groups[1] != "kvm" {
+		t.Errorf("Groups: want [video kvm], got %v", cfg.Groups)
+	}
+	if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nGROUP video:44\n")); err == nil {
+		t.Errorf("expected error for GROUP video:44, got nil")
+	}
+}
+
+func TestLoadRuntimeConfig_PackageCaches(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nPACKAGE_CACHE npm cargo\nPACKAGE_CACHE go npm\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"npm", "cargo", "go"}; !reflect.DeepEqual(cfg.PackageCaches, want) {
+		t.Errorf("PackageCaches: want %v, got %v", want, cfg.PackageCaches)
+	}
+	if PackageCachePath("cargo") != ".cargo/registry" {
+		t.Errorf("PackageCachePath(cargo) = %q", PackageCachePath("cargo"))
+	}
+	for _, value := range []string{"bower", ""} {
+		if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nPACKAGE_CACHE "+value+"\n")); err == nil {
+			t.Errorf("expected error for PACKAGE_CACHE %q, got nil", value)
+		}
+	}
+}
+
+func TestLoadRuntimeConfig_SharedVolumes(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nSHARED_VOLUME datasets /data ro\nSHARED_VOLUME workspace /home/dev/workspace\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []SharedVolume{{Name: "datasets", Target: "/data", ReadOnly: true}, {Name: "workspace", Target: "/home/dev/workspace"}}
+	if !reflect.DeepEqual(cfg.SharedVolumes, want) {
+		t.Errorf("SharedVolumes: want %v, got %v", want, cfg.SharedVolumes)
+	}
+	for _, value := range []string{
+		"datasets",
+		"Datasets /data",
+		"datasets data",
+		"datasets /paul-env/data",
+		"datasets /data rx",
+		"datasets /data\nSHARED_VOLUME datasets /other",
+	} {
+		if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nSHARED_VOLUME "+value+"\n")); err == nil {
+			t.Errorf("expected error for SHARED_VOLUME %q, got nil", value)
+		}
+	}
+}
+
+func TestLoadRuntimeConfig_Tmpfs(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nTMPFS /scratch/\nTMPFS /run/secrets size=16m,mode=0700\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []TmpfsMount{{Target: "/scratch"}, {Target: "/run/secrets", Size: "16m", Mode: "0700"}}
+	if !reflect.DeepEqual(cfg.Tmpfs, want) {
+		t.Errorf("Tmpfs: want %v, got %v", want, cfg.Tmpfs)
+	}
+	for _, value := range []string{
+		"",
+		"scratch",
+		"/paul-env/tmp",
+		"/scratch size=big",
+		"/scratch mode=999",
+		"/scratch size=1g,size=2g",
+		"/scratch uid=1000",
+		"/scratch\nTMPFS /scratch/",
+	} {
+		if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nTMPFS "+value+"\n")); err == nil {
+			t.Errorf("expected error for TMPFS %q, got nil", value)
+		}
+	}
+}
+
+func TestLoadRuntimeConfig_CredentialForwarding(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nSSH_AGENT true\nGIT_CREDENTIALS true\nGPG_AGENT true\nCLIPBOARD true\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.SSHAgent || !cfg.GitCredentials || !cfg.GPGAgent || !cfg.Clipboard {
+		t.Errorf("SSHAgent, GitCredentials, GPGAgent, Clipboard: want true, got %v, %v, %v, %v", cfg.SSHAgent, cfg.GitCredentials, cfg.GPGAgent, cfg.Clipboard)
+	}
+	for _, directive := range []string{"SSH_AGENT", "GIT_CREDENTIALS", "GPG_AGENT", "CLIPBOARD"} {
+		if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\n"+directive+" yes\n")); err == nil {
+			t.Errorf("expected error for %s yes, got nil", directive)
+		}
+	}
+}
+
+func TestLoadRuntimeConfig_HostCommands(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nHOST_COMMAND xdg-open notify-send\nHOST_COMMAND podman xdg-open\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"xdg-open", "notify-send", "podman"}; !slices.Equal(cfg.HostCommands, want) {
+		t.Errorf("HostCommands: want %v, got %v", want, cfg.HostCommands)
+	}
+	for _, invalid := range []string{"/usr/bin/xdg-open", "../podman", "-rf"} {
+		if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nHOST_COMMAND "+invalid+"\n")); err == nil {
+			t.Errorf("expected error for HOST_COMMAND %s, got nil", invalid)
+		}
+	}
+	if !cfg.ServesHostRequests() {
+		t.Errorf("ServesHostRequests(): want true with host commands")
+	}
+	if cfg, _ := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\n")); cfg.ServesHostRequests() {
+		t.Errorf("ServesHostRequests(): want false without host commands nor clipboard")
+	}
+}
+
+func TestLoadRuntimeConfig_PersistentSession(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nPERSISTENT_SESSION true\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.PersistentSession {
+		t.Errorf("PersistentSession: want true with PERSISTENT_SESSION true")
+	}
+	if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nPERSISTENT_SESSION tmux\n")); err == nil {
+		t.Errorf("expected error for PERSISTENT_SESSION tmux, got nil")
+	}
+}
+
+func TestLoadRuntimeConfig_Audio(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nAUDIO true\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Audio {
+		t.Errorf("Audio: want true with AUDIO true")
+	}
+	if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nAUDIO yes\n")); err == nil {
+		t.Errorf("expected error for AUDIO yes, got nil")
+	}
+}
+
+func TestLoadRuntimeConfig_SharedNetwork(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nSHARED_NETWORK true\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.SharedNetwork {
+		t.Errorf("SharedNetwork: want true with SHARED_NETWORK true")
+	}
+	if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nSHARED_NETWORK paulenv-shared\n")); err == nil {
+		t.Errorf("expected error for SHARED_NETWORK paulenv-shared, got nil")
+	}
+}
+
+func TestLoadRuntimeConfig_NameResolution(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nHOST git.corp.example 10.0.0.12\nHOST api.test ::1\nDNS 10.0.0.2 10.0.0.3\nDNS_SEARCH corp.example\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantHosts := []HostEntry{{Name: "git.corp.example", IP: "10.0.0.12"}, {Name: "api.test", IP: "::1"}}
+	if !reflect.DeepEqual(cfg.ExtraHosts, wantHosts) {
+		t.Errorf("ExtraHosts: got %v, want %v", cfg.ExtraHosts, wantHosts)
+	}
+	if !reflect.DeepEqual(cfg.DNSServers, []string{"10.0.0.2", "10.0.0.3"}) {
+		t.Errorf("DNSServers: got %v", cfg.DNSServers)
+	}
+	if !reflect.DeepEqual(cfg.DNSSearch, []string{"corp.example"}) {
+		t.Errorf("DNSSearch: got %v", cfg.DNSSearch)
+	}
+	for _, line := range []string{
+		"HOST git.corp.example",
+		"HOST git.corp.example corp-gateway",
+		"HOST -bad 10.0.0.1",
+		"DNS",
+		"DNS dns.google",
+		"DNS_SEARCH corp_example",
+	} {
+		if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\n"+line+"\n")); err == nil {
+			t.Errorf("expected error for %q, got nil", line)
+		}
+	}
+}
+
+func TestLoadRuntimeConfig_Proxy(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nHTTP_PROXY http://proxy:3128\nHTTPS_PROXY none\nNO_PROXY localhost,.corp.example\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{"HTTP_PROXY": "http://proxy:3128", "HTTPS_PROXY": "", "NO_PROXY": "localhost,.corp.example"}
+	if !reflect.DeepEqual(cfg.Proxy, want) {
+		t.Errorf("Proxy: got %v, want %v", cfg.Proxy, want)
+	}
+}
+
+func TestLoadRuntimeConfig_BuildSettings(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nBUILD_TIMEOUT 90m\nBUILD_RETRIES 5\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.BuildTimeout == nil || *cfg.BuildTimeout != 90*time.Minute || cfg.BuildRetries == nil || *cfg.BuildRetries != 5 {
+		t.Errorf("BuildTimeout, BuildRetries: got %v, %v, want 90m and 5", cfg.BuildTimeout, cfg.BuildRetries)
+	}
+	cfg, err = LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\n"))
+	if err != nil || cfg.BuildTimeout != nil || cfg.BuildRetries != nil {
+		t.Errorf("BuildTimeout, BuildRetries: got %v, %v, %v, want them unset", cfg.BuildTimeout, cfg.BuildRetries, err)
+	}
+	for _, line := range []string{"BUILD_TIMEOUT 2", "BUILD_TIMEOUT -1h", "BUILD_RETRIES many"} {
+		if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\n"+line+"\n")); err == nil {
+			t.Errorf("expected error for %s, got nil", line)
+		}
+	}
+}
+
+func TestLoadRuntimeConfig_IdleTimeout(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nIDLE_TIMEOUT 0\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.IdleTimeout == nil || *cfg.IdleTimeout != 0 {
+		t.Errorf("IdleTimeout: got %v, want 0", cfg.IdleTimeout)
+	}
+	if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nIDLE_TIMEOUT never\n")); err == nil {
+		t.Errorf("expected error for IDLE_TIMEOUT never, got nil")
+	}
+}
+
+func TestLoadRuntimeConfig_Userns(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nUSERNS keep-id 1000:1001\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Userns.Mode != UsernsKeepID || cfg.Userns.UID == nil || *cfg.Userns.UID != 1000 || *cfg.Userns.GID != 1001 {
+		t.Errorf("Userns: got %+v, want keep-id mapped to 1000:1001", cfg.Userns)
+	}
+	cfg, err = LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nUSERNS host\n"))
+	if err != nil || cfg.Userns.Mode != UsernsHost || cfg.Userns.UID != nil {
+		t.Errorf("Userns: got %+v, %v, want host", cfg.Userns, err)
+	}
+	for _, value := range []string{"private", "keep-id 1000", "keep-id -1:1000", "host 1000:1000"} {
+		if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nUSERNS "+value+"\n")); err == nil {
+			t.Errorf("expected error for USERNS %s, got nil", value)
+		}
+	}
+}
+
+func TestLoadRuntimeConfig_Locale(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nTIMEZONE Europe/Paris\nLOCALE host\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Timezone != "Europe/Paris" || cfg.Locale != HostSetting {
+		t.Errorf("Timezone, Locale: got %q, %q, want %q, %q", cfg.Timezone, cfg.Locale, "Europe/Paris", HostSetting)
+	}
+	for _, line := range []string{"TIMEZONE ../etc/passwd", "TIMEZONE Europe/Paris extra", "LOCALE fr FR", "LOCALE fr_FR.UTF-8;"} {
+		if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\n"+line+"\n")); err == nil {
+			t.Errorf("expected error for %s, got nil", line)
+		}
+	}
+}
+
+func TestLoadRuntimeConfig_Display(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nDISPLAY true\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Display {
+		t.Errorf("Display: want true with DISPLAY true")
+	}
+	if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nDISPLAY :0\n")); err == nil {
+		t.Errorf("expected error for DISPLAY :0, got nil")
+	}
+}
+
+func TestLoadRuntimeConfig_SELinuxRelabel(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nSELINUX_RELABEL false\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.NoSELinuxRelabel {
+		t.Errorf("NoSELinuxRelabel: want true with SELINUX_RELABEL false")
+	}
+	if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nSELINUX_RELABEL Z\n")); err == nil {
+		t.Errorf("expected error for SELINUX_RELABEL Z, got nil")
+	}
+}
+
+func TestLoadRuntimeConfig_SSHPort(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nSSH_PORT 2222\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.SSHPort != "2222" {
+		t.Errorf("SSHPort: got %q, want %q", cfg.SSHPort, "2222")
+	}
+	for _, value := range []string{"0", "70000", "ssh"} {
+		content := "VERSION 1.2.0\nPATH /srv/myproject\nSSH_PORT " + value + "\n"
+		if _, err := LoadRuntimeConfig(writeConf(t, content)); err == nil {
+			t.Errorf("expected error for SSH_PORT %q, got nil", value)
+		}
+	}
+}
+
 func TestLoadRuntimeConfig_MissingVersion(t *testing.T) {
 	_, err := LoadRuntimeConfig(writeConf(t, "PATH /srv/myproject\n"))
 	if err == nil {
@@ -220,3 +717,151 @@ func assertStringSlice(t *testing.T, name string, want, got []string) {
 		}
 	}
 }
+
+func TestLoadRuntimeConfig_DotfilesProfile(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nDOTFILES_PATH dotfiles\nDOTFILES_PROFILE work\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.DotfilesProfile != "work" {
+		t.Errorf("DotfilesProfile: want work, got %q", cfg.DotfilesProfile)
+	}
+	for _, invalid := range []string{"../work", ".hidden", "my profile"} {
+		if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nDOTFILES_PROFILE "+invalid+"\n")); err == nil {
+			t.Errorf("expected error for %q, got nil", invalid)
+		}
+	}
+}
+
+func TestLoadRuntimeConfig_StartupScripts(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\n"+
+		"STARTUP ./setup.sh\nSTARTUP /opt/my scripts/watch.sh warn\nSTARTUP deps.sh abort\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []StartupScript{
+		{Path: "./setup.sh", OnFailure: StartupAbort},
+		{Path: "/opt/my scripts/watch.sh", OnFailure: StartupWarn},
+		{Path: "deps.sh", OnFailure: StartupAbort},
+	}
+	if !reflect.DeepEqual(cfg.StartupScripts, want) {
+		t.Errorf("StartupScripts: want %v, got %v", want, cfg.StartupScripts)
+	}
+	if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nSTARTUP a.sh\nSTARTUP a.sh warn\n")); err == nil {
+		t.Error("expected error for a script declared twice, got nil")
+	}
+}
+
+func TestLoadRuntimeConfig_ActivationScripts(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\n"+
+		"ACTIVATE ./env.sh\nACTIVATE /opt/my scripts/nvm.fish\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"./env.sh", "/opt/my scripts/nvm.fish"}
+	if !reflect.DeepEqual(cfg.ActivationScripts, want) {
+		t.Errorf("ActivationScripts: want %v, got %v", want, cfg.ActivationScripts)
+	}
+	if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nACTIVATE a.sh\nACTIVATE a.sh\n")); err == nil {
+		t.Error("expected error for a script declared twice, got nil")
+	}
+	if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nACTIVATE  \n")); err == nil {
+		t.Error("expected error for an empty path, got nil")
+	}
+}
+
+func TestLoadRuntimeConfig_Processes(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\n"+
+		"PROCESS lsp gopls serve -listen=:4389\nPROCESS dockerd dockerd --iptables=false\nPROCESS_ROOT dockerd\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Process{
+		{Name: "lsp", Command: "gopls serve -listen=:4389"},
+		{Name: "dockerd", Command: "dockerd --iptables=false", Root: true},
+	}
+	if !reflect.DeepEqual(cfg.Processes, want) {
+		t.Errorf("Processes: want %v, got %v", want, cfg.Processes)
+	}
+	for _, content := range []string{
+		"PROCESS lsp\n",
+		"PROCESS Bad-Name cmd\n",
+		"PROCESS lsp gopls\nPROCESS lsp gopls serve\n",
+		"PROCESS_ROOT dockerd\n",
+	} {
+		if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\n"+content)); err == nil {
+			t.Errorf("expected error for %q, got nil", content)
+		}
+	}
+}
+
+func TestLoadRuntimeConfig_Secrets(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\n"+
+		"SECRET GITHUB_TOKEN pass:github/token\nSECRET NPM_TOKEN\nSECRET API_KEY HOST_API_KEY\nSECRET KEY age:C:\\keys\\k.age\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Secret{
+		{Name: "GITHUB_TOKEN", Backend: "pass", Reference: "github/token"},
+		{Name: "NPM_TOKEN", Reference: "NPM_TOKEN"},
+		{Name: "API_KEY", Reference: "HOST_API_KEY"},
+		{Name: "KEY", Backend: "age", Reference: "C:\\keys\\k.age"},
+	}
+	if !reflect.DeepEqual(cfg.Secrets, want) {
+		t.Errorf("Secrets: want %v, got %v", want, cfg.Secrets)
+	}
+	if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nSECRET 1TOKEN x\n")); err == nil {
+		t.Error("expected error for an invalid variable name, got nil")
+	}
+}
+
+func TestLoadRuntimeConfig_PassthroughEnv(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nPASSTHROUGH_ENV TERM COLORTERM\nPASSTHROUGH_ENV AWS_*\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.PassthroughEnv, []string{"TERM", "COLORTERM", "AWS_*"}) {
+		t.Errorf("PassthroughEnv: got %v", cfg.PassthroughEnv)
+	}
+
+	for _, line := range []string{
+		"PASSTHROUGH_ENV",
+		"PASSTHROUGH_ENV *",
+		"PASSTHROUGH_ENV AWS_?",
+		"PASSTHROUGH_ENV HOME=/root",
+	} {
+		if _, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\n"+line+"\n")); err == nil {
+			t.Errorf("expected error for %q, got nil", line)
+		}
+	}
+}
+
+func TestLoadRuntimeConfig_ServiceHealthcheck(t *testing.T) {
+	cfg, err := LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\n"+
+		"SERVICE db postgres:16\nSERVICE_HEALTHCHECK db pg_isready -U postgres\nSERVICE cache redis:7\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.Services[0].Healthcheck; got != "pg_isready -U postgres" {
+		t.Errorf("Services[0].Healthcheck: want %q, got %q", "pg_isready -U postgres", got)
+	}
+	if got := cfg.ServiceReadyTimeout(); got != DefaultServiceWaitTimeout {
+		t.Errorf("ServiceReadyTimeout: want the default, got %s", got)
+	}
+
+	cfg, err = LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nSERVICE_WAIT_TIMEOUT 0\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.ServiceReadyTimeout(); got != 0 {
+		t.Errorf("ServiceReadyTimeout: want 0, got %s", got)
+	}
+
+	cfg, err = LoadRuntimeConfig(writeConf(t, "VERSION 1.2.0\nPATH /srv/myproject\nSERVICE_GRACE_PERIOD 30m\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.ServiceGracePeriod; got != 30*time.Minute {
+		t.Errorf("ServiceGracePeriod: want 30m, got %s", got)
+	}
+}
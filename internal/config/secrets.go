@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Backends from which secrets can be fetched: an age-encrypted file, the OS
+// keyring, pass, gopass, or an environment variable of the host.
+var SecretBackends = []string{"age", "keyring", "pass", "gopass", "env"}
+
+// Backend of secrets not naming one when no other is configured.
+const DefaultSecretBackend = "env"
+
+var secretNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// A secret given to a project's container as an environment variable, fetched
+// from a secret backend when the container is created.
+type Secret struct {
+	// Name of the environment variable
+	Name string
+	// optional; backend it is fetched from, the configured default if empty
+	Backend string
+	// What identifies it for its backend: a file for `age`, an entry for
+	// `keyring`, `pass` and `gopass` and a variable for `env`
+	Reference string
+}
+
+var credentialVariableRegex = regexp.MustCompile(`(?i)(PASSWORD|PASSWD|SECRET|TOKEN|CREDENTIAL|API_?KEY|PRIVATE_?KEY)`)
+
+// Returns `true` if the given environment variable name looks like it holds a
+// credential, whose value should not be shared.
+func IsCredentialVariable(name string) bool {
+	return credentialVariableRegex.MatchString(name)
+}
+
+// Check that the given secret backend is known.
+func ValidateSecretBackend(backend string) error {
+	if !slices.Contains(SecretBackends, backend) {
+		return fmt.Errorf("unknown secret backend %q, expected one of: %s", backend, strings.Join(SecretBackends, ", "))
+	}
+	return nil
+}
+
+// Parse the value of a SECRET directive: a variable name, optionally followed
+// by a reference prefixed by its backend (e.g. "pass:github/token"). Without
+// a reference, the variable's name is used as one.
+func parseSecret(value string) (Secret, error) {
+	name, reference, _ := strings.Cut(value, " ")
+	if !secretNameRegex.MatchString(name) {
+		return Secret{}, fmt.Errorf("must start with an environment variable name, got %q", name)
+	}
+	secret := Secret{Name: name, Reference: strings.TrimSpace(reference)}
+	if backend, rest, ok := strings.Cut(secret.Reference, ":"); ok && slices.Contains(SecretBackends, backend) {
+		secret.Backend = backend
+		secret.Reference = rest
+	}
+	if secret.Reference == "" {
+		secret.Reference = name
+	}
+	return secret, nil
+}
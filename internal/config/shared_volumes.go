@@ -0,0 +1,50 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// A volume several projects mount on purpose (`SHARED_VOLUME` directive), e.g.
+// a common workspace or dataset. It is kept as long as one of them declares it.
+type SharedVolume struct {
+	// Name under which projects share it
+	Name string
+	// Absolute path in the container
+	Target   string
+	ReadOnly bool
+}
+
+var sharedVolumeNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Parse the value of a SHARED_VOLUME directive: a name, a target in the
+// container and optionally `ro` or `rw`.
+func parseSharedVolume(value string) (SharedVolume, error) {
+	fields := strings.Fields(value)
+	if len(fields) < 2 || len(fields) > 3 {
+		return SharedVolume{}, fmt.Errorf("must be a volume name, a container path and an optional ro or rw, got %q", value)
+	}
+	volume := SharedVolume{Name: fields[0], Target: fields[1]}
+	if !sharedVolumeNameRegex.MatchString(volume.Name) {
+		return SharedVolume{}, fmt.Errorf("invalid volume name %q: only lowercase letters, digits, '_' and '-' are allowed", volume.Name)
+	}
+	if !path.IsAbs(volume.Target) || path.Clean(volume.Target) == "/" || strings.Contains(volume.Target, ":") {
+		return SharedVolume{}, fmt.Errorf("container path must be absolute, not the root and without ':', got %q", volume.Target)
+	}
+	if cleaned := path.Clean(volume.Target); cleaned == "/paul-env" || strings.HasPrefix(cleaned, "/paul-env/") {
+		return SharedVolume{}, errors.New("container paths under /paul-env are reserved to paul-envs")
+	}
+	if len(fields) == 3 {
+		switch fields[2] {
+		case "ro":
+			volume.ReadOnly = true
+		case "rw":
+		default:
+			return SharedVolume{}, fmt.Errorf("unknown option %q, expected ro or rw", fields[2])
+		}
+	}
+	return volume, nil
+}
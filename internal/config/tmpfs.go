@@ -0,0 +1,62 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// A tmpfs mounted in the container (`TMPFS` directive): scratch space, or room
+// for secret material, kept in memory so it never touches the disk and is
+// lost when the container stops.
+type TmpfsMount struct {
+	// Absolute path in the container, cleaned
+	Target string
+	// optional; maximum size, e.g. "64m", the engine's default if empty
+	Size string
+	// optional; octal permissions of its root, e.g. "0700", the engine's
+	// default if empty
+	Mode string
+}
+
+var tmpfsModeRegex = regexp.MustCompile(`^[0-7]{3,4}$`)
+
+// Parse the value of a TMPFS directive: a path in the container and optionally
+// comma-separated `size=<size>` and `mode=<octal mode>` options.
+func parseTmpfs(value string) (TmpfsMount, error) {
+	fields := strings.Fields(value)
+	if len(fields) < 1 || len(fields) > 2 {
+		return TmpfsMount{}, fmt.Errorf("must be a container path and optional options, got %q", value)
+	}
+	mount := TmpfsMount{Target: fields[0]}
+	if !path.IsAbs(mount.Target) || path.Clean(mount.Target) == "/" || strings.ContainsAny(mount.Target, ":,") {
+		return TmpfsMount{}, fmt.Errorf("container path must be absolute, not the root and without ':' nor ',', got %q", mount.Target)
+	}
+	mount.Target = path.Clean(mount.Target)
+	if mount.Target == "/paul-env" || strings.HasPrefix(mount.Target, "/paul-env/") {
+		return TmpfsMount{}, errors.New("container paths under /paul-env are reserved to paul-envs")
+	}
+	if len(fields) == 1 {
+		return mount, nil
+	}
+	for option := range strings.SplitSeq(fields[1], ",") {
+		key, value, _ := strings.Cut(option, "=")
+		switch key {
+		case "size":
+			if mount.Size != "" || !memoryLimitRegex.MatchString(value) {
+				return TmpfsMount{}, fmt.Errorf("size must be given once, as a size such as 64m or 1g, got %q", fields[1])
+			}
+			mount.Size = value
+		case "mode":
+			if mount.Mode != "" || !tmpfsModeRegex.MatchString(value) {
+				return TmpfsMount{}, fmt.Errorf("mode must be given once, as octal permissions such as 0700, got %q", fields[1])
+			}
+			mount.Mode = value
+		default:
+			return TmpfsMount{}, fmt.Errorf("unknown option %q, expected size or mode", option)
+		}
+	}
+	return mount, nil
+}
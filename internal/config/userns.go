@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Modes of USERNS in run.conf, the engine's default being chosen when empty.
+const (
+	// The host user is mapped to the container user, so files they create in
+	// bind mounts belong to the host user (Podman only)
+	UsernsKeepID = "keep-id"
+	// The container shares the user namespace of the engine
+	UsernsHost = "host"
+)
+
+// User namespace of a project's container, set with USERNS in run.conf.
+type Userns struct {
+	// `UsernsKeepID`, `UsernsHost` or empty for the engine's default
+	Mode string
+	// With `UsernsKeepID`, IDs in the container the host user and group are
+	// mapped to, the host's own ones if `nil`
+	UID *int
+	GID *int
+}
+
+// Parse the value of a USERNS directive: `auto`, `host`, `keep-id` or
+// `keep-id` followed by a "<uid>:<gid>" mapping.
+func parseUserns(value string) (Userns, error) {
+	fields := strings.Fields(value)
+	switch {
+	case len(fields) == 1 && fields[0] == "auto":
+		return Userns{}, nil
+	case len(fields) == 1 && fields[0] == UsernsHost:
+		return Userns{Mode: UsernsHost}, nil
+	case len(fields) == 1 && fields[0] == UsernsKeepID:
+		return Userns{Mode: UsernsKeepID}, nil
+	case len(fields) == 2 && fields[0] == UsernsKeepID:
+		uidStr, gidStr, found := strings.Cut(fields[1], ":")
+		uid, uidErr := strconv.Atoi(uidStr)
+		gid, gidErr := strconv.Atoi(gidStr)
+		if !found || uidErr != nil || gidErr != nil || uid < 0 || gid < 0 {
+			return Userns{}, fmt.Errorf("mapping must be \"<uid>:<gid>\", e.g. \"1000:1000\", got %q", fields[1])
+		}
+		return Userns{Mode: UsernsKeepID, UID: &uid, GID: &gid}, nil
+	}
+	return Userns{}, fmt.Errorf("must be auto, host, keep-id or keep-id followed by a \"<uid>:<gid>\" mapping, got %q", value)
+}
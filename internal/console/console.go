@@ -3,9 +3,12 @@ package console
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/logging"
 )
 
 const (
@@ -17,19 +20,38 @@ const (
 	colorReset = "\033[0m"
 )
 
+// Displays messages to the user and asks for input.
+//
+// Displayed messages are also logged, giving context to the other records of
+// the log file.
 type Console struct {
 	reader    *bufio.Reader
 	writer    io.Writer
 	errWriter io.Writer
 	ctx       context.Context
+	// If set, informative messages (progress, successes) are not displayed
+	quiet bool
+	// If set, nothing is asked and messages are prefixed by their level
+	// instead of being colored
+	nonInteractive bool
+	// How messages and progress are rendered, empty for colored messages
+	// unless in non-interactive mode
+	output Output
+	// Set if `writer` is a terminal
+	terminal bool
 }
 
+// Error wrapped by questions which could not be asked in non-interactive
+// mode.
+var ErrNonInteractive = errors.New("non-interactive mode")
+
 func New(ctx context.Context, rd io.Reader, w io.Writer, ew io.Writer) *Console {
 	return &Console{
 		reader:    bufio.NewReader(rd),
 		writer:    w,
 		errWriter: ew,
 		ctx:       ctx,
+		terminal:  isTerminal(w),
 	}
 }
 
@@ -41,21 +63,101 @@ func (c *Console) ErrorWriter() io.Writer {
 	return c.errWriter
 }
 
+// Only display errors, warnings and the commands' results, not informative
+// messages.
+func (c *Console) SetQuiet(quiet bool) {
+	c.quiet = quiet
+}
+
+// Returns `true` if informative messages are not displayed.
+func (c *Console) IsQuiet() bool {
+	return c.quiet || c.output == OutputQuiet
+}
+
+// Choose how messages and progress are rendered. Quiet mode and
+// non-interactive mode, where messages are prefixed by their level, still
+// apply unless `OutputFancy` is explicitly chosen.
+func (c *Console) SetOutput(output Output) {
+	c.output = output
+}
+
+// The renderer of the chosen output.
+func (c *Console) renderer() renderer {
+	var r renderer = fancyRenderer{terminal: c.terminal}
+	if c.terminal {
+		r = fancyRenderer{terminal: true, width: terminalWidth(c.writer)}
+	}
+	switch c.output {
+	case OutputFancy:
+	case OutputPlain:
+		r = plainRenderer{}
+	case OutputAuto, OutputQuiet:
+		if c.nonInteractive || !c.terminal {
+			r = plainRenderer{}
+		}
+	default:
+		if c.nonInteractive {
+			r = plainRenderer{}
+		}
+	}
+	if c.IsQuiet() {
+		r = quietRenderer{inner: r}
+	}
+	return r
+}
+
+// Disable questions, which then fail with `ErrNonInteractive`, and write
+// each message on lines prefixed by its level (e.g. "[warn] ") for them to be
+// parsed, e.g. in a CI pipeline.
+func (c *Console) SetNonInteractive(nonInteractive bool) {
+	c.nonInteractive = nonInteractive
+}
+
+// Returns `true` in non-interactive mode.
+func (c *Console) IsNonInteractive() bool {
+	return c.nonInteractive
+}
+
 func (c *Console) Error(format string, args ...any) {
-	fmt.Fprintf(c.errWriter, // red+
-		format+colorReset+"\n", args...)
+	c.write(c.errWriter, levelError, format, args...)
 }
 
 func (c *Console) Success(format string, args ...any) {
-	fmt.Fprintf(c.writer, green+format+colorReset+"\n", args...)
+	c.write(c.writer, levelSuccess, format, args...)
 }
 
 func (c *Console) Warn(format string, args ...any) {
-	fmt.Fprintf(c.writer, yellow+format+colorReset+"\n", args...)
+	c.write(c.writer, levelWarn, format, args...)
 }
 
 func (c *Console) Info(format string, args ...any) {
-	fmt.Fprintf(c.writer, blue+format+colorReset+"\n", args...)
+	c.write(c.writer, levelInfo, format, args...)
+}
+
+// Like `Info`, but written to the error output, for messages which must not
+// mix with what a command writes for other programs to read (e.g. the shell
+// code output by `shellenv`).
+func (c *Console) Note(format string, args ...any) {
+	c.write(c.errWriter, levelInfo, format, args...)
+}
+
+// Log a message of the given level and render it.
+func (c *Console) write(w io.Writer, level level, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	switch level {
+	case levelError:
+		logging.Log().Error(strings.TrimSpace(message))
+	case levelWarn:
+		logging.Log().Warn(strings.TrimSpace(message))
+	default:
+		logging.Log().Info(strings.TrimSpace(message))
+	}
+	c.renderer().message(w, level, message)
+}
+
+// Error returned by questions in non-interactive mode.
+func (c *Console) nonInteractiveError(prompt string) error {
+	return fmt.Errorf("%w: cannot ask %q\nHint: Give the answer through the command's flags or arguments instead", ErrNonInteractive, prompt)
 }
 
 func (c *Console) WriteLn(format string, args ...any) {
@@ -63,6 +165,9 @@ func (c *Console) WriteLn(format string, args ...any) {
 }
 
 func (c *Console) AskYesNo(prompt string, defaultVal bool) (bool, error) {
+	if c.nonInteractive {
+		return false, c.nonInteractiveError(prompt)
+	}
 	for {
 		if defaultVal {
 			fmt.Fprintf(c.writer, "%s (Y/n): ", prompt)
@@ -106,6 +211,9 @@ func (c *Console) AskYesNo(prompt string, defaultVal bool) (bool, error) {
 }
 
 func (c *Console) AskString(prompt, defaultVal string) (string, error) {
+	if c.nonInteractive {
+		return "", c.nonInteractiveError(prompt)
+	}
 	for {
 		if defaultVal != "" {
 			fmt.Fprintf(c.writer, "%s [%s]: ", prompt, defaultVal)
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"testing"
 	"time"
 
@@ -149,3 +150,136 @@ func TestAskString_Cancelled(t *testing.T) {
 		t.Fatalf("expected context.Canceled error, got %v", err)
 	}
 }
+
+func TestSetQuiet(t *testing.T) {
+	c, out, errOut, _, cancel := newTestConsole("")
+	defer cancel()
+
+	c.SetQuiet(true)
+	c.Info("progress")
+	c.Success("done")
+	c.Warn("careful")
+	c.WriteLn("result")
+	c.Error("failure")
+	if got := out.String(); got != "\033[1;33mcareful\033[0m\nresult\n" {
+		t.Fatalf("unexpected quiet output: %q", got)
+	}
+	if !bytes.Contains(errOut.Bytes(), []byte("failure")) {
+		t.Fatalf("errors should still be displayed when quiet, got %q", errOut.String())
+	}
+}
+
+func TestSetNonInteractive(t *testing.T) {
+	c, out, errOut, _, cancel := newTestConsole("y\n")
+	defer cancel()
+
+	c.SetNonInteractive(true)
+	if _, err := c.AskYesNo("Continue?", true); !errors.Is(err, console.ErrNonInteractive) {
+		t.Fatalf("AskYesNo should fail with ErrNonInteractive, got %v", err)
+	}
+	if _, err := c.AskString("Name", "demo"); !errors.Is(err, console.ErrNonInteractive) {
+		t.Fatalf("AskString should fail with ErrNonInteractive, got %v", err)
+	}
+	c.Info("Building 'demo'...")
+	c.Warn("careful\nreally")
+	c.Error("Error: failure")
+	if got := out.String(); got != "[info] Building 'demo'...\n[warn] careful\n[warn] really\n" {
+		t.Fatalf("unexpected non-interactive output: %q", got)
+	}
+	if got := errOut.String(); got != "[error] Error: failure\n" {
+		t.Fatalf("unexpected non-interactive error output: %q", got)
+	}
+}
+
+func TestParseOutput(t *testing.T) {
+	for _, value := range []string{"auto", "fancy", "plain", "quiet"} {
+		if output, err := console.ParseOutput(value); err != nil || string(output) != value {
+			t.Errorf("ParseOutput(%q) = %q, %v", value, output, err)
+		}
+	}
+	if _, err := console.ParseOutput("tty"); err == nil {
+		t.Error("ParseOutput should reject unknown outputs")
+	}
+}
+
+func TestSetOutput_AutoIsPlainOutsideTerminals(t *testing.T) {
+	c, out, _, _, cancel := newTestConsole("")
+	defer cancel()
+
+	c.SetOutput(console.OutputAuto)
+	c.Info("Building 'demo'...")
+	c.Success("Built 'demo'")
+	if got := out.String(); got != "[info] Building 'demo'...\n[success] Built 'demo'\n" {
+		t.Fatalf("unexpected auto output: %q", got)
+	}
+}
+
+func TestSetOutput_FancyOverridesNonInteractive(t *testing.T) {
+	c, out, _, _, cancel := newTestConsole("")
+	defer cancel()
+
+	c.SetNonInteractive(true)
+	c.SetOutput(console.OutputFancy)
+	c.Warn("careful")
+	if got := out.String(); got != "\033[1;33mcareful\033[0m\n" {
+		t.Fatalf("unexpected fancy output: %q", got)
+	}
+}
+
+func TestSection_Plain(t *testing.T) {
+	c, out, _, _, cancel := newTestConsole("")
+	defer cancel()
+
+	c.SetOutput(console.OutputPlain)
+	section := c.StartSection("Building 'demo'")
+	fmt.Fprint(section, "step 1\nstep")
+	fmt.Fprint(section, " 2\n\nstep 3")
+	section.End(nil)
+	fmt.Fprint(section, "ignored\n")
+	if got := out.String(); got != "[info] Building 'demo'...\nstep 1\nstep 2\n\nstep 3\n" {
+		t.Fatalf("unexpected section output: %q", got)
+	}
+}
+
+func TestSection_QuietOnlyShowsFailures(t *testing.T) {
+	c, out, errOut, _, cancel := newTestConsole("")
+	defer cancel()
+
+	c.SetOutput(console.OutputQuiet)
+	if !c.IsQuiet() {
+		t.Fatal("the quiet output should make the console quiet")
+	}
+	section := c.StartSection("Building 'demo'")
+	fmt.Fprint(section, "step 1\n")
+	section.End(nil)
+	if out.Len() != 0 || errOut.Len() != 0 {
+		t.Fatalf("successful sections should display nothing when quiet, got %q and %q", out.String(), errOut.String())
+	}
+
+	section = c.StartSection("Building 'demo'")
+	for i := range 30 {
+		fmt.Fprintf(section, "step %d\n", i)
+	}
+	section.End(errors.New("failure"))
+	lines := strings.Split(strings.TrimSuffix(errOut.String(), "\n"), "\n")
+	if len(lines) != 21 || !strings.Contains(lines[0], "Building 'demo' failed") || lines[1] != "step 10" || lines[20] != "step 29" {
+		t.Fatalf("failed sections should display their last lines when quiet, got %q", errOut.String())
+	}
+	if out.Len() != 0 {
+		t.Fatalf("unexpected quiet output: %q", out.String())
+	}
+}
+
+func TestStatus_OutsideTerminals(t *testing.T) {
+	c, out, _, _, cancel := newTestConsole("")
+	defer cancel()
+
+	status := c.Status()
+	status.Update("Waiting (1s)")
+	status.Update("Waiting (2s)")
+	status.Clear()
+	status.Update("Waiting again")
+	if got := out.String(); got != "\033[0;34mWaiting (1s)...\033[0m\n\033[0;34mWaiting again...\033[0m\n" {
+		t.Fatalf("unexpected status output: %q", got)
+	}
+}
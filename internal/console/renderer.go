@@ -0,0 +1,246 @@
+// # renderer.go
+// Messages, status lines and sections (e.g. the output of a build) are
+// rendered differently depending on where they end up:
+//   - on a terminal, messages are colored, status lines are redrawn in place
+//     with a spinner and sections collapse into a single line updated as
+//     they write, only expanded if they fail.
+//   - in logs and CI pipelines, everything is written line after line,
+//     messages being prefixed by their level to be parsed.
+//   - in quiet mode, only errors and warnings are written, as well as the
+//     end of a section's output if it fails.
+
+package console
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// How messages and progress are rendered.
+type Output string
+
+const (
+	// `OutputFancy` on terminals, `OutputPlain` elsewhere
+	OutputAuto Output = "auto"
+	// Colored messages, spinners and collapsed sections
+	OutputFancy Output = "fancy"
+	// Plain lines, messages being prefixed by their level
+	OutputPlain Output = "plain"
+	// Only errors and warnings
+	OutputQuiet Output = "quiet"
+)
+
+// Parse an output mode as given through the `--output` flag.
+func ParseOutput(value string) (Output, error) {
+	switch output := Output(value); output {
+	case OutputAuto, OutputFancy, OutputPlain, OutputQuiet:
+		return output, nil
+	default:
+		return "", fmt.Errorf("invalid output %q, expected auto, fancy, plain or quiet", value)
+	}
+}
+
+var spinnerFrames = []rune{'|', '/', '-', '\\'}
+
+type level string
+
+const (
+	levelError   level = "error"
+	levelWarn    level = "warn"
+	levelInfo    level = "info"
+	levelSuccess level = "success"
+)
+
+var levelColors = map[level]string{
+	levelSuccess: green,
+	levelWarn:    yellow,
+	levelInfo:    blue,
+}
+
+// Renders what the console writes. `w` is the output where to render it,
+// `ew` the error output.
+type renderer interface {
+	// Write a message of the given level.
+	message(w io.Writer, level level, text string)
+	// Returns `true` if status lines and sections are redrawn in place, and
+	// should then be redrawn periodically for their spinner to turn.
+	inPlace() bool
+	// Display a status in place of the previous one, `frame` being the
+	// number of statuses displayed before it.
+	status(w io.Writer, text string, frame int)
+	// Remove the status line, if any was displayed.
+	clearStatus(w io.Writer)
+	// Display the start of a section.
+	sectionStart(w io.Writer, title string)
+	// Display a line written by a section, `frame` being the number of
+	// redraws of the section before it. In-place renderers are also called
+	// periodically with its last line.
+	sectionLine(w io.Writer, title string, line string, frame int)
+	// Display the end of a section, which failed if `failed` is set, `tail`
+	// being its last lines.
+	sectionEnd(w io.Writer, ew io.Writer, title string, elapsed time.Duration, failed bool, tail []string)
+}
+
+// Colored messages and, on terminals, redrawn status lines and sections.
+type fancyRenderer struct {
+	terminal bool
+	// Width of the terminal, to keep redrawn lines on a single line
+	width int
+}
+
+func (r fancyRenderer) message(w io.Writer, level level, text string) {
+	fmt.Fprint(w, levelColors[level]+text+colorReset+"\n")
+}
+
+func (r fancyRenderer) inPlace() bool {
+	return r.terminal
+}
+
+func (r fancyRenderer) status(w io.Writer, text string, frame int) {
+	if !r.terminal {
+		if frame == 0 {
+			r.message(w, levelInfo, text+"...")
+		}
+		return
+	}
+	r.redraw(w, fmt.Sprintf("%c %s", spinnerFrames[frame%len(spinnerFrames)], text))
+}
+
+func (r fancyRenderer) clearStatus(w io.Writer) {
+	if r.terminal {
+		fmt.Fprint(w, "\r\033[K")
+	}
+}
+
+func (r fancyRenderer) sectionStart(w io.Writer, title string) {
+	if !r.terminal {
+		r.message(w, levelInfo, title+"...")
+		return
+	}
+	r.sectionLine(w, title, "", 0)
+}
+
+func (r fancyRenderer) sectionLine(w io.Writer, title string, line string, frame int) {
+	if !r.terminal {
+		fmt.Fprintln(w, line)
+		return
+	}
+	text := fmt.Sprintf("%c %s", spinnerFrames[frame%len(spinnerFrames)], title)
+	if line = strings.TrimSpace(line); line != "" {
+		text += ": " + line
+	}
+	r.redraw(w, text)
+}
+
+func (r fancyRenderer) sectionEnd(w io.Writer, _ io.Writer, title string, elapsed time.Duration, failed bool, tail []string) {
+	if !r.terminal {
+		return
+	}
+	r.clearStatus(w)
+	if !failed {
+		r.message(w, levelSuccess, fmt.Sprintf("✓ %s (%s)", title, elapsed.Round(time.Second)))
+		return
+	}
+	r.message(w, levelWarn, fmt.Sprintf("✗ %s failed after %s, its last lines were:", title, elapsed.Round(time.Second)))
+	for _, line := range tail {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// Replace the current line of the terminal by `text`, cut to its width.
+func (r fancyRenderer) redraw(w io.Writer, text string) {
+	if runes := []rune(text); r.width > 1 && len(runes) >= r.width {
+		text = string(runes[:r.width-2]) + "…"
+	}
+	fmt.Fprintf(w, "\r\033[K%s", text)
+}
+
+// Plain lines, with messages prefixed by their level.
+type plainRenderer struct{}
+
+func (r plainRenderer) message(w io.Writer, level level, text string) {
+	text = strings.TrimLeft(text, "\n")
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Fprintf(w, "[%s] %s\n", level, line)
+	}
+}
+
+func (r plainRenderer) inPlace() bool {
+	return false
+}
+
+func (r plainRenderer) status(w io.Writer, text string, frame int) {
+	if frame == 0 {
+		r.message(w, levelInfo, text+"...")
+	}
+}
+
+func (r plainRenderer) clearStatus(io.Writer) {}
+
+func (r plainRenderer) sectionStart(w io.Writer, title string) {
+	r.message(w, levelInfo, title+"...")
+}
+
+func (r plainRenderer) sectionLine(w io.Writer, _ string, line string, _ int) {
+	fmt.Fprintln(w, line)
+}
+
+func (r plainRenderer) sectionEnd(io.Writer, io.Writer, string, time.Duration, bool, []string) {}
+
+// Only renders errors and warnings through `inner`, and the last lines of
+// sections which failed.
+type quietRenderer struct {
+	inner renderer
+}
+
+func (r quietRenderer) message(w io.Writer, level level, text string) {
+	if level == levelError || level == levelWarn {
+		r.inner.message(w, level, text)
+	}
+}
+
+func (r quietRenderer) inPlace() bool {
+	return false
+}
+
+func (r quietRenderer) status(io.Writer, string, int) {}
+
+func (r quietRenderer) clearStatus(io.Writer) {}
+
+func (r quietRenderer) sectionStart(io.Writer, string) {}
+
+func (r quietRenderer) sectionLine(io.Writer, string, string, int) {}
+
+func (r quietRenderer) sectionEnd(_ io.Writer, ew io.Writer, title string, elapsed time.Duration, failed bool, tail []string) {
+	if !failed {
+		return
+	}
+	r.inner.message(ew, levelWarn, fmt.Sprintf("%s failed after %s, its last lines were:", title, elapsed.Round(time.Second)))
+	for _, line := range tail {
+		fmt.Fprintln(ew, line)
+	}
+}
+
+// Returns `true` if `w` is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// Width of the terminal `w` is, `0` if unknown.
+func terminalWidth(w io.Writer) int {
+	f, ok := w.(*os.File)
+	if !ok {
+		return 0
+	}
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
@@ -0,0 +1,121 @@
+package console
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Number of lines of a section's output kept to be displayed if it fails.
+const sectionTailLines = 20
+
+// Period at which the spinner of sections redrawn in place turns.
+const sectionRedrawPeriod = 150 * time.Millisecond
+
+// Output of a long step, e.g. a build, written through it as an `io.Writer`.
+//
+// On terminals it is collapsed into a single line showing its last line,
+// only expanded if it fails. Elsewhere it is written as is, except in quiet
+// mode where only its last lines are, if it fails.
+type Section struct {
+	console  *Console
+	renderer renderer
+	title    string
+	start    time.Time
+
+	mu sync.Mutex
+	// Last incomplete line written
+	partial []byte
+	// Last complete lines written, at most `sectionTailLines`
+	tail   []string
+	frames int
+	ended  bool
+	done   chan struct{}
+}
+
+// Start a section of the given title (e.g. "Building project 'demo'"),
+// ended by `End`.
+func (c *Console) StartSection(title string) *Section {
+	s := &Section{
+		console:  c,
+		renderer: c.renderer(),
+		title:    title,
+		start:    time.Now(),
+		done:     make(chan struct{}),
+	}
+	s.renderer.sectionStart(c.writer, title)
+	if s.renderer.inPlace() {
+		go s.spin()
+	}
+	return s
+}
+
+func (s *Section) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return len(p), nil
+	}
+	data := append(s.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		s.addLine(string(data[:i]))
+		data = data[i+1:]
+	}
+	s.partial = append([]byte(nil), data...)
+	return len(p), nil
+}
+
+// End the section, which failed if `err` is not `nil`.
+func (s *Section) End(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	if len(s.partial) > 0 {
+		s.addLine(string(s.partial))
+		s.partial = nil
+	}
+	s.ended = true
+	close(s.done)
+	s.renderer.sectionEnd(s.console.writer, s.console.errWriter, s.title, time.Since(s.start), err != nil, s.tail)
+}
+
+func (s *Section) addLine(line string) {
+	line = strings.TrimRight(line, "\r")
+	if len(s.tail) == sectionTailLines {
+		s.tail = s.tail[1:]
+	}
+	s.tail = append(s.tail, line)
+	s.frames++
+	s.renderer.sectionLine(s.console.writer, s.title, line, s.frames)
+}
+
+// Redraw the section periodically until it ends, for its spinner to turn
+// even while nothing is written.
+func (s *Section) spin() {
+	ticker := time.NewTicker(sectionRedrawPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if !s.ended {
+				s.frames++
+				last := ""
+				if len(s.tail) > 0 {
+					last = s.tail[len(s.tail)-1]
+				}
+				s.renderer.sectionLine(s.console.writer, s.title, last, s.frames)
+			}
+			s.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package console
+
+// Status line displayed while waiting for something, redrawn in place with a
+// spinner on terminals.
+//
+// Elsewhere, the first status is written as an informative message and the
+// following ones are not.
+type Status struct {
+	console  *Console
+	renderer renderer
+	frames   int
+}
+
+// Start a status line, displayed by its first `Update`.
+func (c *Console) Status() *Status {
+	return &Status{console: c, renderer: c.renderer()}
+}
+
+// Display that status in place of the previous one.
+func (s *Status) Update(status string) {
+	s.renderer.status(s.console.writer, status, s.frames)
+	s.frames++
+}
+
+// Remove the status line, if any was displayed. The next `Update` displays
+// it again.
+func (s *Status) Clear() {
+	if s.frames > 0 {
+		s.renderer.clearStatus(s.console.writer)
+	}
+	s.frames = 0
+}
@@ -0,0 +1,54 @@
+// # activation.go
+// Activation scripts of a project (`ACTIVATE` in its run.conf) are mounted in
+// its container and sourced by its shells when they start, after paul-envs'
+// own overrides, e.g. to load a version manager or export variables the
+// project needs.
+//
+// Bash and zsh source the same POSIX scripts, which also apply to the
+// commands run through them. Fish cannot source those: only scripts ending
+// in `.fish` are sourced by it, and the others are skipped. Nushell sources
+// none.
+
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Directory in the container where activation scripts are mounted, as files
+// named after their position and kept extension.
+const containerActivateDir = "/paul-env/activate"
+
+// Arguments mounting the activation scripts of a project in its container and
+// telling the entrypoint their order.
+func activationRunArgs(project files.ProjectEntry, runtimeCfg config.RuntimeConfig) ([]string, error) {
+	if len(runtimeCfg.ActivationScripts) == 0 {
+		return nil, nil
+	}
+	var args []string
+	var names []string
+	for i, script := range runtimeCfg.ActivationScripts {
+		hostPath, err := resolveRuntimePath(project.RuntimeConfigPath, script)
+		if err != nil {
+			return nil, fmt.Errorf("resolve ACTIVATE: %w", err)
+		}
+		if info, err := os.Stat(hostPath); err != nil || info.IsDir() {
+			return nil, fmt.Errorf("activation script %q not found: %s is not a file\nHint: Create it or remove it from %s",
+				script, hostPath, project.RuntimeConfigPath)
+		}
+		name := fmt.Sprintf("%d.sh", i+1)
+		if strings.EqualFold(filepath.Ext(hostPath), ".fish") {
+			name = fmt.Sprintf("%d.fish", i+1)
+		}
+		args = append(args, "--volume", bindVolume(hostPath, path.Join(containerActivateDir, name), "ro", autoRelabel(runtimeCfg, hostPath)))
+		names = append(names, name)
+	}
+	return append(args, "--env", "PAULENV_ACTIVATE="+strings.Join(names, " ")), nil
+}
@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestActivationRunArgs(t *testing.T) {
+	setSELinuxEnforcing(t, false)
+	dir := t.TempDir()
+	for _, name := range []string{"env.sh", "nvm.fish"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("true\n"), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: filepath.Join(dir, "run.conf")}
+	runtimeCfg := config.RuntimeConfig{ActivationScripts: []string{"env.sh", filepath.Join(dir, "nvm.fish")}}
+
+	args, err := activationRunArgs(project, runtimeCfg)
+	if err != nil {
+		t.Fatalf("activationRunArgs() error = %v", err)
+	}
+	want := []string{
+		"--volume", filepath.Join(dir, "env.sh") + ":/paul-env/activate/1.sh:ro",
+		"--volume", filepath.Join(dir, "nvm.fish") + ":/paul-env/activate/2.fish:ro",
+		"--env", "PAULENV_ACTIVATE=1.sh 2.fish",
+	}
+	if !slices.Equal(args, want) {
+		t.Fatalf("activationRunArgs() = %q, want %q", args, want)
+	}
+
+	runtimeCfg.ActivationScripts = append(runtimeCfg.ActivationScripts, "missing.sh")
+	if _, err := activationRunArgs(project, runtimeCfg); err == nil {
+		t.Fatalf("activationRunArgs() should fail with a missing script")
+	}
+	if args, err := activationRunArgs(project, config.RuntimeConfig{}); err != nil || len(args) != 0 {
+		t.Fatalf("activationRunArgs() without scripts = %q, %v, want no argument", args, err)
+	}
+}
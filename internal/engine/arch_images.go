@@ -0,0 +1,108 @@
+// # arch_images.go
+// A project can have images built for several architectures, e.g. through
+// emulation or on an engine shared between an arm64 and an amd64 host.
+//
+// Each build also tags its image for the architecture it targets, so `run`
+// can make the one matching the host the project's current image instead
+// of running another architecture's through emulation.
+
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Information on the image of a project built for a given architecture.
+type ArchImageInfo struct {
+	// The name of the corresponding paulenv project
+	ProjectName string
+	// Architecture it has been built for, as named by Go and container
+	// engines (e.g. "amd64", "arm64")
+	Architecture string
+	// The name it is actually refered to by the container engine.
+	ImageName string
+	// The timestamp at which it has been built, `nil` if unknown.
+	BuiltAt *time.Time
+	// Disk usage of that image as reported by the container engine, empty if
+	// unknown.
+	Size string
+}
+
+// Name of the image of that project built for the given architecture.
+func archImageName(projectName string, architecture string) string {
+	return projectTaggedImageName("paulenv-arch", projectName, architecture)
+}
+
+// Parse the output of an `images` command listing
+// `{{.Repository}}:{{.Tag}}\t{{.CreatedAt}}\t{{.Size}}` into the
+// architecture-specific images it contains.
+func parseArchImageList(output string, quirks engineQuirks) []ArchImageInfo {
+	var result []ArchImageInfo
+	for _, image := range parseProjectTaggedImages(output, "paulenv-arch", quirks) {
+		result = append(result, ArchImageInfo{
+			ProjectName:  image.projectName,
+			Architecture: image.tag,
+			ImageName:    image.imageName,
+			BuiltAt:      image.createdAt,
+			Size:         image.size,
+		})
+	}
+	return result
+}
+
+var platformRegex = regexp.MustCompile(`^(?:linux/)?([a-z0-9_]+)(?:/(v[0-9]+))?$`)
+
+// Parse a target platform given by the user, either an architecture (e.g.
+// "arm64") or an OCI platform (e.g. "linux/arm64", "linux/arm/v7"), into the
+// OCI platform it designates.
+func ParsePlatform(value string) (string, error) {
+	matches := platformRegex.FindStringSubmatch(value)
+	if matches == nil {
+		return "", fmt.Errorf("invalid platform %q: expected an architecture (e.g. \"arm64\") or a linux platform (e.g. \"linux/arm64\")", value)
+	}
+	platform := "linux/" + matches[1]
+	if matches[2] != "" {
+		platform += "/" + matches[2]
+	}
+	return platform, nil
+}
+
+// Architecture of the given OCI platform, e.g. "arm64" for "linux/arm64".
+func PlatformArchitecture(platform string) string {
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 {
+		return platform
+	}
+	return parts[1]
+}
+
+// Name of the shared base image on top of which project images for the given
+// platform are built, empty for the engine's own.
+func baseImageNameFor(platform string) string {
+	if platform == "" {
+		return baseImageName
+	}
+	return "paulenv-base:" + strings.ReplaceAll(strings.TrimPrefix(platform, "linux/"), "/", "-")
+}
+
+// Format of the `image inspect` command whose output `parseImageInspect`
+// parses.
+const imageInspectFormat = "{{.Created}}\t{{.Architecture}}\t{{json .Config.Labels}}"
+
+// Parse the output of an `image inspect` command with the
+// `imageInspectFormat` format into the image's creation date, architecture
+// and labels.
+func parseImageInspect(output string) (string, string, map[string]string) {
+	fields := strings.Split(strings.TrimSpace(output), "\t")
+	for len(fields) < 3 {
+		fields = append(fields, "")
+	}
+	// `null` for images without labels
+	var labels map[string]string
+	_ = json.Unmarshal([]byte(strings.TrimSpace(fields[2])), &labels)
+	return fields[0], strings.TrimSpace(fields[1]), labels
+}
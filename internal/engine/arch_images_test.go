@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"testing"
+)
+
+func TestParsePlatform(t *testing.T) {
+	for value, want := range map[string]string{
+		"arm64":        "linux/arm64",
+		"linux/amd64":  "linux/amd64",
+		"linux/arm/v7": "linux/arm/v7",
+		"arm/v6":       "linux/arm/v6",
+	} {
+		got, err := ParsePlatform(value)
+		if err != nil || got != want {
+			t.Fatalf("ParsePlatform(%q) = %q, %v, want %q", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "windows/amd64", "linux/", "ARM64", "linux/arm64/v8/x"} {
+		if _, err := ParsePlatform(value); err == nil {
+			t.Fatalf("ParsePlatform(%q) should fail", value)
+		}
+	}
+}
+
+func TestPlatformArchitecture(t *testing.T) {
+	for platform, want := range map[string]string{"linux/arm64": "arm64", "linux/arm/v7": "arm", "": ""} {
+		if got := PlatformArchitecture(platform); got != want {
+			t.Fatalf("PlatformArchitecture(%q) = %q, want %q", platform, got, want)
+		}
+	}
+}
+
+func TestBaseImageNameFor(t *testing.T) {
+	for platform, want := range map[string]string{
+		"":             "paulenv-base:latest",
+		"linux/arm64":  "paulenv-base:arm64",
+		"linux/arm/v7": "paulenv-base:arm-v7",
+	} {
+		if got := baseImageNameFor(platform); got != want {
+			t.Fatalf("baseImageNameFor(%q) = %q, want %q", platform, got, want)
+		}
+		if !isBaseImage(baseImageNameFor(platform)) {
+			t.Fatalf("%q should be recognized as a base image", baseImageNameFor(platform))
+		}
+	}
+}
+
+func TestParseArchImageList(t *testing.T) {
+	output := "paulenv:app\t2026-03-04 10:20:30 +0000 UTC\t1.2GB\n" +
+		"localhost/paulenv-arch:app.arm64\t2026-03-04 10:20:30 +0000 UTC\t1.1GB\n" +
+		"paulenv-arch:app.amd64\t2026-03-05T10:10:10Z\t1.2GB\n"
+	got := parseArchImageList(output, quirksFor("podman", ""))
+	if len(got) != 2 {
+		t.Fatalf("parseArchImageList() = %+v, want 2 images", got)
+	}
+	if got[0].ProjectName != "app" || got[0].Architecture != "arm64" || got[0].Size != "1.1GB" || got[0].BuiltAt == nil {
+		t.Fatalf("unexpected first image: %+v", got[0])
+	}
+	if got[1].Architecture != "amd64" || got[1].ImageName != archImageName("app", "amd64") {
+		t.Fatalf("unexpected second image: %+v", got[1])
+	}
+}
+
+func TestParseImageInspect(t *testing.T) {
+	created, architecture, labels := parseImageInspect("2026-03-04T10:20:30Z\tarm64\t{\"paulenv.config-hash\":\"abc123\"}\n")
+	if created != "2026-03-04T10:20:30Z" || architecture != "arm64" || labels[configHashLabel] != "abc123" {
+		t.Fatalf("parseImageInspect() = %q, %q, %v", created, architecture, labels)
+	}
+	if _, _, labels := parseImageInspect("2026-03-04T10:20:30Z\tarm64\tnull\n"); len(labels) != 0 {
+		t.Fatalf("parseImageInspect() without label = %v", labels)
+	}
+	if created, architecture, _ := parseImageInspect("2026-03-04T10:20:30Z\n"); created != "2026-03-04T10:20:30Z" || architecture != "" {
+		t.Fatalf("parseImageInspect() without architecture = %q, %q", created, architecture)
+	}
+}
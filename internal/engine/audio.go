@@ -0,0 +1,85 @@
+// # audio.go
+// Forwarding of the host's sound server (PulseAudio or PipeWire) to
+// containers, so they can play and record sound.
+
+package engine
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Sound server sockets of the host which can be forwarded to containers.
+type hostAudio struct {
+	// Path to the PulseAudio socket (also served by `pipewire-pulse`), empty
+	// if none
+	pulseSocket string
+	// Path to PulseAudio's authentication cookie, empty if none
+	pulseCookie string
+	// Path to the native PipeWire socket, empty if none
+	pipewireSocket string
+}
+
+func detectHostAudio() hostAudio {
+	var audio hostAudio
+	runtimeDir := getenv("XDG_RUNTIME_DIR")
+
+	pulseSocket := ""
+	if server := getenv("PULSE_SERVER"); strings.HasPrefix(server, "unix:") {
+		pulseSocket = strings.TrimPrefix(server, "unix:")
+	} else if runtimeDir != "" {
+		pulseSocket = filepath.Join(runtimeDir, "pulse", "native")
+	}
+	if pulseSocket != "" && fileExists(pulseSocket) {
+		audio.pulseSocket = pulseSocket
+		cookie := getenv("PULSE_COOKIE")
+		if cookie == "" {
+			configDir := getenv("XDG_CONFIG_HOME")
+			if configDir == "" {
+				configDir = filepath.Join(getenv("HOME"), ".config")
+			}
+			cookie = filepath.Join(configDir, "pulse", "cookie")
+		}
+		if fileExists(cookie) {
+			audio.pulseCookie = cookie
+		}
+	}
+
+	if runtimeDir != "" {
+		remote := getenv("PIPEWIRE_REMOTE")
+		if remote == "" {
+			remote = "pipewire-0"
+		}
+		socket := remote
+		if !filepath.IsAbs(socket) {
+			socket = filepath.Join(runtimeDir, remote)
+		}
+		if fileExists(socket) {
+			audio.pipewireSocket = socket
+		}
+	}
+	return audio
+}
+
+// Arguments of the `run` command forwarding the given host sound server.
+func audioRunArgs(audio hostAudio) []string {
+	var args []string
+	if audio.pulseSocket != "" {
+		args = append(args,
+			"--volume", audio.pulseSocket+":"+containerRuntimeDir+"/pulse/native",
+			"--env", "PULSE_SERVER=unix:"+containerRuntimeDir+"/pulse/native",
+		)
+		if audio.pulseCookie != "" {
+			args = append(args,
+				"--volume", audio.pulseCookie+":/tmp/paulenv-pulse-cookie:ro",
+				"--env", "PULSE_COOKIE=/tmp/paulenv-pulse-cookie",
+			)
+		}
+	}
+	if audio.pipewireSocket != "" {
+		args = append(args,
+			"--volume", audio.pipewireSocket+":"+containerRuntimeDir+"/pipewire-0",
+		)
+	}
+	return args
+}
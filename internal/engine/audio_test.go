@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDetectHostAudio(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		existing []string
+		want     hostAudio
+	}{
+		{
+			name:     "pipewire with pulse compatibility",
+			env:      map[string]string{"XDG_RUNTIME_DIR": "/run/user/1000", "HOME": "/home/me"},
+			existing: []string{"/run/user/1000/pulse/native", "/run/user/1000/pipewire-0"},
+			want:     hostAudio{pulseSocket: "/run/user/1000/pulse/native", pipewireSocket: "/run/user/1000/pipewire-0"},
+		},
+		{
+			name:     "pulseaudio with cookie",
+			env:      map[string]string{"XDG_RUNTIME_DIR": "/run/user/1000", "HOME": "/home/me"},
+			existing: []string{"/run/user/1000/pulse/native", "/home/me/.config/pulse/cookie"},
+			want:     hostAudio{pulseSocket: "/run/user/1000/pulse/native", pulseCookie: "/home/me/.config/pulse/cookie"},
+		},
+		{
+			name:     "explicit pulse server",
+			env:      map[string]string{"PULSE_SERVER": "unix:/tmp/pulse.sock"},
+			existing: []string{"/tmp/pulse.sock"},
+			want:     hostAudio{pulseSocket: "/tmp/pulse.sock"},
+		},
+		{
+			name:     "no sound server",
+			env:      map[string]string{"XDG_RUNTIME_DIR": "/run/user/1000"},
+			existing: nil,
+			want:     hostAudio{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubHost(t, tt.env, tt.existing...)
+			if got := detectHostAudio(); got != tt.want {
+				t.Fatalf("detectHostAudio() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAudioRunArgs(t *testing.T) {
+	got := audioRunArgs(hostAudio{
+		pulseSocket:    "/run/user/1000/pulse/native",
+		pulseCookie:    "/home/me/.config/pulse/cookie",
+		pipewireSocket: "/run/user/1000/pipewire-0",
+	})
+	want := []string{
+		"--volume", "/run/user/1000/pulse/native:/tmp/paulenv-runtime/pulse/native",
+		"--env", "PULSE_SERVER=unix:/tmp/paulenv-runtime/pulse/native",
+		"--volume", "/home/me/.config/pulse/cookie:/tmp/paulenv-pulse-cookie:ro",
+		"--env", "PULSE_COOKIE=/tmp/paulenv-pulse-cookie",
+		"--volume", "/run/user/1000/pipewire-0:/tmp/paulenv-runtime/pipewire-0",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("audioRunArgs() = %v, want %v", got, want)
+	}
+	if got := audioRunArgs(hostAudio{}); got != nil {
+		t.Fatalf("audioRunArgs() = %v without sound server, want nil", got)
+	}
+}
@@ -0,0 +1,35 @@
+// # broadcast.go
+// Users of a running container can be told something (e.g. that it will
+// soon be stopped) by writing it to all its terminals: those of the `run`
+// which started it and of the shells joining it, each being a pseudo-terminal
+// of the container.
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Writes the message (`$0`) to every pseudo-terminal of the container,
+// ringing its bell.
+const broadcastScript = `for tty in /dev/pts/[0-9]*; do
+    [ -c "$tty" ] && printf '\n\a%s\n' "$0" > "$tty" 2>/dev/null
+done
+exit 0`
+
+// Display that message on every terminal of that running container.
+func BroadcastMessage(ctx context.Context, c ContainerEngine, container ContainerInfo, message string) error {
+	err := c.ExecContainer(ctx, container, []string{"sh", "-c", broadcastScript, message}, ExecOptions{
+		NoTTY:  true,
+		Stdin:  strings.NewReader(""),
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to display a message in container %s: %w", container.ContainerId, err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// # build_cache_stats.go
+// How much of a build came from the engine's cache is read back from its
+// output: BuildKit's plain progress numbers each step and reports those it
+// reused as `#<n> CACHED`, while Podman and Buildah announce each step with
+// `STEP <i>/<n>:` and the reused ones with `--> Using cache`.
+
+package engine
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// e.g. "#7 [stage 3/9] RUN apt-get update", capturing the step's number
+	// and instruction
+	buildKitStepRegex = regexp.MustCompile(`#(\d+) \[[^\]]*\d+/\d+\] (\S+)`)
+	// e.g. "#7 CACHED"
+	buildKitCachedRegex = regexp.MustCompile(`#(\d+) CACHED\s*$`)
+	// e.g. "STEP 3/9: RUN apt-get update", capturing its instruction
+	buildahStepRegex = regexp.MustCompile(`STEP \d+/\d+: (\S+)`)
+)
+
+// Count the steps of a build reused from the engine's cache and all of its
+// steps in its output, `FROM` ones excluded. Both are `0` if that output has
+// no recognizable step.
+func BuildCacheStats(output string) (cached int, steps int) {
+	buildKitSteps := map[string]bool{}
+	buildKitCached := map[string]bool{}
+	buildahCached := 0
+	buildahSteps := 0
+	for line := range strings.SplitSeq(output, "\n") {
+		if match := buildKitStepRegex.FindStringSubmatch(line); match != nil {
+			if !strings.EqualFold(match[2], "FROM") {
+				buildKitSteps[match[1]] = true
+			}
+		} else if match := buildKitCachedRegex.FindStringSubmatch(line); match != nil {
+			buildKitCached[match[1]] = true
+		} else if match := buildahStepRegex.FindStringSubmatch(line); match != nil {
+			if !strings.EqualFold(match[1], "FROM") {
+				buildahSteps++
+			}
+		} else if strings.Contains(line, "--> Using cache") {
+			buildahCached++
+		}
+	}
+	if len(buildKitSteps) > 0 {
+		for step := range buildKitSteps {
+			if buildKitCached[step] {
+				cached++
+			}
+		}
+		return cached, len(buildKitSteps)
+	}
+	return min(buildahCached, buildahSteps), buildahSteps
+}
@@ -0,0 +1,31 @@
+package engine
+
+import "testing"
+
+func TestBuildCacheStats(t *testing.T) {
+	buildKit := "[10:00:01] #4 [base 1/3] FROM docker.io/library/paulenv-base:latest\n" +
+		"[10:00:01] #4 CACHED\n" +
+		"[10:00:01] #5 [base 2/3] RUN apt-get update\n" +
+		"[10:00:01] #5 CACHED\n" +
+		"[10:00:02] #6 [base 3/3] COPY dotfiles /home/dev\n" +
+		"[10:00:02] #6 DONE 0.1s\n" +
+		"[10:00:02] #6 [base 3/3] COPY dotfiles /home/dev\n"
+	if cached, steps := BuildCacheStats(buildKit); cached != 1 || steps != 2 {
+		t.Fatalf("BuildCacheStats() of a BuildKit build = %d/%d, want 1/2", cached, steps)
+	}
+
+	buildah := "STEP 1/4: FROM localhost/paulenv-base:latest\n" +
+		"STEP 2/4: ARG USERNAME\n" +
+		"--> Using cache 1a2b3c\n" +
+		"STEP 3/4: RUN apt-get update\n" +
+		"--> Using cache 4d5e6f\n" +
+		"STEP 4/4: COPY dotfiles /home/dev\n" +
+		"--> 7a8b9c\n"
+	if cached, steps := BuildCacheStats(buildah); cached != 2 || steps != 3 {
+		t.Fatalf("BuildCacheStats() of a Podman build = %d/%d, want 2/3", cached, steps)
+	}
+
+	if cached, steps := BuildCacheStats("Successfully built\n"); cached != 0 || steps != 0 {
+		t.Fatalf("BuildCacheStats() without steps = %d/%d, want 0/0", cached, steps)
+	}
+}
@@ -0,0 +1,112 @@
+// # build_retry.go
+// Builds pull images and packages from the network, where a single flaky
+// request to a registry or a mirror would otherwise fail a long build. Builds
+// failing on errors known to be transient are thus retried after an
+// increasing delay, the engine's cache keeping what was already built.
+//
+// Each attempt can also be bounded in time, so a stuck build does not block
+// e.g. a CI job forever.
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+// Delay before retrying a build the first time, doubled for each following
+// retry. Isolated for tests.
+var buildRetryDelay = 10 * time.Second
+
+// Lowercase fragments of the output of builds failing on network or registry
+// errors which may not happen again.
+var transientBuildErrors = []string{
+	"tls handshake timeout",
+	"i/o timeout",
+	"connection reset by peer",
+	"connection timed out",
+	"unexpected eof",
+	"temporary failure in name resolution",
+	"temporary failure resolving",
+	"could not connect to",
+	"net/http: request canceled",
+	"502 bad gateway",
+	"503 service unavailable",
+	"504 gateway timeout",
+	"429 too many requests",
+	"toomanyrequests",
+}
+
+// Returns `options` with the build settings of a project's run.conf replacing
+// the global ones.
+func withProjectBuildSettings(options BuildOptions, runtimeCfg config.RuntimeConfig) BuildOptions {
+	if runtimeCfg.BuildTimeout != nil {
+		options.Timeout = *runtimeCfg.BuildTimeout
+	}
+	if runtimeCfg.BuildRetries != nil {
+		options.Retries = *runtimeCfg.BuildRetries
+	}
+	return options
+}
+
+// Returns `true` if the given end of a failed build's output shows a failure
+// which may not happen again.
+func isTransientBuildFailure(output string) bool {
+	output = strings.ToLower(output)
+	for _, fragment := range transientBuildErrors {
+		if strings.Contains(output, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run the build command created by `newCommand` for the given context, each
+// attempt bounded by `options.Timeout` and retried up to `options.Retries`
+// times after a transient failure. Failures are returned as `*BuildError`.
+func runBuildAttempts(ctx context.Context, options BuildOptions, newCommand func(ctx context.Context) *exec.Cmd) error {
+	_, stderr := buildOutputs(options)
+	for attempt := 0; ; attempt++ {
+		output := &tailBuffer{limit: loggedOutputSize}
+		attemptOptions := options
+		attemptOptions.Log = output
+		if options.Log != nil {
+			attemptOptions.Log = io.MultiWriter(options.Log, output)
+		}
+		err := runBuildAttempt(ctx, attemptOptions, newCommand)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		if attempt >= options.Retries || !isTransientBuildFailure(output.String()) {
+			return &BuildError{Err: err, LogTail: output.String()}
+		}
+		delay := buildRetryDelay << attempt
+		fmt.Fprintf(stderr, "Build failed on what looks like a transient network error, retrying in %s (%d/%d)...\n",
+			delay, attempt+1, options.Retries)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func runBuildAttempt(ctx context.Context, options BuildOptions, newCommand func(ctx context.Context) *exec.Cmd) error {
+	if options.Timeout <= 0 {
+		return runBuildCommand(newCommand(ctx), options)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, options.Timeout)
+	defer cancel()
+	err := runBuildCommand(newCommand(attemptCtx), options)
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s: %w", options.Timeout, err)
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+func TestIsTransientBuildFailure(t *testing.T) {
+	for _, output := range []string{
+		"ERROR: failed to solve: ubuntu:24.04: failed to do request: Head \"https://registry-1.docker.io/v2/\": net/http: TLS handshake timeout",
+		"Error: initializing source docker://ubuntu:24.04: reading manifest: toomanyrequests: You have reached your pull rate limit",
+		"E: Failed to fetch http://archive.ubuntu.com/ubuntu/pool/main/c/curl.deb  Temporary failure resolving 'archive.ubuntu.com'",
+		"received unexpected HTTP status: 503 Service Unavailable",
+	} {
+		if !isTransientBuildFailure(output) {
+			t.Errorf("isTransientBuildFailure(%q) = false, want true", output)
+		}
+	}
+	for _, output := range []string{
+		"E: Unable to locate package nodejss",
+		"ERROR: failed to solve: process \"/bin/sh -c make\" did not complete successfully: exit code: 2",
+	} {
+		if isTransientBuildFailure(output) {
+			t.Errorf("isTransientBuildFailure(%q) = true, want false", output)
+		}
+	}
+}
+
+func TestRunBuildAttempts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on sh")
+	}
+	prevDelay := buildRetryDelay
+	t.Cleanup(func() { buildRetryDelay = prevDelay })
+	buildRetryDelay = time.Millisecond
+
+	// Fails on a transient error until it was run `failures + 1` times
+	attemptsFile := filepath.Join(t.TempDir(), "attempts")
+	flakyBuild := func(failures int, message string) func(ctx context.Context) *exec.Cmd {
+		return func(ctx context.Context) *exec.Cmd {
+			return exec.CommandContext(ctx, "sh", "-c", `echo x >> "$0"; [ "$(wc -l < "$0")" -gt "$1" ] && exit 0; echo "$2" >&2; exit 1`,
+				attemptsFile, strconv.Itoa(failures), message)
+		}
+	}
+	attempts := func() int {
+		data, _ := os.ReadFile(attemptsFile)
+		return strings.Count(string(data), "\n")
+	}
+	reset := func() { _ = os.Remove(attemptsFile) }
+
+	options := BuildOptions{Quiet: true, Retries: 2}
+	if err := runBuildAttempts(context.Background(), options, flakyBuild(2, "i/o timeout")); err != nil {
+		t.Fatalf("runBuildAttempts() error = %v, want success on the last retry", err)
+	}
+	if got := attempts(); got != 3 {
+		t.Fatalf("runBuildAttempts() ran %d attempts, want 3", got)
+	}
+
+	reset()
+	if err := runBuildAttempts(context.Background(), options, flakyBuild(3, "i/o timeout")); err == nil {
+		t.Fatalf("runBuildAttempts() should fail once out of retries")
+	}
+	if got := attempts(); got != 3 {
+		t.Fatalf("runBuildAttempts() ran %d attempts, want 3", got)
+	}
+
+	reset()
+	err := runBuildAttempts(context.Background(), options, flakyBuild(1, "Unable to locate package"))
+	if err == nil {
+		t.Fatalf("runBuildAttempts() should not retry a non-transient failure")
+	}
+	var buildErr *BuildError
+	if !errors.As(err, &buildErr) || !strings.Contains(buildErr.LogTail, "Unable to locate package") {
+		t.Fatalf("runBuildAttempts() error = %v, want a BuildError with the end of its output", err)
+	}
+	if got := attempts(); got != 1 {
+		t.Fatalf("runBuildAttempts() ran %d attempts, want 1", got)
+	}
+}
+
+func TestRunBuildAttempts_Timeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on sh")
+	}
+	options := BuildOptions{Quiet: true, Timeout: 50 * time.Millisecond, Retries: 2}
+	err := runBuildAttempts(context.Background(), options, func(ctx context.Context) *exec.Cmd {
+		return exec.CommandContext(ctx, "sleep", "5")
+	})
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Fatalf("runBuildAttempts() error = %v, want a timeout", err)
+	}
+}
+
+func TestWithProjectBuildSettings(t *testing.T) {
+	timeout, retries := time.Hour, 0
+	options := withProjectBuildSettings(BuildOptions{Timeout: time.Minute, Retries: 2}, config.RuntimeConfig{})
+	if options.Timeout != time.Minute || options.Retries != 2 {
+		t.Fatalf("withProjectBuildSettings() without settings = %+v, want the global ones", options)
+	}
+	options = withProjectBuildSettings(options, config.RuntimeConfig{BuildTimeout: &timeout, BuildRetries: &retries})
+	if options.Timeout != time.Hour || options.Retries != 0 {
+		t.Fatalf("withProjectBuildSettings() = %+v, want the project's ones", options)
+	}
+}
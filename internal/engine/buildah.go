@@ -0,0 +1,69 @@
+// # buildah.go
+// Podman builds images through Buildah's library. With the `PODMAN_BUILDER`
+// global setting set to "buildah", the `buildah` CLI is run directly instead,
+// with the same flags: its step-by-step output is more detailed and its
+// layers caching can be controlled (see `SQUASH`).
+//
+// Buildah shares the image storage of the local Podman, which thus finds the
+// images it built. It cannot reach the storage of a Podman virtual machine or
+// of rootful Podman, reached through their API socket.
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Programs Podman images can be built with, values of
+// `BuildOptions.Builder`.
+const (
+	BuilderPodman  = "podman"
+	BuilderBuildah = "buildah"
+)
+
+// Returns an error if the builder set by `options` cannot build images for
+// this engine.
+func (c *PodmanEngine) checkBuilder(options BuildOptions) error {
+	if options.Builder != BuilderBuildah {
+		return nil
+	}
+	if hostOS != "linux" || c.remoteURL != "" || windowsCLIFromWSL.Load() {
+		return errors.New("PODMAN_BUILDER=buildah needs Podman to run on this Linux host, not in a virtual machine nor in rootful mode")
+	}
+	if _, err := exec.LookPath("buildah"); err != nil {
+		return utils.WithCategory(fmt.Errorf("PODMAN_BUILDER=buildah needs the buildah CLI: %w", err), ErrEngineUnavailable)
+	}
+	return nil
+}
+
+// Create the command building an image with the given `podman build`
+// arguments, run by the builder set by `options`.
+func (c *PodmanEngine) buildCommand(ctx context.Context, options BuildOptions, args ...string) *exec.Cmd {
+	if options.Builder == BuilderBuildah {
+		// `buildah build` takes the same arguments
+		return engineCommand(ctx, "buildah", args...)
+	}
+	return c.command(ctx, args...)
+}
+
+// Flags squashing the layers produced by a build, or keeping them.
+//
+// Buildah only caches intermediate layers with `--layers`, which Podman sets
+// by default.
+func podmanLayerArgs(options BuildOptions) []string {
+	if options.Builder == BuilderBuildah {
+		if options.squash {
+			return nil
+		}
+		return []string{"--layers"}
+	}
+	if options.squash {
+		return []string{"--squash"}
+	}
+	return nil
+}
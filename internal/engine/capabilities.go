@@ -0,0 +1,138 @@
+// # capabilities.go
+// Some features of paul-envs rely on things only some container engines, or
+// some of their versions, can do: cache mounts in builds, CRIU checkpoints,
+// CDI devices, running Kubernetes YAML...
+//
+// Each engine reports what it can do as its `Capabilities`, which features
+// check before relying on one, so they can do without it or tell clearly why
+// they cannot run instead of surfacing the engine's own failure.
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Matched with `errors.Is` by errors returned when the container engine lacks
+// a capability a feature needs.
+var ErrUnsupported = errors.New("unsupported")
+
+var (
+	// The generated Dockerfiles cannot be built
+	ErrCacheMountsUnsupported = fmt.Errorf("build cache mounts %w", ErrUnsupported)
+	ErrKubePlayUnsupported    = fmt.Errorf("kube play %w", ErrUnsupported)
+)
+
+// What a container engine can do, beyond what all of them can.
+type Capabilities struct {
+	// Builds support cache mounts (`RUN --mount=type=cache`), which the
+	// generated Dockerfiles rely on
+	CacheMounts bool `json:"cacheMounts"`
+	// `PruneBuildCache` only removes the build cache of paul-envs' images
+	BuildCachePrune bool `json:"buildCachePrune"`
+	// `CheckpointContainer` and `RestoreContainer` are supported
+	Checkpoint bool `json:"checkpoint"`
+	// Containers can be given devices by their CDI name (e.g.
+	// "nvidia.com/gpu=all")
+	CDIDevices bool `json:"cdiDevices"`
+	// `PlayKube` is supported
+	KubePlay bool `json:"kubePlay"`
+}
+
+// A capability as shown to the user, e.g. by `paul-envs version`.
+type Capability struct {
+	Name      string
+	Supported bool
+}
+
+// All capabilities, supported or not, in a stable order.
+func (c Capabilities) List() []Capability {
+	return []Capability{
+		{Name: "build cache mounts", Supported: c.CacheMounts},
+		{Name: "scoped build cache pruning", Supported: c.BuildCachePrune},
+		{Name: "checkpoints", Supported: c.Checkpoint},
+		{Name: "CDI devices", Supported: c.CDIDevices},
+		{Name: "kube play", Supported: c.KubePlay},
+	}
+}
+
+// First versions of an engine having a capability, by engine.
+var (
+	// Docker 23 moved BuildKit to the separate buildx plugin, which is then
+	// checked for
+	dockerBuildxVersion = utils.Version{Major: 23, Minor: 0, Patch: 0}
+	// Enabled by default from then on, only experimental before
+	dockerCDIVersion = utils.Version{Major: 28, Minor: 2, Patch: 0}
+	// Buildah 1.23, which it relies on, added `RUN --mount`
+	podmanCacheMountsVersion = utils.Version{Major: 3, Minor: 4, Patch: 0}
+	podmanCDIVersion         = utils.Version{Major: 4, Minor: 1, Patch: 0}
+	// Previously `podman play kube`
+	podmanKubePlayVersion = utils.Version{Major: 4, Minor: 2, Patch: 0}
+)
+
+// Returns `true` if `version` is `since` or a later version. Unknown versions
+// are assumed to be recent enough, not to refuse what may work.
+func isVersionSince(version string, since utils.Version) bool {
+	parsed, err := utils.ParseVersion(version)
+	return err != nil || !parsed.IsBefore(since)
+}
+
+// What the given Docker version can do, `hasBuildx` telling if its buildx
+// plugin is installed.
+func dockerCapabilities(version string, hasBuildx bool) Capabilities {
+	return Capabilities{
+		CacheMounts:     hasBuildx || !isVersionSince(version, dockerBuildxVersion),
+		BuildCachePrune: true,
+		CDIDevices:      isVersionSince(version, dockerCDIVersion),
+	}
+}
+
+// What the given Podman version can do, rootful or not: CRIU needs root.
+func podmanCapabilities(version string, rootful bool) Capabilities {
+	return Capabilities{
+		CacheMounts: isVersionSince(version, podmanCacheMountsVersion),
+		Checkpoint:  rootful,
+		CDIDevices:  isVersionSince(version, podmanCDIVersion),
+		KubePlay:    isVersionSince(version, podmanKubePlayVersion),
+	}
+}
+
+func (c *DockerEngine) Capabilities(ctx context.Context) Capabilities {
+	c.capabilitiesOnce.Do(func() {
+		info, _ := c.Info(ctx)
+		hasBuildx := runEngineCommand(engineCommand(ctx, "docker", "buildx", "version")) == nil
+		c.capabilities = dockerCapabilities(info.Version, hasBuildx)
+	})
+	return c.capabilities
+}
+
+func (c *PodmanEngine) Capabilities(ctx context.Context) Capabilities {
+	c.capabilitiesOnce.Do(func() {
+		info, _ := c.Info(ctx)
+		c.capabilities = podmanCapabilities(info.Version, c.rootful || os.Geteuid() == 0)
+	})
+	return c.capabilities
+}
+
+// Fails if the Docker installation cannot build the generated Dockerfiles.
+func (c *DockerEngine) checkCacheMounts(ctx context.Context) error {
+	if c.Capabilities(ctx).CacheMounts {
+		return nil
+	}
+	return fmt.Errorf("%w: builds need BuildKit, which Docker only provides through its buildx plugin since version %d\n"+
+		"Hint: Install it, e.g. with the docker-buildx-plugin or docker-buildx package", ErrCacheMountsUnsupported, dockerBuildxVersion.Major)
+}
+
+// Fails if the Podman installation cannot build the generated Dockerfiles.
+// `buildah` builds are left to it.
+func (c *PodmanEngine) checkCacheMounts(ctx context.Context, options BuildOptions) error {
+	if options.Builder == BuilderBuildah || c.Capabilities(ctx).CacheMounts {
+		return nil
+	}
+	return fmt.Errorf("%w: builds need Podman %s or later", ErrCacheMountsUnsupported, podmanCacheMountsVersion.ToString())
+}
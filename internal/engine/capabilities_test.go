@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+)
+
+func TestDockerCapabilities(t *testing.T) {
+	for _, tc := range []struct {
+		version   string
+		hasBuildx bool
+		want      Capabilities
+	}{
+		{"20.10.24", false, Capabilities{CacheMounts: true, BuildCachePrune: true}},
+		{"24.0.7", false, Capabilities{BuildCachePrune: true}},
+		{"24.0.7", true, Capabilities{CacheMounts: true, BuildCachePrune: true}},
+		{"28.2.1", true, Capabilities{CacheMounts: true, BuildCachePrune: true, CDIDevices: true}},
+		{"", false, Capabilities{BuildCachePrune: true, CDIDevices: true}},
+	} {
+		if got := dockerCapabilities(tc.version, tc.hasBuildx); got != tc.want {
+			t.Errorf("dockerCapabilities(%q, %v) = %+v, want %+v", tc.version, tc.hasBuildx, got, tc.want)
+		}
+	}
+}
+
+func TestPodmanCapabilities(t *testing.T) {
+	for _, tc := range []struct {
+		version string
+		rootful bool
+		want    Capabilities
+	}{
+		{"3.0.1", false, Capabilities{}},
+		{"3.4.4", true, Capabilities{CacheMounts: true, Checkpoint: true}},
+		{"4.1.1", false, Capabilities{CacheMounts: true, CDIDevices: true}},
+		{"5.2.0", false, Capabilities{CacheMounts: true, CDIDevices: true, KubePlay: true}},
+		{"unknown", true, Capabilities{CacheMounts: true, Checkpoint: true, CDIDevices: true, KubePlay: true}},
+	} {
+		if got := podmanCapabilities(tc.version, tc.rootful); got != tc.want {
+			t.Errorf("podmanCapabilities(%q, %v) = %+v, want %+v", tc.version, tc.rootful, got, tc.want)
+		}
+	}
+}
+
+func TestUnsupportedErrors(t *testing.T) {
+	for _, err := range []error{ErrCheckpointUnsupported, ErrCacheMountsUnsupported, ErrKubePlayUnsupported} {
+		if !errors.Is(err, ErrUnsupported) {
+			t.Errorf("%v should match ErrUnsupported", err)
+		}
+	}
+	if err := (&DockerEngine{}).PlayKube(context.Background(), "pod.yaml"); !errors.Is(err, ErrKubePlayUnsupported) {
+		t.Errorf("DockerEngine.PlayKube() error = %v, want ErrKubePlayUnsupported", err)
+	}
+}
+
+func TestPluginEngine_Capabilities(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on sh")
+	}
+	installFakePlugin(t, "capable", "#!/bin/sh\necho '{\"result\": {\"protocol\": 1, \"buildCachePrune\": true, \"kubePlay\": true}}'\n")
+	ctx := context.Background()
+	plugin, err := newPlugin(ctx, "capable")
+	if err != nil {
+		t.Fatalf("newPlugin() error = %v", err)
+	}
+	if got, want := plugin.Capabilities(ctx), (Capabilities{BuildCachePrune: true, KubePlay: true}); got != want {
+		t.Fatalf("Capabilities() = %+v, want %+v", got, want)
+	}
+
+	installFakePlugin(t, "basic", "#!/bin/sh\necho '{\"result\": {\"protocol\": 1}}'\n")
+	plugin, err = newPlugin(ctx, "basic")
+	if err != nil {
+		t.Fatalf("newPlugin() error = %v", err)
+	}
+	if err := plugin.PlayKube(ctx, "pod.yaml"); !errors.Is(err, ErrKubePlayUnsupported) {
+		t.Fatalf("PlayKube() error = %v, want ErrKubePlayUnsupported", err)
+	}
+}
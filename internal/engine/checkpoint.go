@@ -0,0 +1,87 @@
+// # checkpoint.go
+// Podman can checkpoint a running container with CRIU: its processes, their
+// memory and its filesystem changes are written to an archive, from which the
+// container is later recreated and resumed where it was, e.g. after a reboot.
+//
+// Volumes are left out of those archives as they outlive the container
+// anyway, and are shared with the project's other instances.
+//
+// CRIU needs root, so only rootful Podman supports it. Docker's own
+// checkpoints are still experimental and cannot be moved out of its storage.
+
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/profiling"
+)
+
+// Matched with `errors.Is` by errors returned when the container engine
+// cannot checkpoint containers, and by `ErrUnsupported`.
+var ErrCheckpointUnsupported = fmt.Errorf("checkpoints %w", ErrUnsupported)
+
+// Flags given both when checkpointing and restoring: CRIU needs them to be
+// the same.
+var checkpointFlags = []string{"--ignore-volumes", "--tcp-established", "--file-locks"}
+
+func (c *DockerEngine) CheckpointContainer(context.Context, ContainerInfo, string) error {
+	return fmt.Errorf("%w: Docker cannot export checkpoints, only rootful Podman can", ErrCheckpointUnsupported)
+}
+
+func (c *DockerEngine) RestoreContainer(context.Context, files.ProjectEntry, string) error {
+	return fmt.Errorf("%w: Docker cannot import checkpoints, only rootful Podman can", ErrCheckpointUnsupported)
+}
+
+func (c *PodmanEngine) CheckpointContainer(ctx context.Context, container ContainerInfo, exportPath string) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman CheckpointContainer")()
+	if err := c.checkCheckpointSupport(ctx); err != nil {
+		return err
+	}
+	args := append([]string{"container", "checkpoint", "--export", exportPath}, checkpointFlags...)
+	cmd := c.command(ctx, append(args, container.ContainerId)...)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to checkpoint container %s: %w", container.ContainerId, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) RestoreContainer(ctx context.Context, project files.ProjectEntry, importPath string) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman RestoreContainer")()
+	if err := c.checkCheckpointSupport(ctx); err != nil {
+		return err
+	}
+	runtimeCfg, err := loadRuntimeConfig(project)
+	if err != nil {
+		return err
+	}
+	// Its volumes and network may have been removed since it was checkpointed
+	if err := c.ensureVolumesExist(ctx, "paulenv-shared-cache", ProjectLocalVolumeName(project.ProjectName)); err != nil {
+		return err
+	}
+	if network := runNetworkName(project.ProjectName, runtimeCfg); network != "" {
+		if err := c.ensureNetworkExists(ctx, network); err != nil {
+			return err
+		}
+	}
+	args := append([]string{"container", "restore", "--import", importPath}, checkpointFlags...)
+	if err := runEngineCommand(c.command(ctx, args...)); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to restore container of project '%s': %w", project.ProjectName, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) checkCheckpointSupport(ctx context.Context) error {
+	if !c.Capabilities(ctx).Checkpoint {
+		return fmt.Errorf("%w: rootless Podman cannot run CRIU, use rootful Podman ('--engine %s')", ErrCheckpointUnsupported, SelectionPodmanRootful)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// A resource name derived from a project which is already taken by an engine
+// resource not belonging to that project.
+type NameCollision struct {
+	// Kind of resource, e.g. "container"
+	Kind string
+	// The conflicting name
+	Name string
+	// Human-readable description of what currently uses that name
+	Detail string
+}
+
+// Names of the resources looked for by `ListNamedResources`.
+type ResourceNames struct {
+	Containers []string
+	Volumes    []string
+	Networks   []string
+}
+
+// A resource found by its exact name through `ListNamedResources`, whatever
+// created it.
+type NamedResource struct {
+	// Kind of resource, e.g. "container"
+	Kind string
+	Name string
+	// The image it runs, for containers
+	ImageName string
+	// All its labels, empty if it has none
+	Labels map[string]string
+}
+
+var imageIDRegex = regexp.MustCompile(`^(sha256:)?[0-9a-f]{12,64}$`)
+
+// Check that the resource names the given project relies on are not already
+// used by resources foreign to it, so we can fail early with a clear message
+// instead of letting the engine fail half-way.
+func FindNameCollisions(ctx context.Context, c ContainerEngine, projectName string) ([]NameCollision, error) {
+	// Foreign resources are not listed by `ListContainers` and the like,
+	// which only report paul-envs' own: they are looked up by name
+	resources, err := c.ListNamedResources(ctx, ResourceNames{
+		Containers: []string{projectContainerName(projectName)},
+		Volumes:    []string{ProjectLocalVolumeName(projectName)},
+		Networks:   []string{projectNetworkName(projectName)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resources: %w", err)
+	}
+	return findNameCollisions(projectName, resources), nil
+}
+
+// Arguments of the `ps` command listing the containers with exactly those
+// names, whatever created them.
+func namedContainerListArgs(names []string) []string {
+	args := []string{"ps", "-a", "--no-trunc"}
+	for _, name := range names {
+		args = append(args, "--filter", "name=^"+regexp.QuoteMeta(name)+"$")
+	}
+	return append(args, "--format", "{{.ID}}\t{{.Image}}\t{{.Names}}\t{{json .Labels}}")
+}
+
+// Parse the output of a `ps` command of `namedContainerListArgs`, keeping
+// only the containers named as one of `names`.
+func parseNamedContainerList(output string, names []string) []NamedResource {
+	var resources []NamedResource
+	for line := range strings.SplitSeq(strings.TrimSpace(output), "\n") {
+		parts := strings.SplitN(line, "\t", 4)
+		if len(parts) < 3 || !slices.Contains(names, parts[2]) {
+			continue
+		}
+		resource := NamedResource{Kind: "container", Name: parts[2], ImageName: parts[1]}
+		if len(parts) > 3 {
+			resource.Labels = parseJSONLabels(parts[3])
+		}
+		resources = append(resources, resource)
+	}
+	return resources
+}
+
+// Arguments of the `volume ls` or `network ls` command (`kind` being
+// "volume" or "network") listing those with names containing one of `names`,
+// whatever created them.
+func namedResourceListArgs(kind string, names []string) []string {
+	args := []string{kind, "ls"}
+	for _, name := range names {
+		args = append(args, "--filter", "name="+name)
+	}
+	return append(args, "--format", "{{.Name}}\t{{json .Labels}}")
+}
+
+// Parse the output of a command of `namedResourceListArgs`, keeping only
+// the resources named exactly as one of `names`.
+func parseNamedResourceList(kind string, output string, names []string) []NamedResource {
+	var resources []NamedResource
+	for line := range strings.SplitSeq(strings.TrimSpace(output), "\n") {
+		name, labels, _ := strings.Cut(line, "\t")
+		if !slices.Contains(names, name) {
+			continue
+		}
+		resources = append(resources, NamedResource{Kind: kind, Name: name, Labels: parseJSONLabels(labels)})
+	}
+	return resources
+}
+
+// Parse labels formatted through `{{json .Labels}}`: an object with Podman,
+// a "key=value,..." string with Docker.
+func parseJSONLabels(value string) map[string]string {
+	var labels map[string]string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(value)), &labels); err == nil {
+		return labels
+	}
+	var joined string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(value)), &joined); err != nil || joined == "" {
+		return nil
+	}
+	labels = map[string]string{}
+	for pair := range strings.SplitSeq(joined, ",") {
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+func findNameCollisions(projectName string, resources []NamedResource) []NameCollision {
+	collisions := []NameCollision{}
+	for _, resource := range resources {
+		var detail string
+		switch {
+		case resource.Kind == "container" && resource.Name == projectContainerName(projectName):
+			detail = foreignContainerDetail(projectName, resource)
+		case resource.Kind == "volume" && resource.Name == ProjectLocalVolumeName(projectName),
+			resource.Kind == "network" && resource.Name == projectNetworkName(projectName):
+			detail = foreignResourceDetail(resource)
+		}
+		if detail != "" {
+			collisions = append(collisions, NameCollision{Kind: resource.Kind, Name: resource.Name, Detail: detail})
+		}
+	}
+	return collisions
+}
+
+// Describe what the given container named like that project's is, empty if
+// it is the project's own.
+func foreignContainerDetail(projectName string, container NamedResource) string {
+	if container.Labels[projectLabel] == projectName {
+		return ""
+	}
+	image := container.ImageName
+	// An image ID is displayed when the tag moved to a newer build while
+	// that container was still around: it is still ours.
+	if image == "" || imageIDRegex.MatchString(image) {
+		return ""
+	}
+	if imageProject := projectNameFromImage(image); imageProject != nil && *imageProject == projectName {
+		return ""
+	}
+	return fmt.Sprintf("runs image '%s'", image)
+}
+
+// Describe what the given volume or network named like that project's is,
+// empty if it is the project's own.
+//
+// Those created by earlier versions of paul-envs have no label at all: they
+// are still considered the project's, like in `isManagedResource`.
+func foreignResourceDetail(resource NamedResource) string {
+	if len(resource.Labels) == 0 || resource.Labels[managedLabel] == "true" {
+		return ""
+	}
+	labels := make([]string, 0, len(resource.Labels))
+	for key, value := range resource.Labels {
+		labels = append(labels, key+"="+value)
+	}
+	slices.Sort(labels)
+	return fmt.Sprintf("was created by another tool (labels: %s)", strings.Join(labels, ", "))
+}
@@ -0,0 +1,134 @@
+package engine
+
+import (
+	"context"
+	"maps"
+	"testing"
+)
+
+func TestFindNameCollisions(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource NamedResource
+		want     int
+	}{
+		{
+			name:     "own container",
+			resource: NamedResource{Kind: "container", Name: "paulenv-app", ImageName: "paulenv:app"},
+			want:     0,
+		},
+		{
+			name:     "own podman container",
+			resource: NamedResource{Kind: "container", Name: "paulenv-app", ImageName: "localhost/paulenv:app"},
+			want:     0,
+		},
+		{
+			name:     "own container after a rebuild",
+			resource: NamedResource{Kind: "container", Name: "paulenv-app", ImageName: "3f4e2a1b9c8d"},
+			want:     0,
+		},
+		{
+			name: "own labelled container running a snapshot",
+			resource: NamedResource{Kind: "container", Name: "paulenv-app", ImageName: "paulenv-snapshot:app-1",
+				Labels: map[string]string{projectLabel: "app"}},
+			want: 0,
+		},
+		{
+			name:     "foreign container",
+			resource: NamedResource{Kind: "container", Name: "paulenv-app", ImageName: "postgres:16"},
+			want:     1,
+		},
+		{
+			name: "own volume",
+			resource: NamedResource{Kind: "volume", Name: "paulenv-app-local",
+				Labels: map[string]string{managedLabel: "true", projectLabel: "app"}},
+			want: 0,
+		},
+		{
+			name:     "volume of an earlier version",
+			resource: NamedResource{Kind: "volume", Name: "paulenv-app-local"},
+			want:     0,
+		},
+		{
+			name: "foreign volume",
+			resource: NamedResource{Kind: "volume", Name: "paulenv-app-local",
+				Labels: map[string]string{"com.docker.compose.project": "app"}},
+			want: 1,
+		},
+		{
+			name: "foreign volume of another project's name",
+			resource: NamedResource{Kind: "volume", Name: "paulenv-other-local",
+				Labels: map[string]string{"com.docker.compose.project": "other"}},
+			want: 0,
+		},
+		{
+			name: "own network",
+			resource: NamedResource{Kind: "network", Name: "paulenv-app",
+				Labels: map[string]string{managedLabel: "true", projectLabel: "app"}},
+			want: 0,
+		},
+		{
+			name: "foreign network",
+			resource: NamedResource{Kind: "network", Name: "paulenv-app",
+				Labels: map[string]string{"com.docker.compose.network": "default"}},
+			want: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findNameCollisions("app", []NamedResource{tt.resource})
+			if len(got) != tt.want {
+				t.Fatalf("findNameCollisions() = %v, want %d collision(s)", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindNameCollisions_FakeEngine(t *testing.T) {
+	fake := &FakeEngine{NamedResources: []NamedResource{
+		{Kind: "container", Name: "paulenv-app", ImageName: "postgres:16"},
+		{Kind: "container", Name: "paulenv-other", ImageName: "postgres:16"},
+		{Kind: "volume", Name: "paulenv-app-local", Labels: map[string]string{"owner": "someone"}},
+	}}
+	collisions, err := FindNameCollisions(context.Background(), fake, "app")
+	if err != nil || len(collisions) != 2 || collisions[0].Name != "paulenv-app" || collisions[1].Name != "paulenv-app-local" {
+		t.Fatalf("FindNameCollisions() = %v, %v, want the foreign container and volume named like the project's", collisions, err)
+	}
+}
+
+func TestParseNamedContainerList(t *testing.T) {
+	output := "abc\tpostgres:16\tpaulenv-app\t\"com.example=1,paulenv.project=app\"\n" +
+		"def\tpaulenv:app\tpaulenv-app-2\t{\"paulenv.project\":\"app\"}\n" +
+		"ghi\tredis:7\tpaulenv-app\tnull\n"
+	got := parseNamedContainerList(output, []string{"paulenv-app", "paulenv-app-2"})
+	if len(got) != 3 {
+		t.Fatalf("parseNamedContainerList() = %+v, want 3 containers", got)
+	}
+	if want := map[string]string{"com.example": "1", projectLabel: "app"}; !maps.Equal(got[0].Labels, want) {
+		t.Fatalf("Docker labels = %v, want %v", got[0].Labels, want)
+	}
+	if want := map[string]string{projectLabel: "app"}; !maps.Equal(got[1].Labels, want) {
+		t.Fatalf("Podman labels = %v, want %v", got[1].Labels, want)
+	}
+	if len(got[2].Labels) != 0 || got[2].ImageName != "redis:7" {
+		t.Fatalf("unlabelled container = %+v, want no label", got[2])
+	}
+	if got := parseNamedContainerList(output, []string{"paulenv-app-2"}); len(got) != 1 {
+		t.Fatalf("parseNamedContainerList() = %+v, want only the containers with the names asked", got)
+	}
+}
+
+func TestParseNamedResourceList(t *testing.T) {
+	output := "paulenv-app-local\t\"com.docker.compose.project=app\"\n" +
+		"paulenv-app-local-backup\t\"\"\n" +
+		"paulenv-app\t{}\n"
+	got := parseNamedResourceList("volume", output, []string{"paulenv-app-local"})
+	if len(got) != 1 || got[0].Kind != "volume" || got[0].Labels["com.docker.compose.project"] != "app" {
+		t.Fatalf("parseNamedResourceList() = %+v, want only the volume with that exact name", got)
+	}
+	got = parseNamedResourceList("network", output, []string{"paulenv-app"})
+	if len(got) != 1 || len(got[0].Labels) != 0 {
+		t.Fatalf("parseNamedResourceList() = %+v, want the unlabelled network", got)
+	}
+}
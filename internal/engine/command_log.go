@@ -0,0 +1,162 @@
+// # command_log.go
+// Engine calls are run through `runEngineCommand` and `engineCommandOutput`
+// instead of `cmd.Run` and `cmd.Output`, so each one is logged with its
+// duration, result and, unless it is attached to the terminal, the end of its
+// output. They are also where calls are skipped in dry-run mode.
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/logging"
+)
+
+// Size of the end of each output of an engine call kept for the log.
+const loggedOutputSize = 16 << 10
+
+// Run an engine command, like `cmd.Run()`.
+func runEngineCommand(cmd *exec.Cmd) error {
+	if skipInDryRun(cmd) {
+		return nil
+	}
+	stdout := captureOutput(&cmd.Stdout)
+	stderr := captureOutput(&cmd.Stderr)
+	start := time.Now()
+	logging.Log().Debug("engine call", "command", commandLine(cmd))
+	err := cmd.Run()
+	logEngineResult(cmd, start, err, stdout.String(), stderr.String())
+	err = classifyCommandError(err, stderr.String())
+	forgetEngineOnFailure(err)
+	return err
+}
+
+// Run an engine command and return its standard output, like `cmd.Output()`.
+func engineCommandOutput(cmd *exec.Cmd) ([]byte, error) {
+	if skipInDryRun(cmd) {
+		return nil, nil
+	}
+	// Left to `cmd.Output()` which puts it in the returned error
+	captureStderr := cmd.Stderr != nil
+	var stderr *tailBuffer
+	if captureStderr {
+		stderr = captureOutput(&cmd.Stderr)
+	}
+	start := time.Now()
+	logging.Log().Debug("engine call", "command", commandLine(cmd))
+	output, err := cmd.Output()
+	stderrText := ""
+	var exitErr *exec.ExitError
+	if captureStderr {
+		stderrText = stderr.String()
+	} else if errors.As(err, &exitErr) {
+		stderrText = string(exitErr.Stderr)
+	}
+	logEngineResult(cmd, start, err, lastBytes(output, loggedOutputSize), stderrText)
+	err = classifyCommandError(err, stderrText)
+	forgetEngineOnFailure(err)
+	return output, err
+}
+
+// An engine call made by this process.
+type ExecutedCall struct {
+	// Its command line
+	Command  string
+	Duration time.Duration
+	// Its exit code, `-1` if it could not be run or was killed
+	ExitCode int
+}
+
+var (
+	executedCallsMu sync.Mutex
+	executedCalls   []ExecutedCall
+)
+
+// Engine calls made by this process until now, in order.
+func ExecutedCalls() []ExecutedCall {
+	executedCallsMu.Lock()
+	defer executedCallsMu.Unlock()
+	return slices.Clone(executedCalls)
+}
+
+func logEngineResult(cmd *exec.Cmd, start time.Time, err error, stdout string, stderr string) {
+	duration := time.Since(start)
+	call := ExecutedCall{Command: commandLine(cmd), Duration: duration}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		call.ExitCode = exitErr.ExitCode()
+	} else if err != nil {
+		call.ExitCode = -1
+	}
+	executedCallsMu.Lock()
+	executedCalls = append(executedCalls, call)
+	executedCallsMu.Unlock()
+
+	log := logging.Log()
+	attrs := []any{"command", commandLine(cmd), "duration", duration.Round(time.Millisecond)}
+	if err != nil {
+		log.Debug("engine call failed", append(attrs, "error", err)...)
+	} else {
+		log.Debug("engine call done", attrs...)
+	}
+	if stdout != "" || stderr != "" {
+		log.Log(context.Background(), logging.LevelTrace, "engine call output", "command", commandLine(cmd), "stdout", stdout, "stderr", stderr)
+	}
+}
+
+func commandLine(cmd *exec.Cmd) string {
+	return strings.Join(cmd.Args, " ")
+}
+
+// Also write what is written to the given output of a command to the
+// returned buffer, unless it is a file (e.g. the terminal) which the command
+// has to inherit as is.
+func captureOutput(output *io.Writer) *tailBuffer {
+	buffer := &tailBuffer{limit: loggedOutputSize}
+	switch (*output).(type) {
+	case nil:
+		*output = buffer
+	case *os.File:
+	default:
+		*output = io.MultiWriter(*output, buffer)
+	}
+	return buffer
+}
+
+// Writer keeping the last `limit` bytes written to it.
+type tailBuffer struct {
+	mu    sync.Mutex
+	limit int
+	data  []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	if len(b.data) > b.limit {
+		b.data = append(b.data[:0], b.data[len(b.data)-b.limit:]...)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.data)
+}
+
+func lastBytes(data []byte, limit int) string {
+	if len(data) > limit {
+		data = data[len(data)-limit:]
+	}
+	return string(data)
+}
@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCaptureOutput(t *testing.T) {
+	var unset io.Writer
+	buffer := captureOutput(&unset)
+	if unset != io.Writer(buffer) {
+		t.Fatalf("an unset output should be replaced by the capture buffer")
+	}
+
+	var original bytes.Buffer
+	var output io.Writer = &original
+	buffer = captureOutput(&output)
+	io.WriteString(output, "hello")
+	if original.String() != "hello" || buffer.String() != "hello" {
+		t.Fatalf("output should be written to both, got %q and %q", original.String(), buffer.String())
+	}
+
+	// Commands have to inherit the terminal as is
+	var terminal io.Writer = os.Stdout
+	captureOutput(&terminal)
+	if terminal != io.Writer(os.Stdout) {
+		t.Fatalf("a file output should be left untouched")
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	buffer := &tailBuffer{limit: 8}
+	io.WriteString(buffer, "0123456")
+	io.WriteString(buffer, "789abc")
+	if got := buffer.String(); got != "56789abc" {
+		t.Fatalf("tailBuffer should keep the last bytes, got %q", got)
+	}
+	if got := lastBytes([]byte(strings.Repeat("x", 10)+"end"), 3); got != "end" {
+		t.Fatalf("lastBytes() = %q", got)
+	}
+}
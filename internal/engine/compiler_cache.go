@@ -0,0 +1,50 @@
+package engine
+
+import "github.com/peaberberian/paul-envs/internal/config"
+
+// Volume holding the ccache and sccache caches of all projects enabling
+// `ENABLE_COMPILER_CACHE`, as the same dependencies are often compiled by
+// several of them.
+const CompilerCacheVolumeName = "paulenv-compiler-cache"
+
+// Returns `true` if the project's image caches C/C++ and Rust compilations.
+func hasCompilerCache(buildCfg config.BuildConfig) bool {
+	return buildCfg.Args["ENABLE_COMPILER_CACHE"] == "true"
+}
+
+// Where the compiler cache volume is mounted in the container.
+func compilerCacheTarget(username string) string {
+	return "/home/" + username + "/.compiler-cache"
+}
+
+// Environment pointing ccache and sccache to the compiler cache volume, and
+// making cargo go through sccache. ccache itself is reached through the
+// compiler links the image puts first in `PATH`.
+func compilerCacheEnv(username string) []string {
+	target := compilerCacheTarget(username)
+	return []string{
+		"CCACHE_DIR=" + target + "/ccache",
+		"SCCACHE_DIR=" + target + "/sccache",
+		"RUSTC_WRAPPER=sccache",
+	}
+}
+
+// Arguments for the `run` command mounting the compiler cache, if the
+// project's image relies on it.
+func compilerCacheRunArgs(username string, buildCfg config.BuildConfig) []string {
+	if !hasCompilerCache(buildCfg) {
+		return nil
+	}
+	args := []string{"--volume", CompilerCacheVolumeName + ":" + compilerCacheTarget(username)}
+	for _, env := range compilerCacheEnv(username) {
+		args = append(args, "--env", env)
+	}
+	return args
+}
+
+// Returns `true` if that volume is a cache shared by all projects rather
+// than belonging to one of them.
+func IsSharedCacheVolume(volumeName string) bool {
+	return volumeName == "paulenv-shared-cache" || volumeName == CompilerCacheVolumeName ||
+		IsPackageCacheVolume(volumeName)
+}
@@ -0,0 +1,489 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Returns a self-contained bundle running the given project through compose,
+// without paul-envs: a `compose.yaml` file, the Dockerfile and entrypoint it
+// builds from, a `.env` file with host-specific values and the dotfiles.
+func ComposeBundle(project files.ProjectEntry) (files.Bundle, error) {
+	runtimeCfg, err := loadRuntimeConfig(project)
+	if err != nil {
+		return files.Bundle{}, err
+	}
+	return composeBundle(project, runtimeCfg)
+}
+
+// Same as `ComposeBundle`, but shareable with others: without the git author
+// identity nor service credentials, the user's global compose override, and
+// with an empty `.env` file.
+func ShareableComposeBundle(project files.ProjectEntry) (files.Bundle, error) {
+	runtimeCfg, err := loadRuntimeConfig(project)
+	if err != nil {
+		return files.Bundle{}, err
+	}
+	project.GlobalComposeOverridePath = ""
+	runtimeCfg.GitName, runtimeCfg.GitEmail = "", ""
+	services := make([]config.Service, len(runtimeCfg.Services))
+	for i, service := range runtimeCfg.Services {
+		services[i] = service
+		services[i].Env = make([]string, len(service.Env))
+		for j, env := range service.Env {
+			if key, _, _ := strings.Cut(env, "="); config.IsCredentialVariable(key) {
+				env = key + "="
+			}
+			services[i].Env[j] = env
+		}
+	}
+	runtimeCfg.Services = services
+	bundle, err := composeBundle(project, runtimeCfg)
+	if err != nil {
+		return files.Bundle{}, err
+	}
+	bundle.Files[".env"] = []byte(composeEnvFile(config.RuntimeConfig{}))
+	return bundle, nil
+}
+
+func composeBundle(project files.ProjectEntry, runtimeCfg config.RuntimeConfig) (files.Bundle, error) {
+	buildCfg, err := loadBuildConfig(project)
+	if err != nil {
+		return files.Bundle{}, err
+	}
+	dockerfile, err := files.StandaloneDockerfile()
+	if err != nil {
+		return files.Bundle{}, err
+	}
+	entrypoint, err := files.EntrypointScript()
+	if err != nil {
+		return files.Bundle{}, err
+	}
+
+	bundle := files.Bundle{
+		Files: map[string][]byte{
+			".env":          []byte(composeEnvFile(runtimeCfg)),
+			"entrypoint.sh": entrypoint,
+		},
+		Dirs: map[string]string{},
+	}
+	// The project's own Dockerfile is built from where it is, the bundle
+	// being its `paulenv` build context
+	customDockerfile := ""
+	if buildCfg.Dockerfile != "" {
+		if customDockerfile, err = resolveProjectDockerfile(project, buildCfg.Dockerfile); err != nil {
+			return files.Bundle{}, err
+		}
+	} else {
+		bundle.Files["Dockerfile"] = dockerfile
+	}
+	for _, override := range []struct{ name, path string }{
+		{globalComposeOverrideName, project.GlobalComposeOverridePath},
+		{projectComposeOverrideName, project.ComposeOverridePath},
+	} {
+		if override.path == "" {
+			continue
+		}
+		content, err := os.ReadFile(override.path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return files.Bundle{}, fmt.Errorf("cannot read compose override file: %w", err)
+		}
+		bundle.Files[override.name] = content
+	}
+	bundle.Files["compose.yaml"] = []byte(composeFile(project, buildCfg, runtimeCfg, customDockerfile, ComposeCommand(bundle)))
+	dotfilesPath, err := projectDotfilesPath(project, runtimeCfg)
+	if err != nil {
+		return files.Bundle{}, err
+	}
+	if dotfilesPath != "" {
+		bundle.Dirs["dotfiles"] = dotfilesPath
+	}
+	return bundle, nil
+}
+
+// Names in a compose bundle of the compose override files, layered in that
+// order on top of `compose.yaml`.
+const (
+	globalComposeOverrideName  = "paul-envs.override.yaml"
+	projectComposeOverrideName = "compose.override.yaml"
+)
+
+// Returns the compose command to run from the directory of `bundle`, giving
+// it the override files it contains on top of `compose.yaml`.
+func ComposeCommand(bundle files.Bundle) string {
+	return ComposeCommandWith(bundle, "docker compose")
+}
+
+// Same as `ComposeCommand`, with the given compose implementation.
+func ComposeCommandWith(bundle files.Bundle, provider string) string {
+	command := provider
+	if _, ok := bundle.Files[globalComposeOverrideName]; !ok {
+		if _, ok := bundle.Files[projectComposeOverrideName]; !ok {
+			return command
+		}
+	}
+	command += " -f compose.yaml"
+	for _, name := range []string{globalComposeOverrideName, projectComposeOverrideName} {
+		if _, ok := bundle.Files[name]; ok {
+			command += " -f " + name
+		}
+	}
+	return command
+}
+
+// Variables of the `.env` file, which compose reads to interpolate the
+// compose file.
+func composeEnvFile(runtimeCfg config.RuntimeConfig) string {
+	var b strings.Builder
+	b.WriteString("# Host-specific values used by compose.yaml, adapt them to your machine.\n")
+	fmt.Fprintf(&b, "PROJECT_PATH=%s\n", runtimeCfg.ProjectPath)
+	fmt.Fprintf(&b, "GIT_AUTHOR_NAME=%s\n", runtimeCfg.GitName)
+	fmt.Fprintf(&b, "GIT_AUTHOR_EMAIL=%s\n", runtimeCfg.GitEmail)
+	return b.String()
+}
+
+// Returns the content of the generated `compose.yaml` file, building the
+// project's own Dockerfile if `customDockerfile` is set, whose comments tell
+// how to start it with `composeCommand`.
+func composeFile(project files.ProjectEntry, buildCfg config.BuildConfig, runtimeCfg config.RuntimeConfig, customDockerfile string, composeCommand string) string {
+	username := buildCfg.Args["USERNAME"]
+	projectMount := projectMountTarget(username, project.ProjectName)
+	workDir := runtimeCfg.WorkDir
+	if workDir == "" {
+		workDir = projectMount
+	}
+	localVolume := ProjectLocalVolumeName(project.ProjectName)
+	mainService := runtimeCfg.MainServiceName(project.ProjectName)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by paul-envs for the '%s' project.\n", project.ProjectName)
+	if hasSystemd(buildCfg) {
+		fmt.Fprintf(&b, "# Start it with: %s up -d %s\n", composeCommand, mainService)
+		fmt.Fprintf(&b, "# Then a shell in it with: %s exec %s /usr/local/bin/entrypoint.sh\n", composeCommand, mainService)
+	} else {
+		fmt.Fprintf(&b, "# Start a shell in it with: %s run --rm %s\n", composeCommand, mainService)
+	}
+	if profiles := runtimeCfg.ServiceProfiles(); len(profiles) > 0 {
+		fmt.Fprintf(&b, "# Add e.g. '--profile %s' to also start the services of that profile\n", profiles[0])
+	}
+	fmt.Fprintf(&b, "name: %s\n", yamlQuote(projectContainerName(project.ProjectName)))
+	b.WriteString("services:\n")
+	fmt.Fprintf(&b, "  %s:\n", yamlQuote(mainService))
+	b.WriteString("    build:\n")
+	buildArgs := maps.Clone(buildCfg.Args)
+	if buildArgs == nil {
+		buildArgs = map[string]string{}
+	}
+	if customDockerfile == "" {
+		b.WriteString("      context: .\n")
+		b.WriteString("      dockerfile: Dockerfile\n")
+	} else {
+		fmt.Fprintf(&b, "      context: %s\n", yamlQuote(filepath.ToSlash(filepath.Dir(customDockerfile))))
+		fmt.Fprintf(&b, "      dockerfile: %s\n", yamlQuote(filepath.Base(customDockerfile)))
+		b.WriteString("      additional_contexts:\n")
+		fmt.Fprintf(&b, "        %s: .\n", paulenvBuildContext)
+		buildArgs["BASE_IMAGE"] = baseImageName
+	}
+	maps.Copy(buildArgs, buildCfg.BuildArgs)
+	if len(buildArgs) > 0 {
+		b.WriteString("      args:\n")
+		keys := make([]string, 0, len(buildArgs))
+		for key := range buildArgs {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(&b, "        %s: %s\n", key, yamlQuote(buildArgs[key]))
+		}
+	}
+	if len(buildCfg.Labels) > 0 {
+		b.WriteString("      labels:\n")
+		for _, name := range slices.Sorted(maps.Keys(buildCfg.Labels)) {
+			fmt.Fprintf(&b, "        %s: %s\n", yamlQuote(name), yamlQuote(buildCfg.Labels[name]))
+		}
+	}
+	fmt.Fprintf(&b, "    image: %s\n", yamlQuote(projectImageName(project.ProjectName)))
+	fmt.Fprintf(&b, "    container_name: %s\n", yamlQuote(projectContainerName(project.ProjectName)))
+	if hasSystemd(buildCfg) {
+		b.WriteString("    stop_signal: SIGRTMIN+3\n")
+		b.WriteString("    cgroup: host\n")
+	} else {
+		b.WriteString("    init: true\n")
+	}
+	b.WriteString("    stdin_open: true\n")
+	b.WriteString("    tty: true\n")
+	fmt.Fprintf(&b, "    working_dir: %s\n", yamlQuote(workDir))
+	switch userns := runtimeCfg.Userns; {
+	case userns.Mode == config.UsernsKeepID && userns.UID != nil:
+		fmt.Fprintf(&b, "    userns_mode: %s\n", yamlQuote(fmt.Sprintf("keep-id:uid=%d,gid=%d", *userns.UID, *userns.GID)))
+	case userns.Mode != "":
+		fmt.Fprintf(&b, "    userns_mode: %s\n", yamlQuote(userns.Mode))
+	}
+	b.WriteString("    environment:\n")
+	b.WriteString("      GIT_AUTHOR_NAME: ${GIT_AUTHOR_NAME:-}\n")
+	b.WriteString("      GIT_AUTHOR_EMAIL: ${GIT_AUTHOR_EMAIL:-}\n")
+	fmt.Fprintf(&b, "      PAULENV_PROJECT: %s\n", yamlQuote(project.ProjectName))
+	if len(runtimeCfg.PackageCaches) > 0 {
+		fmt.Fprintf(&b, "      PAULENV_PACKAGE_CACHES: %s\n", yamlQuote(packageCachesEnvValue(username, runtimeCfg.PackageCaches)))
+	}
+	if targets := sharedVolumesEnvValue(runtimeCfg.SharedVolumes); targets != "" {
+		fmt.Fprintf(&b, "      PAULENV_SHARED_VOLUMES: %s\n", yamlQuote(targets))
+	}
+	if len(runtimeCfg.Tmpfs) > 0 {
+		fmt.Fprintf(&b, "      PAULENV_TMPFS: %s\n", yamlQuote(tmpfsEnvValue(runtimeCfg.Tmpfs)))
+	}
+	if hasSystemd(buildCfg) {
+		fmt.Fprintf(&b, "      PAULENV_SYSTEMD: %s\n", yamlQuote("1"))
+	}
+	if hasCompilerCache(buildCfg) {
+		for _, env := range compilerCacheEnv(username) {
+			name, value, _ := strings.Cut(env, "=")
+			fmt.Fprintf(&b, "      %s: %s\n", name, yamlQuote(value))
+		}
+	}
+	// The host's ones are taken from the environment compose runs in
+	switch runtimeCfg.Timezone {
+	case "":
+	case config.HostSetting:
+		b.WriteString("      TZ: ${TZ:-}\n")
+	default:
+		fmt.Fprintf(&b, "      TZ: %s\n", yamlQuote(runtimeCfg.Timezone))
+	}
+	switch runtimeCfg.Locale {
+	case "":
+	case config.HostSetting:
+		b.WriteString("      LANG: ${LANG:-}\n")
+	default:
+		fmt.Fprintf(&b, "      LANG: %s\n", yamlQuote(runtimeCfg.Locale))
+	}
+
+	b.WriteString("    volumes:\n")
+	fmt.Fprintf(&b, "      - %s\n", yamlQuote("${PROJECT_PATH}:"+projectMount))
+	fmt.Fprintf(&b, "      - %s\n", yamlQuote("paulenv-shared-cache:/home/"+username+"/.container-cache"))
+	fmt.Fprintf(&b, "      - %s\n", yamlQuote(localVolume+":/home/"+username+"/.container-local"))
+	for _, cache := range runtimeCfg.PackageCaches {
+		fmt.Fprintf(&b, "      - %s\n", yamlQuote(PackageCacheVolumeName(cache)+":"+packageCacheTarget(username, cache)))
+	}
+	for _, volume := range runtimeCfg.SharedVolumes {
+		spec := SharedVolumeName(volume.Name) + ":" + volume.Target
+		if volume.ReadOnly {
+			spec += ":ro"
+		}
+		fmt.Fprintf(&b, "      - %s\n", yamlQuote(spec))
+	}
+	if hasCompilerCache(buildCfg) {
+		fmt.Fprintf(&b, "      - %s\n", yamlQuote(CompilerCacheVolumeName+":"+compilerCacheTarget(username)))
+	}
+	if hasSystemd(buildCfg) {
+		fmt.Fprintf(&b, "      - %s\n", yamlQuote("/sys/fs/cgroup:/sys/fs/cgroup:rw"))
+	}
+	if runtimeCfg.DotfilesPath != "" || runtimeCfg.DotfilesProfile != "" {
+		fmt.Fprintf(&b, "      - %s\n", yamlQuote("./dotfiles:/paul-env/dotfiles:ro"))
+	}
+	for _, volume := range runtimeCfg.Volumes {
+		fmt.Fprintf(&b, "      - %s\n", yamlQuote(volume))
+	}
+	for _, mount := range runtimeCfg.Mounts {
+		spec, err := mountVolumeSpec(project, runtimeCfg, mount)
+		if err != nil {
+			// Left for the user to adapt
+			spec = volumeSpec(mount.Source, mount)
+		}
+		fmt.Fprintf(&b, "      - %s\n", yamlQuote(spec))
+	}
+	if runtimeCfg.Hardened {
+		fmt.Fprintf(&b, "      - %s\n", yamlQuote("/home/"+username))
+	}
+	if len(runtimeCfg.Ports) > 0 {
+		b.WriteString("    ports:\n")
+		for _, port := range runtimeCfg.Ports {
+			fmt.Fprintf(&b, "      - %s\n", yamlQuote(port))
+		}
+	}
+	extraHosts := make([]string, 0, len(runtimeCfg.ExtraHosts))
+	for _, host := range runtimeCfg.ExtraHosts {
+		extraHosts = append(extraHosts, host.Name+":"+host.IP)
+	}
+	writeComposeList(&b, "extra_hosts", extraHosts)
+	writeComposeList(&b, "dns", runtimeCfg.DNSServers)
+	writeComposeList(&b, "dns_search", runtimeCfg.DNSSearch)
+	if runtimeCfg.Cpus != "" {
+		fmt.Fprintf(&b, "    cpus: %s\n", runtimeCfg.Cpus)
+	}
+	if runtimeCfg.Memory != "" {
+		fmt.Fprintf(&b, "    mem_limit: %s\n", yamlQuote(runtimeCfg.Memory))
+	}
+	if runtimeCfg.PidsLimit != "" {
+		fmt.Fprintf(&b, "    pids_limit: %s\n", runtimeCfg.PidsLimit)
+	}
+	writeComposeSecurity(&b, project, buildCfg, runtimeCfg, username)
+	writeComposeDependencies(&b, runtimeCfg.Services)
+
+	// Compose services reach each other through their names, like sidecars
+	namedVolumes := []string{"paulenv-shared-cache", localVolume}
+	for _, cache := range runtimeCfg.PackageCaches {
+		namedVolumes = append(namedVolumes, PackageCacheVolumeName(cache))
+	}
+	for _, volume := range runtimeCfg.SharedVolumes {
+		namedVolumes = append(namedVolumes, SharedVolumeName(volume.Name))
+	}
+	if hasCompilerCache(buildCfg) {
+		namedVolumes = append(namedVolumes, CompilerCacheVolumeName)
+	}
+	for _, service := range runtimeCfg.Services {
+		fmt.Fprintf(&b, "  %s:\n", yamlQuote(service.Name))
+		fmt.Fprintf(&b, "    image: %s\n", yamlQuote(service.Image))
+		writeComposeList(&b, "profiles", service.Profiles)
+		if len(service.Env) > 0 {
+			b.WriteString("    environment:\n")
+			for _, env := range service.Env {
+				fmt.Fprintf(&b, "      - %s\n", yamlQuote(env))
+			}
+		}
+		if service.Healthcheck != "" {
+			b.WriteString("    healthcheck:\n")
+			fmt.Fprintf(&b, "      test: [\"CMD-SHELL\", %s]\n", yamlQuote(service.Healthcheck))
+			fmt.Fprintf(&b, "      interval: %s\n", yamlQuote(composeHealthcheckInterval))
+		}
+		if service.DataPath != "" {
+			dataVolume := sidecarDataVolumeName(project.ProjectName, service.Name)
+			namedVolumes = append(namedVolumes, dataVolume)
+			b.WriteString("    volumes:\n")
+			fmt.Fprintf(&b, "      - %s\n", yamlQuote(dataVolume+":"+service.DataPath))
+		}
+	}
+
+	b.WriteString("volumes:\n")
+	for _, volume := range namedVolumes {
+		fmt.Fprintf(&b, "  %s:\n", yamlQuote(volume))
+		fmt.Fprintf(&b, "    name: %s\n", yamlQuote(volume))
+	}
+	if runtimeCfg.SharedNetwork {
+		b.WriteString("# Shared with other projects, create it with: docker network create " + sharedNetworkName + "\n")
+		b.WriteString("networks:\n")
+		b.WriteString("  default:\n")
+		fmt.Fprintf(&b, "    name: %s\n", yamlQuote(sharedNetworkName))
+		b.WriteString("    external: true\n")
+	}
+	return b.String()
+}
+
+// Write the security settings of the project's run.conf, as applied by
+// `securityRunArgs` with Docker.
+func writeComposeSecurity(b *strings.Builder, project files.ProjectEntry, buildCfg config.BuildConfig, runtimeCfg config.RuntimeConfig, username string) {
+	var tmpfs, securityOpts []string
+	if hasSystemd(buildCfg) {
+		tmpfs = append(tmpfs, "/run", "/run/lock")
+	}
+	if hasNestedContainers(buildCfg) && !runtimeCfg.Hardened {
+		b.WriteString("    devices:\n")
+		b.WriteString("      - /dev/fuse\n")
+		securityOpts = append(securityOpts, nestedContainersSecurityOpts(runtimeCfg, false)...)
+	}
+	if runtimeCfg.Hardened {
+		b.WriteString("    read_only: true\n")
+		for _, dir := range hardenedTmpfsDirs {
+			if !hasTmpfs(runtimeCfg, dir) {
+				tmpfs = append(tmpfs, dir)
+			}
+		}
+		securityOpts = append(securityOpts, "no-new-privileges:true")
+		b.WriteString("    cap_drop:\n")
+		b.WriteString("      - ALL\n")
+		b.WriteString("    cap_add:\n")
+		for _, capability := range append(hardenedCapabilities, runtimeCfg.HardenedCapabilities...) {
+			fmt.Fprintf(b, "      - %s\n", capability)
+		}
+	}
+	if runtimeCfg.SeccompProfile != "" {
+		profile, err := seccompProfilePath(project, runtimeCfg)
+		if err != nil {
+			// Left for the user to adapt
+			profile = runtimeCfg.SeccompProfile
+		}
+		securityOpts = append(securityOpts, "seccomp="+profile)
+	}
+	for _, mount := range runtimeCfg.Tmpfs {
+		tmpfs = append(tmpfs, tmpfsSpec(mount))
+	}
+	for _, maskedPath := range maskedPaths(project, runtimeCfg, username) {
+		tmpfs = append(tmpfs, maskedPath+":ro")
+	}
+	if len(securityOpts) > 0 {
+		b.WriteString("    security_opt:\n")
+		for _, opt := range securityOpts {
+			fmt.Fprintf(b, "      - %s\n", yamlQuote(opt))
+		}
+	}
+	if len(tmpfs) > 0 {
+		b.WriteString("    tmpfs:\n")
+		for _, dir := range tmpfs {
+			fmt.Fprintf(b, "      - %s\n", yamlQuote(dir))
+		}
+	}
+}
+
+// Write the given service setting as a list of quoted values, if not empty.
+// Interval between the healthchecks of exported services.
+const composeHealthcheckInterval = "2s"
+
+// Write the project's dependencies on its services, waiting for those with a
+// healthcheck to be healthy as `run` does.
+func writeComposeDependencies(b *strings.Builder, services []config.Service) {
+	// Compose refuses dependencies on services of profiles not enabled
+	var dependencies []config.Service
+	hasHealthchecks := false
+	for _, service := range services {
+		if len(service.Profiles) == 0 {
+			dependencies = append(dependencies, service)
+			hasHealthchecks = hasHealthchecks || service.Healthcheck != ""
+		}
+	}
+	if !hasHealthchecks {
+		names := make([]string, len(dependencies))
+		for i, service := range dependencies {
+			names[i] = service.Name
+		}
+		writeComposeList(b, "depends_on", names)
+		return
+	}
+	b.WriteString("    depends_on:\n")
+	for _, service := range dependencies {
+		condition := "service_started"
+		if service.Healthcheck != "" {
+			condition = "service_healthy"
+		}
+		fmt.Fprintf(b, "      %s:\n", yamlQuote(service.Name))
+		fmt.Fprintf(b, "        condition: %s\n", condition)
+	}
+}
+
+func writeComposeList(b *strings.Builder, key string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "    %s:\n", key)
+	for _, value := range values {
+		fmt.Fprintf(b, "      - %s\n", yamlQuote(value))
+	}
+}
+
+// Double-quoted YAML scalar. Escapes produced by `strconv.Quote` are all valid
+// in YAML double-quoted strings.
+func yamlQuote(value string) string {
+	return strconv.Quote(value)
+}
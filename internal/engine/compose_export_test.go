@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestComposeFile(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: "/tmp/demo/run.conf"}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev", "USER_SHELL": "zsh"}}
+	runtimeCfg := config.RuntimeConfig{
+		ProjectPath:  "/code/demo",
+		DotfilesPath: "dotfiles",
+		Ports:        []string{"3000:3000"},
+		Volumes:      []string{"/data:/home/dev/data"},
+		Memory:       "4g",
+		Services: []config.Service{
+			{Name: "db", Image: "postgres:16", Env: []string{"POSTGRES_PASSWORD=dev"}, DataPath: "/var/lib/postgresql/data"},
+		},
+	}
+
+	got := composeFile(project, buildCfg, runtimeCfg, "", "docker compose")
+	for _, fragment := range []string{
+		"name: \"paulenv-demo\"\n",
+		"  \"demo\":\n",
+		"        USER_SHELL: \"zsh\"\n",
+		"    image: \"paulenv:demo\"\n",
+		"      - \"${PROJECT_PATH}:/home/dev/projects/demo\"\n",
+		"      - \"paulenv-demo-local:/home/dev/.container-local\"\n",
+		"      - \"./dotfiles:/paul-env/dotfiles:ro\"\n",
+		"      - \"/data:/home/dev/data\"\n",
+		"    ports:\n      - \"3000:3000\"\n",
+		"    mem_limit: \"4g\"\n",
+		"    name: \"paulenv-shared-cache\"\n",
+		"    depends_on:\n      - \"db\"\n",
+		"  \"db\":\n    image: \"postgres:16\"\n    environment:\n      - \"POSTGRES_PASSWORD=dev\"\n",
+		"      - \"paulenv-demo.db-local:/var/lib/postgresql/data\"\n",
+		"    name: \"paulenv-demo.db-local\"\n",
+	} {
+		if !strings.Contains(got, fragment) {
+			t.Fatalf("composeFile() should contain %q, got:\n%s", fragment, got)
+		}
+	}
+	if strings.Contains(got, "cpus:") {
+		t.Fatalf("composeFile() should not set cpus when not configured, got:\n%s", got)
+	}
+
+	runtimeCfg.ExtraHosts = []config.HostEntry{{Name: "git.corp.example", IP: "10.0.0.12"}}
+	runtimeCfg.DNSServers = []string{"10.0.0.2"}
+	got = composeFile(project, buildCfg, runtimeCfg, "", "docker compose")
+	for _, fragment := range []string{
+		"    extra_hosts:\n      - \"git.corp.example:10.0.0.12\"\n",
+		"    dns:\n      - \"10.0.0.2\"\n",
+	} {
+		if !strings.Contains(got, fragment) {
+			t.Fatalf("composeFile() should contain %q, got:\n%s", fragment, got)
+		}
+	}
+
+	uid, gid := 1000, 1000
+	runtimeCfg.Userns = config.Userns{Mode: config.UsernsKeepID, UID: &uid, GID: &gid}
+	if got = composeFile(project, buildCfg, runtimeCfg, "", "docker compose"); !strings.Contains(got, "    userns_mode: \"keep-id:uid=1000,gid=1000\"\n") {
+		t.Fatalf("composeFile() should set the USERNS mode, got:\n%s", got)
+	}
+
+	runtimeCfg.Services = append(runtimeCfg.Services, config.Service{Name: "search", Image: "opensearch:2", Profiles: []string{"heavy"}})
+	got = composeFile(project, buildCfg, runtimeCfg, "", "docker compose")
+	for _, fragment := range []string{
+		"# Add e.g. '--profile heavy' to also start the services of that profile\n",
+		"    depends_on:\n      - \"db\"\n  \"db\":\n",
+		"  \"search\":\n    image: \"opensearch:2\"\n    profiles:\n      - \"heavy\"\n",
+	} {
+		if !strings.Contains(got, fragment) {
+			t.Fatalf("composeFile() should contain %q, got:\n%s", fragment, got)
+		}
+	}
+	if strings.Contains(got, "      - \"search\"\n") {
+		t.Fatalf("composeFile() should not depend on services of profiles, got:\n%s", got)
+	}
+
+	runtimeCfg.MainService = "app"
+	got = composeFile(project, buildCfg, runtimeCfg, "", "docker compose")
+	if !strings.Contains(got, "  \"app\":\n    build:\n") || !strings.Contains(got, "docker compose run --rm app\n") {
+		t.Fatalf("composeFile() should name the main service after MAIN_SERVICE, got:\n%s", got)
+	}
+
+	runtimeCfg.Services = append(runtimeCfg.Services, config.Service{Name: "cache", Image: "redis:7", Healthcheck: "redis-cli ping"})
+	got = composeFile(project, buildCfg, runtimeCfg, "", "docker compose")
+	for _, fragment := range []string{
+		"    depends_on:\n      \"db\":\n        condition: service_started\n      \"cache\":\n        condition: service_healthy\n",
+		"    healthcheck:\n      test: [\"CMD-SHELL\", \"redis-cli ping\"]\n",
+	} {
+		if !strings.Contains(got, fragment) {
+			t.Fatalf("composeFile() should contain %q, got:\n%s", fragment, got)
+		}
+	}
+}
+
+func TestComposeFile_Hardened(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: "/tmp/demo/run.conf"}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}
+	runtimeCfg := config.RuntimeConfig{ProjectPath: "/code/demo", Hardened: true, MaskedPaths: []string{".env"}}
+
+	got := composeFile(project, buildCfg, runtimeCfg, "", "docker compose")
+	for _, fragment := range []string{
+		"      - \"/home/dev\"\n",
+		"    read_only: true\n",
+		"    cap_drop:\n      - ALL\n",
+		"    security_opt:\n      - \"no-new-privileges:true\"\n",
+		"    tmpfs:\n      - \"/tmp\"\n      - \"/var/tmp\"\n      - \"/home/dev/projects/demo/.env:ro\"\n",
+	} {
+		if !strings.Contains(got, fragment) {
+			t.Fatalf("composeFile() should contain %q, got:\n%s", fragment, got)
+		}
+	}
+
+	runtimeCfg.Tmpfs = []config.TmpfsMount{{Target: "/tmp", Size: "1g"}, {Target: "/run/secrets", Size: "16m", Mode: "0700"}}
+	got = composeFile(project, buildCfg, runtimeCfg, "", "docker compose")
+	for _, fragment := range []string{
+		"      PAULENV_TMPFS: \"/tmp /run/secrets\"\n",
+		"    tmpfs:\n      - \"/var/tmp\"\n      - \"/tmp:size=1g\"\n      - \"/run/secrets:size=16m,mode=0700\"\n",
+	} {
+		if !strings.Contains(got, fragment) {
+			t.Fatalf("composeFile() should contain %q, got:\n%s", fragment, got)
+		}
+	}
+}
+
+func TestComposeEnvFile(t *testing.T) {
+	got := composeEnvFile(config.RuntimeConfig{ProjectPath: "/code/demo", GitName: "Jane"})
+	for _, line := range []string{"PROJECT_PATH=/code/demo\n", "GIT_AUTHOR_NAME=Jane\n", "GIT_AUTHOR_EMAIL=\n"} {
+		if !strings.Contains(got, line) {
+			t.Fatalf("composeEnvFile() should contain %q, got:\n%s", line, got)
+		}
+	}
+}
+
+func TestComposeCommand(t *testing.T) {
+	bundle := files.Bundle{Files: map[string][]byte{"compose.yaml": nil}}
+	if got := ComposeCommand(bundle); got != "docker compose" {
+		t.Fatalf("ComposeCommand() = %q without overrides", got)
+	}
+	bundle.Files[projectComposeOverrideName] = nil
+	if got := ComposeCommand(bundle); got != "docker compose -f compose.yaml -f compose.override.yaml" {
+		t.Fatalf("ComposeCommand() = %q with the project's override", got)
+	}
+	bundle.Files[globalComposeOverrideName] = nil
+	if got := ComposeCommand(bundle); got != "docker compose -f compose.yaml -f paul-envs.override.yaml -f compose.override.yaml" {
+		t.Fatalf("ComposeCommand() = %q with both overrides", got)
+	}
+}
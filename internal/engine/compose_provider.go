@@ -0,0 +1,72 @@
+// # compose_provider.go
+// paul-envs does not run compose itself, but bundles written by `paul-envs
+// export compose` are run with it. The engine's own `compose` subcommand is
+// preferred, falling back to the standalone `podman-compose` and
+// `docker-compose`, or to `docker compose` for Podman, as `podman compose`
+// only wraps one of those and fails without them.
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// A compose implementation able to run the bundles of `export compose`.
+type ComposeProvider struct {
+	// Command running it, e.g. "podman compose" or "podman-compose"
+	Command string
+	// The `compose` subcommand of the engine, if it is not that one
+	Preferred string
+}
+
+// Returns `true` if it is not the engine's own `compose` subcommand.
+func (p ComposeProvider) IsFallback() bool {
+	return p.Command != p.Preferred
+}
+
+// Returned by `DetectComposeProvider` when no compose implementation is found.
+var ErrComposeUnavailable = errors.New("no compose implementation found")
+
+// Compose commands tried for that engine (as named by `EngineInfo`, or empty
+// for any), in order of preference.
+func composeCandidates(engineName string) []string {
+	switch engineName {
+	case "docker":
+		return []string{"docker compose", "docker-compose"}
+	case "podman", string(SelectionPodmanRootful):
+		return []string{"podman compose", "podman-compose", "docker compose", "docker-compose"}
+	default:
+		return []string{"docker compose", "podman compose", "podman-compose", "docker-compose"}
+	}
+}
+
+// Returns the compose implementation to run bundles with for that engine (as
+// named by `EngineInfo`, or empty for any), `ErrComposeUnavailable` if there
+// is none.
+func DetectComposeProvider(ctx context.Context, engineName string) (ComposeProvider, error) {
+	candidates := composeCandidates(engineName)
+	provider := ComposeProvider{Preferred: candidates[0]}
+	for _, candidate := range candidates {
+		if isComposeAvailable(ctx, candidate) {
+			provider.Command = candidate
+			return provider, nil
+		}
+	}
+	return provider, ErrComposeUnavailable
+}
+
+func isComposeAvailable(ctx context.Context, command string) bool {
+	binary, subcommand, isSubcommand := strings.Cut(command, " ")
+	if !isSubcommand {
+		_, err := exec.LookPath(binary)
+		return err == nil
+	}
+	if _, err := lookEngineExecutable(binary); err != nil {
+		return false
+	}
+	_, _, err := runDiagnosticCommand(ctx, binary, subcommand, "version")
+	return err == nil
+}
@@ -0,0 +1,43 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDetectComposeProvider(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on executable scripts")
+	}
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+	if _, err := DetectComposeProvider(context.Background(), "podman"); !errors.Is(err, ErrComposeUnavailable) {
+		t.Fatalf("DetectComposeProvider() without compose: got %v, want ErrComposeUnavailable", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "podman-compose"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	provider, err := DetectComposeProvider(context.Background(), "podman")
+	if err != nil {
+		t.Fatalf("DetectComposeProvider() failed: %v", err)
+	}
+	if provider.Command != "podman-compose" || provider.Preferred != "podman compose" || !provider.IsFallback() {
+		t.Fatalf("DetectComposeProvider() = %+v, want a fallback to podman-compose", provider)
+	}
+
+	// `podman compose` only wraps the others, and works with them
+	if err := os.WriteFile(filepath.Join(dir, "podman"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if provider, err := DetectComposeProvider(context.Background(), "podman"); err != nil || provider.IsFallback() {
+		t.Fatalf("DetectComposeProvider() = %+v, %v, want podman compose", provider, err)
+	}
+	if _, err := DetectComposeProvider(context.Background(), "docker"); !errors.Is(err, ErrComposeUnavailable) {
+		t.Fatalf("DetectComposeProvider() for docker: got %v, want ErrComposeUnavailable", err)
+	}
+}
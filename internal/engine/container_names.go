@@ -0,0 +1,97 @@
+// # container_names.go
+// Names of project containers and of their networks, following the
+// `CONTAINER_NAME` global setting (`paulenv-{project}` by default), e.g. to
+// avoid collisions with other tools or between users of a shared host.
+//
+// Instances and sidecars of a project are named after its container, followed
+// by `..<instance>` or `.<service>`. Containers and networks are attributed to
+// their project through their labels, so those named under a previous scheme
+// are still listed and removed with it.
+
+package engine
+
+import (
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// What comes before and after the project's name in the name of its
+// container.
+var (
+	containerNamePrefix = "paulenv-"
+	containerNameSuffix = ""
+)
+
+// Set the template names of project containers follow, in which `{project}`
+// is replaced by the project's name, and `{user}` and `{host}` by the names
+// of the current user and host.
+func SetContainerNameTemplate(template string) {
+	prefix, suffix, ok := strings.Cut(template, "{project}")
+	if !ok {
+		return
+	}
+	expand := strings.NewReplacer("{user}", containerNameUser(), "{host}", containerNameHost())
+	containerNamePrefix = expand.Replace(prefix)
+	containerNameSuffix = expand.Replace(suffix)
+}
+
+// Name of the user running paul-envs, the one calling `sudo` if it is.
+func containerNameUser() string {
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
+		return sanitizeContainerNamePart(sudoUser)
+	}
+	if current, err := user.Current(); err == nil {
+		// Windows usernames are prefixed by their domain
+		name := current.Username
+		if i := strings.LastIndex(name, `\`); i >= 0 {
+			name = name[i+1:]
+		}
+		return sanitizeContainerNamePart(name)
+	}
+	return "user"
+}
+
+// Name of the host without its domain.
+func containerNameHost() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "host"
+	}
+	hostname, _, _ = strings.Cut(hostname, ".")
+	return sanitizeContainerNamePart(hostname)
+}
+
+// Lowercase the given name and replace what container names cannot contain.
+func sanitizeContainerNamePart(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		default:
+			return '-'
+		}
+	}, name)
+	if name == "" {
+		return "unknown"
+	}
+	return name
+}
+
+// Returns the project whose container or network has that name under the
+// current naming scheme, `nil` if it has none.
+func projectNameFromSchemeName(name string) *string {
+	projectName, ok := strings.CutPrefix(name, containerNamePrefix)
+	if !ok {
+		return nil
+	}
+	projectName, ok = strings.CutSuffix(projectName, containerNameSuffix)
+	if !ok || utils.ValidateProjectName(projectName) != nil {
+		return nil
+	}
+	return &projectName
+}
@@ -0,0 +1,31 @@
+package engine
+
+import "testing"
+
+func TestSetContainerNameTemplate(t *testing.T) {
+	t.Cleanup(func() { SetContainerNameTemplate("paulenv-{project}") })
+	t.Setenv("SUDO_USER", "Jane.Doe")
+
+	SetContainerNameTemplate("{user}-{project}-dev")
+	if got := projectContainerName("myapp"); got != "jane-doe-myapp-dev" {
+		t.Fatalf("projectContainerName() = %q, want %q", got, "jane-doe-myapp-dev")
+	}
+	if got := instanceContainerName("myapp", "tests"); got != "jane-doe-myapp-dev..tests" {
+		t.Fatalf("instanceContainerName() = %q, want %q", got, "jane-doe-myapp-dev..tests")
+	}
+	if got := sidecarContainerName("myapp", "db"); got != "jane-doe-myapp-dev.db" {
+		t.Fatalf("sidecarContainerName() = %q, want %q", got, "jane-doe-myapp-dev.db")
+	}
+	if got := projectNameFromSchemeName(projectNetworkName("myapp")); got == nil || *got != "myapp" {
+		t.Fatalf("projectNameFromSchemeName() = %v, want %q", got, "myapp")
+	}
+	for _, name := range []string{"paulenv-myapp", "jane-doe-myapp-dev.db", "jane-doe-My.App-dev"} {
+		if got := projectNameFromSchemeName(name); got != nil {
+			t.Fatalf("projectNameFromSchemeName(%q) = %q, want none", name, *got)
+		}
+	}
+	instanceName := "jane-doe-myapp-dev..tests"
+	if !isProjectInstanceContainer(ContainerInfo{ContainerName: &instanceName}, "myapp") {
+		t.Fatal("instances named under the configured scheme should be the project's")
+	}
+}
@@ -0,0 +1,96 @@
+// # custom_dockerfile.go
+// A project can be built from its own Dockerfile, set by the `DOCKERFILE`
+// directive of its build.conf, instead of the generated one. It is built like
+// the generated one: tagged and labeled as a paul-envs image, with the
+// project's build arguments (including `BASE_IMAGE`), and run the same way,
+// with its volumes and dotfiles mounts.
+//
+// Dotfiles are applied and volumes initialized by paul-envs' entrypoint,
+// which such a Dockerfile has to install itself to keep them. The directory
+// of the generated build files is thus given to its build as the `paulenv`
+// build context, e.g. `COPY --from=paulenv entrypoint.sh /usr/local/bin/`.
+
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Name of the build context giving a project's own Dockerfile access to
+// paul-envs' build files.
+const paulenvBuildContext = "paulenv"
+
+// Returns the Dockerfile and build context directory to build the project
+// with.
+func projectBuildFiles(project files.ProjectEntry, options BuildOptions) (dockerfile string, buildContext string) {
+	if options.customDockerfile != "" {
+		return options.customDockerfile, filepath.Dir(options.customDockerfile)
+	}
+	return projectDockerfilePath(project), projectBaseDataDir(project)
+}
+
+// Flags of a build from the project's own Dockerfile, labeling its image like
+// the generated Dockerfile does and giving it paul-envs' build files.
+func customDockerfileArgs(project files.ProjectEntry, options BuildOptions) []string {
+	if options.customDockerfile == "" {
+		return nil
+	}
+	return []string{
+		"--label", "paulenv=true",
+		"--build-context", paulenvBuildContext + "=" + hostPath(projectBaseDataDir(project)),
+	}
+}
+
+// Set the project's own Dockerfile, if its build.conf has one, in `options`.
+func withProjectDockerfile(options BuildOptions, project files.ProjectEntry, buildCfg config.BuildConfig) (BuildOptions, error) {
+	if buildCfg.Dockerfile == "" {
+		return options, nil
+	}
+	dockerfile, err := resolveProjectDockerfile(project, buildCfg.Dockerfile)
+	if err != nil {
+		return options, err
+	}
+	options.customDockerfile = dockerfile
+	return options, nil
+}
+
+// Returns the path of the Dockerfile set by `DOCKERFILE`, which is either
+// that file or a directory containing a `Dockerfile`, relative to the project
+// directory if not absolute.
+func resolveProjectDockerfile(project files.ProjectEntry, configured string) (string, error) {
+	path := configured
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(project.ProjectPath, path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot use the Dockerfile set by DOCKERFILE: %w", err)
+	}
+	if info.IsDir() {
+		path = filepath.Join(path, "Dockerfile")
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("cannot use the Dockerfile set by DOCKERFILE: %w", err)
+		}
+	}
+	return filepath.Clean(path), nil
+}
+
+// Returns the Dockerfile the project's image is built from: its own one if
+// its build.conf sets one, the generated one otherwise.
+func ProjectDockerfile(project files.ProjectEntry) (string, error) {
+	buildCfg, err := loadBuildConfig(project)
+	if err != nil {
+		return "", err
+	}
+	options, err := withProjectDockerfile(BuildOptions{}, project, buildCfg)
+	if err != nil {
+		return "", err
+	}
+	dockerfile, _ := projectBuildFiles(project, options)
+	return dockerfile, nil
+}
@@ -0,0 +1,38 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestResolveProjectDockerfile(t *testing.T) {
+	projectPath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(projectPath, "docker"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Dockerfile.dev", filepath.Join("docker", "Dockerfile")} {
+		if err := os.WriteFile(filepath.Join(projectPath, name), []byte("FROM scratch\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	project := files.ProjectEntry{ProjectName: "demo", ProjectPath: projectPath}
+
+	for configured, want := range map[string]string{
+		"Dockerfile.dev": filepath.Join(projectPath, "Dockerfile.dev"),
+		"docker":         filepath.Join(projectPath, "docker", "Dockerfile"),
+		filepath.Join(projectPath, "docker", "Dockerfile"): filepath.Join(projectPath, "docker", "Dockerfile"),
+	} {
+		got, err := resolveProjectDockerfile(project, configured)
+		if err != nil || got != want {
+			t.Errorf("resolveProjectDockerfile(%q) = %q, %v, want %q", configured, got, err, want)
+		}
+	}
+	for _, configured := range []string{"missing", "."} {
+		if _, err := resolveProjectDockerfile(project, configured); err == nil {
+			t.Errorf("resolveProjectDockerfile(%q) should fail", configured)
+		}
+	}
+}
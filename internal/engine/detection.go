@@ -0,0 +1,152 @@
+// # detection.go
+// Finding which container engine to rely on, and its version (on which its
+// quirks depend), means looking for both engines and running the chosen one
+// on each invocation, which is noticeable with Podman. The engine detected
+// for a selection is thus cached in paul-envs' data directory until it gets
+// stale, its executable changes (e.g. it was upgraded) or one of its commands
+// fails because it is unavailable. The global `--refresh-engine` flag
+// detects it again.
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/logging"
+)
+
+// How long a detected engine is relied on before being detected again.
+const detectionCacheTTL = 24 * time.Hour
+
+var (
+	detectionStore   *files.FileStore
+	refreshDetection bool
+	forgetOnce       sync.Once
+)
+
+// Cache the engines detected in `filestore`, ignoring those already cached if
+// `refresh` is set. Detected engines are not cached if never called.
+func SetDetectionCache(filestore *files.FileStore, refresh bool) {
+	detectionStore = filestore
+	refreshDetection = refresh
+	forgetOnce = sync.Once{}
+}
+
+// Returns the engine cached for `selection`, nil if there is none or it
+// cannot be relied on anymore.
+func cachedEngine(ctx context.Context, selection Selection) ContainerEngine {
+	if detectionStore == nil || refreshDetection {
+		return nil
+	}
+	cache, err := detectionStore.GetEngineDetectionCache()
+	if err != nil || cache == nil {
+		return nil
+	}
+	if cache.Selection != string(selection) || cache.Preferred != string(preferredSelection) ||
+		time.Since(cache.At) > detectionCacheTTL || cache.Version == "" {
+		return nil
+	}
+	if modTime, ok := executableModTime(cache.Engine); !ok || !modTime.Equal(cache.ExecutableModTime) {
+		return nil
+	}
+	switch cache.Engine {
+	case "podman":
+		podman, err := newPodman(ctx, selection == SelectionPodmanRootful)
+		if err != nil {
+			return nil
+		}
+		podman.setVersion(cache.Version)
+		return podman
+	case "docker":
+		docker, err := newDocker(ctx)
+		if err != nil {
+			return nil
+		}
+		docker.setVersion(cache.Version)
+		return docker
+	}
+	return nil
+}
+
+// Cache `engine` as the one detected for `selection`.
+func rememberEngine(ctx context.Context, selection Selection, engine ContainerEngine) {
+	if detectionStore == nil || dryRun {
+		return
+	}
+	var name string
+	switch engine.(type) {
+	case *PodmanEngine:
+		name = "podman"
+	case *DockerEngine:
+		name = "docker"
+	default:
+		return
+	}
+	if preferredSelection != SelectionAuto && name != string(preferredSelection) {
+		// Keep warning that the preferred engine is not available
+		return
+	}
+	modTime, ok := executableModTime(name)
+	if !ok {
+		return
+	}
+	info, err := engine.Info(ctx)
+	if err != nil {
+		return
+	}
+	switch e := engine.(type) {
+	case *PodmanEngine:
+		e.setVersion(info.Version)
+	case *DockerEngine:
+		e.setVersion(info.Version)
+	}
+	err = detectionStore.SetEngineDetectionCache(files.EngineDetectionCache{
+		At:                time.Now(),
+		Selection:         string(selection),
+		Preferred:         string(preferredSelection),
+		Engine:            name,
+		Version:           info.Version,
+		ExecutableModTime: modTime,
+	})
+	if err != nil {
+		logging.Log().Debug("cannot cache the detected engine", "error", err)
+	}
+}
+
+// Forget the cached engine if `err` tells that it cannot be used anymore, so
+// the next invocation detects it again.
+func forgetEngineOnFailure(err error) {
+	if detectionStore == nil || dryRun || err == nil {
+		return
+	}
+	if !errors.Is(err, ErrEngineUnavailable) && !errors.Is(err, exec.ErrNotFound) {
+		return
+	}
+	forgetOnce.Do(func() {
+		if err := detectionStore.ForgetEngineDetectionCache(); err != nil {
+			logging.Log().Debug("cannot forget the detected engine", "error", err)
+		}
+	})
+}
+
+// Returns the modification time of the executable of the engine `name`.
+func executableModTime(name string) (time.Time, bool) {
+	path, err := lookEngineExecutable(name)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if path, err = exec.LookPath(path); err != nil {
+		return time.Time{}, false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return info.ModTime(), true
+}
@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Docker CLI only knowing its version, recording how many times it was run
+const fakeDockerCLI = `#!/bin/sh
+echo "$*" >> "$(dirname "$0")/calls"
+echo "Docker version 27.1.0, build abc"
+`
+
+func TestDetectionCache(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on sh")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(fakeDockerCLI), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	store, err := files.NewFileStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetDetectionCache(store, false)
+	t.Cleanup(func() { SetDetectionCache(nil, false) })
+	calls := func() int {
+		content, _ := os.ReadFile(filepath.Join(dir, "calls"))
+		return strings.Count(string(content), "\n")
+	}
+
+	ctx := context.Background()
+	cons := console.New(ctx, strings.NewReader(""), io.Discard, io.Discard)
+	if _, err := NewSelected(ctx, cons, SelectionDocker); err != nil {
+		t.Fatalf("NewSelected() error = %v", err)
+	}
+	cache, err := store.GetEngineDetectionCache()
+	if err != nil || cache == nil || cache.Engine != "docker" || cache.Version != "27.1.0" || cache.Selection != "docker" {
+		t.Fatalf("GetEngineDetectionCache() = %+v, %v, want the detected docker engine", cache, err)
+	}
+
+	before := calls()
+	engine, err := NewSelected(ctx, cons, SelectionDocker)
+	if err != nil {
+		t.Fatalf("NewSelected() error = %v", err)
+	}
+	engine.(*DockerEngine).getQuirks(ctx)
+	if calls() != before {
+		t.Fatalf("a cached engine should not be run to know its version")
+	}
+	if cachedEngine(ctx, SelectionAuto) != nil {
+		t.Fatalf("an engine should only be cached for the selection it was detected for")
+	}
+
+	SetDetectionCache(store, true)
+	if cachedEngine(ctx, SelectionDocker) != nil {
+		t.Fatalf("refreshing should ignore the cached engine")
+	}
+
+	forgetEngineOnFailure(utils.WithCategory(errors.New("cannot connect"), ErrEngineUnavailable))
+	if cache, _ := store.GetEngineDetectionCache(); cache != nil {
+		t.Fatalf("an unavailable engine should be forgotten, got %+v", cache)
+	}
+}
@@ -0,0 +1,318 @@
+// # diagnostics.go
+// Checks of the container engines' setup on this host, used by the `doctor`
+// command to tell why an engine cannot be used and how to fix it.
+
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type DiagnosticStatus int
+
+const (
+	DiagnosticOk DiagnosticStatus = iota
+	// Not blocking, but some features won't work
+	DiagnosticWarning
+	// Prevents using the engine
+	DiagnosticFailure
+	// Not applicable to this host
+	DiagnosticSkipped
+)
+
+// Result of a single check.
+type Diagnostic struct {
+	// What has been checked, e.g. "docker daemon reachable"
+	Name   string
+	Status DiagnosticStatus
+	// What was found
+	Detail string
+	// How to fix it, empty if there's nothing to fix
+	Fix string
+}
+
+// Time given to each engine command run by diagnostics, so an unresponsive
+// daemon is reported instead of hanging.
+const diagnosticTimeout = 10 * time.Second
+
+// Run all checks of the given engine ("docker" or "podman").
+//
+// Returns `false` alongside its diagnostics if it is not installed, in which
+// case no other check is done.
+func Diagnose(ctx context.Context, engineName string) ([]Diagnostic, bool) {
+	if _, err := lookEngineExecutable(engineName); err != nil {
+		return []Diagnostic{{
+			Name:   engineName + " installed",
+			Status: DiagnosticSkipped,
+			Detail: fmt.Sprintf("'%s' command not found", engineName),
+		}}, false
+	}
+	diagnostics := []Diagnostic{{Name: engineName + " installed", Status: DiagnosticOk}}
+	switch engineName {
+	case "docker":
+		diagnostics = append(diagnostics, diagnoseDockerSocket())
+		diagnostics = append(diagnostics, diagnoseDockerDaemon(ctx))
+	case "podman":
+		if runtime.GOOS != "linux" {
+			diagnostics = append(diagnostics, diagnosePodmanMachine(ctx))
+		} else if os.Geteuid() != 0 {
+			diagnostics = append(diagnostics, diagnoseSubIDs()...)
+		}
+		diagnostics = append(diagnostics, diagnosePodmanService(ctx))
+		if runtime.GOOS == "linux" {
+			diagnostics = append(diagnostics, diagnosePodmanSocket(os.Geteuid() == 0))
+			if os.Geteuid() != 0 {
+				diagnostics = append(diagnostics, diagnosePodmanSocket(true))
+			}
+		}
+	}
+	if len(registrySettings.Mirrors) > 0 || len(registrySettings.Insecure) > 0 {
+		diagnostics = append(diagnostics, diagnoseRegistrySettings(ctx, engineName))
+	}
+	return append(diagnostics, diagnoseCompose(ctx, engineName)), true
+}
+
+// Run a diagnostic command, returning its output and error output.
+func runDiagnosticCommand(ctx context.Context, binary string, args ...string) (string, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, diagnosticTimeout)
+	defer cancel()
+	cmd := engineCommand(ctx, binary, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := runEngineCommand(cmd)
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("no answer after %s", diagnosticTimeout)
+	}
+	return strings.TrimSpace(stdout.String()), strings.TrimSpace(stderr.String()), err
+}
+
+// Path of the Docker daemon socket used, empty if it is not a local socket.
+func dockerSocketPath() string {
+	host := os.Getenv("DOCKER_HOST")
+	if host == "" {
+		return "/var/run/docker.sock"
+	}
+	if path, ok := strings.CutPrefix(host, "unix://"); ok {
+		return path
+	}
+	return ""
+}
+
+func diagnoseDockerSocket() Diagnostic {
+	diagnostic := Diagnostic{Name: "docker socket permissions"}
+	path := dockerSocketPath()
+	if path == "" || runtime.GOOS == "windows" {
+		diagnostic.Status = DiagnosticSkipped
+		diagnostic.Detail = "not using a local unix socket"
+		return diagnostic
+	}
+	conn, err := net.DialTimeout("unix", path, diagnosticTimeout)
+	switch {
+	case err == nil:
+		conn.Close()
+		diagnostic.Status = DiagnosticOk
+		diagnostic.Detail = path
+	case os.IsPermission(err) || strings.Contains(err.Error(), "permission denied"):
+		diagnostic.Status = DiagnosticFailure
+		diagnostic.Detail = fmt.Sprintf("no permission to use %s", path)
+		diagnostic.Fix = "Add your user to the 'docker' group ('sudo usermod -aG docker $USER'), then log in again.\n" +
+			"Alternatively, set up rootless Docker or use Podman."
+	default:
+		diagnostic.Status = DiagnosticFailure
+		diagnostic.Detail = fmt.Sprintf("cannot connect to %s: %s", path, err)
+		diagnostic.Fix = "Start the Docker daemon (e.g. 'sudo systemctl start docker'), or Docker Desktop."
+	}
+	return diagnostic
+}
+
+func diagnoseDockerDaemon(ctx context.Context) Diagnostic {
+	diagnostic := Diagnostic{Name: "docker daemon reachable"}
+	version, stderr, err := runDiagnosticCommand(ctx, "docker", "info", "--format", "{{.ServerVersion}}")
+	if err != nil {
+		diagnostic.Status = DiagnosticFailure
+		diagnostic.Detail = firstLine(stderr, err)
+		if errors.Is(classifyStderr(stderr), ErrPermission) {
+			diagnostic.Fix = "Add your user to the 'docker' group ('sudo usermod -aG docker $USER'), then log in again."
+		} else {
+			diagnostic.Fix = "Start the Docker daemon (e.g. 'sudo systemctl start docker'), or Docker Desktop."
+		}
+		return diagnostic
+	}
+	diagnostic.Status = DiagnosticOk
+	diagnostic.Detail = "server version " + version
+	return diagnostic
+}
+
+func diagnosePodmanService(ctx context.Context) Diagnostic {
+	diagnostic := Diagnostic{Name: "podman reachable"}
+	version, stderr, err := runDiagnosticCommand(ctx, "podman", "info", "--format", "{{.Version.Version}}")
+	if err != nil {
+		diagnostic.Status = DiagnosticFailure
+		diagnostic.Detail = firstLine(stderr, err)
+		if runtime.GOOS != "linux" {
+			diagnostic.Fix = "Start its virtual machine with 'podman machine start'."
+		} else {
+			diagnostic.Fix = "Run 'podman system migrate' after any change to your user's subordinate ids, " +
+				"or 'podman system reset' if its storage is corrupted (this removes all its images and containers)."
+		}
+		return diagnostic
+	}
+	diagnostic.Status = DiagnosticOk
+	diagnostic.Detail = "version " + version
+	return diagnostic
+}
+
+// Check that Podman's API socket in the given mode is active. Only tools
+// speaking Docker's API need the rootless one, but regular users reach rootful
+// Podman through it.
+func diagnosePodmanSocket(rootful bool) Diagnostic {
+	diagnostic := Diagnostic{Name: "rootless podman socket"}
+	fix := "Enable it with 'systemctl --user enable --now podman.socket'."
+	if rootful {
+		diagnostic.Name = "rootful podman socket"
+		fix = "Enable it with 'sudo systemctl enable --now podman.socket'."
+	}
+	path := podmanSocketPath(rootful)
+	err := dialSocket(path)
+	switch {
+	case err == nil:
+		diagnostic.Status = DiagnosticOk
+		diagnostic.Detail = path
+	case rootful && os.IsPermission(err):
+		diagnostic.Status = DiagnosticWarning
+		diagnostic.Detail = fmt.Sprintf("no permission to use %s, only needed by projects built with --rootful", path)
+		diagnostic.Fix = "Give one of your groups access to it with 'sudo systemctl edit podman.socket',\n" +
+			"setting 'SocketGroup=<group>' in its [Socket] section, then 'sudo systemctl restart podman.socket'."
+	default:
+		diagnostic.Status = DiagnosticWarning
+		diagnostic.Detail = fmt.Sprintf("%s is not active, only needed by tools using Docker's API (e.g. docker-compose)", path)
+		if rootful && os.Geteuid() != 0 {
+			diagnostic.Detail = fmt.Sprintf("%s is not active, only needed by projects built with --rootful", path)
+		}
+		diagnostic.Fix = fix
+	}
+	return diagnostic
+}
+
+func diagnosePodmanMachine(ctx context.Context) Diagnostic {
+	diagnostic := Diagnostic{Name: "podman machine running"}
+	output, stderr, err := runDiagnosticCommand(ctx, "podman", "machine", "list", "--format", "{{.Name}}\t{{.Running}}")
+	if err != nil {
+		diagnostic.Status = DiagnosticFailure
+		diagnostic.Detail = firstLine(stderr, err)
+		return diagnostic
+	}
+	machines, running := parseMachineList(output)
+	switch {
+	case machines == 0:
+		diagnostic.Status = DiagnosticFailure
+		diagnostic.Detail = "no podman machine"
+		diagnostic.Fix = "Create and start one with 'podman machine init' then 'podman machine start'."
+	case running == "":
+		diagnostic.Status = DiagnosticFailure
+		diagnostic.Detail = "no podman machine is running"
+		diagnostic.Fix = "Start it with 'podman machine start'."
+	default:
+		diagnostic.Status = DiagnosticOk
+		diagnostic.Detail = running
+	}
+	return diagnostic
+}
+
+// Parse the output of `podman machine list --format "{{.Name}}\t{{.Running}}"`
+// into the number of machines and the name of the running one, if any.
+func parseMachineList(output string) (int, string) {
+	count := 0
+	running := ""
+	for line := range strings.SplitSeq(output, "\n") {
+		name, state, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if !ok {
+			continue
+		}
+		count++
+		if isRunning, _ := strconv.ParseBool(state); isRunning {
+			running = strings.TrimSuffix(name, "*")
+		}
+	}
+	return count, running
+}
+
+// Check that the current user has subordinate user and group ids, which
+// rootless Podman needs to map users inside containers.
+func diagnoseSubIDs() []Diagnostic {
+	current, err := user.Current()
+	if err != nil {
+		return []Diagnostic{{
+			Name:   "rootless podman setup",
+			Status: DiagnosticWarning,
+			Detail: fmt.Sprintf("cannot identify the current user: %s", err),
+		}}
+	}
+	var diagnostics []Diagnostic
+	for _, path := range []string{"/etc/subuid", "/etc/subgid"} {
+		diagnostic := Diagnostic{Name: "rootless podman " + path}
+		content, err := os.ReadFile(path)
+		switch {
+		case err != nil && !os.IsNotExist(err):
+			diagnostic.Status = DiagnosticWarning
+			diagnostic.Detail = err.Error()
+		case err == nil && hasSubIDRange(string(content), current.Username, current.Uid):
+			diagnostic.Status = DiagnosticOk
+		default:
+			diagnostic.Status = DiagnosticFailure
+			diagnostic.Detail = fmt.Sprintf("no range for user '%s'", current.Username)
+			diagnostic.Fix = fmt.Sprintf("Run 'sudo usermod --add-subuids 100000-165535 --add-subgids 100000-165535 %s',\n"+
+				"then 'podman system migrate'.", current.Username)
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	return diagnostics
+}
+
+// Whether an /etc/subuid or /etc/subgid content has a range for that user.
+func hasSubIDRange(content string, username string, uid string) bool {
+	for line := range strings.SplitSeq(content, "\n") {
+		owner, _, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if ok && (owner == username || owner == uid) {
+			return true
+		}
+	}
+	return false
+}
+
+func diagnoseCompose(ctx context.Context, engineName string) Diagnostic {
+	diagnostic := Diagnostic{Name: engineName + " compose available"}
+	if provider, err := DetectComposeProvider(ctx, engineName); err == nil {
+		diagnostic.Status = DiagnosticOk
+		diagnostic.Detail = "with '" + provider.Command + "'"
+		if provider.IsFallback() {
+			diagnostic.Detail += ", as '" + provider.Preferred + "' is not available"
+		}
+		return diagnostic
+	}
+	diagnostic.Status = DiagnosticWarning
+	diagnostic.Detail = "not found, only needed to run bundles written by 'paul-envs export compose'"
+	if engineName == "docker" {
+		diagnostic.Fix = "Install the Docker Compose plugin (e.g. the 'docker-compose-plugin' package)."
+	} else {
+		diagnostic.Fix = "Install 'podman-compose' or 'docker-compose'."
+	}
+	return diagnostic
+}
+
+func firstLine(stderr string, err error) string {
+	if line, _, _ := strings.Cut(stderr, "\n"); line != "" {
+		return line
+	}
+	return err.Error()
+}
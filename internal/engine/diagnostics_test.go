@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseMachineList(t *testing.T) {
+	count, running := parseMachineList("podman-machine-default*\ttrue\nother\tfalse\n")
+	if count != 2 || running != "podman-machine-default" {
+		t.Fatalf("parseMachineList() = %d, %q", count, running)
+	}
+	count, running = parseMachineList("podman-machine-default\tfalse")
+	if count != 1 || running != "" {
+		t.Fatalf("parseMachineList() = %d, %q, want 1 stopped machine", count, running)
+	}
+	if count, _ := parseMachineList(""); count != 0 {
+		t.Fatalf("parseMachineList(\"\") = %d machines, want 0", count)
+	}
+}
+
+func TestHasSubIDRange(t *testing.T) {
+	content := "alice:100000:65536\n1001:165536:65536\n"
+	if !hasSubIDRange(content, "alice", "1000") {
+		t.Fatal("expected a range for alice")
+	}
+	if !hasSubIDRange(content, "bob", "1001") {
+		t.Fatal("expected a range for uid 1001")
+	}
+	if hasSubIDRange(content, "carol", "1002") || hasSubIDRange(content, "ali", "1") {
+		t.Fatal("unexpected range found")
+	}
+}
+
+func TestDockerSocketPath(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "")
+	if got := dockerSocketPath(); got != "/var/run/docker.sock" {
+		t.Fatalf("dockerSocketPath() = %q", got)
+	}
+	t.Setenv("DOCKER_HOST", "unix:///run/user/1000/docker.sock")
+	if got := dockerSocketPath(); got != "/run/user/1000/docker.sock" {
+		t.Fatalf("dockerSocketPath() = %q", got)
+	}
+	t.Setenv("DOCKER_HOST", "tcp://127.0.0.1:2375")
+	if got := dockerSocketPath(); got != "" {
+		t.Fatalf("dockerSocketPath() = %q, want no local socket", got)
+	}
+}
+
+func TestDiagnosePodmanSocket(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	if got := diagnosePodmanSocket(false); got.Status != DiagnosticWarning || !strings.Contains(got.Fix, "systemctl --user") {
+		t.Fatalf("diagnosePodmanSocket() without socket = %+v", got)
+	}
+
+	path := rootlessPodmanSocket()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("cannot listen on a unix socket: %v", err)
+	}
+	defer listener.Close()
+	if got := diagnosePodmanSocket(false); got.Status != DiagnosticOk || got.Detail != path {
+		t.Fatalf("diagnosePodmanSocket() with an active socket = %+v", got)
+	}
+}
@@ -0,0 +1,130 @@
+// # disk_usage.go
+// Disk space used by images, volumes and the build cache of a container
+// engine, as reported by its `system df` command, for `paul-envs du`.
+//
+// Docker reports it as JSON. Podman refuses to format its detailed report,
+// whose tables are thus parsed, their columns being separated by at least two
+// spaces.
+
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Disk space used by the resources of a container engine, in bytes.
+type DiskUsage struct {
+	// By image reference, as listed by the engine (e.g. "paulenv:myapp")
+	Images map[string]ImageUsage
+	// By volume name
+	Volumes map[string]int64
+	// Space used by the whole build cache of the engine, which cannot be
+	// attributed to a project
+	BuildCache int64
+}
+
+// Disk space used by an image, in bytes.
+type ImageUsage struct {
+	// Id of the image, several references being able to tag the same one
+	ImageId string
+	// Size of all its layers
+	Size int64
+	// Size of the layers no other image shares, freed by removing it
+	UniqueSize int64
+}
+
+// Output of `docker system df -v --format '{{json .}}'`
+type dockerDiskUsage struct {
+	Images []struct {
+		Repository string
+		Tag        string
+		ID         string
+		Size       string
+		UniqueSize string
+	}
+	Volumes []struct {
+		Name string
+		Size string
+	}
+	BuildCache []struct {
+		Size string
+	}
+}
+
+func parseDockerDiskUsage(output []byte) (DiskUsage, error) {
+	var report dockerDiskUsage
+	if err := json.Unmarshal(output, &report); err != nil {
+		return DiskUsage{}, fmt.Errorf("unexpected disk usage report: %w", err)
+	}
+	usage := DiskUsage{Images: map[string]ImageUsage{}, Volumes: map[string]int64{}}
+	for _, image := range report.Images {
+		if image.Repository == "<none>" || image.Tag == "<none>" {
+			continue
+		}
+		usage.Images[image.Repository+":"+image.Tag] = ImageUsage{
+			ImageId:    image.ID,
+			Size:       parseReportedSize(image.Size),
+			UniqueSize: parseReportedSize(image.UniqueSize),
+		}
+	}
+	for _, volume := range report.Volumes {
+		usage.Volumes[volume.Name] = parseReportedSize(volume.Size)
+	}
+	for _, record := range report.BuildCache {
+		usage.BuildCache += parseReportedSize(record.Size)
+	}
+	return usage, nil
+}
+
+var podmanColumnSeparator = regexp.MustCompile(`\s{2,}`)
+
+// Parse the output of `podman system df -v`.
+func parsePodmanDiskUsage(output string) DiskUsage {
+	usage := DiskUsage{Images: map[string]ImageUsage{}, Volumes: map[string]int64{}}
+	section := ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			continue
+		case strings.HasSuffix(line, "space usage:"):
+			section = line
+			continue
+		case strings.HasPrefix(line, "REPOSITORY"), strings.HasPrefix(line, "VOLUME NAME"):
+			// Header of a table
+			continue
+		}
+		columns := podmanColumnSeparator.Split(line, -1)
+		switch {
+		// REPOSITORY, TAG, IMAGE ID, CREATED, SIZE, SHARED SIZE, UNIQUE SIZE,
+		// CONTAINERS
+		case section == "Images space usage:" && len(columns) >= 7:
+			if columns[0] == "<none>" || columns[1] == "<none>" {
+				continue
+			}
+			usage.Images[columns[0]+":"+columns[1]] = ImageUsage{
+				ImageId:    columns[2],
+				Size:       parseReportedSize(columns[4]),
+				UniqueSize: parseReportedSize(columns[6]),
+			}
+		// VOLUME NAME, LINKS, SIZE
+		case section == "Local Volumes space usage:" && len(columns) >= 3:
+			usage.Volumes[columns[0]] = parseReportedSize(columns[2])
+		}
+	}
+	return usage
+}
+
+// Sizes the engine could not compute (e.g. "N/A") are taken as empty.
+func parseReportedSize(value string) int64 {
+	size, err := utils.ParseSize(value)
+	if err != nil {
+		return 0
+	}
+	return size
+}
@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDockerDiskUsage(t *testing.T) {
+	output := `{"Images":[` +
+		`{"Repository":"paulenv","Tag":"app","ID":"sha256:aaa","Size":"1.5GB","SharedSize":"1.2GB","UniqueSize":"300MB"},` +
+		`{"Repository":"<none>","Tag":"<none>","ID":"sha256:bbb","Size":"10MB","SharedSize":"0B","UniqueSize":"10MB"}],` +
+		`"Volumes":[{"Name":"paulenv-app-local","Links":"1","Size":"2GB"},{"Name":"other","Size":"N/A"}],` +
+		`"BuildCache":[{"ID":"x","Size":"1.5MB"},{"ID":"y","Size":"500kB"}]}`
+	usage, err := parseDockerDiskUsage([]byte(output))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := DiskUsage{
+		Images: map[string]ImageUsage{
+			"paulenv:app": {ImageId: "sha256:aaa", Size: 1_500_000_000, UniqueSize: 300_000_000},
+		},
+		Volumes:    map[string]int64{"paulenv-app-local": 2_000_000_000, "other": 0},
+		BuildCache: 2_000_000,
+	}
+	if !reflect.DeepEqual(usage, want) {
+		t.Errorf("want %+v, got %+v", want, usage)
+	}
+
+	if _, err := parseDockerDiskUsage([]byte("Images space usage:")); err == nil {
+		t.Error("expected an error on a non-JSON report")
+	}
+}
+
+func TestParsePodmanDiskUsage(t *testing.T) {
+	output := `Images space usage:
+
+REPOSITORY                 TAG         IMAGE ID      CREATED       SIZE        SHARED SIZE  UNIQUE SIZE  CONTAINERS
+localhost/paulenv          app         0123456789ab  2 weeks ago   1.5GB       1.2GB        300MB        1
+localhost/paulenv-base     latest      ba9876543210  3 weeks ago   1.2GB       1.2GB        0B           0
+<none>                     <none>      ffffffffffff  3 weeks ago   10MB        0B           10MB         0
+
+Containers space usage:
+
+CONTAINER ID  IMAGE         COMMAND     LOCAL VOLUMES  SIZE        CREATED      STATUS      NAMES
+0123456789ab  0123456789ab  /bin/bash   1              12kB        2 hours ago  running     paulenv-app
+
+Local Volumes space usage:
+
+VOLUME NAME         LINKS       SIZE
+paulenv-app-local   1           2GB
+`
+	want := DiskUsage{
+		Images: map[string]ImageUsage{
+			"localhost/paulenv:app":         {ImageId: "0123456789ab", Size: 1_500_000_000, UniqueSize: 300_000_000},
+			"localhost/paulenv-base:latest": {ImageId: "ba9876543210", Size: 1_200_000_000},
+		},
+		Volumes: map[string]int64{"paulenv-app-local": 2_000_000_000},
+	}
+	if usage := parsePodmanDiskUsage(output); !reflect.DeepEqual(usage, want) {
+		t.Errorf("want %+v, got %+v", want, usage)
+	}
+}
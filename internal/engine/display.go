@@ -0,0 +1,86 @@
+// # display.go
+// Forwarding of the host's display servers (Wayland and X11) to containers, so
+// GUI applications can run inside them.
+
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Directory used as `XDG_RUNTIME_DIR` in containers, where forwarded sockets
+// (display, audio) are mounted. The entrypoint makes it owned by the container
+// user.
+const containerRuntimeDir = "/tmp/paulenv-runtime"
+
+// Isolated for tests
+var getenv = os.Getenv
+var fileExists = func(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Display servers of the host which can be forwarded to containers.
+type hostDisplay struct {
+	// Path to the Wayland socket, empty if none
+	waylandSocket string
+	// Value of the X11 `DISPLAY` to forward, empty if none
+	x11Display string
+}
+
+func detectHostDisplay() hostDisplay {
+	var display hostDisplay
+	if runtimeDir, wayland := getenv("XDG_RUNTIME_DIR"), getenv("WAYLAND_DISPLAY"); wayland != "" {
+		socket := wayland
+		if !filepath.IsAbs(socket) {
+			socket = filepath.Join(runtimeDir, wayland)
+		}
+		if fileExists(socket) {
+			display.waylandSocket = socket
+		}
+	}
+	// Only local displays (e.g. ":0") are reachable through their socket
+	if x11 := getenv("DISPLAY"); strings.HasPrefix(x11, ":") && fileExists("/tmp/.X11-unix") {
+		display.x11Display = x11
+	}
+	return display
+}
+
+// Arguments of the `run` command forwarding the given host displays.
+//
+// `xauthorityPath` is only mounted if it exists.
+func displayRunArgs(display hostDisplay, xauthorityPath string) []string {
+	var args []string
+	if display.waylandSocket != "" {
+		name := filepath.Base(display.waylandSocket)
+		args = append(args,
+			"--volume", display.waylandSocket+":"+containerRuntimeDir+"/"+name,
+			"--env", "WAYLAND_DISPLAY="+name,
+		)
+	}
+	if display.x11Display != "" {
+		args = append(args,
+			"--volume", "/tmp/.X11-unix:/tmp/.X11-unix",
+			"--env", "DISPLAY="+display.x11Display,
+		)
+		if xauthorityPath != "" && fileExists(xauthorityPath) {
+			args = append(args,
+				"--volume", xauthorityPath+":/tmp/paulenv-xauthority:ro",
+				"--env", "XAUTHORITY=/tmp/paulenv-xauthority",
+			)
+		}
+	}
+	return args
+}
+
+// Arguments of the `run` command to add once host sockets are forwarded into
+// `containerRuntimeDir`.
+func runtimeDirRunArgs() []string {
+	// SELinux would otherwise prevent the container from using host sockets
+	return []string{
+		"--env", "XDG_RUNTIME_DIR=" + containerRuntimeDir,
+		"--security-opt", "label=disable",
+	}
+}
@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+)
+
+func stubHost(t *testing.T, env map[string]string, existing ...string) {
+	t.Helper()
+	prevGetenv, prevFileExists := getenv, fileExists
+	t.Cleanup(func() { getenv, fileExists = prevGetenv, prevFileExists })
+	getenv = func(key string) string { return env[key] }
+	fileExists = func(path string) bool { return slices.Contains(existing, path) }
+}
+
+func TestDetectHostDisplay(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		existing []string
+		want     hostDisplay
+	}{
+		{
+			name:     "wayland and x11",
+			env:      map[string]string{"XDG_RUNTIME_DIR": "/run/user/1000", "WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"},
+			existing: []string{"/run/user/1000/wayland-0", "/tmp/.X11-unix"},
+			want:     hostDisplay{waylandSocket: "/run/user/1000/wayland-0", x11Display: ":0"},
+		},
+		{
+			name:     "missing wayland socket",
+			env:      map[string]string{"XDG_RUNTIME_DIR": "/run/user/1000", "WAYLAND_DISPLAY": "wayland-0"},
+			existing: nil,
+			want:     hostDisplay{},
+		},
+		{
+			name:     "remote x11 display",
+			env:      map[string]string{"DISPLAY": "localhost:10.0"},
+			existing: []string{"/tmp/.X11-unix"},
+			want:     hostDisplay{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubHost(t, tt.env, tt.existing...)
+			if got := detectHostDisplay(); got != tt.want {
+				t.Fatalf("detectHostDisplay() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisplayRunArgs(t *testing.T) {
+	stubHost(t, nil, "/data/demo/.paul-env/Xauthority")
+	got := displayRunArgs(
+		hostDisplay{waylandSocket: "/run/user/1000/wayland-0", x11Display: ":1"},
+		"/data/demo/.paul-env/Xauthority",
+	)
+	want := []string{
+		"--volume", "/run/user/1000/wayland-0:/tmp/paulenv-runtime/wayland-0",
+		"--env", "WAYLAND_DISPLAY=wayland-0",
+		"--volume", "/tmp/.X11-unix:/tmp/.X11-unix",
+		"--env", "DISPLAY=:1",
+		"--volume", "/data/demo/.paul-env/Xauthority:/tmp/paulenv-xauthority:ro",
+		"--env", "XAUTHORITY=/tmp/paulenv-xauthority",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("displayRunArgs() = %v, want %v", got, want)
+	}
+	if got := displayRunArgs(hostDisplay{}, ""); got != nil {
+		t.Fatalf("displayRunArgs() = %v without display, want nil", got)
+	}
+}
@@ -5,39 +5,141 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"regexp"
 	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/peaberberian/paul-envs/internal/config"
 	"github.com/peaberberian/paul-envs/internal/files"
-	"golang.org/x/term"
+	"github.com/peaberberian/paul-envs/internal/profiling"
+	"github.com/peaberberian/paul-envs/internal/utils"
 )
 
+// Builds are done by BuildKit, even on versions of Docker still defaulting
+// to the legacy builder, as the generated Dockerfiles rely on its cache mounts
+// (`RUN --mount=type=cache`). Podman supports them natively.
+const dockerBuildKitEnv = "DOCKER_BUILDKIT=1"
+
 // Implements `ContainerEngine` for Docker.
-type DockerEngine struct{}
+type DockerEngine struct {
+	quirksOnce       sync.Once
+	quirks           engineQuirks
+	capabilitiesOnce sync.Once
+	capabilities     Capabilities
+}
 
 func newDocker(ctx context.Context) (*DockerEngine, error) {
-	if _, err := exec.LookPath("docker"); err != nil {
+	if _, err := lookEngineExecutable("docker"); err != nil {
 		return nil, fmt.Errorf("docker command not found: %w", err)
 	}
 	return &DockerEngine{}, nil
 }
 
+func (c *DockerEngine) BuildBaseImage(ctx context.Context, baseFilesDir string, options BuildOptions) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker BuildBaseImage")()
+	if err := c.checkCacheMounts(ctx); err != nil {
+		return err
+	}
+	options.proxyEnv = config.ProxyEnv(os.Environ(), nil)
+	registryEnv, err := dockerRegistryEnv(ctx)
+	if err != nil {
+		return err
+	}
+	options.registryEnv = registryEnv
+	cmdArgs := dockerBaseBuildArgs(baseFilesDir, options)
+	if err := runBuildAttempts(ctx, options, func(ctx context.Context) *exec.Cmd {
+		cmd := engineCommand(ctx, "docker", cmdArgs...)
+		withEnv(cmd, slices.Concat([]string{dockerBuildKitEnv}, options.proxyEnv, options.registryEnv))
+		return cmd
+	}); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("base image build failed: %w", err)
+	}
+	return nil
+}
+
+func dockerBaseBuildArgs(baseFilesDir string, options BuildOptions) []string {
+	cmdArgs := append([]string{"build"}, dockerProgressArgs()...)
+	if options.NoCache {
+		cmdArgs = append(cmdArgs, "--no-cache")
+	}
+	if options.Platform != "" {
+		cmdArgs = append(cmdArgs, "--platform", options.Platform)
+	}
+	if options.DistributionImage != "" {
+		cmdArgs = append(cmdArgs, "--build-arg", "DISTRIBUTION_IMAGE="+options.DistributionImage)
+	}
+	cmdArgs = append(cmdArgs, envNameArgs("--build-arg", options.proxyEnv)...)
+	return append(cmdArgs,
+		"--file", hostPath(baseDockerfilePath(baseFilesDir)),
+		"--tag", baseImageNameFor(options.Platform),
+		hostPath(baseFilesDir),
+	)
+}
+
+func (c *DockerEngine) HasBaseImage(ctx context.Context, platform string) (bool, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker HasBaseImage")()
+	cmd := engineCommand(ctx, "docker", "image", "inspect", baseImageNameFor(platform))
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return false, pErr
+		}
+		if c.getQuirks(ctx).isMissingImage(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
 func (c *DockerEngine) BuildImage(ctx context.Context, project files.ProjectEntry, options BuildOptions) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker BuildImage")()
+	if err := c.checkCacheMounts(ctx); err != nil {
+		return err
+	}
 	buildCfg, err := loadBuildConfig(project)
 	if err != nil {
 		return err
 	}
 
+	runtimeCfg, err := loadRuntimeConfig(project)
+	if err != nil {
+		return err
+	}
+
+	options.proxyEnv = projectProxyEnv(runtimeCfg)
+	if options.registryEnv, err = dockerRegistryEnv(ctx); err != nil {
+		return err
+	}
+	options = withProjectBuildSettings(options, runtimeCfg)
+	options = withProjectBuildArgs(options, buildCfg)
+	configHash, err := utils.FileHash(project.BuildConfigPath)
+	if err != nil {
+		return fmt.Errorf("cannot hash build.conf: %w", err)
+	}
+	options = withProjectImageSettings(options, project.ProjectName, buildCfg, configHash)
+	options = withProvenanceLabels(ctx, options, project, buildCfg, runtimeCfg)
+	if options.squash {
+		// Only with its legacy builder, in experimental mode
+		return errors.New("SQUASH is not supported by Docker, only by Podman")
+	}
+	if options, err = withProjectDockerfile(options, project, buildCfg); err != nil {
+		return err
+	}
 	cmdArgs := dockerBuildArgs(project, buildCfg.Args, options)
-	cmd := exec.CommandContext(ctx, "docker", cmdArgs...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	if err := runBuildAttempts(ctx, options, func(ctx context.Context) *exec.Cmd {
+		cmd := engineCommand(ctx, "docker", cmdArgs...)
+		withEnv(cmd, slices.Concat([]string{dockerBuildKitEnv}, options.proxyEnv, options.registryEnv))
+		return cmd
+	}); err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return pErr
 		}
@@ -47,11 +149,12 @@ func (c *DockerEngine) BuildImage(ctx context.Context, project files.ProjectEntr
 }
 
 func dockerBuildArgs(project files.ProjectEntry, buildArgs map[string]string, options BuildOptions) []string {
-	cmdArgs := []string{
-		"build",
-		"--file", projectDockerfilePath(project),
+	dockerfile, buildContext := projectBuildFiles(project, options)
+	cmdArgs := append([]string{"build"}, dockerProgressArgs()...)
+	cmdArgs = append(cmdArgs,
+		"--file", hostPath(dockerfile),
 		"--tag", projectImageName(project.ProjectName),
-	}
+	)
 	if options.NoCache {
 		cmdArgs = append(cmdArgs, "--no-cache")
 	}
@@ -61,14 +164,24 @@ func dockerBuildArgs(project files.ProjectEntry, buildArgs map[string]string, op
 		keys = append(keys, key)
 	}
 	sort.Strings(keys)
+	if options.Platform != "" {
+		cmdArgs = append(cmdArgs, "--platform", options.Platform)
+	}
+	cmdArgs = append(cmdArgs, "--build-arg", "BASE_IMAGE="+baseImageNameFor(options.Platform))
 	for _, key := range keys {
 		cmdArgs = append(cmdArgs, "--build-arg", fmt.Sprintf("%s=%s", key, buildArgs[key]))
 	}
-	cmdArgs = append(cmdArgs, projectBaseDataDir(project))
+	cmdArgs = append(cmdArgs, envNameArgs("--build-arg", options.proxyEnv)...)
+	// Last, so they replace the ones above
+	cmdArgs = append(cmdArgs, buildArgFlags(options.BuildArgs)...)
+	cmdArgs = append(cmdArgs, imageLabelFlags(options.labels)...)
+	cmdArgs = append(cmdArgs, customDockerfileArgs(project, options)...)
+	cmdArgs = append(cmdArgs, hostPath(buildContext))
 	return cmdArgs
 }
 
-func (c *DockerEngine) RunContainer(ctx context.Context, project files.ProjectEntry, args []string) error {
+func (c *DockerEngine) RunContainer(ctx context.Context, project files.ProjectEntry, args []string, options RunOptions) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker RunContainer")()
 	buildCfg, err := loadBuildConfig(project)
 	if err != nil {
 		return err
@@ -78,60 +191,45 @@ func (c *DockerEngine) RunContainer(ctx context.Context, project files.ProjectEn
 		return err
 	}
 
-	username := buildCfg.Args["USERNAME"]
-	projectMount := projectMountTarget(username, project.ProjectName)
-	workDir := runtimeCfg.WorkDir
-	if workDir == "" {
-		workDir = projectMount
-	}
-
-	if err := c.ensureVolumesExist(ctx, "paulenv-shared-cache", projectLocalVolumeName(project.ProjectName)); err != nil {
+	if err := c.ensureVolumesExist(ctx, "paulenv-shared-cache", options.Instance.localVolumeName(project.ProjectName)); err != nil {
 		return err
 	}
-
-	cmdArgs := []string{
-		"run",
-		"--rm",
-		"--init",
-		"--name", projectContainerName(project.ProjectName),
-		"--workdir", workDir,
-		"--volume", runtimeCfg.ProjectPath + ":" + projectMount,
-		"--volume", "paulenv-shared-cache:/home/" + username + "/.container-cache",
-		"--volume", projectLocalVolumeName(project.ProjectName) + ":/home/" + username + "/.container-local",
-	}
-	if runtimeCfg.DotfilesPath != "" {
-		dotfilesPath, err := resolveRuntimePath(project.RuntimeConfigPath, runtimeCfg.DotfilesPath)
-		if err != nil {
-			return fmt.Errorf("resolve DOTFILES_PATH: %w", err)
+	if network := runNetworkName(project.ProjectName, runtimeCfg); network != "" {
+		if err := c.ensureNetworkExists(ctx, network); err != nil {
+			return err
 		}
-		cmdArgs = append(cmdArgs, "--volume", dotfilesPath+":/paul-env/dotfiles:ro")
-	}
-	if runtimeCfg.GitName != "" {
-		cmdArgs = append(cmdArgs, "--env", "GIT_AUTHOR_NAME="+runtimeCfg.GitName)
-	}
-	if runtimeCfg.GitEmail != "" {
-		cmdArgs = append(cmdArgs, "--env", "GIT_AUTHOR_EMAIL="+runtimeCfg.GitEmail)
 	}
 
-	for _, volume := range runtimeCfg.Volumes {
-		cmdArgs = append(cmdArgs, "--volume", volume)
-	}
-	for _, port := range runtimeCfg.Ports {
-		cmdArgs = append(cmdArgs, "--publish", port)
+	session := usesPersistentSession(runtimeCfg, args, options.hasTerminalInput())
+	if hasSystemd(buildCfg) || session {
+		// systemd keeps the container alive by itself
+		var detachedCmd []string
+		if !hasSystemd(buildCfg) {
+			detachedCmd = backgroundCommand
+		}
+		cmdArgs, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, options.runEnv(), detachedCmd)
+		if err != nil {
+			return err
+		}
+		cmd := engineCommand(ctx, "docker", detachedRunArgs(options.containerRunArgs(cmdArgs, project))...)
+		options.withSecrets(cmd)
+		withEnv(cmd, projectProxyEnv(runtimeCfg))
+		return runDetachedContainer(ctx, c, project, options.Instance, cmd, args, session)
 	}
-
-	if term.IsTerminal(int(os.Stdin.Fd())) {
-		cmdArgs = append(cmdArgs, "--tty", "--interactive")
+	cmdArgs, err := dockerRunArgs(project, buildCfg, runtimeCfg, options.hasTerminalInput(), options.runEnv(), args)
+	if err != nil {
+		return err
 	}
 
-	cmdArgs = append(cmdArgs, projectImageName(project.ProjectName))
-	cmdArgs = append(cmdArgs, args...)
-
-	cmd := exec.CommandContext(ctx, "docker", cmdArgs...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	cmd := engineCommand(ctx, "docker", options.containerRunArgs(cmdArgs, project)...)
+	options.withSecrets(cmd)
+	withEnv(cmd, projectProxyEnv(runtimeCfg))
+	options.withStreams(cmd)
+	if err := runEngineCommand(cmd); err != nil {
+		if ctx.Err() != nil {
+			removeInterruptedContainer(ctx, c, instanceContainerName(project.ProjectName, options.Instance.Name))
+			return fmt.Errorf("run interrupted: %w", ctx.Err())
+		}
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return pErr
 		}
@@ -140,18 +238,43 @@ func (c *DockerEngine) RunContainer(ctx context.Context, project files.ProjectEn
 	return nil
 }
 
+func (c *DockerEngine) CheckShell(ctx context.Context, project files.ProjectEntry) (ShellCheck, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker CheckShell")()
+	runtimeCfg, err := loadRuntimeConfig(project)
+	if err != nil {
+		return ShellCheck{}, err
+	}
+	cmdArgs, err := shellCheckRunArgs(project, runtimeCfg, projectImageName(project.ProjectName))
+	if err != nil {
+		return ShellCheck{}, err
+	}
+	cmd := engineCommand(ctx, "docker", cmdArgs...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err = runEngineCommand(cmd)
+	check, ran := shellCheckResult(err, stderr.String())
+	if !ran {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return ShellCheck{}, pErr
+		}
+		return ShellCheck{}, fmt.Errorf("failed to run the shell of project %s: %w", project.ProjectName, err)
+	}
+	return check, nil
+}
+
 func (c *DockerEngine) JoinContainer(ctx context.Context, containerInfo ContainerInfo, args []string) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker JoinContainer")()
 	cmdArgs := []string{"exec"}
-	if term.IsTerminal(int(os.Stdin.Fd())) {
+	if stdinIsTerminal() {
 		cmdArgs = append(cmdArgs, "-it")
 	}
 	cmdArgs = append(cmdArgs, containerInfo.ContainerId, "/usr/local/bin/entrypoint.sh")
 	cmdArgs = append(cmdArgs, args...)
-	cmd := exec.CommandContext(ctx, "docker", cmdArgs...)
+	cmd := engineCommand(ctx, "docker", cmdArgs...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	if err := runEngineCommand(cmd); err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return pErr
 		}
@@ -160,16 +283,135 @@ func (c *DockerEngine) JoinContainer(ctx context.Context, containerInfo Containe
 	return nil
 }
 
+func (c *DockerEngine) ExecContainer(ctx context.Context, containerInfo ContainerInfo, args []string, options ExecOptions) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker ExecContainer")()
+	tty := options.hasTerminalInput()
+	cmd := engineCommand(ctx, "docker", execArgs(containerInfo.ContainerId, args, options, tty)...)
+	options.withStreams(cmd)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("exec exited: %w", err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) CopyTo(ctx context.Context, containerInfo ContainerInfo, hostFile string, containerPath string) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker CopyTo")()
+	// Keep the uid and gid of copied files, root's otherwise
+	cmd := engineCommand(ctx, "docker", "cp", "--archive", hostPath(hostFile), containerInfo.ContainerId+":"+containerPath)
+	cmd.Stderr = engineOutput
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to copy %s into container %s: %w", hostFile, containerInfo.ContainerId, err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) CopyFrom(ctx context.Context, containerInfo ContainerInfo, containerPath string, hostFile string) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker CopyFrom")()
+	cmd := engineCommand(ctx, "docker", "cp", containerInfo.ContainerId+":"+containerPath, hostPath(hostFile))
+	cmd.Stderr = engineOutput
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to copy %s from container %s: %w", containerPath, containerInfo.ContainerId, err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) StartContainer(ctx context.Context, project files.ProjectEntry) (ContainerInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker StartContainer")()
+	buildCfg, err := loadBuildConfig(project)
+	if err != nil {
+		return ContainerInfo{}, err
+	}
+	runtimeCfg, err := loadRuntimeConfig(project)
+	if err != nil {
+		return ContainerInfo{}, err
+	}
+
+	if err := c.ensureVolumesExist(ctx, "paulenv-shared-cache", ProjectLocalVolumeName(project.ProjectName)); err != nil {
+		return ContainerInfo{}, err
+	}
+	if network := runNetworkName(project.ProjectName, runtimeCfg); network != "" {
+		if err := c.ensureNetworkExists(ctx, network); err != nil {
+			return ContainerInfo{}, err
+		}
+	}
+
+	cmdArgs, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, backgroundCommand)
+	if err != nil {
+		return ContainerInfo{}, err
+	}
+
+	cmd := engineCommand(ctx, "docker", detachedRunArgs(cmdArgs)...)
+	withEnv(cmd, projectProxyEnv(runtimeCfg))
+	cmd.Stderr = engineOutput
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return ContainerInfo{}, pErr
+		}
+		return ContainerInfo{}, fmt.Errorf("start failed: %w", err)
+	}
+	projectName := project.ProjectName
+	containerName := projectContainerName(projectName)
+	imageName := projectImageName(projectName)
+	return ContainerInfo{
+		ProjectName:   &projectName,
+		ContainerName: &containerName,
+		ImageName:     &imageName,
+		ContainerId:   strings.TrimSpace(string(output)),
+		Running:       true,
+	}, nil
+}
+
+func (c *DockerEngine) GetContainerStartTime(ctx context.Context, container ContainerInfo) (time.Time, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker GetContainerStartTime")()
+	startedAt, err := containerStartTime(ctx, func(ctx context.Context, args ...string) *exec.Cmd {
+		return engineCommand(ctx, "docker", args...)
+	}, container.ContainerId)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return time.Time{}, pErr
+		}
+	}
+	return startedAt, err
+}
+
+func (c *DockerEngine) WaitContainer(ctx context.Context, container ContainerInfo) (ContainerExit, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker WaitContainer")()
+	exit, err := waitContainer(ctx, func(ctx context.Context, args ...string) *exec.Cmd {
+		return engineCommand(ctx, "docker", args...)
+	}, container.ContainerId)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ContainerExit{}, ctx.Err()
+		}
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return ContainerExit{}, pErr
+		}
+		return ContainerExit{}, err
+	}
+	return exit, nil
+}
+
 func (c *DockerEngine) HasBeenBuilt(ctx context.Context, projectName string) (bool, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker HasBeenBuilt")()
 	imageName := projectImageName(projectName)
-	cmd := exec.CommandContext(ctx, "docker", "image", "inspect", imageName)
-	err := cmd.Run()
+	cmd := engineCommand(ctx, "docker", "image", "inspect", imageName)
+	err := runEngineCommand(cmd)
 
 	if err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return false, pErr
 		}
-		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		if c.getQuirks(ctx).isMissingImage(err) {
 			return false, nil
 		}
 		return false, err
@@ -179,8 +421,9 @@ func (c *DockerEngine) HasBeenBuilt(ctx context.Context, projectName string) (bo
 }
 
 func (c *DockerEngine) Info(ctx context.Context) (EngineInfo, error) {
-	cmd := exec.CommandContext(ctx, "docker", "--version")
-	output, err := cmd.Output()
+	defer profiling.Track(profiling.CategoryEngine, "docker Info")()
+	cmd := engineCommand(ctx, "docker", "--version")
+	output, err := engineCommandOutput(cmd)
 	if err != nil {
 		return EngineInfo{}, fmt.Errorf("failed to obtain docker version: %w", err)
 	}
@@ -193,10 +436,27 @@ func (c *DockerEngine) Info(ctx context.Context) (EngineInfo, error) {
 	return EngineInfo{}, fmt.Errorf("failed to obtain docker version, unknown version format: %s", parsed)
 }
 
+// Workarounds needed for the installed Docker version.
+func (c *DockerEngine) getQuirks(ctx context.Context) engineQuirks {
+	c.quirksOnce.Do(func() {
+		info, _ := c.Info(ctx)
+		c.quirks = quirksFor("docker", info.Version)
+	})
+	return c.quirks
+}
+
+// Rely on `version` being the installed version, e.g. when already known.
+func (c *DockerEngine) setVersion(version string) {
+	c.quirksOnce.Do(func() {
+		c.quirks = quirksFor("docker", version)
+	})
+}
+
 func (c *DockerEngine) CreateVolume(ctx context.Context, name string) error {
-	cmd := exec.CommandContext(ctx, "docker", "volume", "create", name)
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	defer profiling.Track(profiling.CategoryEngine, "docker CreateVolume")()
+	cmd := engineCommand(ctx, "docker", volumeCreateArgs(name)...)
+	cmd.Stderr = engineOutput
+	if err := runEngineCommand(cmd); err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return pErr
 		}
@@ -206,28 +466,34 @@ func (c *DockerEngine) CreateVolume(ctx context.Context, name string) error {
 }
 
 func (c *DockerEngine) GetImageInfo(ctx context.Context, projectName string) (*ImageInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker GetImageInfo")()
 	imageName := projectImageName(projectName)
 	info := &ImageInfo{ImageName: imageName, ProjectName: &projectName}
 
-	cmd := exec.CommandContext(ctx, "docker", "image", "inspect", imageName, "--format", "{{.Created}}")
-	output, err := cmd.Output()
+	cmd := engineCommand(ctx, "docker", "image", "inspect", imageName, "--format", imageInspectFormat)
+	output, err := engineCommandOutput(cmd)
 	if err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return nil, pErr
-		} else if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		} else if c.getQuirks(ctx).isMissingImage(err) {
 			return info, nil
 		}
 		return nil, err
 	}
-	if buildTime, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(output))); err == nil {
-		info.BuiltAt = &buildTime
+	created, architecture, labels := parseImageInspect(string(output))
+	if buildTime := c.getQuirks(ctx).parseCreatedAt(created); buildTime != nil {
+		info.BuiltAt = buildTime
 	}
+	info.Architecture = architecture
+	info.ConfigHash = labels[configHashLabel]
+	info.Provenance = parseProvenance(labels)
 	return info, nil
 }
 
 func (c *DockerEngine) ListContainers(ctx context.Context) ([]ContainerInfo, error) {
-	cmd := exec.CommandContext(ctx, "docker", "ps", "-a", "--filter", "name=paulenv-", "--format", "{{.ID}}\t{{.Image}}\t{{.Names}}")
-	output, err := cmd.Output()
+	defer profiling.Track(profiling.CategoryEngine, "docker ListContainers")()
+	cmd := engineCommand(ctx, "docker", "ps", "-a", "--no-trunc", "--format", "{{.ID}}\t{{.Image}}\t{{.Names}}\t{{.State}}\t{{.Label \""+projectLabel+"\"}}\t{{.Label \""+instanceLabel+"\"}}\t{{.Label \""+sourceLabel+"\"}}")
+	output, err := engineCommandOutput(cmd)
 	if err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return []ContainerInfo{}, pErr
@@ -235,47 +501,45 @@ func (c *DockerEngine) ListContainers(ctx context.Context) ([]ContainerInfo, err
 		return []ContainerInfo{}, fmt.Errorf("failed to list containers: %w", err)
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	result := make([]ContainerInfo, 0, len(lines))
-	for _, s := range lines {
-		if s == "" {
-			continue
-		}
-		parts := strings.SplitN(s, "\t", 3)
-		id := parts[0]
-		var image *string
-		var name *string
-		var projectName *string
+	return parseContainerList(string(output)), nil
+}
 
-		if len(parts) > 1 && parts[1] != "" {
-			image = &parts[1]
-		}
-		if len(parts) > 2 && parts[2] != "" {
-			name = &parts[2]
-		}
-		if image != nil {
-			projectName = projectNameFromImage(*image)
+func (c *DockerEngine) ListNamedResources(ctx context.Context, names ResourceNames) ([]NamedResource, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker ListNamedResources")()
+	var resources []NamedResource
+	if len(names.Containers) > 0 {
+		cmd := engineCommand(ctx, "docker", namedContainerListArgs(names.Containers)...)
+		output, err := engineCommandOutput(cmd)
+		if err != nil {
+			if pErr := c.checkPermissions(ctx); pErr != nil {
+				return nil, pErr
+			}
+			return nil, fmt.Errorf("failed to list containers: %w", err)
 		}
-		if projectName == nil && name != nil {
-			projectName = projectNameFromContainerName(*name)
+		resources = append(resources, parseNamedContainerList(string(output), names.Containers)...)
+	}
+	for _, kind := range []string{"volume", "network"} {
+		kindNames := names.Volumes
+		if kind == "network" {
+			kindNames = names.Networks
 		}
-		if projectName == nil {
+		if len(kindNames) == 0 {
 			continue
 		}
-
-		result = append(result, ContainerInfo{
-			ProjectName:   projectName,
-			ContainerName: name,
-			ContainerId:   id,
-			ImageName:     image,
-		})
+		cmd := engineCommand(ctx, "docker", namedResourceListArgs(kind, kindNames)...)
+		output, err := engineCommandOutput(cmd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %ss: %w", kind, err)
+		}
+		resources = append(resources, parseNamedResourceList(kind, string(output), kindNames)...)
 	}
-	return result, nil
+	return resources, nil
 }
 
 func (c *DockerEngine) RemoveContainer(ctx context.Context, container ContainerInfo) error {
-	cmd := exec.CommandContext(ctx, "docker", "rm", "-f", container.ContainerId)
-	if err := cmd.Run(); err != nil {
+	defer profiling.Track(profiling.CategoryEngine, "docker RemoveContainer")()
+	cmd := engineCommand(ctx, "docker", "rm", "-f", "-v", container.ContainerId)
+	if err := runEngineCommand(cmd); err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return pErr
 		}
@@ -284,50 +548,115 @@ func (c *DockerEngine) RemoveContainer(ctx context.Context, container ContainerI
 	return nil
 }
 
+func (c *DockerEngine) StopContainer(ctx context.Context, container ContainerInfo) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker StopContainer")()
+	cmd := engineCommand(ctx, "docker", "stop", container.ContainerId)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to stop container %s: %w", container.ContainerId, err)
+	}
+	return nil
+}
+
 func (c *DockerEngine) checkPermissions(ctx context.Context) error {
-	cmd := exec.CommandContext(ctx, "docker", "ps")
+	cmd := engineCommand(ctx, "docker", "ps")
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
-	if err := cmd.Run(); err != nil {
-		stderrStr := stderr.String()
-		if strings.Contains(stderrStr, "permission denied") ||
-			strings.Contains(stderrStr, "access denied") ||
-			strings.Contains(stderrStr, "dial unix") && strings.Contains(stderrStr, "connect: permission denied") {
-			return errors.New("permission denied. Please run with elevated privileges")
-		}
-		return fmt.Errorf("failed to connect to Docker: %w\n%s", err, stderrStr)
+	err := runEngineCommand(cmd)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrPermission):
+		return utils.WithCategory(fmt.Errorf("permission denied while connecting to the Docker daemon: %s\n"+
+			"Run 'paul-envs doctor' to diagnose the setup", strings.TrimSpace(stderr.String())), ErrPermission)
+	case errors.Is(err, ErrEngineUnavailable):
+		return utils.WithCategory(fmt.Errorf("failed to connect to Docker: %s\n"+
+			"Run 'paul-envs doctor' to diagnose the setup", strings.TrimSpace(stderr.String())), ErrEngineUnavailable)
 	}
-	return nil
+	return fmt.Errorf("failed to connect to Docker: %w\n%s\nRun 'paul-envs doctor' to diagnose the setup", err, stderr.String())
 }
 
 func (c *DockerEngine) ListVolumes(ctx context.Context) ([]VolumeInfo, error) {
-	cmd := exec.CommandContext(ctx, "docker", "volume", "ls", "--filter", "name=paulenv-", "--format", "{{.Name}}")
-	output, err := cmd.Output()
+	defer profiling.Track(profiling.CategoryEngine, "docker ListVolumes")()
+	cmd := engineCommand(ctx, "docker", "volume", "ls", "--format", "{{.Name}}\t{{.Label \""+managedLabel+"\"}}")
+	output, err := engineCommandOutput(cmd)
 	if err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return []VolumeInfo{}, pErr
 		}
 		return []VolumeInfo{}, fmt.Errorf("failed to list volumes: %w", err)
 	}
+	return parseVolumeList(string(output)), nil
+}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	result := make([]VolumeInfo, 0, len(lines))
-	for _, volumeName := range lines {
-		if volumeName == "" || !isPaulEnvVolume(volumeName) {
-			continue
+func (c *DockerEngine) GetDiskUsage(ctx context.Context) (DiskUsage, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker GetDiskUsage")()
+	cmd := engineCommand(ctx, "docker", "system", "df", "-v", "--format", "{{json .}}")
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return DiskUsage{}, pErr
 		}
-		result = append(result, VolumeInfo{
-			VolumeId:   volumeName,
-			VolumeName: volumeName,
-		})
+		return DiskUsage{}, fmt.Errorf("failed to obtain disk usage: %w", err)
 	}
-	return result, nil
+	return parseDockerDiskUsage(output)
+}
+
+func (c *DockerEngine) GetImageHistory(ctx context.Context, projectName string) ([]ImageLayer, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker GetImageHistory")()
+	cmd := engineCommand(ctx, "docker", imageHistoryArgs(projectImageName(projectName))...)
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return nil, pErr
+		}
+		return nil, fmt.Errorf("failed to list the layers of the image of project '%s': %w", projectName, err)
+	}
+	layers := parseImageHistory(string(output))
+	// Without it, no layer is reported as coming from it
+	cmd = engineCommand(ctx, "docker", imageHistoryArgs(baseImageName)...)
+	if output, err := engineCommandOutput(cmd); err == nil {
+		markBaseImageLayers(layers, parseImageHistory(string(output)))
+	}
+	return layers, nil
+}
+
+func (c *DockerEngine) ExportVolume(ctx context.Context, name string, w io.Writer) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker ExportVolume")()
+	cmd := engineCommand(ctx, "docker", volumeTarArgs(name, true)...)
+	cmd.Stdout = w
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return volumeTarError("export", name, err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) ImportVolume(ctx context.Context, name string, r io.Reader) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker ImportVolume")()
+	if err := c.ensureVolumesExist(ctx, name); err != nil {
+		return err
+	}
+	cmd := engineCommand(ctx, "docker", volumeTarArgs(name, false)...)
+	cmd.Stdin = r
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return volumeTarError("import", name, err)
+	}
+	return nil
 }
 
 func (c *DockerEngine) RemoveVolume(ctx context.Context, volume VolumeInfo) error {
-	cmd := exec.CommandContext(ctx, "docker", "volume", "rm", volume.VolumeName)
-	if err := cmd.Run(); err != nil {
+	defer profiling.Track(profiling.CategoryEngine, "docker RemoveVolume")()
+	cmd := engineCommand(ctx, "docker", "volume", "rm", volume.VolumeName)
+	if err := runEngineCommand(cmd); err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return pErr
 		}
@@ -337,8 +666,10 @@ func (c *DockerEngine) RemoveVolume(ctx context.Context, volume VolumeInfo) erro
 }
 
 func (c *DockerEngine) ListNetworks(ctx context.Context) ([]NetworkInfo, error) {
-	cmd := exec.CommandContext(ctx, "docker", "network", "ls", "--filter", "name=paulenv-", "--format", "{{.ID}}\t{{.Name}}")
-	output, err := cmd.Output()
+	defer profiling.Track(profiling.CategoryEngine, "docker ListNetworks")()
+	cmd := engineCommand(ctx, "docker", "network", "ls", "--format",
+		"{{.ID}}\t{{.Name}}\t{{.Label \""+managedLabel+"\"}}\t{{.Label \""+projectLabel+"\"}}")
+	output, err := engineCommandOutput(cmd)
 	if err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return []NetworkInfo{}, pErr
@@ -346,29 +677,13 @@ func (c *DockerEngine) ListNetworks(ctx context.Context) ([]NetworkInfo, error)
 		return []NetworkInfo{}, fmt.Errorf("failed to list networks: %w", err)
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	result := make([]NetworkInfo, 0, len(lines))
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-		parts := strings.SplitN(line, "\t", 2)
-		if len(parts) < 2 {
-			continue
-		}
-		projectName := projectNameFromContainerName(parts[1])
-		result = append(result, NetworkInfo{
-			NetworkId:   parts[0],
-			NetworkName: parts[1],
-			ProjectName: projectName,
-		})
-	}
-	return result, nil
+	return parseNetworkList(string(output)), nil
 }
 
 func (c *DockerEngine) RemoveNetwork(ctx context.Context, network NetworkInfo) error {
-	cmd := exec.CommandContext(ctx, "docker", "network", "rm", network.NetworkId)
-	if err := cmd.Run(); err != nil {
+	defer profiling.Track(profiling.CategoryEngine, "docker RemoveNetwork")()
+	cmd := engineCommand(ctx, "docker", "network", "rm", network.NetworkId)
+	if err := runEngineCommand(cmd); err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return pErr
 		}
@@ -378,8 +693,9 @@ func (c *DockerEngine) RemoveNetwork(ctx context.Context, network NetworkInfo) e
 }
 
 func (c *DockerEngine) PruneBuildCache(ctx context.Context) error {
-	cmd := exec.CommandContext(ctx, "docker", "builder", "prune", "-f", "--filter", "label=paulenv=true")
-	if err := cmd.Run(); err != nil {
+	defer profiling.Track(profiling.CategoryEngine, "docker PruneBuildCache")()
+	cmd := engineCommand(ctx, "docker", "builder", "prune", "-f", "--filter", "label=paulenv=true")
+	if err := runEngineCommand(cmd); err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return pErr
 		}
@@ -388,13 +704,10 @@ func (c *DockerEngine) PruneBuildCache(ctx context.Context) error {
 	return nil
 }
 
-func (c *DockerEngine) SupportsBuildCachePrune() bool {
-	return true
-}
-
 func (c *DockerEngine) ListImages(ctx context.Context) ([]ImageInfo, error) {
-	cmd := exec.CommandContext(ctx, "docker", "images", "--filter", "reference=paulenv:*", "--format", "{{.Repository}}:{{.Tag}}\t{{.CreatedAt}}")
-	output, err := cmd.Output()
+	defer profiling.Track(profiling.CategoryEngine, "docker ListImages")()
+	cmd := engineCommand(ctx, "docker", "images", "--filter", "reference=paulenv:*", "--filter", "reference=paulenv-base:*", "--format", "{{.Repository}}:{{.Tag}}\t{{.CreatedAt}}\t{{.Size}}")
+	output, err := engineCommandOutput(cmd)
 	if err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return []ImageInfo{}, pErr
@@ -408,30 +721,212 @@ func (c *DockerEngine) ListImages(ctx context.Context) ([]ImageInfo, error) {
 		if line == "" {
 			continue
 		}
-		parts := strings.SplitN(line, "\t", 2)
+		parts := strings.SplitN(line, "\t", 3)
 		imageName := parts[0]
 		projectName := projectNameFromImage(imageName)
-		if projectName == nil {
+		if projectName == nil && !isBaseImage(imageName) {
 			continue
 		}
 
 		var builtAt *time.Time
 		if len(parts) > 1 {
-			builtAt = parseCreatedAt(parts[1])
+			builtAt = c.getQuirks(ctx).parseCreatedAt(parts[1])
+		}
+		size := ""
+		if len(parts) > 2 {
+			size = strings.TrimSpace(parts[2])
 		}
 
 		result = append(result, ImageInfo{
 			ImageName:   imageName,
 			ProjectName: projectName,
 			BuiltAt:     builtAt,
+			Size:        size,
 		})
 	}
 	return result, nil
 }
 
+func (c *DockerEngine) CommitContainer(ctx context.Context, container ContainerInfo, projectName string, tag string) (SnapshotInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker CommitContainer")()
+	imageName := snapshotImageName(projectName, tag)
+	args := append([]string{"commit"}, snapshotCommitChanges...)
+	cmd := engineCommand(ctx, "docker", append(args, container.ContainerId, imageName)...)
+	cmd.Stderr = engineOutput
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return SnapshotInfo{}, pErr
+		}
+		return SnapshotInfo{}, fmt.Errorf("failed to commit container %s: %w", container.ContainerId, err)
+	}
+	now := time.Now()
+	return SnapshotInfo{ProjectName: projectName, Tag: tag, ImageName: imageName, CreatedAt: &now}, nil
+}
+
+func (c *DockerEngine) ListSnapshots(ctx context.Context) ([]SnapshotInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker ListSnapshots")()
+	cmd := engineCommand(ctx, "docker", "images", "--filter", "reference=paulenv-snapshot:*", "--format", "{{.Repository}}:{{.Tag}}\t{{.CreatedAt}}\t{{.Size}}")
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return nil, pErr
+		}
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	return parseSnapshotList(string(output), c.getQuirks(ctx)), nil
+}
+
+func (c *DockerEngine) RestoreSnapshot(ctx context.Context, snapshot SnapshotInfo) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker RestoreSnapshot")()
+	cmd := engineCommand(ctx, "docker", "tag", snapshot.ImageName, projectImageName(snapshot.ProjectName))
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to restore snapshot %s: %w", snapshot.ImageName, err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) RemoveSnapshot(ctx context.Context, snapshot SnapshotInfo) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker RemoveSnapshot")()
+	cmd := engineCommand(ctx, "docker", "rmi", snapshot.ImageName)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to remove snapshot %s: %w", snapshot.ImageName, err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) SaveGeneration(ctx context.Context, projectName string, number int) (GenerationInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker SaveGeneration")()
+	imageName := generationImageName(projectName, number)
+	cmd := engineCommand(ctx, "docker", "tag", projectImageName(projectName), imageName)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return GenerationInfo{}, pErr
+		}
+		return GenerationInfo{}, fmt.Errorf("failed to keep the image of project %s: %w", projectName, err)
+	}
+	return GenerationInfo{ProjectName: projectName, Number: number, ImageName: imageName}, nil
+}
+
+func (c *DockerEngine) ListGenerations(ctx context.Context) ([]GenerationInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker ListGenerations")()
+	cmd := engineCommand(ctx, "docker", "images", "--filter", "reference=paulenv-generation:*", "--format", "{{.Repository}}:{{.Tag}}\t{{.CreatedAt}}\t{{.Size}}")
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return nil, pErr
+		}
+		return nil, fmt.Errorf("failed to list image generations: %w", err)
+	}
+	return parseGenerationList(string(output), c.getQuirks(ctx)), nil
+}
+
+func (c *DockerEngine) RestoreGeneration(ctx context.Context, generation GenerationInfo) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker RestoreGeneration")()
+	cmd := engineCommand(ctx, "docker", "tag", generation.ImageName, projectImageName(generation.ProjectName))
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to restore image %s: %w", generation.ImageName, err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) RemoveGeneration(ctx context.Context, generation GenerationInfo) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker RemoveGeneration")()
+	cmd := engineCommand(ctx, "docker", "rmi", generation.ImageName)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to remove image %s: %w", generation.ImageName, err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) SaveArchImage(ctx context.Context, projectName string) (ArchImageInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker SaveArchImage")()
+	cmd := engineCommand(ctx, "docker", "image", "inspect", projectImageName(projectName), "--format", "{{.Architecture}}")
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return ArchImageInfo{}, pErr
+		}
+		return ArchImageInfo{}, fmt.Errorf("failed to get the architecture of the image of project %s: %w", projectName, err)
+	}
+	architecture := strings.TrimSpace(string(output))
+	imageName := archImageName(projectName, architecture)
+	cmd = engineCommand(ctx, "docker", "tag", projectImageName(projectName), imageName)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return ArchImageInfo{}, pErr
+		}
+		return ArchImageInfo{}, fmt.Errorf("failed to tag the %s image of project %s: %w", architecture, projectName, err)
+	}
+	return ArchImageInfo{ProjectName: projectName, Architecture: architecture, ImageName: imageName}, nil
+}
+
+func (c *DockerEngine) ListArchImages(ctx context.Context) ([]ArchImageInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker ListArchImages")()
+	cmd := engineCommand(ctx, "docker", "images", "--filter", "reference=paulenv-arch:*", "--format", "{{.Repository}}:{{.Tag}}\t{{.CreatedAt}}\t{{.Size}}")
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return nil, pErr
+		}
+		return nil, fmt.Errorf("failed to list architecture-specific images: %w", err)
+	}
+	return parseArchImageList(string(output), c.getQuirks(ctx)), nil
+}
+
+func (c *DockerEngine) UseArchImage(ctx context.Context, image ArchImageInfo) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker UseArchImage")()
+	cmd := engineCommand(ctx, "docker", "tag", image.ImageName, projectImageName(image.ProjectName))
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to use image %s: %w", image.ImageName, err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) RemoveArchImage(ctx context.Context, image ArchImageInfo) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker RemoveArchImage")()
+	cmd := engineCommand(ctx, "docker", "rmi", image.ImageName)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to remove image %s: %w", image.ImageName, err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) EnableEmulation(ctx context.Context, architectures []string) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker EnableEmulation")()
+	cmd := engineCommand(ctx, "docker", enableEmulationArgs(architectures)...)
+	cmd.Stdout = engineOutput
+	cmd.Stderr = engineOutput
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to register emulators: %w", err)
+	}
+	return nil
+}
+
 func (c *DockerEngine) RemoveImage(ctx context.Context, image ImageInfo) error {
-	cmd := exec.CommandContext(ctx, "docker", "rmi", "-f", image.ImageName)
-	if err := cmd.Run(); err != nil {
+	defer profiling.Track(profiling.CategoryEngine, "docker RemoveImage")()
+	cmd := engineCommand(ctx, "docker", "rmi", "-f", image.ImageName)
+	if err := runEngineCommand(cmd); err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return pErr
 		}
@@ -440,6 +935,280 @@ func (c *DockerEngine) RemoveImage(ctx context.Context, image ImageInfo) error {
 	return nil
 }
 
+func (c *DockerEngine) ExportImage(ctx context.Context, projectName string, w io.Writer) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker ExportImage")()
+	cmd := engineCommand(ctx, "docker", "image", "save", projectImageName(projectName))
+	cmd.Stdout = w
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to export the image of project %s: %w", projectName, err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) ImportImage(ctx context.Context, projectName string, r io.Reader) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker ImportImage")()
+	cmd := engineCommand(ctx, "docker", "image", "load")
+	cmd.Stdin = r
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to import the image of project %s: %w", projectName, err)
+	}
+	loaded := parseLoadedImage(string(output))
+	if loaded == "" {
+		return fmt.Errorf("failed to import the image of project %s: no image was loaded", projectName)
+	}
+	cmd = engineCommand(ctx, "docker", "tag", loaded, projectImageName(projectName))
+	if err := runEngineCommand(cmd); err != nil {
+		return fmt.Errorf("failed to tag the imported image of project %s: %w", projectName, err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) PushImage(ctx context.Context, projectName string, reference string) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker PushImage")()
+	if offline {
+		return offlineError("push", reference)
+	}
+	// `docker push` only pushes an image under its own name
+	cmd := engineCommand(ctx, "docker", "tag", projectImageName(projectName), reference)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to tag the image of project %s as %s: %w", projectName, reference, err)
+	}
+	defer func() {
+		// Only removes that tag
+		_ = runEngineCommand(engineCommand(context.WithoutCancel(ctx), "docker", "rmi", reference))
+	}()
+	registryEnv, err := dockerRegistryEnv(ctx)
+	if err != nil {
+		return err
+	}
+	cmd = engineCommand(ctx, "docker", "push", reference)
+	withEnv(cmd, registryEnv)
+	cmd.Stdout, cmd.Stderr = engineOutput, engineOutput
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return registryError("push", reference, "docker", err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) PullImage(ctx context.Context, projectName string, reference string) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker PullImage")()
+	if offline {
+		return offlineError("pull", reference)
+	}
+	registryEnv, err := dockerRegistryEnv(ctx)
+	if err != nil {
+		return err
+	}
+	cmd := engineCommand(ctx, "docker", "pull", reference)
+	withEnv(cmd, registryEnv)
+	cmd.Stdout, cmd.Stderr = engineOutput, engineOutput
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return registryError("pull", reference, "docker", err)
+	}
+	cmd = engineCommand(ctx, "docker", "tag", reference, projectImageName(projectName))
+	if err := runEngineCommand(cmd); err != nil {
+		return fmt.Errorf("failed to tag the pulled image of project %s: %w", projectName, err)
+	}
+	// Only removes the pulled reference, which stays the project's image
+	_ = runEngineCommand(engineCommand(ctx, "docker", "rmi", reference))
+	return nil
+}
+
+func (c *DockerEngine) ImageDigest(ctx context.Context, image string, pull bool) (string, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker ImageDigest")()
+	if pull {
+		if offline {
+			return "", offlineError("pull", image)
+		}
+		registryEnv, err := dockerRegistryEnv(ctx)
+		if err != nil {
+			return "", err
+		}
+		cmd := engineCommand(ctx, "docker", "pull", image)
+		withEnv(cmd, registryEnv)
+		cmd.Stdout, cmd.Stderr = engineOutput, engineOutput
+		if err := runEngineCommand(cmd); err != nil {
+			if pErr := c.checkPermissions(ctx); pErr != nil {
+				return "", pErr
+			}
+			return "", registryError("pull", image, "docker", err)
+		}
+	}
+	cmd := engineCommand(ctx, "docker", "image", "inspect", "--format", "{{json .RepoDigests}}", image)
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return "", pErr
+		}
+		return "", fmt.Errorf("failed to inspect image %s: %w", image, err)
+	}
+	return parseRepoDigests(output)
+}
+
+func (c *DockerEngine) TagImage(ctx context.Context, image string, target string) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker TagImage")()
+	cmd := engineCommand(ctx, "docker", "tag", image, target)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to tag image %s as %s: %w", image, target, err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) UntagImage(ctx context.Context, image string) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker UntagImage")()
+	cmd := engineCommand(ctx, "docker", "rmi", image)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to untag image %s: %w", image, err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) StartSidecar(ctx context.Context, projectName string, service config.Service, sharedNetwork bool) (SidecarInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker StartSidecar")()
+	network := sidecarNetworkName(projectName, sharedNetwork)
+	if err := c.ensureNetworkExists(ctx, network); err != nil {
+		return SidecarInfo{}, err
+	}
+	if service.DataPath != "" {
+		if err := c.ensureVolumesExist(ctx, sidecarDataVolumeName(projectName, service.Name)); err != nil {
+			return SidecarInfo{}, err
+		}
+	}
+	cmd := engineCommand(ctx, "docker", sidecarRunArgs(projectName, service, network)...)
+	cmd.Stderr = engineOutput
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return SidecarInfo{}, pErr
+		}
+		return SidecarInfo{}, fmt.Errorf("failed to start service %s: %w", service.Name, err)
+	}
+	return SidecarInfo{
+		ProjectName:   projectName,
+		ServiceName:   service.Name,
+		ContainerName: sidecarContainerName(projectName, service.Name),
+		ContainerId:   strings.TrimSpace(string(output)),
+		Running:       true,
+	}, nil
+}
+
+func (c *DockerEngine) ListSidecars(ctx context.Context) ([]SidecarInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker ListSidecars")()
+	cmd := engineCommand(ctx, "docker", "ps", "-a", "--no-trunc", "--format", "{{.ID}}\t{{.Names}}\t{{.State}}\t{{.Label \""+projectLabel+"\"}}\t{{.Label \""+serviceLabel+"\"}}")
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return nil, pErr
+		}
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	return parseSidecarList(string(output)), nil
+}
+
+func (c *DockerEngine) JoinSidecar(ctx context.Context, sidecar SidecarInfo, args []string) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker JoinSidecar")()
+	cmd := engineCommand(ctx, "docker", sidecarExecArgs(sidecar, stdinIsTerminal(), args)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("join exited: %w", err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) CheckSidecarHealth(ctx context.Context, sidecar SidecarInfo, command string) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker CheckSidecarHealth")()
+	cmd := engineCommand(ctx, "docker", sidecarExecArgs(sidecar, false, []string{"sh", "-c", command})...)
+	if err := runEngineCommand(cmd); err != nil {
+		return fmt.Errorf("healthcheck of service %s failed: %w", sidecar.ServiceName, err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) GetContainerStats(ctx context.Context) ([]ContainerStats, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker GetContainerStats")()
+	containers, err := c.ListContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sidecars, err := c.ListSidecars(ctx)
+	if err != nil {
+		return nil, err
+	}
+	targets := newStatsTargets(containers, sidecars)
+	if len(targets) == 0 {
+		return nil, nil
+	}
+	cmd := engineCommand(ctx, "docker", targets.statsArgs()...)
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return nil, pErr
+		}
+		return nil, fmt.Errorf("failed to get container stats: %w", err)
+	}
+	return targets.parse(string(output)), nil
+}
+
+func (c *DockerEngine) RemoveSidecar(ctx context.Context, sidecar SidecarInfo) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker RemoveSidecar")()
+	cmd := engineCommand(ctx, "docker", "rm", "-f", "-v", sidecar.ContainerId)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to remove service container %s: %w", sidecar.ContainerName, err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) ensureNetworkExists(ctx context.Context, name string) error {
+	networks, err := c.ListNetworks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list created networks: %w", err)
+	}
+	for _, network := range networks {
+		if network.NetworkName == name {
+			return nil
+		}
+	}
+	cmd := engineCommand(ctx, "docker", networkCreateArgs(name)...)
+	cmd.Stderr = engineOutput
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to create network %s: %w", name, err)
+	}
+	return nil
+}
+
 func (c *DockerEngine) ensureVolumesExist(ctx context.Context, names ...string) error {
 	volumes, err := c.ListVolumes(ctx)
 	if err != nil {
@@ -467,7 +1236,8 @@ func projectNameFromContainerName(containerName string) *string {
 		return nil
 	}
 	projectName := strings.TrimPrefix(containerName, "paulenv-")
-	if projectName == "" {
+	// Sidecars' names contain their service name after a dot
+	if projectName == "" || strings.Contains(projectName, ".") {
 		return nil
 	}
 	return &projectName
@@ -0,0 +1,89 @@
+// # dry_run.go
+// In dry-run mode (the global `--dry-run` flag), engine calls changing
+// anything (builds, runs, removals...) are only displayed, with their exact
+// command line, instead of being run. Calls only reading the engine's state
+// are still made, so commands can go as far as they would otherwise.
+
+package engine
+
+import (
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+var dryRun bool
+
+// Enable or disable the dry-run mode.
+func SetDryRun(enabled bool) {
+	dryRun = enabled
+}
+
+// Returns `true` if engine calls changing anything should only be displayed.
+func IsDryRun() bool {
+	return dryRun
+}
+
+// Starts of the arguments of engine calls which only read the engine's
+// state, and are thus still made in dry-run mode.
+var readOnlyEngineCalls = [][]string{
+	{"--version"},
+	{"version"},
+	{"info"},
+	{"ps"},
+	{"images"},
+	{"inspect"},
+	{"image", "inspect"},
+	{"image", "exists"},
+	{"image", "ls"},
+	{"container", "inspect"},
+	{"container", "exists"},
+	{"volume", "ls"},
+	{"volume", "inspect"},
+	{"volume", "exists"},
+	{"network", "ls"},
+	{"network", "inspect"},
+	{"network", "exists"},
+	{"machine", "list"},
+	{"machine", "inspect"},
+	{"system", "df"},
+	{"buildx", "version"},
+	{"stats"},
+	{"history"},
+	{"logs"},
+	{"wait"},
+	{"events"},
+	// Engine plugins, see `plugin.go`
+	{"has-base-image"},
+	{"has-been-built"},
+	{"get-image-info"},
+	{"get-container-stats"},
+	{"get-disk-usage"},
+	{"inspect-volume"},
+	{"inspect-toolbox"},
+	{"get-image-history"},
+	{"wait-container"},
+	{"get-container-start-time"},
+	{"stream-events"},
+}
+
+// Returns `true` if the engine call with the given arguments changes nothing.
+func isReadOnlyEngineCall(args []string) bool {
+	if len(args) > 0 && strings.HasPrefix(args[0], "list-") {
+		return true
+	}
+	return slices.ContainsFunc(readOnlyEngineCalls, func(call []string) bool {
+		return len(args) >= len(call) && slices.Equal(args[:len(call)], call)
+	})
+}
+
+// Returns `true`, after displaying it, if `cmd` should not be run because of
+// the dry-run mode.
+func skipInDryRun(cmd *exec.Cmd) bool {
+	if !dryRun || isReadOnlyEngineCall(cmd.Args[1:]) {
+		return false
+	}
+	fmt.Fprintf(engineOutput, "[dry-run] would run: %s\n", commandLine(cmd))
+	return true
+}
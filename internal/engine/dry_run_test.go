@@ -0,0 +1,45 @@
+package engine
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on touch")
+	}
+	var output bytes.Buffer
+	prevOutput := engineOutput
+	t.Cleanup(func() {
+		SetDryRun(false)
+		engineOutput = prevOutput
+	})
+	SetOutput(&output)
+	SetDryRun(true)
+
+	marker := filepath.Join(t.TempDir(), "created")
+	if err := runEngineCommand(exec.Command("touch", marker)); err != nil {
+		t.Fatalf("runEngineCommand() error = %v", err)
+	}
+	if fileExists(marker) {
+		t.Fatalf("runEngineCommand() should not run commands in dry-run mode")
+	}
+	if want := "[dry-run] would run: touch " + marker + "\n"; output.String() != want {
+		t.Fatalf("dry-run output = %q, want %q", output.String(), want)
+	}
+
+	for _, args := range [][]string{{"ps", "-a"}, {"image", "inspect", "x"}, {"list-containers"}} {
+		if !isReadOnlyEngineCall(args) {
+			t.Errorf("isReadOnlyEngineCall(%v) = false, want true", args)
+		}
+	}
+	for _, args := range [][]string{{"run", "--rm", "x"}, {"image", "rm", "x"}, {"volume", "create", "v"}, {"remove-image"}} {
+		if isReadOnlyEngineCall(args) {
+			t.Errorf("isReadOnlyEngineCall(%v) = true, want false", args)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// # emulation.go
+// Images built for another architecture than the host's can only run if the
+// host kernel hands their binaries to an emulator (QEMU in user mode), which is
+// registered through `binfmt_misc`.
+//
+// Docker Desktop and Podman machines do it in their virtual machine, but on a
+// Linux host it has to be set up once, which can be done through the container
+// engine itself with an image registering statically-linked QEMU emulators.
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Image registering QEMU emulators through `binfmt_misc` when run privileged.
+const binfmtImage = "docker.io/tonistiigi/binfmt:latest"
+
+// Directory where `binfmt_misc` registrations are listed.
+var binfmtMiscDir = "/proc/sys/fs/binfmt_misc"
+
+// Name of the QEMU user-mode emulator of architectures known by both Go and
+// container engines.
+var qemuArchitectures = map[string]string{
+	"amd64":    "x86_64",
+	"arm64":    "aarch64",
+	"arm":      "arm",
+	"386":      "i386",
+	"riscv64":  "riscv64",
+	"ppc64le":  "ppc64le",
+	"s390x":    "s390x",
+	"mips64le": "mips64el",
+	"loong64":  "loongarch64",
+}
+
+// Architectures other than the host's for which an emulator is the most
+// commonly needed, when none is explicitly wanted.
+func DefaultEmulatedArchitectures() []string {
+	var architectures []string
+	for _, architecture := range []string{"amd64", "arm64"} {
+		if architecture != runtime.GOARCH {
+			architectures = append(architectures, architecture)
+		}
+	}
+	return architectures
+}
+
+// Check that the given architecture, as named by Go and container engines
+// (e.g. "arm64"), can be emulated.
+func ValidateEmulatedArchitecture(architecture string) error {
+	if _, ok := qemuArchitectures[architecture]; !ok {
+		known := make([]string, 0, len(qemuArchitectures))
+		for name := range qemuArchitectures {
+			known = append(known, name)
+		}
+		sort.Strings(known)
+		return fmt.Errorf("cannot emulate architecture %q, expected one of: %s", architecture, strings.Join(known, ", "))
+	}
+	return nil
+}
+
+// Returns `true` if binaries of the given architecture (e.g. "arm64") are
+// run through an emulator on this host, or natively.
+//
+// Returns an `errors.ErrUnsupported` error when this cannot be known from the
+// host, e.g. when the engine runs in a virtual machine.
+func IsEmulationEnabled(architecture string) (bool, error) {
+	if architecture == runtime.GOARCH {
+		return true, nil
+	}
+	if runtime.GOOS != "linux" {
+		return false, errors.ErrUnsupported
+	}
+	qemuName, ok := qemuArchitectures[architecture]
+	if !ok {
+		return false, nil
+	}
+	if _, err := os.Stat(filepath.Join(binfmtMiscDir, "status")); err != nil {
+		return false, fmt.Errorf("binfmt_misc is not available: %w", err)
+	}
+	data, err := os.ReadFile(filepath.Join(binfmtMiscDir, "qemu-"+qemuName))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("cannot read binfmt_misc registration: %w", err)
+	}
+	return isBinfmtEnabled(string(data)), nil
+}
+
+// Parse a `binfmt_misc` registration file, whose first line tells if it is
+// enabled.
+func isBinfmtEnabled(registration string) bool {
+	first, _, _ := strings.Cut(registration, "\n")
+	return strings.TrimSpace(first) == "enabled"
+}
+
+// Arguments registering emulators for the given architectures through the
+// container engine.
+func enableEmulationArgs(architectures []string) []string {
+	return []string{"run", "--rm", "--privileged", binfmtImage, "--install", strings.Join(architectures, ",")}
+}
+
+// Check the `binfmt_misc` registrations of this host for the diagnostics of
+// the `doctor` command.
+func DiagnoseEmulation() []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, architecture := range DefaultEmulatedArchitectures() {
+		diagnostic := Diagnostic{Name: architecture + " emulation"}
+		enabled, err := IsEmulationEnabled(architecture)
+		switch {
+		case errors.Is(err, errors.ErrUnsupported):
+			diagnostic.Status = DiagnosticSkipped
+			diagnostic.Detail = "handled by the container engine's virtual machine"
+		case err != nil:
+			diagnostic.Status = DiagnosticWarning
+			diagnostic.Detail = err.Error()
+			diagnostic.Fix = "Mount it with 'sudo mount -t binfmt_misc binfmt_misc " + binfmtMiscDir + "', then run 'paul-envs enable-emulation'."
+		case enabled:
+			diagnostic.Status = DiagnosticOk
+		default:
+			// Only needed to run images of other architectures, which most
+			// users never do
+			diagnostic.Status = DiagnosticSkipped
+			diagnostic.Detail = fmt.Sprintf("not set up, %s images cannot run on this host", architecture)
+			diagnostic.Fix = fmt.Sprintf("Run 'paul-envs enable-emulation %s' if you need to.", architecture)
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	return diagnostics
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestIsEmulationEnabled(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("binfmt_misc registrations are only checked on Linux")
+	}
+	dir := t.TempDir()
+	previous := binfmtMiscDir
+	binfmtMiscDir = dir
+	t.Cleanup(func() { binfmtMiscDir = previous })
+
+	foreign := "riscv64"
+	if runtime.GOARCH == foreign {
+		foreign = "s390x"
+	}
+	if _, err := IsEmulationEnabled(foreign); err == nil {
+		t.Fatalf("IsEmulationEnabled() should fail without binfmt_misc")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "status"), []byte("enabled\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if enabled, err := IsEmulationEnabled(foreign); err != nil || enabled {
+		t.Fatalf("IsEmulationEnabled() = %v, %v without registration, want false", enabled, err)
+	}
+	registration := filepath.Join(dir, "qemu-"+qemuArchitectures[foreign])
+	if err := os.WriteFile(registration, []byte("enabled\ninterpreter /usr/bin/qemu\nflags: F\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if enabled, err := IsEmulationEnabled(foreign); err != nil || !enabled {
+		t.Fatalf("IsEmulationEnabled() = %v, %v once registered, want true", enabled, err)
+	}
+	if err := os.WriteFile(registration, []byte("disabled\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if enabled, err := IsEmulationEnabled(foreign); err != nil || enabled {
+		t.Fatalf("IsEmulationEnabled() = %v, %v once disabled, want false", enabled, err)
+	}
+	if enabled, err := IsEmulationEnabled(runtime.GOARCH); err != nil || !enabled {
+		t.Fatalf("IsEmulationEnabled() = %v, %v for the host's architecture, want true", enabled, err)
+	}
+}
+
+func TestDefaultEmulatedArchitectures(t *testing.T) {
+	architectures := DefaultEmulatedArchitectures()
+	if slices.Contains(architectures, runtime.GOARCH) {
+		t.Fatalf("DefaultEmulatedArchitectures() = %v, should not contain the host's architecture", architectures)
+	}
+	for _, architecture := range architectures {
+		if err := ValidateEmulatedArchitecture(architecture); err != nil {
+			t.Fatalf("ValidateEmulatedArchitecture(%q) error = %v", architecture, err)
+		}
+	}
+	if err := ValidateEmulatedArchitecture("sparc"); err == nil {
+		t.Fatalf("ValidateEmulatedArchitecture() should refuse unknown architectures")
+	}
+}
+
+func TestEnableEmulationArgs(t *testing.T) {
+	args := enableEmulationArgs([]string{"arm64", "riscv64"})
+	if !slices.Contains(args, "--privileged") || args[len(args)-1] != "arm64,riscv64" {
+		t.Fatalf("enableEmulationArgs() = %v", args)
+	}
+}
@@ -8,11 +8,19 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
 	"time"
 
+	"github.com/peaberberian/paul-envs/internal/config"
 	"github.com/peaberberian/paul-envs/internal/console"
 	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
 )
 
 // Abstraction allowing to create images and run containers regardless of the softwared
@@ -20,7 +28,15 @@ import (
 type ContainerEngine interface {
 	// Return information on the current chosen "container engine" (its name, its version...)
 	Info(ctx context.Context) (EngineInfo, error)
+	// Build the shared base image on top of which all project images are built,
+	// from the base files found in `baseFilesDir`.
+	BuildBaseImage(ctx context.Context, baseFilesDir string, options BuildOptions) error
+	// Check if the shared base image for the given platform (empty for the
+	// engine's own) is currently present, in which case `true` is returned.
+	HasBaseImage(ctx context.Context, platform string) (bool, error)
 	// Build the image associated to the given project.
+	//
+	// The shared base image has to be built first.
 	BuildImage(ctx context.Context, project files.ProjectEntry, options BuildOptions) error
 	// Run the container whose image has previously been built with `BuildImage`.
 	//
@@ -29,8 +45,43 @@ type ContainerEngine interface {
 	//
 	// If `args` is not empty, the container will just execute the given commands and then
 	// exit.
-	RunContainer(ctx context.Context, project files.ProjectEntry, args []string) error
+	//
+	// `options` adds to the project's runtime configuration for that
+	// container only.
+	RunContainer(ctx context.Context, project files.ProjectEntry, args []string, options RunOptions) error
+	// Start the shell of the given project interactively in a throwaway
+	// container, with its dotfiles applied, and report how it went.
+	//
+	// Return an `error` if the container could not be run.
+	CheckShell(ctx context.Context, project files.ProjectEntry) (ShellCheck, error)
+	// Run `image` as is interactively, or just the given command, in a
+	// throwaway environment of the given name belonging to no project: its
+	// container, network and volume are all removed once it exits, even when
+	// interrupted.
+	RunThrowaway(ctx context.Context, name string, image string, args []string, options ThrowawayOptions) error
+	// Start a shell, or just the given command, in the running throwaway
+	// environment of the given name, through its injected entrypoint if it
+	// has one (see `ThrowawayOptions`).
+	JoinThrowaway(ctx context.Context, name string, args []string) error
 	JoinContainer(ctx context.Context, containerInfo ContainerInfo, args []string) error
+	// Run a command in a running container directly, without going through
+	// its entrypoint like `JoinContainer` does: it gets neither the shell
+	// overrides nor the dotfiles setup.
+	ExecContainer(ctx context.Context, containerInfo ContainerInfo, args []string, options ExecOptions) error
+	// Copy a file or directory of the host into a container, keeping its
+	// ownership so it belongs to the container user.
+	CopyTo(ctx context.Context, containerInfo ContainerInfo, hostPath string, containerPath string) error
+	// Copy a file or directory of a container to the host.
+	CopyFrom(ctx context.Context, containerInfo ContainerInfo, containerPath string, hostPath string) error
+	// Start the container of the given project in the background, without
+	// attaching to it, so other tools (e.g. an IDE) can attach to it. It keeps
+	// running until stopped and other `run` calls will join it.
+	StartContainer(ctx context.Context, project files.ProjectEntry) (ContainerInfo, error)
+	// Wait until the given container exits and return how it did. Fails if
+	// it was already removed.
+	WaitContainer(ctx context.Context, container ContainerInfo) (ContainerExit, error)
+	// Returns when the given running container started.
+	GetContainerStartTime(ctx context.Context, container ContainerInfo) (time.Time, error)
 	// Create the persistent volume whose name is given as argument.
 	CreateVolume(ctx context.Context, name string) error
 	// Check if the project in argument has been built succesfully before and return
@@ -44,16 +95,125 @@ type ContainerEngine interface {
 	GetImageInfo(ctx context.Context, projectName string) (*ImageInfo, error)
 	// List containers currently known by this container engine
 	ListContainers(ctx context.Context) ([]ContainerInfo, error)
-	// Remove container listed from this container engine
+	// List the resources with exactly the given names, including those not
+	// created by paul-envs, which other `List*` methods leave out
+	ListNamedResources(ctx context.Context, names ResourceNames) ([]NamedResource, error)
+	// Remove container listed from this container engine, along with its
+	// anonymous volumes
 	RemoveContainer(ctx context.Context, container ContainerInfo) error
-	// List images currently known by this container engine
+	// Stop a running container listed from this container engine
+	StopContainer(ctx context.Context, container ContainerInfo) error
+	// List images currently known by this container engine, including the
+	// shared base image (whose `ProjectName` is `nil`).
 	ListImages(ctx context.Context) ([]ImageInfo, error)
 	// Remove image listed from this container engine
 	RemoveImage(ctx context.Context, image ImageInfo) error
+	// Save the current state of the given container of a project as a
+	// snapshot image with the given tag, replacing any snapshot with the
+	// same tag.
+	CommitContainer(ctx context.Context, container ContainerInfo, projectName string, tag string) (SnapshotInfo, error)
+	// List snapshots of all projects currently known by this container engine
+	ListSnapshots(ctx context.Context) ([]SnapshotInfo, error)
+	// Make the given snapshot the image of its project, replacing the one
+	// built from its configuration.
+	RestoreSnapshot(ctx context.Context, snapshot SnapshotInfo) error
+	// Remove snapshot listed from this container engine
+	RemoveSnapshot(ctx context.Context, snapshot SnapshotInfo) error
+	// Keep the current image of the given project as its generation `number`,
+	// so it survives a rebuild.
+	SaveGeneration(ctx context.Context, projectName string, number int) (GenerationInfo, error)
+	// List previous generations of all projects currently known by this
+	// container engine
+	ListGenerations(ctx context.Context) ([]GenerationInfo, error)
+	// Make the given generation the current image of its project.
+	RestoreGeneration(ctx context.Context, generation GenerationInfo) error
+	// Remove generation listed from this container engine
+	RemoveGeneration(ctx context.Context, generation GenerationInfo) error
+	// Also tag the current image of the given project for the architecture it
+	// has been built for.
+	SaveArchImage(ctx context.Context, projectName string) (ArchImageInfo, error)
+	// List architecture-specific images of all projects currently known by
+	// this container engine
+	ListArchImages(ctx context.Context) ([]ArchImageInfo, error)
+	// Make the given architecture-specific image the current image of its
+	// project.
+	UseArchImage(ctx context.Context, image ArchImageInfo) error
+	// Remove architecture-specific image listed from this container engine
+	RemoveArchImage(ctx context.Context, image ArchImageInfo) error
+	// Register emulators of the given architectures (e.g. "arm64") on the
+	// host running the containers, so images built for them can run.
+	EnableEmulation(ctx context.Context, architectures []string) error
+	// Write the image of the given project as an archive, which
+	// `ImportImage` of any container engine can load.
+	ExportImage(ctx context.Context, projectName string, w io.Writer) error
+	// Load an image archive written by `ExportImage` as the image of the
+	// given project.
+	ImportImage(ctx context.Context, projectName string, r io.Reader) error
+	// Push the image of the given project to a registry, as the given
+	// reference (e.g. "ghcr.io/team/envs:app"), with the engine's own
+	// registry credentials.
+	PushImage(ctx context.Context, projectName string, reference string) error
+	// Pull the given reference from a registry as the image of the given
+	// project, with the engine's own registry credentials.
+	PullImage(ctx context.Context, projectName string, reference string) error
+	// Returns the registry digest (e.g. "sha256:...") of the given image, empty
+	// if it has none (e.g. if it was built locally). With `pull` set, it is
+	// first pulled, to obtain the digest its tag currently points to.
+	ImageDigest(ctx context.Context, image string, pull bool) (string, error)
+	// Give the image named `image` the additional name `target`.
+	TagImage(ctx context.Context, image string, target string) error
+	// Remove the name `image`, and the image itself if it was its last name.
+	UntagImage(ctx context.Context, image string) error
+	// Start the given sidecar service of a project in the background, on the
+	// network it shares with the project's container: the one shared between
+	// projects if `sharedNetwork` is set.
+	StartSidecar(ctx context.Context, projectName string, service config.Service, sharedNetwork bool) (SidecarInfo, error)
+	// List sidecars of all projects currently known by this container engine
+	ListSidecars(ctx context.Context) ([]SidecarInfo, error)
+	// Stop and remove sidecar listed from this container engine, along with
+	// its anonymous volumes
+	RemoveSidecar(ctx context.Context, sidecar SidecarInfo) error
+	// Start a shell, or the given command, in a running sidecar listed from
+	// this container engine
+	JoinSidecar(ctx context.Context, sidecar SidecarInfo, args []string) error
+	// Run the healthcheck command of a running sidecar listed from this
+	// container engine, failing if it does not succeed
+	CheckSidecarHealth(ctx context.Context, sidecar SidecarInfo, command string) error
+	// Get the current resource usage of the running containers and sidecars
+	// of all projects
+	GetContainerStats(ctx context.Context) ([]ContainerStats, error)
 	// List volumes currently known by this container engine
 	ListVolumes(ctx context.Context) ([]VolumeInfo, error)
+	// Get everything known about the volume of the given name, including
+	// where its data is stored and its size
+	InspectVolume(ctx context.Context, name string) (VolumeInfo, error)
+	// Get how the distrobox or toolbox container of the given name was
+	// created
+	InspectToolbox(ctx context.Context, name string) (ToolboxContainer, error)
+	// Save the whole state of the given running container, processes
+	// included, to the archive at `exportPath` through CRIU, which stops it.
+	//
+	// Returns `ErrCheckpointUnsupported` if this engine cannot do it.
+	CheckpointContainer(ctx context.Context, container ContainerInfo, exportPath string) error
+	// Recreate and resume a container of that project from an archive
+	// written by `CheckpointContainer`.
+	RestoreContainer(ctx context.Context, project files.ProjectEntry, importPath string) error
+	// Get the disk space used by the images, volumes and build cache of this
+	// container engine
+	GetDiskUsage(ctx context.Context) (DiskUsage, error)
+	// Call `handle` for each event happening to paul-envs' resources (e.g. a
+	// container starting or dying), until `ctx` is done
+	StreamEvents(ctx context.Context, handle func(Event)) error
+	// List the layers of the image of the given project, from the most
+	// recent to the oldest
+	GetImageHistory(ctx context.Context, projectName string) ([]ImageLayer, error)
 	// Remove volume listed from this container engine
 	RemoveVolume(ctx context.Context, volume VolumeInfo) error
+	// Write the content of the given volume to `w`, as a tar archive.
+	ExportVolume(ctx context.Context, name string, w io.Writer) error
+	// Extract a tar archive written by `ExportVolume` into the given volume,
+	// creating it if it does not exist.
+	ImportVolume(ctx context.Context, name string, r io.Reader) error
 	// List networks currently known by this container engine
 	ListNetworks(ctx context.Context) ([]NetworkInfo, error)
 	// Remove network listed from this container engine
@@ -61,13 +221,242 @@ type ContainerEngine interface {
 	// Remove the `ContainerEngine`'s build cache from metadata linked to this
 	// executable
 	PruneBuildCache(ctx context.Context) error
-	// Return whether scoped builder cache pruning is meaningfully supported for
-	// this engine.
-	SupportsBuildCachePrune() bool
+	// Give the files of the host directory `dir` owned by other users (e.g.
+	// created by a container whose user namespace did not map its user to the
+	// host's) back to the current user, through a throwaway container running
+	// as root.
+	FixOwnership(ctx context.Context, dir string) error
+	// Run the Kubernetes YAML of `path`, replacing the Pods it previously
+	// created.
+	//
+	// Returns `ErrKubePlayUnsupported` if this engine cannot do it.
+	PlayKube(ctx context.Context, path string) error
+	// Return what this engine can do beyond what all engines can, which
+	// features relying on it should check first.
+	Capabilities(ctx context.Context) Capabilities
 }
 
 type BuildOptions struct {
 	NoCache bool
+	// Streams the engine's output is written to, the terminal's if nil.
+	Stdout io.Writer
+	Stderr io.Writer
+	// If set, the engine's output is also written to it, on top of
+	// `Stdout` and `Stderr`.
+	Log io.Writer
+	// If set, the engine's output is not written to `Stdout` and `Stderr`,
+	// only to `Log`, e.g. when several builds run at once.
+	Quiet bool
+	// Period without output after which a heartbeat is printed, `0` to
+	// disable it.
+	Heartbeat time.Duration
+	// Period without output after which the build is reported as possibly
+	// stalled, `0` to disable it.
+	StallThreshold time.Duration
+	// OCI platform to build for (e.g. "linux/arm64"), empty for the engine's
+	// own.
+	Platform string
+	// Distribution image the shared base image is built from, empty for the
+	// one of `Dockerfile.base`.
+	DistributionImage string
+	// Digest `DistributionImage` is pinned to, recorded in the labels of
+	// project images. Empty if unknown.
+	DistributionDigest string
+	// Maximum duration of each build attempt, `0` for no limit. Replaced by
+	// the project's own for project builds.
+	Timeout time.Duration
+	// Number of times a build failing on a transient error is retried.
+	// Replaced by the project's own for project builds.
+	Retries int
+	// Other build arguments of project builds, by name (e.g. from `build
+	// --build-arg`), replacing those of the project's build.conf.
+	BuildArgs map[string]string
+	// Program building Podman images, `BuilderPodman` if empty. Ignored by
+	// other engines.
+	Builder string
+
+	// Proxy settings given to the build, as "KEY=VALUE", set by the engine
+	proxyEnv []string
+	// Environment of the engine CLI applying the registry settings, as
+	// "KEY=VALUE", set by the engine
+	registryEnv []string
+	// Flags applying the registry settings, set by the engine
+	registryArgs []string
+	// The project's own Dockerfile, set by the engine, empty to build from
+	// the generated one
+	customDockerfile string
+	// Labels of the project's image, set by the engine
+	labels map[string]string
+	// If `true`, the layers of the project's image are squashed, set by the
+	// engine
+	squash bool
+}
+
+type RunOptions struct {
+	// Additional environment variables, as "KEY=VALUE", set on top of the
+	// project's own.
+	Env []string
+	// Secrets, as "KEY=VALUE", set as environment variables before `Env`.
+	// Their values never appear in the engine's command line.
+	Secrets []string
+	// Streams of the container, the terminal's if nil. No pseudo-terminal is
+	// allocated with a custom input.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// Instance of the project's container to run, its default one if unnamed
+	Instance Instance
+	// Profiles whose services (`SERVICE_PROFILE`) are started along with it,
+	// on top of those belonging to none
+	Profiles []string
+	// Keep the container once it exited instead of removing it, e.g. to copy
+	// files out of it. The caller then has to remove it.
+	Keep bool
+}
+
+// Returns `true` if the container's input is the terminal.
+func (o RunOptions) hasTerminalInput() bool {
+	return isTerminalInput(o.Stdin)
+}
+
+// Give the streams of `o` to the engine CLI run by `cmd`.
+func (o RunOptions) withStreams(cmd *exec.Cmd) {
+	withStreams(cmd, o.Stdin, o.Stdout, o.Stderr)
+}
+
+// Options of `RunThrowaway`.
+type ThrowawayOptions struct {
+	// Host path of the entrypoint injected into its container, for images
+	// lacking paul-envs' own (see `files.FileStore.WriteInjectedEntrypoint`).
+	// It then runs the image's shell or command, after applying the dotfiles
+	// of `DotfilesPath`, and sessions joining it go through it too. Nothing
+	// is injected if empty, the image's own entrypoint being run.
+	EntrypointPath string
+	// Host directory of the dotfiles applied by the injected entrypoint, none
+	// if empty.
+	DotfilesPath string
+}
+
+type ExecOptions struct {
+	// User running the command, the container's default (root) if empty
+	User string
+	// Directory the command is run in, the container's working directory if
+	// empty
+	WorkDir string
+	// Never allocate a pseudo-terminal, even when the standard input is one
+	NoTTY bool
+	// Streams of the command, the terminal's if nil. No pseudo-terminal is
+	// allocated with a custom input.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Returns `true` if the command's input is the terminal.
+func (o ExecOptions) hasTerminalInput() bool {
+	return isTerminalInput(o.Stdin)
+}
+
+// Give the streams of `o` to the engine CLI run by `cmd`.
+func (o ExecOptions) withStreams(cmd *exec.Cmd) {
+	withStreams(cmd, o.Stdin, o.Stdout, o.Stderr)
+}
+
+// Returns `true` if `stdin`, a command's custom input if not nil, is the
+// terminal.
+func isTerminalInput(stdin io.Reader) bool {
+	return stdin == nil && stdinIsTerminal()
+}
+
+// Give the given streams to the engine CLI run by `cmd`, the terminal's for
+// those which are nil.
+func withStreams(cmd *exec.Cmd, stdin io.Reader, stdout io.Writer, stderr io.Writer) {
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
+	if stderr != nil {
+		cmd.Stderr = stderr
+	}
+	if stdin != nil {
+		cmd.Stdin = stdin
+		// Its copy to the engine CLI would otherwise delay its exit until the
+		// input ends, which e.g. a network connection may never do
+		cmd.WaitDelay = time.Second
+	}
+}
+
+// Writers to which the output of a build command should be written.
+func buildOutputs(options BuildOptions) (stdout io.Writer, stderr io.Writer) {
+	if options.Quiet {
+		if options.Log == nil {
+			return io.Discard, io.Discard
+		}
+		return options.Log, options.Log
+	}
+	stdout, stderr = os.Stdout, os.Stderr
+	if options.Stdout != nil {
+		stdout = options.Stdout
+	}
+	if options.Stderr != nil {
+		stderr = options.Stderr
+	}
+	if options.Log == nil {
+		return stdout, stderr
+	}
+	return io.MultiWriter(stdout, options.Log), io.MultiWriter(stderr, options.Log)
+}
+
+// Add the `BUILD_ARG` directives of the project's build.conf to the build
+// arguments of `options`, those already there being kept.
+func withProjectBuildArgs(options BuildOptions, buildCfg config.BuildConfig) BuildOptions {
+	if len(buildCfg.BuildArgs) == 0 {
+		return options
+	}
+	merged := maps.Clone(buildCfg.BuildArgs)
+	maps.Copy(merged, options.BuildArgs)
+	options.BuildArgs = merged
+	return options
+}
+
+// Label recording the hash of the build.conf an image was built from, to tell
+// whether it still matches the project's definition, and of the run.conf a
+// container was created from.
+const configHashLabel = "paulenv.config-hash"
+
+// Set the image labels and layers squashing of the project's build.conf in
+// `options`, labelling the image with `configHash` and as built by paul-envs
+// for that project.
+func withProjectImageSettings(options BuildOptions, projectName string, buildCfg config.BuildConfig, configHash string) BuildOptions {
+	labels := maps.Clone(buildCfg.Labels)
+	if labels == nil {
+		labels = make(map[string]string, 5)
+	}
+	// Over the project's own, which must not pass for another project's
+	maps.Copy(labels, resourceLabels(projectName, sourceBuild))
+	labels[configHashLabel] = configHash
+	options.labels = labels
+	options.squash = buildCfg.Squash
+	return options
+}
+
+// Returns the `--label` flags setting `labels` on the image, sorted by name.
+func imageLabelFlags(labels map[string]string) []string {
+	args := make([]string, 0, 2*len(labels))
+	for _, name := range slices.Sorted(maps.Keys(labels)) {
+		args = append(args, "--label", name+"="+labels[name])
+	}
+	return args
+}
+
+// Returns the `--build-arg` flags giving `buildArgs` to the build, sorted by
+// name.
+func buildArgFlags(buildArgs map[string]string) []string {
+	args := make([]string, 0, 2*len(buildArgs))
+	for _, name := range slices.Sorted(maps.Keys(buildArgs)) {
+		args = append(args, "--build-arg", name+"="+buildArgs[name])
+	}
+	return args
 }
 
 // Returns information on a specific "engine" able to create images and run containers
@@ -85,7 +474,9 @@ const (
 	SelectionAuto   Selection = ""
 	SelectionDocker Selection = "docker"
 	SelectionPodman Selection = "podman"
-	SelectionAll    Selection = "all"
+	// Podman run by root, see `podman_mode.go`
+	SelectionPodmanRootful Selection = "podman-rootful"
+	SelectionAll           Selection = "all"
 )
 
 // Information on a particular built image
@@ -97,6 +488,16 @@ type ImageInfo struct {
 	// The timestamp at which it has last been built.
 	// `nil` if it never has been built.
 	BuiltAt *time.Time
+	// Disk usage of that image as reported by the container engine, empty if
+	// unknown.
+	Size string
+	// Architecture it has been built for (e.g. "arm64"), empty if unknown.
+	Architecture string
+	// Hash of the build.conf it has been built from, empty if unknown.
+	ConfigHash string
+	// Where it comes from, as recorded when it was built. Only set by
+	// `GetImageInfo`.
+	Provenance Provenance
 }
 
 // Information on a particular container as stored by the container engine
@@ -107,8 +508,13 @@ type ContainerInfo struct {
 	ContainerName *string
 	// The name of the corresponding image
 	ImageName *string
-	// Its Id with which it can be refered to
+	// The name of the instance of the project it runs, empty for its default
+	// one (see `run --instance`)
+	Instance string
+	// Its full (non-truncated) Id with which it can be refered to
 	ContainerId string
+	// `true` if that container is currently running
+	Running bool
 }
 
 // Information on a particular container Network interface
@@ -127,6 +533,38 @@ type VolumeInfo struct {
 	VolumeId string
 	// The name it is actually refered to by the container engine.
 	VolumeName string
+	// The following are only set by `InspectVolume`.
+
+	// Volume driver storing its data, e.g. "local"
+	Driver string
+	// Where its data is stored, on the host of the container engine (which
+	// may be a virtual machine)
+	Mountpoint string
+	// When it was created, `nil` if unknown
+	CreatedAt *time.Time
+	// Space used by its data in bytes, `nil` if the engine did not report it
+	Size *int64
+}
+
+// Engine picked by `SelectionAuto` when it is available, set from the user's
+// global configuration.
+var preferredSelection = SelectionAuto
+
+// Make `SelectionAuto` pick the given engine when it is available, instead of
+// preferring Podman.
+func SetPreferredEngine(selection Selection) {
+	preferredSelection = selection
+}
+
+// Writer to which engine commands write their progress and errors when they
+// are not tied to a build or a run (pulls, pushes, volume creations...).
+var engineOutput io.Writer = os.Stderr
+
+// Make engine commands write their progress and errors not tied to a build
+// or a run to `w` instead of the terminal, e.g. when used from a TUI. Build
+// and run output go to the streams of their `BuildOptions` and `RunOptions`.
+func SetOutput(w io.Writer) {
+	engineOutput = w
 }
 
 // Create a new `ContainerEngine`, based on what's available right now.
@@ -136,11 +574,58 @@ func New(ctx context.Context, console *console.Console) (ContainerEngine, error)
 
 // Create a new `ContainerEngine` based on the requested engine selection.
 func NewSelected(ctx context.Context, console *console.Console, selection Selection) (ContainerEngine, error) {
-	podman, podmanErr := newPodman(ctx)
+	if err := loadFaults(console); err != nil {
+		return nil, err
+	}
+	if fake := envFakeEngine(); fake != nil {
+		return fake, nil
+	}
+	switch selection {
+	case SelectionAuto, SelectionPodman, SelectionPodmanRootful, SelectionDocker:
+	default:
+		if !IsPlugin(string(selection)) {
+			return nil, fmt.Errorf("invalid container engine selection %q", selection)
+		}
+		plugin, err := newPlugin(ctx, string(selection))
+		if err != nil {
+			return nil, utils.WithCategory(fmt.Errorf("requested engine %q is not available: %w", selection, err), ErrEngineUnavailable)
+		}
+		return plugin, nil
+	}
+	if selection == SelectionAuto && IsPlugin(string(preferredSelection)) {
+		// Otherwise warned about below, like the other engines
+		if plugin, err := newPlugin(ctx, string(preferredSelection)); err == nil {
+			return plugin, nil
+		}
+	}
+	if cached := cachedEngine(ctx, selection); cached != nil {
+		return cached, nil
+	}
+	engine, err := detectEngine(ctx, console, selection)
+	if err != nil {
+		forgetEngineOnFailure(err)
+		return nil, err
+	}
+	rememberEngine(ctx, selection, engine)
+	return engine, nil
+}
+
+// Look for the Podman or Docker engine matching `selection`.
+func detectEngine(ctx context.Context, console *console.Console, selection Selection) (ContainerEngine, error) {
+	podman, podmanErr := newPodman(ctx, false)
 	docker, dockerErr := newDocker(ctx)
 
 	switch selection {
 	case SelectionAuto:
+		switch {
+		case preferredSelection == SelectionPodman && podmanErr == nil:
+			return podman, nil
+		case preferredSelection == SelectionDocker && dockerErr == nil:
+			return docker, nil
+		}
+		if preferredSelection != SelectionAuto && (podmanErr == nil || dockerErr == nil) {
+			console.Warn("The preferred container engine %q is not available, relying on another one.", preferredSelection)
+		}
 		if podmanErr == nil {
 			if dockerErr == nil {
 				console.Info("Both Podman and Docker are available; relying on Podman.")
@@ -150,15 +635,20 @@ func NewSelected(ctx context.Context, console *console.Console, selection Select
 		if dockerErr == nil {
 			return docker, nil
 		}
-		return nil, fmt.Errorf("no supported container engine found, please install podman or docker first")
+		return nil, utils.WithCategory(errors.New("no supported container engine found, please install podman or docker first"), ErrEngineUnavailable)
 	case SelectionPodman:
 		if podmanErr != nil {
-			return nil, fmt.Errorf("requested engine %q is not available: %w", SelectionPodman, podmanErr)
+			return nil, utils.WithCategory(fmt.Errorf("requested engine %q is not available: %w", SelectionPodman, podmanErr), ErrEngineUnavailable)
 		}
 		return podman, nil
+	case SelectionPodmanRootful:
+		if podmanErr != nil {
+			return nil, utils.WithCategory(fmt.Errorf("requested engine %q is not available: %w", SelectionPodmanRootful, podmanErr), ErrEngineUnavailable)
+		}
+		return newPodman(ctx, true)
 	case SelectionDocker:
 		if dockerErr != nil {
-			return nil, fmt.Errorf("requested engine %q is not available: %w", SelectionDocker, dockerErr)
+			return nil, utils.WithCategory(fmt.Errorf("requested engine %q is not available: %w", SelectionDocker, dockerErr), ErrEngineUnavailable)
 		}
 		return docker, nil
 	default:
@@ -176,8 +666,14 @@ func NewSet(ctx context.Context, console *console.Console, selection Selection)
 		return []ContainerEngine{engine}, nil
 	}
 
+	if err := loadFaults(console); err != nil {
+		return nil, err
+	}
+	if fake := envFakeEngine(); fake != nil {
+		return []ContainerEngine{fake}, nil
+	}
 	engines := []ContainerEngine{}
-	podman, podmanErr := newPodman(ctx)
+	podman, podmanErr := newPodman(ctx, false)
 	if podmanErr == nil {
 		engines = append(engines, podman)
 	}
@@ -185,8 +681,16 @@ func NewSet(ctx context.Context, console *console.Console, selection Selection)
 	if dockerErr == nil {
 		engines = append(engines, docker)
 	}
+	for _, name := range ListPlugins() {
+		plugin, err := newPlugin(ctx, name)
+		if err != nil {
+			console.Warn("Ignoring the %q engine plugin: %s", name, err)
+			continue
+		}
+		engines = append(engines, plugin)
+	}
 	if len(engines) == 0 {
-		return nil, fmt.Errorf("no supported container engine found, please install podman or docker first")
+		return nil, utils.WithCategory(errors.New("no supported container engine found, please install podman or docker first"), ErrEngineUnavailable)
 	}
 	return engines, nil
 }
@@ -0,0 +1,45 @@
+package engine
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestBuildOutputs(t *testing.T) {
+	var stdout, stderr, log bytes.Buffer
+	out, errOut := buildOutputs(BuildOptions{Stdout: &stdout, Stderr: &stderr, Log: &log})
+	io.WriteString(out, "step 1\n")
+	io.WriteString(errOut, "warning\n")
+	if stdout.String() != "step 1\n" || stderr.String() != "warning\n" || log.String() != "step 1\nwarning\n" {
+		t.Fatalf("buildOutputs() wrote stdout %q, stderr %q, log %q", stdout.String(), stderr.String(), log.String())
+	}
+
+	stdout.Reset()
+	log.Reset()
+	out, _ = buildOutputs(BuildOptions{Stdout: &stdout, Log: &log, Quiet: true})
+	io.WriteString(out, "step 1\n")
+	if stdout.Len() != 0 || log.String() != "step 1\n" {
+		t.Fatalf("buildOutputs() quiet wrote stdout %q, log %q, want only the log", stdout.String(), log.String())
+	}
+
+	if out, errOut := buildOutputs(BuildOptions{}); out != os.Stdout || errOut != os.Stderr {
+		t.Fatalf("buildOutputs() without streams should write to the terminal")
+	}
+}
+
+func TestRunOptionsStreams(t *testing.T) {
+	var stdout bytes.Buffer
+	options := RunOptions{Stdin: strings.NewReader("input"), Stdout: &stdout}
+	if options.hasTerminalInput() {
+		t.Fatalf("hasTerminalInput() = true with a custom input")
+	}
+	cmd := exec.Command("true")
+	options.withStreams(cmd)
+	if cmd.Stdin != options.Stdin || cmd.Stdout != &stdout || cmd.Stderr != os.Stderr {
+		t.Fatalf("withStreams() should give the custom streams, and the terminal's for the others")
+	}
+}
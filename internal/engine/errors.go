@@ -0,0 +1,104 @@
+// # errors.go
+// Failures of the container engines are classified into a few categories,
+// matched with `errors.Is`, so paul-envs' commands and other tools built on
+// this package can react to them without parsing messages themselves.
+//
+// Engines mostly tell them apart in their error output only, their exit codes
+// being shared by most failures (and changing between Podman versions). That
+// output is thus matched here, once, when their commands fail.
+
+package engine
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Matched with `errors.Is` by errors returned when the requested container
+// engine cannot be used.
+var ErrEngineUnavailable = errors.New("container engine unavailable")
+
+// Matched with `errors.Is` by errors returned when the container engine
+// cannot be used by the current user. The same as `fs.ErrPermission`.
+var ErrPermission = fs.ErrPermission
+
+// Matched with `errors.Is` by errors returned when the image, container,
+// volume or network an operation is about does not exist.
+var ErrNotFound = errors.New("no such resource")
+
+// Matched with `errors.Is` by errors returned when building an image failed,
+// which are `*BuildError`.
+var ErrBuildFailed = errors.New("build failed")
+
+// Failure of an image build.
+type BuildError struct {
+	Err error
+	// End of the output of the failed build, empty if unknown
+	LogTail string
+}
+
+func (e *BuildError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *BuildError) Unwrap() []error {
+	return []error{e.Err, ErrBuildFailed}
+}
+
+// Lowercase fragments of the error output of engine commands, by category of
+// failure, checked in that order: a permission denied on the engine's socket
+// is also a failure to connect to it.
+var failureCategories = []struct {
+	category  error
+	fragments []string
+}{
+	{ErrPermission, []string{
+		"permission denied",
+		"access denied",
+	}},
+	{ErrEngineUnavailable, []string{
+		"cannot connect to the docker daemon",
+		"is the docker daemon running",
+		"cannot connect to podman",
+		"unable to connect to podman",
+		"connection refused",
+	}},
+	{ErrNotFound, []string{
+		"no such image",
+		"no such container",
+		"no such volume",
+		"no such network",
+		"no such object",
+		"image not known",
+		"network not found",
+	}},
+}
+
+// Returns the category of the failure an engine command reported with that
+// error output, nil if it is not known.
+func classifyStderr(stderr string) error {
+	stderr = strings.ToLower(stderr)
+	for _, c := range failureCategories {
+		for _, fragment := range c.fragments {
+			if strings.Contains(stderr, fragment) {
+				return c.category
+			}
+		}
+	}
+	return nil
+}
+
+// Returns `err`, the failure of an engine command which wrote `stderr`, with
+// its category if it has a known one.
+func classifyCommandError(err error, stderr string) error {
+	if err == nil {
+		return nil
+	}
+	if category := classifyStderr(stderr); category != nil && !errors.Is(err, category) {
+		return utils.WithCategory(err, category)
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+)
+
+func TestClassifyStderr(t *testing.T) {
+	tests := []struct {
+		stderr string
+		want   error
+	}{
+		{"permission denied while trying to connect to the Docker daemon socket at unix:///var/run/docker.sock", ErrPermission},
+		{"Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?", ErrEngineUnavailable},
+		{"Error: unable to connect to Podman socket: dial unix /run/user/1000/podman/podman.sock: connect: connection refused", ErrEngineUnavailable},
+		{"Error: paulenv:demo: image not known", ErrNotFound},
+		{`Error: no container with name or ID "paulenv-demo" found: no such container`, ErrNotFound},
+		{"Error response from daemon: No such volume: paulenv-demo-local", ErrNotFound},
+		{"Error: something else went wrong", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := classifyStderr(tt.stderr); got != tt.want {
+			t.Errorf("classifyStderr(%q) = %v, want %v", tt.stderr, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyCommandError(t *testing.T) {
+	if classifyCommandError(nil, "no such image") != nil {
+		t.Fatal("no failure should stay nil")
+	}
+	cause := errors.New("exit status 125")
+	err := classifyCommandError(cause, "Error: paulenv:demo: image not known")
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, cause) || err.Error() != cause.Error() {
+		t.Fatalf("classifyCommandError() = %v, want the cause categorized as not found", err)
+	}
+	if err := classifyCommandError(cause, "Error: unexpected"); err != cause {
+		t.Fatalf("classifyCommandError() = %v, want the cause as is", err)
+	}
+	if !errors.Is(classifyCommandError(cause, "permission denied"), fs.ErrPermission) {
+		t.Fatal("ErrPermission should match fs.ErrPermission")
+	}
+}
+
+func TestBuildError(t *testing.T) {
+	cause := errors.New("exit status 1")
+	err := fmt.Errorf("failed to build: %w", &BuildError{Err: cause, LogTail: "E: Unable to locate package foo\n"})
+	if !errors.Is(err, ErrBuildFailed) || !errors.Is(err, cause) {
+		t.Fatalf("%v should match ErrBuildFailed and its cause", err)
+	}
+	var buildErr *BuildError
+	if !errors.As(err, &buildErr) || buildErr.LogTail != "E: Unable to locate package foo\n" {
+		t.Fatalf("the build log tail should be kept, got %+v", buildErr)
+	}
+}
@@ -0,0 +1,234 @@
+// # events.go
+// Docker and Podman both stream what happens to their resources through an
+// `events` command, each with its own JSON format. Only events on paul-envs'
+// resources are kept, normalized to `Event`.
+
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Something which happened to a paul-envs resource.
+type Event struct {
+	Time time.Time
+	// Kind of resource: "container", "image", "volume" or "network"
+	Type string
+	// What happened to it, e.g. "start" or "die"
+	Action string
+	// Name of the resource
+	Name string
+	// Project it belongs to, empty if unknown
+	ProjectName string
+	// Exit code of a container which died, nil for other events
+	ExitCode *int
+}
+
+// e.g. "container paulenv-demo die (exit code 1)"
+func (e Event) String() string {
+	desc := e.Type + " " + e.Name + " " + e.Action
+	if e.ExitCode != nil {
+		desc += fmt.Sprintf(" (exit code %d)", *e.ExitCode)
+	}
+	return desc
+}
+
+// Exit codes of processes terminated by SIGINT and SIGTERM, which are how
+// containers are asked to stop.
+const (
+	exitCodeInterrupted = 130
+	exitCodeTerminated  = 143
+)
+
+// Tells, from the events of containers, which of them died unexpectedly:
+// killed by a signal (e.g. a segmentation fault or SIGKILL) or for exceeding
+// their memory limit, without having been stopped through the engine.
+//
+// Exit codes of containers exiting on their own are not considered, as they
+// are those of the last command of interactive shells.
+type CrashTracker struct {
+	// Containers stopped, killed or running out of memory, by name
+	stopped map[string]bool
+	oom     map[string]bool
+}
+
+func NewCrashTracker() *CrashTracker {
+	return &CrashTracker{stopped: map[string]bool{}, oom: map[string]bool{}}
+}
+
+// Returns `true` if that event is the unexpected death of a container.
+func (t *CrashTracker) IsCrash(e Event) bool {
+	if e.Type != "container" {
+		return false
+	}
+	switch e.Action {
+	case "kill", "stop":
+		t.stopped[e.Name] = true
+	case "oom":
+		t.oom[e.Name] = true
+	case "die":
+		stopped, oom := t.stopped[e.Name], t.oom[e.Name]
+		delete(t.stopped, e.Name)
+		delete(t.oom, e.Name)
+		if oom {
+			return true
+		}
+		if stopped || e.ExitCode == nil {
+			return false
+		}
+		code := *e.ExitCode
+		return code > 128 && code != exitCodeInterrupted && code != exitCodeTerminated
+	}
+	return false
+}
+
+// Fills `event` with the name of a resource and the project it belongs to
+// (from its `projectLabel`, or its name), returning `false` if it is not
+// paul-envs'.
+func setEventResource(event *Event, name string, projectName string) bool {
+	event.Name = name
+	event.ProjectName = projectName
+	// Image references may be qualified by their registry
+	shortName := strings.TrimPrefix(strings.TrimPrefix(name, "localhost/"), "docker.io/library/")
+	if event.ProjectName == "" {
+		switch {
+		case strings.HasPrefix(shortName, "paulenv:"):
+			event.ProjectName = strings.TrimPrefix(shortName, "paulenv:")
+		case event.Type == "container" && strings.HasPrefix(shortName, "paulenv-"):
+			event.ProjectName = strings.TrimPrefix(shortName, "paulenv-")
+		}
+	}
+	return event.ProjectName != "" || strings.HasPrefix(shortName, "paulenv")
+}
+
+// A line of `docker events --format '{{json .}}'`.
+type dockerEvent struct {
+	Type   string
+	Action string
+	Actor  struct {
+		ID         string
+		Attributes map[string]string
+	}
+	TimeNano int64 `json:"timeNano"`
+}
+
+func parseDockerEvent(line []byte) (Event, bool) {
+	var raw dockerEvent
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return Event{}, false
+	}
+	// Docker's actions may have details, e.g. "exec_start: bash"
+	action, _, _ := strings.Cut(raw.Action, ":")
+	event := Event{Time: time.Unix(0, raw.TimeNano), Type: raw.Type, Action: action}
+	name := raw.Actor.Attributes["name"]
+	if name == "" {
+		name = raw.Actor.ID
+	}
+	if code, err := strconv.Atoi(raw.Actor.Attributes["exitCode"]); err == nil && action == "die" {
+		event.ExitCode = &code
+	}
+	return event, setEventResource(&event, name, raw.Actor.Attributes[projectLabel])
+}
+
+// A line of `podman events --format json`.
+type podmanEvent struct {
+	Type              string
+	Status            string
+	Name              string
+	Attributes        map[string]string
+	ContainerExitCode *int
+	TimeNano          int64 `json:"timeNano"`
+	// Older Podman versions only have it as a string
+	Time json.RawMessage `json:"time"`
+}
+
+func parsePodmanEvent(line []byte) (Event, bool) {
+	var raw podmanEvent
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return Event{}, false
+	}
+	event := Event{Type: raw.Type, Action: raw.Status}
+	switch {
+	case raw.TimeNano > 0:
+		event.Time = time.Unix(0, raw.TimeNano)
+	default:
+		var seconds int64
+		var timestamp time.Time
+		if json.Unmarshal(raw.Time, &seconds) == nil {
+			event.Time = time.Unix(seconds, 0)
+		} else if json.Unmarshal(raw.Time, &timestamp) == nil {
+			event.Time = timestamp
+		}
+	}
+	// Named like Docker's, to be handled the same way
+	if event.Action == "died" {
+		event.Action = "die"
+		event.ExitCode = raw.ContainerExitCode
+	}
+	return event, setEventResource(&event, raw.Name, raw.Attributes[projectLabel])
+}
+
+// Writer calling `handle` for each paul-envs event of the lines written to it.
+type eventWriter struct {
+	parse   func([]byte) (Event, bool)
+	handle  func(Event)
+	pending []byte
+}
+
+func (w *eventWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+	for {
+		line, rest, found := bytes.Cut(w.pending, []byte("\n"))
+		if !found {
+			return len(p), nil
+		}
+		if event, ok := w.parse(line); ok {
+			w.handle(event)
+		}
+		w.pending = rest
+	}
+}
+
+// Returns the error of an events command, nil if it ended because `ctx` is
+// done.
+func eventsStreamError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to follow engine events: %w", err)
+	}
+	return nil
+}
+
+func (c *DockerEngine) StreamEvents(ctx context.Context, handle func(Event)) error {
+	cmd := engineCommand(ctx, "docker", "events", "--format", "{{json .}}")
+	cmd.Stdout = &eventWriter{parse: parseDockerEvent, handle: handle}
+	cmd.Stderr = engineOutput
+	err := runEngineCommand(cmd)
+	if err != nil && ctx.Err() == nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+	}
+	return eventsStreamError(ctx, err)
+}
+
+func (c *PodmanEngine) StreamEvents(ctx context.Context, handle func(Event)) error {
+	cmd := c.command(ctx, "events", "--format", "json")
+	cmd.Stdout = &eventWriter{parse: parsePodmanEvent, handle: handle}
+	cmd.Stderr = engineOutput
+	err := runEngineCommand(cmd)
+	if err != nil && ctx.Err() == nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+	}
+	return eventsStreamError(ctx, err)
+}
@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDockerEvent(t *testing.T) {
+	line := `{"status":"die","id":"abc","Type":"container","Action":"die","Actor":{"ID":"abc","Attributes":{"exitCode":"139","name":"paulenv-demo..tests","paulenv.project":"demo"}},"scope":"local","time":1760000000,"timeNano":1760000000123456789}`
+	event, ok := parseDockerEvent([]byte(line))
+	if !ok {
+		t.Fatalf("parseDockerEvent() should keep events of project containers")
+	}
+	if event.Type != "container" || event.Action != "die" || event.Name != "paulenv-demo..tests" || event.ProjectName != "demo" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if event.ExitCode == nil || *event.ExitCode != 139 {
+		t.Fatalf("unexpected exit code: %v", event.ExitCode)
+	}
+	if !event.Time.Equal(time.Unix(0, 1760000000123456789)) {
+		t.Fatalf("unexpected time: %s", event.Time)
+	}
+
+	event, ok = parseDockerEvent([]byte(`{"Type":"container","Action":"exec_start: bash","Actor":{"ID":"abc","Attributes":{"name":"paulenv-demo"}},"timeNano":1}`))
+	if !ok || event.Action != "exec_start" || event.ProjectName != "demo" || event.ExitCode != nil {
+		t.Fatalf("unexpected exec event: %+v, %v", event, ok)
+	}
+	if _, ok := parseDockerEvent([]byte(`{"Type":"container","Action":"start","Actor":{"ID":"abc","Attributes":{"name":"postgres"}}}`)); ok {
+		t.Fatalf("parseDockerEvent() should drop events of other containers")
+	}
+	if event, ok := parseDockerEvent([]byte(`{"Type":"volume","Action":"create","Actor":{"ID":"paulenv-shared.data","Attributes":{"driver":"local"}}}`)); !ok || event.Name != "paulenv-shared.data" || event.ProjectName != "" {
+		t.Fatalf("unexpected volume event: %+v, %v", event, ok)
+	}
+}
+
+func TestParsePodmanEvent(t *testing.T) {
+	line := `{"ID":"abc","Image":"localhost/paulenv:demo","Name":"paulenv-demo","Status":"died","time":1760000000,"Type":"container","Attributes":{"paulenv.project":"demo"},"ContainerExitCode":137}`
+	event, ok := parsePodmanEvent([]byte(line))
+	if !ok || event.Action != "die" || event.ProjectName != "demo" || event.ExitCode == nil || *event.ExitCode != 137 {
+		t.Fatalf("unexpected event: %+v, %v", event, ok)
+	}
+	if !event.Time.Equal(time.Unix(1760000000, 0)) {
+		t.Fatalf("unexpected time: %s", event.Time)
+	}
+	event, ok = parsePodmanEvent([]byte(`{"ID":"def","Name":"localhost/paulenv:demo","Status":"tag","Time":"2026-10-15T10:00:00Z","Type":"image"}`))
+	if !ok || event.ProjectName != "demo" || event.Time.Year() != 2026 {
+		t.Fatalf("unexpected image event: %+v, %v", event, ok)
+	}
+	if _, ok := parsePodmanEvent([]byte("not json")); ok {
+		t.Fatalf("parsePodmanEvent() should drop invalid lines")
+	}
+}
+
+func TestCrashTracker(t *testing.T) {
+	exitCode := func(code int) *int { return &code }
+	die := func(name string, code int) Event {
+		return Event{Type: "container", Action: "die", Name: name, ExitCode: exitCode(code)}
+	}
+	tracker := NewCrashTracker()
+	if !tracker.IsCrash(die("paulenv-a", 139)) {
+		t.Fatalf("a segmentation fault should be a crash")
+	}
+	for _, code := range []int{0, 1, 130, 143} {
+		if tracker.IsCrash(die("paulenv-a", code)) {
+			t.Fatalf("exiting with code %d should not be a crash", code)
+		}
+	}
+	tracker.IsCrash(Event{Type: "container", Action: "kill", Name: "paulenv-b"})
+	if tracker.IsCrash(die("paulenv-b", 137)) {
+		t.Fatalf("a container killed through the engine should not crash")
+	}
+	if !tracker.IsCrash(die("paulenv-b", 137)) {
+		t.Fatalf("a kill should only apply to the following death")
+	}
+	tracker.IsCrash(Event{Type: "container", Action: "oom", Name: "paulenv-c"})
+	if !tracker.IsCrash(die("paulenv-c", 0)) {
+		t.Fatalf("running out of memory should be a crash")
+	}
+}
+
+func TestEventWriter(t *testing.T) {
+	var events []Event
+	w := &eventWriter{parse: parseDockerEvent, handle: func(e Event) { events = append(events, e) }}
+	line := `{"Type":"container","Action":"start","Actor":{"ID":"abc","Attributes":{"name":"paulenv-demo"}}}` + "\n"
+	w.Write([]byte(line[:20]))
+	if len(events) != 0 {
+		t.Fatalf("incomplete lines should not be parsed")
+	}
+	w.Write([]byte(line[20:] + line))
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+}
@@ -0,0 +1,452 @@
+// # fake.go
+// A `ContainerEngine` which does nothing but record the calls made to it and
+// answer with canned data, to test code relying on a container engine (this
+// package and paul-envs' commands, but also tools built on top of them)
+// without having Docker or Podman installed.
+//
+// Setting the `PAULENVS_FAKE_ENGINE` environment variable also makes paul-envs
+// itself rely on a fake engine, e.g. to test scripts calling it. Its calls are
+// then logged (see `--verbose`).
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/logging"
+)
+
+const fakeEngineEnvVar = "PAULENVS_FAKE_ENGINE"
+
+// Returns the fake engine replacing all others if `PAULENVS_FAKE_ENGINE` is
+// set, nil otherwise.
+func envFakeEngine() *FakeEngine {
+	if os.Getenv(fakeEngineEnvVar) == "" {
+		return nil
+	}
+	return &FakeEngine{Name: "fake", Version: "0.0.0"}
+}
+
+// Fake `ContainerEngine`. Its zero value is an engine on which nothing has
+// been built yet, with nothing to list, and on which every operation
+// succeeds.
+type FakeEngine struct {
+	// Reported by `Info`
+	Name    string
+	Version string
+
+	// Returned by the corresponding `List*` methods
+	Containers  []ContainerInfo
+	Images      []ImageInfo
+	Snapshots   []SnapshotInfo
+	Generations []GenerationInfo
+	ArchImages  []ArchImageInfo
+	Sidecars    []SidecarInfo
+	Volumes     []VolumeInfo
+	Networks    []NetworkInfo
+	// Returned by `ListNamedResources` when named as asked, including those
+	// foreign to paul-envs
+	NamedResources []NamedResource
+	// Returned by `GetContainerStats`
+	Stats []ContainerStats
+	// Returned by `GetDiskUsage`
+	DiskUsage DiskUsage
+	// Returned by `GetImageHistory`, by project
+	ImageHistories map[string][]ImageLayer
+	// Returned by `InspectToolbox`, by name
+	Toolboxes map[string]ToolboxContainer
+
+	// Projects reported as built by `HasBeenBuilt`
+	BuiltProjects []string
+	// If set, `HasBaseImage` reports the shared base image as missing
+	NoBaseImage bool
+	// Returned by `GetContainerStartTime`, by container id. Zero for others.
+	StartTimes map[string]time.Time
+	// Exit reported by `WaitContainer`. If nil, containers keep running until
+	// the context is done.
+	Exit *ContainerExit
+	// Sent by `StreamEvents`, which then waits for the context to be done
+	Events []Event
+	// Reported by `Capabilities`
+	Supported Capabilities
+	// Errors returned by methods instead of succeeding, by method name
+	// (e.g. "RunContainer")
+	Errors map[string]error
+
+	mu    sync.Mutex
+	calls []FakeCall
+}
+
+// A call made to a `FakeEngine`.
+type FakeCall struct {
+	// Name of the method called, e.g. "RunContainer"
+	Method string
+	// Arguments it was called with, without the context
+	Args []any
+}
+
+// Calls made to that engine until now, in order.
+func (f *FakeEngine) Calls() []FakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+// Calls made to the given method of that engine until now, in order.
+func (f *FakeEngine) CallsTo(method string) []FakeCall {
+	calls := []FakeCall{}
+	for _, call := range f.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Record a call, returning the error it should fail with if any.
+func (f *FakeEngine) record(method string, args ...any) error {
+	logging.Log().Debug("fake engine call", "method", method, "args", args)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, FakeCall{Method: method, Args: args})
+	return f.Errors[method]
+}
+
+func (f *FakeEngine) Info(context.Context) (EngineInfo, error) {
+	err := f.record("Info")
+	return EngineInfo{Name: f.Name, Version: f.Version}, err
+}
+
+func (f *FakeEngine) BuildBaseImage(_ context.Context, baseFilesDir string, options BuildOptions) error {
+	return f.record("BuildBaseImage", baseFilesDir, options)
+}
+
+func (f *FakeEngine) HasBaseImage(_ context.Context, platform string) (bool, error) {
+	err := f.record("HasBaseImage", platform)
+	return !f.NoBaseImage, err
+}
+
+func (f *FakeEngine) BuildImage(_ context.Context, project files.ProjectEntry, options BuildOptions) error {
+	return f.record("BuildImage", project, options)
+}
+
+func (f *FakeEngine) RunContainer(_ context.Context, project files.ProjectEntry, args []string, options RunOptions) error {
+	return f.record("RunContainer", project, args, options)
+}
+
+func (f *FakeEngine) CheckShell(_ context.Context, project files.ProjectEntry) (ShellCheck, error) {
+	return ShellCheck{}, f.record("CheckShell", project)
+}
+
+func (f *FakeEngine) RunThrowaway(_ context.Context, name string, image string, args []string, options ThrowawayOptions) error {
+	return f.record("RunThrowaway", name, image, args, options)
+}
+
+func (f *FakeEngine) JoinThrowaway(_ context.Context, name string, args []string) error {
+	return f.record("JoinThrowaway", name, args)
+}
+
+func (f *FakeEngine) JoinContainer(_ context.Context, containerInfo ContainerInfo, args []string) error {
+	return f.record("JoinContainer", containerInfo, args)
+}
+
+func (f *FakeEngine) ExecContainer(_ context.Context, containerInfo ContainerInfo, args []string, options ExecOptions) error {
+	return f.record("ExecContainer", containerInfo, args, options)
+}
+
+func (f *FakeEngine) CopyTo(_ context.Context, containerInfo ContainerInfo, hostPath string, containerPath string) error {
+	return f.record("CopyTo", containerInfo, hostPath, containerPath)
+}
+
+func (f *FakeEngine) CopyFrom(_ context.Context, containerInfo ContainerInfo, containerPath string, hostPath string) error {
+	return f.record("CopyFrom", containerInfo, containerPath, hostPath)
+}
+
+func (f *FakeEngine) StartContainer(_ context.Context, project files.ProjectEntry) (ContainerInfo, error) {
+	return ContainerInfo{}, f.record("StartContainer", project)
+}
+
+func (f *FakeEngine) GetContainerStartTime(_ context.Context, container ContainerInfo) (time.Time, error) {
+	err := f.record("GetContainerStartTime", container)
+	return f.StartTimes[container.ContainerId], err
+}
+
+func (f *FakeEngine) WaitContainer(ctx context.Context, container ContainerInfo) (ContainerExit, error) {
+	if err := f.record("WaitContainer", container); err != nil {
+		return ContainerExit{}, err
+	}
+	if f.Exit != nil {
+		return *f.Exit, nil
+	}
+	<-ctx.Done()
+	return ContainerExit{}, ctx.Err()
+}
+
+func (f *FakeEngine) StreamEvents(ctx context.Context, handle func(Event)) error {
+	if err := f.record("StreamEvents"); err != nil {
+		return err
+	}
+	for _, event := range f.Events {
+		handle(event)
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func (f *FakeEngine) CreateVolume(_ context.Context, name string) error {
+	return f.record("CreateVolume", name)
+}
+
+func (f *FakeEngine) HasBeenBuilt(_ context.Context, projectName string) (bool, error) {
+	err := f.record("HasBeenBuilt", projectName)
+	return slices.Contains(f.BuiltProjects, projectName), err
+}
+
+func (f *FakeEngine) GetImageInfo(_ context.Context, projectName string) (*ImageInfo, error) {
+	if err := f.record("GetImageInfo", projectName); err != nil {
+		return nil, err
+	}
+	for _, image := range f.Images {
+		if image.ProjectName != nil && *image.ProjectName == projectName {
+			return &image, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *FakeEngine) ListContainers(context.Context) ([]ContainerInfo, error) {
+	return append([]ContainerInfo{}, f.Containers...), f.record("ListContainers")
+}
+
+func (f *FakeEngine) ListNamedResources(_ context.Context, names ResourceNames) ([]NamedResource, error) {
+	resources := []NamedResource{}
+	for _, resource := range f.NamedResources {
+		wanted := map[string][]string{"container": names.Containers, "volume": names.Volumes, "network": names.Networks}[resource.Kind]
+		if slices.Contains(wanted, resource.Name) {
+			resources = append(resources, resource)
+		}
+	}
+	return resources, f.record("ListNamedResources", names)
+}
+
+func (f *FakeEngine) RemoveContainer(_ context.Context, container ContainerInfo) error {
+	return f.record("RemoveContainer", container)
+}
+
+func (f *FakeEngine) StopContainer(_ context.Context, container ContainerInfo) error {
+	return f.record("StopContainer", container)
+}
+
+func (f *FakeEngine) ListImages(context.Context) ([]ImageInfo, error) {
+	return append([]ImageInfo{}, f.Images...), f.record("ListImages")
+}
+
+func (f *FakeEngine) RemoveImage(_ context.Context, image ImageInfo) error {
+	return f.record("RemoveImage", image)
+}
+
+func (f *FakeEngine) CommitContainer(_ context.Context, container ContainerInfo, projectName string, tag string) (SnapshotInfo, error) {
+	err := f.record("CommitContainer", container, projectName, tag)
+	return SnapshotInfo{ProjectName: projectName, Tag: tag, ImageName: snapshotImageName(projectName, tag)}, err
+}
+
+func (f *FakeEngine) ListSnapshots(context.Context) ([]SnapshotInfo, error) {
+	return append([]SnapshotInfo{}, f.Snapshots...), f.record("ListSnapshots")
+}
+
+func (f *FakeEngine) RestoreSnapshot(_ context.Context, snapshot SnapshotInfo) error {
+	return f.record("RestoreSnapshot", snapshot)
+}
+
+func (f *FakeEngine) RemoveSnapshot(_ context.Context, snapshot SnapshotInfo) error {
+	return f.record("RemoveSnapshot", snapshot)
+}
+
+func (f *FakeEngine) SaveGeneration(_ context.Context, projectName string, number int) (GenerationInfo, error) {
+	err := f.record("SaveGeneration", projectName, number)
+	return GenerationInfo{ProjectName: projectName, Number: number, ImageName: generationImageName(projectName, number)}, err
+}
+
+func (f *FakeEngine) ListGenerations(context.Context) ([]GenerationInfo, error) {
+	return append([]GenerationInfo{}, f.Generations...), f.record("ListGenerations")
+}
+
+func (f *FakeEngine) RestoreGeneration(_ context.Context, generation GenerationInfo) error {
+	return f.record("RestoreGeneration", generation)
+}
+
+func (f *FakeEngine) RemoveGeneration(_ context.Context, generation GenerationInfo) error {
+	return f.record("RemoveGeneration", generation)
+}
+
+func (f *FakeEngine) SaveArchImage(_ context.Context, projectName string) (ArchImageInfo, error) {
+	return ArchImageInfo{ProjectName: projectName}, f.record("SaveArchImage", projectName)
+}
+
+func (f *FakeEngine) ListArchImages(context.Context) ([]ArchImageInfo, error) {
+	return append([]ArchImageInfo{}, f.ArchImages...), f.record("ListArchImages")
+}
+
+func (f *FakeEngine) UseArchImage(_ context.Context, image ArchImageInfo) error {
+	return f.record("UseArchImage", image)
+}
+
+func (f *FakeEngine) RemoveArchImage(_ context.Context, image ArchImageInfo) error {
+	return f.record("RemoveArchImage", image)
+}
+
+func (f *FakeEngine) EnableEmulation(_ context.Context, architectures []string) error {
+	return f.record("EnableEmulation", architectures)
+}
+
+func (f *FakeEngine) ExportImage(_ context.Context, projectName string, _ io.Writer) error {
+	return f.record("ExportImage", projectName)
+}
+
+func (f *FakeEngine) ImportImage(_ context.Context, projectName string, r io.Reader) error {
+	if err := f.record("ImportImage", projectName); err != nil {
+		return err
+	}
+	_, err := io.Copy(io.Discard, r)
+	return err
+}
+
+func (f *FakeEngine) PushImage(_ context.Context, projectName string, reference string) error {
+	return f.record("PushImage", projectName, reference)
+}
+
+func (f *FakeEngine) PullImage(_ context.Context, projectName string, reference string) error {
+	return f.record("PullImage", projectName, reference)
+}
+
+func (f *FakeEngine) ImageDigest(_ context.Context, image string, pull bool) (string, error) {
+	return "", f.record("ImageDigest", image, pull)
+}
+
+func (f *FakeEngine) TagImage(_ context.Context, image string, target string) error {
+	return f.record("TagImage", image, target)
+}
+
+func (f *FakeEngine) UntagImage(_ context.Context, image string) error {
+	return f.record("UntagImage", image)
+}
+
+func (f *FakeEngine) StartSidecar(_ context.Context, projectName string, service config.Service, sharedNetwork bool) (SidecarInfo, error) {
+	err := f.record("StartSidecar", projectName, service, sharedNetwork)
+	return SidecarInfo{
+		ProjectName:   projectName,
+		ServiceName:   service.Name,
+		ContainerName: sidecarContainerName(projectName, service.Name),
+		Running:       err == nil,
+	}, err
+}
+
+func (f *FakeEngine) ListSidecars(context.Context) ([]SidecarInfo, error) {
+	return append([]SidecarInfo{}, f.Sidecars...), f.record("ListSidecars")
+}
+
+func (f *FakeEngine) RemoveSidecar(_ context.Context, sidecar SidecarInfo) error {
+	return f.record("RemoveSidecar", sidecar)
+}
+
+func (f *FakeEngine) JoinSidecar(_ context.Context, sidecar SidecarInfo, args []string) error {
+	return f.record("JoinSidecar", sidecar, args)
+}
+
+func (f *FakeEngine) CheckSidecarHealth(_ context.Context, sidecar SidecarInfo, command string) error {
+	return f.record("CheckSidecarHealth", sidecar, command)
+}
+
+func (f *FakeEngine) GetContainerStats(context.Context) ([]ContainerStats, error) {
+	return append([]ContainerStats{}, f.Stats...), f.record("GetContainerStats")
+}
+
+func (f *FakeEngine) ListVolumes(context.Context) ([]VolumeInfo, error) {
+	return append([]VolumeInfo{}, f.Volumes...), f.record("ListVolumes")
+}
+
+func (f *FakeEngine) InspectVolume(_ context.Context, name string) (VolumeInfo, error) {
+	if err := f.record("InspectVolume", name); err != nil {
+		return VolumeInfo{}, err
+	}
+	for _, volume := range f.Volumes {
+		if volume.VolumeName == name {
+			return volume, nil
+		}
+	}
+	return VolumeInfo{}, fmt.Errorf("no such volume: %s", name)
+}
+
+func (f *FakeEngine) CheckpointContainer(_ context.Context, container ContainerInfo, exportPath string) error {
+	return f.record("CheckpointContainer", container, exportPath)
+}
+
+func (f *FakeEngine) RestoreContainer(_ context.Context, project files.ProjectEntry, importPath string) error {
+	return f.record("RestoreContainer", project.ProjectName, importPath)
+}
+
+func (f *FakeEngine) InspectToolbox(_ context.Context, name string) (ToolboxContainer, error) {
+	if err := f.record("InspectToolbox", name); err != nil {
+		return ToolboxContainer{}, err
+	}
+	if container, ok := f.Toolboxes[name]; ok {
+		return container, nil
+	}
+	return ToolboxContainer{}, fmt.Errorf("no such container: %s", name)
+}
+
+func (f *FakeEngine) GetDiskUsage(context.Context) (DiskUsage, error) {
+	return f.DiskUsage, f.record("GetDiskUsage")
+}
+
+func (f *FakeEngine) GetImageHistory(_ context.Context, projectName string) ([]ImageLayer, error) {
+	return append([]ImageLayer{}, f.ImageHistories[projectName]...), f.record("GetImageHistory", projectName)
+}
+
+func (f *FakeEngine) RemoveVolume(_ context.Context, volume VolumeInfo) error {
+	return f.record("RemoveVolume", volume)
+}
+
+func (f *FakeEngine) ExportVolume(_ context.Context, name string, _ io.Writer) error {
+	return f.record("ExportVolume", name)
+}
+
+func (f *FakeEngine) ImportVolume(_ context.Context, name string, r io.Reader) error {
+	if err := f.record("ImportVolume", name); err != nil {
+		return err
+	}
+	_, err := io.Copy(io.Discard, r)
+	return err
+}
+
+func (f *FakeEngine) ListNetworks(context.Context) ([]NetworkInfo, error) {
+	return append([]NetworkInfo{}, f.Networks...), f.record("ListNetworks")
+}
+
+func (f *FakeEngine) RemoveNetwork(_ context.Context, network NetworkInfo) error {
+	return f.record("RemoveNetwork", network)
+}
+
+func (f *FakeEngine) PruneBuildCache(context.Context) error {
+	return f.record("PruneBuildCache")
+}
+
+func (f *FakeEngine) FixOwnership(_ context.Context, dir string) error {
+	return f.record("FixOwnership", dir)
+}
+
+func (f *FakeEngine) PlayKube(_ context.Context, path string) error {
+	return f.record("PlayKube", path)
+}
+
+func (f *FakeEngine) Capabilities(context.Context) Capabilities {
+	return f.Supported
+}
@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestFakeEngine(t *testing.T) {
+	app := "app"
+	failure := errors.New("no space left on device")
+	fake := &FakeEngine{
+		Name:          "fake",
+		Images:        []ImageInfo{{ProjectName: &app, ImageName: projectImageName(app)}},
+		BuiltProjects: []string{app},
+		Errors:        map[string]error{"BuildImage": failure},
+	}
+	ctx := context.Background()
+
+	if built, err := fake.HasBeenBuilt(ctx, app); !built || err != nil {
+		t.Fatalf("HasBeenBuilt() = %t, %v, want the canned answer", built, err)
+	}
+	if image, err := fake.GetImageInfo(ctx, app); err != nil || image == nil || image.ImageName != projectImageName(app) {
+		t.Fatalf("GetImageInfo() = %+v, %v, want the canned image", image, err)
+	}
+	if image, _ := fake.GetImageInfo(ctx, "other"); image != nil {
+		t.Fatalf("GetImageInfo() of an unknown project = %+v, want nil", image)
+	}
+	project := files.ProjectEntry{ProjectName: app}
+	if err := fake.BuildImage(ctx, project, BuildOptions{}); !errors.Is(err, failure) {
+		t.Fatalf("BuildImage() error = %v, want the canned error", err)
+	}
+	if err := fake.RunContainer(ctx, project, []string{"make"}, RunOptions{}); err != nil {
+		t.Fatalf("RunContainer() error = %v", err)
+	}
+
+	calls := fake.Calls()
+	if len(calls) != 5 || calls[3].Method != "BuildImage" || calls[4].Method != "RunContainer" {
+		t.Fatalf("Calls() = %+v, want every call in order", calls)
+	}
+	runs := fake.CallsTo("RunContainer")
+	if len(runs) != 1 || runs[0].Args[0].(files.ProjectEntry).ProjectName != app {
+		t.Fatalf("CallsTo(RunContainer) = %+v, want the run of %q", runs, app)
+	}
+}
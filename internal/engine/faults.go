@@ -0,0 +1,159 @@
+// # faults.go
+// Failure injection in container engine calls, to exercise error paths of the
+// CLI (in integration tests, or when reproducing a bug report) without having
+// to break an actual engine.
+//
+// It is enabled through the `PAULENVS_FAULTS` environment variable, a comma
+// separated list of `<command>=<fault>` rules where `<command>` is the start
+// of the engine's arguments (e.g. `ps`, `image inspect`, `build`) or `*` for
+// any call, and `<fault>` one of:
+//   - `exit:<code>`: fail with that exit code without running anything
+//   - `garbage`: succeed with an unparsable output
+//   - `hang`: never return until interrupted, like an unresponsive engine
+//   - `delay:<duration>`: wait (e.g. `3s`) before actually running the call
+//
+// e.g. `PAULENVS_FAULTS="image inspect=exit:125,ps=garbage"`.
+// The first matching rule applies. Faults rely on `sh` and are thus only
+// available on Unix-like systems.
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+)
+
+const faultsEnvVar = "PAULENVS_FAULTS"
+
+type faultKind int
+
+const (
+	faultExit faultKind = iota
+	faultGarbage
+	faultHang
+	faultDelay
+)
+
+// A failure injected in the engine calls matching `command`.
+type fault struct {
+	// Arguments a call has to start with, empty for any call
+	command  []string
+	kind     faultKind
+	exitCode int
+	delay    time.Duration
+}
+
+// Faults to inject, loaded by `loadFaults`
+var injectedFaults []fault
+
+// Load the faults to inject from `PAULENVS_FAULTS`, warning if there are
+// some.
+func loadFaults(console *console.Console) error {
+	faults, err := parseFaults(os.Getenv(faultsEnvVar))
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", faultsEnvVar, err)
+	}
+	if len(faults) > 0 && len(injectedFaults) == 0 {
+		console.Warn("%s is set: failures are injected in container engine calls", faultsEnvVar)
+	}
+	injectedFaults = faults
+	return nil
+}
+
+func parseFaults(value string) ([]fault, error) {
+	var faults []fault
+	for rule := range strings.SplitSeq(value, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		command, spec, ok := strings.Cut(rule, "=")
+		if !ok {
+			return nil, fmt.Errorf("rule %q: expected <command>=<fault>", rule)
+		}
+		f := fault{}
+		if command = strings.TrimSpace(command); command != "*" {
+			f.command = strings.Fields(command)
+			if len(f.command) == 0 {
+				return nil, fmt.Errorf("rule %q: missing command", rule)
+			}
+		}
+		kind, arg, _ := strings.Cut(strings.TrimSpace(spec), ":")
+		switch kind {
+		case "exit":
+			code, err := strconv.Atoi(arg)
+			if err != nil || code <= 0 || code > 255 {
+				return nil, fmt.Errorf("rule %q: invalid exit code %q", rule, arg)
+			}
+			f.kind, f.exitCode = faultExit, code
+		case "garbage":
+			f.kind = faultGarbage
+		case "hang":
+			f.kind = faultHang
+		case "delay":
+			delay, err := time.ParseDuration(arg)
+			if err != nil || delay < 0 {
+				return nil, fmt.Errorf("rule %q: invalid delay %q", rule, arg)
+			}
+			f.kind, f.delay = faultDelay, delay
+		default:
+			return nil, fmt.Errorf("rule %q: unknown fault %q, expected exit:<code>, garbage, hang or delay:<duration>", rule, spec)
+		}
+		faults = append(faults, f)
+	}
+	return faults, nil
+}
+
+func (f fault) matches(args []string) bool {
+	if len(f.command) > len(args) {
+		return false
+	}
+	for i, arg := range f.command {
+		if args[i] != arg {
+			return false
+		}
+	}
+	return true
+}
+
+// Create the command calling the given container engine binary with `args`,
+// or one simulating the first injected fault matching them.
+func engineCommand(ctx context.Context, binary string, args ...string) *exec.Cmd {
+	var cmd *exec.Cmd
+	for _, f := range injectedFaults {
+		if f.matches(args) {
+			cmd = f.simulate(ctx, binary, args)
+			break
+		}
+	}
+	if cmd == nil {
+		if path, err := lookEngineExecutable(binary); err == nil {
+			binary = path
+		}
+		cmd = exec.CommandContext(ctx, binary, args...)
+	}
+	interruptOnCancel(cmd)
+	return cmd
+}
+
+func (f fault) simulate(ctx context.Context, binary string, args []string) *exec.Cmd {
+	var script string
+	switch f.kind {
+	case faultExit:
+		script = fmt.Sprintf(`echo "Error: injected fault (exit %d)" >&2; exit %d`, f.exitCode, f.exitCode)
+	case faultGarbage:
+		script = `printf '\001\002injected\tgarbage\nnot an engine output\n'`
+	case faultHang:
+		script = `while :; do sleep 1; done`
+	case faultDelay:
+		script = fmt.Sprintf(`sleep %s; exec "$@"`, strconv.FormatFloat(f.delay.Seconds(), 'f', -1, 64))
+	}
+	return exec.CommandContext(ctx, "sh", append([]string{"-c", script, binary, binary}, args...)...)
+}
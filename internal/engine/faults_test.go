@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFaults(t *testing.T) {
+	faults, err := parseFaults("image inspect=exit:125, ps=garbage,*=delay:1.5s,build=hang")
+	if err != nil {
+		t.Fatalf("parseFaults() error = %v", err)
+	}
+	if len(faults) != 4 {
+		t.Fatalf("parseFaults() = %d faults, want 4", len(faults))
+	}
+	if faults[0].kind != faultExit || faults[0].exitCode != 125 || strings.Join(faults[0].command, " ") != "image inspect" {
+		t.Fatalf("parseFaults()[0] = %+v", faults[0])
+	}
+	if faults[1].kind != faultGarbage || faults[3].kind != faultHang {
+		t.Fatalf("parseFaults() = %+v", faults)
+	}
+	if faults[2].kind != faultDelay || faults[2].delay != 1500*time.Millisecond || faults[2].command != nil {
+		t.Fatalf("parseFaults()[2] = %+v", faults[2])
+	}
+	if faults, err := parseFaults(""); err != nil || len(faults) != 0 {
+		t.Fatalf("parseFaults(\"\") = %v, %v, want none", faults, err)
+	}
+
+	for _, value := range []string{"ps", "ps=exit", "ps=exit:0", "ps=exit:300", "ps=crash", "=garbage", "ps=delay:soon"} {
+		if _, err := parseFaults(value); err == nil {
+			t.Fatalf("parseFaults(%q) expected an error", value)
+		}
+	}
+}
+
+func TestEngineCommand_Faults(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh available")
+	}
+	previous := injectedFaults
+	t.Cleanup(func() { injectedFaults = previous })
+	ctx := context.Background()
+
+	var err error
+	injectedFaults, err = parseFaults("image inspect=exit:125,ps=garbage,volume=delay:0s,network=hang")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = engineCommand(ctx, "podman", "image", "inspect", "paulenv:myproject").Run()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 125 {
+		t.Fatalf("exit fault: got %v, want exit code 125", err)
+	}
+	if !quirksFor("podman", "5.2.2").isMissingImage(err) {
+		t.Fatal("exit fault: podman should see a missing image")
+	}
+
+	output, err := engineCommand(ctx, "docker", "ps", "-a").Output()
+	if err != nil || !strings.Contains(string(output), "garbage") {
+		t.Fatalf("garbage fault: got %q, %v", output, err)
+	}
+
+	output, err = engineCommand(ctx, "echo", "volume", "ls").Output()
+	if err != nil || string(output) != "volume ls\n" {
+		t.Fatalf("delay fault: got %q, %v, want the actual command output", output, err)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if err := engineCommand(timeout, "docker", "network", "ls").Run(); err == nil {
+		t.Fatal("hang fault: expected the command to be interrupted")
+	}
+
+	output, err = engineCommand(ctx, "echo", "info").Output()
+	if err != nil || string(output) != "info\n" {
+		t.Fatalf("no fault: got %q, %v, want the actual command output", output, err)
+	}
+}
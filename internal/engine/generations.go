@@ -0,0 +1,51 @@
+// # generations.go
+// Generations are the previously built images of a project, kept when it is
+// rebuilt so a bad rebuild can be rolled back.
+
+package engine
+
+import (
+	"strconv"
+	"time"
+)
+
+// Information on a previously built image of a project.
+type GenerationInfo struct {
+	// The name of the corresponding paulenv project
+	ProjectName string
+	// Increasing number of that generation, per project
+	Number int
+	// The name it is actually refered to by the container engine.
+	ImageName string
+	// The timestamp at which it has been built, `nil` if unknown.
+	BuiltAt *time.Time
+	// Disk usage of that image as reported by the container engine, empty if
+	// unknown.
+	Size string
+}
+
+// Name of the image of the given generation.
+func generationImageName(projectName string, number int) string {
+	return projectTaggedImageName("paulenv-generation", projectName, strconv.Itoa(number))
+}
+
+// Parse the output of an `images` command listing
+// `{{.Repository}}:{{.Tag}}\t{{.CreatedAt}}\t{{.Size}}` into the generations
+// it contains.
+func parseGenerationList(output string, quirks engineQuirks) []GenerationInfo {
+	var result []GenerationInfo
+	for _, image := range parseProjectTaggedImages(output, "paulenv-generation", quirks) {
+		number, err := strconv.Atoi(image.tag)
+		if err != nil || number <= 0 {
+			continue
+		}
+		result = append(result, GenerationInfo{
+			ProjectName: image.projectName,
+			Number:      number,
+			ImageName:   image.imageName,
+			BuiltAt:     image.createdAt,
+			Size:        image.size,
+		})
+	}
+	return result
+}
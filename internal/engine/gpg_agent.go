@@ -0,0 +1,60 @@
+// # gpg_agent.go
+// Forwarding of the host's gpg-agent to containers (`GPG_AGENT` directive),
+// so commits can be signed there without exporting any secret key.
+//
+// Its "extra" socket is the one forwarded: gpg-agent provides it for remote
+// uses, through which keys can be used but neither exported nor deleted.
+
+package engine
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Where the host's gpg-agent extra socket is mounted in containers.
+const containerGPGAgentSocket = containerRuntimeDir + "/gpg-agent.sock"
+
+// Where the host's gpg public keys are mounted in containers.
+const containerGPGKeysDir = "/tmp/paulenv-gpg-keys"
+
+// Path of the host's gpg-agent extra socket, as gnupg configures it, empty if
+// gnupg is not installed.
+var gpgAgentExtraSocket = func() string {
+	output, err := exec.Command("gpgconf", "--list-dirs", "agent-extra-socket").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// The socket of the host's gpg-agent to forward, empty if none.
+//
+// On macOS, engines run containers in a virtual machine which host sockets
+// cannot reach.
+func detectHostGPGAgent() string {
+	if hostOS == "darwin" {
+		return ""
+	}
+	socket := gpgAgentExtraSocket()
+	if socket == "" || !fileExists(socket) {
+		return ""
+	}
+	return socket
+}
+
+// Arguments of the `run` command forwarding the given gpg-agent socket with
+// the host's public keys.
+func gpgAgentRunArgs(socket string, project files.ProjectEntry) []string {
+	if socket == "" {
+		return nil
+	}
+	return []string{
+		"--volume", socket + ":" + containerGPGAgentSocket,
+		"--volume", project.GPGKeysDir + ":" + containerGPGKeysDir + ":ro",
+		"--env", "PAULENV_GPG_AGENT=" + containerGPGAgentSocket,
+		"--env", "PAULENV_GPG_KEYS=" + containerGPGKeysDir,
+	}
+}
@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func stubGPGAgentExtraSocket(t *testing.T, socket string) {
+	t.Helper()
+	prev := gpgAgentExtraSocket
+	t.Cleanup(func() { gpgAgentExtraSocket = prev })
+	gpgAgentExtraSocket = func() string { return socket }
+}
+
+func TestDetectHostGPGAgent(t *testing.T) {
+	previousOS := hostOS
+	t.Cleanup(func() { hostOS = previousOS })
+	hostOS = "linux"
+
+	const socket = "/run/user/1000/gnupg/S.gpg-agent.extra"
+	stubGPGAgentExtraSocket(t, socket)
+	stubHost(t, nil, socket)
+	if got := detectHostGPGAgent(); got != socket {
+		t.Fatalf("detectHostGPGAgent() = %q, want %q", got, socket)
+	}
+
+	stubHost(t, nil)
+	if got := detectHostGPGAgent(); got != "" {
+		t.Fatalf("detectHostGPGAgent() = %q, want none for a missing socket", got)
+	}
+
+	stubGPGAgentExtraSocket(t, "")
+	if got := detectHostGPGAgent(); got != "" {
+		t.Fatalf("detectHostGPGAgent() = %q, want none without gnupg", got)
+	}
+
+	hostOS = "darwin"
+	stubGPGAgentExtraSocket(t, socket)
+	stubHost(t, nil, socket)
+	if got := detectHostGPGAgent(); got != "" {
+		t.Fatalf("detectHostGPGAgent() = %q on macOS, want none", got)
+	}
+}
+
+func TestGPGAgentRunArgs(t *testing.T) {
+	project := files.ProjectEntry{GPGKeysDir: "/data/demo/.internal/gpg-keys"}
+	want := []string{
+		"--volume", "/run/user/1000/gnupg/S.gpg-agent.extra:/tmp/paulenv-runtime/gpg-agent.sock",
+		"--volume", "/data/demo/.internal/gpg-keys:/tmp/paulenv-gpg-keys:ro",
+		"--env", "PAULENV_GPG_AGENT=/tmp/paulenv-runtime/gpg-agent.sock",
+		"--env", "PAULENV_GPG_KEYS=/tmp/paulenv-gpg-keys",
+	}
+	if got := gpgAgentRunArgs("/run/user/1000/gnupg/S.gpg-agent.extra", project); !slices.Equal(got, want) {
+		t.Fatalf("gpgAgentRunArgs() = %v, want %v", got, want)
+	}
+	if got := gpgAgentRunArgs("", project); got != nil {
+		t.Fatalf("gpgAgentRunArgs() without agent = %v, want none", got)
+	}
+}
@@ -0,0 +1,60 @@
+// # groups.go
+// Supplementary host groups given to the container user (e.g. to access GPUs,
+// serial ports or `/dev/kvm`).
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os/user"
+	"strings"
+)
+
+// Isolated for tests
+var lookupGroup = user.LookupGroup
+
+// Resolve the given host group names into their GID, failing if one does not
+// exist on the host.
+func resolveHostGroups(names []string) ([]string, error) {
+	gids := make([]string, 0, len(names))
+	for _, name := range names {
+		group, err := lookupGroup(name)
+		if err != nil {
+			var unknown user.UnknownGroupError
+			if errors.As(err, &unknown) {
+				return nil, fmt.Errorf("GROUP %q does not exist on this host", name)
+			}
+			return nil, fmt.Errorf("cannot look up GROUP %q: %w", name, err)
+		}
+		gids = append(gids, group.Gid)
+	}
+	return gids, nil
+}
+
+// Arguments of the `run` command adding the given host groups to the
+// container user.
+//
+// Rootless Podman is refused: host groups are not mapped into its user
+// namespace, so they would silently be ineffective.
+func groupRunArgs(groups []string, rootlessPodman bool) ([]string, error) {
+	// See `UnsupportedDirectives`
+	if len(groups) == 0 || hostOS == "windows" {
+		return nil, nil
+	}
+	if rootlessPodman {
+		return nil, errors.New("GROUP directives are not supported with rootless Podman, " +
+			"which cannot map host groups into containers")
+	}
+	gids, err := resolveHostGroups(groups)
+	if err != nil {
+		return nil, err
+	}
+	var args []string
+	for _, gid := range gids {
+		args = append(args, "--group-add", gid)
+	}
+	// The entrypoint registers them, as switching to the container user resets
+	// its groups
+	return append(args, "--env", "PAULENV_GROUP_IDS="+strings.Join(gids, ",")), nil
+}
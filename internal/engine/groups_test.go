@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"os/user"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func stubHostGroups(t *testing.T, groups map[string]string) {
+	t.Helper()
+	prev := lookupGroup
+	t.Cleanup(func() { lookupGroup = prev })
+	lookupGroup = func(name string) (*user.Group, error) {
+		gid, ok := groups[name]
+		if !ok {
+			return nil, user.UnknownGroupError(name)
+		}
+		return &user.Group{Gid: gid, Name: name}, nil
+	}
+}
+
+func TestGroupRunArgs(t *testing.T) {
+	stubHostGroups(t, map[string]string{"video": "44", "kvm": "992"})
+
+	got, err := groupRunArgs([]string{"video", "kvm"}, false)
+	if err != nil {
+		t.Fatalf("groupRunArgs() error = %v", err)
+	}
+	want := []string{"--group-add", "44", "--group-add", "992", "--env", "PAULENV_GROUP_IDS=44,992"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("groupRunArgs() = %v, want %v", got, want)
+	}
+
+	if got, err := groupRunArgs(nil, true); err != nil || got != nil {
+		t.Fatalf("groupRunArgs() = %v, %v without groups, want nothing", got, err)
+	}
+}
+
+func TestGroupRunArgs_Errors(t *testing.T) {
+	stubHostGroups(t, map[string]string{"video": "44"})
+
+	_, err := groupRunArgs([]string{"video", "dialout"}, false)
+	if err == nil || !strings.Contains(err.Error(), `"dialout" does not exist`) {
+		t.Fatalf("groupRunArgs() error = %v, want unknown group error", err)
+	}
+	if _, err := groupRunArgs([]string{"video"}, true); err == nil {
+		t.Fatal("groupRunArgs() expected an error with rootless Podman")
+	}
+}
@@ -0,0 +1,106 @@
+// # hardening.go
+// Projects opening untrusted code can run with a restricted security profile
+// (`HARDENED` in run.conf): a read-only root filesystem, no way to gain new
+// privileges (e.g. through `sudo`) and only the capabilities the entrypoint
+// needs to set up the container before switching to its user.
+//
+// A seccomp profile and masked paths can also be set, with or without it.
+
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Capabilities kept by hardened containers: those needed by the entrypoint to
+// initialize volumes and the home directory, then to switch to the container
+// user.
+var hardenedCapabilities = []string{"CHOWN", "DAC_OVERRIDE", "FOWNER", "SETUID", "SETGID", "KILL", "AUDIT_WRITE"}
+
+// Directories written by the entrypoint and the user's tools outside of
+// volumes, mounted as tmpfs in hardened containers.
+var hardenedTmpfsDirs = []string{"/tmp", "/var/tmp"}
+
+// Arguments of the `run` command applying the security settings of the
+// project's run.conf.
+//
+// Podman mounts its own tmpfs on a read-only root filesystem and can mask
+// paths natively, which `podman` tells.
+func securityRunArgs(project files.ProjectEntry, runtimeCfg config.RuntimeConfig, username string, podman bool) ([]string, error) {
+	var args []string
+	if runtimeCfg.Hardened {
+		args = append(args, "--read-only")
+		if podman {
+			args = append(args, "--read-only-tmpfs=false")
+		}
+		for _, dir := range hardenedTmpfsDirs {
+			// The project may set its own size or mode for it
+			if !hasTmpfs(runtimeCfg, dir) {
+				args = append(args, "--tmpfs", dir)
+			}
+		}
+		// Anonymous volume initialized from the image, so the entrypoint can
+		// write its shell overrides and sync dotfiles as usual
+		args = append(args, "--volume", "/home/"+username)
+		args = append(args, "--security-opt", "no-new-privileges", "--cap-drop", "ALL")
+		for _, capability := range append(hardenedCapabilities, runtimeCfg.HardenedCapabilities...) {
+			args = append(args, "--cap-add", capability)
+		}
+	}
+	if runtimeCfg.SeccompProfile != "" {
+		profile, err := seccompProfilePath(project, runtimeCfg)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--security-opt", "seccomp="+hostPath(profile))
+	}
+	masked := maskedPaths(project, runtimeCfg, username)
+	if len(masked) == 0 {
+		return args, nil
+	}
+	if podman {
+		return append(args, "--security-opt", "mask="+strings.Join(masked, ":")), nil
+	}
+	// Docker has no option for it, an empty read-only tmpfs hides directories
+	for _, maskedPath := range masked {
+		args = append(args, "--tmpfs", maskedPath+":ro")
+	}
+	return args, nil
+}
+
+// Returns `true` if the project declares a tmpfs on that container path.
+func hasTmpfs(runtimeCfg config.RuntimeConfig, target string) bool {
+	return slices.ContainsFunc(runtimeCfg.Tmpfs, func(m config.TmpfsMount) bool { return m.Target == target })
+}
+
+// Resolve the seccomp profile of a project, which has to exist on the host.
+func seccompProfilePath(project files.ProjectEntry, runtimeCfg config.RuntimeConfig) (string, error) {
+	profile, err := resolveRuntimePath(project.RuntimeConfigPath, runtimeCfg.SeccompProfile)
+	if err != nil {
+		return "", fmt.Errorf("resolve SECCOMP_PROFILE: %w", err)
+	}
+	if _, err := os.Stat(profile); err != nil {
+		return "", fmt.Errorf("cannot apply seccomp profile: %s does not exist\nHint: Fix or remove the SECCOMP_PROFILE of %s",
+			profile, project.RuntimeConfigPath)
+	}
+	return profile, nil
+}
+
+// Absolute container paths masked in a project's container.
+func maskedPaths(project files.ProjectEntry, runtimeCfg config.RuntimeConfig, username string) []string {
+	masked := make([]string, 0, len(runtimeCfg.MaskedPaths))
+	for _, maskedPath := range runtimeCfg.MaskedPaths {
+		if !path.IsAbs(maskedPath) {
+			maskedPath = path.Join(projectMountTarget(username, project.ProjectName), maskedPath)
+		}
+		masked = append(masked, path.Clean(maskedPath))
+	}
+	return masked
+}
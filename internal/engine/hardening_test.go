@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestSecurityRunArgs_Hardened(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: "/tmp/demo/run.conf"}
+	runtimeCfg := config.RuntimeConfig{Hardened: true, HardenedCapabilities: []string{"NET_BIND_SERVICE"}}
+
+	args, err := securityRunArgs(project, runtimeCfg, "dev", false)
+	if err != nil {
+		t.Fatalf("securityRunArgs() error = %v", err)
+	}
+	joined := strings.Join(args, " ")
+	for _, fragment := range []string{
+		"--read-only --tmpfs /tmp --tmpfs /var/tmp",
+		"--volume /home/dev",
+		"--security-opt no-new-privileges --cap-drop ALL",
+		"--cap-add SETUID",
+		"--cap-add NET_BIND_SERVICE",
+	} {
+		if !strings.Contains(joined, fragment) {
+			t.Fatalf("securityRunArgs() should contain %q, got %v", fragment, args)
+		}
+	}
+	if slices.Contains(args, "--read-only-tmpfs=false") {
+		t.Fatalf("securityRunArgs() should not use Podman flags with Docker, got %v", args)
+	}
+
+	args, err = securityRunArgs(project, runtimeCfg, "dev", true)
+	if err != nil || !slices.Contains(args, "--read-only-tmpfs=false") {
+		t.Fatalf("securityRunArgs() with Podman = %v, %v, want its own tmpfs disabled", args, err)
+	}
+
+	args, err = securityRunArgs(project, config.RuntimeConfig{}, "dev", false)
+	if err != nil || len(args) != 0 {
+		t.Fatalf("securityRunArgs() without settings = %v, %v, want no argument", args, err)
+	}
+}
+
+func TestSecurityRunArgs_SeccompAndMasks(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "seccomp.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: filepath.Join(dir, "run.conf")}
+	runtimeCfg := config.RuntimeConfig{SeccompProfile: "seccomp.json", MaskedPaths: []string{"secrets", "/etc/hostname"}}
+
+	args, err := securityRunArgs(project, runtimeCfg, "dev", false)
+	if err != nil {
+		t.Fatalf("securityRunArgs() error = %v", err)
+	}
+	want := []string{
+		"--security-opt", "seccomp=" + filepath.Join(dir, "seccomp.json"),
+		"--tmpfs", "/home/dev/projects/demo/secrets:ro",
+		"--tmpfs", "/etc/hostname:ro",
+	}
+	if !slices.Equal(args, want) {
+		t.Fatalf("securityRunArgs() = %v, want %v", args, want)
+	}
+
+	args, err = securityRunArgs(project, runtimeCfg, "dev", true)
+	if err != nil || args[len(args)-1] != "mask=/home/dev/projects/demo/secrets:/etc/hostname" {
+		t.Fatalf("securityRunArgs() with Podman = %v, %v, want native masks", args, err)
+	}
+
+	runtimeCfg.SeccompProfile = "missing.json"
+	if _, err := securityRunArgs(project, runtimeCfg, "dev", false); err == nil {
+		t.Fatal("securityRunArgs() should fail with a missing seccomp profile")
+	}
+}
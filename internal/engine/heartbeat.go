@@ -0,0 +1,135 @@
+// # heartbeat.go
+// Builds can stay silent for a long time, e.g. while a large layer is being
+// downloaded or compressed, which cannot be told apart from a build stuck on
+// an unresponsive registry.
+//
+// While a build runs without output, a heartbeat reporting the elapsed time
+// is thus printed regularly and, past a longer threshold, the likely causes
+// of a stall are listed.
+
+package engine
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Defaults for `BuildOptions.Heartbeat` and `BuildOptions.StallThreshold`.
+const (
+	DefaultBuildHeartbeat      = 30 * time.Second
+	DefaultBuildStallThreshold = 5 * time.Minute
+)
+
+const stallHint = `Likely causes:
+  - a registry or package mirror not answering: check your network, proxy and mirror settings
+  - DNS resolution hanging during the build: check the engine's DNS configuration
+  - IO pressure on the host: check the free disk space and disk activity (e.g. 'df -h', 'iostat')
+Press Ctrl+C to cancel the build.`
+
+// Tracks the output of a running build to report silent periods.
+type buildWatch struct {
+	mu             sync.Mutex
+	heartbeat      time.Duration
+	stallThreshold time.Duration
+	start          time.Time
+	lastOutput     time.Time
+	// Heartbeats printed since the last output
+	heartbeats    int
+	stallReported bool
+}
+
+func newBuildWatch(options BuildOptions, now time.Time) *buildWatch {
+	return &buildWatch{
+		heartbeat:      options.Heartbeat,
+		stallThreshold: options.StallThreshold,
+		start:          now,
+		lastOutput:     now,
+	}
+}
+
+func (w *buildWatch) onOutput(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lastOutput = now
+	w.heartbeats = 0
+	w.stallReported = false
+}
+
+// Returns the message to report at `now`, empty if there's none.
+func (w *buildWatch) check(now time.Time) string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	idle := now.Sub(w.lastOutput)
+	elapsed := now.Sub(w.start).Round(time.Second)
+	if w.stallThreshold > 0 && !w.stallReported && idle >= w.stallThreshold {
+		w.stallReported = true
+		if w.heartbeat > 0 {
+			w.heartbeats = int(idle / w.heartbeat)
+		}
+		return fmt.Sprintf("paul-envs: no build output for %s (%s elapsed), it may be stalled.\n%s",
+			idle.Round(time.Second), elapsed, stallHint)
+	}
+	if w.heartbeat > 0 && idle >= w.heartbeat*time.Duration(w.heartbeats+1) {
+		w.heartbeats = int(idle / w.heartbeat)
+		return fmt.Sprintf("paul-envs: still building, %s elapsed (no output for %s)",
+			elapsed, idle.Round(time.Second))
+	}
+	return ""
+}
+
+// Writer signaling a `buildWatch` each time something is written through it.
+type activityWriter struct {
+	out   io.Writer
+	watch *buildWatch
+}
+
+func (a activityWriter) Write(p []byte) (int, error) {
+	a.watch.onOutput(time.Now())
+	return a.out.Write(p)
+}
+
+// Run the given build command, writing its output as set in `options` and
+// reporting silent periods on its error output.
+func runBuildCommand(cmd *exec.Cmd, options BuildOptions) error {
+	stdout, stderr := buildOutputs(options)
+	if options.Heartbeat <= 0 && options.StallThreshold <= 0 {
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+		return runEngineCommand(cmd)
+	}
+
+	watch := newBuildWatch(options, time.Now())
+	// Serializes reports with the build's own error output
+	var stderrMu sync.Mutex
+	lockedStderr := writerFunc(func(p []byte) (int, error) {
+		stderrMu.Lock()
+		defer stderrMu.Unlock()
+		return stderr.Write(p)
+	})
+	cmd.Stdout = activityWriter{out: stdout, watch: watch}
+	cmd.Stderr = activityWriter{out: lockedStderr, watch: watch}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				if msg := watch.check(now); msg != "" {
+					fmt.Fprintln(lockedStderr, msg)
+				}
+			}
+		}
+	}()
+	return runEngineCommand(cmd)
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
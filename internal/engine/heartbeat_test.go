@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildWatch(t *testing.T) {
+	start := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+	watch := newBuildWatch(BuildOptions{Heartbeat: 30 * time.Second, StallThreshold: 2 * time.Minute}, start)
+
+	if msg := watch.check(at(10 * time.Second)); msg != "" {
+		t.Fatalf("check() before the heartbeat period = %q, want nothing", msg)
+	}
+	msg := watch.check(at(30 * time.Second))
+	if !strings.Contains(msg, "still building, 30s elapsed") {
+		t.Fatalf("check() after the heartbeat period = %q, want a heartbeat", msg)
+	}
+	if msg := watch.check(at(40 * time.Second)); msg != "" {
+		t.Fatalf("check() right after a heartbeat = %q, want nothing", msg)
+	}
+	if msg := watch.check(at(60 * time.Second)); !strings.Contains(msg, "no output for 1m0s") {
+		t.Fatalf("check() one period after a heartbeat = %q, want another heartbeat", msg)
+	}
+
+	watch.onOutput(at(70 * time.Second))
+	if msg := watch.check(at(90 * time.Second)); msg != "" {
+		t.Fatalf("check() after some output = %q, want nothing", msg)
+	}
+	msg = watch.check(at(190 * time.Second))
+	if !strings.Contains(msg, "may be stalled") || !strings.Contains(msg, "Ctrl+C") {
+		t.Fatalf("check() after the stall threshold = %q, want a stall report", msg)
+	}
+	if msg := watch.check(at(191 * time.Second)); strings.Contains(msg, "stalled") {
+		t.Fatalf("check() after a stall report = %q, want it reported only once", msg)
+	}
+}
+
+func TestBuildWatch_Disabled(t *testing.T) {
+	start := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	watch := newBuildWatch(BuildOptions{}, start)
+	if msg := watch.check(start.Add(time.Hour)); msg != "" {
+		t.Fatalf("check() without heartbeat nor stall threshold = %q, want nothing", msg)
+	}
+}
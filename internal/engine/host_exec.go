@@ -0,0 +1,49 @@
+// # host_exec.go
+// Containers can run some of the host's commands (`HOST_COMMAND` directive),
+// e.g. `xdg-open` to open a URL in the host's browser, through the
+// `paulenv-host` helper their entrypoint installs. It forwards them to
+// `paul-envs run` over a socket mounted in the container.
+//
+// The same socket gives them the host's clipboard (`CLIPBOARD` directive),
+// through a `paulenv-clipboard` helper also installed in place of the usual
+// clipboard commands (`pbcopy`, `wl-copy`, `xclip`...) missing from the
+// image, which editors look for.
+
+package engine
+
+import (
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Where the directory of the socket serving host commands is mounted in
+// containers.
+const containerHostExecDir = "/tmp/paulenv-host-exec"
+
+// Arguments of the `run` command giving the container the directory where
+// `run` serves the given host commands and, if asked, the host's clipboard.
+func hostExecRunArgs(project files.ProjectEntry, commands []string, clipboard bool) []string {
+	args := []string{
+		"--volume", project.HostExecDir + ":" + containerHostExecDir,
+		"--env", "PAULENV_HOST_EXEC=" + containerHostExecDir + "/" + files.HostExecSocketName,
+	}
+	if len(commands) > 0 {
+		// Lets the entrypoint forward those missing from the image
+		args = append(args, "--env", "PAULENV_HOST_COMMANDS="+strings.Join(commands, " "))
+	}
+	if clipboard {
+		args = append(args, "--env", "PAULENV_CLIPBOARD=true")
+	}
+	return args
+}
+
+// Returns the directory where the project's directory is mounted in its
+// container.
+func ProjectMountDir(project files.ProjectEntry) (string, error) {
+	buildCfg, err := loadBuildConfig(project)
+	if err != nil {
+		return "", err
+	}
+	return projectMountTarget(buildCfg.Args["USERNAME"], project.ProjectName), nil
+}
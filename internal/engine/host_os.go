@@ -0,0 +1,128 @@
+// # host_os.go
+// Specifics of Windows hosts, where engines run their containers in a Linux
+// virtual machine:
+//   - their CLI is not always in the PATH,
+//   - POSIX-only features (unix sockets, host groups) cannot be shared with
+//     containers,
+//   - from WSL, only the Windows CLI of an engine (e.g. `docker.exe`) may be
+//     reachable, which does not understand the paths of the WSL distribution.
+
+package engine
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+// Isolated for tests
+var hostOS = runtime.GOOS
+
+// Where the installers of each engine put their CLI, relative to the Program
+// Files directory.
+var windowsEngineLocations = map[string]string{
+	"docker": filepath.Join("Docker", "Docker", "resources", "bin", "docker.exe"),
+	"podman": filepath.Join("RedHat", "Podman", "podman.exe"),
+}
+
+// Set once the CLI of an engine is a Windows one run from WSL, whose host
+// paths then have to be translated.
+var windowsCLIFromWSL atomic.Bool
+
+// The CLI of the given engine ("docker" or "podman"): `name` itself if it is
+// in the PATH, otherwise the path where its installer puts it on Windows or,
+// in WSL, the one of the Windows CLI.
+func lookEngineExecutable(name string) (string, error) {
+	_, err := exec.LookPath(name)
+	if err == nil {
+		return name, nil
+	}
+	switch {
+	case hostOS == "windows":
+		if location, ok := windowsEngineLocations[name]; ok {
+			programFiles := os.Getenv("ProgramFiles")
+			if programFiles == "" {
+				programFiles = `C:\Program Files`
+			}
+			if candidate := filepath.Join(programFiles, location); fileExists(candidate) {
+				return candidate, nil
+			}
+		}
+	case isWSL():
+		if windowsCLI, wslErr := exec.LookPath(name + ".exe"); wslErr == nil {
+			windowsCLIFromWSL.Store(true)
+			return windowsCLI, nil
+		}
+	}
+	return "", err
+}
+
+// Returns `true` when running in a WSL distribution.
+func isWSL() bool {
+	return hostOS == "linux" && (getenv("WSL_DISTRO_NAME") != "" || fileExists("/proc/sys/fs/binfmt_misc/WSLInterop"))
+}
+
+// The given path of this host as the engine's CLI understands it.
+func hostPath(path string) string {
+	if !windowsCLIFromWSL.Load() {
+		return path
+	}
+	// e.g. "/mnt/c/Users/me" to "C:\Users\me" and "/home/me" to
+	// "\\wsl.localhost\<distribution>\home\me"
+	output, err := exec.Command("wslpath", "-w", path).Output()
+	if err != nil {
+		return path
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// Descriptions of the directives of the given runtime configuration which
+// are ignored on this host as it cannot share what they need with containers.
+func UnsupportedDirectives(runtimeCfg config.RuntimeConfig) []string {
+	if hostOS != "windows" {
+		var unsupported []string
+		if runtimeCfg.SSHAgent && detectHostSSHAgent() == "" {
+			unsupported = append(unsupported, "SSH_AGENT: no ssh agent is running (SSH_AUTH_SOCK is not set)")
+		}
+		if runtimeCfg.GPGAgent && hostOS == "darwin" {
+			unsupported = append(unsupported, "GPG_AGENT: macOS sockets cannot be given to the engine's virtual machine")
+		} else if runtimeCfg.GPGAgent && detectHostGPGAgent() == "" {
+			unsupported = append(unsupported, "GPG_AGENT: no gpg-agent extra socket found (is gnupg installed?)")
+		}
+		return unsupported
+	}
+	var unsupported []string
+	if runtimeCfg.Display {
+		unsupported = append(unsupported, "DISPLAY: Windows has no X11 or Wayland socket to forward")
+	}
+	if runtimeCfg.Audio {
+		unsupported = append(unsupported, "AUDIO: Windows has no PulseAudio or PipeWire socket to forward")
+	}
+	if len(runtimeCfg.Groups) > 0 {
+		unsupported = append(unsupported, "GROUP: Windows groups cannot be given to containers")
+	}
+	if runtimeCfg.Timezone == config.HostSetting && hostTimezone() == "" {
+		unsupported = append(unsupported, "TIMEZONE host: the Windows timezone cannot be given to containers, set TZ or a timezone name")
+	}
+	if runtimeCfg.SSHAgent {
+		unsupported = append(unsupported, "SSH_AGENT: the Windows ssh agent is a named pipe, which cannot be given to containers")
+	}
+	if runtimeCfg.GitCredentials {
+		unsupported = append(unsupported, "GIT_CREDENTIALS: unix sockets cannot be shared with containers from Windows")
+	}
+	if runtimeCfg.GPGAgent {
+		unsupported = append(unsupported, "GPG_AGENT: the Windows gpg-agent socket cannot be given to containers")
+	}
+	if len(runtimeCfg.HostCommands) > 0 {
+		unsupported = append(unsupported, "HOST_COMMAND: unix sockets cannot be shared with containers from Windows")
+	}
+	if runtimeCfg.Clipboard {
+		unsupported = append(unsupported, "CLIPBOARD: unix sockets cannot be shared with containers from Windows")
+	}
+	return unsupported
+}
@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+func TestLookEngineExecutableOnWindows(t *testing.T) {
+	programFiles := t.TempDir()
+	t.Setenv("PATH", "")
+	t.Setenv("ProgramFiles", programFiles)
+	previousOS := hostOS
+	hostOS = "windows"
+	t.Cleanup(func() { hostOS = previousOS })
+
+	if _, err := lookEngineExecutable("podman"); err == nil {
+		t.Fatal("expected an error when podman is not installed")
+	}
+	installed := filepath.Join(programFiles, windowsEngineLocations["podman"])
+	if err := os.MkdirAll(filepath.Dir(installed), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(installed, nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	got, err := lookEngineExecutable("podman")
+	if err != nil {
+		t.Fatalf("lookEngineExecutable() error = %v", err)
+	}
+	if got != installed {
+		t.Fatalf("lookEngineExecutable() = %q, want %q", got, installed)
+	}
+}
+
+func TestIsWSL(t *testing.T) {
+	previousOS, previousGetenv, previousExists := hostOS, getenv, fileExists
+	t.Cleanup(func() { hostOS, getenv, fileExists = previousOS, previousGetenv, previousExists })
+	fileExists = func(string) bool { return false }
+
+	hostOS = "linux"
+	getenv = func(key string) string { return "" }
+	if isWSL() {
+		t.Fatal("isWSL() = true outside of WSL")
+	}
+	getenv = func(key string) string {
+		if key == "WSL_DISTRO_NAME" {
+			return "Ubuntu"
+		}
+		return ""
+	}
+	if !isWSL() {
+		t.Fatal("isWSL() = false with WSL_DISTRO_NAME set")
+	}
+	hostOS = "windows"
+	if isWSL() {
+		t.Fatal("isWSL() = true on Windows")
+	}
+}
+
+func TestUnsupportedDirectives(t *testing.T) {
+	previousOS := hostOS
+	t.Cleanup(func() { hostOS = previousOS })
+	cfg := config.RuntimeConfig{Display: true, Audio: true, Groups: []string{"video"}}
+
+	hostOS = "linux"
+	if got := UnsupportedDirectives(cfg); len(got) != 0 {
+		t.Fatalf("UnsupportedDirectives() on linux = %v, want none", got)
+	}
+
+	hostOS = "windows"
+	got := UnsupportedDirectives(cfg)
+	if len(got) != 3 {
+		t.Fatalf("UnsupportedDirectives() on windows = %v, want 3 directives", got)
+	}
+	if args, err := groupRunArgs(cfg.Groups, false); err != nil || len(args) != 0 {
+		t.Fatalf("groupRunArgs() on windows = %v, %v, want nothing", args, err)
+	}
+}
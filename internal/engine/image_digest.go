@@ -0,0 +1,37 @@
+// # image_digest.go
+// A tag like "ubuntu:24.04" is moved to newer images over time, so two builds
+// from it may not start from the same image. The distribution image the shared
+// base image is built from is thus pinned to the registry digest it resolved
+// to when first built, and only moved when asked to.
+
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Returns the reference of `image` pinned to `digest` (e.g.
+// "ubuntu:24.04@sha256:..."), its tag being kept for readability.
+func PinnedImageReference(image string, digest string) string {
+	if name, _, found := strings.Cut(image, "@"); found {
+		image = name
+	}
+	return image + "@" + digest
+}
+
+// Parse the JSON list of repository digests (e.g. `["ubuntu@sha256:..."]`)
+// written by `image inspect` into the image's digest, empty if it has none.
+func parseRepoDigests(output []byte) (string, error) {
+	var repoDigests []string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(output))), &repoDigests); err != nil {
+		return "", fmt.Errorf("unexpected repository digests %q: %w", strings.TrimSpace(string(output)), err)
+	}
+	for _, repoDigest := range repoDigests {
+		if _, digest, found := strings.Cut(repoDigest, "@"); found && digest != "" {
+			return digest, nil
+		}
+	}
+	return "", nil
+}
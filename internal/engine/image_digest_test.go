@@ -0,0 +1,30 @@
+package engine
+
+import "testing"
+
+func TestPinnedImageReference(t *testing.T) {
+	for image, want := range map[string]string{
+		"ubuntu:24.04":                   "ubuntu:24.04@sha256:abc",
+		"ubuntu:24.04@sha256:old":        "ubuntu:24.04@sha256:abc",
+		"registry.local:5000/base/image": "registry.local:5000/base/image@sha256:abc",
+	} {
+		if got := PinnedImageReference(image, "sha256:abc"); got != want {
+			t.Fatalf("PinnedImageReference(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+func TestParseRepoDigests(t *testing.T) {
+	digest, err := parseRepoDigests([]byte(`["docker.io/library/ubuntu@sha256:abc","ubuntu@sha256:abc"]` + "\n"))
+	if err != nil || digest != "sha256:abc" {
+		t.Fatalf("parseRepoDigests() = %q, %v, want sha256:abc", digest, err)
+	}
+	for _, output := range []string{"[]", "null"} {
+		if digest, err := parseRepoDigests([]byte(output)); err != nil || digest != "" {
+			t.Fatalf("parseRepoDigests(%s) = %q, %v, want no digest", output, digest, err)
+		}
+	}
+	if _, err := parseRepoDigests([]byte("<no value>")); err == nil {
+		t.Fatal("parseRepoDigests() should fail on unexpected output")
+	}
+}
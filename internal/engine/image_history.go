@@ -0,0 +1,62 @@
+// # image_history.go
+// Layers of a project image, as listed by the container engine's `history`
+// command, for `paul-envs image analyze`.
+//
+// Both Docker and Podman list them from the most recent to the oldest, with
+// the instruction which produced each. Those of the shared base image (and of
+// the distribution image it is built from) are the project image's oldest
+// ones, recognized by comparing both histories.
+
+package engine
+
+import (
+	"strconv"
+	"strings"
+)
+
+// A layer of an image, or an instruction only changing its metadata.
+type ImageLayer struct {
+	// Instruction which produced it, as recorded by the engine (e.g.
+	// "/bin/sh -c apt-get update && ..."). Empty if unknown.
+	CreatedBy string
+	// Size of the layer in bytes, `0` for metadata-only instructions
+	Size int64
+	// `true` if it comes from the shared base image
+	FromBaseImage bool
+}
+
+// Arguments of a `history` command listing the layers of `image` in a format
+// parsed by `parseImageHistory`.
+func imageHistoryArgs(image string) []string {
+	return []string{"history", "--human=false", "--no-trunc", "--format", "{{.Size}}\t{{.CreatedBy}}", image}
+}
+
+// Parse the output of a `history` command ran with `imageHistoryArgs`.
+func parseImageHistory(output string) []ImageLayer {
+	var layers []ImageLayer
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		sizeStr, createdBy, _ := strings.Cut(line, "\t")
+		// Sizes are in bytes, though formatted by some versions as "1.2MB"
+		size, err := strconv.ParseInt(strings.TrimSpace(sizeStr), 10, 64)
+		if err != nil {
+			size = parseReportedSize(sizeStr)
+		}
+		layers = append(layers, ImageLayer{CreatedBy: strings.TrimSpace(createdBy), Size: size})
+	}
+	return layers
+}
+
+// Mark the layers of `layers` also found at the bottom of `baseLayers`, the
+// history of the shared base image it was built from, as coming from it.
+func markBaseImageLayers(layers []ImageLayer, baseLayers []ImageLayer) {
+	i, j := len(layers)-1, len(baseLayers)-1
+	for ; i >= 0 && j >= 0; i, j = i-1, j-1 {
+		if layers[i].CreatedBy != baseLayers[j].CreatedBy || layers[i].Size != baseLayers[j].Size {
+			return
+		}
+		layers[i].FromBaseImage = true
+	}
+}
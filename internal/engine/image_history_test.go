@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseImageHistory(t *testing.T) {
+	output := "0\t/bin/sh -c #(nop)  CMD [\"/bin/bash\"]\n" +
+		"251000000\tRUN |1 PACKAGES=git /bin/sh -c apt-get install -y git # buildkit\n" +
+		"1.2MB\tCOPY entrypoint.sh / # buildkit\n" +
+		"\n"
+	want := []ImageLayer{
+		{CreatedBy: `/bin/sh -c #(nop)  CMD ["/bin/bash"]`},
+		{CreatedBy: "RUN |1 PACKAGES=git /bin/sh -c apt-get install -y git # buildkit", Size: 251_000_000},
+		{CreatedBy: "COPY entrypoint.sh / # buildkit", Size: 1_200_000},
+	}
+	if got := parseImageHistory(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
+
+func TestMarkBaseImageLayers(t *testing.T) {
+	layers := []ImageLayer{
+		{CreatedBy: "RUN npm install", Size: 300},
+		{CreatedBy: "USER dev"},
+		{CreatedBy: "RUN apt-get install", Size: 200},
+		{CreatedBy: "ADD rootfs", Size: 100},
+	}
+	base := []ImageLayer{
+		{CreatedBy: "CMD bash"},
+		{CreatedBy: "RUN apt-get install", Size: 200},
+		{CreatedBy: "ADD rootfs", Size: 100},
+	}
+	markBaseImageLayers(layers, base)
+	for i, want := range []bool{false, false, true, true} {
+		if layers[i].FromBaseImage != want {
+			t.Errorf("layer %d (%s): FromBaseImage = %t, want %t", i, layers[i].CreatedBy, layers[i].FromBaseImage, want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+// # image_transfer.go
+// Project images can be copied from one container engine to another, through
+// an image archive written by the first one and loaded by the other.
+//
+// Engines don't name images the same way (Podman prefixes local ones with
+// `localhost/`), so the loaded image is re-tagged to the project image name of
+// the receiving engine.
+
+package engine
+
+import (
+	"strings"
+)
+
+// Returns the name of the first image reported as loaded in the output of
+// `docker load` or `podman load`, empty if none is.
+func parseLoadedImage(output string) string {
+	for line := range strings.SplitSeq(output, "\n") {
+		_, name, found := strings.Cut(strings.TrimSpace(line), "Loaded image: ")
+		if !found {
+			_, name, found = strings.Cut(strings.TrimSpace(line), "Loaded image(s): ")
+		}
+		if found {
+			name, _, _ = strings.Cut(name, ",")
+			return strings.TrimSpace(name)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,23 @@
+package engine
+
+import "testing"
+
+func TestParseLoadedImage(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{"docker", "Loaded image: localhost/paulenv:app\n", "localhost/paulenv:app"},
+		{"podman", "Getting image source signatures\nCopying blob 5f70bf18a086 done\nWriting manifest to image destination\nLoaded image: docker.io/library/paulenv:app\n", "docker.io/library/paulenv:app"},
+		{"podman several", "Loaded image(s): localhost/paulenv:app,localhost/paulenv:other\n", "localhost/paulenv:app"},
+		{"none", "open /dev/stdin: no such file\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseLoadedImage(tt.output); got != tt.want {
+				t.Errorf("parseLoadedImage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
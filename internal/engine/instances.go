@@ -0,0 +1,118 @@
+// # instances.go
+// Additional instances of a project's container (`run --instance`), run from
+// the same image next to its default one, e.g. to start a second task without
+// sharing a shell with the first.
+//
+// Each runs in a `<project container>..<instance>` container labeled with its
+// instance name. As project and service names cannot contain dots, it is
+// mistaken for neither a project's default container nor a sidecar. It shares
+// the project's local volume, unless asked to have its own
+// (`paulenv-<project>..<instance>-local`), which is then attributed to the
+// project like its sidecars' data volumes.
+
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Label set on the containers of a project's instances to their name.
+const instanceLabel = "paulenv.instance"
+
+// An instance of a project's container.
+type Instance struct {
+	// Its name, empty for the project's default one
+	Name string
+	// If set, it has its own local volume instead of sharing the project's
+	SeparateVolume bool
+}
+
+func instanceContainerName(projectName string, instance string) string {
+	if instance == "" {
+		return projectContainerName(projectName)
+	}
+	return projectContainerName(projectName) + ".." + instance
+}
+
+func instanceLocalVolumeName(projectName string, instance string) string {
+	return fmt.Sprintf("paulenv-%s..%s-local", projectName, instance)
+}
+
+// Name of the local volume mounted in the container of that instance.
+func (i Instance) localVolumeName(projectName string) string {
+	if i.Name != "" && i.SeparateVolume {
+		return instanceLocalVolumeName(projectName, i.Name)
+	}
+	return ProjectLocalVolumeName(projectName)
+}
+
+// Adapt the arguments of the `run` command of a project's default container
+// to the given instance. Only the options before the image are considered, so
+// the command run in it is left as is.
+//
+// Instances do not take the project's alias on its network, which sidecars
+// use to reach its default container, nor publish its ports, which the host
+// could not give twice.
+func instanceRunArgs(cmdArgs []string, project files.ProjectEntry, instance Instance) []string {
+	if instance.Name == "" {
+		return cmdArgs
+	}
+	image := projectImageName(project.ProjectName)
+	localVolumePrefix := ProjectLocalVolumeName(project.ProjectName) + ":"
+	result := make([]string, 0, len(cmdArgs)+2)
+	for i := 0; i < len(cmdArgs); i++ {
+		arg := cmdArgs[i]
+		if arg == image {
+			return append(result, cmdArgs[i:]...)
+		}
+		if i+1 >= len(cmdArgs) {
+			result = append(result, arg)
+			continue
+		}
+		value := cmdArgs[i+1]
+		switch {
+		case arg == "--name":
+			value = instanceContainerName(project.ProjectName, instance.Name)
+		case arg == "--label" && strings.HasPrefix(value, projectLabel+"="):
+			result = append(result, arg, value, "--env", "PAULENV_INSTANCE="+instance.Name)
+			value = instanceLabel + "=" + instance.Name
+		case arg == "--volume" && strings.HasPrefix(value, localVolumePrefix):
+			value = instance.localVolumeName(project.ProjectName) + ":" + strings.TrimPrefix(value, localVolumePrefix)
+		case arg == "--network-alias" || arg == "--publish":
+			i++
+			continue
+		default:
+			result = append(result, arg)
+			continue
+		}
+		result = append(result, arg, value)
+		i++
+	}
+	return result
+}
+
+// Returns `true` if that container is the default one of the given project or
+// one of its instances, not e.g. one `try` runs from the same image.
+func isProjectInstanceContainer(container ContainerInfo, projectName string) bool {
+	if container.ContainerName == nil {
+		return false
+	}
+	name := *container.ContainerName
+	return name == projectContainerName(projectName) ||
+		strings.HasPrefix(name, projectContainerName(projectName)+"..")
+}
+
+// Filter the given containers to those of the project's instances, its default
+// one included.
+func ProjectInstances(containers []ContainerInfo, projectName string) []ContainerInfo {
+	var instances []ContainerInfo
+	for _, container := range containers {
+		if isProjectInstanceContainer(container, projectName) {
+			instances = append(instances, container)
+		}
+	}
+	return instances
+}
@@ -0,0 +1,46 @@
+// # interrupt.go
+// Interrupting paul-envs (e.g. with Ctrl+C) cancels the context of the engine
+// commands it runs. Rather than being killed right away, which could leave
+// the intermediate containers of a build or the container of a `run --rm`
+// behind, they are interrupted as they would be from a terminal and given
+// some time to clean up after themselves.
+
+package engine
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/logging"
+)
+
+// Time given to an interrupted engine command to exit before it is killed.
+const interruptGracePeriod = 10 * time.Second
+
+// Interrupt `cmd` instead of killing it when its context is canceled.
+func interruptOnCancel(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		// Windows processes cannot be sent an interrupt
+		if runtime.GOOS == "windows" {
+			return cmd.Process.Kill()
+		}
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = interruptGracePeriod
+}
+
+// Remove the container of an interrupted `run --rm`, as its engine CLI may
+// have been killed before removing it.
+//
+// It is usually already gone, so failures are only logged.
+func removeInterruptedContainer(ctx context.Context, c ContainerEngine, containerName string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), interruptGracePeriod)
+	defer cancel()
+	container := ContainerInfo{ContainerId: containerName}
+	if err := c.RemoveContainer(ctx, container); err != nil {
+		logging.Log().Debug("interrupted container not removed", "container", container.ContainerId, "error", err)
+	}
+}
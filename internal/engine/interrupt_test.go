@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os/exec"
+	"runtime"
+	"testing"
+)
+
+func TestInterruptOnCancel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows processes are killed instead")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", `trap 'echo cleaned up; exit 3' INT; echo started; while :; do sleep 0.1; done`)
+	interruptOnCancel(cmd)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("StdoutPipe() error = %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	reader := bufio.NewReader(stdout)
+	if line, err := reader.ReadString('\n'); err != nil || line != "started\n" {
+		t.Fatalf("command did not start: %q, %v", line, err)
+	}
+	cancel()
+	rest, _ := io.ReadAll(reader)
+	_ = cmd.Wait()
+	if string(rest) != "cleaned up\n" {
+		t.Fatalf("interrupted command should have cleaned up, got output %q", rest)
+	}
+	if code := cmd.ProcessState.ExitCode(); code != 3 {
+		t.Fatalf("interrupted command exit code = %d, want its own 3", code)
+	}
+}
@@ -0,0 +1,442 @@
+// # kube_export.go
+// `paul-envs export kube` translates a project into the Kubernetes YAML of a
+// Pod, or of a Deployment running it, to move a development environment onto
+// a cluster or to run it with `podman kube play`.
+//
+// The project's directory, dotfiles and mounts are taken from the host with
+// `hostPath` volumes and its named volumes become persistent volume claims,
+// which `podman kube play` maps to Podman volumes of the same name. Its
+// sidecar services are containers of the same Pod, reached through
+// `localhost`. Settings without a Pod equivalent (security options, the shared
+// network, systemd) are not translated.
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/profiling"
+)
+
+// Returns the Kubernetes YAML running the given project: a Pod, or a
+// Deployment of one replica of it if `deployment` is set.
+func KubeYAML(project files.ProjectEntry, deployment bool) (string, error) {
+	buildCfg, err := loadBuildConfig(project)
+	if err != nil {
+		return "", err
+	}
+	runtimeCfg, err := loadRuntimeConfig(project)
+	if err != nil {
+		return "", err
+	}
+	dotfilesPath, err := projectDotfilesPath(project, runtimeCfg)
+	if err != nil {
+		return "", err
+	}
+	mounts := make([]string, 0, len(runtimeCfg.Mounts))
+	for _, mount := range runtimeCfg.Mounts {
+		spec, err := mountVolumeSpec(project, runtimeCfg, mount)
+		if err != nil {
+			return "", err
+		}
+		mounts = append(mounts, spec)
+	}
+	return kubeFile(project, buildCfg, runtimeCfg, dotfilesPath, mounts, deployment), nil
+}
+
+// Returns the content of the Kubernetes YAML of the project, mounting its
+// dotfiles from `dotfilesPath` if set and `mounts`, its resolved `MOUNT`
+// volume specifications.
+func kubeFile(
+	project files.ProjectEntry,
+	buildCfg config.BuildConfig,
+	runtimeCfg config.RuntimeConfig,
+	dotfilesPath string,
+	mounts []string,
+	deployment bool,
+) string {
+	name := kubeName(projectContainerName(project.ProjectName))
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by paul-envs for the '%s' project.\n", project.ProjectName)
+	fmt.Fprintf(&b, "# Run it with: podman kube play <this file>, its image being built by 'paul-envs build %s'.\n", project.ProjectName)
+	b.WriteString("# On a cluster, push that image to a registry and replace its name first.\n")
+	if hasSystemd(buildCfg) {
+		b.WriteString("# The project's systemd init is not started in a Pod.\n")
+	}
+
+	podIndent := ""
+	if deployment {
+		b.WriteString("apiVersion: apps/v1\n")
+		b.WriteString("kind: Deployment\n")
+		writeKubeMetadata(&b, "", name, project.ProjectName)
+		b.WriteString("spec:\n")
+		b.WriteString("  replicas: 1\n")
+		b.WriteString("  selector:\n")
+		b.WriteString("    matchLabels:\n")
+		fmt.Fprintf(&b, "      %s: %s\n", projectLabel, yamlQuote(project.ProjectName))
+		b.WriteString("  template:\n")
+		podIndent = "    "
+		writeKubeMetadata(&b, podIndent, "", project.ProjectName)
+	} else {
+		b.WriteString("apiVersion: v1\n")
+		b.WriteString("kind: Pod\n")
+		writeKubeMetadata(&b, "", name, project.ProjectName)
+	}
+	writeKubePodSpec(&b, podIndent, project, buildCfg, runtimeCfg, dotfilesPath, mounts)
+	return b.String()
+}
+
+// Write the metadata of a Kubernetes object, labeled with the project's name,
+// and named if `name` is not empty.
+func writeKubeMetadata(b *strings.Builder, indent string, name string, projectName string) {
+	fmt.Fprintf(b, "%smetadata:\n", indent)
+	if name != "" {
+		fmt.Fprintf(b, "%s  name: %s\n", indent, yamlQuote(name))
+	}
+	fmt.Fprintf(b, "%s  labels:\n", indent)
+	fmt.Fprintf(b, "%s    paulenv: %s\n", indent, yamlQuote("true"))
+	fmt.Fprintf(b, "%s    %s: %s\n", indent, projectLabel, yamlQuote(projectName))
+}
+
+// A volume of the Pod: a persistent volume claim if `claim` is set, a path of
+// the host if `hostPath` is, an empty directory otherwise, kept in memory if
+// `memory` is set.
+type kubeVolume struct {
+	name     string
+	claim    string
+	hostPath string
+	memory   bool
+	// Maximum size of an empty directory, as a Kubernetes quantity
+	sizeLimit string
+}
+
+// A volume mounted in one of the Pod's containers.
+type kubeVolumeMount struct {
+	volume   string
+	target   string
+	readOnly bool
+}
+
+// Accumulates the volumes of a Pod and the mounts of its containers.
+type kubeVolumes struct {
+	volumes []kubeVolume
+}
+
+// Add a persistent volume claim for the engine volume `claim`, returning how
+// it is mounted at `target`. A claim is only declared once.
+func (v *kubeVolumes) claim(claim string, target string, readOnly bool) kubeVolumeMount {
+	for _, volume := range v.volumes {
+		if volume.claim == claim {
+			return kubeVolumeMount{volume: volume.name, target: target, readOnly: readOnly}
+		}
+	}
+	name := fmt.Sprintf("volume-%d", len(v.volumes))
+	v.volumes = append(v.volumes, kubeVolume{name: name, claim: claim})
+	return kubeVolumeMount{volume: name, target: target, readOnly: readOnly}
+}
+
+// Add a volume of the host path `path`, returning how it is mounted at
+// `target`.
+func (v *kubeVolumes) hostPath(path string, target string, readOnly bool) kubeVolumeMount {
+	name := fmt.Sprintf("volume-%d", len(v.volumes))
+	v.volumes = append(v.volumes, kubeVolume{name: name, hostPath: path})
+	return kubeVolumeMount{volume: name, target: target, readOnly: readOnly}
+}
+
+// Add an empty directory kept in memory for the given tmpfs, returning how it
+// is mounted. Kubernetes has no setting for its mode.
+func (v *kubeVolumes) tmpfs(mount config.TmpfsMount) kubeVolumeMount {
+	name := fmt.Sprintf("volume-%d", len(v.volumes))
+	volume := kubeVolume{name: name, memory: true}
+	if mount.Size != "" {
+		volume.sizeLimit = kubeQuantity(mount.Size)
+	}
+	v.volumes = append(v.volumes, volume)
+	return kubeVolumeMount{volume: name, target: mount.Target}
+}
+
+// Add a volume from a `--volume` specification ("source:target[:options]"),
+// a named volume if its source is not a path.
+func (v *kubeVolumes) fromSpec(spec string) kubeVolumeMount {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 {
+		// Anonymous volume, not kept once the Pod is removed
+		name := fmt.Sprintf("volume-%d", len(v.volumes))
+		v.volumes = append(v.volumes, kubeVolume{name: name})
+		return kubeVolumeMount{volume: name, target: spec}
+	}
+	source, target := parts[0], parts[1]
+	readOnly := len(parts) > 2 && strings.Contains(","+parts[2]+",", ",ro,")
+	if strings.HasPrefix(source, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			source = filepath.Join(home, source[2:])
+		}
+	}
+	if strings.HasPrefix(source, "/") || filepath.IsAbs(source) {
+		return v.hostPath(source, target, readOnly)
+	}
+	return v.claim(source, target, readOnly)
+}
+
+// Write the spec of the project's Pod.
+func writeKubePodSpec(
+	b *strings.Builder,
+	indent string,
+	project files.ProjectEntry,
+	buildCfg config.BuildConfig,
+	runtimeCfg config.RuntimeConfig,
+	dotfilesPath string,
+	mounts []string,
+) {
+	username := buildCfg.Args["USERNAME"]
+	projectMount := projectMountTarget(username, project.ProjectName)
+	workDir := runtimeCfg.WorkDir
+	if workDir == "" {
+		workDir = projectMount
+	}
+
+	var volumes kubeVolumes
+	mainMounts := []kubeVolumeMount{
+		volumes.hostPath(runtimeCfg.ProjectPath, projectMount, false),
+		volumes.claim("paulenv-shared-cache", "/home/"+username+"/.container-cache", false),
+		volumes.claim(ProjectLocalVolumeName(project.ProjectName), "/home/"+username+"/.container-local", false),
+	}
+	for _, cache := range runtimeCfg.PackageCaches {
+		mainMounts = append(mainMounts, volumes.claim(PackageCacheVolumeName(cache), packageCacheTarget(username, cache), false))
+	}
+	for _, volume := range runtimeCfg.SharedVolumes {
+		mainMounts = append(mainMounts, volumes.claim(SharedVolumeName(volume.Name), volume.Target, volume.ReadOnly))
+	}
+	for _, mount := range runtimeCfg.Tmpfs {
+		mainMounts = append(mainMounts, volumes.tmpfs(mount))
+	}
+	if hasCompilerCache(buildCfg) {
+		mainMounts = append(mainMounts, volumes.claim(CompilerCacheVolumeName, compilerCacheTarget(username), false))
+	}
+	if dotfilesPath != "" {
+		mainMounts = append(mainMounts, volumes.hostPath(dotfilesPath, "/paul-env/dotfiles", true))
+	}
+	for _, volume := range runtimeCfg.Volumes {
+		mainMounts = append(mainMounts, volumes.fromSpec(volume))
+	}
+	for _, mount := range mounts {
+		mainMounts = append(mainMounts, volumes.fromSpec(mount))
+	}
+
+	env := []string{"GIT_AUTHOR_NAME=" + runtimeCfg.GitName, "GIT_AUTHOR_EMAIL=" + runtimeCfg.GitEmail, "PAULENV_PROJECT=" + project.ProjectName}
+	if len(runtimeCfg.PackageCaches) > 0 {
+		env = append(env, "PAULENV_PACKAGE_CACHES="+packageCachesEnvValue(username, runtimeCfg.PackageCaches))
+	}
+	if targets := sharedVolumesEnvValue(runtimeCfg.SharedVolumes); targets != "" {
+		env = append(env, "PAULENV_SHARED_VOLUMES="+targets)
+	}
+	if len(runtimeCfg.Tmpfs) > 0 {
+		env = append(env, "PAULENV_TMPFS="+tmpfsEnvValue(runtimeCfg.Tmpfs))
+	}
+	if hasCompilerCache(buildCfg) {
+		env = append(env, compilerCacheEnv(username)...)
+	}
+	if runtimeCfg.Timezone != "" && runtimeCfg.Timezone != config.HostSetting {
+		env = append(env, "TZ="+runtimeCfg.Timezone)
+	}
+	if runtimeCfg.Locale != "" && runtimeCfg.Locale != config.HostSetting {
+		env = append(env, "LANG="+runtimeCfg.Locale)
+	}
+
+	fmt.Fprintf(b, "%sspec:\n", indent)
+	fmt.Fprintf(b, "%s  containers:\n", indent)
+	fmt.Fprintf(b, "%s    - name: %s\n", indent, yamlQuote(kubeName(runtimeCfg.MainServiceName(project.ProjectName))))
+	fmt.Fprintf(b, "%s      image: %s\n", indent, yamlQuote("localhost/"+projectImageName(project.ProjectName)))
+	fmt.Fprintf(b, "%s      stdin: true\n", indent)
+	fmt.Fprintf(b, "%s      tty: true\n", indent)
+	fmt.Fprintf(b, "%s      workingDir: %s\n", indent, yamlQuote(workDir))
+	writeKubeEnv(b, indent+"      ", env)
+	if ports := kubeContainerPorts(runtimeCfg.Ports); len(ports) > 0 {
+		fmt.Fprintf(b, "%s      ports:\n", indent)
+		for _, port := range ports {
+			fmt.Fprintf(b, "%s        - containerPort: %s\n", indent, port.containerPort)
+			if port.hostPort != "" {
+				fmt.Fprintf(b, "%s          hostPort: %s\n", indent, port.hostPort)
+			}
+			if port.hostIP != "" {
+				fmt.Fprintf(b, "%s          hostIP: %s\n", indent, yamlQuote(port.hostIP))
+			}
+			if port.protocol != "" {
+				fmt.Fprintf(b, "%s          protocol: %s\n", indent, strings.ToUpper(port.protocol))
+			}
+		}
+	}
+	if runtimeCfg.Cpus != "" || runtimeCfg.Memory != "" {
+		fmt.Fprintf(b, "%s      resources:\n", indent)
+		fmt.Fprintf(b, "%s        limits:\n", indent)
+		if runtimeCfg.Cpus != "" {
+			fmt.Fprintf(b, "%s          cpu: %s\n", indent, yamlQuote(runtimeCfg.Cpus))
+		}
+		if runtimeCfg.Memory != "" {
+			fmt.Fprintf(b, "%s          memory: %s\n", indent, yamlQuote(kubeQuantity(runtimeCfg.Memory)))
+		}
+	}
+	writeKubeVolumeMounts(b, indent+"      ", mainMounts)
+
+	for _, service := range runtimeCfg.Services {
+		fmt.Fprintf(b, "%s    - name: %s\n", indent, yamlQuote(kubeName(service.Name)))
+		fmt.Fprintf(b, "%s      image: %s\n", indent, yamlQuote(service.Image))
+		writeKubeEnv(b, indent+"      ", service.Env)
+		if service.DataPath != "" {
+			writeKubeVolumeMounts(b, indent+"      ", []kubeVolumeMount{
+				volumes.claim(sidecarDataVolumeName(project.ProjectName, service.Name), service.DataPath, false),
+			})
+		}
+	}
+
+	if len(runtimeCfg.ExtraHosts) > 0 {
+		fmt.Fprintf(b, "%s  hostAliases:\n", indent)
+		for _, host := range runtimeCfg.ExtraHosts {
+			fmt.Fprintf(b, "%s    - ip: %s\n", indent, yamlQuote(host.IP))
+			fmt.Fprintf(b, "%s      hostnames:\n", indent)
+			fmt.Fprintf(b, "%s        - %s\n", indent, yamlQuote(host.Name))
+		}
+	}
+	if len(runtimeCfg.DNSServers) > 0 || len(runtimeCfg.DNSSearch) > 0 {
+		fmt.Fprintf(b, "%s  dnsConfig:\n", indent)
+		writeKubeList(b, indent+"    ", "nameservers", runtimeCfg.DNSServers)
+		writeKubeList(b, indent+"    ", "searches", runtimeCfg.DNSSearch)
+	}
+	fmt.Fprintf(b, "%s  volumes:\n", indent)
+	for _, volume := range volumes.volumes {
+		fmt.Fprintf(b, "%s    - name: %s\n", indent, volume.name)
+		switch {
+		case volume.claim != "":
+			fmt.Fprintf(b, "%s      persistentVolumeClaim:\n", indent)
+			fmt.Fprintf(b, "%s        claimName: %s\n", indent, yamlQuote(volume.claim))
+		case volume.hostPath != "":
+			fmt.Fprintf(b, "%s      hostPath:\n", indent)
+			fmt.Fprintf(b, "%s        path: %s\n", indent, yamlQuote(filepath.ToSlash(volume.hostPath)))
+		case volume.memory:
+			fmt.Fprintf(b, "%s      emptyDir:\n", indent)
+			fmt.Fprintf(b, "%s        medium: Memory\n", indent)
+			if volume.sizeLimit != "" {
+				fmt.Fprintf(b, "%s        sizeLimit: %s\n", indent, yamlQuote(volume.sizeLimit))
+			}
+		default:
+			fmt.Fprintf(b, "%s      emptyDir: {}\n", indent)
+		}
+	}
+}
+
+// Write the given setting as a list of quoted values, if not empty.
+func writeKubeList(b *strings.Builder, indent string, key string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "%s%s:\n", indent, key)
+	for _, value := range values {
+		fmt.Fprintf(b, "%s  - %s\n", indent, yamlQuote(value))
+	}
+}
+
+// Write the `env` list of a container from "KEY=VALUE" variables.
+func writeKubeEnv(b *strings.Builder, indent string, env []string) {
+	if len(env) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "%senv:\n", indent)
+	for _, variable := range env {
+		name, value, _ := strings.Cut(variable, "=")
+		fmt.Fprintf(b, "%s  - name: %s\n", indent, yamlQuote(name))
+		fmt.Fprintf(b, "%s    value: %s\n", indent, yamlQuote(value))
+	}
+}
+
+// Write the `volumeMounts` list of a container.
+func writeKubeVolumeMounts(b *strings.Builder, indent string, mounts []kubeVolumeMount) {
+	if len(mounts) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "%svolumeMounts:\n", indent)
+	for _, mount := range mounts {
+		fmt.Fprintf(b, "%s  - name: %s\n", indent, mount.volume)
+		fmt.Fprintf(b, "%s    mountPath: %s\n", indent, yamlQuote(mount.target))
+		if mount.readOnly {
+			fmt.Fprintf(b, "%s    readOnly: true\n", indent)
+		}
+	}
+}
+
+// A port of a container published on the host.
+type kubeContainerPort struct {
+	containerPort string
+	// Empty if the engine picks it
+	hostPort string
+	// Empty for all addresses
+	hostIP string
+	// Empty for TCP
+	protocol string
+}
+
+// Parse `PORT` values ("[[hostIP:]hostPort:]containerPort[/protocol]"),
+// ignoring port ranges which have no Pod equivalent.
+func kubeContainerPorts(ports []string) []kubeContainerPort {
+	var result []kubeContainerPort
+	for _, value := range ports {
+		var port kubeContainerPort
+		value, port.protocol, _ = strings.Cut(value, "/")
+		if strings.Contains(value, "-") {
+			continue
+		}
+		parts := strings.Split(value, ":")
+		port.containerPort = parts[len(parts)-1]
+		if len(parts) > 1 {
+			port.hostPort = parts[len(parts)-2]
+		}
+		if len(parts) > 2 {
+			port.hostIP = strings.Trim(strings.Join(parts[:len(parts)-2], ":"), "[]")
+		}
+		result = append(result, port)
+	}
+	return result
+}
+
+// Kubernetes quantity of an engine memory limit, e.g. "4Gi" for "4g".
+func kubeQuantity(memory string) string {
+	lower := strings.TrimSuffix(strings.ToLower(memory), "b")
+	for _, unit := range []string{"k", "m", "g", "t"} {
+		if number, ok := strings.CutSuffix(lower, unit); ok {
+			return number + strings.ToUpper(unit) + "i"
+		}
+	}
+	return lower
+}
+
+// Object names of Kubernetes only allow lowercase letters, digits and dashes.
+func kubeName(name string) string {
+	return strings.ReplaceAll(name, "_", "-")
+}
+
+func (c *DockerEngine) PlayKube(context.Context, string) error {
+	return fmt.Errorf("%w: Docker cannot run Kubernetes YAML, only Podman can", ErrKubePlayUnsupported)
+}
+
+// Run the Kubernetes YAML of `path` with `podman kube play`, replacing the
+// Pods it previously created.
+func (c *PodmanEngine) PlayKube(ctx context.Context, path string) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman PlayKube")()
+	if !c.Capabilities(ctx).KubePlay {
+		return fmt.Errorf("%w: it needs Podman %s or later", ErrKubePlayUnsupported, podmanKubePlayVersion.ToString())
+	}
+	cmd := c.command(ctx, "kube", "play", "--replace", hostPath(path))
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("podman kube play failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestKubeFile(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "my_app", RuntimeConfigPath: "/tmp/my_app/run.conf"}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}
+	runtimeCfg := config.RuntimeConfig{
+		ProjectPath: "/code/my_app",
+		Ports:       []string{"127.0.0.1:3000:3000", "5353:53/udp"},
+		Volumes:     []string{"/data:/home/dev/data:ro", "my-volume:/home/dev/volume"},
+		Memory:      "4g",
+		Tmpfs:       []config.TmpfsMount{{Target: "/run/secrets", Size: "16m"}},
+		Services: []config.Service{
+			{Name: "db", Image: "postgres:16", Env: []string{"POSTGRES_PASSWORD=dev"}, DataPath: "/var/lib/postgresql/data"},
+		},
+	}
+
+	got := kubeFile(project, buildCfg, runtimeCfg, "/home/me/dotfiles", nil, false)
+	for _, fragment := range []string{
+		"kind: Pod\nmetadata:\n  name: \"paulenv-my-app\"\n",
+		"    paulenv.project: \"my_app\"\n",
+		"    - name: \"my-app\"\n      image: \"localhost/paulenv:my_app\"\n",
+		"        - containerPort: 3000\n          hostPort: 3000\n          hostIP: \"127.0.0.1\"\n",
+		"        - containerPort: 53\n          hostPort: 5353\n          protocol: UDP\n",
+		"          memory: \"4Gi\"\n",
+		"        - name: volume-0\n          mountPath: \"/home/dev/projects/my_app\"\n",
+		"          mountPath: \"/paul-env/dotfiles\"\n          readOnly: true\n",
+		"          mountPath: \"/home/dev/data\"\n          readOnly: true\n",
+		"    - name: \"db\"\n      image: \"postgres:16\"\n",
+		"        claimName: \"paulenv-shared-cache\"\n",
+		"        claimName: \"my-volume\"\n",
+		"        claimName: \"" + sidecarDataVolumeName("my_app", "db") + "\"\n",
+		"      hostPath:\n        path: \"/code/my_app\"\n",
+		"      emptyDir:\n        medium: Memory\n        sizeLimit: \"16Mi\"\n",
+	} {
+		if !strings.Contains(got, fragment) {
+			t.Fatalf("kubeFile() should contain %q, got:\n%s", fragment, got)
+		}
+	}
+
+	got = kubeFile(project, buildCfg, runtimeCfg, "", nil, true)
+	for _, fragment := range []string{
+		"kind: Deployment\n",
+		"  selector:\n    matchLabels:\n      paulenv.project: \"my_app\"\n",
+		"  template:\n    metadata:\n      labels:\n",
+		"    spec:\n      containers:\n",
+	} {
+		if !strings.Contains(got, fragment) {
+			t.Fatalf("kubeFile() should contain %q, got:\n%s", fragment, got)
+		}
+	}
+	if strings.Contains(got, "/paul-env/dotfiles") {
+		t.Fatalf("kubeFile() should not mount dotfiles without any, got:\n%s", got)
+	}
+}
+
+func TestKubeContainerPorts(t *testing.T) {
+	got := kubeContainerPorts([]string{"8080", "8000-8010:8000-8010", "[::1]:2222:22"})
+	want := []kubeContainerPort{{containerPort: "8080"}, {containerPort: "22", hostPort: "2222", hostIP: "::1"}}
+	if !slices.Equal(got, want) {
+		t.Fatalf("kubeContainerPorts() = %+v, want %+v", got, want)
+	}
+}
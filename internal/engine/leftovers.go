@@ -0,0 +1,115 @@
+// # leftovers.go
+// Project containers and sidecars are run with `--rm`, so the engine removes
+// them with their anonymous volumes once they exit. It does not when it was
+// itself interrupted (e.g. killed, or its daemon restarted), leaving a stopped
+// container which keeps the project's container name taken, its anonymous
+// volumes and the project's network behind.
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Resources of a project left behind by runs which did not end normally.
+type RunLeftovers struct {
+	// Stopped containers of the project and of its sidecars
+	Containers []string
+	// The project's network, if no container uses it anymore
+	Networks []NetworkInfo
+
+	containers []ContainerInfo
+	sidecars   []SidecarInfo
+}
+
+func (l RunLeftovers) IsEmpty() bool {
+	return len(l.Containers) == 0 && len(l.Networks) == 0
+}
+
+// Look for what runs of the given project left behind, once none of its
+// containers should be running anymore.
+func FindRunLeftovers(ctx context.Context, c ContainerEngine, projectName string) (RunLeftovers, error) {
+	containers, err := c.ListContainers(ctx)
+	if err != nil {
+		return RunLeftovers{}, fmt.Errorf("failed to list containers: %w", err)
+	}
+	sidecars, err := c.ListSidecars(ctx)
+	if err != nil {
+		return RunLeftovers{}, err
+	}
+	networks, err := c.ListNetworks(ctx)
+	if err != nil {
+		return RunLeftovers{}, err
+	}
+	return findRunLeftovers(projectName, containers, sidecars, networks), nil
+}
+
+// Same as `FindRunLeftovers`, from resources already listed from the engine.
+func RunLeftoversIn(projectName string, containers []ContainerInfo, sidecars []SidecarInfo, networks []NetworkInfo) RunLeftovers {
+	return findRunLeftovers(projectName, containers, sidecars, networks)
+}
+
+func findRunLeftovers(projectName string, containers []ContainerInfo, sidecars []SidecarInfo, networks []NetworkInfo) RunLeftovers {
+	leftovers := RunLeftovers{}
+	inUse := false
+	// Only the project's own containers are considered, not e.g. those
+	// `try` runs from the same image under another name.
+	for _, container := range ProjectInstances(containers, projectName) {
+		if container.Running {
+			inUse = true
+			continue
+		}
+		leftovers.containers = append(leftovers.containers, container)
+		leftovers.Containers = append(leftovers.Containers, *container.ContainerName)
+	}
+	for _, sidecar := range sidecars {
+		if sidecar.ProjectName != projectName {
+			continue
+		}
+		if sidecar.Running {
+			inUse = true
+			continue
+		}
+		leftovers.sidecars = append(leftovers.sidecars, sidecar)
+		leftovers.Containers = append(leftovers.Containers, sidecar.ContainerName)
+	}
+	if inUse {
+		return leftovers
+	}
+	networkName := projectNetworkName(projectName)
+	for _, network := range networks {
+		if network.NetworkName == networkName {
+			leftovers.Networks = append(leftovers.Networks, network)
+		}
+	}
+	return leftovers
+}
+
+// Remove the given leftovers. Containers are removed with their anonymous
+// volumes only: named ones, which hold the project's persistent data, are
+// kept.
+func RemoveRunLeftovers(ctx context.Context, c ContainerEngine, leftovers RunLeftovers) error {
+	var errs []error
+	for _, container := range leftovers.containers {
+		if err := c.RemoveContainer(ctx, container); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, sidecar := range leftovers.sidecars {
+		if err := c.RemoveSidecar(ctx, sidecar); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	// The network cannot be removed while a leftover container still uses it
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	for _, network := range leftovers.Networks {
+		if err := c.RemoveNetwork(ctx, network); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
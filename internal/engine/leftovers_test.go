@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFindRunLeftovers(t *testing.T) {
+	strPtr := func(s string) *string { return &s }
+	network := NetworkInfo{NetworkId: "n1", NetworkName: "paulenv-app", ProjectName: strPtr("app")}
+	otherNetwork := NetworkInfo{NetworkId: "n2", NetworkName: "paulenv-other", ProjectName: strPtr("other")}
+
+	tests := []struct {
+		name           string
+		containers     []ContainerInfo
+		sidecars       []SidecarInfo
+		wantContainers []string
+		wantNetwork    bool
+	}{
+		{
+			name:        "nothing left but the network",
+			wantNetwork: true,
+		},
+		{
+			name: "stopped project container and sidecar",
+			containers: []ContainerInfo{
+				{ProjectName: strPtr("app"), ContainerName: strPtr("paulenv-app"), ContainerId: "c1"},
+				{ProjectName: strPtr("other"), ContainerName: strPtr("paulenv-other"), ContainerId: "c2"},
+			},
+			sidecars: []SidecarInfo{
+				{ProjectName: "app", ServiceName: "db", ContainerName: "paulenv-app.db", ContainerId: "s1"},
+			},
+			wantContainers: []string{"paulenv-app", "paulenv-app.db"},
+			wantNetwork:    true,
+		},
+		{
+			name: "running container keeps the network",
+			containers: []ContainerInfo{
+				{ProjectName: strPtr("app"), ContainerName: strPtr("paulenv-app"), ContainerId: "c1", Running: true},
+			},
+			sidecars: []SidecarInfo{
+				{ProjectName: "app", ServiceName: "db", ContainerName: "paulenv-app.db", ContainerId: "s1"},
+			},
+			wantContainers: []string{"paulenv-app.db"},
+		},
+		{
+			name: "running instance keeps the network",
+			containers: []ContainerInfo{
+				{ProjectName: strPtr("app"), ContainerName: strPtr("paulenv-app"), ContainerId: "c1"},
+				{ProjectName: strPtr("app"), ContainerName: strPtr("paulenv-app..tests"), ContainerId: "c2", Running: true},
+				{ProjectName: strPtr("app"), ContainerName: strPtr("paulenv-app..lint"), ContainerId: "c3"},
+			},
+			wantContainers: []string{"paulenv-app", "paulenv-app..lint"},
+		},
+		{
+			name: "running sidecar keeps the network",
+			sidecars: []SidecarInfo{
+				{ProjectName: "app", ServiceName: "db", ContainerName: "paulenv-app.db", ContainerId: "s1", Running: true},
+			},
+		},
+		{
+			name: "container of the same image under another name",
+			containers: []ContainerInfo{
+				{ProjectName: strPtr("app"), ContainerName: strPtr("paulenv-try-app"), ContainerId: "c1"},
+			},
+			wantNetwork: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findRunLeftovers("app", tt.containers, tt.sidecars, []NetworkInfo{network, otherNetwork})
+			if !slices.Equal(got.Containers, tt.wantContainers) {
+				t.Fatalf("Containers = %v, want %v", got.Containers, tt.wantContainers)
+			}
+			if gotNetwork := len(got.Networks) == 1 && got.Networks[0] == network; gotNetwork != tt.wantNetwork || (!tt.wantNetwork && len(got.Networks) > 0) {
+				t.Fatalf("Networks = %v, want the project's: %t", got.Networks, tt.wantNetwork)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// # locale.go
+// The timezone and locale of containers can be set per project, or mirror
+// those of the host (`TIMEZONE host` and `LOCALE host` in run.conf), so dates
+// and messages inside them look like those outside.
+//
+// They are given as `TZ` and `LANG`: the base image has the timezone database
+// and the entrypoint generates the locale if the image does not have it.
+
+package engine
+
+import (
+	"os"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+// Isolated for tests
+var readLink = os.Readlink
+
+// Returns the host's timezone as a name of the tz database (e.g.
+// "Europe/Paris"), empty if it cannot be known.
+func hostTimezone() string {
+	if tz := strings.TrimPrefix(getenv("TZ"), ":"); tz != "" && !strings.HasPrefix(tz, "/") {
+		return tz
+	}
+	// Windows has its own timezone names, which containers would not know
+	if hostOS == "windows" {
+		return ""
+	}
+	// e.g. "/usr/share/zoneinfo/Europe/Paris", or
+	// "/var/db/timezone/zoneinfo/Europe/Paris" on macOS
+	target, err := readLink("/etc/localtime")
+	if err != nil {
+		return ""
+	}
+	if _, zone, found := strings.Cut(target, "zoneinfo/"); found {
+		return zone
+	}
+	return ""
+}
+
+// Returns the host's locale (e.g. "fr_FR.UTF-8"), empty if it cannot be known
+// or is the default one.
+func hostLocale() string {
+	for _, name := range []string{"LC_ALL", "LANG"} {
+		switch locale := getenv(name); locale {
+		case "":
+			continue
+		case "C", "POSIX":
+			return ""
+		default:
+			return locale
+		}
+	}
+	return ""
+}
+
+// Arguments of the `run` command setting the timezone and locale of a
+// project's container.
+func localeRunArgs(runtimeCfg config.RuntimeConfig) []string {
+	var args []string
+	timezone := runtimeCfg.Timezone
+	if timezone == config.HostSetting {
+		timezone = hostTimezone()
+	}
+	if timezone != "" {
+		args = append(args, "--env", "TZ="+timezone)
+	}
+	locale := runtimeCfg.Locale
+	if locale == config.HostSetting {
+		locale = hostLocale()
+	}
+	if locale != "" {
+		args = append(args, "--env", "LANG="+locale)
+	}
+	return args
+}
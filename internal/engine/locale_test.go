@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+func stubLocaltime(t *testing.T, target string) {
+	t.Helper()
+	prevReadLink := readLink
+	t.Cleanup(func() { readLink = prevReadLink })
+	readLink = func(string) (string, error) {
+		if target == "" {
+			return "", errors.New("not a symlink")
+		}
+		return target, nil
+	}
+}
+
+func TestHostTimezone(t *testing.T) {
+	previousOS := hostOS
+	t.Cleanup(func() { hostOS = previousOS })
+	hostOS = "linux"
+
+	tests := []struct {
+		name      string
+		env       map[string]string
+		localtime string
+		want      string
+	}{
+		{name: "from TZ", env: map[string]string{"TZ": "America/New_York"}, localtime: "/usr/share/zoneinfo/Europe/Paris", want: "America/New_York"},
+		{name: "from TZ with a colon", env: map[string]string{"TZ": ":Asia/Tokyo"}, want: "Asia/Tokyo"},
+		{name: "from /etc/localtime", localtime: "/usr/share/zoneinfo/Europe/Paris", want: "Europe/Paris"},
+		{name: "from /etc/localtime on macOS", localtime: "/var/db/timezone/zoneinfo/Europe/Paris", want: "Europe/Paris"},
+		{name: "TZ as a path", env: map[string]string{"TZ": "/etc/localtime"}, localtime: "../usr/share/zoneinfo/UTC", want: "UTC"},
+		{name: "unknown", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubHost(t, tt.env)
+			stubLocaltime(t, tt.localtime)
+			if got := hostTimezone(); got != tt.want {
+				t.Fatalf("hostTimezone() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	hostOS = "windows"
+	stubHost(t, nil)
+	stubLocaltime(t, "/usr/share/zoneinfo/Europe/Paris")
+	if got := hostTimezone(); got != "" {
+		t.Fatalf("hostTimezone() on windows = %q, want none", got)
+	}
+}
+
+func TestHostLocale(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{env: map[string]string{"LANG": "fr_FR.UTF-8"}, want: "fr_FR.UTF-8"},
+		{env: map[string]string{"LANG": "fr_FR.UTF-8", "LC_ALL": "de_DE.UTF-8"}, want: "de_DE.UTF-8"},
+		{env: map[string]string{"LANG": "C"}, want: ""},
+		{env: map[string]string{"LC_ALL": "POSIX", "LANG": "fr_FR.UTF-8"}, want: ""},
+		{env: nil, want: ""},
+	}
+	for _, tt := range tests {
+		stubHost(t, tt.env)
+		if got := hostLocale(); got != tt.want {
+			t.Fatalf("hostLocale() with %v = %q, want %q", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestLocaleRunArgs(t *testing.T) {
+	stubHost(t, map[string]string{"TZ": "Europe/Paris", "LANG": "fr_FR.UTF-8"})
+
+	if args := localeRunArgs(config.RuntimeConfig{}); len(args) != 0 {
+		t.Fatalf("localeRunArgs() without directives = %v, want none", args)
+	}
+	got := localeRunArgs(config.RuntimeConfig{Timezone: config.HostSetting, Locale: config.HostSetting})
+	want := []string{"--env", "TZ=Europe/Paris", "--env", "LANG=fr_FR.UTF-8"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("localeRunArgs() for the host = %v, want %v", got, want)
+	}
+	got = localeRunArgs(config.RuntimeConfig{Timezone: "UTC", Locale: "en_US.UTF-8"})
+	want = []string{"--env", "TZ=UTC", "--env", "LANG=en_US.UTF-8"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("localeRunArgs() = %v, want %v", got, want)
+	}
+}
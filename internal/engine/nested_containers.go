@@ -0,0 +1,54 @@
+// # nested_containers.go
+// Running containers inside a project's container (`ENABLE_NESTED_CONTAINERS`
+// build directive), through the rootless Podman its image then has.
+//
+// That Podman stores its images in the project's local volume and mounts them
+// through fuse-overlayfs, so the container needs `/dev/fuse` and to be allowed
+// to create user namespaces.
+
+package engine
+
+import (
+	"errors"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+// Returns `true` if the project's image can run containers itself.
+func hasNestedContainers(buildCfg config.BuildConfig) bool {
+	return buildCfg.Args["ENABLE_NESTED_CONTAINERS"] == "true"
+}
+
+// Arguments of the `run` command letting the container run containers, if
+// the project's image can.
+func nestedContainersRunArgs(buildCfg config.BuildConfig, runtimeCfg config.RuntimeConfig, podman bool) ([]string, error) {
+	if !hasNestedContainers(buildCfg) {
+		return nil, nil
+	}
+	if runtimeCfg.Hardened {
+		return nil, errors.New("ENABLE_NESTED_CONTAINERS cannot be used with HARDENED, which drops the capabilities needed to create containers\n" +
+			"Hint: Remove the HARDENED directive of the project's run.conf or disable ENABLE_NESTED_CONTAINERS in its build.conf")
+	}
+	args := []string{"--device", "/dev/fuse"}
+	for _, opt := range nestedContainersSecurityOpts(runtimeCfg, podman) {
+		args = append(args, "--security-opt", opt)
+	}
+	return args, nil
+}
+
+// Security options needed by the container's Podman.
+//
+// Docker's default seccomp and AppArmor profiles forbid creating user
+// namespaces, and the `/proc` paths it masks prevent mounting a new procfs in
+// them. A seccomp profile set in run.conf is kept, it is then up to it.
+func nestedContainersSecurityOpts(runtimeCfg config.RuntimeConfig, podman bool) []string {
+	opts := []string{"label=disable"}
+	if podman {
+		return opts
+	}
+	opts = append(opts, "apparmor=unconfined", "systempaths=unconfined")
+	if runtimeCfg.SeccompProfile == "" {
+		opts = append(opts, "seccomp=unconfined")
+	}
+	return opts
+}
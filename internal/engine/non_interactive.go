@@ -0,0 +1,37 @@
+// # non_interactive.go
+// In non-interactive mode (the global `--ci` flag or
+// `PAULENV_NONINTERACTIVE=1`), e.g. in a CI pipeline, no pseudo-terminal is
+// allocated to containers and commands ran in them, even when paul-envs'
+// standard input is one, so they behave the same wherever they run, and
+// Docker's builds report their progress as plain lines.
+
+package engine
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+var nonInteractive bool
+
+// Enable or disable the non-interactive mode.
+func SetNonInteractive(enabled bool) {
+	nonInteractive = enabled
+}
+
+// Returns `true` if a pseudo-terminal can be allocated to a container reading
+// paul-envs' standard input.
+func stdinIsTerminal() bool {
+	return !nonInteractive && term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// Arguments of Docker's `build` command making BuildKit write its progress as
+// plain lines in non-interactive mode, instead of redrawing it when the
+// output is a terminal.
+func dockerProgressArgs() []string {
+	if nonInteractive {
+		return []string{"--progress=plain"}
+	}
+	return nil
+}
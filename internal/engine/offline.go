@@ -0,0 +1,45 @@
+// # offline.go
+// In offline mode (the global `--offline` flag), e.g. on a plane or in an
+// air-gapped lab, container engines are not let to fetch anything from
+// registries: builds and containers only use images already there, and
+// operations which cannot work without a registry (pushes, pulls, checking
+// for a newer distribution image) fail right away instead of after their
+// network timeouts.
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+var offline bool
+
+// Enable or disable the offline mode.
+func SetOffline(enabled bool) {
+	offline = enabled
+}
+
+// Returns `true` if registries should not be reached.
+func IsOffline() bool {
+	return offline
+}
+
+// Error wrapped by operations refused in offline mode.
+var ErrOffline = errors.New("offline mode")
+
+func offlineError(action string, reference string) error {
+	return fmt.Errorf("%w: cannot %s %s, which needs its registry\nHint: Run it again without --offline once online", ErrOffline, action, reference)
+}
+
+// Arguments of `run` and Podman's `build` commands never pulling images in
+// offline mode, as engines otherwise pull those missing.
+//
+// Docker's `build` has no such argument, but only pulls images missing
+// locally.
+func offlinePullArgs() []string {
+	if offline {
+		return []string{"--pull=never"}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestOffline(t *testing.T) {
+	t.Cleanup(func() { SetOffline(false) })
+	project := files.ProjectEntry{ProjectName: "demo"}
+	service := config.Service{Name: "db", Image: "postgres:16"}
+
+	SetOffline(false)
+	if args := sidecarRunArgs("demo", service, "paulenv-demo"); slices.Contains(args, "--pull=never") {
+		t.Fatalf("sidecarRunArgs() online = %v, should not forbid pulls", args)
+	}
+
+	SetOffline(true)
+	args := sidecarRunArgs("demo", service, "paulenv-demo")
+	if i := slices.Index(args, "--pull=never"); i < 0 || args[len(args)-1] != "postgres:16" {
+		t.Fatalf("sidecarRunArgs() offline = %v, should forbid pulls before the image", args)
+	}
+	if args := podmanBuildArgs(project, nil, BuildOptions{}); !slices.Contains(args, "--pull=never") {
+		t.Fatalf("podmanBuildArgs() offline = %v, should forbid pulls", args)
+	}
+	if err := (&DockerEngine{}).PullImage(context.Background(), "demo", "registry.example/demo:1"); !errors.Is(err, ErrOffline) {
+		t.Fatalf("PullImage() offline error = %v, want ErrOffline", err)
+	}
+	if err := (&PodmanEngine{}).PushImage(context.Background(), "demo", "registry.example/demo:1"); !errors.Is(err, ErrOffline) {
+		t.Fatalf("PushImage() offline error = %v, want ErrOffline", err)
+	}
+	if _, err := (&PodmanEngine{}).ImageDigest(context.Background(), "ubuntu:24.04", true); !errors.Is(err, ErrOffline) {
+		t.Fatalf("ImageDigest() offline error = %v, want ErrOffline", err)
+	}
+}
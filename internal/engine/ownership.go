@@ -0,0 +1,146 @@
+// # ownership.go
+// Files a container creates in its project's directory should belong to the
+// host user. They end up owned by root or by subordinate IDs when the user
+// namespace of the container did not map its user to the host's (e.g. after
+// switching between rootful and rootless engines, or changing USERNS), and
+// the host user cannot modify nor remove them anymore.
+//
+// Those files are found from the host, then given back to its user from a
+// throwaway container running as root, the only one allowed to do so without
+// `sudo`.
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/peaberberian/paul-envs/internal/profiling"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Maximum number of paths listed in `ForeignOwnership.Examples`.
+const maxForeignOwnedExamples = 5
+
+// Files of a host directory owned by another user than the current one.
+type ForeignOwnership struct {
+	// Number of them
+	Count int
+	// Some of them, relative to the directory
+	Examples []string
+	// Set if the directory had more entries than the scan was allowed to
+	// check, in which case there may be more of them
+	Partial bool
+}
+
+// Look for files and directories of `dir`, itself included, owned by another
+// user than the current one, checking at most `maxEntries` of them (all if
+// `0`).
+//
+// Nothing is found on hosts whose files have no owner ID (Windows).
+func FindForeignOwnedFiles(dir string, maxEntries int) (ForeignOwnership, error) {
+	var result ForeignOwnership
+	if hostOS == "windows" {
+		return result, nil
+	}
+	uid := os.Getuid()
+	checked := 0
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// e.g. a directory of another user which cannot be read, itself
+			// already counted
+			if path != dir {
+				return nil
+			}
+			return err
+		}
+		if maxEntries > 0 && checked >= maxEntries {
+			result.Partial = true
+			return fs.SkipAll
+		}
+		checked++
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		if owner, ok := utils.FileOwner(info); !ok || owner == uid {
+			return nil
+		}
+		result.Count++
+		if len(result.Examples) < maxForeignOwnedExamples {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				rel = path
+			}
+			result.Examples = append(result.Examples, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to check the owners of %s: %w", dir, err)
+	}
+	return result, nil
+}
+
+// Arguments of the `run` command giving the files of `dir` not owned by
+// `uid` to `uid` and `gid`, which are IDs of the container's user namespace.
+//
+// The namespace of the engine itself is used, where root is the host's root
+// or, for rootless engines, the host user. SELinux labels are ignored rather
+// than relabeling the whole directory.
+func fixOwnershipArgs(dir string, uid int, gid int) []string {
+	return []string{
+		"run", "--rm", "--user", "0", "--userns", "host", "--network", "none",
+		"--security-opt", "label=disable",
+		"--volume", hostPath(dir) + ":/workspace",
+		"--entrypoint", "find", baseImageNameFor(""),
+		"/workspace", "!", "-uid", strconv.Itoa(uid),
+		"-exec", "chown", "-h", strconv.Itoa(uid) + ":" + strconv.Itoa(gid), "{}", "+",
+	}
+}
+
+func fixOwnershipError(dir string, err error) error {
+	return fmt.Errorf("failed to give the files of %s back to you: %w\nHint: It is done through the shared base image, which can be built with 'paul-envs build --base'", dir, err)
+}
+
+func (c *DockerEngine) FixOwnership(ctx context.Context, dir string) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker FixOwnership")()
+	if hostOS == "windows" {
+		return errors.New("files have no owner ID on Windows")
+	}
+	cmd := engineCommand(ctx, "docker", fixOwnershipArgs(dir, os.Getuid(), os.Getgid())...)
+	cmd.Stderr = engineOutput
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fixOwnershipError(dir, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) FixOwnership(ctx context.Context, dir string) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman FixOwnership")()
+	if hostOS == "windows" {
+		return errors.New("files have no owner ID on Windows")
+	}
+	uid, gid := os.Getuid(), os.Getgid()
+	if c.isRootless(ctx) {
+		// The host user is root in the rootless user namespace
+		uid, gid = 0, 0
+	}
+	cmd := c.command(ctx, fixOwnershipArgs(dir, uid, gid)...)
+	cmd.Stderr = engineOutput
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fixOwnershipError(dir, err)
+	}
+	return nil
+}
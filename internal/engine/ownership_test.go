@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestFixOwnershipArgs(t *testing.T) {
+	want := []string{
+		"run", "--rm", "--user", "0", "--userns", "host", "--network", "none",
+		"--security-opt", "label=disable",
+		"--volume", "/srv/app:/workspace",
+		"--entrypoint", "find", "paulenv-base:latest",
+		"/workspace", "!", "-uid", "1000",
+		"-exec", "chown", "-h", "1000:1001", "{}", "+",
+	}
+	if got := fixOwnershipArgs("/srv/app", 1000, 1001); !reflect.DeepEqual(got, want) {
+		t.Fatalf("fixOwnershipArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestFindForeignOwnedFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("files have no owner ID on Windows")
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b", "sub/c", "sub/d"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	foreign, err := FindForeignOwnedFiles(dir, 0)
+	if err != nil || !reflect.DeepEqual(foreign, ForeignOwnership{}) {
+		t.Fatalf("FindForeignOwnedFiles() = %+v, %v, want nothing found", foreign, err)
+	}
+	if foreign, _ := FindForeignOwnedFiles(dir, 3); !foreign.Partial {
+		t.Fatalf("FindForeignOwnedFiles() with a limit = %+v, want a partial scan", foreign)
+	}
+
+	if os.Getuid() != 0 {
+		return
+	}
+	// Only root can give files away
+	if err := os.Lchown(filepath.Join(dir, "sub", "c"), 100999, 100999); err != nil {
+		t.Fatal(err)
+	}
+	foreign, err = FindForeignOwnedFiles(dir, 0)
+	want := ForeignOwnership{Count: 1, Examples: []string{filepath.Join("sub", "c")}}
+	if err != nil || !reflect.DeepEqual(foreign, want) {
+		t.Fatalf("FindForeignOwnedFiles() = %+v, %v, want %+v", foreign, err, want)
+	}
+}
@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+const packageCacheVolumePrefix = "paulenv-cache-"
+
+// Name of the volume holding the cache of the given package manager, shared
+// by all projects listing it in their `PACKAGE_CACHE` directives.
+func PackageCacheVolumeName(name string) string {
+	return packageCacheVolumePrefix + name
+}
+
+// Returns `true` if that volume is the shared cache of a package manager,
+// which belongs to no project in particular.
+func IsPackageCacheVolume(volumeName string) bool {
+	name, ok := strings.CutPrefix(volumeName, packageCacheVolumePrefix)
+	return ok && config.PackageCachePath(name) != ""
+}
+
+// Where the cache of the given package manager is mounted in the container.
+func packageCacheTarget(username string, cache string) string {
+	return "/home/" + username + "/" + config.PackageCachePath(cache)
+}
+
+// Value of the `PAULENV_PACKAGE_CACHES` variable telling the entrypoint where
+// the given package caches are mounted, as engines create the missing
+// directories a volume is mounted on as root.
+func packageCachesEnvValue(username string, caches []string) string {
+	targets := make([]string, 0, len(caches))
+	for _, cache := range caches {
+		targets = append(targets, packageCacheTarget(username, cache))
+	}
+	return strings.Join(targets, " ")
+}
+
+// Arguments for the `run` command mounting the given package caches.
+func packageCacheRunArgs(username string, caches []string) []string {
+	if len(caches) == 0 {
+		return nil
+	}
+	var args []string
+	for _, cache := range caches {
+		args = append(args, "--volume", PackageCacheVolumeName(cache)+":"+packageCacheTarget(username, cache))
+	}
+	return append(args, "--env", "PAULENV_PACKAGE_CACHES="+packageCachesEnvValue(username, caches))
+}
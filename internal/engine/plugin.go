@@ -0,0 +1,645 @@
+// # plugin.go
+// Container engines other than Docker and Podman (e.g. a company's own
+// runtime) can be added without changing paul-envs, as "engine plugins":
+// executables named `paulenv-engine-<name>` found in the `PATH`, selected
+// like other engines with `--engine <name>` or the `ENGINE` setting.
+//
+// Each `ContainerEngine` method is a call to that executable, with the
+// method's name in kebab-case as its only argument (e.g.
+// `paulenv-engine-corp list-containers`) and its arguments as a JSON object in
+// the `PAULENV_ENGINE_REQUEST` environment variable. Go types (e.g. a
+// `ContainerInfo`) are encoded with their field names as is. Containers
+// listed by `list-containers` are those of paul-envs projects, so their
+// `ProjectName` is required.
+//
+// Most methods answer with a single JSON object on their standard output:
+// `{"result": <value>}` on success or `{"error": "<message>"}` on failure.
+// Methods interacting with the user or streaming data (builds, `run`,
+// `export-image`...) are instead given the terminal, or the data, as their
+// standard streams and only report through their exit code.
+
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/logging"
+)
+
+const (
+	pluginPrefix = "paulenv-engine-"
+	// Version of the protocol described above, which plugins report in their
+	// `info` answer.
+	pluginProtocol = 1
+)
+
+// Names which cannot be given to a plugin, as they select built-in engines.
+var reservedPluginNames = []string{
+	string(SelectionDocker),
+	string(SelectionPodman),
+	string(SelectionPodmanRootful),
+	string(SelectionAll),
+}
+
+// Names of the engine plugins found in the `PATH`, sorted.
+func ListPlugins() []string {
+	names := []string{}
+	for _, dir := range filepath.SplitList(getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := strings.CutPrefix(entry.Name(), pluginPrefix)
+			if !ok || entry.IsDir() {
+				continue
+			}
+			if hostOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			if IsPlugin(name) && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Returns `true` if `name` is the name of an engine plugin found in the
+// `PATH`.
+func IsPlugin(name string) bool {
+	_, err := lookupPlugin(name)
+	return err == nil
+}
+
+func lookupPlugin(name string) (string, error) {
+	if name == "" || slices.Contains(reservedPluginNames, name) || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("%q cannot be the name of an engine plugin", name)
+	}
+	return exec.LookPath(pluginPrefix + name)
+}
+
+// `ContainerEngine` implemented by an engine plugin.
+type PluginEngine struct {
+	name    string
+	path    string
+	version string
+	// As reported by its `info` answer
+	capabilities Capabilities
+}
+
+// Answer of a plugin to its `info` call.
+type pluginInfo struct {
+	Version  string `json:"version"`
+	Protocol int    `json:"protocol"`
+	// Reported alongside the other fields, all unsupported if omitted
+	Capabilities
+}
+
+func newPlugin(ctx context.Context, name string) (*PluginEngine, error) {
+	path, err := lookupPlugin(name)
+	if err != nil {
+		return nil, err
+	}
+	p := &PluginEngine{name: name, path: path}
+	var info pluginInfo
+	if err := p.query(ctx, "info", nil, &info); err != nil {
+		return nil, err
+	}
+	if info.Protocol != pluginProtocol {
+		return nil, fmt.Errorf("engine plugin %q speaks protocol version %d, paul-envs only version %d", name, info.Protocol, pluginProtocol)
+	}
+	p.version = info.Version
+	p.capabilities = info.Capabilities
+	return p, nil
+}
+
+// Create the call of the given method of the plugin.
+func (p *PluginEngine) command(ctx context.Context, method string, request any) (*exec.Cmd, error) {
+	encoded, err := p.encodeRequest(method, request)
+	if err != nil {
+		return nil, err
+	}
+	return p.commandWith(ctx, method, encoded), nil
+}
+
+func (p *PluginEngine) encodeRequest(method string, request any) ([]byte, error) {
+	if request == nil {
+		return []byte("{}"), nil
+	}
+	encoded, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("engine plugin %q: could not encode %s request: %w", p.name, method, err)
+	}
+	return encoded, nil
+}
+
+func (p *PluginEngine) commandWith(ctx context.Context, method string, encodedRequest []byte) *exec.Cmd {
+	cmd := engineCommand(ctx, p.path, method)
+	cmd.Env = append(os.Environ(), "PAULENV_ENGINE_REQUEST="+string(encodedRequest))
+	if IsOffline() {
+		cmd.Env = append(cmd.Env, "PAULENV_ENGINE_OFFLINE=1")
+	}
+	if nonInteractive {
+		cmd.Env = append(cmd.Env, "PAULENV_NONINTERACTIVE=1")
+	}
+	return cmd
+}
+
+// Call a method of the plugin answering on its standard output, decoding its
+// result into `result` if not nil.
+func (p *PluginEngine) query(ctx context.Context, method string, request any, result any) error {
+	cmd, err := p.command(ctx, method, request)
+	if err != nil {
+		return err
+	}
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		return p.failure(method, err)
+	}
+	if len(output) == 0 && IsDryRun() {
+		return nil
+	}
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  string          `json:"error"`
+	}
+	if err := json.Unmarshal(output, &response); err != nil {
+		return fmt.Errorf("engine plugin %q: invalid %s answer: %w", p.name, method, err)
+	}
+	if response.Error != "" {
+		return fmt.Errorf("engine plugin %q: %s: %s", p.name, method, response.Error)
+	}
+	if result != nil && len(response.Result) > 0 {
+		if err := json.Unmarshal(response.Result, result); err != nil {
+			return fmt.Errorf("engine plugin %q: invalid %s result: %w", p.name, method, err)
+		}
+	}
+	return nil
+}
+
+// Call a method of the plugin with the given streams, the terminal's if nil.
+func (p *PluginEngine) attach(ctx context.Context, method string, request any, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	cmd, err := p.command(ctx, method, request)
+	if err != nil {
+		return err
+	}
+	withStreams(cmd, stdin, stdout, stderr)
+	if err := runEngineCommand(cmd); err != nil {
+		return p.failure(method, err)
+	}
+	return nil
+}
+
+func (p *PluginEngine) failure(method string, err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if lines := strings.Split(strings.TrimSpace(string(exitErr.Stderr)), "\n"); lines[len(lines)-1] != "" {
+			return fmt.Errorf("engine plugin %q: %s failed: %s", p.name, method, lines[len(lines)-1])
+		}
+	}
+	return fmt.Errorf("engine plugin %q: %s failed: %w", p.name, method, err)
+}
+
+func (p *PluginEngine) Info(ctx context.Context) (EngineInfo, error) {
+	return EngineInfo{Name: p.name, Version: p.version}, nil
+}
+
+// Arguments of a build given to plugins.
+type pluginBuildRequest struct {
+	BaseFilesDir      string              `json:"baseFilesDir,omitempty"`
+	Project           *files.ProjectEntry `json:"project,omitempty"`
+	NoCache           bool                `json:"noCache"`
+	Platform          string              `json:"platform,omitempty"`
+	DistributionImage string              `json:"distributionImage,omitempty"`
+	BuildArgs         map[string]string   `json:"buildArgs,omitempty"`
+}
+
+func (p *PluginEngine) build(ctx context.Context, method string, request pluginBuildRequest, options BuildOptions) error {
+	encoded, err := p.encodeRequest(method, request)
+	if err != nil {
+		return err
+	}
+	err = runBuildAttempts(ctx, options, func(ctx context.Context) *exec.Cmd {
+		return p.commandWith(ctx, method, encoded)
+	})
+	if err != nil {
+		return p.failure(method, err)
+	}
+	return nil
+}
+
+func (p *PluginEngine) BuildBaseImage(ctx context.Context, baseFilesDir string, options BuildOptions) error {
+	return p.build(ctx, "build-base-image", pluginBuildRequest{
+		BaseFilesDir:      baseFilesDir,
+		NoCache:           options.NoCache,
+		Platform:          options.Platform,
+		DistributionImage: options.DistributionImage,
+	}, options)
+}
+
+func (p *PluginEngine) HasBaseImage(ctx context.Context, platform string) (bool, error) {
+	var found bool
+	err := p.query(ctx, "has-base-image", map[string]any{"platform": platform}, &found)
+	return found, err
+}
+
+func (p *PluginEngine) BuildImage(ctx context.Context, project files.ProjectEntry, options BuildOptions) error {
+	runtimeCfg, err := config.LoadRuntimeConfig(project.RuntimeConfigPath)
+	if err != nil {
+		return err
+	}
+	return p.build(ctx, "build-image", pluginBuildRequest{
+		Project:   &project,
+		NoCache:   options.NoCache,
+		Platform:  options.Platform,
+		BuildArgs: options.BuildArgs,
+	}, withProjectBuildSettings(options, runtimeCfg))
+}
+
+func (p *PluginEngine) RunContainer(ctx context.Context, project files.ProjectEntry, args []string, options RunOptions) error {
+	return p.attach(ctx, "run-container", map[string]any{
+		"project": project,
+		"args":    args,
+		"env":     options.Env,
+		"secrets": options.Secrets,
+		"tty":     options.hasTerminalInput(),
+		"keep":    options.Keep,
+		"instance": map[string]any{
+			"name":           options.Instance.Name,
+			"separateVolume": options.Instance.SeparateVolume,
+		},
+	}, options.Stdin, options.Stdout, options.Stderr)
+}
+
+func (p *PluginEngine) CheckShell(ctx context.Context, project files.ProjectEntry) (ShellCheck, error) {
+	err := p.attach(ctx, "check-shell", map[string]any{"project": project}, nil, nil, nil)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return ShellCheck{ExitCode: exitErr.ExitCode()}, nil
+	}
+	return ShellCheck{}, err
+}
+
+func (p *PluginEngine) RunThrowaway(ctx context.Context, name string, image string, args []string, options ThrowawayOptions) error {
+	return p.attach(ctx, "run-throwaway", map[string]any{
+		"name":    name,
+		"image":   image,
+		"args":    args,
+		"tty":     stdinIsTerminal(),
+		"options": options,
+	}, nil, nil, nil)
+}
+
+func (p *PluginEngine) JoinThrowaway(ctx context.Context, name string, args []string) error {
+	return p.attach(ctx, "join-throwaway", map[string]any{
+		"name": name,
+		"args": args,
+		"tty":  stdinIsTerminal(),
+	}, nil, nil, nil)
+}
+
+func (p *PluginEngine) JoinContainer(ctx context.Context, containerInfo ContainerInfo, args []string) error {
+	return p.attach(ctx, "join-container", map[string]any{"container": containerInfo, "args": args}, nil, nil, nil)
+}
+
+func (p *PluginEngine) ExecContainer(ctx context.Context, containerInfo ContainerInfo, args []string, options ExecOptions) error {
+	cmd, err := p.command(ctx, "exec-container", map[string]any{
+		"container": containerInfo,
+		"args":      args,
+		"user":      options.User,
+		"workDir":   options.WorkDir,
+		"tty":       !options.NoTTY && options.hasTerminalInput(),
+	})
+	if err != nil {
+		return err
+	}
+	options.withStreams(cmd)
+	if err := runEngineCommand(cmd); err != nil {
+		return p.failure("exec-container", err)
+	}
+	return nil
+}
+
+func (p *PluginEngine) CopyTo(ctx context.Context, containerInfo ContainerInfo, hostPath string, containerPath string) error {
+	return p.query(ctx, "copy-to", map[string]any{"container": containerInfo, "hostPath": hostPath, "containerPath": containerPath}, nil)
+}
+
+func (p *PluginEngine) CopyFrom(ctx context.Context, containerInfo ContainerInfo, containerPath string, hostPath string) error {
+	return p.query(ctx, "copy-from", map[string]any{"container": containerInfo, "containerPath": containerPath, "hostPath": hostPath}, nil)
+}
+
+func (p *PluginEngine) StartContainer(ctx context.Context, project files.ProjectEntry) (ContainerInfo, error) {
+	var container ContainerInfo
+	err := p.query(ctx, "start-container", map[string]any{"project": project}, &container)
+	return container, err
+}
+
+func (p *PluginEngine) WaitContainer(ctx context.Context, container ContainerInfo) (ContainerExit, error) {
+	var exit ContainerExit
+	err := p.query(ctx, "wait-container", map[string]any{"container": container}, &exit)
+	return exit, err
+}
+
+func (p *PluginEngine) GetContainerStartTime(ctx context.Context, container ContainerInfo) (time.Time, error) {
+	var startedAt time.Time
+	err := p.query(ctx, "get-container-start-time", map[string]any{"container": container}, &startedAt)
+	return startedAt, err
+}
+
+func (p *PluginEngine) CreateVolume(ctx context.Context, name string) error {
+	return p.query(ctx, "create-volume", map[string]any{"name": name}, nil)
+}
+
+func (p *PluginEngine) HasBeenBuilt(ctx context.Context, projectName string) (bool, error) {
+	var built bool
+	err := p.query(ctx, "has-been-built", map[string]any{"projectName": projectName}, &built)
+	return built, err
+}
+
+func (p *PluginEngine) GetImageInfo(ctx context.Context, projectName string) (*ImageInfo, error) {
+	var image *ImageInfo
+	err := p.query(ctx, "get-image-info", map[string]any{"projectName": projectName}, &image)
+	return image, err
+}
+
+// Plugins only list the containers of paul-envs projects, whose
+// `ProjectName` is then required: those without one are ignored.
+func (p *PluginEngine) ListContainers(ctx context.Context) ([]ContainerInfo, error) {
+	containers := []ContainerInfo{}
+	if err := p.query(ctx, "list-containers", nil, &containers); err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(containers, func(container ContainerInfo) bool {
+		if container.ProjectName == nil || *container.ProjectName == "" {
+			logging.Log().Warn("ignoring a container listed by an engine plugin without its project",
+				"plugin", p.name, "container", container.ContainerId)
+			return true
+		}
+		return false
+	}), nil
+}
+
+func (p *PluginEngine) ListNamedResources(ctx context.Context, names ResourceNames) ([]NamedResource, error) {
+	resources := []NamedResource{}
+	err := p.query(ctx, "list-named-resources", map[string]any{"names": names}, &resources)
+	return resources, err
+}
+
+func (p *PluginEngine) RemoveContainer(ctx context.Context, container ContainerInfo) error {
+	return p.query(ctx, "remove-container", map[string]any{"container": container}, nil)
+}
+
+func (p *PluginEngine) StopContainer(ctx context.Context, container ContainerInfo) error {
+	return p.query(ctx, "stop-container", map[string]any{"container": container}, nil)
+}
+
+func (p *PluginEngine) ListImages(ctx context.Context) ([]ImageInfo, error) {
+	images := []ImageInfo{}
+	err := p.query(ctx, "list-images", nil, &images)
+	return images, err
+}
+
+func (p *PluginEngine) RemoveImage(ctx context.Context, image ImageInfo) error {
+	return p.query(ctx, "remove-image", map[string]any{"image": image}, nil)
+}
+
+func (p *PluginEngine) CommitContainer(ctx context.Context, container ContainerInfo, projectName string, tag string) (SnapshotInfo, error) {
+	var snapshot SnapshotInfo
+	err := p.query(ctx, "commit-container", map[string]any{"container": container, "projectName": projectName, "tag": tag}, &snapshot)
+	return snapshot, err
+}
+
+func (p *PluginEngine) ListSnapshots(ctx context.Context) ([]SnapshotInfo, error) {
+	snapshots := []SnapshotInfo{}
+	err := p.query(ctx, "list-snapshots", nil, &snapshots)
+	return snapshots, err
+}
+
+func (p *PluginEngine) RestoreSnapshot(ctx context.Context, snapshot SnapshotInfo) error {
+	return p.query(ctx, "restore-snapshot", map[string]any{"snapshot": snapshot}, nil)
+}
+
+func (p *PluginEngine) RemoveSnapshot(ctx context.Context, snapshot SnapshotInfo) error {
+	return p.query(ctx, "remove-snapshot", map[string]any{"snapshot": snapshot}, nil)
+}
+
+func (p *PluginEngine) SaveGeneration(ctx context.Context, projectName string, number int) (GenerationInfo, error) {
+	var generation GenerationInfo
+	err := p.query(ctx, "save-generation", map[string]any{"projectName": projectName, "number": number}, &generation)
+	return generation, err
+}
+
+func (p *PluginEngine) ListGenerations(ctx context.Context) ([]GenerationInfo, error) {
+	generations := []GenerationInfo{}
+	err := p.query(ctx, "list-generations", nil, &generations)
+	return generations, err
+}
+
+func (p *PluginEngine) RestoreGeneration(ctx context.Context, generation GenerationInfo) error {
+	return p.query(ctx, "restore-generation", map[string]any{"generation": generation}, nil)
+}
+
+func (p *PluginEngine) RemoveGeneration(ctx context.Context, generation GenerationInfo) error {
+	return p.query(ctx, "remove-generation", map[string]any{"generation": generation}, nil)
+}
+
+func (p *PluginEngine) SaveArchImage(ctx context.Context, projectName string) (ArchImageInfo, error) {
+	var image ArchImageInfo
+	err := p.query(ctx, "save-arch-image", map[string]any{"projectName": projectName}, &image)
+	return image, err
+}
+
+func (p *PluginEngine) ListArchImages(ctx context.Context) ([]ArchImageInfo, error) {
+	images := []ArchImageInfo{}
+	err := p.query(ctx, "list-arch-images", nil, &images)
+	return images, err
+}
+
+func (p *PluginEngine) UseArchImage(ctx context.Context, image ArchImageInfo) error {
+	return p.query(ctx, "use-arch-image", map[string]any{"image": image}, nil)
+}
+
+func (p *PluginEngine) RemoveArchImage(ctx context.Context, image ArchImageInfo) error {
+	return p.query(ctx, "remove-arch-image", map[string]any{"image": image}, nil)
+}
+
+func (p *PluginEngine) EnableEmulation(ctx context.Context, architectures []string) error {
+	return p.attach(ctx, "enable-emulation", map[string]any{"architectures": architectures}, nil, engineOutput, engineOutput)
+}
+
+func (p *PluginEngine) ExportImage(ctx context.Context, projectName string, w io.Writer) error {
+	return p.attach(ctx, "export-image", map[string]any{"projectName": projectName}, nil, w, engineOutput)
+}
+
+func (p *PluginEngine) ImportImage(ctx context.Context, projectName string, r io.Reader) error {
+	return p.attach(ctx, "import-image", map[string]any{"projectName": projectName}, r, engineOutput, engineOutput)
+}
+
+func (p *PluginEngine) PushImage(ctx context.Context, projectName string, reference string) error {
+	if IsOffline() {
+		return offlineError("push to", reference)
+	}
+	return p.attach(ctx, "push-image", map[string]any{"projectName": projectName, "reference": reference}, nil, engineOutput, engineOutput)
+}
+
+func (p *PluginEngine) PullImage(ctx context.Context, projectName string, reference string) error {
+	if IsOffline() {
+		return offlineError("pull", reference)
+	}
+	return p.attach(ctx, "pull-image", map[string]any{"projectName": projectName, "reference": reference}, nil, engineOutput, engineOutput)
+}
+
+func (p *PluginEngine) ImageDigest(ctx context.Context, image string, pull bool) (string, error) {
+	if pull && IsOffline() {
+		return "", offlineError("pull", image)
+	}
+	var digest string
+	err := p.query(ctx, "image-digest", map[string]any{"image": image, "pull": pull}, &digest)
+	return digest, err
+}
+
+func (p *PluginEngine) TagImage(ctx context.Context, image string, target string) error {
+	return p.query(ctx, "tag-image", map[string]any{"image": image, "target": target}, nil)
+}
+
+func (p *PluginEngine) UntagImage(ctx context.Context, image string) error {
+	return p.query(ctx, "untag-image", map[string]any{"image": image}, nil)
+}
+
+func (p *PluginEngine) StartSidecar(ctx context.Context, projectName string, service config.Service, sharedNetwork bool) (SidecarInfo, error) {
+	var sidecar SidecarInfo
+	err := p.query(ctx, "start-sidecar", map[string]any{"projectName": projectName, "service": service, "sharedNetwork": sharedNetwork}, &sidecar)
+	return sidecar, err
+}
+
+func (p *PluginEngine) ListSidecars(ctx context.Context) ([]SidecarInfo, error) {
+	sidecars := []SidecarInfo{}
+	err := p.query(ctx, "list-sidecars", nil, &sidecars)
+	return sidecars, err
+}
+
+func (p *PluginEngine) RemoveSidecar(ctx context.Context, sidecar SidecarInfo) error {
+	return p.query(ctx, "remove-sidecar", map[string]any{"sidecar": sidecar}, nil)
+}
+
+func (p *PluginEngine) JoinSidecar(ctx context.Context, sidecar SidecarInfo, args []string) error {
+	return p.attach(ctx, "join-sidecar", map[string]any{"sidecar": sidecar, "args": args}, nil, nil, nil)
+}
+
+func (p *PluginEngine) CheckSidecarHealth(ctx context.Context, sidecar SidecarInfo, command string) error {
+	return p.query(ctx, "check-sidecar-health", map[string]any{"sidecar": sidecar, "command": command}, nil)
+}
+
+func (p *PluginEngine) GetContainerStats(ctx context.Context) ([]ContainerStats, error) {
+	stats := []ContainerStats{}
+	err := p.query(ctx, "get-container-stats", nil, &stats)
+	return stats, err
+}
+
+func (p *PluginEngine) ListVolumes(ctx context.Context) ([]VolumeInfo, error) {
+	volumes := []VolumeInfo{}
+	err := p.query(ctx, "list-volumes", nil, &volumes)
+	return volumes, err
+}
+
+func (p *PluginEngine) InspectVolume(ctx context.Context, name string) (VolumeInfo, error) {
+	volume := VolumeInfo{}
+	err := p.query(ctx, "inspect-volume", map[string]any{"name": name}, &volume)
+	return volume, err
+}
+
+func (p *PluginEngine) InspectToolbox(ctx context.Context, name string) (ToolboxContainer, error) {
+	container := ToolboxContainer{}
+	err := p.query(ctx, "inspect-toolbox", map[string]any{"name": name}, &container)
+	return container, err
+}
+
+func (p *PluginEngine) CheckpointContainer(ctx context.Context, container ContainerInfo, exportPath string) error {
+	return p.query(ctx, "checkpoint-container", map[string]any{"container": container, "exportPath": exportPath}, nil)
+}
+
+func (p *PluginEngine) RestoreContainer(ctx context.Context, project files.ProjectEntry, importPath string) error {
+	return p.query(ctx, "restore-container", map[string]any{"project": project, "importPath": importPath}, nil)
+}
+
+// Plugins write each event as a line of JSON.
+func (p *PluginEngine) StreamEvents(ctx context.Context, handle func(Event)) error {
+	events := &eventWriter{
+		parse: func(line []byte) (Event, bool) {
+			var event Event
+			return event, json.Unmarshal(line, &event) == nil
+		},
+		handle: handle,
+	}
+	return eventsStreamError(ctx, p.attach(ctx, "stream-events", nil, nil, events, engineOutput))
+}
+
+func (p *PluginEngine) GetDiskUsage(ctx context.Context) (DiskUsage, error) {
+	usage := DiskUsage{}
+	err := p.query(ctx, "get-disk-usage", nil, &usage)
+	return usage, err
+}
+
+func (p *PluginEngine) GetImageHistory(ctx context.Context, projectName string) ([]ImageLayer, error) {
+	layers := []ImageLayer{}
+	err := p.query(ctx, "get-image-history", map[string]any{"projectName": projectName}, &layers)
+	return layers, err
+}
+
+func (p *PluginEngine) RemoveVolume(ctx context.Context, volume VolumeInfo) error {
+	return p.query(ctx, "remove-volume", map[string]any{"volume": volume}, nil)
+}
+
+func (p *PluginEngine) ExportVolume(ctx context.Context, name string, w io.Writer) error {
+	return p.attach(ctx, "export-volume", map[string]any{"name": name}, nil, w, engineOutput)
+}
+
+func (p *PluginEngine) ImportVolume(ctx context.Context, name string, r io.Reader) error {
+	return p.attach(ctx, "import-volume", map[string]any{"name": name}, r, engineOutput, engineOutput)
+}
+
+func (p *PluginEngine) ListNetworks(ctx context.Context) ([]NetworkInfo, error) {
+	networks := []NetworkInfo{}
+	err := p.query(ctx, "list-networks", nil, &networks)
+	return networks, err
+}
+
+func (p *PluginEngine) RemoveNetwork(ctx context.Context, network NetworkInfo) error {
+	return p.query(ctx, "remove-network", map[string]any{"network": network}, nil)
+}
+
+func (p *PluginEngine) PruneBuildCache(ctx context.Context) error {
+	if !p.capabilities.BuildCachePrune {
+		return nil
+	}
+	return p.attach(ctx, "prune-build-cache", nil, nil, engineOutput, engineOutput)
+}
+
+func (p *PluginEngine) FixOwnership(ctx context.Context, dir string) error {
+	return p.attach(ctx, "fix-ownership", map[string]any{"dir": dir}, nil, engineOutput, engineOutput)
+}
+
+func (p *PluginEngine) PlayKube(ctx context.Context, path string) error {
+	if !p.capabilities.KubePlay {
+		return fmt.Errorf("%w: engine plugin %q does not report supporting it", ErrKubePlayUnsupported, p.name)
+	}
+	return p.attach(ctx, "play-kube", map[string]any{"path": path}, nil, engineOutput, engineOutput)
+}
+
+func (p *PluginEngine) Capabilities(context.Context) Capabilities {
+	return p.capabilities
+}
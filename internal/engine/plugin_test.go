@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Plugin answering from a shell script, recording the requests it got
+const fakePlugin = `#!/bin/sh
+echo "$1 $PAULENV_ENGINE_REQUEST" >> "$(dirname "$0")/requests"
+case "$1" in
+info) echo '{"result": {"version": "fake 1.0", "protocol": 1}}' ;;
+list-containers) echo '{"result": [{"ContainerId": "abc", "ProjectName": "demo", "Running": true}, {"ContainerId": "def", "ProjectName": null}]}' ;;
+*) echo '{"error": "unsupported"}' ;;
+esac
+`
+
+func installFakePlugin(t *testing.T, name string, script string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, pluginPrefix+name), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return dir
+}
+
+func TestPluginEngine(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on sh")
+	}
+	dir := installFakePlugin(t, "fake", fakePlugin)
+	if plugins := ListPlugins(); !slices.Contains(plugins, "fake") {
+		t.Fatalf("ListPlugins() = %v, should contain the fake plugin", plugins)
+	}
+	if IsPlugin("docker") || IsPlugin("missing") {
+		t.Fatalf("IsPlugin() should only accept plugins found in the PATH, under a non-reserved name")
+	}
+
+	ctx := context.Background()
+	plugin, err := newPlugin(ctx, "fake")
+	if err != nil {
+		t.Fatalf("newPlugin() error = %v", err)
+	}
+	if info, _ := plugin.Info(ctx); info != (EngineInfo{Name: "fake", Version: "fake 1.0"}) {
+		t.Fatalf("Info() = %+v, want the plugin's name and version", info)
+	}
+
+	containers, err := plugin.ListContainers(ctx)
+	if err != nil || len(containers) != 1 || containers[0].ContainerId != "abc" || !containers[0].Running {
+		t.Fatalf("ListContainers() = %+v, %v, want the plugin's container with a project", containers, err)
+	}
+	err = plugin.CreateVolume(ctx, "paulenv-demo")
+	if err == nil || !strings.Contains(err.Error(), "create-volume: unsupported") {
+		t.Fatalf("CreateVolume() error = %v, want the plugin's error", err)
+	}
+	requests, _ := os.ReadFile(filepath.Join(dir, "requests"))
+	if !strings.Contains(string(requests), `create-volume {"name":"paulenv-demo"}`) {
+		t.Fatalf("plugin got requests %q, want the volume's name", requests)
+	}
+
+	var stdout strings.Builder
+	if err := plugin.ExportVolume(ctx, "paulenv-demo", &stdout); err != nil {
+		t.Fatalf("ExportVolume() error = %v", err)
+	}
+	if !strings.Contains(stdout.String(), "unsupported") {
+		t.Fatalf("ExportVolume() wrote %q, want the plugin's output", stdout.String())
+	}
+}
+
+func TestPluginEngine_ProtocolMismatch(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on sh")
+	}
+	installFakePlugin(t, "future", "#!/bin/sh\necho '{\"result\": {\"protocol\": 2}}'\n")
+	if _, err := newPlugin(context.Background(), "future"); err == nil {
+		t.Fatalf("newPlugin() should refuse a plugin speaking another protocol")
+	}
+}
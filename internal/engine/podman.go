@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"regexp"
@@ -12,33 +13,147 @@ import (
 	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/peaberberian/paul-envs/internal/config"
 	"github.com/peaberberian/paul-envs/internal/files"
-	"golang.org/x/term"
+	"github.com/peaberberian/paul-envs/internal/profiling"
+	"github.com/peaberberian/paul-envs/internal/utils"
 )
 
 // Implements `ContainerEngine` for Podman.
-type PodmanEngine struct{}
+type PodmanEngine struct {
+	quirksOnce       sync.Once
+	quirks           engineQuirks
+	capabilitiesOnce sync.Once
+	capabilities     Capabilities
+	// `true` for `SelectionPodmanRootful`
+	rootful bool
+	// Set to reach rootful Podman as a regular user, see `connectRootfulPodman`
+	remoteURL    string
+	rootlessOnce sync.Once
+	rootless     bool
+}
 
-func newPodman(ctx context.Context) (*PodmanEngine, error) {
-	if _, err := exec.LookPath("podman"); err != nil {
+func newPodman(ctx context.Context, rootful bool) (*PodmanEngine, error) {
+	if _, err := lookEngineExecutable("podman"); err != nil {
 		return nil, fmt.Errorf("podman command not found: %w", err)
 	}
-	return &PodmanEngine{}, nil
+	engine := &PodmanEngine{rootful: rootful}
+	if rootful && os.Geteuid() != 0 {
+		remoteURL, err := connectRootfulPodman(ctx)
+		if err != nil {
+			return nil, err
+		}
+		engine.remoteURL = remoteURL
+	}
+	return engine, nil
+}
+
+func (c *PodmanEngine) BuildBaseImage(ctx context.Context, baseFilesDir string, options BuildOptions) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman BuildBaseImage")()
+	if err := c.checkBuilder(options); err != nil {
+		return err
+	}
+	if err := c.checkCacheMounts(ctx, options); err != nil {
+		return err
+	}
+	options.proxyEnv = config.ProxyEnv(os.Environ(), nil)
+	registryEnv, registryArgs, err := c.registryOptions()
+	if err != nil {
+		return err
+	}
+	options.registryEnv, options.registryArgs = registryEnv, registryArgs
+	cmdArgs := podmanBaseBuildArgs(baseFilesDir, options)
+	if err := runBuildAttempts(ctx, options, func(ctx context.Context) *exec.Cmd {
+		cmd := c.buildCommand(ctx, options, cmdArgs...)
+		withEnv(cmd, slices.Concat(options.proxyEnv, options.registryEnv))
+		return cmd
+	}); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("base image build failed: %w", err)
+	}
+	return nil
+}
+
+func podmanBaseBuildArgs(baseFilesDir string, options BuildOptions) []string {
+	cmdArgs := append([]string{"build"}, offlinePullArgs()...)
+	if options.NoCache {
+		cmdArgs = append(cmdArgs, "--no-cache")
+	}
+	cmdArgs = append(cmdArgs, podmanLayerArgs(options)...)
+	if options.Platform != "" {
+		cmdArgs = append(cmdArgs, "--platform", options.Platform)
+	}
+	if options.DistributionImage != "" {
+		cmdArgs = append(cmdArgs, "--build-arg", "DISTRIBUTION_IMAGE="+options.DistributionImage)
+	}
+	cmdArgs = append(cmdArgs, envNameArgs("--build-arg", options.proxyEnv)...)
+	cmdArgs = append(cmdArgs, options.registryArgs...)
+	return append(cmdArgs,
+		"--file", hostPath(baseDockerfilePath(baseFilesDir)),
+		"--tag", baseImageNameFor(options.Platform),
+		hostPath(baseFilesDir),
+	)
+}
+
+func (c *PodmanEngine) HasBaseImage(ctx context.Context, platform string) (bool, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman HasBaseImage")()
+	cmd := c.command(ctx, "image", "inspect", "localhost/"+baseImageNameFor(platform))
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return false, pErr
+		}
+		if c.getQuirks(ctx).isMissingImage(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
 }
 
 func (c *PodmanEngine) BuildImage(ctx context.Context, project files.ProjectEntry, options BuildOptions) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman BuildImage")()
+	if err := c.checkBuilder(options); err != nil {
+		return err
+	}
+	if err := c.checkCacheMounts(ctx, options); err != nil {
+		return err
+	}
 	buildCfg, err := loadBuildConfig(project)
 	if err != nil {
 		return err
 	}
 
+	runtimeCfg, err := loadRuntimeConfig(project)
+	if err != nil {
+		return err
+	}
+
+	options.proxyEnv = projectProxyEnv(runtimeCfg)
+	if options.registryEnv, options.registryArgs, err = c.registryOptions(); err != nil {
+		return err
+	}
+	options = withProjectBuildSettings(options, runtimeCfg)
+	options = withProjectBuildArgs(options, buildCfg)
+	configHash, err := utils.FileHash(project.BuildConfigPath)
+	if err != nil {
+		return fmt.Errorf("cannot hash build.conf: %w", err)
+	}
+	options = withProjectImageSettings(options, project.ProjectName, buildCfg, configHash)
+	options = withProvenanceLabels(ctx, options, project, buildCfg, runtimeCfg)
+	if options, err = withProjectDockerfile(options, project, buildCfg); err != nil {
+		return err
+	}
 	cmdArgs := podmanBuildArgs(project, buildCfg.Args, options)
-	cmd := exec.CommandContext(ctx, "podman", cmdArgs...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	if err := runBuildAttempts(ctx, options, func(ctx context.Context) *exec.Cmd {
+		cmd := c.buildCommand(ctx, options, cmdArgs...)
+		withEnv(cmd, slices.Concat(options.proxyEnv, options.registryEnv))
+		return cmd
+	}); err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return pErr
 		}
@@ -48,12 +163,14 @@ func (c *PodmanEngine) BuildImage(ctx context.Context, project files.ProjectEntr
 }
 
 func podmanBuildArgs(project files.ProjectEntry, buildArgs map[string]string, options BuildOptions) []string {
-	cmdArgs := []string{"build"}
+	dockerfile, buildContext := projectBuildFiles(project, options)
+	cmdArgs := append([]string{"build"}, offlinePullArgs()...)
 	if options.NoCache {
 		cmdArgs = append(cmdArgs, "--no-cache")
 	}
+	cmdArgs = append(cmdArgs, podmanLayerArgs(options)...)
 	cmdArgs = append(cmdArgs,
-		"--file", projectDockerfilePath(project),
+		"--file", hostPath(dockerfile),
 		"--tag", projectImageName(project.ProjectName),
 	)
 
@@ -62,16 +179,27 @@ func podmanBuildArgs(project files.ProjectEntry, buildArgs map[string]string, op
 		keys = append(keys, key)
 	}
 	sort.Strings(keys)
+	if options.Platform != "" {
+		cmdArgs = append(cmdArgs, "--platform", options.Platform)
+	}
+	cmdArgs = append(cmdArgs, "--build-arg", "BASE_IMAGE=localhost/"+baseImageNameFor(options.Platform))
 	for _, key := range keys {
 		// Safe without extra escaping: exec.Command passes this as a single argv
 		// element, and directive names are validated earlier at parsing time
 		cmdArgs = append(cmdArgs, "--build-arg", fmt.Sprintf("%s=%s", key, buildArgs[key]))
 	}
-	cmdArgs = append(cmdArgs, projectBaseDataDir(project))
+	cmdArgs = append(cmdArgs, envNameArgs("--build-arg", options.proxyEnv)...)
+	cmdArgs = append(cmdArgs, options.registryArgs...)
+	// Last, so they replace the ones above
+	cmdArgs = append(cmdArgs, buildArgFlags(options.BuildArgs)...)
+	cmdArgs = append(cmdArgs, imageLabelFlags(options.labels)...)
+	cmdArgs = append(cmdArgs, customDockerfileArgs(project, options)...)
+	cmdArgs = append(cmdArgs, hostPath(buildContext))
 	return cmdArgs
 }
 
-func (c *PodmanEngine) RunContainer(ctx context.Context, project files.ProjectEntry, args []string) error {
+func (c *PodmanEngine) RunContainer(ctx context.Context, project files.ProjectEntry, args []string, options RunOptions) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman RunContainer")()
 	buildCfg, err := loadBuildConfig(project)
 	if err != nil {
 		return err
@@ -81,62 +209,49 @@ func (c *PodmanEngine) RunContainer(ctx context.Context, project files.ProjectEn
 		return err
 	}
 
-	username := buildCfg.Args["USERNAME"]
-	projectMount := projectMountTarget(username, project.ProjectName)
-	workDir := runtimeCfg.WorkDir
-	if workDir == "" {
-		workDir = projectMount
-	}
-
-	if err := c.ensureVolumesExist(ctx, "paulenv-shared-cache", projectLocalVolumeName(project.ProjectName)); err != nil {
+	if err := c.ensureVolumesExist(ctx, "paulenv-shared-cache", options.Instance.localVolumeName(project.ProjectName)); err != nil {
 		return err
 	}
-
-	cmdArgs := []string{"run"}
-	if shouldUsePodmanKeepID() {
-		cmdArgs = append(cmdArgs, "--userns=keep-id")
-	}
-	cmdArgs = append(cmdArgs,
-		"--rm",
-		"--init",
-		"--name", projectContainerName(project.ProjectName),
-		"--workdir", workDir,
-		"--volume", runtimeCfg.ProjectPath+":"+projectMount,
-		"--volume", "paulenv-shared-cache:/home/"+username+"/.container-cache",
-		"--volume", projectLocalVolumeName(project.ProjectName)+":/home/"+username+"/.container-local",
-	)
-	if runtimeCfg.DotfilesPath != "" {
-		dotfilesPath, err := resolveRuntimePath(project.RuntimeConfigPath, runtimeCfg.DotfilesPath)
-		if err != nil {
-			return fmt.Errorf("resolve DOTFILES_PATH: %w", err)
+	if network := runNetworkName(project.ProjectName, runtimeCfg); network != "" {
+		if err := c.ensureNetworkExists(ctx, network); err != nil {
+			return err
 		}
-		cmdArgs = append(cmdArgs, "--volume", dotfilesPath+":/paul-env/dotfiles:ro")
-	}
-	if runtimeCfg.GitName != "" {
-		cmdArgs = append(cmdArgs, "--env", "GIT_AUTHOR_NAME="+runtimeCfg.GitName)
-	}
-	if runtimeCfg.GitEmail != "" {
-		cmdArgs = append(cmdArgs, "--env", "GIT_AUTHOR_EMAIL="+runtimeCfg.GitEmail)
 	}
 
-	for _, volume := range runtimeCfg.Volumes {
-		cmdArgs = append(cmdArgs, "--volume", volume)
+	userns, err := podmanUserns(runtimeCfg.Userns, c.getQuirks(ctx), c.isRootless(ctx))
+	if err != nil {
+		return err
 	}
-	for _, port := range runtimeCfg.Ports {
-		cmdArgs = append(cmdArgs, "--publish", port)
+	session := usesPersistentSession(runtimeCfg, args, options.hasTerminalInput())
+	if hasSystemd(buildCfg) || session {
+		// systemd keeps the container alive by itself
+		var detachedCmd []string
+		if !hasSystemd(buildCfg) {
+			detachedCmd = backgroundCommand
+		}
+		cmdArgs, err := podmanRunArgs(project, buildCfg, runtimeCfg, userns, c.isRootless(ctx), false, options.runEnv(), detachedCmd)
+		if err != nil {
+			return err
+		}
+		cmd := c.command(ctx, detachedRunArgs(options.containerRunArgs(cmdArgs, project))...)
+		options.withSecrets(cmd)
+		withEnv(cmd, projectProxyEnv(runtimeCfg))
+		return runDetachedContainer(ctx, c, project, options.Instance, cmd, args, session)
 	}
-	if term.IsTerminal(int(os.Stdin.Fd())) {
-		cmdArgs = append(cmdArgs, "--tty", "--interactive")
+	cmdArgs, err := podmanRunArgs(project, buildCfg, runtimeCfg, userns, c.isRootless(ctx), options.hasTerminalInput(), options.runEnv(), args)
+	if err != nil {
+		return err
 	}
 
-	cmdArgs = append(cmdArgs, projectImageName(project.ProjectName))
-	cmdArgs = append(cmdArgs, args...)
-
-	cmd := exec.CommandContext(ctx, "podman", cmdArgs...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err = cmd.Run(); err != nil {
+	cmd := c.command(ctx, options.containerRunArgs(cmdArgs, project)...)
+	options.withSecrets(cmd)
+	withEnv(cmd, projectProxyEnv(runtimeCfg))
+	options.withStreams(cmd)
+	if err = runEngineCommand(cmd); err != nil {
+		if ctx.Err() != nil {
+			removeInterruptedContainer(ctx, c, instanceContainerName(project.ProjectName, options.Instance.Name))
+			return fmt.Errorf("run interrupted: %w", ctx.Err())
+		}
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return pErr
 		}
@@ -145,18 +260,43 @@ func (c *PodmanEngine) RunContainer(ctx context.Context, project files.ProjectEn
 	return nil
 }
 
+func (c *PodmanEngine) CheckShell(ctx context.Context, project files.ProjectEntry) (ShellCheck, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman CheckShell")()
+	runtimeCfg, err := loadRuntimeConfig(project)
+	if err != nil {
+		return ShellCheck{}, err
+	}
+	cmdArgs, err := shellCheckRunArgs(project, runtimeCfg, projectImageName(project.ProjectName))
+	if err != nil {
+		return ShellCheck{}, err
+	}
+	cmd := c.command(ctx, cmdArgs...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err = runEngineCommand(cmd)
+	check, ran := shellCheckResult(err, stderr.String())
+	if !ran {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return ShellCheck{}, pErr
+		}
+		return ShellCheck{}, fmt.Errorf("failed to run the shell of project %s: %w", project.ProjectName, err)
+	}
+	return check, nil
+}
+
 func (c *PodmanEngine) JoinContainer(ctx context.Context, containerInfo ContainerInfo, args []string) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman JoinContainer")()
 	cmdArgs := []string{"exec"}
-	if term.IsTerminal(int(os.Stdin.Fd())) {
+	if stdinIsTerminal() {
 		cmdArgs = append(cmdArgs, "-it")
 	}
 	cmdArgs = append(cmdArgs, containerInfo.ContainerId, "/usr/local/bin/entrypoint.sh")
 	cmdArgs = append(cmdArgs, args...)
-	cmd := exec.CommandContext(ctx, "podman", cmdArgs...)
+	cmd := c.command(ctx, cmdArgs...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	if err := runEngineCommand(cmd); err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return pErr
 		}
@@ -165,16 +305,136 @@ func (c *PodmanEngine) JoinContainer(ctx context.Context, containerInfo Containe
 	return nil
 }
 
+func (c *PodmanEngine) ExecContainer(ctx context.Context, containerInfo ContainerInfo, args []string, options ExecOptions) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman ExecContainer")()
+	tty := options.hasTerminalInput()
+	cmd := c.command(ctx, execArgs(containerInfo.ContainerId, args, options, tty)...)
+	options.withStreams(cmd)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("exec exited: %w", err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) CopyTo(ctx context.Context, containerInfo ContainerInfo, hostFile string, containerPath string) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman CopyTo")()
+	// Keep the uid and gid of copied files, Podman gives them to the
+	// container's main user (root) otherwise
+	cmd := c.command(ctx, "cp", "--archive=false", hostPath(hostFile), containerInfo.ContainerId+":"+containerPath)
+	cmd.Stderr = engineOutput
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to copy %s into container %s: %w", hostFile, containerInfo.ContainerId, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) CopyFrom(ctx context.Context, containerInfo ContainerInfo, containerPath string, hostFile string) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman CopyFrom")()
+	cmd := c.command(ctx, "cp", containerInfo.ContainerId+":"+containerPath, hostPath(hostFile))
+	cmd.Stderr = engineOutput
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to copy %s from container %s: %w", containerPath, containerInfo.ContainerId, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) StartContainer(ctx context.Context, project files.ProjectEntry) (ContainerInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman StartContainer")()
+	buildCfg, err := loadBuildConfig(project)
+	if err != nil {
+		return ContainerInfo{}, err
+	}
+	runtimeCfg, err := loadRuntimeConfig(project)
+	if err != nil {
+		return ContainerInfo{}, err
+	}
+
+	if err := c.ensureVolumesExist(ctx, "paulenv-shared-cache", ProjectLocalVolumeName(project.ProjectName)); err != nil {
+		return ContainerInfo{}, err
+	}
+	if network := runNetworkName(project.ProjectName, runtimeCfg); network != "" {
+		if err := c.ensureNetworkExists(ctx, network); err != nil {
+			return ContainerInfo{}, err
+		}
+	}
+
+	userns, err := podmanUserns(runtimeCfg.Userns, c.getQuirks(ctx), c.isRootless(ctx))
+	if err != nil {
+		return ContainerInfo{}, err
+	}
+	cmdArgs, err := podmanRunArgs(project, buildCfg, runtimeCfg, userns, c.isRootless(ctx), false, nil, backgroundCommand)
+	if err != nil {
+		return ContainerInfo{}, err
+	}
+
+	cmd := c.command(ctx, detachedRunArgs(cmdArgs)...)
+	withEnv(cmd, projectProxyEnv(runtimeCfg))
+	cmd.Stderr = engineOutput
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return ContainerInfo{}, pErr
+		}
+		return ContainerInfo{}, fmt.Errorf("start failed: %w", err)
+	}
+	projectName := project.ProjectName
+	containerName := projectContainerName(projectName)
+	imageName := projectImageName(projectName)
+	return ContainerInfo{
+		ProjectName:   &projectName,
+		ContainerName: &containerName,
+		ImageName:     &imageName,
+		ContainerId:   strings.TrimSpace(string(output)),
+		Running:       true,
+	}, nil
+}
+
+func (c *PodmanEngine) GetContainerStartTime(ctx context.Context, container ContainerInfo) (time.Time, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman GetContainerStartTime")()
+	startedAt, err := containerStartTime(ctx, c.command, container.ContainerId)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return time.Time{}, pErr
+		}
+	}
+	return startedAt, err
+}
+
+func (c *PodmanEngine) WaitContainer(ctx context.Context, container ContainerInfo) (ContainerExit, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman WaitContainer")()
+	exit, err := waitContainer(ctx, c.command, container.ContainerId)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ContainerExit{}, ctx.Err()
+		}
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return ContainerExit{}, pErr
+		}
+		return ContainerExit{}, err
+	}
+	return exit, nil
+}
+
 func (c *PodmanEngine) HasBeenBuilt(ctx context.Context, projectName string) (bool, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman HasBeenBuilt")()
 	imageName := projectImageName(projectName)
-	cmd := exec.CommandContext(ctx, "podman", "image", "inspect", imageName)
-	err := cmd.Run()
+	cmd := c.command(ctx, "image", "inspect", imageName)
+	err := runEngineCommand(cmd)
 
 	if err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return false, pErr
 		}
-		if exitErr, ok := err.(*exec.ExitError); ok && (exitErr.ExitCode() == 125 || exitErr.ExitCode() == 1) {
+		if c.getQuirks(ctx).isMissingImage(err) {
 			return false, nil
 		}
 		return false, err
@@ -184,8 +444,9 @@ func (c *PodmanEngine) HasBeenBuilt(ctx context.Context, projectName string) (bo
 }
 
 func (c *PodmanEngine) Info(ctx context.Context) (EngineInfo, error) {
-	cmd := exec.CommandContext(ctx, "podman", "--version")
-	output, err := cmd.Output()
+	defer profiling.Track(profiling.CategoryEngine, "podman Info")()
+	cmd := c.command(ctx, "--version")
+	output, err := engineCommandOutput(cmd)
 	if err != nil {
 		return EngineInfo{}, fmt.Errorf("failed to obtain podman version: %w", err)
 	}
@@ -193,15 +454,37 @@ func (c *PodmanEngine) Info(ctx context.Context) (EngineInfo, error) {
 	re := regexp.MustCompile(`podman version ([0-9]+\.[0-9]+\.[0-9]+)`)
 	matches := re.FindStringSubmatch(parsed)
 	if len(matches) > 1 {
-		return EngineInfo{Version: matches[1], Name: "podman"}, nil
+		name := "podman"
+		if c.rootful {
+			// Has its own images and containers
+			name = string(SelectionPodmanRootful)
+		}
+		return EngineInfo{Version: matches[1], Name: name}, nil
 	}
 	return EngineInfo{}, fmt.Errorf("failed to obtain podman version, unknown version format: %s", parsed)
 }
 
+// Workarounds needed for the installed Podman version.
+func (c *PodmanEngine) getQuirks(ctx context.Context) engineQuirks {
+	c.quirksOnce.Do(func() {
+		info, _ := c.Info(ctx)
+		c.quirks = quirksFor("podman", info.Version)
+	})
+	return c.quirks
+}
+
+// Rely on `version` being the installed version, e.g. when already known.
+func (c *PodmanEngine) setVersion(version string) {
+	c.quirksOnce.Do(func() {
+		c.quirks = quirksFor("podman", version)
+	})
+}
+
 func (c *PodmanEngine) CreateVolume(ctx context.Context, name string) error {
-	cmd := exec.CommandContext(ctx, "podman", "volume", "create", name)
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	defer profiling.Track(profiling.CategoryEngine, "podman CreateVolume")()
+	cmd := c.command(ctx, volumeCreateArgs(name)...)
+	cmd.Stderr = engineOutput
+	if err := runEngineCommand(cmd); err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return pErr
 		}
@@ -211,27 +494,33 @@ func (c *PodmanEngine) CreateVolume(ctx context.Context, name string) error {
 }
 
 func (c *PodmanEngine) GetImageInfo(ctx context.Context, projectName string) (*ImageInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman GetImageInfo")()
 	imageName := "localhost/" + projectImageName(projectName)
 	info := &ImageInfo{ImageName: imageName, ProjectName: &projectName}
-	cmd := exec.CommandContext(ctx, "podman", "image", "inspect", imageName, "--format", "{{.Created}}")
-	output, err := cmd.Output()
+	cmd := c.command(ctx, "image", "inspect", imageName, "--format", imageInspectFormat)
+	output, err := engineCommandOutput(cmd)
 	if err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return nil, pErr
-		} else if exitErr, ok := err.(*exec.ExitError); ok && (exitErr.ExitCode() == 125 || exitErr.ExitCode() == 1) {
+		} else if c.getQuirks(ctx).isMissingImage(err) {
 			return info, nil
 		}
 		return nil, err
 	}
-	if buildTime := parseCreatedAt(string(output)); buildTime != nil {
+	created, architecture, labels := parseImageInspect(string(output))
+	if buildTime := c.getQuirks(ctx).parseCreatedAt(created); buildTime != nil {
 		info.BuiltAt = buildTime
 	}
+	info.Architecture = architecture
+	info.ConfigHash = labels[configHashLabel]
+	info.Provenance = parseProvenance(labels)
 	return info, nil
 }
 
 func (c *PodmanEngine) ListContainers(ctx context.Context) ([]ContainerInfo, error) {
-	cmd := exec.CommandContext(ctx, "podman", "ps", "-a", "--format", "{{.ID}}\t{{.Image}}\t{{.Names}}")
-	output, err := cmd.Output()
+	defer profiling.Track(profiling.CategoryEngine, "podman ListContainers")()
+	cmd := c.command(ctx, "ps", "-a", "--no-trunc", "--format", "{{.ID}}\t{{.Image}}\t{{.Names}}\t{{.State}}\t{{index .Labels \""+projectLabel+"\"}}\t{{index .Labels \""+instanceLabel+"\"}}\t{{index .Labels \""+sourceLabel+"\"}}")
+	output, err := engineCommandOutput(cmd)
 	if err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return []ContainerInfo{}, pErr
@@ -239,48 +528,45 @@ func (c *PodmanEngine) ListContainers(ctx context.Context) ([]ContainerInfo, err
 		return []ContainerInfo{}, fmt.Errorf("failed to list containers: %w", err)
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	result := make([]ContainerInfo, 0, len(lines))
-	for _, s := range lines {
-		if s == "" {
-			continue
-		}
-		parts := strings.SplitN(s, "\t", 3)
-		id := parts[0]
-		var image *string
-		var name *string
-		var projectName *string
-
-		if len(parts) > 1 && parts[1] != "" {
-			image = &parts[1]
-		}
-		if len(parts) > 2 && parts[2] != "" {
-			name = &parts[2]
-		}
+	return parseContainerList(string(output)), nil
+}
 
-		if image != nil {
-			projectName = projectNameFromImage(*image)
+func (c *PodmanEngine) ListNamedResources(ctx context.Context, names ResourceNames) ([]NamedResource, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman ListNamedResources")()
+	var resources []NamedResource
+	if len(names.Containers) > 0 {
+		cmd := c.command(ctx, namedContainerListArgs(names.Containers)...)
+		output, err := engineCommandOutput(cmd)
+		if err != nil {
+			if pErr := c.checkPermissions(ctx); pErr != nil {
+				return nil, pErr
+			}
+			return nil, fmt.Errorf("failed to list containers: %w", err)
 		}
-		if projectName == nil && name != nil {
-			projectName = projectNameFromContainerName(*name)
+		resources = append(resources, parseNamedContainerList(string(output), names.Containers)...)
+	}
+	for _, kind := range []string{"volume", "network"} {
+		kindNames := names.Volumes
+		if kind == "network" {
+			kindNames = names.Networks
 		}
-		if projectName == nil {
+		if len(kindNames) == 0 {
 			continue
 		}
-
-		result = append(result, ContainerInfo{
-			ProjectName:   projectName,
-			ContainerName: name,
-			ContainerId:   id,
-			ImageName:     image,
-		})
+		cmd := c.command(ctx, namedResourceListArgs(kind, kindNames)...)
+		output, err := engineCommandOutput(cmd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %ss: %w", kind, err)
+		}
+		resources = append(resources, parseNamedResourceList(kind, string(output), kindNames)...)
 	}
-	return result, nil
+	return resources, nil
 }
 
 func (c *PodmanEngine) RemoveContainer(ctx context.Context, container ContainerInfo) error {
-	cmd := exec.CommandContext(ctx, "podman", "rm", "-f", container.ContainerId)
-	if err := cmd.Run(); err != nil {
+	defer profiling.Track(profiling.CategoryEngine, "podman RemoveContainer")()
+	cmd := c.command(ctx, "rm", "-f", "-v", container.ContainerId)
+	if err := runEngineCommand(cmd); err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return pErr
 		}
@@ -289,50 +575,115 @@ func (c *PodmanEngine) RemoveContainer(ctx context.Context, container ContainerI
 	return nil
 }
 
+func (c *PodmanEngine) StopContainer(ctx context.Context, container ContainerInfo) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman StopContainer")()
+	cmd := c.command(ctx, "stop", container.ContainerId)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to stop container %s: %w", container.ContainerId, err)
+	}
+	return nil
+}
+
 func (c *PodmanEngine) checkPermissions(ctx context.Context) error {
-	cmd := exec.CommandContext(ctx, "podman", "ps")
+	cmd := c.command(ctx, "ps")
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
-	if err := cmd.Run(); err != nil {
-		stderrStr := stderr.String()
-		if strings.Contains(stderrStr, "permission denied") ||
-			strings.Contains(stderrStr, "access denied") ||
-			strings.Contains(stderrStr, "cannot connect to Podman") {
-			return errors.New("permission denied. Please check Podman permissions")
-		}
-		return fmt.Errorf("failed to connect to Podman: %w\n%s", err, stderrStr)
+	err := runEngineCommand(cmd)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrPermission):
+		return utils.WithCategory(fmt.Errorf("permission denied while connecting to Podman: %s\n"+
+			"Run 'paul-envs doctor' to diagnose the setup", strings.TrimSpace(stderr.String())), ErrPermission)
+	case errors.Is(err, ErrEngineUnavailable):
+		return utils.WithCategory(fmt.Errorf("failed to connect to Podman: %s\n"+
+			"Run 'paul-envs doctor' to diagnose the setup", strings.TrimSpace(stderr.String())), ErrEngineUnavailable)
 	}
-	return nil
+	return fmt.Errorf("failed to connect to Podman: %w\n%s\nRun 'paul-envs doctor' to diagnose the setup", err, stderr.String())
 }
 
 func (c *PodmanEngine) ListVolumes(ctx context.Context) ([]VolumeInfo, error) {
-	cmd := exec.CommandContext(ctx, "podman", "volume", "ls", "--format", "{{.Name}}")
-	output, err := cmd.Output()
+	defer profiling.Track(profiling.CategoryEngine, "podman ListVolumes")()
+	cmd := c.command(ctx, "volume", "ls", "--format", "{{.Name}}\t{{index .Labels \""+managedLabel+"\"}}")
+	output, err := engineCommandOutput(cmd)
 	if err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return []VolumeInfo{}, pErr
 		}
 		return []VolumeInfo{}, fmt.Errorf("failed to list volumes: %w", err)
 	}
+	return parseVolumeList(string(output)), nil
+}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	result := make([]VolumeInfo, 0, len(lines))
-	for _, volumeName := range lines {
-		if volumeName == "" || !isPaulEnvVolume(volumeName) {
-			continue
+func (c *PodmanEngine) GetDiskUsage(ctx context.Context) (DiskUsage, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman GetDiskUsage")()
+	cmd := c.command(ctx, "system", "df", "-v")
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return DiskUsage{}, pErr
 		}
-		result = append(result, VolumeInfo{
-			VolumeId:   volumeName,
-			VolumeName: volumeName,
-		})
+		return DiskUsage{}, fmt.Errorf("failed to obtain disk usage: %w", err)
 	}
-	return result, nil
+	return parsePodmanDiskUsage(string(output)), nil
+}
+
+func (c *PodmanEngine) GetImageHistory(ctx context.Context, projectName string) ([]ImageLayer, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman GetImageHistory")()
+	cmd := c.command(ctx, imageHistoryArgs("localhost/"+projectImageName(projectName))...)
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return nil, pErr
+		}
+		return nil, fmt.Errorf("failed to list the layers of the image of project '%s': %w", projectName, err)
+	}
+	layers := parseImageHistory(string(output))
+	// Without it, no layer is reported as coming from it
+	cmd = c.command(ctx, imageHistoryArgs("localhost/"+baseImageName)...)
+	if output, err := engineCommandOutput(cmd); err == nil {
+		markBaseImageLayers(layers, parseImageHistory(string(output)))
+	}
+	return layers, nil
+}
+
+func (c *PodmanEngine) ExportVolume(ctx context.Context, name string, w io.Writer) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman ExportVolume")()
+	cmd := c.command(ctx, "volume", "export", name)
+	cmd.Stdout = w
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to export volume %s: %w", name, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) ImportVolume(ctx context.Context, name string, r io.Reader) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman ImportVolume")()
+	if err := c.ensureVolumesExist(ctx, name); err != nil {
+		return err
+	}
+	cmd := c.command(ctx, "volume", "import", name, "-")
+	cmd.Stdin = r
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to import volume %s: %w", name, err)
+	}
+	return nil
 }
 
 func (c *PodmanEngine) RemoveVolume(ctx context.Context, volume VolumeInfo) error {
-	cmd := exec.CommandContext(ctx, "podman", "volume", "rm", volume.VolumeName)
-	if err := cmd.Run(); err != nil {
+	defer profiling.Track(profiling.CategoryEngine, "podman RemoveVolume")()
+	cmd := c.command(ctx, "volume", "rm", volume.VolumeName)
+	if err := runEngineCommand(cmd); err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return pErr
 		}
@@ -342,8 +693,10 @@ func (c *PodmanEngine) RemoveVolume(ctx context.Context, volume VolumeInfo) erro
 }
 
 func (c *PodmanEngine) ListNetworks(ctx context.Context) ([]NetworkInfo, error) {
-	cmd := exec.CommandContext(ctx, "podman", "network", "ls", "--format", "{{.ID}}\t{{.Name}}")
-	output, err := cmd.Output()
+	defer profiling.Track(profiling.CategoryEngine, "podman ListNetworks")()
+	cmd := c.command(ctx, "network", "ls", "--format",
+		"{{.ID}}\t{{.Name}}\t{{index .Labels \""+managedLabel+"\"}}\t{{index .Labels \""+projectLabel+"\"}}")
+	output, err := engineCommandOutput(cmd)
 	if err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return []NetworkInfo{}, pErr
@@ -351,32 +704,13 @@ func (c *PodmanEngine) ListNetworks(ctx context.Context) ([]NetworkInfo, error)
 		return []NetworkInfo{}, fmt.Errorf("failed to list networks: %w", err)
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	result := make([]NetworkInfo, 0, len(lines))
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-		parts := strings.SplitN(line, "\t", 2)
-		if len(parts) < 2 {
-			continue
-		}
-		projectName := projectNameFromContainerName(parts[1])
-		if projectName == nil {
-			continue
-		}
-		result = append(result, NetworkInfo{
-			NetworkId:   parts[0],
-			NetworkName: parts[1],
-			ProjectName: projectName,
-		})
-	}
-	return result, nil
+	return parseNetworkList(string(output)), nil
 }
 
 func (c *PodmanEngine) RemoveNetwork(ctx context.Context, network NetworkInfo) error {
-	cmd := exec.CommandContext(ctx, "podman", "network", "rm", network.NetworkId)
-	if err := cmd.Run(); err != nil {
+	defer profiling.Track(profiling.CategoryEngine, "podman RemoveNetwork")()
+	cmd := c.command(ctx, "network", "rm", network.NetworkId)
+	if err := runEngineCommand(cmd); err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return pErr
 		}
@@ -386,8 +720,9 @@ func (c *PodmanEngine) RemoveNetwork(ctx context.Context, network NetworkInfo) e
 }
 
 func (c *PodmanEngine) PruneBuildCache(ctx context.Context) error {
-	cmd := exec.CommandContext(ctx, "podman", "image", "prune", "-f", "--filter", "label=paulenv=true")
-	if err := cmd.Run(); err != nil {
+	defer profiling.Track(profiling.CategoryEngine, "podman PruneBuildCache")()
+	cmd := c.command(ctx, "image", "prune", "-f", "--filter", "label=paulenv=true")
+	if err := runEngineCommand(cmd); err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return pErr
 		}
@@ -396,13 +731,10 @@ func (c *PodmanEngine) PruneBuildCache(ctx context.Context) error {
 	return nil
 }
 
-func (c *PodmanEngine) SupportsBuildCachePrune() bool {
-	return false
-}
-
 func (c *PodmanEngine) ListImages(ctx context.Context) ([]ImageInfo, error) {
-	cmd := exec.CommandContext(ctx, "podman", "images", "--format", "{{.Repository}}:{{.Tag}}\t{{.CreatedAt}}")
-	output, err := cmd.Output()
+	defer profiling.Track(profiling.CategoryEngine, "podman ListImages")()
+	cmd := c.command(ctx, "images", "--format", "{{.Repository}}:{{.Tag}}\t{{.CreatedAt}}\t{{.Size}}")
+	output, err := engineCommandOutput(cmd)
 	if err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return []ImageInfo{}, pErr
@@ -416,30 +748,218 @@ func (c *PodmanEngine) ListImages(ctx context.Context) ([]ImageInfo, error) {
 		if line == "" {
 			continue
 		}
-		parts := strings.SplitN(line, "\t", 2)
+		parts := strings.SplitN(line, "\t", 3)
 		imageName := parts[0]
 		projectName := projectNameFromImage(imageName)
-		if projectName == nil {
+		if projectName == nil && !isBaseImage(imageName) {
 			continue
 		}
 
 		var builtAt *time.Time
 		if len(parts) > 1 {
-			builtAt = parseCreatedAt(parts[1])
+			builtAt = c.getQuirks(ctx).parseCreatedAt(parts[1])
+		}
+		size := ""
+		if len(parts) > 2 {
+			size = strings.TrimSpace(parts[2])
 		}
 
 		result = append(result, ImageInfo{
 			ImageName:   imageName,
 			ProjectName: projectName,
 			BuiltAt:     builtAt,
+			Size:        size,
 		})
 	}
 	return result, nil
 }
 
+func (c *PodmanEngine) CommitContainer(ctx context.Context, container ContainerInfo, projectName string, tag string) (SnapshotInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman CommitContainer")()
+	imageName := snapshotImageName(projectName, tag)
+	args := append([]string{"commit"}, snapshotCommitChanges...)
+	cmd := c.command(ctx, append(args, container.ContainerId, imageName)...)
+	cmd.Stderr = engineOutput
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return SnapshotInfo{}, pErr
+		}
+		return SnapshotInfo{}, fmt.Errorf("failed to commit container %s: %w", container.ContainerId, err)
+	}
+	now := time.Now()
+	return SnapshotInfo{ProjectName: projectName, Tag: tag, ImageName: imageName, CreatedAt: &now}, nil
+}
+
+func (c *PodmanEngine) ListSnapshots(ctx context.Context) ([]SnapshotInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman ListSnapshots")()
+	cmd := c.command(ctx, "images", "--format", "{{.Repository}}:{{.Tag}}\t{{.CreatedAt}}\t{{.Size}}")
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return nil, pErr
+		}
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	return parseSnapshotList(string(output), c.getQuirks(ctx)), nil
+}
+
+func (c *PodmanEngine) RestoreSnapshot(ctx context.Context, snapshot SnapshotInfo) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman RestoreSnapshot")()
+	cmd := c.command(ctx, "tag", snapshot.ImageName, projectImageName(snapshot.ProjectName))
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to restore snapshot %s: %w", snapshot.ImageName, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) RemoveSnapshot(ctx context.Context, snapshot SnapshotInfo) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman RemoveSnapshot")()
+	cmd := c.command(ctx, "rmi", snapshot.ImageName)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to remove snapshot %s: %w", snapshot.ImageName, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) SaveGeneration(ctx context.Context, projectName string, number int) (GenerationInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman SaveGeneration")()
+	imageName := generationImageName(projectName, number)
+	cmd := c.command(ctx, "tag", projectImageName(projectName), imageName)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return GenerationInfo{}, pErr
+		}
+		return GenerationInfo{}, fmt.Errorf("failed to keep the image of project %s: %w", projectName, err)
+	}
+	return GenerationInfo{ProjectName: projectName, Number: number, ImageName: imageName}, nil
+}
+
+func (c *PodmanEngine) ListGenerations(ctx context.Context) ([]GenerationInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman ListGenerations")()
+	cmd := c.command(ctx, "images", "--format", "{{.Repository}}:{{.Tag}}\t{{.CreatedAt}}\t{{.Size}}")
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return nil, pErr
+		}
+		return nil, fmt.Errorf("failed to list image generations: %w", err)
+	}
+	return parseGenerationList(string(output), c.getQuirks(ctx)), nil
+}
+
+func (c *PodmanEngine) RestoreGeneration(ctx context.Context, generation GenerationInfo) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman RestoreGeneration")()
+	cmd := c.command(ctx, "tag", generation.ImageName, projectImageName(generation.ProjectName))
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to restore image %s: %w", generation.ImageName, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) RemoveGeneration(ctx context.Context, generation GenerationInfo) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman RemoveGeneration")()
+	cmd := c.command(ctx, "rmi", generation.ImageName)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to remove image %s: %w", generation.ImageName, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) SaveArchImage(ctx context.Context, projectName string) (ArchImageInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman SaveArchImage")()
+	cmd := c.command(ctx, "image", "inspect", projectImageName(projectName), "--format", "{{.Architecture}}")
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return ArchImageInfo{}, pErr
+		}
+		return ArchImageInfo{}, fmt.Errorf("failed to get the architecture of the image of project %s: %w", projectName, err)
+	}
+	architecture := strings.TrimSpace(string(output))
+	imageName := archImageName(projectName, architecture)
+	cmd = c.command(ctx, "tag", projectImageName(projectName), imageName)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return ArchImageInfo{}, pErr
+		}
+		return ArchImageInfo{}, fmt.Errorf("failed to tag the %s image of project %s: %w", architecture, projectName, err)
+	}
+	return ArchImageInfo{ProjectName: projectName, Architecture: architecture, ImageName: imageName}, nil
+}
+
+func (c *PodmanEngine) ListArchImages(ctx context.Context) ([]ArchImageInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman ListArchImages")()
+	cmd := c.command(ctx, "images", "--format", "{{.Repository}}:{{.Tag}}\t{{.CreatedAt}}\t{{.Size}}")
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return nil, pErr
+		}
+		return nil, fmt.Errorf("failed to list architecture-specific images: %w", err)
+	}
+	return parseArchImageList(string(output), c.getQuirks(ctx)), nil
+}
+
+func (c *PodmanEngine) UseArchImage(ctx context.Context, image ArchImageInfo) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman UseArchImage")()
+	cmd := c.command(ctx, "tag", image.ImageName, projectImageName(image.ProjectName))
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to use image %s: %w", image.ImageName, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) RemoveArchImage(ctx context.Context, image ArchImageInfo) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman RemoveArchImage")()
+	cmd := c.command(ctx, "rmi", image.ImageName)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to remove image %s: %w", image.ImageName, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) EnableEmulation(ctx context.Context, architectures []string) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman EnableEmulation")()
+	// Even privileged, rootless containers cannot register emulators on the
+	// host
+	if c.isRootless(ctx) && runtime.GOOS == "linux" {
+		return fmt.Errorf("rootless Podman cannot register emulators, either run 'sudo podman %s' or install your distribution's qemu-user-static package",
+			strings.Join(enableEmulationArgs(architectures), " "))
+	}
+	cmd := c.command(ctx, enableEmulationArgs(architectures)...)
+	cmd.Stdout = engineOutput
+	cmd.Stderr = engineOutput
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to register emulators: %w", err)
+	}
+	return nil
+}
+
 func (c *PodmanEngine) RemoveImage(ctx context.Context, image ImageInfo) error {
-	cmd := exec.CommandContext(ctx, "podman", "rmi", "-f", image.ImageName)
-	if err := cmd.Run(); err != nil {
+	defer profiling.Track(profiling.CategoryEngine, "podman RemoveImage")()
+	cmd := c.command(ctx, "rmi", "-f", image.ImageName)
+	if err := runEngineCommand(cmd); err != nil {
 		if pErr := c.checkPermissions(ctx); pErr != nil {
 			return pErr
 		}
@@ -448,6 +968,268 @@ func (c *PodmanEngine) RemoveImage(ctx context.Context, image ImageInfo) error {
 	return nil
 }
 
+func (c *PodmanEngine) ExportImage(ctx context.Context, projectName string, w io.Writer) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman ExportImage")()
+	cmd := c.command(ctx, "image", "save", projectImageName(projectName))
+	cmd.Stdout = w
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to export the image of project %s: %w", projectName, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) ImportImage(ctx context.Context, projectName string, r io.Reader) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman ImportImage")()
+	cmd := c.command(ctx, "image", "load")
+	cmd.Stdin = r
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to import the image of project %s: %w", projectName, err)
+	}
+	loaded := parseLoadedImage(string(output))
+	if loaded == "" {
+		return fmt.Errorf("failed to import the image of project %s: no image was loaded", projectName)
+	}
+	cmd = c.command(ctx, "tag", loaded, projectImageName(projectName))
+	if err := runEngineCommand(cmd); err != nil {
+		return fmt.Errorf("failed to tag the imported image of project %s: %w", projectName, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) PushImage(ctx context.Context, projectName string, reference string) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman PushImage")()
+	if offline {
+		return offlineError("push", reference)
+	}
+	registryEnv, registryArgs, err := c.registryOptions()
+	if err != nil {
+		return err
+	}
+	cmd := c.command(ctx, slices.Concat([]string{"push"}, registryArgs, []string{projectImageName(projectName), reference})...)
+	withEnv(cmd, registryEnv)
+	cmd.Stdout, cmd.Stderr = engineOutput, engineOutput
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return registryError("push", reference, "podman", err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) PullImage(ctx context.Context, projectName string, reference string) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman PullImage")()
+	if offline {
+		return offlineError("pull", reference)
+	}
+	registryEnv, registryArgs, err := c.registryOptions()
+	if err != nil {
+		return err
+	}
+	cmd := c.command(ctx, slices.Concat([]string{"pull"}, registryArgs, []string{reference})...)
+	withEnv(cmd, registryEnv)
+	cmd.Stdout, cmd.Stderr = engineOutput, engineOutput
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return registryError("pull", reference, "podman", err)
+	}
+	cmd = c.command(ctx, "tag", reference, projectImageName(projectName))
+	if err := runEngineCommand(cmd); err != nil {
+		return fmt.Errorf("failed to tag the pulled image of project %s: %w", projectName, err)
+	}
+	// Only removes the pulled reference, which stays the project's image
+	_ = runEngineCommand(c.command(ctx, "rmi", reference))
+	return nil
+}
+
+func (c *PodmanEngine) ImageDigest(ctx context.Context, image string, pull bool) (string, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman ImageDigest")()
+	if pull {
+		if offline {
+			return "", offlineError("pull", image)
+		}
+		registryEnv, registryArgs, err := c.registryOptions()
+		if err != nil {
+			return "", err
+		}
+		cmd := c.command(ctx, slices.Concat([]string{"pull"}, registryArgs, []string{image})...)
+		withEnv(cmd, registryEnv)
+		cmd.Stdout, cmd.Stderr = engineOutput, engineOutput
+		if err := runEngineCommand(cmd); err != nil {
+			if pErr := c.checkPermissions(ctx); pErr != nil {
+				return "", pErr
+			}
+			return "", registryError("pull", image, "podman", err)
+		}
+	}
+	cmd := c.command(ctx, "image", "inspect", "--format", "{{json .RepoDigests}}", image)
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return "", pErr
+		}
+		return "", fmt.Errorf("failed to inspect image %s: %w", image, err)
+	}
+	return parseRepoDigests(output)
+}
+
+func (c *PodmanEngine) TagImage(ctx context.Context, image string, target string) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman TagImage")()
+	cmd := c.command(ctx, "tag", image, target)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to tag image %s as %s: %w", image, target, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) UntagImage(ctx context.Context, image string) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman UntagImage")()
+	cmd := c.command(ctx, "untag", image)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to untag image %s: %w", image, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) StartSidecar(ctx context.Context, projectName string, service config.Service, sharedNetwork bool) (SidecarInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman StartSidecar")()
+	network := sidecarNetworkName(projectName, sharedNetwork)
+	if err := c.ensureNetworkExists(ctx, network); err != nil {
+		return SidecarInfo{}, err
+	}
+	if service.DataPath != "" {
+		if err := c.ensureVolumesExist(ctx, sidecarDataVolumeName(projectName, service.Name)); err != nil {
+			return SidecarInfo{}, err
+		}
+	}
+	cmd := c.command(ctx, sidecarRunArgs(projectName, service, network)...)
+	cmd.Stderr = engineOutput
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return SidecarInfo{}, pErr
+		}
+		return SidecarInfo{}, fmt.Errorf("failed to start service %s: %w", service.Name, err)
+	}
+	return SidecarInfo{
+		ProjectName:   projectName,
+		ServiceName:   service.Name,
+		ContainerName: sidecarContainerName(projectName, service.Name),
+		ContainerId:   strings.TrimSpace(string(output)),
+		Running:       true,
+	}, nil
+}
+
+func (c *PodmanEngine) ListSidecars(ctx context.Context) ([]SidecarInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman ListSidecars")()
+	cmd := c.command(ctx, "ps", "-a", "--no-trunc", "--format", "{{.ID}}\t{{.Names}}\t{{.State}}\t{{index .Labels \""+projectLabel+"\"}}\t{{index .Labels \""+serviceLabel+"\"}}")
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return nil, pErr
+		}
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	return parseSidecarList(string(output)), nil
+}
+
+func (c *PodmanEngine) JoinSidecar(ctx context.Context, sidecar SidecarInfo, args []string) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman JoinSidecar")()
+	cmd := c.command(ctx, sidecarExecArgs(sidecar, stdinIsTerminal(), args)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("join exited: %w", err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) CheckSidecarHealth(ctx context.Context, sidecar SidecarInfo, command string) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman CheckSidecarHealth")()
+	cmd := engineCommand(ctx, "podman", sidecarExecArgs(sidecar, false, []string{"sh", "-c", command})...)
+	if err := runEngineCommand(cmd); err != nil {
+		return fmt.Errorf("healthcheck of service %s failed: %w", sidecar.ServiceName, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) GetContainerStats(ctx context.Context) ([]ContainerStats, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman GetContainerStats")()
+	containers, err := c.ListContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sidecars, err := c.ListSidecars(ctx)
+	if err != nil {
+		return nil, err
+	}
+	targets := newStatsTargets(containers, sidecars)
+	if len(targets) == 0 {
+		return nil, nil
+	}
+	cmd := c.command(ctx, targets.statsArgs()...)
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return nil, pErr
+		}
+		return nil, fmt.Errorf("failed to get container stats: %w", err)
+	}
+	return targets.parse(string(output)), nil
+}
+
+func (c *PodmanEngine) RemoveSidecar(ctx context.Context, sidecar SidecarInfo) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman RemoveSidecar")()
+	cmd := c.command(ctx, "rm", "-f", "-v", sidecar.ContainerId)
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to remove service container %s: %w", sidecar.ContainerName, err)
+	}
+	return nil
+}
+
+func (c *PodmanEngine) ensureNetworkExists(ctx context.Context, name string) error {
+	networks, err := c.ListNetworks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list created networks: %w", err)
+	}
+	for _, network := range networks {
+		if network.NetworkName == name {
+			return nil
+		}
+	}
+	cmd := c.command(ctx, networkCreateArgs(name)...)
+	cmd.Stderr = engineOutput
+	if err := runEngineCommand(cmd); err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+		return fmt.Errorf("failed to create network %s: %w", name, err)
+	}
+	return nil
+}
+
 func (c *PodmanEngine) ensureVolumesExist(ctx context.Context, names ...string) error {
 	volumes, err := c.ListVolumes(ctx)
 	if err != nil {
@@ -480,38 +1262,29 @@ func projectNameFromImage(imageName string) *string {
 	return nil
 }
 
+// Returns `true` if that volume is named like those created by paul-envs,
+// which is all that tells those of its earlier versions apart (see
+// `resource_labels.go`).
 func isPaulEnvVolume(volumeName string) bool {
-	return volumeName == "paulenv-shared-cache" ||
-		(strings.HasPrefix(volumeName, "paulenv-") && strings.HasSuffix(volumeName, "-local"))
+	_, isShared := SharedVolumeFromName(volumeName)
+	return IsSharedCacheVolume(volumeName) || isShared || volumeProjectName(volumeName) != ""
 }
 
-func parseCreatedAt(timeStr string) *time.Time {
-	formats := []string{
-		"2006-01-02 15:04:05 -0700 MST",
-		time.RFC3339,
-		time.RFC3339Nano,
-	}
-	timeStr = strings.TrimSpace(timeStr)
-	for _, format := range formats {
-		if parsedTime, err := time.Parse(format, timeStr); err == nil {
-			return &parsedTime
+// Parse the output of a volume listing whose lines are formatted as
+// "{{.Name}}\t<value of the managed label>" into the volumes paul-envs
+// created.
+func parseVolumeList(output string) []VolumeInfo {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	result := make([]VolumeInfo, 0, len(lines))
+	for _, line := range lines {
+		volumeName, managed, _ := strings.Cut(line, "\t")
+		if volumeName == "" || !isManagedResource(volumeName, managed, isPaulEnvVolume) {
+			continue
 		}
+		result = append(result, VolumeInfo{
+			VolumeId:   volumeName,
+			VolumeName: volumeName,
+		})
 	}
-	fmt.Fprintf(os.Stderr, "Debug: could not parse image creation time %q; rebuild detection may be affected\n", timeStr)
-	return nil
-}
-
-func shouldUsePodmanKeepID() bool {
-	return os.Getenv("CI") != "true" && supportsKeepID()
-}
-
-func supportsKeepID() bool {
-	if runtime.GOOS != "linux" {
-		return true
-	}
-	data, err := os.ReadFile("/proc/sys/user/max_user_namespaces")
-	if err != nil {
-		return true
-	}
-	return strings.TrimSpace(string(data)) != "0"
+	return result
 }
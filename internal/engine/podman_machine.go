@@ -0,0 +1,167 @@
+// # podman_machine.go
+// Outside of Linux, Podman runs containers in a virtual machine which has to
+// be created and started first, with enough resources for the projects run in
+// it. Commands needing it offer to do so instead of failing on an unreachable
+// Podman.
+
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/console"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Disk size under which a Podman machine may not fit the shared base image
+// and a few project images.
+const minPodmanMachineDisk = 20 * 1000 * 1000 * 1000
+
+// Resources a project needs from the Podman machine running it.
+type MachineNeeds struct {
+	// Memory limit of its container, as written in its run.conf (e.g. "4g"),
+	// empty if none
+	Memory string
+	// CPUs of its container, as written in its run.conf, empty if none
+	Cpus string
+}
+
+// A Podman machine, as reported by `podman machine list --format json`.
+type podmanMachine struct {
+	Name     string
+	Default  bool
+	Running  bool
+	Starting bool
+	CPUs     uint64
+	// Both in bytes, reported as strings by Podman
+	Memory   machineSize
+	DiskSize machineSize
+}
+
+type machineSize int64
+
+func (s *machineSize) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		*s = machineSize(n)
+		return nil
+	}
+	n, err := utils.ParseSize(value)
+	if err != nil {
+		// Unknown format, the corresponding check is skipped
+		return nil
+	}
+	*s = machineSize(n)
+	return nil
+}
+
+func parseMachines(output []byte) ([]podmanMachine, error) {
+	var machines []podmanMachine
+	if err := json.Unmarshal(output, &machines); err != nil {
+		return nil, fmt.Errorf("unexpected 'podman machine list' output: %w", err)
+	}
+	return machines, nil
+}
+
+// The machine Podman uses: the running one, otherwise the default one,
+// otherwise the first one. `nil` if there's none.
+func usedMachine(machines []podmanMachine) *podmanMachine {
+	var used *podmanMachine
+	for i := range machines {
+		switch {
+		case machines[i].Running || machines[i].Starting:
+			return &machines[i]
+		case used == nil || machines[i].Default:
+			used = &machines[i]
+		}
+	}
+	return used
+}
+
+// Make sure the Podman machine needed by `c`, if it is a Podman running
+// containers in one, exists and runs, offering to create or start it, and
+// warn if it lacks resources for `needs`.
+func EnsurePodmanMachine(ctx context.Context, c ContainerEngine, needs MachineNeeds, console *console.Console) error {
+	podman, ok := c.(*PodmanEngine)
+	if !ok || hostOS == "linux" || podman.remoteURL != "" {
+		return nil
+	}
+	machines, err := podman.listMachines(ctx)
+	if err != nil {
+		return err
+	}
+	machine := usedMachine(machines)
+	if machine == nil {
+		choice, err := console.AskYesNo("No Podman machine exists, which is needed to run containers. Create one now?", true)
+		if err != nil || !choice {
+			return utils.WithCategory(fmt.Errorf("no Podman machine\nHint: Create one with 'podman machine init' then start it with 'podman machine start'"), ErrEngineUnavailable)
+		}
+		if err := podman.runMachineCommand(ctx, "init"); err != nil {
+			return utils.WithCategory(fmt.Errorf("failed to create a Podman machine: %w", err), ErrEngineUnavailable)
+		}
+		if machines, err = podman.listMachines(ctx); err != nil {
+			return err
+		}
+		if machine = usedMachine(machines); machine == nil {
+			return utils.WithCategory(fmt.Errorf("no Podman machine found after its creation"), ErrEngineUnavailable)
+		}
+	}
+	if !machine.Running && !machine.Starting {
+		choice, err := console.AskYesNo(fmt.Sprintf("The Podman machine '%s' is not running. Start it now?", machine.Name), true)
+		if err != nil || !choice {
+			return utils.WithCategory(fmt.Errorf("the Podman machine '%s' is not running\nHint: Start it with 'podman machine start %s'", machine.Name, machine.Name), ErrEngineUnavailable)
+		}
+		if err := podman.runMachineCommand(ctx, "start", machine.Name); err != nil {
+			return utils.WithCategory(fmt.Errorf("failed to start the Podman machine '%s': %w", machine.Name, err), ErrEngineUnavailable)
+		}
+	}
+	for _, problem := range machineResourceProblems(*machine, needs) {
+		console.Warn("%s", problem)
+	}
+	return nil
+}
+
+// Describe what the given machine lacks for `needs`, with how to fix it.
+func machineResourceProblems(machine podmanMachine, needs MachineNeeds) []string {
+	var problems []string
+	resize := fmt.Sprintf("podman machine stop %s && podman machine set %%s %s && podman machine start %s", machine.Name, machine.Name, machine.Name)
+	if needs.Memory != "" && machine.Memory > 0 {
+		if memory, err := utils.ParseSize(needs.Memory); err == nil && memory > int64(machine.Memory) {
+			problems = append(problems, fmt.Sprintf("The Podman machine '%s' has %s of memory, less than the %s asked by MEMORY\nHint: Give it more with '%s'",
+				machine.Name, utils.FormatSize(int64(machine.Memory)), needs.Memory,
+				// In MiB
+				fmt.Sprintf(resize, "--memory "+strconv.FormatInt((memory+1<<20-1)>>20, 10))))
+		}
+	}
+	if needs.Cpus != "" && machine.CPUs > 0 {
+		if cpus, err := strconv.ParseFloat(needs.Cpus, 64); err == nil && cpus > float64(machine.CPUs) {
+			problems = append(problems, fmt.Sprintf("The Podman machine '%s' has %d CPUs, less than the %s asked by CPUS\nHint: Give it more with '%s'",
+				machine.Name, machine.CPUs, needs.Cpus, fmt.Sprintf(resize, "--cpus "+strconv.Itoa(int(math.Ceil(cpus))))))
+		}
+	}
+	if machine.DiskSize > 0 && machine.DiskSize < minPodmanMachineDisk {
+		problems = append(problems, fmt.Sprintf("The Podman machine '%s' only has a %s disk, which builds may fill\nHint: Grow it with '%s'",
+			machine.Name, utils.FormatSize(int64(machine.DiskSize)), fmt.Sprintf(resize, "--disk-size 50"))) // In GiB
+	}
+	return problems
+}
+
+func (c *PodmanEngine) listMachines(ctx context.Context) ([]podmanMachine, error) {
+	output, err := engineCommandOutput(c.command(ctx, "machine", "list", "--format", "json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Podman machines: %w", err)
+	}
+	return parseMachines(output)
+}
+
+// Run a `podman machine` command showing its progress.
+func (c *PodmanEngine) runMachineCommand(ctx context.Context, args ...string) error {
+	cmd := c.command(ctx, append([]string{"machine"}, args...)...)
+	cmd.Stdout, cmd.Stderr = engineOutput, engineOutput
+	return runEngineCommand(cmd)
+}
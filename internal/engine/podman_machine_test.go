@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMachines(t *testing.T) {
+	output := `[
+  {"Name": "podman-machine-default", "Default": true, "Running": false, "CPUs": 4, "Memory": "2147483648", "DiskSize": "107374182400"},
+  {"Name": "other", "Default": false, "Running": false, "CPUs": 2, "Memory": 4294967296, "DiskSize": "10GB"}
+]`
+	machines, err := parseMachines([]byte(output))
+	if err != nil {
+		t.Fatalf("parseMachines() error: %v", err)
+	}
+	if len(machines) != 2 {
+		t.Fatalf("parseMachines() = %d machines, want 2", len(machines))
+	}
+	if machines[0].Memory != 2147483648 || machines[0].DiskSize != 107374182400 || machines[0].CPUs != 4 {
+		t.Fatalf("unexpected first machine: %+v", machines[0])
+	}
+	if machines[1].Memory != 4294967296 || machines[1].DiskSize != 10*1000*1000*1000 {
+		t.Fatalf("unexpected second machine: %+v", machines[1])
+	}
+	if _, err := parseMachines([]byte("not json")); err == nil {
+		t.Fatal("expected an error on invalid output")
+	}
+}
+
+func TestUsedMachine(t *testing.T) {
+	if usedMachine(nil) != nil {
+		t.Fatal("expected no machine")
+	}
+	machines := []podmanMachine{{Name: "first"}, {Name: "default", Default: true}}
+	if got := usedMachine(machines); got.Name != "default" {
+		t.Fatalf("usedMachine() = %q, want the default one", got.Name)
+	}
+	machines = append(machines, podmanMachine{Name: "running", Running: true})
+	if got := usedMachine(machines); got.Name != "running" {
+		t.Fatalf("usedMachine() = %q, want the running one", got.Name)
+	}
+	if got := usedMachine([]podmanMachine{{Name: "a"}, {Name: "b"}}); got.Name != "a" {
+		t.Fatalf("usedMachine() = %q, want the first one", got.Name)
+	}
+}
+
+func TestMachineResourceProblems(t *testing.T) {
+	machine := podmanMachine{Name: "m", CPUs: 2, Memory: 2 * 1000 * 1000 * 1000, DiskSize: 100 * 1000 * 1000 * 1000}
+	if problems := machineResourceProblems(machine, MachineNeeds{Memory: "2g", Cpus: "2"}); len(problems) != 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+	if problems := machineResourceProblems(machine, MachineNeeds{}); len(problems) != 0 {
+		t.Fatalf("unexpected problems without needs: %v", problems)
+	}
+	problems := machineResourceProblems(machine, MachineNeeds{Memory: "4g", Cpus: "2.5"})
+	if len(problems) != 2 {
+		t.Fatalf("machineResourceProblems() = %v, want memory and CPU problems", problems)
+	}
+	if !strings.Contains(problems[0], "--memory 3815") || !strings.Contains(problems[1], "--cpus 3") {
+		t.Fatalf("unexpected fixes: %v", problems)
+	}
+	machine.DiskSize = 10 * 1000 * 1000 * 1000
+	problems = machineResourceProblems(machine, MachineNeeds{})
+	if len(problems) != 1 || !strings.Contains(problems[0], "--disk-size") {
+		t.Fatalf("machineResourceProblems() = %v, want a disk problem", problems)
+	}
+}
@@ -0,0 +1,123 @@
+// # podman_mode.go
+// Podman run by a regular user is rootless: it has its own images and
+// containers, which run in a user namespace and thus cannot e.g. publish ports
+// below 1024 or use some devices. Root's Podman is "rootful" and has separate
+// ones.
+//
+// Projects which need it are built and run with rootful Podman (the
+// `podman-rootful` engine), reached by regular users through its API socket,
+// the `podman` CLI then acting as a remote client.
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// API socket of rootful Podman, activated by systemd's `podman.socket` unit.
+const rootfulPodmanSocket = "/run/podman/podman.sock"
+
+// API socket of the current user's rootless Podman, activated by systemd's
+// `podman.socket` user unit.
+func rootlessPodmanSocket() string {
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		runtimeDir = filepath.Join("/run/user", strconv.Itoa(os.Getuid()))
+	}
+	return filepath.Join(runtimeDir, "podman", "podman.sock")
+}
+
+// API socket of Podman in the given mode.
+func podmanSocketPath(rootful bool) string {
+	if rootful {
+		return rootfulPodmanSocket
+	}
+	return rootlessPodmanSocket()
+}
+
+// Create the command running the `podman` CLI with the given arguments,
+// through the rootful Podman's socket if needed.
+func (c *PodmanEngine) command(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := engineCommand(ctx, "podman", args...)
+	if c.remoteURL != "" {
+		// Also enables its `--remote` mode
+		cmd.Env = append(os.Environ(), "CONTAINER_HOST="+c.remoteURL)
+	}
+	return cmd
+}
+
+// Returns `true` if containers of this engine are rootless.
+func (c *PodmanEngine) isRootless(ctx context.Context) bool {
+	c.rootlessOnce.Do(func() {
+		if runtime.GOOS == "linux" && c.remoteURL == "" {
+			c.rootless = os.Geteuid() != 0
+			return
+		}
+		// Its virtual machine or remote service may be rootful or not
+		output, err := engineCommandOutput(c.command(ctx, "info", "--format", "{{.Host.Security.Rootless}}"))
+		if err == nil {
+			if rootless, err := strconv.ParseBool(strings.TrimSpace(string(output))); err == nil {
+				c.rootless = rootless
+				return
+			}
+		}
+		c.rootless = !c.rootful
+	})
+	return c.rootless
+}
+
+// Returns `true` if this engine is the rootful Podman requested through
+// `SelectionPodmanRootful`.
+func (c *PodmanEngine) IsRootful() bool {
+	return c.rootful
+}
+
+// Make sure rootful Podman can be reached by the current, non-root, user
+// through its socket, starting it through systemd socket activation if
+// needed.
+func connectRootfulPodman(ctx context.Context) (string, error) {
+	if runtime.GOOS != "linux" {
+		return "", utils.WithCategory(errors.New("rootful Podman is selected through its machine on this OS\n"+
+			"Hint: Run 'podman machine set --rootful' while it is stopped, then use Podman normally"), ErrEngineUnavailable)
+	}
+	err := dialSocket(rootfulPodmanSocket)
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ECONNREFUSED) {
+		// Fails without asking for a password if the user is not allowed to
+		activate := exec.CommandContext(ctx, "systemctl", "--no-ask-password", "start", "podman.socket")
+		if runEngineCommand(activate) == nil {
+			err = dialSocket(rootfulPodmanSocket)
+		}
+	}
+	switch {
+	case err == nil:
+		return "unix://" + rootfulPodmanSocket, nil
+	case errors.Is(err, os.ErrPermission):
+		return "", utils.WithCategory(fmt.Errorf("no permission to use rootful Podman's socket %s\n"+
+			"Hint: Give one of your groups access to it with 'sudo systemctl edit podman.socket', setting 'SocketGroup=<group>' in its [Socket] section, "+
+			"then restart it with 'sudo systemctl restart podman.socket'", rootfulPodmanSocket), ErrEngineUnavailable)
+	default:
+		return "", utils.WithCategory(fmt.Errorf("rootful Podman's socket %s is not available: %w\n"+
+			"Hint: Enable it with 'sudo systemctl enable --now podman.socket'", rootfulPodmanSocket, err), ErrEngineUnavailable)
+	}
+}
+
+func dialSocket(path string) error {
+	conn, err := net.DialTimeout("unix", path, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
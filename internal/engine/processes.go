@@ -0,0 +1,173 @@
+// # processes.go
+// Background processes of a project (`PROCESS` in its run.conf, e.g. a
+// language server or `dockerd`) are started by its container's entrypoint,
+// which restarts them when they exit, without needing systemd.
+//
+// The entrypoint keeps the state of each one in a directory of the container
+// named after it, which `paul-envs services` reads and writes through `exec`
+// to list, restart, stop and start them.
+
+package engine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+// Directory in the container where the entrypoint keeps the state of its
+// background processes.
+const containerProcessesDir = "/tmp/paulenv-processes"
+
+// State of a background process of a running container.
+type ProcessStatus struct {
+	Name string
+	// PID of its process group, `0` if it does not run
+	Pid int
+	// Set if it was stopped through `StopProcess`
+	Stopped bool
+	// Number of times it exited by itself and was restarted
+	Restarts int
+	// When it last started, zero if unknown
+	StartedAt time.Time
+	// Exit code of its last run, `nil` if it never exited
+	LastExitCode *int
+}
+
+// Arguments of the `run` command telling the entrypoint which background
+// processes to supervise.
+func processRunArgs(runtimeCfg config.RuntimeConfig) []string {
+	if len(runtimeCfg.Processes) == 0 {
+		return nil
+	}
+	lines := make([]string, 0, len(runtimeCfg.Processes))
+	for _, process := range runtimeCfg.Processes {
+		user := "user"
+		if process.Root {
+			user = "root"
+		}
+		lines = append(lines, process.Name+"\t"+user+"\t"+process.Command)
+	}
+	return []string{"--env", "PAULENV_PROCESSES=" + strings.Join(lines, "\n")}
+}
+
+// Prints one tab-separated line per process: its name, PID, whether it is
+// stopped, its restarts, start time and last exit code.
+const listProcessesScript = `cd "$0" 2>/dev/null || exit 0
+for dir in */; do
+    dir="${dir%/}"
+    [ -d "$dir" ] || continue
+    stopped=0
+    [ -f "$dir/stopped" ] && stopped=1
+    printf '%s\t%s\t%s\t%s\t%s\t%s\n' "$dir" "$(cat "$dir/pid" 2>/dev/null)" "$stopped" \
+        "$(cat "$dir/restarts" 2>/dev/null)" "$(cat "$dir/started" 2>/dev/null)" "$(cat "$dir/exit" 2>/dev/null)"
+done`
+
+// List the background processes of that running container, in name order.
+// Empty if it has none, or if its image predates them.
+func ListProcesses(ctx context.Context, c ContainerEngine, container ContainerInfo) ([]ProcessStatus, error) {
+	var stdout bytes.Buffer
+	err := c.ExecContainer(ctx, container, []string{"bash", "-c", listProcessesScript, containerProcessesDir}, ExecOptions{
+		NoTTY:  true,
+		Stdin:  strings.NewReader(""),
+		Stdout: &stdout,
+		Stderr: io.Discard,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list background processes: %w", err)
+	}
+	return parseProcessList(stdout.String()), nil
+}
+
+func parseProcessList(output string) []ProcessStatus {
+	var processes []ProcessStatus
+	for line := range strings.SplitSeq(output, "\n") {
+		fields := strings.Split(strings.TrimRight(line, "\r"), "\t")
+		if len(fields) < 6 || fields[0] == "" {
+			continue
+		}
+		process := ProcessStatus{Name: fields[0], Stopped: fields[2] == "1"}
+		process.Pid, _ = strconv.Atoi(fields[1])
+		process.Restarts, _ = strconv.Atoi(fields[3])
+		if started, err := strconv.ParseInt(fields[4], 10, 64); err == nil {
+			process.StartedAt = time.Unix(started, 0)
+		}
+		if code, err := strconv.Atoi(fields[5]); err == nil {
+			process.LastExitCode = &code
+		}
+		processes = append(processes, process)
+	}
+	return processes
+}
+
+// Scripts run in the directory of a background process (`$0`), changing its
+// state.
+const (
+	// Its supervisor starts it again right away
+	restartProcessScript = `cd "$0" || exit 1
+rm -f stopped
+touch restart
+if [ -f pid ]; then kill -TERM -- "-$(cat pid)"; fi`
+	// Its supervisor waits until it is started again
+	stopProcessScript = `cd "$0" || exit 1
+touch stopped
+if [ -f pid ]; then kill -TERM -- "-$(cat pid)"; fi
+exit 0`
+	startProcessScript = `cd "$0" || exit 1
+rm -f stopped`
+)
+
+// Restart that background process of a running container, or start it if it
+// was stopped.
+func RestartProcess(ctx context.Context, c ContainerEngine, container ContainerInfo, name string) error {
+	return controlProcess(ctx, c, container, name, "restart", restartProcessScript)
+}
+
+// Stop that background process of a running container, until it is started
+// again.
+func StopProcess(ctx context.Context, c ContainerEngine, container ContainerInfo, name string) error {
+	return controlProcess(ctx, c, container, name, "stop", stopProcessScript)
+}
+
+// Start again that stopped background process of a running container.
+func StartProcess(ctx context.Context, c ContainerEngine, container ContainerInfo, name string) error {
+	return controlProcess(ctx, c, container, name, "start", startProcessScript)
+}
+
+func controlProcess(ctx context.Context, c ContainerEngine, container ContainerInfo, name string, action string, script string) error {
+	var stderr bytes.Buffer
+	err := c.ExecContainer(ctx, container, []string{"bash", "-c", script, containerProcessesDir + "/" + name}, ExecOptions{
+		NoTTY:  true,
+		Stdin:  strings.NewReader(""),
+		Stdout: io.Discard,
+		Stderr: &stderr,
+	})
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("failed to %s process %s: %w: %s", action, name, err, msg)
+		}
+		return fmt.Errorf("failed to %s process %s: %w", action, name, err)
+	}
+	return nil
+}
+
+// Write the output of that background process of a running container to
+// `stdout`, then what it outputs next until `ctx` is done if `follow` is set.
+func ProcessLogs(ctx context.Context, c ContainerEngine, container ContainerInfo, name string, follow bool, stdout io.Writer) error {
+	args := []string{"tail", "-n", "100"}
+	if follow {
+		args = append(args, "-F")
+	}
+	args = append(args, containerProcessesDir+"/"+name+"/log")
+	return c.ExecContainer(ctx, container, args, ExecOptions{
+		NoTTY:  true,
+		Stdin:  strings.NewReader(""),
+		Stdout: stdout,
+	})
+}
@@ -0,0 +1,37 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+func TestProcessRunArgs(t *testing.T) {
+	if args := processRunArgs(config.RuntimeConfig{}); args != nil {
+		t.Fatalf("processRunArgs() without processes = %v, want nil", args)
+	}
+	args := processRunArgs(config.RuntimeConfig{Processes: []config.Process{
+		{Name: "lsp", Command: "gopls serve -listen=:4389"},
+		{Name: "dockerd", Command: "dockerd --iptables=false", Root: true},
+	}})
+	want := []string{"--env", "PAULENV_PROCESSES=lsp\tuser\tgopls serve -listen=:4389\ndockerd\troot\tdockerd --iptables=false"}
+	if !reflect.DeepEqual(args, want) {
+		t.Fatalf("processRunArgs() = %q, want %q", args, want)
+	}
+}
+
+func TestParseProcessList(t *testing.T) {
+	processes := parseProcessList("dockerd\t\t1\t0\t1700000000\t\n" +
+		"lsp\t42\t0\t3\t1700000100\t1\r\n" +
+		"broken\t12\n\n")
+	code := 1
+	want := []ProcessStatus{
+		{Name: "dockerd", Stopped: true, StartedAt: time.Unix(1700000000, 0)},
+		{Name: "lsp", Pid: 42, Restarts: 3, StartedAt: time.Unix(1700000100, 0), LastExitCode: &code},
+	}
+	if !reflect.DeepEqual(processes, want) {
+		t.Fatalf("parseProcessList() = %+v, want %+v", processes, want)
+	}
+}
@@ -30,19 +30,97 @@ func projectBaseDataDir(project files.ProjectEntry) string {
 	return filepath.Clean(filepath.Join(filepath.Dir(project.BuildConfigPath), "..", ".."))
 }
 
+// Returns the Dockerfile generated for the project, which is the shared one
+// unless it has Dockerfile snippets.
 func projectDockerfilePath(project files.ProjectEntry) string {
+	if project.DockerfilePath != "" {
+		if _, err := os.Stat(project.DockerfilePath); err == nil {
+			return project.DockerfilePath
+		}
+	}
 	return filepath.Join(projectBaseDataDir(project), "Dockerfile")
 }
 
+// Name of the shared image on top of which all project images are built.
+const baseImageName = "paulenv-base:latest"
+
+func baseDockerfilePath(baseFilesDir string) string {
+	return filepath.Join(baseFilesDir, "Dockerfile.base")
+}
+
+func isBaseImage(imageName string) bool {
+	return strings.HasPrefix(imageName, "paulenv-base:") || strings.HasPrefix(imageName, "localhost/paulenv-base:")
+}
+
 func projectImageName(projectName string) string {
 	return fmt.Sprintf("paulenv:%s", projectName)
 }
 
 func projectContainerName(projectName string) string {
-	return fmt.Sprintf("paulenv-%s", projectName)
+	return containerNamePrefix + projectName + containerNameSuffix
+}
+
+// Label set on project containers to the name of their project, so they are
+// still attributed to it once renamed or once its image was rebuilt.
+const projectLabel = "paulenv.project"
+
+// Parse the output of a container listing whose lines are formatted as
+// "{{.ID}}\t{{.Image}}\t{{.Names}}\t{{.State}}\t<value of the project
+// label>\t<value of the instance label>\t<value of the source label>",
+// keeping only the containers of paul-envs projects: those labelled with
+// their project, or running one of their images. Their names are not enough,
+// others may be named alike. Their sidecars, also labelled with their
+// project, are left out.
+func parseContainerList(output string) []ContainerInfo {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	result := make([]ContainerInfo, 0, len(lines))
+	for _, s := range lines {
+		if s == "" {
+			continue
+		}
+		parts := strings.SplitN(s, "\t", 7)
+		if len(parts) > 6 && parts[6] == sourceService {
+			continue
+		}
+		id := parts[0]
+		var image *string
+		var name *string
+		var projectName *string
+
+		if len(parts) > 1 && parts[1] != "" {
+			image = &parts[1]
+		}
+		if len(parts) > 2 && parts[2] != "" {
+			name = &parts[2]
+		}
+		if len(parts) > 4 && parts[4] != "" && parts[4] != "<no value>" {
+			projectName = &parts[4]
+		}
+		if projectName == nil && image != nil {
+			projectName = projectNameFromImage(*image)
+		}
+		if projectName == nil {
+			continue
+		}
+		var instance string
+		if len(parts) > 5 && parts[5] != "<no value>" {
+			instance = parts[5]
+		}
+
+		result = append(result, ContainerInfo{
+			ProjectName:   projectName,
+			ContainerName: name,
+			ContainerId:   id,
+			ImageName:     image,
+			Instance:      instance,
+			Running:       len(parts) > 3 && strings.EqualFold(parts[3], "running"),
+		})
+	}
+	return result
 }
 
-func projectLocalVolumeName(projectName string) string {
+// Name of the volume keeping the data of a project's default instance.
+func ProjectLocalVolumeName(projectName string) string {
 	return fmt.Sprintf("paulenv-%s-local", projectName)
 }
 
@@ -50,6 +128,64 @@ func projectMountTarget(username, projectName string) string {
 	return fmt.Sprintf("/home/%s/projects/%s", username, projectName)
 }
 
+// Returns the directory in which commands are run in the given project's
+// container.
+func ProjectWorkDir(project files.ProjectEntry) (string, error) {
+	runtimeCfg, err := loadRuntimeConfig(project)
+	if err != nil {
+		return "", err
+	}
+	if runtimeCfg.WorkDir != "" {
+		return runtimeCfg.WorkDir, nil
+	}
+	buildCfg, err := loadBuildConfig(project)
+	if err != nil {
+		return "", err
+	}
+	return projectMountTarget(buildCfg.Args["USERNAME"], project.ProjectName), nil
+}
+
+// Returns the name of the user commands are run as in the given project's
+// container.
+func ProjectUsername(project files.ProjectEntry) (string, error) {
+	buildCfg, err := loadBuildConfig(project)
+	if err != nil {
+		return "", err
+	}
+	return buildCfg.Args["USERNAME"], nil
+}
+
+// Returns the host directory of dotfiles applied in the given project's
+// container, empty if it has none.
+func ProjectDotfilesDir(project files.ProjectEntry) (string, error) {
+	runtimeCfg, err := loadRuntimeConfig(project)
+	if err != nil {
+		return "", err
+	}
+	return projectDotfilesPath(project, runtimeCfg)
+}
+
+// Resolve the host directory of the dotfiles of a project: its dotfiles
+// profile if it has one, else its DOTFILES_PATH. Empty if it has none.
+func projectDotfilesPath(project files.ProjectEntry, runtimeCfg config.RuntimeConfig) (string, error) {
+	if runtimeCfg.DotfilesProfile == "" {
+		path, err := resolveRuntimePath(project.RuntimeConfigPath, runtimeCfg.DotfilesPath)
+		if err != nil {
+			return "", fmt.Errorf("resolve DOTFILES_PATH: %w", err)
+		}
+		return path, nil
+	}
+	if project.DotfilesProfilesDir == "" {
+		return "", fmt.Errorf("cannot use dotfiles profile %q: unknown profiles directory", runtimeCfg.DotfilesProfile)
+	}
+	path := filepath.Join(project.DotfilesProfilesDir, runtimeCfg.DotfilesProfile)
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("dotfiles profile %q not found: %s is not a directory\nHint: Create it or remove DOTFILES_PROFILE from %s",
+			runtimeCfg.DotfilesProfile, path, project.RuntimeConfigPath)
+	}
+	return path, nil
+}
+
 func resolveRuntimePath(configPath, configuredPath string) (string, error) {
 	if configuredPath == "" {
 		return "", nil
@@ -42,3 +42,39 @@ func TestResolveRuntimePath(t *testing.T) {
 		})
 	}
 }
+
+func TestParseContainerList(t *testing.T) {
+	output := "aaa\tpaulenv:app\tpaulenv-app\trunning\tapp\n" +
+		// Renamed, on an image rebuilt since
+		"bbb\tsha256:0123\tmy-app\texited\tweb\n" +
+		// Started before containers were labelled
+		"ccc\tlocalhost/paulenv:api\tpaulenv-api\texited\t<no value>\n" +
+		"ddd\tpostgres:16\tpaulenv-app.db\trunning\t\n" +
+		"eee\tnginx\tnginx\trunning\t\n" +
+		// Only named like a project's
+		"ggg\tubuntu\tpaulenv-web\trunning\t<no value>\n" +
+		"fff\tpaulenv:app\tpaulenv-app..tests\trunning\tapp\ttests\trun\n" +
+		// A sidecar, listed separately
+		"hhh\tpostgres:16\tpaulenv-app.db\trunning\tapp\t<no value>\tservice\n"
+	containers := parseContainerList(output)
+	want := []struct {
+		id       string
+		project  string
+		instance string
+		running  bool
+	}{
+		{"aaa", "app", "", true},
+		{"bbb", "web", "", false},
+		{"ccc", "api", "", false},
+		{"fff", "app", "tests", true},
+	}
+	if len(containers) != len(want) {
+		t.Fatalf("parseContainerList() = %d containers, want %d", len(containers), len(want))
+	}
+	for i, w := range want {
+		c := containers[i]
+		if c.ContainerId != w.id || c.ProjectName == nil || *c.ProjectName != w.project || c.Instance != w.instance || c.Running != w.running {
+			t.Fatalf("parseContainerList()[%d] = %+v, want %+v", i, c, w)
+		}
+	}
+}
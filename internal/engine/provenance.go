@@ -0,0 +1,126 @@
+// # provenance.go
+// Project images are labelled with where they come from when built: the
+// distribution image the shared base image was built from, the commit of the
+// project's dotfiles and the packages its build.conf installs, next to the
+// version of paul-envs which built them. `paul-envs inspect` reads them back,
+// e.g. to tell what an image was made of long after its configuration moved.
+//
+// Images built before those labels existed have none of them.
+
+package engine
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Label set to the distribution image the shared base image was built from,
+// pinned to its digest when known.
+const baseImageLabel = "paulenv.base-image"
+
+// Label set to the commit of the project's dotfiles, when they are in a git
+// repository.
+const dotfilesCommitLabel = "paulenv.dotfiles-commit"
+
+// Label set to the packages installed by the project's build.conf.
+const packagesLabel = "paulenv.packages"
+
+// Package managers of the build.conf directives listing packages, in the
+// order they are recorded.
+var packageDirectiveManagers = []struct{ directive, manager string }{
+	{"SUPPLEMENTARY_PACKAGES", "apt"},
+	{"PIPX_PACKAGES", "pipx"},
+	{"CARGO_PACKAGES", "cargo"},
+	{"NPM_PACKAGES", "npm"},
+}
+
+// Where a project's image comes from, as recorded in its labels.
+type Provenance struct {
+	// Version of paul-envs which built it
+	Version string
+	// Distribution image the shared base image was built from, pinned to its
+	// digest when known
+	BaseImage string
+	// Commit of the project's dotfiles, suffixed by "-dirty" if they had
+	// uncommitted changes
+	DotfilesCommit string
+	// Packages installed by its build.conf, as "<manager>:<package>"
+	Packages []string
+}
+
+// Parse the labels of a project's image into its provenance.
+func parseProvenance(labels map[string]string) Provenance {
+	return Provenance{
+		Version:        labels[versionLabel],
+		BaseImage:      labels[baseImageLabel],
+		DotfilesCommit: labels[dotfilesCommitLabel],
+		Packages:       strings.Fields(labels[packagesLabel]),
+	}
+}
+
+// Add the provenance labels of a project's image to `options`.
+func withProvenanceLabels(ctx context.Context, options BuildOptions, project files.ProjectEntry, buildCfg config.BuildConfig, runtimeCfg config.RuntimeConfig) BuildOptions {
+	labels := map[string]string{
+		baseImageLabel: provenanceBaseImage(options),
+		packagesLabel:  strings.Join(projectPackages(buildCfg, options.BuildArgs), " "),
+	}
+	if dotfilesPath, err := projectDotfilesPath(project, runtimeCfg); err == nil && dotfilesPath != "" {
+		labels[dotfilesCommitLabel] = gitCommit(ctx, dotfilesPath)
+	}
+	if options.labels == nil {
+		options.labels = make(map[string]string, len(labels))
+	}
+	for name, value := range labels {
+		if value != "" {
+			options.labels[name] = value
+		}
+	}
+	return options
+}
+
+// Reference of the distribution image the shared base image is built from.
+func provenanceBaseImage(options BuildOptions) string {
+	image := options.DistributionImage
+	if image == "" {
+		image = files.DefaultDistributionImage
+	}
+	if options.DistributionDigest == "" {
+		return image
+	}
+	return PinnedImageReference(image, options.DistributionDigest)
+}
+
+// Packages installed by a project's build.conf, with the given build arguments
+// replacing its own, as "<manager>:<package>".
+func projectPackages(buildCfg config.BuildConfig, buildArgs map[string]string) []string {
+	var packages []string
+	for _, d := range packageDirectiveManagers {
+		value, ok := buildArgs[d.directive]
+		if !ok {
+			value = buildCfg.Args[d.directive]
+		}
+		for _, name := range strings.Fields(value) {
+			packages = append(packages, d.manager+":"+name)
+		}
+	}
+	return packages
+}
+
+// Commit checked out in the git repository of `dir`, suffixed by "-dirty" if
+// it has uncommitted changes. Empty if it is not in one.
+func gitCommit(ctx context.Context, dir string) string {
+	output, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	commit := strings.TrimSpace(string(output))
+	status, err := exec.CommandContext(ctx, "git", "-C", dir, "status", "--porcelain").Output()
+	if err == nil && strings.TrimSpace(string(status)) != "" {
+		commit += "-dirty"
+	}
+	return commit
+}
@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestWithProvenanceLabels(t *testing.T) {
+	dir := t.TempDir()
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: filepath.Join(dir, "run.conf")}
+	buildCfg := config.BuildConfig{Args: map[string]string{
+		"SUPPLEMENTARY_PACKAGES": "git curl",
+		"NPM_PACKAGES":           "typescript",
+		"PIPX_PACKAGES":          "",
+	}}
+	options := BuildOptions{
+		DistributionImage:  "debian:12",
+		DistributionDigest: "sha256:abc",
+		BuildArgs:          map[string]string{"NPM_PACKAGES": "prettier"},
+	}
+	options = withProvenanceLabels(context.Background(), options, project, buildCfg, config.RuntimeConfig{DotfilesPath: dir})
+
+	got := parseProvenance(options.labels)
+	want := Provenance{
+		BaseImage: "debian:12@sha256:abc",
+		Packages:  []string{"apt:git", "apt:curl", "npm:prettier"},
+	}
+	if got.BaseImage != want.BaseImage || !slices.Equal(got.Packages, want.Packages) {
+		t.Fatalf("parseProvenance() = %+v, want %+v", got, want)
+	}
+
+	options = withProvenanceLabels(context.Background(), BuildOptions{}, project, config.BuildConfig{}, config.RuntimeConfig{})
+	if options.labels[baseImageLabel] != files.DefaultDistributionImage {
+		t.Fatalf("base image label = %q, want %q", options.labels[baseImageLabel], files.DefaultDistributionImage)
+	}
+	if _, ok := options.labels[packagesLabel]; ok {
+		t.Fatal("no packages label should be set without packages")
+	}
+}
@@ -0,0 +1,47 @@
+// # proxy.go
+// Proxy settings of the host (e.g. HTTP_PROXY), or those of a project's
+// run.conf, are given to builds and containers, so that package managers work
+// behind a corporate proxy.
+//
+// Builds get them as build arguments, which engines predefine for those names
+// without them invalidating their cache. As their values may contain
+// credentials, they are given by name only, the engine CLI taking them from
+// its own environment.
+
+package engine
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+// Proxy settings of the given project, as "KEY=VALUE".
+func projectProxyEnv(runtimeCfg config.RuntimeConfig) []string {
+	return config.ProxyEnv(os.Environ(), runtimeCfg.Proxy)
+}
+
+// Arguments giving the variables of `env` by name with `flag` (e.g.
+// "--build-arg").
+func envNameArgs(flag string, env []string) []string {
+	args := make([]string, 0, 2*len(env))
+	for _, variable := range env {
+		name, _, _ := strings.Cut(variable, "=")
+		args = append(args, flag, name)
+	}
+	return args
+}
+
+// Give the variables of `env` to the engine CLI run by `cmd` through its
+// environment.
+func withEnv(cmd *exec.Cmd, env []string) {
+	if len(env) == 0 {
+		return
+	}
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, env...)
+}
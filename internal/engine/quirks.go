@@ -0,0 +1,144 @@
+// # quirks.go
+// Container engines do not all behave the same way, and their behavior also
+// changes between versions (exit codes, output formats, supported flags...).
+//
+// Those differences are all described here as "quirks" tied to an engine and
+// a range of versions, so engine methods just rely on the resulting
+// `engineQuirks` instead of guessing ad hoc.
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Workarounds to apply for a given engine version.
+type engineQuirks struct {
+	// Exit codes of `image inspect` meaning that the image does not exist.
+	missingImageExitCodes []int
+	// Layouts of the image creation dates output by the engine, tried in order.
+	createdAtLayouts []string
+	// If `true`, `--userns=keep-id` is refused when running as root.
+	keepIDRootlessOnly bool
+	// If `true`, `--userns=keep-id` cannot choose the IDs the user is mapped
+	// to (`keep-id:uid=...,gid=...`).
+	keepIDNoMapping bool
+}
+
+// A workaround needed by an engine in a range of versions.
+type quirk struct {
+	engine string
+	// Lowest affected version (inclusive), `nil` if there's no lower bound
+	since *utils.Version
+	// First unaffected version, `nil` if there's no upper bound
+	until *utils.Version
+	apply func(*engineQuirks)
+}
+
+var knownQuirks = []quirk{
+	{
+		// "Error: No such image"
+		engine: "docker",
+		apply: func(q *engineQuirks) {
+			q.missingImageExitCodes = append(q.missingImageExitCodes, 1)
+		},
+	},
+	{
+		// `image inspect` outputs RFC 3339 dates while `images` outputs Go's
+		// default time format, with no fractional seconds.
+		engine: "docker",
+		apply: func(q *engineQuirks) {
+			q.createdAtLayouts = append(q.createdAtLayouts,
+				time.RFC3339Nano, "2006-01-02 15:04:05 -0700 MST")
+		},
+	},
+	{
+		// Podman reports its own errors, such as an unknown image, with 125 but
+		// some releases and storage drivers exit with 1 instead.
+		engine: "podman",
+		apply: func(q *engineQuirks) {
+			q.missingImageExitCodes = append(q.missingImageExitCodes, 125, 1)
+		},
+	},
+	{
+		// Both `image inspect` and `images` output Go's default time format,
+		// with fractional seconds.
+		engine: "podman",
+		apply: func(q *engineQuirks) {
+			q.createdAtLayouts = append(q.createdAtLayouts,
+				"2006-01-02 15:04:05 -0700 MST", time.RFC3339Nano)
+		},
+	},
+	{
+		// "keep-id is only supported in rootless mode", and its `uid` and `gid`
+		// options came with rootful support
+		engine: "podman",
+		until:  &utils.Version{Major: 4, Minor: 3, Patch: 0},
+		apply: func(q *engineQuirks) {
+			q.keepIDRootlessOnly = true
+			q.keepIDNoMapping = true
+		},
+	},
+}
+
+// Returns the workarounds needed by the given engine at the given version.
+//
+// If the version is unknown, all known quirks of that engine are applied as
+// they are lenient enough to not break more recent versions.
+func quirksFor(engineName string, version string) engineQuirks {
+	parsed, err := utils.ParseVersion(version)
+	knownVersion := err == nil
+	var quirks engineQuirks
+	for _, q := range knownQuirks {
+		if q.engine != engineName {
+			continue
+		}
+		if knownVersion {
+			if q.since != nil && parsed.IsBefore(*q.since) {
+				continue
+			}
+			if q.until != nil && !parsed.IsBefore(*q.until) {
+				continue
+			}
+		}
+		q.apply(&quirks)
+	}
+	return quirks
+}
+
+// Returns `true` if the given error from an `image inspect` command means that
+// the image does not exist.
+//
+// Its exit code is only relied on when its output did not tell, as it is
+// shared with other failures.
+func (q engineQuirks) isMissingImage(err error) bool {
+	if errors.Is(err, ErrNotFound) {
+		return true
+	}
+	if errors.Is(err, ErrEngineUnavailable) || errors.Is(err, ErrPermission) {
+		return false
+	}
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && slices.Contains(q.missingImageExitCodes, exitErr.ExitCode())
+}
+
+// Parse an image creation date as output by the engine.
+//
+// Returns `nil` if its format is not known.
+func (q engineQuirks) parseCreatedAt(timeStr string) *time.Time {
+	timeStr = strings.TrimSpace(timeStr)
+	for _, layout := range q.createdAtLayouts {
+		if parsedTime, err := time.Parse(layout, timeStr); err == nil {
+			return &parsedTime
+		}
+	}
+	fmt.Fprintf(engineOutput, "Debug: could not parse image creation time %q; rebuild detection may be affected\n", timeStr)
+	return nil
+}
@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestQuirksFor_VersionMatrix(t *testing.T) {
+	tests := []struct {
+		engine             string
+		version            string
+		missingImageCodes  []int
+		keepIDRootlessOnly bool
+	}{
+		{"docker", "20.10.24", []int{1}, false},
+		{"docker", "27.3.1", []int{1}, false},
+		{"docker", "", []int{1}, false},
+		{"podman", "3.4.4", []int{125, 1}, true},
+		{"podman", "4.2.9", []int{125, 1}, true},
+		{"podman", "4.3.0", []int{125, 1}, false},
+		{"podman", "5.2.2", []int{125, 1}, false},
+		{"podman", "", []int{125, 1}, true},
+	}
+	for _, tt := range tests {
+		q := quirksFor(tt.engine, tt.version)
+		if len(q.missingImageExitCodes) != len(tt.missingImageCodes) {
+			t.Fatalf("quirksFor(%q, %q).missingImageExitCodes = %v, want %v",
+				tt.engine, tt.version, q.missingImageExitCodes, tt.missingImageCodes)
+		}
+		for i, code := range tt.missingImageCodes {
+			if q.missingImageExitCodes[i] != code {
+				t.Fatalf("quirksFor(%q, %q).missingImageExitCodes = %v, want %v",
+					tt.engine, tt.version, q.missingImageExitCodes, tt.missingImageCodes)
+			}
+		}
+		// Both came with Podman 4.3
+		if q.keepIDRootlessOnly != tt.keepIDRootlessOnly || q.keepIDNoMapping != tt.keepIDRootlessOnly {
+			t.Fatalf("quirksFor(%q, %q).keepIDRootlessOnly, keepIDNoMapping = %v, %v, want %v",
+				tt.engine, tt.version, q.keepIDRootlessOnly, q.keepIDNoMapping, tt.keepIDRootlessOnly)
+		}
+	}
+}
+
+func TestQuirks_IsMissingImage(t *testing.T) {
+	exitWith := func(code string) error {
+		return exec.Command("sh", "-c", "exit "+code).Run()
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh available")
+	}
+
+	docker := quirksFor("docker", "27.3.1")
+	podman := quirksFor("podman", "5.2.2")
+	if !docker.isMissingImage(exitWith("1")) || docker.isMissingImage(exitWith("125")) {
+		t.Fatal("docker: only exit code 1 should mean a missing image")
+	}
+	if !podman.isMissingImage(exitWith("125")) || !podman.isMissingImage(exitWith("1")) {
+		t.Fatal("podman: exit codes 125 and 1 should mean a missing image")
+	}
+	if podman.isMissingImage(exitWith("2")) || podman.isMissingImage(nil) {
+		t.Fatal("podman: other results should not mean a missing image")
+	}
+	unreachable := classifyCommandError(exitWith("125"), "Error: Cannot connect to Podman. Please verify your connection")
+	if podman.isMissingImage(unreachable) {
+		t.Fatal("podman: an unreachable engine should not mean a missing image, whatever its exit code")
+	}
+	if !docker.isMissingImage(classifyCommandError(exitWith("125"), "Error response from daemon: No such image: paulenv:demo")) {
+		t.Fatal("docker: its error output should tell a missing image, whatever its exit code")
+	}
+}
+
+func TestQuirks_ParseCreatedAt(t *testing.T) {
+	want := time.Date(2026, 3, 4, 10, 20, 30, 0, time.UTC)
+	tests := []struct {
+		engine string
+		input  string
+	}{
+		{"docker", "2026-03-04T10:20:30Z"},
+		{"docker", "2026-03-04T10:20:30.000000000Z"},
+		{"docker", "2026-03-04 10:20:30 +0000 UTC"},
+		{"podman", "2026-03-04 10:20:30.000000000 +0000 UTC\n"},
+		{"podman", "2026-03-04T10:20:30Z"},
+	}
+	for _, tt := range tests {
+		got := quirksFor(tt.engine, "").parseCreatedAt(tt.input)
+		if got == nil || !got.Equal(want) {
+			t.Fatalf("%s parseCreatedAt(%q) = %v, want %v", tt.engine, tt.input, got, want)
+		}
+	}
+}
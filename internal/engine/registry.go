@@ -0,0 +1,51 @@
+// # registry.go
+// Project images can be shared through a container registry: built once (e.g.
+// in CI) and pushed, then pulled by everyone else instead of being rebuilt.
+//
+// A repository given without tag is mapped to one tag per project, so a
+// single repository can hold the images of several projects.
+
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Returns the full reference under which the image of the given project is
+// pushed to or pulled from `reference`, a repository (e.g.
+// "ghcr.io/team/envs") tagged with the project name unless it already has a
+// tag or digest.
+func RegistryReference(reference string, projectName string) (string, error) {
+	if reference == "" || strings.ContainsAny(reference, " \t\n") {
+		return "", fmt.Errorf("invalid image reference '%s'", reference)
+	}
+	if strings.HasSuffix(reference, "/") || strings.HasSuffix(reference, ":") {
+		return "", fmt.Errorf("invalid image reference '%s': missing repository or tag", reference)
+	}
+	if strings.Contains(reference, "@") {
+		return reference, nil
+	}
+	// A ':' before the last '/' is the port of the registry host
+	lastComponent := reference[strings.LastIndex(reference, "/")+1:]
+	if strings.Contains(lastComponent, ":") {
+		return reference, nil
+	}
+	return reference + ":" + projectName, nil
+}
+
+// Returns the registry host of the given reference, as given to `login`.
+func registryHost(reference string) string {
+	host, _, found := strings.Cut(reference, "/")
+	if found && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		return host
+	}
+	return "docker.io"
+}
+
+// Wrap the failure to push or pull `reference` with a hint on logging in to
+// its registry, which is the most common cause.
+func registryError(action string, reference string, engineName string, err error) error {
+	return fmt.Errorf("failed to %s %s: %w\nHint: If the registry needs credentials, log in to it first with '%s login %s'",
+		action, reference, err, engineName, registryHost(reference))
+}
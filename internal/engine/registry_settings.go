@@ -0,0 +1,389 @@
+// # registry_settings.go
+// Registry mirrors, insecure registries and per-registry auth files of the
+// global configuration (`REGISTRY_MIRRORS`, `INSECURE_REGISTRIES` and
+// `REGISTRY_AUTH_FILES`) are applied to builds, pulls and pushes, so that
+// e.g. a corporate mirror can be used without editing each engine's own
+// configuration.
+//
+// Podman and Buildah read them from a generated `registries.conf`, made of
+// the one they would otherwise read followed by those registries, and from a
+// generated `--authfile` gathering the credentials of each registry. Podman
+// reached through its API socket (in a virtual machine or in rootful mode)
+// only gets the latter, its service reading its own `registries.conf`.
+//
+// Docker reads mirrors and insecure registries from its daemon's
+// configuration only, which `doctor` compares to those settings. It gets the
+// credentials through a generated client configuration directory, linking
+// everything else of the user's one.
+
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Registry settings of the global configuration.
+type RegistrySettings struct {
+	// Mirror of each registry, by registry
+	Mirrors map[string]string
+	// Registries and mirrors reached without verifying their TLS certificate
+	Insecure []string
+	// Auth file of each registry, by registry
+	AuthFiles map[string]string
+}
+
+var (
+	registrySettings RegistrySettings
+	// Where the generated configuration files are written
+	registryStore *files.FileStore
+
+	podmanRegistryOnce sync.Once
+	podmanRegistryConf string
+	podmanAuthFile     string
+	podmanRegistryErr  error
+
+	dockerRegistryOnce sync.Once
+	dockerConfigDir    string
+	dockerRegistryErr  error
+)
+
+// Set the registry settings applied to builds, pulls and pushes, the
+// configuration files they need being written in `filestore`.
+func SetRegistrySettings(settings RegistrySettings, filestore *files.FileStore) {
+	registrySettings = settings
+	registryStore = filestore
+	podmanRegistryOnce = sync.Once{}
+	dockerRegistryOnce = sync.Once{}
+}
+
+// Environment and flags of the Podman or Buildah commands building, pulling
+// or pushing images.
+func (c *PodmanEngine) registryOptions() ([]string, []string, error) {
+	podmanRegistryOnce.Do(func() {
+		podmanRegistryConf, podmanAuthFile, podmanRegistryErr = writePodmanRegistryConfig()
+	})
+	if podmanRegistryErr != nil {
+		return nil, nil, podmanRegistryErr
+	}
+	var env, args []string
+	// Only read by the CLI when it does not go through an API socket
+	if podmanRegistryConf != "" && hostOS == "linux" && c.remoteURL == "" && !windowsCLIFromWSL.Load() {
+		env = append(env, "CONTAINERS_REGISTRIES_CONF="+podmanRegistryConf)
+	}
+	if podmanAuthFile != "" {
+		args = append(args, "--authfile", hostPath(podmanAuthFile))
+	}
+	return env, args, nil
+}
+
+// Write the `registries.conf` and auth file of Podman and Buildah, returning
+// their paths, empty if not needed.
+func writePodmanRegistryConfig() (string, string, error) {
+	var confPath, authPath string
+	if len(registrySettings.Mirrors) > 0 || len(registrySettings.Insecure) > 0 {
+		base, err := os.ReadFile(podmanRegistriesConfPath())
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", "", fmt.Errorf("cannot read registries.conf: %w", err)
+		}
+		content := podmanRegistriesConf(string(base), registrySettings)
+		if confPath, err = registryStore.WriteRegistryConfig("registries.conf", []byte(content)); err != nil {
+			return "", "", err
+		}
+	}
+	if len(registrySettings.AuthFiles) > 0 {
+		auths, err := registryAuths(registrySettings.AuthFiles)
+		if err != nil {
+			return "", "", err
+		}
+		content, err := json.MarshalIndent(map[string]any{"auths": auths}, "", "\t")
+		if err != nil {
+			return "", "", err
+		}
+		if authPath, err = registryStore.WriteRegistryConfig("auth.json", content); err != nil {
+			return "", "", err
+		}
+	}
+	return confPath, authPath, nil
+}
+
+// `registries.conf` read by Podman and Buildah: the one of
+// `CONTAINERS_REGISTRIES_CONF`, else the user's, else the system's.
+func podmanRegistriesConfPath() string {
+	if path := os.Getenv("CONTAINERS_REGISTRIES_CONF"); path != "" {
+		return path
+	}
+	if os.Geteuid() != 0 {
+		configDir := os.Getenv("XDG_CONFIG_HOME")
+		if configDir == "" {
+			home, _ := os.UserHomeDir()
+			configDir = filepath.Join(home, ".config")
+		}
+		path := filepath.Join(configDir, "containers", "registries.conf")
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return "/etc/containers/registries.conf"
+}
+
+// Content of `base`, a `registries.conf`, followed by a `[[registry]]` table
+// for each registry with a mirror or insecure.
+func podmanRegistriesConf(base string, settings RegistrySettings) string {
+	var sb strings.Builder
+	sb.WriteString(base)
+	if base != "" && !strings.HasSuffix(base, "\n") {
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n# Added by paul-envs from its global configuration\n")
+	registries := slices.Sorted(maps.Keys(settings.Mirrors))
+	for _, registry := range settings.Insecure {
+		if !slices.Contains(registries, registry) {
+			registries = append(registries, registry)
+		}
+	}
+	for _, registry := range registries {
+		fmt.Fprintf(&sb, "\n[[registry]]\nprefix = %s\nlocation = %s\n", strconv.Quote(registry), strconv.Quote(registry))
+		if slices.Contains(settings.Insecure, registry) {
+			sb.WriteString("insecure = true\n")
+		}
+		if mirror, ok := settings.Mirrors[registry]; ok {
+			fmt.Fprintf(&sb, "\n[[registry.mirror]]\nlocation = %s\n", strconv.Quote(mirror))
+			if mirrorHost, _, _ := strings.Cut(mirror, "/"); slices.Contains(settings.Insecure, mirrorHost) {
+				sb.WriteString("insecure = true\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// Environment of the Docker commands building, pulling or pushing images.
+func dockerRegistryEnv(ctx context.Context) ([]string, error) {
+	if len(registrySettings.AuthFiles) == 0 {
+		return nil, nil
+	}
+	dockerRegistryOnce.Do(func() {
+		dockerConfigDir, dockerRegistryErr = writeDockerRegistryConfig(ctx)
+	})
+	if dockerRegistryErr != nil {
+		return nil, dockerRegistryErr
+	}
+	return []string{"DOCKER_CONFIG=" + dockerConfigDir}, nil
+}
+
+// Write the Docker client configuration directory with the credentials of
+// the auth files, returning its path.
+func writeDockerRegistryConfig(ctx context.Context) (string, error) {
+	auths, err := registryAuths(registrySettings.AuthFiles)
+	if err != nil {
+		return "", err
+	}
+	sourceDir := userDockerConfigDir()
+	base, err := os.ReadFile(filepath.Join(sourceDir, "config.json"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("cannot read the Docker configuration: %w", err)
+	}
+	config, err := dockerClientConfig(base, auths)
+	if err != nil {
+		return "", err
+	}
+	return registryStore.PrepareDockerConfigDir(ctx, sourceDir, config)
+}
+
+// Configuration directory of the Docker CLI: `DOCKER_CONFIG`, else
+// `~/.docker`.
+func userDockerConfigDir() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".docker")
+}
+
+// Docker's `config.json` content `base` with the given credentials by
+// registry.
+func dockerClientConfig(base []byte, auths map[string]json.RawMessage) ([]byte, error) {
+	config := map[string]json.RawMessage{}
+	configAuths := map[string]json.RawMessage{}
+	credHelpers := map[string]string{}
+	if len(bytes.TrimSpace(base)) > 0 {
+		if err := json.Unmarshal(base, &config); err != nil {
+			return nil, fmt.Errorf("cannot parse the Docker configuration: %w", err)
+		}
+		if err := unmarshalConfigField(config, "auths", &configAuths); err != nil {
+			return nil, err
+		}
+		if err := unmarshalConfigField(config, "credHelpers", &credHelpers); err != nil {
+			return nil, err
+		}
+	}
+	for registry, auth := range auths {
+		key := registry
+		if registry == "docker.io" {
+			// Docker Hub's credentials are under its legacy index address
+			key = "https://index.docker.io/v1/"
+		}
+		configAuths[key] = auth
+		// Empty, Docker reads them from `auths` even if a `credsStore` is set
+		credHelpers[key] = ""
+	}
+	var err error
+	if config["auths"], err = json.Marshal(configAuths); err != nil {
+		return nil, err
+	}
+	if config["credHelpers"], err = json.Marshal(credHelpers); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(config, "", "\t")
+}
+
+func unmarshalConfigField(config map[string]json.RawMessage, field string, target any) error {
+	value, ok := config[field]
+	if !ok {
+		return nil
+	}
+	if err := json.Unmarshal(value, target); err != nil {
+		return fmt.Errorf("cannot parse the %q of the Docker configuration: %w", field, err)
+	}
+	return nil
+}
+
+// Credentials of each registry, read from its auth file.
+func registryAuths(authFiles map[string]string) (map[string]json.RawMessage, error) {
+	auths := map[string]json.RawMessage{}
+	for _, registry := range slices.Sorted(maps.Keys(authFiles)) {
+		path := authFiles[registry]
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read the auth file of registry %s: %w", registry, err)
+		}
+		var file struct {
+			Auths map[string]json.RawMessage `json:"auths"`
+		}
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("cannot parse the auth file of registry %s (%s): %w", registry, path, err)
+		}
+		for _, key := range slices.Sorted(maps.Keys(file.Auths)) {
+			if authRegistryHost(key) == registry {
+				auths[registry] = file.Auths[key]
+				break
+			}
+		}
+		if _, ok := auths[registry]; !ok {
+			return nil, fmt.Errorf("the auth file of registry %s (%s) has no credentials for it\nHint: Write it with 'podman login --authfile %s %s'", registry, path, path, registry)
+		}
+	}
+	return auths, nil
+}
+
+// Registry host of a key of an auth file, which can be a URL (e.g.
+// "https://index.docker.io/v1/") or a repository (e.g. "quay.io/team").
+func authRegistryHost(key string) string {
+	key = strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://")
+	host, _, _ := strings.Cut(key, "/")
+	switch host {
+	case "index.docker.io", "registry-1.docker.io":
+		return "docker.io"
+	}
+	return host
+}
+
+// Check that the engine applies the registry mirrors and insecure registries
+// of the global configuration.
+func diagnoseRegistrySettings(ctx context.Context, engineName string) Diagnostic {
+	diagnostic := Diagnostic{Name: engineName + " registry settings"}
+	if engineName == "docker" {
+		info, stderr, err := runDiagnosticCommand(ctx, "docker", "info", "--format", "{{json .RegistryConfig}}")
+		if err != nil {
+			diagnostic.Status = DiagnosticSkipped
+			diagnostic.Detail = "cannot read the daemon's registry configuration: " + firstLine(stderr, err)
+			return diagnostic
+		}
+		return dockerRegistryDiagnostic(diagnostic, info, registrySettings)
+	}
+	if runtime.GOOS != "linux" {
+		diagnostic.Status = DiagnosticWarning
+		diagnostic.Detail = "registry mirrors and insecure registries are not applied in Podman's virtual machine"
+		diagnostic.Fix = "Add this to /etc/containers/registries.conf in it ('podman machine ssh'):" +
+			strings.TrimRight(podmanRegistriesConf("", registrySettings), "\n")
+		return diagnostic
+	}
+	diagnostic.Status = DiagnosticOk
+	diagnostic.Detail = "applied through a generated registries.conf, except to rootful Podman reached through its socket"
+	return diagnostic
+}
+
+// Check the registry configuration of the Docker daemon, as reported in JSON
+// by `docker info`, against `settings`.
+func dockerRegistryDiagnostic(diagnostic Diagnostic, info string, settings RegistrySettings) Diagnostic {
+	var config struct {
+		IndexConfigs map[string]struct{ Secure bool }
+		Mirrors      []string
+	}
+	if err := json.Unmarshal([]byte(info), &config); err != nil {
+		diagnostic.Status = DiagnosticSkipped
+		diagnostic.Detail = "cannot parse the daemon's registry configuration: " + err.Error()
+		return diagnostic
+	}
+	var problems, mirrors, insecure []string
+	for _, registry := range slices.Sorted(maps.Keys(settings.Mirrors)) {
+		mirror := settings.Mirrors[registry]
+		if registry != "docker.io" {
+			problems = append(problems, fmt.Sprintf("Docker only supports mirrors of docker.io, not of %s", registry))
+			continue
+		}
+		configured := slices.ContainsFunc(config.Mirrors, func(url string) bool {
+			url = strings.TrimPrefix(strings.TrimPrefix(url, "https://"), "http://")
+			return strings.TrimSuffix(url, "/") == mirror
+		})
+		if !configured {
+			problems = append(problems, fmt.Sprintf("mirror %s of docker.io is not configured", mirror))
+			scheme := "https://"
+			if mirrorHost, _, _ := strings.Cut(mirror, "/"); slices.Contains(settings.Insecure, mirrorHost) {
+				scheme = "http://"
+			}
+			mirrors = append(mirrors, strconv.Quote(scheme+mirror))
+		}
+	}
+	for _, registry := range settings.Insecure {
+		// Docker always considers local registries insecure
+		if index, ok := config.IndexConfigs[registry]; (ok && !index.Secure) ||
+			strings.HasPrefix(registry, "localhost") || strings.HasPrefix(registry, "127.") {
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("%s is not an insecure registry", registry))
+		insecure = append(insecure, strconv.Quote(registry))
+	}
+	if len(problems) == 0 {
+		diagnostic.Status = DiagnosticOk
+		diagnostic.Detail = "the daemon's configuration has the registry mirrors and insecure registries"
+		return diagnostic
+	}
+	diagnostic.Status = DiagnosticWarning
+	diagnostic.Detail = strings.Join(problems, ", ")
+	var entries []string
+	if len(mirrors) > 0 {
+		entries = append(entries, fmt.Sprintf(`"registry-mirrors": [%s]`, strings.Join(mirrors, ", ")))
+	}
+	if len(insecure) > 0 {
+		entries = append(entries, fmt.Sprintf(`"insecure-registries": [%s]`, strings.Join(insecure, ", ")))
+	}
+	if len(entries) > 0 {
+		diagnostic.Fix = fmt.Sprintf("Add %s to the Docker daemon's configuration (/etc/docker/daemon.json, or Docker Desktop's settings), then restart it.",
+			strings.Join(entries, " and "))
+	}
+	return diagnostic
+}
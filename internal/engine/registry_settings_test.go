@@ -0,0 +1,128 @@
+package engine
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPodmanRegistriesConf(t *testing.T) {
+	settings := RegistrySettings{
+		Mirrors:  map[string]string{"docker.io": "mirror.example.com:5000/docker-remote", "quay.io": "mirror.example.com/quay"},
+		Insecure: []string{"mirror.example.com:5000", "localhost:5000"},
+	}
+	got := podmanRegistriesConf(`unqualified-search-registries = ["docker.io"]`, settings)
+	want := `unqualified-search-registries = ["docker.io"]
+
+# Added by paul-envs from its global configuration
+
+[[registry]]
+prefix = "docker.io"
+location = "docker.io"
+
+[[registry.mirror]]
+location = "mirror.example.com:5000/docker-remote"
+insecure = true
+
+[[registry]]
+prefix = "quay.io"
+location = "quay.io"
+
+[[registry.mirror]]
+location = "mirror.example.com/quay"
+
+[[registry]]
+prefix = "mirror.example.com:5000"
+location = "mirror.example.com:5000"
+insecure = true
+
+[[registry]]
+prefix = "localhost:5000"
+location = "localhost:5000"
+insecure = true
+`
+	if got != want {
+		t.Fatalf("podmanRegistriesConf() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRegistryAuths(t *testing.T) {
+	dir := t.TempDir()
+	hubAuth := filepath.Join(dir, "hub.json")
+	mirrorAuth := filepath.Join(dir, "mirror.json")
+	if err := os.WriteFile(hubAuth, []byte(`{"auths": {"https://index.docker.io/v1/": {"auth": "aHViOnB3"}}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(mirrorAuth, []byte(`{"auths": {"other.example.com": {"auth": "eDp5"}, "mirror.example.com": {"auth": "bTpw"}}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	auths, err := registryAuths(map[string]string{"docker.io": hubAuth, "mirror.example.com": mirrorAuth})
+	if err != nil {
+		t.Fatalf("registryAuths() failed: %v", err)
+	}
+	want := map[string]json.RawMessage{
+		"docker.io":          json.RawMessage(`{"auth": "aHViOnB3"}`),
+		"mirror.example.com": json.RawMessage(`{"auth": "bTpw"}`),
+	}
+	if !reflect.DeepEqual(auths, want) {
+		t.Fatalf("registryAuths() = %s, want %s", auths, want)
+	}
+
+	if _, err := registryAuths(map[string]string{"quay.io": mirrorAuth}); err == nil || !strings.Contains(err.Error(), "no credentials") {
+		t.Fatalf("registryAuths() with a file missing the registry: got %v, want an error", err)
+	}
+}
+
+func TestDockerClientConfig(t *testing.T) {
+	base := []byte(`{"credsStore": "desktop", "currentContext": "desktop-linux", "auths": {"ghcr.io": {}}}`)
+	auths := map[string]json.RawMessage{
+		"docker.io":          json.RawMessage(`{"auth":"aHViOnB3"}`),
+		"mirror.example.com": json.RawMessage(`{"auth":"bTpw"}`),
+	}
+	config, err := dockerClientConfig(base, auths)
+	if err != nil {
+		t.Fatalf("dockerClientConfig() failed: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(config, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"credsStore":     "desktop",
+		"currentContext": "desktop-linux",
+		"auths": map[string]any{
+			"ghcr.io":                     map[string]any{},
+			"https://index.docker.io/v1/": map[string]any{"auth": "aHViOnB3"},
+			"mirror.example.com":          map[string]any{"auth": "bTpw"},
+		},
+		"credHelpers": map[string]any{"https://index.docker.io/v1/": "", "mirror.example.com": ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("dockerClientConfig() = %v, want %v", got, want)
+	}
+}
+
+func TestDockerRegistryDiagnostic(t *testing.T) {
+	settings := RegistrySettings{
+		Mirrors:  map[string]string{"docker.io": "mirror.example.com", "quay.io": "mirror.example.com/quay"},
+		Insecure: []string{"registry.lan:5000", "localhost:5000"},
+	}
+	info := `{"IndexConfigs": {"docker.io": {"Secure": true}}, "Mirrors": []}`
+	diagnostic := dockerRegistryDiagnostic(Diagnostic{}, info, settings)
+	if diagnostic.Status != DiagnosticWarning {
+		t.Fatalf("dockerRegistryDiagnostic() status = %v, want a warning", diagnostic.Status)
+	}
+	wantFix := `Add "registry-mirrors": ["https://mirror.example.com"] and "insecure-registries": ["registry.lan:5000"]`
+	if !strings.HasPrefix(diagnostic.Fix, wantFix) || !strings.Contains(diagnostic.Detail, "not of quay.io") {
+		t.Fatalf("dockerRegistryDiagnostic() = %+v", diagnostic)
+	}
+
+	settings.Mirrors = map[string]string{"docker.io": "mirror.example.com"}
+	info = `{"IndexConfigs": {"registry.lan:5000": {"Secure": false}}, "Mirrors": ["https://mirror.example.com/"]}`
+	if diagnostic := dockerRegistryDiagnostic(Diagnostic{}, info, settings); diagnostic.Status != DiagnosticOk {
+		t.Fatalf("dockerRegistryDiagnostic() with a configured daemon = %+v, want it ok", diagnostic)
+	}
+}
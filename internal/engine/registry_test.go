@@ -0,0 +1,46 @@
+package engine
+
+import "testing"
+
+func TestRegistryReference(t *testing.T) {
+	cases := []struct {
+		reference string
+		want      string
+	}{
+		{"ghcr.io/team/envs", "ghcr.io/team/envs:app"},
+		{"ghcr.io/team/envs:v2", "ghcr.io/team/envs:v2"},
+		{"localhost:5000/envs", "localhost:5000/envs:app"},
+		{"localhost:5000/envs:v2", "localhost:5000/envs:v2"},
+		{"team/envs@sha256:abcd", "team/envs@sha256:abcd"},
+		{"envs", "envs:app"},
+	}
+	for _, c := range cases {
+		got, err := RegistryReference(c.reference, "app")
+		if err != nil {
+			t.Fatalf("RegistryReference(%q) error: %v", c.reference, err)
+		}
+		if got != c.want {
+			t.Errorf("RegistryReference(%q) = %q, want %q", c.reference, got, c.want)
+		}
+	}
+	for _, invalid := range []string{"", "ghcr.io/team/", "team/envs:", "team envs"} {
+		if _, err := RegistryReference(invalid, "app"); err == nil {
+			t.Errorf("RegistryReference(%q) succeeded, want an error", invalid)
+		}
+	}
+}
+
+func TestRegistryHost(t *testing.T) {
+	cases := map[string]string{
+		"ghcr.io/team/envs:app":   "ghcr.io",
+		"localhost:5000/envs:app": "localhost:5000",
+		"localhost/envs:app":      "localhost",
+		"team/envs:app":           "docker.io",
+		"envs:app":                "docker.io",
+	}
+	for reference, want := range cases {
+		if got := registryHost(reference); got != want {
+			t.Errorf("registryHost(%q) = %q, want %q", reference, got, want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// # rename.go
+// A project's name is part of the name of all its engine resources: its
+// images (current, previous ones, snapshots, per-architecture ones), its
+// volumes and those of its services, its containers and its network.
+//
+// Renaming a project thus renames them: images are tagged under their new
+// name and volumes copied to their new name, before those under the previous
+// name are removed once the project itself was renamed. Containers and
+// networks are not renamed but removed, they are created again on the next
+// run.
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Resources of a project to rename along with it.
+type ProjectRename struct {
+	From string
+	To   string
+	// Images to tag under another name, as `{previous, new}` names
+	Images [][2]string
+	// Volumes to copy to another name, as `{previous, new}` names
+	Volumes [][2]string
+	// Networks removed along with the previous resources
+	Networks []NetworkInfo
+
+	leftovers RunLeftovers
+}
+
+// List the resources of project `from` to rename for it to be named `to`.
+//
+// Fails if the project is running, or if resources of a project named `to`
+// already exist.
+func PlanProjectRename(ctx context.Context, c ContainerEngine, from string, to string) (ProjectRename, error) {
+	containers, err := c.ListContainers(ctx)
+	if err != nil {
+		return ProjectRename{}, fmt.Errorf("failed to list containers: %w", err)
+	}
+	sidecars, err := c.ListSidecars(ctx)
+	if err != nil {
+		return ProjectRename{}, err
+	}
+	images, err := c.ListImages(ctx)
+	if err != nil {
+		return ProjectRename{}, fmt.Errorf("failed to list images: %w", err)
+	}
+	generations, err := c.ListGenerations(ctx)
+	if err != nil {
+		return ProjectRename{}, err
+	}
+	snapshots, err := c.ListSnapshots(ctx)
+	if err != nil {
+		return ProjectRename{}, err
+	}
+	archImages, err := c.ListArchImages(ctx)
+	if err != nil {
+		return ProjectRename{}, err
+	}
+	volumes, err := c.ListVolumes(ctx)
+	if err != nil {
+		return ProjectRename{}, fmt.Errorf("failed to list volumes: %w", err)
+	}
+	networks, err := c.ListNetworks(ctx)
+	if err != nil {
+		return ProjectRename{}, err
+	}
+
+	for _, container := range containers {
+		if container.Running && container.ProjectName != nil && *container.ProjectName == from {
+			return ProjectRename{}, fmt.Errorf("project '%s' is running, stop it first", from)
+		}
+	}
+	for _, sidecar := range sidecars {
+		if sidecar.Running && sidecar.ProjectName == from {
+			return ProjectRename{}, fmt.Errorf("services of project '%s' are running, stop them first", from)
+		}
+	}
+
+	rename := ProjectRename{From: from, To: to}
+	taken := []string{}
+	for _, image := range images {
+		if image.ProjectName == nil {
+			continue
+		}
+		switch *image.ProjectName {
+		case from:
+			rename.Images = append(rename.Images, [2]string{projectImageName(from), projectImageName(to)})
+		case to:
+			taken = append(taken, image.ImageName)
+		}
+	}
+	for _, generation := range generations {
+		switch generation.ProjectName {
+		case from:
+			rename.Images = append(rename.Images, [2]string{generation.ImageName, generationImageName(to, generation.Number)})
+		case to:
+			taken = append(taken, generation.ImageName)
+		}
+	}
+	for _, snapshot := range snapshots {
+		switch snapshot.ProjectName {
+		case from:
+			rename.Images = append(rename.Images, [2]string{snapshot.ImageName, snapshotImageName(to, snapshot.Tag)})
+		case to:
+			taken = append(taken, snapshot.ImageName)
+		}
+	}
+	for _, image := range archImages {
+		switch image.ProjectName {
+		case from:
+			rename.Images = append(rename.Images, [2]string{image.ImageName, archImageName(to, image.Architecture)})
+		case to:
+			taken = append(taken, image.ImageName)
+		}
+	}
+	for _, volume := range volumes {
+		if suffix, ok := projectVolumeSuffix(volume.VolumeName, from); ok {
+			rename.Volumes = append(rename.Volumes, [2]string{volume.VolumeName, "paulenv-" + to + suffix})
+		} else if _, ok := projectVolumeSuffix(volume.VolumeName, to); ok {
+			taken = append(taken, volume.VolumeName)
+		}
+	}
+	for _, network := range networks {
+		if network.ProjectName == nil {
+			continue
+		}
+		switch *network.ProjectName {
+		case from:
+			rename.Networks = append(rename.Networks, network)
+		case to:
+			taken = append(taken, network.NetworkName)
+		}
+	}
+	if len(taken) > 0 {
+		return ProjectRename{}, fmt.Errorf("resources of a project named '%s' already exist: %s", to, strings.Join(taken, ", "))
+	}
+	rename.leftovers = findRunLeftovers(from, containers, sidecars, nil)
+	return rename, nil
+}
+
+// Returns what follows the project name in the name of the given volume of
+// that project or of one of its services (e.g. "-local" or ".db-local"), `ok`
+// set to `false` if it is not one of them.
+func projectVolumeSuffix(volumeName string, projectName string) (string, bool) {
+	suffix, ok := strings.CutPrefix(volumeName, "paulenv-"+projectName)
+	if !ok || !strings.HasSuffix(suffix, "-local") {
+		return "", false
+	}
+	if suffix == "-local" || strings.HasPrefix(suffix, ".") {
+		return suffix, true
+	}
+	return "", false
+}
+
+// Make the resources of the project available under its new name, keeping
+// those under the previous one. Containers left behind by previous runs are
+// removed first, as they would keep its volumes in use.
+//
+// On failure, the resources already copied are removed.
+func (r ProjectRename) Copy(ctx context.Context, c ContainerEngine) error {
+	if err := RemoveRunLeftovers(ctx, c, r.leftovers); err != nil {
+		return err
+	}
+	var tagged []string
+	var copied []string
+	undo := func(err error) error {
+		for _, image := range tagged {
+			if uErr := c.UntagImage(ctx, image); uErr != nil {
+				err = errors.Join(err, uErr)
+			}
+		}
+		for _, volume := range copied {
+			if rErr := c.RemoveVolume(ctx, VolumeInfo{VolumeName: volume}); rErr != nil {
+				err = errors.Join(err, rErr)
+			}
+		}
+		return err
+	}
+	for _, image := range r.Images {
+		if err := c.TagImage(ctx, image[0], image[1]); err != nil {
+			return undo(err)
+		}
+		tagged = append(tagged, image[1])
+	}
+	for _, volume := range r.Volumes {
+		// Created by the import, it may be partially written on failure
+		copied = append(copied, volume[1])
+		if err := copyVolume(ctx, c, volume[0], volume[1]); err != nil {
+			return undo(fmt.Errorf("failed to copy volume %s to %s: %w", volume[0], volume[1], err))
+		}
+	}
+	return nil
+}
+
+// Remove the resources of the project under its previous name, once they
+// were copied.
+func (r ProjectRename) RemovePrevious(ctx context.Context, c ContainerEngine) error {
+	var errs []error
+	for _, image := range r.Images {
+		if err := c.UntagImage(ctx, image[0]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, volume := range r.Volumes {
+		if err := c.RemoveVolume(ctx, VolumeInfo{VolumeName: volume[0]}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, network := range r.Networks {
+		if err := c.RemoveNetwork(ctx, network); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
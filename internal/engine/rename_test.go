@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Engine recording the operations made to rename a project, with the
+// content of its volumes.
+type renameEngine struct {
+	FakeEngine
+	containers []ContainerInfo
+	images     []ImageInfo
+	volumes    map[string]string
+	networks   []NetworkInfo
+	operations []string
+}
+
+func (e *renameEngine) ListContainers(context.Context) ([]ContainerInfo, error) {
+	return e.containers, nil
+}
+func (e *renameEngine) ListSidecars(context.Context) ([]SidecarInfo, error) { return nil, nil }
+func (e *renameEngine) ListImages(context.Context) ([]ImageInfo, error)     { return e.images, nil }
+func (e *renameEngine) ListGenerations(context.Context) ([]GenerationInfo, error) {
+	return []GenerationInfo{{ProjectName: "app", Number: 2, ImageName: generationImageName("app", 2)}}, nil
+}
+func (e *renameEngine) ListSnapshots(context.Context) ([]SnapshotInfo, error) { return nil, nil }
+func (e *renameEngine) ListArchImages(context.Context) ([]ArchImageInfo, error) {
+	return nil, nil
+}
+func (e *renameEngine) ListVolumes(context.Context) ([]VolumeInfo, error) {
+	volumes := []VolumeInfo{}
+	for name := range e.volumes {
+		volumes = append(volumes, VolumeInfo{VolumeName: name})
+	}
+	slices.SortFunc(volumes, func(a, b VolumeInfo) int { return strings.Compare(a.VolumeName, b.VolumeName) })
+	return volumes, nil
+}
+func (e *renameEngine) ListNetworks(context.Context) ([]NetworkInfo, error) { return e.networks, nil }
+func (e *renameEngine) RemoveContainer(_ context.Context, container ContainerInfo) error {
+	e.operations = append(e.operations, "rm "+container.ContainerId)
+	return nil
+}
+func (e *renameEngine) TagImage(_ context.Context, image string, target string) error {
+	e.operations = append(e.operations, "tag "+image+" "+target)
+	return nil
+}
+func (e *renameEngine) UntagImage(_ context.Context, image string) error {
+	e.operations = append(e.operations, "untag "+image)
+	return nil
+}
+func (e *renameEngine) ExportVolume(_ context.Context, name string, w io.Writer) error {
+	_, err := io.WriteString(w, e.volumes[name])
+	return err
+}
+func (e *renameEngine) ImportVolume(_ context.Context, name string, r io.Reader) error {
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil {
+		return err
+	}
+	e.volumes[name] = buf.String()
+	e.operations = append(e.operations, "copy to "+name)
+	return nil
+}
+func (e *renameEngine) RemoveVolume(_ context.Context, volume VolumeInfo) error {
+	delete(e.volumes, volume.VolumeName)
+	e.operations = append(e.operations, "rm volume "+volume.VolumeName)
+	return nil
+}
+func (e *renameEngine) RemoveNetwork(_ context.Context, network NetworkInfo) error {
+	e.operations = append(e.operations, "rm network "+network.NetworkName)
+	return nil
+}
+
+func TestProjectRename(t *testing.T) {
+	str := func(s string) *string { return &s }
+	ctx := context.Background()
+	e := &renameEngine{
+		containers: []ContainerInfo{{ProjectName: str("app"), ContainerName: str("paulenv-app"), ContainerId: "c1"}},
+		images: []ImageInfo{
+			{ImageName: "paulenv-base:latest"},
+			{ProjectName: str("app"), ImageName: "paulenv:app"},
+		},
+		volumes: map[string]string{
+			"paulenv-app-local":    "home",
+			"paulenv-app.db-local": "data",
+			"paulenv-apps-local":   "other",
+		},
+		networks: []NetworkInfo{{ProjectName: str("app"), NetworkName: "paulenv-app"}},
+	}
+	rename, err := PlanProjectRename(ctx, e, "app", "web")
+	if err != nil {
+		t.Fatalf("PlanProjectRename() error = %v", err)
+	}
+	if err := rename.Copy(ctx, e); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if e.volumes["paulenv-web-local"] != "home" || e.volumes["paulenv-web.db-local"] != "data" || e.volumes["paulenv-app-local"] != "home" {
+		t.Fatalf("Copy() volumes = %v", e.volumes)
+	}
+	if err := rename.RemovePrevious(ctx, e); err != nil {
+		t.Fatalf("RemovePrevious() error = %v", err)
+	}
+	want := []string{
+		"rm c1",
+		"tag paulenv:app paulenv:web",
+		"tag " + generationImageName("app", 2) + " " + generationImageName("web", 2),
+		"copy to paulenv-web-local",
+		"copy to paulenv-web.db-local",
+		"untag paulenv:app",
+		"untag " + generationImageName("app", 2),
+		"rm volume paulenv-app-local",
+		"rm volume paulenv-app.db-local",
+		"rm network paulenv-app",
+	}
+	if !slices.Equal(e.operations, want) {
+		t.Fatalf("operations =\n%q\nwant:\n%q", e.operations, want)
+	}
+	if _, ok := e.volumes["paulenv-apps-local"]; !ok {
+		t.Fatal("the volume of another project should be kept")
+	}
+
+	e.containers[0].Running = true
+	if _, err := PlanProjectRename(ctx, e, "app", "other"); err == nil {
+		t.Fatal("PlanProjectRename() should refuse to rename a running project")
+	}
+	e.containers[0].Running = false
+	if _, err := PlanProjectRename(ctx, e, "apps", "web"); err == nil || !strings.Contains(err.Error(), "paulenv-web-local") {
+		t.Fatalf("PlanProjectRename() to an existing project's resources error = %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+// # resource_labels.go
+// Labels set on the images, containers, volumes and networks paul-envs
+// creates, so they are told apart from other resources of the engine by what
+// they are, not by their name, and so one can tell what created them.
+//
+// Resources created before they were labelled have none of those labels.
+// They are still recognized by their exact name, until they are created
+// again.
+
+package engine
+
+import (
+	"maps"
+	"strings"
+
+	versions "github.com/peaberberian/paul-envs/internal"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Label set to "true" on all resources created by paul-envs. The Dockerfiles
+// also set it on the images built from them.
+const managedLabel = "paulenv"
+
+// Label set to the version of paul-envs which created a resource.
+const versionLabel = "paulenv.version"
+
+// Label set to what created a resource, one of the `source*` values.
+const sourceLabel = "paulenv.source"
+
+// What created a resource, as recorded by `sourceLabel`.
+const (
+	// A project's image, built by `build`
+	sourceBuild = "build"
+	// A project's container (or the container of one of its instances)
+	sourceRun = "run"
+	// The container of a project's service (`SERVICE` directive)
+	sourceService = "service"
+	// A volume or network created for a project's containers
+	sourceRuntime = "runtime"
+	// The container, network or volume of a throwaway environment (`tmp`)
+	sourceThrowaway = "tmp"
+)
+
+// Labels of a resource created by `source` for the given project, or shared
+// between projects if `projectName` is empty.
+func resourceLabels(projectName string, source string) map[string]string {
+	labels := map[string]string{
+		managedLabel: "true",
+		versionLabel: versions.Version.ToString(),
+		sourceLabel:  source,
+	}
+	if projectName != "" {
+		labels[projectLabel] = projectName
+	}
+	return labels
+}
+
+// Returns the `--label` flags setting the labels of a resource created by
+// `source` for the given project, along with `extra` ones.
+func resourceLabelFlags(projectName string, source string, extra map[string]string) []string {
+	labels := resourceLabels(projectName, source)
+	maps.Copy(labels, extra)
+	return imageLabelFlags(labels)
+}
+
+// Arguments of the `volume create` command creating that volume, labelled
+// with the project it belongs to if any.
+func volumeCreateArgs(name string) []string {
+	args := append([]string{"volume", "create"}, resourceLabelFlags(volumeProjectName(name), sourceRuntime, nil)...)
+	return append(args, name)
+}
+
+// Arguments of the `network create` command creating that network, labelled
+// with the project it belongs to unless it is the shared one.
+func networkCreateArgs(name string) []string {
+	projectName := ""
+	if name != sharedNetworkName {
+		if project := projectNameFromSchemeName(name); project != nil {
+			projectName = *project
+		}
+	}
+	args := append([]string{"network", "create"}, resourceLabelFlags(projectName, sourceRuntime, nil)...)
+	return append(args, name)
+}
+
+// Returns `true` if a volume or network with that name and value of
+// `managedLabel` was created by paul-envs. Unlabelled ones are those of
+// earlier versions if `isLegacyName` matches their name.
+func isManagedResource(name string, managedValue string, isLegacyName func(string) bool) bool {
+	switch strings.TrimSpace(managedValue) {
+	case "true":
+		return true
+	case "", "<no value>":
+		return isLegacyName(name)
+	default:
+		return false
+	}
+}
+
+// Project a volume named like the local volumes of its containers or services
+// belongs to, empty if it is shared between projects.
+func volumeProjectName(volumeName string) string {
+	name, ok := strings.CutPrefix(volumeName, "paulenv-")
+	if !ok || !strings.HasSuffix(name, "-local") {
+		return ""
+	}
+	// Followed by ".<service>" or "..<instance>"
+	projectName, _, _ := strings.Cut(strings.TrimSuffix(name, "-local"), ".")
+	if utils.ValidateProjectName(projectName) != nil {
+		return ""
+	}
+	return projectName
+}
+
+// Returns `true` if that network is named like those of earlier versions of
+// paul-envs, which were not labelled: the shared one or a project's own.
+func isLegacyNetworkName(networkName string) bool {
+	if networkName == sharedNetworkName {
+		return true
+	}
+	projectName := projectNameFromContainerName(networkName)
+	return projectName != nil && utils.ValidateProjectName(*projectName) == nil
+}
@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseVolumeList(t *testing.T) {
+	output := "paulenv-app-local\ttrue\n" +
+		// Created before volumes were labelled
+		"paulenv-shared-cache\t\n" +
+		"paulenv-app.db-local\t<no value>\n" +
+		"paulenv-app..tests-local\t\n" +
+		// Only starting like them
+		"paulenv-backup\t\n" +
+		"paulenv-Bad Name-local\t\n" +
+		"mydata\t\n" +
+		"labelled-elsewhere\tfalse\n" +
+		"renamed-volume\ttrue\n"
+	var got []string
+	for _, volume := range parseVolumeList(output) {
+		got = append(got, volume.VolumeName)
+	}
+	want := []string{"paulenv-app-local", "paulenv-shared-cache", "paulenv-app.db-local", "paulenv-app..tests-local", "renamed-volume"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("parseVolumeList() = %v, want %v", got, want)
+	}
+}
+
+func TestResourceCreateArgs(t *testing.T) {
+	args := volumeCreateArgs("paulenv-app.db-local")
+	if args[len(args)-1] != "paulenv-app.db-local" ||
+		!slices.Contains(args, managedLabel+"=true") ||
+		!slices.Contains(args, projectLabel+"=app") ||
+		!slices.Contains(args, sourceLabel+"="+sourceRuntime) {
+		t.Fatalf("volumeCreateArgs() = %v, want it labelled with its project", args)
+	}
+	if args := volumeCreateArgs("paulenv-shared-cache"); slices.ContainsFunc(args, func(arg string) bool {
+		return strings.HasPrefix(arg, projectLabel+"=")
+	}) {
+		t.Fatalf("volumeCreateArgs() of a shared volume = %v, want no project label", args)
+	}
+	args = networkCreateArgs("paulenv-app")
+	if args[0] != "network" || !slices.Contains(args, projectLabel+"=app") {
+		t.Fatalf("networkCreateArgs() = %v, want it labelled with its project", args)
+	}
+	if args := networkCreateArgs(sharedNetworkName); slices.Contains(args, projectLabel+"=shared") {
+		t.Fatalf("networkCreateArgs() of the shared network = %v, want no project label", args)
+	}
+}
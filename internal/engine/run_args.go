@@ -0,0 +1,345 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Arguments for the `run` command which are common to all engines, from the
+// project's build and runtime configuration.
+func projectRunArgs(project files.ProjectEntry, buildCfg config.BuildConfig, runtimeCfg config.RuntimeConfig) ([]string, error) {
+	username := buildCfg.Args["USERNAME"]
+	projectMount := projectMountTarget(username, project.ProjectName)
+	workDir := runtimeCfg.WorkDir
+	if workDir == "" {
+		workDir = projectMount
+	}
+
+	cmdArgs := []string{"--rm"}
+	// systemd has to be the PID 1 itself
+	if !hasSystemd(buildCfg) {
+		cmdArgs = append(cmdArgs, "--init")
+	}
+	// Tells which run.conf it was created from, when it can be read
+	labels := map[string]string{}
+	if hash, err := utils.FileHash(project.RuntimeConfigPath); err == nil {
+		labels[configHashLabel] = hash
+	}
+	cmdArgs = append(cmdArgs, "--name", projectContainerName(project.ProjectName))
+	cmdArgs = append(cmdArgs, resourceLabelFlags(project.ProjectName, sourceRun, labels)...)
+	cmdArgs = append(cmdArgs,
+		// e.g. for shell prompts to display it
+		"--env", "PAULENV_PROJECT="+project.ProjectName,
+		"--workdir", workDir,
+		"--volume", bindVolume(runtimeCfg.ProjectPath, projectMount, autoRelabel(runtimeCfg, runtimeCfg.ProjectPath)),
+		"--volume", "paulenv-shared-cache:/home/"+username+"/.container-cache",
+		"--volume", ProjectLocalVolumeName(project.ProjectName)+":/home/"+username+"/.container-local",
+	)
+	cmdArgs = append(cmdArgs, packageCacheRunArgs(username, runtimeCfg.PackageCaches)...)
+	cmdArgs = append(cmdArgs, sharedVolumeRunArgs(runtimeCfg.SharedVolumes)...)
+	cmdArgs = append(cmdArgs, tmpfsRunArgs(runtimeCfg.Tmpfs)...)
+	cmdArgs = append(cmdArgs, compilerCacheRunArgs(username, buildCfg)...)
+	dotfilesPath, err := projectDotfilesPath(project, runtimeCfg)
+	if err != nil {
+		return nil, err
+	}
+	if dotfilesPath != "" {
+		cmdArgs = append(cmdArgs, "--volume", bindVolume(dotfilesPath, "/paul-env/dotfiles", "ro", autoRelabel(runtimeCfg, dotfilesPath)))
+	}
+	startupArgs, err := startupRunArgs(project, runtimeCfg)
+	if err != nil {
+		return nil, err
+	}
+	cmdArgs = append(cmdArgs, startupArgs...)
+	activationArgs, err := activationRunArgs(project, runtimeCfg)
+	if err != nil {
+		return nil, err
+	}
+	cmdArgs = append(cmdArgs, activationArgs...)
+	cmdArgs = append(cmdArgs, processRunArgs(runtimeCfg)...)
+	if runtimeCfg.GitName != "" {
+		cmdArgs = append(cmdArgs, "--env", "GIT_AUTHOR_NAME="+runtimeCfg.GitName)
+	}
+	if runtimeCfg.GitEmail != "" {
+		cmdArgs = append(cmdArgs, "--env", "GIT_AUTHOR_EMAIL="+runtimeCfg.GitEmail)
+	}
+	cmdArgs = append(cmdArgs, sessionRunArgs(runtimeCfg)...)
+	// By name only: the engine CLI takes their values from its environment,
+	// which is this one's
+	for _, name := range config.PassthroughEnvNames(runtimeCfg.PassthroughEnv, os.Environ()) {
+		cmdArgs = append(cmdArgs, "--env", name)
+	}
+	cmdArgs = append(cmdArgs, envNameArgs("--env", projectProxyEnv(runtimeCfg))...)
+	cmdArgs = append(cmdArgs, localeRunArgs(runtimeCfg)...)
+
+	for _, volume := range runtimeCfg.Volumes {
+		cmdArgs = append(cmdArgs, "--volume", volume)
+	}
+	for _, mount := range runtimeCfg.Mounts {
+		spec, err := mountVolumeSpec(project, runtimeCfg, mount)
+		if err != nil {
+			return nil, err
+		}
+		cmdArgs = append(cmdArgs, "--volume", spec)
+	}
+	for _, port := range runtimeCfg.Ports {
+		cmdArgs = append(cmdArgs, "--publish", port)
+	}
+	for _, host := range runtimeCfg.ExtraHosts {
+		cmdArgs = append(cmdArgs, "--add-host", host.Name+":"+host.IP)
+	}
+	for _, server := range runtimeCfg.DNSServers {
+		cmdArgs = append(cmdArgs, "--dns", server)
+	}
+	for _, domain := range runtimeCfg.DNSSearch {
+		cmdArgs = append(cmdArgs, "--dns-search", domain)
+	}
+	if network := runNetworkName(project.ProjectName, runtimeCfg); network != "" {
+		cmdArgs = append(cmdArgs,
+			"--network", network,
+			"--network-alias", runtimeCfg.MainServiceName(project.ProjectName))
+	}
+
+	if runtimeCfg.SSHPort != "" {
+		cmdArgs = append(cmdArgs,
+			"--publish", "127.0.0.1:"+runtimeCfg.SSHPort+":22",
+			"--volume", bindVolume(project.SSHAuthorizedKeysPath, "/etc/ssh/authorized_keys/"+username, "ro",
+				autoRelabel(runtimeCfg, project.SSHAuthorizedKeysPath)))
+	}
+
+	// See `UnsupportedDirectives`
+	posixHost := hostOS != "windows"
+	var socketArgs []string
+	if runtimeCfg.Display && posixHost {
+		socketArgs = append(socketArgs, displayRunArgs(detectHostDisplay(), project.XauthorityPath)...)
+	}
+	if runtimeCfg.Audio && posixHost {
+		socketArgs = append(socketArgs, audioRunArgs(detectHostAudio())...)
+	}
+	if runtimeCfg.SSHAgent && posixHost {
+		socketArgs = append(socketArgs, sshAgentRunArgs(detectHostSSHAgent())...)
+	}
+	if runtimeCfg.GitCredentials && posixHost {
+		socketArgs = append(socketArgs, gitCredentialsRunArgs(project)...)
+	}
+	if runtimeCfg.GPGAgent && posixHost {
+		socketArgs = append(socketArgs, gpgAgentRunArgs(detectHostGPGAgent(), project)...)
+	}
+	if runtimeCfg.ServesHostRequests() && posixHost {
+		socketArgs = append(socketArgs, hostExecRunArgs(project, runtimeCfg.HostCommands, runtimeCfg.Clipboard)...)
+	}
+	if len(socketArgs) > 0 {
+		cmdArgs = append(cmdArgs, socketArgs...)
+		cmdArgs = append(cmdArgs, runtimeDirRunArgs()...)
+	}
+
+	cmdArgs = append(cmdArgs, offlinePullArgs()...)
+	if runtimeCfg.Cpus != "" {
+		cmdArgs = append(cmdArgs, "--cpus", runtimeCfg.Cpus)
+	}
+	if runtimeCfg.Memory != "" {
+		cmdArgs = append(cmdArgs, "--memory", runtimeCfg.Memory)
+	}
+	if runtimeCfg.PidsLimit != "" {
+		cmdArgs = append(cmdArgs, "--pids-limit", runtimeCfg.PidsLimit)
+	}
+	return cmdArgs, nil
+}
+
+// `--volume` value of an additional mount of a project, whose host path has
+// to exist: engines would otherwise create it as a root-owned directory.
+func mountVolumeSpec(project files.ProjectEntry, runtimeCfg config.RuntimeConfig, mount config.Mount) (string, error) {
+	source, err := resolveRuntimePath(project.RuntimeConfigPath, mount.Source)
+	if err != nil {
+		return "", fmt.Errorf("resolve MOUNT: %w", err)
+	}
+	if _, err := os.Stat(source); err != nil {
+		return "", fmt.Errorf("cannot mount %s: %s does not exist\nHint: Create it or remove its MOUNT from %s",
+			mount.Source, source, project.RuntimeConfigPath)
+	}
+	if mount.Relabel == "" && !mount.NoRelabel {
+		mount.Relabel = autoRelabel(runtimeCfg, source)
+	}
+	return volumeSpec(source, mount), nil
+}
+
+// `--volume` value mounting `source` as described by `mount`.
+func volumeSpec(source string, mount config.Mount) string {
+	mode := ""
+	if mount.ReadOnly {
+		mode = "ro"
+	}
+	return bindVolume(source, mount.Target, mode, mount.Relabel)
+}
+
+// Environment variables given to the engine's `run` call: secrets by name
+// only, their values being taken from the engine CLI's own environment (see
+// `withSecrets`), then the additional variables.
+func (o RunOptions) runEnv() []string {
+	env := make([]string, 0, len(o.Secrets)+len(o.Env))
+	for _, secret := range o.Secrets {
+		name, _, _ := strings.Cut(secret, "=")
+		env = append(env, name)
+	}
+	return append(env, o.Env...)
+}
+
+// Give the secrets of `o` to the engine CLI run by `cmd` through its
+// environment.
+func (o RunOptions) withSecrets(cmd *exec.Cmd) {
+	if len(o.Secrets) == 0 {
+		return
+	}
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, o.Secrets...)
+}
+
+// Adapt the arguments of the `run` command of a project's default container
+// to the instance of `o`, and to keep the container once it exited if asked.
+func (o RunOptions) containerRunArgs(cmdArgs []string, project files.ProjectEntry) []string {
+	cmdArgs = instanceRunArgs(cmdArgs, project, o.Instance)
+	if !o.Keep {
+		return cmdArgs
+	}
+	// Only the options before the image, the command run may have a `--rm`
+	imageIndex := slices.Index(cmdArgs, projectImageName(project.ProjectName))
+	if i := slices.Index(cmdArgs, "--rm"); i >= 0 && i < imageIndex {
+		return slices.Delete(slices.Clone(cmdArgs), i, i+1)
+	}
+	return cmdArgs
+}
+
+// Arguments of the `exec` call running a command in the given container
+// without its entrypoint, shared by both engines.
+func execArgs(containerID string, args []string, options ExecOptions, tty bool) []string {
+	cmdArgs := []string{"exec", "--interactive"}
+	if tty && !options.NoTTY {
+		cmdArgs = append(cmdArgs, "--tty")
+	}
+	if options.User != "" {
+		cmdArgs = append(cmdArgs, "--user", options.User)
+	}
+	if options.WorkDir != "" {
+		cmdArgs = append(cmdArgs, "--workdir", options.WorkDir)
+	}
+	cmdArgs = append(cmdArgs, containerID)
+	return append(cmdArgs, args...)
+}
+
+// Command run by containers started in the background, keeping them alive
+// until they are stopped.
+var backgroundCommand = []string{"sleep", "infinity"}
+
+// Turn `run` arguments into ones starting the container in the background.
+func detachedRunArgs(runArgs []string) []string {
+	return append([]string{runArgs[0], "--detach"}, runArgs[1:]...)
+}
+
+func dockerRunArgs(
+	project files.ProjectEntry,
+	buildCfg config.BuildConfig,
+	runtimeCfg config.RuntimeConfig,
+	interactive bool,
+	env []string,
+	args []string,
+) ([]string, error) {
+	commonArgs, err := projectRunArgs(project, buildCfg, runtimeCfg)
+	if err != nil {
+		return nil, err
+	}
+	usernsArgs, err := dockerUsernsArgs(runtimeCfg.Userns)
+	if err != nil {
+		return nil, err
+	}
+	cmdArgs := append([]string{"run"}, usernsArgs...)
+	cmdArgs = append(cmdArgs, commonArgs...)
+	groupArgs, err := groupRunArgs(runtimeCfg.Groups, false)
+	if err != nil {
+		return nil, err
+	}
+	cmdArgs = append(cmdArgs, groupArgs...)
+	securityArgs, err := securityRunArgs(project, runtimeCfg, buildCfg.Args["USERNAME"], false)
+	if err != nil {
+		return nil, err
+	}
+	cmdArgs = append(cmdArgs, securityArgs...)
+	nestedArgs, err := nestedContainersRunArgs(buildCfg, runtimeCfg, false)
+	if err != nil {
+		return nil, err
+	}
+	cmdArgs = append(cmdArgs, nestedArgs...)
+	systemdArgs, err := systemdRunArgs(buildCfg, runtimeCfg, false)
+	if err != nil {
+		return nil, err
+	}
+	cmdArgs = append(cmdArgs, systemdArgs...)
+	for _, variable := range env {
+		cmdArgs = append(cmdArgs, "--env", variable)
+	}
+	if interactive {
+		cmdArgs = append(cmdArgs, "--tty", "--interactive")
+	}
+	cmdArgs = append(cmdArgs, projectImageName(project.ProjectName))
+	return append(cmdArgs, args...), nil
+}
+
+func podmanRunArgs(
+	project files.ProjectEntry,
+	buildCfg config.BuildConfig,
+	runtimeCfg config.RuntimeConfig,
+	userns string,
+	rootless bool,
+	interactive bool,
+	env []string,
+	args []string,
+) ([]string, error) {
+	commonArgs, err := projectRunArgs(project, buildCfg, runtimeCfg)
+	if err != nil {
+		return nil, err
+	}
+	cmdArgs := []string{"run"}
+	if userns != "" {
+		cmdArgs = append(cmdArgs, "--userns="+userns)
+	}
+	cmdArgs = append(cmdArgs, commonArgs...)
+	groupArgs, err := groupRunArgs(runtimeCfg.Groups, rootless)
+	if err != nil {
+		return nil, err
+	}
+	cmdArgs = append(cmdArgs, groupArgs...)
+	if err := checkPodmanSocketForwarding(runtimeCfg, userns, rootless); err != nil {
+		return nil, err
+	}
+	securityArgs, err := securityRunArgs(project, runtimeCfg, buildCfg.Args["USERNAME"], true)
+	if err != nil {
+		return nil, err
+	}
+	cmdArgs = append(cmdArgs, securityArgs...)
+	nestedArgs, err := nestedContainersRunArgs(buildCfg, runtimeCfg, true)
+	if err != nil {
+		return nil, err
+	}
+	cmdArgs = append(cmdArgs, nestedArgs...)
+	systemdArgs, err := systemdRunArgs(buildCfg, runtimeCfg, true)
+	if err != nil {
+		return nil, err
+	}
+	cmdArgs = append(cmdArgs, systemdArgs...)
+	for _, variable := range env {
+		cmdArgs = append(cmdArgs, "--env", variable)
+	}
+	if interactive {
+		cmdArgs = append(cmdArgs, "--tty", "--interactive")
+	}
+	cmdArgs = append(cmdArgs, projectImageName(project.ProjectName))
+	return append(cmdArgs, args...), nil
+}
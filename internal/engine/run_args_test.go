@@ -0,0 +1,511 @@
+package engine
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestRunArgs_ResourceLimits(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: "/tmp/demo/run.conf"}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}
+	runtimeCfg := config.RuntimeConfig{ProjectPath: "/code/demo", Cpus: "2", Memory: "4g", PidsLimit: "256"}
+
+	dockerArgs, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, []string{"ls"})
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	podmanArgs, err := podmanRunArgs(project, buildCfg, runtimeCfg, "keep-id", true, false, nil, []string{"ls"})
+	if err != nil {
+		t.Fatalf("podmanRunArgs() error = %v", err)
+	}
+
+	for name, args := range map[string][]string{"docker": dockerArgs, "podman": podmanArgs} {
+		for _, pair := range [][2]string{{"--cpus", "2"}, {"--memory", "4g"}, {"--pids-limit", "256"}} {
+			idx := slices.Index(args, pair[0])
+			if idx == -1 || idx+1 >= len(args) || args[idx+1] != pair[1] {
+				t.Fatalf("%s run args should include %s %s, got %v", name, pair[0], pair[1], args)
+			}
+		}
+		if args[len(args)-2] != "paulenv:demo" || args[len(args)-1] != "ls" {
+			t.Fatalf("%s run args should end with the image and command, got %v", name, args)
+		}
+	}
+	if podmanArgs[1] != "--userns=keep-id" {
+		t.Fatalf("podmanRunArgs() should start with --userns=keep-id, got %v", podmanArgs)
+	}
+}
+
+func TestRunArgs_NoResourceLimitsByDefault(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: "/tmp/demo/run.conf"}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}
+	runtimeCfg := config.RuntimeConfig{ProjectPath: "/code/demo"}
+
+	args, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, nil)
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	for _, flag := range []string{"--cpus", "--memory", "--pids-limit"} {
+		if slices.Contains(args, flag) {
+			t.Fatalf("dockerRunArgs() should not include %s, got %v", flag, args)
+		}
+	}
+}
+
+func TestRunArgs_SSHPort(t *testing.T) {
+	setSELinuxEnforcing(t, false)
+	project := files.ProjectEntry{
+		ProjectName:           "demo",
+		RuntimeConfigPath:     "/tmp/demo/run.conf",
+		SSHAuthorizedKeysPath: "/tmp/demo/.paul-env/ssh/authorized_keys",
+	}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}
+	runtimeCfg := config.RuntimeConfig{ProjectPath: "/code/demo", SSHPort: "2222"}
+
+	args, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, nil)
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	for _, pair := range [][2]string{
+		{"--publish", "127.0.0.1:2222:22"},
+		{"--volume", "/tmp/demo/.paul-env/ssh/authorized_keys:/etc/ssh/authorized_keys/dev:ro"},
+	} {
+		found := false
+		for i := 0; i+1 < len(args); i++ {
+			if args[i] == pair[0] && args[i+1] == pair[1] {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("dockerRunArgs() should include %s %s, got %v", pair[0], pair[1], args)
+		}
+	}
+}
+
+func TestDetachedRunArgs(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: "/tmp/demo/run.conf"}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}
+	runtimeCfg := config.RuntimeConfig{ProjectPath: "/code/demo"}
+
+	runArgs, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, backgroundCommand)
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	args := detachedRunArgs(runArgs)
+	if args[0] != "run" || args[1] != "--detach" {
+		t.Fatalf("detachedRunArgs() should start with run --detach, got %v", args)
+	}
+	if !slices.Equal(args[len(args)-3:], []string{"paulenv:demo", "sleep", "infinity"}) {
+		t.Fatalf("detachedRunArgs() should end with the image and background command, got %v", args)
+	}
+}
+
+func TestRunArgs_DotfilesProfile(t *testing.T) {
+	setSELinuxEnforcing(t, false)
+	profilesDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(profilesDir, "work"), 0755); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: "/tmp/demo/run.conf", DotfilesProfilesDir: profilesDir}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}
+	runtimeCfg := config.RuntimeConfig{ProjectPath: "/code/demo", DotfilesPath: "dotfiles", DotfilesProfile: "work"}
+
+	args, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, nil)
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	want := filepath.Join(profilesDir, "work") + ":/paul-env/dotfiles:ro"
+	if !slices.Contains(args, want) {
+		t.Fatalf("dockerRunArgs() should mount the profile's dotfiles as %s, got %v", want, args)
+	}
+
+	runtimeCfg.DotfilesProfile = "missing"
+	if _, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, nil); err == nil {
+		t.Fatalf("dockerRunArgs() should fail with a missing dotfiles profile")
+	}
+}
+
+func TestRunArgs_Env(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: "/tmp/demo/run.conf"}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}
+	runtimeCfg := config.RuntimeConfig{ProjectPath: "/code/demo", GitName: "Project Name"}
+
+	args, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, []string{"GIT_AUTHOR_NAME=Override", "DEBUG=1"}, []string{"ls"})
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	projectEnv := slices.Index(args, "GIT_AUTHOR_NAME=Project Name")
+	override := slices.Index(args, "GIT_AUTHOR_NAME=Override")
+	image := slices.Index(args, "paulenv:demo")
+	if projectEnv < 0 || override < projectEnv || image < override {
+		t.Fatalf("dockerRunArgs() should set the additional variables after the project's own, got %v", args)
+	}
+	if args[override-1] != "--env" || !slices.Contains(args, "DEBUG=1") {
+		t.Fatalf("dockerRunArgs() should pass the additional variables through --env, got %v", args)
+	}
+}
+
+func TestExecArgs(t *testing.T) {
+	got := execArgs("abc123", []string{"make", "test"}, ExecOptions{User: "dev", WorkDir: "/home/dev/projects/demo"}, true)
+	want := []string{"exec", "--interactive", "--tty", "--user", "dev", "--workdir", "/home/dev/projects/demo", "abc123", "make", "test"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("execArgs() = %q, want %q", got, want)
+	}
+
+	got = execArgs("abc123", []string{"ls"}, ExecOptions{NoTTY: true}, true)
+	want = []string{"exec", "--interactive", "abc123", "ls"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("execArgs() with NoTTY = %q, want %q", got, want)
+	}
+}
+
+func TestRunArgs_Secrets(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: "/tmp/demo/run.conf"}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}
+	runtimeCfg := config.RuntimeConfig{ProjectPath: "/code/demo"}
+	options := RunOptions{Env: []string{"DEBUG=1"}, Secrets: []string{"TOKEN=s3cr3t=x"}}
+
+	args, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, options.runEnv(), nil)
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	if slices.ContainsFunc(args, func(arg string) bool { return strings.Contains(arg, "s3cr3t") }) {
+		t.Fatalf("dockerRunArgs() should not contain secret values, got %v", args)
+	}
+	secret := slices.Index(args, "TOKEN")
+	if secret < 1 || args[secret-1] != "--env" || slices.Index(args, "DEBUG=1") < secret {
+		t.Fatalf("dockerRunArgs() should name secrets through --env before other variables, got %v", args)
+	}
+
+	cmd := exec.Command("docker")
+	options.withSecrets(cmd)
+	if !slices.Contains(cmd.Env, "TOKEN=s3cr3t=x") {
+		t.Fatalf("withSecrets() should set the secrets in the engine's environment")
+	}
+}
+
+func TestRunArgs_Mounts(t *testing.T) {
+	setSELinuxEnforcing(t, false)
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "shared"), 0755); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: filepath.Join(dir, "run.conf")}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}
+	runtimeCfg := config.RuntimeConfig{ProjectPath: "/code/demo", Mounts: []config.Mount{
+		{Source: "shared", Target: "/home/dev/shared", ReadOnly: true, Relabel: "Z"},
+		{Source: dir, Target: "/data"},
+	}}
+
+	args, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, nil)
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	for _, want := range []string{filepath.Join(dir, "shared") + ":/home/dev/shared:ro,Z", dir + ":/data"} {
+		if i := slices.Index(args, want); i < 1 || args[i-1] != "--volume" {
+			t.Fatalf("dockerRunArgs() should mount %s, got %v", want, args)
+		}
+	}
+
+	runtimeCfg.Mounts = append(runtimeCfg.Mounts, config.Mount{Source: "missing", Target: "/missing"})
+	if _, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, nil); err == nil {
+		t.Fatalf("dockerRunArgs() should fail with a missing host path")
+	}
+}
+
+func TestRunArgs_PassthroughEnv(t *testing.T) {
+	t.Setenv("PAULENV_TEST_REGION", "eu-west-3")
+	t.Setenv("PAULENV_TEST_PROFILE", "dev")
+	t.Setenv("PAULENV_OTHER", "1")
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: "/tmp/demo/run.conf"}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}
+	runtimeCfg := config.RuntimeConfig{ProjectPath: "/code/demo", PassthroughEnv: []string{"PAULENV_TEST_*"}}
+
+	args, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, nil)
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	for _, name := range []string{"PAULENV_TEST_PROFILE", "PAULENV_TEST_REGION"} {
+		idx := slices.Index(args, name)
+		if idx < 1 || args[idx-1] != "--env" {
+			t.Fatalf("dockerRunArgs() should pass %s by name through --env, got %v", name, args)
+		}
+	}
+	if slices.ContainsFunc(args, func(arg string) bool {
+		return strings.Contains(arg, "eu-west-3") || strings.Contains(arg, "PAULENV_OTHER")
+	}) {
+		t.Fatalf("dockerRunArgs() should only name the matching variables, got %v", args)
+	}
+}
+
+func TestRunArgs_NameResolution(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: "/tmp/demo/run.conf"}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}
+	runtimeCfg := config.RuntimeConfig{
+		ProjectPath: "/code/demo",
+		ExtraHosts:  []config.HostEntry{{Name: "git.corp.example", IP: "10.0.0.12"}},
+		DNSServers:  []string{"10.0.0.2", "10.0.0.3"},
+		DNSSearch:   []string{"corp.example"},
+	}
+
+	args, err := podmanRunArgs(project, buildCfg, runtimeCfg, "keep-id", true, false, nil, nil)
+	if err != nil {
+		t.Fatalf("podmanRunArgs() error = %v", err)
+	}
+	joined := strings.Join(args, " ")
+	for _, fragment := range []string{
+		"--add-host git.corp.example:10.0.0.12",
+		"--dns 10.0.0.2 --dns 10.0.0.3",
+		"--dns-search corp.example",
+	} {
+		if !strings.Contains(joined, fragment) {
+			t.Fatalf("podmanRunArgs() should include %q, got %v", fragment, args)
+		}
+	}
+}
+
+func TestRunArgs_PackageCaches(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: "/tmp/demo/run.conf"}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}
+	runtimeCfg := config.RuntimeConfig{ProjectPath: "/code/demo", PackageCaches: []string{"npm", "cargo"}}
+
+	args, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, nil)
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	for _, pair := range [][2]string{
+		{"--volume", "paulenv-cache-npm:/home/dev/.container-cache/.npm"},
+		{"--volume", "paulenv-cache-cargo:/home/dev/.cargo/registry"},
+		{"--env", "PAULENV_PACKAGE_CACHES=/home/dev/.container-cache/.npm /home/dev/.cargo/registry"},
+	} {
+		if i := slices.Index(args, pair[1]); i < 1 || args[i-1] != pair[0] {
+			t.Fatalf("dockerRunArgs() should include %s %s, got %v", pair[0], pair[1], args)
+		}
+	}
+	if !IsPackageCacheVolume("paulenv-cache-npm") || IsPackageCacheVolume("paulenv-cache-npm-local") {
+		t.Fatalf("IsPackageCacheVolume() should only match package cache volumes")
+	}
+}
+
+func TestRunArgs_SharedVolumes(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: "/tmp/demo/run.conf"}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}
+	runtimeCfg := config.RuntimeConfig{ProjectPath: "/code/demo", SharedVolumes: []config.SharedVolume{
+		{Name: "datasets", Target: "/data", ReadOnly: true},
+		{Name: "workspace", Target: "/home/dev/workspace"},
+	}}
+
+	args, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, nil)
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	for _, pair := range [][2]string{
+		{"--volume", "paulenv-shared.datasets:/data:ro"},
+		{"--volume", "paulenv-shared.workspace:/home/dev/workspace"},
+		{"--env", "PAULENV_SHARED_VOLUMES=/home/dev/workspace"},
+	} {
+		if i := slices.Index(args, pair[1]); i < 1 || args[i-1] != pair[0] {
+			t.Fatalf("dockerRunArgs() should include %s %s, got %v", pair[0], pair[1], args)
+		}
+	}
+	if name, ok := SharedVolumeFromName("paulenv-shared.datasets"); !ok || name != "datasets" || !isPaulEnvVolume("paulenv-shared.datasets") {
+		t.Fatalf("SharedVolumeFromName() should recognize shared volumes")
+	}
+	if _, ok := SharedVolumeFromName("paulenv-shared-cache"); ok {
+		t.Fatalf("SharedVolumeFromName() should not match the shared cache")
+	}
+}
+
+func TestRunArgs_Tmpfs(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: "/tmp/demo/run.conf"}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}
+	runtimeCfg := config.RuntimeConfig{ProjectPath: "/code/demo", Hardened: true, Tmpfs: []config.TmpfsMount{
+		{Target: "/tmp", Size: "1g"},
+		{Target: "/run/secrets", Size: "16m", Mode: "0700"},
+	}}
+
+	args, err := podmanRunArgs(project, buildCfg, runtimeCfg, "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("podmanRunArgs() error = %v", err)
+	}
+	for _, pair := range [][2]string{
+		{"--tmpfs", "/tmp:size=1g"},
+		{"--tmpfs", "/run/secrets:size=16m,mode=0700"},
+		{"--tmpfs", "/var/tmp"},
+		{"--env", "PAULENV_TMPFS=/tmp /run/secrets"},
+	} {
+		if i := slices.Index(args, pair[1]); i < 1 || args[i-1] != pair[0] {
+			t.Fatalf("podmanRunArgs() should include %s %s, got %v", pair[0], pair[1], args)
+		}
+	}
+	if slices.Contains(args, "/tmp") {
+		t.Fatalf("podmanRunArgs() should not mount the hardened profile's /tmp over the project's one, got %v", args)
+	}
+}
+
+func TestRunArgs_CompilerCache(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: "/tmp/demo/run.conf"}
+	runtimeCfg := config.RuntimeConfig{ProjectPath: "/code/demo"}
+
+	args, err := dockerRunArgs(project, config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}, runtimeCfg, false, nil, nil)
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	if slices.Contains(args, "RUSTC_WRAPPER=sccache") {
+		t.Fatalf("dockerRunArgs() should not set up a compiler cache by default, got %v", args)
+	}
+
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev", "ENABLE_COMPILER_CACHE": "true"}}
+	args, err = dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, nil)
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	for _, pair := range [][2]string{
+		{"--volume", "paulenv-compiler-cache:/home/dev/.compiler-cache"},
+		{"--env", "CCACHE_DIR=/home/dev/.compiler-cache/ccache"},
+		{"--env", "SCCACHE_DIR=/home/dev/.compiler-cache/sccache"},
+		{"--env", "RUSTC_WRAPPER=sccache"},
+	} {
+		if i := slices.Index(args, pair[1]); i < 1 || args[i-1] != pair[0] {
+			t.Fatalf("dockerRunArgs() should include %s %s, got %v", pair[0], pair[1], args)
+		}
+	}
+	if !IsSharedCacheVolume(CompilerCacheVolumeName) {
+		t.Fatalf("IsSharedCacheVolume() should match the compiler cache volume")
+	}
+}
+
+func TestRunArgs_NestedContainers(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: "/tmp/demo/run.conf"}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev", "ENABLE_NESTED_CONTAINERS": "true"}}
+	runtimeCfg := config.RuntimeConfig{ProjectPath: "/code/demo"}
+
+	args, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, nil)
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	for _, pair := range [][2]string{
+		{"--device", "/dev/fuse"},
+		{"--security-opt", "seccomp=unconfined"},
+		{"--security-opt", "apparmor=unconfined"},
+		{"--security-opt", "systempaths=unconfined"},
+	} {
+		if i := slices.Index(args, pair[1]); i < 1 || args[i-1] != pair[0] {
+			t.Fatalf("dockerRunArgs() should include %s %s, got %v", pair[0], pair[1], args)
+		}
+	}
+
+	args, err = podmanRunArgs(project, buildCfg, runtimeCfg, "keep-id", true, false, nil, nil)
+	if err != nil {
+		t.Fatalf("podmanRunArgs() error = %v", err)
+	}
+	if !slices.Contains(args, "/dev/fuse") || slices.Contains(args, "seccomp=unconfined") {
+		t.Fatalf("podmanRunArgs() should give /dev/fuse and keep the seccomp profile, got %v", args)
+	}
+
+	runtimeCfg.Hardened = true
+	if _, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, nil); err == nil {
+		t.Fatal("dockerRunArgs() should refuse nested containers in a hardened container")
+	}
+}
+
+func TestRunArgs_Systemd(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: "/tmp/demo/run.conf"}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev", "ENABLE_SYSTEMD": "true"}}
+	runtimeCfg := config.RuntimeConfig{ProjectPath: "/code/demo"}
+
+	args, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, nil)
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	if slices.Contains(args, "--init") {
+		t.Fatalf("dockerRunArgs() should let systemd be the PID 1, got %v", args)
+	}
+	for _, pair := range [][2]string{
+		{"--env", "PAULENV_SYSTEMD=1"},
+		{"--tmpfs", "/run"},
+		{"--volume", "/sys/fs/cgroup:/sys/fs/cgroup:rw"},
+		{"--stop-signal", "SIGRTMIN+3"},
+	} {
+		if i := slices.Index(args, pair[1]); i < 1 || args[i-1] != pair[0] {
+			t.Fatalf("dockerRunArgs() should include %s %s, got %v", pair[0], pair[1], args)
+		}
+	}
+
+	args, err = podmanRunArgs(project, buildCfg, runtimeCfg, "keep-id", true, false, nil, nil)
+	if err != nil {
+		t.Fatalf("podmanRunArgs() error = %v", err)
+	}
+	if !slices.Contains(args, "--systemd=always") || slices.Contains(args, "--init") {
+		t.Fatalf("podmanRunArgs() should run systemd as the PID 1, got %v", args)
+	}
+
+	runtimeCfg.Hardened = true
+	if _, err := podmanRunArgs(project, buildCfg, runtimeCfg, "keep-id", true, false, nil, nil); err == nil {
+		t.Fatal("podmanRunArgs() should refuse systemd in a hardened container")
+	}
+}
+
+func TestRunArgs_Instance(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: "/tmp/demo/run.conf"}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}
+	runtimeCfg := config.RuntimeConfig{
+		ProjectPath: "/code/demo",
+		Ports:       []string{"8080:80"},
+		Services:    []config.Service{{Name: "db", Image: "postgres:16"}},
+	}
+	args, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, []string{"make", "--name", "x"})
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	if got := instanceRunArgs(args, project, Instance{}); !slices.Equal(got, args) {
+		t.Fatalf("instanceRunArgs() should not change the default instance's, got %v", got)
+	}
+
+	got := instanceRunArgs(args, project, Instance{Name: "tests"})
+	for _, pair := range [][2]string{
+		{"--name", "paulenv-demo..tests"},
+		{"--label", "paulenv.instance=tests"},
+		{"--label", "paulenv.project=demo"},
+		{"--env", "PAULENV_INSTANCE=tests"},
+		{"--volume", "paulenv-demo-local:/home/dev/.container-local"},
+	} {
+		if i := slices.Index(got, pair[1]); i < 1 || got[i-1] != pair[0] {
+			t.Fatalf("instanceRunArgs() should include %s %s, got %v", pair[0], pair[1], got)
+		}
+	}
+	if slices.Contains(got, "--network-alias") || slices.Contains(got, "--publish") {
+		t.Fatalf("instanceRunArgs() should neither take the network alias nor publish ports, got %v", got)
+	}
+	if !slices.Equal(got[len(got)-3:], []string{"make", "--name", "x"}) {
+		t.Fatalf("instanceRunArgs() should leave the command alone, got %v", got)
+	}
+
+	got = instanceRunArgs(args, project, Instance{Name: "tests", SeparateVolume: true})
+	if !slices.Contains(got, "paulenv-demo..tests-local:/home/dev/.container-local") {
+		t.Fatalf("instanceRunArgs() should mount the instance's own volume, got %v", got)
+	}
+}
+
+func TestRunArgs_Keep(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: "/tmp/demo/run.conf"}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}
+	runtimeCfg := config.RuntimeConfig{ProjectPath: "/code/demo"}
+	args, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, []string{"docker", "run", "--rm", "x"})
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	if got := (RunOptions{}).containerRunArgs(args, project); !slices.Equal(got, args) {
+		t.Fatalf("containerRunArgs() should not change them by default, got %v", got)
+	}
+	got := RunOptions{Keep: true}.containerRunArgs(args, project)
+	if len(got) != len(args)-1 || slices.Index(got, "--rm") != len(got)-2 {
+		t.Fatalf("containerRunArgs() should only remove --rm before the image, got %v", got)
+	}
+}
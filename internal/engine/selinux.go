@@ -0,0 +1,72 @@
+// # selinux.go
+// On SELinux-enforcing hosts (e.g. Fedora), files bind-mounted in a container
+// keep their host label, which container processes are denied access to
+// (EACCES) unless the engine relabels them through the `z` or `Z` volume
+// options.
+//
+// Bind mounts generated by paul-envs are thus relabeled with `z`, letting
+// other containers (e.g. from another engine) use them too, unless disabled
+// with `SELINUX_RELABEL false` in run.conf. `MOUNT` directives can also set
+// their own option, or `nolabel` to keep the host label.
+
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+// File telling if SELinux is enforcing, when it is enabled.
+var selinuxEnforcePath = "/sys/fs/selinux/enforce"
+
+// Returns `true` if this host enforces SELinux policies.
+//
+// Isolated for tests
+var isSELinuxEnforcing = func() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	data, err := os.ReadFile(selinuxEnforcePath)
+	return err == nil && strings.TrimSpace(string(data)) == "1"
+}
+
+// Volume option relabeling a bind mount of `source` generated from the
+// project's configuration, empty if it should not be.
+//
+// Directories shared by the whole system (e.g. `/`, `/home` or `/usr`) and the
+// user's home directory are never relabeled: it would break other programs
+// using them, which is also why engines refuse it for some of them.
+func autoRelabel(runtimeCfg config.RuntimeConfig, source string) string {
+	if runtimeCfg.NoSELinuxRelabel || !isSELinuxEnforcing() {
+		return ""
+	}
+	cleaned := filepath.Clean(source)
+	if filepath.Dir(cleaned) == filepath.Dir(filepath.Dir(cleaned)) {
+		// The root or one of its direct children
+		return ""
+	}
+	if home, err := os.UserHomeDir(); err == nil && cleaned == filepath.Clean(home) {
+		return ""
+	}
+	return "z"
+}
+
+// `--volume` value bind-mounting the host's `source` to `target` with the
+// given options, empty ones being ignored.
+func bindVolume(source string, target string, options ...string) string {
+	spec := hostPath(source) + ":" + target
+	var nonEmpty []string
+	for _, option := range options {
+		if option != "" {
+			nonEmpty = append(nonEmpty, option)
+		}
+	}
+	if len(nonEmpty) > 0 {
+		spec += ":" + strings.Join(nonEmpty, ",")
+	}
+	return spec
+}
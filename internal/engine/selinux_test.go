@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Make the host SELinux-enforcing or not for the duration of the test.
+func setSELinuxEnforcing(t *testing.T, enforcing bool) {
+	t.Helper()
+	prev := isSELinuxEnforcing
+	t.Cleanup(func() { isSELinuxEnforcing = prev })
+	isSELinuxEnforcing = func() bool { return enforcing }
+}
+
+func TestAutoRelabel(t *testing.T) {
+	setSELinuxEnforcing(t, true)
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	for source, want := range map[string]string{
+		"/code/demo":                    "z",
+		filepath.Join(home, "projects"): "z",
+		home:                            "",
+		"/home":                         "",
+		"/":                             "",
+	} {
+		if got := autoRelabel(config.RuntimeConfig{}, source); got != want {
+			t.Errorf("autoRelabel(%q) = %q, want %q", source, got, want)
+		}
+	}
+	if got := autoRelabel(config.RuntimeConfig{NoSELinuxRelabel: true}, "/code/demo"); got != "" {
+		t.Errorf("autoRelabel() with SELINUX_RELABEL false = %q, want none", got)
+	}
+
+	setSELinuxEnforcing(t, false)
+	if got := autoRelabel(config.RuntimeConfig{}, "/code/demo"); got != "" {
+		t.Errorf("autoRelabel() without SELinux = %q, want none", got)
+	}
+}
+
+func TestIsSELinuxEnforcing(t *testing.T) {
+	prev := selinuxEnforcePath
+	t.Cleanup(func() { selinuxEnforcePath = prev })
+	selinuxEnforcePath = filepath.Join(t.TempDir(), "enforce")
+	if isSELinuxEnforcing() {
+		t.Fatal("isSELinuxEnforcing() = true without SELinux")
+	}
+	if err := os.WriteFile(selinuxEnforcePath, []byte("1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := isSELinuxEnforcing(); got != (runtime.GOOS == "linux") {
+		t.Fatalf("isSELinuxEnforcing() = %v when enforcing", got)
+	}
+}
+
+func TestRunArgs_SELinuxRelabel(t *testing.T) {
+	setSELinuxEnforcing(t, true)
+	dir := t.TempDir()
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: filepath.Join(dir, "run.conf")}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}
+	runtimeCfg := config.RuntimeConfig{ProjectPath: "/code/demo", Mounts: []config.Mount{
+		{Source: dir, Target: "/auto"},
+		{Source: dir, Target: "/private", Relabel: "Z"},
+		{Source: dir, Target: "/kept", NoRelabel: true, ReadOnly: true},
+	}}
+
+	args, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, nil)
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	for _, want := range []string{
+		"/code/demo:/home/dev/projects/demo:z",
+		dir + ":/auto:z",
+		dir + ":/private:Z",
+		dir + ":/kept:ro",
+	} {
+		if i := slices.Index(args, want); i < 1 || args[i-1] != "--volume" {
+			t.Fatalf("dockerRunArgs() should mount %s, got %v", want, args)
+		}
+	}
+
+	runtimeCfg.NoSELinuxRelabel = true
+	args, err = dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, nil)
+	if err != nil || !slices.Contains(args, "/code/demo:/home/dev/projects/demo") || !slices.Contains(args, dir+":/auto") {
+		t.Fatalf("dockerRunArgs() with SELINUX_RELABEL false = %v, %v, want no relabeling", args, err)
+	}
+}
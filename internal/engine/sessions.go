@@ -0,0 +1,76 @@
+// # sessions.go
+// Persistent sessions (`PERSISTENT_SESSION` run directive): the container's
+// shell runs in a tmux session, so what was started in it goes on once the
+// terminal attached to it is closed, and later `run` calls attach to that
+// same session instead of starting new shells.
+//
+// The container is then started in the background, kept alive by its own
+// command (or systemd), and shells attach to the session through `exec`. It
+// is only stopped once that session ended, i.e. once its shell exited, not
+// when it was merely detached from.
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Name of the tmux session of containers with persistent sessions.
+const sessionName = "paulenv"
+
+// Returns `true` if running `args` in the project's container attaches to its
+// persistent session: only interactive shells do, commands are run as usual.
+func usesPersistentSession(runtimeCfg config.RuntimeConfig, args []string, interactive bool) bool {
+	return runtimeCfg.PersistentSession && len(args) == 0 && interactive
+}
+
+// Arguments of the `run` command telling the entrypoint to start shells in
+// the persistent session.
+func sessionRunArgs(runtimeCfg config.RuntimeConfig) []string {
+	if !runtimeCfg.PersistentSession {
+		return nil
+	}
+	return []string{"--env", "PAULENV_SESSION=" + sessionName}
+}
+
+// Returns `true` if the persistent session of that container still runs.
+// It is considered running if that cannot be checked, e.g. if the engine
+// cannot be reached, so work is never stopped by mistake.
+func isSessionRunning(ctx context.Context, c ContainerEngine, container ContainerInfo, username string) bool {
+	err := c.ExecContainer(ctx, container, []string{"tmux", "has-session", "-t", sessionName}, ExecOptions{
+		User:   username,
+		NoTTY:  true,
+		Stdin:  strings.NewReader(""),
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+	})
+	var exitErr *exec.ExitError
+	return err == nil || !errors.As(err, &exitErr)
+}
+
+// Stop the container of a project with persistent sessions once its session
+// ended. Returns `false` if it still runs, as it was only detached from.
+func StopEndedSession(ctx context.Context, c ContainerEngine, project files.ProjectEntry, container ContainerInfo) (bool, error) {
+	buildCfg, err := loadBuildConfig(project)
+	if err != nil {
+		return false, err
+	}
+	if isSessionRunning(ctx, c, container, buildCfg.Args["USERNAME"]) {
+		return false, nil
+	}
+	return true, c.StopContainer(ctx, container)
+}
+
+// Returns `true` if running or joining that project's container with `args`
+// from this terminal attaches to its persistent session.
+func AttachesToSession(project files.ProjectEntry, args []string) bool {
+	runtimeCfg, err := loadRuntimeConfig(project)
+	return err == nil && usesPersistentSession(runtimeCfg, args, isTerminalInput(nil))
+}
@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"slices"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestUsesPersistentSession(t *testing.T) {
+	runtimeCfg := config.RuntimeConfig{PersistentSession: true}
+	if !usesPersistentSession(runtimeCfg, nil, true) {
+		t.Fatal("interactive shells should attach to the session")
+	}
+	if usesPersistentSession(runtimeCfg, []string{"make"}, true) {
+		t.Fatal("commands should not run in the session")
+	}
+	if usesPersistentSession(runtimeCfg, nil, false) {
+		t.Fatal("non-interactive shells should not run in the session")
+	}
+	if usesPersistentSession(config.RuntimeConfig{}, nil, true) {
+		t.Fatal("shells should not run in a session without PERSISTENT_SESSION")
+	}
+}
+
+func TestRunArgs_PersistentSession(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: "/tmp/demo/run.conf"}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}
+	runtimeCfg := config.RuntimeConfig{ProjectPath: "/code/demo", PersistentSession: true}
+	args, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, nil)
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	if i := slices.Index(args, "PAULENV_SESSION=paulenv"); i < 1 || args[i-1] != "--env" {
+		t.Fatalf("dockerRunArgs() should set PAULENV_SESSION, got %v", args)
+	}
+}
+
+func TestIsSessionRunning(t *testing.T) {
+	container := ContainerInfo{ContainerId: "c1"}
+	fake := &FakeEngine{}
+	if !isSessionRunning(context.Background(), fake, container, "dev") {
+		t.Fatal("isSessionRunning() = false, want true when tmux finds the session")
+	}
+	calls := fake.CallsTo("ExecContainer")
+	if len(calls) != 1 || !slices.Equal(calls[0].Args[1].([]string), []string{"tmux", "has-session", "-t", "paulenv"}) ||
+		calls[0].Args[2].(ExecOptions).User != "dev" {
+		t.Fatalf("isSessionRunning() should ask tmux as the container user, got %+v", calls)
+	}
+
+	fake.Errors = map[string]error{"ExecContainer": exec.Command("sh", "-c", "exit 1").Run()}
+	if isSessionRunning(context.Background(), fake, container, "dev") {
+		t.Fatal("isSessionRunning() = true, want false once tmux has no session")
+	}
+
+	// e.g. the engine CLI could not be run
+	fake.Errors = map[string]error{"ExecContainer": errors.New("unreachable")}
+	if !isSessionRunning(context.Background(), fake, container, "dev") {
+		t.Fatal("isSessionRunning() = false, want true when it cannot be checked")
+	}
+}
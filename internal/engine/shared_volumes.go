@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+const sharedVolumePrefix = "paulenv-shared."
+
+// Name of the engine volume behind the given `SHARED_VOLUME`.
+func SharedVolumeName(name string) string {
+	return sharedVolumePrefix + name
+}
+
+// Returns the `SHARED_VOLUME` name behind that engine volume, if it is one.
+func SharedVolumeFromName(volumeName string) (string, bool) {
+	name, ok := strings.CutPrefix(volumeName, sharedVolumePrefix)
+	return name, ok && name != ""
+}
+
+// Value of the `PAULENV_SHARED_VOLUMES` variable telling the entrypoint where
+// the writable shared volumes are mounted, as engines create a new volume
+// owned by root.
+func sharedVolumesEnvValue(volumes []config.SharedVolume) string {
+	var targets []string
+	for _, volume := range volumes {
+		if !volume.ReadOnly {
+			targets = append(targets, volume.Target)
+		}
+	}
+	return strings.Join(targets, " ")
+}
+
+// Arguments for the `run` command mounting the given shared volumes.
+func sharedVolumeRunArgs(volumes []config.SharedVolume) []string {
+	if len(volumes) == 0 {
+		return nil
+	}
+	var args []string
+	for _, volume := range volumes {
+		spec := SharedVolumeName(volume.Name) + ":" + volume.Target
+		if volume.ReadOnly {
+			spec += ":ro"
+		}
+		args = append(args, "--volume", spec)
+	}
+	if targets := sharedVolumesEnvValue(volumes); targets != "" {
+		args = append(args, "--env", "PAULENV_SHARED_VOLUMES="+targets)
+	}
+	return args
+}
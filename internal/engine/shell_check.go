@@ -0,0 +1,98 @@
+// # shell_check.go
+// Dotfiles are applied when a container starts, so a broken rc file is
+// otherwise only noticed when first running or joining the project. A built
+// image can be checked by starting its shell interactively in a throwaway
+// container with the project's dotfiles, which loads all its rc files.
+
+package engine
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Exit code of `docker run` and `podman run` when they failed to run the
+// container at all.
+const engineRunFailureCode = 125
+
+// How the shell of a project started in a fresh container.
+type ShellCheck struct {
+	ExitCode int
+	// What the shell and its rc files wrote to stderr, without the notices of
+	// an interactive shell running without terminal
+	Errors string
+}
+
+// Returns `true` if the shell started cleanly.
+func (s ShellCheck) Passed() bool {
+	return s.ExitCode == 0 && s.Errors == ""
+}
+
+// Arguments of the `run` command checking the shell of that project: not
+// named nor publishing ports, so it does not conflict with a running
+// container of the project, and without its startup scripts whose side
+// effects a check should not have. Its activation scripts are kept, as its
+// shell sources them.
+func shellCheckRunArgs(project files.ProjectEntry, runtimeCfg config.RuntimeConfig, imageName string) ([]string, error) {
+	cmdArgs := []string{"run", "--rm", "--init"}
+	dotfilesPath, err := projectDotfilesPath(project, runtimeCfg)
+	if err != nil {
+		return nil, err
+	}
+	if dotfilesPath != "" {
+		cmdArgs = append(cmdArgs, "--volume", bindVolume(dotfilesPath, "/paul-env/dotfiles", "ro", autoRelabel(runtimeCfg, dotfilesPath)))
+	}
+	activationArgs, err := activationRunArgs(project, runtimeCfg)
+	if err != nil {
+		return nil, err
+	}
+	cmdArgs = append(cmdArgs, activationArgs...)
+	if runtimeCfg.GitName != "" {
+		cmdArgs = append(cmdArgs, "--env", "GIT_AUTHOR_NAME="+runtimeCfg.GitName)
+	}
+	if runtimeCfg.GitEmail != "" {
+		cmdArgs = append(cmdArgs, "--env", "GIT_AUTHOR_EMAIL="+runtimeCfg.GitEmail)
+	}
+	// The entrypoint runs commands through the user's shell, which sets
+	// `$SHELL` to it
+	return append(cmdArgs, imageName, "sh", "-c", `exec "$SHELL" -i -c exit`), nil
+}
+
+// Interpret how the `run` command checking a shell ended, with what it wrote
+// to stderr. `ran` is `false` if the container could not be run at all.
+func shellCheckResult(runErr error, stderr string) (check ShellCheck, ran bool) {
+	check = ShellCheck{Errors: shellCheckErrors(stderr)}
+	if runErr == nil {
+		return check, true
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(runErr, &exitErr) || exitErr.ExitCode() == engineRunFailureCode {
+		return ShellCheck{}, false
+	}
+	check.ExitCode = exitErr.ExitCode()
+	return check, true
+}
+
+// Parse what the shell check wrote to stderr into its errors.
+func shellCheckErrors(stderr string) string {
+	var lines []string
+	for line := range strings.SplitSeq(stderr, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || isShellCheckNoise(trimmed) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Returns `true` for the notices of interactive shells having no terminal.
+func isShellCheckNoise(line string) bool {
+	return strings.Contains(line, "cannot set terminal process group") ||
+		strings.Contains(line, "no job control in this shell") ||
+		strings.Contains(line, "No TTY for interactive shell")
+}
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"errors"
+	"os/exec"
+	"slices"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestShellCheckErrors(t *testing.T) {
+	stderr := "bash: cannot set terminal process group (-1): Inappropriate ioctl for device\n" +
+		"bash: no job control in this shell\n\n" +
+		"/home/dev/.bashrc: line 3: syntax error near unexpected token `fi'\n"
+	got := shellCheckErrors(stderr)
+	if got != "/home/dev/.bashrc: line 3: syntax error near unexpected token `fi'" {
+		t.Fatalf("shellCheckErrors() = %q", got)
+	}
+	if got := shellCheckErrors("bash: no job control in this shell\n"); got != "" {
+		t.Fatalf("shellCheckErrors() = %q, want no error", got)
+	}
+}
+
+func TestShellCheckResult(t *testing.T) {
+	check, ran := shellCheckResult(nil, "")
+	if !ran || !check.Passed() {
+		t.Fatalf("shellCheckResult(nil) = %+v, %v, want a passed check", check, ran)
+	}
+	check, ran = shellCheckResult(nil, "zsh: command not found: foo\n")
+	if !ran || check.Passed() {
+		t.Fatalf("shellCheckResult() = %+v, %v, want a failed check", check, ran)
+	}
+	if _, ran := shellCheckResult(errors.New("not found"), ""); ran {
+		t.Fatal("expected a failure to run the engine to be reported")
+	}
+	exitErr := exec.Command("sh", "-c", "exit 2").Run()
+	check, ran = shellCheckResult(exitErr, "")
+	if !ran || check.ExitCode != 2 || check.Passed() {
+		t.Fatalf("shellCheckResult(exit 2) = %+v, %v", check, ran)
+	}
+	if _, ran := shellCheckResult(exec.Command("sh", "-c", "exit 125").Run(), ""); ran {
+		t.Fatal("expected exit code 125 to be reported as a failure to run")
+	}
+}
+
+func TestShellCheckRunArgs(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "app"}
+	args, err := shellCheckRunArgs(project, config.RuntimeConfig{GitName: "Me", Ports: []string{"8080:8080"}}, "paulenv:app")
+	if err != nil {
+		t.Fatalf("shellCheckRunArgs() error: %v", err)
+	}
+	if slices.Contains(args, "--name") || slices.Contains(args, "--publish") {
+		t.Fatalf("shellCheckRunArgs() = %v, want neither a name nor published ports", args)
+	}
+	if !slices.Contains(args, "GIT_AUTHOR_NAME=Me") || !slices.Contains(args, "paulenv:app") {
+		t.Fatalf("shellCheckRunArgs() = %v", args)
+	}
+}
@@ -0,0 +1,193 @@
+// # sidecars.go
+// Sidecar services of a project (databases, caches...), declared with
+// `SERVICE` in its run.conf.
+//
+// Each one runs in its own `<project container>.<service>` container, on a
+// network shared with the project's container where it is reachable through
+// its service name. As project names cannot contain dots, those containers are
+// never mistaken for projects' ones.
+//
+// Projects setting `SHARED_NETWORK` all use the `paulenv-shared` network
+// instead of their own, so their containers and sidecars reach each other.
+
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+// Information on a running sidecar container
+type SidecarInfo struct {
+	// The name of the paulenv project it belongs to
+	ProjectName string
+	// The name of its service in that project's run.conf
+	ServiceName string
+	// The name it is actually refered to by the container engine.
+	ContainerName string
+	// Its full (non-truncated) Id with which it can be refered to
+	ContainerId string
+	// `true` if that container is currently running
+	Running bool
+}
+
+// Label set on sidecar containers to the name of their service.
+const serviceLabel = "paulenv.service"
+
+func sidecarContainerName(projectName string, serviceName string) string {
+	return projectContainerName(projectName) + "." + serviceName
+}
+
+// Name of the volume persisting the data of a sidecar, whose suffix lets
+// `gc` attribute it to its project like its local volume.
+func sidecarDataVolumeName(projectName string, serviceName string) string {
+	return fmt.Sprintf("paulenv-%s.%s-local", projectName, serviceName)
+}
+
+// Network joined by the projects setting `SHARED_NETWORK`, which belongs to
+// none of them.
+const sharedNetworkName = "paulenv-shared"
+
+// Network shared by a project's container and its sidecars.
+func projectNetworkName(projectName string) string {
+	return projectContainerName(projectName)
+}
+
+// Network joined by a project's sidecars: the one shared between projects if
+// `shared` is set, otherwise the project's own.
+func sidecarNetworkName(projectName string, shared bool) string {
+	if shared {
+		return sharedNetworkName
+	}
+	return projectNetworkName(projectName)
+}
+
+// Network joined by a project's container, empty if it needs none as it has
+// no sidecar and does not use the shared network.
+func runNetworkName(projectName string, runtimeCfg config.RuntimeConfig) string {
+	if !runtimeCfg.SharedNetwork && len(runtimeCfg.Services) == 0 {
+		return ""
+	}
+	return sidecarNetworkName(projectName, runtimeCfg.SharedNetwork)
+}
+
+// Parse the output of a network listing whose lines are formatted as
+// "{{.ID}}\t{{.Name}}\t<value of the managed label>\t<value of the project
+// label>" into the paul-envs networks it lists.
+func parseNetworkList(output string) []NetworkInfo {
+	var networks []NetworkInfo
+	for line := range strings.SplitSeq(strings.TrimSpace(output), "\n") {
+		parts := strings.Split(line, "\t")
+		if len(parts) < 2 {
+			continue
+		}
+		id, name := parts[0], parts[1]
+		managed, projectName := "", ""
+		if len(parts) > 2 {
+			managed = parts[2]
+		}
+		if len(parts) > 3 && parts[3] != "<no value>" {
+			projectName = parts[3]
+		}
+		if !isManagedResource(name, managed, isLegacyNetworkName) {
+			continue
+		}
+		network := NetworkInfo{NetworkId: id, NetworkName: name}
+		if projectName != "" {
+			network.ProjectName = &projectName
+		} else if name != sharedNetworkName {
+			network.ProjectName = projectNameFromContainerName(name)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// Returns the project and service names of the given sidecar container name,
+// for sidecars started before they were labelled with their service.
+func parseSidecarContainerName(containerName string) (string, string, bool) {
+	name, ok := strings.CutPrefix(containerName, "paulenv-")
+	if !ok {
+		return "", "", false
+	}
+	projectName, serviceName, ok := strings.Cut(name, ".")
+	// Instances of the project's container are separated by two dots
+	if !ok || projectName == "" || serviceName == "" || strings.HasPrefix(serviceName, ".") {
+		return "", "", false
+	}
+	return projectName, serviceName, true
+}
+
+// Parse the output of a container listing whose lines are formatted as
+// "{{.ID}}\t{{.Names}}\t{{.State}}\t<value of the project label>\t<value of
+// the service label>" into the sidecars it lists.
+func parseSidecarList(output string) []SidecarInfo {
+	var sidecars []SidecarInfo
+	for line := range strings.SplitSeq(strings.TrimSpace(output), "\n") {
+		parts := strings.Split(line, "\t")
+		if len(parts) < 2 {
+			continue
+		}
+		labelAt := func(i int) string {
+			if len(parts) <= i || parts[i] == "<no value>" {
+				return ""
+			}
+			return parts[i]
+		}
+		projectName, serviceName := labelAt(3), labelAt(4)
+		if projectName == "" || serviceName == "" {
+			var ok bool
+			projectName, serviceName, ok = parseSidecarContainerName(parts[1])
+			if !ok {
+				continue
+			}
+		}
+		sidecars = append(sidecars, SidecarInfo{
+			ProjectName:   projectName,
+			ServiceName:   serviceName,
+			ContainerName: parts[1],
+			ContainerId:   parts[0],
+			Running:       len(parts) > 2 && strings.EqualFold(parts[2], "running"),
+		})
+	}
+	return sidecars
+}
+
+// Arguments of the `exec` command running `args` in the given sidecar, or a
+// shell if empty. Sidecars run arbitrary images, so only `sh` can be relied
+// on.
+func sidecarExecArgs(sidecar SidecarInfo, interactive bool, args []string) []string {
+	cmdArgs := []string{"exec"}
+	if interactive {
+		cmdArgs = append(cmdArgs, "-it")
+	}
+	cmdArgs = append(cmdArgs, sidecar.ContainerId)
+	if len(args) == 0 {
+		return append(cmdArgs, "sh")
+	}
+	return append(cmdArgs, args...)
+}
+
+// Arguments of the `run` command starting the given sidecar of a project in
+// the background, on the given network.
+func sidecarRunArgs(projectName string, service config.Service, network string) []string {
+	args := []string{
+		"run",
+		"--detach",
+		"--rm",
+		"--name", sidecarContainerName(projectName, service.Name),
+		"--network", network,
+		"--network-alias", service.Name,
+	}
+	args = append(args, resourceLabelFlags(projectName, sourceService, map[string]string{serviceLabel: service.Name})...)
+	for _, env := range service.Env {
+		args = append(args, "--env", env)
+	}
+	if service.DataPath != "" {
+		args = append(args, "--volume", sidecarDataVolumeName(projectName, service.Name)+":"+service.DataPath)
+	}
+	args = append(args, offlinePullArgs()...)
+	return append(args, service.Image)
+}
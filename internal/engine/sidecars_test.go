@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+
+	versions "github.com/peaberberian/paul-envs/internal"
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestParseSidecarList(t *testing.T) {
+	output := "abc\tpaulenv-myapp.db\trunning\n" +
+		"def\tpaulenv-myapp\trunning\n" +
+		"def\tpaulenv-myapp..tests\trunning\n" +
+		"ghi\tpaulenv-other.cache\texited\n" +
+		"jkl\tunrelated\trunning\n" +
+		"mno\tdev-myapp.queue\trunning\tmyapp\tqueue\n" +
+		"pqr\tpaulenv-myapp..tests\trunning\tmyapp\t<no value>\n"
+	got := parseSidecarList(output)
+	want := []SidecarInfo{
+		{ProjectName: "myapp", ServiceName: "db", ContainerName: "paulenv-myapp.db", ContainerId: "abc", Running: true},
+		{ProjectName: "other", ServiceName: "cache", ContainerName: "paulenv-other.cache", ContainerId: "ghi", Running: false},
+		{ProjectName: "myapp", ServiceName: "queue", ContainerName: "dev-myapp.queue", ContainerId: "mno", Running: true},
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("parseSidecarList() = %+v, want %+v", got, want)
+	}
+	if projectNameFromContainerName("paulenv-myapp.db") != nil {
+		t.Fatal("sidecar containers should not be seen as project containers")
+	}
+}
+
+func TestSidecarRunArgs(t *testing.T) {
+	service := config.Service{
+		Name:     "db",
+		Image:    "postgres:16",
+		Env:      []string{"POSTGRES_PASSWORD=dev"},
+		DataPath: "/var/lib/postgresql/data",
+	}
+	got := sidecarRunArgs("myapp", service, "paulenv-myapp")
+	want := []string{
+		"run", "--detach", "--rm",
+		"--name", "paulenv-myapp.db",
+		"--network", "paulenv-myapp",
+		"--network-alias", "db",
+		"--label", "paulenv=true",
+		"--label", "paulenv.project=myapp",
+		"--label", "paulenv.service=db",
+		"--label", "paulenv.source=service",
+		"--label", "paulenv.version=" + versions.Version.ToString(),
+		"--env", "POSTGRES_PASSWORD=dev",
+		"--volume", "paulenv-myapp.db-local:/var/lib/postgresql/data",
+		"postgres:16",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("sidecarRunArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestRunArgs_ServicesNetwork(t *testing.T) {
+	project := files.ProjectEntry{ProjectName: "demo", RuntimeConfigPath: "/tmp/demo/run.conf"}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}
+	runtimeCfg := config.RuntimeConfig{ProjectPath: "/code/demo"}
+
+	args, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, nil)
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	if slices.Contains(args, "--network") {
+		t.Fatalf("dockerRunArgs() should not join a network without services, got %v", args)
+	}
+
+	runtimeCfg.Services = []config.Service{{Name: "db", Image: "postgres:16"}}
+	args, err = dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, nil)
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	idx := slices.Index(args, "--network")
+	if idx == -1 || args[idx+1] != "paulenv-demo" {
+		t.Fatalf("dockerRunArgs() should join the project network, got %v", args)
+	}
+	idx = slices.Index(args, "--network-alias")
+	if idx == -1 || args[idx+1] != "demo" {
+		t.Fatalf("dockerRunArgs() should be reachable through the project name, got %v", args)
+	}
+
+	runtimeCfg.MainService = "app"
+	args, err = dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, nil)
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	idx = slices.Index(args, "--network-alias")
+	if idx == -1 || args[idx+1] != "app" {
+		t.Fatalf("dockerRunArgs() should be reachable through MAIN_SERVICE, got %v", args)
+	}
+}
+
+func TestSidecarExecArgs(t *testing.T) {
+	sidecar := SidecarInfo{ProjectName: "demo", ServiceName: "db", ContainerId: "abc"}
+	if got := sidecarExecArgs(sidecar, true, nil); !slices.Equal(got, []string{"exec", "-it", "abc", "sh"}) {
+		t.Fatalf("sidecarExecArgs() = %v", got)
+	}
+	got := sidecarExecArgs(sidecar, false, []string{"psql", "-U", "postgres"})
+	if !slices.Equal(got, []string{"exec", "abc", "psql", "-U", "postgres"}) {
+		t.Fatalf("sidecarExecArgs() = %v", got)
+	}
+}
+
+func TestParseNetworkList(t *testing.T) {
+	output := "abc\tpaulenv-myapp\t<no value>\t<no value>\n" +
+		"def\tpaulenv-shared\n" +
+		"ghi\tbridge\t\t\n" +
+		"jkl\tother-paulenv-x\t\t\n" +
+		// Labelled, whatever its name
+		"mno\tpaulenv-renamed\ttrue\tweb\n" +
+		"pqr\tpaulenv-Not_A-Project\t\t\n"
+	got := parseNetworkList(output)
+	if len(got) != 3 {
+		t.Fatalf("parseNetworkList() = %+v, want 2 networks", got)
+	}
+	if got[0].NetworkId != "abc" || got[0].ProjectName == nil || *got[0].ProjectName != "myapp" {
+		t.Fatalf("parseNetworkList() project network = %+v", got[0])
+	}
+	if got[1].NetworkName != "paulenv-shared" || got[1].ProjectName != nil {
+		t.Fatalf("parseNetworkList() should not attribute the shared network to a project, got %+v", got[1])
+	}
+	if got[2].NetworkId != "mno" || got[2].ProjectName == nil || *got[2].ProjectName != "web" {
+		t.Fatalf("parseNetworkList() should attribute labelled networks to their project, got %+v", got[2])
+	}
+}
+
+func TestRunNetworkName(t *testing.T) {
+	services := []config.Service{{Name: "db", Image: "postgres:16"}}
+	for _, tc := range []struct {
+		runtimeCfg config.RuntimeConfig
+		want       string
+	}{
+		{config.RuntimeConfig{}, ""},
+		{config.RuntimeConfig{Services: services}, "paulenv-myapp"},
+		{config.RuntimeConfig{SharedNetwork: true}, "paulenv-shared"},
+		{config.RuntimeConfig{Services: services, SharedNetwork: true}, "paulenv-shared"},
+	} {
+		if got := runNetworkName("myapp", tc.runtimeCfg); got != tc.want {
+			t.Errorf("runNetworkName(%+v) = %q, want %q", tc.runtimeCfg, got, tc.want)
+		}
+	}
+}
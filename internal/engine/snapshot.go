@@ -0,0 +1,71 @@
+// # snapshot.go
+// Snapshots are images committed from a project's running container, so
+// changes made by hand inside it can be kept, run again or rolled back to.
+
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Information on a snapshot of a project's container.
+type SnapshotInfo struct {
+	// The name of the corresponding paulenv project
+	ProjectName string
+	// Tag given to that snapshot, unique per project
+	Tag string
+	// The name it is actually refered to by the container engine.
+	ImageName string
+	// The timestamp at which it has been taken, `nil` if unknown.
+	CreatedAt *time.Time
+	// Disk usage of that image as reported by the container engine, empty if
+	// unknown.
+	Size string
+}
+
+// Tags are part of an image tag, after the project's name
+var snapshotTagRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,63}$`)
+
+// Validate the tag of a snapshot given by the user.
+func ValidateSnapshotTag(tag string) error {
+	if !snapshotTagRegex.MatchString(tag) {
+		return fmt.Errorf("invalid snapshot tag %q: must be up to 64 letters, digits, '_', '.' or '-', starting with a letter or digit", tag)
+	}
+	return nil
+}
+
+// Name of the image of the given snapshot.
+func snapshotImageName(projectName string, tag string) string {
+	return projectTaggedImageName("paulenv-snapshot", projectName, tag)
+}
+
+// Returns the project and tag of the given snapshot image name, `ok` set to
+// `false` if it is not one.
+func parseSnapshotImageName(imageName string) (projectName string, tag string, ok bool) {
+	return parseProjectTaggedImage(imageName, "paulenv-snapshot")
+}
+
+// Parse the output of an `images` command listing
+// `{{.Repository}}:{{.Tag}}\t{{.CreatedAt}}\t{{.Size}}` into the snapshots
+// it contains.
+func parseSnapshotList(output string, quirks engineQuirks) []SnapshotInfo {
+	var result []SnapshotInfo
+	for _, image := range parseProjectTaggedImages(output, "paulenv-snapshot", quirks) {
+		result = append(result, SnapshotInfo{
+			ProjectName: image.projectName,
+			Tag:         image.tag,
+			ImageName:   image.imageName,
+			CreatedAt:   image.createdAt,
+			Size:        image.size,
+		})
+	}
+	return result
+}
+
+// Options of the `commit` command creating a snapshot.
+//
+// The container's command (e.g. `sleep infinity` for one started in the
+// background) would otherwise become the image's default one.
+var snapshotCommitChanges = []string{"--change", "CMD []"}
@@ -0,0 +1,79 @@
+// # ssh_agent.go
+// Forwarding of the host's ssh agent and git credential helpers to
+// containers, so `git push` and `ssh` work there without copying any private
+// key or token into them.
+
+package engine
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Where Docker Desktop exposes the host's ssh agent to containers on macOS,
+// whose own sockets cannot cross its virtual machine.
+const dockerDesktopSSHAgentSocket = "/run/host-services/ssh-auth.sock"
+
+// Where the directory of the socket serving the host's git credentials is
+// mounted in containers.
+const containerGitCredentialsDir = "/tmp/paulenv-git-credentials"
+
+// The socket of the host's ssh agent as the engine sees it, empty if none.
+//
+// `SSH_AUTH_SOCK` often is a link kept pointing to the current agent (e.g. by
+// terminal multiplexers' setups), which engines would mount as is: it is
+// resolved first.
+func detectHostSSHAgent() string {
+	if hostOS == "darwin" {
+		return dockerDesktopSSHAgentSocket
+	}
+	socket := getenv("SSH_AUTH_SOCK")
+	if socket == "" || !fileExists(socket) {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(socket); err == nil {
+		return resolved
+	}
+	return socket
+}
+
+// Arguments of the `run` command forwarding the given ssh agent socket.
+func sshAgentRunArgs(socket string) []string {
+	if socket == "" {
+		return nil
+	}
+	return []string{
+		"--volume", socket + ":" + containerRuntimeDir + "/ssh-agent.sock",
+		"--env", "SSH_AUTH_SOCK=" + containerRuntimeDir + "/ssh-agent.sock",
+	}
+}
+
+// Arguments of the `run` command giving the container the directory where
+// `run` serves the host's git credentials.
+func gitCredentialsRunArgs(project files.ProjectEntry) []string {
+	return []string{
+		"--volume", project.GitCredentialsDir + ":" + containerGitCredentialsDir,
+		"--env", "PAULENV_GIT_CREDENTIALS=" + containerGitCredentialsDir + "/" + files.GitCredentialsSocketName,
+	}
+}
+
+// Sockets forwarded to rootless Podman containers stay owned by the host
+// user, which is only the container user's if it is mapped to it. Without
+// mapping, it becomes root's in the container and the agent is unreachable.
+func checkPodmanSocketForwarding(runtimeCfg config.RuntimeConfig, userns string, rootless bool) error {
+	if !runtimeCfg.SSHAgent && !runtimeCfg.GitCredentials && !runtimeCfg.GPGAgent && !runtimeCfg.ServesHostRequests() {
+		return nil
+	}
+	if hostOS == "darwin" && runtimeCfg.SSHAgent {
+		return errors.New("SSH_AGENT is not supported by Podman machines, which cannot reach the macOS ssh agent")
+	}
+	if rootless && !strings.HasPrefix(userns, "keep-id") {
+		return errors.New("SSH_AGENT, GIT_CREDENTIALS, GPG_AGENT, HOST_COMMAND and CLIPBOARD need the container user to be mapped to yours with rootless Podman\n" +
+			"Hint: Remove the USERNS directive of the project's run.conf or set it to 'keep-id'")
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestDetectHostSSHAgent(t *testing.T) {
+	previousOS := hostOS
+	t.Cleanup(func() { hostOS = previousOS })
+
+	hostOS = "linux"
+	stubHost(t, map[string]string{"SSH_AUTH_SOCK": "/tmp/ssh-abc/agent.1"}, "/tmp/ssh-abc/agent.1")
+	if got := detectHostSSHAgent(); got != "/tmp/ssh-abc/agent.1" {
+		t.Fatalf("detectHostSSHAgent() = %q, want the SSH_AUTH_SOCK socket", got)
+	}
+	stubHost(t, map[string]string{"SSH_AUTH_SOCK": "/tmp/ssh-abc/agent.1"})
+	if got := detectHostSSHAgent(); got != "" {
+		t.Fatalf("detectHostSSHAgent() = %q, want none for a missing socket", got)
+	}
+
+	hostOS = "darwin"
+	if got := detectHostSSHAgent(); got != dockerDesktopSSHAgentSocket {
+		t.Fatalf("detectHostSSHAgent() = %q on macOS, want Docker Desktop's socket", got)
+	}
+}
+
+func TestSSHAgentRunArgs(t *testing.T) {
+	want := []string{
+		"--volume", "/tmp/ssh-abc/agent.1:/tmp/paulenv-runtime/ssh-agent.sock",
+		"--env", "SSH_AUTH_SOCK=/tmp/paulenv-runtime/ssh-agent.sock",
+	}
+	if got := sshAgentRunArgs("/tmp/ssh-abc/agent.1"); !slices.Equal(got, want) {
+		t.Fatalf("sshAgentRunArgs() = %v, want %v", got, want)
+	}
+	if got := sshAgentRunArgs(""); got != nil {
+		t.Fatalf("sshAgentRunArgs() without agent = %v, want none", got)
+	}
+}
+
+func TestRunArgs_GitCredentials(t *testing.T) {
+	project := files.ProjectEntry{
+		ProjectName:       "demo",
+		RuntimeConfigPath: "/tmp/demo/run.conf",
+		GitCredentialsDir: "/data/demo/.internal/git-credentials",
+	}
+	buildCfg := config.BuildConfig{Args: map[string]string{"USERNAME": "dev"}}
+	runtimeCfg := config.RuntimeConfig{ProjectPath: "/code/demo", GitCredentials: true}
+
+	args, err := dockerRunArgs(project, buildCfg, runtimeCfg, false, nil, nil)
+	if err != nil {
+		t.Fatalf("dockerRunArgs() error = %v", err)
+	}
+	for _, pair := range [][2]string{
+		{"--volume", "/data/demo/.internal/git-credentials:/tmp/paulenv-git-credentials"},
+		{"--env", "PAULENV_GIT_CREDENTIALS=/tmp/paulenv-git-credentials/socket"},
+		{"--security-opt", "label=disable"},
+	} {
+		if i := slices.Index(args, pair[1]); i < 1 || args[i-1] != pair[0] {
+			t.Fatalf("dockerRunArgs() should include %s %s, got %v", pair[0], pair[1], args)
+		}
+	}
+}
+
+func TestCheckPodmanSocketForwarding(t *testing.T) {
+	previousOS := hostOS
+	t.Cleanup(func() { hostOS = previousOS })
+	hostOS = "linux"
+
+	runtimeCfg := config.RuntimeConfig{SSHAgent: true}
+	if err := checkPodmanSocketForwarding(runtimeCfg, "keep-id", true); err != nil {
+		t.Fatalf("checkPodmanSocketForwarding() with keep-id = %v, want no error", err)
+	}
+	if err := checkPodmanSocketForwarding(runtimeCfg, "", false); err != nil {
+		t.Fatalf("checkPodmanSocketForwarding() rootful = %v, want no error", err)
+	}
+	if err := checkPodmanSocketForwarding(runtimeCfg, "", true); err == nil {
+		t.Fatal("checkPodmanSocketForwarding() should fail when rootless Podman maps the host user to root")
+	}
+	if err := checkPodmanSocketForwarding(config.RuntimeConfig{}, "", true); err != nil {
+		t.Fatalf("checkPodmanSocketForwarding() without forwarding = %v, want no error", err)
+	}
+
+	hostOS = "darwin"
+	if err := checkPodmanSocketForwarding(runtimeCfg, "keep-id", true); err == nil {
+		t.Fatal("checkPodmanSocketForwarding() should fail for SSH_AGENT with a macOS Podman machine")
+	}
+}
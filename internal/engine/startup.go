@@ -0,0 +1,122 @@
+// # startup.go
+// Startup scripts of a project (`STARTUP` in its run.conf) are mounted in its
+// container and run by its entrypoint in their declaration order, before its
+// shell or command.
+//
+// The entrypoint reports their progress through structured markers appended
+// to a file mounted from the host, one per line and tab-separated:
+// "startup", then "started", "done" or "failed", the script's name and for
+// failures its exit code and failure policy. paul-envs reads them once the
+// container exited to tell which script failed.
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+// Directory in the container where startup scripts are mounted, as files
+// named after their position.
+const containerStartupDir = "/paul-env/startup"
+
+// File in the container where the entrypoint reports its progress.
+const containerProgressPath = "/paul-env/progress"
+
+// Status of a startup script, as reported by the entrypoint.
+type StartupStatus string
+
+const (
+	StartupStarted StartupStatus = "started"
+	StartupDone    StartupStatus = "done"
+	StartupFailed  StartupStatus = "failed"
+)
+
+// Last reported progress of a startup script.
+type StartupReport struct {
+	// Base name of the script on the host
+	Name   string
+	Status StartupStatus
+	// Only set for failures
+	ExitCode  int
+	OnFailure config.StartupFailurePolicy
+}
+
+// Arguments mounting the startup scripts of a project in its container and
+// telling the entrypoint their order and failure policies.
+func startupRunArgs(project files.ProjectEntry, runtimeCfg config.RuntimeConfig) ([]string, error) {
+	if len(runtimeCfg.StartupScripts) == 0 {
+		return nil, nil
+	}
+	var args []string
+	var scripts []string
+	for i, script := range runtimeCfg.StartupScripts {
+		path, err := resolveRuntimePath(project.RuntimeConfigPath, script.Path)
+		if err != nil {
+			return nil, fmt.Errorf("resolve STARTUP: %w", err)
+		}
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			return nil, fmt.Errorf("startup script %q not found: %s is not a file\nHint: Create it or remove it from %s",
+				script.Path, path, project.RuntimeConfigPath)
+		}
+		args = append(args, "--volume", bindVolume(path, fmt.Sprintf("%s/%d", containerStartupDir, i+1), "ro", autoRelabel(runtimeCfg, path)))
+		scripts = append(scripts, string(script.OnFailure)+" "+filepath.Base(path))
+	}
+	return append(args,
+		"--volume", bindVolume(project.StartupProgressPath, containerProgressPath, autoRelabel(runtimeCfg, project.StartupProgressPath)),
+		"--env", "PAULENV_STARTUP="+strings.Join(scripts, "\n"),
+	), nil
+}
+
+// Read the progress of the startup scripts of the last container of a
+// project, in their run order. Empty if it has none or ran none.
+func ReadStartupProgress(project files.ProjectEntry) ([]StartupReport, error) {
+	data, err := os.ReadFile(project.StartupProgressPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot read startup progress: %w", err)
+	}
+	return parseStartupProgress(string(data)), nil
+}
+
+// Parse progress markers into the last status of each startup script.
+// Markers of other steps and malformed lines are ignored.
+func parseStartupProgress(content string) []StartupReport {
+	var reports []StartupReport
+	for line := range strings.SplitSeq(content, "\n") {
+		fields := strings.Split(strings.TrimRight(line, "\r"), "\t")
+		if len(fields) < 3 || fields[0] != "startup" || fields[2] == "" {
+			continue
+		}
+		report := StartupReport{Name: fields[2], Status: StartupStatus(fields[1])}
+		switch report.Status {
+		case StartupStarted, StartupDone:
+		case StartupFailed:
+			if len(fields) < 5 {
+				continue
+			}
+			code, err := strconv.Atoi(fields[3])
+			if err != nil {
+				continue
+			}
+			report.ExitCode = code
+			report.OnFailure = config.StartupFailurePolicy(fields[4])
+		default:
+			continue
+		}
+		if n := len(reports); n > 0 && reports[n-1].Name == report.Name && reports[n-1].Status == StartupStarted {
+			reports[n-1] = report
+		} else {
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
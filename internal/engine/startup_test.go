@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+)
+
+func TestStartupRunArgs(t *testing.T) {
+	setSELinuxEnforcing(t, false)
+	dir := t.TempDir()
+	for _, name := range []string{"setup.sh", "watch.sh"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("true\n"), 0755); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	project := files.ProjectEntry{
+		ProjectName:         "demo",
+		RuntimeConfigPath:   filepath.Join(dir, "run.conf"),
+		StartupProgressPath: "/data/demo/startup.progress",
+	}
+	runtimeCfg := config.RuntimeConfig{StartupScripts: []config.StartupScript{
+		{Path: "watch.sh", OnFailure: config.StartupWarn},
+		{Path: filepath.Join(dir, "setup.sh"), OnFailure: config.StartupAbort},
+	}}
+
+	args, err := startupRunArgs(project, runtimeCfg)
+	if err != nil {
+		t.Fatalf("startupRunArgs() error = %v", err)
+	}
+	want := []string{
+		"--volume", filepath.Join(dir, "watch.sh") + ":/paul-env/startup/1:ro",
+		"--volume", filepath.Join(dir, "setup.sh") + ":/paul-env/startup/2:ro",
+		"--volume", "/data/demo/startup.progress:/paul-env/progress",
+		"--env", "PAULENV_STARTUP=warn watch.sh\nabort setup.sh",
+	}
+	if !slices.Equal(args, want) {
+		t.Fatalf("startupRunArgs() = %q, want %q", args, want)
+	}
+
+	runtimeCfg.StartupScripts = append(runtimeCfg.StartupScripts, config.StartupScript{Path: "missing.sh", OnFailure: config.StartupAbort})
+	if _, err := startupRunArgs(project, runtimeCfg); err == nil {
+		t.Fatalf("startupRunArgs() should fail with a missing script")
+	}
+	if args, err := startupRunArgs(project, config.RuntimeConfig{}); err != nil || len(args) != 0 {
+		t.Fatalf("startupRunArgs() without scripts = %q, %v, want no argument", args, err)
+	}
+}
+
+func TestParseStartupProgress(t *testing.T) {
+	content := "startup\tstarted\tsetup.sh\n" +
+		"startup\tdone\tsetup.sh\n" +
+		"startup\tstarted\twatch.sh\n" +
+		"startup\tfailed\twatch.sh\t3\twarn\n" +
+		"other\tdone\tsomething\n" +
+		"startup\tfailed\tbroken.sh\n" +
+		"startup\tstarted\tdeps.sh\n"
+	want := []StartupReport{
+		{Name: "setup.sh", Status: StartupDone},
+		{Name: "watch.sh", Status: StartupFailed, ExitCode: 3, OnFailure: config.StartupWarn},
+		{Name: "deps.sh", Status: StartupStarted},
+	}
+	if got := parseStartupProgress(content); !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseStartupProgress() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,95 @@
+// # stats.go
+// Live resource usage of the containers of paulenv projects, as reported by
+// the `stats` command of container engines.
+
+package engine
+
+import "strings"
+
+// Resource usage of a running container, as formatted by its container
+// engine.
+type ContainerStats struct {
+	// The name of the paulenv project it belongs to
+	ProjectName string
+	// The name of its service for a sidecar, empty for the project's own
+	// container
+	ServiceName string
+	// The name it is actually refered to by the container engine.
+	ContainerName string
+	// e.g. "1.25%"
+	CPU string
+	// Used and available memory, e.g. "120MiB / 7.6GiB"
+	Memory string
+	// e.g. "1.54%"
+	MemoryPercent string
+	// Received and sent bytes, e.g. "1.2kB / 648B"
+	NetIO string
+	// Read and written bytes, e.g. "4.1MB / 0B"
+	BlockIO string
+}
+
+const statsFormat = "{{.ID}}\t{{.CPUPerc}}\t{{.MemUsage}}\t{{.MemPerc}}\t{{.NetIO}}\t{{.BlockIO}}"
+
+// Containers to get the stats of, by full id.
+type statsTargets map[string]ContainerStats
+
+// Returns the running containers and sidecars of projects, whose stats can be
+// asked for.
+func newStatsTargets(containers []ContainerInfo, sidecars []SidecarInfo) statsTargets {
+	targets := statsTargets{}
+	for _, container := range containers {
+		if !container.Running || container.ProjectName == nil {
+			continue
+		}
+		name := container.ContainerId
+		if container.ContainerName != nil {
+			name = *container.ContainerName
+		}
+		targets[container.ContainerId] = ContainerStats{ProjectName: *container.ProjectName, ContainerName: name}
+	}
+	for _, sidecar := range sidecars {
+		if sidecar.Running {
+			targets[sidecar.ContainerId] = ContainerStats{
+				ProjectName:   sidecar.ProjectName,
+				ServiceName:   sidecar.ServiceName,
+				ContainerName: sidecar.ContainerName,
+			}
+		}
+	}
+	return targets
+}
+
+// Arguments of the `stats` command reporting once on all targets.
+func (t statsTargets) statsArgs() []string {
+	args := []string{"stats", "--no-stream", "--format", statsFormat}
+	for id := range t {
+		args = append(args, id)
+	}
+	return args
+}
+
+// Parse the output of a `stats --no-stream --format statsFormat` command.
+//
+// Engines may print truncated ids, which are thus matched by prefix.
+func (t statsTargets) parse(output string) []ContainerStats {
+	var stats []ContainerStats
+	for line := range strings.SplitSeq(strings.TrimSpace(output), "\n") {
+		parts := strings.Split(line, "\t")
+		if len(parts) != 6 || parts[0] == "" {
+			continue
+		}
+		for id, target := range t {
+			if !strings.HasPrefix(id, parts[0]) {
+				continue
+			}
+			target.CPU = parts[1]
+			target.Memory = parts[2]
+			target.MemoryPercent = parts[3]
+			target.NetIO = parts[4]
+			target.BlockIO = parts[5]
+			stats = append(stats, target)
+			break
+		}
+	}
+	return stats
+}
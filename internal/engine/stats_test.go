@@ -0,0 +1,45 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStatsTargets(t *testing.T) {
+	app := "app"
+	appContainer := "paulenv-app"
+	other := "other"
+	containers := []ContainerInfo{
+		{ProjectName: &app, ContainerName: &appContainer, ContainerId: "aaaaaaaaaaaa1111", Running: true},
+		{ProjectName: &other, ContainerId: "bbbbbbbbbbbb2222", Running: false},
+	}
+	sidecars := []SidecarInfo{
+		{ProjectName: "app", ServiceName: "db", ContainerName: "paulenv-app.db", ContainerId: "cccccccccccc3333", Running: true},
+	}
+	targets := newStatsTargets(containers, sidecars)
+	if len(targets) != 2 {
+		t.Fatalf("newStatsTargets() = %+v, want the 2 running containers", targets)
+	}
+	args := targets.statsArgs()
+	if !slices.Contains(args, "--no-stream") || !slices.Contains(args, "aaaaaaaaaaaa1111") ||
+		!slices.Contains(args, "cccccccccccc3333") || slices.Contains(args, "bbbbbbbbbbbb2222") {
+		t.Fatalf("statsArgs() = %v", args)
+	}
+
+	output := "aaaaaaaaaaaa\t1.50%\t120MiB / 7.6GiB\t1.54%\t1.2kB / 648B\t4.1MB / 0B\n" +
+		"cccccccccccc\t0.10%\t30MiB / 7.6GiB\t0.38%\t648B / 1.2kB\t0B / 12kB\n" +
+		"dddddddddddd\t9.00%\t1GiB / 7.6GiB\t13%\t0B / 0B\t0B / 0B\n" +
+		"garbage\n"
+	stats := targets.parse(output)
+	if len(stats) != 2 {
+		t.Fatalf("parse() = %+v, want 2 stats", stats)
+	}
+	if stats[0].ProjectName != "app" || stats[0].ServiceName != "" || stats[0].ContainerName != "paulenv-app" ||
+		stats[0].CPU != "1.50%" || stats[0].Memory != "120MiB / 7.6GiB" || stats[0].MemoryPercent != "1.54%" ||
+		stats[0].NetIO != "1.2kB / 648B" || stats[0].BlockIO != "4.1MB / 0B" {
+		t.Fatalf("parse()[0] = %+v", stats[0])
+	}
+	if stats[1].ServiceName != "db" || stats[1].ContainerName != "paulenv-app.db" || stats[1].CPU != "0.10%" {
+		t.Fatalf("parse()[1] = %+v", stats[1])
+	}
+}
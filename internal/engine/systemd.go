@@ -0,0 +1,91 @@
+// # systemd.go
+// Containers running systemd as their PID 1 (`ENABLE_SYSTEMD` build
+// directive), so services relying on systemd units can be developed in them.
+//
+// systemd then replaces the shell as the container's main process: the
+// command or shell of `run` is started through `exec`, as when joining a
+// running container, and the container is stopped once it exits (or, with a
+// persistent session, once that session ended).
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/files"
+	"github.com/peaberberian/paul-envs/internal/logging"
+)
+
+// Returns `true` if the project's image runs systemd as its PID 1.
+func hasSystemd(buildCfg config.BuildConfig) bool {
+	return buildCfg.Args["ENABLE_SYSTEMD"] == "true"
+}
+
+// Arguments of the `run` command letting systemd run as PID 1, if the
+// project's image has it.
+//
+// Podman sets it up by itself. Docker needs writable cgroups and a `/run`
+// tmpfs, and to stop it with the signal systemd shuts down on.
+func systemdRunArgs(buildCfg config.BuildConfig, runtimeCfg config.RuntimeConfig, podman bool) ([]string, error) {
+	if !hasSystemd(buildCfg) {
+		return nil, nil
+	}
+	if runtimeCfg.Hardened {
+		return nil, errors.New("ENABLE_SYSTEMD cannot be used with HARDENED, as systemd needs a writable root filesystem and most capabilities\n" +
+			"Hint: Remove the HARDENED directive of the project's run.conf or disable ENABLE_SYSTEMD in its build.conf")
+	}
+	args := []string{"--env", "PAULENV_SYSTEMD=1"}
+	if podman {
+		return append(args, "--systemd=always"), nil
+	}
+	return append(args,
+		"--tmpfs", "/run",
+		"--tmpfs", "/run/lock",
+		"--cgroupns=host",
+		"--volume", "/sys/fs/cgroup:/sys/fs/cgroup:rw",
+		"--stop-signal", "SIGRTMIN+3",
+	), nil
+}
+
+// Run the command (or shell if none) of `args` in the container started in
+// the background by `cmd`, then stop it. With a persistent session, it is
+// only stopped if that session ended.
+func runDetachedContainer(ctx context.Context, c ContainerEngine, project files.ProjectEntry, instance Instance, cmd *exec.Cmd, args []string, session bool) error {
+	cmd.Stderr = engineOutput
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if ctx.Err() != nil {
+			removeInterruptedContainer(ctx, c, instanceContainerName(project.ProjectName, instance.Name))
+			return fmt.Errorf("run interrupted: %w", ctx.Err())
+		}
+		return fmt.Errorf("run failed: %w", err)
+	}
+	projectName := project.ProjectName
+	containerName := instanceContainerName(projectName, instance.Name)
+	imageName := projectImageName(projectName)
+	container := ContainerInfo{
+		ProjectName:   &projectName,
+		ContainerName: &containerName,
+		ImageName:     &imageName,
+		Instance:      instance.Name,
+		ContainerId:   strings.TrimSpace(string(output)),
+		Running:       true,
+	}
+	defer func() {
+		var err error
+		if session {
+			_, err = StopEndedSession(context.WithoutCancel(ctx), c, project, container)
+		} else {
+			err = c.StopContainer(context.WithoutCancel(ctx), container)
+		}
+		if err != nil {
+			logging.Log().Debug("detached container not stopped", "container", container.ContainerId, "error", err)
+		}
+	}()
+	return c.JoinContainer(ctx, container, args)
+}
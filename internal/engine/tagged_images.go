@@ -0,0 +1,62 @@
+// # tagged_images.go
+// Images kept for a project on top of its current one (snapshots, previous
+// generations) are named `<repository>:<project>.<tag>`, under a repository
+// of their own so they are never mistaken for a project's current image.
+
+package engine
+
+import (
+	"strings"
+	"time"
+)
+
+type projectTaggedImage struct {
+	projectName string
+	tag         string
+	imageName   string
+	createdAt   *time.Time
+	size        string
+}
+
+// Name of the image with the given tag kept for that project under the given
+// repository.
+//
+// Project names cannot contain a '.', which separates them from the tag.
+func projectTaggedImageName(repository string, projectName string, tag string) string {
+	return repository + ":" + projectName + "." + tag
+}
+
+// Returns the project and tag of the given image name if it belongs to the
+// given repository, `ok` set to `false` otherwise.
+func parseProjectTaggedImage(imageName string, repository string) (projectName string, tag string, ok bool) {
+	for _, prefix := range []string{repository + ":", "localhost/" + repository + ":"} {
+		if name, found := strings.CutPrefix(imageName, prefix); found {
+			projectName, tag, ok = strings.Cut(name, ".")
+			return projectName, tag, ok && projectName != "" && tag != ""
+		}
+	}
+	return "", "", false
+}
+
+// Parse the output of an `images` command listing
+// `{{.Repository}}:{{.Tag}}\t{{.CreatedAt}}\t{{.Size}}` into the images of
+// the given repository it contains.
+func parseProjectTaggedImages(output string, repository string, quirks engineQuirks) []projectTaggedImage {
+	var result []projectTaggedImage
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.SplitN(line, "\t", 3)
+		projectName, tag, ok := parseProjectTaggedImage(parts[0], repository)
+		if !ok {
+			continue
+		}
+		image := projectTaggedImage{projectName: projectName, tag: tag, imageName: parts[0]}
+		if len(parts) > 1 {
+			image.createdAt = quirks.parseCreatedAt(parts[1])
+		}
+		if len(parts) > 2 {
+			image.size = strings.TrimSpace(parts[2])
+		}
+		result = append(result, image)
+	}
+	return result
+}
@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"testing"
+)
+
+func TestSnapshotImageName_RoundTrip(t *testing.T) {
+	name := snapshotImageName("my-app", "with-deps.v2")
+	if name != "paulenv-snapshot:my-app.with-deps.v2" {
+		t.Fatalf("snapshotImageName() = %q", name)
+	}
+	for _, imageName := range []string{name, "localhost/" + name} {
+		project, tag, ok := parseSnapshotImageName(imageName)
+		if !ok || project != "my-app" || tag != "with-deps.v2" {
+			t.Fatalf("parseSnapshotImageName(%q) = %q, %q, %v", imageName, project, tag, ok)
+		}
+	}
+	for _, imageName := range []string{"paulenv:my-app", "paulenv-snapshot:my-app", "paulenv-snapshot:.tag"} {
+		if _, _, ok := parseSnapshotImageName(imageName); ok {
+			t.Fatalf("parseSnapshotImageName(%q) should not be a snapshot", imageName)
+		}
+	}
+}
+
+func TestParseSnapshotList(t *testing.T) {
+	output := "paulenv:app\t2026-03-04 10:20:30 +0000 UTC\t1.2GB\n" +
+		"localhost/paulenv-snapshot:app.first\t2026-03-04 10:20:30 +0000 UTC\t1.3GB\n" +
+		"paulenv-snapshot:other.20260305-101010\t2026-03-05T10:10:10Z\t900MB\n"
+	got := parseSnapshotList(output, quirksFor("podman", ""))
+	if len(got) != 2 {
+		t.Fatalf("parseSnapshotList() = %+v, want 2 snapshots", got)
+	}
+	if got[0].ProjectName != "app" || got[0].Tag != "first" || got[0].Size != "1.3GB" || got[0].CreatedAt == nil {
+		t.Fatalf("unexpected first snapshot: %+v", got[0])
+	}
+	if got[1].ProjectName != "other" || got[1].Tag != "20260305-101010" {
+		t.Fatalf("unexpected second snapshot: %+v", got[1])
+	}
+}
+
+func TestValidateSnapshotTag(t *testing.T) {
+	for _, tag := range []string{"v1", "20260314-153000", "with_deps.2"} {
+		if err := ValidateSnapshotTag(tag); err != nil {
+			t.Fatalf("ValidateSnapshotTag(%q) error = %v", tag, err)
+		}
+	}
+	for _, tag := range []string{"", "-v1", "a/b", "a:b"} {
+		if err := ValidateSnapshotTag(tag); err == nil {
+			t.Fatalf("ValidateSnapshotTag(%q) should fail", tag)
+		}
+	}
+}
+
+func TestParseGenerationList(t *testing.T) {
+	output := "paulenv:app\t2026-03-04T10:20:30Z\t1.2GB\n" +
+		"paulenv-generation:app.2\t2026-03-04T10:20:30Z\t1.2GB\n" +
+		"localhost/paulenv-generation:app.10\t2026-03-05T10:20:30Z\t1.1GB\n" +
+		"paulenv-generation:app.latest\t2026-03-05T10:20:30Z\t1.1GB\n" +
+		"paulenv-snapshot:app.3\t2026-03-05T10:20:30Z\t1.1GB\n"
+	got := parseGenerationList(output, quirksFor("docker", ""))
+	if len(got) != 2 {
+		t.Fatalf("parseGenerationList() = %+v, want 2 generations", got)
+	}
+	if got[0].ProjectName != "app" || got[0].Number != 2 || got[1].Number != 10 || got[1].BuiltAt == nil {
+		t.Fatalf("unexpected generations: %+v", got)
+	}
+	if generationImageName("app", 3) != "paulenv-generation:app.3" {
+		t.Fatalf("generationImageName() = %q", generationImageName("app", 3))
+	}
+}
@@ -0,0 +1,285 @@
+// # throwaway.go
+// Throwaway environments (the `tmp` command) run an image as is, for quick
+// experiments, in a container with its own network and volume which all
+// disappear once it exits.
+//
+// They belong to no project, so they are labelled with `paulenv=tmp` rather
+// than `paulenv=true`: listings of paul-envs' resources, `gc` included, never
+// see them. Their names have a dot after "paulenv", which no name of a
+// project's resources has. Those left behind by a paul-envs process which
+// could not clean up after itself (e.g. killed) are pruned by the next one.
+//
+// Images not built by paul-envs lack its entrypoint: with
+// `ThrowawayOptions.EntrypointPath`, a minimal one is bind-mounted and run in
+// place of theirs, applying the dotfiles. Other sessions can join a running
+// environment, through that entrypoint when it was injected.
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/logging"
+	"github.com/peaberberian/paul-envs/internal/profiling"
+)
+
+// Value of `managedLabel` on the resources of throwaway environments.
+const throwawayManagedValue = "tmp"
+
+// Label set to the name of the throwaway environment a resource belongs to.
+const throwawayLabel = "paulenv.tmp"
+
+// Where the volume of a throwaway environment is mounted, which is also the
+// working directory of its container.
+const throwawayWorkDir = "/workspace"
+
+// Where the injected entrypoint of a throwaway environment is mounted.
+const throwawayEntrypoint = "/paul-env/entrypoint.sh"
+
+// Where the dotfiles applied by the injected entrypoint are mounted.
+const throwawayDotfilesDir = "/paul-env/dotfiles"
+
+// Script run by sessions joining a throwaway environment: its injected
+// entrypoint if it has one, else the given command or a shell.
+const throwawayJoinScript = `[ -f ` + throwawayEntrypoint + ` ] && exec /bin/sh ` + throwawayEntrypoint + ` "$@"
+[ "$#" -gt 0 ] && exec "$@"
+command -v bash >/dev/null 2>&1 && exec bash -l
+exec sh -l`
+
+// Time given to the removal of a throwaway environment's resources.
+const throwawayCleanupTimeout = 30 * time.Second
+
+// How an engine's CLI runs throwaway environments.
+type throwawayCLI struct {
+	command commandFunc
+	// Environment of the `run` command, which may pull the image
+	runEnv []string
+	// Arguments added to the `run` command, before the image
+	runArgs []string
+	// Arguments of the command removing unused volumes, named ones included
+	volumePruneArgs []string
+}
+
+// Name of the container, network and volume of the throwaway environment of
+// the given name.
+func throwawayResourceName(name string) string {
+	return "paulenv." + name
+}
+
+// Returns the `--label` flags of the resources of the throwaway environment
+// of the given name.
+func throwawayLabelFlags(name string) []string {
+	labels := resourceLabels("", sourceThrowaway)
+	labels[managedLabel] = throwawayManagedValue
+	labels[throwawayLabel] = name
+	return imageLabelFlags(labels)
+}
+
+// Arguments of the `run` command running `image` in the throwaway
+// environment of the given name, with a pseudo-terminal if `tty` is set.
+func throwawayRunArgs(name string, image string, args []string, options ThrowawayOptions, tty bool, extra []string) []string {
+	resource := throwawayResourceName(name)
+	cmdArgs := []string{"run", "--rm", "-i"}
+	if tty {
+		cmdArgs = append(cmdArgs, "-t")
+	}
+	cmdArgs = append(cmdArgs,
+		"--name", resource,
+		"--hostname", name,
+		"--network", resource,
+		"-v", resource+":"+throwawayWorkDir,
+		"-w", throwawayWorkDir,
+	)
+	cmdArgs = append(cmdArgs, throwawayLabelFlags(name)...)
+	cmdArgs = append(cmdArgs, offlinePullArgs()...)
+	if options.EntrypointPath != "" {
+		cmdArgs = append(cmdArgs,
+			"--entrypoint", "/bin/sh",
+			"--volume", bindVolume(options.EntrypointPath, throwawayEntrypoint, "ro", autoRelabel(config.RuntimeConfig{}, options.EntrypointPath)),
+		)
+		if options.DotfilesPath != "" {
+			cmdArgs = append(cmdArgs, "--volume", bindVolume(options.DotfilesPath, throwawayDotfilesDir, "ro", autoRelabel(config.RuntimeConfig{}, options.DotfilesPath)))
+		}
+	}
+	cmdArgs = append(cmdArgs, extra...)
+	cmdArgs = append(cmdArgs, image)
+	if options.EntrypointPath != "" {
+		cmdArgs = append(cmdArgs, throwawayEntrypoint)
+	}
+	return append(cmdArgs, args...)
+}
+
+// Arguments of the `exec` command joining the throwaway environment of the
+// given name, with a pseudo-terminal if `tty` is set.
+func throwawayJoinArgs(name string, args []string, tty bool) []string {
+	cmdArgs := []string{"exec", "-i"}
+	if tty {
+		cmdArgs = append(cmdArgs, "-t")
+	}
+	cmdArgs = append(cmdArgs, throwawayResourceName(name), "/bin/sh", "-c", throwawayJoinScript, "sh")
+	return append(cmdArgs, args...)
+}
+
+// Run `image` in a new throwaway environment of the given name, then remove
+// it.
+func runThrowaway(ctx context.Context, cli throwawayCLI, name string, image string, args []string, options ThrowawayOptions) (err error) {
+	pruneThrowaways(ctx, cli)
+
+	resource := throwawayResourceName(name)
+	labels := throwawayLabelFlags(name)
+	var created []string
+	defer func() {
+		err = errors.Join(err, removeThrowaway(ctx, cli, resource, created))
+	}()
+	for _, kind := range []string{"network", "volume"} {
+		cmdArgs := append([]string{kind, "create"}, labels...)
+		cmd := cli.command(ctx, append(cmdArgs, resource)...)
+		cmd.Stderr = engineOutput
+		if err := runEngineCommand(cmd); err != nil {
+			return fmt.Errorf("failed to create %s %s: %w", kind, resource, err)
+		}
+		created = append(created, kind)
+	}
+	created = append(created, "container")
+
+	cmd := cli.command(ctx, throwawayRunArgs(name, image, args, options, stdinIsTerminal(), cli.runArgs)...)
+	withEnv(cmd, cli.runEnv)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := runEngineCommand(cmd); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("run interrupted: %w", ctx.Err())
+		}
+		return fmt.Errorf("tmp exited: %w", err)
+	}
+	return nil
+}
+
+// Start a shell, or the given command, in the running throwaway environment
+// of the given name.
+func joinThrowaway(ctx context.Context, command commandFunc, name string, args []string) error {
+	cmd := command(ctx, throwawayJoinArgs(name, args, stdinIsTerminal())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := runEngineCommand(cmd); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("join interrupted: %w", ctx.Err())
+		}
+		return fmt.Errorf("join exited: %w", err)
+	}
+	return nil
+}
+
+// Remove the given kinds of resources of a throwaway environment, in the
+// reverse order of their creation, even if `ctx` is canceled.
+//
+// Its container is removed by the engine when it exits (`--rm`), unless its
+// CLI was killed first, so failing to remove it is only logged.
+func removeThrowaway(ctx context.Context, cli throwawayCLI, resource string, kinds []string) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), throwawayCleanupTimeout)
+	defer cancel()
+	var errs []error
+	for i := len(kinds) - 1; i >= 0; i-- {
+		kind := kinds[i]
+		cmdArgs := []string{kind, "rm", resource}
+		if kind == "container" {
+			cmdArgs = []string{kind, "rm", "-f", resource}
+		}
+		if err := runEngineCommand(cli.command(ctx, cmdArgs...)); err != nil {
+			if kind == "container" {
+				logging.Log().Debug("throwaway container not removed", "container", resource, "error", err)
+				continue
+			}
+			errs = append(errs, fmt.Errorf("failed to remove %s %s: %w", kind, resource, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Remove the unused resources of throwaway environments left behind, which
+// does not touch those of environments still running.
+//
+// This is only housekeeping, so failures are only logged.
+func pruneThrowaways(ctx context.Context, cli throwawayCLI) {
+	filter := "label=" + managedLabel + "=" + throwawayManagedValue
+	for _, cmdArgs := range [][]string{
+		{"container", "prune", "-f", "--filter", filter},
+		{"network", "prune", "-f", "--filter", filter},
+		slices.Concat(cli.volumePruneArgs, []string{"--filter", filter}),
+	} {
+		if err := runEngineCommand(cli.command(ctx, cmdArgs...)); err != nil {
+			logging.Log().Debug("throwaway resources not pruned", "command", cmdArgs, "error", err)
+		}
+	}
+}
+
+func (c *DockerEngine) RunThrowaway(ctx context.Context, name string, image string, args []string, options ThrowawayOptions) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker RunThrowaway")()
+	registryEnv, err := dockerRegistryEnv(ctx)
+	if err != nil {
+		return err
+	}
+	err = runThrowaway(ctx, throwawayCLI{
+		command: func(ctx context.Context, args ...string) *exec.Cmd {
+			return engineCommand(ctx, "docker", args...)
+		},
+		runEnv: registryEnv,
+		// Only anonymous volumes are pruned without `--all`
+		volumePruneArgs: []string{"volume", "prune", "-f", "--all"},
+	}, name, image, args, options)
+	if err != nil && ctx.Err() == nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+	}
+	return err
+}
+
+func (c *PodmanEngine) RunThrowaway(ctx context.Context, name string, image string, args []string, options ThrowawayOptions) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman RunThrowaway")()
+	registryEnv, registryArgs, err := c.registryOptions()
+	if err != nil {
+		return err
+	}
+	err = runThrowaway(ctx, throwawayCLI{
+		command:         c.command,
+		runEnv:          registryEnv,
+		runArgs:         registryArgs,
+		volumePruneArgs: []string{"volume", "prune", "-f"},
+	}, name, image, args, options)
+	if err != nil && ctx.Err() == nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+	}
+	return err
+}
+
+func (c *DockerEngine) JoinThrowaway(ctx context.Context, name string, args []string) error {
+	defer profiling.Track(profiling.CategoryEngine, "docker JoinThrowaway")()
+	err := joinThrowaway(ctx, func(ctx context.Context, args ...string) *exec.Cmd {
+		return engineCommand(ctx, "docker", args...)
+	}, name, args)
+	if err != nil && ctx.Err() == nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+	}
+	return err
+}
+
+func (c *PodmanEngine) JoinThrowaway(ctx context.Context, name string, args []string) error {
+	defer profiling.Track(profiling.CategoryEngine, "podman JoinThrowaway")()
+	err := joinThrowaway(ctx, c.command, name, args)
+	if err != nil && ctx.Err() == nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return pErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestThrowawayRunArgs(t *testing.T) {
+	args := throwawayRunArgs("tmp-1a2b", "fedora:41", []string{"dnf", "info", "git"}, ThrowawayOptions{}, true, []string{"--authfile", "auth.json"})
+	if !slices.Equal(args[:4], []string{"run", "--rm", "-i", "-t"}) {
+		t.Fatalf("throwawayRunArgs() = %v, want an interactive run removing its container", args)
+	}
+	for _, pair := range [][]string{
+		{"--name", "paulenv.tmp-1a2b"},
+		{"--network", "paulenv.tmp-1a2b"},
+		{"-v", "paulenv.tmp-1a2b:" + throwawayWorkDir},
+	} {
+		i := slices.Index(args, pair[0])
+		if i < 0 || args[i+1] != pair[1] {
+			t.Errorf("throwawayRunArgs() = %v, want %s %s", args, pair[0], pair[1])
+		}
+	}
+	if !slices.Contains(args, managedLabel+"="+throwawayManagedValue) || !slices.Contains(args, throwawayLabel+"=tmp-1a2b") {
+		t.Errorf("throwawayRunArgs() = %v, want its container labelled as a throwaway one", args)
+	}
+	tail := []string{"--authfile", "auth.json", "fedora:41", "dnf", "info", "git"}
+	if !slices.Equal(args[len(args)-len(tail):], tail) {
+		t.Errorf("throwawayRunArgs() = %v, want it to end with %v", args, tail)
+	}
+	if args := throwawayRunArgs("tmp-1a2b", "fedora:41", nil, ThrowawayOptions{}, false, nil); slices.Contains(args, "-t") {
+		t.Errorf("throwawayRunArgs() = %v, want no pseudo-terminal", args)
+	}
+	if slices.Contains(args, "--entrypoint") {
+		t.Errorf("throwawayRunArgs() = %v, want the image's own entrypoint", args)
+	}
+
+	options := ThrowawayOptions{EntrypointPath: "/data/inject-entrypoint.sh", DotfilesPath: "/config/dotfiles"}
+	args = throwawayRunArgs("tmp-1a2b", "alpine:3", nil, options, false, nil)
+	if i := slices.Index(args, "--entrypoint"); i < 0 || args[i+1] != "/bin/sh" {
+		t.Errorf("throwawayRunArgs() = %v, want the image's shell as entrypoint", args)
+	}
+	// Mounts may be relabelled for SELinux
+	for _, mount := range []string{
+		hostPath("/data/inject-entrypoint.sh") + ":" + throwawayEntrypoint + ":ro",
+		hostPath("/config/dotfiles") + ":" + throwawayDotfilesDir + ":ro",
+	} {
+		if !slices.ContainsFunc(args, func(arg string) bool { return strings.HasPrefix(arg, mount) }) {
+			t.Errorf("throwawayRunArgs() = %v, want it to mount %s", args, mount)
+		}
+	}
+	if tail := []string{"alpine:3", throwawayEntrypoint}; !slices.Equal(args[len(args)-2:], tail) {
+		t.Errorf("throwawayRunArgs() = %v, want it to end with %v", args, tail)
+	}
+}
+
+func TestThrowawayJoinArgs(t *testing.T) {
+	args := throwawayJoinArgs("tmp-1a2b", []string{"ls", "-l"}, true)
+	want := []string{"exec", "-i", "-t", "paulenv.tmp-1a2b", "/bin/sh", "-c", throwawayJoinScript, "sh", "ls", "-l"}
+	if !slices.Equal(args, want) {
+		t.Errorf("throwawayJoinArgs() = %v, want %v", args, want)
+	}
+}
+
+func TestThrowawayResourcesAreNotListed(t *testing.T) {
+	name := throwawayResourceName("tmp-1a2b")
+	if volumes := parseVolumeList(name + "\t" + throwawayManagedValue); len(volumes) != 0 {
+		t.Errorf("parseVolumeList() = %v, want throwaway volumes ignored", volumes)
+	}
+	if networks := parseNetworkList("abc\t" + name + "\t" + throwawayManagedValue + "\t<no value>"); len(networks) != 0 {
+		t.Errorf("parseNetworkList() = %v, want throwaway networks ignored", networks)
+	}
+}
+
+func TestRunThrowawayCleansUp(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on the true and false commands")
+	}
+	var calls []string
+	cli := throwawayCLI{
+		command: func(ctx context.Context, args ...string) *exec.Cmd {
+			calls = append(calls, strings.Join(args[:2], " "))
+			if args[0] == "run" {
+				return exec.CommandContext(ctx, "false")
+			}
+			return exec.CommandContext(ctx, "true")
+		},
+		volumePruneArgs: []string{"volume", "prune"},
+	}
+	if err := runThrowaway(context.Background(), cli, "tmp-1a2b", "fedora:41", nil, ThrowawayOptions{}); err == nil {
+		t.Fatal("runThrowaway() succeeded, want the failure of its container")
+	}
+	want := []string{
+		"container prune", "network prune", "volume prune",
+		"network create", "volume create", "run --rm",
+		"container rm", "volume rm", "network rm",
+	}
+	if !slices.Equal(calls, want) {
+		t.Fatalf("runThrowaway() ran %v, want %v", calls, want)
+	}
+}
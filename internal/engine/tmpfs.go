@@ -0,0 +1,45 @@
+package engine
+
+import (
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+// Value of a `--tmpfs` argument mounting the given tmpfs, also understood by
+// Compose's `tmpfs` setting.
+func tmpfsSpec(mount config.TmpfsMount) string {
+	var options []string
+	if mount.Size != "" {
+		options = append(options, "size="+mount.Size)
+	}
+	if mount.Mode != "" {
+		options = append(options, "mode="+mount.Mode)
+	}
+	if len(options) == 0 {
+		return mount.Target
+	}
+	return mount.Target + ":" + strings.Join(options, ",")
+}
+
+// Value of the `PAULENV_TMPFS` variable telling the entrypoint where the
+// project's tmpfs are mounted, so they are given to the container user.
+func tmpfsEnvValue(mounts []config.TmpfsMount) string {
+	targets := make([]string, 0, len(mounts))
+	for _, mount := range mounts {
+		targets = append(targets, mount.Target)
+	}
+	return strings.Join(targets, " ")
+}
+
+// Arguments for the `run` command mounting the given tmpfs.
+func tmpfsRunArgs(mounts []config.TmpfsMount) []string {
+	if len(mounts) == 0 {
+		return nil
+	}
+	var args []string
+	for _, mount := range mounts {
+		args = append(args, "--tmpfs", tmpfsSpec(mount))
+	}
+	return append(args, "--env", "PAULENV_TMPFS="+tmpfsEnvValue(mounts))
+}
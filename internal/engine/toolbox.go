@@ -0,0 +1,171 @@
+// # toolbox.go
+// distrobox and toolbox both create containers with the host's engine, set up
+// by an entrypoint of theirs whose arguments tell how they were created (their
+// user, home directory, shell, layered packages...). Inspecting them is enough
+// to recreate an equivalent paul-envs project.
+
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/profiling"
+)
+
+// A container created by distrobox or toolbox.
+type ToolboxContainer struct {
+	// "distrobox" or "toolbox"
+	Tool  string
+	Name  string
+	Image string
+	// Their user, empty if unknown
+	User string
+	// Home directory of their user on the host, which may be another one than
+	// the host user's (distrobox's `--home`)
+	Home string
+	// Path of their user's shell, empty if unknown
+	Shell string
+	// Packages installed on top of their image (distrobox's
+	// `--additional-packages`)
+	Packages []string
+	// Other host directories mounted in them, as HOST:CONT[:ro]
+	Volumes []string
+}
+
+// Mount points distrobox and toolbox give to all their containers, to
+// integrate them with the host.
+var toolboxSystemMounts = []string{"/dev", "/etc", "/media", "/mnt", "/proc", "/run", "/sys", "/tmp", "/usr", "/var"}
+
+// A container, as listed by `container inspect`.
+type toolboxInspect struct {
+	Name      string
+	ImageName string
+	Config    struct {
+		Image      string
+		Labels     map[string]string
+		Env        []string
+		Entrypoint any
+		Cmd        []string
+	}
+	Mounts []struct {
+		Type        string
+		Source      string
+		Destination string
+		RW          bool
+	}
+}
+
+// Parse the output of `container inspect` on a distrobox or toolbox
+// container.
+func parseToolboxInspect(output []byte) (ToolboxContainer, error) {
+	var containers []toolboxInspect
+	if err := json.Unmarshal(output, &containers); err != nil {
+		return ToolboxContainer{}, fmt.Errorf("cannot parse container: %w", err)
+	}
+	if len(containers) != 1 {
+		return ToolboxContainer{}, errors.New("cannot parse container: unexpected output")
+	}
+	raw := containers[0]
+	container := ToolboxContainer{Name: strings.TrimPrefix(raw.Name, "/"), Image: raw.ImageName}
+	if container.Image == "" {
+		container.Image = raw.Config.Image
+	}
+	labels := raw.Config.Labels
+	switch {
+	case labels["manager"] == "distrobox":
+		container.Tool = "distrobox"
+	case labels["com.github.containers.toolbox"] == "true", labels["com.github.debarshiray.toolbox"] == "true":
+		container.Tool = "toolbox"
+	default:
+		return ToolboxContainer{}, fmt.Errorf("container %s was not created by distrobox nor toolbox", container.Name)
+	}
+
+	// Docker has the entrypoint as an array, Podman as a string
+	var args []string
+	switch entrypoint := raw.Config.Entrypoint.(type) {
+	case string:
+		args = strings.Fields(entrypoint)
+	case []any:
+		for _, arg := range entrypoint {
+			if arg, ok := arg.(string); ok {
+				args = append(args, arg)
+			}
+		}
+	}
+	args = append(args, raw.Config.Cmd...)
+	for i := 0; i+1 < len(args); i++ {
+		value := args[i+1]
+		switch args[i] {
+		case "--user":
+			container.User = value
+		case "--home":
+			container.Home = value
+		case "--shell":
+			container.Shell = value
+		case "--additional-packages":
+			container.Packages = append(container.Packages, strings.Fields(value)...)
+		default:
+			continue
+		}
+		i++
+	}
+	for _, env := range raw.Config.Env {
+		if shell, ok := strings.CutPrefix(env, "SHELL="); ok && container.Shell == "" {
+			container.Shell = shell
+		}
+	}
+
+	for _, mount := range raw.Mounts {
+		if mount.Type != "bind" || mount.Source == container.Home || isToolboxSystemMount(mount.Destination) {
+			continue
+		}
+		volume := mount.Source + ":" + mount.Destination
+		if !mount.RW {
+			volume += ":ro"
+		}
+		container.Volumes = append(container.Volumes, volume)
+	}
+	return container, nil
+}
+
+func isToolboxSystemMount(destination string) bool {
+	destination = path.Clean(destination)
+	if destination == "/" {
+		return true
+	}
+	for _, mount := range toolboxSystemMounts {
+		if destination == mount || strings.HasPrefix(destination, mount+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *DockerEngine) InspectToolbox(ctx context.Context, name string) (ToolboxContainer, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker InspectToolbox")()
+	output, err := engineCommandOutput(engineCommand(ctx, "docker", "container", "inspect", name))
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return ToolboxContainer{}, pErr
+		}
+		return ToolboxContainer{}, fmt.Errorf("failed to inspect container %s: %w", name, err)
+	}
+	return parseToolboxInspect(output)
+}
+
+func (c *PodmanEngine) InspectToolbox(ctx context.Context, name string) (ToolboxContainer, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman InspectToolbox")()
+	output, err := engineCommandOutput(c.command(ctx, "container", "inspect", name))
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return ToolboxContainer{}, pErr
+		}
+		return ToolboxContainer{}, fmt.Errorf("failed to inspect container %s: %w", name, err)
+	}
+	return parseToolboxInspect(output)
+}
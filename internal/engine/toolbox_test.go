@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseToolboxInspect_Distrobox(t *testing.T) {
+	output := `[{
+		"Name": "/mybox",
+		"Config": {
+			"Image": "docker.io/library/ubuntu:24.04",
+			"Labels": {"manager": "distrobox"},
+			"Env": ["SHELL=/bin/bash"],
+			"Entrypoint": ["/usr/bin/entrypoint", "-v"],
+			"Cmd": ["--name", "mybox", "--user", "alice", "--home", "/home/alice/boxes/mybox", "--additional-packages", "ripgrep fd-find", "--"]
+		},
+		"Mounts": [
+			{"Type": "bind", "Source": "/home/alice/boxes/mybox", "Destination": "/home/alice/boxes/mybox", "RW": true},
+			{"Type": "bind", "Source": "/etc/hosts", "Destination": "/etc/hosts", "RW": false},
+			{"Type": "bind", "Source": "/", "Destination": "/run/host", "RW": true},
+			{"Type": "bind", "Source": "/srv/data", "Destination": "/data", "RW": false},
+			{"Type": "volume", "Source": "cache", "Destination": "/cache", "RW": true}
+		]
+	}]`
+	got, err := parseToolboxInspect([]byte(output))
+	if err != nil {
+		t.Fatalf("parseToolboxInspect() error = %v", err)
+	}
+	if got.Tool != "distrobox" || got.Name != "mybox" || got.Image != "docker.io/library/ubuntu:24.04" ||
+		got.User != "alice" || got.Home != "/home/alice/boxes/mybox" || got.Shell != "/bin/bash" {
+		t.Fatalf("parseToolboxInspect() = %+v", got)
+	}
+	if !slices.Equal(got.Packages, []string{"ripgrep", "fd-find"}) {
+		t.Fatalf("parseToolboxInspect() packages = %v", got.Packages)
+	}
+	if !slices.Equal(got.Volumes, []string{"/srv/data:/data:ro"}) {
+		t.Fatalf("parseToolboxInspect() volumes = %v", got.Volumes)
+	}
+}
+
+func TestParseToolboxInspect_Toolbox(t *testing.T) {
+	output := `[{
+		"Name": "fedora-toolbox-40",
+		"ImageName": "registry.fedoraproject.org/fedora-toolbox:40",
+		"Config": {
+			"Image": "sha256:0123",
+			"Labels": {"com.github.containers.toolbox": "true"},
+			"Entrypoint": "toolbox --log-level debug init-container --home /home/bob --shell /usr/bin/zsh --user bob",
+			"Cmd": null
+		},
+		"Mounts": []
+	}]`
+	got, err := parseToolboxInspect([]byte(output))
+	if err != nil {
+		t.Fatalf("parseToolboxInspect() error = %v", err)
+	}
+	if got.Tool != "toolbox" || got.Image != "registry.fedoraproject.org/fedora-toolbox:40" ||
+		got.User != "bob" || got.Home != "/home/bob" || got.Shell != "/usr/bin/zsh" || len(got.Volumes) != 0 {
+		t.Fatalf("parseToolboxInspect() = %+v", got)
+	}
+}
+
+func TestParseToolboxInspect_OtherContainer(t *testing.T) {
+	output := `[{"Name": "/web", "Config": {"Image": "nginx", "Labels": {}}}]`
+	if _, err := parseToolboxInspect([]byte(output)); err == nil || !strings.Contains(err.Error(), "not created by distrobox nor toolbox") {
+		t.Fatalf("parseToolboxInspect() = %v, want an error for another container", err)
+	}
+}
@@ -0,0 +1,79 @@
+// # userns.go
+// User namespace of project containers, deciding which host user owns the
+// files they create in bind mounts.
+//
+// By default, Podman maps the host user to the container user with
+// `--userns=keep-id` where it can, as Docker (without user namespace
+// remapping) already does. Projects can choose another mode with USERNS in
+// run.conf, which is refused if the engine cannot honor it rather than leaving
+// files owned by unexpected subordinate IDs.
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+// Returns the `--userns` value of a Podman container, empty for Podman's
+// default.
+func podmanUserns(userns config.Userns, quirks engineQuirks, rootless bool) (string, error) {
+	switch userns.Mode {
+	case config.UsernsHost:
+		return "host", nil
+	case config.UsernsKeepID:
+		if quirks.keepIDRootlessOnly && !rootless {
+			return "", errors.New("USERNS keep-id is only supported by rootless Podman before version 4.3")
+		}
+		if !supportsKeepID() {
+			return "", errors.New("USERNS keep-id needs user namespaces, which are disabled on this host")
+		}
+		if userns.UID == nil {
+			return "keep-id", nil
+		}
+		if quirks.keepIDNoMapping {
+			return "", errors.New("USERNS keep-id with a uid:gid mapping needs Podman 4.3 or more recent")
+		}
+		return fmt.Sprintf("keep-id:uid=%d,gid=%d", *userns.UID, *userns.GID), nil
+	default:
+		if shouldUsePodmanKeepID(quirks, rootless) {
+			return "keep-id", nil
+		}
+		return "", nil
+	}
+}
+
+// Returns the `--userns` arguments of a Docker container.
+func dockerUsernsArgs(userns config.Userns) ([]string, error) {
+	switch userns.Mode {
+	case config.UsernsHost:
+		return []string{"--userns=host"}, nil
+	case config.UsernsKeepID:
+		return nil, errors.New("USERNS keep-id is only supported by Podman, Docker already maps the host user to the container's unless its daemon remaps users")
+	default:
+		return nil, nil
+	}
+}
+
+func shouldUsePodmanKeepID(quirks engineQuirks, rootless bool) bool {
+	if quirks.keepIDRootlessOnly && !rootless {
+		return false
+	}
+	return os.Getenv("CI") != "true" && supportsKeepID()
+}
+
+func supportsKeepID() bool {
+	if runtime.GOOS != "linux" {
+		return true
+	}
+	data, err := os.ReadFile("/proc/sys/user/max_user_namespaces")
+	if err != nil {
+		return true
+	}
+	return strings.TrimSpace(string(data)) != "0"
+}
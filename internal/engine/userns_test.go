@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+func TestPodmanUserns(t *testing.T) {
+	if !supportsKeepID() {
+		t.Skip("user namespaces are disabled on this host")
+	}
+	t.Setenv("CI", "")
+	uid, gid := 1001, 1002
+	recent := quirksFor("podman", "5.2.2")
+	old := quirksFor("podman", "4.2.0")
+
+	tests := []struct {
+		name     string
+		userns   config.Userns
+		quirks   engineQuirks
+		rootless bool
+		want     string
+		wantErr  bool
+	}{
+		{name: "default rootless", quirks: recent, rootless: true, want: "keep-id"},
+		{name: "default rootful on old podman", quirks: old, want: ""},
+		{name: "host", userns: config.Userns{Mode: config.UsernsHost}, quirks: old, want: "host"},
+		{name: "keep-id", userns: config.Userns{Mode: config.UsernsKeepID}, quirks: recent, want: "keep-id"},
+		{name: "keep-id rootful on old podman", userns: config.Userns{Mode: config.UsernsKeepID}, quirks: old, wantErr: true},
+		{name: "mapping", userns: config.Userns{Mode: config.UsernsKeepID, UID: &uid, GID: &gid}, quirks: recent, want: "keep-id:uid=1001,gid=1002"},
+		{name: "mapping on old podman", userns: config.Userns{Mode: config.UsernsKeepID, UID: &uid, GID: &gid}, quirks: old, rootless: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := podmanUserns(tt.userns, tt.quirks, tt.rootless)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("podmanUserns() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("podmanUserns() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Setenv("CI", "true")
+	if got, _ := podmanUserns(config.Userns{}, recent, true); got != "" {
+		t.Fatalf("podmanUserns() in CI = %q, want the engine's default", got)
+	}
+}
+
+func TestDockerUsernsArgs(t *testing.T) {
+	if args, err := dockerUsernsArgs(config.Userns{}); err != nil || len(args) != 0 {
+		t.Fatalf("dockerUsernsArgs() by default = %v, %v, want nothing", args, err)
+	}
+	if args, err := dockerUsernsArgs(config.Userns{Mode: config.UsernsHost}); err != nil || len(args) != 1 || args[0] != "--userns=host" {
+		t.Fatalf("dockerUsernsArgs() for host = %v, %v, want --userns=host", args, err)
+	}
+	if _, err := dockerUsernsArgs(config.Userns{Mode: config.UsernsKeepID}); err == nil {
+		t.Fatalf("dockerUsernsArgs() should refuse keep-id")
+	}
+}
@@ -0,0 +1,79 @@
+// # volume_archive.go
+// The content of volumes can be exported as a tar archive and imported back,
+// e.g. to back them up or to copy them. Podman does it natively, Docker
+// through `tar` run in a throwaway container of the shared base image, which
+// every engine used by paul-envs has.
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Arguments of the `docker run` call writing the content of the given volume
+// to its stdout as a tar archive if `export` is set, or extracting one read
+// from its stdin into it otherwise. Owners and permissions are kept as is.
+func volumeTarArgs(name string, export bool) []string {
+	cmdArgs := []string{"run", "--rm", "--user", "0", "--network", "none"}
+	if export {
+		cmdArgs = append(cmdArgs, "--volume", name+":/volume:ro")
+	} else {
+		cmdArgs = append(cmdArgs, "--interactive", "--volume", name+":/volume")
+	}
+	cmdArgs = append(cmdArgs, "--entrypoint", "tar", baseImageNameFor(""), "--numeric-owner", "-C", "/volume")
+	if export {
+		return append(cmdArgs, "-cf", "-", ".")
+	}
+	return append(cmdArgs, "-xpf", "-")
+}
+
+func volumeTarError(action string, name string, err error) error {
+	return fmt.Errorf("failed to %s volume %s: %w\nHint: It is done through the shared base image, which can be built with 'paul-envs build --base'", action, name, err)
+}
+
+// Copy the content of the local volume of project `from`, mounted as its
+// home's ~/.container-local directory, to the one of project `to`, which is
+// removed again on failure.
+//
+// Returns `false` without copying anything if project `from` has no local
+// volume yet.
+func CopyProjectLocalVolume(ctx context.Context, c ContainerEngine, from string, to string) (bool, error) {
+	volumes, err := c.ListVolumes(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to list volumes: %w", err)
+	}
+	source := ProjectLocalVolumeName(from)
+	if !slices.ContainsFunc(volumes, func(v VolumeInfo) bool { return v.VolumeName == source }) {
+		return false, nil
+	}
+	target := ProjectLocalVolumeName(to)
+	if err := copyVolume(ctx, c, source, target); err != nil {
+		if rErr := c.RemoveVolume(ctx, VolumeInfo{VolumeName: target}); rErr != nil {
+			err = errors.Join(err, rErr)
+		}
+		return false, fmt.Errorf("failed to copy volume %s to %s: %w", source, target, err)
+	}
+	return true, nil
+}
+
+// Copy the content of volume `from` to volume `to`, creating it.
+func copyVolume(ctx context.Context, c ContainerEngine, from string, to string) error {
+	r, w := io.Pipe()
+	exported := make(chan error, 1)
+	go func() {
+		err := c.ExportVolume(ctx, from, w)
+		w.CloseWithError(err)
+		exported <- err
+	}()
+	err := c.ImportVolume(ctx, to, r)
+	// Unblocks the export if the import stopped reading
+	r.CloseWithError(err)
+	if exportErr := <-exported; exportErr != nil && err == nil {
+		err = exportErr
+	}
+	return err
+}
@@ -0,0 +1,23 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestVolumeTarArgs(t *testing.T) {
+	export := volumeTarArgs("paulenv-app-local", true)
+	if !slices.Contains(export, "paulenv-app-local:/volume:ro") || !slices.Equal(export[len(export)-3:], []string{"-cf", "-", "."}) {
+		t.Fatalf("volumeTarArgs(export) = %v", export)
+	}
+	if slices.Contains(export, "--interactive") {
+		t.Fatalf("volumeTarArgs(export) should not read stdin: %v", export)
+	}
+	restore := volumeTarArgs("paulenv-app-local", false)
+	if !slices.Contains(restore, "paulenv-app-local:/volume") || !slices.Contains(restore, "--interactive") {
+		t.Fatalf("volumeTarArgs(import) = %v", restore)
+	}
+	if !slices.Equal(restore[len(restore)-2:], []string{"-xpf", "-"}) {
+		t.Fatalf("volumeTarArgs(import) = %v", restore)
+	}
+}
@@ -0,0 +1,94 @@
+// # volume_inspect.go
+// Docker and Podman describe a volume with the same `volume inspect` output,
+// but only report the space it uses among their whole disk usage.
+
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/profiling"
+)
+
+// Fields shared by `docker volume inspect` and `podman volume inspect`
+// outputs, which are a JSON array of them.
+type volumeInspectEntry struct {
+	Name       string
+	Driver     string
+	Mountpoint string
+	CreatedAt  string
+}
+
+func parseVolumeInspect(output []byte) (VolumeInfo, error) {
+	var entries []volumeInspectEntry
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return VolumeInfo{}, fmt.Errorf("unexpected volume description: %w", err)
+	}
+	if len(entries) != 1 {
+		return VolumeInfo{}, fmt.Errorf("expected the description of one volume, got %d", len(entries))
+	}
+	entry := entries[0]
+	volume := VolumeInfo{
+		VolumeId:   entry.Name,
+		VolumeName: entry.Name,
+		Driver:     entry.Driver,
+		Mountpoint: entry.Mountpoint,
+	}
+	// Podman adds fractional seconds, Docker does not
+	if createdAt, err := time.Parse(time.RFC3339Nano, entry.CreatedAt); err == nil {
+		volume.CreatedAt = &createdAt
+	}
+	return volume, nil
+}
+
+// Set the size of the given volume from the engine's disk usage, leaving it
+// unknown if it cannot be obtained.
+func setVolumeSize(ctx context.Context, c ContainerEngine, volume *VolumeInfo) {
+	usage, err := c.GetDiskUsage(ctx)
+	if err != nil {
+		fmt.Fprintf(engineOutput, "Debug: could not obtain the size of volume %s: %s\n", volume.VolumeName, err)
+		return
+	}
+	if size, ok := usage.Volumes[volume.VolumeName]; ok {
+		volume.Size = &size
+	}
+}
+
+func (c *DockerEngine) InspectVolume(ctx context.Context, name string) (VolumeInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "docker InspectVolume")()
+	cmd := engineCommand(ctx, "docker", "volume", "inspect", name)
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return VolumeInfo{}, pErr
+		}
+		return VolumeInfo{}, fmt.Errorf("failed to inspect volume %s: %w", name, err)
+	}
+	volume, err := parseVolumeInspect(output)
+	if err != nil {
+		return VolumeInfo{}, fmt.Errorf("failed to inspect volume %s: %w", name, err)
+	}
+	setVolumeSize(ctx, c, &volume)
+	return volume, nil
+}
+
+func (c *PodmanEngine) InspectVolume(ctx context.Context, name string) (VolumeInfo, error) {
+	defer profiling.Track(profiling.CategoryEngine, "podman InspectVolume")()
+	cmd := c.command(ctx, "volume", "inspect", name)
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		if pErr := c.checkPermissions(ctx); pErr != nil {
+			return VolumeInfo{}, pErr
+		}
+		return VolumeInfo{}, fmt.Errorf("failed to inspect volume %s: %w", name, err)
+	}
+	volume, err := parseVolumeInspect(output)
+	if err != nil {
+		return VolumeInfo{}, fmt.Errorf("failed to inspect volume %s: %w", name, err)
+	}
+	setVolumeSize(ctx, c, &volume)
+	return volume, nil
+}
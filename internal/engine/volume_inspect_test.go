@@ -0,0 +1,32 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseVolumeInspect(t *testing.T) {
+	output := `[{"CreatedAt": "2026-03-01T10:20:30.123456789+01:00", "Driver": "local", "Labels": null,
+		"Mountpoint": "/home/me/.local/share/containers/storage/volumes/paulenv-demo-local/_data",
+		"Name": "paulenv-demo-local", "Options": {}, "Scope": "local"}]`
+	volume, err := parseVolumeInspect([]byte(output))
+	if err != nil {
+		t.Fatalf("parseVolumeInspect() error = %v", err)
+	}
+	if volume.VolumeName != "paulenv-demo-local" || volume.Driver != "local" ||
+		volume.Mountpoint != "/home/me/.local/share/containers/storage/volumes/paulenv-demo-local/_data" {
+		t.Fatalf("parseVolumeInspect() = %+v", volume)
+	}
+	want := time.Date(2026, 3, 1, 9, 20, 30, 123456789, time.UTC)
+	if volume.CreatedAt == nil || !volume.CreatedAt.Equal(want) {
+		t.Fatalf("parseVolumeInspect() CreatedAt = %v, want %v", volume.CreatedAt, want)
+	}
+
+	volume, err = parseVolumeInspect([]byte(`[{"CreatedAt": "2026-03-01T10:20:30Z", "Name": "paulenv-demo-local"}]`))
+	if err != nil || volume.CreatedAt == nil {
+		t.Fatalf("parseVolumeInspect() should parse Docker's creation times, got %+v, %v", volume, err)
+	}
+	if _, err := parseVolumeInspect([]byte(`[]`)); err == nil {
+		t.Fatalf("parseVolumeInspect() should fail without any volume")
+	}
+}
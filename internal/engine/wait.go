@@ -0,0 +1,148 @@
+// # wait.go
+// Waiting for a container to exit and summarizing how it went, through the
+// `wait` and `inspect` commands which Docker and Podman both implement.
+//
+// Project containers are run with `--rm`: their state is read before waiting
+// as it may be gone once they exited.
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How a container exited.
+type ContainerExit struct {
+	ExitCode int
+	// Time it ran for, `0` if unknown
+	Duration time.Duration
+	// Whether it was killed for exceeding its memory limit
+	OOMKilled bool
+}
+
+// e.g. "exit code 137 after 2m3s, out of memory"
+func (e ContainerExit) String() string {
+	desc := fmt.Sprintf("exit code %d", e.ExitCode)
+	if e.Duration > 0 {
+		desc += " after " + e.Duration.Round(100*time.Millisecond).String()
+	}
+	if e.OOMKilled {
+		desc += ", out of memory"
+	}
+	return desc
+}
+
+const exitStateFormat = "{{.State.OOMKilled}}\t{{.State.StartedAt}}\t{{.State.FinishedAt}}"
+
+// State of a container, as reported by `inspect --format exitStateFormat`.
+type exitState struct {
+	oomKilled bool
+	// Zero if unknown
+	startedAt time.Time
+	// Zero if unknown or still running
+	finishedAt time.Time
+}
+
+// Layouts of the timestamps of `inspect`: Docker's, then Podman's which is
+// the default formatting of Go's `time.Time`.
+var exitStateTimeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999 -0700 MST"}
+
+func parseExitState(output string) (exitState, error) {
+	parts := strings.Split(strings.TrimSpace(output), "\t")
+	if len(parts) != 3 {
+		return exitState{}, fmt.Errorf("unexpected container state %q", output)
+	}
+	oomKilled, err := strconv.ParseBool(parts[0])
+	if err != nil {
+		return exitState{}, fmt.Errorf("unexpected container state %q", output)
+	}
+	return exitState{
+		oomKilled:  oomKilled,
+		startedAt:  parseStateTime(parts[1]),
+		finishedAt: parseStateTime(parts[2]),
+	}, nil
+}
+
+func parseStateTime(value string) time.Time {
+	for _, layout := range exitStateTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil && t.Year() > 1 {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Creates a command running an engine's CLI with the given arguments.
+type commandFunc func(ctx context.Context, args ...string) *exec.Cmd
+
+func inspectExitState(ctx context.Context, command commandFunc, containerID string) (exitState, error) {
+	cmd := command(ctx, "container", "inspect", "--format", exitStateFormat, containerID)
+	output, err := engineCommandOutput(cmd)
+	if err != nil {
+		return exitState{}, err
+	}
+	return parseExitState(string(output))
+}
+
+// Returns when the given container started, with the given engine's CLI.
+func containerStartTime(ctx context.Context, command commandFunc, containerID string) (time.Time, error) {
+	state, err := inspectExitState(ctx, command, containerID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to inspect container %s: %w", containerID, err)
+	}
+	if state.startedAt.IsZero() {
+		return time.Time{}, fmt.Errorf("container %s has no known start time", containerID)
+	}
+	return state.startedAt, nil
+}
+
+// Wait until the given container exits, with the given engine's CLI.
+func waitContainer(ctx context.Context, command commandFunc, containerID string) (ContainerExit, error) {
+	before, err := inspectExitState(ctx, command, containerID)
+	if err != nil {
+		return ContainerExit{}, fmt.Errorf("failed to inspect container %s: %w", containerID, err)
+	}
+	output, err := engineCommandOutput(command(ctx, "container", "wait", containerID))
+	if err != nil {
+		return ContainerExit{}, fmt.Errorf("failed to wait for container %s: %w", containerID, err)
+	}
+	exitCode, err := strconv.Atoi(strings.TrimSpace(string(output)))
+	if err != nil {
+		return ContainerExit{}, fmt.Errorf("unexpected exit code %q of container %s", output, containerID)
+	}
+	return summarizeExit(exitCode, before, inspectAfterExit(ctx, command, containerID), time.Now()), nil
+}
+
+// State of an exited container, `nil` if it is already removed.
+func inspectAfterExit(ctx context.Context, command commandFunc, containerID string) *exitState {
+	state, err := inspectExitState(ctx, command, containerID)
+	if err != nil {
+		return nil
+	}
+	return &state
+}
+
+// Summarize how a container exited at `now` from its state before waiting and
+// after it exited, the latter being `nil` if it could not be read.
+func summarizeExit(exitCode int, before exitState, after *exitState, now time.Time) ContainerExit {
+	exit := ContainerExit{ExitCode: exitCode}
+	start, end := before.startedAt, now
+	if after != nil {
+		exit.OOMKilled = after.oomKilled
+		if !after.startedAt.IsZero() {
+			start = after.startedAt
+		}
+		if !after.finishedAt.IsZero() {
+			end = after.finishedAt
+		}
+	}
+	if !start.IsZero() && end.After(start) {
+		exit.Duration = end.Sub(start)
+	}
+	return exit
+}
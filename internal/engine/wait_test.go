@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseExitState(t *testing.T) {
+	started := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	for output, want := range map[string]exitState{
+		// Docker
+		"true\t2024-05-01T10:00:00Z\t2024-05-01T10:02:03.5Z\n": {oomKilled: true, startedAt: started, finishedAt: started.Add(123500 * time.Millisecond)},
+		"false\t2024-05-01T10:00:00Z\t0001-01-01T00:00:00Z\n":  {startedAt: started},
+		// Podman
+		"false\t2024-05-01 12:00:00 +0200 CEST\t0001-01-01 00:00:00 +0000 UTC\n": {startedAt: started},
+	} {
+		got, err := parseExitState(output)
+		if err != nil {
+			t.Fatalf("parseExitState(%q) error = %v", output, err)
+		}
+		if got.oomKilled != want.oomKilled || !got.startedAt.Equal(want.startedAt) || !got.finishedAt.Equal(want.finishedAt) {
+			t.Errorf("parseExitState(%q) = %+v, want %+v", output, got, want)
+		}
+	}
+	for _, output := range []string{"", "garbage", "maybe\tx\ty"} {
+		if _, err := parseExitState(output); err == nil {
+			t.Errorf("parseExitState(%q): expected an error, got none", output)
+		}
+	}
+}
+
+func TestSummarizeExit(t *testing.T) {
+	started := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	before := exitState{startedAt: started}
+	now := started.Add(time.Minute)
+
+	after := exitState{oomKilled: true, startedAt: started, finishedAt: started.Add(30 * time.Second)}
+	got := summarizeExit(137, before, &after, now)
+	want := ContainerExit{ExitCode: 137, Duration: 30 * time.Second, OOMKilled: true}
+	if got != want {
+		t.Errorf("summarizeExit() = %+v, want %+v", got, want)
+	}
+	if got.String() != "exit code 137 after 30s, out of memory" {
+		t.Errorf("String() = %q", got.String())
+	}
+
+	// Removed once exited
+	got = summarizeExit(1, before, nil, now)
+	if want := (ContainerExit{ExitCode: 1, Duration: time.Minute}); got != want {
+		t.Errorf("summarizeExit() of a removed container = %+v, want %+v", got, want)
+	}
+	got = summarizeExit(0, exitState{}, nil, now)
+	if want := (ContainerExit{}); got != want || got.String() != "exit code 0" {
+		t.Errorf("summarizeExit() without start time = %+v, want %+v", got, want)
+	}
+}
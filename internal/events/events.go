@@ -0,0 +1,136 @@
+// # events.go
+// Lifecycle events of projects (creation, builds, runs, removal) that other
+// parts of paul-envs (e.g. a TUI or a background process) can subscribe to,
+// without the commands emitting them having to know about them. e.g. the
+// durations of builds displayed by `paul-envs times` are recorded from their
+// `BuildStart` and `BuildEnd` events.
+//
+// Handlers are called synchronously, in the goroutine emitting the event, in
+// the order they subscribed. They should thus return quickly.
+
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Kind of lifecycle event
+type Kind string
+
+// A project was created
+const ProjectCreated Kind = "project-created"
+
+// A project was removed, with its container resources
+const ProjectRemoved Kind = "project-removed"
+
+// The image of a project is about to be built
+const BuildStart Kind = "build-start"
+
+// The build of a project's image ended, `Event.Err` is set if it failed
+const BuildEnd Kind = "build-end"
+
+// A container of a project is about to be run (or joined)
+const RunStart Kind = "run-start"
+
+// A container run (or joined) by paul-envs exited, `Event.Err` is set if it
+// did not succeed
+const RunEnd Kind = "run-end"
+
+// A single lifecycle event.
+type Event struct {
+	Kind    Kind
+	Project string
+	Time    time.Time
+	// Error with which the operation ended, only for `*End` events
+	Err error
+}
+
+// Function called when an event is emitted.
+type Handler func(Event)
+
+// Set of handlers subscribed to events.
+//
+// The zero value is ready to use.
+type Bus struct {
+	mu       sync.Mutex
+	nextID   int
+	handlers []subscription
+}
+
+type subscription struct {
+	id      int
+	kind    Kind
+	handler Handler
+}
+
+// Bus to which commands emit their events.
+var Default = &Bus{}
+
+// Call `handler` on each event of the given kind. An empty kind subscribes to
+// all events.
+//
+// The returned function removes the subscription.
+func (b *Bus) Subscribe(kind Kind, handler Handler) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	id := b.nextID
+	b.handlers = append(b.handlers, subscription{id: id, kind: kind, handler: handler})
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, sub := range b.handlers {
+			if sub.id == id {
+				b.handlers = append(b.handlers[:i:i], b.handlers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Call handlers subscribed to the event's kind. Its `Time` is set to now if
+// not set.
+func (b *Bus) Emit(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	b.mu.Lock()
+	// Copied so handlers can (un)subscribe without deadlocking
+	handlers := make([]Handler, 0, len(b.handlers))
+	for _, sub := range b.handlers {
+		if sub.kind == "" || sub.kind == event.Kind {
+			handlers = append(handlers, sub.handler)
+		}
+	}
+	b.mu.Unlock()
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// Emit an event of the given kind for the given project on the `Default` bus.
+func Emit(kind Kind, project string, err error) {
+	Default.Emit(Event{Kind: kind, Project: project, Err: err})
+}
+
+// Subscribe to all events on the `Default` bus.
+func OnAny(handler Handler) func() { return Default.Subscribe("", handler) }
+
+// Subscribe to `ProjectCreated` events on the `Default` bus.
+func OnProjectCreated(handler Handler) func() { return Default.Subscribe(ProjectCreated, handler) }
+
+// Subscribe to `ProjectRemoved` events on the `Default` bus.
+func OnProjectRemoved(handler Handler) func() { return Default.Subscribe(ProjectRemoved, handler) }
+
+// Subscribe to `BuildStart` events on the `Default` bus.
+func OnBuildStart(handler Handler) func() { return Default.Subscribe(BuildStart, handler) }
+
+// Subscribe to `BuildEnd` events on the `Default` bus.
+func OnBuildEnd(handler Handler) func() { return Default.Subscribe(BuildEnd, handler) }
+
+// Subscribe to `RunStart` events on the `Default` bus.
+func OnRunStart(handler Handler) func() { return Default.Subscribe(RunStart, handler) }
+
+// Subscribe to `RunEnd` events on the `Default` bus.
+func OnRunEnd(handler Handler) func() { return Default.Subscribe(RunEnd, handler) }
@@ -0,0 +1,70 @@
+package events
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestBus_SubscribeByKind(t *testing.T) {
+	var bus Bus
+	var got []string
+	bus.Subscribe(BuildStart, func(e Event) { got = append(got, "start:"+e.Project) })
+	bus.Subscribe(BuildEnd, func(e Event) { got = append(got, "end:"+e.Project) })
+	bus.Subscribe("", func(e Event) { got = append(got, "any:"+string(e.Kind)) })
+
+	bus.Emit(Event{Kind: BuildStart, Project: "app"})
+	bus.Emit(Event{Kind: RunStart, Project: "app"})
+	bus.Emit(Event{Kind: BuildEnd, Project: "app"})
+
+	want := []string{"start:app", "any:build-start", "any:run-start", "end:app", "any:build-end"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("handlers called %v, want %v", got, want)
+	}
+}
+
+func TestBus_Unsubscribe(t *testing.T) {
+	var bus Bus
+	calls := 0
+	unsubscribe := bus.Subscribe(RunEnd, func(Event) { calls++ })
+	other := 0
+	bus.Subscribe(RunEnd, func(Event) { other++ })
+
+	bus.Emit(Event{Kind: RunEnd})
+	unsubscribe()
+	unsubscribe()
+	bus.Emit(Event{Kind: RunEnd})
+
+	if calls != 1 || other != 2 {
+		t.Fatalf("calls = %d and %d, want 1 and 2", calls, other)
+	}
+}
+
+func TestBus_EmitFillsTimeAndErr(t *testing.T) {
+	var bus Bus
+	var got Event
+	bus.Subscribe(BuildEnd, func(e Event) { got = e })
+	buildErr := errors.New("boom")
+	bus.Emit(Event{Kind: BuildEnd, Project: "app", Err: buildErr})
+	if got.Time.IsZero() {
+		t.Fatal("expected event time to be set")
+	}
+	if !errors.Is(got.Err, buildErr) {
+		t.Fatalf("Err = %v, want %v", got.Err, buildErr)
+	}
+}
+
+func TestBus_HandlerCanUnsubscribeItself(t *testing.T) {
+	var bus Bus
+	calls := 0
+	var unsubscribe func()
+	unsubscribe = bus.Subscribe("", func(Event) {
+		calls++
+		unsubscribe()
+	})
+	bus.Emit(Event{Kind: RunStart})
+	bus.Emit(Event{Kind: RunStart})
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+}
@@ -0,0 +1,69 @@
+// # activity.go
+// This file handles when paul-envs was last attached to each of a project's
+// containers, refreshed while it stays attached, so those which are left
+// running with nothing attached to them (a detached persistent session, a
+// `run` whose terminal was closed...) can be stopped once idle for too long.
+
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const projectActivityFilename = "activity"
+
+// Returns when each instance of that project's container was last in use, by
+// instance name, its default one being "".
+func (f *FileStore) GetProjectActivity(projectName string) (map[string]time.Time, error) {
+	data, err := os.ReadFile(f.getProjectActivityPath(projectName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]time.Time{}, nil
+		}
+		return nil, fmt.Errorf("could not read '%s': %w", projectActivityFilename, err)
+	}
+	activity := map[string]time.Time{}
+	for line := range strings.SplitSeq(string(data), "\n") {
+		at, instance, _ := strings.Cut(line, "\t")
+		if t, err := time.Parse(time.RFC3339, at); err == nil {
+			activity[instance] = t
+		}
+	}
+	return activity, nil
+}
+
+// Record that the given instance of that project's container ("" for its
+// default one) was in use at `at`.
+func (f *FileStore) RecordProjectActivity(projectName string, instance string, at time.Time) error {
+	activity, err := f.GetProjectActivity(projectName)
+	if err != nil {
+		return err
+	}
+	activity[instance] = at
+
+	instances := make([]string, 0, len(activity))
+	for name := range activity {
+		instances = append(instances, name)
+	}
+	slices.Sort(instances)
+	var content strings.Builder
+	for _, name := range instances {
+		fmt.Fprintf(&content, "%s\t%s\n", activity[name].UTC().Format(time.RFC3339), name)
+	}
+	if err := f.userFS.MkdirAsUser(f.getProjectInternalDir(projectName), 0755); err != nil {
+		return fmt.Errorf("cannot create project internal directory: %w", err)
+	}
+	if err := f.userFS.WriteFileAsUser(f.getProjectActivityPath(projectName), []byte(content.String()), 0644); err != nil {
+		return fmt.Errorf("could not write '%s': %w", projectActivityFilename, err)
+	}
+	return nil
+}
+
+func (f *FileStore) getProjectActivityPath(projectName string) string {
+	return filepath.Join(f.getProjectInternalDir(projectName), projectActivityFilename)
+}
@@ -0,0 +1,36 @@
+package files
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProjectActivity(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	store, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+
+	activity, err := store.GetProjectActivity("myproject")
+	if err != nil || len(activity) != 0 {
+		t.Fatalf("GetProjectActivity() = %v, %v, want none", activity, err)
+	}
+	first := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+	for _, record := range []struct {
+		instance string
+		at       time.Time
+	}{{"", first}, {"tests", first}, {"", second}} {
+		if err := store.RecordProjectActivity("myproject", record.instance, record.at); err != nil {
+			t.Fatalf("RecordProjectActivity(%q) error = %v", record.instance, err)
+		}
+	}
+	activity, err = store.GetProjectActivity("myproject")
+	if err != nil {
+		t.Fatalf("GetProjectActivity() error = %v", err)
+	}
+	if len(activity) != 2 || !activity[""].Equal(second) || !activity["tests"].Equal(first) {
+		t.Fatalf("GetProjectActivity() = %v, want the default instance at %v and tests at %v", activity, second, first)
+	}
+}
@@ -0,0 +1,26 @@
+// # api_socket.go
+// This file handles where `paul-envs api` serves its local HTTP API, through
+// which other tools (editor plugins, status bars...) drive paul-envs.
+
+package files
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+const apiSocketFilename = "api.sock"
+
+// Get the default path of the socket `paul-envs api` listens on.
+func (f *FileStore) GetAPISocketPath() string {
+	return filepath.Join(f.baseDataDir, apiSocketFilename)
+}
+
+// Create the directory in which the socket of `paul-envs api` is created by
+// default.
+func (f *FileStore) PrepareAPISocketDir() error {
+	if err := f.userFS.MkdirAsUser(f.baseDataDir, 0755); err != nil {
+		return fmt.Errorf("cannot create data directory: %w", err)
+	}
+	return nil
+}
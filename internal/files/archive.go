@@ -0,0 +1,125 @@
+// # archive.go
+// This file writes and reads gzipped tar archives of files, such as the
+// diagnostics collected by `support-bundle` or project bundles.
+
+package files
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/profiling"
+)
+
+// A file to write in an archive.
+type ArchiveFile struct {
+	// Its path inside the archive
+	Name string
+	Data []byte
+	// Its permissions, 0600 if unset
+	Mode os.FileMode
+}
+
+// Write the given files as a gzipped tar archive at `path`, replacing it if
+// it already exists.
+//
+// It is only readable by the current user, as it may contain information on
+// the host.
+func (f *FileStore) WriteArchive(path string, archiveFiles []ArchiveFile) error {
+	defer profiling.Track(profiling.CategoryFiles, "write archive "+path)()
+	data, err := buildArchive(archiveFiles, time.Now())
+	if err != nil {
+		return fmt.Errorf("cannot create archive: %w", err)
+	}
+	if err := f.userFS.WriteFileAsUser(path, data, 0600); err != nil {
+		return fmt.Errorf("cannot write archive '%s': %w", path, err)
+	}
+	return nil
+}
+
+func buildArchive(archiveFiles []ArchiveFile, modTime time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, file := range archiveFiles {
+		mode := file.Mode
+		if mode == 0 {
+			mode = 0600
+		}
+		header := &tar.Header{
+			Name:    file.Name,
+			Mode:    int64(mode.Perm()),
+			Size:    int64(len(file.Data)),
+			ModTime: modTime,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(file.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Read the regular files of the gzipped tar archive at `path`.
+//
+// Fails on files whose path would escape the directory it is extracted to.
+func ReadArchive(archivePath string) ([]ArchiveFile, error) {
+	defer profiling.Track(profiling.CategoryFiles, "read archive "+archivePath)()
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open archive '%s': %w", archivePath, err)
+	}
+	defer file.Close()
+	archiveFiles, err := readArchive(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read archive '%s': %w", archivePath, err)
+	}
+	return archiveFiles, nil
+}
+
+func readArchive(r io.Reader) ([]ArchiveFile, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	var archiveFiles []ArchiveFile
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return archiveFiles, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("invalid file path %q", header.Name)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		archiveFiles = append(archiveFiles, ArchiveFile{Name: name, Data: data, Mode: os.FileMode(header.Mode).Perm()})
+	}
+}
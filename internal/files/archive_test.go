@@ -0,0 +1,85 @@
+package files
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteArchive(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	store, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	want := []ArchiveFile{
+		{Name: "bundle/doctor.txt", Data: []byte("all good\n")},
+		{Name: "bundle/project/run.conf", Data: []byte("PATH /srv\n")},
+	}
+	if err := store.WriteArchive(path, want); err != nil {
+		t.Fatalf("WriteArchive() error = %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	tr := tar.NewReader(gz)
+	for _, expected := range want {
+		header, err := tr.Next()
+		if err != nil {
+			t.Fatalf("reading %s: %v", expected.Name, err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Name != expected.Name || string(data) != string(expected.Data) {
+			t.Fatalf("archive entry = %s %q, want %s %q", header.Name, data, expected.Name, expected.Data)
+		}
+	}
+	if _, err := tr.Next(); err != io.EOF {
+		t.Fatalf("expected the end of the archive, got %v", err)
+	}
+}
+
+func TestReadArchive(t *testing.T) {
+	store := &FileStore{userFS: &UserFS{homeDir: t.TempDir()}}
+	path := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	want := []ArchiveFile{
+		{Name: "run.conf", Data: []byte("PATH /srv\n"), Mode: 0644},
+		{Name: "dotfiles/.zshrc", Data: []byte("bindkey -v\n"), Mode: 0600},
+	}
+	if err := store.WriteArchive(path, want); err != nil {
+		t.Fatalf("WriteArchive() error = %v", err)
+	}
+	got, err := ReadArchive(path)
+	if err != nil {
+		t.Fatalf("ReadArchive() error = %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ReadArchive() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].Name != want[i].Name || string(got[i].Data) != string(want[i].Data) || got[i].Mode != want[i].Mode {
+			t.Fatalf("ReadArchive()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	unsafe := filepath.Join(t.TempDir(), "unsafe.tar.gz")
+	if err := store.WriteArchive(unsafe, []ArchiveFile{{Name: "../escape", Data: []byte("x")}}); err != nil {
+		t.Fatalf("WriteArchive() error = %v", err)
+	}
+	if _, err := ReadArchive(unsafe); err == nil {
+		t.Fatal("ReadArchive() should reject paths outside of the archive")
+	}
+}
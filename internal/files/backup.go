@@ -0,0 +1,473 @@
+// # backup.go
+// The whole state of paul-envs (its global configuration and dotfiles, every
+// project's definition and what it knows of their builds) can be saved in a
+// single archive and restored from it, e.g. to move to a new machine or to
+// recover from an accidental removal. The content of the projects' volumes can
+// be saved alongside it.
+//
+// Volumes can be large, so archives are written and read as streams instead of
+// in memory.
+
+package files
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/profiling"
+)
+
+const (
+	backupManifestFilename = "paulenv-backup"
+	backupVersion          = 1
+	backupDataDir          = "data"
+	backupConfigDir        = "config"
+	backupVolumesDir       = "volumes"
+)
+
+// Entries of the data directory which are not backed up, as they are specific
+// to this machine or to running processes, or generated from the global
+// configuration.
+var backupExcludedData = []string{locksDirname, "machine-id", migrationBackupsDirname, checkpointsDirname, registriesDirname}
+
+// Returned by `RestoreBackup` when files it would replace already exist with
+// another content.
+var ErrBackupConflict = errors.New("files already exist")
+
+// What a backup contains, as written in its manifest.
+type BackupManifest struct {
+	CreatedAt time.Time
+	// Names of the projects it contains
+	Projects []string
+	// Names of the volumes whose content it contains
+	Volumes []string
+}
+
+// Write a backup of the data and configuration directories at `path`, along
+// with the content of the given volumes, written by `exportVolume` as a tar
+// archive.
+func (f *FileStore) WriteBackup(
+	ctx context.Context,
+	path string,
+	volumes []string,
+	exportVolume func(name string, w io.Writer) error,
+) (BackupManifest, error) {
+	defer profiling.Track(profiling.CategoryFiles, "write backup "+path)()
+	projects, err := f.GetAllProjects()
+	if err != nil {
+		return BackupManifest{}, err
+	}
+	manifest := BackupManifest{CreatedAt: time.Now(), Volumes: volumes}
+	for _, project := range projects {
+		manifest.Projects = append(manifest.Projects, project.ProjectName)
+	}
+
+	file, err := f.userFS.CreateFileAsUser(path, 0600)
+	if err != nil {
+		return BackupManifest{}, fmt.Errorf("cannot create backup '%s': %w", path, err)
+	}
+	err = f.writeBackup(ctx, file, manifest, exportVolume, filepath.Dir(path))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return BackupManifest{}, fmt.Errorf("cannot write backup '%s': %w", path, err)
+	}
+	return manifest, nil
+}
+
+func (f *FileStore) writeBackup(
+	ctx context.Context,
+	w io.Writer,
+	manifest BackupManifest,
+	exportVolume func(name string, w io.Writer) error,
+	tmpDir string,
+) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	data := formatBackupManifest(manifest)
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    backupManifestFilename,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: manifest.CreatedAt,
+	}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return err
+	}
+	if err := addBackupDir(ctx, tw, f.baseDataDir, backupDataDir, backupExcludedData); err != nil {
+		return err
+	}
+	if err := addBackupDir(ctx, tw, f.baseConfigDir, backupConfigDir, nil); err != nil {
+		return err
+	}
+	for _, volume := range manifest.Volumes {
+		if err := addBackupVolume(tw, volume, exportVolume, tmpDir); err != nil {
+			return fmt.Errorf("cannot back up volume %s: %w", volume, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Add the regular files and symbolic links of `dir` to the backup, under
+// `prefix`, except the `excluded` entries at its root.
+func addBackupDir(ctx context.Context, tw *tar.Writer, dir string, prefix string, excluded []string) error {
+	err := filepath.WalkDir(dir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) && filePath == dir {
+				return filepath.SkipDir
+			}
+			return fmt.Errorf("walk %q: %w", filePath, err)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(excluded, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		linkTarget := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if linkTarget, err = os.Readlink(filePath); err != nil {
+				return err
+			}
+		} else if !info.Mode().IsRegular() {
+			return nil
+		}
+		header, err := tar.FileInfoHeader(info, linkTarget)
+		if err != nil {
+			return err
+		}
+		header.Name = prefix + "/" + filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if linkTarget != "" {
+			return nil
+		}
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("cannot back up '%s': %w", dir, err)
+	}
+	return nil
+}
+
+// Add the content of the given volume to the backup. It is first exported to
+// a temporary file in `tmpDir`, as its size has to be known beforehand.
+func addBackupVolume(tw *tar.Writer, volume string, exportVolume func(name string, w io.Writer) error, tmpDir string) error {
+	tmp, err := os.CreateTemp(tmpDir, ".paulenv-volume-*.tar")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	if err := exportVolume(volume, tmp); err != nil {
+		return err
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    backupVolumesDir + "/" + volume + ".tar",
+		Mode:    0600,
+		Size:    size,
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, tmp)
+	return err
+}
+
+func formatBackupManifest(manifest BackupManifest) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("# paul-envs backup, restore it with 'paul-envs restore <backup>'\n")
+	fmt.Fprintf(&buf, "VERSION %d\n", backupVersion)
+	fmt.Fprintf(&buf, "CREATED_AT %s\n", manifest.CreatedAt.Format(time.RFC3339))
+	for _, project := range manifest.Projects {
+		fmt.Fprintf(&buf, "PROJECT %s\n", project)
+	}
+	for _, volume := range manifest.Volumes {
+		fmt.Fprintf(&buf, "VOLUME %s\n", volume)
+	}
+	return buf.Bytes()
+}
+
+func parseBackupManifest(data []byte) (BackupManifest, error) {
+	var manifest BackupManifest
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "VERSION":
+			version, err := strconv.Atoi(value)
+			if err != nil || version > backupVersion {
+				return BackupManifest{}, fmt.Errorf("unsupported backup version %q, paul-envs may need to be updated", value)
+			}
+		case "CREATED_AT":
+			createdAt, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return BackupManifest{}, fmt.Errorf("invalid backup date %q: %w", value, err)
+			}
+			manifest.CreatedAt = createdAt
+		case "PROJECT":
+			manifest.Projects = append(manifest.Projects, value)
+		case "VOLUME":
+			manifest.Volumes = append(manifest.Volumes, value)
+		}
+	}
+	return manifest, scanner.Err()
+}
+
+// Call `onEntry` for each entry of the backup at `backupPath` following its
+// manifest, which is returned.
+func walkBackup(backupPath string, onEntry func(header *tar.Header, r io.Reader) error) (BackupManifest, error) {
+	file, err := os.Open(backupPath)
+	if err != nil {
+		return BackupManifest{}, fmt.Errorf("cannot open backup '%s': %w", backupPath, err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return BackupManifest{}, fmt.Errorf("'%s' is not a paul-envs backup: %w", backupPath, err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	header, err := tr.Next()
+	if err != nil || header.Name != backupManifestFilename {
+		return BackupManifest{}, fmt.Errorf("'%s' is not a paul-envs backup", backupPath)
+	}
+	data, err := io.ReadAll(tr)
+	if err != nil {
+		return BackupManifest{}, fmt.Errorf("cannot read backup '%s': %w", backupPath, err)
+	}
+	manifest, err := parseBackupManifest(data)
+	if err != nil {
+		return BackupManifest{}, err
+	}
+	if onEntry == nil {
+		return manifest, nil
+	}
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return manifest, nil
+		}
+		if err != nil {
+			return BackupManifest{}, fmt.Errorf("cannot read backup '%s': %w", backupPath, err)
+		}
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return BackupManifest{}, fmt.Errorf("invalid file path %q in backup", header.Name)
+		}
+		header.Name = name
+		if err := onEntry(header, tr); err != nil {
+			return BackupManifest{}, err
+		}
+	}
+}
+
+// Read the manifest of the backup at `backupPath`.
+func ReadBackupManifest(backupPath string) (BackupManifest, error) {
+	return walkBackup(backupPath, nil)
+}
+
+// Restore the data and configuration directories from the backup at
+// `backupPath`. Files which are not in it are kept.
+//
+// Unless `overwrite` is set, nothing is restored if files it contains already
+// exist with another content, and an error wrapping `ErrBackupConflict` is
+// returned.
+func (f *FileStore) RestoreBackup(ctx context.Context, backupPath string, overwrite bool) (BackupManifest, error) {
+	defer profiling.Track(profiling.CategoryFiles, "restore backup "+backupPath)()
+	if !overwrite {
+		var conflicts []string
+		_, err := walkBackup(backupPath, func(header *tar.Header, r io.Reader) error {
+			target := f.backupTarget(header.Name)
+			if target != "" && backupEntryConflicts(header, r, target) {
+				conflicts = append(conflicts, target)
+			}
+			return nil
+		})
+		if err != nil {
+			return BackupManifest{}, err
+		}
+		if len(conflicts) > 0 {
+			return BackupManifest{}, fmt.Errorf("%w with another content: %s", ErrBackupConflict, summarizePaths(conflicts, 5))
+		}
+	}
+	return walkBackup(backupPath, func(header *tar.Header, r io.Reader) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		target := f.backupTarget(header.Name)
+		if target == "" {
+			return nil
+		}
+		if err := f.restoreBackupEntry(header, r, target); err != nil {
+			return fmt.Errorf("cannot restore '%s': %w", target, err)
+		}
+		return nil
+	})
+}
+
+// Restore the content of the volumes of the backup at `backupPath` through
+// `importVolume`, for those `restoreVolume` returns `true` for.
+func RestoreBackupVolumes(
+	backupPath string,
+	restoreVolume func(name string) bool,
+	importVolume func(name string, r io.Reader) error,
+) error {
+	_, err := walkBackup(backupPath, func(header *tar.Header, r io.Reader) error {
+		rest, ok := strings.CutPrefix(header.Name, backupVolumesDir+"/")
+		volume, isTar := strings.CutSuffix(rest, ".tar")
+		if !ok || !isTar || strings.Contains(volume, "/") || !restoreVolume(volume) {
+			return nil
+		}
+		if err := importVolume(volume, r); err != nil {
+			return fmt.Errorf("cannot restore volume %s: %w", volume, err)
+		}
+		return nil
+	})
+	return err
+}
+
+// Returns the path on this host the given backup entry is restored to, empty
+// if it is not a file of the data or configuration directories.
+func (f *FileStore) backupTarget(name string) string {
+	if rest, ok := strings.CutPrefix(name, backupDataDir+"/"); ok {
+		first, _, _ := strings.Cut(rest, "/")
+		if slices.Contains(backupExcludedData, first) {
+			return ""
+		}
+		return filepath.Join(f.baseDataDir, filepath.FromSlash(rest))
+	}
+	if rest, ok := strings.CutPrefix(name, backupConfigDir+"/"); ok {
+		return filepath.Join(f.baseConfigDir, filepath.FromSlash(rest))
+	}
+	return ""
+}
+
+// Returns `true` if `target` exists with another content than the given
+// backup entry.
+func backupEntryConflicts(header *tar.Header, r io.Reader, target string) bool {
+	info, err := os.Lstat(target)
+	if err != nil {
+		return false
+	}
+	switch header.Typeflag {
+	case tar.TypeSymlink:
+		linkTarget, err := os.Readlink(target)
+		return err != nil || linkTarget != header.Linkname
+	case tar.TypeReg:
+		if !info.Mode().IsRegular() || info.Size() != header.Size {
+			return true
+		}
+		existing, err := os.ReadFile(target)
+		if err != nil {
+			return true
+		}
+		data, err := io.ReadAll(r)
+		return err != nil || !bytes.Equal(existing, data)
+	}
+	return false
+}
+
+func (f *FileStore) restoreBackupEntry(header *tar.Header, r io.Reader, target string) error {
+	if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeSymlink {
+		return nil
+	}
+	dir := filepath.Dir(target)
+	if err := f.userFS.MkdirAsUser(dir, 0755); err != nil {
+		return err
+	}
+	// Entries are never written through a symbolic link leading out of the
+	// restored directories
+	if resolved, err := filepath.EvalSymlinks(dir); err != nil || !f.isInBackedUpDir(resolved) {
+		return fmt.Errorf("'%s' leads out of paul-envs' directories", dir)
+	}
+	if info, err := os.Lstat(target); err == nil && (header.Typeflag == tar.TypeSymlink || info.Mode()&os.ModeSymlink != 0) {
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+	}
+	if header.Typeflag == tar.TypeSymlink {
+		if err := os.Symlink(header.Linkname, target); err != nil {
+			return err
+		}
+		return f.userFS.chownIfNeeded(target)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return f.userFS.WriteFileAsUser(target, data, os.FileMode(header.Mode).Perm())
+}
+
+func (f *FileStore) isInBackedUpDir(path string) bool {
+	for _, dir := range []string{f.baseDataDir, f.baseConfigDir} {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = resolved
+		}
+		if rel, err := filepath.Rel(dir, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Join the first `limit` paths, telling how many others there are.
+func summarizePaths(paths []string, limit int) string {
+	if len(paths) <= limit {
+		return strings.Join(paths, ", ")
+	}
+	return fmt.Sprintf("%s and %d others", strings.Join(paths[:limit], ", "), len(paths)-limit)
+}
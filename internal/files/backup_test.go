@@ -0,0 +1,122 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func newBackupTestStore(t *testing.T) *FileStore {
+	t.Helper()
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	store, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	return store
+}
+
+func TestBackupRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source := newBackupTestStore(t)
+	bundle := ProjectBundle{
+		ProjectName:   "app",
+		BuildConfig:   []byte("VERSION 1.0.0\nHOST_UID 1000\nHOST_GID 1000\nUSERNAME dev\nUSER_SHELL zsh\n"),
+		RuntimeConfig: []byte("VERSION 1.0.0\nPATH /home/alice/app\n"),
+	}
+	if err := source.ImportProjectBundle(ctx, "app", bundle, bundle.RuntimeConfig); err != nil {
+		t.Fatalf("ImportProjectBundle() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(source.baseDataDir, "machine-id"), []byte("source"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(source.baseConfigDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(source.GetGlobalConfigPath(), []byte("BASE_IMAGE ubuntu:24.04\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "backup.tar.gz")
+	manifest, err := source.WriteBackup(ctx, path, []string{"paulenv-app-local"}, func(name string, w io.Writer) error {
+		_, err := io.WriteString(w, "content of "+name)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WriteBackup() error = %v", err)
+	}
+	if !slices.Equal(manifest.Projects, []string{"app"}) {
+		t.Fatalf("WriteBackup() projects = %v", manifest.Projects)
+	}
+	read, err := ReadBackupManifest(path)
+	if err != nil {
+		t.Fatalf("ReadBackupManifest() error = %v", err)
+	}
+	if !slices.Equal(read.Projects, manifest.Projects) || !slices.Equal(read.Volumes, manifest.Volumes) {
+		t.Fatalf("ReadBackupManifest() = %+v, want %+v", read, manifest)
+	}
+
+	target := newBackupTestStore(t)
+	if _, err := target.RestoreBackup(ctx, path, false); err != nil {
+		t.Fatalf("RestoreBackup() error = %v", err)
+	}
+	if !target.DoesProjectExist("app") {
+		t.Fatal("RestoreBackup() should restore the project")
+	}
+	data, err := os.ReadFile(target.GetGlobalConfigPath())
+	if err != nil || string(data) != "BASE_IMAGE ubuntu:24.04\n" {
+		t.Fatalf("restored global config = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(target.baseDataDir, "machine-id")); !os.IsNotExist(err) {
+		t.Fatalf("RestoreBackup() should not restore the machine id, got %v", err)
+	}
+
+	// Restoring again the same content is not a conflict
+	if _, err := target.RestoreBackup(ctx, path, false); err != nil {
+		t.Fatalf("RestoreBackup() on the same content error = %v", err)
+	}
+	if err := os.WriteFile(target.GetGlobalConfigPath(), []byte("BASE_IMAGE debian:13\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := target.RestoreBackup(ctx, path, false); !errors.Is(err, ErrBackupConflict) {
+		t.Fatalf("RestoreBackup() error = %v, want ErrBackupConflict", err)
+	}
+	data, _ = os.ReadFile(target.GetGlobalConfigPath())
+	if string(data) != "BASE_IMAGE debian:13\n" {
+		t.Fatalf("a conflicting RestoreBackup() should not write anything, got %q", data)
+	}
+	if _, err := target.RestoreBackup(ctx, path, true); err != nil {
+		t.Fatalf("RestoreBackup() with overwrite error = %v", err)
+	}
+	data, _ = os.ReadFile(target.GetGlobalConfigPath())
+	if string(data) != "BASE_IMAGE ubuntu:24.04\n" {
+		t.Fatalf("RestoreBackup() with overwrite should replace the file, got %q", data)
+	}
+
+	restored := map[string]string{}
+	err = RestoreBackupVolumes(path, func(string) bool { return true }, func(name string, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		restored[name] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("RestoreBackupVolumes() error = %v", err)
+	}
+	if len(restored) != 1 || restored["paulenv-app-local"] != "content of paulenv-app-local" {
+		t.Fatalf("RestoreBackupVolumes() restored %v", restored)
+	}
+}
+
+func TestSummarizePaths(t *testing.T) {
+	if got := summarizePaths([]string{"a", "b"}, 5); got != "a, b" {
+		t.Fatalf("summarizePaths() = %q", got)
+	}
+	if got := summarizePaths([]string{"a", "b", "c"}, 2); got != "a, b and 1 others" {
+		t.Fatalf("summarizePaths() = %q", got)
+	}
+}
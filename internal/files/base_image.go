@@ -0,0 +1,153 @@
+// # base_image.go
+// This file keeps track of the shared `paulenv-base` image, on top of which
+// every project image is built, so it is only (re-)built when needed and
+// project images built on an older one can be detected.
+
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+// Information on the last build of the shared base image for a given
+// container engine.
+type baseImageBuildState struct {
+	// The hash of the `Dockerfile.base` file and distribution image used for
+	// that build
+	dockerfileHash string
+	// Digest of the distribution image it was built from, empty if unknown
+	distributionDigest string
+	// The last time it was built according to this tool
+	builtAt time.Time
+}
+
+// Directory containing the base files written by `RefreshBaseFiles`, used as
+// build context.
+func (f *FileStore) GetBaseFilesDir() string {
+	return f.baseDataDir
+}
+
+func (f *FileStore) getBaseImageBuildInfoPath(engineName string) string {
+	return filepath.Join(f.baseDataDir, fmt.Sprintf("base-%s.buildinfo", engineName))
+}
+
+// Update the file storing information on the last build of the shared base
+// image for the given container engine, from the given distribution image
+// (empty for the one of `Dockerfile.base`) resolved to `digest` (empty if
+// unknown).
+//
+// Should be called after each base image build.
+func (f *FileStore) RefreshBaseImageBuildInfo(engineName string, distributionImage string, digest string) error {
+	dockerfileHash, err := baseDockerfileHash(distributionImage)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "DOCKERFILE=%s\nLAST_BUILT_AT=%s\n", dockerfileHash, time.Now().Format(time.RFC3339))
+	if digest != "" {
+		fmt.Fprintf(&buf, "DISTRIBUTION_DIGEST=%s\n", digest)
+	}
+	path := f.getBaseImageBuildInfoPath(engineName)
+	if err := f.userFS.WriteFileAsUser(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write '%s': %w", path, err)
+	}
+	return nil
+}
+
+// Read information on the last build of the shared base image for the given
+// container engine.
+func (f *FileStore) ReadBaseImageBuildInfo(engineName string) (*baseImageBuildState, error) {
+	file, err := os.Open(f.getBaseImageBuildInfoPath(engineName))
+	if err != nil {
+		return nil, fmt.Errorf("could not open base image build info: %w", err)
+	}
+	defer file.Close()
+
+	var state baseImageBuildState
+	var parsedBuiltAt *time.Time
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if v, ok := strings.CutPrefix(line, "DOCKERFILE="); ok {
+			state.dockerfileHash = v
+			continue
+		}
+		if v, ok := strings.CutPrefix(line, "DISTRIBUTION_DIGEST="); ok {
+			state.distributionDigest = v
+			continue
+		}
+		if v, ok := strings.CutPrefix(line, "LAST_BUILT_AT="); ok {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid base image LAST_BUILT_AT value '%s': %w", v, err)
+			}
+			parsedBuiltAt = &parsed
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading base image build info: %w", err)
+	}
+	if parsedBuiltAt == nil {
+		return nil, errors.New("invalid base image build info: no LAST_BUILT_AT value")
+	}
+	state.builtAt = *parsedBuiltAt
+	return &state, nil
+}
+
+// Returns the digest of the distribution image the shared base image for the
+// given container engine was last built from, empty if unknown.
+func (f *FileStore) GetBaseImageDistributionDigest(engineName string) (string, error) {
+	state, err := f.ReadBaseImageBuildInfo(engineName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+	return state.distributionDigest, nil
+}
+
+// Returns `true` if the shared base image for the given container engine was
+// either never built through this tool or built from another `Dockerfile.base`
+// or distribution image, including the same one at another `digest` than the
+// given one.
+func (f *FileStore) IsBaseImageOutdated(engineName string, distributionImage string, digest string) (bool, error) {
+	state, err := f.ReadBaseImageBuildInfo(engineName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return true, nil
+		}
+		return true, err
+	}
+	currentHash, err := baseDockerfileHash(distributionImage)
+	if err != nil {
+		return true, err
+	}
+	if state.dockerfileHash != currentHash {
+		return true, nil
+	}
+	// Base images built before digests were recorded are kept
+	return digest != "" && state.distributionDigest != "" && state.distributionDigest != digest, nil
+}
+
+func baseDockerfileHash(distributionImage string) (string, error) {
+	data, err := assets.ReadFile("embeds/Dockerfile.base")
+	if err != nil {
+		return "", fmt.Errorf("cannot read base file 'Dockerfile.base': %w", err)
+	}
+	// Keep the hash of base images built from the default distribution image
+	// as it was before it could be changed
+	if distributionImage != "" {
+		data = fmt.Appendf(data, "\nDISTRIBUTION_IMAGE=%s\n", distributionImage)
+	}
+	return utils.BufferHash(data), nil
+}
@@ -0,0 +1,98 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileStore_IsBaseImageOutdated(t *testing.T) {
+	store := &FileStore{
+		userFS:      &UserFS{homeDir: t.TempDir()},
+		baseDataDir: t.TempDir(),
+	}
+
+	if outdated, err := store.IsBaseImageOutdated("podman", "", ""); err != nil || !outdated {
+		t.Fatalf("IsBaseImageOutdated() = %v, %v before any build, want true", outdated, err)
+	}
+
+	if err := store.RefreshBaseImageBuildInfo("podman", "", ""); err != nil {
+		t.Fatalf("RefreshBaseImageBuildInfo() error = %v", err)
+	}
+	if outdated, err := store.IsBaseImageOutdated("podman", "", ""); err != nil || outdated {
+		t.Fatalf("IsBaseImageOutdated() = %v, %v right after a build, want false", outdated, err)
+	}
+	if outdated, err := store.IsBaseImageOutdated("docker", "", ""); err != nil || !outdated {
+		t.Fatalf("IsBaseImageOutdated() = %v, %v for another engine, want true", outdated, err)
+	}
+	if outdated, err := store.IsBaseImageOutdated("podman", "debian:12", ""); err != nil || !outdated {
+		t.Fatalf("IsBaseImageOutdated() = %v, %v for another distribution image, want true", outdated, err)
+	}
+
+	// Base images built before their digest was known are not rebuilt for it
+	if outdated, err := store.IsBaseImageOutdated("podman", "", "sha256:aaa"); err != nil || outdated {
+		t.Fatalf("IsBaseImageOutdated() = %v, %v for a build without digest, want false", outdated, err)
+	}
+	if err := store.RefreshBaseImageBuildInfo("podman", "", "sha256:aaa"); err != nil {
+		t.Fatalf("RefreshBaseImageBuildInfo() error = %v", err)
+	}
+	if outdated, err := store.IsBaseImageOutdated("podman", "", "sha256:aaa"); err != nil || outdated {
+		t.Fatalf("IsBaseImageOutdated() = %v, %v for the same digest, want false", outdated, err)
+	}
+	if digest, err := store.GetBaseImageDistributionDigest("podman"); err != nil || digest != "sha256:aaa" {
+		t.Fatalf("GetBaseImageDistributionDigest() = %q, %v, want sha256:aaa", digest, err)
+	}
+	if digest, err := store.GetBaseImageDistributionDigest("docker"); err != nil || digest != "" {
+		t.Fatalf("GetBaseImageDistributionDigest() = %q, %v before any build, want empty", digest, err)
+	}
+	if outdated, err := store.IsBaseImageOutdated("podman", "", "sha256:bbb"); err != nil || !outdated {
+		t.Fatalf("IsBaseImageOutdated() = %v, %v for another digest, want true", outdated, err)
+	}
+
+	path := filepath.Join(store.baseDataDir, "base-podman.buildinfo")
+	if err := os.WriteFile(path, []byte("DOCKERFILE=old\nLAST_BUILT_AT=2000-01-01T00:00:00Z\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if outdated, err := store.IsBaseImageOutdated("podman", "", ""); err != nil || !outdated {
+		t.Fatalf("IsBaseImageOutdated() = %v, %v with another Dockerfile.base, want true", outdated, err)
+	}
+}
+
+func TestFileStore_DistributionPins(t *testing.T) {
+	store := &FileStore{
+		userFS:      &UserFS{homeDir: t.TempDir()},
+		baseDataDir: t.TempDir(),
+	}
+	if digest, err := store.GetDistributionPin(DefaultDistributionImage); err != nil || digest != "" {
+		t.Fatalf("GetDistributionPin() = %q, %v before any pin, want empty", digest, err)
+	}
+	if err := store.SetDistributionPin(DefaultDistributionImage, "sha256:aaa"); err != nil {
+		t.Fatalf("SetDistributionPin() error = %v", err)
+	}
+	if err := store.SetDistributionPin("debian:12", "sha256:bbb"); err != nil {
+		t.Fatalf("SetDistributionPin() error = %v", err)
+	}
+	if digest, err := store.GetDistributionPin(DefaultDistributionImage); err != nil || digest != "sha256:aaa" {
+		t.Fatalf("GetDistributionPin() = %q, %v, want sha256:aaa", digest, err)
+	}
+	if err := store.SetDistributionPin(DefaultDistributionImage, ""); err != nil {
+		t.Fatalf("SetDistributionPin() error = %v", err)
+	}
+	if digest, err := store.GetDistributionPin(DefaultDistributionImage); err != nil || digest != "" {
+		t.Fatalf("GetDistributionPin() = %q, %v once unpinned, want empty", digest, err)
+	}
+	if digest, err := store.GetDistributionPin("debian:12"); err != nil || digest != "sha256:bbb" {
+		t.Fatalf("GetDistributionPin() = %q, %v, want sha256:bbb", digest, err)
+	}
+}
+
+func TestDefaultDistributionImage(t *testing.T) {
+	data, err := assets.ReadFile("embeds/Dockerfile.base")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "\nARG DISTRIBUTION_IMAGE="+DefaultDistributionImage+"\n") {
+		t.Fatalf("Dockerfile.base should default to %s", DefaultDistributionImage)
+	}
+}
@@ -0,0 +1,102 @@
+// # build_log.go
+// This file handles the log of the last build of each project, which keeps
+// the container engine's output, with timestamps, for later inspection.
+
+package files
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const buildLogFilename = "build.log"
+
+// Size after which a build log is not written to anymore, so a looping build
+// cannot fill the disk.
+const maxBuildLogSize = 10 << 20
+
+// Get path to the log of the last build of the given project.
+func (f *FileStore) GetProjectBuildLogPath(projectName string) string {
+	return filepath.Join(f.getProjectInternalDir(projectName), buildLogFilename)
+}
+
+// Create the log of a new build of the given project, replacing the previous
+// one.
+//
+// It has to be closed once the build is over.
+func (f *FileStore) CreateProjectBuildLog(projectName string) (*BuildLog, error) {
+	if err := f.userFS.MkdirAsUser(f.getProjectInternalDir(projectName), 0755); err != nil {
+		return nil, fmt.Errorf("cannot create project internal directory: %w", err)
+	}
+	file, err := f.userFS.CreateFileAsUser(f.GetProjectBuildLogPath(projectName), 0644)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create build log: %w", err)
+	}
+	log := newBuildLog(file, maxBuildLogSize, time.Now)
+	fmt.Fprintf(log, "Build of project '%s' started on %s\n", projectName, time.Now().Format(time.RFC3339))
+	return log, nil
+}
+
+// Writer prefixing each line with the time at which it was written and
+// stopping to write after a size limit.
+//
+// It can be written concurrently (e.g. by a command's stdout and stderr) and
+// never fails so it can be teed with the terminal through an
+// `io.MultiWriter` without interrupting it. Write errors are reported by
+// `Close` instead.
+type BuildLog struct {
+	mu          sync.Mutex
+	out         io.WriteCloser
+	now         func() time.Time
+	atLineStart bool
+	remaining   int64
+	truncated   bool
+	err         error
+}
+
+func newBuildLog(out io.WriteCloser, limit int64, now func() time.Time) *BuildLog {
+	return &BuildLog{out: out, now: now, atLineStart: true, remaining: limit}
+}
+
+func (l *BuildLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil || l.truncated {
+		return len(p), nil
+	}
+
+	var buf []byte
+	for _, b := range p {
+		if l.atLineStart {
+			buf = l.now().AppendFormat(buf, "[15:04:05] ")
+			l.atLineStart = false
+		}
+		buf = append(buf, b)
+		if b == '\n' {
+			l.atLineStart = true
+		}
+	}
+	if int64(len(buf)) > l.remaining {
+		buf = append(buf[:l.remaining], "\n[build log truncated]\n"...)
+		l.truncated = true
+	}
+	l.remaining -= int64(len(buf))
+	if _, err := l.out.Write(buf); err != nil {
+		l.err = err
+	}
+	return len(p), nil
+}
+
+// Close the log, returning the first error encountered while writing it.
+func (l *BuildLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	closeErr := l.out.Close()
+	if l.err != nil {
+		return l.err
+	}
+	return closeErr
+}
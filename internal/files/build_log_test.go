@@ -0,0 +1,62 @@
+package files
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type nopCloseBuffer struct {
+	bytes.Buffer
+}
+
+func (b *nopCloseBuffer) Close() error { return nil }
+
+type failingWriteCloser struct{}
+
+func (failingWriteCloser) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+func (failingWriteCloser) Close() error              { return nil }
+
+func TestBuildLog_Timestamps(t *testing.T) {
+	now := func() time.Time { return time.Date(2026, 1, 2, 13, 4, 5, 0, time.UTC) }
+	out := &nopCloseBuffer{}
+	log := newBuildLog(out, 1<<20, now)
+	for _, chunk := range []string{"Step 1/3", " : FROM base\nStep 2", "/3\n\n"} {
+		if n, err := log.Write([]byte(chunk)); err != nil || n != len(chunk) {
+			t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+		}
+	}
+	want := "[13:04:05] Step 1/3 : FROM base\n[13:04:05] Step 2/3\n[13:04:05] \n"
+	if out.String() != want {
+		t.Fatalf("build log = %q, want %q", out.String(), want)
+	}
+}
+
+func TestBuildLog_SizeLimit(t *testing.T) {
+	out := &nopCloseBuffer{}
+	log := newBuildLog(out, 20, func() time.Time { return time.Time{} })
+	line := "0123456789\n"
+	for range 5 {
+		if n, err := log.Write([]byte(line)); err != nil || n != len(line) {
+			t.Fatalf("Write() = %d, %v, want %d, nil", n, err, len(line))
+		}
+	}
+	if !strings.HasSuffix(out.String(), "[build log truncated]\n") {
+		t.Fatalf("build log should end with a truncation note, got %q", out.String())
+	}
+	if strings.Count(out.String(), "0123") != 1 {
+		t.Fatalf("build log should stop after its limit, got %q", out.String())
+	}
+}
+
+func TestBuildLog_ReportsWriteErrorsOnClose(t *testing.T) {
+	log := newBuildLog(failingWriteCloser{}, 1<<20, time.Now)
+	if n, err := log.Write([]byte("line\n")); err != nil || n != 5 {
+		t.Fatalf("Write() = %d, %v, want 5, nil", n, err)
+	}
+	if err := log.Close(); err == nil {
+		t.Fatal("Close() should report the write error")
+	}
+}
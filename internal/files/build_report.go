@@ -0,0 +1,67 @@
+// # build_report.go
+// When a build fails, what is needed to understand why (its whole log, the
+// files it was built from, the state of the container engine...) is written
+// to a report directory, meant to be attached as is to a bug report.
+//
+// Only the most recent reports of each project are kept.
+
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+const buildReportsDirname = "build-reports"
+
+// Number of reports kept per project, older ones being removed when a new one
+// is written.
+const maxBuildReports = 5
+
+// Get the directory the build failure reports of that project are written to.
+func (f *FileStore) GetProjectBuildReportsDir(projectName string) string {
+	return filepath.Join(f.baseDataDir, buildReportsDirname, projectName)
+}
+
+// Write a build failure report of that project, made of `reportFiles` whose
+// names are relative to its directory, then remove its oldest reports beyond
+// those kept.
+//
+// Returns the path of the report's directory.
+func (f *FileStore) WriteBuildReport(projectName string, now time.Time, reportFiles []ArchiveFile) (string, error) {
+	reportsDir := f.GetProjectBuildReportsDir(projectName)
+	dir := filepath.Join(reportsDir, now.Format("20060102-150405"))
+	if err := f.userFS.MkdirAsUser(dir, 0755); err != nil {
+		return "", fmt.Errorf("cannot create build report directory: %w", err)
+	}
+	for _, file := range reportFiles {
+		mode := file.Mode
+		if mode == 0 {
+			mode = 0600
+		}
+		if err := f.userFS.WriteFileAsUser(filepath.Join(dir, file.Name), file.Data, mode); err != nil {
+			return "", fmt.Errorf("cannot write build report: %w", err)
+		}
+	}
+
+	entries, err := os.ReadDir(reportsDir)
+	if err != nil {
+		return dir, nil
+	}
+	var reports []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			reports = append(reports, entry.Name())
+		}
+	}
+	// Named after their date, so sorted oldest first
+	slices.Sort(reports)
+	for len(reports) > maxBuildReports {
+		_ = os.RemoveAll(filepath.Join(reportsDir, reports[0]))
+		reports = reports[1:]
+	}
+	return dir, nil
+}
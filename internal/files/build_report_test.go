@@ -0,0 +1,34 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteBuildReport(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	store, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	start := time.Date(2026, 1, 2, 13, 4, 5, 0, time.UTC)
+	var dir string
+	for i := range maxBuildReports + 2 {
+		dir, err = store.WriteBuildReport("app", start.Add(time.Duration(i)*time.Minute), []ArchiveFile{{Name: "build.log", Data: []byte("failed\n")}})
+		if err != nil {
+			t.Fatalf("WriteBuildReport() error = %v", err)
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "build.log")); err != nil || string(data) != "failed\n" {
+		t.Fatalf("build.log of the report = %q, %v", data, err)
+	}
+	entries, err := os.ReadDir(store.GetProjectBuildReportsDir("app"))
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != maxBuildReports || entries[0].Name() != start.Add(2*time.Minute).Format("20060102-150405") {
+		t.Fatalf("kept reports = %v, want the %d most recent ones", entries, maxBuildReports)
+	}
+}
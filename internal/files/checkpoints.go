@@ -0,0 +1,43 @@
+// # checkpoints.go
+// Archives of the containers frozen by `paul-envs freeze`, from which `thaw`
+// resumes them. They are kept apart from projects' directories as they can
+// weigh gigabytes and only make sense on this machine, so are not backed up.
+
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const checkpointsDirname = "checkpoints"
+
+// Get path to the archive of the frozen container of the given project,
+// which may not exist.
+func (f *FileStore) GetProjectCheckpointPath(projectName string) string {
+	return filepath.Join(f.baseDataDir, checkpointsDirname, projectName+".tar.gz")
+}
+
+// Returns true if the given project's container is frozen.
+func (f *FileStore) HasProjectCheckpoint(projectName string) bool {
+	_, err := os.Stat(f.GetProjectCheckpointPath(projectName))
+	return err == nil
+}
+
+// Create the directory of the checkpoint archives, for a new one to be
+// written there.
+func (f *FileStore) CreateCheckpointsDir() error {
+	if err := f.userFS.MkdirAsUser(filepath.Join(f.baseDataDir, checkpointsDirname), 0755); err != nil {
+		return fmt.Errorf("cannot create checkpoints directory: %w", err)
+	}
+	return nil
+}
+
+// Remove the archive of the given project's frozen container, if any.
+func (f *FileStore) RemoveProjectCheckpoint(projectName string) error {
+	if err := os.Remove(f.GetProjectCheckpointPath(projectName)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot remove checkpoint of project '%s': %w", projectName, err)
+	}
+	return nil
+}
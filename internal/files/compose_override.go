@@ -0,0 +1,26 @@
+// # compose_override.go
+// Compose override files are compose files written by the user, layered on
+// top of the one generated by `paul-envs export compose` (e.g. to add mounts
+// or devices). As paul-envs never writes them, they are kept when the
+// generated one is.
+//
+// Each project can have its own, next to its build.conf, and one applying to
+// all projects can be put in paul-envs' config directory.
+
+package files
+
+import (
+	"path/filepath"
+)
+
+const composeOverrideFilename = "compose.override.yaml"
+
+// Get path to the optional compose override file of the given project.
+func (f *FileStore) GetProjectComposeOverridePath(name string) string {
+	return filepath.Join(f.getProjectDir(name), composeOverrideFilename)
+}
+
+// Get path to the optional compose override file applying to all projects.
+func (f *FileStore) GetGlobalComposeOverridePath() string {
+	return filepath.Join(f.baseConfigDir, composeOverrideFilename)
+}
@@ -0,0 +1,43 @@
+// # debug_log.go
+// This file handles paul-envs' own log, to which each invocation appends what
+// it did (messages, container engine calls and their outputs) for post-mortem
+// debugging.
+
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const debugLogFilename = "paul-envs.log"
+
+// Size after which the log is rotated, only keeping the previous one.
+const maxDebugLogSize = 10 << 20
+
+// Get path to paul-envs' own log.
+func (f *FileStore) GetDebugLogPath() string {
+	return filepath.Join(f.baseDataDir, debugLogFilename)
+}
+
+// Open paul-envs' own log for appending to it, rotating it first if it grew
+// too large.
+//
+// It has to be closed once the command is over.
+func (f *FileStore) OpenDebugLog() (*os.File, error) {
+	if err := f.userFS.MkdirAsUser(f.baseDataDir, 0755); err != nil {
+		return nil, fmt.Errorf("cannot create data directory: %w", err)
+	}
+	path := f.GetDebugLogPath()
+	if info, err := os.Stat(path); err == nil && info.Size() > maxDebugLogSize {
+		if err := os.Rename(path, path+".1"); err != nil {
+			return nil, fmt.Errorf("cannot rotate log: %w", err)
+		}
+	}
+	file, err := f.userFS.AppendFileAsUser(path, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open log: %w", err)
+	}
+	return file, nil
+}
@@ -0,0 +1,93 @@
+// # distribution_pins.go
+// This file keeps the registry digest each distribution image was pinned to,
+// so the shared base image is always built from the same image, until the pin
+// is explicitly moved with `paul-envs update`.
+
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Distribution image `Dockerfile.base` is built from when none is configured.
+const DefaultDistributionImage = "ubuntu:24.04"
+
+const distributionPinsFilename = "distribution.pins"
+
+func (f *FileStore) getDistributionPinsPath() string {
+	return filepath.Join(f.baseDataDir, distributionPinsFilename)
+}
+
+// Returns the digest the given distribution image (e.g. "ubuntu:24.04") is
+// pinned to, empty if it is not pinned yet.
+func (f *FileStore) GetDistributionPin(image string) (string, error) {
+	pins, err := f.readDistributionPins()
+	if err != nil {
+		return "", err
+	}
+	return pins[image], nil
+}
+
+// Pin the given distribution image to `digest`, or unpin it if `digest` is
+// empty.
+func (f *FileStore) SetDistributionPin(image string, digest string) error {
+	pins, err := f.readDistributionPins()
+	if err != nil {
+		return err
+	}
+	if digest == "" {
+		delete(pins, image)
+	} else {
+		pins[image] = digest
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("# Digests distribution images are pinned to, moved with 'paul-envs update'\n")
+	images := make([]string, 0, len(pins))
+	for pinnedImage := range pins {
+		images = append(images, pinnedImage)
+	}
+	slices.Sort(images)
+	for _, pinnedImage := range images {
+		fmt.Fprintf(&buf, "%s %s\n", pinnedImage, pins[pinnedImage])
+	}
+	if err := f.userFS.MkdirAsUser(f.baseDataDir, 0755); err != nil {
+		return err
+	}
+	path := f.getDistributionPinsPath()
+	if err := f.userFS.WriteFileAsUser(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write '%s': %w", path, err)
+	}
+	return nil
+}
+
+func (f *FileStore) readDistributionPins() (map[string]string, error) {
+	pins := map[string]string{}
+	data, err := os.ReadFile(f.getDistributionPinsPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return pins, nil
+		}
+		return nil, fmt.Errorf("could not read distribution image pins: %w", err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		image, digest, found := strings.Cut(line, " ")
+		if !found || strings.TrimSpace(digest) == "" {
+			return nil, fmt.Errorf("invalid distribution image pin %q", line)
+		}
+		pins[image] = strings.TrimSpace(digest)
+	}
+	return pins, scanner.Err()
+}
@@ -0,0 +1,120 @@
+// # dockerfile_snippets.go
+// Dockerfile snippets are fragments of a Dockerfile written by the user
+// (e.g. installing a corporate CA certificate or adding a package repository),
+// spliced into the generated Dockerfile of a project so such recurring tweaks
+// don't require maintaining a whole Dockerfile.
+//
+// A `Dockerfile.pre` snippet is spliced at the start of the project's image,
+// before anything is installed in it, and a `Dockerfile.post` one at its end,
+// once everything is. Both run as root. They can be written in paul-envs'
+// config directory, for all projects, and next to a project's build.conf,
+// the project's own being spliced after the global one.
+//
+// Projects with snippets are built from their own copy of the generated
+// Dockerfile, written in their internal directory before each build.
+
+package files
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const (
+	dockerfilePreSnippetFilename  = "Dockerfile.pre"
+	dockerfilePostSnippetFilename = "Dockerfile.post"
+	projectDockerfileFilename     = "Dockerfile"
+)
+
+// Lines of the generated Dockerfile replaced by the snippets.
+const (
+	dockerfilePreSnippetsMarker  = "# paul-envs: pre snippets"
+	dockerfilePostSnippetsMarker = "# paul-envs: post snippets"
+)
+
+// Get path to the Dockerfile generated for the given project with its
+// snippets. Only exists once `RefreshProjectDockerfile` has been called, and
+// only if it has snippets.
+func (f *FileStore) GetProjectDockerfilePath(name string) string {
+	return filepath.Join(f.getProjectInternalDir(name), projectDockerfileFilename)
+}
+
+// Write the Dockerfile of the given project with its Dockerfile snippets,
+// or remove it if it has none, so it is built from the shared one.
+func (f *FileStore) RefreshProjectDockerfile(name string) error {
+	pre, err := f.readDockerfileSnippets(name, dockerfilePreSnippetFilename)
+	if err != nil {
+		return err
+	}
+	post, err := f.readDockerfileSnippets(name, dockerfilePostSnippetFilename)
+	if err != nil {
+		return err
+	}
+	path := f.GetProjectDockerfilePath(name)
+	if len(pre) == 0 && len(post) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("cannot remove the previous Dockerfile of project '%s': %w", name, err)
+		}
+		return nil
+	}
+
+	dockerfile, err := assets.ReadFile("embeds/Dockerfile")
+	if err != nil {
+		return err
+	}
+	spliced, err := spliceDockerfileSnippets(dockerfile, pre, post)
+	if err != nil {
+		return err
+	}
+	if err := f.userFS.MkdirAsUser(f.getProjectInternalDir(name), 0755); err != nil {
+		return err
+	}
+	return f.userFS.WriteFileAsUser(path, spliced, 0644)
+}
+
+// Returns the content of the global then of the project's snippet with that
+// file name, empty if neither exists.
+func (f *FileStore) readDockerfileSnippets(name string, filename string) ([]byte, error) {
+	var snippets []byte
+	for _, path := range []string{
+		filepath.Join(f.baseConfigDir, filename),
+		filepath.Join(f.getProjectDir(name), filename),
+	} {
+		content, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read Dockerfile snippet: %w", err)
+		}
+		if len(bytes.TrimSpace(content)) == 0 {
+			continue
+		}
+		snippets = append(snippets, fmt.Sprintf("# From %s\n", path)...)
+		snippets = append(snippets, bytes.TrimRight(content, "\n")...)
+		snippets = append(snippets, '\n')
+	}
+	return snippets, nil
+}
+
+// Replace the snippet markers of `dockerfile` by the given snippets.
+func spliceDockerfileSnippets(dockerfile []byte, pre []byte, post []byte) ([]byte, error) {
+	for _, splice := range []struct {
+		marker  string
+		snippet []byte
+	}{
+		{dockerfilePreSnippetsMarker, pre},
+		{dockerfilePostSnippetsMarker, post},
+	} {
+		marker := []byte(splice.marker + "\n")
+		if !bytes.Contains(dockerfile, marker) {
+			return nil, fmt.Errorf("cannot splice Dockerfile snippets: %q not found", splice.marker)
+		}
+		dockerfile = bytes.Replace(dockerfile, marker, splice.snippet, 1)
+	}
+	return dockerfile, nil
+}
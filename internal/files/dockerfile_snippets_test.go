@@ -0,0 +1,76 @@
+package files
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRefreshProjectDockerfile(t *testing.T) {
+	root := t.TempDir()
+	store := &FileStore{
+		userFS:        &UserFS{homeDir: root},
+		baseDataDir:   filepath.Join(root, "data"),
+		baseConfigDir: filepath.Join(root, "config"),
+		projectsDir:   filepath.Join(root, "data", "projects"),
+	}
+	for _, dir := range []string{store.baseConfigDir, store.getProjectDir("demo")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := store.GetProjectDockerfilePath("demo")
+
+	if err := store.RefreshProjectDockerfile("demo"); err != nil {
+		t.Fatalf("RefreshProjectDockerfile() without snippets: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("no Dockerfile should be written without snippets, got %v", err)
+	}
+
+	snippets := map[string]string{
+		filepath.Join(store.baseConfigDir, dockerfilePreSnippetFilename):          "RUN echo global-pre\n",
+		filepath.Join(store.getProjectDir("demo"), dockerfilePreSnippetFilename):  "RUN echo project-pre",
+		filepath.Join(store.getProjectDir("demo"), dockerfilePostSnippetFilename): "RUN echo project-post\n",
+	}
+	for snippetPath, content := range snippets {
+		if err := os.WriteFile(snippetPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.RefreshProjectDockerfile("demo"); err != nil {
+		t.Fatalf("RefreshProjectDockerfile(): %v", err)
+	}
+	dockerfile, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("the project's Dockerfile should be written: %v", err)
+	}
+	globalPre := bytes.Index(dockerfile, []byte("RUN echo global-pre\n"))
+	projectPre := bytes.Index(dockerfile, []byte("RUN echo project-pre\n"))
+	userCreation := bytes.Index(dockerfile, []byte("# Create user"))
+	projectPost := bytes.Index(dockerfile, []byte("RUN echo project-post\n"))
+	entrypoint := bytes.Index(dockerfile, []byte("COPY entrypoint.sh"))
+	if globalPre == -1 || projectPre < globalPre || userCreation < projectPre {
+		t.Fatalf("pre snippets should be spliced in order before the user is created, got:\n%s", dockerfile)
+	}
+	if projectPost == -1 || entrypoint < projectPost || projectPost < userCreation {
+		t.Fatalf("post snippets should be spliced before the entrypoint, got:\n%s", dockerfile)
+	}
+	if strings.Contains(string(dockerfile), dockerfilePreSnippetsMarker) {
+		t.Fatal("snippet markers should be replaced")
+	}
+
+	for snippetPath := range snippets {
+		if err := os.Remove(snippetPath); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.RefreshProjectDockerfile("demo"); err != nil {
+		t.Fatalf("RefreshProjectDockerfile() once snippets are removed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("the project's Dockerfile should be removed with its snippets, got %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+// # dotfiles_profiles.go
+// Dotfiles profiles are named dotfiles directories (e.g. "minimal", "work")
+// shared between projects, which each project can use instead of its own
+// dotfiles directory through the `DOTFILES_PROFILE` directive of its run.conf.
+
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const dotfilesProfilesDirname = "dotfiles-profiles"
+
+// Get path to the directory containing one directory per dotfiles profile.
+func (f *FileStore) GetDotfilesProfilesDir() string {
+	return filepath.Join(f.baseConfigDir, dotfilesProfilesDirname)
+}
+
+// Get path to the directory of the given dotfiles profile.
+func (f *FileStore) GetDotfilesProfilePath(name string) string {
+	return filepath.Join(f.GetDotfilesProfilesDir(), name)
+}
+
+// Returns the names of all dotfiles profiles, sorted.
+func (f *FileStore) ListDotfilesProfiles() ([]string, error) {
+	entries, err := os.ReadDir(f.GetDotfilesProfilesDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read dotfiles profiles: %w", err)
+	}
+	var profiles []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			profiles = append(profiles, entry.Name())
+		}
+	}
+	sort.Strings(profiles)
+	return profiles, nil
+}
@@ -6,21 +6,77 @@ _paulenvs()
     prev="${COMP_WORDS[COMP_CWORD-1]}"
 
     # Main commands
-    local commands="create list build run remove version completion interactive help clean"
+    local commands="create list build run stop remove version completion interactive help clean tui trust status gc export ssh-config code watch snapshot rollback doctor support-bundle stats config enable-emulation try exec cp info rebuild sshd push pull import update backup restore outdated reconcile rename clone history cache-stats reap daemon du image volume diff events migrate freeze thaw group resources api shellenv prompt task inspect times tmp shortcut services fix-perms"
 
     # Options for create command
-    local create_flags="--help --name --uid --gid --username --shell --nodejs --rust --python --go --git-name --git-email --package --enable-ssh --enable-sudo --neovim --starship --oh-my-posh --atuin --zellij --jujutsu --delta --open-code --claude-code --codex --firefox --no-mise --port --volume"
+    local create_flags="--help --name --uid --gid --username --shell --nodejs --rust --python --go --git-name --git-email --package --pipx-package --cargo-package --npm-package --compiler-cache --nested-containers --systemd --enable-ssh --enable-sudo --neovim --starship --oh-my-posh --atuin --zellij --jujutsu --delta --open-code --claude-code --codex --firefox --no-mise --port --volume --dotfiles-profile --no-completions"
 
     # Options for list command
-    local list_flags="--help --names"
-    local build_flags="--help --no-cache"
-    local remove_flags="--help --no-prompt"
+    local list_flags="--help --names --wide"
+    local build_flags="--help --no-cache --base --rollback --heartbeat --stall-after --platform --rootful --verify --build-arg"
+    local remove_flags="--help --no-prompt --engine --all --containers --image --volumes"
     local clean_flags="--help --no-prompt --engine --projects --config --managed-resources --build-cache"
     local engine_values="docker podman all"
-    local run_flags="--help"
+    local stop_flags="--help --engine"
+    local run_flags="--help --auto-rebuild --no-banner --service --instance --separate-volume --profile --fresh --reset-volumes --env --env-file --rootful"
     local version_flags="--help"
     local completion_values="bash zsh fish"
     local interactive_flags="--help"
+    local tui_flags="--help"
+    local trust_flags="--help"
+    local status_flags="--help --wide"
+    local gc_flags="--help --dry-run --no-prompt --older-than --engine"
+    local export_flags="--help --force --deployment --play"
+    local ssh_config_flags="--help"
+    local code_flags="--help --print --engine"
+    local watch_flags="--help --restart --interval --engine"
+    local snapshot_flags="--help --tag --list --restore --delete --engine"
+    local rollback_flags="--help --list --to --pin --unpin --engine"
+    local doctor_flags="--help"
+    local support_bundle_flags="--help --output"
+    local stats_flags="--help --watch --interval --engine"
+    local config_flags="--help"
+    local enable_emulation_flags="--help --engine"
+    local try_flags="--help --engine --build --keep"
+    local exec_flags="--help --user --workdir -T"
+    local cp_flags="--help"
+    local info_flags="--help --full"
+    local rebuild_flags="--help --stale --no-cache --jobs --engine"
+    local sshd_flags="--help --port"
+    local push_flags="--help --engine"
+    local pull_flags="--help --engine"
+    local import_flags="--help --name --path --map --engine"
+    local update_flags="--help --engine"
+    local backup_flags="--help --volumes --force --engine"
+    local restore_flags="--help --force --no-volumes --engine"
+    local outdated_flags="--help --rebuild --engine"
+    local reconcile_flags="--help --fix --no-prompt --engine"
+    local rename_flags="--help --engine"
+    local clone_flags="--help --with-volumes --engine"
+    local history_flags="--help --limit --calls --failed --wide"
+    local cache_stats_flags="--help --zero"
+    local reap_flags="--help --dry-run --idle --engine"
+    local daemon_flags="--help --once --no-notify --systemd-unit --metrics-address"
+    local du_flags="--help --engine"
+    local image_flags="--help --all --engine"
+    local volume_flags="--help --engine"
+    local diff_flags="--help --engine"
+    local events_flags="--help --engine"
+    local migrate_flags="--help --list --rollback"
+    local freeze_flags="--help --engine"
+    local thaw_flags="--help --engine --discard"
+    local group_flags="--help"
+    local resources_flags="--help --filter --sort --engine --wide"
+    local api_flags="--help --socket"
+    local shellenv_flags="--help --shell --envrc"
+    local prompt_flags="--help --format --stale-symbol"
+    local task_flags="--help --engine --auto-rebuild --artifact --artifacts-dir --env --env-file"
+    local inspect_flags="--help --engine"
+    local times_flags="--help --wide"
+    local tmp_flags="--help --image --inject --join --engine"
+    local shortcut_flags="--help --name --icon --remove"
+    local services_flags="--help --follow"
+    local fix_perms_flags="--help --no-prompt --engine"
 
     # Get list of existing containers from paul-envs ls
     _get_containers() {
@@ -43,13 +99,13 @@ _paulenvs()
                     COMPREPLY=( $(compgen -W "$(id -u) $(id -g)" -- ${cur}) )
                     return 0
                     ;;
-                --username|--git-name|--git-email|--package|--nodejs|--rust|--python|--go|--port)
+                --username|--git-name|--git-email|--package|--pipx-package|--cargo-package|--npm-package|--nodejs|--rust|--python|--go|--port|--dotfiles-profile)
                     # Let user type freely
                     COMPREPLY=()
                     return 0
                     ;;
                 --shell)
-                    COMPREPLY=( $(compgen -W "bash zsh fish" -- ${cur}) )
+                    COMPREPLY=( $(compgen -W "bash zsh fish nushell" -- ${cur}) )
                     return 0
                     ;;
                 --volume)
@@ -89,6 +145,18 @@ _paulenvs()
             fi
             return 0
             ;;
+        stop)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            if [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${stop_flags}" -- ${cur}) )
+            else
+                COMPREPLY=( $(compgen -W "$(_get_containers)" -- ${cur}) )
+            fi
+            return 0
+            ;;
         run)
             if [[ $COMP_CWORD -eq 2 ]]; then
                 COMPREPLY=( $(compgen -W "$(_get_containers) ${run_flags}" -- ${cur}) )
@@ -129,6 +197,664 @@ _paulenvs()
             fi
             return 0
             ;;
+        tui)
+            COMPREPLY=( $(compgen -W "${tui_flags}" -- ${cur}) )
+            return 0
+            ;;
+        trust)
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "list revoke ${trust_flags}" -- ${cur}) )
+            elif [[ "${COMP_WORDS[2]}" == revoke && "${cur}" != --* ]]; then
+                COMPREPLY=( $(compgen -d -- ${cur}) )
+            else
+                COMPREPLY=( $(compgen -W "${trust_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        status)
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${status_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${status_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        gc)
+            if [[ "${prev}" == --older-than ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman all" -- ${cur}) )
+                return 0
+            fi
+            COMPREPLY=( $(compgen -W "${gc_flags}" -- ${cur}) )
+            return 0
+            ;;
+        export)
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "compose bundle kube ${export_flags}" -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 3 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers)" -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 4 && "${cur}" != --* ]]; then
+                COMPREPLY=( $(compgen -d -- ${cur}) )
+            else
+                COMPREPLY=( $(compgen -W "${export_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        ssh-config)
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${ssh_config_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${ssh_config_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        code)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${code_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${code_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        watch)
+            if [[ "${prev}" == --interval ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${watch_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${watch_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        snapshot)
+            if [[ "${prev}" == --tag ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ "${prev}" == --restore ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ "${prev}" == --delete ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${snapshot_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${snapshot_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        rollback)
+            if [[ "${prev}" == --to ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${rollback_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${rollback_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        doctor)
+            COMPREPLY=( $(compgen -W "${doctor_flags}" -- ${cur}) )
+            return 0
+            ;;
+        support-bundle)
+            if [[ "${prev}" == --output ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${support_bundle_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${support_bundle_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        stats)
+            if [[ "${prev}" == --interval ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman all" -- ${cur}) )
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${stats_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${stats_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        config)
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "list get set unset ${config_flags}" -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 3 && "${COMP_WORDS[2]}" != list ]]; then
+                COMPREPLY=( $(compgen -W "engine base_image shell dotfiles parallelism" -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 4 && "${COMP_WORDS[2]}" == set && "${COMP_WORDS[3]}" == engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 4 && "${COMP_WORDS[2]}" == set && "${COMP_WORDS[3]}" == shell ]]; then
+                COMPREPLY=( $(compgen -W "bash zsh fish nushell" -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 4 && "${COMP_WORDS[2]}" == set && "${COMP_WORDS[3]}" == dotfiles ]]; then
+                COMPREPLY=( $(compgen -d -- ${cur}) )
+            else
+                COMPREPLY=( $(compgen -W "${config_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        enable-emulation)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            COMPREPLY=( $(compgen -W "${enable_emulation_flags}" -- ${cur}) )
+            return 0
+            ;;
+        try)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${try_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${try_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        exec)
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${exec_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${exec_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        cp)
+            if [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${cp_flags}" -- ${cur}) )
+            else
+                # Either "<project-name>:" or a host path
+                local project_operands=""
+                for project in $(_get_containers); do
+                    project_operands="${project_operands} ${project}:"
+                done
+                COMPREPLY=( $(compgen -W "${project_operands}" -- ${cur}) $(compgen -f -- ${cur}) )
+                compopt -o nospace 2>/dev/null
+            fi
+            return 0
+            ;;
+        info)
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${info_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${info_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        rebuild)
+            if [[ "${prev}" == --jobs ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            if [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${rebuild_flags}" -- ${cur}) )
+            else
+                COMPREPLY=( $(compgen -W "$(_get_containers)" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        sshd)
+            if [[ "${prev}" == --port ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${sshd_flags}" -- ${cur}) )
+            else
+                COMPREPLY=( $(compgen -W "$(_get_containers)" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        push)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${push_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${push_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        pull)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${pull_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${pull_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        import)
+            if [[ "${prev}" == --name ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ "${prev}" == --path ]]; then
+                COMPREPLY=( $(compgen -d -- ${cur}) )
+                return 0
+            fi
+            if [[ "${prev}" == --map ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            if [[ "${cur}" == -* ]]; then
+                COMPREPLY=( $(compgen -W "${import_flags}" -- ${cur}) )
+            elif [[ ${COMP_CWORD} -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "distrobox toolbox" -- ${cur}) $(compgen -f -- ${cur}) )
+            else
+                COMPREPLY=( $(compgen -f -- ${cur}) )
+            fi
+            return 0
+            ;;
+        update)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${update_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${update_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        backup)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            if [[ "${cur}" == -* ]]; then
+                COMPREPLY=( $(compgen -W "${backup_flags}" -- ${cur}) )
+            else
+                COMPREPLY=( $(compgen -f -- ${cur}) )
+            fi
+            return 0
+            ;;
+        restore)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            if [[ "${cur}" == -* ]]; then
+                COMPREPLY=( $(compgen -W "${restore_flags}" -- ${cur}) )
+            else
+                COMPREPLY=( $(compgen -f -- ${cur}) )
+            fi
+            return 0
+            ;;
+        outdated)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            COMPREPLY=( $(compgen -W "${outdated_flags}" -- ${cur}) )
+            return 0
+            ;;
+        reconcile)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman all" -- ${cur}) )
+                return 0
+            fi
+            COMPREPLY=( $(compgen -W "${reconcile_flags}" -- ${cur}) )
+            return 0
+            ;;
+        rename)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${rename_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${rename_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        clone)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${clone_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${clone_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        history)
+            if [[ "${prev}" == --limit ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${history_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${history_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        cache-stats)
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${cache_stats_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${cache_stats_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        reap)
+            if [[ "${prev}" == --idle ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman all" -- ${cur}) )
+                return 0
+            fi
+            COMPREPLY=( $(compgen -W "${reap_flags}" -- ${cur}) )
+            return 0
+            ;;
+        daemon)
+            if [[ "${prev}" == --metrics-address ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            COMPREPLY=( $(compgen -W "${daemon_flags}" -- ${cur}) )
+            return 0
+            ;;
+        du)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman all" -- ${cur}) )
+                return 0
+            fi
+            COMPREPLY=( $(compgen -W "${du_flags}" -- ${cur}) )
+            return 0
+            ;;
+        image)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "analyze ${image_flags}" -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 3 && "${COMP_WORDS[2]}" == analyze ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${image_flags}" -- ${cur}) )
+            else
+                COMPREPLY=( $(compgen -W "${image_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        volume)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "inspect ${volume_flags}" -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 3 && "${COMP_WORDS[2]}" == inspect ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${volume_flags}" -- ${cur}) )
+            else
+                COMPREPLY=( $(compgen -W "${volume_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        diff)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${diff_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${diff_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        events)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${events_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${events_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        migrate)
+            COMPREPLY=( $(compgen -W "${migrate_flags}" -- ${cur}) )
+            return 0
+            ;;
+        freeze)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "podman-rootful podman docker" -- ${cur}) )
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${freeze_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${freeze_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        thaw)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "podman-rootful podman docker" -- ${cur}) )
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${thaw_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${thaw_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        group)
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "list add remove ${group_flags}" -- ${cur}) )
+            elif [[ $COMP_CWORD -gt 3 && ( "${COMP_WORDS[2]}" == add || "${COMP_WORDS[2]}" == remove ) ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${group_flags}" -- ${cur}) )
+            else
+                COMPREPLY=( $(compgen -W "${group_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        resources)
+            if [[ "${prev}" == --filter ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ "${prev}" == --sort ]]; then
+                COMPREPLY=( $(compgen -W "kind name project age size" -- ${cur}) )
+                return 0
+            fi
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman all" -- ${cur}) )
+                return 0
+            fi
+            COMPREPLY=( $(compgen -W "images containers volumes networks ${resources_flags}" -- ${cur}) )
+            return 0
+            ;;
+        api)
+            if [[ "${prev}" == --socket ]]; then
+                COMPREPLY=( $(compgen -f -- ${cur}) )
+                return 0
+            fi
+            COMPREPLY=( $(compgen -W "${api_flags}" -- ${cur}) )
+            return 0
+            ;;
+        shellenv)
+            if [[ "${prev}" == --shell ]]; then
+                COMPREPLY=( $(compgen -W "bash zsh fish" -- ${cur}) )
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${shellenv_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${shellenv_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        prompt)
+            if [[ "${prev}" == --format ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ "${prev}" == --stale-symbol ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${prompt_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${prompt_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        task)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            if [[ "${prev}" == --artifact ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ "${prev}" == --artifacts-dir ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ "${prev}" == --env ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ "${prev}" == --env-file ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${task_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${task_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        inspect)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${inspect_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${inspect_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        times)
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${times_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${times_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        tmp)
+            if [[ "${prev}" == --image || "${prev}" == --join ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            COMPREPLY=( $(compgen -W "${tmp_flags}" -- ${cur}) )
+            return 0
+            ;;
+        shortcut)
+            if [[ "${prev}" == --name ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ "${prev}" == --icon ]]; then
+                COMPREPLY=()
+                return 0
+            fi
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${shortcut_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${shortcut_flags}" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        services)
+            if [[ $COMP_CWORD -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(_get_containers) ${services_flags}" -- ${cur}) )
+            elif [[ "${cur}" == --* ]]; then
+                COMPREPLY=( $(compgen -W "${services_flags}" -- ${cur}) )
+            elif [[ $COMP_CWORD -eq 3 ]]; then
+                COMPREPLY=( $(compgen -W "restart stop start logs" -- ${cur}) )
+            fi
+            return 0
+            ;;
+        fix-perms)
+            if [[ "${prev}" == --engine ]]; then
+                COMPREPLY=( $(compgen -W "docker podman" -- ${cur}) )
+                return 0
+            fi
+            COMPREPLY=( $(compgen -W "$(_get_containers) ${fix_perms_flags}" -- ${cur}) )
+            return 0
+            ;;
         help)
             # No further completion
             return 0
@@ -5,24 +5,87 @@ function __paul_envs_containers
     paul-envs list --names 2>/dev/null
 end
 
+# Global flags
+complete -c paul-envs -l profile-cli -d 'Report where time went in that invocation'
+complete -c paul-envs -l offline -d 'Never reach container registries'
+complete -c paul-envs -l dry-run -d 'Only display engine calls and file writes'
+complete -c paul-envs -n 'not __fish_use_subcommand' -s v -l verbose -d 'Also display container engine calls'
+complete -c paul-envs -n 'not __fish_use_subcommand' -s q -l quiet -d 'Only display errors, warnings and results'
+
 # Main commands
 complete -c paul-envs -f -n __fish_use_subcommand -a interactive -d 'Start interactive mode'
 complete -c paul-envs -f -n __fish_use_subcommand -a create -d 'Create a container configuration'
 complete -c paul-envs -f -n __fish_use_subcommand -a list -d 'List all available containers'
 complete -c paul-envs -f -n __fish_use_subcommand -a build -d 'Build a container'
 complete -c paul-envs -f -n __fish_use_subcommand -a run -d 'Start a container'
+complete -c paul-envs -f -n __fish_use_subcommand -a stop -d 'Stop the containers and services of projects'
 complete -c paul-envs -f -n __fish_use_subcommand -a remove -d 'Remove a container'
 complete -c paul-envs -f -n __fish_use_subcommand -a help -d 'Show help'
 complete -c paul-envs -f -n __fish_use_subcommand -a version -d 'Show version'
 complete -c paul-envs -f -n __fish_use_subcommand -a completion -d 'Print shell completion scripts'
 complete -c paul-envs -f -n __fish_use_subcommand -a clean -d 'Remove all stored paul-envs data from your computer'
+complete -c paul-envs -f -n __fish_use_subcommand -a tui -d 'Start a dashboard to manage all projects'
+complete -c paul-envs -f -n __fish_use_subcommand -a trust -d 'List or revoke trusted in-repository definitions'
+complete -c paul-envs -f -n __fish_use_subcommand -a status -d 'Show the engine-side state of each project'
+complete -c paul-envs -f -n __fish_use_subcommand -a gc -d 'Remove resources of deleted projects and old images'
+complete -c paul-envs -f -n __fish_use_subcommand -a export -d 'Export a project as a compose bundle, a shareable project bundle or Kubernetes YAML'
+complete -c paul-envs -f -n __fish_use_subcommand -a ssh-config -d 'Print an ssh_config entry to connect to a project\'s container'
+complete -c paul-envs -f -n __fish_use_subcommand -a code -d 'Open a project\'s container in VS Code'
+complete -c paul-envs -f -n __fish_use_subcommand -a watch -d 'Rebuild a project when its configuration changes'
+complete -c paul-envs -f -n __fish_use_subcommand -a snapshot -d 'Save a project\'s running container as an image'
+complete -c paul-envs -f -n __fish_use_subcommand -a rollback -d 'Go back to a project\'s previously built image'
+complete -c paul-envs -f -n __fish_use_subcommand -a doctor -d 'Diagnose the host setup and print how to fix problems'
+complete -c paul-envs -f -n __fish_use_subcommand -a support-bundle -d 'Collect redacted diagnostics into an archive for bug reports'
+complete -c paul-envs -f -n __fish_use_subcommand -a stats -d 'Show live resource usage of running project containers'
+complete -c paul-envs -f -n __fish_use_subcommand -a config -d 'Manage the global configuration (default engine, base image, shell...)'
+complete -c paul-envs -f -n __fish_use_subcommand -a enable-emulation -d 'Set up QEMU emulation to run images of other architectures'
+complete -c paul-envs -f -n __fish_use_subcommand -a try -d 'Run a project on another container engine without changing its own'
+complete -c paul-envs -f -n __fish_use_subcommand -a exec -d 'Run a command in a running project container without its entrypoint'
+complete -c paul-envs -f -n __fish_use_subcommand -a cp -d 'Copy files between the host and a project container'
+complete -c paul-envs -f -n __fish_use_subcommand -a info -d 'Show a project\'s details and README'
+complete -c paul-envs -f -n __fish_use_subcommand -a rebuild -d 'Rebuild several project images in parallel'
+complete -c paul-envs -f -n __fish_use_subcommand -a sshd -d 'Serve ssh connections to a project\'s container'
+complete -c paul-envs -f -n __fish_use_subcommand -a push -d 'Push a project\'s image to a container registry'
+complete -c paul-envs -f -n __fish_use_subcommand -a pull -d 'Pull a project\'s image from a container registry'
+complete -c paul-envs -f -n __fish_use_subcommand -a import -d 'Create a project from a bundle exported on another machine'
+complete -c paul-envs -f -n __fish_use_subcommand -a update -d 'Move the pinned distribution image to its latest digest and rebuild'
+complete -c paul-envs -f -n __fish_use_subcommand -a backup -d 'Save the whole paul-envs state in an archive'
+complete -c paul-envs -f -n __fish_use_subcommand -a restore -d 'Restore the paul-envs state saved by backup'
+complete -c paul-envs -f -n __fish_use_subcommand -a outdated -d 'List projects behind the latest distribution image'
+complete -c paul-envs -f -n __fish_use_subcommand -a reconcile -d 'Report and fix mismatches between projects and engine resources'
+complete -c paul-envs -f -n __fish_use_subcommand -a rename -d 'Rename a project along with its container resources'
+complete -c paul-envs -f -n __fish_use_subcommand -a clone -d 'Create a new project with the same definition as an existing one'
+complete -c paul-envs -f -n __fish_use_subcommand -a history -d 'Show the last invocations and the engine calls they made'
+complete -c paul-envs -f -n __fish_use_subcommand -a cache-stats -d 'Show the hit statistics of a project\'s compiler caches'
+complete -c paul-envs -f -n __fish_use_subcommand -a reap -d 'Stop containers nothing was attached to for too long'
+complete -c paul-envs -f -n __fish_use_subcommand -a daemon -d 'Run gc, outdated checks and reap periodically'
+complete -c paul-envs -f -n __fish_use_subcommand -a du -d 'Show the disk space used by each project'
+complete -c paul-envs -f -n __fish_use_subcommand -a image -d 'Analyze the layers of a project image'
+complete -c paul-envs -f -n __fish_use_subcommand -a volume -d 'Show where a volume\'s data is stored and its size'
+complete -c paul-envs -f -n __fish_use_subcommand -a diff -d 'Show what changed in a project\'s configuration since its image was built'
+complete -c paul-envs -f -n __fish_use_subcommand -a events -d 'Follow what happens to paul-envs\' containers, images and volumes'
+complete -c paul-envs -f -n __fish_use_subcommand -a migrate -d 'Upgrade paul-envs\' files written by a previous release'
+complete -c paul-envs -f -n __fish_use_subcommand -a freeze -d 'Save a running project container with its processes, to resume it later'
+complete -c paul-envs -f -n __fish_use_subcommand -a thaw -d 'Resume a project container saved by freeze'
+complete -c paul-envs -f -n __fish_use_subcommand -a group -d 'Manage groups of projects, selected with @group'
+complete -c paul-envs -f -n __fish_use_subcommand -a resources -d 'List the images, containers, volumes and networks of paul-envs'
+complete -c paul-envs -f -n __fish_use_subcommand -a api -d 'Serve a local HTTP API for other tools'
+complete -c paul-envs -f -n __fish_use_subcommand -a shellenv -d 'Output shell code selecting a project'
+complete -c paul-envs -f -n __fish_use_subcommand -a prompt -d 'Output the selected project for a shell prompt'
+complete -c paul-envs -f -n __fish_use_subcommand -a task -d 'Run a command in a new container and exit with its code'
+complete -c paul-envs -f -n __fish_use_subcommand -a inspect -d 'Show where a project\'s image comes from'
+complete -c paul-envs -f -n __fish_use_subcommand -a times -d 'Summarize how long builds, pulls and runs took'
+complete -c paul-envs -f -n __fish_use_subcommand -a tmp -d 'Run an image in a throwaway environment removed once exited'
+complete -c paul-envs -f -n __fish_use_subcommand -a shortcut -d 'Add a GUI application of a project to the host\'s application menu'
+complete -c paul-envs -f -n __fish_use_subcommand -a services -d 'List, restart or stop the background processes of a project'
+complete -c paul-envs -f -n __fish_use_subcommand -a fix-perms -d 'Give files of a project owned by another user back to you'
 
 # Create command options
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l name -d "Specific a container name" -x
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l uid -d 'Host UID' -x
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l gid -d 'Host GID' -x
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l username -d 'Container username' -x
-complete -c paul-envs -n "__fish_seen_subcommand_from create" -l shell -d 'User shell' -xa 'bash zsh fish'
+complete -c paul-envs -n "__fish_seen_subcommand_from create" -l shell -d 'User shell' -xa 'bash zsh fish nushell'
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l nodejs -d 'Node.js installation' -x
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l rust -d 'Rust installation' -x
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l python -d 'Python installation' -x
@@ -30,6 +93,12 @@ complete -c paul-envs -n "__fish_seen_subcommand_from create" -l go -d 'Go insta
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l git-name -d 'Git author name' -x
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l git-email -d 'Git author email' -x
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l package -d 'Additional Ubuntu package' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from create" -l pipx-package -d 'Python application installed through pipx' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from create" -l cargo-package -d 'Crate installed through cargo install' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from create" -l npm-package -d 'npm package installed globally' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from create" -l compiler-cache -d "Cache C/C++ and Rust compilations" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from create" -l nested-containers -d "Install Podman to run containers in the container" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from create" -l systemd -d "Run systemd as the first process of the container" -f
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l enable-ssh -d "Enable ssh access" -f
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l enable-sudo -d "Enable sudo access (password: \"dev\")" -f
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l neovim -d "Install Neovim" -f
@@ -44,18 +113,47 @@ complete -c paul-envs -n "__fish_seen_subcommand_from create" -l claude-code -d
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l codex -d "Install OpenAI codex" -f
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l firefox -d "Install Mozilla Firefox" -f
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l no-mise -d "Prevent Mise installation" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from create" -l no-completions -d "Do not set up shell completions of installed tools" -f
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l port -d 'Expose port' -x
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l volume -d 'Add volume' -r
+complete -c paul-envs -n "__fish_seen_subcommand_from create" -l dotfiles-profile -d 'Use a shared dotfiles profile' -x
 complete -c paul-envs -n "__fish_seen_subcommand_from create" -l help -s h -d 'Show help' -f
 
 complete -c paul-envs -n "__fish_seen_subcommand_from list" -l names -d "Only display names" -f
+complete -c paul-envs -n "__fish_seen_subcommand_from list" -l wide -d 'Display full values even if they do not fit' -f
 complete -c paul-envs -n "__fish_seen_subcommand_from list" -l help -s h -d 'Show help' -f
 
 complete -c paul-envs -n "__fish_seen_subcommand_from build" -l help -s h -d 'Show help' -f
 complete -c paul-envs -n "__fish_seen_subcommand_from build" -l no-cache -d 'Build without using cached layers' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from build" -l base -d 'Also rebuild the shared base image' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from build" -l rollback -d 'Restore the configuration files from before their last regeneration' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from build" -l heartbeat -d 'Print a heartbeat when the build is silent for that long' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from build" -l stall-after -d 'Report the build as possibly stalled once silent for that long' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from build" -l platform -d 'Architecture to build the image for' -xa 'amd64 arm64'
+complete -c paul-envs -n "__fish_seen_subcommand_from build" -l rootful -d 'Build with rootful Podman' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from build" -l verify -d 'Check that the shell starts cleanly with its dotfiles once built' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from build" -l build-arg -d 'Build argument to give to this build, as NAME=VALUE' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from stop" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from stop" -l engine -d 'Container engine to use' -xa 'docker podman'
 complete -c paul-envs -n "__fish_seen_subcommand_from run" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from run" -l auto-rebuild -d 'Build a missing or stale image first without asking' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from run" -l no-banner -d 'Do not display the project summary first' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from run" -l service -d 'Service to run or join' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from run" -l instance -d 'Instance of the project container to run or join' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from run" -l separate-volume -d 'Give a new instance its own home volume' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from run" -l profile -d 'Also start the services of that profile' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from run" -l fresh -d 'Remove the container and stop its services first' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from run" -l reset-volumes -d 'With --fresh, also remove the project volumes' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from run" -l env -s e -d 'Set an environment variable' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from run" -l env-file -d 'Set environment variables from a file' -r
+complete -c paul-envs -n "__fish_seen_subcommand_from run" -l rootful -d 'Use rootful Podman' -f
 complete -c paul-envs -n "__fish_seen_subcommand_from remove" -l help -s h -d 'Show help' -f
 complete -c paul-envs -n "__fish_seen_subcommand_from remove" -l no-prompt -d 'Skip confirmation and require a project name' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from remove" -l engine -d 'Container engine to use' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from remove" -l all -d 'Remove the configuration and all container assets' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from remove" -l containers -d 'Only remove the containers and network' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from remove" -l image -d 'Only remove the images' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from remove" -l volumes -d 'Only remove the volumes' -f
 complete -c paul-envs -n "__fish_seen_subcommand_from version" -l help -s h -d 'Show help' -f
 complete -c paul-envs -n "__fish_seen_subcommand_from completion" -l help -s h -d 'Show help' -f
 complete -c paul-envs -f -n "__fish_seen_subcommand_from completion" -a 'bash zsh fish'
@@ -67,8 +165,225 @@ complete -c paul-envs -n "__fish_seen_subcommand_from clean" -l projects -d 'Onl
 complete -c paul-envs -n "__fish_seen_subcommand_from clean" -l config -d 'Only remove the global paul-envs configuration' -f
 complete -c paul-envs -n "__fish_seen_subcommand_from clean" -l managed-resources -d 'Only remove managed containers, images, volumes, and networks' -f
 complete -c paul-envs -n "__fish_seen_subcommand_from clean" -l build-cache -d 'Only prune cached build data associated with paul-envs images' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from tui" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from trust" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from status" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from status" -l wide -d 'Display full values even if they do not fit' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from gc" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from gc" -l dry-run -d 'Only display what would be removed' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from gc" -l no-prompt -d 'Remove without asking for confirmation' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from gc" -l older-than -d 'Also remove project images older than that age' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from gc" -l engine -d 'Container engine to collect from' -xa 'docker podman all'
+complete -c paul-envs -n "__fish_seen_subcommand_from export" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from export" -l force -d 'Overwrite existing files' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from export" -l deployment -d 'Write a Kubernetes Deployment instead of a Pod' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from export" -l play -d 'Run the Kubernetes YAML with podman kube play' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from ssh-config" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from code" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from code" -l print -d 'Only print the URI opening the container in VS Code' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from code" -l engine -d 'Container engine to use' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from watch" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from watch" -l restart -d 'Also restart the running container on changes' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from watch" -l interval -d 'How often to check for changes' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from watch" -l engine -d 'Container engine to use' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from snapshot" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from snapshot" -l tag -d 'Tag of the new snapshot' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from snapshot" -l list -d 'List the project\'s snapshots' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from snapshot" -l restore -d 'Restore the snapshot with that tag' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from snapshot" -l delete -d 'Remove the snapshot with that tag' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from snapshot" -l engine -d 'Container engine to use' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from rollback" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from rollback" -l list -d 'List the project\'s previous images' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from rollback" -l to -d 'Number of the previous image to restore' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from rollback" -l pin -d 'Number of the previous image to pin' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from rollback" -l unpin -d 'Number of the previous image to unpin' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from rollback" -l engine -d 'Container engine to use' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from doctor" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from support-bundle" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from support-bundle" -l output -d 'Path of the archive to write' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from stats" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from stats" -l watch -d 'Refresh the statistics continuously' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from stats" -l interval -d 'How often to refresh with --watch' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from stats" -l engine -d 'Container engine to query' -xa 'docker podman all'
+complete -c paul-envs -n "__fish_seen_subcommand_from config" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from enable-emulation" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from enable-emulation" -l engine -d 'Container engine registering emulators' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from try" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from try" -l engine -d 'Container engine to try the project on' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from try" -l build -d 'Build the image on that engine instead of copying it' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from try" -l keep -d 'Keep the image on that engine once exited' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from exec" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from exec" -l user -d 'User running the command' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from exec" -l workdir -d 'Directory in which the command is run' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from exec" -s T -d 'Never allocate a pseudo-terminal' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from cp" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from info" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from info" -l full -d 'Also display the project\'s README' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from rebuild" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from rebuild" -l stale -d 'Only rebuild images no longer matching their definition' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from rebuild" -l no-cache -d 'Build without using cached layers' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from rebuild" -l jobs -d 'Maximum number of images built at once' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from rebuild" -l engine -d 'Container engine to use' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from sshd" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from sshd" -l port -d 'Port of this machine to serve ssh connections on' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from push" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from push" -l engine -d 'Container engine to push from' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from pull" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from pull" -l engine -d 'Container engine to pull with' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from import" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from import" -l name -d 'Name of the created project' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from import" -l path -d 'Directory to mount as the project' -xa '(__fish_complete_directories)'
+complete -c paul-envs -n "__fish_seen_subcommand_from import" -F
+complete -c paul-envs -n "__fish_seen_subcommand_from import" -l map -d 'Replace a host path prefix, as <old>=<new>' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from import" -l engine -d 'Container engine of the distrobox or toolbox container' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from import" -a 'distrobox toolbox' -d 'Import a container'
+complete -c paul-envs -n "__fish_seen_subcommand_from update" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from update" -l engine -d 'Container engine to use' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from backup" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from backup" -l volumes -d 'Also save the content of project volumes' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from backup" -l force -d 'Replace the backup file if it exists' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from backup" -l engine -d 'Container engine whose volumes are saved' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from backup" -F
+complete -c paul-envs -n "__fish_seen_subcommand_from restore" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from restore" -l force -d 'Replace files and volumes which already exist' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from restore" -l no-volumes -d 'Do not restore saved volumes' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from restore" -l engine -d 'Container engine in which volumes are restored' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from restore" -F
+complete -c paul-envs -n "__fish_seen_subcommand_from outdated" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from outdated" -l rebuild -d 'Update and rebuild the projects which are behind' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from outdated" -l engine -d 'Container engine to use' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from reconcile" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from reconcile" -l fix -d 'Fix the inconsistencies found' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from reconcile" -l no-prompt -d 'Fix without asking for confirmation' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from reconcile" -l engine -d 'Container engine to check' -xa 'docker podman all'
+complete -c paul-envs -n "__fish_seen_subcommand_from rename" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from rename" -l engine -d 'Container engine whose resources are renamed' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from clone" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from clone" -l with-volumes -d 'Also copy the content of the project\'s volume' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from clone" -l engine -d 'Container engine whose volume is copied' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from history" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from history" -l limit -d 'Number of most recent invocations displayed' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from history" -l calls -d 'Also display their container engine calls' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from history" -l failed -d 'Only display failed invocations' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from history" -l wide -d 'Never elide values' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from cache-stats" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from cache-stats" -l zero -d 'Reset the statistics' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from reap" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from reap" -l dry-run -d 'Only display which containers would be stopped' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from reap" -l idle -d 'Idle duration after which containers are stopped' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from reap" -l engine -d 'Container engine to look at' -xa 'docker podman all'
+complete -c paul-envs -n "__fish_seen_subcommand_from daemon" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from daemon" -l once -d 'Run the tasks a single time then exit' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from daemon" -l no-notify -d 'Do not display desktop notifications' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from daemon" -l systemd-unit -d 'Only output a systemd user service running the daemon' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from daemon" -l metrics-address -d 'Serve Prometheus metrics on that address' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from du" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from du" -l engine -d 'Container engine to query' -xa 'docker podman all'
+complete -c paul-envs -n "__fish_seen_subcommand_from image" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from image" -l all -d 'Also list the shared base image layers and metadata-only instructions' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from image" -l engine -d 'Container engine to use' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from volume" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from volume" -l engine -d 'Container engine to query' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from diff" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from diff" -l engine -d 'Container engine to query' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from events" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from events" -l engine -d 'Container engine to follow' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from migrate" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from migrate" -l list -d 'Only list the pending migrations' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from migrate" -l rollback -d 'Restore the files as they were before the last migration' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from freeze" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from freeze" -l engine -d 'Container engine to use (rootful Podman)' -xa 'podman-rootful podman docker'
+complete -c paul-envs -n "__fish_seen_subcommand_from thaw" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from thaw" -l engine -d 'Container engine to use (rootful Podman)' -xa 'podman-rootful podman docker'
+complete -c paul-envs -n "__fish_seen_subcommand_from thaw" -l discard -d 'Remove the checkpoint instead of resuming it' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from group" -l help -s h -d 'Show help' -f
+complete -c paul-envs -f -n "__fish_seen_subcommand_from group; and not __fish_seen_subcommand_from list add remove" -a 'list add remove'
+complete -c paul-envs -n "__fish_seen_subcommand_from resources" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from resources" -l filter -d 'Only list resources matching a condition (e.g. project=myapp, age>30d, size>1GB)' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from resources" -l sort -d 'Order of the listed resources' -xa 'kind name project age size'
+complete -c paul-envs -n "__fish_seen_subcommand_from resources" -l engine -d 'Container engine to query' -xa 'docker podman all'
+complete -c paul-envs -n "__fish_seen_subcommand_from resources" -l wide -d 'Display full values' -f
+complete -c paul-envs -f -n "__fish_seen_subcommand_from resources" -a 'images containers volumes networks'
+complete -c paul-envs -n "__fish_seen_subcommand_from api" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from api" -l socket -d 'Path of the Unix socket to listen on' -r
+complete -c paul-envs -n "__fish_seen_subcommand_from shellenv" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from shellenv" -l shell -d 'Shell to output code for' -xa 'bash zsh fish'
+complete -c paul-envs -n "__fish_seen_subcommand_from shellenv" -l envrc -d 'Output a snippet for direnv\'s .envrc' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from prompt" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from prompt" -l format -d 'What to output ({project}, {stale})' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from prompt" -l stale-symbol -d 'Symbol displayed when the image is stale' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from task" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from task" -l engine -d 'Container engine to use' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from task" -l auto-rebuild -d 'Build the image first if missing or stale' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from task" -l artifact -d 'Path in the container to copy back to the host' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from task" -l artifacts-dir -d 'Host directory artifacts are copied to' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from task" -l env -d 'Environment variable to set in the container' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from task" -l env-file -d 'Env file of variables to set in the container' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from inspect" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from inspect" -l engine -d 'Container engine to use' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from times" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from times" -l wide -d 'Never elide values, even if the table does not fit the terminal' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from tmp" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from tmp" -l image -d 'Image to run, e.g. fedora:41' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from tmp" -l inject -d 'Run it through paul-envs\'s injected entrypoint, applying the global dotfiles' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from tmp" -l join -d 'Open a shell in the running throwaway environment of that name' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from tmp" -l engine -d 'Container engine to run it with' -xa 'docker podman'
+complete -c paul-envs -n "__fish_seen_subcommand_from shortcut" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from shortcut" -l name -d 'Name of the application in the menu' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from shortcut" -l icon -d 'Icon of the application in the menu' -x
+complete -c paul-envs -n "__fish_seen_subcommand_from shortcut" -l remove -d 'Remove the project\'s shortcut to that command, or all of them' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from services" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from services" -l follow -d 'With logs, keep printing what the process outputs' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from fix-perms" -l help -s h -d 'Show help' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from fix-perms" -l no-prompt -d 'Non-interactive mode: fix without asking for confirmation' -f
+complete -c paul-envs -n "__fish_seen_subcommand_from fix-perms" -l engine -d 'Container engine to use' -xa 'docker podman'
 
 # Container name completion for build, run, remove
 complete -c paul-envs -f -n "__fish_seen_subcommand_from build" -a '(__paul_envs_containers)'
 complete -c paul-envs -f -n "__fish_seen_subcommand_from run" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from stop" -a '(__paul_envs_containers)'
 complete -c paul-envs -f -n "__fish_seen_subcommand_from remove" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from trust; and not __fish_seen_subcommand_from list revoke" -a 'list revoke'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from status" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from config; and not __fish_seen_subcommand_from list get set unset" -a 'list get set unset'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from config; and __fish_seen_subcommand_from get set unset; and not __fish_seen_subcommand_from engine base_image shell dotfiles parallelism" -a 'engine base_image shell dotfiles parallelism'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from config; and __fish_seen_subcommand_from set; and __fish_seen_subcommand_from engine" -a 'docker podman'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from config; and __fish_seen_subcommand_from set; and __fish_seen_subcommand_from shell" -a 'bash zsh fish nushell'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from export; and not __fish_seen_subcommand_from compose bundle kube" -a 'compose bundle kube'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from export; and __fish_seen_subcommand_from compose bundle kube" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from ssh-config" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from code" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from watch" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from snapshot" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from rollback" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from support-bundle" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from stats" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from try" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from exec" -a '(__paul_envs_containers)'
+complete -c paul-envs -n "__fish_seen_subcommand_from cp" -a '(__paul_envs_containers | string replace -r "\$" ":")'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from info" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from rebuild" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from sshd" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from push" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from pull" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from update" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from rename" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from clone" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from history" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from cache-stats" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from image; and not __fish_seen_subcommand_from analyze" -a 'analyze'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from image; and __fish_seen_subcommand_from analyze" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from volume; and not __fish_seen_subcommand_from inspect" -a 'inspect'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from volume; and __fish_seen_subcommand_from inspect" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from diff" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from events" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from freeze" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from thaw" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from shellenv" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from prompt" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from task" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from inspect" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from times" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from shortcut" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from services" -a '(__paul_envs_containers)'
+complete -c paul-envs -f -n "__fish_seen_subcommand_from fix-perms" -a '(__paul_envs_containers)'
@@ -8,11 +8,67 @@ _paulenvs() {
         'list:List all available containers'
         'build:Build a container'
         'run:Start a container'
+        'stop:Stop the containers and services of projects'
         'remove:Remove a container'
         'help:Show help'
         'version:Show version'
         'completion:Print shell completion scripts'
         'clean:Remove all stored paul-envs data from your computer'
+        'tui:Start a dashboard to manage all projects'
+        'trust:List or revoke trusted in-repository definitions'
+        'status:Show the engine-side state of each project'
+        'gc:Remove resources of deleted projects and old images'
+        'export:Export a project as a compose bundle, a shareable project bundle or Kubernetes YAML'
+        'ssh-config:Print an ssh_config entry to connect to a project'\''s container'
+        'code:Open a project'\''s container in VS Code'
+        'watch:Rebuild a project when its configuration changes'
+        'snapshot:Save a project'\''s running container as an image'
+        'rollback:Go back to a project'\''s previously built image'
+        'doctor:Diagnose the host setup and print how to fix problems'
+        'support-bundle:Collect redacted diagnostics into an archive for bug reports'
+        'stats:Show live resource usage of running project containers'
+        'config:Manage the global configuration (default engine, base image, shell...)'
+        'enable-emulation:Set up QEMU emulation to run images of other architectures'
+        'try:Run a project on another container engine without changing its own'
+        'exec:Run a command in a running project container without its entrypoint'
+        'cp:Copy files between the host and a project container'
+        'info:Show a project'\''s details and README'
+        'rebuild:Rebuild several project images in parallel'
+        'sshd:Serve ssh connections to a project'\''s container'
+        'push:Push a project'\''s image to a container registry'
+        'pull:Pull a project'\''s image from a container registry'
+        'import:Create a project from a bundle exported on another machine'
+        'update:Move the pinned distribution image to its latest digest and rebuild'
+        'backup:Save the whole paul-envs state in an archive'
+        'restore:Restore the paul-envs state saved by backup'
+        'outdated:List projects behind the latest distribution image'
+        'reconcile:Report and fix mismatches between projects and engine resources'
+        'rename:Rename a project along with its container resources'
+        'clone:Create a new project with the same definition as an existing one'
+        'history:Show the last invocations and the engine calls they made'
+        'cache-stats:Show the hit statistics of a project'\''s compiler caches'
+        'reap:Stop containers nothing was attached to for too long'
+        'daemon:Run gc, outdated checks and reap periodically'
+        'du:Show the disk space used by each project'
+        'image:Analyze the layers of a project image'
+        'volume:Show where a volume'\''s data is stored and its size'
+        'diff:Show what changed in a project'\''s configuration since its image was built'
+        'events:Follow what happens to paul-envs'\'' containers, images and volumes'
+        'migrate:Upgrade paul-envs'\'' files written by a previous release'
+        'freeze:Save a running project container with its processes, to resume it later'
+        'thaw:Resume a project container saved by freeze'
+        'group:Manage groups of projects, selected with @group'
+        'resources:List the images, containers, volumes and networks of paul-envs'
+        'api:Serve a local HTTP API for other tools'
+        'shellenv:Output shell code selecting a project'
+        'prompt:Output the selected project for a shell prompt'
+        'task:Run a command in a new container and exit with its code'
+        'inspect:Show where a project'\''s image comes from'
+        'times:Summarize how long builds, pulls and runs took'
+        'tmp:Run an image in a throwaway environment removed once exited'
+        'shortcut:Add a GUI application of a project to the host'\''s application menu'
+        'services:List, restart or stop the background processes of a project'
+        'fix-perms:Give files of a project owned by another user back to you'
     )
 
     # Get list of existing containers from paul-envs ls
@@ -41,13 +97,16 @@ _paulenvs() {
                         '--uid[Host UID]:uid:($(id -u))' \
                         '--gid[Host GID]:gid:($(id -g))' \
                         '--username[Container username]:username:' \
-                        '--shell[User shell]:shell:(bash zsh fish)' \
+                        '--shell[User shell]:shell:(bash zsh fish nushell)' \
                         '--nodejs[Node.js installation]:version:' \
                         '--rust[Rust installation]:version:' \
                         '--python[Python installation]:version:' \
                         '--go[Go installation]:version:' \
                         '--git-name[Git author name]:name:' \
                         '--git-email[Git author email]:email:' \
+                        '--compiler-cache[Cache C/C++ and Rust compilations]' \
+                        '--nested-containers[Install Podman to run containers in the container]' \
+                        '--systemd[Run systemd as the first process of the container]' \
                         '--enable-ssh[Enable ssh access]' \
                         '--enable-sudo[Enable sudo access (password: \"dev\")]' \
                         '--neovim[Install latest Neovim]' \
@@ -62,24 +121,55 @@ _paulenvs() {
                         '--codex[Install latest codex (from OpenAI)]' \
                         '--firefox[Install Mozilla Firefox]' \
                         '--no-mise[Prevent Mise installation]' \
+                        '--no-completions[Do not set up shell completions of installed tools]' \
                         '*--package[Additional package from Ubuntu repo]:package:' \
+                        '*--pipx-package[Python application installed through pipx]:package:' \
+                        '*--cargo-package[Crate installed through cargo install]:package:' \
+                        '*--npm-package[npm package installed globally]:package:' \
                         '*--port[Expose port]:port:' \
-                        '*--volume[Add volume]:volume:_files'
+                        '*--volume[Add volume]:volume:_files' \
+                        '--dotfiles-profile[Use a shared dotfiles profile]:profile:'
                     ;;
                 list)
                     _arguments \
                         '(-h --help)'{-h,--help}'[Show help]' \
                         '--names[Only display names]' \
+                        '--wide[Display full values even if they do not fit]' \
                     ;;
                 build)
                     _arguments \
                         '(-h --help)'{-h,--help}'[Show help]' \
+                        '--rollback[Restore the configuration files from before their last regeneration]' \
+                        '--base[Also rebuild the shared base image]' \
                         '--no-cache[Build without using cached layers]' \
+                        '--heartbeat[Print a heartbeat when the build is silent for that long]:duration:' \
+                        '--stall-after[Report the build as possibly stalled once silent for that long]:duration:' \
+                        '--platform[Architecture to build the image for]:platform:(amd64 arm64)' \
+                        '--rootful[Build with rootful Podman]' \
+                        '--verify[Check that the shell starts cleanly with its dotfiles once built]' \
+                        '*--build-arg[Build argument to give to this build]:NAME=VALUE:' \
                         "2:container name:(${containers[@]})"
                     ;;
+                stop)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--engine[Container engine to use]:engine:(docker podman)' \
+                        "*:project name:(${containers[@]})"
+                    ;;
                 run)
                     _arguments \
                         '(-h --help)'{-h,--help}'[Show help]' \
+                    '--no-banner[Do not display the project summary first]' \
+                        '--auto-rebuild[Build a missing or stale image first without asking]' \
+                        '--service[Service to run or join]:name:' \
+                        '--instance[Instance of the project container to run or join]:name:' \
+                        '--separate-volume[Give a new instance its own home volume]' \
+                        '*--profile[Also start the services of that profile]:profile:' \
+                        '--fresh[Remove the container and stop its services first]' \
+                        '--reset-volumes[With --fresh, also remove the project volumes]' \
+                        '*'{-e,--env}'[Set an environment variable]:KEY=VALUE:' \
+                        '*--env-file[Set environment variables from a file]:env file:_files' \
+                        '--rootful[Use rootful Podman]' \
                         "2:container name:(${containers[@]})" \
                         '*:command:'
                     ;;
@@ -87,8 +177,397 @@ _paulenvs() {
                     _arguments \
                         '(-h --help)'{-h,--help}'[Show help]' \
                         '--no-prompt[Skip confirmation and require a project name]' \
+                        '--engine[Container engine to use]:engine:(docker podman)' \
+                        '--all[Remove the configuration and all container assets]' \
+                        '--containers[Only remove the containers and network]' \
+                        '--image[Only remove the images]' \
+                        '--volumes[Only remove the volumes]' \
                         "2:container name:(${containers[@]})"
                     ;;
+                tui)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]'
+                    ;;
+                trust)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '2:subcommand:(list revoke)' \
+                        '3:repository path:_directories'
+                    ;;
+                status)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--wide[Display full values even if they do not fit]' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                gc)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--dry-run[Only display what would be removed]' \
+                        '--no-prompt[Remove without asking for confirmation]' \
+                        '--older-than[Also remove project images older than that age]:older-than:' \
+                        '--engine[Container engine to collect from]:engine:(docker podman all)'
+                    ;;
+                export)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--force[Overwrite existing files]' \
+                        '--deployment[Write a Kubernetes Deployment instead of a Pod]' \
+                        '--play[Run the Kubernetes YAML with podman kube play]' \
+                        '2:format:(compose bundle kube)' \
+                        "3:project name:(${containers[@]})" \
+                        '4:directory:_directories'
+                    ;;
+                ssh-config)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                code)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--print[Only print the URI opening the container in VS Code]' \
+                        '--engine[Container engine to use]:engine:(docker podman)' \
+                        "*:project name:(${containers[@]})"
+                    ;;
+                watch)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--restart[Also restart the running container on changes]' \
+                        '--interval[How often to check for changes]:interval:' \
+                        '--engine[Container engine to use]:engine:(docker podman)' \
+                        "*:project name:(${containers[@]})"
+                    ;;
+                snapshot)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--tag[Tag of the new snapshot]:tag:' \
+                        '--list[List the project'\''s snapshots]' \
+                        '--restore[Restore the snapshot with that tag]:restore:' \
+                        '--delete[Remove the snapshot with that tag]:delete:' \
+                        '--engine[Container engine to use]:engine:(docker podman)' \
+                        "*:project name:(${containers[@]})"
+                    ;;
+                rollback)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--list[List the project'\''s previous images]' \
+                        '--to[Number of the previous image to restore]:to:' \
+                        '--pin[Number of the previous image to pin]:pin:' \
+                        '--unpin[Number of the previous image to unpin]:unpin:' \
+                        '--engine[Container engine to use]:engine:(docker podman)' \
+                        "*:project name:(${containers[@]})"
+                    ;;
+                doctor)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]'
+                    ;;
+                support-bundle)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--output[Path of the archive to write]:output:' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                stats)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--watch[Refresh the statistics continuously]' \
+                        '--interval[How often to refresh with --watch]:interval:' \
+                        '--engine[Container engine to query]:engine:(docker podman all)' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                config)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '2:subcommand:(list get set unset)' \
+                        '3:setting:(engine base_image shell dotfiles parallelism)' \
+                        '4:value:'
+                    ;;
+                enable-emulation)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--engine[Container engine registering emulators]:engine:(docker podman)'
+                    ;;
+                try)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--engine[Container engine to try the project on]:engine:(docker podman)' \
+                        '--build[Build the image on that engine instead of copying it]' \
+                        '--keep[Keep the image on that engine once exited]' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                exec)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--user[User running the command]:user:' \
+                        '--workdir[Directory in which the command is run]:directory:' \
+                        '-T[Never allocate a pseudo-terminal]' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                cp)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        "*:path:{_alternative 'projects:project:compadd -S : -a containers' 'files:host path:_files'}"
+                    ;;
+                info)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--full[Also display the project'\''s README]' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                rebuild)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--stale[Only rebuild images no longer matching their definition]' \
+                        '--no-cache[Build without using cached layers]' \
+                        '--jobs[Maximum number of images built at once]:jobs:' \
+                        '--engine[Container engine to use]:engine:(docker podman)' \
+                        "*:project name:(${containers[@]})"
+                    ;;
+                sshd)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--port[Port of this machine to serve ssh connections on]:port:' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                push)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--engine[Container engine to push from]:engine:(docker podman)' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                pull)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--engine[Container engine to pull with]:engine:(docker podman)' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                import)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--name[Name of the created project]:name:' \
+                        '--path[Directory to mount as the project]:path:_files -/' \
+                        '--map[Replace a host path prefix, as <old>=<new>]:map:' \
+                        '--engine[Container engine of the distrobox or toolbox container]:engine:(docker podman)' \
+                        '1:bundle:_files'
+                    ;;
+                update)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--engine[Container engine to use]:engine:(docker podman)' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                backup)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--volumes[Also save the content of project volumes]' \
+                        '--force[Replace the backup file if it exists]' \
+                        '--engine[Container engine whose volumes are saved]:engine:(docker podman)' \
+                        '1:file:_files'
+                    ;;
+                restore)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--force[Replace files and volumes which already exist]' \
+                        '--no-volumes[Do not restore saved volumes]' \
+                        '--engine[Container engine in which volumes are restored]:engine:(docker podman)' \
+                        '1:file:_files'
+                    ;;
+                outdated)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--rebuild[Update and rebuild the projects which are behind]' \
+                        '--engine[Container engine to use]:engine:(docker podman)'
+                    ;;
+                reconcile)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--fix[Fix the inconsistencies found]' \
+                        '--no-prompt[Fix without asking for confirmation]' \
+                        '--engine[Container engine to check]:engine:(docker podman all)'
+                    ;;
+                rename)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--engine[Container engine whose resources are renamed]:engine:(docker podman)' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                clone)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--with-volumes[Also copy the content of the project'\''s volume]' \
+                        '--engine[Container engine whose volume is copied]:engine:(docker podman)' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                history)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--limit[Number of most recent invocations displayed]:limit:' \
+                        '--calls[Also display their container engine calls]' \
+                        '--failed[Only display failed invocations]' \
+                        '--wide[Never elide values]' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                cache-stats)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--zero[Reset the statistics]' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                reap)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--dry-run[Only display which containers would be stopped]' \
+                        '--idle[Idle duration after which containers are stopped]:idle:' \
+                        '--engine[Container engine to look at]:engine:(docker podman all)'
+                    ;;
+                daemon)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--once[Run the tasks a single time then exit]' \
+                        '--no-notify[Do not display desktop notifications]' \
+                        '--systemd-unit[Only output a systemd user service running the daemon]' \
+                        '--metrics-address[Serve Prometheus metrics on that address]:address:'
+                    ;;
+                du)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--engine[Container engine to query]:engine:(docker podman all)'
+                    ;;
+                image)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--all[Also list the shared base image layers and metadata-only instructions]' \
+                        '--engine[Container engine to use]:engine:(docker podman)' \
+                        '2:subcommand:(analyze)' \
+                        "3:project name:(${containers[@]})"
+                    ;;
+                volume)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--engine[Container engine to query]:engine:(docker podman)' \
+                        '2:subcommand:(inspect)' \
+                        "3:volume or project name:(${containers[@]})"
+                    ;;
+                diff)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--engine[Container engine to query]:engine:(docker podman)' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                events)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--engine[Container engine to follow]:engine:(docker podman)' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                migrate)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--list[Only list the pending migrations]' \
+                        '--rollback[Restore the files as they were before the last migration]'
+                    ;;
+                freeze)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--engine[Container engine to use (rootful Podman)]:engine:(podman-rootful podman docker)' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                thaw)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--engine[Container engine to use (rootful Podman)]:engine:(podman-rootful podman docker)' \
+                        '--discard[Remove the checkpoint instead of resuming it]' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                group)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '2:subcommand:(list add remove)' \
+                        '3:group name:' \
+                        "*:project name:(${containers[@]})"
+                    ;;
+                resources)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--filter[Only list resources matching a condition (e.g. project=myapp, age>30d, size>1GB)]:filter:' \
+                        '--sort[Order of the listed resources]:sort:(kind name project age size)' \
+                        '--engine[Container engine to query]:engine:(docker podman all)' \
+                        '--wide[Display full values]' \
+                        '*:category:(images containers volumes networks)'
+                    ;;
+                api)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--socket[Path of the Unix socket to listen on]:socket:_files'
+                    ;;
+                shellenv)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--shell[Shell to output code for]:shell:(bash zsh fish)' \
+                        '--envrc[Output a snippet for direnv'\''s .envrc]' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                prompt)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--format[What to output ({project}, {stale})]:format:' \
+                        '--stale-symbol[Symbol displayed when the image is stale]:stale-symbol:' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                task)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--engine[Container engine to use]:engine:(docker podman)' \
+                        '--auto-rebuild[Build the image first if missing or stale]' \
+                        '--artifact[Path in the container to copy back to the host]:artifact:' \
+                        '--artifacts-dir[Host directory artifacts are copied to]:artifacts-dir:' \
+                        '--env[Environment variable to set in the container]:env:' \
+                        '--env-file[Env file of variables to set in the container]:env-file:' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                inspect)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--engine[Container engine to use]:engine:(docker podman)' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                times)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--wide[Never elide values, even if the table does not fit the terminal]' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                tmp)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--image[Image to run, e.g. fedora:41]:image:' \
+                        '--inject[Run it through paul-envs'\''s injected entrypoint, applying the global dotfiles]' \
+                        '--join[Open a shell in the running throwaway environment of that name]:name:' \
+                        '--engine[Container engine to run it with]:engine:(docker podman)'
+                    ;;
+                shortcut)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--name[Name of the application in the menu]:name:' \
+                        '--icon[Icon of the application in the menu]:icon:' \
+                        '--remove[Remove the project'\''s shortcut to that command, or all of them]' \
+                        "2:project name:(${containers[@]})"
+                    ;;
+                services)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--follow[With logs, keep printing what the process outputs]' \
+                        "2:project name:(${containers[@]})" \
+                        '3:action:(restart stop start logs)'
+                    ;;
+                fix-perms)
+                    _arguments \
+                        '(-h --help)'{-h,--help}'[Show help]' \
+                        '--no-prompt[Non-interactive mode: fix without asking for confirmation]' \
+                        '--engine[Container engine to use]:engine:(docker podman)' \
+                        "2:project name:(${containers[@]})"
+                    ;;
                 help)
                     # No additional arguments
                     ;;
@@ -16,6 +16,19 @@ CONTAINER_LOCAL_DIR="${CONTAINER_LOCAL_DIR:-${HOME_DIR}/.container-local}"
 CACHE_MARKER="${CONTAINER_CACHE_DIR}/.initialized"
 LOCAL_MARKER="${CONTAINER_LOCAL_DIR}/.initialized"
 DOTFILES_MOUNT_DIR="${DOTFILES_MOUNT_DIR:-/paul-env/dotfiles}"
+STARTUP_DIR="/paul-env/startup"
+STARTUP_MARKER="/tmp/.paulenv-startup-done"
+PROGRESS_FILE="/paul-env/progress"
+PROCESSES_DIR="/tmp/paulenv-processes"
+
+# With systemd as PID 1 (`ENABLE_SYSTEMD` directive), sessions join the
+# container right after it is started: let its own entrypoint finish first.
+if [ -n "${PAULENV_SYSTEMD:-}" ] && [ "$$" -ne 1 ]; then
+    for _ in $(seq 1 300); do
+        [ "$(cat /proc/1/comm 2>/dev/null)" = "systemd" ] && break
+        sleep 0.1
+    done
+fi
 
 ensure_managed_block() {
     target_file="$1"
@@ -47,6 +60,13 @@ EOF
 if test -f ~/.container-overrides.fish
     source ~/.container-overrides.fish
 end
+EOF
+            ;;
+        nushell)
+            # Nushell resolves `source` when parsing, the file must exist
+            cat >> "$target_file" <<'EOF'
+# paul-envs managed nushell overrides
+source ~/.container-overrides.nu
 EOF
             ;;
     esac
@@ -80,6 +100,9 @@ fi
 if command -v atuin >/dev/null 2>&1; then
     eval "\$(atuin init bash)"
 fi
+if [[ \$- == *i* ]] && [ -z "\${BASH_COMPLETION_VERSINFO:-}" ] && [ -f /usr/share/bash-completion/bash_completion ]; then
+    . /usr/share/bash-completion/bash_completion
+fi
 EOF
 
     cat > "${HOME_DIR}/.container-overrides.zsh" <<EOF
@@ -109,6 +132,9 @@ fi
 if command -v atuin >/dev/null 2>&1; then
     eval "\$(atuin init zsh)"
 fi
+if [[ -o interactive ]] && (( ! \$+functions[compdef] )); then
+    autoload -Uz compinit && compinit -d "\$XDG_CACHE_HOME/zcompdump"
+fi
 EOF
 
     cat > "${HOME_DIR}/.container-overrides.fish" <<EOF
@@ -137,12 +163,76 @@ end
 if type -q atuin
     atuin init fish | source
 end
+EOF
+
+    cat > "${HOME_DIR}/.container-overrides.nu" <<EOF
+\$env.XDG_CACHE_HOME = "${HOME_DIR}/.container-cache/cache"
+\$env.XDG_STATE_HOME = "${HOME_DIR}/.container-local/state"
+\$env.XDG_DATA_HOME = "${HOME_DIR}/.container-local/data"
+\$env._ZO_DATA_DIR = "${HOME_DIR}/.container-local/zoxide"
+\$env.STARSHIP_CACHE = "${HOME_DIR}/.container-local/starship"
+\$env.ATUIN_DB_PATH = "${HOME_DIR}/.container-local/atuin/history.db"
+\$env.PIP_CACHE_DIR = "${HOME_DIR}/.container-cache/pip"
+\$env.GOPATH = "${HOME_DIR}/.container-local/gopath"
+\$env.GOMODCACHE = "${HOME_DIR}/.container-cache/go/mod"
+\$env.PATH = (\$env.PATH | split row (char esep) | prepend ["${HOME_DIR}/.local/bin" "${HOME_DIR}/.opencode/bin" "${HOME_DIR}/.container-local/gopath/bin"])
+if ("${HOME_DIR}/.cargo/env" | path exists) {
+    \$env.PATH = (\$env.PATH | prepend "${HOME_DIR}/.cargo/bin")
+}
 EOF
 
     chown "${CONTAINER_USERNAME}:${CONTAINER_USERNAME}" \
         "${HOME_DIR}/.container-overrides.bash" \
         "${HOME_DIR}/.container-overrides.zsh" \
-        "${HOME_DIR}/.container-overrides.fish"
+        "${HOME_DIR}/.container-overrides.fish" \
+        "${HOME_DIR}/.container-overrides.nu"
+}
+
+# Activation scripts of the project (`ACTIVATE` directive), listed in
+# `PAULENV_ACTIVATE` in their order, sourced at the end of the overrides of
+# the shells able to: bash and zsh for POSIX ones, fish for `.fish` ones.
+write_activation_hooks() {
+    for name in ${PAULENV_ACTIVATE:-}; do
+        script="/paul-env/activate/${name}"
+        case "$name" in
+            *.fish)
+                printf 'if test -f %s\n    source %s\nend\n' "$script" "$script" \
+                    >> "${HOME_DIR}/.container-overrides.fish"
+                ;;
+            *)
+                printf '[ -f %s ] && . %s\n' "$script" "$script" | tee -a \
+                    "${HOME_DIR}/.container-overrides.bash" \
+                    "${HOME_DIR}/.container-overrides.zsh" >/dev/null
+                ;;
+        esac
+    done
+}
+
+# Nushell cannot evaluate generated code at startup, the initialization
+# scripts of the prompt and history tools are instead written to its vendor
+# autoload directory, which it sources.
+write_nushell_autoloads() {
+    case "$USER_SHELL" in
+        *nushell) ;;
+        *) return ;;
+    esac
+    su "${CONTAINER_USERNAME}" -s /bin/bash -c '
+        source "$HOME/.container-overrides.bash"
+        dir="$XDG_DATA_HOME/nushell/vendor/autoload"
+        mkdir -p "$dir"
+        if command -v mise >/dev/null 2>&1; then
+            mise activate nu > "$dir/mise.nu"
+        fi
+        if command -v starship >/dev/null 2>&1; then
+            starship init nu > "$dir/starship.nu"
+        fi
+        if command -v oh-my-posh >/dev/null 2>&1; then
+            oh-my-posh init nu --print > "$dir/oh-my-posh.nu"
+        fi
+        if command -v atuin >/dev/null 2>&1; then
+            atuin init nu > "$dir/atuin.nu"
+        fi
+    '
 }
 
 sync_dotfiles() {
@@ -164,12 +254,219 @@ sync_dotfiles() {
             --exclude=.container-overrides.bash \
             --exclude=.container-overrides.zsh \
             --exclude=.container-overrides.fish \
+            --exclude=.container-overrides.nu \
+            --exclude=.container-git-credential-helper \
             --exclude=.paul-env \
             --exclude=.paul-envs \
             -cf - . | tar -C "$HOME" -xf -
     '
 }
 
+# Git credential helper asking the host's credentials to `paul-envs run`
+# (`GIT_CREDENTIALS` directive). It answers nothing when no `run` serves them,
+# e.g. in containers started in the background.
+write_git_credential_helper() {
+    if [ -z "${PAULENV_GIT_CREDENTIALS:-}" ]; then
+        return
+    fi
+    cat > "${HOME_DIR}/.container-git-credential-helper" <<EOF
+#!/bin/sh
+# paul-envs managed git credential helper
+case "\$1" in get|store|erase) ;; *) exit 0 ;; esac
+[ -S "${PAULENV_GIT_CREDENTIALS}" ] || exit 0
+curl -sf --unix-socket "${PAULENV_GIT_CREDENTIALS}" --data-binary @- "http://paulenv/\$1" || true
+EOF
+    chmod 755 "${HOME_DIR}/.container-git-credential-helper"
+    chown "${CONTAINER_USERNAME}:${CONTAINER_USERNAME}" "${HOME_DIR}/.container-git-credential-helper"
+}
+
+# `paulenv-host` helper running commands of the host allowed by the project
+# (`HOST_COMMAND` directive) through `paul-envs run`, which also replaces
+# those of them missing from the image (e.g. `xdg-open`).
+write_host_exec_helper() {
+    if [ -z "${PAULENV_HOST_COMMANDS:-}" ]; then
+        return
+    fi
+    cat > /usr/local/bin/paulenv-host <<'EOF'
+#!/bin/sh
+# paul-envs managed host command helper
+if [ $# -eq 0 ]; then
+    echo "usage: paulenv-host <command> [arguments...]" >&2
+    exit 2
+fi
+if [ ! -S "${PAULENV_HOST_EXEC:-}" ]; then
+    echo "paulenv-host: host commands are only served while 'paul-envs run' runs" >&2
+    exit 127
+fi
+tmp="$(mktemp -d)"
+trap 'rm -rf "$tmp"' EXIT
+n=$#
+set -- "$@" --form-string "command=$1" --form-string "cwd=$PWD"
+shift
+n=$((n - 1))
+while [ "$n" -gt 0 ]; do
+    set -- "$@" --form-string "arg=$1"
+    shift
+    n=$((n - 1))
+done
+if [ ! -t 0 ]; then
+    cat > "$tmp/stdin"
+    set -- "$@" -F "stdin=@$tmp/stdin"
+fi
+if ! curl -s --unix-socket "$PAULENV_HOST_EXEC" -D "$tmp/headers" -o "$tmp/stdout" "$@" http://paulenv/exec; then
+    echo "paulenv-host: cannot reach 'paul-envs run'" >&2
+    exit 127
+fi
+tr -d '\r' < "$tmp/headers" > "$tmp/headers.lf"
+status="$(sed -n '1s/^HTTP\/[0-9.]* \([0-9]*\).*/\1/p' "$tmp/headers.lf")"
+if [ "$status" != 200 ]; then
+    cat "$tmp/stdout" >&2
+    exit 126
+fi
+sed -n 's/^[Xx]-[Pp]aulenv-[Ss]tderr: *//p' "$tmp/headers.lf" | base64 -d >&2 2>/dev/null
+cat "$tmp/stdout"
+code="$(sed -n 's/^[Xx]-[Pp]aulenv-[Ee]xit-[Cc]ode: *\([0-9]*\).*/\1/p' "$tmp/headers.lf")"
+exit "${code:-1}"
+EOF
+    chmod 755 /usr/local/bin/paulenv-host
+    for command in ${PAULENV_HOST_COMMANDS:-}; do
+        if ! command -v "$command" >/dev/null 2>&1; then
+            printf '#!/bin/sh\n# paul-envs managed host command\nexec paulenv-host %s "$@"\n' "$command" > "/usr/local/bin/$command"
+            chmod 755 "/usr/local/bin/$command"
+        fi
+    done
+}
+
+# `paulenv-clipboard` helper copying to and pasting from the host's clipboard
+# (`CLIPBOARD` directive) through `paul-envs run`. It also replaces the usual
+# clipboard commands missing from the image, whose main flags it understands,
+# so editors find one.
+write_clipboard_helper() {
+    if [ -z "${PAULENV_CLIPBOARD:-}" ]; then
+        return
+    fi
+    cat > /usr/local/bin/paulenv-clipboard <<'EOF'
+#!/bin/sh
+# paul-envs managed clipboard helper
+action=
+selection=clipboard
+text=
+name="$(basename "$0")"
+case "$name" in
+    pbcopy) action=copy ;;
+    pbpaste) action=paste ;;
+    wl-copy|wl-paste)
+        [ "$name" = wl-copy ] && action=copy || action=paste
+        while [ $# -gt 0 ]; do
+            case "$1" in
+                -p|--primary) selection=primary ;;
+                -t|--type|-s|--seat) shift ;;
+                -*) ;;
+                *) text="${text:+$text }$1" ;;
+            esac
+            [ $# -gt 0 ] && shift
+        done
+        ;;
+    xclip)
+        selection=primary
+        action=copy
+        while [ $# -gt 0 ]; do
+            case "$1" in
+                -o|-out) action=paste ;;
+                -sel|-selection)
+                    shift
+                    case "${1:-}" in c*) selection=clipboard ;; *) selection=primary ;; esac
+                    ;;
+                -t|-target|-d|-display|-l|-loops) shift ;;
+            esac
+            [ $# -gt 0 ] && shift
+        done
+        ;;
+    xsel)
+        selection=primary
+        if [ -t 0 ]; then action=paste; else action=copy; fi
+        for arg; do
+            case "$arg" in
+                -o|--output) action=paste ;;
+                -i|--input) action=copy ;;
+                -b|--clipboard) selection=clipboard ;;
+                -p|--primary) selection=primary ;;
+            esac
+        done
+        ;;
+    lemonade)
+        action="${1:-}"
+        [ $# -gt 0 ] && shift
+        text="$*"
+        ;;
+    *)
+        action="${1:-}"
+        [ "${2:-}" = --primary ] && selection=primary
+        ;;
+esac
+case "$action" in
+    copy) method=POST ;;
+    paste) method=GET ;;
+    *)
+        echo "usage: paulenv-clipboard copy|paste [--primary]" >&2
+        exit 2
+        ;;
+esac
+if [ ! -S "${PAULENV_HOST_EXEC:-}" ]; then
+    echo "$name: the host's clipboard is only shared while 'paul-envs run' runs" >&2
+    exit 1
+fi
+tmp="$(mktemp -d)"
+trap 'rm -rf "$tmp"' EXIT
+set --
+if [ "$method" = POST ]; then
+    if [ -n "$text" ]; then printf '%s' "$text" > "$tmp/in"; else cat > "$tmp/in"; fi
+    set -- --data-binary "@$tmp/in"
+fi
+status="$(curl -s --unix-socket "$PAULENV_HOST_EXEC" -X "$method" -o "$tmp/out" -w '%{http_code}' "$@" "http://paulenv/clipboard?selection=$selection")"
+if [ "$status" != 200 ]; then
+    if [ -s "$tmp/out" ]; then
+        cat "$tmp/out" >&2
+    else
+        echo "$name: cannot reach 'paul-envs run'" >&2
+    fi
+    exit 1
+fi
+cat "$tmp/out"
+EOF
+    chmod 755 /usr/local/bin/paulenv-clipboard
+    for command in pbcopy pbpaste wl-copy wl-paste xclip xsel lemonade; do
+        if ! command -v "$command" >/dev/null 2>&1; then
+            ln -sfn paulenv-clipboard "/usr/local/bin/$command"
+        fi
+    done
+}
+
+# Forwarded gpg-agent of the host (`GPG_AGENT` directive), used in place of a
+# local one, with the host's public keys so its secret ones can sign.
+setup_gpg_agent() {
+    if [ -z "${PAULENV_GPG_AGENT:-}" ]; then
+        return
+    fi
+    su "${CONTAINER_USERNAME}" -s /bin/sh -c '
+        set -eu
+        mkdir -p "$HOME/.gnupg"
+        chmod 700 "$HOME/.gnupg"
+        # A local agent would replace the forwarded one
+        touch "$HOME/.gnupg/gpg.conf"
+        if ! grep -qxF no-autostart "$HOME/.gnupg/gpg.conf"; then
+            echo no-autostart >> "$HOME/.gnupg/gpg.conf"
+        fi
+        ln -sfn "$PAULENV_GPG_AGENT" "$HOME/.gnupg/S.gpg-agent"
+        if [ -s "$PAULENV_GPG_KEYS/public-keys.gpg" ]; then
+            gpg --batch --quiet --import "$PAULENV_GPG_KEYS/public-keys.gpg" 2>/dev/null || true
+        fi
+        if [ -s "$PAULENV_GPG_KEYS/ownertrust.txt" ]; then
+            gpg --batch --quiet --import-ownertrust < "$PAULENV_GPG_KEYS/ownertrust.txt" 2>/dev/null || true
+        fi
+    '
+}
+
 apply_git_config() {
     su "${CONTAINER_USERNAME}" -s /bin/sh -c '
         set -eu
@@ -193,9 +490,141 @@ apply_git_config() {
                 jj config set --user user.email "${GIT_AUTHOR_EMAIL}"
             fi
         fi
+        helper="$HOME/.container-git-credential-helper"
+        if [ -n "${PAULENV_GIT_CREDENTIALS:-}" ] &&
+            ! git config --global --get-all credential.helper | grep -qxF "$helper"; then
+            git config --global --add credential.helper "$helper"
+        fi
     '
 }
 
+# Generate the locale set in `LANG` (`LOCALE` directive) if the image does not
+# have it, falling back to `C.UTF-8` if it cannot be (e.g. with a read-only
+# root filesystem).
+ensure_locale() {
+    case "${LANG:-}" in
+        "" | C | C.* | POSIX) return ;;
+    esac
+    # `locale -a` lists e.g. "fr_FR.UTF-8" as "fr_FR.utf8"
+    normalized="$(echo "$LANG" | sed -E 's/\.[Uu][Tt][Ff]-?8/.utf8/')"
+    if locale -a 2>/dev/null | grep -qxF "$normalized"; then
+        return
+    fi
+    name="${LANG%%@*}"
+    modifier=""
+    if [[ "$LANG" == *@* ]]; then
+        modifier="@${LANG#*@}"
+    fi
+    charset="UTF-8"
+    if [[ "$name" == *.* ]]; then
+        charset="${name#*.}"
+    fi
+    if ! localedef -i "${name%%.*}${modifier}" -f "$charset" "$LANG" 2>/dev/null; then
+        echo "WARNING: Could not generate locale ${LANG}, using C.UTF-8 instead." >&2
+        export LANG=C.UTF-8
+    fi
+}
+
+# Append a progress marker for paul-envs on the host, as tab-separated fields:
+# step, status, then details.
+report_progress() {
+    if [ -w "$PROGRESS_FILE" ]; then
+        (IFS=$'\t'; echo "$*") >> "$PROGRESS_FILE"
+    fi
+}
+
+# Run the startup scripts listed in `PAULENV_STARTUP` ("<policy> <name>" lines,
+# mounted in that order as 1, 2...) as the container user.
+# Returns 1 if one whose policy is "abort" failed, skipping the next ones.
+run_startup_scripts() {
+    if [ -z "${PAULENV_STARTUP:-}" ] || [ -f "$STARTUP_MARKER" ]; then
+        return 0
+    fi
+    touch "$STARTUP_MARKER"
+    index=0
+    # Read through fd 3 so scripts keep the container's stdin
+    while IFS=' ' read -r policy name <&3; do
+        index=$((index + 1))
+        report_progress startup started "$name"
+        code=0
+        su "${CONTAINER_USERNAME}" -s /bin/bash \
+            -c 'source $HOME/.container-overrides.bash; if [ -x "$0" ]; then exec "$0"; else exec bash "$0"; fi' \
+            -- "${STARTUP_DIR}/${index}" || code=$?
+        if [ "$code" -eq 0 ]; then
+            report_progress startup done "$name"
+            continue
+        fi
+        report_progress startup failed "$name" "$code" "$policy"
+        if [ "$policy" = "warn" ]; then
+            echo "WARNING: Startup script ${name} failed with exit code ${code}, continuing." >&2
+        else
+            echo "ERROR: Startup script ${name} failed with exit code ${code}, stopping." >&2
+            return 1
+        fi
+    done 3<<< "$PAULENV_STARTUP"
+}
+
+# Keep a background process (`PROCESS` directive) running, its state being
+# kept in `${PROCESSES_DIR}/<name>`: the PID of its process group while it
+# runs, when it last started, how many times it exited by itself and with
+# which code. It is restarted after a delay doubling up to a minute, reset
+# once it ran for that long. `paul-envs services` restarts it right away by
+# creating a `restart` file and stops it with a `stopped` one.
+supervise_process() {
+    name="$1"
+    user="$2"
+    command="$3"
+    dir="${PROCESSES_DIR}/${name}"
+    mkdir -p "$dir"
+    echo 0 > "$dir/restarts"
+    delay=1
+    while true; do
+        if [ -f "$dir/stopped" ]; then
+            sleep 1
+            continue
+        fi
+        rm -f "$dir/restart"
+        started="$(date +%s)"
+        echo "$started" > "$dir/started"
+        if [ "$user" = "root" ]; then
+            setsid bash -c "$command" >> "$dir/log" 2>&1 < /dev/null &
+        else
+            setsid su "${CONTAINER_USERNAME}" -s /bin/bash \
+                -c 'source $HOME/.container-overrides.bash; eval "$0"' \
+                -- "$command" >> "$dir/log" 2>&1 < /dev/null &
+        fi
+        echo "$!" > "$dir/pid"
+        code=0
+        wait "$!" || code=$?
+        rm -f "$dir/pid"
+        echo "$code" > "$dir/exit"
+        if [ -f "$dir/restart" ] || [ -f "$dir/stopped" ]; then
+            delay=1
+            continue
+        fi
+        echo "$(( $(cat "$dir/restarts") + 1 ))" > "$dir/restarts"
+        if [ $(( $(date +%s) - started )) -ge 60 ]; then
+            delay=1
+        fi
+        echo "[paul-envs] ${name} exited with code ${code}, restarting it in ${delay}s" >> "$dir/log"
+        sleep "$delay"
+        delay=$(( delay * 2 > 60 ? 60 : delay * 2 ))
+    done
+}
+
+# Start supervising the background processes listed in `PAULENV_PROCESSES`
+# ("<name>\t<root or user>\t<command>" lines), once per container.
+start_processes() {
+    if [ -z "${PAULENV_PROCESSES:-}" ] || [ -d "$PROCESSES_DIR" ]; then
+        return 0
+    fi
+    mkdir -p "$PROCESSES_DIR"
+    while IFS=$'\t' read -r name user command <&3; do
+        supervise_process "$name" "$user" "$command" < /dev/null > /dev/null 2>&1 &
+        disown
+    done 3<<< "$PAULENV_PROCESSES"
+}
+
 # Initialize shared cache (only if not already initialized by another container)
 if [ ! -f "$CACHE_MARKER" ]; then
     echo "Initializing shared cache..."
@@ -214,20 +643,77 @@ if [ ! -f "$LOCAL_MARKER" ]; then
 fi
 chown -R "${CONTAINER_USERNAME}:${CONTAINER_USERNAME}" "$CONTAINER_LOCAL_DIR" 2>/dev/null || true
 
+# Shared package caches (`PACKAGE_CACHE` directive), whose volumes and their
+# missing parent directories may have been created as root by the engine
+for cache_dir in ${PAULENV_PACKAGE_CACHES:-}; do
+    dir="$cache_dir"
+    while [ "$dir" != "$HOME_DIR" ] && [ "$dir" != "/" ]; do
+        if [ "$(stat -c %u "$dir" 2>/dev/null)" = "0" ]; then
+            chown "${CONTAINER_USERNAME}:${CONTAINER_USERNAME}" "$dir"
+        fi
+        dir="$(dirname "$dir")"
+    done
+done
+
+# Shared volumes (`SHARED_VOLUME` directive), owned by root when the engine
+# just created them, and tmpfs (`TMPFS` directive), always mounted as root
+for volume_dir in ${PAULENV_SHARED_VOLUMES:-} ${PAULENV_TMPFS:-}; do
+    if [ "$(stat -c %u "$volume_dir" 2>/dev/null)" = "0" ]; then
+        chown "${CONTAINER_USERNAME}:${CONTAINER_USERNAME}" "$volume_dir"
+    fi
+done
+
+# Directory of forwarded sockets (display, audio), only usable by its owner
+if [ -n "${XDG_RUNTIME_DIR:-}" ] && [ -d "$XDG_RUNTIME_DIR" ]; then
+    chown "${CONTAINER_USERNAME}:${CONTAINER_USERNAME}" "$XDG_RUNTIME_DIR"
+    chmod 700 "$XDG_RUNTIME_DIR"
+fi
+
+# Host groups given to the container user (`GROUP` directive). `su` resets
+# supplementary groups from /etc/group, so they have to be registered there.
+if [ -n "${PAULENV_GROUP_IDS:-}" ]; then
+    for gid in ${PAULENV_GROUP_IDS//,/ }; do
+        group_name="$(getent group "$gid" | cut -d: -f1)"
+        if [ -z "$group_name" ]; then
+            group_name="paulenv-host-${gid}"
+            groupadd --gid "$gid" "$group_name"
+        fi
+        usermod --append --groups "$group_name" "$CONTAINER_USERNAME"
+    done
+fi
+
+ensure_locale
 sync_dotfiles
 write_shell_overrides
+write_activation_hooks
 ensure_managed_block "${HOME_DIR}/.bashrc" "bash"
 ensure_managed_block "${HOME_DIR}/.bash_profile" "bash"
 ensure_managed_block "${HOME_DIR}/.zshrc" "zsh"
 ensure_managed_block "${HOME_DIR}/.zprofile" "zsh"
 ensure_managed_block "${HOME_DIR}/.config/fish/config.fish" "fish"
+ensure_managed_block "${HOME_DIR}/.config/nushell/config.nu" "nushell"
 chown "${CONTAINER_USERNAME}:${CONTAINER_USERNAME}" \
     "${HOME_DIR}/.bashrc" \
     "${HOME_DIR}/.bash_profile" \
     "${HOME_DIR}/.zshrc" \
     "${HOME_DIR}/.zprofile" \
-    "${HOME_DIR}/.config/fish/config.fish"
+    "${HOME_DIR}/.config/fish/config.fish" \
+    "${HOME_DIR}/.config/nushell/config.nu"
+write_nushell_autoloads
+write_git_credential_helper
+write_host_exec_helper
+write_clipboard_helper
+setup_gpg_agent
 apply_git_config
+if ! run_startup_scripts; then
+    exit 1
+fi
+
+if [ -n "${PAULENV_SYSTEMD:-}" ] && [ "$$" -eq 1 ]; then
+    exec /lib/systemd/systemd
+fi
+
+start_processes
 
 # SSH daemon setup
 if [[ -d /var/run/sshd ]] && ! pgrep -x sshd >/dev/null; then
@@ -238,6 +724,15 @@ if [[ -d /var/run/sshd ]] && ! pgrep -x sshd >/dev/null; then
     fi
 fi
 
+# Persistent session (`PERSISTENT_SESSION` directive): interactive shells
+# attach to the container's tmux session, the first one creating it
+if [[ $# -eq 0 ]] && [ -n "${PAULENV_SESSION:-}" ] && [[ -t 0 ]]; then
+    if command -v tmux >/dev/null 2>&1; then
+        exec su "${CONTAINER_USERNAME}" -s /bin/bash -c 'exec tmux new-session -A -s "$PAULENV_SESSION" "$0"' -- "${USER_SHELL}"
+    fi
+    echo "WARNING: tmux is not installed in this image, rebuild it for persistent sessions." >&2
+fi
+
 # Execute command or start shell
 if [[ $# -eq 0 ]]; then
     exec su "${CONTAINER_USERNAME}" -s "${USER_SHELL}"
@@ -246,6 +741,11 @@ else
         *fish)
             exec su "${CONTAINER_USERNAME}" -s "${USER_SHELL}" -c 'source $HOME/.container-overrides.fish; exec $argv[1] $argv[2..]' -- "$@"
             ;;
+        *nushell)
+            # Nushell cannot pass arguments to a command string, commands are
+            # run through bash instead, with the same environment
+            exec su "${CONTAINER_USERNAME}" -s /bin/bash -c 'source $HOME/.container-overrides.bash; exec "$0" "$@"' -- "$@"
+            ;;
         *zsh)
             exec su "${CONTAINER_USERNAME}" -s "${USER_SHELL}" -c 'source $HOME/.container-overrides.zsh; exec "$0" "$@"' -- "$@"
             ;;
@@ -0,0 +1,44 @@
+#!/bin/sh
+
+# Entry point injected at runtime into the containers of images not built by
+# paul-envs, which lack its own (`paul-envs tmp --inject`). It is bind-mounted
+# read-only and run with the image's /bin/sh, only relying on POSIX tools as
+# those images can be minimal ones (alpine, busybox...).
+#
+# The first session of the container applies the dotfiles. Sessions joining
+# it later (`paul-envs tmp --join`) go through it too, only starting their
+# shell or command.
+
+set -eu
+
+DOTFILES_MOUNT_DIR="${DOTFILES_MOUNT_DIR:-/paul-env/dotfiles}"
+SETUP_MARKER="/tmp/.paulenv-injected"
+HOME="${HOME:-/root}"
+export HOME
+
+sync_dotfiles() {
+    if [ ! -d "$DOTFILES_MOUNT_DIR" ]; then
+        return
+    fi
+    mkdir -p "$HOME"
+    # Neither rsync nor tar can be relied on here
+    if ! cp -R "$DOTFILES_MOUNT_DIR/." "$HOME/"; then
+        echo "paul-envs: some dotfiles could not be copied to $HOME" >&2
+    fi
+}
+
+if [ ! -e "$SETUP_MARKER" ]; then
+    sync_dotfiles
+    : > "$SETUP_MARKER"
+fi
+
+if [ "$#" -gt 0 ]; then
+    exec "$@"
+fi
+for shell in "${SHELL:-}" /bin/bash /bin/ash /bin/sh; do
+    if [ -n "$shell" ] && [ -x "$shell" ]; then
+        exec "$shell" -l
+    fi
+done
+echo "paul-envs: no shell found in this image" >&2
+exit 127
@@ -0,0 +1,110 @@
+// # engine_detection_cache.go
+// This file handles the container engine last detected for a selection, kept
+// so later invocations do not have to run it again to find it.
+
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const engineDetectionCacheFilename = "engine-detection.cache"
+
+// Container engine detected for a selection.
+type EngineDetectionCache struct {
+	// When it was detected
+	At time.Time
+	// Requested engine selection, e.g. "auto"
+	Selection string
+	// Preferred engine from the global configuration when it was detected
+	Preferred string
+	// Detected engine: "podman" or "docker"
+	Engine  string
+	Version string
+	// Modification time of its executable, changing when it is upgraded
+	ExecutableModTime time.Time
+}
+
+// Returns the last detected container engine, `nil` if none was recorded.
+func (f *FileStore) GetEngineDetectionCache() (*EngineDetectionCache, error) {
+	file, err := os.Open(f.getEngineDetectionCachePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not open '%s': %w", engineDetectionCacheFilename, err)
+	}
+	defer file.Close()
+
+	cache := &EngineDetectionCache{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "\t")
+		if !found {
+			continue
+		}
+		switch key {
+		case "AT":
+			cache.At, _ = time.Parse(time.RFC3339, value)
+		case "SELECTION":
+			cache.Selection = value
+		case "PREFERRED":
+			cache.Preferred = value
+		case "ENGINE":
+			cache.Engine = value
+		case "VERSION":
+			cache.Version = value
+		case "EXECUTABLE_MTIME":
+			cache.ExecutableModTime, _ = time.Parse(time.RFC3339Nano, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read '%s': %w", engineDetectionCacheFilename, err)
+	}
+	if cache.At.IsZero() || cache.Engine == "" {
+		return nil, nil
+	}
+	return cache, nil
+}
+
+// Record the last detected container engine, replacing the previous one.
+func (f *FileStore) SetEngineDetectionCache(cache EngineDetectionCache) error {
+	var buf bytes.Buffer
+	buf.WriteString("# Container engine last detected, re-detected once stale or failing.\n")
+	buf.WriteString("# Format: <key>\\t<value>\n")
+	fmt.Fprintf(&buf, "AT\t%s\n", cache.At.Format(time.RFC3339))
+	fmt.Fprintf(&buf, "SELECTION\t%s\n", cache.Selection)
+	fmt.Fprintf(&buf, "PREFERRED\t%s\n", cache.Preferred)
+	fmt.Fprintf(&buf, "ENGINE\t%s\n", cache.Engine)
+	fmt.Fprintf(&buf, "VERSION\t%s\n", cache.Version)
+	fmt.Fprintf(&buf, "EXECUTABLE_MTIME\t%s\n", cache.ExecutableModTime.Format(time.RFC3339Nano))
+	if err := f.userFS.MkdirAsUser(f.baseDataDir, 0755); err != nil {
+		return fmt.Errorf("create data directory: %w", err)
+	}
+	if err := f.userFS.WriteFileAsUser(f.getEngineDetectionCachePath(), buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("could not write '%s': %w", engineDetectionCacheFilename, err)
+	}
+	return nil
+}
+
+// Remove the last detected container engine, if any.
+func (f *FileStore) ForgetEngineDetectionCache() error {
+	if err := os.Remove(f.getEngineDetectionCachePath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not remove '%s': %w", engineDetectionCacheFilename, err)
+	}
+	return nil
+}
+
+func (f *FileStore) getEngineDetectionCachePath() string {
+	return filepath.Join(f.baseDataDir, engineDetectionCacheFilename)
+}
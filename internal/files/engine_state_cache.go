@@ -0,0 +1,150 @@
+// # engine_state_cache.go
+// This file handles the last state of projects seen on container engines
+// (images, containers, volumes, networks), kept so it can still be displayed
+// while those engines are unreachable.
+
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const engineStateCacheFilename = "engine-state.cache"
+
+// State of all projects, as last seen on container engines.
+type EngineStateCache struct {
+	// When it was seen
+	At       time.Time
+	Projects map[string]*CachedProjectState
+}
+
+// State of a project as last seen on container engines.
+type CachedProjectState struct {
+	// Name of the engine on which it was seen, empty if none
+	EngineName string
+	// Name of its image, empty if it was not built
+	ImageName string
+	// `nil` if unknown
+	ImageBuiltAt *time.Time
+	ImageSize    string
+	Containers   []CachedContainer
+	Volumes      []string
+	Networks     []string
+}
+
+type CachedContainer struct {
+	Name    string
+	Running bool
+}
+
+// Returns the last state seen on container engines, `nil` if none was
+// recorded.
+func (f *FileStore) GetEngineStateCache() (*EngineStateCache, error) {
+	file, err := os.Open(f.getEngineStateCachePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not open '%s': %w", engineStateCacheFilename, err)
+	}
+	defer file.Close()
+
+	cache := &EngineStateCache{Projects: map[string]*CachedProjectState{}}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Split(line, "\t")
+		if parts[0] == "AT" && len(parts) == 2 {
+			cache.At, _ = time.Parse(time.RFC3339, parts[1])
+			continue
+		}
+		if len(parts) < 2 {
+			continue
+		}
+		state, ok := cache.Projects[parts[1]]
+		if !ok {
+			state = &CachedProjectState{}
+			cache.Projects[parts[1]] = state
+		}
+		switch {
+		case parts[0] == "ENGINE" && len(parts) == 3:
+			state.EngineName = parts[2]
+		case parts[0] == "IMAGE" && len(parts) == 5:
+			state.ImageName = parts[2]
+			if builtAt, err := time.Parse(time.RFC3339, parts[3]); err == nil {
+				state.ImageBuiltAt = &builtAt
+			}
+			state.ImageSize = parts[4]
+		case parts[0] == "CONTAINER" && len(parts) == 4:
+			state.Containers = append(state.Containers, CachedContainer{Name: parts[2], Running: parts[3] == "running"})
+		case parts[0] == "VOLUME" && len(parts) == 3:
+			state.Volumes = append(state.Volumes, parts[2])
+		case parts[0] == "NETWORK" && len(parts) == 3:
+			state.Networks = append(state.Networks, parts[2])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read '%s': %w", engineStateCacheFilename, err)
+	}
+	if cache.At.IsZero() {
+		return nil, nil
+	}
+	return cache, nil
+}
+
+// Record the state currently seen on container engines, replacing the
+// previous one.
+func (f *FileStore) SetEngineStateCache(cache EngineStateCache) error {
+	var buf bytes.Buffer
+	buf.WriteString("# Last state of projects seen on container engines, displayed while they are\n")
+	buf.WriteString("# unreachable. Format: <kind>\\t<project>\\t<values...>\n")
+	fmt.Fprintf(&buf, "AT\t%s\n", cache.At.Format(time.RFC3339))
+	for _, name := range slices.Sorted(maps.Keys(cache.Projects)) {
+		state := cache.Projects[name]
+		if state.EngineName != "" {
+			fmt.Fprintf(&buf, "ENGINE\t%s\t%s\n", name, state.EngineName)
+		}
+		if state.ImageName != "" {
+			builtAt := "-"
+			if state.ImageBuiltAt != nil {
+				builtAt = state.ImageBuiltAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(&buf, "IMAGE\t%s\t%s\t%s\t%s\n", name, state.ImageName, builtAt, state.ImageSize)
+		}
+		for _, container := range state.Containers {
+			status := "stopped"
+			if container.Running {
+				status = "running"
+			}
+			fmt.Fprintf(&buf, "CONTAINER\t%s\t%s\t%s\n", name, container.Name, status)
+		}
+		for _, volume := range state.Volumes {
+			fmt.Fprintf(&buf, "VOLUME\t%s\t%s\n", name, volume)
+		}
+		for _, network := range state.Networks {
+			fmt.Fprintf(&buf, "NETWORK\t%s\t%s\n", name, network)
+		}
+	}
+	if err := f.userFS.MkdirAsUser(f.baseDataDir, 0755); err != nil {
+		return fmt.Errorf("create data directory: %w", err)
+	}
+	if err := f.userFS.WriteFileAsUser(f.getEngineStateCachePath(), buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("could not write '%s': %w", engineStateCacheFilename, err)
+	}
+	return nil
+}
+
+func (f *FileStore) getEngineStateCachePath() string {
+	return filepath.Join(f.baseDataDir, engineStateCacheFilename)
+}
@@ -0,0 +1,93 @@
+package files
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEngineStateCacheRoundTrip(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	store, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+
+	cache, err := store.GetEngineStateCache()
+	if err != nil || cache != nil {
+		t.Fatalf("GetEngineStateCache() = %v, %v, want nil, nil", cache, err)
+	}
+
+	at := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	builtAt := at.Add(-time.Hour)
+	err = store.SetEngineStateCache(EngineStateCache{At: at, Projects: map[string]*CachedProjectState{
+		"app": {
+			EngineName:   "docker",
+			ImageName:    "paulenv:app",
+			ImageBuiltAt: &builtAt,
+			ImageSize:    "800MB",
+			Containers:   []CachedContainer{{Name: "paulenv-app", Running: true}},
+			Volumes:      []string{"paulenv-app-local"},
+			Networks:     []string{"paulenv-app"},
+		},
+		"unbuilt": {},
+	}})
+	if err != nil {
+		t.Fatalf("SetEngineStateCache() error = %v", err)
+	}
+
+	cache, err = store.GetEngineStateCache()
+	if err != nil || cache == nil {
+		t.Fatalf("GetEngineStateCache() = %v, %v", cache, err)
+	}
+	if !cache.At.Equal(at) {
+		t.Fatalf("At = %s, want %s", cache.At, at)
+	}
+	app := cache.Projects["app"]
+	if app == nil || app.EngineName != "docker" || app.ImageName != "paulenv:app" || app.ImageSize != "800MB" {
+		t.Fatalf("Projects[app] = %+v", app)
+	}
+	if app.ImageBuiltAt == nil || !app.ImageBuiltAt.Equal(builtAt) {
+		t.Fatalf("ImageBuiltAt = %v, want %s", app.ImageBuiltAt, builtAt)
+	}
+	if len(app.Containers) != 1 || app.Containers[0] != (CachedContainer{Name: "paulenv-app", Running: true}) {
+		t.Fatalf("Containers = %+v", app.Containers)
+	}
+	if len(app.Volumes) != 1 || len(app.Networks) != 1 {
+		t.Fatalf("Volumes = %v, Networks = %v", app.Volumes, app.Networks)
+	}
+}
+
+func TestEngineDetectionCacheRoundTrip(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	store, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	if cache, err := store.GetEngineDetectionCache(); err != nil || cache != nil {
+		t.Fatalf("GetEngineDetectionCache() = %v, %v, want nil, nil", cache, err)
+	}
+
+	want := EngineDetectionCache{
+		At:                time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC),
+		Selection:         "auto",
+		Preferred:         "podman",
+		Engine:            "podman",
+		Version:           "5.2.1",
+		ExecutableModTime: time.Date(2026, 4, 1, 8, 30, 0, 123456789, time.UTC),
+	}
+	if err := store.SetEngineDetectionCache(want); err != nil {
+		t.Fatalf("SetEngineDetectionCache() error = %v", err)
+	}
+	cache, err := store.GetEngineDetectionCache()
+	if err != nil || cache == nil || !cache.At.Equal(want.At) || !cache.ExecutableModTime.Equal(want.ExecutableModTime) ||
+		cache.Selection != want.Selection || cache.Preferred != want.Preferred || cache.Engine != want.Engine || cache.Version != want.Version {
+		t.Fatalf("GetEngineDetectionCache() = %+v, %v, want %+v", cache, err, want)
+	}
+
+	if err := store.ForgetEngineDetectionCache(); err != nil {
+		t.Fatalf("ForgetEngineDetectionCache() error = %v", err)
+	}
+	if cache, err := store.GetEngineDetectionCache(); err != nil || cache != nil {
+		t.Fatalf("GetEngineDetectionCache() = %v, %v after forgetting it", cache, err)
+	}
+}
@@ -21,8 +21,12 @@ func TestEmbeddedEntrypointContainsRuntimeManagement(t *testing.T) {
 		"paul-envs managed bash overrides",
 		"paul-envs managed zsh overrides",
 		"paul-envs managed fish overrides",
+		"paul-envs managed nushell overrides",
 		"git config --global user.name",
 		"git config --global user.email",
+		"paul-envs managed git credential helper",
+		"$HOME/.gnupg/S.gpg-agent",
+		"tmux new-session -A",
 	}
 	for _, check := range checks {
 		if !strings.Contains(script, check) {
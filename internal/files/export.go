@@ -0,0 +1,107 @@
+// # export.go
+// This file writes bundles allowing to use a project without paul-envs (e.g. a
+// compose file alongside the files it needs).
+
+package files
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/peaberberian/paul-envs/internal/profiling"
+)
+
+// Content of a bundle to write in a directory.
+type Bundle struct {
+	// Files to write, by their path relative to the bundle directory
+	Files map[string][]byte
+	// Directories to copy, by their path relative to the bundle directory. The
+	// value is the source directory.
+	Dirs map[string]string
+}
+
+// Returns a single multi-stage Dockerfile building a project image, including
+// the stages of the shared base image, so it can be built without paul-envs.
+func StandaloneDockerfile() ([]byte, error) {
+	baseData, err := assets.ReadFile("embeds/Dockerfile.base")
+	if err != nil {
+		return nil, err
+	}
+	projectData, err := assets.ReadFile("embeds/Dockerfile")
+	if err != nil {
+		return nil, err
+	}
+
+	replacements := []struct {
+		data     *[]byte
+		old, new string
+	}{
+		{&baseData, "\nFROM ${DISTRIBUTION_IMAGE}\n", "\nFROM ${DISTRIBUTION_IMAGE} AS paulenv-base\n"},
+		{&projectData, "\nARG BASE_IMAGE=paulenv-base:latest\n", "\n"},
+		{&projectData, "\nFROM ${BASE_IMAGE} AS ubuntu-base\n", "\nFROM paulenv-base AS ubuntu-base\n"},
+	}
+	for _, r := range replacements {
+		if !bytes.Contains(*r.data, []byte(r.old)) {
+			return nil, fmt.Errorf("cannot merge Dockerfiles: %q not found", r.old)
+		}
+		*r.data = bytes.Replace(*r.data, []byte(r.old), []byte(r.new), 1)
+	}
+
+	var buf bytes.Buffer
+	buf.Write(baseData)
+	buf.WriteString("\n\n#############################################\n")
+	buf.Write(projectData)
+	return buf.Bytes(), nil
+}
+
+// Returns the script set as entrypoint of project images.
+func EntrypointScript() ([]byte, error) {
+	return assets.ReadFile("embeds/entrypoint.sh")
+}
+
+// Write the given bundle in `dir`, creating it if needed.
+//
+// Fails without writing anything if one of its files already exists, unless
+// `overwrite` is set.
+func (f *FileStore) WriteBundle(ctx context.Context, dir string, bundle Bundle, overwrite bool) error {
+	defer profiling.Track(profiling.CategoryGeneration, "write bundle "+dir)()
+	names := make([]string, 0, len(bundle.Files)+len(bundle.Dirs))
+	for name := range bundle.Files {
+		names = append(names, name)
+	}
+	for name := range bundle.Dirs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if !overwrite {
+		for _, name := range names {
+			if _, err := os.Lstat(filepath.Join(dir, name)); err == nil {
+				return fmt.Errorf("'%s' already exists in %s", name, dir)
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+	}
+
+	if err := f.userFS.MkdirAsUser(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create directory %s: %w", dir, err)
+	}
+	for _, name := range names {
+		target := filepath.Join(dir, name)
+		if data, ok := bundle.Files[name]; ok {
+			if err := f.userFS.WriteFileAsUser(target, data, 0644); err != nil {
+				return fmt.Errorf("cannot write '%s': %w", target, err)
+			}
+			continue
+		}
+		if err := f.userFS.CopyDirAsUser(ctx, bundle.Dirs[name], target); err != nil {
+			return fmt.Errorf("cannot copy '%s': %w", bundle.Dirs[name], err)
+		}
+	}
+	return nil
+}
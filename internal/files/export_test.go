@@ -0,0 +1,54 @@
+package files
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStandaloneDockerfile(t *testing.T) {
+	data, err := StandaloneDockerfile()
+	if err != nil {
+		t.Fatalf("StandaloneDockerfile() error = %v", err)
+	}
+	got := string(data)
+	for _, fragment := range []string{"FROM ${DISTRIBUTION_IMAGE} AS paulenv-base\n", "FROM paulenv-base AS ubuntu-base\n"} {
+		if !strings.Contains(got, fragment) {
+			t.Fatalf("StandaloneDockerfile() should contain %q", fragment)
+		}
+	}
+	if strings.Contains(got, "BASE_IMAGE") {
+		t.Fatalf("StandaloneDockerfile() should not depend on a separately built base image")
+	}
+}
+
+func TestFileStore_WriteBundle(t *testing.T) {
+	store := &FileStore{userFS: &UserFS{homeDir: t.TempDir()}}
+	srcDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(srcDir, ".bashrc"), []byte("alias ll='ls -l'\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	dir := filepath.Join(t.TempDir(), "bundle")
+	bundle := Bundle{
+		Files: map[string][]byte{"compose.yaml": []byte("services: {}\n")},
+		Dirs:  map[string]string{"dotfiles": srcDir},
+	}
+
+	if err := store.WriteBundle(context.Background(), dir, bundle, false); err != nil {
+		t.Fatalf("WriteBundle() error = %v", err)
+	}
+	for _, name := range []string{"compose.yaml", filepath.Join("dotfiles", ".bashrc")} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("WriteBundle() should have written %s: %v", name, err)
+		}
+	}
+
+	if err := store.WriteBundle(context.Background(), dir, bundle, false); err == nil {
+		t.Fatalf("WriteBundle() should refuse to overwrite existing files")
+	}
+	if err := store.WriteBundle(context.Background(), dir, bundle, true); err != nil {
+		t.Fatalf("WriteBundle() with overwrite error = %v", err)
+	}
+}
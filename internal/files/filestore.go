@@ -7,6 +7,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+
+	"github.com/peaberberian/paul-envs/internal/profiling"
 )
 
 const (
@@ -15,6 +18,10 @@ const (
 	projectInternalDirname       = ".paul-env"
 	projectInfoFilename          = "project.lock"
 	buildInfoFilename            = "project.buildinfo"
+	// Copies of the configuration files the project's image was last built
+	// with
+	builtBuildConfigFilename   = "build.conf.built"
+	builtRuntimeConfigFilename = "run.conf.built"
 )
 
 // Struct allowing to create, read and obtain the path of all files created by
@@ -27,6 +34,12 @@ type FileStore struct {
 	baseDataDir   string
 	baseConfigDir string
 	projectsDir   string
+	// Global dotfiles template directory set by the user, the default one if
+	// empty
+	globalDotfilesDir string
+	// Locks currently held by this process, see `lock.go`
+	locksMu sync.Mutex
+	locks   map[string]*heldLock
 }
 
 // Information linked to a given project.
@@ -41,6 +54,36 @@ type ProjectEntry struct {
 	BuildConfigPath string
 	// `run.conf` file associated to this project.
 	RuntimeConfigPath string
+	// `authorized_keys` file to mount in its container if it runs an ssh
+	// server. Only exists once `EnsureProjectSSHKey` has been called.
+	SSHAuthorizedKeysPath string
+	// X11 authority file to mount in its container if it forwards the host's
+	// display. Only exists once `PrepareProjectXauthority` has been called.
+	XauthorityPath string
+	// Directory where `run` serves the host's git credentials to its
+	// container. Only exists once `PrepareProjectGitCredentialsDir` has been
+	// called.
+	GitCredentialsDir string
+	// Directory where `run` serves the host commands its container may run.
+	// Only exists once `PrepareProjectHostExecDir` has been called.
+	HostExecDir string
+	// Directory of the host's gpg public keys to mount in its container if it
+	// forwards the host's gpg-agent. Only exists once `PrepareProjectGPGKeys`
+	// has been called.
+	GPGKeysDir string
+	// File in which its container reports the progress of its startup
+	// scripts. Only exists once `ResetProjectStartupProgress` has been called.
+	StartupProgressPath string
+	// Directory in which the dotfiles profile named by its run.conf, if any,
+	// is found.
+	DotfilesProfilesDir string
+	// Compose files layered on top of its compose export, the global one then
+	// its own. They may not exist.
+	GlobalComposeOverridePath string
+	ComposeOverridePath       string
+	// Dockerfile generated for it with its Dockerfile snippets. Only exists
+	// once `RefreshProjectDockerfile` has been called, if it has snippets.
+	DockerfilePath string
 	// TODO: Last built / last run?
 }
 
@@ -83,6 +126,17 @@ func (f *FileStore) DeleteProjectDirectory(name string) error {
 	return os.RemoveAll(f.getProjectDir(name))
 }
 
+// Rename the files of project `from` to `to`, at once.
+func (f *FileStore) RenameProject(from string, to string) error {
+	if f.DoesProjectExist(to) {
+		return fmt.Errorf("project '%s' already exists", to)
+	}
+	if err := os.Rename(f.getProjectDir(from), f.getProjectDir(to)); err != nil {
+		return fmt.Errorf("failed to rename project directory: %w", err)
+	}
+	return nil
+}
+
 // Returns true if the given project name currently exists on disk.
 func (f *FileStore) DoesProjectExist(name string) bool {
 	infoFile := f.getProjectDir(name)
@@ -94,6 +148,7 @@ func (f *FileStore) DoesProjectExist(name string) bool {
 
 // Get the project information behind the given project name
 func (f *FileStore) GetProject(name string) (ProjectEntry, error) {
+	defer profiling.Track(profiling.CategoryFiles, "read project "+name)()
 	projectPath, err := f.parseProjectPath(name)
 	if err != nil {
 		if !f.DoesProjectExist(name) {
@@ -106,12 +161,25 @@ func (f *FileStore) GetProject(name string) (ProjectEntry, error) {
 		ProjectPath:       projectPath,
 		BuildConfigPath:   f.GetProjectBuildConfigPath(name),
 		RuntimeConfigPath: f.GetProjectRuntimeConfigPath(name),
+
+		SSHAuthorizedKeysPath: f.GetProjectSSHAuthorizedKeysPath(name),
+		XauthorityPath:        f.GetProjectXauthorityPath(name),
+		GitCredentialsDir:     f.GetProjectGitCredentialsDir(name),
+		HostExecDir:           f.GetProjectHostExecDir(name),
+		GPGKeysDir:            f.GetProjectGPGKeysDir(name),
+		StartupProgressPath:   f.GetProjectStartupProgressPath(name),
+		DotfilesProfilesDir:   f.GetDotfilesProfilesDir(),
+
+		GlobalComposeOverridePath: f.GetGlobalComposeOverridePath(),
+		ComposeOverridePath:       f.GetProjectComposeOverridePath(name),
+		DockerfilePath:            f.GetProjectDockerfilePath(name),
 	}, nil
 }
 
 // Get a list of `ProjectEntry` struct, each describing a single project whose
 // configuration has been created.
 func (f *FileStore) GetAllProjects() ([]ProjectEntry, error) {
+	defer profiling.Track(profiling.CategoryFiles, "read all projects")()
 	dirBase := f.getProjectDirBase()
 	if _, err := os.Stat(dirBase); os.IsNotExist(err) {
 		return []ProjectEntry{}, nil
@@ -155,9 +223,18 @@ func (f *FileStore) GetProjectDotfilesPath(name string) string {
 
 // Get path to the optional global dotfiles template directory.
 func (f *FileStore) GetGlobalDotfilesPath() string {
+	if f.globalDotfilesDir != "" {
+		return f.globalDotfilesDir
+	}
 	return filepath.Join(f.baseConfigDir, "dotfiles")
 }
 
+// Use another directory as global dotfiles template, the default one if
+// empty.
+func (f *FileStore) SetGlobalDotfilesPath(path string) {
+	f.globalDotfilesDir = path
+}
+
 // Returns true if the global dotfiles template directory exists and is non-empty.
 func (f *FileStore) HasGlobalDotfilesTemplate() (bool, error) {
 	dotfilesDir := f.GetGlobalDotfilesPath()
@@ -209,6 +286,18 @@ func (f *FileStore) GetProjectBuildInfoPath(projectName string) string {
 	return f.getBuildInfoFilePathFor(projectName)
 }
 
+// Get path to the copy of the build.conf file the project's image was last
+// built with.
+func (f *FileStore) GetProjectBuiltBuildConfigPath(projectName string) string {
+	return filepath.Join(f.getProjectInternalDir(projectName), builtBuildConfigFilename)
+}
+
+// Get path to the copy of the run.conf file the project's image was last
+// built with.
+func (f *FileStore) GetProjectBuiltRuntimeConfigPath(projectName string) string {
+	return filepath.Join(f.getProjectInternalDir(projectName), builtRuntimeConfigFilename)
+}
+
 // Get directory where a specific project's files will be put.
 func (f *FileStore) getProjectDir(name string) string {
 	return filepath.Join(f.projectsDir, name)
@@ -137,6 +137,33 @@ func TestFileStore_GetGlobalDotfilesPath(t *testing.T) {
 	if got != expected {
 		t.Errorf("GetGlobalDotfilesPath() = %v, want %v", got, expected)
 	}
+
+	store.SetGlobalDotfilesPath("/home/me/dotfiles")
+	if got := store.GetGlobalDotfilesPath(); got != "/home/me/dotfiles" {
+		t.Errorf("GetGlobalDotfilesPath() = %v, want /home/me/dotfiles", got)
+	}
+}
+
+func TestFileStore_GlobalConfig(t *testing.T) {
+	store := &FileStore{
+		userFS:        &UserFS{homeDir: t.TempDir(), sudoUser: nil},
+		baseConfigDir: filepath.Join(t.TempDir(), "paul-envs"),
+	}
+
+	content, err := store.ReadGlobalConfig()
+	if err != nil || content != "" {
+		t.Fatalf("ReadGlobalConfig() = %q, %v, want no content", content, err)
+	}
+	if err := store.WriteGlobalConfig("SHELL fish\n"); err != nil {
+		t.Fatalf("WriteGlobalConfig() error = %v", err)
+	}
+	cfg, err := store.LoadGlobalConfig()
+	if err != nil {
+		t.Fatalf("LoadGlobalConfig() error = %v", err)
+	}
+	if cfg.Shell != "fish" {
+		t.Errorf("LoadGlobalConfig().Shell = %q, want fish", cfg.Shell)
+	}
 }
 
 func TestFileStore_HasGlobalDotfilesTemplate(t *testing.T) {
@@ -209,3 +236,26 @@ func TestFileStore_SeedProjectDotfiles(t *testing.T) {
 		t.Fatalf("expected seeded file to exist: %v", err)
 	}
 }
+
+func TestFileStore_ListDotfilesProfiles(t *testing.T) {
+	store := &FileStore{baseConfigDir: t.TempDir()}
+	profiles, err := store.ListDotfilesProfiles()
+	if err != nil || len(profiles) != 0 {
+		t.Fatalf("ListDotfilesProfiles() = %v, %v, want none", profiles, err)
+	}
+	for _, name := range []string{"work", "minimal"} {
+		if err := os.MkdirAll(store.GetDotfilesProfilePath(name), 0755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(store.GetDotfilesProfilesDir(), "README"), []byte("notes\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	profiles, err = store.ListDotfilesProfiles()
+	if err != nil {
+		t.Fatalf("ListDotfilesProfiles() error = %v", err)
+	}
+	if len(profiles) != 2 || profiles[0] != "minimal" || profiles[1] != "work" {
+		t.Fatalf("ListDotfilesProfiles() = %v, want [minimal work]", profiles)
+	}
+}
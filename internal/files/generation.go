@@ -0,0 +1,172 @@
+// # generation.go
+// The configuration files of a project (`build.conf`, `run.conf` and
+// `project.lock`) are always regenerated together, as a "generation":
+//
+// 1. the new files are first staged in the project's internal directory and
+//    validated, so an invalid generation never replaces a working one.
+// 2. the files they replace are kept aside as the "previous" generation.
+// 3. staged files are then renamed in place. If that fails mid-way, the
+//    previous generation is restored.
+//
+// The previous generation is kept until the next successful build, so it can
+// be restored if that build fails.
+
+package files
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+const (
+	stagedGenerationDirname   = "next"
+	previousGenerationDirname = "previous"
+)
+
+// Content of the configuration files of a project generation.
+type projectGeneration struct {
+	buildConfig   []byte
+	runtimeConfig []byte
+}
+
+// Paths of the files making up a generation of the given project.
+func (f *FileStore) generationFiles(projectName string) []string {
+	return []string{
+		f.GetProjectBuildConfigPath(projectName),
+		f.GetProjectRuntimeConfigPath(projectName),
+		f.getProjectInfoFilePathFor(projectName),
+	}
+}
+
+func (f *FileStore) getStagedGenerationDir(projectName string) string {
+	return filepath.Join(f.getProjectInternalDir(projectName), stagedGenerationDirname)
+}
+
+func (f *FileStore) getPreviousGenerationDir(projectName string) string {
+	return filepath.Join(f.getProjectInternalDir(projectName), previousGenerationDirname)
+}
+
+// Replace the configuration files of the given project by the given
+// generation.
+//
+// The project and its internal directories should already exist.
+func (f *FileStore) writeProjectGeneration(projectName string, gen projectGeneration) error {
+	stagedDir := f.getStagedGenerationDir(projectName)
+	if err := os.RemoveAll(stagedDir); err != nil {
+		return fmt.Errorf("cannot clean previously staged files: %w", err)
+	}
+	if err := f.userFS.MkdirAsUser(stagedDir, 0755); err != nil {
+		return fmt.Errorf("cannot create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stagedDir)
+
+	lockBytes, err := formatProjectInfo()
+	if err != nil {
+		return fmt.Errorf("could not format 'project.lock' file: %v", err)
+	}
+	contents := [][]byte{gen.buildConfig, gen.runtimeConfig, lockBytes}
+	targets := f.generationFiles(projectName)
+	staged := make([]string, len(targets))
+	for i, target := range targets {
+		staged[i] = filepath.Join(stagedDir, filepath.Base(target))
+		if err := f.userFS.WriteFileAsUser(staged[i], contents[i], 0644); err != nil {
+			return fmt.Errorf("cannot write '%s': %w", filepath.Base(target), err)
+		}
+	}
+
+	if _, err := config.LoadBuildConfig(staged[0]); err != nil {
+		return fmt.Errorf("generated build.conf is invalid: %w", err)
+	}
+	if _, err := config.LoadRuntimeConfig(staged[1]); err != nil {
+		return fmt.Errorf("generated run.conf is invalid: %w", err)
+	}
+
+	hasPrevious, err := f.keepCurrentGeneration(projectName)
+	if err != nil {
+		return err
+	}
+	for i, target := range targets {
+		if err := os.Rename(staged[i], target); err != nil {
+			err = fmt.Errorf("cannot replace '%s': %w", filepath.Base(target), err)
+			if hasPrevious {
+				if rbErr := f.RollbackProjectFiles(projectName); rbErr != nil {
+					return fmt.Errorf("%w (and restoring the previous files failed: %v)", err, rbErr)
+				}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Copy the current configuration files of a project as its previous
+// generation, replacing the one already there.
+//
+// Returns `false` without error if the project has no complete generation
+// yet, in which case nothing is kept.
+func (f *FileStore) keepCurrentGeneration(projectName string) (bool, error) {
+	current := f.generationFiles(projectName)
+	contents := make([][]byte, len(current))
+	for i, path := range current {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("cannot read '%s': %w", filepath.Base(path), err)
+		}
+		contents[i] = data
+	}
+
+	previousDir := f.getPreviousGenerationDir(projectName)
+	if err := os.RemoveAll(previousDir); err != nil {
+		return false, fmt.Errorf("cannot remove previous generation: %w", err)
+	}
+	if err := f.userFS.MkdirAsUser(previousDir, 0755); err != nil {
+		return false, fmt.Errorf("cannot create previous generation directory: %w", err)
+	}
+	for i, path := range current {
+		if err := f.userFS.WriteFileAsUser(filepath.Join(previousDir, filepath.Base(path)), contents[i], 0644); err != nil {
+			os.RemoveAll(previousDir)
+			return false, fmt.Errorf("cannot keep '%s': %w", filepath.Base(path), err)
+		}
+	}
+	return true, nil
+}
+
+// Returns `true` if the configuration files of the given project were
+// regenerated since its last successful build and their previous version can
+// be restored through `RollbackProjectFiles`.
+func (f *FileStore) HasPreviousGeneration(projectName string) bool {
+	info, err := os.Stat(f.getPreviousGenerationDir(projectName))
+	return err == nil && info.IsDir()
+}
+
+// Restore the configuration files a project had before they were last
+// regenerated.
+func (f *FileStore) RollbackProjectFiles(projectName string) error {
+	if !f.HasPreviousGeneration(projectName) {
+		return fmt.Errorf("project '%s' has no previous configuration to restore", projectName)
+	}
+	previousDir := f.getPreviousGenerationDir(projectName)
+	for _, target := range f.generationFiles(projectName) {
+		data, err := os.ReadFile(filepath.Join(previousDir, filepath.Base(target)))
+		if err != nil {
+			return fmt.Errorf("cannot read previous '%s': %w", filepath.Base(target), err)
+		}
+		if err := f.userFS.WriteFileAsUser(target, data, 0644); err != nil {
+			return fmt.Errorf("cannot restore '%s': %w", filepath.Base(target), err)
+		}
+	}
+	return os.RemoveAll(previousDir)
+}
+
+// Forget about the previous configuration files of a project, e.g. once a
+// build succeeded with the current ones.
+func (f *FileStore) DiscardPreviousGeneration(projectName string) error {
+	return os.RemoveAll(f.getPreviousGenerationDir(projectName))
+}
@@ -0,0 +1,95 @@
+package files
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProjectGenerations(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	store, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	ctx := context.Background()
+
+	root := t.TempDir()
+	writeRepoDefinition(t, root, "WORKDIR /first\nVERSION 1.1.0\n")
+	def := RepoDefinition{RootDir: root, DefinitionDir: filepath.Join(root, repoDefinitionDirname)}
+	if _, err := store.SyncRepoDefinition(ctx, "repo", def); err != nil {
+		t.Fatalf("SyncRepoDefinition() error = %v", err)
+	}
+	if store.HasPreviousGeneration("repo") {
+		t.Fatal("HasPreviousGeneration() = true after first generation")
+	}
+
+	readRunConf := func() string {
+		t.Helper()
+		data, err := os.ReadFile(store.GetProjectRuntimeConfigPath("repo"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	writeRepoDefinition(t, root, "WORKDIR /second\nVERSION 1.1.0\n")
+	if _, err := store.SyncRepoDefinition(ctx, "repo", def); err != nil {
+		t.Fatalf("SyncRepoDefinition() error = %v", err)
+	}
+	if !strings.Contains(readRunConf(), "WORKDIR /second") {
+		t.Fatalf("unexpected run.conf after regeneration:\n%s", readRunConf())
+	}
+	if !store.HasPreviousGeneration("repo") {
+		t.Fatal("HasPreviousGeneration() = false after regeneration")
+	}
+
+	// An invalid generation never replaces the current one
+	writeRepoDefinition(t, root, "CPUS many\nVERSION 1.1.0\n")
+	if _, err := store.SyncRepoDefinition(ctx, "repo", def); err == nil {
+		t.Fatal("SyncRepoDefinition() expected error for an invalid run.conf")
+	}
+	if !strings.Contains(readRunConf(), "WORKDIR /second") {
+		t.Fatalf("run.conf replaced by an invalid generation:\n%s", readRunConf())
+	}
+	if _, err := os.Stat(store.getStagedGenerationDir("repo")); !os.IsNotExist(err) {
+		t.Fatalf("staged files left behind: %v", err)
+	}
+
+	if err := store.RollbackProjectFiles("repo"); err != nil {
+		t.Fatalf("RollbackProjectFiles() error = %v", err)
+	}
+	if !strings.Contains(readRunConf(), "WORKDIR /first") {
+		t.Fatalf("unexpected run.conf after rollback:\n%s", readRunConf())
+	}
+	if store.HasPreviousGeneration("repo") {
+		t.Fatal("HasPreviousGeneration() = true after rollback")
+	}
+	if err := store.RollbackProjectFiles("repo"); err == nil {
+		t.Fatal("RollbackProjectFiles() expected error without a previous generation")
+	}
+}
+
+func TestWriteFileAsUser_LeavesNoTemporaryFile(t *testing.T) {
+	dir := t.TempDir()
+	u := &UserFS{homeDir: dir}
+	path := filepath.Join(dir, "build.conf")
+	for _, content := range []string{"first\n", "second\n"} {
+		if err := u.WriteFileAsUser(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFileAsUser() error = %v", err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "second\n" {
+		t.Fatalf("ReadFile() = %q, %v, want %q", data, err, "second\n")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("directory contains %d entries, want only build.conf", len(entries))
+	}
+}
@@ -0,0 +1,34 @@
+// # git_credentials.go
+// This file handles the directory where `paul-envs run` serves the host's git
+// credentials to a project's container (`GIT_CREDENTIALS` directive).
+//
+// The whole directory is mounted rather than the socket itself, as the socket
+// only exists while a `run` serves it: containers started otherwise then just
+// find no credentials.
+
+package files
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+const projectGitCredentialsDirname = "git-credentials"
+
+// Name of the socket serving git credentials in that directory.
+const GitCredentialsSocketName = "socket"
+
+// Get path to the directory where the given project's git credentials are
+// served.
+func (f *FileStore) GetProjectGitCredentialsDir(projectName string) string {
+	return filepath.Join(f.getProjectInternalDir(projectName), projectGitCredentialsDirname)
+}
+
+// Create the directory where the given project's git credentials are served,
+// so it can be mounted in its container.
+func (f *FileStore) PrepareProjectGitCredentialsDir(projectName string) error {
+	if err := f.userFS.MkdirAsUser(f.GetProjectGitCredentialsDir(projectName), 0700); err != nil {
+		return fmt.Errorf("cannot create git credentials directory: %w", err)
+	}
+	return nil
+}
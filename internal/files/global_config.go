@@ -0,0 +1,51 @@
+// # global_config.go
+// This file handles the user's global configuration file, setting defaults
+// applying to all projects.
+
+package files
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+const globalConfigFilename = "paul-envs.conf"
+
+// Get path to the global configuration file.
+func (f *FileStore) GetGlobalConfigPath() string {
+	return filepath.Join(f.baseConfigDir, globalConfigFilename)
+}
+
+// Parse the global configuration file, returning the default configuration if
+// there's none.
+func (f *FileStore) LoadGlobalConfig() (config.GlobalConfig, error) {
+	return config.LoadGlobalConfig(f.GetGlobalConfigPath())
+}
+
+// Read the raw content of the global configuration file, empty if there's
+// none.
+func (f *FileStore) ReadGlobalConfig() (string, error) {
+	data, err := os.ReadFile(f.GetGlobalConfigPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("cannot read global configuration: %w", err)
+	}
+	return string(data), nil
+}
+
+// Replace the content of the global configuration file.
+func (f *FileStore) WriteGlobalConfig(content string) error {
+	if err := f.userFS.MkdirAsUser(f.baseConfigDir, 0755); err != nil {
+		return fmt.Errorf("cannot create config directory: %w", err)
+	}
+	path := f.GetGlobalConfigPath()
+	if err := f.userFS.WriteFileAsUser(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write '%s': %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+// # gpg_keys.go
+// This file handles the host's gpg public keys given to containers forwarding
+// its gpg-agent (`GPG_AGENT` directive).
+//
+// The agent only holds secret keys: gpg in the container also has to know the
+// public ones, and how much they are trusted, to sign with them and check
+// signatures.
+
+package files
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+const projectGPGKeysDirname = "gpg-keys"
+
+// Names of the files of the host's public keys and of their owner trust in
+// that directory.
+const (
+	GPGPublicKeysFilename = "public-keys.gpg"
+	GPGOwnerTrustFilename = "ownertrust.txt"
+)
+
+// Get path to the directory of the host's gpg public keys to mount in the
+// given project's container.
+func (f *FileStore) GetProjectGPGKeysDir(projectName string) string {
+	return filepath.Join(f.getProjectInternalDir(projectName), projectGPGKeysDirname)
+}
+
+// Write the host's gpg public keys and their owner trust, as exported by gpg,
+// in the given project's directory for them.
+func (f *FileStore) PrepareProjectGPGKeys(projectName string, publicKeys []byte, ownerTrust []byte) error {
+	dir := f.GetProjectGPGKeysDir(projectName)
+	if err := f.userFS.MkdirAsUser(dir, 0700); err != nil {
+		return fmt.Errorf("cannot create gpg keys directory: %w", err)
+	}
+	if err := f.userFS.WriteFileAsUser(filepath.Join(dir, GPGPublicKeysFilename), publicKeys, 0600); err != nil {
+		return fmt.Errorf("cannot write gpg public keys: %w", err)
+	}
+	if err := f.userFS.WriteFileAsUser(filepath.Join(dir, GPGOwnerTrustFilename), ownerTrust, 0600); err != nil {
+		return fmt.Errorf("cannot write gpg owner trust: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// # history.go
+// This file handles the history of paul-envs invocations: each one appends
+// its arguments, duration, exit code and the container engine calls it made,
+// so `paul-envs history` can tell what was done to an environment before it
+// broke.
+//
+// Unlike `paul-envs.log`, it is meant to be read by users: one JSON object
+// per invocation and no engine output.
+
+package files
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const historyFilename = "history.jsonl"
+
+// Size after which the history is rotated, only keeping the previous one.
+const maxHistorySize = 2 << 20
+
+// A paul-envs invocation, as recorded in the history.
+type HistoryEntry struct {
+	Time time.Time `json:"time"`
+	// Its arguments, without the executable
+	Args []string `json:"args"`
+	// The directory it was run from
+	Dir      string        `json:"dir"`
+	Duration time.Duration `json:"duration"`
+	ExitCode int           `json:"exitCode"`
+	// Its error message if it failed
+	Error       string              `json:"error,omitempty"`
+	EngineCalls []HistoryEngineCall `json:"engineCalls,omitempty"`
+}
+
+// A container engine call made by a paul-envs invocation.
+type HistoryEngineCall struct {
+	Command  string        `json:"command"`
+	Duration time.Duration `json:"duration"`
+	ExitCode int           `json:"exitCode"`
+}
+
+// Get path to the history of paul-envs invocations.
+func (f *FileStore) GetHistoryPath() string {
+	return filepath.Join(f.baseDataDir, historyFilename)
+}
+
+// Append an invocation to the history, rotating it first if it grew too
+// large.
+func (f *FileStore) AppendHistory(entry HistoryEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("cannot encode history entry: %w", err)
+	}
+	if err := f.userFS.MkdirAsUser(f.baseDataDir, 0755); err != nil {
+		return fmt.Errorf("cannot create data directory: %w", err)
+	}
+	path := f.GetHistoryPath()
+	if info, err := os.Stat(path); err == nil && info.Size() > maxHistorySize {
+		if err := os.Rename(path, path+".1"); err != nil {
+			return fmt.Errorf("cannot rotate history: %w", err)
+		}
+	}
+	file, err := f.userFS.AppendFileAsUser(path, 0600)
+	if err != nil {
+		return fmt.Errorf("cannot open history: %w", err)
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// Read the recorded invocations, oldest first, including those of the
+// previous history once rotated. Lines which cannot be parsed (e.g. written
+// by an interrupted invocation) are skipped.
+func (f *FileStore) ReadHistory() ([]HistoryEntry, error) {
+	entries := []HistoryEntry{}
+	for _, path := range []string{f.GetHistoryPath() + ".1", f.GetHistoryPath()} {
+		file, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("cannot open history: %w", err)
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			var entry HistoryEntry
+			if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+				entries = append(entries, entry)
+			}
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read history: %w", err)
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,56 @@
+package files
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	store, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	if entries, err := store.ReadHistory(); err != nil || len(entries) != 0 {
+		t.Fatalf("ReadHistory() without history = %v, %v, want nothing", entries, err)
+	}
+
+	first := HistoryEntry{
+		Time:        time.Date(2026, 1, 2, 13, 4, 5, 0, time.UTC),
+		Args:        []string{"build", "app"},
+		Duration:    90 * time.Second,
+		EngineCalls: []HistoryEngineCall{{Command: "docker build -t paulenv:app .", Duration: time.Minute}},
+	}
+	second := HistoryEntry{Args: []string{"run", "app"}, ExitCode: 1, Error: "run failed"}
+	for _, entry := range []HistoryEntry{first, second} {
+		if err := store.AppendHistory(entry); err != nil {
+			t.Fatalf("AppendHistory() error = %v", err)
+		}
+	}
+	// Interrupted while writing
+	file, _ := os.OpenFile(store.GetHistoryPath(), os.O_APPEND|os.O_WRONLY, 0)
+	file.WriteString(`{"args": ["rem`)
+	file.Close()
+
+	entries, err := store.ReadHistory()
+	if err != nil {
+		t.Fatalf("ReadHistory() error = %v", err)
+	}
+	if len(entries) != 2 || !entries[0].Time.Equal(first.Time) || entries[0].EngineCalls[0].Duration != time.Minute ||
+		entries[1].Error != "run failed" {
+		t.Fatalf("ReadHistory() = %+v, want both entries", entries)
+	}
+
+	// Rotated history is still read, first
+	if err := os.WriteFile(store.GetHistoryPath(), []byte(strings.Repeat("\n", maxHistorySize+1)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AppendHistory(second); err != nil {
+		t.Fatalf("AppendHistory() error = %v", err)
+	}
+	if entries, _ := store.ReadHistory(); len(entries) != 1 {
+		t.Fatalf("ReadHistory() after rotation = %+v, want the new entry only", entries)
+	}
+}
@@ -0,0 +1,32 @@
+// # host_exec.go
+// This file handles the directory where `paul-envs run` serves the host
+// commands a project's container may run (`HOST_COMMAND` directive). Like the
+// one of git credentials, the whole directory is mounted as its socket only
+// exists while a `run` serves it.
+
+package files
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+const projectHostExecDirname = "host-exec"
+
+// Name of the socket serving host commands in that directory.
+const HostExecSocketName = "socket"
+
+// Get path to the directory where the host commands of the given project are
+// served.
+func (f *FileStore) GetProjectHostExecDir(projectName string) string {
+	return filepath.Join(f.getProjectInternalDir(projectName), projectHostExecDirname)
+}
+
+// Create the directory where the host commands of the given project are
+// served, so it can be mounted in its container.
+func (f *FileStore) PrepareProjectHostExecDir(projectName string) error {
+	if err := f.userFS.MkdirAsUser(f.GetProjectHostExecDir(projectName), 0700); err != nil {
+		return fmt.Errorf("cannot create host commands directory: %w", err)
+	}
+	return nil
+}
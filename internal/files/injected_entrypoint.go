@@ -0,0 +1,31 @@
+// # injected_entrypoint.go
+// Images not built by paul-envs lack its entrypoint, applying the dotfiles
+// and letting other sessions join a running container alike. Throwaway
+// environments running them with `tmp --inject` get a minimal one instead,
+// bind-mounted from the file written here.
+
+package files
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+const injectedEntrypointFilename = "inject-entrypoint.sh"
+
+// Write the entrypoint injected into the containers of images lacking
+// paul-envs' own, and returns its path.
+func (f *FileStore) WriteInjectedEntrypoint() (string, error) {
+	data, err := assets.ReadFile("embeds/" + injectedEntrypointFilename)
+	if err != nil {
+		return "", err
+	}
+	if err := f.userFS.MkdirAsUser(f.baseDataDir, 0755); err != nil {
+		return "", fmt.Errorf("cannot create data directory: %w", err)
+	}
+	path := filepath.Join(f.baseDataDir, injectedEntrypointFilename)
+	if err := f.userFS.WriteFileAsUser(path, data, 0644); err != nil {
+		return "", fmt.Errorf("could not write '%s': %w", injectedEntrypointFilename, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,70 @@
+// # lease_warnings.go
+// This file handles when the users of a project's containers were warned that
+// they would be stopped for going past the project's lease, so they are only
+// stopped once that warning had time to be read, even by a `reap` run long
+// after the previous one.
+
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const projectLeaseWarningsFilename = "lease-warnings"
+
+// Returns when the users of that project's containers were warned, by
+// container id.
+func (f *FileStore) GetProjectLeaseWarnings(projectName string) (map[string]time.Time, error) {
+	data, err := os.ReadFile(f.getProjectLeaseWarningsPath(projectName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]time.Time{}, nil
+		}
+		return nil, fmt.Errorf("could not read '%s': %w", projectLeaseWarningsFilename, err)
+	}
+	warnings := map[string]time.Time{}
+	for line := range strings.SplitSeq(string(data), "\n") {
+		at, containerID, _ := strings.Cut(line, "\t")
+		if t, err := time.Parse(time.RFC3339, at); err == nil && containerID != "" {
+			warnings[containerID] = t
+		}
+	}
+	return warnings, nil
+}
+
+// Replace when the users of that project's containers were warned, by
+// container id. The file is removed if there is none.
+func (f *FileStore) SetProjectLeaseWarnings(projectName string, warnings map[string]time.Time) error {
+	path := f.getProjectLeaseWarningsPath(projectName)
+	if len(warnings) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not remove '%s': %w", projectLeaseWarningsFilename, err)
+		}
+		return nil
+	}
+	containerIDs := make([]string, 0, len(warnings))
+	for id := range warnings {
+		containerIDs = append(containerIDs, id)
+	}
+	slices.Sort(containerIDs)
+	var content strings.Builder
+	for _, id := range containerIDs {
+		fmt.Fprintf(&content, "%s\t%s\n", warnings[id].UTC().Format(time.RFC3339), id)
+	}
+	if err := f.userFS.MkdirAsUser(f.getProjectInternalDir(projectName), 0755); err != nil {
+		return fmt.Errorf("cannot create project internal directory: %w", err)
+	}
+	if err := f.userFS.WriteFileAsUser(path, []byte(content.String()), 0644); err != nil {
+		return fmt.Errorf("could not write '%s': %w", projectLeaseWarningsFilename, err)
+	}
+	return nil
+}
+
+func (f *FileStore) getProjectLeaseWarningsPath(projectName string) string {
+	return filepath.Join(f.getProjectInternalDir(projectName), projectLeaseWarningsFilename)
+}
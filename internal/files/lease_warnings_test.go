@@ -0,0 +1,34 @@
+package files
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProjectLeaseWarnings(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	store, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+
+	warnings, err := store.GetProjectLeaseWarnings("myproject")
+	if err != nil || len(warnings) != 0 {
+		t.Fatalf("GetProjectLeaseWarnings() = %v, %v, want none", warnings, err)
+	}
+	at := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	if err := store.SetProjectLeaseWarnings("myproject", map[string]time.Time{"abc": at}); err != nil {
+		t.Fatalf("SetProjectLeaseWarnings() error = %v", err)
+	}
+	warnings, err = store.GetProjectLeaseWarnings("myproject")
+	if err != nil || len(warnings) != 1 || !warnings["abc"].Equal(at) {
+		t.Fatalf("GetProjectLeaseWarnings() = %v, %v, want abc at %v", warnings, err, at)
+	}
+	if err := store.SetProjectLeaseWarnings("myproject", nil); err != nil {
+		t.Fatalf("SetProjectLeaseWarnings(nil) error = %v", err)
+	}
+	warnings, err = store.GetProjectLeaseWarnings("myproject")
+	if err != nil || len(warnings) != 0 {
+		t.Fatalf("GetProjectLeaseWarnings() = %v, %v, want none once cleared", warnings, err)
+	}
+}
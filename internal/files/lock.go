@@ -0,0 +1,169 @@
+// # lock.go
+// Inter-process locks preventing two paul-envs processes (e.g. launched from
+// two terminals) from modifying the same state at once:
+//   - the registry lock is held while projects are created or removed
+//   - a project's lock is held while its image is built or its files and
+//     images are modified
+//
+// When both are needed, the registry lock is always taken first.
+//
+// Readers do not lock: project files are always replaced atomically.
+//
+// The process holding a lock describes itself in its lock file, so others can
+// tell who they wait for.
+//
+// Locks rely on advisory file locks (`flock` or `LockFileEx`), which are
+// released by the OS if the process dies. Lock files are kept in their own
+// directory, so removing a project never removes a lock file others may wait
+// on.
+
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const locksDirname = "locks"
+
+// How often a busy lock is tried again
+const lockRetryInterval = 100 * time.Millisecond
+
+// Wrapped by the errors of locks not waited for because another process
+// holds them.
+var ErrLocked = errors.New("lock held by another process")
+
+// A lock held by this process, which may be taken several times by it (e.g. a
+// `run` triggering a `build`).
+type heldLock struct {
+	file  *os.File
+	count int
+}
+
+// Take the lock of the project registry, blocking until it is available or
+// `ctx` is cancelled.
+//
+// `onWait` is called once if another process holds it.
+// The returned function releases it.
+func (f *FileStore) LockRegistry(ctx context.Context, onWait func()) (func(), error) {
+	return f.lock(ctx, "registry", true, onWait)
+}
+
+// Take the lock of the given project, blocking until it is available or `ctx`
+// is cancelled.
+//
+// `onWait` is called once if another process holds it.
+// The returned function releases it.
+func (f *FileStore) LockProject(ctx context.Context, projectName string, onWait func()) (func(), error) {
+	return f.lock(ctx, "project-"+projectName, true, onWait)
+}
+
+// Take the lock of the given project if no other process holds it, otherwise
+// return an error wrapping `ErrLocked` and describing that process.
+// The returned function releases it.
+func (f *FileStore) TryLockProject(ctx context.Context, projectName string) (func(), error) {
+	return f.lock(ctx, "project-"+projectName, false, nil)
+}
+
+func (f *FileStore) lock(ctx context.Context, name string, wait bool, onWait func()) (func(), error) {
+	f.locksMu.Lock()
+	defer f.locksMu.Unlock()
+	if held, ok := f.locks[name]; ok {
+		held.count++
+		return f.unlockFunc(name), nil
+	}
+
+	// Also created in dry-run mode: other processes have to see what a dry
+	// run waits on, even if it writes nothing else
+	dir := filepath.Join(f.baseDataDir, locksDirname)
+	if err := f.userFS.mkdirAsUserAlways(dir, 0755); err != nil {
+		return nil, fmt.Errorf("cannot create locks directory: %w", err)
+	}
+	path := filepath.Join(dir, name+".lock")
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open lock file: %w", err)
+	}
+	if err := f.userFS.chownIfNeeded(path); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("cannot open lock file: %w", err)
+	}
+	if err := waitForLock(ctx, file, wait, onWait); err != nil {
+		file.Close()
+		return nil, err
+	}
+	describeLockHolder(file)
+	if f.locks == nil {
+		f.locks = map[string]*heldLock{}
+	}
+	f.locks[name] = &heldLock{file: file, count: 1}
+	return f.unlockFunc(name), nil
+}
+
+func waitForLock(ctx context.Context, file *os.File, wait bool, onWait func()) error {
+	for waited := false; ; waited = true {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			return fmt.Errorf("cannot lock %s: %w", file.Name(), err)
+		}
+		if locked {
+			return nil
+		}
+		if !wait {
+			if holder := readLockHolder(file); holder != "" {
+				return fmt.Errorf("%w (%s)", ErrLocked, holder)
+			}
+			return ErrLocked
+		}
+		if !waited && onWait != nil {
+			onWait()
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// Write which process holds the lock of `file` in it. Only informative, so
+// failures are ignored.
+func describeLockHolder(file *os.File) {
+	holder := fmt.Sprintf("pid %d: paul-envs %s", os.Getpid(), strings.Join(os.Args[1:], " "))
+	if file.Truncate(0) == nil {
+		file.WriteAt([]byte(holder), 0)
+	}
+}
+
+// Returns the description of the process holding the lock of `file`, empty
+// if unknown (e.g. on Windows, where the locked file cannot be read).
+func readLockHolder(file *os.File) string {
+	content, err := os.ReadFile(file.Name())
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+func (f *FileStore) unlockFunc(name string) func() {
+	released := false
+	return func() {
+		f.locksMu.Lock()
+		defer f.locksMu.Unlock()
+		if released {
+			return
+		}
+		released = true
+		held := f.locks[name]
+		if held.count--; held.count == 0 {
+			delete(f.locks, name)
+			// Closing the file releases its lock
+			held.file.Close()
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLockProject(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	first, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	// Another process, with its own open file descriptions
+	second, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	ctx := context.Background()
+
+	unlock, err := first.LockProject(ctx, "app", nil)
+	if err != nil {
+		t.Fatalf("LockProject() error = %v", err)
+	}
+	// Locks are reentrant within a process
+	unlockAgain, err := first.LockProject(ctx, "app", nil)
+	if err != nil {
+		t.Fatalf("LockProject() error = %v on a lock already held", err)
+	}
+	unlockAgain()
+
+	waited := false
+	timeoutCtx, cancel := context.WithTimeout(ctx, 300*time.Millisecond)
+	defer cancel()
+	_, err = second.LockProject(timeoutCtx, "app", func() { waited = true })
+	if !errors.Is(err, context.DeadlineExceeded) || !waited {
+		t.Fatalf("LockProject() = %v (waited: %t), want to wait until the deadline", err, waited)
+	}
+	unlockOther, err := second.LockProject(ctx, "other", nil)
+	if err != nil {
+		t.Fatalf("LockProject() error = %v on another project", err)
+	}
+	unlockOther()
+
+	unlock()
+	// Releasing twice has no effect
+	unlock()
+	unlockSecond, err := second.LockProject(timeoutCtx, "app", nil)
+	if err != nil {
+		t.Fatalf("LockProject() error = %v once released", err)
+	}
+	unlockSecond()
+}
+
+func TestLockRegistry_WaitsForRelease(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	first, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	second, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	ctx := context.Background()
+	unlock, err := first.LockRegistry(ctx, nil)
+	if err != nil {
+		t.Fatalf("LockRegistry() error = %v", err)
+	}
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		unlock()
+	}()
+	unlockSecond, err := second.LockRegistry(ctx, nil)
+	if err != nil {
+		t.Fatalf("LockRegistry() error = %v", err)
+	}
+	unlockSecond()
+}
+
+func TestTryLockProject(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	first, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	second, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	ctx := context.Background()
+
+	unlock, err := first.TryLockProject(ctx, "app")
+	if err != nil {
+		t.Fatalf("TryLockProject() error = %v", err)
+	}
+	_, err = second.TryLockProject(ctx, "app")
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("TryLockProject() = %v, want ErrLocked without waiting", err)
+	}
+	if runtime.GOOS != "windows" && !strings.Contains(err.Error(), fmt.Sprintf("pid %d", os.Getpid())) {
+		t.Fatalf("TryLockProject() = %v, should describe the process holding the lock", err)
+	}
+	unlock()
+	unlockSecond, err := second.TryLockProject(ctx, "app")
+	if err != nil {
+		t.Fatalf("TryLockProject() error = %v once released", err)
+	}
+	unlockSecond()
+}
+
+func TestLockRegistry_DryRun(t *testing.T) {
+	SetDryRun(true)
+	t.Cleanup(func() { SetDryRun(false) })
+	// Nothing was ever written in that data directory
+	t.Setenv("XDG_DATA_HOME", filepath.Join(t.TempDir(), "data"))
+	filestore, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	unlock, err := filestore.LockRegistry(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("LockRegistry() error = %v in dry-run mode", err)
+	}
+	unlock()
+}
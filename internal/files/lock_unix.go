@@ -0,0 +1,19 @@
+//go:build !windows
+
+package files
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// Take an exclusive lock on `file` without blocking, returning `false` if it
+// is already held.
+func tryLockFile(file *os.File) (bool, error) {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,253 @@
+// # migrations.go
+// On-disk formats are versioned: paul-envs' state as a whole by its
+// "state.version" file, and each project entry by the VERSION of its
+// "project.lock" file. Formats only change through migration steps, each
+// upgrading one of them to a new version, which `paul-envs migrate` applies
+// to bring an installation made by a previous release up to date.
+//
+// A backup of the data and configuration directories is written before
+// migrating, so a failed migration is reverted and a successful one can be
+// rolled back, e.g. to go back to the previous release.
+
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	versions "github.com/peaberberian/paul-envs/internal"
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+const (
+	stateVersionFilename    = "state.version"
+	migrationBackupsDirname = "migration-backups"
+)
+
+// Upgrade of a format to a new version.
+type migrationStep struct {
+	// Version the format has once it is applied
+	to          utils.Version
+	description string
+	// Does the upgrade, nil if only the version changes. The project's name
+	// is empty for steps of paul-envs' state.
+	apply func(f *FileStore, projectName string) error
+}
+
+// Steps upgrading paul-envs' state, by increasing version.
+var stateMigrationSteps = []migrationStep{
+	{
+		to:          utils.Version{Major: 1, Minor: 0, Patch: 0},
+		description: "record the version of paul-envs' state",
+	},
+}
+
+// Steps upgrading project entries, by increasing version.
+var projectMigrationSteps = []migrationStep{
+	{
+		to:          utils.Version{Major: 1, Minor: 1, Patch: 0},
+		description: "record the version of paul-envs which wrote the project",
+		apply: func(f *FileStore, projectName string) error {
+			pInfo, err := f.ReadProjectInfo(projectName)
+			if err != nil {
+				return err
+			}
+			pInfo.writtenBy = versions.Version.ToString()
+			return f.writeProjectLockInfo(projectName, pInfo)
+		},
+	},
+}
+
+// A pending upgrade of paul-envs' state or of a project entry.
+type Migration struct {
+	// Project it upgrades, empty for paul-envs' state
+	ProjectName string
+	From        utils.Version
+	To          utils.Version
+	// What it does, one line per step
+	Steps []string
+	steps []migrationStep
+}
+
+// e.g. "project 'app': 1.0.0 -> 1.1.0"
+func (m Migration) String() string {
+	target := "paul-envs state"
+	if m.ProjectName != "" {
+		target = fmt.Sprintf("project '%s'", m.ProjectName)
+	}
+	return fmt.Sprintf("%s: %s -> %s", target, m.From.ToString(), m.To.ToString())
+}
+
+// Returns the version of paul-envs' state, 0.0.0 if it was never recorded.
+func (f *FileStore) GetStateVersion() (utils.Version, error) {
+	data, err := os.ReadFile(f.getStateVersionPath())
+	if os.IsNotExist(err) {
+		return utils.Version{}, nil
+	}
+	if err != nil {
+		return utils.Version{}, fmt.Errorf("could not read '%s': %w", stateVersionFilename, err)
+	}
+	version, err := utils.ParseVersion(strings.TrimSpace(string(data)))
+	if err != nil {
+		return utils.Version{}, fmt.Errorf("invalid '%s': %w", stateVersionFilename, err)
+	}
+	return version, nil
+}
+
+// Returns the migrations needed to bring paul-envs' state and every project
+// to the current formats, starting with the state's.
+//
+// Fails if one of them has a newer format than this version of paul-envs
+// knows.
+func (f *FileStore) PendingMigrations() ([]Migration, error) {
+	var migrations []Migration
+	stateVersion, err := f.GetStateVersion()
+	if err != nil {
+		return nil, err
+	}
+	if versions.StateVersion.IsBefore(stateVersion) {
+		return nil, fmt.Errorf("paul-envs' state has version %s, written by a newer paul-envs (this one supports up to %s)",
+			stateVersion.ToString(), versions.StateVersion.ToString())
+	}
+	if m := pendingMigration("", stateVersion, stateMigrationSteps); m != nil {
+		migrations = append(migrations, *m)
+	}
+
+	projects, err := f.GetAllProjects()
+	if err != nil {
+		return nil, err
+	}
+	for _, project := range projects {
+		pInfo, err := f.ReadProjectInfo(project.ProjectName)
+		if err != nil {
+			return nil, fmt.Errorf("project '%s': %w", project.ProjectName, err)
+		}
+		if versions.ProjectLockVersion.IsBefore(pInfo.version) {
+			return nil, fmt.Errorf("project '%s' has version %s, written by a newer paul-envs (this one supports up to %s)",
+				project.ProjectName, pInfo.version.ToString(), versions.ProjectLockVersion.ToString())
+		}
+		if m := pendingMigration(project.ProjectName, pInfo.version, projectMigrationSteps); m != nil {
+			migrations = append(migrations, *m)
+		}
+	}
+	return migrations, nil
+}
+
+func pendingMigration(projectName string, from utils.Version, steps []migrationStep) *Migration {
+	m := Migration{ProjectName: projectName, From: from}
+	for _, step := range steps {
+		if from.IsBefore(step.to) {
+			m.steps = append(m.steps, step)
+			m.Steps = append(m.Steps, fmt.Sprintf("%s: %s", step.to.ToString(), step.description))
+			m.To = step.to
+		}
+	}
+	if len(m.steps) == 0 {
+		return nil
+	}
+	return &m
+}
+
+// Apply the given migrations, once the data and configuration directories
+// are backed up. If one fails, they are restored from that backup.
+//
+// Returns the path of the backup, to roll the migrations back with
+// `RollbackMigrations`.
+func (f *FileStore) Migrate(ctx context.Context, migrations []Migration) (string, error) {
+	dir := filepath.Join(f.baseDataDir, migrationBackupsDirname)
+	if err := f.userFS.MkdirAsUser(dir, 0755); err != nil {
+		return "", fmt.Errorf("cannot create migration backups directory: %w", err)
+	}
+	backupPath := filepath.Join(dir, time.Now().Format("20060102-150405")+".tar.gz")
+	if _, err := f.WriteBackup(ctx, backupPath, nil, nil); err != nil {
+		return "", err
+	}
+	for _, m := range migrations {
+		if err := f.applyMigration(m); err != nil {
+			err = fmt.Errorf("cannot migrate %s: %w", m, err)
+			if restoreErr := f.restoreMigrationBackup(ctx, backupPath); restoreErr != nil {
+				return backupPath, fmt.Errorf("%w, nor restore the previous state from %s: %w", err, backupPath, restoreErr)
+			}
+			os.Remove(backupPath)
+			return "", fmt.Errorf("%w (previous state restored)", err)
+		}
+	}
+	return backupPath, nil
+}
+
+func (f *FileStore) applyMigration(m Migration) error {
+	for _, step := range m.steps {
+		if step.apply != nil {
+			if err := step.apply(f, m.ProjectName); err != nil {
+				return err
+			}
+		}
+		if m.ProjectName == "" {
+			data := []byte(step.to.ToString() + "\n")
+			if err := f.userFS.WriteFileAsUser(f.getStateVersionPath(), data, 0644); err != nil {
+				return fmt.Errorf("could not write '%s': %w", stateVersionFilename, err)
+			}
+			continue
+		}
+		pInfo, err := f.ReadProjectInfo(m.ProjectName)
+		if err != nil {
+			return err
+		}
+		pInfo.version = step.to
+		if err := f.writeProjectLockInfo(m.ProjectName, pInfo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Restore the data and configuration directories as they were before the
+// last migration, removing its backup. Returns the path of that backup.
+//
+// Files written since then are replaced too, projects created since then
+// are kept.
+func (f *FileStore) RollbackMigrations(ctx context.Context) (string, error) {
+	dir := filepath.Join(f.baseDataDir, migrationBackupsDirname)
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("cannot read migration backups: %w", err)
+	}
+	var backups []string
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".tar.gz") {
+			backups = append(backups, entry.Name())
+		}
+	}
+	if len(backups) == 0 {
+		return "", errors.New("no migration to roll back")
+	}
+	// Named by date, so sorted chronologically
+	slices.Sort(backups)
+	backupPath := filepath.Join(dir, backups[len(backups)-1])
+	if err := f.restoreMigrationBackup(ctx, backupPath); err != nil {
+		return "", err
+	}
+	if err := os.Remove(backupPath); err != nil {
+		return "", fmt.Errorf("cannot remove migration backup: %w", err)
+	}
+	return backupPath, nil
+}
+
+func (f *FileStore) restoreMigrationBackup(ctx context.Context, backupPath string) error {
+	// Not in the backup if the state was never versioned
+	if err := os.Remove(f.getStateVersionPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not remove '%s': %w", stateVersionFilename, err)
+	}
+	_, err := f.RestoreBackup(ctx, backupPath, true)
+	return err
+}
+
+func (f *FileStore) getStateVersionPath() string {
+	return filepath.Join(f.baseDataDir, stateVersionFilename)
+}
@@ -0,0 +1,83 @@
+package files
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	versions "github.com/peaberberian/paul-envs/internal"
+)
+
+func TestMigrateAndRollback(t *testing.T) {
+	ctx := context.Background()
+	store := newBackupTestStore(t)
+	bundle := ProjectBundle{
+		ProjectName:   "app",
+		BuildConfig:   []byte("VERSION 1.0.0\nHOST_UID 1000\nHOST_GID 1000\nUSERNAME dev\nUSER_SHELL zsh\n"),
+		RuntimeConfig: []byte("VERSION 1.0.0\nPATH /home/alice/app\n"),
+	}
+	if err := store.ImportProjectBundle(ctx, "app", bundle, bundle.RuntimeConfig); err != nil {
+		t.Fatalf("ImportProjectBundle() error = %v", err)
+	}
+	// As written by a release predating versioned migrations
+	oldLock := "VERSION=1.0.0\nDOCKERFILE_VERSION=2.3.0\n"
+	lockPath := store.getProjectInfoFilePathFor("app")
+	if err := os.WriteFile(lockPath, []byte(oldLock), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	migrations, err := store.PendingMigrations()
+	if err != nil {
+		t.Fatalf("PendingMigrations() error = %v", err)
+	}
+	if len(migrations) != 2 || migrations[0].ProjectName != "" || migrations[1].ProjectName != "app" {
+		t.Fatalf("PendingMigrations() = %v, want the state's then the project's", migrations)
+	}
+	if got := migrations[1].String(); got != "project 'app': 1.0.0 -> 1.1.0" {
+		t.Fatalf("Migration.String() = %q", got)
+	}
+
+	if _, err := store.Migrate(ctx, migrations); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if version, err := store.GetStateVersion(); err != nil || version != versions.StateVersion {
+		t.Fatalf("GetStateVersion() = %v, %v after migrating", version, err)
+	}
+	pInfo, err := store.ReadProjectInfo("app")
+	if err != nil {
+		t.Fatalf("ReadProjectInfo() error = %v", err)
+	}
+	if pInfo.version.ToString() != "1.1.0" || pInfo.dockerfileVersion.ToString() != "2.3.0" || pInfo.writtenBy != versions.Version.ToString() {
+		t.Fatalf("ReadProjectInfo() = %+v after migrating", pInfo)
+	}
+	if migrations, err := store.PendingMigrations(); err != nil || len(migrations) != 0 {
+		t.Fatalf("PendingMigrations() = %v, %v once migrated", migrations, err)
+	}
+
+	if _, err := store.RollbackMigrations(ctx); err != nil {
+		t.Fatalf("RollbackMigrations() error = %v", err)
+	}
+	if content, _ := os.ReadFile(lockPath); string(content) != oldLock {
+		t.Fatalf("project.lock = %q after rolling back, want %q", content, oldLock)
+	}
+	if version, err := store.GetStateVersion(); err != nil || version.ToString() != "0.0.0" {
+		t.Fatalf("GetStateVersion() = %v, %v after rolling back", version, err)
+	}
+	if _, err := store.RollbackMigrations(ctx); err == nil || !strings.Contains(err.Error(), "no migration") {
+		t.Fatalf("RollbackMigrations() = %v, want an error once nothing is left to roll back", err)
+	}
+}
+
+func TestPendingMigrations_NewerFormat(t *testing.T) {
+	store := newBackupTestStore(t)
+	if err := os.MkdirAll(store.baseDataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(store.getStateVersionPath(), []byte("99.0.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.PendingMigrations(); err == nil || !strings.Contains(err.Error(), "newer paul-envs") {
+		t.Fatalf("PendingMigrations() = %v, want an error for a newer state", err)
+	}
+}
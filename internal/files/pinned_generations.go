@@ -0,0 +1,73 @@
+// # pinned_generations.go
+// This file handles the list of previously built images of a project pinned
+// by the user, which are never removed by its retention policy.
+
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const pinnedGenerationsFilename = "pinned-generations"
+
+// Returns the numbers of the generations pinned for that project.
+func (f *FileStore) GetPinnedGenerations(projectName string) (map[int]bool, error) {
+	data, err := os.ReadFile(f.getPinnedGenerationsPath(projectName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[int]bool{}, nil
+		}
+		return nil, fmt.Errorf("could not read '%s': %w", pinnedGenerationsFilename, err)
+	}
+	pinned := map[int]bool{}
+	for line := range strings.FieldsSeq(string(data)) {
+		number, err := strconv.Atoi(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid generation %q in '%s'", line, pinnedGenerationsFilename)
+		}
+		pinned[number] = true
+	}
+	return pinned, nil
+}
+
+// Pin or unpin a generation of that project's image.
+func (f *FileStore) SetGenerationPinned(projectName string, number int, pin bool) error {
+	pinned, err := f.GetPinnedGenerations(projectName)
+	if err != nil {
+		return err
+	}
+	if pinned[number] == pin {
+		return nil
+	}
+	if pin {
+		pinned[number] = true
+	} else {
+		delete(pinned, number)
+	}
+
+	numbers := make([]int, 0, len(pinned))
+	for number := range pinned {
+		numbers = append(numbers, number)
+	}
+	slices.Sort(numbers)
+	var content strings.Builder
+	for _, number := range numbers {
+		fmt.Fprintf(&content, "%d\n", number)
+	}
+	if err := f.userFS.MkdirAsUser(f.getProjectInternalDir(projectName), 0755); err != nil {
+		return fmt.Errorf("cannot create project internal directory: %w", err)
+	}
+	if err := f.userFS.WriteFileAsUser(f.getPinnedGenerationsPath(projectName), []byte(content.String()), 0644); err != nil {
+		return fmt.Errorf("could not write '%s': %w", pinnedGenerationsFilename, err)
+	}
+	return nil
+}
+
+func (f *FileStore) getPinnedGenerationsPath(projectName string) string {
+	return filepath.Join(f.getProjectInternalDir(projectName), pinnedGenerationsFilename)
+}
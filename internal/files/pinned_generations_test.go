@@ -0,0 +1,31 @@
+package files
+
+import "testing"
+
+func TestPinnedGenerations(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	store, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+
+	pinned, err := store.GetPinnedGenerations("myproject")
+	if err != nil || len(pinned) != 0 {
+		t.Fatalf("GetPinnedGenerations() = %v, %v, want none", pinned, err)
+	}
+	for _, number := range []int{3, 1, 3} {
+		if err := store.SetGenerationPinned("myproject", number, true); err != nil {
+			t.Fatalf("SetGenerationPinned(%d, true) error = %v", number, err)
+		}
+	}
+	if err := store.SetGenerationPinned("myproject", 1, false); err != nil {
+		t.Fatalf("SetGenerationPinned(1, false) error = %v", err)
+	}
+	pinned, err = store.GetPinnedGenerations("myproject")
+	if err != nil {
+		t.Fatalf("GetPinnedGenerations() error = %v", err)
+	}
+	if len(pinned) != 1 || !pinned[3] {
+		t.Fatalf("GetPinnedGenerations() = %v, want only 3", pinned)
+	}
+}
@@ -21,6 +21,7 @@ import (
 
 	versions "github.com/peaberberian/paul-envs/internal"
 	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/profiling"
 	"github.com/peaberberian/paul-envs/internal/utils"
 )
 
@@ -29,31 +30,38 @@ var assets embed.FS
 
 // Data needed to construct a project's `build.conf` file.
 type BuildTemplateData struct {
-	Version           string
-	HostUID           string
-	HostGID           string
-	Username          string
-	Shell             string
-	InstallNode       string
-	InstallRust       string
-	InstallPython     string
-	InstallGo         string
-	EnableWasm        string
-	EnableSSH         string
-	EnableSudo        string
-	Packages          string
-	InstallNeovim     string
-	InstallStarship   string
-	InstallOhMyPosh   string
-	InstallAtuin      string
-	InstallMise       string
-	InstallZellij     string
-	InstallJujutsu    string
-	InstallDelta      string
-	InstallOpenCode   string
-	InstallClaudeCode string
-	InstallCodex      string
-	InstallFirefox    string
+	Version                string
+	HostUID                string
+	HostGID                string
+	Username               string
+	Shell                  string
+	InstallNode            string
+	InstallRust            string
+	InstallPython          string
+	InstallGo              string
+	EnableWasm             string
+	EnableCompilerCache    string
+	EnableNestedContainers string
+	EnableSystemd          string
+	EnableSSH              string
+	EnableSudo             string
+	Packages               string
+	PipxPackages           string
+	CargoPackages          string
+	NpmPackages            string
+	InstallNeovim          string
+	InstallStarship        string
+	InstallOhMyPosh        string
+	InstallAtuin           string
+	InstallMise            string
+	InstallZellij          string
+	InstallJujutsu         string
+	InstallDelta           string
+	InstallOpenCode        string
+	InstallClaudeCode      string
+	InstallCodex           string
+	InstallFirefox         string
+	InstallCompletions     string
 }
 
 // Data needed to construct a project's `run.conf` file.
@@ -61,6 +69,7 @@ type RuntimeTemplateData struct {
 	Version         string
 	ProjectHostPath string
 	DotfilesPath    string
+	DotfilesProfile string
 	GitName         string
 	GitEmail        string
 	Volumes         []string
@@ -73,6 +82,9 @@ type projectLockInfo struct {
 	version utils.Version
 	// The Dockerfile version it has been created for.
 	dockerfileVersion utils.Version
+	// Version of paul-envs which created or last migrated the project, empty
+	// if unknown
+	writtenBy string
 }
 
 // Hols
@@ -83,6 +95,15 @@ type buildState struct {
 	builtBy string
 	// The hash of the `build.conf` file the last time the project has been built
 	buildConfigHash string
+	// The hash of the base files (Dockerfile, entrypoint) used for the last
+	// build. Empty if unknown.
+	baseFilesHash string
+	// When the `paulenv-base` image the last build relied on was built, as
+	// formatted in its own build info. Empty if unknown.
+	baseImageBuiltAt string
+	// Digest of the distribution image (e.g. ubuntu:24.04) the `paulenv-base`
+	// image the last build relied on was built from. Empty if unknown.
+	baseImageDigest string
 	// The version of the build.conf file used for the last build
 	buildConfigVersion string
 	// The version of the run.conf file used for the last build
@@ -103,6 +124,8 @@ const (
 	RebuildDifferentMachine
 	RebuildBuildConfigChanged
 	RebuildDifferentEngine
+	RebuildBaseFilesChanged
+	RebuildBaseImageChanged
 )
 
 func (r RebuildReason) String() string {
@@ -115,6 +138,10 @@ func (r RebuildReason) String() string {
 		return "build.conf file has changed since last build"
 	case RebuildDifferentEngine:
 		return "built on a different container engine"
+	case RebuildBaseFilesChanged:
+		return "the base Dockerfile has changed since last build"
+	case RebuildBaseImageChanged:
+		return "the shared base image has been rebuilt since last build"
 	default:
 		return "unknown reason"
 	}
@@ -159,6 +186,7 @@ func (f *FileStore) CreateProjectFiles(
 	buildTplData BuildTemplateData,
 	runtimeTplData RuntimeTemplateData,
 ) error {
+	defer profiling.Track(profiling.CategoryGeneration, "create project files "+projectName)()
 	if err := f.RefreshBaseFiles(); err != nil {
 		return fmt.Errorf("create base files: %w", err)
 	}
@@ -190,10 +218,7 @@ func (f *FileStore) CreateProjectFiles(
 		return fmt.Errorf("create project internal directory: %w", err)
 	}
 
-	buildBytes := buf.Bytes()
-	if err := f.userFS.WriteFileAsUser(f.GetProjectBuildConfigPath(projectName), buildBytes, 0644); err != nil {
-		return fmt.Errorf("write build config file: %w", err)
-	}
+	buildBytes := bytes.Clone(buf.Bytes())
 
 	// Now for run.conf
 
@@ -212,33 +237,32 @@ func (f *FileStore) CreateProjectFiles(
 		return fmt.Errorf("execute runtime config template: %w", err)
 	}
 
-	runtimeBytes := buf.Bytes()
-	if err := f.userFS.WriteFileAsUser(f.GetProjectRuntimeConfigPath(projectName), runtimeBytes, 0644); err != nil {
-		return fmt.Errorf("write runtime config file: %w", err)
-	}
-
-	if err := f.writeProjectInfo(projectName); err != nil {
-		return fmt.Errorf("impossibility to write 'project.lock' file: %w", err)
+	gen := projectGeneration{buildConfig: buildBytes, runtimeConfig: buf.Bytes()}
+	if err := f.writeProjectGeneration(projectName, gen); err != nil {
+		return fmt.Errorf("write project files: %w", err)
 	}
 	return nil
 }
 
-// Write the base Dockerfile file in the base directory if not already done
+// Write the base Dockerfiles in the base directory if not already done
 func (f *FileStore) RefreshBaseFiles() error {
-	// Write Dockerfile
-	// TODO:Check if required first?
-	dockerfileData, err := assets.ReadFile("embeds/Dockerfile")
-	if err != nil {
+	defer profiling.Track(profiling.CategoryGeneration, "write base files")()
+	if err := f.userFS.MkdirAsUser(f.baseDataDir, 0755); err != nil {
 		return err
 	}
 
-	if err = f.userFS.MkdirAsUser(f.baseDataDir, 0755); err != nil {
-		return err
-	}
-	if err = f.userFS.WriteFileAsUser(
-		filepath.Join(f.baseDataDir, "Dockerfile"),
-		dockerfileData, 0644); err != nil {
-		return err
+	// Write Dockerfiles
+	// TODO:Check if required first?
+	for _, name := range []string{"Dockerfile", "Dockerfile.base"} {
+		dockerfileData, err := assets.ReadFile("embeds/" + name)
+		if err != nil {
+			return err
+		}
+		if err = f.userFS.WriteFileAsUser(
+			filepath.Join(f.baseDataDir, name),
+			dockerfileData, 0644); err != nil {
+			return err
+		}
 	}
 
 	// Write entrypoint
@@ -258,9 +282,37 @@ func (f *FileStore) RefreshBaseFiles() error {
 	return nil
 }
 
-// writeProjectInfo writes the given projectLockInfo to a project.lock file
+// Hash of the base files written by `RefreshBaseFiles`, which are inputs of
+// every image build.
+func baseFilesHash() (string, error) {
+	var buf bytes.Buffer
+	for _, name := range []string{"embeds/Dockerfile", "embeds/Dockerfile.base", "embeds/entrypoint.sh"} {
+		data, err := assets.ReadFile(name)
+		if err != nil {
+			return "", fmt.Errorf("cannot read base file '%s': %w", name, err)
+		}
+		buf.WriteString(utils.BufferHash(data))
+	}
+	return utils.BufferHash(buf.Bytes()), nil
+}
+
+// The projectLockInfo of projects created by this version of paul-envs
+func currentProjectLockInfo() projectLockInfo {
+	return projectLockInfo{
+		version:           versions.ProjectLockVersion,
+		dockerfileVersion: versions.DockerfileVersion,
+		writtenBy:         versions.Version.ToString(),
+	}
+}
+
+// writeProjectInfo writes a project.lock file for the current formats
 func (f *FileStore) writeProjectInfo(projectName string) error {
-	bytes, err := formatProjectInfo()
+	return f.writeProjectLockInfo(projectName, currentProjectLockInfo())
+}
+
+// writeProjectLockInfo writes the given projectLockInfo to a project.lock file
+func (f *FileStore) writeProjectLockInfo(projectName string, pInfo projectLockInfo) error {
+	bytes, err := formatProjectLockInfo(pInfo)
 	if err != nil {
 		return fmt.Errorf("could not format 'project.lock' file: %v", err)
 	}
@@ -268,13 +320,20 @@ func (f *FileStore) writeProjectInfo(projectName string) error {
 }
 
 func formatProjectInfo() ([]byte, error) {
+	return formatProjectLockInfo(currentProjectLockInfo())
+}
+
+func formatProjectLockInfo(pInfo projectLockInfo) ([]byte, error) {
 	var buf bytes.Buffer
 	_, err := fmt.Fprintf(&buf,
 		"VERSION=%s\n"+
 			"DOCKERFILE_VERSION=%s\n",
-		versions.ProjectLockVersion.ToString(),
-		versions.DockerfileVersion.ToString(),
+		pInfo.version.ToString(),
+		pInfo.dockerfileVersion.ToString(),
 	)
+	if err == nil && pInfo.writtenBy != "" {
+		_, err = fmt.Fprintf(&buf, "WRITTEN_BY=%s\n", pInfo.writtenBy)
+	}
 
 	if err != nil {
 		return nil, fmt.Errorf("error formatting project.lock content: %w", err)
@@ -285,6 +344,7 @@ func formatProjectInfo() ([]byte, error) {
 
 // ReadProjectInfo reads the project.lock file and returns a populated projectLockInfo struct.
 func (filestore *FileStore) ReadProjectInfo(projectName string) (projectLockInfo, error) {
+	defer profiling.Track(profiling.CategoryFiles, "read project.lock "+projectName)()
 	file, err := os.Open(filestore.getProjectInfoFilePathFor(projectName))
 	if err != nil {
 		return projectLockInfo{}, fmt.Errorf("could not open project.lock: %w", err)
@@ -315,6 +375,10 @@ func (filestore *FileStore) ReadProjectInfo(projectName string) (projectLockInfo
 			pInfo.dockerfileVersion = v
 			continue
 		}
+		if writtenBy, ok := strings.CutPrefix(line, "WRITTEN_BY="); ok {
+			pInfo.writtenBy = writtenBy
+			continue
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -329,6 +393,7 @@ func (filestore *FileStore) ReadProjectInfo(projectName string) (projectLockInfo
 //
 // Should be called after each build.
 func (f *FileStore) RefreshBuildInfoFile(projectName string, engineName string, engineVersion string) error {
+	defer profiling.Track(profiling.CategoryFiles, "write project.buildinfo "+projectName)()
 	machineId, err := f.getMachineID()
 	if err != nil {
 		return fmt.Errorf("failed to create 'project.buildinfo' file: %w", err)
@@ -347,11 +412,23 @@ func (f *FileStore) RefreshBuildInfoFile(projectName string, engineName string,
 		return fmt.Errorf("failed to create 'project.buildinfo' file due to impossibility to read file '%s': %w", buildConfigPath, err)
 	}
 	buildConfigHash := utils.BufferHash(buildConfigBytes)
+	baseFilesHash, err := baseFilesHash()
+	if err != nil {
+		return fmt.Errorf("failed to create 'project.buildinfo' file: %w", err)
+	}
+	baseImageBuiltAt, baseImageDigest := "", ""
+	if baseState, err := f.ReadBaseImageBuildInfo(engineName); err == nil {
+		baseImageBuiltAt = baseState.builtAt.Format(time.RFC3339)
+		baseImageDigest = baseState.distributionDigest
+	}
 	now := time.Now()
 	buildInfoBytes, err := formatBuildInfo(buildState{
 		version:                versions.BuildInfoVersion,
 		builtBy:                machineId,
 		buildConfigHash:        buildConfigHash,
+		baseFilesHash:          baseFilesHash,
+		baseImageBuiltAt:       baseImageBuiltAt,
+		baseImageDigest:        baseImageDigest,
 		buildConfigVersion:     buildCfg.Version.ToString(),
 		runtimeConfigVersion:   runtimeCfg.Version.ToString(),
 		builtAt:                now,
@@ -366,11 +443,30 @@ func (f *FileStore) RefreshBuildInfoFile(projectName string, engineName string,
 	if err != nil {
 		return fmt.Errorf("failed to create 'project.buildinfo' due to impossibility to write '%s': %w", buildInfoPath, err)
 	}
+	return f.saveBuiltConfigs(projectName, buildConfigBytes)
+}
+
+// Keep a copy of the configuration files a project's image was built with, to
+// tell what changed since.
+func (f *FileStore) saveBuiltConfigs(projectName string, buildConfigBytes []byte) error {
+	builtBuildConfigPath := f.GetProjectBuiltBuildConfigPath(projectName)
+	if err := f.userFS.WriteFileAsUser(builtBuildConfigPath, buildConfigBytes, 0644); err != nil {
+		return fmt.Errorf("failed to write '%s': %w", builtBuildConfigPath, err)
+	}
+	runtimeConfigBytes, err := os.ReadFile(f.GetProjectRuntimeConfigPath(projectName))
+	if err != nil {
+		return fmt.Errorf("failed to read run.conf: %w", err)
+	}
+	builtRuntimeConfigPath := f.GetProjectBuiltRuntimeConfigPath(projectName)
+	if err := f.userFS.WriteFileAsUser(builtRuntimeConfigPath, runtimeConfigBytes, 0644); err != nil {
+		return fmt.Errorf("failed to write '%s': %w", builtRuntimeConfigPath, err)
+	}
 	return nil
 }
 
 // ReadBuildInfo reads the "project.buildinfo" file and returns a populated buildState struct.
 func (filestore *FileStore) ReadBuildInfo(projectName string) (*buildState, error) {
+	defer profiling.Track(profiling.CategoryFiles, "read project.buildinfo "+projectName)()
 	file, err := os.Open(filestore.getBuildInfoFilePathFor(projectName))
 	if err != nil {
 		return nil, fmt.Errorf("could not open 'project.buildinfo': %w", err)
@@ -404,6 +500,18 @@ func (filestore *FileStore) ReadBuildInfo(projectName string) (*buildState, erro
 			bState.buildConfigHash = v
 			continue
 		}
+		if v, ok := strings.CutPrefix(line, "BASE_FILES="); ok {
+			bState.baseFilesHash = v
+			continue
+		}
+		if v, ok := strings.CutPrefix(line, "BASE_IMAGE_BUILT_AT="); ok {
+			bState.baseImageBuiltAt = v
+			continue
+		}
+		if v, ok := strings.CutPrefix(line, "BASE_IMAGE_DIGEST="); ok {
+			bState.baseImageDigest = v
+			continue
+		}
 		if v, ok := strings.CutPrefix(line, "BUILD_CONFIG_VERSION="); ok {
 			bState.buildConfigVersion = v
 			continue
@@ -466,6 +574,7 @@ func (filestore *FileStore) ReadBuildInfo(projectName string) (*buildState, erro
 }
 
 func (filestore *FileStore) NeedsRebuild(projectName string, currentEngineName string, bState *buildState) (bool, RebuildReason, error) {
+	defer profiling.Track(profiling.CategoryFiles, "check rebuild "+projectName)()
 	if bState == nil {
 		return false, RebuildNotNeeded, errors.New("cannot determine if rebuild is needed, no build state")
 	}
@@ -485,10 +594,33 @@ func (filestore *FileStore) NeedsRebuild(projectName string, currentEngineName s
 		return true, RebuildBuildConfigChanged, nil
 	}
 
+	if bState.baseFilesHash != "" {
+		currentBaseFilesHash, err := baseFilesHash()
+		if err != nil {
+			return false, RebuildNotNeeded, err
+		}
+		if bState.baseFilesHash != currentBaseFilesHash {
+			return true, RebuildBaseFilesChanged, nil
+		}
+	}
+
 	if currentEngineName != "" && bState.containerEngine != currentEngineName {
 		return true, RebuildDifferentEngine, nil
 	}
 
+	if bState.baseImageBuiltAt != "" || bState.baseImageDigest != "" {
+		baseState, err := filestore.ReadBaseImageBuildInfo(bState.containerEngine)
+		switch {
+		case err != nil:
+		case bState.baseImageDigest != "" && baseState.distributionDigest != "" &&
+			baseState.distributionDigest != bState.baseImageDigest:
+			// Rebuilt from an updated distribution image
+			return true, RebuildBaseImageChanged, nil
+		case bState.baseImageBuiltAt != "" && baseState.builtAt.Format(time.RFC3339) != bState.baseImageBuiltAt:
+			return true, RebuildBaseImageChanged, nil
+		}
+	}
+
 	return false, RebuildNotNeeded, nil
 }
 
@@ -500,6 +632,24 @@ func (filestore *FileStore) GetBuildEngineSelection(projectName string) (string,
 	return buildInfo.containerEngine, nil
 }
 
+// Remove the record of the last build of the given project, e.g. once its
+// image was removed outside of paul-envs, so it is considered as never built.
+func (filestore *FileStore) ForgetBuild(projectName string) error {
+	err := os.Remove(filestore.getBuildInfoFilePathFor(projectName))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not remove 'project.buildinfo': %w", err)
+	}
+	for _, path := range []string{
+		filestore.GetProjectBuiltBuildConfigPath(projectName),
+		filestore.GetProjectBuiltRuntimeConfigPath(projectName),
+	} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not remove '%s': %w", filepath.Base(path), err)
+		}
+	}
+	return nil
+}
+
 func (filestore *FileStore) ValidateProjectLock(projectName string) (ProjectLockStatus, error) {
 	pInfo, err := filestore.ReadProjectInfo(projectName)
 	if err != nil {
@@ -542,6 +692,9 @@ func formatBuildInfo(bInfo buildState) ([]byte, error) {
 		"VERSION=%s\n"+
 			"BUILT_BY=%s\n"+
 			"BUILD_CONFIG=%s\n"+
+			"BASE_FILES=%s\n"+
+			"BASE_IMAGE_BUILT_AT=%s\n"+
+			"BASE_IMAGE_DIGEST=%s\n"+
 			"BUILD_CONFIG_VERSION=%s\n"+
 			"RUNTIME_CONFIG_VERSION=%s\n"+
 			"LAST_BUILT_AT=%s\n"+
@@ -550,6 +703,9 @@ func formatBuildInfo(bInfo buildState) ([]byte, error) {
 		bInfo.version.ToString(),
 		bInfo.builtBy,
 		bInfo.buildConfigHash,
+		bInfo.baseFilesHash,
+		bInfo.baseImageBuiltAt,
+		bInfo.baseImageDigest,
 		bInfo.buildConfigVersion,
 		bInfo.runtimeConfigVersion,
 		bInfo.builtAt.Format(time.RFC3339),
@@ -23,31 +23,36 @@ func TestFileStore_CreateProjectFiles(t *testing.T) {
 	}
 
 	buildTplData := BuildTemplateData{
-		Version:           "1.0.0",
-		HostUID:           "1000",
-		HostGID:           "1000",
-		Username:          "testuser",
-		Shell:             "bash",
-		InstallNode:       "latest",
-		InstallRust:       "none",
-		InstallPython:     "3.12.0",
-		InstallGo:         "none",
-		EnableWasm:        "false",
-		EnableSSH:         "true",
-		EnableSudo:        "true",
-		Packages:          "git vim",
-		InstallNeovim:     "true",
-		InstallStarship:   "true",
-		InstallOhMyPosh:   "true",
-		InstallAtuin:      "false",
-		InstallMise:       "true",
-		InstallZellij:     "false",
-		InstallJujutsu:    "false",
-		InstallDelta:      "false",
-		InstallOpenCode:   "false",
-		InstallClaudeCode: "false",
-		InstallCodex:      "false",
-		InstallFirefox:    "false",
+		Version:                "1.0.0",
+		HostUID:                "1000",
+		HostGID:                "1000",
+		Username:               "testuser",
+		Shell:                  "bash",
+		InstallNode:            "latest",
+		InstallRust:            "none",
+		InstallPython:          "3.12.0",
+		InstallGo:              "none",
+		EnableWasm:             "false",
+		EnableCompilerCache:    "false",
+		EnableNestedContainers: "false",
+		EnableSystemd:          "false",
+		EnableSSH:              "true",
+		EnableSudo:             "true",
+		Packages:               "git vim",
+		NpmPackages:            "typescript@5 prettier",
+		InstallNeovim:          "true",
+		InstallStarship:        "true",
+		InstallOhMyPosh:        "true",
+		InstallAtuin:           "false",
+		InstallMise:            "true",
+		InstallZellij:          "false",
+		InstallJujutsu:         "false",
+		InstallDelta:           "false",
+		InstallOpenCode:        "false",
+		InstallClaudeCode:      "false",
+		InstallCodex:           "false",
+		InstallFirefox:         "false",
+		InstallCompletions:     "true",
 	}
 
 	runtimeTplData := RuntimeTemplateData{
@@ -95,6 +100,8 @@ func TestFileStore_CreateProjectFiles(t *testing.T) {
 		`USER_SHELL bash`,
 		`INSTALL_NODE latest`,
 		`ENABLE_SSH true`,
+		`INSTALL_COMPLETIONS true`,
+		`NPM_PACKAGES typescript@5 prettier`,
 		`HOST_UID 1000`,
 	}
 	for _, check := range buildChecks {
@@ -138,3 +145,109 @@ func TestFileStore_CreateProjectFiles(t *testing.T) {
 		}
 	}
 }
+
+func TestFileStore_NeedsRebuildOnBaseFilesChange(t *testing.T) {
+	baseDataDir := t.TempDir()
+	store := &FileStore{
+		userFS:        &UserFS{homeDir: t.TempDir()},
+		baseDataDir:   baseDataDir,
+		baseConfigDir: t.TempDir(),
+		projectsDir:   filepath.Join(baseDataDir, "projects"),
+	}
+	err := store.CreateProjectFiles("stale",
+		BuildTemplateData{
+			Version:                "1.0.0",
+			HostUID:                "1000",
+			HostGID:                "1000",
+			Username:               "dev",
+			Shell:                  "bash",
+			InstallNode:            "none",
+			InstallRust:            "none",
+			InstallPython:          "none",
+			InstallGo:              "none",
+			EnableWasm:             "false",
+			EnableCompilerCache:    "false",
+			EnableNestedContainers: "false",
+			EnableSystemd:          "false",
+			EnableSSH:              "false",
+			EnableSudo:             "false",
+			InstallNeovim:          "false",
+			InstallStarship:        "false",
+			InstallOhMyPosh:        "false",
+			InstallAtuin:           "false",
+			InstallMise:            "false",
+			InstallZellij:          "false",
+			InstallJujutsu:         "false",
+			InstallDelta:           "false",
+			InstallOpenCode:        "false",
+			InstallClaudeCode:      "false",
+			InstallCodex:           "false",
+			InstallFirefox:         "false",
+			InstallCompletions:     "true",
+		},
+		RuntimeTemplateData{Version: "1.0.0", ProjectHostPath: "/host/path"},
+	)
+	if err != nil {
+		t.Fatalf("CreateProjectFiles() error = %v", err)
+	}
+	if err := store.RefreshBuildInfoFile("stale", "docker", "27.0.0"); err != nil {
+		t.Fatalf("RefreshBuildInfoFile() error = %v", err)
+	}
+	for _, path := range []string{store.GetProjectBuiltBuildConfigPath("stale"), store.GetProjectBuiltRuntimeConfigPath("stale")} {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("a copy of the configuration the project was built with should be kept: %v", err)
+		}
+	}
+
+	bState, err := store.ReadBuildInfo("stale")
+	if err != nil {
+		t.Fatalf("ReadBuildInfo() error = %v", err)
+	}
+	if needed, reason, err := store.NeedsRebuild("stale", "docker", bState); err != nil || needed {
+		t.Fatalf("NeedsRebuild() = %v, %v, %v right after a build, want false", needed, reason, err)
+	}
+
+	bState.baseFilesHash = "outdated"
+	needed, reason, err := store.NeedsRebuild("stale", "docker", bState)
+	if err != nil || !needed || reason != RebuildBaseFilesChanged {
+		t.Fatalf("NeedsRebuild() = %v, %v, %v, want true, %v", needed, reason, err, RebuildBaseFilesChanged)
+	}
+
+	// Build info written by older versions has no base files hash
+	bState.baseFilesHash = ""
+	if needed, reason, err := store.NeedsRebuild("stale", "docker", bState); err != nil || needed {
+		t.Fatalf("NeedsRebuild() = %v, %v, %v without base files hash, want false", needed, reason, err)
+	}
+
+	if err := store.RefreshBaseImageBuildInfo("docker", "", ""); err != nil {
+		t.Fatalf("RefreshBaseImageBuildInfo() error = %v", err)
+	}
+	bState.baseImageBuiltAt = "2000-01-01T00:00:00Z"
+	needed, reason, err = store.NeedsRebuild("stale", "docker", bState)
+	if err != nil || !needed || reason != RebuildBaseImageChanged {
+		t.Fatalf("NeedsRebuild() = %v, %v, %v, want true, %v", needed, reason, err, RebuildBaseImageChanged)
+	}
+
+	// Base image rebuilt from an updated distribution image
+	if err := store.RefreshBaseImageBuildInfo("docker", "", "sha256:aaa"); err != nil {
+		t.Fatalf("RefreshBaseImageBuildInfo() error = %v", err)
+	}
+	if err := store.RefreshBuildInfoFile("stale", "docker", "27.0.0"); err != nil {
+		t.Fatalf("RefreshBuildInfoFile() error = %v", err)
+	}
+	if bState, err = store.ReadBuildInfo("stale"); err != nil || bState.baseImageDigest != "sha256:aaa" {
+		t.Fatalf("ReadBuildInfo() = %+v, %v, want the digest of the base image's distribution image", bState, err)
+	}
+	if needed, reason, err := store.NeedsRebuild("stale", "docker", bState); err != nil || needed {
+		t.Fatalf("NeedsRebuild() = %v, %v, %v right after a build, want false", needed, reason, err)
+	}
+	if err := store.RefreshBaseImageBuildInfo("docker", "", "sha256:bbb"); err != nil {
+		t.Fatalf("RefreshBaseImageBuildInfo() error = %v", err)
+	}
+	// Even if recorded within the same second
+	bState.baseImageBuiltAt = ""
+	needed, reason, err = store.NeedsRebuild("stale", "docker", bState)
+	if err != nil || !needed || reason != RebuildBaseImageChanged {
+		t.Fatalf("NeedsRebuild() = %v, %v, %v after a new distribution digest, want true, %v", needed, reason, err, RebuildBaseImageChanged)
+	}
+}
@@ -0,0 +1,372 @@
+// # project_bundle.go
+// Projects can be recreated on another machine from a bundle: a gzipped tar
+// archive of their definition (build.conf, run.conf, README and dotfiles),
+// alongside files written by the caller (e.g. a compose export usable without
+// paul-envs).
+//
+// Values specific to the exporting user are not shared: the git author
+// identity and credentials given to services are stripped, and host paths are
+// remapped when importing it.
+
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/profiling"
+)
+
+const (
+	projectBundleManifestFilename = "paulenv-bundle"
+	projectBundleVersion          = 1
+	projectBundleDotfilesDir      = "dotfiles"
+)
+
+// A project definition read from a bundle.
+type ProjectBundle struct {
+	// Name of the project it was exported from
+	ProjectName   string
+	BuildConfig   []byte
+	RuntimeConfig []byte
+	// `nil` if it has none
+	Readme []byte
+	// By their path relative to the dotfiles directory
+	Dotfiles []ArchiveFile
+	// Directives whose value was stripped when exporting (e.g.
+	// "GIT_AUTHOR_NAME" or "SERVICE_ENV db POSTGRES_PASSWORD")
+	Stripped []string
+}
+
+// Returns the project directory of the machine it was exported from, as
+// written in its run.conf.
+func (b ProjectBundle) ProjectPath() string {
+	projectPath := ""
+	rewriteDirectives(b.RuntimeConfig, func(key string, value string) (string, bool) {
+		if key == "PATH" {
+			projectPath = value
+		}
+		return "", false
+	})
+	return projectPath
+}
+
+// A host path prefix replaced by another when importing a bundle.
+type PathMapping struct {
+	From string
+	To   string
+}
+
+// Write the definition of the given project as a bundle at `path`, with
+// `extraFiles` at its root and `dotfilesDir`, the project's dotfiles if not
+// empty, as its dotfiles.
+//
+// Returns the directives whose value has been stripped.
+func (f *FileStore) ExportProjectBundle(projectName string, dotfilesDir string, extraFiles []ArchiveFile, path string) ([]string, error) {
+	defer profiling.Track(profiling.CategoryGeneration, "export project bundle "+projectName)()
+	buildConfig, err := os.ReadFile(f.GetProjectBuildConfigPath(projectName))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read build.conf: %w", err)
+	}
+	runtimeConfig, err := os.ReadFile(f.GetProjectRuntimeConfigPath(projectName))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read run.conf: %w", err)
+	}
+	var dotfiles []ArchiveFile
+	if dotfilesDir != "" {
+		if dotfiles, err = readDotfiles(dotfilesDir); err != nil {
+			return nil, err
+		}
+	}
+	runtimeConfig, stripped := bundleRuntimeConfig(runtimeConfig, len(dotfiles) > 0)
+
+	var manifest bytes.Buffer
+	manifest.WriteString("# paul-envs project bundle, import it with 'paul-envs import <bundle>'\n")
+	fmt.Fprintf(&manifest, "VERSION %d\n", projectBundleVersion)
+	fmt.Fprintf(&manifest, "NAME %s\n", projectName)
+	for _, directive := range stripped {
+		fmt.Fprintf(&manifest, "STRIPPED %s\n", directive)
+	}
+	archiveFiles := []ArchiveFile{
+		{Name: projectBundleManifestFilename, Data: manifest.Bytes(), Mode: 0644},
+		{Name: projectBuildConfigFilename, Data: buildConfig, Mode: 0644},
+		{Name: projectRuntimeConfigFilename, Data: runtimeConfig, Mode: 0644},
+	}
+	if readme, err := os.ReadFile(f.GetProjectReadmePath(projectName)); err == nil {
+		archiveFiles = append(archiveFiles, ArchiveFile{Name: projectReadmeFilename, Data: readme, Mode: 0644})
+	}
+	for _, dotfile := range dotfiles {
+		dotfile.Name = projectBundleDotfilesDir + "/" + dotfile.Name
+		archiveFiles = append(archiveFiles, dotfile)
+	}
+	archiveFiles = append(archiveFiles, extraFiles...)
+	if err := f.WriteArchive(path, archiveFiles); err != nil {
+		return nil, err
+	}
+	return stripped, nil
+}
+
+// Read the bundle at `path`, written by `ExportProjectBundle`.
+func ReadProjectBundle(path string) (ProjectBundle, error) {
+	archiveFiles, err := ReadArchive(path)
+	if err != nil {
+		return ProjectBundle{}, err
+	}
+	bundle := ProjectBundle{}
+	hasManifest := false
+	for _, file := range archiveFiles {
+		switch {
+		case file.Name == projectBundleManifestFilename:
+			hasManifest = true
+			if err := parseBundleManifest(file.Data, &bundle); err != nil {
+				return ProjectBundle{}, err
+			}
+		case file.Name == projectBuildConfigFilename:
+			bundle.BuildConfig = file.Data
+		case file.Name == projectRuntimeConfigFilename:
+			bundle.RuntimeConfig = file.Data
+		case file.Name == projectReadmeFilename:
+			bundle.Readme = file.Data
+		case strings.HasPrefix(file.Name, projectBundleDotfilesDir+"/"):
+			file.Name = strings.TrimPrefix(file.Name, projectBundleDotfilesDir+"/")
+			bundle.Dotfiles = append(bundle.Dotfiles, file)
+		}
+	}
+	if !hasManifest || bundle.BuildConfig == nil || bundle.RuntimeConfig == nil {
+		return ProjectBundle{}, fmt.Errorf("'%s' is not a paul-envs project bundle", path)
+	}
+	return bundle, nil
+}
+
+// Create the given project from a bundle, with `runtimeConfig` as its
+// run.conf (e.g. the bundle's one with host paths remapped).
+func (f *FileStore) ImportProjectBundle(ctx context.Context, projectName string, bundle ProjectBundle, runtimeConfig []byte) error {
+	defer profiling.Track(profiling.CategoryGeneration, "import project bundle "+projectName)()
+	if f.DoesProjectExist(projectName) {
+		return fmt.Errorf("project '%s' already exists", projectName)
+	}
+	if err := f.RefreshBaseFiles(); err != nil {
+		return fmt.Errorf("create base files: %w", err)
+	}
+	if err := f.importProjectBundle(ctx, projectName, bundle, runtimeConfig); err != nil {
+		// Not left half-created
+		_ = os.RemoveAll(f.getProjectDir(projectName))
+		return err
+	}
+	return nil
+}
+
+// Create project `to` with the same definition as project `from`: its
+// build.conf and run.conf as they are, its README and the content of its
+// dotfiles directory. It starts unbuilt, with none of its previous
+// configurations.
+func (f *FileStore) CloneProject(ctx context.Context, from string, to string) error {
+	defer profiling.Track(profiling.CategoryGeneration, "clone project "+from)()
+	bundle := ProjectBundle{ProjectName: from}
+	var err error
+	if bundle.BuildConfig, err = os.ReadFile(f.GetProjectBuildConfigPath(from)); err != nil {
+		return fmt.Errorf("cannot read build.conf: %w", err)
+	}
+	if bundle.RuntimeConfig, err = os.ReadFile(f.GetProjectRuntimeConfigPath(from)); err != nil {
+		return fmt.Errorf("cannot read run.conf: %w", err)
+	}
+	if readme, err := os.ReadFile(f.GetProjectReadmePath(from)); err == nil {
+		bundle.Readme = readme
+	}
+	dotfilesDir := f.GetProjectDotfilesPath(from)
+	if _, err := os.Stat(dotfilesDir); err == nil {
+		if bundle.Dotfiles, err = readDotfiles(dotfilesDir); err != nil {
+			return err
+		}
+	}
+	return f.ImportProjectBundle(ctx, to, bundle, bundle.RuntimeConfig)
+}
+
+func (f *FileStore) importProjectBundle(ctx context.Context, projectName string, bundle ProjectBundle, runtimeConfig []byte) error {
+	for _, dir := range []string{f.getProjectDir(projectName), f.GetProjectDotfilesPath(projectName), f.getProjectInternalDir(projectName)} {
+		if err := f.userFS.MkdirAsUser(dir, 0755); err != nil {
+			return fmt.Errorf("create project directory: %w", err)
+		}
+	}
+	gen := projectGeneration{buildConfig: bundle.BuildConfig, runtimeConfig: runtimeConfig}
+	if err := f.writeProjectGeneration(projectName, gen); err != nil {
+		return fmt.Errorf("write project files: %w", err)
+	}
+	dotfilesDir := f.GetProjectDotfilesPath(projectName)
+	for _, dotfile := range bundle.Dotfiles {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		target := filepath.Join(dotfilesDir, filepath.FromSlash(dotfile.Name))
+		if err := f.userFS.MkdirAsUser(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("create dotfiles directory: %w", err)
+		}
+		mode := dotfile.Mode
+		if mode == 0 {
+			mode = 0644
+		}
+		if err := f.userFS.WriteFileAsUser(target, dotfile.Data, mode); err != nil {
+			return fmt.Errorf("write dotfile '%s': %w", dotfile.Name, err)
+		}
+	}
+	if bundle.Readme != nil {
+		if err := f.userFS.WriteFileAsUser(f.GetProjectReadmePath(projectName), bundle.Readme, 0644); err != nil {
+			return fmt.Errorf("write README: %w", err)
+		}
+	}
+	return nil
+}
+
+func parseBundleManifest(data []byte, bundle *ProjectBundle) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "VERSION":
+			version, err := strconv.Atoi(value)
+			if err != nil || version > projectBundleVersion {
+				return fmt.Errorf("unsupported project bundle version %q, paul-envs may need to be updated", value)
+			}
+		case "NAME":
+			bundle.ProjectName = value
+		case "STRIPPED":
+			bundle.Stripped = append(bundle.Stripped, value)
+		}
+	}
+	return scanner.Err()
+}
+
+// Regular files of the given dotfiles directory, by their slash-separated
+// path relative to it.
+func readDotfiles(dir string) ([]ArchiveFile, error) {
+	var dotfiles []ArchiveFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("walk %q: %w", path, err)
+		}
+		// Symbolic links are followed
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("cannot read dotfile '%s': %w", path, err)
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		dotfiles = append(dotfiles, ArchiveFile{Name: filepath.ToSlash(rel), Data: data, Mode: info.Mode().Perm()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot read dotfiles: %w", err)
+	}
+	return dotfiles, nil
+}
+
+// Produce the run.conf of a bundle from the project's one: without the git
+// author identity nor service credentials, and using the bundled dotfiles if
+// `hasDotfiles` is set.
+//
+// Returns it with the directives whose value was stripped.
+func bundleRuntimeConfig(content []byte, hasDotfiles bool) ([]byte, []string) {
+	var stripped []string
+	dotfilesSet := false
+	result := rewriteDirectives(content, func(key string, value string) (string, bool) {
+		switch key {
+		case "GIT_AUTHOR_NAME", "GIT_AUTHOR_EMAIL":
+			if value != "" {
+				stripped = append(stripped, key)
+			}
+			return key + " ", true
+		case "SERVICE_ENV":
+			service, env, _ := strings.Cut(value, " ")
+			envKey, envValue, _ := strings.Cut(strings.TrimSpace(env), "=")
+			if envValue != "" && config.IsCredentialVariable(envKey) {
+				stripped = append(stripped, key+" "+service+" "+envKey)
+				return key + " " + service + " " + envKey + "=", true
+			}
+		case "DOTFILES_PATH", "DOTFILES_PROFILE":
+			if !hasDotfiles {
+				break
+			}
+			if dotfilesSet {
+				return "", false
+			}
+			dotfilesSet = true
+			return "DOTFILES_PATH " + projectBundleDotfilesDir, true
+		}
+		return key + " " + value, true
+	})
+	if hasDotfiles && !dotfilesSet {
+		result = append(result, []byte("DOTFILES_PATH "+projectBundleDotfilesDir+"\n")...)
+	}
+	return result, stripped
+}
+
+// Replace, in the given run.conf, host paths starting with the `From` of one
+// of the given mappings by its `To`.
+func RemapRuntimeConfig(content []byte, mappings []PathMapping) []byte {
+	return rewriteDirectives(content, func(key string, value string) (string, bool) {
+		switch key {
+		case "PATH", "DOTFILES_PATH", "MOUNT", "VOLUME", "SECCOMP_PROFILE", "STARTUP", "ACTIVATE":
+			for _, mapping := range mappings {
+				if rest, ok := cutPathPrefix(value, mapping.From); ok {
+					return key + " " + mapping.To + rest, true
+				}
+			}
+		}
+		return key + " " + value, true
+	})
+}
+
+// Returns what follows `prefix` in `value` if it starts with that path, e.g.
+// "/b" for "/a/b" and "/a" but not for "/ab".
+func cutPathPrefix(value string, prefix string) (string, bool) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	rest, ok := strings.CutPrefix(value, prefix)
+	if !ok || prefix == "" {
+		return "", false
+	}
+	if rest == "" || strings.ContainsAny(rest[:1], "/: \t") {
+		return rest, true
+	}
+	return "", false
+}
+
+// Rewrite each directive line of a configuration file through `rewrite`,
+// which returns its new line or `false` to remove it. Comments and blank
+// lines are kept as-is.
+func rewriteDirectives(content []byte, rewrite func(key string, value string) (string, bool)) []byte {
+	var buf bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" || trimmed[0] == '#' {
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+			continue
+		}
+		key, value, _ := strings.Cut(trimmed, " ")
+		if newLine, keep := rewrite(key, value); keep {
+			buf.WriteString(newLine)
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,192 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBundleRuntimeConfig(t *testing.T) {
+	content := []byte(`# Project directory
+PATH /home/alice/app
+GIT_AUTHOR_NAME Alice
+GIT_AUTHOR_EMAIL
+DOTFILES_PROFILE work
+SERVICE_ENV db POSTGRES_PASSWORD=hunter2
+SERVICE_ENV db POSTGRES_DB=app
+`)
+	got, stripped := bundleRuntimeConfig(content, true)
+	want := `# Project directory
+PATH /home/alice/app
+GIT_AUTHOR_NAME 
+GIT_AUTHOR_EMAIL 
+DOTFILES_PATH dotfiles
+SERVICE_ENV db POSTGRES_PASSWORD=
+SERVICE_ENV db POSTGRES_DB=app
+`
+	if string(got) != want {
+		t.Fatalf("bundleRuntimeConfig() = %q, want %q", got, want)
+	}
+	wantStripped := []string{"GIT_AUTHOR_NAME", "SERVICE_ENV db POSTGRES_PASSWORD"}
+	if !slices.Equal(stripped, wantStripped) {
+		t.Fatalf("bundleRuntimeConfig() stripped = %v, want %v", stripped, wantStripped)
+	}
+
+	got, _ = bundleRuntimeConfig([]byte("PATH /app\n"), true)
+	if string(got) != "PATH /app\nDOTFILES_PATH dotfiles\n" {
+		t.Fatalf("bundleRuntimeConfig() should point to the bundled dotfiles, got %q", got)
+	}
+	got, _ = bundleRuntimeConfig([]byte("DOTFILES_PATH /home/alice/dots\n"), false)
+	if string(got) != "DOTFILES_PATH /home/alice/dots\n" {
+		t.Fatalf("bundleRuntimeConfig() without dotfiles should keep DOTFILES_PATH, got %q", got)
+	}
+}
+
+func TestRemapRuntimeConfig(t *testing.T) {
+	content := []byte(`PATH /home/alice/app
+MOUNT /home/alice/.cache:/home/dev/.cache:ro
+MOUNT /home/alicia/data:/data
+STARTUP /home/alice/setup.sh
+ACTIVATE /home/alice/env.sh
+DOTFILES_PATH dotfiles
+`)
+	got := RemapRuntimeConfig(content, []PathMapping{
+		{From: "/home/alice/app", To: "/srv/app"},
+		{From: "/home/alice/", To: "/home/bob"},
+	})
+	want := `PATH /srv/app
+MOUNT /home/bob/.cache:/home/dev/.cache:ro
+MOUNT /home/alicia/data:/data
+STARTUP /home/bob/setup.sh
+ACTIVATE /home/bob/env.sh
+DOTFILES_PATH dotfiles
+`
+	if string(got) != want {
+		t.Fatalf("RemapRuntimeConfig() = %q, want %q", got, want)
+	}
+}
+
+func TestProjectBundleRoundTrip(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	store, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	ctx := context.Background()
+	source := ProjectBundle{
+		ProjectName:   "app",
+		BuildConfig:   []byte("VERSION 1.0.0\nHOST_UID 1000\nHOST_GID 1000\nUSERNAME dev\nUSER_SHELL zsh\n"),
+		RuntimeConfig: []byte("VERSION 1.0.0\nPATH /home/alice/app\nGIT_AUTHOR_NAME Alice\n"),
+		Readme:        []byte("# app\n"),
+	}
+	if err := store.ImportProjectBundle(ctx, "app", source, source.RuntimeConfig); err != nil {
+		t.Fatalf("ImportProjectBundle() error = %v", err)
+	}
+	dotfilesDir := store.GetProjectDotfilesPath("app")
+	if err := os.MkdirAll(filepath.Join(dotfilesDir, ".config"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dotfilesDir, ".config", "starship.toml"), []byte("add_newline = false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "app.tar.gz")
+	extra := []ArchiveFile{{Name: "compose.yaml", Data: []byte("services: {}\n"), Mode: 0644}}
+	stripped, err := store.ExportProjectBundle("app", dotfilesDir, extra, path)
+	if err != nil {
+		t.Fatalf("ExportProjectBundle() error = %v", err)
+	}
+	if !slices.Equal(stripped, []string{"GIT_AUTHOR_NAME"}) {
+		t.Fatalf("ExportProjectBundle() stripped = %v", stripped)
+	}
+
+	bundle, err := ReadProjectBundle(path)
+	if err != nil {
+		t.Fatalf("ReadProjectBundle() error = %v", err)
+	}
+	if bundle.ProjectName != "app" || bundle.ProjectPath() != "/home/alice/app" || string(bundle.Readme) != "# app\n" {
+		t.Fatalf("ReadProjectBundle() = %+v", bundle)
+	}
+	if !slices.Equal(bundle.Stripped, stripped) {
+		t.Fatalf("ReadProjectBundle() stripped = %v, want %v", bundle.Stripped, stripped)
+	}
+	if len(bundle.Dotfiles) != 1 || bundle.Dotfiles[0].Name != ".config/starship.toml" {
+		t.Fatalf("ReadProjectBundle() dotfiles = %+v", bundle.Dotfiles)
+	}
+
+	runtimeConfig := RemapRuntimeConfig(bundle.RuntimeConfig, []PathMapping{{From: bundle.ProjectPath(), To: "/home/bob/app"}})
+	if err := store.ImportProjectBundle(ctx, "app", bundle, runtimeConfig); err == nil {
+		t.Fatal("ImportProjectBundle() should refuse to replace an existing project")
+	}
+	if err := store.ImportProjectBundle(ctx, "app2", bundle, runtimeConfig); err != nil {
+		t.Fatalf("ImportProjectBundle() error = %v", err)
+	}
+	runConf, err := os.ReadFile(store.GetProjectRuntimeConfigPath("app2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(runConf), "PATH /home/bob/app\n") || strings.Contains(string(runConf), "Alice") {
+		t.Fatalf("imported run.conf = %q", runConf)
+	}
+	data, err := os.ReadFile(filepath.Join(store.GetProjectDotfilesPath("app2"), ".config", "starship.toml"))
+	if err != nil || string(data) != "add_newline = false\n" {
+		t.Fatalf("imported dotfile = %q, %v", data, err)
+	}
+}
+
+func TestReadProjectBundle_NotABundle(t *testing.T) {
+	store := &FileStore{userFS: &UserFS{homeDir: t.TempDir()}}
+	path := filepath.Join(t.TempDir(), "other.tar.gz")
+	if err := store.WriteArchive(path, []ArchiveFile{{Name: "run.conf", Data: []byte("PATH /app\n")}}); err != nil {
+		t.Fatalf("WriteArchive() error = %v", err)
+	}
+	if _, err := ReadProjectBundle(path); err == nil {
+		t.Fatal("ReadProjectBundle() should reject an archive without manifest")
+	}
+}
+
+func TestCloneProject(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	store, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	ctx := context.Background()
+	source := ProjectBundle{
+		ProjectName:   "app",
+		BuildConfig:   []byte("VERSION 1.0.0\nHOST_UID 1000\nHOST_GID 1000\nUSERNAME dev\nUSER_SHELL zsh\n"),
+		RuntimeConfig: []byte("VERSION 1.0.0\nPATH /home/alice/app\nGIT_AUTHOR_NAME Alice\n"),
+		Dotfiles:      []ArchiveFile{{Name: ".zshrc", Data: []byte("export EDITOR=vim\n"), Mode: 0600}},
+	}
+	if err := store.ImportProjectBundle(ctx, "app", source, source.RuntimeConfig); err != nil {
+		t.Fatalf("ImportProjectBundle() error = %v", err)
+	}
+	if err := store.RefreshBuildInfoFile("app", "docker", "27.0.0"); err != nil {
+		t.Fatalf("RefreshBuildInfoFile() error = %v", err)
+	}
+
+	if err := store.CloneProject(ctx, "app", "app-copy"); err != nil {
+		t.Fatalf("CloneProject() error = %v", err)
+	}
+	runConf, err := os.ReadFile(store.GetProjectRuntimeConfigPath("app-copy"))
+	if err != nil || string(runConf) != string(source.RuntimeConfig) {
+		t.Fatalf("cloned run.conf = %q, %v", runConf, err)
+	}
+	data, err := os.ReadFile(filepath.Join(store.GetProjectDotfilesPath("app-copy"), ".zshrc"))
+	if err != nil || string(data) != "export EDITOR=vim\n" {
+		t.Fatalf("cloned dotfile = %q, %v", data, err)
+	}
+	if _, err := os.Stat(store.GetProjectReadmePath("app-copy")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("clone should have no README, got %v", err)
+	}
+	if _, err := store.ReadBuildInfo("app-copy"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("clone should start unbuilt, ReadBuildInfo() error = %v", err)
+	}
+	if err := store.CloneProject(ctx, "app", "app-copy"); err == nil {
+		t.Fatal("CloneProject() should refuse to replace an existing project")
+	}
+}
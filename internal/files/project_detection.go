@@ -0,0 +1,87 @@
+// # project_detection.go
+// This file finds which project a directory belongs to, so commands run from
+// inside a project's directory do not need to be given its name.
+
+package files
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// File naming the project its directory belongs to, e.g. when it is not the
+// directory the project mounts. Not to be confused with a `.paulenv/`
+// directory, defining a project (see `repo_definition.go`).
+const projectMarkerFilename = ".paulenv"
+
+// Find the project the given directory belongs to: the one named by a
+// `.paulenv` marker file in it or in one of its parents, or the one whose
+// mounted directory contains it. The closest one to that directory wins.
+//
+// Returns an empty name if it belongs to none. The name of a marker file is
+// returned as-is, it may not be a valid or existing project.
+func (f *FileStore) FindProjectOfDir(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid directory '%s': %w", dir, err)
+	}
+	dir = resolvedPath(dir)
+	projects, err := f.GetAllProjects()
+	if err != nil {
+		return "", err
+	}
+	byPath := make(map[string]string, len(projects))
+	for _, project := range projects {
+		if project.ProjectPath != "" {
+			byPath[resolvedPath(project.ProjectPath)] = project.ProjectName
+		}
+	}
+	for {
+		name, err := readProjectMarker(filepath.Join(dir, projectMarkerFilename))
+		if err != nil || name != "" {
+			return name, err
+		}
+		if name, ok := byPath[dir]; ok {
+			return name, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Returns the project named by that marker file, empty if it is not one.
+func readProjectMarker(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("cannot read '%s': %w", path, err)
+	}
+	defer file.Close()
+	if info, err := file.Stat(); err != nil || info.IsDir() {
+		return "", nil
+	}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line, nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// Clean that absolute path, resolving its symbolic links if it exists.
+func resolvedPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
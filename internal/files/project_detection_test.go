@@ -0,0 +1,49 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindProjectOfDir_Marker(t *testing.T) {
+	baseDataDir := t.TempDir()
+	store := &FileStore{
+		userFS:        &UserFS{homeDir: t.TempDir()},
+		baseDataDir:   baseDataDir,
+		baseConfigDir: t.TempDir(),
+		projectsDir:   filepath.Join(baseDataDir, "projects"),
+	}
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, projectMarkerFilename), []byte("# project of this repo\n\nmyApp\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	name, err := store.FindProjectOfDir(nested)
+	if err != nil || name != "myApp" {
+		t.Fatalf("FindProjectOfDir() = %q, %v, want myApp", name, err)
+	}
+
+	// The closest marker wins
+	if err := os.WriteFile(filepath.Join(root, "a", projectMarkerFilename), []byte("other\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	name, err = store.FindProjectOfDir(nested)
+	if err != nil || name != "other" {
+		t.Fatalf("FindProjectOfDir() = %q, %v, want other", name, err)
+	}
+
+	// A `.paulenv/` definition directory is no marker
+	defRoot := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(defRoot, projectMarkerFilename), 0755); err != nil {
+		t.Fatal(err)
+	}
+	name, err = store.FindProjectOfDir(defRoot)
+	if err != nil || name != "" {
+		t.Fatalf("FindProjectOfDir() = %q, %v, want none", name, err)
+	}
+}
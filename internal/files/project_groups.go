@@ -0,0 +1,76 @@
+// # project_groups.go
+// This file handles the groups a project was put in by the user (e.g.
+// "work"), through which commands act on several projects at once.
+
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const projectGroupsFilename = "groups"
+
+// Returns the groups that project belongs to, sorted.
+func (f *FileStore) GetProjectGroups(projectName string) ([]string, error) {
+	data, err := os.ReadFile(f.getProjectGroupsPath(projectName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("could not read '%s': %w", projectGroupsFilename, err)
+	}
+	groups := strings.Fields(string(data))
+	slices.Sort(groups)
+	return slices.Compact(groups), nil
+}
+
+// Replace the groups that project belongs to.
+func (f *FileStore) SetProjectGroups(projectName string, groups []string) error {
+	groups = slices.Clone(groups)
+	slices.Sort(groups)
+	groups = slices.Compact(groups)
+	path := f.getProjectGroupsPath(projectName)
+	if len(groups) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not remove '%s': %w", projectGroupsFilename, err)
+		}
+		return nil
+	}
+	if err := f.userFS.MkdirAsUser(f.getProjectInternalDir(projectName), 0755); err != nil {
+		return fmt.Errorf("cannot create project internal directory: %w", err)
+	}
+	if err := f.userFS.WriteFileAsUser(path, []byte(strings.Join(groups, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("could not write '%s': %w", projectGroupsFilename, err)
+	}
+	return nil
+}
+
+// Returns the projects of each group, sorted by name.
+func (f *FileStore) GetAllGroups() (map[string][]string, error) {
+	projects, err := f.GetAllProjects()
+	if err != nil {
+		return nil, err
+	}
+	groups := map[string][]string{}
+	for _, project := range projects {
+		projectGroups, err := f.GetProjectGroups(project.ProjectName)
+		if err != nil {
+			return nil, fmt.Errorf("cannot obtain the groups of project '%s': %w", project.ProjectName, err)
+		}
+		for _, group := range projectGroups {
+			groups[group] = append(groups[group], project.ProjectName)
+		}
+	}
+	for _, members := range groups {
+		slices.Sort(members)
+	}
+	return groups, nil
+}
+
+func (f *FileStore) getProjectGroupsPath(projectName string) string {
+	return filepath.Join(f.getProjectInternalDir(projectName), projectGroupsFilename)
+}
@@ -0,0 +1,61 @@
+// # project_readme.go
+// This file handles the optional README of a project, in Markdown, through
+// which shared environments can carry their own usage notes. It is displayed
+// by `paul-envs info --full` and, if its run.conf asks for it, when first
+// entering the container of a freshly built image.
+
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	projectReadmeFilename      = "README.md"
+	projectReadmeShownFilename = "readme.shown"
+)
+
+// Get path to the given project's README.
+func (f *FileStore) GetProjectReadmePath(projectName string) string {
+	return filepath.Join(f.getProjectDir(projectName), projectReadmeFilename)
+}
+
+// Read the README of the given project, an empty string if it has none.
+func (f *FileStore) ReadProjectReadme(projectName string) (string, error) {
+	data, err := os.ReadFile(f.GetProjectReadmePath(projectName))
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("cannot read README of project '%s': %w", projectName, err)
+	}
+	return string(data), nil
+}
+
+// Returns `true` if the README of the given project has already been
+// displayed for its image built at `builtAt`.
+func (f *FileStore) IsProjectReadmeShown(projectName string, builtAt time.Time) bool {
+	data, err := os.ReadFile(f.getProjectReadmeShownPath(projectName))
+	if err != nil {
+		return false
+	}
+	shownFor, err := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+	return err == nil && shownFor.Equal(builtAt)
+}
+
+// Record that the README of the given project has been displayed for its
+// image built at `builtAt`.
+func (f *FileStore) MarkProjectReadmeShown(projectName string, builtAt time.Time) error {
+	content := []byte(builtAt.UTC().Format(time.RFC3339) + "\n")
+	if err := f.userFS.WriteFileAsUser(f.getProjectReadmeShownPath(projectName), content, 0644); err != nil {
+		return fmt.Errorf("impossibility to write '%s' file: %w", projectReadmeShownFilename, err)
+	}
+	return nil
+}
+
+func (f *FileStore) getProjectReadmeShownPath(projectName string) string {
+	return filepath.Join(f.getProjectInternalDir(projectName), projectReadmeShownFilename)
+}
@@ -0,0 +1,67 @@
+package files
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSyncRepoDefinition_Readme(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	store, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+
+	root := t.TempDir()
+	writeRepoDefinition(t, root, "")
+	def := RepoDefinition{RootDir: root, DefinitionDir: filepath.Join(root, repoDefinitionDirname)}
+	if err := os.WriteFile(def.readmePath(), []byte("# Team env\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.SyncRepoDefinition(context.Background(), "repo", def); err != nil {
+		t.Fatalf("SyncRepoDefinition() error = %v", err)
+	}
+	readme, err := store.ReadProjectReadme("repo")
+	if err != nil || readme != "# Team env\n" {
+		t.Fatalf("ReadProjectReadme() = %q, %v, want the in-repo README", readme, err)
+	}
+
+	if err := os.Remove(def.readmePath()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.SyncRepoDefinition(context.Background(), "repo", def); err != nil {
+		t.Fatalf("SyncRepoDefinition() error = %v", err)
+	}
+	readme, err = store.ReadProjectReadme("repo")
+	if err != nil || readme != "" {
+		t.Fatalf("ReadProjectReadme() = %q, %v, want no README once removed from the repository", readme, err)
+	}
+}
+
+func TestProjectReadmeShown(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	store, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	if err := os.MkdirAll(store.getProjectInternalDir("app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	builtAt := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	if store.IsProjectReadmeShown("app", builtAt) {
+		t.Fatal("IsProjectReadmeShown() = true before being marked")
+	}
+	if err := store.MarkProjectReadmeShown("app", builtAt); err != nil {
+		t.Fatalf("MarkProjectReadmeShown() error = %v", err)
+	}
+	if !store.IsProjectReadmeShown("app", builtAt.In(time.FixedZone("CEST", 2*3600))) {
+		t.Fatal("IsProjectReadmeShown() = false for the image it was marked for")
+	}
+	if store.IsProjectReadmeShown("app", builtAt.Add(time.Hour)) {
+		t.Fatal("IsProjectReadmeShown() = true for a rebuilt image")
+	}
+}
@@ -0,0 +1,85 @@
+// # registry_config.go
+// This file handles the engine configuration files generated from the
+// registry settings of the global configuration (mirrors, insecure registries
+// and auth files). They are written to the `registries` directory of
+// paul-envs' data directory, only readable by the user as they may hold
+// credentials, and are left out of backups.
+
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const registriesDirname = "registries"
+
+// Name of the Docker client configuration directory in the registries
+// directory.
+const dockerConfigDirname = "docker"
+
+// Write the generated engine configuration file with that name, returning its
+// path.
+func (f *FileStore) WriteRegistryConfig(filename string, content []byte) (string, error) {
+	dir := filepath.Join(f.baseDataDir, registriesDirname)
+	if err := f.userFS.MkdirAsUser(dir, 0700); err != nil {
+		return "", fmt.Errorf("cannot create registries directory: %w", err)
+	}
+	path := filepath.Join(dir, filename)
+	if err := f.userFS.WriteFileAsUser(path, content, 0600); err != nil {
+		return "", fmt.Errorf("cannot write '%s': %w", filename, err)
+	}
+	return path, nil
+}
+
+// Write a Docker client configuration directory holding the given
+// `config.json`, in which everything else of the `sourceDir` configuration
+// directory (CLI plugins, contexts...) is linked. Returns its path.
+func (f *FileStore) PrepareDockerConfigDir(ctx context.Context, sourceDir string, configJSON []byte) (string, error) {
+	dir := filepath.Join(f.baseDataDir, registriesDirname, dockerConfigDirname)
+	if err := f.userFS.MkdirAsUser(dir, 0700); err != nil {
+		return "", fmt.Errorf("cannot create Docker configuration directory: %w", err)
+	}
+	if err := f.userFS.WriteFileAsUser(filepath.Join(dir, "config.json"), configJSON, 0600); err != nil {
+		return "", fmt.Errorf("cannot write Docker configuration: %w", err)
+	}
+	entries, err := os.ReadDir(sourceDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("cannot read Docker configuration directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.Name() == "config.json" {
+			continue
+		}
+		source := filepath.Join(sourceDir, entry.Name())
+		target := filepath.Join(dir, entry.Name())
+		if info, err := os.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			continue
+		}
+		if err := os.RemoveAll(target); err != nil {
+			return "", fmt.Errorf("cannot replace '%s' in the Docker configuration directory: %w", entry.Name(), err)
+		}
+		if err := os.Symlink(source, target); err == nil {
+			if err := f.userFS.chownIfNeeded(target); err != nil {
+				return "", err
+			}
+			continue
+		}
+		// Creating symlinks needs a privilege Windows users seldom have,
+		// copy it instead (e.g. Docker Desktop's contexts)
+		if entry.IsDir() {
+			err = f.userFS.CopyDirAsUser(ctx, source, target)
+		} else if data, readErr := os.ReadFile(source); readErr != nil {
+			err = readErr
+		} else {
+			err = f.userFS.WriteFileAsUser(target, data, 0600)
+		}
+		if err != nil {
+			return "", fmt.Errorf("cannot copy '%s' in the Docker configuration directory: %w", entry.Name(), err)
+		}
+	}
+	return dir, nil
+}
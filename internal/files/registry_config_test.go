@@ -0,0 +1,39 @@
+package files
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStore_PrepareDockerConfigDir(t *testing.T) {
+	store := &FileStore{
+		userFS:      &UserFS{homeDir: t.TempDir()},
+		baseDataDir: t.TempDir(),
+	}
+	source := t.TempDir()
+	if err := os.WriteFile(filepath.Join(source, "config.json"), []byte(`{"credsStore": "pass"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(source, "contexts", "meta"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		dir, err := store.PrepareDockerConfigDir(context.Background(), source, []byte(`{"auths": {}}`))
+		if err != nil {
+			t.Fatalf("PrepareDockerConfigDir() failed: %v", err)
+		}
+		config, err := os.ReadFile(filepath.Join(dir, "config.json"))
+		if err != nil || string(config) != `{"auths": {}}` {
+			t.Fatalf("config.json = %q, %v, want the given configuration", config, err)
+		}
+		if info, err := os.Stat(filepath.Join(dir, "config.json")); err != nil || info.Mode().Perm() != 0600 {
+			t.Fatalf("config.json is readable by others: %v, %v", info, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "contexts", "meta")); err != nil {
+			t.Fatalf("contexts of the source directory not found: %v", err)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+// # repo_definition.go
+// This file handles project definitions committed inside a repository, under
+// a `.paulenv/` directory, so teams can version their environment alongside
+// their code:
+// -  `.paulenv/build.conf`: required, copied as-is as the project's build.conf
+// -  `.paulenv/run.conf`: optional, its `PATH` is always set to the repository
+// -  `.paulenv/dotfiles/`: optional, copied as the project's dotfiles
+// -  `.paulenv/README.md`: optional, copied as the project's README
+//
+// Its build.conf may only set a `DOCKERFILE` under `.paulenv/`: unlike the
+// files above, that Dockerfile is read from the repository at each build, so
+// it has to be covered by the fingerprint the definition is trusted with.
+
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	versions "github.com/peaberberian/paul-envs/internal"
+	"github.com/peaberberian/paul-envs/internal/config"
+	"github.com/peaberberian/paul-envs/internal/profiling"
+)
+
+const (
+	repoDefinitionDirname = ".paulenv"
+	projectSourceFilename = "project.source"
+)
+
+// Definition of a project found inside a repository.
+type RepoDefinition struct {
+	// Root of the repository, which will be the mounted project directory.
+	RootDir string
+	// The `.paulenv` directory containing the definition files.
+	DefinitionDir string
+}
+
+func (d RepoDefinition) buildConfigPath() string {
+	return filepath.Join(d.DefinitionDir, projectBuildConfigFilename)
+}
+
+func (d RepoDefinition) runtimeConfigPath() string {
+	return filepath.Join(d.DefinitionDir, projectRuntimeConfigFilename)
+}
+
+func (d RepoDefinition) dotfilesPath() string {
+	return filepath.Join(d.DefinitionDir, "dotfiles")
+}
+
+func (d RepoDefinition) readmePath() string {
+	return filepath.Join(d.DefinitionDir, projectReadmeFilename)
+}
+
+// Look for a `.paulenv/build.conf` file in `startDir` or any of its parents.
+//
+// Returns `nil` with no error if no definition has been found.
+func FindRepoDefinition(startDir string) (*RepoDefinition, error) {
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid directory '%s': %w", startDir, err)
+	}
+	for {
+		definitionDir := filepath.Join(dir, repoDefinitionDirname)
+		def := RepoDefinition{RootDir: dir, DefinitionDir: definitionDir}
+		// A `.paulenv` file only names a project (see `project_detection.go`)
+		if info, err := os.Stat(definitionDir); err == nil && info.IsDir() {
+			info, err := os.Stat(def.buildConfigPath())
+			if err == nil && !info.IsDir() {
+				return &def, nil
+			}
+			if err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("cannot check '%s': %w", def.buildConfigPath(), err)
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// Register or update the named project from an in-repository definition.
+//
+// Returns `true` if the project files were created or changed, dotfiles
+// included. Dotfiles removed from the definition are removed from the project.
+func (f *FileStore) SyncRepoDefinition(ctx context.Context, projectName string, def RepoDefinition) (bool, error) {
+	defer profiling.Track(profiling.CategoryGeneration, "sync repository definition "+projectName)()
+	if f.DoesProjectExist(projectName) {
+		source, err := f.GetProjectSource(projectName)
+		if err != nil {
+			return false, err
+		}
+		if source != def.RootDir {
+			return false, fmt.Errorf(
+				"project '%s' already exists and was not created from %s\n"+
+					"Hint: remove it with 'paul-envs remove %s' or rename the repository directory",
+				projectName, def.RootDir, projectName)
+		}
+	}
+
+	buildBytes, err := os.ReadFile(def.buildConfigPath())
+	if err != nil {
+		return false, fmt.Errorf("cannot read in-repo build.conf: %w", err)
+	}
+	if err := def.checkDockerfile(); err != nil {
+		return false, err
+	}
+	runtimeBytes, err := repoRuntimeConfig(def)
+	if err != nil {
+		return false, err
+	}
+
+	configChanged := !sameFileContent(f.GetProjectBuildConfigPath(projectName), buildBytes) ||
+		!sameFileContent(f.GetProjectRuntimeConfigPath(projectName), runtimeBytes)
+	dotfiles, err := listDotfiles(def.dotfilesPath())
+	if err != nil {
+		return false, fmt.Errorf("cannot read in-repo dotfiles: %w", err)
+	}
+	currentDotfiles, err := listDotfiles(f.GetProjectDotfilesPath(projectName))
+	if err != nil {
+		return false, err
+	}
+	dotfilesChanged := !slices.EqualFunc(dotfiles, currentDotfiles, func(a, b ArchiveFile) bool {
+		return a.Name == b.Name && a.Mode == b.Mode && bytes.Equal(a.Data, b.Data)
+	})
+
+	if err := f.RefreshBaseFiles(); err != nil {
+		return false, fmt.Errorf("create base files: %w", err)
+	}
+	if err := f.userFS.MkdirAsUser(f.getProjectDir(projectName), 0755); err != nil {
+		return false, fmt.Errorf("create project directory: %w", err)
+	}
+	if err := f.userFS.MkdirAsUser(f.GetProjectDotfilesPath(projectName), 0755); err != nil {
+		return false, fmt.Errorf("create project dotfiles directory: %w", err)
+	}
+	if err := f.userFS.MkdirAsUser(f.getProjectInternalDir(projectName), 0755); err != nil {
+		return false, fmt.Errorf("create project internal directory: %w", err)
+	}
+	if configChanged {
+		gen := projectGeneration{buildConfig: buildBytes, runtimeConfig: runtimeBytes}
+		if err := f.writeProjectGeneration(projectName, gen); err != nil {
+			return false, fmt.Errorf("write project files: %w", err)
+		}
+	} else if err := f.writeProjectInfo(projectName); err != nil {
+		return false, fmt.Errorf("impossibility to write 'project.lock' file: %w", err)
+	}
+	if err := f.userFS.WriteFileAsUser(f.getProjectSourceFilePathFor(projectName), []byte(def.RootDir), 0644); err != nil {
+		return false, fmt.Errorf("impossibility to write 'project.source' file: %w", err)
+	}
+
+	if dotfilesChanged {
+		if err := f.replaceProjectDotfiles(ctx, projectName, def); err != nil {
+			return false, fmt.Errorf("copy in-repo dotfiles: %w", err)
+		}
+	}
+	if readme, err := os.ReadFile(def.readmePath()); err == nil {
+		if err := f.userFS.WriteFileAsUser(f.GetProjectReadmePath(projectName), readme, 0644); err != nil {
+			return false, fmt.Errorf("copy in-repo README: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return false, fmt.Errorf("cannot read in-repo README: %w", err)
+	} else if err := os.Remove(f.GetProjectReadmePath(projectName)); err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("remove README no longer in the repository: %w", err)
+	}
+	return configChanged || dotfilesChanged, nil
+}
+
+// Replace the dotfiles of the given project by those of the definition,
+// removing those it had which are not part of them.
+func (f *FileStore) replaceProjectDotfiles(ctx context.Context, projectName string, def RepoDefinition) error {
+	dotfilesDir := f.GetProjectDotfilesPath(projectName)
+	if err := os.RemoveAll(dotfilesDir); err != nil {
+		return fmt.Errorf("remove previous dotfiles: %w", err)
+	}
+	if err := f.userFS.MkdirAsUser(dotfilesDir, 0755); err != nil {
+		return fmt.Errorf("create project dotfiles directory: %w", err)
+	}
+	if info, err := os.Stat(def.dotfilesPath()); err == nil && info.IsDir() {
+		return f.userFS.CopyDirAsUser(ctx, def.dotfilesPath(), dotfilesDir)
+	}
+	return nil
+}
+
+// Files of the given dotfiles directory, by their slash-separated path
+// relative to it, to compare it with another one. Symbolic links are not
+// followed: they are listed with their target as data. Of their permissions,
+// only the executable bits are kept, the others depending on the umask.
+//
+// Returns no file if the directory does not exist.
+func listDotfiles(dir string) ([]ArchiveFile, error) {
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	var dotfiles []ArchiveFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("walk %q: %w", path, err)
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		var data []byte
+		if d.Type()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			data = []byte(target)
+		} else if data, err = os.ReadFile(path); err != nil {
+			return err
+		}
+		dotfiles = append(dotfiles, ArchiveFile{Name: filepath.ToSlash(rel), Data: data, Mode: info.Mode().Type() | info.Mode().Perm()&0111})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot read dotfiles: %w", err)
+	}
+	return dotfiles, nil
+}
+
+// Fail if the `DOCKERFILE` of the definition's build.conf, if any, is not
+// under its `.paulenv` directory, symbolic links resolved.
+func (d RepoDefinition) checkDockerfile() error {
+	buildCfg, err := config.LoadBuildConfig(d.buildConfigPath())
+	if err != nil {
+		return fmt.Errorf("cannot read in-repo build.conf: %w", err)
+	}
+	if buildCfg.Dockerfile == "" {
+		return nil
+	}
+	definitionDir, err := filepath.EvalSymlinks(d.DefinitionDir)
+	if err != nil {
+		return fmt.Errorf("cannot read definition in %s: %w", d.DefinitionDir, err)
+	}
+	path := buildCfg.Dockerfile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(d.RootDir, path)
+	}
+	paths := []string{path}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		paths = append(paths, filepath.Join(path, "Dockerfile"))
+	}
+	for _, path := range paths {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return fmt.Errorf("cannot use the Dockerfile set by DOCKERFILE: %w", err)
+		}
+		if rel, err := filepath.Rel(definitionDir, resolved); err != nil || !filepath.IsLocal(rel) {
+			return fmt.Errorf("DOCKERFILE %s is outside of %s, which in-repo definitions have to build from\n"+
+				"Hint: move it and its build context under %s, e.g. 'DOCKERFILE %s/Dockerfile'",
+				buildCfg.Dockerfile, d.DefinitionDir, repoDefinitionDirname, repoDefinitionDirname)
+		}
+	}
+	return nil
+}
+
+// Returns the repository root the given project was registered from, or an
+// empty string if it was not created from an in-repo definition.
+func (f *FileStore) GetProjectSource(projectName string) (string, error) {
+	data, err := os.ReadFile(f.getProjectSourceFilePathFor(projectName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("could not read 'project.source': %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Get path to the 'project.source' file associated to a project.
+func (f *FileStore) getProjectSourceFilePathFor(projectName string) string {
+	return filepath.Join(f.getProjectInternalDir(projectName), projectSourceFilename)
+}
+
+// Produce the run.conf of a project defined in a repository: the in-repo one
+// if present, with its `PATH` directive forced to the repository root.
+func repoRuntimeConfig(def RepoDefinition) ([]byte, error) {
+	content, err := os.ReadFile(def.runtimeConfigPath())
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("cannot read in-repo run.conf: %w", err)
+		}
+		content = fmt.Appendf(nil,
+			"DOTFILES_PATH dotfiles\nVERSION %s\n",
+			versions.RuntimeConfigVersion.ToString())
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "PATH %s\n", def.RootDir)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(strings.TrimLeft(line, " \t"), "PATH ") {
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read in-repo run.conf: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func sameFileContent(path string, content []byte) bool {
+	existing, err := os.ReadFile(path)
+	return err == nil && bytes.Equal(existing, content)
+}
@@ -0,0 +1,186 @@
+package files
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testBuildConf = "HOST_UID 1000\nHOST_GID 1000\nUSERNAME dev\nUSER_SHELL bash\nVERSION 1.1.0\n"
+
+func writeRepoDefinition(t *testing.T, root string, runConf string) {
+	t.Helper()
+	defDir := filepath.Join(root, repoDefinitionDirname)
+	if err := os.MkdirAll(filepath.Join(defDir, "dotfiles"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(defDir, "build.conf"), []byte(testBuildConf), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(defDir, "dotfiles", ".bashrc"), []byte("# team rc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if runConf != "" {
+		if err := os.WriteFile(filepath.Join(defDir, "run.conf"), []byte(runConf), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFindRepoDefinition(t *testing.T) {
+	root := t.TempDir()
+	writeRepoDefinition(t, root, "")
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	def, err := FindRepoDefinition(nested)
+	if err != nil {
+		t.Fatalf("FindRepoDefinition() error = %v", err)
+	}
+	if def == nil || def.RootDir != root {
+		t.Fatalf("FindRepoDefinition() = %+v, want root %s", def, root)
+	}
+
+	def, err = FindRepoDefinition(t.TempDir())
+	if err != nil || def != nil {
+		t.Fatalf("FindRepoDefinition() = %+v, %v, want nil, nil", def, err)
+	}
+}
+
+func TestSyncRepoDefinition(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	store, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+
+	root := t.TempDir()
+	writeRepoDefinition(t, root, "PATH /somewhere/else\nWORKDIR /tmp\nVERSION 1.1.0\n")
+	def := RepoDefinition{RootDir: root, DefinitionDir: filepath.Join(root, repoDefinitionDirname)}
+
+	changed, err := store.SyncRepoDefinition(context.Background(), "repo", def)
+	if err != nil {
+		t.Fatalf("SyncRepoDefinition() error = %v", err)
+	}
+	if !changed {
+		t.Fatal("SyncRepoDefinition() changed = false on first sync")
+	}
+
+	entry, err := store.GetProject("repo")
+	if err != nil {
+		t.Fatalf("GetProject() error = %v", err)
+	}
+	if entry.ProjectPath != root {
+		t.Fatalf("ProjectPath = %s, want %s", entry.ProjectPath, root)
+	}
+	runConf, err := os.ReadFile(store.GetProjectRuntimeConfigPath("repo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(runConf), "/somewhere/else") || !strings.Contains(string(runConf), "WORKDIR /tmp") {
+		t.Fatalf("unexpected run.conf:\n%s", runConf)
+	}
+	if _, err := os.Stat(filepath.Join(store.GetProjectDotfilesPath("repo"), ".bashrc")); err != nil {
+		t.Fatalf("dotfiles not copied: %v", err)
+	}
+	source, err := store.GetProjectSource("repo")
+	if err != nil || source != root {
+		t.Fatalf("GetProjectSource() = %q, %v, want %q", source, err, root)
+	}
+
+	changed, err = store.SyncRepoDefinition(context.Background(), "repo", def)
+	if err != nil || changed {
+		t.Fatalf("SyncRepoDefinition() = %v, %v on unchanged definition, want false, nil", changed, err)
+	}
+
+	other := t.TempDir()
+	writeRepoDefinition(t, other, "")
+	otherDef := RepoDefinition{RootDir: other, DefinitionDir: filepath.Join(other, repoDefinitionDirname)}
+	if _, err := store.SyncRepoDefinition(context.Background(), "repo", otherDef); err == nil {
+		t.Fatal("SyncRepoDefinition() expected error for a project from another source")
+	}
+}
+
+func TestSyncRepoDefinitionDotfiles(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	store, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+
+	root := t.TempDir()
+	writeRepoDefinition(t, root, "")
+	def := RepoDefinition{RootDir: root, DefinitionDir: filepath.Join(root, repoDefinitionDirname)}
+	repoDotfiles := def.dotfilesPath()
+	if err := os.WriteFile(filepath.Join(repoDotfiles, ".vimrc"), []byte("set nu\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.SyncRepoDefinition(context.Background(), "repo", def); err != nil {
+		t.Fatalf("SyncRepoDefinition() error = %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(repoDotfiles, ".bashrc"), []byte("# new team rc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(repoDotfiles, ".vimrc")); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := store.SyncRepoDefinition(context.Background(), "repo", def)
+	if err != nil || !changed {
+		t.Fatalf("SyncRepoDefinition() = %v, %v on changed dotfiles, want true, nil", changed, err)
+	}
+	projectDotfiles := store.GetProjectDotfilesPath("repo")
+	if data, err := os.ReadFile(filepath.Join(projectDotfiles, ".bashrc")); err != nil || string(data) != "# new team rc\n" {
+		t.Fatalf(".bashrc = %q, %v, want the updated one", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(projectDotfiles, ".vimrc")); !os.IsNotExist(err) {
+		t.Fatalf("dotfile removed from the definition still present: %v", err)
+	}
+
+	changed, err = store.SyncRepoDefinition(context.Background(), "repo", def)
+	if err != nil || changed {
+		t.Fatalf("SyncRepoDefinition() = %v, %v on unchanged dotfiles, want false, nil", changed, err)
+	}
+}
+
+func TestRepoDefinitionCheckDockerfile(t *testing.T) {
+	root := t.TempDir()
+	writeRepoDefinition(t, root, "")
+	def := RepoDefinition{RootDir: root, DefinitionDir: filepath.Join(root, repoDefinitionDirname)}
+	for _, dir := range []string{filepath.Join(def.DefinitionDir, "docker"), filepath.Join(root, ".devcontainer")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM scratch\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join("..", ".devcontainer", "Dockerfile"), filepath.Join(def.DefinitionDir, "Dockerfile")); err != nil {
+		t.Skipf("cannot create symbolic links: %v", err)
+	}
+
+	for dockerfile, allowed := range map[string]bool{
+		"":                                true,
+		".paulenv/docker/Dockerfile":      true,
+		".paulenv/docker":                 true,
+		".devcontainer/Dockerfile":        false,
+		".paulenv/../.devcontainer":       false,
+		".paulenv/Dockerfile":             false,
+		filepath.Join(root, "Dockerfile"): false,
+	} {
+		conf := testBuildConf
+		if dockerfile != "" {
+			conf += "DOCKERFILE " + dockerfile + "\n"
+		}
+		if err := os.WriteFile(def.buildConfigPath(), []byte(conf), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := def.checkDockerfile(); (err == nil) != allowed {
+			t.Errorf("checkDockerfile() with DOCKERFILE %q = %v, allowed: %t", dockerfile, err, allowed)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// # shortcuts.go
+// Desktop entries written by `paul-envs shortcut` in the host's applications
+// directory, so GUI applications of a project's container appear in the
+// host's application menu. They are recognized by a key of theirs naming
+// their project, not by their filename.
+
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Key of the desktop entries of shortcuts set to the name of their project.
+const ShortcutProjectKey = "X-PaulEnvs-Project"
+
+// A shortcut written in the host's applications directory.
+type Shortcut struct {
+	// Path to its desktop entry
+	Path string
+	// Project it runs a command of
+	ProjectName string
+	// Name displayed in the application menu
+	Name string
+	// Command line it runs on the host
+	Exec string
+}
+
+// Get the directory the desktop entries of the host's applications menu are
+// read from.
+func (f *FileStore) GetApplicationsDir() string {
+	return filepath.Join(f.userFS.GetUserDataDir(), "applications")
+}
+
+// Get the path to the desktop entry of the shortcut `id` of that project.
+func (f *FileStore) GetShortcutPath(projectName string, id string) string {
+	return filepath.Join(f.GetApplicationsDir(), "paulenv-"+projectName+"."+id+".desktop")
+}
+
+// Write the desktop entry of a shortcut at the given path, as returned by
+// `GetShortcutPath`.
+func (f *FileStore) WriteShortcut(path string, content []byte) error {
+	if err := f.userFS.MkdirAsUser(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("cannot create applications directory: %w", err)
+	}
+	if err := f.userFS.WriteFileAsUser(path, content, 0644); err != nil {
+		return fmt.Errorf("cannot write shortcut: %w", err)
+	}
+	return nil
+}
+
+// List the shortcuts of the given project, of all of them if empty.
+func (f *FileStore) ListShortcuts(projectName string) ([]Shortcut, error) {
+	entries, err := os.ReadDir(f.GetApplicationsDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot read applications directory: %w", err)
+	}
+	var shortcuts []Shortcut
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), "paulenv-") || filepath.Ext(entry.Name()) != ".desktop" {
+			continue
+		}
+		path := filepath.Join(f.GetApplicationsDir(), entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read shortcut %s: %w", entry.Name(), err)
+		}
+		shortcut := parseShortcut(data)
+		if shortcut.ProjectName == "" || (projectName != "" && shortcut.ProjectName != projectName) {
+			continue
+		}
+		shortcut.Path = path
+		shortcuts = append(shortcuts, shortcut)
+	}
+	return shortcuts, nil
+}
+
+// Remove all shortcuts of the given project, returning how many there were.
+func (f *FileStore) RemoveProjectShortcuts(projectName string) (int, error) {
+	shortcuts, err := f.ListShortcuts(projectName)
+	if err != nil {
+		return 0, err
+	}
+	for _, shortcut := range shortcuts {
+		if err := f.RemoveShortcut(shortcut); err != nil {
+			return 0, err
+		}
+	}
+	return len(shortcuts), nil
+}
+
+// Move the shortcuts of project `from` to project `to`, once renamed:
+// `rewrite` gives the content of their new desktop entry from their current
+// one. Returns how many there were.
+func (f *FileStore) RenameProjectShortcuts(from string, to string, rewrite func(entry []byte) []byte) (int, error) {
+	shortcuts, err := f.ListShortcuts(from)
+	if err != nil {
+		return 0, err
+	}
+	for _, shortcut := range shortcuts {
+		data, err := os.ReadFile(shortcut.Path)
+		if err != nil {
+			return 0, fmt.Errorf("cannot read shortcut %s: %w", filepath.Base(shortcut.Path), err)
+		}
+		id := strings.TrimSuffix(filepath.Base(shortcut.Path), ".desktop")
+		id = strings.TrimPrefix(id, "paulenv-"+from+".")
+		if err := f.WriteShortcut(f.GetShortcutPath(to, id), rewrite(data)); err != nil {
+			return 0, err
+		}
+		if err := f.RemoveShortcut(shortcut); err != nil {
+			return 0, err
+		}
+	}
+	return len(shortcuts), nil
+}
+
+// Remove the desktop entry of that shortcut.
+func (f *FileStore) RemoveShortcut(shortcut Shortcut) error {
+	if err := os.Remove(shortcut.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("cannot remove shortcut: %w", err)
+	}
+	return nil
+}
+
+// Read the keys of a shortcut from its desktop entry. Its project is empty if
+// it was not written by paul-envs.
+func parseShortcut(data []byte) Shortcut {
+	var shortcut Shortcut
+	inMainGroup := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inMainGroup = line == "[Desktop Entry]"
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !inMainGroup || !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case ShortcutProjectKey:
+			shortcut.ProjectName = strings.TrimSpace(value)
+		case "Name":
+			shortcut.Name = strings.TrimSpace(value)
+		case "Exec":
+			shortcut.Exec = strings.TrimSpace(value)
+		}
+	}
+	return shortcut
+}
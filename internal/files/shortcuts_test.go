@@ -0,0 +1,92 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShortcuts(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	store, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	if shortcuts, err := store.ListShortcuts(""); err != nil || len(shortcuts) != 0 {
+		t.Fatalf("ListShortcuts() without applications directory = %v, %v, want nothing", shortcuts, err)
+	}
+
+	path := store.GetShortcutPath("app", "firefox")
+	content := "[Desktop Entry]\nName=Firefox (app)\nExec=paul-envs run app firefox\n" + ShortcutProjectKey + "=app\n" +
+		"[Desktop Action new]\nName=Other\n"
+	if err := store.WriteShortcut(path, []byte(content)); err != nil {
+		t.Fatalf("WriteShortcut() error = %v", err)
+	}
+	other := store.GetShortcutPath("app-2", "code")
+	if err := store.WriteShortcut(other, []byte("[Desktop Entry]\nName=Code\n"+ShortcutProjectKey+"=app-2\n")); err != nil {
+		t.Fatalf("WriteShortcut() error = %v", err)
+	}
+	// Not written by paul-envs
+	if err := os.WriteFile(filepath.Join(store.GetApplicationsDir(), "paulenv-notes.desktop"), []byte("[Desktop Entry]\nName=Notes\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	shortcuts, err := store.ListShortcuts("app")
+	if err != nil {
+		t.Fatalf("ListShortcuts() error = %v", err)
+	}
+	want := Shortcut{Path: path, ProjectName: "app", Name: "Firefox (app)", Exec: "paul-envs run app firefox"}
+	if len(shortcuts) != 1 || shortcuts[0] != want {
+		t.Fatalf("ListShortcuts() = %v, want [%v]", shortcuts, want)
+	}
+	if all, err := store.ListShortcuts(""); err != nil || len(all) != 2 {
+		t.Fatalf("ListShortcuts() of all projects = %v, %v, want 2 shortcuts", all, err)
+	}
+
+	if count, err := store.RemoveProjectShortcuts("app"); err != nil || count != 1 {
+		t.Fatalf("RemoveProjectShortcuts() = %d, %v, want 1", count, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("shortcut still exists after RemoveProjectShortcuts(): %v", err)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Fatalf("shortcut of another project removed: %v", err)
+	}
+}
+
+func TestRenameProjectShortcuts(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	store, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	path := store.GetShortcutPath("app", "firefox")
+	if err := store.WriteShortcut(path, []byte("[Desktop Entry]\nName=Firefox (app)\n"+ShortcutProjectKey+"=app\n")); err != nil {
+		t.Fatalf("WriteShortcut() error = %v", err)
+	}
+	other := store.GetShortcutPath("app-2", "code")
+	if err := store.WriteShortcut(other, []byte("[Desktop Entry]\nName=Code\n"+ShortcutProjectKey+"=app-2\n")); err != nil {
+		t.Fatalf("WriteShortcut() error = %v", err)
+	}
+
+	rewrite := func(entry []byte) []byte {
+		return []byte("[Desktop Entry]\nName=Firefox (web)\n" + ShortcutProjectKey + "=web\n")
+	}
+	if count, err := store.RenameProjectShortcuts("app", "web", rewrite); err != nil || count != 1 {
+		t.Fatalf("RenameProjectShortcuts() = %d, %v, want 1", count, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("shortcut under the previous name still exists: %v", err)
+	}
+	shortcuts, err := store.ListShortcuts("web")
+	if err != nil {
+		t.Fatalf("ListShortcuts() error = %v", err)
+	}
+	want := Shortcut{Path: store.GetShortcutPath("web", "firefox"), ProjectName: "web", Name: "Firefox (web)"}
+	if len(shortcuts) != 1 || shortcuts[0] != want {
+		t.Fatalf("ListShortcuts() of the renamed project = %v, want [%v]", shortcuts, want)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Fatalf("shortcut of another project moved: %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+// # ssh_key.go
+// This file handles the ssh key generated for each project whose container
+// runs an ssh server, so IDEs and ssh clients on the host can connect to it
+// without the user having to provide their own key.
+
+package files
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	projectSSHDirname            = "ssh"
+	projectSSHKeyFilename        = "id_ed25519"
+	projectAuthorizedKeyFilename = "authorized_keys"
+	projectSSHDPortFilename      = "sshd.port"
+)
+
+// Get path to the private ssh key generated for the given project.
+func (f *FileStore) GetProjectSSHKeyPath(projectName string) string {
+	return filepath.Join(f.getProjectInternalDir(projectName), projectSSHDirname, projectSSHKeyFilename)
+}
+
+// Get path to the `authorized_keys` file to mount in the given project's
+// container, allowing its generated ssh key.
+func (f *FileStore) GetProjectSSHAuthorizedKeysPath(projectName string) string {
+	return filepath.Join(f.getProjectInternalDir(projectName), projectSSHDirname, projectAuthorizedKeyFilename)
+}
+
+// Get the host port on which `paul-envs sshd` last served the given project,
+// empty if it never did.
+func (f *FileStore) GetProjectSSHDPort(projectName string) (string, error) {
+	data, err := os.ReadFile(f.getProjectSSHDPortPath(projectName))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("cannot read sshd port: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Remember the host port on which `paul-envs sshd` serves the given project,
+// so it keeps the same one and ssh_config entries stay valid.
+func (f *FileStore) SetProjectSSHDPort(projectName string, port string) error {
+	path := f.getProjectSSHDPortPath(projectName)
+	if err := f.userFS.MkdirAsUser(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("cannot create ssh directory: %w", err)
+	}
+	if err := f.userFS.WriteFileAsUser(path, []byte(port+"\n"), 0644); err != nil {
+		return fmt.Errorf("cannot write sshd port: %w", err)
+	}
+	return nil
+}
+
+func (f *FileStore) getProjectSSHDPortPath(projectName string) string {
+	return filepath.Join(f.getProjectInternalDir(projectName), projectSSHDirname, projectSSHDPortFilename)
+}
+
+// Generate the ssh key of the given project if not already done.
+//
+// Returns the path to its private key.
+func (f *FileStore) EnsureProjectSSHKey(projectName string) (string, error) {
+	keyPath := f.GetProjectSSHKeyPath(projectName)
+	authorizedKeysPath := f.GetProjectSSHAuthorizedKeysPath(projectName)
+	_, keyErr := os.Stat(keyPath)
+	_, authErr := os.Stat(authorizedKeysPath)
+	if keyErr == nil && authErr == nil {
+		return keyPath, nil
+	}
+	if keyErr != nil && !errors.Is(keyErr, os.ErrNotExist) {
+		return "", fmt.Errorf("cannot check ssh key: %w", keyErr)
+	}
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("cannot generate ssh key: %w", err)
+	}
+	comment := "paulenv-" + projectName
+	privatePEM, err := marshalOpenSSHPrivateKey(publicKey, privateKey, comment)
+	if err != nil {
+		return "", fmt.Errorf("cannot encode ssh key: %w", err)
+	}
+	authorizedKey := formatAuthorizedKey(publicKey, comment)
+
+	if err := f.userFS.MkdirAsUser(filepath.Dir(keyPath), 0700); err != nil {
+		return "", fmt.Errorf("cannot create ssh key directory: %w", err)
+	}
+	if err := f.userFS.WriteFileAsUser(keyPath, privatePEM, 0600); err != nil {
+		return "", fmt.Errorf("cannot write ssh key: %w", err)
+	}
+	if err := f.userFS.WriteFileAsUser(keyPath+".pub", authorizedKey, 0644); err != nil {
+		return "", fmt.Errorf("cannot write ssh public key: %w", err)
+	}
+	if err := f.userFS.WriteFileAsUser(authorizedKeysPath, authorizedKey, 0644); err != nil {
+		return "", fmt.Errorf("cannot write authorized_keys file: %w", err)
+	}
+	return keyPath, nil
+}
+
+// Format an ed25519 public key as an `authorized_keys` line.
+func formatAuthorizedKey(publicKey ed25519.PublicKey, comment string) []byte {
+	var blob bytes.Buffer
+	writeSSHString(&blob, []byte("ssh-ed25519"))
+	writeSSHString(&blob, publicKey)
+	return fmt.Appendf(nil, "ssh-ed25519 %s %s\n", base64.StdEncoding.EncodeToString(blob.Bytes()), comment)
+}
+
+// Encode an unencrypted ed25519 private key in the "openssh-key-v1" format, as
+// written by `ssh-keygen`.
+func marshalOpenSSHPrivateKey(publicKey ed25519.PublicKey, privateKey ed25519.PrivateKey, comment string) ([]byte, error) {
+	var publicBlob bytes.Buffer
+	writeSSHString(&publicBlob, []byte("ssh-ed25519"))
+	writeSSHString(&publicBlob, publicKey)
+
+	var check [4]byte
+	if _, err := rand.Read(check[:]); err != nil {
+		return nil, err
+	}
+	var private bytes.Buffer
+	private.Write(check[:])
+	private.Write(check[:])
+	writeSSHString(&private, []byte("ssh-ed25519"))
+	writeSSHString(&private, publicKey)
+	writeSSHString(&private, privateKey)
+	writeSSHString(&private, []byte(comment))
+	for i := byte(1); private.Len()%8 != 0; i++ {
+		private.WriteByte(i)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("openssh-key-v1\x00")
+	writeSSHString(&out, []byte("none"))
+	writeSSHString(&out, []byte("none"))
+	writeSSHString(&out, nil)
+	binary.Write(&out, binary.BigEndian, uint32(1))
+	writeSSHString(&out, publicBlob.Bytes())
+	writeSSHString(&out, private.Bytes())
+	return pem.EncodeToMemory(&pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: out.Bytes()}), nil
+}
+
+func writeSSHString(buf *bytes.Buffer, data []byte) {
+	binary.Write(buf, binary.BigEndian, uint32(len(data)))
+	buf.Write(data)
+}
@@ -0,0 +1,27 @@
+// # startup_progress.go
+// This file handles the progress file through which a project's container
+// reports how its startup scripts went, read by paul-envs once it exited.
+
+package files
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+const projectStartupProgressFilename = "startup.progress"
+
+// Get path to the file in which the given project's container reports the
+// progress of its startup scripts.
+func (f *FileStore) GetProjectStartupProgressPath(projectName string) string {
+	return filepath.Join(f.getProjectInternalDir(projectName), projectStartupProgressFilename)
+}
+
+// Empty the startup progress file of the given project before its container
+// is started, creating it if needed so the engine mounts it as a file.
+func (f *FileStore) ResetProjectStartupProgress(projectName string) error {
+	if err := f.userFS.WriteFileAsUser(f.GetProjectStartupProgressPath(projectName), nil, 0644); err != nil {
+		return fmt.Errorf("cannot reset startup progress file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+// # timings.go
+// This file records how long each build, pull and run of a project took, and
+// for builds how many of their steps came from the engine's cache.
+// `paul-envs times` summarizes them and `paul-envs daemon --metrics-address`
+// exposes build duration histograms from them.
+//
+// Successful builds used to be recorded alone in a `build.durations` file,
+// which is still read.
+
+package files
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const timingsFilename = "timings"
+
+// Former file of successful build durations.
+const buildDurationsFilename = "build.durations"
+
+// What a timing was recorded for.
+type TimingKind string
+
+const (
+	TimingBuild TimingKind = "build"
+	TimingPull  TimingKind = "pull"
+	TimingRun   TimingKind = "run"
+)
+
+// How long a build, pull or run of a project took.
+type Timing struct {
+	Kind TimingKind
+	// When it ended
+	At       time.Time
+	Duration time.Duration
+	Failed   bool
+	// Steps of a build reused from the engine's cache, out of its `Steps`.
+	// Both `0` if unknown.
+	CachedSteps int
+	Steps       int
+}
+
+// Record a build, pull or run of that project.
+func (f *FileStore) RecordTiming(projectName string, timing Timing) error {
+	if err := f.userFS.MkdirAsUser(f.getProjectInternalDir(projectName), 0755); err != nil {
+		return fmt.Errorf("cannot create project internal directory: %w", err)
+	}
+	file, err := f.userFS.AppendFileAsUser(f.getTimingsPath(projectName), 0644)
+	if err != nil {
+		return fmt.Errorf("cannot open '%s': %w", timingsFilename, err)
+	}
+	defer file.Close()
+	outcome := "ok"
+	if timing.Failed {
+		outcome = "failed"
+	}
+	_, err = fmt.Fprintf(file, "%s %s %.3f %s %d/%d\n", timing.At.UTC().Format(time.RFC3339), timing.Kind,
+		timing.Duration.Seconds(), outcome, timing.CachedSteps, timing.Steps)
+	return err
+}
+
+// Returns the recorded builds, pulls and runs of that project, oldest first.
+// Lines which cannot be parsed are skipped.
+func (f *FileStore) GetTimings(projectName string) ([]Timing, error) {
+	timings, err := readTimingLines(f.getBuildDurationsPath(projectName), parseBuildDurationLine)
+	if err != nil {
+		return nil, err
+	}
+	recorded, err := readTimingLines(f.getTimingsPath(projectName), parseTimingLine)
+	if err != nil {
+		return nil, err
+	}
+	timings = append(timings, recorded...)
+	slices.SortStableFunc(timings, func(a, b Timing) int { return a.At.Compare(b.At) })
+	return timings, nil
+}
+
+// Returns the durations of all successful builds of that project, oldest
+// first.
+func (f *FileStore) GetBuildDurations(projectName string) ([]time.Duration, error) {
+	timings, err := f.GetTimings(projectName)
+	if err != nil {
+		return nil, err
+	}
+	durations := []time.Duration{}
+	for _, timing := range timings {
+		if timing.Kind == TimingBuild && !timing.Failed {
+			durations = append(durations, timing.Duration)
+		}
+	}
+	return durations, nil
+}
+
+func readTimingLines(path string, parse func([]string) (Timing, bool)) ([]Timing, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not read '%s': %w", filepath.Base(path), err)
+	}
+	defer file.Close()
+	var timings []Timing
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if timing, ok := parse(strings.Fields(scanner.Text())); ok {
+			timings = append(timings, timing)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read '%s': %w", filepath.Base(path), err)
+	}
+	return timings, nil
+}
+
+// Parse a "<time> <kind> <seconds> <ok|failed> <cached>/<steps>" line.
+func parseTimingLine(fields []string) (Timing, bool) {
+	if len(fields) != 5 {
+		return Timing{}, false
+	}
+	at, err := time.Parse(time.RFC3339, fields[0])
+	if err != nil {
+		return Timing{}, false
+	}
+	duration, ok := parseTimingSeconds(fields[2])
+	if !ok || (fields[3] != "ok" && fields[3] != "failed") {
+		return Timing{}, false
+	}
+	cached, steps, _ := strings.Cut(fields[4], "/")
+	timing := Timing{Kind: TimingKind(fields[1]), At: at, Duration: duration, Failed: fields[3] == "failed"}
+	timing.CachedSteps, _ = strconv.Atoi(cached)
+	timing.Steps, _ = strconv.Atoi(steps)
+	if timing.CachedSteps < 0 || timing.Steps < timing.CachedSteps {
+		timing.CachedSteps, timing.Steps = 0, 0
+	}
+	return timing, true
+}
+
+// Parse a "<time> <seconds>" line of the former `build.durations` file.
+func parseBuildDurationLine(fields []string) (Timing, bool) {
+	if len(fields) != 2 {
+		return Timing{}, false
+	}
+	duration, ok := parseTimingSeconds(fields[1])
+	if !ok {
+		return Timing{}, false
+	}
+	// Its lines are in order, keep them so if their time cannot be parsed
+	at, _ := time.Parse(time.RFC3339, fields[0])
+	return Timing{Kind: TimingBuild, At: at, Duration: duration}, true
+}
+
+func parseTimingSeconds(value string) (time.Duration, bool) {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+func (f *FileStore) getBuildDurationsPath(projectName string) string {
+	return filepath.Join(f.getProjectInternalDir(projectName), buildDurationsFilename)
+}
+
+func (f *FileStore) getTimingsPath(projectName string) string {
+	return filepath.Join(f.getProjectInternalDir(projectName), timingsFilename)
+}
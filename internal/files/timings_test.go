@@ -0,0 +1,59 @@
+package files
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTimings(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	store, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	if timings, err := store.GetTimings("app"); err != nil || len(timings) != 0 {
+		t.Fatalf("GetTimings() without records = %v, %v, want nothing", timings, err)
+	}
+
+	at := time.Date(2026, 1, 2, 13, 4, 5, 0, time.UTC)
+	// Recorded by earlier versions
+	if err := os.MkdirAll(store.getProjectInternalDir("app"), 0755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(store.getBuildDurationsPath("app"), []byte("2026-01-01T10:00:00Z 90.000\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	recorded := []Timing{
+		{Kind: TimingBuild, At: at, Duration: 1500 * time.Millisecond, CachedSteps: 7, Steps: 9},
+		{Kind: TimingBuild, At: at.Add(time.Hour), Duration: 30 * time.Second, Failed: true},
+		{Kind: TimingRun, At: at.Add(2 * time.Hour), Duration: 2 * time.Hour},
+	}
+	for _, timing := range recorded {
+		if err := store.RecordTiming("app", timing); err != nil {
+			t.Fatalf("RecordTiming() error = %v", err)
+		}
+	}
+	// Interrupted while writing
+	file, _ := os.OpenFile(store.getTimingsPath("app"), os.O_APPEND|os.O_WRONLY, 0)
+	file.WriteString("2026-01-02T13:")
+	file.Close()
+
+	timings, err := store.GetTimings("app")
+	if err != nil {
+		t.Fatalf("GetTimings() error = %v", err)
+	}
+	legacy := Timing{Kind: TimingBuild, At: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC), Duration: 90 * time.Second}
+	if want := append([]Timing{legacy}, recorded...); !slices.Equal(timings, want) {
+		t.Fatalf("GetTimings() = %+v, want %+v", timings, want)
+	}
+
+	durations, err := store.GetBuildDurations("app")
+	if err != nil {
+		t.Fatalf("GetBuildDurations() error = %v", err)
+	}
+	if want := []time.Duration{90 * time.Second, 1500 * time.Millisecond}; !slices.Equal(durations, want) {
+		t.Fatalf("GetBuildDurations() = %v, want %v", durations, want)
+	}
+}
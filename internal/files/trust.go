@@ -0,0 +1,183 @@
+// # trust.go
+// In-repository definitions come from code the user may just have cloned: they
+// can declare arbitrary build steps and host mounts. Before relying on one,
+// the user has to trust it explicitly and we record a fingerprint of all its
+// files, so any later change to it has to be trusted again.
+
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/peaberberian/paul-envs/internal/utils"
+)
+
+const trustedDefinitionsFilename = "trusted-definitions"
+
+// An in-repository definition the user chose to trust.
+type TrustedDefinition struct {
+	// Root of the repository containing the `.paulenv` directory.
+	RootDir string
+	// Fingerprint of the definition's content when it was trusted.
+	Fingerprint string
+	// When it was trusted.
+	TrustedAt time.Time
+}
+
+// Compute a fingerprint covering the content of every file in the definition
+// directory, including dotfiles.
+func (d RepoDefinition) Fingerprint() (string, error) {
+	paths := []string{}
+	err := filepath.WalkDir(d.DefinitionDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type().IsRegular() || entry.Type()&fs.ModeSymlink != 0 {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot read definition in %s: %w", d.DefinitionDir, err)
+	}
+	sort.Strings(paths)
+
+	var buf bytes.Buffer
+	for _, path := range paths {
+		rel, err := filepath.Rel(d.DefinitionDir, path)
+		if err != nil {
+			return "", err
+		}
+		var content []byte
+		if info, err := os.Lstat(path); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return "", err
+			}
+			content = []byte("symlink:" + target)
+		} else if content, err = os.ReadFile(path); err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&buf, "%s\x00%s\x00", filepath.ToSlash(rel), utils.BufferHash(content))
+	}
+	return utils.BufferHash(buf.Bytes()), nil
+}
+
+// Returns every in-repository definition currently trusted.
+func (f *FileStore) GetTrustedDefinitions() ([]TrustedDefinition, error) {
+	file, err := os.Open(f.getTrustedDefinitionsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []TrustedDefinition{}, nil
+		}
+		return nil, fmt.Errorf("could not open '%s': %w", trustedDefinitionsFilename, err)
+	}
+	defer file.Close()
+
+	trusted := []TrustedDefinition{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		trustedAt, _ := time.Parse(time.RFC3339, parts[1])
+		trusted = append(trusted, TrustedDefinition{
+			Fingerprint: parts[0],
+			TrustedAt:   trustedAt,
+			RootDir:     parts[2],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read '%s': %w", trustedDefinitionsFilename, err)
+	}
+	return trusted, nil
+}
+
+// Returns the trust record for the definition at the given repository root,
+// or `nil` if it has never been trusted.
+func (f *FileStore) GetDefinitionTrust(rootDir string) (*TrustedDefinition, error) {
+	trusted, err := f.GetTrustedDefinitions()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range trusted {
+		if t.RootDir == rootDir {
+			return &t, nil
+		}
+	}
+	return nil, nil
+}
+
+// Record the given fingerprint as trusted for that repository root, replacing
+// any previous one.
+func (f *FileStore) TrustDefinition(rootDir string, fingerprint string) error {
+	trusted, err := f.GetTrustedDefinitions()
+	if err != nil {
+		return err
+	}
+	trusted = removeTrust(trusted, rootDir)
+	trusted = append(trusted, TrustedDefinition{
+		RootDir:     rootDir,
+		Fingerprint: fingerprint,
+		TrustedAt:   time.Now(),
+	})
+	return f.writeTrustedDefinitions(trusted)
+}
+
+// Forget trust for the definition at the given repository root.
+//
+// Returns `false` if it was not trusted in the first place.
+func (f *FileStore) RevokeDefinitionTrust(rootDir string) (bool, error) {
+	trusted, err := f.GetTrustedDefinitions()
+	if err != nil {
+		return false, err
+	}
+	remaining := removeTrust(trusted, rootDir)
+	if len(remaining) == len(trusted) {
+		return false, nil
+	}
+	return true, f.writeTrustedDefinitions(remaining)
+}
+
+func (f *FileStore) getTrustedDefinitionsPath() string {
+	return filepath.Join(f.baseDataDir, trustedDefinitionsFilename)
+}
+
+func (f *FileStore) writeTrustedDefinitions(trusted []TrustedDefinition) error {
+	var buf bytes.Buffer
+	buf.WriteString("# In-repository definitions trusted by paul-envs.\n")
+	buf.WriteString("# Format: <fingerprint>\\t<trusted at>\\t<repository root>\n")
+	for _, t := range trusted {
+		fmt.Fprintf(&buf, "%s\t%s\t%s\n", t.Fingerprint, t.TrustedAt.Format(time.RFC3339), t.RootDir)
+	}
+	if err := f.userFS.MkdirAsUser(f.baseDataDir, 0755); err != nil {
+		return fmt.Errorf("create data directory: %w", err)
+	}
+	if err := f.userFS.WriteFileAsUser(f.getTrustedDefinitionsPath(), buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("could not write '%s': %w", trustedDefinitionsFilename, err)
+	}
+	return nil
+}
+
+func removeTrust(trusted []TrustedDefinition, rootDir string) []TrustedDefinition {
+	remaining := make([]TrustedDefinition, 0, len(trusted))
+	for _, t := range trusted {
+		if t.RootDir != rootDir {
+			remaining = append(remaining, t)
+		}
+	}
+	return remaining
+}
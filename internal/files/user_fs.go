@@ -75,6 +75,15 @@ func NewUserFS() (*UserFS, error) {
 	}, nil
 }
 
+// In dry-run mode (the global `--dry-run` flag), files and directories are
+// not written, only displayed.
+var dryRun bool
+
+// Enable or disable the dry-run mode.
+func SetDryRun(enabled bool) {
+	dryRun = enabled
+}
+
 // Create a directory with the associated file permissions and the set the
 // current user as the owner
 func (u *UserFS) MkdirAsUser(path string, perm os.FileMode) error {
@@ -82,6 +91,24 @@ func (u *UserFS) MkdirAsUser(path string, perm os.FileMode) error {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		existed = false
 	}
+	if dryRun {
+		if !existed {
+			fmt.Fprintf(os.Stderr, "[dry-run] would create directory: %s\n", path)
+		}
+		return nil
+	}
+	return u.mkdirAll(path, perm, existed)
+}
+
+// Create a directory like `MkdirAsUser`, even in dry-run mode. Only for
+// paul-envs' own bookkeeping (e.g. lock files), never for what a command is
+// asked to write.
+func (u *UserFS) mkdirAsUserAlways(path string, perm os.FileMode) error {
+	_, err := os.Stat(path)
+	return u.mkdirAll(path, perm, !os.IsNotExist(err))
+}
+
+func (u *UserFS) mkdirAll(path string, perm os.FileMode, existed bool) error {
 	if err := os.MkdirAll(path, perm); err != nil {
 		return err
 	}
@@ -98,24 +125,93 @@ func (u *UserFS) MkdirAsUser(path string, perm os.FileMode) error {
 }
 
 // Create a file with the associated file permissions and the set the
-// current user as the owner
+// current user as the owner.
+//
+// The content is first written to a temporary file in the same directory,
+// which then replaces `path` in a single rename, so an interruption never
+// leaves a half-written file behind.
+//
+// If `path` is a symbolic link (e.g. to a dotfiles repository), the file it
+// points to is replaced instead of the link. An existing file keeps its mode.
 func (u *UserFS) WriteFileAsUser(path string, data []byte, perm os.FileMode) error {
-	existed := true
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		existed = false
+	if dryRun {
+		fmt.Fprintf(os.Stderr, "[dry-run] would write: %s (%d bytes)\n", path, len(data))
+		return nil
+	}
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
 	}
-	if err := os.WriteFile(path, data, perm); err != nil {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
 		return err
 	}
-	if err := u.chownIfNeeded(path); err != nil {
-		if !existed {
-			os.Remove(path)
-		}
+	tmpPath := tmp.Name()
+	if err := writeAndSync(tmp, data, perm); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := u.chownIfNeeded(tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
 		return err
 	}
 	return nil
 }
 
+// Create (or truncate) a file with the associated file permissions, set the
+// current user as its owner and open it for writing.
+func (u *UserFS) CreateFileAsUser(path string, perm os.FileMode) (*os.File, error) {
+	if dryRun {
+		fmt.Fprintf(os.Stderr, "[dry-run] would write: %s\n", path)
+		return os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.chownIfNeeded(path); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// Open a file for appending to it, creating it with the associated file
+// permissions and the current user as its owner if it does not exist.
+func (u *UserFS) AppendFileAsUser(path string, perm os.FileMode) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, perm)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.chownIfNeeded(path); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func writeAndSync(file *os.File, data []byte, perm os.FileMode) error {
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Chmod(perm); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
 // Returns the "data" directory associated with this user, where application
 // data can reside.
 func (u *UserFS) GetUserDataDir() string {
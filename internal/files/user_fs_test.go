@@ -1,6 +1,7 @@
 package files
 
 import (
+	"os"
 	"os/user"
 	"path/filepath"
 	"testing"
@@ -324,3 +325,60 @@ func TestNewUserFS_NoHome(t *testing.T) {
 		t.Fatal("expected error when HOME not set")
 	}
 }
+
+func TestWriteFileAsUser_DryRun(t *testing.T) {
+	SetDryRun(true)
+	t.Cleanup(func() { SetDryRun(false) })
+	dir := filepath.Join(t.TempDir(), "project")
+	ufs := &UserFS{}
+	if err := ufs.MkdirAsUser(dir, 0755); err != nil {
+		t.Fatalf("MkdirAsUser() error = %v", err)
+	}
+	if err := ufs.WriteFileAsUser(filepath.Join(dir, "run.conf"), []byte("VERSION 1.2.0\n"), 0644); err != nil {
+		t.Fatalf("WriteFileAsUser() error = %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("nothing should be written in dry-run mode, got %v", err)
+	}
+}
+
+func TestWriteFileAsUser_Symlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "config")
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("ENGINE docker\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "config")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+	ufs := &UserFS{}
+	if err := ufs.WriteFileAsUser(link, []byte("ENGINE podman\n"), 0644); err != nil {
+		t.Fatalf("WriteFileAsUser() error = %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("WriteFileAsUser() replaced the symbolic link: %v", err)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil || string(data) != "ENGINE podman\n" {
+		t.Fatalf("target of the link = %q, %v, want the new content", data, err)
+	}
+	if info, err := os.Stat(target); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Fatalf("target of the link mode = %v, want its previous mode kept", info.Mode().Perm())
+	}
+
+	created := filepath.Join(dir, "run.conf")
+	if err := ufs.WriteFileAsUser(created, []byte("VERSION 1.2.0\n"), 0644); err != nil {
+		t.Fatalf("WriteFileAsUser() error = %v", err)
+	}
+	if info, err := os.Stat(created); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0644 {
+		t.Fatalf("created file mode = %v, want 0644", info.Mode().Perm())
+	}
+}
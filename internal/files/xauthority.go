@@ -0,0 +1,86 @@
+// # xauthority.go
+// This file handles the X11 authority file given to containers forwarding the
+// host's X11 display.
+//
+// The host's cookies are bound to its hostname, which differs from the
+// container's, so a copy where all cookies match any host is written instead.
+
+package files
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const projectXauthorityFilename = "Xauthority"
+
+// `FamilyWild` in Xauth: the cookie applies to any host.
+const xauthFamilyWild = 0xffff
+
+// Get path to the X11 authority file to mount in the given project's
+// container.
+func (f *FileStore) GetProjectXauthorityPath(projectName string) string {
+	return filepath.Join(f.getProjectInternalDir(projectName), projectXauthorityFilename)
+}
+
+// Write the X11 authority file of the given project from the host's one
+// (`$XAUTHORITY` or `~/.Xauthority`).
+//
+// If the host has none, any previously written one is removed.
+func (f *FileStore) PrepareProjectXauthority(projectName string) error {
+	target := f.GetProjectXauthorityPath(projectName)
+	source := os.Getenv("XAUTHORITY")
+	if source == "" {
+		source = filepath.Join(f.userFS.homeDir, ".Xauthority")
+	}
+	data, err := os.ReadFile(source)
+	if errors.Is(err, os.ErrNotExist) {
+		if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("cannot remove outdated X11 authority file: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot read X11 authority file: %w", err)
+	}
+	wild, err := wildcardXauthority(data)
+	if err != nil {
+		return fmt.Errorf("cannot read X11 authority file %s: %w", source, err)
+	}
+	if err := f.userFS.WriteFileAsUser(target, wild, 0600); err != nil {
+		return fmt.Errorf("cannot write X11 authority file: %w", err)
+	}
+	return nil
+}
+
+// Returns a copy of the given Xauthority file content where all entries apply
+// to any host.
+//
+// Each entry is made of a 16-bit family followed by four length-prefixed
+// fields: address, display number, authorization name and data.
+func wildcardXauthority(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	for offset := 0; offset < len(data); {
+		if len(data)-offset < 2 {
+			return nil, errors.New("truncated entry")
+		}
+		start := offset
+		offset += 2
+		for range 4 {
+			if len(data)-offset < 2 {
+				return nil, errors.New("truncated entry")
+			}
+			length := int(binary.BigEndian.Uint16(data[offset:]))
+			offset += 2 + length
+			if offset > len(data) {
+				return nil, errors.New("truncated entry")
+			}
+		}
+		out = binary.BigEndian.AppendUint16(out, xauthFamilyWild)
+		out = append(out, data[start+2:offset]...)
+	}
+	return out, nil
+}
@@ -0,0 +1,34 @@
+package files
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWildcardXauthority(t *testing.T) {
+	entry := func(family byte, address, number string) []byte {
+		var b []byte
+		b = append(b, 0, family)
+		for _, field := range []string{address, number, "MIT-MAGIC-COOKIE-1", "0123456789abcdef"} {
+			b = append(b, 0, byte(len(field)))
+			b = append(b, field...)
+		}
+		return b
+	}
+	input := append(entry(0x01, "myhost", "0"), entry(0x00, "\x7f\x00\x00\x01", "1")...)
+	got, err := wildcardXauthority(input)
+	if err != nil {
+		t.Fatalf("wildcardXauthority() error = %v", err)
+	}
+	want := append(entry(0x01, "myhost", "0"), entry(0x00, "\x7f\x00\x00\x01", "1")...)
+	want[0], want[1] = 0xff, 0xff
+	second := len(entry(0x01, "myhost", "0"))
+	want[second], want[second+1] = 0xff, 0xff
+	if !bytes.Equal(got, want) {
+		t.Fatalf("wildcardXauthority() = %x, want %x", got, want)
+	}
+
+	if _, err := wildcardXauthority(input[:len(input)-3]); err == nil {
+		t.Fatal("wildcardXauthority() expected error for a truncated file")
+	}
+}
@@ -0,0 +1,142 @@
+// # logging.go
+// Diagnostic log of what paul-envs does, through `log/slog`: the messages
+// shown to the user, the container engine calls, how long they took and what
+// they output.
+//
+// All records are appended to a log file kept for post-mortem debugging,
+// e.g. of a failed build. When verbose, records below the `Info` level (the
+// others being already displayed by the console) are also written to the
+// error output.
+//
+// Logging is disabled until `Setup` is called, in which case records cost
+// nearly nothing.
+
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+// Level of the most detailed records, e.g. the output of engine calls.
+const LevelTrace = slog.LevelDebug - 4
+
+type Verbosity int
+
+const (
+	// Only display errors, warnings and the commands' results
+	VerbosityQuiet Verbosity = iota - 1
+	VerbosityNormal
+	// Also display debug records, e.g. engine calls (`-v`)
+	VerbosityVerbose
+	// Also display trace records, e.g. engine outputs (`-vv`)
+	VerbosityTrace
+)
+
+var logger = slog.New(slog.DiscardHandler)
+
+// Returns the logger to which records have to be written.
+func Log() *slog.Logger {
+	return logger
+}
+
+// Set up the logger so records are written to `logFile`, if not `nil`, and
+// to `stderr` depending on the `verbosity`.
+func Setup(verbosity Verbosity, stderr io.Writer, logFile io.Writer) {
+	var handlers fanoutHandler
+	if logFile != nil {
+		handlers = append(handlers, slog.NewTextHandler(logFile, &slog.HandlerOptions{
+			Level:       LevelTrace,
+			ReplaceAttr: replaceLevelName,
+		}))
+	}
+	if verbosity >= VerbosityVerbose {
+		level := slog.LevelDebug
+		if verbosity >= VerbosityTrace {
+			level = LevelTrace
+		}
+		handlers = append(handlers, belowInfoHandler{slog.NewTextHandler(stderr, &slog.HandlerOptions{
+			Level: level,
+			ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+				// Already shown by the terminal
+				if len(groups) == 0 && attr.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return replaceLevelName(groups, attr)
+			},
+		})})
+	}
+	if len(handlers) == 0 {
+		logger = slog.New(slog.DiscardHandler)
+		return
+	}
+	logger = slog.New(handlers)
+}
+
+func replaceLevelName(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) == 0 && attr.Key == slog.LevelKey {
+		if level, ok := attr.Value.Any().(slog.Level); ok && level == LevelTrace {
+			return slog.String(slog.LevelKey, "TRACE")
+		}
+	}
+	return attr
+}
+
+// Handler writing records to all its handlers enabled for them.
+type fanoutHandler []slog.Handler
+
+func (h fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var firstErr error
+	for _, handler := range h {
+		if !handler.Enabled(ctx, record.Level) {
+			continue
+		}
+		if err := handler.Handle(ctx, record.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (h fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	result := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		result[i] = handler.WithAttrs(attrs)
+	}
+	return result
+}
+
+func (h fanoutHandler) WithGroup(name string) slog.Handler {
+	result := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		result[i] = handler.WithGroup(name)
+	}
+	return result
+}
+
+// Handler only enabled for records below the `Info` level.
+type belowInfoHandler struct {
+	slog.Handler
+}
+
+func (h belowInfoHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level < slog.LevelInfo && h.Handler.Enabled(ctx, level)
+}
+
+func (h belowInfoHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return belowInfoHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h belowInfoHandler) WithGroup(name string) slog.Handler {
+	return belowInfoHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSetup_Verbosity(t *testing.T) {
+	defer Setup(VerbosityNormal, nil, nil)
+	for _, tc := range []struct {
+		verbosity  Verbosity
+		wantStderr []string
+	}{
+		{VerbosityQuiet, nil},
+		{VerbosityNormal, nil},
+		{VerbosityVerbose, []string{"debug record"}},
+		{VerbosityTrace, []string{"debug record", "trace record"}},
+	} {
+		var stderr, logFile bytes.Buffer
+		Setup(tc.verbosity, &stderr, &logFile)
+		Log().Info("info record")
+		Log().Debug("debug record")
+		Log().Log(t.Context(), LevelTrace, "trace record")
+
+		for _, msg := range []string{"info record", "debug record", "trace record"} {
+			if !strings.Contains(logFile.String(), msg) {
+				t.Fatalf("verbosity %d: log file should contain %q, got %q", tc.verbosity, msg, logFile.String())
+			}
+		}
+		if !strings.Contains(logFile.String(), "level=TRACE") {
+			t.Fatalf("verbosity %d: trace records should be named, got %q", tc.verbosity, logFile.String())
+		}
+		// Info and above are displayed by the console itself
+		if strings.Contains(stderr.String(), "info record") {
+			t.Fatalf("verbosity %d: info records should not be written to stderr, got %q", tc.verbosity, stderr.String())
+		}
+		lines := strings.Count(stderr.String(), "\n")
+		if lines != len(tc.wantStderr) {
+			t.Fatalf("verbosity %d: got %d records on stderr, want %d: %q", tc.verbosity, lines, len(tc.wantStderr), stderr.String())
+		}
+		for _, msg := range tc.wantStderr {
+			if !strings.Contains(stderr.String(), msg) {
+				t.Fatalf("verbosity %d: stderr should contain %q, got %q", tc.verbosity, msg, stderr.String())
+			}
+		}
+	}
+}
+
+func TestSetup_NoOutput(t *testing.T) {
+	Setup(VerbosityNormal, nil, nil)
+	if Log().Enabled(t.Context(), slog.LevelError) {
+		t.Fatalf("logger without output should be disabled")
+	}
+}
@@ -0,0 +1,232 @@
+// Package markdown renders Markdown documents, like projects' READMEs, for
+// the terminal.
+//
+// Only the commonly used subset is understood: headings, paragraphs, lists,
+// block quotes, fenced code blocks, horizontal rules and inline code,
+// emphasis and links. Anything else is displayed as is.
+package markdown
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const (
+	bold      = "\033[1m"
+	dim       = "\033[2m"
+	italic    = "\033[3m"
+	underline = "\033[4m"
+	cyan      = "\033[36m"
+	reset     = "\033[0m"
+)
+
+// Width of rendered text when the terminal's is not known.
+const defaultWidth = 80
+
+type Options struct {
+	// Width available, in columns. `0` or less means `defaultWidth`.
+	Width int
+	// If `true`, ANSI escape codes are used for emphasis, headings and code.
+	// Otherwise their Markdown markers are kept or replaced by plain text.
+	Styled bool
+}
+
+var (
+	headingRegex   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	listItemRegex  = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	ruleRegex      = regexp.MustCompile(`^\s*((-\s*){3,}|(\*\s*){3,}|(_\s*){3,})$`)
+	inlineRegex    = regexp.MustCompile("`[^`]+`|\\*\\*[^*]+\\*\\*|__[^_]+__|\\*[^*\\s][^*]*\\*|\\[[^\\]]+\\]\\([^)]+\\)")
+	escapeSeqRegex = regexp.MustCompile("\033\\[[0-9;]*m")
+)
+
+// Returns `true` if `w` is a terminal, in which case rendered documents should
+// be styled.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd())) && os.Getenv("NO_COLOR") == ""
+}
+
+// Render the given Markdown document.
+func Render(w io.Writer, source string, opts Options) error {
+	width := opts.Width
+	if width <= 0 {
+		width = defaultWidth
+	}
+	r := renderer{opts: opts, width: width}
+	lines := strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			fence := trimmed[:3]
+			r.blockStart()
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				r.writeLine("    " + r.style(dim, strings.TrimRight(lines[i], " \t")))
+			}
+		case trimmed == "":
+			r.paragraphEnd = true
+		case headingRegex.MatchString(line):
+			r.heading(headingRegex.FindStringSubmatch(line))
+		case ruleRegex.MatchString(line):
+			r.blockStart()
+			r.writeLine(r.style(dim, strings.Repeat("─", min(width, defaultWidth))))
+		case strings.HasPrefix(trimmed, ">"):
+			r.blockStart()
+			text := strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+			for ; i+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i+1]), ">"); i++ {
+				text += " " + strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[i+1]), ">"))
+			}
+			r.wrapped(r.style(dim, "│ "), r.style(dim, "│ "), r.inline(text))
+		case listItemRegex.MatchString(line):
+			match := listItemRegex.FindStringSubmatch(line)
+			indent := strings.Repeat("  ", len(match[1])/2)
+			marker := "• "
+			if match[2][0] >= '0' && match[2][0] <= '9' {
+				marker = match[2] + " "
+			}
+			text := match[3]
+			for ; i+1 < len(lines) && isContinuation(lines[i+1]); i++ {
+				text += " " + strings.TrimSpace(lines[i+1])
+			}
+			r.listItem()
+			r.wrapped("  "+indent+marker, "  "+indent+strings.Repeat(" ", len([]rune(marker))), r.inline(text))
+		default:
+			r.blockStart()
+			text := trimmed
+			for ; i+1 < len(lines) && isContinuation(lines[i+1]); i++ {
+				text += " " + strings.TrimSpace(lines[i+1])
+			}
+			r.wrapped("", "", r.inline(text))
+		}
+	}
+	_, err := io.WriteString(w, r.out.String())
+	return err
+}
+
+// Returns `true` if that line continues the paragraph or list item before it.
+func isContinuation(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed != "" &&
+		!headingRegex.MatchString(line) &&
+		!listItemRegex.MatchString(line) &&
+		!ruleRegex.MatchString(line) &&
+		!strings.HasPrefix(trimmed, ">") &&
+		!strings.HasPrefix(trimmed, "```") &&
+		!strings.HasPrefix(trimmed, "~~~")
+}
+
+type renderer struct {
+	opts  Options
+	width int
+	out   strings.Builder
+	// `true` once something has been written
+	started bool
+	// `true` if a blank line ended the last block
+	paragraphEnd bool
+	// `true` if the last block is a list
+	inList bool
+}
+
+// Separate a new block from the previous one by a blank line.
+func (r *renderer) blockStart() {
+	if r.started {
+		r.out.WriteString("\n")
+	}
+	r.started = true
+	r.paragraphEnd = false
+	r.inList = false
+}
+
+// Separate list items only from what is not part of the same list.
+func (r *renderer) listItem() {
+	if !r.inList || r.paragraphEnd {
+		r.blockStart()
+	}
+	r.inList = true
+}
+
+func (r *renderer) heading(match []string) {
+	r.blockStart()
+	text := r.inline(match[2])
+	switch {
+	case r.opts.Styled && len(match[1]) == 1:
+		r.writeLine(r.style(bold+underline, text))
+	case r.opts.Styled:
+		r.writeLine(r.style(bold, text))
+	case len(match[1]) <= 2:
+		underlineChar := "="
+		if len(match[1]) == 2 {
+			underlineChar = "-"
+		}
+		r.writeLine(text)
+		r.writeLine(strings.Repeat(underlineChar, visibleWidth(text)))
+	default:
+		r.writeLine(match[1] + " " + text)
+	}
+}
+
+func (r *renderer) writeLine(line string) {
+	r.out.WriteString(line)
+	r.out.WriteString("\n")
+}
+
+// Write `text` wrapped to the available width, its first line prefixed by
+// `first` and the following ones by `next`.
+func (r *renderer) wrapped(first string, next string, text string) {
+	prefix := first
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && visibleWidth(prefix)+visibleWidth(line)+1+visibleWidth(word) > r.width {
+			r.writeLine(prefix + line)
+			prefix = next
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	r.writeLine(prefix + line)
+}
+
+// Render the inline elements of `text`: code spans, emphasis and links.
+func (r *renderer) inline(text string) string {
+	return inlineRegex.ReplaceAllStringFunc(text, func(span string) string {
+		switch {
+		case strings.HasPrefix(span, "`"):
+			if r.opts.Styled {
+				return r.style(cyan, span[1:len(span)-1])
+			}
+			return span
+		case strings.HasPrefix(span, "**") || strings.HasPrefix(span, "__"):
+			return r.style(bold, span[2:len(span)-2])
+		case strings.HasPrefix(span, "*"):
+			return r.style(italic, span[1:len(span)-1])
+		default:
+			label, url, _ := strings.Cut(span[1:len(span)-1], "](")
+			if label == url {
+				return r.style(underline, url)
+			}
+			return label + " (" + r.style(underline, url) + ")"
+		}
+	})
+}
+
+// Apply an ANSI style to `text` if styling is enabled.
+func (r *renderer) style(style string, text string) string {
+	if !r.opts.Styled || text == "" {
+		return text
+	}
+	// Styles nested in `text` end with a reset, after which `style` applies again
+	return style + strings.ReplaceAll(text, reset, reset+style) + reset
+}
+
+// Number of columns `s` takes, ignoring ANSI escape codes.
+func visibleWidth(s string) int {
+	return len([]rune(escapeSeqRegex.ReplaceAllString(s, "")))
+}
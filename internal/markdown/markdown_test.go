@@ -0,0 +1,68 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func render(t *testing.T, source string, opts Options) string {
+	t.Helper()
+	var out strings.Builder
+	if err := Render(&out, source, opts); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	return out.String()
+}
+
+func TestRender_Plain(t *testing.T) {
+	source := "# Team env\n\n" +
+		"Run `make dev` to start,\nthen **open** the [docs](https://example.com).\n\n" +
+		"## Tools\n" +
+		"- node\n" +
+		"- go\n  with its linters\n" +
+		"1. first\n\n" +
+		"> Keep it\n> simple\n\n" +
+		"```sh\nmake   test\n```\n" +
+		"---\n"
+	want := "Team env\n" +
+		"========\n" +
+		"\n" +
+		"Run `make dev` to start, then open the docs (https://example.com).\n" +
+		"\n" +
+		"Tools\n" +
+		"-----\n" +
+		"\n" +
+		"  • node\n" +
+		"  • go with its linters\n" +
+		"  1. first\n" +
+		"\n" +
+		"│ Keep it simple\n" +
+		"\n" +
+		"    make   test\n" +
+		"\n" +
+		strings.Repeat("─", 80) + "\n"
+	if got := render(t, source, Options{}); got != want {
+		t.Fatalf("Render() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRender_Wraps(t *testing.T) {
+	got := render(t, "- one two three four five six", Options{Width: 14})
+	want := "  • one two\n    three four\n    five six\n"
+	if got != want {
+		t.Fatalf("Render() =\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestRender_StyledWrapsOnVisibleWidth(t *testing.T) {
+	got := render(t, "**bold** word", Options{Width: 9, Styled: true})
+	want := bold + "bold" + reset + " word\n"
+	if got != want {
+		t.Fatalf("Render() = %q, want %q", got, want)
+	}
+	got = render(t, "### Title `code`", Options{Styled: true})
+	want = bold + "Title " + cyan + "code" + reset + bold + reset + "\n"
+	if got != want {
+		t.Fatalf("Render() = %q, want %q", got, want)
+	}
+}
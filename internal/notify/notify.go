@@ -0,0 +1,74 @@
+// # notify.go
+// Desktop notifications, telling users about what paul-envs did while they
+// were not looking at its output (e.g. when it runs in the background).
+//
+// They rely on the host's own notification tool: `notify-send` on Linux and
+// BSDs, `osascript` on macOS. Where none is available, sending one fails with
+// `ErrUnsupported`, which callers are expected to only log or replace by the
+// terminal's bell.
+
+package notify
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// No way to display desktop notifications was found on this host.
+var ErrUnsupported = errors.New("desktop notifications are not supported on this host")
+
+// Maximum duration the host's notification tool may take.
+const sendTimeout = 10 * time.Second
+
+// Display a desktop notification with that title and message.
+func Send(ctx context.Context, title string, message string) error {
+	name, args := notificationCommand(runtime.GOOS, title, message)
+	if name == "" {
+		return ErrUnsupported
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return ErrUnsupported
+	}
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	return exec.CommandContext(ctx, name, args...).Run()
+}
+
+// Ring the bell of the terminal `w` writes to, failing with `ErrUnsupported`
+// if it is not a terminal.
+func RingBell(w io.Writer) error {
+	f, ok := w.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return ErrUnsupported
+	}
+	_, err := f.WriteString("\a")
+	return err
+}
+
+// Returns the command displaying a notification on the given OS, `name` being
+// empty if there is none.
+func notificationCommand(goos string, title string, message string) (name string, args []string) {
+	switch goos {
+	case "darwin":
+		script := "display notification " + appleScriptString(message) +
+			" with title " + appleScriptString(title)
+		return "osascript", []string{"-e", script}
+	case "windows":
+		return "", nil
+	default:
+		return "notify-send", []string{"--app-name=paul-envs", title, message}
+	}
+}
+
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
@@ -0,0 +1,32 @@
+package notify
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestNotificationCommand(t *testing.T) {
+	name, args := notificationCommand("linux", "paul-envs", "2 containers stopped")
+	if name != "notify-send" || !slices.Equal(args, []string{"--app-name=paul-envs", "paul-envs", "2 containers stopped"}) {
+		t.Errorf("linux: got %s %v", name, args)
+	}
+
+	name, args = notificationCommand("darwin", "paul-envs", `say "hi" \o/`)
+	want := []string{"-e", `display notification "say \"hi\" \\o/" with title "paul-envs"`}
+	if name != "osascript" || !slices.Equal(args, want) {
+		t.Errorf("darwin: got %s %v, want osascript %v", name, args, want)
+	}
+
+	if name, _ = notificationCommand("windows", "paul-envs", "message"); name != "" {
+		t.Errorf("windows: want no command, got %s", name)
+	}
+}
+
+func TestRingBell_NotATerminal(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RingBell(&buf); !errors.Is(err, ErrUnsupported) || buf.Len() != 0 {
+		t.Errorf("RingBell() = %v, wrote %q, want ErrUnsupported and nothing written", err, buf.String())
+	}
+}
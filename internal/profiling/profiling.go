@@ -0,0 +1,192 @@
+// # profiling.go
+// Record where time goes in a CLI invocation (container engine calls, file
+// reads and writes, generation...) so it can be reported as a summary or
+// written as a trace file.
+//
+// Recording is disabled by default, in which case tracking operations costs
+// nothing.
+
+package profiling
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Category of an engine call (e.g. listing images)
+const CategoryEngine = "engine"
+
+// Category of file reads and writes
+const CategoryFiles = "files"
+
+// Category of the generation of project files
+const CategoryGeneration = "generation"
+
+// Category of whole commands
+const CategoryCommand = "command"
+
+// A single recorded operation.
+type Span struct {
+	Category string
+	Name     string
+	Start    time.Time
+	Duration time.Duration
+}
+
+type recorder struct {
+	mu    sync.Mutex
+	spans []Span
+}
+
+var current *recorder
+
+// Start recording operations, until the process exits.
+func Enable() {
+	current = &recorder{}
+}
+
+// Track an operation starting now. The returned function has to be called
+// when it ends, e.g. with:
+//
+//	defer profiling.Track(profiling.CategoryFiles, "read build.conf")()
+func Track(category string, name string) func() {
+	rec := current
+	if rec == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		span := Span{Category: category, Name: name, Start: start, Duration: time.Since(start)}
+		rec.mu.Lock()
+		rec.spans = append(rec.spans, span)
+		rec.mu.Unlock()
+	}
+}
+
+// Returns all operations recorded until now, in the order they ended.
+func Spans() []Span {
+	rec := current
+	if rec == nil {
+		return nil
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]Span{}, rec.spans...)
+}
+
+// Write a human-readable summary of the recorded operations: the time spent
+// per category and the slowest operations.
+func WriteSummary(w io.Writer, spans []Span, total time.Duration, slowest int) error {
+	type categoryTotal struct {
+		name     string
+		duration time.Duration
+		calls    int
+	}
+	byCategory := map[string]*categoryTotal{}
+	for _, span := range spans {
+		if span.Category == CategoryCommand {
+			continue
+		}
+		cat, ok := byCategory[span.Category]
+		if !ok {
+			cat = &categoryTotal{name: span.Category}
+			byCategory[span.Category] = cat
+		}
+		cat.duration += span.Duration
+		cat.calls++
+	}
+	categories := make([]*categoryTotal, 0, len(byCategory))
+	for _, cat := range byCategory {
+		categories = append(categories, cat)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].duration != categories[j].duration {
+			return categories[i].duration > categories[j].duration
+		}
+		return categories[i].name < categories[j].name
+	})
+
+	if _, err := fmt.Fprintf(w, "Total: %s\n", formatDuration(total)); err != nil {
+		return err
+	}
+	for _, cat := range categories {
+		plural := "s"
+		if cat.calls == 1 {
+			plural = ""
+		}
+		if _, err := fmt.Fprintf(w, "  %-12s %9s  %d call%s\n", cat.name, formatDuration(cat.duration), cat.calls, plural); err != nil {
+			return err
+		}
+	}
+
+	sorted := make([]Span, 0, len(spans))
+	for _, span := range spans {
+		if span.Category != CategoryCommand {
+			sorted = append(sorted, span)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Duration > sorted[j].Duration })
+	if len(sorted) > slowest {
+		sorted = sorted[:slowest]
+	}
+	if len(sorted) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintln(w, "Slowest operations:"); err != nil {
+		return err
+	}
+	for _, span := range sorted {
+		if _, err := fmt.Fprintf(w, "  %9s  %-12s %s\n", formatDuration(span.Duration), span.Category, span.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write the recorded operations in the Trace Event format, which can be loaded
+// in `chrome://tracing` or https://ui.perfetto.dev.
+func WriteTrace(w io.Writer, spans []Span) error {
+	type traceEvent struct {
+		Name      string `json:"name"`
+		Category  string `json:"cat"`
+		Phase     string `json:"ph"`
+		Timestamp int64  `json:"ts"`
+		Duration  int64  `json:"dur"`
+		Pid       int    `json:"pid"`
+		Tid       int    `json:"tid"`
+	}
+	var origin time.Time
+	for i, span := range spans {
+		if i == 0 || span.Start.Before(origin) {
+			origin = span.Start
+		}
+	}
+	events := make([]traceEvent, 0, len(spans))
+	for _, span := range spans {
+		events = append(events, traceEvent{
+			Name:      span.Name,
+			Category:  span.Category,
+			Phase:     "X",
+			Timestamp: span.Start.Sub(origin).Microseconds(),
+			Duration:  span.Duration.Microseconds(),
+			Pid:       1,
+			Tid:       1,
+		})
+	}
+	return json.NewEncoder(w).Encode(map[string]any{"traceEvents": events})
+}
+
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	case d >= time.Millisecond:
+		return fmt.Sprintf("%.1fms", float64(d.Microseconds())/1000)
+	default:
+		return fmt.Sprintf("%dµs", d.Microseconds())
+	}
+}
@@ -0,0 +1,78 @@
+package profiling
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrackDisabled(t *testing.T) {
+	current = nil
+	Track(CategoryFiles, "read")()
+	if spans := Spans(); len(spans) != 0 {
+		t.Fatalf("Spans() = %v while disabled, want none", spans)
+	}
+}
+
+func TestTrackEnabled(t *testing.T) {
+	Enable()
+	defer func() { current = nil }()
+
+	Track(CategoryEngine, "docker ListImages")()
+	Track(CategoryFiles, "load build.conf")()
+	spans := Spans()
+	if len(spans) != 2 || spans[0].Name != "docker ListImages" || spans[1].Category != CategoryFiles {
+		t.Fatalf("Spans() = %v, want both tracked operations in order", spans)
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	start := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	spans := []Span{
+		{Category: CategoryEngine, Name: "docker ListImages", Start: start, Duration: 1500 * time.Millisecond},
+		{Category: CategoryEngine, Name: "docker ListContainers", Start: start, Duration: 500 * time.Millisecond},
+		{Category: CategoryFiles, Name: "read all projects", Start: start, Duration: 2 * time.Millisecond},
+		{Category: CategoryCommand, Name: "status", Start: start, Duration: 2100 * time.Millisecond},
+	}
+
+	var out strings.Builder
+	if err := WriteSummary(&out, spans, 2100*time.Millisecond, 2); err != nil {
+		t.Fatalf("WriteSummary() error = %v", err)
+	}
+	want := "Total: 2.10s\n" +
+		"  engine           2.00s  2 calls\n" +
+		"  files            2.0ms  1 call\n" +
+		"Slowest operations:\n" +
+		"      1.50s  engine       docker ListImages\n" +
+		"    500.0ms  engine       docker ListContainers\n"
+	if out.String() != want {
+		t.Fatalf("WriteSummary() =\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestWriteTrace(t *testing.T) {
+	start := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	spans := []Span{
+		{Category: CategoryFiles, Name: "load run.conf", Start: start.Add(3 * time.Millisecond), Duration: time.Millisecond},
+		{Category: CategoryCommand, Name: "run", Start: start, Duration: 10 * time.Millisecond},
+	}
+
+	var out strings.Builder
+	if err := WriteTrace(&out, spans); err != nil {
+		t.Fatalf("WriteTrace() error = %v", err)
+	}
+	var parsed struct {
+		TraceEvents []struct {
+			Name string `json:"name"`
+			Ts   int64  `json:"ts"`
+			Dur  int64  `json:"dur"`
+		} `json:"traceEvents"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &parsed); err != nil {
+		t.Fatalf("WriteTrace() wrote invalid JSON: %v", err)
+	}
+	if len(parsed.TraceEvents) != 2 || parsed.TraceEvents[0].Ts != 3000 || parsed.TraceEvents[0].Dur != 1000 || parsed.TraceEvents[1].Ts != 0 {
+		t.Fatalf("WriteTrace() events = %+v, want timestamps relative to the first operation", parsed.TraceEvents)
+	}
+}
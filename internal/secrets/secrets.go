@@ -0,0 +1,195 @@
+// Package secrets fetches the secrets given to projects' containers from the
+// user's own secret tooling, on the host when a container is created, so they
+// are never written in paul-envs' files nor in images.
+//
+// Each tool is a `Backend`. The one of a secret is named by the reference of
+// its `SECRET` directive (e.g. "pass:github/token"), or else is the one of
+// the `SECRETS_BACKEND` global setting.
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+// Service under which secrets of the `keyring` backend are stored.
+const keyringService = "paul-envs"
+
+// A source of secrets.
+type Backend interface {
+	// Fetch the secret identified by `reference`, whose meaning depends on
+	// the backend.
+	Get(ctx context.Context, reference string) (string, error)
+}
+
+// What backends may need besides a secret's reference.
+type Options struct {
+	// age identity file decrypting the secrets of the `age` backend
+	AgeIdentity string
+	// Directory relative references to files are relative to, usually the
+	// one of the project's run.conf
+	BaseDir string
+}
+
+// Run a command of a secret tool and return its standard output.
+//
+// Isolated for tests
+var runTool = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, fmt.Errorf("%s is not installed", name)
+	} else if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s failed: %s", name, msg)
+		}
+		return nil, fmt.Errorf("%s failed: %w", name, err)
+	}
+	return output, nil
+}
+
+// Returns the backend of the given name.
+func NewBackend(name string, options Options) (Backend, error) {
+	switch name {
+	case "age":
+		return ageBackend{options}, nil
+	case "keyring":
+		return keyringBackend{}, nil
+	case "pass":
+		return passBackend{}, nil
+	case "gopass":
+		return gopassBackend{}, nil
+	case "env":
+		return envBackend{}, nil
+	default:
+		return nil, config.ValidateSecretBackend(name)
+	}
+}
+
+// Fetch the given secrets, each from its own backend or `defaultBackend`.
+//
+// Returns them as "NAME=VALUE", in the same order.
+func Resolve(ctx context.Context, secrets []config.Secret, defaultBackend string, options Options) ([]string, error) {
+	if defaultBackend == "" {
+		defaultBackend = config.DefaultSecretBackend
+	}
+	env := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		backendName := secret.Backend
+		if backendName == "" {
+			backendName = defaultBackend
+		}
+		backend, err := NewBackend(backendName, options)
+		if err != nil {
+			return nil, err
+		}
+		value, err := backend.Get(ctx, secret.Reference)
+		if err != nil {
+			return nil, fmt.Errorf("cannot fetch secret %s from %s: %w", secret.Name, backendName, err)
+		}
+		env = append(env, secret.Name+"="+value)
+	}
+	return env, nil
+}
+
+// Secrets stored in age-encrypted files, the reference being the file.
+type ageBackend struct {
+	options Options
+}
+
+func (b ageBackend) Get(ctx context.Context, reference string) (string, error) {
+	if b.options.AgeIdentity == "" {
+		return "", errors.New("no age identity configured\nHint: Set one with 'paul-envs config set AGE_IDENTITY <path>'")
+	}
+	path, err := b.resolvePath(reference)
+	if err != nil {
+		return "", err
+	}
+	output, err := runTool(ctx, "age", "--decrypt", "--identity", b.options.AgeIdentity, path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(output), "\n"), nil
+}
+
+func (b ageBackend) resolvePath(reference string) (string, error) {
+	if reference == "~" || strings.HasPrefix(reference, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("resolve home directory: %w", err)
+		}
+		return filepath.Join(home, strings.TrimPrefix(reference, "~")), nil
+	}
+	if filepath.IsAbs(reference) || b.options.BaseDir == "" {
+		return reference, nil
+	}
+	return filepath.Join(b.options.BaseDir, reference), nil
+}
+
+// Secrets stored in the OS keyring under the "paul-envs" service, the
+// reference being their account.
+type keyringBackend struct{}
+
+func (keyringBackend) Get(ctx context.Context, reference string) (string, error) {
+	var output []byte
+	var err error
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		output, err = runTool(ctx, "secret-tool", "lookup", "service", keyringService, "account", reference)
+	case "darwin":
+		output, err = runTool(ctx, "security", "find-generic-password", "-s", keyringService, "-a", reference, "-w")
+	default:
+		return "", fmt.Errorf("the OS keyring cannot be read on %s", runtime.GOOS)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(output), "\n"), nil
+}
+
+// Secrets stored in a pass password store, the reference being their entry.
+// Only the first line of the entry is the secret, like `pass` does.
+type passBackend struct{}
+
+func (passBackend) Get(ctx context.Context, reference string) (string, error) {
+	output, err := runTool(ctx, "pass", "show", reference)
+	if err != nil {
+		return "", err
+	}
+	first, _, _ := strings.Cut(string(output), "\n")
+	return first, nil
+}
+
+// Secrets stored in a gopass password store, the reference being their entry.
+type gopassBackend struct{}
+
+func (gopassBackend) Get(ctx context.Context, reference string) (string, error) {
+	output, err := runTool(ctx, "gopass", "show", "--password", reference)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(output), "\n"), nil
+}
+
+// Secrets passed through from the host's environment, the reference being a
+// variable.
+type envBackend struct{}
+
+func (envBackend) Get(_ context.Context, reference string) (string, error) {
+	value, ok := os.LookupEnv(reference)
+	if !ok {
+		return "", fmt.Errorf("%s is not set", reference)
+	}
+	return value, nil
+}
@@ -0,0 +1,90 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/peaberberian/paul-envs/internal/config"
+)
+
+// Replace the secret tools by `fake` for the duration of the test, recording
+// their calls.
+func stubTools(t *testing.T, fake func(name string, args []string) (string, error)) *[]string {
+	t.Helper()
+	var calls []string
+	original := runTool
+	runTool = func(_ context.Context, name string, args ...string) ([]byte, error) {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		output, err := fake(name, args)
+		return []byte(output), err
+	}
+	t.Cleanup(func() { runTool = original })
+	return &calls
+}
+
+func TestResolve(t *testing.T) {
+	calls := stubTools(t, func(name string, args []string) (string, error) {
+		switch name {
+		case "pass":
+			return "pass-secret\nuser: me\n", nil
+		case "gopass":
+			return "gopass-secret\n", nil
+		case "age":
+			return "age-secret\n", nil
+		}
+		return "", errors.New("unexpected tool")
+	})
+	t.Setenv("HOST_TOKEN", "env-secret")
+
+	got, err := Resolve(context.Background(), []config.Secret{
+		{Name: "A", Backend: "pass", Reference: "github/token"},
+		{Name: "B", Backend: "gopass", Reference: "npm"},
+		{Name: "C", Backend: "age", Reference: "secrets/c.age"},
+		{Name: "D", Reference: "HOST_TOKEN"},
+	}, "", Options{AgeIdentity: "/keys/age.txt", BaseDir: "/conf/demo"})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	want := []string{"A=pass-secret", "B=gopass-secret", "C=age-secret", "D=env-secret"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("Resolve() = %q, want %q", got, want)
+	}
+	wantCalls := []string{
+		"pass show github/token",
+		"gopass show --password npm",
+		"age --decrypt --identity /keys/age.txt " + filepath.Join("/conf/demo", "secrets/c.age"),
+	}
+	if !slices.Equal(*calls, wantCalls) {
+		t.Fatalf("tool calls = %q, want %q", *calls, wantCalls)
+	}
+}
+
+func TestResolveDefaultBackend(t *testing.T) {
+	calls := stubTools(t, func(string, []string) (string, error) { return "from-pass\n", nil })
+	got, err := Resolve(context.Background(), []config.Secret{{Name: "TOKEN", Reference: "TOKEN"}}, "pass", Options{})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if !slices.Equal(got, []string{"TOKEN=from-pass"}) || len(*calls) != 1 {
+		t.Fatalf("Resolve() = %q with calls %q, want the default backend to be used", got, *calls)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	stubTools(t, func(string, []string) (string, error) { return "", errors.New("pass failed: not in the store") })
+	for _, secret := range []config.Secret{
+		{Name: "MISSING", Backend: "env", Reference: "PAULENV_TEST_UNSET_VARIABLE"},
+		{Name: "ABSENT", Backend: "pass", Reference: "absent"},
+		{Name: "NO_IDENTITY", Backend: "age", Reference: "/secrets/x.age"},
+		{Name: "UNKNOWN", Backend: "vault", Reference: "x"},
+	} {
+		_, err := Resolve(context.Background(), []config.Secret{secret}, "", Options{})
+		if err == nil {
+			t.Errorf("Resolve(%+v) should fail", secret)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// # table.go
+// Render tabular command output which adapts to the terminal width: columns
+// are shrunk and their values elided when the table would not fit, unless
+// the "wide" mode is asked for.
+// Cells can contain multiple values (ports, mounts...), which are then
+// displayed one per line.
+
+package table
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const (
+	columnGap      = "  "
+	minColumnWidth = 4
+	ellipsis       = "…"
+)
+
+// A single row of a table, each cell may contain zero or multiple values.
+type Row [][]string
+
+type Options struct {
+	// Width available, in columns. `0` or less means no limit.
+	Width int
+	// If `true`, never elide values, even if the table does not fit in `Width`.
+	Wide bool
+}
+
+// Returns the width of the terminal the given writer outputs to, or the
+// `COLUMNS` environment variable if it is not a terminal.
+//
+// Returns `0` if unknown.
+func TerminalWidth(w io.Writer) int {
+	if f, ok := w.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		if width, _, err := term.GetSize(int(f.Fd())); err == nil && width > 0 {
+			return width
+		}
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return 0
+}
+
+// Write the table to `w`.
+func Render(w io.Writer, headers []string, rows []Row, opts Options) error {
+	widths := columnWidths(headers, rows, opts)
+
+	var buf strings.Builder
+	writeLine(&buf, headers, widths)
+	for _, row := range rows {
+		height := 1
+		for _, cell := range row {
+			height = max(height, len(cell))
+		}
+		for i := range height {
+			line := make([]string, len(headers))
+			for col := range headers {
+				if col < len(row) && i < len(row[col]) {
+					line[col] = row[col][i]
+				}
+			}
+			writeLine(&buf, line, widths)
+		}
+	}
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+// Compute the width of each column so the table fits in the wanted width, by
+// shrinking the widest columns first.
+func columnWidths(headers []string, rows []Row, opts Options) []int {
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = textWidth(header)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				break
+			}
+			for _, value := range cell {
+				widths[i] = max(widths[i], textWidth(value))
+			}
+		}
+	}
+	if opts.Wide || opts.Width <= 0 {
+		return widths
+	}
+
+	available := opts.Width - len(columnGap)*(len(widths)-1)
+	for total(widths) > available {
+		widest := 0
+		for i := range widths {
+			if widths[i] > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= minColumnWidth {
+			break
+		}
+		widths[widest]--
+	}
+	return widths
+}
+
+func writeLine(buf *strings.Builder, values []string, widths []int) {
+	var line strings.Builder
+	for i, value := range values {
+		if i > 0 {
+			line.WriteString(columnGap)
+		}
+		value = elide(value, widths[i])
+		line.WriteString(value)
+		line.WriteString(strings.Repeat(" ", widths[i]-textWidth(value)))
+	}
+	buf.WriteString(strings.TrimRight(line.String(), " "))
+	buf.WriteByte('\n')
+}
+
+// Shorten `value` to `width` columns. Paths keep their end, which is usually
+// the most meaningful part, other values keep their start.
+func elide(value string, width int) string {
+	runes := []rune(value)
+	if len(runes) <= width {
+		return value
+	}
+	if width <= 1 {
+		return string(runes[:width])
+	}
+	if strings.HasPrefix(value, "/") || strings.HasPrefix(value, "~") {
+		return ellipsis + string(runes[len(runes)-width+1:])
+	}
+	return string(runes[:width-1]) + ellipsis
+}
+
+func textWidth(s string) int {
+	return len([]rune(s))
+}
+
+func total(widths []int) int {
+	sum := 0
+	for _, w := range widths {
+		sum += w
+	}
+	return sum
+}
@@ -0,0 +1,68 @@
+package table
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender_NaturalWidth(t *testing.T) {
+	var out strings.Builder
+	rows := []Row{
+		{{"app"}, {"/home/me/code/app"}, {"3000:3000", "8080:80"}},
+		{{"other"}, {"/tmp/o"}, nil},
+	}
+	if err := Render(&out, []string{"PROJECT", "PATH", "PORTS"}, rows, Options{}); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := "" +
+		"PROJECT  PATH               PORTS\n" +
+		"app      /home/me/code/app  3000:3000\n" +
+		"                            8080:80\n" +
+		"other    /tmp/o\n"
+	if out.String() != want {
+		t.Fatalf("Render() =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestRender_ElidesToFitWidth(t *testing.T) {
+	rows := []Row{{{"app"}, {"/home/me/some/very/long/path/to/app"}, {"a-rather-long-image-name"}}}
+	headers := []string{"PROJECT", "PATH", "IMAGE"}
+
+	var out strings.Builder
+	if err := Render(&out, headers, rows, Options{Width: 40}); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	for _, line := range strings.Split(strings.TrimRight(out.String(), "\n"), "\n") {
+		if n := len([]rune(line)); n > 40 {
+			t.Fatalf("line %q is %d columns wide, want at most 40", line, n)
+		}
+	}
+	if !strings.Contains(out.String(), "…g/path/to/app") {
+		t.Fatalf("expected path to keep its end, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := Render(&out, headers, rows, Options{Width: 40, Wide: true}); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "/home/me/some/very/long/path/to/app") {
+		t.Fatalf("expected full values in wide mode, got:\n%s", out.String())
+	}
+}
+
+func TestElide(t *testing.T) {
+	tests := []struct {
+		value string
+		width int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"paulenv:project", 8, "paulenv…"},
+		{"/a/b/c/d", 5, "…/c/d"},
+	}
+	for _, tt := range tests {
+		if got := elide(tt.value, tt.width); got != tt.want {
+			t.Fatalf("elide(%q, %d) = %q, want %q", tt.value, tt.width, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || freebsd)
+
+package utils
+
+import "errors"
+
+// Returns the space available to unprivileged users on the filesystem
+// containing `path`, in bytes.
+//
+// Not implemented on this platform.
+func FreeDiskSpace(path string) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,15 @@
+//go:build linux || darwin || freebsd
+
+package utils
+
+import "syscall"
+
+// Returns the space available to unprivileged users on the filesystem
+// containing `path`, in bytes.
+func FreeDiskSpace(path string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return int64(uint64(stat.Bavail) * uint64(stat.Bsize)), nil
+}
@@ -0,0 +1,24 @@
+package utils
+
+// WithCategory returns an error with the same message as `err` which also
+// matches `category` with `errors.Is`, so callers can branch on the kind of
+// failure without parsing messages.
+func WithCategory(err error, category error) error {
+	if err == nil {
+		return nil
+	}
+	return &categorizedError{err: err, category: category}
+}
+
+type categorizedError struct {
+	err      error
+	category error
+}
+
+func (e *categorizedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *categorizedError) Unwrap() []error {
+	return []error{e.err, e.category}
+}
@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestWithCategory(t *testing.T) {
+	cause := errors.New("docker ps failed")
+	err := WithCategory(cause, fs.ErrPermission)
+	if err.Error() != "docker ps failed" {
+		t.Fatalf("Error() = %q, want the wrapped message", err.Error())
+	}
+	if !errors.Is(err, fs.ErrPermission) || !errors.Is(err, cause) {
+		t.Fatalf("errors.Is() should match both the category and the wrapped error")
+	}
+	if WithCategory(nil, fs.ErrPermission) != nil {
+		t.Fatalf("WithCategory(nil) should be nil")
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package utils
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// Returns the user ID owning the file described by `info`, `false` if it is
+// unknown.
+func FileOwner(info fs.FileInfo) (int, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int(stat.Uid), true
+}
@@ -0,0 +1,27 @@
+package utils
+
+import "sync"
+
+// Call `fn` with each index from `0` to `count - 1`, with at most `limit`
+// calls running at once, and wait for all of them to return.
+//
+// A `limit` of `0` or less runs them one at a time.
+func Parallel(count int, limit int, fn func(i int)) {
+	limit = max(1, min(limit, count))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range limit {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := range count {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
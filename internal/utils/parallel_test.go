@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParallel(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	done := make([]bool, 10)
+	Parallel(len(done), 3, func(i int) {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		running--
+		done[i] = true
+		mu.Unlock()
+	})
+	for i, ok := range done {
+		if !ok {
+			t.Fatalf("Parallel() did not call fn(%d)", i)
+		}
+	}
+	if maxRunning > 3 {
+		t.Fatalf("Parallel() ran %d calls at once, want at most 3", maxRunning)
+	}
+}
+
+func TestParallel_NoLimit(t *testing.T) {
+	calls := 0
+	Parallel(2, 0, func(int) { calls++ })
+	if calls != 2 {
+		t.Fatalf("Parallel() made %d calls, want 2", calls)
+	}
+	Parallel(0, 4, func(int) { t.Fatal("Parallel() called fn without items") })
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+const redacted = "<redacted>"
+
+// Upper-case name of a variable or directive which looks sensitive
+const sensitiveName = `[A-Z0-9_]*(?:TOKEN|SECRET|PASSWORD|PASSWD|API_KEY|APIKEY|PRIVATE_KEY|CREDENTIAL|AUTH)[A-Z0-9_]*`
+
+var redactions = []struct {
+	re          *regexp.Regexp
+	replacement string
+}{
+	// Private keys pasted in files or printed by a build
+	{
+		regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+		redacted,
+	},
+	// Values of variables and directives whose name looks sensitive, e.g.
+	// `GITHUB_TOKEN=...`, `POSTGRES_PASSWORD: ...` or `GIT_AUTHOR_EMAIL ...`
+	{
+		regexp.MustCompile(`\b(` + sensitiveName + `)(\s*[=:]\s*)("[^"]*"|'[^']*'|\S+)`),
+		"${1}${2}" + redacted,
+	},
+	{regexp.MustCompile(`(?m)^(\s*` + sensitiveName + `[ \t]+)\S.*$`), "${1}" + redacted},
+	// Credentials in URLs
+	{regexp.MustCompile(`(://)[^/\s:@]+:[^/\s@]+@`), "${1}" + redacted + "@"},
+	{regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9._~+/=-]+`), "${1}" + redacted},
+	{regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), redacted},
+}
+
+// Remove what looks like secrets and personal information from `text`, so it
+// can be shared publicly (e.g. in a bug report): tokens, passwords, private
+// keys, credentials in URLs and e-mail addresses. The home directory, if not
+// empty, is replaced by `~`.
+//
+// This is a best effort: what is redacted is based on how it looks.
+func RedactSecrets(text string, homeDir string) string {
+	for _, r := range redactions {
+		text = r.re.ReplaceAllString(text, r.replacement)
+	}
+	if homeDir != "" && homeDir != "/" {
+		text = strings.ReplaceAll(text, homeDir, "~")
+	}
+	return text
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Sizes as written in configuration files ("512m", "10g") and as reported by
+// container engines ("1.2GB", "980 kB").
+var sizeRe = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([a-zA-Z]*)$`)
+
+var sizeUnits = map[string]int64{
+	"":   1,
+	"b":  1,
+	"k":  1000,
+	"kb": 1000,
+	"m":  1000 * 1000,
+	"mb": 1000 * 1000,
+	"g":  1000 * 1000 * 1000,
+	"gb": 1000 * 1000 * 1000,
+	"t":  1000 * 1000 * 1000 * 1000,
+	"tb": 1000 * 1000 * 1000 * 1000,
+}
+
+// Parse a size in bytes such as "512m", "10g" or "1.2GB".
+//
+// Units are decimal, as container engines report image sizes that way.
+func ParseSize(value string) (int64, error) {
+	match := sizeRe.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	unit, ok := sizeUnits[strings.ToLower(match[2])]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q", value, match[2])
+	}
+	num, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return int64(num * float64(unit)), nil
+}
+
+// Format a size in bytes the way container engines do, e.g. "1.2GB".
+func FormatSize(size int64) string {
+	units := []string{"B", "kB", "MB", "GB", "TB"}
+	value := float64(size)
+	i := 0
+	for value >= 1000 && i < len(units)-1 {
+		value /= 1000
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%dB", size)
+	}
+	return strings.TrimSuffix(strconv.FormatFloat(value, 'f', 1, 64), ".0") + units[i]
+}
@@ -0,0 +1,46 @@
+package utils
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"512", 512},
+		{"512m", 512_000_000},
+		{"10g", 10_000_000_000},
+		{"10G", 10_000_000_000},
+		{"1.2GB", 1_200_000_000},
+		{"980 kB", 980_000},
+		{"0B", 0},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.input)
+		if err != nil || got != tt.want {
+			t.Fatalf("ParseSize(%q) = %d, %v, want %d", tt.input, got, err, tt.want)
+		}
+	}
+	for _, input := range []string{"", "g", "-1g", "1.2.3GB", "10x", "ten"} {
+		if _, err := ParseSize(input); err == nil {
+			t.Fatalf("ParseSize(%q) should have failed", input)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		input int64
+		want  string
+	}{
+		{512, "512B"},
+		{980_000, "980kB"},
+		{1_200_000_000, "1.2GB"},
+		{10_000_000_000, "10GB"},
+	}
+	for _, tt := range tests {
+		if got := FormatSize(tt.input); got != tt.want {
+			t.Fatalf("FormatSize(%d) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
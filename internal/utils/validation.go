@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"regexp"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -26,14 +27,19 @@ var (
 	// ^[a-z0-9]          → must start with letter or digit
 	// [a-z0-9+.-]{1,254}$ → remaining allowed chars
 	pkgNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9+.-]{1,254}$`)
+	// Package of a language's package manager (pipx, cargo, npm), optionally
+	// with its scope and version (e.g. "@scope/name@1.2", "name==1.2"),
+	// without anything a shell would interpret
+	languagePkgRe = regexp.MustCompile(`^[A-Za-z0-9@][A-Za-z0-9@/._+=~^:-]{0,254}$`)
 	// Docker image name component rules (reference component):
 	// - Lowercase letters, digits, hyphens, underscores only
 	// - Must start/end with alphanumeric
 	// - Max 128 chars per component
 	projectNameRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9_-]*[a-z0-9])?$`)
-	versionRegex     = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+$`)
-	usernameRegex    = regexp.MustCompile(`^[a-z_][a-z0-9_-]*$`)
-	gitEmailRegex    = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+	// Complete or partial version of a language toolchain, e.g. "22", "1.23"
+	toolchainVersionRegex = regexp.MustCompile(`^[0-9]+(\.[0-9]+){0,2}$`)
+	usernameRegex         = regexp.MustCompile(`^[a-z_][a-z0-9_-]*$`)
+	gitEmailRegex         = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 )
 
 func ValidateProjectName(name string) error {
@@ -137,14 +143,28 @@ func SanitizeProjectName(dirName string) (string, error) {
 	return name, nil
 }
 
-func ValidateVersionArg(version string) error {
-	if version == "" || version == VersionLatest || version == VersionNone {
+// Release channels each language toolchain accepts as version, beside
+// "latest".
+var toolchainChannels = map[string][]string{
+	"node": {"lts"},
+	"rust": {"stable", "beta", "nightly"},
+}
+
+// ValidateToolchainVersion checks the version wanted for the toolchain of
+// the given language ("node", "rust", "python" or "go"): "none", "latest",
+// one of its release channels or a complete or partial version, the latest
+// matching one being installed.
+func ValidateToolchainVersion(language string, version string) error {
+	if version == "" || version == VersionLatest || version == VersionNone ||
+		slices.Contains(toolchainChannels[language], version) ||
+		toolchainVersionRegex.MatchString(version) {
 		return nil
 	}
-	if !versionRegex.MatchString(version) {
-		return fmt.Errorf("invalid version argument: '%s'. Must be either \"none\", \"latest\" or semantic versioning (e.g., 20.10.0)", version)
+	accepted := `"none", "latest"`
+	for _, channel := range toolchainChannels[language] {
+		accepted += `, "` + channel + `"`
 	}
-	return nil
+	return fmt.Errorf("invalid version argument: '%s'. Must be either %s or a version, complete or not (e.g., 22, 1.23 or 20.10.0)", version, accepted)
 }
 
 func ValidateUIDGID(id string) error {
@@ -155,6 +175,24 @@ func ValidateUIDGID(id string) error {
 	return nil
 }
 
+// Instances of a project's container end up in container and volume names,
+// like project names.
+func ValidateInstanceName(name string) error {
+	if !projectNameRegex.MatchString(name) || len(name) > 64 {
+		return fmt.Errorf("invalid instance name '%s': must be at most 64 characters, lowercase, start and end with alphanumeric, and contain only lowercase letters, digits, hyphens, and underscores", name)
+	}
+	return nil
+}
+
+// Groups of projects are named like them, to be told apart from them by a
+// leading `@` in commands.
+func ValidateGroupName(name string) error {
+	if !projectNameRegex.MatchString(name) || len(name) > 64 {
+		return fmt.Errorf("invalid group name '%s': must be at most 64 characters, lowercase, start and end with alphanumeric, and contain only lowercase letters, digits, hyphens, and underscores", name)
+	}
+	return nil
+}
+
 func ValidateUsername(username string) error {
 	if !usernameRegex.MatchString(username) {
 		return fmt.Errorf("invalid username '%s'. Must start with lowercase letter or underscore, followed by lowercase letters, digits, underscores, or hyphens", username)
@@ -210,3 +248,9 @@ func EscapeEnvValue(str string) string {
 func IsValidUbuntuPackageName(name string) bool {
 	return pkgNameRe.MatchString(name)
 }
+
+// IsValidLanguagePackageName returns true if the name can be given to pipx,
+// cargo or npm to install a package.
+func IsValidLanguagePackageName(name string) bool {
+	return languagePkgRe.MatchString(name)
+}
@@ -1,6 +1,9 @@
 package utils
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestValidateProjectName(t *testing.T) {
 	tests := []struct {
@@ -25,26 +28,34 @@ func TestValidateProjectName(t *testing.T) {
 	}
 }
 
-func TestValidateVersionArg(t *testing.T) {
+func TestValidateToolchainVersion(t *testing.T) {
 	tests := []struct {
-		in string
-		ok bool
+		language string
+		in       string
+		ok       bool
 	}{
-		{"", true},
-		{"latest", true},
-		{"none", true},
-		{"1.2.3", true},
-		{"1.2", false},
-		{"bad", false},
+		{"node", "", true},
+		{"node", "latest", true},
+		{"go", "none", true},
+		{"node", "1.2.3", true},
+		{"go", "1.23", true},
+		{"node", "22", true},
+		{"node", "lts", true},
+		{"rust", "stable", true},
+		{"rust", "nightly", true},
+		{"python", "stable", false},
+		{"go", "1.2.3.4", false},
+		{"node", "v22", false},
+		{"node", "bad", false},
 	}
 
 	for _, tt := range tests {
-		err := ValidateVersionArg(tt.in)
+		err := ValidateToolchainVersion(tt.language, tt.in)
 		if tt.ok && err != nil {
-			t.Errorf("expected ok for %q, got %v", tt.in, err)
+			t.Errorf("expected ok for %s %q, got %v", tt.language, tt.in, err)
 		}
 		if !tt.ok && err == nil {
-			t.Errorf("expected error for %q", tt.in)
+			t.Errorf("expected error for %s %q", tt.language, tt.in)
 		}
 	}
 }
@@ -73,6 +84,19 @@ func TestValidateUIDGID(t *testing.T) {
 	}
 }
 
+func TestValidateInstanceName(t *testing.T) {
+	for _, name := range []string{"tests", "a", "web-2", "lint_ci"} {
+		if err := ValidateInstanceName(name); err != nil {
+			t.Errorf("expected ok for %q, got %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "Tests", "-a", "a.b", "a/b", strings.Repeat("a", 65)} {
+		if err := ValidateInstanceName(name); err == nil {
+			t.Errorf("expected error for %q", name)
+		}
+	}
+}
+
 func TestValidateUsername(t *testing.T) {
 	tests := []struct {
 		in string
@@ -205,6 +229,28 @@ func TestIsValidUbuntuPackageName(t *testing.T) {
 	}
 }
 
+func TestIsValidLanguagePackageName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"ruff", true},
+		{"ruff==0.6.9", true},
+		{"ripgrep@14.1.0", true},
+		{"@scope/tool@^1.2", true},
+		{"", false},
+		{"-flag", false},
+		{"tool;rm", false},
+		{"tool name", false},
+		{"$(tool)", false},
+	}
+	for _, tt := range tests {
+		if got := IsValidLanguagePackageName(tt.input); got != tt.expected {
+			t.Errorf("IsValidLanguagePackageName(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
 // generates strings of repeated char c, length n
 func generateString(c rune, n int) string {
 	r := make([]rune, n)
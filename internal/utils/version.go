@@ -76,3 +76,8 @@ func (v *Version) IsCompatibleWithBase(base Version) bool {
 func (v *Version) ToString() string {
 	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
 }
+
+// Returns `true` if `v` is strictly lower than `other`.
+func (v *Version) IsBefore(other Version) bool {
+	return compareVersions(v, &other) < 0
+}
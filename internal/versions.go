@@ -26,36 +26,130 @@ var Version = utils.Version{
 //   - 1.5.0: Set envs (XDG_* etc.) in global shellrc confs, so it's available in login shells
 //   - 2.0.0: Replace per-project compose/env files with build.conf/run.conf
 //   - 2.1.0: Move dotfiles sync, git identity, and managed shell overrides to container start
+//   - 2.2.0: Build project images on top of a shared `paulenv-base` image
+//   - 2.3.0: Added `DISTRIBUTION_IMAGE` arg to `Dockerfile.base` choosing its distribution image
+//   - 2.4.0: Added `INSTALL_COMPLETIONS` arg setting up shell completions of installed tools
+//   - 2.5.0: Install `tzdata` and `locales`, generate the container's `LANG` locale at start
+//   - 2.6.0: Accept `nushell` as `USER_SHELL`
+//   - 2.7.0: Added `PIPX_PACKAGES`, `CARGO_PACKAGES` and `NPM_PACKAGES` args
+//   - 2.8.0: Check languages' versions before installing them, share their
+//     downloads between builds through a cache mount
+//   - 2.9.0: Added `ENABLE_COMPILER_CACHE` arg installing ccache and sccache
+//   - 2.10.0: Install `gnupg` in the base image, for forwarded gpg-agents
+//   - 2.11.0: Added `ENABLE_NESTED_CONTAINERS` arg installing rootless Podman
+//   - 2.12.0: Added `ENABLE_SYSTEMD` arg installing systemd
+//   - 2.13.0: Install `tmux` in the base image, attach shells to a persistent
+//     session when asked to
+//   - 2.14.0: Declared `PIP_INDEX_URL` and `NPM_CONFIG_REGISTRY` args, to
+//     install packages from mirrors
+//   - 2.15.0: Added the points where Dockerfile snippets are spliced
+//   - 2.16.0: Keep apt, pip and npm downloads in build cache mounts
 var DockerfileVersion = utils.Version{
 	Major: 2,
-	Minor: 1,
+	Minor: 16,
 	Patch: 0,
 }
 
 // Format of generated build.conf files.
+//
+// # Changes
+//   - 1.2.0: Added `INSTALL_COMPLETIONS` to set up shell completions
+//   - 1.3.0: Added `PIPX_PACKAGES`, `CARGO_PACKAGES` and `NPM_PACKAGES` to
+//     install global packages of language package managers
+//   - 1.4.0: Added `ENABLE_COMPILER_CACHE` to cache C/C++ and Rust
+//     compilations
+//   - 1.5.0: Added `ENABLE_NESTED_CONTAINERS` to run containers inside the
+//     project's one
+//   - 1.6.0: Added `ENABLE_SYSTEMD` to run systemd as the container's PID 1
+//   - 1.7.0: Added `BUILD_ARG` to give other build arguments to the build
+//   - 1.8.0: Added `DOCKERFILE` to build the project from its own Dockerfile
+//   - 1.9.0: Added `IMAGE_LABEL` and `SQUASH` to label the image and squash
+//     its layers
 var BuildConfigVersion = utils.Version{
 	Major: 1,
-	Minor: 1,
+	Minor: 9,
 	Patch: 0,
 }
 
 // Format of generated run.conf files.
+//
+// # Changes
+//   - 1.2.0: Added `CPUS`, `MEMORY` and `PIDS_LIMIT` resource limits,
+//     `BANNER` to disable the startup banner, `SSH_PORT` to reach the
+//     container's ssh server, `DISPLAY` to forward the host's display and
+//     `AUDIO` to forward its sound server, `GROUP` to add host groups to the
+//     container user, `IMAGE_GENERATIONS` to set how many previous images
+//     are kept for rollbacks, `IMAGE_GENERATIONS_MAX_SIZE` to bound their
+//     total size, `SERVICE`, `SERVICE_ENV` and `SERVICE_DATA` to declare
+//     sidecar services, `SERVICE_PROFILE` to only start some of them on
+//     demand, `SERVICE_HEALTHCHECK` and `SERVICE_WAIT_TIMEOUT` to wait until
+//     they are ready, `SERVICE_GRACE_PERIOD` to keep them running for a
+//     while after the project's container exits, `MAIN_SERVICE` to name the
+//     project's own one,
+//     `DOTFILES_PROFILE` to use a dotfiles profile shared between projects,
+//     `STARTUP` to run scripts when the container starts, `ACTIVATE` to
+//     source scripts in its shells, `SECRET` to
+//     give it secrets from a secret backend, `MOUNT` to declare checked
+//     additional mounts, `SHOW_README` to display the project's README
+//     when first entering a new image, `HARDENED`, `HARDENED_CAPS`,
+//     `SECCOMP_PROFILE` and `MASK` to restrict what the container can do,
+//     `SELINUX_RELABEL` to not relabel its mounts for SELinux,
+//     `PASSTHROUGH_ENV` to give it variables of the host's environment,
+//     `SHARED_NETWORK` to reach the containers of other projects,
+//     `HOST`, `DNS` and `DNS_SEARCH` to configure its name resolution and
+//     `HTTP_PROXY`, `HTTPS_PROXY`, `FTP_PROXY`, `ALL_PROXY` and `NO_PROXY` to
+//     replace the host's proxy settings, `TIMEZONE` and `LOCALE` to set
+//     the container's timezone and locale or mirror the host's ones,
+//     `USERNS` to choose its user namespace, `BUILD_TIMEOUT` and
+//     `BUILD_RETRIES` to bound and retry its builds, `PACKAGE_CACHE` to
+//     share package managers' caches between projects, `SHARED_VOLUME` to
+//     mount volumes shared with other projects, `TMPFS` to mount tmpfs and
+//     `SSH_AGENT`, `GIT_CREDENTIALS` and `GPG_AGENT` to forward the host's
+//     ssh agent, git credentials and gpg-agent, `HOST_COMMAND` to let it run
+//     commands of the host, `CLIPBOARD` to share the host's clipboard,
+//     `PERSISTENT_SESSION` to run shells in a tmux session outliving their
+//     terminal and `IDLE_TIMEOUT` to stop its containers once idle
 var RuntimeConfigVersion = utils.Version{
 	Major: 1,
-	Minor: 1,
+	Minor: 2,
 	Patch: 0,
 }
 
-// Format of the "project.lock" files: the lockfiles of the various projects.
+// Format of the "project.lock" files: the lockfiles of the various projects,
+// whose version is the one of the project entry as a whole.
+//
+// # Changes
+//   - 1.1.0: Added `WRITTEN_BY`, the version of paul-envs which created or
+//     last migrated the project
 var ProjectLockVersion = utils.Version{
+	Major: 1,
+	Minor: 1,
+	Patch: 0,
+}
+
+// Format of paul-envs' state as a whole (the layout of its data and
+// configuration directories), recorded in the "state.version" file of its
+// data directory. It is 0.0.0 for installations predating it.
+//
+// Upgrading to a new format is done by `paul-envs migrate`.
+//
+// # Changes
+//   - 1.0.0: Base version, recorded in "state.version"
+var StateVersion = utils.Version{
 	Major: 1,
 	Minor: 0,
 	Patch: 0,
 }
 
 // Format of the "project.buildinfo" files: Information on the last build performed for a project
+//
+// # Changes
+//   - 2.1.0: Added optional `BASE_FILES` hash of the Dockerfile and entrypoint,
+//     optional `BASE_IMAGE_BUILT_AT` date of the `paulenv-base` image used and
+//     optional `BASE_IMAGE_DIGEST` digest of the distribution image it was
+//     built from
 var BuildInfoVersion = utils.Version{
 	Major: 2,
-	Minor: 0,
+	Minor: 1,
 	Patch: 0,
 }